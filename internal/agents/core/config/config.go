@@ -1,7 +1,126 @@
 package config
 
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+)
+
 // Config contains configuration for MAGDA agents
 type Config struct {
-	OpenAIAPIKey string // OpenAI API key for LLM provider
-	MCPServerURL string // MCP server URL (optional)
+	OpenAIAPIKey     string // OpenAI API key for LLM provider
+	AnthropicAPIKey  string // Anthropic API key (used when LLMProvider is "anthropic")
+	AnthropicModel   string // Claude model used in place of the agents' GPT models (optional)
+	LLMProvider      string // "openai" (default), "anthropic" or "mock"
+	LLMFallback      string // Comma-separated fallback providers, e.g. "anthropic,openai:gpt-5-mini" (optional)
+	LLMMockResponses string // JSON file of canned responses for the "mock" provider (optional)
+	MCPServerURL     string // MCP server URL (optional)
+
+	ModelRouting        string // "off" (default) or "auto": pick the DAW model by request complexity
+	ModelRoutingSimple  string // Model for simple commands (optional, default gpt-5-nano)
+	ModelRoutingComplex string // Model for everything else (optional, default the DAW agent's model)
+
+	PromptLanguage string // "auto" (default) detects each request's language; a code (de, es, fr, ja, en) pins it
+
+	ScopeCheck      string // "local" (default), "llm" or "off": reject requests unrelated to music production up front
+	DSLParseRetries int    // Re-prompts with the parse error when generated DSL doesn't parse (0 surfaces the error at once)
+
+	LLMCache     string        // "off" (default), "memory" or "redis"
+	LLMCacheTTL  time.Duration // How long a cached response is served (optional)
+	LLMCacheSize int           // Entries kept by the memory cache (optional)
+	RedisURL     string        // Required for the redis cache
+}
+
+// NewProvider returns the LLM provider selected by LLMProvider, followed by any LLMFallback
+// providers. Requests are retried with backoff on 429/5xx/timeouts before failing over.
+// When no provider is configured (see CheckProvider) every request fails instead.
+// With LLMCache set, repeated identical requests are served from the response cache.
+func (c *Config) NewProvider() llm.Provider {
+	providers, err := c.providers()
+	if err != nil {
+		log.Printf("❌ %v", err)
+	}
+
+	var provider llm.Provider = llm.NewFallbackProvider(llm.DefaultRetryPolicy(), providers...)
+	if cache := c.responseCache(); cache != nil && len(providers) > 0 {
+		provider = llm.NewCachingProvider(provider, cache)
+	}
+	return provider
+}
+
+// CheckProvider returns a configuration error when neither LLMProvider nor any LLMFallback
+// provider can be used, e.g. because its API key is missing
+func (c *Config) CheckProvider() error {
+	_, err := c.providers()
+	return err
+}
+
+// providers builds the LLMProvider provider followed by the LLMFallback providers, skipping
+// (and logging) the ones that aren't configured. It is an error when none is left.
+func (c *Config) providers() ([]llm.Provider, error) {
+	factory := llm.NewProviderFactory(c.OpenAIAPIKey).
+		WithAnthropic(c.AnthropicAPIKey, c.AnthropicModel).
+		WithMockResponses(c.LLMMockResponses)
+
+	var providers []llm.Provider
+	primary, primaryErr := factory.GetProviderByName(c.LLMProvider)
+	if primaryErr != nil {
+		log.Printf("⚠️  LLM provider %q unavailable: %v", c.LLMProvider, primaryErr)
+	} else {
+		providers = append(providers, primary)
+	}
+
+	for _, spec := range strings.Split(c.LLMFallback, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		provider, err := factory.GetProviderBySpec(spec)
+		if err != nil {
+			log.Printf("⚠️  Skipping LLM fallback %q: %v", spec, err)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no LLM provider configured: %q is unavailable (%v) and no LLM fallback is usable", c.LLMProvider, primaryErr)
+	}
+	return providers, nil
+}
+
+// responseCaches shares one cache per setting between every agent's provider
+var responseCaches struct {
+	sync.Mutex
+	caches map[string]llm.ResponseCache
+}
+
+// responseCache returns the shared cache for LLMCache, or nil when caching is off or unavailable
+func (c *Config) responseCache() llm.ResponseCache {
+	backend := strings.ToLower(strings.TrimSpace(c.LLMCache))
+	if backend == "" || backend == "off" {
+		return nil
+	}
+
+	key := fmt.Sprintf("%s|%s|%v|%d", backend, c.RedisURL, c.LLMCacheTTL, c.LLMCacheSize)
+	responseCaches.Lock()
+	defer responseCaches.Unlock()
+	if cache, ok := responseCaches.caches[key]; ok {
+		return cache
+	}
+
+	cache, err := llm.NewResponseCache(backend, c.RedisURL, c.LLMCacheTTL, c.LLMCacheSize)
+	if err != nil {
+		log.Printf("⚠️  LLM cache %q unavailable, caching disabled: %v", c.LLMCache, err)
+	} else {
+		log.Printf("⚡ LLM response cache enabled: %s", backend)
+	}
+	if responseCaches.caches == nil {
+		responseCaches.caches = map[string]llm.ResponseCache{}
+	}
+	responseCaches.caches[key] = cache
+	return cache
 }
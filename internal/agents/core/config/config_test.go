@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+)
+
+func TestCheckProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{name: "openai", cfg: Config{OpenAIAPIKey: "sk-test"}},
+		{name: "mock", cfg: Config{LLMProvider: "mock"}},
+		{name: "anthropic without key falls through to openai", cfg: Config{LLMProvider: "anthropic", LLMFallback: "openai", OpenAIAPIKey: "sk-test"}},
+		{name: "openai without key", cfg: Config{}, wantErr: "openai API key not configured"},
+		{name: "anthropic without key does not fall back to openai", cfg: Config{LLMProvider: "anthropic", OpenAIAPIKey: "sk-test"}, wantErr: "anthropic API key not configured"},
+		{name: "unknown provider", cfg: Config{LLMProvider: "gemini", OpenAIAPIKey: "sk-test"}, wantErr: "unknown LLM provider"},
+		{name: "fallbacks unusable too", cfg: Config{LLMProvider: "anthropic", LLMFallback: "openai"}, wantErr: "no LLM fallback is usable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.CheckProvider()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("CheckProvider() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("CheckProvider() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewProviderUnconfigured(t *testing.T) {
+	cfg := Config{LLMProvider: "anthropic", OpenAIAPIKey: "sk-test"}
+	provider := cfg.NewProvider()
+	if provider.Name() == "openai" {
+		t.Fatal("NewProvider() fell back to OpenAI for an unconfigured provider")
+	}
+	if _, err := provider.Generate(context.Background(), &llm.GenerationRequest{}); err == nil {
+		t.Fatal("Generate() succeeded without a configured provider")
+	}
+}
@@ -0,0 +1,110 @@
+package coordination
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+)
+
+const (
+	defaultSimpleModel = "gpt-5-nano"
+	// maxSimpleWords and maxSimpleTracks bound what counts as a simple command
+	maxSimpleWords  = 16
+	maxSimpleTracks = 32
+)
+
+// complexTerms are words that need the stronger model: automation, bulk edits over filtered
+// collections, conditions and routing
+var complexTerms = []string{
+	"automate", "automation", "lfo", "fade", "sweep", "filter", "every", "each", "all",
+	"except", "unless", "if", "where", "random", "arrange", "duplicate", "send", "route",
+	"routing", "sidechain", "bus", "buses",
+}
+
+// statementSeparator splits a request into statements ("create a track and mute it; ...")
+var statementSeparator = regexp.MustCompile(`(?i)\s*(?:[;,.]|\band then\b|\bthen\b|\band\b|\balso\b)\s+`)
+
+// ModelRoute is the model a request's DAW calls use, and why it was chosen
+type ModelRoute struct {
+	Model  string `json:"model"`
+	Reason string `json:"reason"`
+}
+
+// ModelRouter sends simple DAW commands to a cheaper, faster model and everything else to
+// a stronger one. When disabled, every request uses the complex model.
+type ModelRouter struct {
+	Enabled      bool
+	SimpleModel  string
+	ComplexModel string
+}
+
+// NewModelRouter creates the router for cfg.ModelRouting ("off" or "auto")
+func NewModelRouter(cfg *config.Config) *ModelRouter {
+	router := &ModelRouter{
+		Enabled:      strings.EqualFold(strings.TrimSpace(cfg.ModelRouting), "auto"),
+		SimpleModel:  cfg.ModelRoutingSimple,
+		ComplexModel: cfg.ModelRoutingComplex,
+	}
+	if router.SimpleModel == "" {
+		router.SimpleModel = defaultSimpleModel
+	}
+	if router.ComplexModel == "" {
+		router.ComplexModel = daw.DefaultModel
+	}
+	return router
+}
+
+// Route picks the model for question from the agents in plan, the request's wording and the
+// size of the project in state
+func (r *ModelRouter) Route(question string, state map[string]any, plan *AgentPlan) ModelRoute {
+	complexRoute := func(reason string) ModelRoute {
+		return ModelRoute{Model: r.ComplexModel, Reason: reason}
+	}
+	if !r.Enabled {
+		return complexRoute("routing off")
+	}
+
+	switch {
+	case plan != nil && plan.NeedsArranger:
+		return complexRoute("musical content for the arranger")
+	case plan != nil && plan.NeedsDrummer:
+		return complexRoute("drum pattern for the drummer")
+	case plan != nil && len(plan.DAWTasks) > 1:
+		return complexRoute(fmt.Sprintf("%d independent sub-tasks", len(plan.DAWTasks)))
+	}
+
+	// The statement and keyword checks below only know English
+	if language := daw.DetectLanguage(question); language != daw.LanguageEnglish {
+		return complexRoute(fmt.Sprintf("request in %s", language.Name()))
+	}
+
+	text := strings.ToLower(strings.TrimSpace(question))
+	if statements := len(statementSeparator.Split(strings.TrimRight(text, ".!?"), -1)); statements > 1 {
+		return complexRoute(fmt.Sprintf("%d statements", statements))
+	}
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_')
+	})
+	if len(words) > maxSimpleWords {
+		return complexRoute(fmt.Sprintf("long request (%d words)", len(words)))
+	}
+	for _, word := range words {
+		for _, term := range complexTerms {
+			if word == term || strings.HasPrefix(word, term) && len(term) > 3 {
+				return complexRoute(fmt.Sprintf("%q needs the stronger model", word))
+			}
+		}
+	}
+	tracks := getTrackCount(state)
+	if inner, ok := state["state"].(map[string]any); ok && tracks == 0 {
+		tracks = getTrackCount(inner) // REAPER snapshots nest the project under "state"
+	}
+	if tracks > maxSimpleTracks {
+		return complexRoute(fmt.Sprintf("large project (%d tracks)", tracks))
+	}
+
+	return ModelRoute{Model: r.SimpleModel, Reason: "single simple command"}
+}
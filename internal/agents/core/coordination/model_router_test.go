@@ -0,0 +1,64 @@
+package coordination
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModelRouter_Route(t *testing.T) {
+	router := NewModelRouter(&config.Config{ModelRouting: "auto"})
+	manyTracks := make([]any, 40)
+	for i := range manyTracks {
+		manyTracks[i] = map[string]any{"index": i}
+	}
+
+	tests := []struct {
+		name     string
+		question string
+		state    map[string]any
+		plan     *AgentPlan
+		model    string
+		reason   string
+	}{
+		{name: "simple command", question: "set track 2 volume to -6 dB", model: "gpt-5-nano", reason: "single simple command"},
+		{name: "two statements", question: "create a bass track and mute track 3", model: "gpt-5.1", reason: "2 statements"},
+		{name: "automation", question: "automate the volume on track 1", model: "gpt-5.1", reason: `"automate" needs the stronger model`},
+		{name: "bulk edit", question: "mute all drum tracks", model: "gpt-5.1", reason: `"all" needs the stronger model`},
+		{
+			name: "arranger task", question: "add an E minor arpeggio",
+			plan:  &AgentPlan{NeedsArranger: true},
+			model: "gpt-5.1", reason: "musical content for the arranger",
+		},
+		{
+			name: "sub-tasks", question: "rename track 1 to Bass and add a marker at bar 17",
+			plan:  &AgentPlan{DAWTasks: []string{"rename track 1 to Bass", "add a marker at bar 17"}},
+			model: "gpt-5.1", reason: "2 independent sub-tasks",
+		},
+		{
+			name: "large project", question: "solo track 12",
+			state: map[string]any{"state": map[string]any{"tracks": manyTracks}},
+			model: "gpt-5.1", reason: "large project (40 tracks)",
+		},
+		{name: "decimal is not a statement break", question: "set tempo to 122.5", model: "gpt-5-nano", reason: "single simple command"},
+		{name: "german", question: "erstelle eine Spur mit Serum", model: "gpt-5.1", reason: "request in German"},
+		{name: "japanese", question: "Serumでトラックを作成して", model: "gpt-5.1", reason: "request in Japanese"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, ModelRoute{Model: tt.model, Reason: tt.reason}, router.Route(tt.question, tt.state, tt.plan))
+		})
+	}
+}
+
+func TestModelRouter_Config(t *testing.T) {
+	// Off by default: every request uses the complex model
+	router := NewModelRouter(&config.Config{})
+	assert.Equal(t, ModelRoute{Model: "gpt-5.1", Reason: "routing off"}, router.Route("mute track 1", nil, nil))
+
+	router = NewModelRouter(&config.Config{ModelRouting: "AUTO", ModelRoutingSimple: "gpt-4.1-mini", ModelRoutingComplex: "gpt-5.2"})
+	assert.Equal(t, "gpt-4.1-mini", router.Route("mute track 1", nil, nil).Model)
+	assert.Equal(t, "gpt-5.2", router.Route("fade in track 1 over 4 bars", nil, nil).Model)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,8 +13,14 @@ import (
 	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
 	arranger "github.com/Conceptual-Machines/magda-api/internal/agents/shared/arranger"
 	"github.com/Conceptual-Machines/magda-api/internal/agents/shared/drummer"
+	"github.com/Conceptual-Machines/magda-api/internal/experiments"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/observability"
+	"github.com/Conceptual-Machines/magda-api/internal/simulator"
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+	"golang.org/x/sync/errgroup"
 )
 
 // Orchestrator coordinates multiple agents (DAW + Arranger + Drummer) running in parallel
@@ -22,6 +29,9 @@ type Orchestrator struct {
 	arrangerAgent ArrangerAgent // Will be set when we integrate
 	drummerAgent  *drummer.DrummerAgent
 	llmProvider   llm.Provider
+	modelRouter   *ModelRouter
+	scopeChecker  *ScopeChecker
+	language      daw.Language // Pinned request language; empty detects each request's
 }
 
 // ArrangerAgent interface for the arranger agent
@@ -52,14 +62,34 @@ type NoteEvent struct {
 
 // OrchestratorResult combines results from all agents
 type OrchestratorResult struct {
-	Actions []map[string]any `json:"actions"`
-	Usage   any              `json:"usage"`
+	Actions         []map[string]any        `json:"actions"`
+	Usage           any                     `json:"usage"`
+	Warnings        []models.ActionWarning  `json:"warnings,omitempty"`
+	FilterSummaries []models.FilterSummary  `json:"filterSummaries,omitempty"`
+	DSL             string                  `json:"dsl,omitempty"`     // DSL the DAW agent generated (LLM path only)
+	Repairs         []models.DSLRepair      `json:"repairs,omitempty"` // Rewrites applied to the DAW agent's DSL
+	UndoActions     []map[string]any        `json:"undoActions"`
+	Answers         []models.QueryAnswer    `json:"answers,omitempty"`
+	Answer          string                  `json:"answer,omitempty"`
+	Clarification   *models.Clarification   `json:"clarification,omitempty"`
+	OutOfScope      *models.OutOfScope      `json:"outOfScope,omitempty"`    // Set instead of actions when the scope check rejects the request
+	Path            string                  `json:"path"`                    // daw.PathRules or daw.PathLLM
+	Model           string                  `json:"model,omitempty"`         // Model the DAW calls used (LLM path only)
+	RoutingReason   string                  `json:"routingReason,omitempty"` // Why the model router chose Model
+	LLMParams       *daw.LLMParams          `json:"llmParams,omitempty"`     // Parameters the DAW calls used (LLM path only)
+	PromptVariant   string                  `json:"promptVariant,omitempty"` // Prompt experiment variant the DAW calls used
+	GrammarVersion  daw.GrammarVersion      `json:"grammarVersion"`          // DSL grammar the client asked for
+	Seed            *int                    `json:"seed,omitempty"`          // Seed of the arranger's random choices; passing it back repeats them
+	Defaults        *models.MusicalDefaults `json:"defaults,omitempty"`      // Musical defaults the arranger's defaults() call set, for the session to keep
+
+	// Set instead of actions when they delete more than the safety policy allows
+	ConfirmationRequired *models.ConfirmationRequired `json:"confirmationRequired,omitempty"`
 }
 
 // NewOrchestrator creates a new orchestrator instance
 func NewOrchestrator(cfg *config.Config) *Orchestrator {
 	dawAgent := daw.NewDawAgent(cfg)
-	llmProvider := llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+	llmProvider := cfg.NewProvider()
 
 	// Initialize arranger agent (basic, no MCP for now)
 	arrangerAgent := arranger.NewBasicArrangerAgent(cfg)
@@ -67,11 +97,19 @@ func NewOrchestrator(cfg *config.Config) *Orchestrator {
 	// Initialize drummer agent
 	drummerAgent := drummer.NewDrummerAgent(cfg)
 
+	language, err := daw.ParseLanguage(cfg.PromptLanguage)
+	if err != nil {
+		log.Printf("⚠️  %v, detecting each request's language", err)
+	}
+
 	o := &Orchestrator{
 		dawAgent:      dawAgent,
 		arrangerAgent: arrangerAgent,
 		drummerAgent:  drummerAgent,
 		llmProvider:   llmProvider,
+		modelRouter:   NewModelRouter(cfg),
+		scopeChecker:  NewScopeChecker(cfg.ScopeCheck, llmProvider),
+		language:      language,
 	}
 
 	return o
@@ -79,103 +117,124 @@ func NewOrchestrator(cfg *config.Config) *Orchestrator {
 
 // GenerateActions coordinates parallel agent execution and merges results
 func (o *Orchestrator) GenerateActions(ctx context.Context, question string, state map[string]any) (*OrchestratorResult, error) {
+	// Step 0: Simple commands like "mute track 2" are compiled locally, without any LLM call
+	if dawResult, ok := o.dawAgent.GenerateFastPath(ctx, question, state); ok {
+		return o.fastPathResult(ctx, dawResult, state, nil)
+	}
+	ctx = o.withLanguage(ctx, question)
+	outOfScope, scopeUsage := o.scopeChecker.Check(ctx, question)
+	if outOfScope != nil {
+		return outOfScopeResult(ctx, outOfScope, scopeUsage), nil
+	}
+
 	// Step 1: Detect which agents are needed
 	detectionStart := time.Now()
-	needsDAW, needsArranger, needsDrummer, err := o.DetectAgentsNeeded(ctx, question)
+	plan, err := o.PlanAgents(ctx, question)
 	detectionDuration := time.Since(detectionStart)
 	if err != nil {
-		log.Printf("⏱️ Agent detection failed in %v", detectionDuration)
-		// DetectAgentsNeeded already handles LLM validation when no keywords are found
-		// If it returns an error, the request is out of scope
+		logger.Printf(ctx, "⏱️ Agent detection failed in %v", detectionDuration)
+		// If planning fails, the request is out of scope
 		return nil, err
 	}
+	needsDAW, needsArranger, needsDrummer := true, plan.NeedsArranger, plan.NeedsDrummer
 
-	log.Printf("🔍 Agent detection: DAW=%v, Arranger=%v, Drummer=%v (took %v)", needsDAW, needsArranger, needsDrummer, detectionDuration)
+	logger.Printf(ctx, "🔍 Agent detection: DAW=%v, Arranger=%v, Drummer=%v (took %v)", needsDAW, needsArranger, needsDrummer, detectionDuration)
+	logPlanTasks(ctx, plan)
+	route := o.routeModel(ctx, question, state, plan)
+	ctx = daw.WithModel(ctx, route.Model)
 
 	// Step 1.5: Auto-enable DAW if arranger or drummer is needed but no tracks exist
 	// This ensures track creation happens before musical content is added
 	if (needsArranger || needsDrummer) && !needsDAW {
 		trackCount := getTrackCount(state)
 		if trackCount == 0 {
-			log.Printf("🔧 Auto-enabling DAW agent: Musical agent needs a track but none exist")
+			logger.Printf(ctx, "🔧 Auto-enabling DAW agent: Musical agent needs a track but none exist")
 			needsDAW = true
 		}
 	}
 
-	// Step 2: Launch only needed agents in parallel
-	var wg sync.WaitGroup
-	var dawResult *daw.DawResult
+	// Step 2: Launch only needed agents in parallel, at most maxParallelAgents LLM calls at a
+	// time. Independent DAW sub-tasks each get their own call.
+	dawTasks := plan.DAWTasks
+	if len(dawTasks) < 2 {
+		dawTasks = []string{question}
+	}
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxParallelAgents)
+
+	dawResults := make([]*daw.DawResult, len(dawTasks))
 	var arrangerResult *ArrangerResult
 	var drummerResult *drummer.DrummerResult
-	var dawErr error
 	var dawDuration, arrangerDuration, drummerDuration time.Duration
+	var mu sync.Mutex
 
 	if needsDAW {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			start := time.Now()
-			result, err := o.dawAgent.GenerateActions(ctx, question, state)
-			dawDuration = time.Since(start)
-			if err != nil {
-				dawErr = fmt.Errorf("daw agent: %w", err)
-				log.Printf("⏱️ DAW agent failed in %v", dawDuration)
-				return
-			}
-			log.Printf("⏱️ DAW agent completed in %v", dawDuration)
-			dawResult = result
-		}()
+		dawStart := time.Now()
+		for i, task := range dawTasks {
+			group.Go(func() error {
+				result, err := o.dawAgent.GenerateActions(groupCtx, task, state)
+				mu.Lock()
+				dawDuration = time.Since(dawStart)
+				mu.Unlock()
+				if err != nil {
+					// DAW is the gatekeeper - its failure cancels the other agents
+					logger.Printf(ctx, "⏱️ DAW agent failed in %v", time.Since(dawStart))
+					return fmt.Errorf("daw agent: %w", err)
+				}
+				dawResults[i] = result
+				return nil
+			})
+		}
 	}
 
 	if needsArranger && o.arrangerAgent != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		group.Go(func() error {
 			start := time.Now()
-			// Call arranger agent with question
-			result, err := o.arrangerAgent.GenerateActions(ctx, question)
+			// Call arranger agent with its sub-task (and the project key, if the clips show one)
+			result, err := o.arrangerAgent.GenerateActions(arrangerContext(groupCtx, state), plan.arrangerQuestion(question))
 			arrangerDuration = time.Since(start)
 			if err != nil {
-				log.Printf("⚠️ Arranger agent failed in %v: %v", arrangerDuration, err)
-				return
+				// Partial failures are OK - the arranger's results just won't be included
+				logger.Printf(ctx, "⚠️ Arranger agent failed in %v: %v", arrangerDuration, err)
+				return nil
 			}
-			log.Printf("⏱️ Arranger agent completed in %v", arrangerDuration)
-			// Use arranger result directly
+			logger.Printf(ctx, "⏱️ Arranger agent completed in %v", arrangerDuration)
 			arrangerResult = &ArrangerResult{
 				Actions: result.Actions,
 				Usage:   result.Usage,
 			}
-		}()
+			return nil
+		})
 	}
 
 	if needsDrummer && o.drummerAgent != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		group.Go(func() error {
 			start := time.Now()
-			// Build input array from question
+			// Build input array from the drummer's part of the question
 			inputArray := []map[string]any{
 				{
 					"role":    "user",
-					"content": question,
+					"content": plan.drummerQuestion(question),
 				},
 			}
-			result, err := o.drummerAgent.Generate(ctx, "gpt-5.1", inputArray)
+			result, err := o.drummerAgent.Generate(groupCtx, "gpt-5.1", inputArray)
 			drummerDuration = time.Since(start)
 			if err != nil {
-				log.Printf("⚠️ Drummer agent failed in %v: %v", drummerDuration, err)
-				return
+				logger.Printf(ctx, "⚠️ Drummer agent failed in %v: %v", drummerDuration, err)
+				return nil
 			}
-			log.Printf("⏱️ Drummer agent completed in %v", drummerDuration)
+			logger.Printf(ctx, "⏱️ Drummer agent completed in %v", drummerDuration)
 			drummerResult = result
-		}()
+			return nil
+		})
 	}
 
 	// Wait for all active agents to complete
-	wg.Wait()
+	dawErr := group.Wait()
 
 	// Log timing summary
-	log.Printf("⏱️ Agent timing summary: DAW=%v, Arranger=%v, Drummer=%v", dawDuration, arrangerDuration, drummerDuration)
+	logger.Printf(ctx, "⏱️ Agent timing summary: DAW=%v (%d calls), Arranger=%v, Drummer=%v",
+		dawDuration, len(dawTasks), arrangerDuration, drummerDuration)
 
 	// Step 3: Handle errors
 	// DAW is the gatekeeper - if it fails, fail the entire request
@@ -183,10 +242,74 @@ func (o *Orchestrator) GenerateActions(ctx context.Context, question string, sta
 	if dawErr != nil {
 		return nil, fmt.Errorf("DAW agent failed: %w", dawErr)
 	}
-	// For non-DAW agents, partial failures are OK (their results just won't be included)
+
+	var dawResult *daw.DawResult
+	if needsDAW {
+		dawResult = mergeDawResults(dawResults)
+		// Sub-tasks that each created tracks would give them the same indices - redo as one call
+		if len(dawTasks) > 1 && createsTracksInSeveral(dawResults) {
+			logger.Printf(ctx, "🔁 DAW sub-tasks both create tracks, running the whole request instead")
+			result, err := o.dawAgent.GenerateActions(ctx, question, state)
+			if err != nil {
+				return nil, fmt.Errorf("DAW agent failed: %w", err)
+			}
+			// The sub-task calls were paid for too
+			result.Usage = observability.SumUsage(dawResult.Usage, result.Usage)
+			dawResult = result
+		}
+	}
 
 	// Step 4: Merge results
-	return o.mergeResults(dawResult, arrangerResult, drummerResult)
+	result, err := o.mergeResults(ctx, dawResult, arrangerResult, drummerResult, state)
+	if err != nil {
+		return nil, err
+	}
+	result.Usage = observability.SumUsage(scopeUsage, plan.Usage, result.Usage)
+	result.Path = daw.PathLLM
+	result.Model, result.RoutingReason = route.Model, route.Reason
+	result.LLMParams = effectiveLLMParams(ctx, route)
+	result.PromptVariant = experiments.VariantID(ctx)
+	applyActionSchema(ctx, result)
+	applyGrammarVersion(ctx, result)
+	simulateActions(ctx, result, state)
+	applySafetyPolicy(ctx, result, state)
+	return result, nil
+}
+
+// fastPathResult wraps a DAW result compiled by the rules-based fast path, emitting its
+// actions through callback when streaming
+func (o *Orchestrator) fastPathResult(
+	ctx context.Context, dawResult *daw.DawResult, state map[string]any, callback StreamActionCallback,
+) (*OrchestratorResult, error) {
+	result, err := o.mergeResults(ctx, dawResult, nil, nil, state)
+	if err != nil {
+		return nil, err
+	}
+	applyActionSchema(ctx, result)
+	applyGrammarVersion(ctx, result)
+	simulateActions(ctx, result, state)
+	applySafetyPolicy(ctx, result, state)
+	if callback != nil {
+		for _, action := range result.Actions {
+			if err := callback(action); err != nil {
+				return nil, err
+			}
+		}
+	}
+	result.Path = daw.PathRules
+	return result, nil
+}
+
+// outOfScopeResult reports a request the scope check rejected, without actions
+func outOfScopeResult(ctx context.Context, outOfScope *models.OutOfScope, usage any) *OrchestratorResult {
+	result := &OrchestratorResult{
+		Actions:    []map[string]any{},
+		Usage:      usage,
+		OutOfScope: outOfScope,
+		Path:       daw.PathScopeCheck,
+	}
+	applyGrammarVersion(ctx, result)
+	return result
 }
 
 // StreamActionCallback is called for each action found during streaming
@@ -201,24 +324,42 @@ func (o *Orchestrator) GenerateActionsStream(
 	state map[string]any,
 	callback StreamActionCallback,
 ) (*OrchestratorResult, error) {
+	// Step 0: Simple commands like "mute track 2" are compiled locally, without any LLM call
+	if dawResult, ok := o.dawAgent.GenerateFastPath(ctx, question, state); ok {
+		return o.fastPathResult(ctx, dawResult, state, callback)
+	}
+	ctx = o.withLanguage(ctx, question)
+	outOfScope, scopeUsage := o.scopeChecker.Check(ctx, question)
+	if outOfScope != nil {
+		return outOfScopeResult(ctx, outOfScope, scopeUsage), nil
+	}
+
 	// Step 1: Detect which agents are needed
 	detectionStart := time.Now()
-	needsDAW, needsArranger, needsDrummer, err := o.DetectAgentsNeeded(ctx, question)
+	plan, err := o.PlanAgents(ctx, question)
 	detectionDuration := time.Since(detectionStart)
 	if err != nil {
-		log.Printf("⏱️ [Stream] Agent detection failed in %v", detectionDuration)
-		// DetectAgentsNeeded already handles LLM validation when no keywords are found
-		// If it returns an error, the request is out of scope
+		logger.Printf(ctx, "⏱️ [Stream] Agent detection failed in %v", detectionDuration)
+		// If planning fails, the request is out of scope
 		return nil, err
 	}
+	needsDAW, needsArranger, needsDrummer := true, plan.NeedsArranger, plan.NeedsDrummer
+	// Deletions wait for the whole batch when the safety policy may hold it back
+	guard := guardStream(ctx, callback)
+	if guard != nil {
+		callback = guard.emit
+	}
 
-	log.Printf("🔍 [Stream] Agent detection: DAW=%v, Arranger=%v, Drummer=%v (took %v)", needsDAW, needsArranger, needsDrummer, detectionDuration)
+	logger.Printf(ctx, "🔍 [Stream] Agent detection: DAW=%v, Arranger=%v, Drummer=%v (took %v)", needsDAW, needsArranger, needsDrummer, detectionDuration)
+	logPlanTasks(ctx, plan)
+	route := o.routeModel(ctx, question, state, plan)
+	ctx = daw.WithModel(ctx, route.Model)
 
 	// Step 1.5: Auto-enable DAW if arranger or drummer is needed but no tracks exist
 	if (needsArranger || needsDrummer) && !needsDAW {
 		trackCount := getTrackCount(state)
 		if trackCount == 0 {
-			log.Printf("🔧 [Stream] Auto-enabling DAW agent: Musical agent needs a track but none exist")
+			logger.Printf(ctx, "🔧 [Stream] Auto-enabling DAW agent: Musical agent needs a track but none exist")
 			needsDAW = true
 		}
 	}
@@ -228,6 +369,8 @@ func (o *Orchestrator) GenerateActionsStream(
 		mu               sync.Mutex
 		pendingNotes     []models.NoteEvent
 		arrangerActions  []map[string]any // Track arranger actions for generating clip name
+		sectionActions   []map[string]any // Section clips, emitted after all agents finish
+		arrangerWarnings []models.ActionWarning
 		clipCreated      bool
 		targetTrackIdx   int = 0
 		allActions       []map[string]any
@@ -236,12 +379,15 @@ func (o *Orchestrator) GenerateActionsStream(
 		drummerComplete  bool
 	)
 
-	// Helper to emit action via callback and track it
+	// Helper to emit action via callback and track it. Actions that don't match the action
+	// schema or that the client's grammar version doesn't have are kept back; applyActionSchema
+	// and applyGrammarVersion report them in the final result.
+	grammarVersion := daw.GrammarVersionFromContext(ctx)
 	emitAction := func(action map[string]any) error {
 		mu.Lock()
 		allActions = append(allActions, action)
 		mu.Unlock()
-		if callback != nil {
+		if callback != nil && daw.SupportsAction(action, grammarVersion) && len(daw.ValidateAction(action)) == 0 {
 			return callback(action)
 		}
 		return nil
@@ -277,7 +423,7 @@ func (o *Orchestrator) GenerateActionsStream(
 				"name":   clipName,
 			}
 
-			log.Printf("🎵 [Stream] Emitting add_midi with %d notes to track %d (name: %s)", len(pendingNotes), targetTrackIdx, clipName)
+			logger.Printf(ctx, "🎵 [Stream] Emitting add_midi with %d notes to track %d (name: %s)", len(pendingNotes), targetTrackIdx, clipName)
 			allActions = append(allActions, midiAction)
 			pendingNotes = nil // Clear buffer
 
@@ -292,27 +438,35 @@ func (o *Orchestrator) GenerateActionsStream(
 		return nil
 	}
 
-	// Step 2: Launch agents
-	var wg sync.WaitGroup
-	var dawErr error
+	// Step 2: Launch agents, at most maxParallelAgents at a time. A DAW failure cancels the others.
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxParallelAgents)
+	var drummerActions []map[string]any // Emitted after the DAW's actions, whichever agent finishes first
+	var dawWarnings []models.ActionWarning
+	var dawFilterSummaries []models.FilterSummary
+	var dawDSL string
+	var dawRepairs []models.DSLRepair
+	var dawUndoActions []map[string]any
+	var dawAnswers []models.QueryAnswer
+	var dawAnswer string
+	var dawClarification *models.Clarification
+	var dawUsage, arrangerUsage, drummerUsage any
 
 	if needsDAW {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		group.Go(func() error {
 			start := time.Now()
 			defer func() {
 				mu.Lock()
 				dawComplete = true
 				mu.Unlock()
-				log.Printf("⏱️ [Stream] DAW agent completed in %v", time.Since(start))
+				logger.Printf(ctx, "⏱️ [Stream] DAW agent completed in %v", time.Since(start))
 				_ = tryEmitMidi()
 			}()
 
 			// Use streaming DAW agent
 			dawCallback := func(action map[string]any) error {
 				actionType, _ := action["action"].(string)
-				log.Printf("🎬 [Stream] DAW action: %s", actionType)
+				logger.Printf(ctx, "🎬 [Stream] DAW action: %s", actionType)
 
 				// Track clip creation for dependency resolution
 				if actionType == "create_clip_at_bar" || actionType == "new_clip" {
@@ -322,7 +476,7 @@ func (o *Orchestrator) GenerateActionsStream(
 						targetTrackIdx = trackIdx
 					}
 					mu.Unlock()
-					log.Printf("📋 [Stream] Clip created on track %d", targetTrackIdx)
+					logger.Printf(ctx, "📋 [Stream] Clip created on track %d", targetTrackIdx)
 				}
 
 				// Track the track index from create_track
@@ -338,12 +492,20 @@ func (o *Orchestrator) GenerateActionsStream(
 				return emitAction(action)
 			}
 
-			_, err := o.dawAgent.GenerateActionsStream(ctx, question, state, dawCallback)
+			dawResult, err := o.dawAgent.GenerateActionsStream(groupCtx, question, state, dawCallback)
 			if err != nil {
-				dawErr = fmt.Errorf("daw agent stream: %w", err)
-				log.Printf("❌ [Stream] DAW agent error: %v", err)
+				logger.Printf(ctx, "❌ [Stream] DAW agent error: %v", err)
+				return fmt.Errorf("daw agent stream: %w", err)
 			}
-		}()
+			dawWarnings = dawResult.Warnings
+			dawFilterSummaries = dawResult.FilterSummaries
+			dawDSL, dawRepairs = dawResult.DSL, dawResult.Repairs
+			dawUndoActions = dawResult.UndoActions
+			dawAnswers, dawAnswer = dawResult.Answers, dawResult.Answer
+			dawClarification = dawResult.Clarification
+			dawUsage = dawResult.Usage
+			return nil
+		})
 	} else {
 		mu.Lock()
 		dawComplete = true
@@ -351,43 +513,41 @@ func (o *Orchestrator) GenerateActionsStream(
 	}
 
 	if needsArranger && o.arrangerAgent != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		group.Go(func() error {
 			start := time.Now()
 			defer func() {
 				mu.Lock()
 				arrangerComplete = true
 				mu.Unlock()
-				log.Printf("⏱️ [Stream] Arranger agent completed in %v", time.Since(start))
+				logger.Printf(ctx, "⏱️ [Stream] Arranger agent completed in %v", time.Since(start))
 				_ = tryEmitMidi()
 			}()
 
-			result, err := o.arrangerAgent.GenerateActions(ctx, question)
+			result, err := o.arrangerAgent.GenerateActions(arrangerContext(groupCtx, state), plan.arrangerQuestion(question))
 			if err != nil {
-				log.Printf("⚠️ [Stream] Arranger agent error: %v", err)
-				return
+				logger.Printf(ctx, "⚠️ [Stream] Arranger agent error: %v", err)
+				return nil
 			}
 
 			// Store arranger actions for clip naming
 			mu.Lock()
 			arrangerActions = result.Actions
+			arrangerUsage = result.Usage
+			arrangerWarnings = keyChordWarnings(result.Actions, state)
 			mu.Unlock()
 
-			// Convert arranger actions to NoteEvents and buffer them
+			// Convert arranger actions to NoteEvents, then buffer them once quantize/humanize ran
+			arrangerNotes := []models.NoteEvent{}
 			currentBeat := 0.0
 			for _, action := range result.Actions {
-				noteEvents, err := arranger.ConvertArrangerActionToNoteEvents(action, currentBeat)
+				noteEvents, err := convertArrangerAction(action, currentBeat, state)
 				if err != nil {
-					log.Printf("⚠️ [Stream] Failed to convert arranger action: %v", err)
+					logger.Printf(ctx, "⚠️ [Stream] Failed to convert arranger action: %v", err)
 					continue
 				}
 
-				mu.Lock()
-				pendingNotes = append(pendingNotes, noteEvents...)
-				mu.Unlock()
-
-				log.Printf("📦 [Stream] Buffered %d notes (total: %d)", len(noteEvents), len(pendingNotes))
+				arrangerNotes = append(arrangerNotes, noteEvents...)
+				logger.Printf(ctx, "📦 [Stream] Converted %d notes (total: %d)", len(noteEvents), len(arrangerNotes))
 
 				// Update beat position
 				if length, ok := getFloat(action, "length"); ok {
@@ -398,7 +558,28 @@ func (o *Orchestrator) GenerateActionsStream(
 					}
 				}
 			}
-		}()
+
+			// Sections bring their own clips; emit them once the DAW's tracks exist
+			mu.Lock()
+			sectionActions = sectionClipActions(result.Actions, state)
+			mu.Unlock()
+
+			// Transforms on their own edit the selected clips' notes instead
+			if len(arrangerNotes) == 0 {
+				for _, action := range transformStateClipNotes(result.Actions, state) {
+					if emitErr := emitAction(action); emitErr != nil {
+						logger.Printf(ctx, "⚠️ [Stream] Failed to emit clip notes action: %v", emitErr)
+					}
+				}
+				return nil
+			}
+
+			arrangerNotes = applyArrangerTransforms(arrangerNotes, result.Actions, state)
+			mu.Lock()
+			pendingNotes = append(pendingNotes, arrangerNotes...)
+			mu.Unlock()
+			return nil
+		})
 	} else {
 		mu.Lock()
 		arrangerComplete = true
@@ -406,39 +587,36 @@ func (o *Orchestrator) GenerateActionsStream(
 	}
 
 	if needsDrummer && o.drummerAgent != nil {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
+		group.Go(func() error {
 			start := time.Now()
 			defer func() {
 				mu.Lock()
 				drummerComplete = true
 				mu.Unlock()
-				log.Printf("⏱️ [Stream] Drummer agent completed in %v", time.Since(start))
+				logger.Printf(ctx, "⏱️ [Stream] Drummer agent completed in %v", time.Since(start))
 				_ = tryEmitMidi()
 			}()
 
-			// Build input array from question
+			// Build input array from the drummer's part of the question
 			inputArray := []map[string]any{
 				{
 					"role":    "user",
-					"content": question,
+					"content": plan.drummerQuestion(question),
 				},
 			}
-			result, err := o.drummerAgent.Generate(ctx, "gpt-5.1", inputArray)
+			result, err := o.drummerAgent.Generate(groupCtx, "gpt-5.1", inputArray)
 			if err != nil {
-				log.Printf("⚠️ [Stream] Drummer agent error: %v", err)
-				return
+				logger.Printf(ctx, "⚠️ [Stream] Drummer agent error: %v", err)
+				return nil
 			}
 
-			// Emit drummer actions directly (they're already in action format)
-			for _, action := range result.Actions {
-				log.Printf("🥁 [Stream] Emitting drummer action: %v", action["type"])
-				if emitErr := emitAction(action); emitErr != nil {
-					log.Printf("⚠️ [Stream] Failed to emit drummer action: %v", emitErr)
-				}
-			}
-		}()
+			// Drummer actions are already in action format; hold them for a deterministic order
+			mu.Lock()
+			drummerActions = result.Actions
+			drummerUsage = result.Usage
+			mu.Unlock()
+			return nil
+		})
 	} else {
 		mu.Lock()
 		drummerComplete = true
@@ -446,11 +624,28 @@ func (o *Orchestrator) GenerateActionsStream(
 	}
 
 	// Wait for all agents
-	wg.Wait()
+	dawErr := group.Wait()
 
 	// Final check - emit any remaining MIDI
 	_ = tryEmitMidi()
 
+	for _, action := range drummerActions {
+		logger.Printf(ctx, "🥁 [Stream] Emitting drummer action: %v", action["type"])
+		if emitErr := emitAction(action); emitErr != nil {
+			logger.Printf(ctx, "⚠️ [Stream] Failed to emit drummer action: %v", emitErr)
+		}
+	}
+
+	// A clarification holds back the arranger's section clips until the user answers
+	if dawClarification != nil {
+		sectionActions = nil
+	}
+	for _, action := range sectionActions {
+		if emitErr := emitAction(action); emitErr != nil {
+			logger.Printf(ctx, "⚠️ [Stream] Failed to emit section action: %v", emitErr)
+		}
+	}
+
 	// DAW is the gatekeeper - if it fails, fail the entire request
 	// This prevents garbage results from Arranger/Drummer being returned for out-of-scope requests
 	if dawErr != nil {
@@ -462,59 +657,181 @@ func (o *Orchestrator) GenerateActionsStream(
 	// Return all collected actions
 	mu.Lock()
 	result := &OrchestratorResult{
-		Actions: allActions,
+		Actions:         allActions,
+		Usage:           observability.SumUsage(scopeUsage, plan.Usage, dawUsage, arrangerUsage, drummerUsage),
+		Warnings:        append(dawWarnings, arrangerWarnings...),
+		FilterSummaries: dawFilterSummaries,
+		DSL:             dawDSL,
+		Repairs:         dawRepairs,
+		UndoActions:     dawUndoActions,
+		Answers:         dawAnswers,
+		Answer:          dawAnswer,
+		Clarification:   dawClarification,
+		Path:            daw.PathLLM,
+		Model:           route.Model,
+		RoutingReason:   route.Reason,
+		LLMParams:       effectiveLLMParams(ctx, route),
+		PromptVariant:   experiments.VariantID(ctx),
+		Seed:            actionsSeed(arrangerActions),
+		Defaults:        actionsDefaults(arrangerActions),
 	}
 	mu.Unlock()
+	applyActionSchema(ctx, result)
+	applyGrammarVersion(ctx, result)
+	simulateActions(ctx, result, state)
+	applySafetyPolicy(ctx, result, state)
+	if guard != nil {
+		if err := guard.release(ctx, result); err != nil {
+			return nil, err
+		}
+	}
 
-	log.Printf("✅ [Stream] Complete: %d total actions emitted", len(result.Actions))
+	logger.Printf(ctx, "✅ [Stream] Complete: %d total actions emitted", len(result.Actions))
 	return result, nil
 }
 
-// DetectAgentsNeeded uses LLM to detect which musical agents are needed
-// DAW agent is ALWAYS used (handles all REAPER operations: tracks, clips, FX, etc.)
-// Arranger and Drummer are optional based on musical content requested
-func (o *Orchestrator) DetectAgentsNeeded(ctx context.Context, question string) (needsDAW, needsArranger, needsDrummer bool, err error) {
-	// Use LLM to classify if Arranger or Drummer are needed
-	_, needsArranger, needsDrummer, llmErr := o.detectAgentsNeededLLM(ctx, question)
-	if llmErr != nil {
-		return false, false, false, fmt.Errorf("LLM classification failed: %w", llmErr)
+// AgentPlan is the orchestrator's decomposition of a request: which musical agents run, and
+// the part of the request each of them handles. The DAW agent gets the whole request, since it
+// creates the tracks and clips the other agents' content lands on, unless it splits into
+// independent DAW sub-tasks ("rename the tracks and add markers").
+type AgentPlan struct {
+	NeedsArranger bool
+	NeedsDrummer  bool
+	ArrangerTask  string // e.g. "an E-minor arpeggio" from "create a synth track with an E-minor arpeggio and fade it in"
+	DrummerTask   string
+	DAWTasks      []string // Independent parts of the request the DAW agent can do in parallel, if there are several
+	Usage         any      // Token usage of the planning call
+}
+
+// arrangerQuestion returns the arranger's sub-task, or the whole question when there is none
+func (p *AgentPlan) arrangerQuestion(question string) string {
+	if task := strings.TrimSpace(p.ArrangerTask); task != "" {
+		return task
 	}
+	return question
+}
 
-	// DAW is always needed - it handles all REAPER operations
-	needsDAW = true
+// drummerQuestion returns the drummer's sub-task, or the whole question when there is none
+func (p *AgentPlan) drummerQuestion(question string) string {
+	if task := strings.TrimSpace(p.DrummerTask); task != "" {
+		return task
+	}
+	return question
+}
 
-	return needsDAW, needsArranger, needsDrummer, nil
+// applyActionSchema leaves out of result the actions that don't match the published action
+// schema, warning about each, so clients only receive actions they can decode
+func applyActionSchema(ctx context.Context, result *OrchestratorResult) {
+	actions, warnings := daw.ValidateActions(result.Actions)
+	if len(warnings) == 0 {
+		return
+	}
+	for _, warning := range warnings {
+		logger.Printf(ctx, "⚠️ Action schema: %s", warning.Message)
+	}
+	result.Actions = actions
+	result.Warnings = append(result.Warnings, warnings...)
 }
 
-// detectAgentsNeededLLM uses LLM to classify which musical agents are needed
-// DAW agent is always used (handled by caller), this only classifies Arranger and Drummer
-// Returns needsArranger=false, needsDrummer=false if request is out of scope
-func (o *Orchestrator) detectAgentsNeededLLM(ctx context.Context, question string) (needsDAW, needsArranger, needsDrummer bool, err error) {
-	prompt := fmt.Sprintf(`You are a router for a music production AI system. Classify requests to determine which specialized agents are needed.
+// applyGrammarVersion leaves out of result the actions the client's DSL grammar version
+// doesn't have, warning about each, and records the version
+func applyGrammarVersion(ctx context.Context, result *OrchestratorResult) {
+	version := daw.GrammarVersionFromContext(ctx)
+	result.GrammarVersion = version
+	actions, warnings := daw.ActionsForVersion(result.Actions, version)
+	if len(warnings) == 0 {
+		return
+	}
+	logger.Printf(ctx, "🧩 DSL grammar %s: left out %d unsupported actions", version, len(warnings))
+	result.Actions = actions
+	result.Warnings = append(result.Warnings, warnings...)
+	result.UndoActions, _ = daw.ActionsForVersion(result.UndoActions, version)
+}
 
-THE SYSTEM HAS 3 AGENTS:
-1. DAW AGENT (always runs): Handles REAPER operations - tracks, clips, FX, volume, pan, mute, solo, routing. Does NOT generate musical content.
-2. ARRANGER AGENT: Generates melodic/harmonic MIDI content - chords, arpeggios, melodies, basslines, chord progressions. Creates actual notes with pitches.
-3. DRUMMER AGENT: Generates drum/percussion patterns - kick, snare, hi-hat, toms, cymbals. Creates rhythmic patterns on a grid.
+// simulateActions applies result's actions to state with the simulator and warns about the ones
+// that don't fit the project as the actions before them leave it, such as an edit of a deleted
+// track or an FX removed twice. The actions are kept: the client decides. A stale state is
+// already reported by stale_track_index warnings, which the simulation would only repeat.
+func simulateActions(ctx context.Context, result *OrchestratorResult, state map[string]any) {
+	for _, warning := range result.Warnings {
+		if warning.Code == daw.WarningStaleTrackIndex {
+			return
+		}
+	}
+	simulated, err := simulator.Apply(state, result.Actions)
+	if err != nil {
+		logger.Printf(ctx, "⚠️ Action simulation skipped: %v", err)
+		return
+	}
+	for _, warning := range simulated.Warnings {
+		logger.Printf(ctx, "🔮 Action simulation: %s", warning.Message)
+	}
+	result.Warnings = append(result.Warnings, simulated.Warnings...)
+}
+
+// withLanguage attaches the request's language for the DAW prompt: the pinned one, or the one
+// detected in question
+func (o *Orchestrator) withLanguage(ctx context.Context, question string) context.Context {
+	language := o.language
+	if language == "" {
+		language = daw.DetectLanguage(question)
+	}
+	if language != daw.LanguageEnglish {
+		logger.Printf(ctx, "🌐 Request language: %s", language.Name())
+	}
+	return daw.WithLanguage(ctx, language)
+}
 
-YOUR TASK: Decide if ARRANGER and/or DRUMMER are needed (DAW always runs).
+// routeModel picks the DAW agent's model for the request; a model the request overrides wins
+func (o *Orchestrator) routeModel(ctx context.Context, question string, state map[string]any, plan *AgentPlan) ModelRoute {
+	if model := daw.LLMParamsFromContext(ctx).Model; model != "" {
+		logger.Printf(ctx, "🧭 Model routing: %s (requested)", model)
+		return ModelRoute{Model: model, Reason: "requested"}
+	}
+	if o.modelRouter == nil {
+		return ModelRoute{Model: daw.DefaultModel}
+	}
+	route := o.modelRouter.Route(question, state, plan)
+	logger.Printf(ctx, "🧭 Model routing: %s (%s)", route.Model, route.Reason)
+	return route
+}
 
-EXAMPLES:
-- "create a track called Drums" → {"needsArranger": false, "needsDrummer": false} (just naming a track, no content)
-- "add reverb to the bass" → {"needsArranger": false, "needsDrummer": false} (FX operation)
-- "mute track 2" → {"needsArranger": false, "needsDrummer": false} (track control)
-- "add a breakbeat pattern" → {"needsArranger": false, "needsDrummer": true} (generating drums)
-- "create a funk groove with ghost notes" → {"needsArranger": false, "needsDrummer": true} (drum pattern)
-- "add a chord progression in C major" → {"needsArranger": true, "needsDrummer": false} (harmonic content)
-- "create an arpeggio" → {"needsArranger": true, "needsDrummer": false} (melodic content)
-- "add sustained E1" → {"needsArranger": true, "needsDrummer": false} (single note = melodic content)
-- "add note C4" → {"needsArranger": true, "needsDrummer": false} (single note = melodic content)
-- "bass note at bar 2" → {"needsArranger": true, "needsDrummer": false} (single note = melodic content)
-- "create a hip hop beat with kicks and snares" → {"needsArranger": false, "needsDrummer": true} (drum pattern)
+// effectiveLLMParams returns the LLM parameters the request's DAW calls used on route's model
+func effectiveLLMParams(ctx context.Context, route ModelRoute) *daw.LLMParams {
+	params := daw.LLMParamsFromContext(ctx).Effective(route.Model)
+	return &params
+}
 
-REQUEST: "%s"
+// logPlanTasks logs the sub-tasks the musical agents were given
+func logPlanTasks(ctx context.Context, plan *AgentPlan) {
+	if plan.ArrangerTask != "" {
+		logger.Printf(ctx, "🧩 Arranger sub-task: %q", plan.ArrangerTask)
+	}
+	if plan.DrummerTask != "" {
+		logger.Printf(ctx, "🧩 Drummer sub-task: %q", plan.DrummerTask)
+	}
+	if len(plan.DAWTasks) > 1 {
+		logger.Printf(ctx, "🧩 DAW sub-tasks: %q", plan.DAWTasks)
+	}
+}
 
-Return JSON: {"needsArranger": bool, "needsDrummer": bool}`, question)
+// DetectAgentsNeeded uses LLM to detect which musical agents are needed
+// DAW agent is ALWAYS used (handles all REAPER operations: tracks, clips, FX, etc.)
+// Arranger and Drummer are optional based on musical content requested
+func (o *Orchestrator) DetectAgentsNeeded(ctx context.Context, question string) (needsDAW, needsArranger, needsDrummer bool, err error) {
+	plan, err := o.PlanAgents(ctx, question)
+	if err != nil {
+		return false, false, false, err
+	}
+
+	// DAW is always needed - it handles all REAPER operations
+	return true, plan.NeedsArranger, plan.NeedsDrummer, nil
+}
+
+// PlanAgents uses LLM to decide which musical agents are needed and splits the request into
+// their sub-tasks. Returns a plan with no musical agents if the request is out of their scope.
+func (o *Orchestrator) PlanAgents(ctx context.Context, question string) (*AgentPlan, error) {
+	prompt := planAgentsPrompt(question)
 
 	// Use a small, fast model for classification
 	request := &llm.GenerationRequest{
@@ -522,8 +839,8 @@ Return JSON: {"needsArranger": bool, "needsDrummer": bool}`, question)
 		InputArray:    []map[string]any{{"role": "user", "content": prompt}},
 		ReasoningMode: "none",
 		OutputSchema: &llm.OutputSchema{
-			Name:        "MusicalAgentClassification",
-			Description: "Classification of which musical agents (Arranger/Drummer) are needed",
+			Name:        "MusicalAgentPlan",
+			Description: "Which musical agents (Arranger/Drummer) are needed, and each one's sub-task",
 			Schema: map[string]any{
 				"type":                 "object",
 				"additionalProperties": false,
@@ -534,42 +851,118 @@ Return JSON: {"needsArranger": bool, "needsDrummer": bool}`, question)
 					"needsDrummer": map[string]any{
 						"type": "boolean",
 					},
+					"arrangerTask": map[string]any{
+						"type": "string",
+					},
+					"drummerTask": map[string]any{
+						"type": "string",
+					},
+					"dawTasks": map[string]any{
+						"type":  "array",
+						"items": map[string]any{"type": "string"},
+					},
 				},
-				"required": []string{"needsArranger", "needsDrummer"},
+				"required": []string{"needsArranger", "needsDrummer", "arrangerTask", "drummerTask", "dawTasks"},
 			},
 		},
 	}
 
 	resp, llmErr := o.llmProvider.Generate(ctx, request)
 	if llmErr != nil {
-		return false, false, false, fmt.Errorf("LLM classification failed: %w", llmErr)
+		return nil, fmt.Errorf("LLM classification failed: %w", llmErr)
 	}
 
 	// Parse response from RawOutput (JSON Schema returns structured JSON)
 	result := struct {
-		NeedsArranger bool `json:"needsArranger"`
-		NeedsDrummer  bool `json:"needsDrummer"`
-	}{
-		NeedsArranger: false,
-		NeedsDrummer:  false,
-	}
+		NeedsArranger bool     `json:"needsArranger"`
+		NeedsDrummer  bool     `json:"needsDrummer"`
+		ArrangerTask  string   `json:"arrangerTask"`
+		DrummerTask   string   `json:"drummerTask"`
+		DAWTasks      []string `json:"dawTasks"`
+	}{}
 
-	// Try to parse from RawOutput if available
 	if resp.RawOutput != "" {
 		if parseErr := json.Unmarshal([]byte(resp.RawOutput), &result); parseErr != nil {
-			log.Printf("⚠️ Failed to parse LLM classification JSON: %v, raw: %s", parseErr, resp.RawOutput)
-			return false, false, false, fmt.Errorf("failed to parse LLM classification: %w", parseErr)
+			logger.Printf(ctx, "⚠️ Failed to parse LLM classification JSON: %v, raw: %s", parseErr, resp.RawOutput)
+			return nil, fmt.Errorf("failed to parse LLM classification: %w", parseErr)
+		}
+	}
+
+	plan := &AgentPlan{NeedsArranger: result.NeedsArranger, NeedsDrummer: result.NeedsDrummer, Usage: resp.Usage}
+	if plan.NeedsArranger {
+		plan.ArrangerTask = result.ArrangerTask
+	}
+	if plan.NeedsDrummer {
+		plan.DrummerTask = result.DrummerTask
+	}
+	for _, task := range result.DAWTasks {
+		if task = strings.TrimSpace(task); task != "" {
+			plan.DAWTasks = append(plan.DAWTasks, task)
 		}
 	}
+	return plan, nil
+}
 
-	// DAW is always true (handled by caller), return Arranger and Drummer classification
-	return true, result.NeedsArranger, result.NeedsDrummer, nil
+// planAgentsPrompt is PlanAgents' router prompt for question
+func planAgentsPrompt(question string) string {
+	return fmt.Sprintf(`You are a router for a music production AI system. Classify requests to determine which specialized agents are needed.
+
+THE SYSTEM HAS 3 AGENTS:
+1. DAW AGENT (always runs): Handles REAPER operations - tracks, clips, FX, volume, pan, mute, solo, routing, automation. Does NOT generate musical content.
+2. ARRANGER AGENT: Generates melodic/harmonic MIDI content - chords, arpeggios, melodies, basslines, chord progressions. Creates actual notes with pitches.
+3. DRUMMER AGENT: Generates drum/percussion patterns - kick, snare, hi-hat, toms, cymbals. Creates rhythmic patterns on a grid.
+
+YOUR TASK: Decide if ARRANGER and/or DRUMMER are needed (DAW always runs). For each one that is,
+write its sub-task: only the part of the request it handles, in the user's words. Leave a
+sub-task empty when its agent is not needed.
+
+Also split the DAW's work into dawTasks when the request has two or more parts that do NOT depend
+on each other (none uses a track, clip or name another one creates, and at most one creates tracks).
+Each dawTask is a complete request on its own. Otherwise return dawTasks as [] - when in doubt, don't split.
+
+Requests may be in any language (e.g. German, Spanish, Japanese): classify them by meaning, and
+write sub-tasks in the request's language.
+
+EXAMPLES:
+- "create a track called Drums" → {"needsArranger": false, "needsDrummer": false} (just naming a track, no content)
+- "add reverb to the bass" → {"needsArranger": false, "needsDrummer": false} (FX operation)
+- "mute track 2" → {"needsArranger": false, "needsDrummer": false} (track control)
+- "add a breakbeat pattern" → {"needsArranger": false, "needsDrummer": true, "drummerTask": "a breakbeat pattern"} (generating drums)
+- "create a funk groove with ghost notes" → {"needsArranger": false, "needsDrummer": true, "drummerTask": "a funk groove with ghost notes"} (drum pattern)
+- "add a chord progression in C major" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "a chord progression in C major"} (harmonic content)
+- "create an arpeggio" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "an arpeggio"} (melodic content)
+- "add sustained E1" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "sustained E1"} (single note = melodic content)
+- "add note C4" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "note C4"} (single note = melodic content)
+- "bass note at bar 2" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "bass note"} (single note = melodic content)
+- "create a hip hop beat with kicks and snares" → {"needsArranger": false, "needsDrummer": true, "drummerTask": "a hip hop beat with kicks and snares"} (drum pattern)
+- "quantize the drums to 16ths" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "quantize to 16ths"} (edits existing MIDI notes)
+- "humanize the hi-hats" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "humanize"} (edits existing MIDI notes)
+- "add 55%% swing to the hats" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "add 55%% swing"} (edits existing MIDI notes)
+- "arrange an 8-bar pop structure" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "an 8-bar pop structure"} (song structure from a genre preset)
+- "create a synth track with an E-minor arpeggio and fade it in" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "an E-minor arpeggio"} (the DAW creates the track and the fade)
+- "add a piano track playing C Am F G and a drum track with a rock beat" → {"needsArranger": true, "needsDrummer": true, "arrangerTask": "C Am F G chords on piano", "drummerTask": "a rock beat"}
+- "rename track 1 to Bass and add a marker called Chorus at bar 17" → {"needsArranger": false, "needsDrummer": false, "dawTasks": ["rename track 1 to Bass", "add a marker called Chorus at bar 17"]}
+- "create a track called Pad and add reverb to it" → {"needsArranger": false, "needsDrummer": false, "dawTasks": []} (the reverb needs the new track)
+- "from now on write everything an octave lower" → {"needsArranger": true, "needsDrummer": false, "arrangerTask": "from now on write everything an octave lower"} (sets the arranger's defaults for later parts)
+
+REQUEST: "%s"
+
+Return JSON: {"needsArranger": bool, "needsDrummer": bool, "arrangerTask": string, "drummerTask": string, "dawTasks": [string]}`, question)
 }
 
-// mergeResults combines DAW, Arranger, and Drummer results
-func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *ArrangerResult, drummerResult *drummer.DrummerResult) (*OrchestratorResult, error) {
+// mergeResults combines DAW, Arranger, and Drummer results. state supplies the tempo and
+// existing clip notes for the arranger's quantize/humanize actions.
+func (o *Orchestrator) mergeResults(ctx context.Context, dawResult *daw.DawResult, arrangerResult *ArrangerResult, drummerResult *drummer.DrummerResult, state map[string]any) (*OrchestratorResult, error) {
 	result := &OrchestratorResult{
 		Actions: []map[string]any{},
+		Usage:   agentsUsage(dawResult, arrangerResult, drummerResult),
+	}
+
+	// The DAW agent asked a question instead of acting: nothing is applied until the user answers
+	if dawResult != nil && dawResult.Clarification != nil {
+		logger.Printf(ctx, "❓ DAW agent asked for clarification: %s", dawResult.Clarification.Question)
+		result.Clarification = dawResult.Clarification
+		return result, nil
 	}
 
 	// If we only have arranger results (no DAW), convert arranger actions to NoteEvents
@@ -580,9 +973,9 @@ func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *Ar
 		currentBeat := 0.0
 
 		for _, action := range arrangerResult.Actions {
-			noteEvents, err := arranger.ConvertArrangerActionToNoteEvents(action, currentBeat)
+			noteEvents, err := convertArrangerAction(action, currentBeat, state)
 			if err != nil {
-				log.Printf("⚠️ Failed to convert arranger action to NoteEvents: %v", err)
+				logger.Printf(ctx, "⚠️ Failed to convert arranger action to NoteEvents: %v", err)
 				continue
 			}
 
@@ -598,6 +991,13 @@ func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *Ar
 			}
 		}
 
+		if len(allNoteEvents) == 0 {
+			// Transforms on their own edit the selected clips' notes instead
+			result.Actions = append(result.Actions, transformStateClipNotes(arrangerResult.Actions, state)...)
+		}
+		result.Actions = append(result.Actions, sectionClipActions(arrangerResult.Actions, state)...)
+		allNoteEvents = applyArrangerTransforms(allNoteEvents, arrangerResult.Actions, state)
+
 		// Create a DAW action to add MIDI notes
 		if len(allNoteEvents) > 0 {
 			// Convert models.NoteEvent to map format expected by DAW
@@ -625,21 +1025,21 @@ func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *Ar
 	if dawResult != nil {
 		// If we have both DAW and arranger results, inject arranger NoteEvents into DAW actions
 		if arrangerResult != nil && len(arrangerResult.Actions) > 0 {
-			log.Printf("🔄 Merging %d DAW actions with %d arranger actions", len(dawResult.Actions), len(arrangerResult.Actions))
+			logger.Printf(ctx, "🔄 Merging %d DAW actions with %d arranger actions", len(dawResult.Actions), len(arrangerResult.Actions))
 
 			// Convert all arranger actions to NoteEvents
 			allNoteEvents := []models.NoteEvent{}
 			currentBeat := 0.0
 
 			for _, action := range arrangerResult.Actions {
-				log.Printf("🎵 Converting arranger action: type=%v, chord=%v", action["type"], action["chord"])
-				noteEvents, err := arranger.ConvertArrangerActionToNoteEvents(action, currentBeat)
+				logger.Printf(ctx, "🎵 Converting arranger action: type=%v, chord=%v", action["type"], action["chord"])
+				noteEvents, err := convertArrangerAction(action, currentBeat, state)
 				if err != nil {
-					log.Printf("⚠️ Failed to convert arranger action to NoteEvents: %v", err)
+					logger.Printf(ctx, "⚠️ Failed to convert arranger action to NoteEvents: %v", err)
 					continue
 				}
 
-				log.Printf("✅ Converted to %d NoteEvents (starting at beat %.2f)", len(noteEvents), currentBeat)
+				logger.Printf(ctx, "✅ Converted to %d NoteEvents (starting at beat %.2f)", len(noteEvents), currentBeat)
 				allNoteEvents = append(allNoteEvents, noteEvents...)
 
 				// Update currentBeat for next action
@@ -652,7 +1052,8 @@ func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *Ar
 				}
 			}
 
-			log.Printf("📊 Total NoteEvents from arranger: %d", len(allNoteEvents))
+			logger.Printf(ctx, "📊 Total NoteEvents from arranger: %d", len(allNoteEvents))
+			allNoteEvents = applyArrangerTransforms(allNoteEvents, arrangerResult.Actions, state)
 
 			// Find add_midi actions and inject NoteEvents, or create one if needed
 			hasMidiAction := false
@@ -676,22 +1077,15 @@ func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *Ar
 						}
 					}
 					action["notes"] = notesArray
-					log.Printf("✅ Injected %d notes into add_midi action", len(notesArray))
+					logger.Printf(ctx, "✅ Injected %d notes into add_midi action", len(notesArray))
 				}
 				result.Actions = append(result.Actions, action)
 			}
 
 			// If no add_midi action exists but we have NoteEvents, create one
 			if !hasMidiAction && len(allNoteEvents) > 0 {
-				// Find the last track index from DAW actions
-				lastTrackIndex := -1
-				for _, action := range dawResult.Actions {
-					if track, ok := action["track"].(int); ok {
-						lastTrackIndex = track
-					} else if track, ok := action["index"].(int); ok {
-						lastTrackIndex = track
-					}
-				}
+				// The notes land on the clip (or track) the DAW agent created for them
+				lastTrackIndex := arrangerTargetTrack(dawResult.Actions)
 
 				// Convert NoteEvents to map format
 				notesArray := make([]map[string]any, len(allNoteEvents))
@@ -711,27 +1105,109 @@ func (o *Orchestrator) mergeResults(dawResult *daw.DawResult, arrangerResult *Ar
 				if lastTrackIndex >= 0 {
 					midiAction["track"] = lastTrackIndex
 				}
-				// Note: In non-streaming merge mode, original question is not available here
+				if clipName := generateClipName(arrangerResult.Actions); clipName != "" {
+					midiAction["name"] = clipName
+				}
 
-				result.Actions = append(result.Actions, midiAction)
-				log.Printf("✅ Created new add_midi action with %d notes (track=%d)", len(notesArray), lastTrackIndex)
+				result.Actions = insertAfterClip(result.Actions, lastTrackIndex, midiAction)
+				logger.Printf(ctx, "✅ Created new add_midi action with %d notes (track=%d)", len(notesArray), lastTrackIndex)
+			}
+			if len(allNoteEvents) == 0 {
+				result.Actions = append(result.Actions, transformStateClipNotes(arrangerResult.Actions, state)...)
 			}
+			result.Actions = append(result.Actions, sectionClipActions(arrangerResult.Actions, state)...)
 		} else {
 			// No arranger results, just add DAW actions as-is
 			result.Actions = append(result.Actions, dawResult.Actions...)
 		}
-		result.Usage = dawResult.Usage // TODO: merge usage from all agents
+		result.Warnings = dawResult.Warnings
+		result.FilterSummaries = dawResult.FilterSummaries
+		result.DSL = dawResult.DSL
+		result.Repairs = dawResult.Repairs
+		result.UndoActions = dawResult.UndoActions
+		result.Answers = dawResult.Answers
+		result.Answer = dawResult.Answer
+	}
+
+	if arrangerResult != nil {
+		result.Warnings = append(result.Warnings, keyChordWarnings(arrangerResult.Actions, state)...)
+		result.Seed = actionsSeed(arrangerResult.Actions)
+		result.Defaults = actionsDefaults(arrangerResult.Actions)
 	}
 
 	// Add drummer results (drum patterns)
 	if drummerResult != nil && len(drummerResult.Actions) > 0 {
-		log.Printf("🥁 Adding %d drummer actions", len(drummerResult.Actions))
+		logger.Printf(ctx, "🥁 Adding %d drummer actions", len(drummerResult.Actions))
 		result.Actions = append(result.Actions, drummerResult.Actions...)
 	}
 
 	return result, nil
 }
 
+// agentsUsage adds up the token usage of the agents that contributed to a result
+func agentsUsage(dawResult *daw.DawResult, arrangerResult *ArrangerResult, drummerResult *drummer.DrummerResult) any {
+	var usages []any
+	if dawResult != nil {
+		usages = append(usages, dawResult.Usage)
+	}
+	if arrangerResult != nil {
+		usages = append(usages, arrangerResult.Usage)
+	}
+	if drummerResult != nil {
+		usages = append(usages, drummerResult.Usage)
+	}
+	return observability.SumUsage(usages...)
+}
+
+// arrangerTargetTrack returns the track the arranger's notes belong on: that of the last clip
+// the DAW actions create, else the last track they create, else the last track they touch.
+// Returns -1 when the actions refer to no track.
+func arrangerTargetTrack(dawActions []map[string]any) int {
+	clipTrack, createdTrack, lastTrack := -1, -1, -1
+	for _, action := range dawActions {
+		switch action["action"] {
+		case "create_clip", "create_clip_at_bar", "new_clip":
+			if track, ok := action["track"].(int); ok {
+				clipTrack = track
+			}
+		case "create_track":
+			if index, ok := action["index"].(int); ok {
+				createdTrack = index
+			}
+		}
+		if track, ok := action["track"].(int); ok {
+			lastTrack = track
+		} else if index, ok := action["index"].(int); ok {
+			lastTrack = index
+		}
+	}
+	switch {
+	case clipTrack >= 0:
+		return clipTrack
+	case createdTrack >= 0:
+		return createdTrack
+	}
+	return lastTrack
+}
+
+// insertAfterClip inserts action right after the last clip created on track, so notes are
+// added before later actions on that clip's track (a fade, say). Appends when there is none.
+func insertAfterClip(actions []map[string]any, track int, action map[string]any) []map[string]any {
+	at := len(actions)
+	for i, existing := range actions {
+		switch existing["action"] {
+		case "create_clip", "create_clip_at_bar", "new_clip":
+			if existingTrack, ok := existing["track"].(int); ok && existingTrack == track {
+				at = i + 1
+			}
+		}
+	}
+	actions = append(actions, nil)
+	copy(actions[at+1:], actions[at:])
+	actions[at] = action
+	return actions
+}
+
 // Helper functions for type conversion
 func getFloat(m map[string]any, key string) (float64, bool) {
 	if v, ok := m[key]; ok {
@@ -761,6 +1237,267 @@ func getInt(m map[string]any, key string) (int, bool) {
 	return 0, false
 }
 
+// convertArrangerAction converts an arranger action to NoteEvents at the project tempo, which
+// millisecond timings like chord strum_ms depend on
+func convertArrangerAction(action map[string]any, startBeat float64, state map[string]any) ([]models.NoteEvent, error) {
+	if _, hasBPM := action["bpm"]; !hasBPM {
+		if bpm := getProjectBPM(state); bpm > 0 {
+			withTempo := make(map[string]any, len(action)+1)
+			for k, v := range action {
+				withTempo[k] = v
+			}
+			withTempo["bpm"] = bpm
+			action = withTempo
+		}
+	}
+	return arranger.ConvertArrangerActionToNoteEvents(action, startBeat)
+}
+
+// arrangerContext adds the key detected from the clips in state, so the arranger writes parts
+// that fit what's already in the project. The project's time signature replaces the session's
+// default one.
+func arrangerContext(ctx context.Context, state map[string]any) context.Context {
+	if signature := timeutil.TimeSignature(state); signature != "" {
+		defaults := arranger.MusicalDefaultsFromContext(ctx)
+		defaults.TimeSignature = signature
+		ctx = arranger.WithMusicalDefaults(ctx, defaults)
+	}
+
+	key, ok := arranger.DetectKeyFromState(state)
+	if !ok {
+		return ctx
+	}
+	logger.Printf(ctx, "🎼 Detected project key %s (confidence %.2f, %d notes)", key.Name, key.Confidence, key.NoteCount)
+	return arranger.WithProjectKey(ctx, key)
+}
+
+// keyChordWarnings warns about arranger chords outside the key detected from the clips in state
+func keyChordWarnings(arrangerActions []map[string]any, state map[string]any) []models.ActionWarning {
+	key, ok := arranger.DetectKeyFromState(state)
+	if !ok {
+		return nil
+	}
+	return arranger.KeyChordWarnings(arrangerActions, key)
+}
+
+// actionsSeed returns the seed of the arranger actions' random choices, or nil if they make none
+func actionsSeed(arrangerActions []map[string]any) *int {
+	if seed, ok := arranger.ActionsSeed(arrangerActions); ok {
+		return &seed
+	}
+	return nil
+}
+
+// actionsDefaults returns the musical defaults the arranger actions set, or nil if they set none
+func actionsDefaults(arrangerActions []map[string]any) *models.MusicalDefaults {
+	if defaults, ok := arranger.ActionsDefaults(arrangerActions); ok {
+		return &defaults
+	}
+	return nil
+}
+
+// applyArrangerTransforms runs the arranger's quantize/humanize/groove actions on the generated
+// notes, taking grooves from tracks in state. On an invalid transform the notes are returned
+// unchanged.
+func applyArrangerTransforms(noteEvents []models.NoteEvent, arrangerActions []map[string]any, state map[string]any) []models.NoteEvent {
+	arrangerActions, err := arranger.ResolveGrooves(arrangerActions, state)
+	if err != nil {
+		log.Printf("⚠️ Failed to apply arranger note transforms: %v", err)
+		return noteEvents
+	}
+	transformed, err := arranger.ApplyNoteTransforms(noteEvents, arrangerActions, getProjectBPM(state))
+	if err != nil {
+		log.Printf("⚠️ Failed to apply arranger note transforms: %v", err)
+		return noteEvents
+	}
+	return transformed
+}
+
+// transformStateClipNotes applies the arranger's quantize/humanize/groove actions to the notes
+// of the selected clips in state, returning one set_clip_notes action per clip. It returns
+// nothing unless the arranger actions are all transforms.
+// Example: "quantize the drums to 16ths" with the drum clip selected
+func transformStateClipNotes(arrangerActions []map[string]any, state map[string]any) []map[string]any {
+	hasTransform := false
+	for _, action := range arrangerActions {
+		if !arranger.IsNoteTransformAction(action) {
+			return nil // Transforms apply to the generated notes instead
+		}
+		hasTransform = true
+	}
+	if !hasTransform {
+		return nil
+	}
+	arrangerActions, err := arranger.ResolveGrooves(arrangerActions, state)
+	if err != nil {
+		log.Printf("⚠️ Failed to apply arranger note transforms: %v", err)
+		return nil
+	}
+
+	var actions []map[string]any
+	for i, track := range stateMaps(state["tracks"]) {
+		trackIndex, ok := getInt(track, "index")
+		if !ok {
+			trackIndex = i
+		}
+		for _, clip := range stateMaps(track["clips"]) {
+			if selected, _ := clip["selected"].(bool); !selected {
+				continue
+			}
+			noteEvents := arranger.ClipNoteEvents(clip)
+			if len(noteEvents) == 0 {
+				continue
+			}
+			transformed, err := arranger.ApplyNoteTransforms(noteEvents, arrangerActions, getProjectBPM(state))
+			if err != nil {
+				log.Printf("⚠️ Failed to apply arranger note transforms: %v", err)
+				return nil
+			}
+
+			notesArray := make([]map[string]any, len(transformed))
+			for j, note := range transformed {
+				notesArray[j] = map[string]any{
+					"pitch":    note.MidiNoteNumber,
+					"velocity": note.Velocity,
+					"start":    note.StartBeats,
+					"length":   note.DurationBeats,
+				}
+			}
+			action := map[string]any{
+				"action": "set_clip_notes",
+				"track":  trackIndex,
+				"notes":  notesArray,
+			}
+			if position, ok := getFloat(clip, "position"); ok {
+				action["position"] = position
+			} else if clipIndex, ok := getInt(clip, "index"); ok {
+				action["clip"] = clipIndex
+			}
+			actions = append(actions, action)
+		}
+	}
+
+	log.Printf("🎵 Transformed notes of %d selected clips", len(actions))
+	return actions
+}
+
+// sectionClipActions turns the arranger's section actions into a create_clip_at_bar plus an
+// add_midi per clip, so one request can fill several tracks. Quantize/humanize apply to each clip.
+// Arrangement actions become their regions and empty clips; see arrangementActions.
+// Example: section(name="Chorus", bars=8, tracks=[{track=0, progression=[C, Am, F, G]}, {track=1, drum_pattern="house"}])
+func sectionClipActions(arrangerActions []map[string]any, state map[string]any) []map[string]any {
+	var actions []map[string]any
+	for _, action := range arrangerActions {
+		if action["type"] == "arrangement" {
+			actions = append(actions, arrangementActions(action)...)
+			continue
+		}
+		if action["type"] != "section" {
+			continue
+		}
+		if _, hasBPM := action["bpm"]; !hasBPM {
+			if bpm := getProjectBPM(state); bpm > 0 {
+				withTempo := make(map[string]any, len(action)+1)
+				for k, v := range action {
+					withTempo[k] = v
+				}
+				withTempo["bpm"] = bpm
+				action = withTempo
+			}
+		}
+
+		clips, err := arranger.ConvertSectionToClips(action)
+		if err != nil {
+			log.Printf("⚠️ Failed to convert arranger section: %v", err)
+			continue
+		}
+		for _, clip := range clips {
+			noteEvents := applyArrangerTransforms(clip.Notes, arrangerActions, state)
+			notesArray := make([]map[string]any, len(noteEvents))
+			for i, note := range noteEvents {
+				notesArray[i] = map[string]any{
+					"pitch":    note.MidiNoteNumber,
+					"velocity": note.Velocity,
+					"start":    note.StartBeats,
+					"length":   note.DurationBeats,
+				}
+			}
+			actions = append(actions,
+				map[string]any{
+					"action":      "create_clip_at_bar",
+					"track":       clip.Track,
+					"bar":         clip.Bar,
+					"length_bars": clip.LengthBars,
+				},
+				map[string]any{
+					"action": "add_midi",
+					"track":  clip.Track,
+					"notes":  notesArray,
+					"name":   clip.Name,
+				},
+			)
+		}
+	}
+	return actions
+}
+
+// arrangementActions turns an arrangement action into an add_region per section, then an empty
+// create_clip_at_bar per section on each of its tracks for the arranger to fill later.
+// Example: arrangement(genre="edm", tracks=[0, 1])
+func arrangementActions(action map[string]any) []map[string]any {
+	sections, _ := action["sections"].([]arranger.ArrangementSection)
+	tracks, _ := action["tracks"].([]int)
+
+	actions := make([]map[string]any, 0, len(sections)*(len(tracks)+1))
+	for _, section := range sections {
+		actions = append(actions, map[string]any{
+			"action":    "add_region",
+			"start_bar": section.Bar,
+			"end_bar":   section.Bar + section.Bars,
+			"name":      section.Name,
+		})
+	}
+	for _, track := range tracks {
+		for _, section := range sections {
+			actions = append(actions, map[string]any{
+				"action":      "create_clip_at_bar",
+				"track":       track,
+				"bar":         section.Bar,
+				"length_bars": section.Bars,
+			})
+		}
+	}
+	log.Printf("🎼 Arrangement %v: %d regions, %d clips", action["genre"], len(sections), len(sections)*len(tracks))
+	return actions
+}
+
+// stateMaps returns the maps in a state list, which may be []any or []map[string]any
+func stateMaps(value any) []map[string]any {
+	switch list := value.(type) {
+	case []map[string]any:
+		return list
+	case []any:
+		maps := make([]map[string]any, 0, len(list))
+		for _, item := range list {
+			if m, ok := item.(map[string]any); ok {
+				maps = append(maps, m)
+			}
+		}
+		return maps
+	}
+	return nil
+}
+
+// getProjectBPM returns the project tempo from state, or 0 when unknown
+func getProjectBPM(state map[string]any) float64 {
+	project, ok := state["project"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	bpm, _ := getFloat(project, "bpm")
+	return bpm
+}
+
 // getTrackCount extracts the number of tracks from the REAPER state
 func getTrackCount(state map[string]any) int {
 	if state == nil {
@@ -779,14 +1516,17 @@ func getTrackCount(state map[string]any) int {
 }
 
 // generateClipName creates a descriptive name from arranger actions
-// e.g., "Em Arpeggio", "C Chord", "E1 Note", "C-Am-F-G Progression"
+// e.g., "Em Arpeggio", "C Chord", "E1 Note", "C-Am-F-G Progression", "Dm7-G7-Cmaj7 Walking Bass", "House Drums"
 func generateClipName(arrangerActions []map[string]any) string {
 	if len(arrangerActions) == 0 {
 		return ""
 	}
 
-	// Use the first action to generate the name
+	// Use the first action to generate the name, past any defaults() call
 	action := arrangerActions[0]
+	if action["type"] == "set_defaults" && len(arrangerActions) > 1 {
+		action = arrangerActions[1]
+	}
 	actionType, _ := action["type"].(string)
 
 	switch actionType {
@@ -802,6 +1542,10 @@ func generateClipName(arrangerActions []map[string]any) string {
 		if pitch, ok := action["pitch"].(string); ok {
 			return pitch + " Note"
 		}
+	case "drum_pattern":
+		if style, ok := action["style"].(string); ok && style != "" {
+			return strings.ToUpper(style[:1]) + style[1:] + " Drums"
+		}
 	case "progression":
 		if chords, ok := action["chords"].([]string); ok && len(chords) > 0 {
 			// Join chords with dashes, e.g., "C-Am-F-G"
@@ -827,6 +1571,10 @@ func generateClipName(arrangerActions []map[string]any) string {
 			}
 			return name + " Progression"
 		}
+	case "walking_bass":
+		if chords, ok := action["chords"].([]string); ok && len(chords) > 0 {
+			return strings.Join(chords, "-") + " Walking Bass"
+		}
 	}
 
 	return ""
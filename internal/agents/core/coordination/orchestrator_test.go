@@ -0,0 +1,226 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/agents/shared/drummer"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/simulator"
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArrangerTargetTrack(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []map[string]any
+		want    int
+	}{
+		{
+			name: "clip on the created track, then a fade",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 2, "instrument": "Serum"},
+				{"action": "create_clip_at_bar", "track": 2, "bar": 1, "length_bars": 4},
+				{"action": "add_automation", "track": 2, "param": "volume", "curve": "fade_in"},
+			},
+			want: 2,
+		},
+		{
+			name: "created track without a clip",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 3},
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+			want: 3,
+		},
+		{
+			name:    "existing track only",
+			actions: []map[string]any{{"action": "set_track", "track": 1, "volume_db": -3.0}},
+			want:    1,
+		},
+		{
+			name:    "no track",
+			actions: []map[string]any{{"action": "set_tempo", "bpm": 120}},
+			want:    -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, arrangerTargetTrack(tt.actions))
+		})
+	}
+}
+
+func TestInsertAfterClip(t *testing.T) {
+	midi := map[string]any{"action": "add_midi", "track": 0}
+
+	actions := insertAfterClip([]map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "create_clip_at_bar", "track": 0, "bar": 1},
+		{"action": "add_automation", "track": 0, "curve": "fade_in"},
+	}, 0, midi)
+	assert.Equal(t, []map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "create_clip_at_bar", "track": 0, "bar": 1},
+		midi,
+		{"action": "add_automation", "track": 0, "curve": "fade_in"},
+	}, actions)
+
+	// No clip on the track: appended
+	actions = insertAfterClip([]map[string]any{{"action": "create_track", "index": 0}}, 0, midi)
+	assert.Equal(t, []map[string]any{{"action": "create_track", "index": 0}, midi}, actions)
+}
+
+func TestAgentPlanQuestions(t *testing.T) {
+	question := "create a synth track with an E-minor arpeggio and fade it in"
+
+	plan := &AgentPlan{NeedsArranger: true, ArrangerTask: "an E-minor arpeggio"}
+	assert.Equal(t, "an E-minor arpeggio", plan.arrangerQuestion(question))
+	assert.Equal(t, question, plan.drummerQuestion(question))
+
+	// Without sub-tasks each agent gets the whole request
+	plan = &AgentPlan{NeedsArranger: true, NeedsDrummer: true, DrummerTask: "  "}
+	assert.Equal(t, question, plan.arrangerQuestion(question))
+	assert.Equal(t, question, plan.drummerQuestion(question))
+}
+
+func TestApplyGrammarVersion(t *testing.T) {
+	newResult := func() *OrchestratorResult {
+		return &OrchestratorResult{Actions: []map[string]any{
+			{"action": "create_track", "index": 0},
+			{"action": "add_region", "start_bar": 1, "end_bar": 9, "name": "Verse"},
+		}}
+	}
+
+	result := newResult()
+	applyGrammarVersion(context.Background(), result)
+	assert.Equal(t, daw.CurrentGrammarVersion, result.GrammarVersion)
+	assert.Len(t, result.Actions, 2)
+	assert.Empty(t, result.Warnings)
+
+	// A v1 client gets the section's region left out, with a warning
+	result = newResult()
+	applyGrammarVersion(daw.WithGrammarVersion(context.Background(), daw.GrammarV1), result)
+	assert.Equal(t, daw.GrammarV1, result.GrammarVersion)
+	assert.Equal(t, []map[string]any{{"action": "create_track", "index": 0}}, result.Actions)
+	if assert.Len(t, result.Warnings, 1) {
+		assert.Equal(t, daw.WarningUnsupportedAction, result.Warnings[0].Code)
+		assert.Equal(t, 1, result.Warnings[0].ActionIndex)
+	}
+}
+
+func TestApplyActionSchema(t *testing.T) {
+	result := &OrchestratorResult{Actions: []map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "set_track", "track": 0, "mute": "yes"},
+		{"action": "set_track", "track": 0, "mute": true},
+	}}
+	applyActionSchema(context.Background(), result)
+	assert.Equal(t, []map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "set_track", "track": 0, "mute": true},
+	}, result.Actions)
+	if assert.Len(t, result.Warnings, 1) {
+		assert.Equal(t, daw.WarningInvalidAction, result.Warnings[0].Code)
+		assert.Equal(t, 1, result.Warnings[0].ActionIndex)
+	}
+}
+
+func TestTransformStateClipNotes_Groove(t *testing.T) {
+	hats := []any{
+		map[string]any{"pitch": 42, "velocity": 100, "start": 0.0, "length": 0.25},
+		map[string]any{"pitch": 42, "velocity": 100, "start": 0.5, "length": 0.25},
+	}
+	state := map[string]any{"tracks": []any{
+		map[string]any{"index": 0, "name": "Hats", "clips": []any{
+			map[string]any{"index": 0, "position": 4.0, "selected": true, "notes": hats},
+		}},
+		map[string]any{"index": 1, "name": "Keys", "clips": []any{
+			map[string]any{"index": 0, "position": 0.0, "notes": hats},
+		}},
+	}}
+
+	actions := transformStateClipNotes([]map[string]any{{"type": "groove", "template": "swing", "amount": 1.0}}, state)
+	if assert.Len(t, actions, 1) {
+		assert.Equal(t, "set_clip_notes", actions[0]["action"])
+		assert.Equal(t, 4.0, actions[0]["position"])
+		notes := actions[0]["notes"].([]map[string]any)
+		assert.InDelta(t, 0.67, notes[1]["start"], 1e-9)
+		assert.Equal(t, 70, notes[1]["velocity"])
+	}
+
+	// A groove taken from a track without notes changes nothing
+	actions = transformStateClipNotes([]map[string]any{{"type": "groove", "from_track": 5}}, state)
+	assert.Empty(t, actions)
+}
+
+func TestSimulateActions(t *testing.T) {
+	state := map[string]any{"tracks": []any{
+		map[string]any{"index": 0, "name": "Drums", "fx": []any{map[string]any{"name": "ReaComp"}}},
+	}}
+	newResult := func() *OrchestratorResult {
+		return &OrchestratorResult{Actions: []map[string]any{
+			{"action": "remove_fx", "track": 0, "fxname": "ReaComp"},
+			{"action": "remove_fx", "track": 0, "fxname": "ReaComp"},
+		}}
+	}
+
+	// The second removal finds nothing to remove; both actions are kept
+	result := newResult()
+	simulateActions(context.Background(), result, state)
+	assert.Len(t, result.Actions, 2)
+	if assert.Len(t, result.Warnings, 1) {
+		assert.Equal(t, simulator.WarningInconsistentAction, result.Warnings[0].Code)
+		assert.Equal(t, 1, result.Warnings[0].ActionIndex)
+	}
+
+	// A stale state is left to its stale_track_index warnings
+	result = newResult()
+	result.Warnings = []models.ActionWarning{{Code: daw.WarningStaleTrackIndex}}
+	simulateActions(context.Background(), result, state)
+	assert.Len(t, result.Warnings, 1)
+}
+
+func TestWithLanguage(t *testing.T) {
+	o := &Orchestrator{}
+	ctx := o.withLanguage(context.Background(), "erstelle eine Spur mit Serum")
+	assert.Equal(t, daw.LanguageGerman, daw.LanguageFromContext(ctx))
+	ctx = o.withLanguage(context.Background(), "create a track with Serum")
+	assert.Equal(t, daw.LanguageEnglish, daw.LanguageFromContext(ctx))
+
+	// A pinned language applies whatever the request looks like
+	o = &Orchestrator{language: daw.LanguageSpanish}
+	ctx = o.withLanguage(context.Background(), "Serum")
+	assert.Equal(t, daw.LanguageSpanish, daw.LanguageFromContext(ctx))
+}
+
+func TestPlanAgentsPrompt(t *testing.T) {
+	prompt := planAgentsPrompt("add 60% swing to the hats")
+
+	assert.Contains(t, prompt, `REQUEST: "add 60% swing to the hats"`)
+	assert.Contains(t, prompt, `"add 55% swing to the hats"`)
+	assert.NotContains(t, prompt, "%!")
+}
+
+func TestMergeResultsSumsAgentUsage(t *testing.T) {
+	o := &Orchestrator{}
+	dawResult := &daw.DawResult{
+		Actions: []map[string]any{{"action": "create_track", "index": 0}},
+		Usage:   responses.ResponseUsage{InputTokens: 1000, OutputTokens: 200, TotalTokens: 1200},
+	}
+	arrangerResult := &ArrangerResult{Usage: map[string]any{"input_tokens": 500.0, "output_tokens": 100.0, "total_tokens": 600.0}}
+	drummerResult := &drummer.DrummerResult{Usage: responses.ResponseUsage{InputTokens: 300, OutputTokens: 50, TotalTokens: 350}}
+
+	result, err := o.mergeResults(context.Background(), dawResult, arrangerResult, drummerResult, map[string]any{})
+	assert.NoError(t, err)
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 1800, OutputTokens: 350, TotalTokens: 2150}, result.Usage)
+
+	// A clarification still bills the agents that ran
+	dawResult.Clarification = &models.Clarification{Question: "Which track?"}
+	result, err = o.mergeResults(context.Background(), dawResult, nil, drummerResult, map[string]any{})
+	assert.NoError(t, err)
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 1300, OutputTokens: 250, TotalTokens: 1550}, result.Usage)
+}
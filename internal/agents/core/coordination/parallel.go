@@ -0,0 +1,76 @@
+package coordination
+
+import (
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/observability"
+)
+
+// maxParallelAgents bounds the LLM calls one request runs at a time: the DAW sub-tasks plus
+// the arranger and drummer
+const maxParallelAgents = 4
+
+// mergeDawResults combines the results of independent DAW sub-tasks in task order, so the
+// merged plan doesn't depend on which call finished first. Undo actions are combined in
+// reverse, since the last sub-task's actions are undone first. A clarification from any
+// sub-task is returned on its own: nothing is applied until the user answers. Usage is
+// always summed over every sub-task, since each call was paid for.
+func mergeDawResults(results []*daw.DawResult) *daw.DawResult {
+	if len(results) == 1 {
+		return results[0]
+	}
+	usages := make([]any, len(results))
+	for i, result := range results {
+		usages[i] = result.Usage
+	}
+	usage := observability.SumUsage(usages...)
+	for _, result := range results {
+		if result.Clarification != nil {
+			clarification := *result
+			clarification.Usage = usage
+			return &clarification
+		}
+	}
+
+	merged := &daw.DawResult{
+		Actions:     []map[string]any{},
+		UndoActions: []map[string]any{},
+		Usage:       usage,
+	}
+	var answers, scripts []string
+	for _, result := range results {
+		merged.Actions = append(merged.Actions, result.Actions...)
+		merged.Warnings = append(merged.Warnings, result.Warnings...)
+		merged.FilterSummaries = append(merged.FilterSummaries, result.FilterSummaries...)
+		merged.Repairs = append(merged.Repairs, result.Repairs...)
+		merged.Answers = append(merged.Answers, result.Answers...)
+		if result.Answer != "" {
+			answers = append(answers, result.Answer)
+		}
+		if result.DSL != "" {
+			scripts = append(scripts, result.DSL)
+		}
+	}
+	for i := len(results) - 1; i >= 0; i-- {
+		merged.UndoActions = append(merged.UndoActions, results[i].UndoActions...)
+	}
+	merged.Answer = strings.Join(answers, "\n")
+	merged.DSL = strings.Join(scripts, "\n")
+	return merged
+}
+
+// createsTracksInSeveral reports whether more than one sub-task result creates tracks. Each
+// call numbers new tracks from the project's track count, so their indices would collide.
+func createsTracksInSeveral(results []*daw.DawResult) bool {
+	creating := 0
+	for _, result := range results {
+		for _, action := range result.Actions {
+			if action["action"] == "create_track" {
+				creating++
+				break
+			}
+		}
+	}
+	return creating > 1
+}
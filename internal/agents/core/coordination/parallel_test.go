@@ -0,0 +1,63 @@
+package coordination
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeDawResults(t *testing.T) {
+	rename := &daw.DawResult{
+		Actions:     []map[string]any{{"action": "set_track", "track": 0, "name": "Bass"}},
+		UndoActions: []map[string]any{{"action": "set_track", "track": 0, "name": "Track 1"}},
+		Warnings:    []models.ActionWarning{{Message: "rename"}},
+		Usage:       responses.ResponseUsage{InputTokens: 1000, OutputTokens: 100, TotalTokens: 1100},
+	}
+	marker := &daw.DawResult{
+		Actions:     []map[string]any{{"action": "add_marker", "bar": 17, "name": "Chorus"}},
+		UndoActions: []map[string]any{{"action": "delete_marker", "bar": 17}},
+		Answer:      "1 marker",
+		Usage:       responses.ResponseUsage{InputTokens: 800, OutputTokens: 50, TotalTokens: 850},
+	}
+
+	merged := mergeDawResults([]*daw.DawResult{rename, marker})
+	assert.Equal(t, []map[string]any{
+		{"action": "set_track", "track": 0, "name": "Bass"},
+		{"action": "add_marker", "bar": 17, "name": "Chorus"},
+	}, merged.Actions)
+	// The last sub-task is undone first
+	assert.Equal(t, []map[string]any{
+		{"action": "delete_marker", "bar": 17},
+		{"action": "set_track", "track": 0, "name": "Track 1"},
+	}, merged.UndoActions)
+	assert.Equal(t, []models.ActionWarning{{Message: "rename"}}, merged.Warnings)
+	assert.Equal(t, "1 marker", merged.Answer)
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 1800, OutputTokens: 150, TotalTokens: 1950}, merged.Usage)
+
+	// A single result is returned as is
+	assert.Same(t, rename, mergeDawResults([]*daw.DawResult{rename}))
+
+	// A clarification holds back every sub-task, but their usage still counts
+	clarify := &daw.DawResult{
+		Clarification: &models.Clarification{Question: "Which track?"},
+		Usage:         responses.ResponseUsage{InputTokens: 700, OutputTokens: 30, TotalTokens: 730},
+	}
+	merged = mergeDawResults([]*daw.DawResult{rename, clarify})
+	assert.Equal(t, clarify.Clarification, merged.Clarification)
+	assert.Empty(t, merged.Actions)
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 1700, OutputTokens: 130, TotalTokens: 1830}, merged.Usage)
+}
+
+func TestCreatesTracksInSeveral(t *testing.T) {
+	create := &daw.DawResult{Actions: []map[string]any{
+		{"action": "create_track", "index": 2},
+		{"action": "create_track", "index": 3},
+	}}
+	rename := &daw.DawResult{Actions: []map[string]any{{"action": "set_track", "track": 0, "name": "Bass"}}}
+
+	assert.False(t, createsTracksInSeveral([]*daw.DawResult{create, rename}))
+	assert.True(t, createsTracksInSeveral([]*daw.DawResult{create, rename, create}))
+}
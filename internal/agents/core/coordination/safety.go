@@ -0,0 +1,76 @@
+package coordination
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// applySafetyPolicy holds back result's actions when they delete more than the request's
+// safety policy allows, reporting why in result.ConfirmationRequired
+func applySafetyPolicy(ctx context.Context, result *OrchestratorResult, state map[string]any) {
+	confirmation := daw.CheckSafety(result.Actions, state, daw.SafetyPolicyFromContext(ctx))
+	if confirmation == nil {
+		return
+	}
+	logger.Printf(ctx, "🛑 Safety policy: %s", confirmation.Reason)
+	result.ConfirmationRequired = confirmation
+	result.Actions = []map[string]any{}
+	result.UndoActions = nil
+}
+
+// safetyGuard holds back streamed actions from the first deletion on until the safety policy
+// has seen the whole batch, since the client applies streamed actions as they arrive. Actions
+// before the first deletion stream as usual.
+type safetyGuard struct {
+	mu       sync.Mutex
+	callback StreamActionCallback
+	holding  bool
+	held     []map[string]any
+	sent     []map[string]any
+}
+
+// guardStream wraps callback in a safetyGuard when the request's safety policy can hold back
+// actions, and returns nil otherwise
+func guardStream(ctx context.Context, callback StreamActionCallback) *safetyGuard {
+	if callback == nil || !daw.SafetyPolicyFromContext(ctx).Active() {
+		return nil
+	}
+	return &safetyGuard{callback: callback}
+}
+
+// emit sends action, or holds it once a deletion has been seen
+func (g *safetyGuard) emit(action map[string]any) error {
+	g.mu.Lock()
+	g.holding = g.holding || daw.IsGuardedDeletion(action)
+	if g.holding {
+		g.held = append(g.held, action)
+		g.mu.Unlock()
+		return nil
+	}
+	g.sent = append(g.sent, action)
+	g.mu.Unlock()
+	return g.callback(action)
+}
+
+// release sends the held actions once applySafetyPolicy let result through. Otherwise they are
+// dropped, and result keeps the actions already sent, which the client has applied.
+func (g *safetyGuard) release(ctx context.Context, result *OrchestratorResult) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if result.ConfirmationRequired != nil {
+		logger.Printf(ctx, "🛑 [Stream] Safety policy: held back %d actions", len(g.held))
+		result.Actions = append([]map[string]any{}, g.sent...)
+		g.held = nil
+		return nil
+	}
+	for _, action := range g.held {
+		if err := g.callback(action); err != nil {
+			return err
+		}
+	}
+	g.held = nil
+	return nil
+}
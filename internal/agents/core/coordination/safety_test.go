@@ -0,0 +1,82 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySafetyPolicy(t *testing.T) {
+	state := map[string]any{"tracks": []any{
+		map[string]any{"index": 0, "name": "Drums"},
+		map[string]any{"index": 1, "name": "Bass"},
+	}}
+	newResult := func() *OrchestratorResult {
+		return &OrchestratorResult{
+			Actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "delete_track", "track": 0},
+			},
+			UndoActions: []map[string]any{{"action": "create_track", "index": 0}},
+		}
+	}
+
+	// Without a policy the actions go through
+	result := newResult()
+	applySafetyPolicy(context.Background(), result, state)
+	assert.Nil(t, result.ConfirmationRequired)
+	assert.Len(t, result.Actions, 2)
+
+	// Deleting every track asks for confirmation instead
+	ctx := daw.WithSafetyPolicy(context.Background(), daw.SafetyPolicy{Mode: daw.SafetyConfirm})
+	result = newResult()
+	applySafetyPolicy(ctx, result, state)
+	if assert.NotNil(t, result.ConfirmationRequired) {
+		assert.True(t, result.ConfirmationRequired.ClearsProject)
+		assert.Equal(t, 2, result.ConfirmationRequired.DeletedTracks)
+	}
+	assert.Empty(t, result.Actions)
+	assert.Empty(t, result.UndoActions)
+}
+
+func TestSafetyGuard(t *testing.T) {
+	assert.Nil(t, guardStream(context.Background(), func(map[string]any) error { return nil }))
+
+	ctx := daw.WithSafetyPolicy(context.Background(), daw.SafetyPolicy{Mode: daw.SafetyConfirm})
+	actions := []map[string]any{
+		{"action": "set_track", "track": 1, "mute": true},
+		{"action": "delete_track", "track": 0},
+		{"action": "set_track", "track": 0, "solo": true},
+	}
+	stream := func(confirmation bool) ([]map[string]any, *OrchestratorResult) {
+		var sent []map[string]any
+		guard := guardStream(ctx, func(action map[string]any) error {
+			sent = append(sent, action)
+			return nil
+		})
+		for _, action := range actions {
+			assert.NoError(t, guard.emit(action))
+		}
+		// Nothing from the first deletion on is sent before the policy has seen the batch
+		assert.Equal(t, actions[:1], sent)
+
+		result := &OrchestratorResult{Actions: actions}
+		if confirmation {
+			applySafetyPolicy(ctx, result, map[string]any{"tracks": []any{map[string]any{"index": 0}}})
+		}
+		assert.NoError(t, guard.release(ctx, result))
+		return sent, result
+	}
+
+	sent, result := stream(false)
+	assert.Equal(t, actions, sent)
+	assert.Equal(t, actions, result.Actions)
+
+	// Held back: the result keeps only what the client already received
+	sent, result = stream(true)
+	assert.Equal(t, actions[:1], sent)
+	assert.Equal(t, actions[:1], result.Actions)
+	assert.NotNil(t, result.ConfirmationRequired)
+}
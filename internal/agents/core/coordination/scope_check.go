@@ -0,0 +1,163 @@
+package coordination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// Scope check modes (cfg.ScopeCheck)
+const (
+	ScopeCheckOff   = "off"
+	ScopeCheckLocal = "local" // Keyword lists only, no LLM call
+	ScopeCheckLLM   = "llm"   // Keyword lists, then a classification call when they don't decide
+)
+
+// scopeCheckModel classifies requests the keyword lists can't decide
+const scopeCheckModel = "gpt-4.1-mini"
+
+// outOfScopeReason is reported with the topic of a rejected request, in the wording the DAW
+// prompt uses for its // ERROR comments
+const outOfScopeReason = "This request is out of scope. MAGDA only handles music production and REAPER/DAW operations, not %s."
+
+// musicTerms mark a request as music production; one is enough to let it through
+var musicTerms = regexp.MustCompile(`(?i)\b(?:tracks?|clips?|fx|plugins?|reverb|delay|eq|compress(?:or|ion)|limiter|` +
+	`synths?|drums?|bass|guitars?|piano|vocals?|beats?|loops?|chords?|melody|notes?|arpeggios?|tempo|bpm|bars?|` +
+	`mute|solo|pan|volume|mix(?:ing)?|master(?:ing)?|render|bounce|markers?|regions?|automat\w*|fades?|` +
+	`bus(?:es)?|midi|audio|reaper|daw|kick|snare|hi-?hats?|samples?|record(?:ing)?|song|music|sounds?|scale|` +
+	`freeze|folders?|groove|patterns?|verse|chorus|intro|outro)\b`)
+
+// offTopicRules are requests MAGDA never handles, by topic
+var offTopicRules = []struct {
+	pattern *regexp.Regexp
+	topic   string
+}{
+	{regexp.MustCompile(`(?i)\b(?:weather|forecast|raining|sunny|snowing)\b`), "weather"},
+	{regexp.MustCompile(`(?i)\b(?:recipes?|bake|baking|cook|cooking|dinner|lunch|breakfast)\b`), "cooking"},
+	{regexp.MustCompile(`(?i)\b(?:e-?mails?|sms|text message|whatsapp)\b`), "messaging"},
+	{regexp.MustCompile(`(?i)\b(?:stocks?|stock price|bitcoin|crypto|exchange rate|invest(?:ing|ment)?)\b`), "finance"},
+	{regexp.MustCompile(`(?i)\b(?:news|headlines|election)\b`), "news"},
+	{regexp.MustCompile(`(?i)\b(?:flights?|hotels?|restaurants?|book a table|taxi)\b`), "travel and bookings"},
+	{regexp.MustCompile(`(?i)\b(?:jokes?|homework|essay|poem)\b`), "general questions"},
+}
+
+// ScopeChecker rejects requests unrelated to music production before the planning and DSL
+// calls. It fails open: a request it can't decide, or a failed classification call, goes
+// through, and the DAW model's // ERROR comments stay the backstop.
+type ScopeChecker struct {
+	mode     string
+	provider llm.Provider
+}
+
+// NewScopeChecker creates the checker for mode ("off", "local" or "llm"). Unknown modes use
+// "local".
+func NewScopeChecker(mode string, provider llm.Provider) *ScopeChecker {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case ScopeCheckOff, ScopeCheckLLM:
+	default:
+		mode = ScopeCheckLocal
+	}
+	return &ScopeChecker{mode: mode, provider: provider}
+}
+
+// Check returns why question is out of scope, or nil to let it through, and the usage of the
+// classification call if one was made
+func (s *ScopeChecker) Check(ctx context.Context, question string) (*models.OutOfScope, any) {
+	if s == nil || s.mode == ScopeCheckOff {
+		return nil, nil
+	}
+	if outOfScope, decided := localScopeCheck(question); decided {
+		if outOfScope != nil {
+			logger.Printf(ctx, "🚫 Scope check (local): %s", outOfScope.Reason)
+		}
+		return outOfScope, nil
+	}
+	if s.mode != ScopeCheckLLM || s.provider == nil {
+		return nil, nil
+	}
+	outOfScope, usage, err := s.classify(ctx, question)
+	if err != nil {
+		logger.Printf(ctx, "⚠️ Scope check failed, letting the request through: %v", err)
+		return nil, usage
+	}
+	if outOfScope != nil {
+		logger.Printf(ctx, "🚫 Scope check (llm): %s", outOfScope.Reason)
+	}
+	return outOfScope, usage
+}
+
+// localScopeCheck decides from keywords: music terms let a request through, and off-topic
+// terms reject it. It reports false when neither matches. The lists are English; other
+// languages are left to the classification call or the DAW model.
+func localScopeCheck(question string) (*models.OutOfScope, bool) {
+	if musicTerms.MatchString(question) {
+		return nil, true
+	}
+	for _, rule := range offTopicRules {
+		if rule.pattern.MatchString(question) {
+			return &models.OutOfScope{Reason: fmt.Sprintf(outOfScopeReason, rule.topic), Check: ScopeCheckLocal}, true
+		}
+	}
+	return nil, false
+}
+
+// classify asks a small model whether question is about music production
+func (s *ScopeChecker) classify(ctx context.Context, question string) (*models.OutOfScope, any, error) {
+	prompt := fmt.Sprintf(`You screen requests for MAGDA, an assistant for music production in the REAPER DAW: tracks, clips,
+FX, mixing, automation, MIDI, chords, drums, arrangement, rendering and questions about the project.
+Requests may be in any language.
+
+Answer inScope=false only when the request is clearly unrelated to music production, and set
+topic to what it is about in a few English words (e.g. "weather", "cooking"). Otherwise answer
+inScope=true with an empty topic.
+
+REQUEST: "%s"
+
+Return JSON: {"inScope": bool, "topic": string}`, question)
+
+	request := &llm.GenerationRequest{
+		Model:         scopeCheckModel,
+		InputArray:    []map[string]any{{"role": "user", "content": prompt}},
+		ReasoningMode: "none",
+		OutputSchema: &llm.OutputSchema{
+			Name:        "ScopeCheck",
+			Description: "Whether a request is about music production",
+			Schema: map[string]any{
+				"type":                 "object",
+				"additionalProperties": false,
+				"properties": map[string]any{
+					"inScope": map[string]any{"type": "boolean"},
+					"topic":   map[string]any{"type": "string"},
+				},
+				"required": []string{"inScope", "topic"},
+			},
+		},
+	}
+
+	resp, err := s.provider.Generate(ctx, request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("scope classification failed: %w", err)
+	}
+	result := struct {
+		InScope bool   `json:"inScope"`
+		Topic   string `json:"topic"`
+	}{}
+	if err := json.Unmarshal([]byte(resp.RawOutput), &result); err != nil {
+		return nil, resp.Usage, fmt.Errorf("failed to parse scope classification: %w", err)
+	}
+	if result.InScope {
+		return nil, resp.Usage, nil
+	}
+	topic := strings.TrimSpace(result.Topic)
+	if topic == "" {
+		topic = "general questions"
+	}
+	return &models.OutOfScope{Reason: fmt.Sprintf(outOfScopeReason, topic), Check: ScopeCheckLLM}, resp.Usage, nil
+}
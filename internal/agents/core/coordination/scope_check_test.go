@@ -0,0 +1,80 @@
+package coordination
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScopeChecker_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       string
+		question   string
+		responses  []llm.MockResponse
+		wantReason string // Empty when the request goes through
+		wantCheck  string
+		wantCalls  int
+	}{
+		{
+			name: "weather", mode: ScopeCheckLocal, question: "what's the weather today?",
+			wantReason: "This request is out of scope. MAGDA only handles music production and REAPER/DAW operations, not weather.",
+			wantCheck:  ScopeCheckLocal,
+		},
+		{
+			name: "cooking", mode: ScopeCheckLocal, question: "bake me a cake",
+			wantReason: "This request is out of scope. MAGDA only handles music production and REAPER/DAW operations, not cooking.",
+			wantCheck:  ScopeCheckLocal,
+		},
+		{name: "music term wins", mode: ScopeCheckLocal, question: "cook up a funky beat"},
+		{name: "DAW request", mode: ScopeCheckLocal, question: "add reverb to track 1"},
+		{name: "undecided goes through without a call", mode: ScopeCheckLocal, question: "what is 2+2?"},
+		{name: "off", mode: ScopeCheckOff, question: "what's the weather today?"},
+		{
+			name: "classification call rejects", mode: ScopeCheckLLM, question: "what is 2+2?",
+			responses:  []llm.MockResponse{{Tool: "ScopeCheck", Output: `{"inScope": false, "topic": "arithmetic"}`}},
+			wantReason: "This request is out of scope. MAGDA only handles music production and REAPER/DAW operations, not arithmetic.",
+			wantCheck:  ScopeCheckLLM,
+			wantCalls:  1,
+		},
+		{
+			name: "classification call accepts", mode: ScopeCheckLLM, question: "erstelle eine Spur mit Serum",
+			wantCalls: 1,
+		},
+		{
+			name: "keywords skip the call", mode: ScopeCheckLLM, question: "mute the drums",
+		},
+		{
+			name: "failed call goes through", mode: ScopeCheckLLM, question: "what is 2+2?",
+			responses: []llm.MockResponse{{Tool: "ScopeCheck", Output: `not json`}},
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := llm.NewMockProvider(tt.responses...)
+			checker := NewScopeChecker(tt.mode, provider)
+
+			outOfScope, _ := checker.Check(context.Background(), tt.question)
+			assert.Len(t, provider.Requests(), tt.wantCalls)
+			if tt.wantReason == "" {
+				assert.Nil(t, outOfScope)
+				return
+			}
+			if assert.NotNil(t, outOfScope) {
+				assert.Equal(t, tt.wantReason, outOfScope.Reason)
+				assert.Equal(t, tt.wantCheck, outOfScope.Check)
+			}
+		})
+	}
+}
+
+func TestScopeChecker_NilIsOff(t *testing.T) {
+	var checker *ScopeChecker
+	outOfScope, usage := checker.Check(context.Background(), "what's the weather today?")
+	assert.Nil(t, outOfScope)
+	assert.Nil(t, usage)
+}
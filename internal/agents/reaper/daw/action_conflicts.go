@@ -0,0 +1,105 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// WarningConflictingAction is the warning code for an action that targets something
+// an earlier action in the same script already deleted.
+const WarningConflictingAction = "conflicting_action"
+
+// DetectActionConflicts runs a post-parse validation pass over translated actions and
+// flags actions that modify a track or clip after an earlier action deleted it.
+// Order matters: modify-then-delete and create-then-modify are legitimate sequences,
+// and re-creating a track at a deleted index makes later actions on it valid again.
+func DetectActionConflicts(actions []map[string]any) []models.ActionWarning {
+	var warnings []models.ActionWarning
+	deletedTracks := make(map[int]int)   // track index -> index of deleting action
+	deletedClips := make(map[string]int) // clip key -> index of deleting action
+
+	for i, action := range actions {
+		actionType, _ := action["action"].(string)
+
+		if actionType == "create_track" {
+			if trackIndex, ok := actionInt(action, "index"); ok {
+				delete(deletedTracks, trackIndex)
+			}
+			continue
+		}
+
+		trackIndex, ok := actionInt(action, "track")
+		if !ok {
+			continue
+		}
+
+		if deletedAt, deleted := deletedTracks[trackIndex]; deleted {
+			warnings = append(warnings, models.ActionWarning{
+				Code: WarningConflictingAction,
+				Message: fmt.Sprintf("action %d (%s) targets track %d, which was deleted by action %d",
+					i, actionType, trackIndex, deletedAt),
+				ActionIndex: i,
+			})
+			continue
+		}
+
+		switch actionType {
+		case "delete_track":
+			deletedTracks[trackIndex] = i
+		case "delete_clip":
+			if key, ok := clipKey(action, trackIndex); ok {
+				deletedClips[key] = i
+			}
+		case "create_clip", "create_clip_at_bar":
+			if key, ok := clipKey(action, trackIndex); ok {
+				delete(deletedClips, key)
+			}
+		case "set_clip", "set_clip_position":
+			key, ok := clipKey(action, trackIndex)
+			if !ok {
+				continue
+			}
+			if deletedAt, deleted := deletedClips[key]; deleted {
+				warnings = append(warnings, models.ActionWarning{
+					Code: WarningConflictingAction,
+					Message: fmt.Sprintf("action %d (%s) targets clip %s, which was deleted by action %d",
+						i, actionType, key, deletedAt),
+					ActionIndex: i,
+				})
+			}
+		}
+	}
+
+	return warnings
+}
+
+// actionInt reads an integer action field, accepting int or float64.
+func actionInt(action map[string]any, key string) (int, bool) {
+	switch v := action[key].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+// clipKey builds an identifier for the clip an action targets.
+// set_clip_position identifies its clip by old_position rather than position.
+func clipKey(action map[string]any, trackIndex int) (string, bool) {
+	if clip, ok := actionInt(action, "clip"); ok {
+		return fmt.Sprintf("%d on track %d", clip, trackIndex), true
+	}
+	positionKey := "position"
+	if action["action"] == "set_clip_position" {
+		positionKey = "old_position"
+	}
+	if position, ok := action[positionKey].(float64); ok {
+		return fmt.Sprintf("at %gs on track %d", position, trackIndex), true
+	}
+	if bar, ok := actionInt(action, "bar"); ok {
+		return fmt.Sprintf("at bar %d on track %d", bar, trackIndex), true
+	}
+	return "", false
+}
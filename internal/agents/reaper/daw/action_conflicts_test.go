@@ -0,0 +1,133 @@
+package daw
+
+import (
+	"testing"
+)
+
+func TestDetectActionConflicts(t *testing.T) {
+	tests := []struct {
+		name        string
+		actions     []map[string]any
+		wantIndices []int
+	}{
+		{
+			name: "delete then modify track",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "set_track", "track": 1, "name": "Bass"},
+			},
+			wantIndices: []int{1},
+		},
+		{
+			name: "modify then delete track is legitimate",
+			actions: []map[string]any{
+				{"action": "set_track", "track": 1, "name": "Bass"},
+				{"action": "delete_track", "track": 1},
+			},
+			wantIndices: nil,
+		},
+		{
+			name: "create then modify track is legitimate",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 2},
+				{"action": "set_track", "track": 2, "mute": true},
+			},
+			wantIndices: nil,
+		},
+		{
+			name: "recreating a deleted track clears the conflict",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "create_track", "index": 0},
+				{"action": "set_track", "track": 0, "name": "Lead"},
+			},
+			wantIndices: nil,
+		},
+		{
+			name: "any action on a deleted track conflicts",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "add_track_fx", "track": 0, "fxname": "ReaEQ"},
+				{"action": "delete_clip", "track": 0, "clip": 0},
+				{"action": "delete_track", "track": 0},
+			},
+			wantIndices: []int{1, 2, 3},
+		},
+		{
+			name: "different tracks do not conflict",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "set_track", "track": 1, "name": "Keys"},
+			},
+			wantIndices: nil,
+		},
+		{
+			name: "delete then modify clip",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 0, "clip": 2},
+				{"action": "set_clip", "track": 0, "clip": 2, "name": "Intro"},
+				{"action": "set_clip", "track": 0, "clip": 3, "name": "Verse"},
+			},
+			wantIndices: []int{1},
+		},
+		{
+			name: "move clip by old position after delete",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 0, "position": 4.0},
+				{"action": "set_clip_position", "track": 0, "old_position": 4.0, "position": 8.0},
+			},
+			wantIndices: []int{1},
+		},
+		{
+			name: "creating a clip at a deleted bar clears the conflict",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 0, "bar": 3},
+				{"action": "create_clip_at_bar", "track": 0, "bar": 3, "length_bars": 4},
+				{"action": "set_clip", "track": 0, "bar": 3, "name": "New"},
+			},
+			wantIndices: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := DetectActionConflicts(tt.actions)
+			if len(warnings) != len(tt.wantIndices) {
+				t.Fatalf("DetectActionConflicts() returned %d warnings, want %d: %+v", len(warnings), len(tt.wantIndices), warnings)
+			}
+			for i, w := range warnings {
+				if w.Code != WarningConflictingAction {
+					t.Errorf("warning %d code = %q, want %q", i, w.Code, WarningConflictingAction)
+				}
+				if w.ActionIndex != tt.wantIndices[i] {
+					t.Errorf("warning %d action index = %d, want %d", i, w.ActionIndex, tt.wantIndices[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectActionConflicts_FromDSL(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums"},
+		},
+	})
+
+	actions, err := parser.ParseDSL(`track(id=1).delete(); track(id=1).set_track(name="Percussion")`)
+	if err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+
+	warnings := DetectActionConflicts(actions)
+	if len(warnings) != 1 {
+		t.Fatalf("DetectActionConflicts() returned %d warnings, want 1: %+v", len(warnings), warnings)
+	}
+	if warnings[0].ActionIndex != 1 {
+		t.Errorf("warning action index = %d, want 1", warnings[0].ActionIndex)
+	}
+}
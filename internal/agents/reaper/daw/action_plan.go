@@ -0,0 +1,257 @@
+package daw
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// WarningDroppedAction is the warning code for an action left out of the execution plan
+// because the track it targets was deleted earlier in the same script.
+const WarningDroppedAction = "dropped_action"
+
+// planTrackRefKeys are the action fields that hold a track index (dest is a send's destination)
+var planTrackRefKeys = []string{"track", "dest"}
+
+// PlanActions orders translated actions for execution and rewrites their track indices so
+// each one still targets the track it was written for when the actions are applied in order.
+//
+// The parser numbers tracks as if deleted tracks kept their slot, so in a script like
+// "delete track 1, then rename track 3" the rename must target index 2 once track 1 is gone.
+// The planner replays the script against a track list where deletions leave a tombstone:
+// positions in that list are the parser's indices (stable references), and the execution
+// index of a track is the number of live tracks before it. Creates, duplicates and moves
+// shift the list the same way they shift the project. Tracks and clips that have a GUID in
+// state are also referenced by it (track_guid, dest_guid, clip_guid), with the index as fallback.
+//
+// Actions that use a track before the create_track that makes it are moved after that
+// create. Actions on a track deleted earlier in the script are dropped with a warning
+// (DetectActionConflicts reports why). Without deletions, forward references or GUIDs in
+// state the plan is the input unchanged. Warning action indices refer to the input actions.
+func PlanActions(actions []map[string]any, state map[string]any) ([]map[string]any, []models.ActionWarning) {
+	planner := newActionPlanner(actions, state)
+	for i := range actions {
+		planner.add(i)
+	}
+	return planner.finish()
+}
+
+// planTrack is one slot in the planner's track list
+type planTrack struct {
+	state     map[string]any // The track in state, nil for tracks created by the script
+	deleted   bool
+	deletedBy int // Input index of the delete_track action
+}
+
+type actionPlanner struct {
+	actions  []map[string]any
+	tracks   []*planTrack
+	pending  map[int][]int // Script track index -> input indices waiting for its create_track
+	planned  []map[string]any
+	warnings []models.ActionWarning
+}
+
+// newActionPlanner sizes the track list from state. Without a track list in state, every
+// index the script references is assumed to exist.
+func newActionPlanner(actions []map[string]any, state map[string]any) *actionPlanner {
+	trackCount := 0
+	tracks, known := stateTracks(state)
+	if known {
+		trackCount = len(tracks)
+	} else {
+		for _, action := range actions {
+			for _, key := range append([]string{"index", "to"}, planTrackRefKeys...) {
+				if index, ok := actionInt(action, key); ok && index+1 > trackCount {
+					trackCount = index + 1
+				}
+			}
+		}
+	}
+
+	p := &actionPlanner{
+		actions: actions,
+		pending: make(map[int][]int),
+		planned: make([]map[string]any, 0, len(actions)),
+	}
+	p.grow(trackCount)
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index, ok := actionInt(trackMap, "index")
+		if !ok {
+			index = i
+		}
+		if index >= 0 && index < len(p.tracks) {
+			p.tracks[index].state = trackMap
+		}
+	}
+	return p
+}
+
+// add plans the input action at index i
+func (p *actionPlanner) add(i int) {
+	action := p.actions[i]
+	actionType, _ := action["action"].(string)
+
+	// A reference to a track that a later create_track makes waits for that create
+	for _, key := range planTrackRefKeys {
+		index, ok := actionInt(action, key)
+		if ok && index >= len(p.tracks) && actionType != "create_track" && p.createdLater(i, index) {
+			p.pending[index] = append(p.pending[index], i)
+			return
+		}
+	}
+
+	planned := copyAction(action)
+	for _, key := range planTrackRefKeys {
+		index, ok := actionInt(action, key)
+		if !ok || index < 0 {
+			continue
+		}
+		p.grow(index + 1)
+		track := p.tracks[index]
+		if track.deleted {
+			p.warnings = append(p.warnings, models.ActionWarning{
+				Code: WarningDroppedAction,
+				Message: fmt.Sprintf("action %d (%s) was left out: track %d was deleted by action %d",
+					i, actionType, index, track.deletedBy),
+				ActionIndex: i,
+			})
+			return
+		}
+		planned[key] = p.executionIndex(index)
+		if guid, _ := track.state["guid"].(string); guid != "" {
+			planned[key+"_guid"] = guid
+		}
+		if key == "track" && track.state != nil {
+			if guid := clipGUID(track.state, action); guid != "" {
+				planned["clip_guid"] = guid
+			}
+		}
+	}
+
+	switch actionType {
+	case "create_track":
+		index, ok := actionInt(action, "index")
+		if !ok {
+			index = len(p.tracks)
+		}
+		p.grow(index)
+		planned["index"] = p.executionIndex(index)
+		p.insert(index, 1)
+		p.planned = append(p.planned, planned)
+		p.release(index)
+		return
+	case "duplicate_track":
+		if index, ok := actionInt(action, "track"); ok && index >= 0 {
+			count, ok := actionInt(action, "count")
+			if !ok {
+				count = 1
+			}
+			p.insert(index+1, count)
+		}
+	case "move_track":
+		from, okFrom := actionInt(action, "track")
+		to, okTo := actionInt(action, "to")
+		if okFrom && okTo && from >= 0 && to >= 0 {
+			track := p.tracks[from]
+			p.tracks = append(p.tracks[:from], p.tracks[from+1:]...)
+			p.grow(to)
+			planned["to"] = p.executionIndex(to)
+			p.tracks = append(p.tracks[:to], append([]*planTrack{track}, p.tracks[to:]...)...)
+		}
+	case "delete_track":
+		if index, ok := actionInt(action, "track"); ok && index >= 0 {
+			p.tracks[index].deleted = true
+			p.tracks[index].deletedBy = i
+		}
+	}
+	p.planned = append(p.planned, planned)
+}
+
+// finish plans actions still waiting for a create_track (the create didn't make their
+// index after all) in their original order and returns the plan
+func (p *actionPlanner) finish() ([]map[string]any, []models.ActionWarning) {
+	var waiting []int
+	for index, inputs := range p.pending {
+		waiting = append(waiting, inputs...)
+		delete(p.pending, index)
+	}
+	sort.Ints(waiting)
+	for _, i := range waiting {
+		p.addResolved(i)
+	}
+	return p.planned, p.warnings
+}
+
+// addResolved plans an action without deferring it again
+func (p *actionPlanner) addResolved(i int) {
+	for _, key := range planTrackRefKeys {
+		if index, ok := actionInt(p.actions[i], key); ok {
+			p.grow(index + 1)
+		}
+	}
+	p.add(i)
+}
+
+// release plans the actions that were waiting for the track at index
+func (p *actionPlanner) release(index int) {
+	waiting := p.pending[index]
+	delete(p.pending, index)
+	for _, i := range waiting {
+		p.addResolved(i)
+	}
+}
+
+// createdLater reports whether an action after input index i creates a track at index
+func (p *actionPlanner) createdLater(i, index int) bool {
+	for _, action := range p.actions[i+1:] {
+		if action["action"] != "create_track" {
+			continue
+		}
+		if created, ok := actionInt(action, "index"); ok && created == index {
+			return true
+		}
+	}
+	return false
+}
+
+// executionIndex is the number of live tracks before the slot at index
+func (p *actionPlanner) executionIndex(index int) int {
+	live := 0
+	for _, track := range p.tracks[:min(index, len(p.tracks))] {
+		if !track.deleted {
+			live++
+		}
+	}
+	return live
+}
+
+// grow extends the track list to n slots with tracks the state doesn't describe
+func (p *actionPlanner) grow(n int) {
+	for len(p.tracks) < n {
+		p.tracks = append(p.tracks, &planTrack{})
+	}
+}
+
+// insert adds count live tracks at index
+func (p *actionPlanner) insert(index, count int) {
+	p.grow(index)
+	inserted := make([]*planTrack, count)
+	for i := range inserted {
+		inserted[i] = &planTrack{}
+	}
+	p.tracks = append(p.tracks[:index], append(inserted, p.tracks[index:]...)...)
+}
+
+// copyAction returns a shallow copy of action, so rewriting indices leaves the input intact
+func copyAction(action map[string]any) map[string]any {
+	copied := make(map[string]any, len(action))
+	for key, value := range action {
+		copied[key] = value
+	}
+	return copied
+}
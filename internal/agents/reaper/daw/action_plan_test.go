@@ -0,0 +1,196 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanActions(t *testing.T) {
+	fourTracks := map[string]any{"tracks": []any{
+		map[string]any{"index": 0, "name": "Drums"},
+		map[string]any{"index": 1, "name": "Bass"},
+		map[string]any{"index": 2, "name": "Keys"},
+		map[string]any{"index": 3, "name": "Pad"},
+	}}
+
+	tests := []struct {
+		name        string
+		actions     []map[string]any
+		state       map[string]any
+		want        []map[string]any
+		wantDropped []int
+		unchanged   bool
+	}{
+		{
+			name: "no deletes is unchanged",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 4, "name": "Lead"},
+				{"action": "add_track_fx", "track": 4, "fxname": "ReaEQ"},
+				{"action": "set_track", "track": 1, "mute": true},
+			},
+			state:     fourTracks,
+			unchanged: true,
+		},
+		{
+			name: "actions after a delete are shifted",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "set_track", "track": 3, "name": "Strings"},
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "set_track", "track": 2, "name": "Strings"},
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+		},
+		{
+			name: "filtered deletes in ascending order",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "delete_track", "track": 2},
+				{"action": "delete_track", "track": 3},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "delete_track", "track": 1},
+				{"action": "delete_track", "track": 1},
+			},
+		},
+		{
+			name: "created track after a delete",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "create_track", "index": 4, "name": "Lead"},
+				{"action": "add_track_fx", "track": 4, "fxname": "Serum"},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "create_track", "index": 3, "name": "Lead"},
+				{"action": "add_track_fx", "track": 3, "fxname": "Serum"},
+			},
+		},
+		{
+			name: "send destination is shifted",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "add_send", "track": 1, "dest": 3},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "add_send", "track": 0, "dest": 2},
+			},
+		},
+		{
+			name: "create moved before its first use",
+			actions: []map[string]any{
+				{"action": "add_track_fx", "track": 4, "fxname": "ReaComp"},
+				{"action": "set_track", "track": 0, "solo": true},
+				{"action": "create_track", "index": 4, "name": "Bus"},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "solo": true},
+				{"action": "create_track", "index": 4, "name": "Bus"},
+				{"action": "add_track_fx", "track": 4, "fxname": "ReaComp"},
+			},
+		},
+		{
+			name: "actions on a deleted track are dropped",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 2},
+				{"action": "set_track", "track": 2, "name": "Gone"},
+				{"action": "set_track", "track": 3, "name": "Pad 2"},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 2},
+				{"action": "set_track", "track": 2, "name": "Pad 2"},
+			},
+			wantDropped: []int{1},
+		},
+		{
+			name: "recreating a deleted index targets the new track",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "create_track", "index": 0},
+				{"action": "set_track", "track": 0, "name": "Lead"},
+				{"action": "set_track", "track": 2, "mute": true},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "create_track", "index": 0},
+				{"action": "set_track", "track": 0, "name": "Lead"},
+				{"action": "set_track", "track": 1, "mute": true},
+			},
+		},
+		{
+			name: "duplicates shift later tracks like the project",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "duplicate_track", "track": 1, "count": 2},
+				{"action": "set_track", "track": 4, "name": "Keys"},
+			},
+			state: fourTracks,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "duplicate_track", "track": 0, "count": 2},
+				{"action": "set_track", "track": 3, "name": "Keys"},
+			},
+		},
+		{
+			name: "without state every referenced index exists",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "set_track", "track": 5, "name": "Vox"},
+			},
+			want: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "set_track", "track": 4, "name": "Vox"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := PlanActions(tt.actions, tt.state)
+
+			want := tt.want
+			if tt.unchanged {
+				want = tt.actions
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("PlanActions() = %v, want %v", got, want)
+			}
+
+			var dropped []int
+			for _, warning := range warnings {
+				if warning.Code != WarningDroppedAction {
+					t.Errorf("unexpected warning code %q", warning.Code)
+				}
+				dropped = append(dropped, warning.ActionIndex)
+			}
+			if !reflect.DeepEqual(dropped, tt.wantDropped) {
+				t.Errorf("dropped action indices = %v, want %v", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestPlanActions_DoesNotModifyInput(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "delete_track", "track": 0},
+		{"action": "set_track", "track": 1, "name": "Bass"},
+	}
+
+	PlanActions(actions, nil)
+
+	if actions[1]["track"] != 1 {
+		t.Errorf("input action was rewritten: %v", actions[1])
+	}
+}
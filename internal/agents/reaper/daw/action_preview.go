@@ -0,0 +1,197 @@
+package daw
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// destructiveActions remove or overwrite project content: the extension asks for confirmation
+// before applying a batch that contains one
+var destructiveActions = map[string]bool{
+	"delete_track":     true,
+	"delete_clip":      true,
+	"remove_fx":        true,
+	"remove_send":      true,
+	"delete_marker":    true,
+	"set_clip_notes":   true, // replaces the clip's notes
+	"bounce_in_place":  true, // replaces the track's clips with rendered audio
+	"clear_automation": true,
+	"delete_envelope":  true,
+}
+
+// previewHiddenKeys identify an action's target rather than describe the change
+var previewHiddenKeys = map[string]bool{
+	"action": true, "track": true, "clip": true, "track_guid": true, "clip_guid": true, "dest_guid": true,
+}
+
+// PreviewActions describes planned actions one line each, e.g. Delete track 2 "Drums" or
+// Set track 1 "Bass": mute=true. Actions are in execution order with shifted track indices
+// (see PlanActions), so track names are looked up in the project as the earlier actions
+// leave it.
+func PreviewActions(actions []map[string]any, state map[string]any) []models.ActionPreview {
+	names := previewTrackNames(state)
+	previews := make([]models.ActionPreview, 0, len(actions))
+
+	for i, action := range actions {
+		actionType, _ := action["action"].(string)
+		previews = append(previews, models.ActionPreview{
+			ActionIndex: i,
+			Action:      actionType,
+			Summary:     describeAction(action, names),
+			Destructive: destructiveActions[actionType],
+		})
+		names = applyToTrackNames(names, action)
+	}
+	return previews
+}
+
+// describeAction renders an action as a verb, its target and the properties it sets
+func describeAction(action map[string]any, names []string) string {
+	actionType, _ := action["action"].(string)
+	verb, object, _ := strings.Cut(actionType, "_")
+	if verb == "" {
+		verb = "unknown action"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strings.ToUpper(verb[:1]) + verb[1:])
+
+	trackIndex, hasTrack := actionInt(action, "track")
+	clipIndex, hasClip := actionInt(action, "clip")
+	switch {
+	case isMasterAction(action) && object == "track":
+		sb.WriteString(" master track")
+	case isMasterAction(action):
+		fmt.Fprintf(&sb, " %s on master track", strings.ReplaceAll(object, "_", " "))
+	case object == "track" && hasTrack:
+		sb.WriteString(" " + describeTrack(trackIndex, names))
+	case object == "track":
+		if index, ok := actionInt(action, "index"); ok {
+			fmt.Fprintf(&sb, " track %d", index+1)
+		} else {
+			sb.WriteString(" track")
+		}
+	case strings.HasPrefix(object, "clip") && hasClip && hasTrack:
+		fmt.Fprintf(&sb, " %s %d on %s", strings.ReplaceAll(object, "_", " "), clipIndex+1, describeTrack(trackIndex, names))
+	case hasTrack && object != "":
+		fmt.Fprintf(&sb, " %s on %s", strings.ReplaceAll(object, "_", " "), describeTrack(trackIndex, names))
+	case hasTrack:
+		sb.WriteString(" on " + describeTrack(trackIndex, names))
+	case object != "":
+		sb.WriteString(" " + strings.ReplaceAll(object, "_", " "))
+	}
+
+	if details := describeActionProperties(action); details != "" {
+		sb.WriteString(": " + details)
+	}
+	return sb.String()
+}
+
+// describeTrack renders a 0-based track index as track 2 "Drums"
+func describeTrack(index int, names []string) string {
+	if index >= 0 && index < len(names) && names[index] != "" {
+		return fmt.Sprintf("track %d %q", index+1, names[index])
+	}
+	return fmt.Sprintf("track %d", index+1)
+}
+
+// describeActionProperties renders the properties an action sets as key=value, sorted by key
+func describeActionProperties(action map[string]any) string {
+	keys := make([]string, 0, len(action))
+	for key := range action {
+		if !previewHiddenKeys[key] && (key != "index" || action["action"] != "create_track") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, key+"="+describePreviewValue(action[key]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describePreviewValue keeps note and point lists out of the summary
+func describePreviewValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []any:
+		return fmt.Sprintf("[%d items]", len(v))
+	case []map[string]any:
+		return fmt.Sprintf("[%d items]", len(v))
+	case map[string]any:
+		return fmt.Sprintf("{%d fields}", len(v))
+	default:
+		return formatIterationValue(v)
+	}
+}
+
+// previewTrackNames lists the state's track names by index
+func previewTrackNames(state map[string]any) []string {
+	tracks, _ := stateTracks(state)
+	names := make([]string, len(tracks))
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index := i
+		if v, ok := actionInt(trackMap, "index"); ok {
+			index = v
+		}
+		for index >= len(names) {
+			names = append(names, "")
+		}
+		names[index], _ = trackMap["name"].(string)
+	}
+	return names
+}
+
+// applyToTrackNames updates the track names for actions that add, remove, move or rename tracks
+func applyToTrackNames(names []string, action map[string]any) []string {
+	trackIndex, hasTrack := actionInt(action, "track")
+	inRange := hasTrack && trackIndex >= 0 && trackIndex < len(names)
+
+	switch action["action"] {
+	case "create_track":
+		index, ok := actionInt(action, "index")
+		if !ok || index < 0 || index > len(names) {
+			index = len(names)
+		}
+		name, _ := action["name"].(string)
+		names = append(names[:index], append([]string{name}, names[index:]...)...)
+	case "delete_track":
+		if inRange {
+			names = append(names[:trackIndex], names[trackIndex+1:]...)
+		}
+	case "move_track":
+		to, ok := actionInt(action, "to")
+		if inRange && ok && to >= 0 && to < len(names) {
+			name := names[trackIndex]
+			names = append(names[:trackIndex], names[trackIndex+1:]...)
+			names = append(names[:to], append([]string{name}, names[to:]...)...)
+		}
+	case "duplicate_track":
+		if inRange {
+			count, ok := actionInt(action, "count")
+			if !ok || count < 1 {
+				count = 1
+			}
+			copies := make([]string, count)
+			for i := range copies {
+				copies[i] = names[trackIndex]
+			}
+			names = append(names[:trackIndex+1], append(copies, names[trackIndex+1:]...)...)
+		}
+	case "set_track":
+		if name, ok := action["name"].(string); ok && inRange {
+			names[trackIndex] = name
+		}
+	}
+	return names
+}
@@ -0,0 +1,78 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestPreviewActions(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums"},
+			map[string]any{"index": 1, "name": "Bass"},
+			map[string]any{"index": 2, "name": "Pad"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		actions []map[string]any
+		want    []models.ActionPreview
+	}{
+		{
+			name: "track names follow earlier deletes",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "set_track", "track": 0, "mute": true, "volume_db": -6.0},
+			},
+			want: []models.ActionPreview{
+				{ActionIndex: 0, Action: "delete_track", Summary: `Delete track 1 "Drums"`, Destructive: true},
+				{ActionIndex: 1, Action: "set_track", Summary: `Set track 1 "Bass": mute=true, volume_db=-6`},
+			},
+		},
+		{
+			name: "created and moved tracks",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 3, "name": "Lead", "instrument": "Serum"},
+				{"action": "move_track", "track": 3, "to": 0},
+				{"action": "set_track", "track": 0, "color": "#ff0000"},
+			},
+			want: []models.ActionPreview{
+				{ActionIndex: 0, Action: "create_track", Summary: `Create track 4: instrument="Serum", name="Lead"`},
+				{ActionIndex: 1, Action: "move_track", Summary: `Move track 4 "Lead": to=0`},
+				{ActionIndex: 2, Action: "set_track", Summary: `Set track 1 "Lead": color="#ff0000"`},
+			},
+		},
+		{
+			name: "clip, fx and project actions",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 1, "clip": 0},
+				{"action": "set_clip_notes", "track": 2, "position": 4.0, "notes": []map[string]any{{}, {}}},
+				{"action": "remove_fx", "track": 2, "fx": 0, "fxname": "ReaVerb"},
+				{"action": "add_marker", "bar": 17, "name": "Drop"},
+			},
+			want: []models.ActionPreview{
+				{ActionIndex: 0, Action: "delete_clip", Summary: `Delete clip 1 on track 2 "Bass"`, Destructive: true},
+				{ActionIndex: 1, Action: "set_clip_notes", Summary: `Set clip notes on track 3 "Pad": notes=[2 items], position=4`, Destructive: true},
+				{ActionIndex: 2, Action: "remove_fx", Summary: `Remove fx on track 3 "Pad": fx=0, fxname="ReaVerb"`, Destructive: true},
+				{ActionIndex: 3, Action: "add_marker", Summary: `Add marker: bar=17, name="Drop"`},
+			},
+		},
+		{
+			name:    "no actions",
+			actions: nil,
+			want:    []models.ActionPreview{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PreviewActions(tt.actions, state)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PreviewActions() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,602 @@
+package daw
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// WarningInvalidAction is the warning code for an action left out because it doesn't match
+// the published action schema (ActionSchema)
+const WarningInvalidAction = "invalid_action"
+
+// actionField is one property of an action: the JSON types it may have and whether every
+// action of the type carries it
+type actionField struct {
+	types       []string // JSON Schema types: integer, number, string, boolean, array
+	required    bool
+	description string
+}
+
+// actionSpec describes one action type
+type actionSpec struct {
+	description string
+	fields      map[string]actionField
+}
+
+func integerField(description string) actionField {
+	return actionField{types: []string{"integer"}, description: description}
+}
+
+func numberField(description string) actionField {
+	return actionField{types: []string{"number"}, description: description}
+}
+
+func stringField(description string) actionField {
+	return actionField{types: []string{"string"}, description: description}
+}
+
+func booleanField(description string) actionField {
+	return actionField{types: []string{"boolean"}, description: description}
+}
+
+func arrayField(description string) actionField {
+	return actionField{types: []string{"array"}, description: description}
+}
+
+func (f actionField) require() actionField {
+	f.required = true
+	return f
+}
+
+func (f actionField) optional() actionField {
+	f.required = false
+	return f
+}
+
+// Shared fields. track is a 0-based index, or "master" where the master track is allowed;
+// PlanActions adds the GUIDs of the tracks and clips it resolves.
+var (
+	trackField       = integerField("0-based track index").require()
+	masterTrackField = actionField{types: []string{"integer", "string"}, required: true, description: `0-based track index or "master"`}
+	trackGUIDField   = stringField("GUID of the target track")
+	clipGUIDField    = stringField("GUID of the target clip")
+	clipIndexField   = integerField("0-based clip index on the track")
+	positionField    = numberField("clip position in seconds")
+	barField         = integerField("1-based bar")
+	colorField       = stringField(`hex color, e.g. "#ff0000"`)
+	fxIndexField     = integerField("1-based position in the FX chain")
+	fxNameField      = stringField("plugin name")
+)
+
+// withTarget adds the track (and optionally clip) GUID fields to fields
+func withTarget(fields map[string]actionField, clip bool) map[string]actionField {
+	fields["track_guid"] = trackGUIDField
+	if clip {
+		fields["clip_guid"] = clipGUIDField
+	}
+	return fields
+}
+
+// actionSpecs is every action type the API returns, keyed by its "action" value
+var actionSpecs = map[string]actionSpec{
+	"create_track": {
+		description: "Create a track",
+		fields: map[string]actionField{
+			"index":      integerField("0-based index the track is created at").require(),
+			"name":       stringField("track name"),
+			"instrument": stringField("instrument plugin to load"),
+		},
+	},
+	"delete_track": {
+		description: "Delete a track",
+		fields:      withTarget(map[string]actionField{"track": trackField}, false),
+	},
+	"duplicate_track": {
+		description: "Duplicate a track",
+		fields: withTarget(map[string]actionField{
+			"track": trackField,
+			"count": integerField("number of copies"),
+		}, false),
+	},
+	"move_track": {
+		description: "Move a track to another index",
+		fields: withTarget(map[string]actionField{
+			"track": trackField,
+			"to":    integerField("0-based destination index").require(),
+		}, false),
+	},
+	"set_track": {
+		description: "Set track properties",
+		fields: withTarget(map[string]actionField{
+			"track":        masterTrackField,
+			"name":         stringField("track name"),
+			"volume_db":    numberField("volume in dB"),
+			"pan":          numberField("pan from -1 (left) to 1 (right)"),
+			"mute":         booleanField("muted"),
+			"solo":         booleanField("soloed"),
+			"selected":     booleanField("selected"),
+			"color":        colorField,
+			"folder_depth": integerField("REAPER folder depth change: 1 starts a folder, negative closes folders"),
+		}, false),
+	},
+	"freeze_track": {
+		description: "Freeze a track's FX to audio",
+		fields:      withTarget(map[string]actionField{"track": trackField}, false),
+	},
+	"unfreeze_track": {
+		description: "Unfreeze a track",
+		fields:      withTarget(map[string]actionField{"track": trackField}, false),
+	},
+	"bounce_in_place": {
+		description: "Render a track's clips to audio clips that replace them",
+		fields:      withTarget(map[string]actionField{"track": trackField}, false),
+	},
+	"create_clip": {
+		description: "Create a clip at a position in seconds",
+		fields: withTarget(map[string]actionField{
+			"track":    trackField,
+			"position": positionField.require(),
+			"length":   numberField("length in seconds").require(),
+		}, false),
+	},
+	"create_clip_at_bar": {
+		description: "Create a clip at a bar",
+		fields: withTarget(map[string]actionField{
+			"track":       trackField,
+			"bar":         barField.require(),
+			"length_bars": numberField("length in bars").require(),
+			"name":        stringField("clip name"),
+		}, false),
+	},
+	"set_clip": {
+		description: "Set clip properties; the clip is identified by clip, position or bar",
+		fields: withTarget(map[string]actionField{
+			"track":         trackField,
+			"clip":          clipIndexField,
+			"position":      positionField,
+			"bar":           barField,
+			"name":          stringField("clip name"),
+			"color":         colorField,
+			"length":        numberField("length in seconds"),
+			"selected":      booleanField("selected"),
+			"loop":          booleanField("loop the source"),
+			"source_length": numberField("loop length of the source in seconds"),
+		}, true),
+	},
+	"set_clip_position": {
+		description: "Move a clip",
+		fields: withTarget(map[string]actionField{
+			"track":        trackField,
+			"clip":         clipIndexField,
+			"old_position": numberField("current position in seconds"),
+			"position":     numberField("new position in seconds").require(),
+		}, true),
+	},
+	"delete_clip": {
+		description: "Delete a clip; the clip is identified by clip, position or bar",
+		fields: withTarget(map[string]actionField{
+			"track":    trackField,
+			"clip":     clipIndexField,
+			"position": positionField,
+			"bar":      barField,
+		}, true),
+	},
+	"duplicate_clip": {
+		description: "Duplicate a clip",
+		fields: withTarget(map[string]actionField{
+			"track":    trackField,
+			"clip":     clipIndexField,
+			"position": positionField,
+			"bar":      barField,
+			"count":    integerField("number of copies"),
+			"offset":   numberField("seconds from one copy's start to the next"),
+		}, true),
+	},
+	"split_clip": {
+		description: "Split a clip",
+		fields: withTarget(map[string]actionField{
+			"track":    trackField,
+			"clip":     clipIndexField,
+			"position": numberField("split point in seconds"),
+			"bar":      barField,
+		}, true),
+	},
+	"trim_clip": {
+		description: "Trim a clip to a range",
+		fields: withTarget(map[string]actionField{
+			"track":     trackField,
+			"clip":      clipIndexField,
+			"position":  positionField,
+			"bar":       barField,
+			"start":     numberField("new start in seconds"),
+			"end":       numberField("new end in seconds"),
+			"start_bar": numberField("new start bar"),
+			"end_bar":   numberField("new end bar"),
+		}, true),
+	},
+	"add_midi": {
+		description: "Add MIDI notes in a new clip, or the clip created before it on the track",
+		fields: withTarget(map[string]actionField{
+			"track": trackField,
+			"notes": arrayField("notes: pitch, velocity, start and length (beats)").require(),
+			"name":  stringField("clip name"),
+		}, false),
+	},
+	"set_clip_notes": {
+		description: "Replace a clip's MIDI notes",
+		fields: withTarget(map[string]actionField{
+			"track":    trackField,
+			"clip":     clipIndexField,
+			"position": positionField,
+			"notes":    arrayField("notes: pitch, velocity, start and length (beats)").require(),
+		}, true),
+	},
+	"add_track_fx": {
+		description: "Add an effect",
+		fields: withTarget(map[string]actionField{
+			"track":  masterTrackField,
+			"fxname": fxNameField.require(),
+		}, false),
+	},
+	"add_instrument": {
+		description: "Add an instrument",
+		fields: withTarget(map[string]actionField{
+			"track":  trackField,
+			"fxname": fxNameField.require(),
+		}, false),
+	},
+	"set_fx_param": {
+		description: "Set an FX parameter",
+		fields: withTarget(map[string]actionField{
+			"track":  masterTrackField,
+			"fxname": fxNameField.require(),
+			"param":  stringField("parameter name").require(),
+			"value":  numberField("normalized value from 0 to 1").require(),
+		}, false),
+	},
+	"bypass_fx": {
+		description: "Bypass an effect, by position (fx) or name (fxname)",
+		fields: withTarget(map[string]actionField{
+			"track":  masterTrackField,
+			"fx":     fxIndexField,
+			"fxname": fxNameField,
+		}, false),
+	},
+	"enable_fx": {
+		description: "Enable a bypassed effect, by position (fx) or name (fxname)",
+		fields: withTarget(map[string]actionField{
+			"track":  masterTrackField,
+			"fx":     fxIndexField,
+			"fxname": fxNameField,
+		}, false),
+	},
+	"remove_fx": {
+		description: "Remove an effect, by position (fx) or name (fxname)",
+		fields: withTarget(map[string]actionField{
+			"track":  masterTrackField,
+			"fx":     fxIndexField,
+			"fxname": fxNameField,
+		}, false),
+	},
+	"move_fx": {
+		description: "Move an effect within the FX chain",
+		fields: withTarget(map[string]actionField{
+			"track":  masterTrackField,
+			"fx":     fxIndexField,
+			"fxname": fxNameField,
+			"to":     integerField("1-based destination in the FX chain").require(),
+		}, false),
+	},
+	"add_send": {
+		description: "Add a send to another track",
+		fields: withTarget(map[string]actionField{
+			"track":     trackField,
+			"dest":      integerField("0-based destination track").require(),
+			"dest_guid": stringField("GUID of the destination track"),
+			"level_db":  numberField("send level in dB"),
+			"pre_fader": booleanField("send before the fader"),
+			"mute":      booleanField("muted"),
+		}, false),
+	},
+	"set_send": {
+		description: "Change a send",
+		fields: withTarget(map[string]actionField{
+			"track":     trackField,
+			"dest":      integerField("0-based destination track").require(),
+			"dest_guid": stringField("GUID of the destination track"),
+			"level_db":  numberField("send level in dB"),
+			"pre_fader": booleanField("send before the fader"),
+			"mute":      booleanField("muted"),
+		}, false),
+	},
+	"remove_send": {
+		description: "Remove a send",
+		fields: withTarget(map[string]actionField{
+			"track":     trackField,
+			"dest":      integerField("0-based destination track").require(),
+			"dest_guid": stringField("GUID of the destination track"),
+		}, false),
+	},
+	"add_automation": {
+		description: "Write automation from a curve or from points",
+		fields: withTarget(map[string]actionField{
+			"track":     masterTrackField,
+			"param":     stringField(`envelope: volume, pan or "Plugin:Param"`).require(),
+			"curve":     stringField("curve shape, e.g. fade_in or sine"),
+			"points":    arrayField("points: time (seconds) and value"),
+			"start":     numberField("start in seconds"),
+			"end":       numberField("end in seconds"),
+			"start_bar": numberField("start bar"),
+			"end_bar":   numberField("end bar"),
+			"from":      numberField("start value"),
+			"to":        numberField("end value"),
+			"freq":      numberField("oscillation frequency in Hz"),
+			"amplitude": numberField("oscillation depth"),
+			"phase":     numberField("oscillation phase"),
+			"shape":     integerField("REAPER point shape"),
+		}, false),
+	},
+	"clear_automation": {
+		description: "Remove automation points, optionally in a range",
+		fields:      withTarget(automationEditFields(nil), false),
+	},
+	"delete_envelope": {
+		description: "Remove an envelope",
+		fields: withTarget(map[string]actionField{
+			"track": masterTrackField,
+			"param": stringField("envelope").require(),
+		}, false),
+	},
+	"scale_automation": {
+		description: "Scale automation point values, optionally in a range",
+		fields: withTarget(automationEditFields(map[string]actionField{
+			"factor": numberField("multiplier for the point values").require(),
+		}), false),
+	},
+	"add_marker": {
+		description: "Add a project marker",
+		fields: map[string]actionField{
+			"bar":      barField,
+			"position": numberField("position in seconds"),
+			"name":     stringField("marker name"),
+			"color":    colorField,
+		},
+	},
+	"add_region": {
+		description: "Add a project region; end_bar is exclusive",
+		fields: map[string]actionField{
+			"start_bar": barField,
+			"end_bar":   barField,
+			"start":     numberField("start in seconds"),
+			"end":       numberField("end in seconds"),
+			"name":      stringField("region name"),
+			"color":     colorField,
+		},
+	},
+	"delete_marker": {
+		description: "Delete markers or regions by id, name, bar or color",
+		fields: map[string]actionField{
+			"id":       integerField("marker or region number"),
+			"name":     stringField("marker or region name"),
+			"bar":      barField,
+			"position": numberField("position in seconds"),
+			"color":    colorField,
+		},
+	},
+	"rename_region": {
+		description: "Rename a region",
+		fields: map[string]actionField{
+			"id":       integerField("region number"),
+			"name":     stringField("current name"),
+			"new_name": stringField("new name").require(),
+		},
+	},
+	"play":   {description: "Start playback", fields: map[string]actionField{}},
+	"stop":   {description: "Stop playback or recording", fields: map[string]actionField{}},
+	"record": {description: "Start recording", fields: map[string]actionField{}},
+	"set_play_position": {
+		description: "Move the play cursor",
+		fields: map[string]actionField{
+			"bar":  barField,
+			"time": numberField("position in seconds"),
+		},
+	},
+	"render_project": {
+		description: "Render the project or a bar range; end_bar is exclusive",
+		fields: map[string]actionField{
+			"format":    stringField("wav, mp3, flac or ogg").require(),
+			"start_bar": barField,
+			"end_bar":   barField,
+			"stems":     booleanField("render each track separately").require(),
+		},
+	},
+	"set_tempo": {
+		description: "Set the project tempo",
+		fields:      map[string]actionField{"bpm": numberField("beats per minute").require()},
+	},
+	"drum_pattern": {
+		description: "A drum pattern for the drummer's grid",
+		fields: map[string]actionField{
+			"drum":     stringField("drum name, e.g. kick").require(),
+			"grid":     stringField(`16th-note grid, "x" for a hit and "-" for a rest`).require(),
+			"velocity": integerField("MIDI velocity"),
+			"track":    trackField.optional(),
+		},
+	},
+}
+
+// automationEditFields are the fields of an automation edit on param, with an optional range
+func automationEditFields(extra map[string]actionField) map[string]actionField {
+	fields := map[string]actionField{
+		"track":     masterTrackField,
+		"param":     stringField("envelope").require(),
+		"start":     numberField("start in seconds"),
+		"end":       numberField("end in seconds"),
+		"start_bar": numberField("start bar"),
+		"end_bar":   numberField("end bar"),
+	}
+	for name, field := range extra {
+		fields[name] = field
+	}
+	return fields
+}
+
+// ActionSchema returns the JSON Schema (draft 2020-12) of the actions the API returns: one
+// definition per action type, selected by the "action" property. Properties not listed are
+// allowed, so clients should ignore ones they don't know.
+func ActionSchema() map[string]any {
+	names := ActionTypes()
+	defs := make(map[string]any, len(names))
+	oneOf := make([]any, 0, len(names))
+	for _, name := range names {
+		spec := actionSpecs[name]
+		properties := map[string]any{
+			"action": map[string]any{"const": name},
+		}
+		required := []string{"action"}
+		for _, fieldName := range sortedFieldNames(spec.fields) {
+			field := spec.fields[fieldName]
+			property := map[string]any{"description": field.description}
+			if len(field.types) == 1 {
+				property["type"] = field.types[0]
+			} else {
+				property["type"] = field.types
+			}
+			properties[fieldName] = property
+			if field.required {
+				required = append(required, fieldName)
+			}
+		}
+		defs[name] = map[string]any{
+			"type":        "object",
+			"description": spec.description,
+			"properties":  properties,
+			"required":    required,
+		}
+		oneOf = append(oneOf, map[string]any{"$ref": "#/$defs/" + name})
+	}
+
+	return map[string]any{
+		"$schema":     "https://json-schema.org/draft/2020-12/schema",
+		"title":       "MAGDA action",
+		"description": "An action for the REAPER extension to execute, identified by its action property",
+		"type":        "object",
+		"required":    []string{"action"},
+		"oneOf":       oneOf,
+		"$defs":       defs,
+	}
+}
+
+// ActionTypes lists the action types in the schema, sorted
+func ActionTypes() []string {
+	names := make([]string, 0, len(actionSpecs))
+	for name := range actionSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedFieldNames(fields map[string]actionField) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateAction checks action against its type's schema and returns the problems found
+func ValidateAction(action map[string]any) []string {
+	actionType, ok := action["action"].(string)
+	if !ok || actionType == "" {
+		return []string{"missing action type"}
+	}
+	spec, ok := actionSpecs[actionType]
+	if !ok {
+		return []string{fmt.Sprintf("unknown action type %q", actionType)}
+	}
+
+	var problems []string
+	for _, name := range sortedFieldNames(spec.fields) {
+		field := spec.fields[name]
+		value, present := action[name]
+		if !present || value == nil {
+			if field.required {
+				problems = append(problems, fmt.Sprintf("%s is required", name))
+			}
+			continue
+		}
+		if !matchesAnyType(value, field.types) {
+			problems = append(problems, fmt.Sprintf("%s must be %s, got %T", name, strings.Join(field.types, " or "), value))
+		}
+	}
+	return problems
+}
+
+// ValidateActions leaves out the actions that don't match the schema, with a warning for each
+func ValidateActions(actions []map[string]any) ([]map[string]any, []models.ActionWarning) {
+	var warnings []models.ActionWarning
+	valid := make([]map[string]any, 0, len(actions))
+	for i, action := range actions {
+		problems := ValidateAction(action)
+		if len(problems) == 0 {
+			valid = append(valid, action)
+			continue
+		}
+		warnings = append(warnings, models.ActionWarning{
+			Code:        WarningInvalidAction,
+			Message:     fmt.Sprintf("action %d (%v) was left out: %s", i, action["action"], strings.Join(problems, "; ")),
+			ActionIndex: i,
+		})
+	}
+	return valid, warnings
+}
+
+// matchesAnyType reports whether value has one of the JSON types
+func matchesAnyType(value any, types []string) bool {
+	for _, t := range types {
+		if matchesType(value, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesType(value any, jsonType string) bool {
+	switch jsonType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		switch n := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return n == math.Trunc(n) && !math.IsInf(n, 0)
+		}
+		return false
+	case "number":
+		switch n := value.(type) {
+		case int, int32, int64:
+			return true
+		case float64:
+			return !math.IsNaN(n) && !math.IsInf(n, 0)
+		}
+		return false
+	case "array":
+		switch value.(type) {
+		case []any, []map[string]any, []string, []int, []float64:
+			return true
+		}
+		return false
+	}
+	return false
+}
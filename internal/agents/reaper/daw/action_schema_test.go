@@ -0,0 +1,115 @@
+package daw
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/golden"
+)
+
+func TestActionSchema(t *testing.T) {
+	schema := ActionSchema()
+	data, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("schema doesn't marshal: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("schema doesn't unmarshal: %v", err)
+	}
+
+	defs, _ := decoded["$defs"].(map[string]any)
+	oneOf, _ := decoded["oneOf"].([]any)
+	if len(defs) != len(actionSpecs) || len(oneOf) != len(actionSpecs) {
+		t.Fatalf("schema has %d definitions and %d refs, want %d", len(defs), len(oneOf), len(actionSpecs))
+	}
+	for _, ref := range oneOf {
+		name := strings.TrimPrefix(ref.(map[string]any)["$ref"].(string), "#/$defs/")
+		def, ok := defs[name].(map[string]any)
+		if !ok {
+			t.Errorf("ref to undefined action %q", name)
+			continue
+		}
+		action := def["properties"].(map[string]any)["action"].(map[string]any)
+		if action["const"] != name {
+			t.Errorf("%s: action const = %v", name, action["const"])
+		}
+		if required := def["required"].([]any); len(required) == 0 || required[0] != "action" {
+			t.Errorf("%s: required = %v, want action first", name, required)
+		}
+	}
+}
+
+func TestValidateAction(t *testing.T) {
+	tests := []struct {
+		name   string
+		action map[string]any
+		want   []string
+	}{
+		{name: "valid", action: map[string]any{"action": "set_track", "track": 0, "mute": true}},
+		{name: "master track", action: map[string]any{"action": "add_track_fx", "track": "master", "fxname": "ReaEQ"}},
+		{name: "integral float", action: map[string]any{"action": "delete_track", "track": 2.0}},
+		{name: "unknown fields allowed", action: map[string]any{"action": "play", "note": "x"}},
+		{name: "missing type", action: map[string]any{"track": 0}, want: []string{"missing action type"}},
+		{name: "unknown type", action: map[string]any{"action": "explode"}, want: []string{"unknown action type"}},
+		{name: "missing required", action: map[string]any{"action": "move_track", "track": 0}, want: []string{"to is required"}},
+		{name: "fractional integer", action: map[string]any{"action": "delete_track", "track": 1.5}, want: []string{"track must be integer"}},
+		{
+			name:   "wrong types",
+			action: map[string]any{"action": "set_track", "track": 0, "mute": "yes", "volume_db": "-3"},
+			want:   []string{"mute must be boolean", "volume_db must be number"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateAction(tt.action)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ValidateAction() = %v, want %v", got, tt.want)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(got[i], want) {
+					t.Errorf("problem %d = %q, want it to contain %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateActions(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "create_clip_at_bar", "track": 0, "bar": 1},
+		{"action": "play"},
+	}
+	valid, warnings := ValidateActions(actions)
+	if len(valid) != 2 || valid[1]["action"] != "play" {
+		t.Errorf("valid = %v, want create_track and play", valid)
+	}
+	if len(warnings) != 1 || warnings[0].Code != WarningInvalidAction || warnings[0].ActionIndex != 1 {
+		t.Errorf("warnings = %+v, want one %s at action 1", warnings, WarningInvalidAction)
+	}
+}
+
+// Every action the parser produces for the golden files must match the schema
+func TestGoldenActionsMatchSchema(t *testing.T) {
+	cases, err := golden.Load("testdata/golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range cases {
+		if c.Error != "" {
+			continue
+		}
+		var actions []map[string]any
+		if err := json.Unmarshal([]byte(c.Actions), &actions); err != nil {
+			t.Fatalf("%s: %v", c.Name, err)
+		}
+		for i, action := range actions {
+			if problems := ValidateAction(action); len(problems) > 0 {
+				t.Errorf("%s: action %d (%v): %v", c.Name, i, action["action"], problems)
+			}
+		}
+	}
+}
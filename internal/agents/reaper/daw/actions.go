@@ -0,0 +1,204 @@
+package daw
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// Action is an action for the extension, built as a typed struct so its fields are checked at
+// compile time. On the wire an action is a JSON object whose "action" field names its type;
+// after the parser, actions travel through conflict detection, planning and schema validation
+// in that map form (see actionMap), which is identical to what MarshalJSON writes.
+type Action interface {
+	// ActionType is the action's "action" field, e.g. "create_track"
+	ActionType() string
+	json.Marshaler
+}
+
+// TrackRef is the track an action targets: a 0-based track index, or MasterRef for the master
+// track. It is written as the index, or as MasterTrack.
+type TrackRef int
+
+// MasterRef refers to the master track
+const MasterRef TrackRef = -1
+
+// TrackIndex refers to the track at a 0-based index
+func TrackIndex(index int) TrackRef { return TrackRef(index) }
+
+func (t TrackRef) value() any {
+	if t == MasterRef {
+		return MasterTrack
+	}
+	return int(t)
+}
+
+// MarshalJSON writes the track index, or "master"
+func (t TrackRef) MarshalJSON() ([]byte, error) { return json.Marshal(t.value()) }
+
+// ClipRef identifies the clip on a track an action targets. Set one field: the clip's index,
+// its position in seconds, or the bar it starts at.
+type ClipRef struct {
+	Clip     *int     `json:"clip,omitempty"`
+	Position *float64 `json:"position,omitempty"`
+	Bar      *int     `json:"bar,omitempty"`
+}
+
+// ClipAt refers to a clip by its index on the track
+func ClipAt(index int) ClipRef { return ClipRef{Clip: &index} }
+
+// ClipAtPosition refers to a clip by its position in seconds
+func ClipAtPosition(position float64) ClipRef { return ClipRef{Position: &position} }
+
+// ClipAtBar refers to a clip by the bar it starts at
+func ClipAtBar(bar int) ClipRef { return ClipRef{Bar: &bar} }
+
+// CreateTrackAction creates a track at Index, optionally with an instrument
+type CreateTrackAction struct {
+	Index      int    `json:"index"`
+	Name       string `json:"name,omitempty"`
+	Instrument string `json:"instrument,omitempty"` // Resolved against installed plugins later
+}
+
+// CreateClipAction creates an empty clip at a position in seconds
+type CreateClipAction struct {
+	Track    TrackRef `json:"track"`
+	Position float64  `json:"position"`
+	Length   float64  `json:"length"` // Seconds
+}
+
+// CreateClipAtBarAction creates an empty clip starting at a bar
+type CreateClipAtBarAction struct {
+	Track      TrackRef `json:"track"`
+	Bar        int      `json:"bar"`
+	LengthBars int      `json:"length_bars"`
+}
+
+// AddFXAction adds a plugin to a track's FX chain: add_instrument when Instrument is set,
+// add_track_fx otherwise
+type AddFXAction struct {
+	Track      TrackRef `json:"track"`
+	FXName     string   `json:"fxname"`
+	Instrument bool     `json:"-"`
+}
+
+// SetTrackAction sets track properties such as name, volume_db, pan, mute, solo and selected
+type SetTrackAction struct {
+	Track      TrackRef       `json:"track"`
+	Properties map[string]any `json:"-"` // Written as top-level fields
+}
+
+// DeleteTrackAction deletes a track
+type DeleteTrackAction struct {
+	Track TrackRef `json:"track"`
+}
+
+// SetClipAction sets clip properties such as name, color, selected, length and loop
+type SetClipAction struct {
+	Track TrackRef `json:"track"`
+	ClipRef
+	Properties map[string]any `json:"-"` // Written as top-level fields
+}
+
+// DeleteClipAction deletes a clip
+type DeleteClipAction struct {
+	Track TrackRef `json:"track"`
+	ClipRef
+}
+
+// SetClipPositionAction moves a clip to Position (seconds). The clip is identified by index,
+// bar, or its current position as OldPosition.
+type SetClipPositionAction struct {
+	Track       TrackRef `json:"track"`
+	Position    float64  `json:"position"`
+	Clip        *int     `json:"clip,omitempty"`
+	OldPosition *float64 `json:"old_position,omitempty"`
+	Bar         *int     `json:"bar,omitempty"`
+}
+
+func (CreateTrackAction) ActionType() string     { return "create_track" }
+func (CreateClipAction) ActionType() string      { return "create_clip" }
+func (CreateClipAtBarAction) ActionType() string { return "create_clip_at_bar" }
+func (DeleteTrackAction) ActionType() string     { return "delete_track" }
+func (SetTrackAction) ActionType() string        { return "set_track" }
+func (SetClipAction) ActionType() string         { return "set_clip" }
+func (DeleteClipAction) ActionType() string      { return "delete_clip" }
+func (SetClipPositionAction) ActionType() string { return "set_clip_position" }
+
+func (a AddFXAction) ActionType() string {
+	if a.Instrument {
+		return "add_instrument"
+	}
+	return "add_track_fx"
+}
+
+func (a CreateTrackAction) MarshalJSON() ([]byte, error)     { return marshalAction(a) }
+func (a CreateClipAction) MarshalJSON() ([]byte, error)      { return marshalAction(a) }
+func (a CreateClipAtBarAction) MarshalJSON() ([]byte, error) { return marshalAction(a) }
+func (a AddFXAction) MarshalJSON() ([]byte, error)           { return marshalAction(a) }
+func (a SetTrackAction) MarshalJSON() ([]byte, error)        { return marshalAction(a) }
+func (a DeleteTrackAction) MarshalJSON() ([]byte, error)     { return marshalAction(a) }
+func (a SetClipAction) MarshalJSON() ([]byte, error)         { return marshalAction(a) }
+func (a DeleteClipAction) MarshalJSON() ([]byte, error)      { return marshalAction(a) }
+func (a SetClipPositionAction) MarshalJSON() ([]byte, error) { return marshalAction(a) }
+
+func (a SetTrackAction) extraFields() map[string]any { return a.Properties }
+func (a SetClipAction) extraFields() map[string]any  { return a.Properties }
+
+// extraFielder is an action with free-form properties written next to its typed fields
+type extraFielder interface {
+	extraFields() map[string]any
+}
+
+func marshalAction(a Action) ([]byte, error) {
+	return json.Marshal(actionMap(a))
+}
+
+// actionMap converts an action to the map form the rest of the pipeline works on: "action"
+// plus one entry per JSON field, with Go values (ints stay ints). Fields follow their json
+// tags: omitempty drops zero values and nil pointers, embedded structs are flattened. Free-form
+// properties never override the typed fields.
+func actionMap(a Action) map[string]any {
+	action := map[string]any{}
+	if extra, ok := a.(extraFielder); ok {
+		for key, value := range extra.extraFields() {
+			action[key] = value
+		}
+	}
+	addFields(action, reflect.ValueOf(a))
+	action["action"] = a.ActionType()
+	return action
+}
+
+var trackRefType = reflect.TypeOf(TrackRef(0))
+
+func addFields(action map[string]any, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, value := t.Field(i), v.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addFields(action, value)
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		if options == "omitempty" && value.IsZero() {
+			continue
+		}
+		switch {
+		case field.Type == trackRefType:
+			action[name] = value.Interface().(TrackRef).value()
+		case value.Kind() == reflect.Pointer:
+			action[name] = value.Elem().Interface()
+		default:
+			action[name] = value.Interface()
+		}
+	}
+}
+
+// appendAction adds a typed action to the parser's actions
+func (p *FunctionalDSLParser) appendAction(a Action) {
+	p.actions = append(p.actions, actionMap(a))
+}
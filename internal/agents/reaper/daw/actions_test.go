@@ -0,0 +1,67 @@
+package daw
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionMap(t *testing.T) {
+	position := 8.0
+	tests := []struct {
+		name   string
+		action Action
+		want   map[string]any
+	}{
+		{
+			name:   "create track",
+			action: CreateTrackAction{Index: 2, Instrument: "Serum"},
+			want:   map[string]any{"action": "create_track", "index": 2, "instrument": "Serum"},
+		},
+		{
+			name:   "create clip at bar",
+			action: CreateClipAtBarAction{Track: TrackIndex(0), Bar: 5, LengthBars: 4},
+			want:   map[string]any{"action": "create_clip_at_bar", "track": 0, "bar": 5, "length_bars": 4},
+		},
+		{
+			name:   "instrument on master",
+			action: AddFXAction{Track: MasterRef, FXName: "ReaSynth", Instrument: true},
+			want:   map[string]any{"action": "add_instrument", "track": "master", "fxname": "ReaSynth"},
+		},
+		{
+			name:   "set track properties",
+			action: SetTrackAction{Track: TrackIndex(1), Properties: map[string]any{"name": "Bass", "track": 9}},
+			want:   map[string]any{"action": "set_track", "track": 1, "name": "Bass"},
+		},
+		{
+			name:   "set clip by bar",
+			action: SetClipAction{Track: TrackIndex(1), ClipRef: ClipAtBar(3), Properties: map[string]any{"color": "#ff0000"}},
+			want:   map[string]any{"action": "set_clip", "track": 1, "bar": 3, "color": "#ff0000"},
+		},
+		{
+			name:   "delete clip by index",
+			action: DeleteClipAction{Track: TrackIndex(0), ClipRef: ClipAt(0)},
+			want:   map[string]any{"action": "delete_clip", "track": 0, "clip": 0},
+		},
+		{
+			name:   "move clip from old position",
+			action: SetClipPositionAction{Track: TrackIndex(0), Position: 16, OldPosition: &position},
+			want:   map[string]any{"action": "set_clip_position", "track": 0, "position": 16.0, "old_position": 8.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, actionMap(tt.action))
+
+			// The JSON is the same as the map's
+			got, err := json.Marshal(tt.action)
+			require.NoError(t, err)
+			want, err := json.Marshal(tt.want)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(want), string(got))
+		})
+	}
+}
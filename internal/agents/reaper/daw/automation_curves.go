@@ -0,0 +1,177 @@
+package daw
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+)
+
+// Curve sampling mirrors what the extension does with add_automation's curve parameters, so
+// clients can draw a curve before applying it.
+
+const (
+	// DefaultCurveSamples is the number of points SampleCurve returns when none is asked for
+	DefaultCurveSamples = 32
+	// MaxCurveSamples caps the points per preview
+	MaxCurveSamples = 1024
+
+	expCurveSteepness = 5.0 // k in (e^(k·t) - 1) / (e^k - 1)
+)
+
+// CurveSpec is an add_automation curve: the same parameters the DSL emits, plus sampling options
+type CurveSpec struct {
+	Curve       string   `json:"curve" binding:"required"`
+	From        *float64 `json:"from,omitempty"`
+	To          *float64 `json:"to,omitempty"`
+	Freq        float64  `json:"freq,omitempty"`      // Cycles per bar (sine, saw, square)
+	Rate        string   `json:"rate,omitempty"`      // Note division instead of freq, e.g. "1/8T"
+	Amplitude   *float64 `json:"amplitude,omitempty"` // Defaults to 1
+	Phase       float64  `json:"phase,omitempty"`     // 0-1, fraction of a cycle
+	Start       *float64 `json:"start,omitempty"`     // Beats
+	End         *float64 `json:"end,omitempty"`
+	StartBar    *float64 `json:"start_bar,omitempty"`
+	EndBar      *float64 `json:"end_bar,omitempty"`
+	BeatsPerBar float64  `json:"beats_per_bar,omitempty"` // Defaults to 4
+	BeatUnit    int      `json:"beat_unit,omitempty"`     // Note value of a beat (8 in 6/8); defaults to 4
+	Samples     int      `json:"samples,omitempty"`       // Defaults to DefaultCurveSamples
+}
+
+// CurvePoint is a sampled point. Time is in beats, or in bars when the spec uses start_bar/end_bar.
+type CurvePoint struct {
+	Time  float64 `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// SampleCurve evaluates spec at evenly spaced points from its start to its end, both included.
+//
+// fade_in and fade_out go from 0 to 1 and back (normalized gain) unless from/to are given;
+// ramp, exp_in and exp_out go from from to to (default 0 to 1). sine, saw and square swing
+// ±amplitude around 0, or around the midpoint of from and to when both are given.
+func SampleCurve(spec CurveSpec) ([]CurvePoint, error) {
+	curve := strings.ToLower(strings.TrimSpace(spec.Curve))
+	shape, ok := curveShapes[curve]
+	if !ok {
+		return nil, fmt.Errorf("unknown curve %q: must be one of %s", spec.Curve, strings.Join(CurveNames(), ", "))
+	}
+
+	start, end, inBars, err := spec.timeRange()
+	if err != nil {
+		return nil, err
+	}
+	beatsPerBar := spec.BeatsPerBar
+	if beatsPerBar == 0 {
+		beatsPerBar = timeutil.DefaultBeatsPerBar
+	}
+	if beatsPerBar < 0 {
+		return nil, fmt.Errorf("beats_per_bar must be positive, got %g", beatsPerBar)
+	}
+	freq := spec.Freq
+	if spec.Rate != "" {
+		if !oscillatorCurves[curve] {
+			return nil, fmt.Errorf("rate only applies to the sine, saw and square curves, not %q", spec.Curve)
+		}
+		beatUnit := spec.BeatUnit
+		if beatUnit <= 0 {
+			beatUnit = timeutil.DefaultBeatUnit
+		}
+		if freq, err = noteRateFreq(spec.Rate, beatsPerBar, beatUnit); err != nil {
+			return nil, err
+		}
+	}
+	samples := spec.Samples
+	if samples == 0 {
+		samples = DefaultCurveSamples
+	}
+	if samples < 2 || samples > MaxCurveSamples {
+		return nil, fmt.Errorf("samples must be between 2 and %d, got %d", MaxCurveSamples, samples)
+	}
+
+	from, to := 0.0, 1.0
+	if curve == "fade_out" {
+		from, to = 1, 0
+	}
+	if spec.From != nil {
+		from = *spec.From
+	}
+	if spec.To != nil {
+		to = *spec.To
+	}
+	amplitude := 1.0
+	if spec.Amplitude != nil {
+		amplitude = *spec.Amplitude
+	}
+	center := 0.0
+	if spec.From != nil && spec.To != nil {
+		center = (from + to) / 2
+	}
+
+	points := make([]CurvePoint, samples)
+	for i := range points {
+		t := float64(i) / float64(samples-1)
+		time := start + t*(end-start)
+
+		var value float64
+		if shape.periodic {
+			bars := time - start
+			if !inBars {
+				bars /= beatsPerBar
+			}
+			value = center + amplitude*shape.eval(freq*bars+spec.Phase)
+		} else {
+			value = from + (to-from)*shape.eval(t)
+		}
+		points[i] = CurvePoint{Time: time, Value: value}
+	}
+	return points, nil
+}
+
+// CurveNames returns the curves SampleCurve knows, in documentation order
+func CurveNames() []string {
+	return []string{"fade_in", "fade_out", "ramp", "sine", "saw", "square", "exp_in", "exp_out"}
+}
+
+// curveShape maps progress through the curve (0-1) to a fraction of from→to, or, for periodic
+// shapes, the position in cycles to a value in [-1, 1]
+type curveShape struct {
+	eval     func(float64) float64
+	periodic bool
+}
+
+var curveShapes = map[string]curveShape{
+	"fade_in":  {eval: linearShape},
+	"fade_out": {eval: linearShape},
+	"ramp":     {eval: linearShape},
+	"exp_in":   {eval: expInShape},
+	"exp_out":  {eval: func(t float64) float64 { return 1 - expInShape(1-t) }},
+	"sine":     {eval: func(x float64) float64 { return math.Sin(2 * math.Pi * x) }, periodic: true},
+	"saw":      {eval: func(x float64) float64 { return 2*(x-math.Floor(x)) - 1 }, periodic: true},
+	"square": {eval: func(x float64) float64 {
+		if x-math.Floor(x) < 0.5 {
+			return 1
+		}
+		return -1
+	}, periodic: true},
+}
+
+func linearShape(t float64) float64 { return t }
+
+func expInShape(t float64) float64 {
+	return (math.Exp(expCurveSteepness*t) - 1) / (math.Exp(expCurveSteepness) - 1)
+}
+
+// timeRange returns the spec's start and end, and whether they are bars rather than beats
+func (spec CurveSpec) timeRange() (float64, float64, bool, error) {
+	start, end, inBars := spec.Start, spec.End, false
+	if start == nil && end == nil {
+		start, end, inBars = spec.StartBar, spec.EndBar, true
+	}
+	if start == nil || end == nil {
+		return 0, 0, false, fmt.Errorf("curve requires start and end (beats), or start_bar and end_bar")
+	}
+	if *end <= *start {
+		return 0, 0, false, fmt.Errorf("curve end (%g) must be after start (%g)", *end, *start)
+	}
+	return *start, *end, inBars, nil
+}
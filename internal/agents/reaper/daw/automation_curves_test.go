@@ -0,0 +1,112 @@
+package daw
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSampleCurve(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name       string
+		spec       CurveSpec
+		wantTimes  []float64
+		wantValues []float64
+		wantErr    bool
+	}{
+		{
+			name:       "fade in over beats",
+			spec:       CurveSpec{Curve: "fade_in", Start: f(0), End: f(4), Samples: 5},
+			wantTimes:  []float64{0, 1, 2, 3, 4},
+			wantValues: []float64{0, 0.25, 0.5, 0.75, 1},
+		},
+		{
+			name:       "fade out over bars",
+			spec:       CurveSpec{Curve: "fade_out", StartBar: f(8), EndBar: f(12), Samples: 3},
+			wantTimes:  []float64{8, 10, 12},
+			wantValues: []float64{1, 0.5, 0},
+		},
+		{
+			name:       "ramp from to",
+			spec:       CurveSpec{Curve: "ramp", From: f(0.2), To: f(1), Start: f(0), End: f(16), Samples: 3},
+			wantTimes:  []float64{0, 8, 16},
+			wantValues: []float64{0.2, 0.6, 1},
+		},
+		{
+			name:       "exp in and out are mirrored",
+			spec:       CurveSpec{Curve: "exp_out", Start: f(0), End: f(2), Samples: 3},
+			wantTimes:  []float64{0, 1, 2},
+			wantValues: []float64{0, 1 - expInShape(0.5), 1},
+		},
+		{
+			name:       "sine one cycle per bar in beats",
+			spec:       CurveSpec{Curve: "sine", Freq: 1, Start: f(0), End: f(4), Samples: 5},
+			wantTimes:  []float64{0, 1, 2, 3, 4},
+			wantValues: []float64{0, 1, 0, -1, 0},
+		},
+		{
+			name:       "square around from and to",
+			spec:       CurveSpec{Curve: "square", Freq: 1, From: f(0), To: f(1), Amplitude: f(0.5), StartBar: f(1), EndBar: f(2), Samples: 3},
+			wantTimes:  []float64{1, 1.5, 2},
+			wantValues: []float64{1, 0, 1},
+		},
+		{
+			name:       "saw with phase",
+			spec:       CurveSpec{Curve: "saw", Freq: 1, Phase: 0.5, StartBar: f(0), EndBar: f(1), Samples: 2},
+			wantTimes:  []float64{0, 1},
+			wantValues: []float64{0, 0},
+		},
+		{
+			name:       "sine with a note rate",
+			spec:       CurveSpec{Curve: "sine", Rate: "1/2", StartBar: f(0), EndBar: f(1), Samples: 5},
+			wantTimes:  []float64{0, 0.25, 0.5, 0.75, 1},
+			wantValues: []float64{0, 0, 0, 0, 0}, // Two cycles per bar: zero crossings every quarter bar
+		},
+		{
+			name:       "dotted rate in 3/4",
+			spec:       CurveSpec{Curve: "sine", Rate: "1/4D", BeatsPerBar: 3, StartBar: f(0), EndBar: f(0.5), Samples: 5},
+			wantTimes:  []float64{0, 0.125, 0.25, 0.375, 0.5},
+			wantValues: []float64{0, 1, 0, -1, 0}, // A dotted quarter is half a 3/4 bar
+		},
+		{name: "rate on a ramp", spec: CurveSpec{Curve: "ramp", Rate: "1/4", Start: f(0), End: f(4)}, wantErr: true},
+		{name: "unknown curve", spec: CurveSpec{Curve: "wobble", Start: f(0), End: f(4)}, wantErr: true},
+		{name: "missing end", spec: CurveSpec{Curve: "ramp", Start: f(0)}, wantErr: true},
+		{name: "end before start", spec: CurveSpec{Curve: "ramp", StartBar: f(4), EndBar: f(2)}, wantErr: true},
+		{name: "too many samples", spec: CurveSpec{Curve: "ramp", Start: f(0), End: f(4), Samples: MaxCurveSamples + 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SampleCurve(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SampleCurve() expected an error, got %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SampleCurve() error = %v", err)
+			}
+			if len(got) != len(tt.wantValues) {
+				t.Fatalf("SampleCurve() returned %d points, want %d", len(got), len(tt.wantValues))
+			}
+			for i, point := range got {
+				if math.Abs(point.Time-tt.wantTimes[i]) > 1e-9 || math.Abs(point.Value-tt.wantValues[i]) > 1e-9 {
+					t.Errorf("point %d = %+v, want {Time:%g Value:%g}", i, point, tt.wantTimes[i], tt.wantValues[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSampleCurve_DefaultSamples(t *testing.T) {
+	start, end := 0.0, 4.0
+	got, err := SampleCurve(CurveSpec{Curve: "ramp", Start: &start, End: &end})
+	if err != nil {
+		t.Fatalf("SampleCurve() error = %v", err)
+	}
+	if len(got) != DefaultCurveSamples {
+		t.Errorf("SampleCurve() returned %d points, want %d", len(got), DefaultCurveSamples)
+	}
+}
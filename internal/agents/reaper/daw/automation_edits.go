@@ -0,0 +1,104 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// ClearAutomation handles .clear_automation() calls: removes the points of an envelope, within
+// a range (start/end or start_bar/end_bar, as in add_automation) or all of them. The envelope
+// stays, so the parameter keeps its current value.
+// Example: track(id=2).clear_automation(param="volume")
+func (r *ReaperDSL) ClearAutomation(args gs.Args) error {
+	return r.parser.automationEdit("clear_automation", args, nil)
+}
+
+// DeleteEnvelope handles .delete_envelope() calls: removes a parameter's envelope entirely.
+// Example: track(id=1).delete_envelope(param="pan")
+func (r *ReaperDSL) DeleteEnvelope(args gs.Args) error {
+	p := r.parser
+	for _, key := range []string{"start", "end", "start_bar", "end_bar"} {
+		if _, ok := args[key]; ok {
+			return fmt.Errorf("delete_envelope removes the whole envelope and takes no %s", key)
+		}
+	}
+	return p.automationEdit("delete_envelope", args, nil)
+}
+
+// ScaleAutomation handles .scale_automation() calls: multiplies the envelope's point values by
+// factor, optionally within a range. factor=0.5 halves the automation's depth.
+// Example: track(id=3).scale_automation(param="volume", factor=0.5)
+func (r *ReaperDSL) ScaleAutomation(args gs.Args) error {
+	factorValue, ok := args["factor"]
+	if !ok || factorValue.Kind != gs.ValueNumber {
+		return fmt.Errorf("scale_automation requires factor (number)")
+	}
+	if factorValue.Num < 0 {
+		return fmt.Errorf("scale_automation factor must not be negative, got %g", factorValue.Num)
+	}
+	return r.parser.automationEdit("scale_automation", args, map[string]any{"factor": factorValue.Num})
+}
+
+// automationEdit emits actionType for the envelope named by param on the master or the
+// current (or filtered) tracks, with the optional range and extra properties
+func (p *FunctionalDSLParser) automationEdit(actionType string, args gs.Args, extra map[string]any) error {
+	paramValue, ok := args["param"]
+	if !ok || paramValue.Kind != gs.ValueString || strings.TrimSpace(paramValue.Str) == "" {
+		return fmt.Errorf("%s requires param (string)", actionType)
+	}
+	timeRange, err := automationRange(args)
+	if err != nil {
+		return fmt.Errorf("%s %w", actionType, err)
+	}
+
+	var tracks []any
+	if p.onMaster {
+		tracks = []any{MasterTrack}
+	} else {
+		indices, err := p.trackTargets(actionType)
+		if err != nil {
+			return err
+		}
+		for _, index := range indices {
+			tracks = append(tracks, index)
+		}
+	}
+
+	for _, track := range tracks {
+		action := map[string]any{"action": actionType, "track": track, "param": paramValue.Str}
+		for k, v := range timeRange {
+			action[k] = v
+		}
+		for k, v := range extra {
+			action[k] = v
+		}
+		p.actions = append(p.actions, action)
+	}
+	logger.Printf(p.ctx, "✅ %s: tracks=%v, param=%s, range=%v", capitalizeMethodName(actionType), tracks, paramValue.Str, timeRange)
+	return nil
+}
+
+// automationRange reads an optional start/end or start_bar/end_bar range
+func automationRange(args gs.Args) (map[string]any, error) {
+	for _, keys := range [][2]string{{"start", "end"}, {"start_bar", "end_bar"}} {
+		startValue, hasStart := args[keys[0]]
+		endValue, hasEnd := args[keys[1]]
+		if !hasStart && !hasEnd {
+			continue
+		}
+		if !hasStart || !hasEnd || startValue.Kind != gs.ValueNumber || endValue.Kind != gs.ValueNumber {
+			return nil, fmt.Errorf("requires both %s and %s (numbers) for a range", keys[0], keys[1])
+		}
+		if startValue.Num < 0 {
+			return nil, fmt.Errorf("%s must not be negative, got %g", keys[0], startValue.Num)
+		}
+		if endValue.Num <= startValue.Num {
+			return nil, fmt.Errorf("%s (%g) must be after %s (%g)", keys[1], endValue.Num, keys[0], startValue.Num)
+		}
+		return map[string]any{keys[0]: startValue.Num, keys[1]: endValue.Num}, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,86 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_AutomationEdits(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Kick Drum"},
+			map[string]any{"index": 1, "name": "Vocals"},
+			map[string]any{"index": 2, "name": "Snare Drum"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "clear whole envelope",
+			dslCode: `track(id=2).clear_automation(param="volume")`,
+			want:    []map[string]any{{"action": "clear_automation", "track": 1, "param": "volume"}},
+		},
+		{
+			name:    "clear bar range",
+			dslCode: `track(id=2).clear_automation(param="pan", start_bar=9, end_bar=17)`,
+			want: []map[string]any{
+				{"action": "clear_automation", "track": 1, "param": "pan", "start_bar": 9.0, "end_bar": 17.0},
+			},
+		},
+		{
+			name:    "delete envelope on filtered tracks",
+			dslCode: `filter(tracks, track.name contains "drum").delete_envelope(param="mute")`,
+			want: []map[string]any{
+				{"action": "delete_envelope", "track": 0, "param": "mute"},
+				{"action": "delete_envelope", "track": 2, "param": "mute"},
+			},
+		},
+		{
+			name:    "scale in a range",
+			dslCode: `track(id=1).scale_automation(param="volume", factor=0.5, start=0, end=16)`,
+			want: []map[string]any{
+				{"action": "scale_automation", "track": 0, "param": "volume", "factor": 0.5, "start": 0.0, "end": 16.0},
+			},
+		},
+		{
+			name:    "master",
+			dslCode: `master().clear_automation(param="volume")`,
+			want:    []map[string]any{{"action": "clear_automation", "track": "master", "param": "volume"}},
+		},
+		{name: "missing param", dslCode: `track(id=1).clear_automation(start=0, end=4)`, wantErr: true},
+		{name: "half a range", dslCode: `track(id=1).clear_automation(param="volume", start_bar=3)`, wantErr: true},
+		{name: "empty range", dslCode: `track(id=1).clear_automation(param="volume", start=4, end=4)`, wantErr: true},
+		{name: "delete with range", dslCode: `track(id=1).delete_envelope(param="volume", start=0, end=4)`, wantErr: true},
+		{name: "scale without factor", dslCode: `track(id=1).scale_automation(param="volume")`, wantErr: true},
+		{name: "negative factor", dslCode: `track(id=1).scale_automation(param="volume", factor=-1)`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
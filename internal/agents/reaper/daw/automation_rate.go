@@ -0,0 +1,59 @@
+package daw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// oscillatorCurves are the add_automation curves that take freq (or rate)
+var oscillatorCurves = map[string]bool{"sine": true, "saw": true, "square": true}
+
+// noteRateLength parses a note division ("1/4", "1/8T" triplet, "1/16D" or "1/16." dotted, "1"
+// or "2/1" for whole bars of 4/4) into its length in whole notes.
+func noteRateLength(rate string) (float64, error) {
+	s := strings.ToUpper(strings.TrimSpace(rate))
+	modifier := 1.0
+	switch {
+	case strings.HasSuffix(s, "T"):
+		modifier, s = 2.0/3.0, strings.TrimSuffix(s, "T")
+	case strings.HasSuffix(s, "D"), strings.HasSuffix(s, "."):
+		modifier, s = 1.5, s[:len(s)-1]
+	}
+
+	numerator, denominator, found := strings.Cut(s, "/")
+	if !found {
+		denominator = "1"
+	}
+	num, numErr := strconv.Atoi(strings.TrimSpace(numerator))
+	den, denErr := strconv.Atoi(strings.TrimSpace(denominator))
+	if numErr != nil || denErr != nil || num <= 0 || den <= 0 {
+		return 0, fmt.Errorf("invalid rate %q: use a note division like 1/4, 1/8T or 1/16D", rate)
+	}
+	return float64(num) / float64(den) * modifier, nil
+}
+
+// noteRateFreq converts a note division to cycles per bar in a beatsPerBar/beatUnit time
+// signature: a 1/8 rate in 4/4 is 8 cycles per bar, in 6/8 it is 6.
+func noteRateFreq(rate string, beatsPerBar float64, beatUnit int) (float64, error) {
+	length, err := noteRateLength(rate)
+	if err != nil {
+		return 0, err
+	}
+	barLength := beatsPerBar / float64(beatUnit)
+	return barLength / length, nil
+}
+
+// automationRateFreq resolves add_automation's rate against the project's time signature.
+// It also returns the rate in Hz at the project tempo, for logging.
+func (p *FunctionalDSLParser) automationRateFreq(curve, rate string) (freq, hz float64, err error) {
+	if !oscillatorCurves[curve] {
+		return 0, 0, fmt.Errorf("rate only applies to the sine, saw and square curves, not %q", curve)
+	}
+	tempo := p.tempo()
+	freq, err = noteRateFreq(rate, tempo.BeatsPerBar(), tempo.BeatUnit())
+	if err != nil {
+		return 0, 0, err
+	}
+	return freq, freq / tempo.BarsToSeconds(1), nil
+}
@@ -0,0 +1,113 @@
+package daw
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestNoteRateFreq(t *testing.T) {
+	tests := []struct {
+		rate        string
+		beatsPerBar float64
+		beatUnit    int
+		want        float64
+		wantErr     bool
+	}{
+		{rate: "1/4", beatsPerBar: 4, beatUnit: 4, want: 4},
+		{rate: "1/8", beatsPerBar: 4, beatUnit: 4, want: 8},
+		{rate: "1/8T", beatsPerBar: 4, beatUnit: 4, want: 12},
+		{rate: "1/16d", beatsPerBar: 4, beatUnit: 4, want: 16.0 / 1.5},
+		{rate: "1/4.", beatsPerBar: 3, beatUnit: 4, want: 2},
+		{rate: "1/8", beatsPerBar: 6, beatUnit: 8, want: 6},
+		{rate: "1", beatsPerBar: 4, beatUnit: 4, want: 1},
+		{rate: "2/1", beatsPerBar: 4, beatUnit: 4, want: 0.5},
+		{rate: "eighth", wantErr: true},
+		{rate: "1/0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			got, err := noteRateFreq(tt.rate, tt.beatsPerBar, tt.beatUnit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("noteRateFreq(%q) error = %v, wantErr %v", tt.rate, err, tt.wantErr)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("noteRateFreq(%q, %g, %d) = %g, want %g", tt.rate, tt.beatsPerBar, tt.beatUnit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_AutomationRate(t *testing.T) {
+	tracks := []any{map[string]any{"index": 0, "name": "Pad"}}
+
+	tests := []struct {
+		name    string
+		project map[string]any
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "eighth note tremolo in 4/4",
+			dslCode: `track(id=1).add_automation(param="volume", curve="sine", rate="1/8", amplitude=0.5, start_bar=1, end_bar=5)`,
+			want: []map[string]any{{
+				"action": "add_automation", "track": 0, "param": "volume", "curve": "sine",
+				"freq": 8.0, "amplitude": 0.5, "start_bar": 1.0, "end_bar": 5.0,
+			}},
+		},
+		{
+			name:    "quarter note pump in 6/8",
+			project: map[string]any{"bpm": 90.0, "time_signature": "6/8"},
+			dslCode: `track(id=1).add_automation(param="volume", curve="saw", rate="1/4", start_bar=1, end_bar=3)`,
+			want: []map[string]any{{
+				"action": "add_automation", "track": 0, "param": "volume", "curve": "saw",
+				"freq": 3.0, "start_bar": 1.0, "end_bar": 3.0,
+			}},
+		},
+		{
+			name:    "rate and freq",
+			dslCode: `track(id=1).add_automation(param="pan", curve="sine", freq=2, rate="1/8", start=0, end=4)`,
+			wantErr: true,
+		},
+		{
+			name:    "rate on a ramp",
+			dslCode: `track(id=1).add_automation(param="pan", curve="ramp", rate="1/8", start=0, end=4)`,
+			wantErr: true,
+		},
+		{
+			name:    "bad rate",
+			dslCode: `track(id=1).add_automation(param="pan", curve="sine", rate="fast", start=0, end=4)`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			state := map[string]any{"tracks": tracks}
+			if tt.project != nil {
+				state["project"] = tt.project
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package daw
+
+// chainContext is the collection a statement's filter() or nth_clip() call selected for the
+// method chained after it. It belongs to the running statement: ParseDSL executes statements
+// one at a time (see executeStatements), so a selection never reaches a later statement, and
+// the first chained method that applies to it takes it (see takeChain).
+type chainContext struct {
+	items []any
+	from  string // Collection the items were selected from, e.g. tracks, clips or fx_chain
+}
+
+// selectForChain hands items to the next method chained in the running statement
+func (p *FunctionalDSLParser) selectForChain(items []any, from string) {
+	p.chain = &chainContext{items: items, from: from}
+}
+
+// takeChain returns the running statement's selection, or nil without one, and clears it so
+// only the method chained right after the selection applies to it
+func (p *FunctionalDSLParser) takeChain() *chainContext {
+	chain := p.chain
+	p.chain = nil
+	return chain
+}
+
+// takeFiltered returns the items of the running statement's selection and clears it (see
+// takeChain)
+func (p *FunctionalDSLParser) takeFiltered() []any {
+	if chain := p.takeChain(); chain != nil {
+		return chain.items
+	}
+	return nil
+}
+
+// executeStatements executes code one statement at a time (see scanDSLStatements), each
+// starting without a selection. It stops once the request context is done, e.g. past the
+// route's timeout.
+func (p *FunctionalDSLParser) executeStatements(code string) error {
+	defer func() { p.chain = nil }()
+	statements, _ := scanDSLStatements(code)
+	for _, statement := range statements {
+		if p.ctx != nil && p.ctx.Err() != nil {
+			return p.ctx.Err()
+		}
+		p.chain = nil
+		if err := p.engine.execute(p.reaperDSL, p.syntax, code[statement.start:statement.end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package daw
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// A statement's filter() or nth_clip() applies to its own chained method only, never to a later
+// statement, whether or not that method took the selection
+func TestFunctionalDSLParser_SelectionStaysInItsStatement(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Kick Drum"},
+			map[string]any{"index": 1, "name": "Snare Drum"},
+			map[string]any{
+				"index": 2,
+				"name":  "Bass",
+				"clips": []any{
+					map[string]any{"index": 0, "track": 2, "position": 0.0, "length": 4.0},
+					map[string]any{"index": 1, "track": 2, "position": 8.0, "length": 4.0},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+	}{
+		{
+			name:    "filtered add_fx, then a single track",
+			dslCode: "filter(tracks, track.name contains \"Drum\").add_fx(fxname=\"ReaComp\")\ntrack(id=3).set_track(mute=true)",
+			want: []map[string]any{
+				{"action": "add_track_fx", "track": 0, "fxname": "ReaComp"},
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaComp"},
+				{"action": "set_track", "track": 2, "mute": true},
+			},
+		},
+		{
+			name:    "filter without a chained method",
+			dslCode: `filter(tracks, track.name contains "Drum");track(id=3).delete()`,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 2},
+			},
+		},
+		{
+			name:    "nth_clip without a clip operation",
+			dslCode: "track(id=3).nth_clip(2)\ntrack(id=3).delete_clip(clip=0)",
+			want: []map[string]any{
+				{"action": "delete_clip", "track": 2, "clip": 0},
+			},
+		},
+		{
+			name:    "filtered set_fx_param, then a single track",
+			dslCode: `filter(tracks, track.name contains "Drum").set_fx_param(fx="ReaComp", param="Thresh", value=0.5);track(id=3).set_track(solo=true)`,
+			want: []map[string]any{
+				{"action": "set_fx_param", "track": 0, "fxname": "ReaComp", "param": "Thresh", "value": 0.5},
+				{"action": "set_fx_param", "track": 1, "fxname": "ReaComp", "param": "Thresh", "value": 0.5},
+				{"action": "set_track", "track": 2, "solo": true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_SelectionDoesNotOutliveParse(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums"},
+			map[string]any{"index": 1, "name": "Bass"},
+		},
+	})
+
+	if _, err := parser.ParseDSL(`filter(tracks, track.name == "Drums")`); err == nil {
+		t.Fatal("ParseDSL() of a filter without a chained method should produce no actions")
+	}
+	got, err := parser.ParseDSL(`track(id=2).set_track(mute=true)`)
+	if err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	want := []map[string]any{{"action": "set_track", "track": 1, "mute": true}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDSL() = %v, want %v", got, want)
+	}
+}
+
+func TestFunctionalDSLParser_StopsPastDeadline(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	parser.SetContext(ctx)
+
+	if _, err := parser.ParseDSL(`track(name="Bass")`); !errors.Is(err, context.Canceled) {
+		t.Fatalf("ParseDSL() with a done context error = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,100 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// A clarification asks the user a question instead of guessing:
+//
+//	clarify(question="Which track should sound punchier?", options=["Drums", "Bass"])
+//
+// It is always the whole script. The Grammar School engine splits list arguments on their
+// commas, so the call is parsed here instead of being dispatched to a ReaperDSL method.
+
+// Clarification returns the question asked by the last ParseDSL call, or nil when it acted
+func (p *FunctionalDSLParser) Clarification() *models.Clarification {
+	return p.clarification
+}
+
+// isClarifyDSL reports whether code is a clarify() script
+func isClarifyDSL(code string) bool {
+	return strings.HasPrefix(strings.TrimSpace(code), "clarify(")
+}
+
+// parseClarification parses clarify(question="...", options=["...", ...])
+func parseClarification(code string) (*models.Clarification, error) {
+	code = strings.TrimSpace(code)
+	open := len("clarify")
+	closeIndex, err := matchingParen(code, open)
+	if err != nil {
+		return nil, err
+	}
+	if rest := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(code[closeIndex+1:]), ";")); rest != "" {
+		return nil, fmt.Errorf("clarify() must be the only statement, found %q after it", rest)
+	}
+
+	clarification := &models.Clarification{}
+	for _, arg := range splitTopLevel(code[open+1:closeIndex], ",") {
+		if arg = strings.TrimSpace(arg); arg == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(arg, "=")
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(name) {
+		case "question":
+			if clarification.Question, err = unquoteDSLString(value); err != nil {
+				return nil, fmt.Errorf("clarify question: %w", err)
+			}
+		case "options":
+			if clarification.Options, err = parseStringList(value); err != nil {
+				return nil, fmt.Errorf("clarify options: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("unknown clarify argument %q: use question and options", arg)
+		}
+	}
+
+	if strings.TrimSpace(clarification.Question) == "" {
+		return nil, fmt.Errorf("clarify requires a question")
+	}
+	return clarification, nil
+}
+
+// unquoteDSLString returns the text of a quoted DSL string like "Say \"hi\""
+func unquoteDSLString(s string) (string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", fmt.Errorf("expected a quoted string, got %q", s)
+	}
+	text, end, err := scanString(s, 0)
+	if err != nil {
+		return "", err
+	}
+	if end != len(s) {
+		return "", fmt.Errorf("unexpected %q after string", s[end:])
+	}
+	return text, nil
+}
+
+// parseStringList parses a list of quoted strings like ["Drums", "Bass"]
+func parseStringList(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("expected a list like [\"a\", \"b\"], got %q", s)
+	}
+	inner := strings.TrimSpace(s[1 : len(s)-1])
+	if inner == "" {
+		return nil, nil
+	}
+
+	var items []string
+	for _, item := range splitTopLevel(inner, ",") {
+		text, err := unquoteDSLString(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, text)
+	}
+	return items, nil
+}
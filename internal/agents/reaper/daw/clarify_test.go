@@ -0,0 +1,87 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestFunctionalDSLParser_Clarify(t *testing.T) {
+	tests := []struct {
+		name    string
+		dslCode string
+		want    *models.Clarification
+		wantErr bool
+	}{
+		{
+			name:    "question and options",
+			dslCode: `clarify(question="Which track should sound punchier?", options=["Drums", "Bass"])`,
+			want: &models.Clarification{
+				Question: "Which track should sound punchier?",
+				Options:  []string{"Drums", "Bass"},
+			},
+		},
+		{
+			name:    "question only",
+			dslCode: `clarify(question="Louder by how much?")`,
+			want:    &models.Clarification{Question: "Louder by how much?"},
+		},
+		{
+			name:    "commas and quotes inside strings",
+			dslCode: `clarify(question="The \"Lead\" track, or the pad?", options=["Lead, doubled", "Pad (wide)"]);`,
+			want: &models.Clarification{
+				Question: `The "Lead" track, or the pad?`,
+				Options:  []string{"Lead, doubled", "Pad (wide)"},
+			},
+		},
+		{name: "missing question", dslCode: `clarify(options=["Drums"])`, wantErr: true},
+		{name: "unquoted option", dslCode: `clarify(question="Which?", options=[Drums])`, wantErr: true},
+		{name: "unknown argument", dslCode: `clarify(question="Which?", track=1)`, wantErr: true},
+		{name: "followed by an action", dslCode: `clarify(question="Which?"); track(id=1).delete()`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got clarification %+v", tt.dslCode, parser.Clarification())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if len(actions) != 0 {
+				t.Errorf("ParseDSL() = %v, want no actions", actions)
+			}
+			if !reflect.DeepEqual(parser.Clarification(), tt.want) {
+				t.Errorf("Clarification() = %+v, want %+v", parser.Clarification(), tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_ClarificationResets(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "Drums"}}})
+
+	if _, err := parser.ParseDSL(`clarify(question="Which track?")`); err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	if _, err := parser.ParseDSL(`track(id=1).set_track(mute=true)`); err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	if parser.Clarification() != nil {
+		t.Errorf("Clarification() = %+v after a script that acted, want nil", parser.Clarification())
+	}
+}
@@ -0,0 +1,208 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// clipTarget is one clip an edit applies to
+type clipTarget struct {
+	track int
+	clip  map[string]any // The clip from state, nil when the edit targets a track
+}
+
+// SplitClip handles .split_clip() calls: splits the clip under position (seconds) or bar on
+// the current track, filtered tracks, or filtered clips. Filtered clips that don't span the
+// split point are skipped.
+// Example: filter(clips, clip.length > 0).split_clip(bar=17)
+func (r *ReaperDSL) SplitClip(args gs.Args) error {
+	p := r.parser
+
+	split := map[string]any{}
+	splitSeconds := 0.0
+	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		if barValue.Num < 1 {
+			return fmt.Errorf("split_clip bar must be 1 or greater, got %g", barValue.Num)
+		}
+		split["bar"] = int(barValue.Num)
+		splitSeconds = p.barStart(float64(int(barValue.Num)))
+	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		if positionValue.Num < 0 {
+			return fmt.Errorf("split_clip position must not be negative, got %g", positionValue.Num)
+		}
+		split["position"] = positionValue.Num
+		splitSeconds = positionValue.Num
+	} else {
+		return fmt.Errorf("split_clip requires position (seconds) or bar")
+	}
+
+	targets, err := p.clipEditTargets("split_clip")
+	if err != nil {
+		return err
+	}
+
+	splitTracks := make(map[int]bool)
+	for _, target := range targets {
+		if target.clip != nil && !clipSpans(target.clip, splitSeconds) {
+			continue
+		}
+		// The split point picks the clip, so one split per track is enough
+		if splitTracks[target.track] {
+			continue
+		}
+		splitTracks[target.track] = true
+
+		action := map[string]any{"action": "split_clip", "track": target.track}
+		for k, v := range split {
+			action[k] = v
+		}
+		p.actions = append(p.actions, action)
+	}
+	logger.Printf(r.parser.ctx, "✅ SplitClip: Split %d of %d targets at %+v", len(splitTracks), len(targets), split)
+	return nil
+}
+
+// TrimClip handles .trim_clip() calls: moves a clip's start and/or end edge to a project
+// position, in seconds (start, end) or bars (start_bar, end_bar).
+// Example: track(id=1).trim_clip(clip=0, start=1.5, end=6)
+func (r *ReaperDSL) TrimClip(args gs.Args) error {
+	p := r.parser
+
+	edges := map[string]any{}
+	for _, key := range []string{"start", "end"} {
+		if value, ok := args[key]; ok && value.Kind == gs.ValueNumber {
+			if value.Num < 0 {
+				return fmt.Errorf("trim_clip %s must not be negative, got %g", key, value.Num)
+			}
+			edges[key] = value.Num
+		}
+	}
+	for _, key := range []string{"start_bar", "end_bar"} {
+		if value, ok := args[key]; ok && value.Kind == gs.ValueNumber {
+			if value.Num < 1 {
+				return fmt.Errorf("trim_clip %s must be 1 or greater, got %g", key, value.Num)
+			}
+			edges[key] = value.Num
+		}
+	}
+	if len(edges) == 0 {
+		return fmt.Errorf("trim_clip requires start, end, start_bar, or end_bar")
+	}
+	for _, pair := range [][2]string{{"start", "end"}, {"start_bar", "end_bar"}} {
+		start, hasStart := edges[pair[0]].(float64)
+		end, hasEnd := edges[pair[1]].(float64)
+		if hasStart && hasEnd && end <= start {
+			return fmt.Errorf("trim_clip %s (%g) must be after %s (%g)", pair[1], end, pair[0], start)
+		}
+	}
+
+	targets, err := p.clipEditTargets("trim_clip")
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		action := map[string]any{"action": "trim_clip", "track": target.track}
+		if err := identifyEditedClip(target, args, action); err != nil {
+			return fmt.Errorf("trim_clip %w", err)
+		}
+		for k, v := range edges {
+			action[k] = v
+		}
+		p.actions = append(p.actions, action)
+	}
+	return nil
+}
+
+// SetClipLoop handles .set_clip_loop() calls: turns looping on or off, optionally with the loop
+// source length (in the request's length unit). It is shorthand for set_clip(loop=..., source_length=...).
+// Example: filter(clips, clip.selected == true).set_clip_loop(enabled=true, loop_length=2)
+func (r *ReaperDSL) SetClipLoop(args gs.Args) error {
+	enabledValue, ok := args["enabled"]
+	if !ok || enabledValue.Kind != gs.ValueBool {
+		return fmt.Errorf("set_clip_loop requires enabled (true or false)")
+	}
+
+	setClipArgs := gs.Args{"loop": enabledValue}
+	if loopLengthValue, ok := args["loop_length"]; ok && loopLengthValue.Kind == gs.ValueNumber {
+		setClipArgs["source_length"] = loopLengthValue
+	}
+	for _, key := range []string{"clip", "position", "bar"} {
+		if value, ok := args[key]; ok {
+			setClipArgs[key] = value
+		}
+	}
+	return r.SetClip(setClipArgs)
+}
+
+// clipEditTargets returns the clips an edit applies to: the filtered clips, each filtered track,
+// or the current track
+func (p *FunctionalDSLParser) clipEditTargets(actionType string) ([]clipTarget, error) {
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		targets := make([]clipTarget, 0, len(filtered))
+		for _, item := range filtered {
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			_, hasTrack := itemMap["track"]
+			_, hasLength := itemMap["length"]
+			_, hasPosition := itemMap["position"]
+			if hasTrack && (hasLength || hasPosition) {
+				if trackIndex, ok := actionInt(itemMap, "track"); ok {
+					targets = append(targets, clipTarget{track: trackIndex, clip: itemMap})
+				}
+				continue
+			}
+			if trackIndex, ok := actionInt(itemMap, "index"); ok {
+				targets = append(targets, clipTarget{track: trackIndex})
+			}
+		}
+		return targets, nil
+	}
+
+	if p.currentTrackIndex < 0 {
+		return nil, fmt.Errorf("no track context for %s call", actionType)
+	}
+	return []clipTarget{{track: p.currentTrackIndex}}, nil
+}
+
+// identifyEditedClip sets the clip identifier on action: from the filtered clip, or from the
+// clip, position, or bar argument
+func identifyEditedClip(target clipTarget, args gs.Args, action map[string]any) error {
+	if target.clip != nil {
+		if position, ok := getNumericValue(target.clip["position"]); ok {
+			action["position"] = position
+			return nil
+		}
+		if clipIndex, ok := actionInt(target.clip, "index"); ok {
+			action["clip"] = clipIndex
+			return nil
+		}
+		return fmt.Errorf("could not identify clip (no index or position): %+v", target.clip)
+	}
+
+	if clipValue, ok := args["clip"]; ok && clipValue.Kind == gs.ValueNumber {
+		action["clip"] = int(clipValue.Num)
+	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		action["position"] = positionValue.Num
+	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		action["bar"] = int(barValue.Num)
+	} else {
+		return fmt.Errorf("requires one of: clip (index), position (seconds), or bar (number)")
+	}
+	return nil
+}
+
+// clipSpans reports whether seconds falls strictly inside a clip. Clips without a known
+// position and length are assumed to span it.
+func clipSpans(clip map[string]any, seconds float64) bool {
+	position, hasPosition := getNumericValue(clip["position"])
+	length, hasLength := getNumericValue(clip["length"])
+	if !hasPosition || !hasLength {
+		return true
+	}
+	return seconds > position && seconds < position+length
+}
@@ -0,0 +1,117 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_ClipEdits(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"project": map[string]any{"bpm": 120.0, "time_signature": "4/4"},
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Drums", "clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 64.0, "selected": false},
+				}},
+				map[string]any{"index": 1, "name": "Bass", "clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 16.0, "selected": false},
+					map[string]any{"index": 1, "position": 24.0, "length": 16.0, "selected": true},
+				}},
+				map[string]any{"index": 2, "name": "Pad", "clips": []any{
+					map[string]any{"index": 0, "position": 40.0, "length": 8.0, "selected": false},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "split all clips at bar 17 across tracks",
+			dslCode: `filter(clips, clip.length > 0).split_clip(bar=17)`,
+			want: []map[string]any{
+				{"action": "split_clip", "track": 0, "bar": 17},
+				{"action": "split_clip", "track": 1, "bar": 17},
+			},
+		},
+		{
+			name:    "split current track at a position",
+			dslCode: `track(id=3).split_clip(position=44.0)`,
+			want: []map[string]any{
+				{"action": "split_clip", "track": 2, "position": 44.0},
+			},
+		},
+		{
+			name:    "trim a clip by bar",
+			dslCode: `track(id=2).trim_clip(bar=1, end_bar=5)`,
+			want: []map[string]any{
+				{"action": "trim_clip", "track": 1, "bar": 1, "end_bar": 5.0},
+			},
+		},
+		{
+			name:    "trim filtered clips uses their positions",
+			dslCode: `filter(clips, clip.selected == true).trim_clip(start=26, end=38)`,
+			want: []map[string]any{
+				{"action": "trim_clip", "track": 1, "position": 24.0, "start": 26.0, "end": 38.0},
+			},
+		},
+		{
+			name:    "loop the selected clip",
+			dslCode: `filter(clips, clip.selected == true).set_clip_loop(enabled=true)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 1, "position": 24.0, "loop": true},
+			},
+		},
+		{
+			name:    "loop with a source length",
+			dslCode: `track(id=1).set_clip_loop(enabled=true, loop_length=4, clip=0)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 0, "loop": true, "source_length": 4.0},
+			},
+		},
+		{
+			name:    "trim end must be after start",
+			dslCode: `track(id=1).trim_clip(clip=0, start=6, end=2)`,
+			wantErr: "trim_clip end (2) must be after start (6)",
+		},
+		{
+			name:    "trim needs an edge",
+			dslCode: `track(id=1).trim_clip(clip=0)`,
+			wantErr: "trim_clip requires start, end, start_bar, or end_bar",
+		},
+		{
+			name:    "trim needs a clip on a track",
+			dslCode: `track(id=1).trim_clip(end=4)`,
+			wantErr: "trim_clip requires one of",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package daw
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// noteEdit computes a clip's new MIDI notes from the notes in state. notes are copies, sorted by
+// start, and may be changed in place.
+type noteEdit func(clip map[string]any, notes []map[string]any) ([]map[string]any, error)
+
+// noteStartTolerance is how close two note starts (beats) or clip starts (seconds) are to
+// count as the same
+const noteStartTolerance = 1e-6
+
+// TransposeClip handles .transpose_clip() calls: moves the MIDI notes of existing clips by
+// semitones (negative is down).
+// Example: filter(tracks, track.name == "Bass").transpose_clip(semitones=12)
+func (r *ReaperDSL) TransposeClip(args gs.Args) error {
+	semitonesValue, ok := args["semitones"]
+	if !ok || semitonesValue.Kind != gs.ValueNumber {
+		return fmt.Errorf("transpose_clip requires semitones, e.g. transpose_clip(semitones=12)")
+	}
+	semitones := int(semitonesValue.Num)
+	if float64(semitones) != semitonesValue.Num {
+		return fmt.Errorf("transpose_clip semitones must be a whole number, got %g", semitonesValue.Num)
+	}
+
+	return r.parser.editClipNotes("transpose_clip", args, func(_ map[string]any, notes []map[string]any) ([]map[string]any, error) {
+		for _, note := range notes {
+			pitch, ok := actionInt(note, "pitch")
+			if !ok {
+				return nil, fmt.Errorf("transpose_clip: a note in the clip has no pitch: %v", note)
+			}
+			if pitch+semitones < 0 || pitch+semitones > 127 {
+				return nil, fmt.Errorf("transpose_clip by %d semitones moves pitch %d outside the MIDI range 0-127", semitones, pitch)
+			}
+			note["pitch"] = pitch + semitones
+		}
+		return notes, nil
+	})
+}
+
+// ReverseClip handles .reverse_clip() calls: plays a clip's MIDI notes backwards, mirroring
+// them in the clip so the last note ends up first.
+// Example: track(id=2).nth_clip(1).reverse_clip()
+func (r *ReaperDSL) ReverseClip(args gs.Args) error {
+	p := r.parser
+	return p.editClipNotes("reverse_clip", args, func(clip map[string]any, notes []map[string]any) ([]map[string]any, error) {
+		clipBeats := p.clipLengthBeats(clip, notes)
+		for _, note := range notes {
+			start, _ := getNumericValue(note["start"])
+			length, _ := getNumericValue(note["length"])
+			note["start"] = max(0, clipBeats-start-length)
+		}
+		sortNotes(notes)
+		return notes, nil
+	})
+}
+
+// LegatoClip handles .legato_clip() calls: lengthens (or shortens) each MIDI note to reach the
+// next note's start. Notes starting together are chords and end together; the last notes keep
+// their length.
+// Example: filter(clips, clip.selected == true).legato_clip()
+func (r *ReaperDSL) LegatoClip(args gs.Args) error {
+	return r.parser.editClipNotes("legato_clip", args, func(_ map[string]any, notes []map[string]any) ([]map[string]any, error) {
+		for i, note := range notes {
+			start, _ := getNumericValue(note["start"])
+			for _, next := range notes[i+1:] {
+				if nextStart, _ := getNumericValue(next["start"]); nextStart > start+noteStartTolerance {
+					note["length"] = nextStart - start
+					break
+				}
+			}
+		}
+		return notes, nil
+	})
+}
+
+// RemoveNotes handles .remove_notes() calls: deletes the MIDI notes matching a predicate on
+// note (pitch, velocity, start and length, in beats from the clip's start). Like filter's,
+// the predicate is parsed before RemoveNotes runs and arrives as predicate=N.
+// Example: filter(tracks, track.name == "Drums").remove_notes(note.velocity < 30)
+func (r *ReaperDSL) RemoveNotes(args gs.Args) error {
+	p := r.parser
+	predValue, ok := args["predicate"]
+	if !ok || predValue.Kind != gs.ValueNumber || int(predValue.Num) < 0 || int(predValue.Num) >= len(p.predicates) {
+		return fmt.Errorf("remove_notes requires a predicate on note, e.g. remove_notes(note.pitch > 72)")
+	}
+	fp := p.predicates[int(predValue.Num)]
+
+	return p.editClipNotes("remove_notes", args, func(_ map[string]any, notes []map[string]any) ([]map[string]any, error) {
+		kept := notes[:0]
+		for _, note := range notes {
+			matched, err := fp.pred.match(exprVars{"note": note})
+			if err != nil {
+				return nil, fmt.Errorf("remove_notes predicate %q: %w", fp.src, err)
+			}
+			if !matched {
+				kept = append(kept, note)
+			}
+		}
+		return kept, nil
+	})
+}
+
+// editClipNotes applies edit to the MIDI notes of each clip the call targets and replaces
+// them with a set_clip_notes action. Clips without notes in state are skipped; it is an error
+// when none of the targeted clips has any.
+func (p *FunctionalDSLParser) editClipNotes(actionType string, args gs.Args, edit noteEdit) error {
+	targets, err := p.noteEditTargets(actionType, args)
+	if err != nil {
+		return err
+	}
+
+	edited := 0
+	for _, target := range targets {
+		notes, ok := stateClipNotes(target.clip)
+		if !ok {
+			continue
+		}
+		newNotes, err := edit(target.clip, notes)
+		if err != nil {
+			return err
+		}
+
+		action := map[string]any{"action": "set_clip_notes", "track": target.track, "notes": newNotes}
+		if err := identifyEditedClip(target, args, action); err != nil {
+			return fmt.Errorf("%s %w", actionType, err)
+		}
+		p.actions = append(p.actions, action)
+		edited++
+	}
+	if edited == 0 {
+		return fmt.Errorf("%s needs the clip's MIDI notes, but the state has none for the targeted clips", actionType)
+	}
+	logger.Printf(p.ctx, "✅ %s: Edited the notes of %d of %d clips", actionType, edited, len(targets))
+	return nil
+}
+
+// noteEditTargets returns the clips from state a note edit applies to: the filtered clips, or
+// on each filtered track (or the current track) the clip picked by clip, position or bar, or
+// else every clip on the track
+func (p *FunctionalDSLParser) noteEditTargets(actionType string, args gs.Args) ([]clipTarget, error) {
+	targets, err := p.clipEditTargets(actionType)
+	if err != nil {
+		return nil, err
+	}
+
+	var clips []clipTarget
+	for _, target := range targets {
+		if target.clip != nil {
+			clips = append(clips, target)
+			continue
+		}
+		trackClips, err := p.trackClipsByPosition(target.track)
+		if err != nil {
+			if len(targets) == 1 {
+				return nil, fmt.Errorf("%s: %w", actionType, err)
+			}
+			continue // Other filtered tracks may have clips
+		}
+		picked, identified := p.pickClip(trackClips, args)
+		if identified && picked == nil {
+			return nil, fmt.Errorf("%s: no clip matching %s on track %d", actionType, describeClipArgs(args), target.track+1)
+		}
+		if picked != nil {
+			clips = append(clips, clipTarget{track: target.track, clip: picked})
+			continue
+		}
+		for _, clip := range trackClips {
+			clips = append(clips, clipTarget{track: target.track, clip: clip})
+		}
+	}
+	return clips, nil
+}
+
+// pickClip returns the clip the clip (index), position or bar argument identifies: for a
+// position, the clip starting there or else the clip playing over it. identified is false
+// when the call has none of them.
+func (p *FunctionalDSLParser) pickClip(clips []map[string]any, args gs.Args) (clip map[string]any, identified bool) {
+	if clipValue, ok := args["clip"]; ok && clipValue.Kind == gs.ValueNumber {
+		for _, clip := range clips {
+			if index, ok := actionInt(clip, "index"); ok && index == int(clipValue.Num) {
+				return clip, true
+			}
+		}
+		return nil, true
+	}
+
+	var seconds float64
+	if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		seconds = positionValue.Num
+	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		seconds = p.barStart(barValue.Num)
+	} else {
+		return nil, false
+	}
+	for _, clip := range clips {
+		if position, ok := getNumericValue(clip["position"]); ok && position > seconds-noteStartTolerance && position < seconds+noteStartTolerance {
+			return clip, true
+		}
+	}
+	for _, clip := range clips {
+		if clipSpans(clip, seconds) {
+			return clip, true
+		}
+	}
+	return nil, true
+}
+
+// describeClipArgs names the clip identifier of a call for errors, e.g. bar=5
+func describeClipArgs(args gs.Args) string {
+	for _, key := range []string{"clip", "position", "bar"} {
+		if value, ok := args[key]; ok && value.Kind == gs.ValueNumber {
+			return fmt.Sprintf("%s=%g", key, value.Num)
+		}
+	}
+	return "the call"
+}
+
+// stateClipNotes returns copies of a clip's MIDI notes from state, sorted by start. ok is
+// false when the state doesn't include the clip's notes.
+func stateClipNotes(clip map[string]any) (notes []map[string]any, ok bool) {
+	items, ok := clip["notes"].([]any)
+	if !ok {
+		return nil, false
+	}
+	notes = make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if note, ok := item.(map[string]any); ok {
+			notes = append(notes, maps.Clone(note))
+		}
+	}
+	sortNotes(notes)
+	return notes, true
+}
+
+// sortNotes sorts notes by start, then pitch
+func sortNotes(notes []map[string]any) {
+	sort.SliceStable(notes, func(i, j int) bool {
+		si, _ := getNumericValue(notes[i]["start"])
+		sj, _ := getNumericValue(notes[j]["start"])
+		if si != sj {
+			return si < sj
+		}
+		pi, _ := actionInt(notes[i], "pitch")
+		pj, _ := actionInt(notes[j], "pitch")
+		return pi < pj
+	})
+}
+
+// clipLengthBeats returns a clip's length in beats, following tempo changes, or the end of
+// its last note when state doesn't give the clip's position and length
+func (p *FunctionalDSLParser) clipLengthBeats(clip map[string]any, notes []map[string]any) float64 {
+	position, hasPosition := getNumericValue(clip["position"])
+	length, hasLength := getNumericValue(clip["length"])
+	if hasPosition && hasLength && length > 0 {
+		tempo := p.tempo()
+		return tempo.SecondsToBeat(position+length) - tempo.SecondsToBeat(position)
+	}
+	end := 0.0
+	for _, note := range notes {
+		start, _ := getNumericValue(note["start"])
+		noteLength, _ := getNumericValue(note["length"])
+		end = max(end, start+noteLength)
+	}
+	return end
+}
@@ -0,0 +1,132 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_ClipNoteEdits(t *testing.T) {
+	// At 120 BPM the 2s bass clip is 4 beats long
+	newState := func() map[string]any {
+		return map[string]any{
+			"project": map[string]any{"bpm": 120.0, "time_signature": "4/4"},
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Drums", "clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 8.0},
+				}},
+				map[string]any{"index": 1, "name": "Bass", "clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 2.0, "selected": true, "notes": []any{
+						map[string]any{"pitch": 36.0, "velocity": 100.0, "start": 0.0, "length": 1.0},
+						map[string]any{"pitch": 43.0, "velocity": 90.0, "start": 1.0, "length": 0.5},
+						map[string]any{"pitch": 48.0, "velocity": 80.0, "start": 3.0, "length": 1.0},
+					}},
+					map[string]any{"index": 1, "position": 4.0, "length": 2.0}, // Audio, no notes
+				}},
+			},
+		}
+	}
+	note := func(pitch, velocity int, start, length float64) map[string]any {
+		return map[string]any{"pitch": pitch, "velocity": velocity, "start": start, "length": length}
+	}
+	setNotes := func(notes ...map[string]any) []map[string]any {
+		return []map[string]any{{"action": "set_clip_notes", "track": 1, "position": 0.0, "notes": notes}}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		state   func(map[string]any) // Edits the state after SetState normalized it
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "transpose the bass clip up an octave",
+			dslCode: `filter(tracks, track.name == "Bass").transpose_clip(semitones=12)`,
+			want:    setNotes(note(48, 100, 0, 1), note(55, 90, 1, 0.5), note(60, 80, 3, 1)),
+		},
+		{
+			name:    "transpose down",
+			dslCode: `track(id=2).transpose_clip(semitones=-5, clip=0)`,
+			want:    setNotes(note(31, 100, 0, 1), note(38, 90, 1, 0.5), note(43, 80, 3, 1)),
+		},
+		{
+			name:    "reverse mirrors notes in the clip",
+			dslCode: `track(id=2).nth_clip(1).reverse_clip()`,
+			want:    setNotes(note(48, 80, 0, 1), note(43, 90, 2.5, 0.5), note(36, 100, 3, 1)),
+		},
+		{
+			name:    "legato stretches notes to the next one",
+			dslCode: `filter(clips, clip.selected == true).legato_clip()`,
+			want:    setNotes(note(36, 100, 0, 1), note(43, 90, 1, 2), note(48, 80, 3, 1)),
+		},
+		{
+			name:    "remove quiet notes",
+			dslCode: `track(id=2).remove_notes(note.velocity < 95 && note.pitch != 36)`,
+			want:    setNotes(note(36, 100, 0, 1)),
+		},
+		{
+			name:    "clip picked by bar",
+			dslCode: `track(id=2).legato_clip(bar=1)`,
+			want:    setNotes(note(36, 100, 0, 1), note(43, 90, 1, 2), note(48, 80, 3, 1)),
+		},
+		{
+			name:    "transpose beyond the MIDI range",
+			dslCode: `track(id=2).transpose_clip(semitones=100)`,
+			wantErr: "moves pitch 36 outside the MIDI range 0-127",
+		},
+		{
+			name:    "transpose a note without a pitch",
+			dslCode: `filter(clips, clip.selected == true).transpose_clip(semitones=12)`,
+			state: func(state map[string]any) {
+				bass := state["tracks"].([]any)[1].(map[string]any)
+				clip := bass["clips"].([]any)[0].(map[string]any)
+				delete(clip["notes"].([]any)[1].(map[string]any), "pitch")
+			},
+			wantErr: "a note in the clip has no pitch",
+		},
+		{
+			name:    "clips without notes in state",
+			dslCode: `track(id=1).reverse_clip()`,
+			wantErr: "reverse_clip needs the clip's MIDI notes",
+		},
+		{
+			name:    "no clip at the position",
+			dslCode: `track(id=2).reverse_clip(position=30)`,
+			wantErr: "reverse_clip: no clip matching position=30 on track 2",
+		},
+		{
+			name:    "remove_notes predicate must test notes",
+			dslCode: `track(id=2).remove_notes(clip.length > 1)`,
+			wantErr: "must test note properties",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			state := newState()
+			parser.SetState(state)
+			if tt.state != nil {
+				tt.state(state)
+			}
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package daw
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// NthClip handles .nth_clip() calls: selects the Nth clip (1-based, ordered by position)
+// on the current track so the next clip operation applies to it.
+// Example: track(id=1).nth_clip(2).set_clip(color="red")
+func (r *ReaperDSL) NthClip(args gs.Args) error {
+	p := r.parser
+
+	// Grammar School passes positional args (nth_clip(2)) as raw strings under the empty key
+	var number float64
+	if nValue, ok := args["n"]; ok && nValue.Kind == gs.ValueNumber {
+		number = nValue.Num
+	} else if posValue, ok := args[""]; ok && posValue.Kind == gs.ValueString {
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(posValue.Str), 64)
+		if err != nil {
+			return fmt.Errorf("nth_clip requires a clip number, e.g. nth_clip(2), got %q", posValue.Str)
+		}
+		number = parsed
+	} else {
+		return fmt.Errorf("nth_clip requires a clip number, e.g. nth_clip(2)")
+	}
+	n := int(number)
+	if float64(n) != number || n < 1 {
+		return fmt.Errorf("nth_clip number must be a whole number starting at 1 (first clip), got %g", number)
+	}
+
+	if p.currentTrackIndex < 0 {
+		return fmt.Errorf("no track context for nth_clip call")
+	}
+
+	clips, err := p.trackClipsByPosition(p.currentTrackIndex)
+	if err != nil {
+		return err
+	}
+	if n > len(clips) {
+		return fmt.Errorf("nth_clip(%d) is out of range: track %d has %d clip(s)", n, p.currentTrackIndex+1, len(clips))
+	}
+
+	// Select the clip so the chained set_clip/delete_clip/move_clip applies to it only
+	p.selectForChain([]any{clips[n-1]}, "clips")
+	logger.Printf(r.parser.ctx, "🎯 NthClip: Selected clip %d of %d on track %d", n, len(clips), p.currentTrackIndex)
+	return nil
+}
+
+// trackClipsByPosition returns the clips on a track from state, sorted by position.
+// Each clip carries its track index so clip operations can target it.
+func (p *FunctionalDSLParser) trackClipsByPosition(trackIndex int) ([]map[string]any, error) {
+	tracks, _ := p.data["tracks"].([]any)
+
+	var track map[string]any
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index := i
+		if v, ok := getNumericValue(trackMap["index"]); ok {
+			index = int(v)
+		}
+		if index == trackIndex {
+			track = trackMap
+			break
+		}
+	}
+	if track == nil {
+		return nil, fmt.Errorf("track %d not found in state", trackIndex+1)
+	}
+
+	rawClips, _ := track["clips"].([]any)
+	clips := make([]map[string]any, 0, len(rawClips))
+	for _, item := range rawClips {
+		if clipMap, ok := item.(map[string]any); ok {
+			clipMap = maps.Clone(clipMap)
+			clipMap["track"] = trackIndex
+			clips = append(clips, clipMap)
+		}
+	}
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("track %d has no clips", trackIndex+1)
+	}
+
+	sort.SliceStable(clips, func(i, j int) bool {
+		pi, _ := getNumericValue(clips[i]["position"])
+		pj, _ := getNumericValue(clips[j]["position"])
+		return pi < pj
+	})
+	return clips, nil
+}
@@ -0,0 +1,96 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_NthClip(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Drums", "clips": []any{
+					// Deliberately out of position order
+					map[string]any{"index": 0, "position": 8.0, "length": 4.0},
+					map[string]any{"index": 1, "position": 0.0, "length": 4.0},
+					map[string]any{"index": 2, "position": 4.0, "length": 4.0},
+				}},
+				map[string]any{"index": 1, "name": "Empty"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "second clip by position",
+			dslCode: `track(id=1).nth_clip(2).set_clip(color="red")`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "position": 4.0, "color": "#ff0000"},
+			},
+		},
+		{
+			name:    "first clip is 1",
+			dslCode: `track(id=1).nth_clip(1).delete_clip()`,
+			want: []map[string]any{
+				{"action": "delete_clip", "track": 0, "position": 0.0},
+			},
+		},
+		{
+			name:    "last clip moved",
+			dslCode: `track(id=1).nth_clip(3).move_clip(position=16)`,
+			want: []map[string]any{
+				{"action": "set_clip_position", "track": 0, "old_position": 8.0, "position": 16.0},
+			},
+		},
+		{
+			name:    "zero is rejected",
+			dslCode: `track(id=1).nth_clip(0).set_clip(color="red")`,
+			wantErr: "starting at 1",
+		},
+		{
+			name:    "out of range",
+			dslCode: `track(id=1).nth_clip(4).set_clip(color="red")`,
+			wantErr: "out of range: track 1 has 3 clip(s)",
+		},
+		{
+			name:    "track without clips",
+			dslCode: `track(id=2).nth_clip(1).set_clip(color="red")`,
+			wantErr: "track 2 has no clips",
+		},
+		{
+			name:    "track missing from state",
+			dslCode: `track(id=5).nth_clip(1).set_clip(color="red")`,
+			wantErr: "track 5 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package daw
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// colorNames maps common color names to hex values
+var colorNames = map[string]string{
+	"red":        "#ff0000",
+	"green":      "#00ff00",
+	"blue":       "#0000ff",
+	"yellow":     "#ffff00",
+	"orange":     "#ffa500",
+	"purple":     "#800080",
+	"pink":       "#ffc0cb",
+	"cyan":       "#00ffff",
+	"magenta":    "#ff00ff",
+	"lime":       "#00ff00",
+	"maroon":     "#800000",
+	"navy":       "#000080",
+	"olive":      "#808000",
+	"teal":       "#008080",
+	"aqua":       "#00ffff",
+	"silver":     "#c0c0c0",
+	"gray":       "#808080",
+	"grey":       "#808080",
+	"black":      "#000000",
+	"white":      "#ffffff",
+	"brown":      "#a52a2a",
+	"violet":     "#ee82ee",
+	"indigo":     "#4b0082",
+	"gold":       "#ffd700",
+	"coral":      "#ff7f50",
+	"salmon":     "#fa8072",
+	"khaki":      "#f0e68c",
+	"tan":        "#d2b48c",
+	"beige":      "#f5f5dc",
+	"ivory":      "#fffff0",
+	"lavender":   "#e6e6fa",
+	"plum":       "#dda0dd",
+	"turquoise":  "#40e0d0",
+	"crimson":    "#dc143c",
+	"darkred":    "#8b0000",
+	"darkgreen":  "#006400",
+	"darkblue":   "#00008b",
+	"lightblue":  "#add8e6",
+	"lightgreen": "#90ee90",
+	"lightgray":  "#d3d3d3",
+	"lightgrey":  "#d3d3d3",
+	"darkgray":   "#a9a9a9",
+	"darkgrey":   "#a9a9a9",
+}
+
+// shadeStep is how much "light X" and "dark X" change a color's lightness
+const shadeStep = 0.2
+
+// colorPalettes are fixed palettes for set_track_colors_bulk, spread across the tracks
+var colorPalettes = map[string][]string{
+	"pastel":     {"#f4a6a6", "#f6c89f", "#f7e59e", "#b8e0a8", "#a8d8e0", "#a9b8f0", "#d0a9f0", "#f0a9d8"},
+	"neon":       {"#ff073a", "#ff9f00", "#f5ff00", "#39ff14", "#00fff7", "#1f51ff", "#bc13fe", "#ff10f0"},
+	"earth":      {"#6b4226", "#8f5b34", "#b5835a", "#c9a66b", "#7d8c4a", "#556b2f", "#8a9a5b", "#a0522d"},
+	"ocean":      {"#03045e", "#023e8a", "#0077b6", "#0096c7", "#00b4d8", "#48cae4", "#90e0ef", "#2a9d8f"},
+	"sunset":     {"#355070", "#6d597a", "#b56576", "#e56b6f", "#eaac8b", "#f4a261", "#e76f51", "#ffb703"},
+	"monochrome": {"#202020", "#404040", "#606060", "#808080", "#a0a0a0", "#c0c0c0", "#e0e0e0"},
+}
+
+// genrePalettes are per-genre presets for set_track_colors_bulk(genre=...)
+var genrePalettes = map[string][]string{
+	"techno":     {"#0b132b", "#1c2541", "#3a506b", "#5bc0be", "#6fffe9", "#9d4edd", "#ff006e"},
+	"house":      {"#ff7b00", "#ff9500", "#ffb700", "#ffd000", "#e85d04", "#f72585", "#7209b7"},
+	"hiphop":     {"#1b1b1b", "#4a4e69", "#9a8c98", "#c9ada7", "#ffd700", "#b8860b", "#800080"},
+	"rock":       {"#2b2d42", "#8d99ae", "#d90429", "#ef233c", "#5c0000", "#3d3d3d", "#edf2f4"},
+	"jazz":       {"#3e2723", "#6d4c41", "#a1887f", "#d4a373", "#1a237e", "#5c6bc0", "#b8860b"},
+	"ambient":    {"#cdb4db", "#bde0fe", "#a2d2ff", "#caf0f8", "#90e0ef", "#b7e4c7", "#e9edc9"},
+	"pop":        {"#ff4d6d", "#ff8fab", "#ffd60a", "#4cc9f0", "#7209b7", "#06d6a0", "#f77f00"},
+	"orchestral": {"#5e1914", "#8b2e16", "#b8860b", "#d4af37", "#2e4a2e", "#4a6741", "#1f3a5f"},
+	"edm":        {"#ff00ff", "#00ffff", "#39ff14", "#ff3131", "#fff01f", "#7f00ff", "#ff6ec7"},
+	"lofi":       {"#c9ada7", "#9a8c98", "#a3b18a", "#dda15e", "#bc6c25", "#8ecae6", "#f2cc8f"},
+}
+
+// genreAliases maps other spellings to genrePalettes keys
+var genreAliases = map[string]string{
+	"hip hop": "hiphop", "hip-hop": "hiphop", "rap": "hiphop", "trap": "hiphop",
+	"classical": "orchestral", "cinematic": "orchestral", "film": "orchestral",
+	"lo-fi": "lofi", "lo fi": "lofi", "chill": "lofi",
+	"dance": "edm", "electronic": "edm", "metal": "rock", "punk": "rock",
+	"deep house": "house", "tech house": "house", "soul": "jazz", "funk": "jazz",
+}
+
+// colorNameToHex converts common color names to hex values. "light X" and "dark X" adjust
+// the lightness of a known color, e.g. "dark orange".
+func colorNameToHex(colorName string) string {
+	name := strings.ToLower(strings.TrimSpace(colorName))
+	if hex, ok := colorNames[strings.ReplaceAll(name, " ", "")]; ok {
+		return hex
+	}
+	for prefix, delta := range map[string]float64{"light ": shadeStep, "dark ": -shadeStep} {
+		if base, ok := strings.CutPrefix(name, prefix); ok {
+			if hex, ok := colorNames[strings.TrimSpace(base)]; ok {
+				adjusted, _ := adjustLightness(hex, delta)
+				return adjusted
+			}
+		}
+	}
+	return ""
+}
+
+// colorArg converts a color argument to the "#rrggbb" form actions carry. Unknown names are
+// passed through for the extension.
+func colorArg(value gs.Value) (string, error) {
+	switch value.Kind {
+	case gs.ValueString:
+		colorStr := strings.ToLower(strings.TrimSpace(value.Str))
+		if hexColor := colorNameToHex(colorStr); hexColor != "" {
+			return hexColor, nil
+		}
+		if strings.HasPrefix(colorStr, "#") {
+			return colorStr, nil
+		}
+		// Unknown color name, pass through (might be handled by C++ backend)
+		return value.Str, nil
+	case gs.ValueNumber:
+		return fmt.Sprintf("#%06x", int(value.Num)), nil
+	}
+	return "", fmt.Errorf("color must be a string or number")
+}
+
+// ColorHex converts a color name ("red", "dark orange") or hex to "#rrggbb"; unknown names
+// are returned as given
+func ColorHex(color string) string {
+	hex, _ := colorArg(gs.Value{Kind: gs.ValueString, Str: color})
+	return hex
+}
+
+// hslColor is a color as hue (degrees) and saturation and lightness (0 to 1)
+type hslColor struct {
+	h, s, l float64
+}
+
+// parseHexColor parses "#rrggbb" (or "#rgb")
+func parseHexColor(hex string) (hslColor, bool) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return hslColor{}, false
+	}
+	rgb, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return hslColor{}, false
+	}
+	return rgbToHSL(float64(rgb>>16&0xff)/255, float64(rgb>>8&0xff)/255, float64(rgb&0xff)/255), true
+}
+
+func rgbToHSL(r, g, b float64) hslColor {
+	hi, lo := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	c := hslColor{l: (hi + lo) / 2}
+	if hi == lo {
+		return c
+	}
+	d := hi - lo
+	if c.l > 0.5 {
+		c.s = d / (2 - hi - lo)
+	} else {
+		c.s = d / (hi + lo)
+	}
+	switch hi {
+	case r:
+		c.h = math.Mod((g-b)/d+6, 6)
+	case g:
+		c.h = (b-r)/d + 2
+	default:
+		c.h = (r-g)/d + 4
+	}
+	c.h *= 60
+	return c
+}
+
+// hex renders the color as "#rrggbb"
+func (c hslColor) hex() string {
+	chroma := (1 - math.Abs(2*c.l-1)) * c.s
+	h := math.Mod(c.h, 360) / 60
+	x := chroma * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 1:
+		r, g = chroma, x
+	case h < 2:
+		r, g = x, chroma
+	case h < 3:
+		g, b = chroma, x
+	case h < 4:
+		g, b = x, chroma
+	case h < 5:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := c.l - chroma/2
+	channel := func(v float64) int {
+		return int(math.Round(math.Min(1, math.Max(0, v+m)) * 255))
+	}
+	return fmt.Sprintf("#%02x%02x%02x", channel(r), channel(g), channel(b))
+}
+
+// adjustLightness lightens (positive delta) or darkens (negative delta) a hex color
+func adjustLightness(hex string, delta float64) (string, bool) {
+	c, ok := parseHexColor(hex)
+	if !ok {
+		return "", false
+	}
+	c.l = math.Min(1, math.Max(0, c.l+delta))
+	return c.hex(), true
+}
+
+// paletteColors returns n distinct colors from a palette: "rainbow", a fixed palette like
+// "pastel", a genre, or a color whose shades are used ("orange", "shades of blue", "#3366ff")
+func paletteColors(palette string, n int) ([]string, error) {
+	name := strings.ToLower(strings.TrimSpace(palette))
+	name = strings.TrimPrefix(name, "shades of ")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, " shades"), " tones")
+
+	if name == "" || name == "rainbow" {
+		colors := make([]string, n)
+		for i := range colors {
+			colors[i] = hslColor{h: float64(i) * 360 / float64(n), s: 0.65, l: 0.5}.hex()
+		}
+		return colors, nil
+	}
+	if colors, ok := colorPalettes[name]; ok {
+		return spreadPalette(colors, n), nil
+	}
+	if colors, ok := genrePalette(name); ok {
+		return spreadPalette(colors, n), nil
+	}
+
+	base := colorNameToHex(name)
+	if base == "" && strings.HasPrefix(name, "#") {
+		base = name
+	}
+	if c, ok := parseHexColor(base); ok {
+		return colorShades(c, n), nil
+	}
+	return nil, fmt.Errorf("unknown palette %q: use rainbow, a color name (shades of it), one of %s, or a genre",
+		palette, strings.Join(sortedKeys(colorPalettes), ", "))
+}
+
+// genrePalette looks up a genre preset by name or alias
+func genrePalette(genre string) ([]string, bool) {
+	name := strings.ToLower(strings.TrimSpace(genre))
+	if alias, ok := genreAliases[name]; ok {
+		name = alias
+	}
+	colors, ok := genrePalettes[name]
+	return colors, ok
+}
+
+// spreadPalette picks n evenly spaced colors from a fixed palette. Beyond its size the
+// palette repeats in alternately lighter and darker variants so colors stay distinct.
+func spreadPalette(colors []string, n int) []string {
+	spread := make([]string, n)
+	if n <= len(colors) {
+		for i := range spread {
+			spread[i] = colors[i*len(colors)/n]
+		}
+		return spread
+	}
+	for i := range spread {
+		color := colors[i%len(colors)]
+		if round := i / len(colors); round > 0 {
+			delta := 0.12 * float64((round+1)/2)
+			if round%2 == 0 {
+				delta = -delta
+			}
+			color, _ = adjustLightness(color, delta)
+		}
+		spread[i] = color
+	}
+	return spread
+}
+
+// colorShades returns n shades of a color, dark to light around its own lightness
+func colorShades(base hslColor, n int) []string {
+	if n == 1 {
+		return []string{base.hex()}
+	}
+	lo, hi := math.Max(0.2, base.l-0.25), math.Min(0.8, base.l+0.25)
+	shades := make([]string, n)
+	for i := range shades {
+		c := base
+		c.l = lo + (hi-lo)*float64(i)/float64(n-1)
+		shades[i] = c.hex()
+	}
+	return shades
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SetTrackColorsBulk handles .set_track_colors_bulk() calls: gives each of the current (or
+// filtered) tracks its own color from a palette or genre preset, in track order.
+// Example: filter(tracks, track.name contains "drum").set_track_colors_bulk(palette="orange")
+func (r *ReaperDSL) SetTrackColorsBulk(args gs.Args) error {
+	p := r.parser
+	paletteValue, hasPalette := args["palette"]
+	genreValue, hasGenre := args["genre"]
+	if hasPalette && hasGenre {
+		return fmt.Errorf("set_track_colors_bulk takes palette or genre, not both")
+	}
+
+	tracks, err := p.trackTargets("set_track_colors_bulk")
+	if err != nil {
+		return err
+	}
+
+	var colors []string
+	if hasGenre {
+		preset, ok := genrePalette(genreValue.Str)
+		if !ok {
+			return fmt.Errorf("set_track_colors_bulk: unknown genre %q: use one of %s",
+				genreValue.Str, strings.Join(sortedKeys(genrePalettes), ", "))
+		}
+		colors = spreadPalette(preset, len(tracks))
+	} else if colors, err = paletteColors(paletteValue.Str, len(tracks)); err != nil {
+		return fmt.Errorf("set_track_colors_bulk: %w", err)
+	}
+
+	for i, track := range tracks {
+		p.actions = append(p.actions, map[string]any{
+			"action": "set_track",
+			"track":  track,
+			"color":  colors[i],
+		})
+	}
+	logger.Printf(p.ctx, "🎨 set_track_colors_bulk: Colored %d tracks", len(tracks))
+	return nil
+}
+
+// AdjustColor handles .adjust_color() calls: lightens or darkens the current (or filtered)
+// tracks' colors by a lightness amount from 0 to 1, starting from color when given and
+// otherwise from each track's color in the state.
+// Example: filter(tracks, track.name contains "drum").adjust_color(darken=0.2)
+func (r *ReaperDSL) AdjustColor(args gs.Args) error {
+	p := r.parser
+	lightenValue, hasLighten := args["lighten"]
+	darkenValue, hasDarken := args["darken"]
+	var delta float64
+	switch {
+	case hasLighten && hasDarken:
+		return fmt.Errorf("adjust_color takes lighten or darken, not both")
+	case hasLighten && lightenValue.Kind == gs.ValueNumber:
+		delta = lightenValue.Num
+	case hasDarken && darkenValue.Kind == gs.ValueNumber:
+		delta = -darkenValue.Num
+	default:
+		return fmt.Errorf("adjust_color requires lighten or darken (0 to 1)")
+	}
+	if math.Abs(delta) > 1 {
+		return fmt.Errorf("adjust_color amount must be between 0 and 1, got %g", math.Abs(delta))
+	}
+
+	var color string
+	if colorValue, ok := args["color"]; ok {
+		var err error
+		if color, err = colorArg(colorValue); err != nil {
+			return err
+		}
+	}
+
+	tracks, err := p.trackTargets("adjust_color")
+	if err != nil {
+		return err
+	}
+	for _, track := range tracks {
+		base := color
+		if base == "" {
+			base = stateTrackColor(p.stateTrack(track))
+		}
+		adjusted, ok := adjustLightness(base, delta)
+		if !ok {
+			if len(tracks) == 1 {
+				return fmt.Errorf("adjust_color: track %d has no color in state; pass color", track+1)
+			}
+			logger.Printf(p.ctx, "⚠️  adjust_color: Skipping track %d, it has no color in state", track+1)
+			continue
+		}
+		p.actions = append(p.actions, map[string]any{
+			"action": "set_track",
+			"track":  track,
+			"color":  adjusted,
+		})
+	}
+	return nil
+}
+
+// stateTrackColor returns a state track's color as "#rrggbb", or "" when it has none
+func stateTrackColor(track map[string]any) string {
+	switch color := track["color"].(type) {
+	case string:
+		if hex := colorNameToHex(color); hex != "" {
+			return hex
+		}
+		return color
+	case float64:
+		return fmt.Sprintf("#%06x", int(color)&0xffffff)
+	case int:
+		return fmt.Sprintf("#%06x", color&0xffffff)
+	}
+	return ""
+}
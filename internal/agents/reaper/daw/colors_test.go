@@ -0,0 +1,168 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColorNameToHex(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"red", "#ff0000"},
+		{" Orange ", "#ffa500"},
+		{"light blue", "#add8e6"},
+		{"dark red", "#8b0000"},
+		{"dark orange", "#996300"},
+		{"light green", "#90ee90"},
+		{"light red", "#ff6666"},
+		{"sparkly", ""},
+		{"dark sparkly", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := colorNameToHex(tt.name); got != tt.want {
+				t.Errorf("colorNameToHex(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdjustLightness(t *testing.T) {
+	tests := []struct {
+		hex    string
+		delta  float64
+		want   string
+		wantOK bool
+	}{
+		{"#ff0000", -0.2, "#990000", true},
+		{"#ff0000", 0.2, "#ff6666", true},
+		{"#808080", 0.6, "#ffffff", true},
+		{"#f00", 0, "#ff0000", true},
+		{"red", 0.1, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := adjustLightness(tt.hex, tt.delta)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("adjustLightness(%q, %g) = %q, %v, want %q, %v", tt.hex, tt.delta, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestPaletteColors(t *testing.T) {
+	tests := []struct {
+		palette string
+		n       int
+		want    []string
+		wantErr bool
+	}{
+		{palette: "rainbow", n: 3, want: []string{"#d22d2d", "#2dd22d", "#2d2dd2"}},
+		{palette: "", n: 1, want: []string{"#d22d2d"}},
+		{palette: "orange", n: 3, want: []string{"#805300", "#ffa500", "#ffd280"}},
+		{palette: "shades of orange", n: 1, want: []string{"#ffa500"}},
+		{palette: "pastel", n: 4, want: []string{"#f4a6a6", "#f7e59e", "#a8d8e0", "#d0a9f0"}},
+		{palette: "Techno", n: 2, want: []string{"#0b132b", "#5bc0be"}},
+		{palette: "sparkly", n: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.palette, func(t *testing.T) {
+			got, err := paletteColors(tt.palette, tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("paletteColors(%q, %d) error = %v, wantErr %v", tt.palette, tt.n, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("paletteColors(%q, %d) = %v, want %v", tt.palette, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpreadPalette_MoreTracksThanColors(t *testing.T) {
+	got := spreadPalette([]string{"#ff0000", "#0000ff"}, 5)
+	want := []string{"#ff0000", "#0000ff", "#ff3d3d", "#3d3dff", "#c20000"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("spreadPalette() = %v, want %v", got, want)
+	}
+}
+
+func TestFunctionalDSLParser_TrackColors(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Kick Drum", "color": "#ff0000"},
+			map[string]any{"index": 1, "name": "Bass"},
+			map[string]any{"index": 2, "name": "Snare Drum", "color": float64(0x0000ff)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "shades across filtered tracks",
+			dslCode: `filter(tracks, track.name contains "drum").set_track_colors_bulk(palette="orange")`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "color": "#805300"},
+				{"action": "set_track", "track": 2, "color": "#ffd280"},
+			},
+		},
+		{
+			name:    "genre preset on one track",
+			dslCode: `track(id=2).set_track_colors_bulk(genre="hip hop")`,
+			want:    []map[string]any{{"action": "set_track", "track": 1, "color": "#1b1b1b"}},
+		},
+		{
+			name:    "darken current colors",
+			dslCode: `filter(tracks, track.name contains "drum").adjust_color(darken=0.2)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "color": "#990000"},
+				{"action": "set_track", "track": 2, "color": "#000099"},
+			},
+		},
+		{
+			name:    "lighten a given color",
+			dslCode: `track(id=2).adjust_color(lighten=0.2, color="red")`,
+			want:    []map[string]any{{"action": "set_track", "track": 1, "color": "#ff6666"}},
+		},
+		{
+			name:    "set_track with a shade name",
+			dslCode: `track(id=2).set_track(color="dark orange")`,
+			want:    []map[string]any{{"action": "set_track", "track": 1, "color": "#996300"}},
+		},
+		{name: "track without a color", dslCode: `track(id=2).adjust_color(lighten=0.2)`, wantErr: true},
+		{name: "lighten and darken", dslCode: `track(id=1).adjust_color(lighten=0.2, darken=0.1)`, wantErr: true},
+		{name: "amount out of range", dslCode: `track(id=1).adjust_color(darken=2)`, wantErr: true},
+		{name: "unknown palette", dslCode: `track(id=1).set_track_colors_bulk(palette="sparkly")`, wantErr: true},
+		{name: "unknown genre", dslCode: `track(id=1).set_track_colors_bulk(genre="polka")`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package daw
+
+import "context"
+
+type conversationHistoryKey struct{}
+
+// WithConversationHistory returns a context carrying a summary of the session's earlier turns,
+// so follow-ups like "now make it louder" resolve against them.
+func WithConversationHistory(ctx context.Context, summary string) context.Context {
+	return context.WithValue(ctx, conversationHistoryKey{}, summary)
+}
+
+// ConversationHistoryFromContext returns the session history summary, or "".
+func ConversationHistoryFromContext(ctx context.Context) string {
+	summary, _ := ctx.Value(conversationHistoryKey{}).(string)
+	return summary
+}
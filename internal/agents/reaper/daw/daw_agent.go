@@ -2,6 +2,7 @@ package daw
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -9,7 +10,10 @@ import (
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/metrics"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/observability"
 	"github.com/Conceptual-Machines/magda-api/internal/prompt"
 	"github.com/getsentry/sentry-go"
 	"github.com/openai/openai-go/responses"
@@ -23,6 +27,7 @@ type DawAgent struct {
 	promptBuilder *prompt.MagdaPromptBuilder
 	metrics       *metrics.SentryMetrics
 	useDSL        bool // If true, use CFG/DSL mode; if false, use JSON Schema mode
+	parseRetries  int  // Re-prompts with the parse error when the generated DSL doesn't parse
 }
 
 func NewDawAgent(cfg *config.Config) *DawAgent {
@@ -32,8 +37,8 @@ func NewDawAgent(cfg *config.Config) *DawAgent {
 		log.Fatal("Failed to load MAGDA system prompt:", err)
 	}
 
-	// Use OpenAI provider (default for now)
-	provider := llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+	// Use the configured provider, which is required (see CheckProvider)
+	provider := cfg.NewProvider()
 
 	// Always use DSL mode (CFG grammar) for better latency and structured output
 	useDSL := true
@@ -44,6 +49,7 @@ func NewDawAgent(cfg *config.Config) *DawAgent {
 		promptBuilder: promptBuilder,
 		metrics:       metrics.NewSentryMetrics(),
 		useDSL:        useDSL,
+		parseRetries:  max(cfg.DSLParseRetries, 0),
 	}
 
 	log.Printf("🤖 DAW AGENT INITIALIZED:")
@@ -55,13 +61,40 @@ func NewDawAgent(cfg *config.Config) *DawAgent {
 }
 
 type DawResult struct {
-	Actions []map[string]any `json:"actions"`
-	Usage   any              `json:"usage"`
+	Actions         []map[string]any       `json:"actions"`
+	Usage           any                    `json:"usage"`
+	Warnings        []models.ActionWarning `json:"warnings,omitempty"`
+	FilterSummaries []models.FilterSummary `json:"filterSummaries,omitempty"` // Only when the request opts in
+	DSL             string                 `json:"dsl,omitempty"`             // Generated DSL the actions were parsed from
+	Repairs         []models.DSLRepair     `json:"repairs,omitempty"`         // Rewrites applied to the generated DSL
+	UndoActions     []map[string]any       `json:"undoActions"`               // Reverts Actions, in apply order
+	Answers         []models.QueryAnswer   `json:"answers,omitempty"`         // Results of count/sum/min/max queries
+	Answer          string                 `json:"answer,omitempty"`          // Answers as text, one per line
+	Clarification   *models.Clarification  `json:"clarification,omitempty"`   // Set instead of actions for ambiguous requests
+}
+
+// newDawResult validates the parsed actions and orders them for execution. Warnings and undo
+// actions are computed on the actions as parsed, whose track indices match the script.
+// parser supplies the parse's filter summaries, repairs, answers and clarification.
+func newDawResult(actions []map[string]any, state map[string]any, parser *FunctionalDSLParser) *DawResult {
+	warnings := append(DetectActionConflicts(actions), DetectStaleTrackIndices(actions, state)...)
+	planned, planWarnings := PlanActions(actions, state)
+	return &DawResult{
+		Actions:         planned,
+		Warnings:        append(warnings, planWarnings...),
+		FilterSummaries: parser.FilterSummaries(),
+		DSL:             parser.DSL(),
+		Repairs:         parser.Repairs(),
+		UndoActions:     BuildUndoActions(actions, state),
+		Answers:         parser.Answers(),
+		Answer:          answerText(parser.Answers()),
+		Clarification:   parser.Clarification(),
+	}
 }
 
 // getCFGGrammarConfig returns the CFG grammar configuration for the DAW agent
 // This is shared between GenerateActions and GenerateActionsStream to avoid duplication
-func (a *DawAgent) getCFGGrammarConfig() *llm.CFGConfig {
+func (a *DawAgent) getCFGGrammarConfig(version GrammarVersion) *llm.CFGConfig {
 	return &llm.CFGConfig{
 		ToolName: "magda_dsl",
 		Description: "**YOU MUST USE THIS TOOL TO GENERATE YOUR RESPONSE. DO NOT GENERATE TEXT OUTPUT DIRECTLY.** " +
@@ -75,14 +108,33 @@ func (a *DawAgent) getCFGGrammarConfig() *llm.CFGConfig {
 			"- Fade in: curve=\"fade_in\", start=0, end=4 (beats) " +
 			"- Fade out: curve=\"fade_out\", start_bar=8, end_bar=12 " +
 			"- LFO/oscillator: curve=\"sine\", freq=0.5, amplitude=1.0 (freq = cycles per bar) " +
+			"- Tempo-synced LFO: use rate=\"1/8\" (or \"1/4\", \"1/8T\" triplet, \"1/16D\" dotted) instead of freq when the user names a note value, e.g. '1/8 note tremolo' → curve=\"sine\", rate=\"1/8\" " +
 			"- Linear sweep: curve=\"ramp\", from=0.2, to=1.0 " +
 			"- Example: track(id=1).addAutomation(param=\"volume\", curve=\"fade_in\", start=0, end=4) " +
 			"- Example LFO: track(id=1).addAutomation(param=\"pan\", curve=\"sine\", freq=0.5, amplitude=1.0, start=0, end=16) " +
+			"To change existing automation use .clear_automation(param=\"volume\") (optionally with start/end or start_bar/end_bar) to remove points, .delete_envelope(param=\"pan\") to remove the envelope, and .scale_automation(param=\"volume\", factor=0.5) to rescale it, e.g. 'flatten the volume automation on track 2' → track(id=2).clear_automation(param=\"volume\"). " +
 			"When user says 'create track with [instrument]' or 'track with [instrument]', ALWAYS generate track(instrument=\"[instrument]\") - never generate track() without the instrument parameter when an instrument is mentioned. " +
 			"**TRACK CREATION**: To create a new track, use track() or track(name=\"Track Name\") - DO NOT chain .set_track() after track() unless you explicitly need to set a property. For simple track creation, track() or track(name=\"...\") is sufficient. " +
 			"**MULTIPLE TRACK CREATION**: When user requests multiple tracks (e.g., 'create 5 tracks'), generate separate track() calls: track(); track(); track(); track(); track(). For named tracks: track(name=\"Track 1\"); track(name=\"Track 2\"); etc. Each track() call creates ONE track - do NOT chain .set_track() unless explicitly needed. " +
 			"**RANDOM VALUES**: When user requests 'random' (names, positions, values, etc.), generate varied, diverse values instead of sequential or predictable ones. For random names: use creative, varied names (e.g., 'Aurora', 'Nebula', 'Phoenix', 'Echo', 'Vortex') not sequential like 'Track 1', 'Track 2'. For random positions: use varied bar positions (e.g., bar=3, bar=7, bar=12) not sequential. Make each value truly different and varied. " +
 			"For existing tracks, use track(id=1).new_clip(bar=3) where id is 1-based (track 1 = first track). " +
+			"**ROUTING**: For sends use track(id=1).add_send(dest=2, level_db=-6, pre_fader=false), .set_send(dest=2, level_db=-3) or .remove_send(dest=2); dest is the 1-based id of the receiving track, or use dest_name=\"Reverb Bus\". " +
+			"**FX PARAMETERS**: To change a plugin parameter use track(id=1).set_fx_param(fx=\"ReaEQ\", param=\"Freq-Band 1\", value=0.35); value is normalized 0.0-1.0. For every track with a plugin use filter(), e.g. filter(tracks, track.name == \"Lead\").set_fx_param(fx=\"Serum\", param=\"Cutoff\", value=0.3). " +
+			"**FX CHAIN**: Use .bypass_fx(fx=\"ReaVerb\"), .enable_fx(fx=...), .remove_fx(fx=...) and .move_fx(fx=\"ReaComp\", before=\"ReaEQ\") (or after=..., or to=1); fx is a plugin name or 1-based position in the chain. To act on plugins across all tracks filter the fx_chain collection, e.g. 'bypass all reverbs' → filter(fx_chain, fx.name == \"ReaVerb\").bypass_fx(). " +
+			"**CLIP EDITS**: Use .split_clip(bar=17) or .split_clip(position=32.0) to split the clip under that point (e.g. 'split all clips at bar 17' → filter(clips, clip.length > 0).split_clip(bar=17)), .trim_clip(clip=0, start=1.5, end=6) (or start_bar/end_bar) to move clip edges, and .set_clip_loop(enabled=true, loop_length=2) to loop clips (e.g. 'loop the selected clip' → filter(clips, clip.selected == true).set_clip_loop(enabled=true)). " +
+			"**RELATIVE POSITIONS**: Never compute positions from the state yourself when the user phrases them relatively; the server resolves them from the clips and tempo. 'add a clip 2 bars after the last clip' → track(id=1).new_clip(after=\"last_clip\", offset_bars=2, length_bars=4); after=\"project_end\" anchors to the end of the last clip on any track; offset_bars may be negative. 'move it 4 bars earlier' → track(id=1).move_clip(clip=0, relative_bars=-4) (also works on filter(clips, ...) and nth_clip()). " +
+			"**NOTE EDITS**: To change the MIDI notes of existing clips use .transpose_clip(semitones=12) (negative is down), .reverse_clip(), .legato_clip() and .remove_notes(note.velocity < 30) (note has pitch, velocity, start and length in beats), e.g. 'transpose the bass clip up an octave' → filter(tracks, track.name == \"Bass\").transpose_clip(semitones=12). On a track they edit every clip unless clip=, position= or bar= picks one. " +
+			"**DUPLICATION**: Use .duplicate() (or .duplicate(count=2)) to duplicate tracks, e.g. filter(tracks, track.name == \"Bass\").duplicate(); use .duplicate_clip(bar=1, count=4, offset_bars=1) to repeat a clip, where offset/offset_bars is the start-to-start spacing (default back to back). Works on filter(clips, ...) and nth_clip() too. " +
+			"**FOLDERS**: To group tracks use filter(tracks, track.index < 3).make_folder(name=\"Drums\"); use .add_to_folder(folder=\"Drums\") to add tracks to an existing folder and .set_track_parent(parent=1) or .set_track_parent(parent=0) to nest or un-nest one track. Put folder operations after other track edits because they reorder tracks. " +
+			"**MASTER TRACK**: Use master() for the master track; it supports .set_track(volume_db=..., pan=...), .add_fx(fxname=...) and .add_automation(...), e.g. 'put a limiter on the master and pull it down 1 dB' → master().add_fx(fxname=\"ReaLimit\").set_track(volume_db=master.volume_db - 1). Never use track(id=...) for the master. " +
+			"**TRACK COLORS**: To give several tracks distinct colors use .set_track_colors_bulk(palette=\"orange\") on a filter, e.g. 'color the drum tracks in shades of orange' → filter(tracks, track.name contains \"drum\").set_track_colors_bulk(palette=\"orange\"); palette is a color name (its shades), rainbow, pastel, neon, earth, ocean, sunset or monochrome, or use genre=\"techno\" for a genre preset. Use .adjust_color(lighten=0.2) or .adjust_color(darken=0.2) to make tracks' current colors lighter or darker. For one color on every track use set_track(color=\"red\"). " +
+			"**TRANSPORT**: For playback use play(), stop(), record() and set_play_position(bar=33) or set_play_position(time=12.5) (seconds). These are top-level statements and MUST be separated with ';', e.g. 'play from bar 33' → set_play_position(bar=33); play(). " +
+			"**RENDER**: To bounce or export use render_project(format=\"wav\", start_bar=1, end_bar=33, stems=false); end_bar is exclusive, omit start_bar and end_bar for the whole project, and stems=true renders each track separately. It is a top-level statement and MUST be separated with ';'. " +
+			"**FREEZE**: To save CPU use .freeze_track() and .unfreeze_track(); .bounce_in_place() renders tracks to new audio clips that replace the originals. They work on track() and filter(), and tracks have fx_count, e.g. 'freeze all tracks with more than 3 FX' → filter(tracks, track.fx_count > 3).freeze_track(). " +
+			"**TEMPLATES**: For a standard setup the user names (e.g. 'make me a standard vocal chain on track 2'), use apply_template(name=\"vocal chain\", track=2) instead of spelling out its plugins; track is 1-based, or chain it: filter(tracks, track.name contains \"drum\").apply_template(name=\"drum bus\"). Other arguments override the template's parameters, e.g. apply_template(name=\"parallel compression\", track=1, send_db=-9). Available templates: " + trackTemplatesDescription() + ". " +
+			"**MARKERS AND REGIONS**: For song sections use add_region(start_bar=1, end_bar=9, name=\"Intro\", color=\"blue\") (end_bar is exclusive), add_marker(bar=17, name=\"Drop\"), delete_marker(name=\"Drop\") and rename_region(name=\"Intro\", new_name=\"Verse\"). These are top-level statements and MUST be separated with ';', e.g. add_region(start_bar=1, end_bar=9, name=\"Intro\"); add_region(start_bar=9, end_bar=17, name=\"Verse\"). " +
+			"**QUESTIONS**: When the user asks about the project instead of changing it, answer with a query and no actions: count(tracks), filter(tracks, track.muted == true).count(), max(clips, clip.length), min(tracks, track.volume_db) or filter(clips, clip.track == 0).sum(clip.length). E.g. 'how many muted tracks do I have?' → filter(tracks, track.muted == true).count(); 'what's the longest clip?' → max(clips, clip.length). Top-level queries MUST be separated with ';'. " +
+			"**CLARIFICATION**: When a request is too ambiguous to act on safely (e.g. 'make it punchier' with no track selected and no earlier turn naming one), ask instead of guessing: clarify(question=\"Which track should sound punchier?\", options=[\"Drums\", \"Bass\"]), with options taken from the state. clarify() MUST be the whole script. If the conversation history shows you asked a question, the new request is the answer - act on it. " +
 			"**CRITICAL - DELETE OPERATIONS**: " +
 			"- When user says 'delete [track name]' or 'remove [track name]', you MUST generate DSL code: filter(tracks, track.name == \"[name]\").delete() " +
 			"- For delete by track id: track(id=1).delete() where id is 1-based " +
@@ -102,7 +154,7 @@ func (a *DawAgent) getCFGGrammarConfig() *llm.CFGConfig {
 			"If no track is specified in a chain, it applies to the track created by track(). " +
 			"YOU MUST REASON HEAVILY ABOUT THE OPERATIONS AND MAKE SURE THE CODE OBEYS THE GRAMMAR. " +
 			"**REMEMBER: YOU MUST CALL THIS TOOL - DO NOT GENERATE ANY TEXT OUTPUT.**",
-		Grammar: GetMagdaDSLGrammarForFunctional(),
+		Grammar: GetMagdaDSLGrammarForVersion(version),
 		Syntax:  "lark",
 	}
 }
@@ -111,35 +163,33 @@ func (a *DawAgent) GenerateActions(
 	ctx context.Context, question string, state map[string]any,
 ) (*DawResult, error) {
 	startTime := time.Now()
-	log.Printf("🤖 MAGDA REQUEST STARTED: question=%s", question)
+	logger.Printf(ctx, "🤖 MAGDA REQUEST STARTED: question=%s", question)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "magda.generate_actions")
 	defer transaction.Finish()
 
-	transaction.SetTag("model", "gpt-5.1") // GPT-5.1 for MAGDA
+	model := ModelFromContext(ctx)
+	transaction.SetTag("model", model)
 	transaction.SetContext("magda", map[string]any{
 		"question_length": len(question),
 		"has_state":       state != nil,
 	})
 
 	// Build input messages
-	inputArray := a.buildInputMessages(question, state)
+	inputArray := a.buildInputMessages(
+		question, state, model, LengthUnitFromContext(ctx), LanguageFromContext(ctx), ConversationHistoryFromContext(ctx),
+	)
 
-	// Build provider request - support both JSON Schema and CFG/DSL modes
-	request := &llm.GenerationRequest{
-		Model:         "gpt-5.1", // GPT-5.1 for MAGDA - best for complex reasoning and code-heavy tasks
-		InputArray:    inputArray,
-		ReasoningMode: "none", // GPT-5.1 defaults to "none" for faster, low-latency responses
-		SystemPrompt:  a.systemPrompt,
-	}
+	// Build provider request: GPT-5.1 unless routed to a cheaper model or overridden
+	request := generationRequest(ctx, model, inputArray, a.systemPrompt)
 
 	// Always use CFG grammar for DSL output (DSL mode is always enabled)
-	request.CFGGrammar = a.getCFGGrammarConfig()
-	log.Printf("🔧 Using DSL mode (CFG grammar) - always enabled")
+	request.CFGGrammar = a.getCFGGrammarConfig(GrammarVersionFromContext(ctx))
+	logger.Printf(ctx, "🔧 Using DSL mode (CFG grammar) - always enabled")
 
 	// Call provider
-	log.Printf("🚀 MAGDA PROVIDER REQUEST: %s", a.provider.Name())
+	logger.Printf(ctx, "🚀 MAGDA PROVIDER REQUEST: %s", a.provider.Name())
 
 	resp, err := a.provider.Generate(ctx, request)
 	if err != nil {
@@ -152,7 +202,22 @@ func (a *DawAgent) GenerateActions(
 	// Parse actions from response
 	// For MAGDA, we need to parse the raw JSON since the provider expects MusicalOutput format
 	// We'll need to get the raw response text and parse it into MagdaActionsOutput
-	actions, err := a.parseActionsFromResponse(resp, state)
+	actions, parser, err := a.parseActionsFromResponse(ctx, resp, state)
+	usage := resp.Usage
+	var parseErr *dslParseError
+	for attempt := 1; attempt <= a.parseRetries && errors.As(err, &parseErr); attempt++ {
+		logger.Printf(ctx, "🔁 DSL did not parse, asking the LLM to fix it (retry %d/%d): %v", attempt, a.parseRetries, err)
+		transaction.SetTag("parse_retries", fmt.Sprintf("%d", attempt))
+		request = retryRequest(request, parseErr)
+		if resp, err = a.provider.Generate(ctx, request); err != nil {
+			transaction.SetTag("success", "false")
+			transaction.SetTag("error_type", "provider_error")
+			sentry.CaptureException(err)
+			return nil, fmt.Errorf("provider request failed: %w", err)
+		}
+		usage = observability.SumUsage(usage, resp.Usage)
+		actions, parser, err = a.parseActionsFromResponse(ctx, resp, state)
+	}
 	if err != nil {
 		transaction.SetTag("success", "false")
 		transaction.SetTag("error_type", "parse_error")
@@ -160,10 +225,8 @@ func (a *DawAgent) GenerateActions(
 		return nil, fmt.Errorf("failed to parse actions: %w", err)
 	}
 
-	result := &DawResult{
-		Actions: actions,
-		Usage:   resp.Usage,
-	}
+	result := newDawResult(actions, state, parser)
+	result.Usage = usage
 
 	// Mark transaction as successful
 	transaction.SetTag("success", "true")
@@ -177,7 +240,7 @@ func (a *DawAgent) GenerateActions(
 	if result.Usage != nil {
 		if usage, ok := result.Usage.(responses.ResponseUsage); ok {
 			reasoningTokens := int(usage.OutputTokensDetails.ReasoningTokens)
-			a.metrics.RecordTokenUsage(ctx, "gpt-5.1",
+			a.metrics.RecordTokenUsage(ctx, model,
 				int(usage.TotalTokens),
 				int(usage.InputTokens),
 				int(usage.OutputTokens),
@@ -185,15 +248,55 @@ func (a *DawAgent) GenerateActions(
 		}
 	}
 
-	log.Printf("✅ MAGDA REQUEST COMPLETE: actions=%d, duration=%v", len(actions), duration)
+	logger.Printf(ctx, "✅ MAGDA REQUEST COMPLETE: actions=%d, duration=%v", len(actions), duration)
 
 	return result, nil
 }
 
-// buildInputMessages constructs the input array for the LLM
-func (a *DawAgent) buildInputMessages(question string, state map[string]any) []map[string]any {
+// GenerateFastPath resolves simple commands locally. It reports false when the question isn't
+// whitelisted or its DSL doesn't parse against state, in which case the caller uses the LLM.
+func (a *DawAgent) GenerateFastPath(ctx context.Context, question string, state map[string]any) (*DawResult, bool) {
+	dslCode, ok := CompileFastPath(question)
+	if !ok {
+		return nil, false
+	}
+
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		return nil, false
+	}
+	parser.SetState(state)
+	parser.SetLengthUnit(LengthUnitFromContext(ctx))
+	parser.SetReportNoOps(NoOpSummaryFromContext(ctx))
+	parser.SetContext(ctx)
+	actions, err := parser.ParseDSL(dslCode)
+	if err != nil || len(actions) == 0 {
+		logger.Printf(ctx, "⚠️ Fast path DSL %s did not parse, using the LLM: %v", dslCode, err)
+		return nil, false
+	}
+
+	logger.Printf(ctx, "⚡ Fast path: %q -> %s (%d actions)", question, dslCode, len(actions))
+	return newDawResult(actions, state, parser), true
+}
+
+// buildInputMessages constructs the input array for the LLM, trimming state to fit model's
+// context window
+func (a *DawAgent) buildInputMessages(
+	question string, state map[string]any, model string, lengthUnit LengthUnit, language Language, history string,
+) []map[string]any {
 	messages := []map[string]any{}
 
+	// Add earlier turns of the session so references like "it" or "that track" resolve
+	if history != "" {
+		messages = append(messages, map[string]any{
+			"role": "user",
+			"content": "Earlier requests in this conversation (oldest first). The current state already " +
+				"reflects them; use them only to resolve references in the new request, or to read it as the " +
+				"answer to a clarifying question you asked. A Result is what actually happened in the DAW: a FAILED " +
+				"action had no effect, so don't assume it did, and try another way if the user asks again:\n" + history,
+		})
+	}
+
 	// Add user question
 	userMessage := map[string]any{
 		"role":    "user",
@@ -201,41 +304,73 @@ func (a *DawAgent) buildInputMessages(question string, state map[string]any) []m
 	}
 	messages = append(messages, userMessage)
 
-	// Add REAPER state if provided
+	// List installed plugins compactly instead of as part of the state
+	if plugins := prompt.InstalledPluginsMessage(state); plugins != "" {
+		messages = append(messages, map[string]any{
+			"role":    "user",
+			"content": plugins,
+		})
+		state = prompt.WithoutInstalledPlugins(state)
+	}
+
+	// Add REAPER state if provided, trimmed to fit the model's context window
 	if len(state) > 0 {
+		promptState, summary := prompt.SummarizeState(state, question, prompt.StateTokenBudget(model))
+		if summary.Trimmed() {
+			log.Printf("✂️  State trimmed from ~%d to ~%d tokens (budget %d): %s",
+				summary.OriginalTokens, summary.Tokens, summary.Budget, strings.Join(summary.Omitted, "; "))
+		}
 		stateMessage := map[string]any{
 			"role":    "user",
-			"content": fmt.Sprintf("Current REAPER state: %+v", state),
+			"content": fmt.Sprintf("Current REAPER state: %+v", promptState),
 		}
 		messages = append(messages, stateMessage)
 	}
 
+	// Tell the model which unit bare lengths are in so it doesn't convert bars to seconds itself
+	if lengthUnit == LengthUnitBars {
+		messages = append(messages, map[string]any{
+			"role":    "user",
+			"content": "Length unit for this request: bars. Write new_clip/set_clip length and source_length values in bars, not seconds.",
+		})
+	}
+
+	// Map a non-English request onto the English DSL vocabulary
+	if instruction := languageInstruction(language); instruction != "" {
+		messages = append(messages, map[string]any{
+			"role":    "user",
+			"content": instruction,
+		})
+	}
+
 	return messages
 }
 
 // parseActionsFromResponse extracts actions from the LLM response
 // For CFG/DSL mode: RawOutput contains DSL code (e.g., track().new_clip().add_midi())
 // For JSON Schema mode: RawOutput contains JSON with actions array
-func (a *DawAgent) parseActionsFromResponse(resp *llm.GenerationResponse, state map[string]any) ([]map[string]any, error) {
+func (a *DawAgent) parseActionsFromResponse(
+	ctx context.Context, resp *llm.GenerationResponse, state map[string]any,
+) ([]map[string]any, *FunctionalDSLParser, error) {
 	// The provider should have stored the raw output (DSL or JSON) in RawOutput
 	if resp.RawOutput == "" {
-		return nil, fmt.Errorf("no raw output available in response")
+		return nil, nil, fmt.Errorf("no raw output available in response")
 	}
 
 	// Parse as DSL only - no fallback to JSON
-	dslCode := strings.TrimSpace(resp.RawOutput)
+	dslCode, repairs := repairDSL(ctx, strings.TrimSpace(resp.RawOutput))
 
 	// Check for out-of-scope error comments
 	if strings.HasPrefix(dslCode, "// ERROR:") {
 		errorMsg := strings.TrimPrefix(dslCode, "// ERROR:")
 		errorMsg = strings.TrimSpace(errorMsg)
-		return nil, fmt.Errorf("request is out of scope: %s", errorMsg)
+		return nil, nil, fmt.Errorf("request is out of scope: %s", errorMsg)
 	}
 
 	// Check if it's DSL (starts with "track" or similar function call)
 	// NOTE: We only support snake_case methods (new_clip, delete_clip) - NOT camelCase
 	// NOTE: add_midi is NOT generated by DAW agent - arranger agent handles MIDI notes
-	hasTrackPrefix := strings.HasPrefix(dslCode, "track(")
+	hasTrackPrefix := strings.HasPrefix(dslCode, "track(") || strings.HasPrefix(dslCode, "master(")
 	hasFilter := strings.HasPrefix(dslCode, "filter(") || strings.Contains(dslCode, ".filter(")
 	hasMap := strings.HasPrefix(dslCode, "map(") || strings.Contains(dslCode, ".map(")
 	hasForEach := strings.HasPrefix(dslCode, "for_each(") || strings.Contains(dslCode, ".for_each(")
@@ -245,34 +380,46 @@ func (a *DawAgent) parseActionsFromResponse(resp *llm.GenerationResponse, state
 	hasSetTrack := strings.Contains(dslCode, ".set_track(")
 	hasSetClip := strings.Contains(dslCode, ".set_clip(")
 	hasAddFx := strings.Contains(dslCode, ".add_fx(")
+	hasQuery := isQueryDSL(dslCode)
+	hasClarify := isClarifyDSL(dslCode)
+	hasTransport := isTransportDSL(dslCode)
+	hasRender := isRenderDSL(dslCode)
 
 	isDSL := hasTrackPrefix || hasNewClip || hasFilter || hasMap || hasForEach || hasDelete || hasDeleteClip ||
-		hasSetTrack || hasSetClip || hasAddFx
+		hasSetTrack || hasSetClip || hasAddFx || hasQuery || hasClarify || hasTransport ||
+		hasRender
 
 	if !isDSL {
 		const maxLogLength = 500
-		log.Printf("❌ LLM did not generate DSL code. Raw output (first %d chars): %s", maxLogLength, truncate(resp.RawOutput, maxLogLength))
-		return nil, fmt.Errorf("LLM must generate DSL code, but output does not look like DSL. Expected format: track(id=0).delete() or similar")
+		logger.Printf(ctx, "❌ LLM did not generate DSL code. Raw output (first %d chars): %s", maxLogLength, truncate(resp.RawOutput, maxLogLength))
+		return nil, nil, &dslParseError{dsl: dslCode, err: errNotDSL}
 	}
 
 	// This is DSL code - parse and translate to REAPER API actions
-	log.Printf("✅ Found DSL code in response: %s", truncate(dslCode, MaxDSLPreviewLength))
+	logger.Printf(ctx, "✅ Found DSL code in response: %s", truncate(dslCode, MaxDSLPreviewLength))
 
 	parser, err := NewFunctionalDSLParser()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create functional DSL parser: %w", err)
+		return nil, nil, fmt.Errorf("failed to create functional DSL parser: %w", err)
 	}
 	// Pass state directly - SetState handles both {"state": {...}} and {...} formats
 	parser.SetState(state)
+	parser.SetLengthUnit(LengthUnitFromContext(ctx))
+	parser.SetReportNoOps(NoOpSummaryFromContext(ctx))
+	parser.SetContext(ctx)
+	parser.repairs = repairs
 	actions, err := parser.ParseDSL(dslCode)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DSL: %w", err)
+		return nil, nil, &dslParseError{dsl: dslCode, err: fmt.Errorf("failed to parse DSL: %w", err)}
 	}
 
-	log.Printf("✅ Translated DSL to %d REAPER API actions", len(actions))
-	return actions, nil
+	logger.Printf(ctx, "✅ Translated DSL to %d REAPER API actions and %d answers", len(actions), len(parser.Answers()))
+	return actions, parser, nil
 }
 
+// errNotDSL is returned for LLM output that doesn't look like DSL
+var errNotDSL = errors.New("LLM must generate DSL code, but output does not look like DSL. Expected format: track(id=0).delete() or similar")
+
 // truncate truncates a string to a maximum length
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -293,13 +440,14 @@ func (a *DawAgent) GenerateActionsStream(
 	callback StreamActionCallback,
 ) (*DawResult, error) {
 	startTime := time.Now()
-	log.Printf("🤖 MAGDA STREAMING REQUEST STARTED: question=%s", question)
+	logger.Printf(ctx, "🤖 MAGDA STREAMING REQUEST STARTED: question=%s", question)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "magda.generate_actions_stream")
 	defer transaction.Finish()
 
-	transaction.SetTag("model", "gpt-5.1")
+	model := ModelFromContext(ctx)
+	transaction.SetTag("model", model)
 	transaction.SetTag("streaming", "false")
 	transaction.SetContext("magda", map[string]any{
 		"question_length": len(question),
@@ -307,23 +455,28 @@ func (a *DawAgent) GenerateActionsStream(
 	})
 
 	// Build input messages
-	inputArray := a.buildInputMessages(question, state)
+	inputArray := a.buildInputMessages(
+		question, state, model, LengthUnitFromContext(ctx), LanguageFromContext(ctx), ConversationHistoryFromContext(ctx),
+	)
 
 	// Build provider request - support both JSON Schema and CFG/DSL modes
-	request := &llm.GenerationRequest{
-		Model:         "gpt-5.1",
-		InputArray:    inputArray,
-		ReasoningMode: "none",
-		SystemPrompt:  a.systemPrompt,
-	}
+	request := generationRequest(ctx, model, inputArray, a.systemPrompt)
 
 	// Always use CFG grammar for DSL output (DSL mode is always enabled)
-	request.CFGGrammar = a.getCFGGrammarConfig()
-	log.Printf("🔧 Using DSL mode (CFG grammar) - always enabled")
-
-	// Call non-streaming provider
-	log.Printf("🚀 MAGDA PROVIDER REQUEST: %s", a.provider.Name())
-	resp, err := a.provider.Generate(ctx, request)
+	request.CFGGrammar = a.getCFGGrammarConfig(GrammarVersionFromContext(ctx))
+	logger.Printf(ctx, "🔧 Using DSL mode (CFG grammar) - always enabled")
+
+	// Call non-streaming provider, unless the caller wants text deltas
+	logger.Printf(ctx, "🚀 MAGDA PROVIDER REQUEST: %s", a.provider.Name())
+	var resp *llm.GenerationResponse
+	var err error
+	emitted := 0
+	if onText := TextDeltaCallbackFromContext(ctx); onText != nil {
+		transaction.SetTag("streaming", "true")
+		resp, emitted, err = a.streamDSL(ctx, request, state, onText, callback)
+	} else {
+		resp, err = a.provider.Generate(ctx, request)
+	}
 
 	if err != nil {
 		transaction.SetTag("success", "false")
@@ -340,7 +493,24 @@ func (a *DawAgent) GenerateActionsStream(
 	}
 
 	// Parse DSL code into actions
-	allActions, err := a.parseActionsIncremental(resp.RawOutput, state)
+	allActions, parser, err := a.parseActionsIncremental(ctx, resp.RawOutput, state)
+	usage := resp.Usage
+	var parseErr *dslParseError
+	// Actions already streamed from the rejected output can't be taken back, so only retry
+	// before any were emitted
+	for attempt := 1; attempt <= a.parseRetries && emitted == 0 && errors.As(err, &parseErr); attempt++ {
+		logger.Printf(ctx, "🔁 DSL did not parse, asking the LLM to fix it (retry %d/%d): %v", attempt, a.parseRetries, err)
+		transaction.SetTag("parse_retries", fmt.Sprintf("%d", attempt))
+		request = retryRequest(request, parseErr)
+		if resp, err = a.provider.Generate(ctx, request); err != nil {
+			transaction.SetTag("success", "false")
+			transaction.SetTag("error_type", "provider_error")
+			sentry.CaptureException(err)
+			return nil, fmt.Errorf("provider failed: %w", err)
+		}
+		usage = observability.SumUsage(usage, resp.Usage)
+		allActions, parser, err = a.parseActionsIncremental(ctx, resp.RawOutput, state)
+	}
 	if err != nil {
 		transaction.SetTag("success", "false")
 		transaction.SetTag("error_type", "parse_error")
@@ -348,24 +518,21 @@ func (a *DawAgent) GenerateActionsStream(
 		return nil, fmt.Errorf("failed to parse DSL: %w", err)
 	}
 
-	// Call callback for each action
-	for _, action := range allActions {
-		_ = callback(action)
-	}
-
-	if len(allActions) == 0 {
+	if len(allActions) == 0 && len(parser.Answers()) == 0 && parser.Clarification() == nil {
 		transaction.SetTag("success", "false")
 		transaction.SetTag("error_type", "no_actions")
 		return nil, fmt.Errorf("no actions found in DSL output")
 	}
 
-	result := &DawResult{
-		Actions: allActions,
-		Usage:   nil,
+	result := newDawResult(allActions, state, parser)
+
+	// Call callback for each planned action not already emitted while streaming
+	for _, action := range result.Actions[min(emitted, len(result.Actions)):] {
+		_ = callback(action)
 	}
 
-	if resp != nil && resp.Usage != nil {
-		result.Usage = resp.Usage
+	if usage != nil {
+		result.Usage = usage
 	}
 
 	transaction.SetTag("success", "true")
@@ -374,33 +541,60 @@ func (a *DawAgent) GenerateActionsStream(
 	duration := time.Since(startTime)
 	a.metrics.RecordGenerationDuration(ctx, duration, true)
 
-	log.Printf("✅ MAGDA STREAMING REQUEST COMPLETE: actions=%d, duration=%v", len(allActions), duration)
+	logger.Printf(ctx, "✅ MAGDA STREAMING REQUEST COMPLETE: actions=%d, duration=%v", len(allActions), duration)
 
 	return result, nil
 }
 
+// streamDSL generates DSL with the streaming provider path, forwarding text deltas and emitting
+// actions for completed statements. It returns the provider response and the number of actions
+// already emitted, so the caller only emits the remainder after the final parse.
+func (a *DawAgent) streamDSL(
+	ctx context.Context,
+	request *llm.GenerationRequest,
+	state map[string]any,
+	onText TextDeltaCallback,
+	callback StreamActionCallback,
+) (*llm.GenerationResponse, int, error) {
+	emitter := &partialActionEmitter{ctx: ctx, state: state, callback: callback}
+
+	resp, err := a.provider.GenerateStream(ctx, request, func(event llm.StreamEvent) error {
+		if event.Type != "text_delta" || event.Message == "" {
+			return nil
+		}
+		if err := onText(event.Message); err != nil {
+			return err
+		}
+		emitter.add(event.Message)
+		return nil
+	})
+	return resp, emitter.emitted, err
+}
+
 // parseActionsIncremental tries to parse actions from accumulated text (DSL or JSON)
 // It looks for complete DSL code or JSON objects in the text and extracts them
 //
 //nolint:gocyclo // Complex parsing logic is necessary for handling both DSL and JSON formats
-func (a *DawAgent) parseActionsIncremental(text string, state map[string]any) ([]map[string]any, error) {
-	text = strings.TrimSpace(text)
+func (a *DawAgent) parseActionsIncremental(
+	ctx context.Context, text string, state map[string]any,
+) ([]map[string]any, *FunctionalDSLParser, error) {
+	text, repairs := repairDSL(ctx, strings.TrimSpace(text))
 
-	log.Printf("🔍 parseActionsIncremental called with %d chars, useDSL=%v", len(text), a.useDSL)
+	logger.Printf(ctx, "🔍 parseActionsIncremental called with %d chars, useDSL=%v", len(text), a.useDSL)
 	if len(text) > 0 {
 		previewLen := 200
 		if len(text) < previewLen {
 			previewLen = len(text)
 		}
-		log.Printf("📄 Input text preview (first %d chars): %s", previewLen, text[:previewLen])
-		log.Printf("📋 FULL INPUT TEXT (all %d chars, NO TRUNCATION):\n%s", len(text), text)
+		logger.Printf(ctx, "📄 Input text preview (first %d chars): %s", previewLen, text[:previewLen])
+		logger.Printf(ctx, "📋 FULL INPUT TEXT (all %d chars, NO TRUNCATION):\n%s", len(text), text)
 	}
 
 	// Always try parsing as DSL first (DSL mode is always enabled)
 	// Check if it's DSL (starts with "track" or similar function call)
 	// NOTE: We only support snake_case methods (new_clip, delete_clip) - NOT camelCase
 	// NOTE: add_midi is NOT generated by DAW agent - arranger agent handles MIDI notes
-	hasTrackPrefix := strings.HasPrefix(text, "track(")
+	hasTrackPrefix := strings.HasPrefix(text, "track(") || strings.HasPrefix(text, "master(")
 	hasFilter := strings.Contains(text, ".filter(") || strings.Contains(text, "filter(")
 	hasNewClip := strings.Contains(text, ".new_clip(")
 	hasMap := strings.Contains(text, ".map(")
@@ -410,45 +604,54 @@ func (a *DawAgent) parseActionsIncremental(text string, state map[string]any) ([
 	hasSetTrack := strings.Contains(text, ".set_track(")
 	hasSetClip := strings.Contains(text, ".set_clip(")
 	hasAddFx := strings.Contains(text, ".add_fx(")
+	hasQuery := isQueryDSL(text)
+	hasClarify := isClarifyDSL(text)
+	hasTransport := isTransportDSL(text)
+	hasRender := isRenderDSL(text)
 
 	isDSL := hasTrackPrefix || hasNewClip || hasFilter || hasMap || hasForEach || hasDelete || hasDeleteClip ||
-		hasSetTrack || hasSetClip || hasAddFx
+		hasSetTrack || hasSetClip || hasAddFx || hasQuery || hasClarify || hasTransport ||
+		hasRender
 
-	log.Printf("🔍 DSL detection: hasTrackPrefix=%v, hasFilter=%v, hasNewClip=%v, hasMap=%v, hasForEach=%v, hasSetTrack=%v, hasSetClip=%v, hasAddFx=%v, isDSL=%v",
-		hasTrackPrefix, hasFilter, hasNewClip, hasMap, hasForEach, hasSetTrack, hasSetClip, hasAddFx, isDSL)
+	logger.Printf(ctx, "🔍 DSL detection: hasTrackPrefix=%v, hasFilter=%v, hasNewClip=%v, hasMap=%v, hasForEach=%v, hasSetTrack=%v, hasSetClip=%v, hasAddFx=%v, hasQuery=%v, hasClarify=%v, hasTransport=%v, hasRender=%v, isDSL=%v",
+		hasTrackPrefix, hasFilter, hasNewClip, hasMap, hasForEach, hasSetTrack, hasSetClip, hasAddFx, hasQuery, hasClarify, hasTransport, hasRender, isDSL)
 
 	// Check for out-of-scope error comments
 	if strings.HasPrefix(text, "// ERROR:") {
 		errorMsg := strings.TrimPrefix(text, "// ERROR:")
 		errorMsg = strings.TrimSpace(errorMsg)
-		return nil, fmt.Errorf("request is out of scope: %s", errorMsg)
+		return nil, nil, fmt.Errorf("request is out of scope: %s", errorMsg)
 	}
 
 	if !isDSL {
 		const maxLogLength = 500
-		log.Printf("❌ LLM did not generate DSL code in stream. Text (first %d chars): %s", maxLogLength, truncate(text, maxLogLength))
-		return nil, fmt.Errorf("LLM must generate DSL code, but output does not look like DSL. Expected format: track(id=0).delete() or similar")
+		logger.Printf(ctx, "❌ LLM did not generate DSL code in stream. Text (first %d chars): %s", maxLogLength, truncate(text, maxLogLength))
+		return nil, nil, &dslParseError{dsl: text, err: errNotDSL}
 	}
 
 	// This is DSL code - parse and translate to REAPER API actions
-	log.Printf("✅ Found DSL code in stream: %s", truncate(text, MaxDSLPreviewLength))
-	log.Printf("📋 FULL DSL CODE (all %d chars, NO TRUNCATION):\n%s", len(text), text)
+	logger.Printf(ctx, "✅ Found DSL code in stream: %s", truncate(text, MaxDSLPreviewLength))
+	logger.Printf(ctx, "📋 FULL DSL CODE (all %d chars, NO TRUNCATION):\n%s", len(text), text)
 
 	parser, err := NewFunctionalDSLParser()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create functional DSL parser: %w", err)
+		return nil, nil, fmt.Errorf("failed to create functional DSL parser: %w", err)
 	}
 	// Pass state directly - SetState handles both {"state": {...}} and {...} formats
 	parser.SetState(state)
+	parser.SetLengthUnit(LengthUnitFromContext(ctx))
+	parser.SetReportNoOps(NoOpSummaryFromContext(ctx))
+	parser.SetContext(ctx)
+	parser.repairs = repairs
 	actions, err := parser.ParseDSL(text)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse DSL: %w", err)
+		return nil, nil, &dslParseError{dsl: text, err: fmt.Errorf("failed to parse DSL: %w", err)}
 	}
 
-	if len(actions) == 0 {
-		return nil, fmt.Errorf("DSL parsed but produced no actions")
+	if len(actions) == 0 && len(parser.Answers()) == 0 && parser.Clarification() == nil {
+		return nil, nil, &dslParseError{dsl: text, err: fmt.Errorf("DSL parsed but produced no actions")}
 	}
 
-	log.Printf("✅ Translated DSL to %d REAPER API actions", len(actions))
-	return actions, nil
+	logger.Printf(ctx, "✅ Translated DSL to %d REAPER API actions and %d answers", len(actions), len(parser.Answers()))
+	return actions, parser, nil
 }
@@ -177,7 +177,7 @@ func TestDawAgent_ParseErrorComment(t *testing.T) {
 				RawOutput: tt.rawOutput,
 			}
 
-			actions, err := agent.parseActionsFromResponse(resp, nil)
+			actions, _, err := agent.parseActionsFromResponse(context.Background(), resp, nil)
 
 			if tt.expectError {
 				require.Error(t, err, "Expected error for error comment format")
@@ -0,0 +1,68 @@
+package daw
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+)
+
+// dslMethod is a DSL method as a method expression, e.g. (*ReaperDSL).SetTrack for
+// set_track(), so one method table serves every parser's ReaperDSL
+type dslMethod func(*ReaperDSL, gs.Args) error
+
+// dslEngine executes MAGDA DSL code the way gs.Engine does, except that gs.Engine finds the
+// DSL's methods by reflection and binds them to one ReaperDSL, so every request had to build
+// its own. dslEngine is built once (see magdaDSLEngine) and is read-only, so every parser
+// shares it and only brings its own ReaperDSL and syntax parser to execute.
+//
+// Grammar versions only narrow the grammar the LLM generates with (see getCFGGrammarConfig);
+// the parser accepts every version's statements, so one engine serves all of them.
+type dslEngine struct {
+	methods map[string]dslMethod // By CamelCase name, as gs.LarkParser names calls
+}
+
+// magdaDSLEngine validates the MAGDA DSL grammar and builds its engine once, for all parsers
+var magdaDSLEngine = sync.OnceValues(func() (*dslEngine, error) {
+	if err := llm.ValidateGrammar("MAGDA DSL", GetMagdaDSLGrammarForFunctional()); err != nil {
+		return nil, err
+	}
+	return newDSLEngine(), nil
+})
+
+// newDSLEngine collects the ReaperDSL methods gs.NewEngine would: exported, taking gs.Args
+// and returning error
+func newDSLEngine() *dslEngine {
+	engine := &dslEngine{methods: make(map[string]dslMethod)}
+	dslType := reflect.TypeFor[*ReaperDSL]()
+	for i := range dslType.NumMethod() {
+		if method, ok := dslType.Method(i).Func.Interface().(func(*ReaperDSL, gs.Args) error); ok {
+			engine.methods[dslType.Method(i).Name] = method
+		}
+	}
+	return engine
+}
+
+// execute parses code with syntax and calls each method on dsl, with gs.Engine's errors
+func (e *dslEngine) execute(dsl *ReaperDSL, syntax gs.Parser, code string) error {
+	callChain, err := syntax.Parse(code)
+	if err != nil {
+		return fmt.Errorf("parse error: %w", err)
+	}
+	for _, call := range callChain.Calls {
+		method, ok := e.methods[call.Name]
+		if !ok {
+			return fmt.Errorf("unknown method: %s", call.Name)
+		}
+		args := make(gs.Args, len(call.Args))
+		for _, arg := range call.Args {
+			args[arg.Name] = arg.Value
+		}
+		if err := method(dsl, args); err != nil {
+			return fmt.Errorf("method %s error: %w", call.Name, err)
+		}
+	}
+	return nil
+}
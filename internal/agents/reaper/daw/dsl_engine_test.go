@@ -0,0 +1,73 @@
+package daw
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+)
+
+var benchmarkState = map[string]any{
+	"tracks": []any{
+		map[string]any{"index": 0, "name": "Drums", "fx": []any{map[string]any{"name": "ReaComp"}}},
+		map[string]any{"index": 1, "name": "Bass"},
+		map[string]any{"index": 2, "name": "Keys"},
+	},
+}
+
+const benchmarkDSL = `track(instrument="Serum", name="Lead").new_clip(bar=1, length_bars=4);` +
+	`filter(tracks, track.name == "Drums").set_track(mute=true)`
+
+// BenchmarkNewFunctionalDSLParser compares building a gs.Engine for every request, as
+// parsers used to, with the per-request parser on the shared dslEngine
+func BenchmarkNewFunctionalDSLParser(b *testing.B) {
+	b.Run("gs.NewEngine per request", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := gs.NewEngine(GetMagdaDSLGrammarForFunctional(), &ReaperDSL{}, gs.NewLarkParser()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("shared dslEngine", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if _, err := NewFunctionalDSLParser(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkParseDSL measures a request's parse: a new parser, its state and one script
+func BenchmarkParseDSL(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		parser, err := NewFunctionalDSLParser()
+		if err != nil {
+			b.Fatal(err)
+		}
+		parser.SetState(benchmarkState)
+		if _, err := parser.ParseDSL(benchmarkDSL); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestNewFunctionalDSLParser_SharesEngine(t *testing.T) {
+	first, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	second, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	if first.engine != second.engine {
+		t.Error("parsers built their own engines, want one shared engine")
+	}
+	for _, name := range []string{"Track", "Filter", "SetTrack", "NewClip", "Master", "ApplyTemplate"} {
+		if _, ok := first.engine.methods[name]; !ok {
+			t.Errorf("engine has no %s method", name)
+		}
+	}
+}
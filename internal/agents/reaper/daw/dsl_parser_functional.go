@@ -2,25 +2,57 @@ package daw
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
 	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/plugins"
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
 )
 
+// errNoActions is returned by ParseDSL when valid DSL produces no actions
+var errNoActions = errors.New("no actions found in DSL code")
+
+// errConcurrentParse is returned by ParseDSL when the parser is already parsing
+var errConcurrentParse = errors.New("DSL parser is already in use: parsers are not safe for concurrent use, create one per request")
+
 // FunctionalDSLParser parses MAGDA DSL code with functional method support.
-// Uses Grammar School Engine for parsing and supports filter, map, etc.
+// Executes on the shared dslEngine (grammar-school parsing) and supports filter, map, etc.
+//
+// A parser holds the state of its current parse, so it is not safe for concurrent use: each
+// request creates its own with NewFunctionalDSLParser. Parsers may share a state, which
+// SetState only reads once it is normalized.
 type FunctionalDSLParser struct {
-	engine            *gs.Engine
-	reaperDSL         *ReaperDSL
+	engine            *dslEngine          // Shared by all parsers; see dsl_engine.go
+	reaperDSL         *ReaperDSL          // This parser's receiver for the engine's methods
+	syntax            *filterSyntaxParser // Parses code for the engine
 	currentTrackIndex int
+	onMaster          bool // The statement started with master(); see master.go
 	trackCounter      int
 	state             map[string]any
 	data              map[string]any // Storage for collections
+	chain             *chainContext  // Collection selected for the next chained method; see chain_context.go
+	layout            *trackLayout   // Folder tree, built on the first folder method of a parse
 	iterationContext  map[string]any // Current iteration variables (track, fx, clip, etc.)
 	actions           []map[string]any
+	lengthUnit        LengthUnit // How bare clip lengths are interpreted (seconds or bars)
+	reportNoOps       bool       // Collect filterSummaries for filtered statements
+	filterSummaries   []models.FilterSummary
+	dsl               string                // Code of the last parse, after repairs
+	repairs           []models.DSLRepair    // Rewrites applied to the generated code; see dsl_repair.go
+	predicates        []filterPredicate     // Predicates of this parse's filter calls, by predicate=N
+	answers           []models.QueryAnswer  // Results of count/sum/min/max queries
+	clarification     *models.Clarification // Question asked by a clarify() script or for an ambiguous plugin
+	pluginResolver    plugins.Resolver      // Matches plugin names to installed plugins; nil uses the state
+	ctx               context.Context       // Request context, carries correlation IDs into log lines
+	parsing           atomic.Bool           // Set while ParseDSL runs, to refuse concurrent use
 }
 
 // ReaperDSL implements the DSL methods for REAPER operations.
@@ -28,72 +60,78 @@ type ReaperDSL struct {
 	parser *FunctionalDSLParser
 }
 
-// NewFunctionalDSLParser creates a new functional DSL parser.
+// NewFunctionalDSLParser creates a new functional DSL parser. The grammar is validated and
+// its engine built on the first call only, so a parser per request is cheap.
 func NewFunctionalDSLParser() (*FunctionalDSLParser, error) {
+	engine, err := magdaDSLEngine()
+	if err != nil {
+		return nil, err
+	}
+
 	parser := &FunctionalDSLParser{
+		engine:            engine,
 		reaperDSL:         &ReaperDSL{},
 		currentTrackIndex: -1,
 		trackCounter:      0,
 		data:              make(map[string]any),
 		iterationContext:  make(map[string]any),
 		actions:           make([]map[string]any, 0),
+		lengthUnit:        LengthUnitSeconds,
 	}
 
 	parser.reaperDSL.parser = parser
 
-	// Get MAGDA DSL grammar
-	grammar := GetMagdaDSLGrammarForFunctional()
+	// Use generic Lark parser from grammar-school, with filter predicates parsed up front
+	parser.syntax = &filterSyntaxParser{lark: gs.NewLarkParser(), parser: parser}
 
-	// Use generic Lark parser from grammar-school
-	larkParser := gs.NewLarkParser()
-
-	// Create Engine with ReaperDSL instance and parser
-	engine, err := gs.NewEngine(grammar, parser.reaperDSL, larkParser)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create engine: %w", err)
-	}
+	return parser, nil
+}
 
-	parser.engine = engine
+// SetContext sets the request context used for DSL execution and logging.
+func (p *FunctionalDSLParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
 
-	return parser, nil
+// SetLengthUnit sets how bare clip lengths in the DSL are interpreted.
+// Explicit length_bars values are always bars regardless of this setting.
+func (p *FunctionalDSLParser) SetLengthUnit(unit LengthUnit) {
+	p.lengthUnit = unit
 }
 
-// SetState sets the current REAPER state.
+// SetState sets the current REAPER state. The state is normalized in place (see
+// projectstate.Normalize), so track and clip indices in it are ints. The parser doesn't
+// otherwise write to it.
 func (p *FunctionalDSLParser) SetState(state map[string]any) {
+	if _, err := projectstate.Normalize(state); err != nil {
+		logger.Printf(p.ctx, "⚠️  SetState: %v", err)
+	}
 	p.state = state
 	// Populate data with collections from state
 	if state != nil {
-		stateMap, ok := state["state"].(map[string]any)
-		if !ok {
-			stateMap = state
-		}
+		stateMap := projectstate.Unwrap(state)
 		if tracks, ok := stateMap["tracks"].([]any); ok {
 			p.data["tracks"] = tracks
 
 			// Extract all clips from all tracks into a global clips collection
-			// This allows filter(clips, ...) to work on all clips across all tracks
+			// This allows filter(clips, ...) to work on all clips across all tracks.
+			// Each clip carries its track's index as "track".
 			allClips := make([]any, 0)
 			for _, trackInterface := range tracks {
 				if track, ok := trackInterface.(map[string]any); ok {
 					if clips, ok := track["clips"].([]any); ok {
-						// Add track index to each clip for reference
-						trackIndex, _ := track["index"].(int)
-						if trackIndexFloat, ok := track["index"].(float64); ok {
-							trackIndex = int(trackIndexFloat)
-						}
-						for _, clip := range clips {
-							if clipMap, ok := clip.(map[string]any); ok {
-								// Ensure clip has track reference
-								clipMap["track"] = trackIndex
-							}
-							allClips = append(allClips, clip)
-						}
+						allClips = append(allClips, clips...)
 					}
 				}
 			}
 			if len(allClips) > 0 {
 				p.data["clips"] = allClips
-				log.Printf("📦 Extracted %d clips from %d tracks into global clips collection", len(allClips), len(tracks))
+				logger.Printf(p.ctx, "📦 Extracted %d clips from %d tracks into global clips collection", len(allClips), len(tracks))
+			}
+
+			// Same for FX, so filter(fx_chain, fx.name == "ReaVerb") matches plugins on every track
+			if allFx := flattenFxChains(tracks); len(allFx) > 0 {
+				p.data["fx_chain"] = allFx
+				logger.Printf(p.ctx, "📦 Extracted %d FX from %d tracks into global fx_chain collection", len(allFx), len(tracks))
 			}
 		}
 		// Also check for top-level clips collection (if state provides it directly)
@@ -103,24 +141,36 @@ func (p *FunctionalDSLParser) SetState(state map[string]any) {
 	}
 }
 
-// getExistingTrackCount returns the number of existing tracks from the state.
-// This is used to initialize trackCounter so new tracks are created at the correct index.
-func (p *FunctionalDSLParser) getExistingTrackCount() int {
-	if p.state == nil {
-		return 0
-	}
-
-	// Check for tracks in state.state.tracks or state.tracks
-	stateMap, ok := p.state["state"].(map[string]any)
-	if !ok {
-		stateMap = p.state
+// trackTargets returns the 0-based indices of the filtered tracks, or the current track
+func (p *FunctionalDSLParser) trackTargets(actionType string) ([]int, error) {
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		var tracks []int
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if index, ok := actionInt(trackMap, "index"); ok {
+				tracks = append(tracks, index)
+			}
+		}
+		sort.Ints(tracks)
+		if len(tracks) == 0 {
+			return nil, fmt.Errorf("%s: the filtered collection has no tracks", actionType)
+		}
+		return tracks, nil
 	}
-
-	if tracks, ok := stateMap["tracks"].([]any); ok {
-		return len(tracks)
+	if p.currentTrackIndex < 0 {
+		return nil, fmt.Errorf("no track context for %s call", actionType)
 	}
+	return []int{p.currentTrackIndex}, nil
+}
 
-	return 0
+// getExistingTrackCount returns the number of existing tracks from the state.
+// This is used to initialize trackCounter so new tracks are created at the correct index.
+func (p *FunctionalDSLParser) getExistingTrackCount() int {
+	tracks, _ := stateTracks(p.state)
+	return len(tracks)
 }
 
 // ParseDSL parses DSL code and returns REAPER API actions.
@@ -128,10 +178,20 @@ func (p *FunctionalDSLParser) ParseDSL(dslCode string) ([]map[string]any, error)
 	if dslCode == "" {
 		return nil, fmt.Errorf("empty DSL code")
 	}
+	if !p.parsing.CompareAndSwap(false, true) {
+		return nil, errConcurrentParse
+	}
+	defer p.parsing.Store(false)
 
 	// Reset actions for new parse
+	p.dsl = dslCode
 	p.actions = make([]map[string]any, 0)
+	p.filterSummaries = nil
+	p.answers = nil
+	p.clarification = nil
 	p.currentTrackIndex = -1
+	p.onMaster = false
+	p.layout = nil
 
 	// Initialize trackCounter based on existing tracks in state
 	// This ensures new tracks are created at the correct index
@@ -139,17 +199,33 @@ func (p *FunctionalDSLParser) ParseDSL(dslCode string) ([]map[string]any, error)
 
 	p.clearIterationContext()
 
-	// Execute DSL code using Grammar School Engine
-	ctx := context.Background()
-	if err := p.engine.Execute(ctx, dslCode); err != nil {
+	// A clarification asks the user a question instead of acting (see clarify.go)
+	if isClarifyDSL(dslCode) {
+		clarification, err := parseClarification(dslCode)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute DSL: %w", err)
+		}
+		p.clarification = clarification
+		logger.Printf(p.ctx, "❓ Functional DSL Parser: Asking for clarification: %s", clarification.Question)
+		return p.actions, nil
+	}
+
+	// Execute DSL code on the shared engine
+	if err := p.executeStatements(dslCode); err != nil {
 		return nil, fmt.Errorf("failed to execute DSL: %w", err)
 	}
 
-	if len(p.actions) == 0 {
-		return nil, fmt.Errorf("no actions found in DSL code")
+	p.resolvePluginNames()
+	if p.clarification != nil {
+		return p.actions, nil
+	}
+
+	// Query-only scripts (count, sum, min, max) answer without producing actions
+	if len(p.actions) == 0 && len(p.answers) == 0 {
+		return nil, errNoActions
 	}
 
-	log.Printf("✅ Functional DSL Parser: Translated %d actions from DSL", len(p.actions))
+	logger.Printf(p.ctx, "✅ Functional DSL Parser: Translated %d actions and %d answers from DSL", len(p.actions), len(p.answers))
 	return p.actions, nil
 }
 
@@ -199,6 +275,17 @@ func (p *FunctionalDSLParser) resolveCollection(name string) ([]any, error) {
 // Track handles track() calls.
 func (r *ReaperDSL) Track(args gs.Args) error {
 	p := r.parser
+	p.onMaster = false
+
+	// Check if this is a track reference by GUID (stable across project changes)
+	if guidValue, ok := args["guid"]; ok && guidValue.Kind == gs.ValueString {
+		trackIndex, found := p.trackIndexByGUID(guidValue.Str)
+		if !found {
+			return fmt.Errorf("no track with guid %q in state", guidValue.Str)
+		}
+		p.currentTrackIndex = trackIndex
+		return nil
+	}
 
 	// Check if this is a track reference by ID
 	if idValue, ok := args["id"]; ok && idValue.Kind == gs.ValueNumber {
@@ -220,28 +307,22 @@ func (r *ReaperDSL) Track(args gs.Args) error {
 	}
 
 	// This is a track creation
-	action := map[string]any{
-		"action": "create_track",
-	}
+	action := CreateTrackAction{Index: p.trackCounter}
 
 	if instrumentValue, ok := args["instrument"]; ok && instrumentValue.Kind == gs.ValueString {
-		// Plugin name is passed as-is - extension will resolve aliases
-		action["instrument"] = instrumentValue.Str
+		// Plugin name is resolved against installed plugins after execution (see plugin_names.go)
+		action.Instrument = instrumentValue.Str
 	}
 	if nameValue, ok := args["name"]; ok && nameValue.Kind == gs.ValueString {
-		action["name"] = nameValue.Str
+		action.Name = nameValue.Str
 	}
-
 	if indexValue, ok := args["index"]; ok && indexValue.Kind == gs.ValueNumber {
-		action["index"] = int(indexValue.Num)
-		p.trackCounter = int(indexValue.Num) + 1
-	} else {
-		action["index"] = p.trackCounter
-		p.trackCounter++
+		action.Index = int(indexValue.Num)
 	}
 
-	p.currentTrackIndex = action["index"].(int)
-	p.actions = append(p.actions, action)
+	p.trackCounter = action.Index + 1
+	p.currentTrackIndex = action.Index
+	p.appendAction(action)
 
 	return nil
 }
@@ -258,39 +339,44 @@ func (r *ReaperDSL) NewClip(args gs.Args) error {
 		}
 	}
 
-	action := map[string]any{
-		"track": trackIndex,
+	dest, relative, err := p.anchorDestination("new_clip", args)
+	if err != nil {
+		return err
 	}
 
-	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
-		action["action"] = "create_clip_at_bar"
-		action["bar"] = int(barValue.Num)
+	var action Action
+	if relative {
+		position, err := p.resolveDestination("new_clip", dest, trackIndex, nil)
+		if err != nil {
+			return err
+		}
+		action = CreateClipAction{Track: TrackIndex(trackIndex), Position: position, Length: p.clipLengthSeconds(args)}
+	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		lengthBars := 4.0
 		if lengthBarsValue, ok := args["length_bars"]; ok && lengthBarsValue.Kind == gs.ValueNumber {
-			action["length_bars"] = int(lengthBarsValue.Num)
-		} else {
-			action["length_bars"] = 4
+			lengthBars = lengthBarsValue.Num
+		} else if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber && p.lengthUnit == LengthUnitBars {
+			lengthBars = lengthValue.Num
 		}
-	} else if startValue, ok := args["start"]; ok && startValue.Kind == gs.ValueNumber {
-		action["action"] = "create_clip"
-		action["position"] = startValue.Num
-		if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
-			action["length"] = lengthValue.Num
-		} else {
-			action["length"] = 4.0
+		if lengthBars <= 0 {
+			return fmt.Errorf("new_clip length must be positive, got %g bars", lengthBars)
 		}
-	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
-		action["action"] = "create_clip"
-		action["position"] = positionValue.Num
-		if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
-			action["length"] = lengthValue.Num
+		if lengthBars == math.Trunc(lengthBars) {
+			action = CreateClipAtBarAction{Track: TrackIndex(trackIndex), Bar: int(barValue.Num), LengthBars: int(lengthBars)}
 		} else {
-			action["length"] = 4.0
+			// create_clip_at_bar takes whole bars, so a fractional length becomes a clip in seconds
+			start := p.barStart(float64(int(barValue.Num)))
+			action = CreateClipAction{Track: TrackIndex(trackIndex), Position: start, Length: p.tempo().AddBars(start, lengthBars) - start}
 		}
+	} else if startValue, ok := args["start"]; ok && startValue.Kind == gs.ValueNumber {
+		action = CreateClipAction{Track: TrackIndex(trackIndex), Position: startValue.Num, Length: p.clipLengthSeconds(args)}
+	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		action = CreateClipAction{Track: TrackIndex(trackIndex), Position: positionValue.Num, Length: p.clipLengthSeconds(args)}
 	} else {
-		return fmt.Errorf("clip call must specify bar, start, or position")
+		return fmt.Errorf("clip call must specify bar, start, position, or after")
 	}
 
-	p.actions = append(p.actions, action)
+	p.appendAction(action)
 	return nil
 }
 
@@ -302,79 +388,66 @@ func (r *ReaperDSL) AddFx(args gs.Args) error {
 	p := r.parser
 
 	// Check if there's a filtered collection (from filter() call)
-	if filtered, hasFiltered := p.data["current_filtered"]; hasFiltered {
-		if filteredSlice, ok := filtered.([]any); ok && len(filteredSlice) > 0 {
-			log.Printf("🔍 AddFx: Found filtered collection (hasFiltered=true)")
-			log.Printf("🔍 AddFx: Filtered collection has %d items", len(filteredSlice))
-
-			// Determine action type
-			var actionType string
-			var fxname string
-			if fxnameValue, ok := args["fxname"]; ok && fxnameValue.Kind == gs.ValueString {
-				actionType = "add_track_fx"
-				fxname = fxnameValue.Str
-			} else if instrumentValue, ok := args["instrument"]; ok && instrumentValue.Kind == gs.ValueString {
-				actionType = "add_instrument"
-				// Plugin name is passed as-is - extension will resolve aliases
-				fxname = instrumentValue.Str
-			} else {
-				return fmt.Errorf("FX call must specify fxname or instrument")
-			}
-
-			// Apply to all filtered tracks
-			for _, item := range filteredSlice {
-				trackMap, ok := item.(map[string]any)
-				if !ok {
-					log.Printf("⚠️  AddFx: Could not convert filtered item to map: %+v", item)
-					continue
-				}
+	if filteredSlice := p.takeFiltered(); len(filteredSlice) > 0 {
+		logger.Printf(r.parser.ctx, "🔍 AddFx: Filtered collection has %d items", len(filteredSlice))
+
+		// Determine action type
+		var instrument bool
+		var fxname string
+		if fxnameValue, ok := args["fxname"]; ok && fxnameValue.Kind == gs.ValueString {
+			fxname = fxnameValue.Str
+		} else if instrumentValue, ok := args["instrument"]; ok && instrumentValue.Kind == gs.ValueString {
+			instrument = true
+			// Plugin name is resolved against installed plugins after execution (see plugin_names.go)
+			fxname = instrumentValue.Str
+		} else {
+			return fmt.Errorf("FX call must specify fxname or instrument")
+		}
 
-				trackIndex := -1
-				if idx, ok := trackMap["index"].(int); ok {
-					trackIndex = idx
-				} else if idxFloat, ok := trackMap["index"].(float64); ok {
-					trackIndex = int(idxFloat)
-				}
+		// Apply to all filtered tracks
+		for _, item := range filteredSlice {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  AddFx: Could not convert filtered item to map: %+v", item)
+				continue
+			}
 
-				if trackIndex < 0 {
-					log.Printf("⚠️  AddFx: Could not extract track index from %+v", trackMap)
-					continue
-				}
+			trackIndex := -1
+			if idx, ok := trackMap["index"].(int); ok {
+				trackIndex = idx
+			}
 
-				action := map[string]any{
-					"action": actionType,
-					"track":  trackIndex,
-					"fxname": fxname,
-				}
-				log.Printf("✅ AddFx: Adding action for track %d, fxname=%s", trackIndex, fxname)
-				p.actions = append(p.actions, action)
+			if trackIndex < 0 {
+				logger.Printf(r.parser.ctx, "⚠️  AddFx: Could not extract track index from %+v", trackMap)
+				continue
 			}
-			log.Printf("✅ AddFx: Applied to %d filtered tracks", len(filteredSlice))
-			return nil
+
+			logger.Printf(r.parser.ctx, "✅ AddFx: Adding action for track %d, fxname=%s", trackIndex, fxname)
+			p.appendAction(AddFXAction{Track: TrackIndex(trackIndex), FXName: fxname, Instrument: instrument})
 		}
+		logger.Printf(r.parser.ctx, "✅ AddFx: Applied to %d filtered tracks", len(filteredSlice))
+		return nil
 	}
 
 	// No filtered collection - use current track context
-	if p.currentTrackIndex < 0 {
+	action := AddFXAction{Track: TrackIndex(p.currentTrackIndex)}
+	if p.onMaster {
+		action.Track = MasterRef
+	} else if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for FX call")
 	}
 
-	action := map[string]any{
-		"track": p.currentTrackIndex,
-	}
-
 	if fxnameValue, ok := args["fxname"]; ok && fxnameValue.Kind == gs.ValueString {
-		action["action"] = "add_track_fx"
-		action["fxname"] = fxnameValue.Str
+		action.FXName = fxnameValue.Str
 	} else if instrumentValue, ok := args["instrument"]; ok && instrumentValue.Kind == gs.ValueString {
-		action["action"] = "add_instrument"
-		// Plugin name is passed as-is - extension will resolve aliases
-		action["fxname"] = instrumentValue.Str
+		// Plugin name is resolved against installed plugins after execution (see plugin_names.go)
+		action.FXName = instrumentValue.Str
+		action.Instrument = true
 	} else {
 		return fmt.Errorf("FX call must specify fxname or instrument")
 	}
 
-	p.actions = append(p.actions, action)
+	p.appendAction(action)
 	return nil
 }
 
@@ -391,14 +464,11 @@ func (r *ReaperDSL) SetTrack(args gs.Args) error {
 		actionProps["name"] = nameValue.Str
 	}
 
-	// Handle volume_db
-	if volumeValue, ok := args["volume_db"]; ok && volumeValue.Kind == gs.ValueNumber {
-		actionProps["volume_db"] = volumeValue.Num
-	}
-
-	// Handle pan
-	if panValue, ok := args["pan"]; ok && panValue.Kind == gs.ValueNumber {
-		actionProps["pan"] = panValue.Num
+	// Handle volume_db and pan (numbers or expressions like track.volume_db - 3)
+	for _, key := range []string{"volume_db", "pan"} {
+		if value, ok := numericArg(args, key, nil); ok {
+			actionProps[key] = value
+		}
 	}
 
 	// Handle mute
@@ -418,23 +488,9 @@ func (r *ReaperDSL) SetTrack(args gs.Args) error {
 
 	// Handle color (similar to SetClip)
 	if colorValue, ok := args["color"]; ok {
-		var color string
-		if colorValue.Kind == gs.ValueString {
-			colorStr := strings.ToLower(strings.TrimSpace(colorValue.Str))
-			// Convert color name to hex if it's a known color name
-			if hexColor := colorNameToHex(colorStr); hexColor != "" {
-				color = hexColor
-			} else if strings.HasPrefix(colorStr, "#") {
-				// Already a hex color
-				color = colorStr
-			} else {
-				// Unknown color name, pass through (might be handled by C++ backend)
-				color = colorValue.Str
-			}
-		} else if colorValue.Kind == gs.ValueNumber {
-			color = fmt.Sprintf("#%06x", int(colorValue.Num))
-		} else {
-			return fmt.Errorf("color must be a string or number")
+		color, err := colorArg(colorValue)
+		if err != nil {
+			return err
 		}
 		actionProps["color"] = color
 	}
@@ -445,62 +501,49 @@ func (r *ReaperDSL) SetTrack(args gs.Args) error {
 	}
 
 	// Check if we have a filtered collection to apply to
-	if filteredCollection, hasFiltered := p.data["current_filtered"]; hasFiltered {
-		log.Printf("🔍 SetTrack: Found filtered collection (hasFiltered=%v)", hasFiltered)
-		if filtered, ok := filteredCollection.([]any); ok {
-			log.Printf("🔍 SetTrack: Filtered collection has %d items", len(filtered))
-			if len(filtered) > 0 {
-				for _, item := range filtered {
-					trackMap, ok := item.(map[string]any)
-					if !ok {
-						log.Printf("⚠️  SetTrack: Item is not a map: %T", item)
-						continue
-					}
-					trackIndex, ok := trackMap["index"].(int)
-					if !ok {
-						if trackIndexFloat, ok := trackMap["index"].(float64); ok {
-							trackIndex = int(trackIndexFloat)
-						} else {
-							log.Printf("⚠️  SetTrack: Could not extract track index from %+v", trackMap)
-							continue
-						}
-					}
-
-					action := map[string]any{
-						"action": "set_track",
-						"track":  trackIndex,
-					}
-
-					// Copy all properties
-					for k, v := range actionProps {
-						action[k] = v
-					}
-
-					log.Printf("✅ SetTrack: Adding action for track %d, props=%+v", trackIndex, actionProps)
-					p.actions = append(p.actions, action)
-				}
-				delete(p.data, "current_filtered")
-				log.Printf("✅ SetTrack: Applied to %d filtered tracks", len(filtered))
-				return nil
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		logger.Printf(r.parser.ctx, "🔍 SetTrack: Filtered collection has %d items", len(filtered))
+		alreadySet := 0
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  SetTrack: Item is not a map: %T", item)
+				continue
+			}
+			trackProps, err := resolveExprProps(actionProps, exprVars{"track": trackMap})
+			if err != nil {
+				return fmt.Errorf("set_track: %w", err)
+			}
+			if propertiesAlreadySet(trackMap, trackProps) {
+				alreadySet++
 			}
+			trackIndex, ok := trackMap["index"].(int)
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  SetTrack: Could not extract track index from %+v", trackMap)
+				continue
+			}
+
+			logger.Printf(r.parser.ctx, "✅ SetTrack: Adding action for track %d, props=%+v", trackIndex, trackProps)
+			p.appendAction(SetTrackAction{Track: TrackIndex(trackIndex), Properties: trackProps})
 		}
+		p.recordFilterSummary("set_track", actionProps, len(filtered), alreadySet)
+		logger.Printf(r.parser.ctx, "✅ SetTrack: Applied to %d filtered tracks", len(filtered))
+		return nil
+	}
+
+	if p.onMaster {
+		return p.masterSetTrack(actionProps)
 	}
 
 	// Normal single-track operation
 	if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for set_track call")
 	}
-	action := map[string]any{
-		"action": "set_track",
-		"track":  p.currentTrackIndex,
-	}
-
-	// Copy all properties
-	for k, v := range actionProps {
-		action[k] = v
+	trackProps, err := resolveExprProps(actionProps, exprVars{"track": p.stateTrack(p.currentTrackIndex)})
+	if err != nil {
+		return fmt.Errorf("set_track: %w", err)
 	}
-
-	p.actions = append(p.actions, action)
+	p.appendAction(SetTrackAction{Track: TrackIndex(p.currentTrackIndex), Properties: trackProps})
 	return nil
 }
 
@@ -510,59 +553,39 @@ func (r *ReaperDSL) Delete(args gs.Args) error {
 	p := r.parser
 
 	// Check if we have a filtered collection to apply to
-	if filteredCollection, hasFiltered := p.data["current_filtered"]; hasFiltered {
-		log.Printf("🔍 Delete: Found filtered collection (hasFiltered=%v)", hasFiltered)
-		if filtered, ok := filteredCollection.([]any); ok {
-			log.Printf("🔍 Delete: Filtered collection has %d items", len(filtered))
-			if len(filtered) > 0 {
-				// Apply to all filtered tracks
-				for _, item := range filtered {
-					trackMap, ok := item.(map[string]any)
-					if !ok {
-						log.Printf("⚠️  Delete: Item is not a map: %T", item)
-						continue
-					}
-					trackIndex, ok := trackMap["index"].(int)
-					if !ok {
-						// Try float64 (JSON numbers are float64)
-						if trackIndexFloat, ok := trackMap["index"].(float64); ok {
-							trackIndex = int(trackIndexFloat)
-						} else {
-							log.Printf("⚠️  Delete: Could not extract track index from %+v", trackMap)
-							continue
-						}
-					}
-					trackName, _ := trackMap["name"].(string)
-					log.Printf("✅ Delete: Adding action for track %d (name='%s')", trackIndex, trackName)
-					action := map[string]any{
-						"action": "delete_track",
-						"track":  trackIndex,
-					}
-					p.actions = append(p.actions, action)
+	if chain := p.takeChain(); chain != nil {
+		filtered := chain.items
+		logger.Printf(r.parser.ctx, "🔍 Delete: Filtered collection has %d items", len(filtered))
+		if len(filtered) > 0 {
+			// Apply to all filtered tracks
+			for _, item := range filtered {
+				trackMap, ok := item.(map[string]any)
+				if !ok {
+					logger.Printf(r.parser.ctx, "⚠️  Delete: Item is not a map: %T", item)
+					continue
 				}
-				// Clear filtered collection after applying
-				delete(p.data, "current_filtered")
-				log.Printf("✅ Delete: Applied delete_track to %d filtered tracks", len(filtered))
-				return nil
-			} else {
-				log.Printf("⚠️  Delete: Filtered collection is empty! This means filter() returned 0 results.")
+				trackIndex, ok := trackMap["index"].(int)
+				if !ok {
+					logger.Printf(r.parser.ctx, "⚠️  Delete: Could not extract track index from %+v", trackMap)
+					continue
+				}
+				trackName, _ := trackMap["name"].(string)
+				logger.Printf(r.parser.ctx, "✅ Delete: Adding action for track %d (name='%s')", trackIndex, trackName)
+				p.appendAction(DeleteTrackAction{Track: TrackIndex(trackIndex)})
 			}
-		} else {
-			log.Printf("⚠️  Delete: Filtered collection is not a []any: %T", filteredCollection)
+			logger.Printf(r.parser.ctx, "✅ Delete: Applied delete_track to %d filtered tracks", len(filtered))
+			return nil
 		}
+		logger.Printf(r.parser.ctx, "⚠️  Delete: Filtered collection is empty! This means filter() returned 0 results.")
 	} else {
-		log.Printf("🔍 Delete: No filtered collection found, using single-track mode (currentTrackIndex=%d)", p.currentTrackIndex)
+		logger.Printf(r.parser.ctx, "🔍 Delete: No filtered collection found, using single-track mode (currentTrackIndex=%d)", p.currentTrackIndex)
 	}
 
 	// Normal single-track operation
 	if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for delete call")
 	}
-	action := map[string]any{
-		"action": "delete_track",
-		"track":  p.currentTrackIndex,
-	}
-	p.actions = append(p.actions, action)
+	p.appendAction(DeleteTrackAction{Track: TrackIndex(p.currentTrackIndex)})
 	return nil
 }
 
@@ -572,89 +595,74 @@ func (r *ReaperDSL) DeleteClip(args gs.Args) error {
 	p := r.parser
 
 	// Check if we have a filtered collection to apply to
-	if filteredCollection, hasFiltered := p.data["current_filtered"]; hasFiltered {
-		log.Printf("🔍 DeleteClip: Found filtered collection (hasFiltered=%v)", hasFiltered)
-		if filtered, ok := filteredCollection.([]any); ok {
-			log.Printf("🔍 DeleteClip: Filtered collection has %d items", len(filtered))
-			if len(filtered) > 0 {
-				// Check if this is a clips collection
-				firstItem, ok := filtered[0].(map[string]any)
-				if !ok {
-					log.Printf("⚠️  DeleteClip: First item is not a map: %T", filtered[0])
-				} else {
-					_, hasTrackField := firstItem["track"]
-					_, hasLengthField := firstItem["length"]
-					_, hasPositionField := firstItem["position"]
-					isClip := hasTrackField && (hasLengthField || hasPositionField)
-
-					if isClip {
-						// This is a clips collection
-						log.Printf("🔍 DeleteClip: Detected clips collection")
-						for _, item := range filtered {
-							clipMap, ok := item.(map[string]any)
-							if !ok {
-								log.Printf("⚠️  DeleteClip: Clip item is not a map: %T", item)
-								continue
-							}
-							// Get track index from clip
-							trackIndex := -1
-							if trackVal, ok := clipMap["track"].(int); ok {
-								trackIndex = trackVal
-							} else if trackValFloat, ok := clipMap["track"].(float64); ok {
-								trackIndex = int(trackValFloat)
-							}
-
-							// Get clip identifier (prefer position, then index)
-							var clipIndex *int
-							var position *float64
-
-							if idx, ok := clipMap["index"].(int); ok {
-								clipIndex = &idx
-							} else if idxFloat, ok := clipMap["index"].(float64); ok {
-								idxInt := int(idxFloat)
-								clipIndex = &idxInt
-							}
-
-							if pos, ok := clipMap["position"].(float64); ok {
-								position = &pos
-							}
-
-							if trackIndex < 0 {
-								log.Printf("⚠️  DeleteClip: Could not extract track index from clip %+v", clipMap)
-								continue
-							}
-
-							action := map[string]any{
-								"action": "delete_clip",
-								"track":  trackIndex,
-							}
-
-							// Add clip identifier (prefer position, then index)
-							if position != nil {
-								action["position"] = *position
-							} else if clipIndex != nil {
-								action["clip"] = *clipIndex
-							} else {
-								log.Printf("⚠️  DeleteClip: Could not identify clip (no index or position): %+v", clipMap)
-								continue
-							}
-
-							log.Printf("✅ DeleteClip: Adding action for clip on track %d", trackIndex)
-							p.actions = append(p.actions, action)
+	if chain := p.takeChain(); chain != nil {
+		filtered := chain.items
+		logger.Printf(r.parser.ctx, "🔍 DeleteClip: Filtered collection has %d items", len(filtered))
+		if len(filtered) > 0 {
+			// Check if this is a clips collection
+			firstItem, ok := filtered[0].(map[string]any)
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  DeleteClip: First item is not a map: %T", filtered[0])
+			} else {
+				_, hasTrackField := firstItem["track"]
+				_, hasLengthField := firstItem["length"]
+				_, hasPositionField := firstItem["position"]
+				isClip := hasTrackField && (hasLengthField || hasPositionField)
+
+				if isClip {
+					// This is a clips collection
+					logger.Printf(r.parser.ctx, "🔍 DeleteClip: Detected clips collection")
+					for _, item := range filtered {
+						clipMap, ok := item.(map[string]any)
+						if !ok {
+							logger.Printf(r.parser.ctx, "⚠️  DeleteClip: Clip item is not a map: %T", item)
+							continue
+						}
+						// Get track index from clip
+						trackIndex := -1
+						if trackVal, ok := clipMap["track"].(int); ok {
+							trackIndex = trackVal
+						}
+
+						// Get clip identifier (prefer position, then index)
+						var clipIndex *int
+						var position *float64
+
+						if idx, ok := clipMap["index"].(int); ok {
+							clipIndex = &idx
+						}
+
+						if pos, ok := clipMap["position"].(float64); ok {
+							position = &pos
+						}
+
+						if trackIndex < 0 {
+							logger.Printf(r.parser.ctx, "⚠️  DeleteClip: Could not extract track index from clip %+v", clipMap)
+							continue
 						}
-						// Clear filtered collection after applying
-						delete(p.data, "current_filtered")
-						log.Printf("✅ DeleteClip: Applied delete_clip to %d filtered clips", len(filtered))
-						return nil
-					} else {
-						log.Printf("⚠️  DeleteClip: Filtered collection is not clips (isClip=%v)", isClip)
+
+						// Add clip identifier (prefer position, then index)
+						var clip ClipRef
+						if position != nil {
+							clip = ClipAtPosition(*position)
+						} else if clipIndex != nil {
+							clip = ClipAt(*clipIndex)
+						} else {
+							logger.Printf(r.parser.ctx, "⚠️  DeleteClip: Could not identify clip (no index or position): %+v", clipMap)
+							continue
+						}
+
+						logger.Printf(r.parser.ctx, "✅ DeleteClip: Adding action for clip on track %d", trackIndex)
+						p.appendAction(DeleteClipAction{Track: TrackIndex(trackIndex), ClipRef: clip})
 					}
+					logger.Printf(r.parser.ctx, "✅ DeleteClip: Applied delete_clip to %d filtered clips", len(filtered))
+					return nil
+				} else {
+					logger.Printf(r.parser.ctx, "⚠️  DeleteClip: Filtered collection is not clips (isClip=%v)", isClip)
 				}
-			} else {
-				log.Printf("⚠️  DeleteClip: Filtered collection is empty!")
 			}
 		} else {
-			log.Printf("⚠️  DeleteClip: Filtered collection is not a []any: %T", filteredCollection)
+			logger.Printf(r.parser.ctx, "⚠️  DeleteClip: Filtered collection is empty!")
 		}
 	}
 
@@ -662,24 +670,40 @@ func (r *ReaperDSL) DeleteClip(args gs.Args) error {
 	if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for deleteClip call")
 	}
-	action := map[string]any{
-		"action": "delete_clip",
-		"track":  p.currentTrackIndex,
+	clip, ok := clipRefArg(args, "position")
+	if !ok {
+		return fmt.Errorf("deleteClip requires one of: clip (index), position (seconds), or bar (number)")
 	}
 
-	// Clip identification: clip index, position, or bar
+	p.appendAction(DeleteClipAction{Track: TrackIndex(p.currentTrackIndex), ClipRef: clip})
+	return nil
+}
+
+// clipRefArg reads the clip a call targets from its clip (index), positionKey (seconds) or bar
+// argument, in that order of preference
+func clipRefArg(args gs.Args, positionKey string) (ClipRef, bool) {
 	if clipValue, ok := args["clip"]; ok && clipValue.Kind == gs.ValueNumber {
-		action["clip"] = int(clipValue.Num)
-	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
-		action["position"] = positionValue.Num
-	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
-		action["bar"] = int(barValue.Num)
-	} else {
-		return fmt.Errorf("deleteClip requires one of: clip (index), position (seconds), or bar (number)")
+		return ClipAt(int(clipValue.Num)), true
+	}
+	if positionValue, ok := args[positionKey]; ok && positionValue.Kind == gs.ValueNumber {
+		return ClipAtPosition(positionValue.Num), true
+	}
+	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		return ClipAtBar(int(barValue.Num)), true
 	}
+	return ClipRef{}, false
+}
 
-	p.actions = append(p.actions, action)
-	return nil
+// clipLengthSeconds resolves a new_clip length to seconds: length_bars always wins,
+// otherwise length (default 4) is read in the request's length unit.
+func (p *FunctionalDSLParser) clipLengthSeconds(args gs.Args) float64 {
+	if lengthBarsValue, ok := args["length_bars"]; ok && lengthBarsValue.Kind == gs.ValueNumber {
+		return p.barsToSeconds(lengthBarsValue.Num)
+	}
+	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
+		return p.lengthToSeconds(lengthValue.Num)
+	}
+	return p.lengthToSeconds(4.0)
 }
 
 // SetClip handles .set_clip() calls to set clip properties (name, color, selected, etc.).
@@ -697,23 +721,9 @@ func (r *ReaperDSL) SetClip(args gs.Args) error {
 
 	// Handle color
 	if colorValue, ok := args["color"]; ok {
-		var color string
-		if colorValue.Kind == gs.ValueString {
-			colorStr := strings.ToLower(strings.TrimSpace(colorValue.Str))
-			// Convert color name to hex if it's a known color name
-			if hexColor := colorNameToHex(colorStr); hexColor != "" {
-				color = hexColor
-			} else if strings.HasPrefix(colorStr, "#") {
-				// Already a hex color
-				color = colorStr
-			} else {
-				// Unknown color name, pass through (might be handled by C++ backend)
-				color = colorValue.Str
-			}
-		} else if colorValue.Kind == gs.ValueNumber {
-			color = fmt.Sprintf("#%06x", int(colorValue.Num))
-		} else {
-			return fmt.Errorf("color must be a string or number")
+		color, err := colorArg(colorValue)
+		if err != nil {
+			return err
 		}
 		actionProps["color"] = color
 	}
@@ -723,223 +733,257 @@ func (r *ReaperDSL) SetClip(args gs.Args) error {
 		actionProps["selected"] = selectedValue.Bool
 	}
 
-	// Handle length
-	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
-		actionProps["length"] = lengthValue.Num
+	// Lengths are emitted in seconds. length_bars overrides the request unit for the whole
+	// call, so source_length is then read in bars too.
+	toSeconds := p.lengthToSeconds
+	if _, ok := args["length_bars"]; ok {
+		toSeconds = p.barsToSeconds
+	}
+
+	// Handle length; either may be an expression like clip.length * 2
+	if length, ok := numericArg(args, "length_bars", p.barsToSeconds); ok {
+		actionProps["length"] = length
+	} else if length, ok := numericArg(args, "length", toSeconds); ok {
+		actionProps["length"] = length
+	}
+
+	// Handle source_length (loop source length, distinct from item length)
+	sourceLength := 0.0
+	if sourceLengthValue, ok := args["source_length"]; ok && sourceLengthValue.Kind == gs.ValueNumber {
+		if sourceLengthValue.Num <= 0 {
+			return fmt.Errorf("set_clip source_length must be greater than 0, got %g", sourceLengthValue.Num)
+		}
+		sourceLength = toSeconds(sourceLengthValue.Num)
+		actionProps["source_length"] = sourceLength
+	}
+
+	// Handle loop
+	loop := false
+	if loopValue, ok := args["loop"]; ok && loopValue.Kind == gs.ValueBool {
+		loop = loopValue.Bool
+		actionProps["loop"] = loop
 	}
 
 	// Must have at least one property
 	if len(actionProps) == 0 {
-		return fmt.Errorf("set_clip requires at least one property: name, color, selected, or length")
+		return fmt.Errorf("set_clip requires at least one property: name, color, selected, length, length_bars, source_length, or loop")
 	}
 
-	// Check if we have a filtered collection to apply to
-	if filteredCollection, hasFiltered := p.data["current_filtered"]; hasFiltered {
-		log.Printf("🔍 SetClip: Found filtered collection (hasFiltered=%v)", hasFiltered)
-		if filtered, ok := filteredCollection.([]any); ok {
-			log.Printf("🔍 SetClip: Filtered collection has %d items", len(filtered))
-			if len(filtered) > 0 {
-				for _, item := range filtered {
-					clipMap, ok := item.(map[string]any)
-					if !ok {
-						log.Printf("⚠️  SetClip: Clip item is not a map: %T", item)
-						continue
-					}
-					trackIndex := -1
-					if trackVal, ok := clipMap["track"].(int); ok {
-						trackIndex = trackVal
-					} else if trackValFloat, ok := clipMap["track"].(float64); ok {
-						trackIndex = int(trackValFloat)
-					}
-
-					var clipIndex *int
-					var position *float64
+	// An item longer than its source only makes sense if the source loops
+	if length, ok := actionProps["length"].(float64); ok && sourceLength > 0 {
+		if err := validateClipLoopSource(length, sourceLength, loop); err != nil {
+			return err
+		}
+	}
 
-					if idx, ok := clipMap["index"].(int); ok {
-						clipIndex = &idx
-					} else if idxFloat, ok := clipMap["index"].(float64); ok {
-						idxInt := int(idxFloat)
-						clipIndex = &idxInt
-					}
+	// Check if we have a filtered collection to apply to
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		logger.Printf(r.parser.ctx, "🔍 SetClip: Filtered collection has %d items", len(filtered))
+		alreadySet := 0
+		for _, item := range filtered {
+			clipMap, ok := item.(map[string]any)
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  SetClip: Clip item is not a map: %T", item)
+				continue
+			}
+			clipProps, err := resolveExprProps(actionProps, exprVars{"clip": clipMap})
+			if err != nil {
+				return fmt.Errorf("set_clip: %w", err)
+			}
+			if propertiesAlreadySet(clipMap, clipProps) {
+				alreadySet++
+			}
+			trackIndex := -1
+			if trackVal, ok := clipMap["track"].(int); ok {
+				trackIndex = trackVal
+			}
 
-					if pos, ok := clipMap["position"].(float64); ok {
-						position = &pos
-					}
+			var clipIndex *int
+			var position *float64
 
-					if trackIndex < 0 {
-						log.Printf("⚠️  SetClip: Could not extract track index from clip %+v", clipMap)
-						continue
-					}
+			if idx, ok := clipMap["index"].(int); ok {
+				clipIndex = &idx
+			}
 
-					action := map[string]any{
-						"action": "set_clip",
-						"track":  trackIndex,
-					}
+			if pos, ok := clipMap["position"].(float64); ok {
+				position = &pos
+			}
 
-					// Copy all properties
-					for k, v := range actionProps {
-						action[k] = v
-					}
+			if trackIndex < 0 {
+				logger.Printf(r.parser.ctx, "⚠️  SetClip: Could not extract track index from clip %+v", clipMap)
+				continue
+			}
 
-					if position != nil {
-						action["position"] = *position
-					} else if clipIndex != nil {
-						action["clip"] = *clipIndex
-					} else {
-						log.Printf("⚠️  SetClip: Could not identify clip (no index or position): %+v", clipMap)
-						continue
+			// Validate the source against the new length, or the clip's current length
+			if sourceLength > 0 {
+				clipLength, ok := getNumericValue(clipProps["length"])
+				if !ok {
+					clipLength, ok = getNumericValue(clipMap["length"])
+				}
+				if ok {
+					if err := validateClipLoopSource(clipLength, sourceLength, loop); err != nil {
+						return err
 					}
-
-					log.Printf("✅ SetClip: Adding action for clip on track %d, props=%+v", trackIndex, actionProps)
-					p.actions = append(p.actions, action)
 				}
-				delete(p.data, "current_filtered")
-				log.Printf("✅ SetClip: Applied to %d filtered clips", len(filtered))
-				return nil
 			}
+
+			var clip ClipRef
+			if position != nil {
+				clip = ClipAtPosition(*position)
+			} else if clipIndex != nil {
+				clip = ClipAt(*clipIndex)
+			} else {
+				logger.Printf(r.parser.ctx, "⚠️  SetClip: Could not identify clip (no index or position): %+v", clipMap)
+				continue
+			}
+
+			logger.Printf(r.parser.ctx, "✅ SetClip: Adding action for clip on track %d, props=%+v", trackIndex, clipProps)
+			p.appendAction(SetClipAction{Track: TrackIndex(trackIndex), ClipRef: clip, Properties: clipProps})
 		}
+		p.recordFilterSummary("set_clip", actionProps, len(filtered), alreadySet)
+		logger.Printf(r.parser.ctx, "✅ SetClip: Applied to %d filtered clips", len(filtered))
+		return nil
 	}
 
 	// Normal single-clip operation
 	if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for set_clip call")
 	}
-	action := map[string]any{
-		"action": "set_clip",
-		"track":  p.currentTrackIndex,
+	clip, ok := clipRefArg(args, "position")
+	if !ok {
+		return fmt.Errorf("set_clip requires one of: clip (index), position (seconds), or bar (number)")
 	}
+	action := SetClipAction{Track: TrackIndex(p.currentTrackIndex), ClipRef: clip}
 
-	// Copy all properties
-	for k, v := range actionProps {
-		action[k] = v
+	// Expressions see the track and, when state has it, the clip being changed
+	track := p.stateTrack(p.currentTrackIndex)
+	current := stateClip(track, actionMap(action))
+	clipProps, err := resolveExprProps(actionProps, exprVars{"track": track, "clip": current})
+	if err != nil {
+		return fmt.Errorf("set_clip: %w", err)
 	}
 
-	// Clip identification
-	if clipValue, ok := args["clip"]; ok && clipValue.Kind == gs.ValueNumber {
-		action["clip"] = int(clipValue.Num)
-	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
-		action["position"] = positionValue.Num
-	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
-		action["bar"] = int(barValue.Num)
-	} else {
-		return fmt.Errorf("set_clip requires one of: clip (index), position (seconds), or bar (number)")
+	// Validate the source against the new length, or the clip's current length
+	if sourceLength > 0 {
+		clipLength, ok := getNumericValue(clipProps["length"])
+		if !ok {
+			clipLength, ok = getNumericValue(current["length"])
+		}
+		if ok {
+			if err := validateClipLoopSource(clipLength, sourceLength, loop); err != nil {
+				return err
+			}
+		}
 	}
 
-	p.actions = append(p.actions, action)
+	action.Properties = clipProps
+	p.appendAction(action)
 	return nil
 }
 
-// MoveClip handles .move_clip() or .set_clip_position() calls to move a clip.
+// validateClipLoopSource checks that an item longer than its source is set to loop.
+// REAPER would otherwise pad the item with silence past the end of the source.
+func validateClipLoopSource(length, sourceLength float64, loop bool) error {
+	if sourceLength < length && !loop {
+		return fmt.Errorf("set_clip requires loop=true when source_length (%g) is shorter than length (%g)", sourceLength, length)
+	}
+	return nil
+}
+
+// MoveClip handles .move_clip() or .set_clip_position() calls to move a clip, to a position,
+// after an anchor or by relative_bars (see relative_positions.go).
 // If there's a filtered collection, applies to all clips; otherwise uses currentTrackIndex.
 func (r *ReaperDSL) MoveClip(args gs.Args) error {
 	p := r.parser
 
-	// Get position (required)
-	positionValue, ok := args["position"]
-	if !ok {
-		// Try "bar" as alternative
-		if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
-			// Convert bar to position (would need BPM, but for now just use bar number)
-			// This is a placeholder - in real implementation would convert bar to seconds
-			positionValue = barValue
-		} else {
-			return fmt.Errorf("move_clip requires position (seconds) or bar (number)")
-		}
-	}
-
-	var position float64
-	if positionValue.Kind == gs.ValueNumber {
-		position = positionValue.Num
-	} else {
-		return fmt.Errorf("position must be a number")
+	dest, err := p.moveClipDestination(args)
+	if err != nil {
+		return err
 	}
 
 	// Check if we have a filtered collection to apply to
-	if filteredCollection, hasFiltered := p.data["current_filtered"]; hasFiltered {
-		log.Printf("🔍 MoveClip: Found filtered collection (hasFiltered=%v)", hasFiltered)
-		if filtered, ok := filteredCollection.([]any); ok {
-			log.Printf("🔍 MoveClip: Filtered collection has %d items", len(filtered))
-			if len(filtered) > 0 {
-				for _, item := range filtered {
-					clipMap, ok := item.(map[string]any)
-					if !ok {
-						log.Printf("⚠️  MoveClip: Clip item is not a map: %T", item)
-						continue
-					}
-					trackIndex := -1
-					if trackVal, ok := clipMap["track"].(int); ok {
-						trackIndex = trackVal
-					} else if trackValFloat, ok := clipMap["track"].(float64); ok {
-						trackIndex = int(trackValFloat)
-					}
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		logger.Printf(r.parser.ctx, "🔍 MoveClip: Filtered collection has %d items", len(filtered))
+		alreadySet := 0
+		for _, item := range filtered {
+			clipMap, ok := item.(map[string]any)
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  MoveClip: Clip item is not a map: %T", item)
+				continue
+			}
+			trackIndex := -1
+			if trackVal, ok := clipMap["track"].(int); ok {
+				trackIndex = trackVal
+			}
 
-					var clipIndex *int
-					var oldPosition *float64
+			var clipIndex *int
+			var oldPosition *float64
 
-					if idx, ok := clipMap["index"].(int); ok {
-						clipIndex = &idx
-					} else if idxFloat, ok := clipMap["index"].(float64); ok {
-						idxInt := int(idxFloat)
-						clipIndex = &idxInt
-					}
+			if idx, ok := clipMap["index"].(int); ok {
+				clipIndex = &idx
+			}
 
-					if pos, ok := clipMap["position"].(float64); ok {
-						oldPosition = &pos
-					}
+			if pos, ok := clipMap["position"].(float64); ok {
+				oldPosition = &pos
+			}
 
-					if trackIndex < 0 {
-						log.Printf("⚠️  MoveClip: Could not extract track index from clip %+v", clipMap)
-						continue
-					}
+			if trackIndex < 0 {
+				logger.Printf(r.parser.ctx, "⚠️  MoveClip: Could not extract track index from clip %+v", clipMap)
+				continue
+			}
 
-					action := map[string]any{
-						"action":   "set_clip_position",
-						"track":    trackIndex,
-						"position": position,
-					}
+			var current *float64
+			if pos, ok := getNumericValue(clipMap["position"]); ok {
+				current = &pos
+			}
+			position, err := p.resolveDestination("move_clip", dest, trackIndex, current)
+			if err != nil {
+				return err
+			}
+			if propertiesAlreadySet(clipMap, map[string]any{"position": position}) {
+				alreadySet++
+			}
 
-					// Use old position or index to identify the clip
-					if oldPosition != nil {
-						action["old_position"] = *oldPosition
-					} else if clipIndex != nil {
-						action["clip"] = *clipIndex
-					} else {
-						log.Printf("⚠️  MoveClip: Could not identify clip (no index or position): %+v", clipMap)
-						continue
-					}
+			action := SetClipPositionAction{Track: TrackIndex(trackIndex), Position: position}
 
-					log.Printf("✅ MoveClip: Adding action for clip on track %d, new position=%v", trackIndex, position)
-					p.actions = append(p.actions, action)
-				}
-				delete(p.data, "current_filtered")
-				log.Printf("✅ MoveClip: Applied set_clip_position to %d filtered clips", len(filtered))
-				return nil
+			// Use old position or index to identify the clip
+			if oldPosition != nil {
+				action.OldPosition = oldPosition
+			} else if clipIndex != nil {
+				action.Clip = clipIndex
+			} else {
+				logger.Printf(r.parser.ctx, "⚠️  MoveClip: Could not identify clip (no index or position): %+v", clipMap)
+				continue
 			}
+
+			logger.Printf(r.parser.ctx, "✅ MoveClip: Adding action for clip on track %d, new position=%v", trackIndex, position)
+			p.appendAction(action)
 		}
+		p.recordFilterSummary("set_clip_position", destinationProps(args), len(filtered), alreadySet)
+		logger.Printf(r.parser.ctx, "✅ MoveClip: Applied set_clip_position to %d filtered clips", len(filtered))
+		return nil
 	}
 
 	// Normal single-clip operation
 	if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for move_clip call")
 	}
-	action := map[string]any{
-		"action":   "set_clip_position",
-		"track":    p.currentTrackIndex,
-		"position": position,
-	}
-
-	// Clip identification
-	if clipValue, ok := args["clip"]; ok && clipValue.Kind == gs.ValueNumber {
-		action["clip"] = int(clipValue.Num)
-	} else if oldPositionValue, ok := args["old_position"]; ok && oldPositionValue.Kind == gs.ValueNumber {
-		action["old_position"] = oldPositionValue.Num
-	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
-		action["bar"] = int(barValue.Num)
-	} else {
+	clip, ok := clipRefArg(args, "old_position")
+	if !ok {
 		return fmt.Errorf("move_clip requires one of: clip (index), old_position (seconds), or bar (number)")
 	}
+	position, err := p.resolveDestination("move_clip", dest, p.currentTrackIndex, p.clipPosition(p.currentTrackIndex, clip))
+	if err != nil {
+		return err
+	}
 
-	p.actions = append(p.actions, action)
+	p.appendAction(SetClipPositionAction{
+		Track:       TrackIndex(p.currentTrackIndex),
+		Position:    position,
+		Clip:        clip.Clip,
+		OldPosition: clip.Position,
+		Bar:         clip.Bar,
+	})
 	return nil
 }
 
@@ -950,8 +994,10 @@ func (r *ReaperDSL) AddAutomation(args gs.Args) error {
 	p := r.parser
 
 	// Get track index
-	trackIndex := p.currentTrackIndex
-	if trackIndex < 0 {
+	var track any = p.currentTrackIndex
+	if p.onMaster {
+		track = MasterTrack
+	} else if p.currentTrackIndex < 0 {
 		return fmt.Errorf("no track context for addAutomation call")
 	}
 
@@ -964,7 +1010,7 @@ func (r *ReaperDSL) AddAutomation(args gs.Args) error {
 
 	action := map[string]any{
 		"action": "add_automation",
-		"track":  trackIndex,
+		"track":  track,
 		"param":  param,
 	}
 
@@ -998,6 +1044,17 @@ func (r *ReaperDSL) AddAutomation(args gs.Args) error {
 		if freqValue, ok := args["freq"]; ok && freqValue.Kind == gs.ValueNumber {
 			action["freq"] = freqValue.Num
 		}
+		if rateValue, ok := args["rate"]; ok && rateValue.Kind == gs.ValueString {
+			if _, hasFreq := action["freq"]; hasFreq {
+				return fmt.Errorf("addAutomation takes freq or rate, not both")
+			}
+			freq, hz, err := p.automationRateFreq(curveValue.Str, rateValue.Str)
+			if err != nil {
+				return fmt.Errorf("addAutomation: %w", err)
+			}
+			action["freq"] = freq
+			logger.Printf(r.parser.ctx, "🎵 AddAutomation: rate %s = %g cycles per bar (%.2f Hz)", rateValue.Str, freq, hz)
+		}
 		if ampValue, ok := args["amplitude"]; ok && ampValue.Kind == gs.ValueNumber {
 			action["amplitude"] = ampValue.Num
 		}
@@ -1006,7 +1063,7 @@ func (r *ReaperDSL) AddAutomation(args gs.Args) error {
 		}
 
 		p.actions = append(p.actions, action)
-		log.Printf("✅ AddAutomation (curve): track=%d, param=%s, curve=%s", trackIndex, param, curveValue.Str)
+		logger.Printf(r.parser.ctx, "✅ AddAutomation (curve): track=%v, param=%s, curve=%s", track, param, curveValue.Str)
 		return nil
 	}
 
@@ -1020,7 +1077,7 @@ func (r *ReaperDSL) AddAutomation(args gs.Args) error {
 			pointsStr := pointsValue.Str
 			parsed, err := parseAutomationPointsFromString(pointsStr)
 			if err != nil {
-				log.Printf("⚠️ AddAutomation: Failed to parse points string: %v", err)
+				logger.Printf(r.parser.ctx, "⚠️ AddAutomation: Failed to parse points string: %v", err)
 			} else {
 				points = parsed
 			}
@@ -1058,7 +1115,7 @@ func (r *ReaperDSL) AddAutomation(args gs.Args) error {
 	}
 
 	p.actions = append(p.actions, action)
-	log.Printf("✅ AddAutomation (points): track=%d, param=%s, points=%d", trackIndex, param, len(points))
+	logger.Printf(r.parser.ctx, "✅ AddAutomation (points): track=%v, param=%s, points=%d", track, param, len(points))
 	return nil
 }
 
@@ -1152,367 +1209,84 @@ func parseAutomationPointFromString(content string) (map[string]any, error) {
 // ========== Functional methods ==========
 
 // Filter filters a collection using a predicate.
-// For Go, we'll use a simpler approach since we don't have expression evaluation yet.
-// The predicate can be a function reference or we evaluate simple comparisons.
+// Grammar: filter(collection, predicate). The predicate is parsed before Filter runs (see
+// filterSyntaxParser) and arrives as predicate=N, an index into p.predicates.
 //
-// Example: filter(tracks, @is_fx_track) or filter(tracks, "name", "==", "FX")
+// Example: filter(tracks, track.muted == true && track.name != "Master")
 func (r *ReaperDSL) Filter(args gs.Args) error {
 	p := r.parser
+	p.onMaster = false
 
-	// Log all args for debugging
-	log.Printf("🔍 Filter: Received args with %d keys: %v", len(args), getArgsKeys(args))
-	for k, v := range args {
-		log.Printf("   Filter arg[%s] = %+v (Kind: %v, Str: '%s', Num: %v)", k, v, v.Kind, v.Str, v.Num)
+	predValue, ok := args["predicate"]
+	if !ok || predValue.Kind != gs.ValueNumber || int(predValue.Num) < 0 || int(predValue.Num) >= len(p.predicates) {
+		return fmt.Errorf("filter requires a predicate, e.g. filter(tracks, track.muted == true)")
 	}
+	fp := p.predicates[int(predValue.Num)]
 
-	// Get collection name or value
-	var collection []any
+	// The collection is the first argument, or inferred from the predicate's variable
 	var collectionName string
-
-	// Try multiple ways to find the collection argument
-	// 1. Named argument "collection"
-	if collectionValue, ok := args["collection"]; ok {
-		if collectionValue.Kind == gs.ValueString {
-			collectionName = collectionValue.Str
-			var err error
-			collection, err = p.resolveCollection(collectionName)
-			if err == nil {
-				log.Printf("✅ Filter: Found collection '%s' via named arg 'collection'", collectionName)
-			} else {
-				log.Printf("⚠️  Filter: Failed to resolve collection '%s' from named arg: %v", collectionName, err)
-			}
-		}
-	}
-
-	// 2. First positional argument (empty key or _positional)
-	if collection == nil {
-		if collectionValue, ok := args[""]; ok {
-			if collectionValue.Kind == gs.ValueString {
-				collectionName = collectionValue.Str
-				var err error
-				collection, err = p.resolveCollection(collectionName)
-				if err == nil {
-					log.Printf("✅ Filter: Found collection '%s' via positional arg (empty key)", collectionName)
-				} else {
-					log.Printf("⚠️  Filter: Failed to resolve collection '%s' from positional arg: %v", collectionName, err)
-				}
-			}
-		} else if collectionValue, ok := args["_positional"]; ok {
-			if collectionValue.Kind == gs.ValueString {
-				collectionName = collectionValue.Str
-				var err error
-				collection, err = p.resolveCollection(collectionName)
-				if err == nil {
-					log.Printf("✅ Filter: Found collection '%s' via _positional key", collectionName)
-				} else {
-					log.Printf("⚠️  Filter: Failed to resolve collection '%s' from _positional: %v", collectionName, err)
-				}
-			}
+	for _, key := range []string{"collection", ""} {
+		if value, ok := args[key]; ok && value.Kind == gs.ValueString {
+			collectionName = strings.TrimSpace(value.Str)
+			break
 		}
 	}
-
-	// 3. Last resort: iterate and find first string value that resolves to a collection
-	// This handles the case where multiple positional arguments exist and the last one overwrote the first
-	// We need to check ALL args to find which one is the collection name
-	if collection == nil {
-		log.Printf("🔍 Filter: Trying to find collection by iterating all args...")
-		// First, try to find a collection by checking all string values
-		// We prioritize the positional argument (empty key) if it resolves to a collection
-		// Otherwise, check all other args
-		candidates := []struct {
-			key   string
-			value gs.Value
-		}{}
-
-		// Add positional arg first (if it exists)
-		if posValue, ok := args[""]; ok {
-			candidates = append(candidates, struct {
-				key   string
-				value gs.Value
-			}{"", posValue})
-		}
-
-		// Add all other args
-		for key, value := range args {
-			if key != "" && key != "predicate" && key != "property" && key != "operator" && key != "value" {
-				candidates = append(candidates, struct {
-					key   string
-					value gs.Value
-				}{key, value})
-			}
-		}
-
-		// Try each candidate to see if it resolves to a collection
-		for _, candidate := range candidates {
-			if candidate.value.Kind == gs.ValueString {
-				potentialName := candidate.value.Str
-				log.Printf("🔍 Filter: Trying to resolve '%s' (from key '%s') as collection...", potentialName, candidate.key)
-				if resolved, err := p.resolveCollection(potentialName); err == nil && resolved != nil {
-					collectionName = potentialName
-					collection = resolved
-					log.Printf("✅ Filter: Found collection '%s' via iteration (key: '%s')", collectionName, candidate.key)
-					break
-				} else {
-					log.Printf("⚠️  Filter: '%s' is not a valid collection: %v", potentialName, err)
-				}
-			}
-		}
+	if collectionName == "" {
+		collectionName = collectionForVariable(predicateVariable(fp.src))
+		logger.Printf(r.parser.ctx, "🔍 Filter: Inferred collection '%s' from predicate '%s'", collectionName, fp.src)
 	}
-
-	// Check if we found a collection
-	// If not, try to infer from predicate (e.g., "clip.length<1.5" suggests collection is "clips")
-	if collection == nil {
-		log.Printf("🔍 Filter: Could not find collection directly, trying to infer from predicate...")
-		// Check the positional argument - it might be the predicate, not the collection
-		if posValue, ok := args[""]; ok && posValue.Kind == gs.ValueString {
-			predicateStr := posValue.Str
-			log.Printf("🔍 Filter: Positional arg looks like predicate: '%s'", predicateStr)
-			// Try to extract collection name from predicate (e.g., "clip.length<1.5" -> "clips")
-			// Pattern: collection_item.property operator value
-			// We look for patterns like "track.name", "clip.length", etc.
-			if strings.Contains(predicateStr, ".") {
-				parts := strings.SplitN(predicateStr, ".", 2)
-				if len(parts) == 2 {
-					itemName := strings.TrimSpace(parts[0])
-					// Try to pluralize common item names
-					var potentialCollection string
-					switch itemName {
-					case "track":
-						potentialCollection = "tracks"
-					case "clip":
-						potentialCollection = "clips"
-					case "fx":
-						potentialCollection = "fx_chain"
-					default:
-						// Try simple pluralization (add 's')
-						potentialCollection = itemName + "s"
-					}
-					log.Printf("🔍 Filter: Inferred collection '%s' from predicate item '%s'", potentialCollection, itemName)
-					if resolved, err := p.resolveCollection(potentialCollection); err == nil && resolved != nil {
-						collectionName = potentialCollection
-						collection = resolved
-						log.Printf("✅ Filter: Found collection '%s' via predicate inference", collectionName)
-					}
-				}
-			}
-		}
-	}
-
-	// Final check
-	if collection == nil {
-		log.Printf("❌ Filter: Could not find collection argument. Available data keys: %v", getDataKeys(p.data))
-		return fmt.Errorf("filter requires a collection argument (got args: %v, available collections: %v)", args, getDataKeys(p.data))
+	collection, err := p.resolveCollection(collectionName)
+	if err != nil {
+		logger.Printf(r.parser.ctx, "❌ Filter: Could not find collection '%s'. Available data keys: %v", collectionName, getDataKeys(p.data))
+		return fmt.Errorf("filter requires a collection argument (got %q, available collections: %v)", collectionName, getDataKeys(p.data))
 	}
 
 	// Derive iteration variable name
 	iterVar := p.getIterVarFromCollection(collectionName)
+	logger.Printf(r.parser.ctx, "🔍 Filter: Evaluating '%s' over %d items in '%s'", fp.src, len(collection), collectionName)
 
-	// Filter the collection
-	// For now, we'll use a simple predicate evaluation
-	// In a full implementation, you'd evaluate expressions here
 	filtered := make([]any, 0)
-
+	var evalErr error
 	for _, item := range collection {
-		// Set iteration context
+		itemMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
 		p.setIterationContext(map[string]any{
 			iterVar: item,
 		})
 
-		// Evaluate predicate - support property_access comparison_op value format
-		// Example: filter(tracks, track.name == "foo")
-		// The grammar enforces proper predicates (property_access comparison_op value),
-		// so we don't need to handle standalone boolean literals like "true" or "false"
-		predicateMatched := false
-
-		// Try to find predicate components from parsed args
-		// The grammar should parse "track.name == \"foo\"" into property, operator, value
-		if propValue, ok := args["property"]; ok && propValue.Kind == gs.ValueString {
-			// Property access like "track.name"
-			if opValue, ok := args["operator"]; ok && opValue.Kind == gs.ValueString {
-				if compareValue, ok := args["value"]; ok {
-					// Extract property name from "track.name" -> "name"
-					propParts := strings.Split(propValue.Str, ".")
-					var propName string
-					if len(propParts) > 1 {
-						// track.name -> name
-						propName = propParts[len(propParts)-1]
-					} else {
-						propName = propValue.Str
-					}
-					predicateMatched = evaluateSimplePredicate(item, propName, opValue.Str, compareValue)
-				} else {
-					log.Printf("⚠️  Filter: Missing 'value' in predicate args: %+v", args)
-				}
-			} else {
-				log.Printf("⚠️  Filter: Missing 'operator' in predicate args: %+v", args)
-			}
-		} else if predicateValue, ok := args["predicate"]; ok {
-			// Handle function reference predicate (future extension)
-			if predicateValue.Kind == gs.ValueFunction {
-				// Function reference - would need to call it
-				// For now, include all items as placeholder
-				predicateMatched = true
-			}
-		} else {
-			// Try to manually parse predicate from args
-			// The parser might have split the predicate across multiple args
-			// Example: track.name=="Nebula Drift" might be parsed as:
-			//   args["track.name"] = "=\"Nebula Drift\""
-			// We need to reconstruct the full predicate
-
-			// First, try to find a complete predicate string
-			for key, value := range args {
-				if value.Kind == gs.ValueString {
-					predStr := strings.TrimSpace(value.Str)
-					log.Printf("🔍 Filter: Checking predicate string '%s' (key: '%s')", predStr, key)
-					// Check if it looks like a complete predicate: "track.name == \"value\"" or "track.name<1.5" or "clip.length<1.5"
-					// Support ==, !=, <, >, <=, >= operators
-					hasDot := strings.Contains(predStr, ".")
-					hasEq := strings.Contains(predStr, "==")
-					hasNe := strings.Contains(predStr, "!=")
-					hasLt := strings.Contains(predStr, "<")
-					hasGt := strings.Contains(predStr, ">")
-					hasIn := strings.Contains(predStr, " in ")
-					log.Printf("🔍 Filter: Predicate check - hasDot=%v, hasEq=%v, hasNe=%v, hasLt=%v, hasGt=%v, hasIn=%v", hasDot, hasEq, hasNe, hasLt, hasGt, hasIn)
-					if hasDot && (hasEq || hasNe || hasLt || hasGt || hasIn) {
-						log.Printf("🔍 Filter: Attempting to parse complete predicate: '%s'", predStr)
-						// Try to parse it manually
-						if matched := p.parseAndEvaluatePredicate(predStr, item, iterVar); matched {
-							log.Printf("✅ Filter: Predicate matched for item: %v", item)
-							predicateMatched = true
-							break
-						} else {
-							log.Printf("❌ Filter: Predicate did not match for item: %v", item)
-						}
-					}
-				}
-			}
-
-			// If no complete predicate found, try to reconstruct from split args
-			// Look for args with keys like "track.name" and values starting with "=" or "!="
-			// Also handle cases where >= or <= are split: key="track.index>" value=0 means "track.index >= 0"
-			if !predicateMatched {
-				for key, value := range args {
-					// Skip the collection argument (empty key)
-					if key == "" {
-						continue
-					}
-
-					// Check if key ends with > or < (means >= or <= was split by parser)
-					var operator string
-					var propertyKey string
-					if strings.HasSuffix(key, ">") {
-						// This is >= split: "track.index>" with value 0 means "track.index >= 0"
-						propertyKey = strings.TrimSuffix(key, ">")
-						operator = ">="
-					} else if strings.HasSuffix(key, "<") {
-						// This is <= split: "track.index<" with value 0 means "track.index <= 0"
-						propertyKey = strings.TrimSuffix(key, "<")
-						operator = "<="
-					} else if value.Kind == gs.ValueString {
-						valueStr := strings.TrimSpace(value.Str)
-						// Check if value starts with comparison operator (e.g., "=\"value\"" or "==\"value\"")
-						if strings.HasPrefix(valueStr, "=") || strings.HasPrefix(valueStr, "!=") {
-							propertyKey = key
-							// Reconstruct predicate: key + value
-							// key is like "track.name", value is like "=\"Nebula Drift\"" or "=true"
-							operator = "=="
-							if strings.HasPrefix(valueStr, "!=") {
-								operator = "!="
-								valueStr = strings.TrimPrefix(valueStr, "!=")
-							} else {
-								valueStr = strings.TrimPrefix(valueStr, "=")
-							}
-
-							// Check if value is a boolean (true/false) - don't wrap in quotes
-							valueStr = strings.TrimSpace(valueStr)
-							isBoolean := valueStr == "true" || valueStr == "false"
-
-							// Remove quotes if present (for string values)
-							if !isBoolean {
-								valueStr = strings.Trim(valueStr, "\"")
-							}
-
-							// Reconstruct predicate
-							var reconstructedPred string
-							if isBoolean {
-								// For booleans: "track.muted == true" (no quotes)
-								reconstructedPred = fmt.Sprintf("%s %s %s", propertyKey, operator, valueStr)
-							} else {
-								// For strings: "track.name == \"Nebula Drift\"" (with quotes)
-								reconstructedPred = fmt.Sprintf("%s %s \"%s\"", propertyKey, operator, valueStr)
-							}
-							log.Printf("🔍 Filter: Reconstructed predicate from split args: '%s'", reconstructedPred)
-
-							// Parse and evaluate
-							if matched := p.parseAndEvaluatePredicate(reconstructedPred, item, iterVar); matched {
-								log.Printf("✅ Filter: Reconstructed predicate matched for item: %v", item)
-								predicateMatched = true
-								break
-							} else {
-								// This is expected - predicate didn't match this item, continue checking
-								log.Printf("🔍 Filter: Predicate did not match for item (this is normal): %v", item)
-							}
-							continue
-						}
-					}
-
-					// Handle >= and <= cases where key ends with > or < and value is a number
-					if operator != "" && propertyKey != "" {
-						var valueStr string
-						if value.Kind == gs.ValueNumber {
-							valueStr = fmt.Sprintf("%.0f", value.Num)
-						} else if value.Kind == gs.ValueString {
-							valueStr = strings.TrimSpace(value.Str)
-						} else {
-							continue
-						}
-
-						reconstructedPred := fmt.Sprintf("%s %s %s", propertyKey, operator, valueStr)
-						log.Printf("🔍 Filter: Reconstructed predicate from split >=/<= args: '%s' (key='%s', operator='%s', value='%s')", reconstructedPred, key, operator, valueStr)
-
-						// Parse and evaluate
-						if matched := p.parseAndEvaluatePredicate(reconstructedPred, item, iterVar); matched {
-							log.Printf("✅ Filter: Reconstructed predicate matched for item: %v", item)
-							predicateMatched = true
-							break
-						} else {
-							// This is expected - predicate didn't match this item, continue checking
-							log.Printf("🔍 Filter: Predicate did not match for item (this is normal): %v", item)
-						}
-					}
-				}
-			}
-
-			// Note: predicateMatched being false here is expected for items that don't match the predicate
-			// We only log a warning if we couldn't even attempt to parse the predicate
-			// (which would mean we didn't find any predicate-like args at all)
+		matched, err := fp.pred.match(predicateVars(itemMap, iterVar))
+		if err != nil && evalErr == nil {
+			evalErr = err
 		}
-
-		if predicateMatched {
+		if matched {
 			filtered = append(filtered, item)
 		}
 
 		p.clearIterationContext()
 	}
+	if evalErr != nil {
+		logger.Printf(r.parser.ctx, "⚠️  Filter: '%s' could not be evaluated for some items: %v", fp.src, evalErr)
+	}
 
 	// Store filtered result - return the filtered collection name for chaining
 	resultName := collectionName + "_filtered"
 	p.data[resultName] = filtered
 
-	// Also store as "current_filtered" for potential chaining
-	p.data["current_filtered"] = filtered
-	log.Printf("🔍 Filter: Stored filtered collection in current_filtered with %d items", len(filtered))
+	// Hand the result to the method chained after filter()
+	p.selectForChain(filtered, collectionName)
+	logger.Printf(r.parser.ctx, "🔍 Filter: Selected %d items for the chained method", len(filtered))
 
 	// Set the current collection context so chained methods can operate on filtered results
 	p.currentTrackIndex = -1 // Reset, will be set per item in map/for_each
 
-	log.Printf("✅ Filtered %d items from '%s' to %d matches", len(collection), collectionName, len(filtered))
+	logger.Printf(r.parser.ctx, "✅ Filtered %d items from '%s' to %d matches", len(collection), collectionName, len(filtered))
 	if len(filtered) == 0 {
-		log.Printf("⚠️  WARNING: Filter returned 0 results! Args received: %v", getArgsKeys(args))
+		logger.Printf(r.parser.ctx, "⚠️  WARNING: Filter returned 0 results! Args received: %v", getArgsKeys(args))
 		// Log first item to debug
 		if len(collection) > 0 {
-			log.Printf("   First item in collection: %+v", collection[0])
+			logger.Printf(r.parser.ctx, "   First item in collection: %+v", collection[0])
 		}
 	}
 	return nil
@@ -1559,7 +1333,7 @@ func (r *ReaperDSL) Map(args gs.Args) error {
 
 		resultName := collectionName + "_mapped"
 		p.data[resultName] = mapped
-		log.Printf("Mapped %d items", len(collection))
+		logger.Printf(r.parser.ctx, "Mapped %d items", len(collection))
 		return nil
 	}
 
@@ -1570,6 +1344,7 @@ func (r *ReaperDSL) Map(args gs.Args) error {
 // Grammar: for_each(collection, @function) or for_each(collection, item.method())
 func (r *ReaperDSL) ForEach(args gs.Args) error {
 	p := r.parser
+	p.onMaster = false
 
 	// Get collection - similar to Filter and Map
 	var collection []any
@@ -1619,15 +1394,15 @@ func (r *ReaperDSL) ForEach(args gs.Args) error {
 	var funcRef string
 
 	// Log all arguments for debugging
-	log.Printf("🔄 ForEach: Received args: %v", getArgsKeys(args))
+	logger.Printf(r.parser.ctx, "🔄 ForEach: Received args: %v", getArgsKeys(args))
 	for key, value := range args {
-		log.Printf("  ForEach arg[%s]: Kind=%s, Str=%s", key, value.Kind, value.Str)
+		logger.Printf(r.parser.ctx, "  ForEach arg[%s]: Kind=%s, Str=%s", key, value.Kind, value.Str)
 	}
 
 	// Check for function reference (@func_name)
 	if funcValue, ok := args["func"]; ok && funcValue.Kind == gs.ValueFunction {
 		funcRef = funcValue.Str
-		log.Printf("🔄 ForEach: Found function reference: @%s", funcRef)
+		logger.Printf(r.parser.ctx, "🔄 ForEach: Found function reference: @%s", funcRef)
 		// TODO: Implement function registry and execution
 		// For now, function references are not yet supported
 		return fmt.Errorf("function references (@%s) are not yet implemented in for_each", funcRef)
@@ -1646,12 +1421,12 @@ func (r *ReaperDSL) ForEach(args gs.Args) error {
 				// This is a split method call - the key is the method part, value is the parameter value
 				methodCallParts = append(methodCallParts, key)
 				methodCallValue = value.Str
-				log.Printf("🔄 ForEach: Found split method call - key='%s', value='%s'", key, methodCallValue)
+				logger.Printf(r.parser.ctx, "🔄 ForEach: Found split method call - key='%s', value='%s'", key, methodCallValue)
 			} else if key != "" && key != "collection" && key != "func" {
 				// Check if it's a complete method call string
 				if strings.Contains(value.Str, ".") && strings.Contains(value.Str, "(") && strings.Contains(value.Str, ")") {
 					methodCallStr = value.Str
-					log.Printf("🔄 ForEach: Found complete method call in arg[%s]: %s", key, methodCallStr)
+					logger.Printf(r.parser.ctx, "🔄 ForEach: Found complete method call in arg[%s]: %s", key, methodCallStr)
 					break
 				}
 			}
@@ -1665,7 +1440,7 @@ func (r *ReaperDSL) ForEach(args gs.Args) error {
 		// Reconstruct the full method call
 		// The key is like "track.add_fx(fxname" and value is like "\"ReaEQ\""
 		methodCallStr = methodCallKey + "=" + methodCallValue + ")"
-		log.Printf("🔄 ForEach: Reconstructed method call: %s", methodCallStr)
+		logger.Printf(r.parser.ctx, "🔄 ForEach: Reconstructed method call: %s", methodCallStr)
 	}
 
 	// Try positional argument as fallback
@@ -1674,24 +1449,24 @@ func (r *ReaperDSL) ForEach(args gs.Args) error {
 			// Check if it looks like a method call (contains "." and "(")
 			if strings.Contains(value.Str, ".") && strings.Contains(value.Str, "(") {
 				methodCallStr = value.Str
-				log.Printf("🔄 ForEach: Found method call in positional arg: %s", methodCallStr)
+				logger.Printf(r.parser.ctx, "🔄 ForEach: Found method call in positional arg: %s", methodCallStr)
 			}
 		}
 	}
 
-	log.Printf("🔄 ForEach: Iterating over %d items in collection '%s'", len(collection), collectionName)
-	log.Printf("🔄 ForEach: methodCallStr='%s', collectionName='%s'", methodCallStr, collectionName)
+	logger.Printf(r.parser.ctx, "🔄 ForEach: Iterating over %d items in collection '%s'", len(collection), collectionName)
+	logger.Printf(r.parser.ctx, "🔄 ForEach: methodCallStr='%s', collectionName='%s'", methodCallStr, collectionName)
 
 	// If we have a method call, parse and execute it for each item
 	if methodCallStr != "" {
 		// Parse method call: track.add_fx(fxname="ReaEQ")
 		// Extract method name and parameters
-		methodName, methodArgs, err := p.parseMethodCallString(methodCallStr)
+		methodName, _, err := p.parseMethodCallString(methodCallStr, nil)
 		if err != nil {
 			return fmt.Errorf("failed to parse method call '%s': %w", methodCallStr, err)
 		}
 
-		log.Printf("  ForEach: Executing method '%s' on each item", methodName)
+		logger.Printf(r.parser.ctx, "  ForEach: Executing method '%s' on each item", methodName)
 
 		// Execute method for each item
 		for i, item := range collection {
@@ -1700,30 +1475,45 @@ func (r *ReaperDSL) ForEach(args gs.Args) error {
 				iterVar: item,
 			})
 
-			// If item is a track, set currentTrackIndex for method execution
-			if trackMap, ok := item.(map[string]any); ok {
-				if index, ok := trackMap["index"].(int); ok {
-					p.currentTrackIndex = index
-				} else if indexFloat, ok := trackMap["index"].(float64); ok {
-					p.currentTrackIndex = int(indexFloat)
+			// Set currentTrackIndex for method execution: a track's index, or a clip's track
+			itemMap, _ := item.(map[string]any)
+			indexKey := "index"
+			if iterVar == "clip" {
+				indexKey = "track"
+			}
+			if index, ok := actionInt(itemMap, indexKey); ok {
+				p.currentTrackIndex = index
+			}
+
+			// Arguments may refer to the item, e.g. name=track.name + " (old)"
+			_, methodArgs, err := p.parseMethodCallString(methodCallStr, predicateVars(itemMap, iterVar))
+			if err != nil {
+				logger.Printf(r.parser.ctx, "  ⚠️  ForEach[%d]: %v", i, err)
+				p.clearIterationContext()
+				continue
+			}
+			// Clip methods target the iterated clip unless the call picks one
+			if clipIndex, ok := actionInt(itemMap, "index"); ok && iterVar == "clip" {
+				if _, hasClip := methodArgs["clip"]; !hasClip {
+					methodArgs["clip"] = gs.Value{Kind: gs.ValueNumber, Num: float64(clipIndex)}
 				}
 			}
 
 			// Execute the method
 			if err := p.executeMethodOnItem(methodName, methodArgs); err != nil {
-				log.Printf("  ⚠️  ForEach[%d]: Error executing method '%s': %v", i, methodName, err)
+				logger.Printf(r.parser.ctx, "  ⚠️  ForEach[%d]: Error executing method '%s': %v", i, methodName, err)
 				// Continue with next item instead of failing completely
 			}
 
 			p.clearIterationContext()
 		}
 
-		log.Printf("✅ ForEach: Processed %d items from '%s' with method '%s'", len(collection), collectionName, methodName)
+		logger.Printf(r.parser.ctx, "✅ ForEach: Processed %d items from '%s' with method '%s'", len(collection), collectionName, methodName)
 		return nil
 	}
 
 	// If no function or method specified, just iterate and set context (for chaining)
-	log.Printf("⚠️  ForEach: No function or method specified, only setting iteration context")
+	logger.Printf(r.parser.ctx, "⚠️  ForEach: No function or method specified, only setting iteration context")
 	for i, item := range collection {
 		p.setIterationContext(map[string]any{
 			iterVar: item,
@@ -1732,22 +1522,22 @@ func (r *ReaperDSL) ForEach(args gs.Args) error {
 		if trackMap, ok := item.(map[string]any); ok {
 			if index, ok := trackMap["index"].(int); ok {
 				p.currentTrackIndex = index
-			} else if indexFloat, ok := trackMap["index"].(float64); ok {
-				p.currentTrackIndex = int(indexFloat)
 			}
 		}
 
-		log.Printf("  ForEach[%d]: Processing item (index=%d)", i, p.currentTrackIndex)
+		logger.Printf(r.parser.ctx, "  ForEach[%d]: Processing item (index=%d)", i, p.currentTrackIndex)
 		p.clearIterationContext()
 	}
 
-	log.Printf("✅ ForEach: Processed %d items from '%s'", len(collection), collectionName)
+	logger.Printf(r.parser.ctx, "✅ ForEach: Processed %d items from '%s'", len(collection), collectionName)
 	return nil
 }
 
 // parseMethodCallString parses a method call string like "track.add_fx(fxname=\"ReaEQ\")"
-// Returns the method name (e.g., "add_fx") and parsed arguments
-func (p *FunctionalDSLParser) parseMethodCallString(methodCallStr string) (string, gs.Args, error) {
+// Returns the method name (e.g., "add_fx") and parsed arguments. Unquoted arguments that
+// refer to the iteration item (track.name + " (old)") are evaluated against vars; with nil
+// vars they are kept as written.
+func (p *FunctionalDSLParser) parseMethodCallString(methodCallStr string, vars exprVars) (string, gs.Args, error) {
 	methodCallStr = strings.TrimSpace(methodCallStr)
 
 	// Find the dot that separates object from method
@@ -1770,11 +1560,16 @@ func (p *FunctionalDSLParser) parseMethodCallString(methodCallStr string) (strin
 
 	// Extract parameters string
 	paramsStr := methodPart[parenIndex+1:]
-	// Find matching closing parenthesis
+	// Find matching closing parenthesis, skipping any inside quoted strings
 	depth := 1
 	closeIndex := -1
+	inString := false
 	for i, char := range paramsStr {
-		if char == '(' {
+		if char == '"' {
+			inString = !inString
+		} else if inString {
+			continue
+		} else if char == '(' {
 			depth++
 		} else if char == ')' {
 			depth--
@@ -1796,7 +1591,7 @@ func (p *FunctionalDSLParser) parseMethodCallString(methodCallStr string) (strin
 	args := make(gs.Args)
 	if paramsStr != "" {
 		// Simple parameter parsing: key="value" or key=value
-		parts := strings.Split(paramsStr, ",")
+		parts := splitTopLevel(paramsStr, ",")
 		for _, part := range parts {
 			part = strings.TrimSpace(part)
 			if part == "" {
@@ -1814,7 +1609,7 @@ func (p *FunctionalDSLParser) parseMethodCallString(methodCallStr string) (strin
 
 			// Parse value
 			var value gs.Value
-			if strings.HasPrefix(valueStr, "\"") && strings.HasSuffix(valueStr, "\"") {
+			if isStringLiteral(valueStr) {
 				// String value
 				value = gs.Value{
 					Kind: gs.ValueString,
@@ -1826,6 +1621,12 @@ func (p *FunctionalDSLParser) parseMethodCallString(methodCallStr string) (strin
 				value = gs.Value{Kind: gs.ValueBool, Bool: false}
 			} else if num, err := strconv.ParseFloat(valueStr, 64); err == nil {
 				value = gs.Value{Kind: gs.ValueNumber, Num: num}
+			} else if vars != nil && isIterationValue(valueStr) {
+				bound, err := bindIterationValue(valueStr, vars)
+				if err != nil {
+					return "", nil, fmt.Errorf("argument %s: %w", key, err)
+				}
+				value = bound
 			} else {
 				value = gs.Value{Kind: gs.ValueString, Str: valueStr}
 			}
@@ -1856,71 +1657,73 @@ func (p *FunctionalDSLParser) executeMethodOnItem(methodName string, methodArgs
 		return p.reaperDSL.Delete(methodArgs)
 	case "DeleteClip":
 		return p.reaperDSL.DeleteClip(methodArgs)
+	case "SplitClip":
+		return p.reaperDSL.SplitClip(methodArgs)
+	case "TrimClip":
+		return p.reaperDSL.TrimClip(methodArgs)
+	case "SetClipLoop":
+		return p.reaperDSL.SetClipLoop(methodArgs)
+	case "TransposeClip":
+		return p.reaperDSL.TransposeClip(methodArgs)
+	case "ReverseClip":
+		return p.reaperDSL.ReverseClip(methodArgs)
+	case "LegatoClip":
+		return p.reaperDSL.LegatoClip(methodArgs)
+	case "RemoveNotes":
+		return p.reaperDSL.RemoveNotes(methodArgs)
+	case "Duplicate":
+		return p.reaperDSL.Duplicate(methodArgs)
+	case "DuplicateClip":
+		return p.reaperDSL.DuplicateClip(methodArgs)
 	case "SetClip":
 		return p.reaperDSL.SetClip(methodArgs)
 	case "MoveClip", "SetClipPosition":
 		return p.reaperDSL.MoveClip(methodArgs)
 	case "AddAutomation":
 		return p.reaperDSL.AddAutomation(methodArgs)
+	case "SetFxParam":
+		return p.reaperDSL.SetFxParam(methodArgs)
+	case "BypassFx":
+		return p.reaperDSL.BypassFx(methodArgs)
+	case "EnableFx":
+		return p.reaperDSL.EnableFx(methodArgs)
+	case "RemoveFx":
+		return p.reaperDSL.RemoveFx(methodArgs)
+	case "MoveFx":
+		return p.reaperDSL.MoveFx(methodArgs)
+	case "MakeFolder":
+		return p.reaperDSL.MakeFolder(methodArgs)
+	case "AddToFolder":
+		return p.reaperDSL.AddToFolder(methodArgs)
+	case "SetTrackParent":
+		return p.reaperDSL.SetTrackParent(methodArgs)
+	case "AddSend":
+		return p.reaperDSL.AddSend(methodArgs)
+	case "SetSend":
+		return p.reaperDSL.SetSend(methodArgs)
+	case "SetTrackColorsBulk":
+		return p.reaperDSL.SetTrackColorsBulk(methodArgs)
+	case "AdjustColor":
+		return p.reaperDSL.AdjustColor(methodArgs)
+	case "RemoveSend":
+		return p.reaperDSL.RemoveSend(methodArgs)
+	case "ClearAutomation":
+		return p.reaperDSL.ClearAutomation(methodArgs)
+	case "DeleteEnvelope":
+		return p.reaperDSL.DeleteEnvelope(methodArgs)
+	case "ScaleAutomation":
+		return p.reaperDSL.ScaleAutomation(methodArgs)
+	case "FreezeTrack":
+		return p.reaperDSL.FreezeTrack(methodArgs)
+	case "UnfreezeTrack":
+		return p.reaperDSL.UnfreezeTrack(methodArgs)
+	case "BounceInPlace":
+		return p.reaperDSL.BounceInPlace(methodArgs)
 	default:
 		return fmt.Errorf("unknown method: %s (converted from %s)", methodNameCamel, methodName)
 	}
 }
 
-// colorNameToHex converts common color names to hex values
-func colorNameToHex(colorName string) string {
-	colorMap := map[string]string{
-		"red":        "#ff0000",
-		"green":      "#00ff00",
-		"blue":       "#0000ff",
-		"yellow":     "#ffff00",
-		"orange":     "#ffa500",
-		"purple":     "#800080",
-		"pink":       "#ffc0cb",
-		"cyan":       "#00ffff",
-		"magenta":    "#ff00ff",
-		"lime":       "#00ff00",
-		"maroon":     "#800000",
-		"navy":       "#000080",
-		"olive":      "#808000",
-		"teal":       "#008080",
-		"aqua":       "#00ffff",
-		"silver":     "#c0c0c0",
-		"gray":       "#808080",
-		"grey":       "#808080",
-		"black":      "#000000",
-		"white":      "#ffffff",
-		"brown":      "#a52a2a",
-		"violet":     "#ee82ee",
-		"indigo":     "#4b0082",
-		"gold":       "#ffd700",
-		"coral":      "#ff7f50",
-		"salmon":     "#fa8072",
-		"khaki":      "#f0e68c",
-		"tan":        "#d2b48c",
-		"beige":      "#f5f5dc",
-		"ivory":      "#fffff0",
-		"lavender":   "#e6e6fa",
-		"plum":       "#dda0dd",
-		"turquoise":  "#40e0d0",
-		"crimson":    "#dc143c",
-		"darkred":    "#8b0000",
-		"darkgreen":  "#006400",
-		"darkblue":   "#00008b",
-		"lightblue":  "#add8e6",
-		"lightgreen": "#90ee90",
-		"lightgray":  "#d3d3d3",
-		"lightgrey":  "#d3d3d3",
-		"darkgray":   "#a9a9a9",
-		"darkgrey":   "#a9a9a9",
-	}
-
-	if hex, ok := colorMap[colorName]; ok {
-		return hex
-	}
-	return ""
-}
-
 // capitalizeMethodName converts snake_case or camelCase to PascalCase
 // Examples: track -> Track, set_track -> SetTrack, addAutomation -> AddAutomation
 func capitalizeMethodName(name string) string {
@@ -1968,7 +1771,7 @@ func (r *ReaperDSL) Store(args gs.Args) error {
 			value = nil
 		}
 		p.data[nameValue.Str] = value
-		log.Printf("Stored %s = %v", nameValue.Str, value)
+		logger.Printf(r.parser.ctx, "Stored %s = %v", nameValue.Str, value)
 		return nil
 	}
 
@@ -2073,245 +1876,6 @@ func getDataKeys(data map[string]any) []string {
 	return keys
 }
 
-// parseAndEvaluatePredicate parses a predicate string like "track.name == \"value\"" and evaluates it
-func (p *FunctionalDSLParser) parseAndEvaluatePredicate(predStr string, item any, iterVar string) bool {
-	// Remove quotes and whitespace
-	predStr = strings.TrimSpace(predStr)
-	log.Printf("🔍 parseAndEvaluatePredicate: parsing '%s' with iterVar='%s'", predStr, iterVar)
-
-	// Try to match patterns like:
-	// - track.name == "value"
-	// - track.name=="value"
-	// - track.name != "value"
-
-	// Find the operator (check longer operators first to avoid partial matches)
-	var op string
-	var opIndex int
-	if idx := strings.Index(predStr, "<="); idx != -1 {
-		op = "<="
-		opIndex = idx
-	} else if idx := strings.Index(predStr, ">="); idx != -1 {
-		op = ">="
-		opIndex = idx
-	} else if idx := strings.Index(predStr, "=="); idx != -1 {
-		op = "=="
-		opIndex = idx
-	} else if idx := strings.Index(predStr, "!="); idx != -1 {
-		op = "!="
-		opIndex = idx
-	} else if idx := strings.Index(predStr, " in "); idx != -1 {
-		op = "in"
-		opIndex = idx
-	} else if idx := strings.Index(predStr, "<"); idx != -1 {
-		op = "<"
-		opIndex = idx
-	} else if idx := strings.Index(predStr, ">"); idx != -1 {
-		op = ">"
-		opIndex = idx
-	} else {
-		log.Printf("⚠️  parseAndEvaluatePredicate: No operator found in '%s'", predStr)
-		return false
-	}
-	log.Printf("🔍 parseAndEvaluatePredicate: Found operator '%s' at index %d", op, opIndex)
-
-	// Split into left (property) and right (value)
-	left := strings.TrimSpace(predStr[:opIndex])
-	right := strings.TrimSpace(predStr[opIndex+len(op):])
-
-	// For "in" operator, remove the extra spaces around it
-	if op == "in" {
-		right = strings.TrimSpace(right)
-	}
-
-	// Extract property name from "track.name" or "iterVar.name"
-	// The left side should be like "track.name" where "track" is the iterVar
-	propParts := strings.Split(left, ".")
-	if len(propParts) != 2 {
-		return false
-	}
-
-	// Verify the first part matches the iteration variable (or is a common variable name)
-	// For "track.name", we expect iterVar to be "track"
-	// For "clip.length", we expect iterVar to be "clip"
-	// Allow common variable names: track, clip, fx
-	if propParts[0] != iterVar && propParts[0] != "track" && propParts[0] != "clip" && propParts[0] != "fx" {
-		return false
-	}
-
-	propName := propParts[1]
-
-	// Check if right side is a boolean (true/false without quotes)
-	rightTrimmed := strings.TrimSpace(right)
-	isBooleanValue := rightTrimmed == "true" || rightTrimmed == "false"
-
-	// Remove quotes from right side if present (for string values)
-	if !isBooleanValue {
-		right = strings.Trim(right, "\"")
-	}
-
-	// Get the property value from the item
-	itemMap, ok := item.(map[string]any)
-	if !ok {
-		return false
-	}
-
-	itemValue, ok := itemMap[propName]
-	if !ok {
-		return false
-	}
-
-	// Handle boolean comparisons specially
-	if isBooleanValue {
-		expectedBool := rightTrimmed == "true"
-		if itemBool, ok := itemValue.(bool); ok {
-			if op == "==" {
-				return itemBool == expectedBool
-			} else if op == "!=" {
-				return itemBool != expectedBool
-			}
-		}
-		// If item value is not a bool, convert and compare as string
-		itemValueStr := fmt.Sprintf("%t", itemValue)
-		if op == "==" {
-			return itemValueStr == rightTrimmed
-		} else if op == "!=" {
-			return itemValueStr != rightTrimmed
-		}
-		return false
-	}
-
-	// Handle "in" operator: property in [value1, value2, ...]
-	if op == "in" {
-		// Parse the right side as an array: [value1, value2, ...]
-		rightTrimmed := strings.TrimSpace(right)
-		if !strings.HasPrefix(rightTrimmed, "[") || !strings.HasSuffix(rightTrimmed, "]") {
-			return false
-		}
-
-		// Extract array contents
-		arrayContents := strings.TrimSpace(rightTrimmed[1 : len(rightTrimmed)-1])
-		if arrayContents == "" {
-			return false // Empty array
-		}
-
-		// Split by comma (simple parsing, doesn't handle nested arrays or quoted commas)
-		values := strings.Split(arrayContents, ",")
-		collectionValues := make([]any, 0, len(values))
-		for _, valStr := range values {
-			valStr = strings.TrimSpace(valStr)
-			valStr = strings.Trim(valStr, "\"") // Remove quotes
-
-			// Try to parse as number first
-			if num, err := strconv.ParseFloat(valStr, 64); err == nil {
-				collectionValues = append(collectionValues, num)
-			} else if valStr == "true" {
-				collectionValues = append(collectionValues, true)
-			} else if valStr == "false" {
-				collectionValues = append(collectionValues, false)
-			} else {
-				// Treat as string
-				collectionValues = append(collectionValues, valStr)
-			}
-		}
-
-		// Check if itemValue is in the collection
-		for _, collVal := range collectionValues {
-			if compareValuesForIn(itemValue, collVal) {
-				return true
-			}
-		}
-		return false
-	}
-
-	// For numeric comparisons (<, >, <=, >=), we need to compare as numbers
-	// For string comparisons (==, !=), we compare as strings
-	if op == "<" || op == ">" || op == "<=" || op == ">=" {
-		// Numeric comparison
-		var itemNum, rightNum float64
-		var itemOk, rightOk bool
-
-		// Convert item value to number
-		switch v := itemValue.(type) {
-		case float64:
-			itemNum = v
-			itemOk = true
-		case float32:
-			itemNum = float64(v)
-			itemOk = true
-		case int:
-			itemNum = float64(v)
-			itemOk = true
-		case int64:
-			itemNum = float64(v)
-			itemOk = true
-		case int32:
-			itemNum = float64(v)
-			itemOk = true
-		default:
-			// Try to convert via string parsing as fallback
-			if strVal := fmt.Sprintf("%v", itemValue); strVal != "" {
-				if parsed, err := strconv.ParseFloat(strVal, 64); err == nil {
-					itemNum = parsed
-					itemOk = true
-				}
-			}
-		}
-
-		log.Printf("🔍 parseAndEvaluatePredicate: itemValue type=%T, value=%v, converted to num=%v (ok=%v)", itemValue, itemValue, itemNum, itemOk)
-
-		// Parse right side as number
-		rightTrimmed := strings.TrimSpace(right)
-		rightTrimmed = strings.Trim(rightTrimmed, "\"") // Remove quotes if present
-		if parsed, err := strconv.ParseFloat(rightTrimmed, 64); err == nil {
-			rightNum = parsed
-			rightOk = true
-		} else {
-			log.Printf("⚠️  parseAndEvaluatePredicate: Failed to parse right side '%s' as number: %v", rightTrimmed, err)
-		}
-
-		log.Printf("🔍 parseAndEvaluatePredicate: right='%s', parsed to num=%v (ok=%v), comparison: %v %s %v", rightTrimmed, rightNum, rightOk, itemNum, op, rightNum)
-
-		if itemOk && rightOk {
-			var result bool
-			switch op {
-			case "<":
-				result = itemNum < rightNum
-			case ">":
-				result = itemNum > rightNum
-			case "<=":
-				result = itemNum <= rightNum
-			case ">=":
-				result = itemNum >= rightNum
-			}
-			log.Printf("✅ parseAndEvaluatePredicate: Comparison result: %v %s %v = %v", itemNum, op, rightNum, result)
-			return result
-		}
-		log.Printf("⚠️  parseAndEvaluatePredicate: Cannot compare - itemOk=%v, rightOk=%v", itemOk, rightOk)
-		return false
-	}
-
-	// String comparison (==, !=)
-	var itemValueStr string
-	switch v := itemValue.(type) {
-	case string:
-		itemValueStr = v
-	case float64:
-		itemValueStr = fmt.Sprintf("%g", v)
-	case bool:
-		itemValueStr = fmt.Sprintf("%t", v)
-	default:
-		itemValueStr = fmt.Sprintf("%v", v)
-	}
-
-	// Evaluate comparison
-	if op == "==" {
-		return itemValueStr == right
-	} else if op == "!=" {
-		return itemValueStr != right
-	}
-	return false
-}
-
 // compareValuesForIn compares two values for equality in the context of "in" operator, handling different types
 func compareValuesForIn(a, b any) bool {
 	// Handle numeric comparison
@@ -2350,81 +1914,6 @@ func getNumericValue(v any) (float64, bool) {
 	}
 }
 
-// evaluateSimplePredicate evaluates a simple property-based predicate.
-func evaluateSimplePredicate(item any, propName, operator string, compareValue gs.Value) bool {
-	itemMap, ok := item.(map[string]any)
-	if !ok {
-		return false
-	}
-
-	itemValue, ok := itemMap[propName]
-	if !ok {
-		return false
-	}
-
-	switch operator {
-	case "==":
-		return compareValues(itemValue, compareValue) == 0
-	case "!=":
-		return compareValues(itemValue, compareValue) != 0
-	case "<":
-		return compareValues(itemValue, compareValue) < 0
-	case ">":
-		return compareValues(itemValue, compareValue) > 0
-	case "<=":
-		return compareValues(itemValue, compareValue) <= 0
-	case ">=":
-		return compareValues(itemValue, compareValue) >= 0
-	default:
-		return false
-	}
-}
-
-// compareValues compares two values and returns -1, 0, or 1.
-func compareValues(a any, b gs.Value) int {
-	switch b.Kind {
-	case gs.ValueString:
-		aStr, ok := a.(string)
-		if !ok {
-			return -1
-		}
-		if aStr < b.Str {
-			return -1
-		} else if aStr > b.Str {
-			return 1
-		}
-		return 0
-	case gs.ValueNumber:
-		aNum, ok := a.(float64)
-		if !ok {
-			if aInt, ok := a.(int); ok {
-				aNum = float64(aInt)
-			} else {
-				return -1
-			}
-		}
-		if aNum < b.Num {
-			return -1
-		} else if aNum > b.Num {
-			return 1
-		}
-		return 0
-	case gs.ValueBool:
-		aBool, ok := a.(bool)
-		if !ok {
-			return -1
-		}
-		if aBool == b.Bool {
-			return 0
-		} else if !aBool && b.Bool {
-			return -1
-		}
-		return 1
-	default:
-		return -1
-	}
-}
-
 // GetMagdaDSLGrammarForFunctional returns the grammar with functional methods added.
 // This is the grammar used for CFG generation to allow the LLM to generate functional DSL code.
 func GetMagdaDSLGrammarForFunctional() string {
@@ -2434,10 +1923,13 @@ func GetMagdaDSLGrammarForFunctional() string {
 // Syntax: track().new_clip() with method chaining
 // NOTE: add_midi is NOT available - the arranger agent handles MIDI note generation
 
-start: statement (";"? statement)*
+start: (statement | project_call | query_call) (";"? statement | ";" (project_call | query_call))*
+     | clarify_call
 
 statement: track_call chain*
+         | master_call master_chain*
          | functional_call
+         | template_call
 
 track_call: "track" "(" track_params? ")"
 track_params: track_param ("," SP track_param)*
@@ -2446,9 +1938,14 @@ track_param: "instrument" "=" STRING
            | "name" "=" STRING
            | "index" "=" NUMBER
            | "id" "=" NUMBER
+           | "guid" "=" STRING
            | "selected" "=" BOOLEAN
 
-chain: clip_chain | fx_chain | track_properties_chain | delete_chain | delete_clip_chain | clip_properties_chain | clip_move_chain | automation_chain
+// The master track: volume and pan, FX and automation only
+master_call: "master" "(" ")"
+master_chain: track_properties_chain | fx_chain | automation_chain | automation_edit_chain
+
+chain: clip_chain | fx_chain | track_properties_chain | delete_chain | delete_clip_chain | nth_clip_chain | clip_properties_chain | clip_move_chain | automation_chain | send_chain | fx_param_chain | fx_chain_op | folder_chain | duplicate_chain | clip_edit_chain | query_chain | color_chain | freeze_chain | automation_edit_chain | template_chain | clip_notes_chain
 
 clip_chain: ".new_clip" "(" clip_params? ")"
 clip_params: clip_param ("," SP clip_param)*
@@ -2457,20 +1954,63 @@ clip_param: "bar" "=" NUMBER
           | "length_bars" "=" NUMBER
           | "length" "=" NUMBER
           | "position" "=" NUMBER
+          | clip_anchor_param
+
+// Relative positions, resolved from the state's clips and tempo: after="last_clip" (end of the
+// track's last clip) or after="project_end", offset (may be negative) in bars or the length unit
+clip_anchor_param: "after" "=" STRING
+                 | "offset_bars" "=" NUMBER
+                 | "offset" "=" NUMBER
 
 fx_chain: ".add_fx" "(" fx_params? ")"
 fx_params: "fxname" "=" STRING
          | "instrument" "=" STRING
 
+// FX parameters: value is normalized 0.0-1.0, fx matches the plugin name
+fx_param_chain: ".set_fx_param" "(" fx_param_params ")"
+fx_param_params: fx_param_param ("," SP fx_param_param)*
+fx_param_param: "fx" "=" STRING
+              | "param" "=" STRING
+              | "value" "=" NUMBER
+
+// FX chain operations: fx is a plugin name or 1-based position in the chain
+fx_chain_op: ".bypass_fx" "(" fx_ref_param? ")"
+           | ".enable_fx" "(" fx_ref_param? ")"
+           | ".remove_fx" "(" fx_ref_param? ")"
+           | ".move_fx" "(" move_fx_params ")"
+fx_ref_param: "fx" "=" (STRING | NUMBER)
+move_fx_params: move_fx_param ("," SP move_fx_param)*
+move_fx_param: "fx" "=" (STRING | NUMBER)
+             | "to" "=" NUMBER
+             | "before" "=" STRING
+             | "after" "=" STRING
+
 // Unified track properties method
 track_properties_chain: ".set_track" "(" track_properties_params? ")"
 track_properties_params: track_property_param ("," SP track_property_param)*
 track_property_param: "name" "=" STRING
-                    | "volume_db" "=" NUMBER
-                    | "pan" "=" NUMBER
+                    | "volume_db" "=" (NUMBER | expr)
+                    | "pan" "=" (NUMBER | expr)
                     | "mute" "=" BOOLEAN
                     | "solo" "=" BOOLEAN
                     | "selected" "=" BOOLEAN
+                    | "color" "=" (STRING | NUMBER)
+
+// Track colors: distinct colors from a palette, or the current colors lightened/darkened (0 to 1)
+color_chain: ".set_track_colors_bulk" "(" color_palette_param? ")"
+           | ".adjust_color" "(" adjust_color_params ")"
+color_palette_param: "palette" "=" STRING
+                   | "genre" "=" STRING
+adjust_color_params: adjust_color_param ("," SP adjust_color_param)*
+adjust_color_param: "lighten" "=" NUMBER
+                  | "darken" "=" NUMBER
+                  | "color" "=" (STRING | NUMBER)
+
+// Folders: group the current (or filtered) tracks; track ids are 1-based, parent=0 leaves all folders
+folder_chain: ".make_folder" "(" "name" "=" STRING ")"
+            | ".add_to_folder" "(" "folder" "=" (STRING | NUMBER) ")"
+            | ".set_track_parent" "(" "parent" "=" NUMBER ")"
+            | ".set_track_parent" "(" "parent_name" "=" STRING ")"
 
 // Deletion operations
 delete_chain: ".delete" "(" ")"
@@ -2480,13 +2020,62 @@ delete_clip_param: "clip" "=" NUMBER
                  | "position" "=" NUMBER
                  | "bar" "=" NUMBER
 
+// Duplication: count copies; clip copies are offset seconds (or offset_bars) apart, default back to back
+duplicate_chain: ".duplicate" "(" duplicate_params? ")"
+               | ".duplicate_clip" "(" duplicate_clip_params? ")"
+duplicate_params: "count" "=" NUMBER
+duplicate_clip_params: duplicate_clip_param ("," SP duplicate_clip_param)*
+duplicate_clip_param: "count" "=" NUMBER
+                    | "offset" "=" NUMBER
+                    | "offset_bars" "=" NUMBER
+                    | "clip" "=" NUMBER
+                    | "position" "=" NUMBER
+                    | "bar" "=" NUMBER
+
+// Clip edits: split at a point, trim edges to project positions, loop on/off
+clip_edit_chain: ".split_clip" "(" split_clip_param ")"
+               | ".trim_clip" "(" trim_clip_params ")"
+               | ".set_clip_loop" "(" clip_loop_params ")"
+split_clip_param: "position" "=" NUMBER
+                | "bar" "=" NUMBER
+trim_clip_params: trim_clip_param ("," SP trim_clip_param)*
+trim_clip_param: "start" "=" NUMBER
+               | "end" "=" NUMBER
+               | "start_bar" "=" NUMBER
+               | "end_bar" "=" NUMBER
+               | "clip" "=" NUMBER
+               | "position" "=" NUMBER
+               | "bar" "=" NUMBER
+clip_loop_params: clip_loop_param ("," SP clip_loop_param)*
+clip_loop_param: "enabled" "=" BOOLEAN
+               | "loop_length" "=" NUMBER
+               | "clip" "=" NUMBER
+               | "position" "=" NUMBER
+               | "bar" "=" NUMBER
+
+// Edits of existing clips' MIDI notes (from state): transpose, reverse, legato, remove matching notes
+clip_notes_chain: ".transpose_clip" "(" "semitones" "=" NUMBER ("," SP clip_notes_param)* ")"
+                | ".reverse_clip" "(" clip_notes_params? ")"
+                | ".legato_clip" "(" clip_notes_params? ")"
+                | ".remove_notes" "(" filter_predicate ")"
+clip_notes_params: clip_notes_param ("," SP clip_notes_param)*
+clip_notes_param: "clip" "=" NUMBER
+                | "position" "=" NUMBER
+                | "bar" "=" NUMBER
+
+// Clip selection: the Nth clip on the track by position (1-based), for the following clip operation
+nth_clip_chain: ".nth_clip" "(" NUMBER ")"
+
 // Clip editing operations - unified set_clip method
 clip_properties_chain: ".set_clip" "(" clip_properties_params? ")"
 clip_properties_params: clip_property_param ("," SP clip_property_param)*
 clip_property_param: "name" "=" STRING
                    | "color" "=" (STRING | NUMBER)
                    | "selected" "=" BOOLEAN
-                   | "length" "=" NUMBER
+                   | "length" "=" (NUMBER | expr)
+                   | "length_bars" "=" (NUMBER | expr)
+                   | "source_length" "=" NUMBER
+                   | "loop" "=" BOOLEAN
                    | "clip" "=" NUMBER
                    | "position" "=" NUMBER
                    | "bar" "=" NUMBER
@@ -2497,6 +2086,87 @@ move_clip_param: "position" "=" NUMBER
                | "bar" "=" NUMBER
                | "clip" "=" NUMBER
                | "old_position" "=" NUMBER
+               | "relative_bars" "=" NUMBER
+               | "relative" "=" NUMBER
+               | clip_anchor_param
+
+// Routing: sends from the current track to a destination track (dest is 1-based like id)
+send_chain: ".add_send" "(" send_params ")"
+          | ".set_send" "(" send_params ")"
+          | ".remove_send" "(" send_params ")"
+send_params: send_param ("," SP send_param)*
+send_param: "dest" "=" NUMBER
+          | "dest_name" "=" STRING
+          | "level_db" "=" NUMBER
+          | "pre_fader" "=" BOOLEAN
+          | "mute" "=" BOOLEAN
+
+// Track templates: named multi-action snippets, e.g. apply_template(name="vocal chain", track=2)
+// with track 1-based; the other parameters override the template's own. The chained form
+// applies to the current or filtered tracks.
+template_call: "apply_template" "(" template_params ")"
+template_chain: ".apply_template" "(" template_params ")"
+template_params: template_param ("," SP template_param)*
+template_param: IDENTIFIER "=" (STRING | NUMBER | BOOLEAN)
+
+// Read-only queries: answer questions instead of producing actions. Top-level calls are
+// always separated by ";"; the chained form aggregates the preceding filter's result.
+query_call: "count" "(" IDENTIFIER ")"
+          | aggregate_fn "(" IDENTIFIER "," SP query_expr ")"
+          | aggregate_fn "(" query_expr ")"
+query_chain: ".count" "(" ")"
+           | "." aggregate_fn "(" query_expr ")"
+aggregate_fn: "sum" | "min" | "max"
+query_expr: property_access | expr
+
+// Ambiguous requests: ask the user instead of acting. Always the whole script.
+clarify_call: "clarify" "(" "question" "=" STRING ("," SP "options" "=" string_list)? ")"
+string_list: "[" (STRING ("," SP STRING)*)? "]"
+
+// Project-level statements: no track context, always separated by ";"
+project_call: marker_call | transport_call | render_call
+
+// Project markers and regions - top-level statements, always separated by ";"
+marker_call: "add_marker" "(" marker_params ")"
+           | "add_region" "(" region_params ")"
+           | "delete_marker" "(" marker_params ")"
+           | "rename_region" "(" region_params ")"
+marker_params: marker_param ("," SP marker_param)*
+marker_param: "bar" "=" NUMBER
+            | "position" "=" NUMBER
+            | "id" "=" NUMBER
+            | "name" "=" STRING
+            | "color" "=" (STRING | NUMBER)
+region_params: region_param ("," SP region_param)*
+region_param: "start_bar" "=" NUMBER
+            | "end_bar" "=" NUMBER
+            | "start" "=" NUMBER
+            | "end" "=" NUMBER
+            | "id" "=" NUMBER
+            | "name" "=" STRING
+            | "new_name" "=" STRING
+            | "color" "=" (STRING | NUMBER)
+
+// Transport - top-level statements, always separated by ";"
+transport_call: "play" "(" ")"
+              | "stop" "(" ")"
+              | "record" "(" ")"
+              | "set_play_position" "(" play_position_param ")"
+play_position_param: "bar" "=" NUMBER
+                   | "time" "=" NUMBER
+
+// Render/export - top-level statement, always separated by ";"
+render_call: "render_project" "(" render_params? ")"
+render_params: render_param ("," SP render_param)*
+render_param: "format" "=" STRING
+            | "start_bar" "=" NUMBER
+            | "end_bar" "=" NUMBER
+            | "stems" "=" BOOLEAN
+
+// Freeze and bounce - render the track's FX to audio to save CPU
+freeze_chain: ".freeze_track" "(" ")"
+            | ".unfreeze_track" "(" ")"
+            | ".bounce_in_place" "(" ")"
 
 // Automation operations - supports curve-based and point-based syntax
 automation_chain: ".add_automation" "(" automation_params ")"
@@ -2510,6 +2180,7 @@ automation_param: "param" "=" STRING
                 | "from" "=" NUMBER
                 | "to" "=" NUMBER
                 | "freq" "=" NUMBER
+                | "rate" "=" STRING
                 | "amplitude" "=" NUMBER
                 | "phase" "=" NUMBER
                 | "shape" "=" NUMBER
@@ -2521,6 +2192,19 @@ automation_point_field: "time" "=" NUMBER
                       | "bar" "=" NUMBER
                       | "value" "=" NUMBER
 
+// Editing existing automation: clear points (optionally in a range), delete the envelope, or
+// rescale its values
+automation_edit_chain: ".clear_automation" "(" automation_edit_params ")"
+                     | ".delete_envelope" "(" automation_edit_params ")"
+                     | ".scale_automation" "(" automation_edit_params ")"
+automation_edit_params: automation_edit_param ("," SP automation_edit_param)*
+automation_edit_param: "param" "=" STRING
+                     | "start" "=" NUMBER
+                     | "end" "=" NUMBER
+                     | "start_bar" "=" NUMBER
+                     | "end_bar" "=" NUMBER
+                     | "factor" "=" NUMBER
+
 // Functional operations
 functional_call: filter_call chain+
                  | filter_call chain? ";" filter_call chain?
@@ -2528,16 +2212,18 @@ functional_call: filter_call chain+
                  | for_each_call
 
 filter_call: "filter" "(" IDENTIFIER "," filter_predicate ")"
-filter_predicate: property_access comparison_op (STRING | NUMBER | BOOLEAN)
-                | property_access "==" STRING
-                | property_access "!=" STRING
-                | property_access "==" BOOLEAN
-                | property_access "!=" BOOLEAN
-                | property_access "<" NUMBER
-                | property_access ">" NUMBER
-                | property_access "<=" NUMBER
-                | property_access ">=" NUMBER
-                | property_access " in " array
+// Compound predicates: && binds tighter than ||, ! negates a bare property or a group
+filter_predicate: filter_and (SP? "||" SP? filter_and)*
+filter_and: filter_not (SP? "&&" SP? filter_not)*
+filter_not: "!" property_access
+          | "!" "(" filter_predicate ")"
+          | "(" filter_predicate ")"
+          | property_access
+          | filter_comparison
+// Comparisons mirror the predicate syntax tree Filter evaluates (predicate.go)
+filter_comparison: expr SP? comparison_op SP? (expr | STRING | BOOLEAN)
+                 | property_access SP string_op SP STRING
+                 | property_access SP "in" SP array
 
 map_call: "map" "(" IDENTIFIER "," function_ref ")"
           | "map" "(" IDENTIFIER "," method_call ")"
@@ -2547,21 +2233,35 @@ for_each_call: "for_each" "(" IDENTIFIER "," function_ref ")"
 
 method_call: IDENTIFIER "." IDENTIFIER "(" method_params? ")"
 method_params: method_param ("," SP method_param)*
-method_param: IDENTIFIER "=" (STRING | NUMBER | BOOLEAN)
+// Values may use the iterated item: name=track.name + " (old)", volume_db=track.volume_db - 3
+method_param: IDENTIFIER "=" (STRING | NUMBER | BOOLEAN | expr | string_concat)
+string_concat: concat_atom (SP? "+" SP? concat_atom)+
+concat_atom: STRING | property_access | "(" expr ")"
 
 property_access: IDENTIFIER "." IDENTIFIER
                | IDENTIFIER "." IDENTIFIER "[" NUMBER "]"
 
 comparison_op: "==" | "!=" | "<" | ">" | "<=" | ">="
 
+// contains/starts_with/ends_with ignore case; matches is a regular expression
+string_op: "contains" | "starts_with" | "ends_with" | "matches"
+
+// Arithmetic over item properties: clip.position + clip.length, track.volume_db - 3
+expr: expr_term (SP? ("+" | "-") SP? expr_term)*
+expr_term: expr_atom (SP? ("*" | "/") SP? expr_atom)*
+expr_atom: NUMBER
+         | property_access
+         | "-" expr_atom
+         | "(" expr ")"
+
 function_ref: "@" IDENTIFIER
 
 array: "[" (value ("," SP value)*)? "]"
 value: STRING | NUMBER | BOOLEAN | array
 
 SP: " "
-STRING: /"[^"]*"/
-NUMBER: /-?\d+(\.\d+)?/
+STRING: /"(\\.|[^"\\])*"/
+NUMBER: /-?\d+(\.\d+)?([eE][+-]?\d+)?/
 BOOLEAN: "true" | "false"
 IDENTIFIER: /[a-zA-Z_][a-zA-Z0-9_]*/
 `
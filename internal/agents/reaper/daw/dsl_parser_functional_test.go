@@ -141,3 +141,134 @@ func TestFunctionalDSLParser_SetClipLength(t *testing.T) {
 		t.Error("Should have set_clip action with length property")
 	}
 }
+
+func TestFunctionalDSLParser_SetClipSourceLength(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{
+				"index": 0,
+				"name":  "Track 1",
+				"clips": []any{
+					map[string]any{
+						"index":    0,
+						"position": 0.0,
+						"length":   8.0,
+						"track":    0,
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "looped source shorter than item",
+			dslCode: `track(id=1).set_clip(clip=0, length=8, source_length=2, loop=true)`,
+			want: []map[string]any{
+				{
+					"action":        "set_clip",
+					"track":         0,
+					"clip":          0,
+					"length":        8.0,
+					"source_length": 2.0,
+					"loop":          true,
+				},
+			},
+		},
+		{
+			name:    "source equal to item does not need loop",
+			dslCode: `track(id=1).set_clip(clip=0, length=4, source_length=4)`,
+			want: []map[string]any{
+				{
+					"action":        "set_clip",
+					"track":         0,
+					"clip":          0,
+					"length":        4.0,
+					"source_length": 4.0,
+				},
+			},
+		},
+		{
+			name:    "source shorter than item without loop",
+			dslCode: `track(id=1).set_clip(clip=0, length=8, source_length=2)`,
+			wantErr: true,
+		},
+		{
+			name:    "source shorter than item with loop disabled",
+			dslCode: `track(id=1).set_clip(clip=0, length=8, source_length=2, loop=false)`,
+			wantErr: true,
+		},
+		{
+			name:    "zero source length",
+			dslCode: `track(id=1).set_clip(clip=0, source_length=0, loop=true)`,
+			wantErr: true,
+		},
+		{
+			name:    "single clip validates against current clip length",
+			dslCode: `track(id=1).set_clip(clip=0, source_length=2)`,
+			wantErr: true,
+		},
+		{
+			name:    "single clip by position validates against current clip length",
+			dslCode: `track(id=1).set_clip(position=0, source_length=2)`,
+			wantErr: true,
+		},
+		{
+			name:    "single clip with loop",
+			dslCode: `track(id=1).set_clip(clip=0, source_length=2, loop=true)`,
+			want: []map[string]any{
+				{
+					"action":        "set_clip",
+					"track":         0,
+					"clip":          0,
+					"source_length": 2.0,
+					"loop":          true,
+				},
+			},
+		},
+		{
+			name:    "filtered clips validate against current clip length",
+			dslCode: `filter(clips, clip.length > 4.0).set_clip(source_length=2)`,
+			wantErr: true,
+		},
+		{
+			name:    "filtered clips with loop",
+			dslCode: `filter(clips, clip.length > 4.0).set_clip(source_length=2, loop=true)`,
+			want: []map[string]any{
+				{
+					"action":        "set_clip",
+					"track":         0,
+					"position":      0.0,
+					"source_length": 2.0,
+					"loop":          true,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDSL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package daw
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/simulator"
 )
 
 func TestFunctionalDSLParser_TrackIndexWithExistingTracks(t *testing.T) {
@@ -144,3 +147,33 @@ func TestFunctionalDSLParser_ChainedActionsUseCorrectTrackIndex(t *testing.T) {
 		t.Errorf("Expected clip to reference track 1, got %v", createClip["track"])
 	}
 }
+
+// A mute reported through a transaction lands in session state via the simulator; the next
+// turn's filter reads the muted state key
+func TestFunctionalDSLParser_FilterOnSimulatedMute(t *testing.T) {
+	before := map[string]any{"tracks": []any{
+		map[string]any{"index": 0, "name": "Drums", "muted": true},
+		map[string]any{"index": 1, "name": "Bass"},
+	}}
+	simulated, err := simulator.Apply(before, []map[string]any{
+		{"action": "set_track", "track": 0, "mute": false},
+		{"action": "set_track", "track": 1, "mute": true},
+	})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(simulated.State)
+	actions, err := parser.ParseDSL(`filter(tracks, track.muted == true).set_track(name="Muted")`)
+	if err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	want := []map[string]any{{"action": "set_track", "track": 1, "name": "Muted"}}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("ParseDSL() = %v, want %v", actions, want)
+	}
+}
@@ -0,0 +1,273 @@
+package daw
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+type strictDSLKey struct{}
+
+// WithStrictDSL returns a context that turns off the repair pass, so generated DSL is parsed
+// exactly as the LLM wrote it.
+func WithStrictDSL(ctx context.Context, strict bool) context.Context {
+	return context.WithValue(ctx, strictDSLKey{}, strict)
+}
+
+// StrictDSLFromContext reports whether the request turned off DSL repairs.
+func StrictDSLFromContext(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictDSLKey{}).(bool)
+	return strict
+}
+
+// dslRepairRule is one entry of the repair catalog. apply rewrites code and calls fix for each
+// rewrite it makes. Masked rules see string literals replaced by placeholders, so they never
+// change text inside quotes.
+type dslRepairRule struct {
+	id      string
+	message string
+	masked  bool
+	apply   func(code string, fix func(from, to string)) string
+}
+
+// dslRepairRules run in order; unmasked rules come first because they may change the quotes
+// that masking relies on
+var dslRepairRules = []dslRepairRule{
+	{id: "code_fence", message: "removed a markdown code fence around the DSL", apply: repairCodeFence},
+	{id: "smart_quotes", message: `replaced a typographic quote with "`, apply: repairSmartQuotes},
+	{
+		id: "set_property", message: "rewrote a set_<property>() call as set_track(<property>=...)",
+		masked: true, apply: repairSetProperty,
+	},
+	{id: "method_case", message: "rewrote a method name in snake_case", masked: true, apply: repairMethodCase},
+	{
+		id: "iteration_variable", message: "removed the prefix of an iteration variable (_clip -> clip)",
+		masked: true, apply: repairIterationVariable,
+	},
+	{id: "unquoted_string", message: "quoted a bare string argument", masked: true, apply: repairUnquotedString},
+}
+
+// RepairDSL applies the repair catalog to generated DSL. It returns the code to parse and the
+// repairs made, in catalog order; valid DSL comes back unchanged.
+func RepairDSL(code string) (string, []models.DSLRepair) {
+	var repairs []models.DSLRepair
+	var literals []string
+	masked := false
+	for _, rule := range dslRepairRules {
+		if rule.masked && !masked {
+			code, literals = maskDSLStrings(code)
+			masked = true
+		}
+		code = rule.apply(code, func(from, to string) {
+			repairs = append(repairs, models.DSLRepair{
+				Rule:    rule.id,
+				From:    unmaskDSLStrings(from, literals),
+				To:      unmaskDSLStrings(to, literals),
+				Message: rule.message,
+			})
+		})
+	}
+	if masked {
+		code = unmaskDSLStrings(code, literals)
+	}
+	return code, repairs
+}
+
+// repairDSL repairs generated DSL unless the request is strict, logging each repair
+func repairDSL(ctx context.Context, code string) (string, []models.DSLRepair) {
+	if StrictDSLFromContext(ctx) {
+		return code, nil
+	}
+	repaired, repairs := RepairDSL(code)
+	for _, repair := range repairs {
+		logger.Printf(ctx, "🔧 DSL repair %s: %s -> %s", repair.Rule, repair.From, repair.To)
+	}
+	return repaired, repairs
+}
+
+// DSL returns the code of the last parse, after repairs
+func (p *FunctionalDSLParser) DSL() string {
+	return p.dsl
+}
+
+// Repairs returns the rewrites applied to the generated DSL before it was parsed
+func (p *FunctionalDSLParser) Repairs() []models.DSLRepair {
+	return p.repairs
+}
+
+var dslMaskPattern = regexp.MustCompile("\x00([0-9]+)\x00")
+
+// maskDSLStrings replaces each string literal (an unterminated one runs to the end) with a
+// numbered placeholder
+func maskDSLStrings(code string) (string, []string) {
+	var out strings.Builder
+	var literals []string
+	for i := 0; i < len(code); i++ {
+		if code[i] != '"' {
+			out.WriteByte(code[i])
+			continue
+		}
+		end := i + 1
+		for end < len(code) && code[end] != '"' {
+			if code[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		end = min(end+1, len(code))
+		fmt.Fprintf(&out, "\x00%d\x00", len(literals))
+		literals = append(literals, code[i:end])
+		i = end - 1
+	}
+	return out.String(), literals
+}
+
+// unmaskDSLStrings puts back the string literals maskDSLStrings took out
+func unmaskDSLStrings(code string, literals []string) string {
+	if len(literals) == 0 {
+		return code
+	}
+	return dslMaskPattern.ReplaceAllStringFunc(code, func(placeholder string) string {
+		index, err := strconv.Atoi(placeholder[1 : len(placeholder)-1])
+		if err != nil || index >= len(literals) {
+			return placeholder
+		}
+		return literals[index]
+	})
+}
+
+// normalizeDSLName folds case and underscores, so setTrack, Set_Track and set_track compare equal
+func normalizeDSLName(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", ""))
+}
+
+var dslCodeFencePattern = regexp.MustCompile("(?s)^\\s*(```[\\w-]*)[ \\t]*\\n(.*?)\\n?[ \\t]*```\\s*$")
+
+// repairCodeFence unwraps DSL returned as a markdown code block: ```dsl ... ```
+func repairCodeFence(code string, fix func(from, to string)) string {
+	match := dslCodeFencePattern.FindStringSubmatch(code)
+	if match == nil {
+		return code
+	}
+	fix(match[1]+" ... ```", "")
+	return strings.TrimSpace(match[2])
+}
+
+var dslSmartQuotePattern = regexp.MustCompile(`[“”„‟″]`)
+
+// repairSmartQuotes replaces typographic double quotes with ASCII ones
+func repairSmartQuotes(code string, fix func(from, to string)) string {
+	return dslSmartQuotePattern.ReplaceAllStringFunc(code, func(quote string) string {
+		fix(quote, `"`)
+		return `"`
+	})
+}
+
+// setTrackShorthands maps set_<property> methods (normalized) to the set_track property they
+// set and the value a call without arguments means
+var setTrackShorthands = map[string]struct{ prop, empty string }{
+	"setname":     {prop: "name"},
+	"setvolume":   {prop: "volume_db"},
+	"setvolumedb": {prop: "volume_db"},
+	"setpan":      {prop: "pan"},
+	"setmute":     {prop: "mute", empty: "true"},
+	"setsolo":     {prop: "solo", empty: "true"},
+	"setselected": {prop: "selected", empty: "true"},
+	"setcolor":    {prop: "color"},
+}
+
+var dslMethodCallPattern = regexp.MustCompile(`\.([A-Za-z][A-Za-z0-9_]*)\(([^()]*)\)`)
+
+// repairSetProperty rewrites .set_selected(true), .set_mute() and the like as .set_track calls
+func repairSetProperty(code string, fix func(from, to string)) string {
+	return dslMethodCallPattern.ReplaceAllStringFunc(code, func(call string) string {
+		match := dslMethodCallPattern.FindStringSubmatch(call)
+		shorthand, ok := setTrackShorthands[normalizeDSLName(match[1])]
+		if !ok {
+			return call
+		}
+		args := strings.TrimSpace(match[2])
+		switch {
+		case args == "" && shorthand.empty == "":
+			return call
+		case args == "":
+			args = shorthand.prop + "=" + shorthand.empty
+		case !strings.Contains(args, "="):
+			args = shorthand.prop + "=" + args
+		}
+		repaired := ".set_track(" + args + ")"
+		fix(call, repaired)
+		return repaired
+	})
+}
+
+var (
+	grammarCallPattern  = regexp.MustCompile(`"\.?([a-z_]+)" "\("`)
+	dslCallNamePattern  = regexp.MustCompile(`\b([A-Za-z][A-Za-z0-9_]*)\(`)
+	dslMethodNamesOnce  sync.Once
+	dslMethodNamesCache map[string]string
+)
+
+// dslMethodNames maps normalized names to the calls the functional grammar defines
+func dslMethodNames() map[string]string {
+	dslMethodNamesOnce.Do(func() {
+		dslMethodNamesCache = make(map[string]string)
+		for _, match := range grammarCallPattern.FindAllStringSubmatch(GetMagdaDSLGrammarForFunctional(), -1) {
+			dslMethodNamesCache[normalizeDSLName(match[1])] = match[1]
+		}
+	})
+	return dslMethodNamesCache
+}
+
+// repairMethodCase rewrites grammar calls written in another case (setTrack, New_Clip) in
+// snake_case. addAutomation is left alone: the parser accepts it alongside add_automation.
+func repairMethodCase(code string, fix func(from, to string)) string {
+	names := dslMethodNames()
+	return dslCallNamePattern.ReplaceAllStringFunc(code, func(call string) string {
+		name := strings.TrimSuffix(call, "(")
+		canonical, ok := names[normalizeDSLName(name)]
+		if !ok || name == canonical || name == "addAutomation" {
+			return call
+		}
+		fix(name, canonical)
+		return canonical + "("
+	})
+}
+
+var dslPrefixedVariablePattern = regexp.MustCompile(`(^|[^\w$])([_$])(track|clip|fx)\.`)
+
+// repairIterationVariable drops the _ or $ LLMs put in front of item variables (_clip.length)
+func repairIterationVariable(code string, fix func(from, to string)) string {
+	return dslPrefixedVariablePattern.ReplaceAllStringFunc(code, func(ref string) string {
+		match := dslPrefixedVariablePattern.FindStringSubmatch(ref)
+		fix(match[2]+match[3], match[3])
+		return match[1] + match[3] + "."
+	})
+}
+
+// dslUnquotedStringPattern matches a bare word or words passed to one of the grammar's string
+// parameters, up to the next argument or the closing parenthesis
+var dslUnquotedStringPattern = regexp.MustCompile(
+	`(\b(?:instrument|name|guid|fxname|fx|param|palette|genre|before|after|parent_name|folder|curve|dest_name|new_name|format|color)\s*=\s*)` +
+		`([A-Za-z][\w#-]*(?: [A-Za-z0-9][\w#-]*)*)(\s*[,)])`,
+)
+
+// repairUnquotedString quotes bare string arguments: name=Bass -> name="Bass"
+func repairUnquotedString(code string, fix func(from, to string)) string {
+	return dslUnquotedStringPattern.ReplaceAllStringFunc(code, func(arg string) string {
+		match := dslUnquotedStringPattern.FindStringSubmatch(arg)
+		value := match[2]
+		if value == "true" || value == "false" {
+			return arg
+		}
+		quoted := `"` + value + `"`
+		fix(value, quoted)
+		return match[1] + quoted + match[3]
+	})
+}
@@ -0,0 +1,121 @@
+package daw
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepairDSL(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    string
+		want    string
+		repairs []models.DSLRepair
+	}{
+		{
+			name: "valid DSL is unchanged",
+			code: `track(instrument="Serum").new_clip(bar=3, length_bars=4)`,
+			want: `track(instrument="Serum").new_clip(bar=3, length_bars=4)`,
+		},
+		{
+			name: "code fence",
+			code: "```dsl\ntrack(id=1).delete()\n```",
+			want: "track(id=1).delete()",
+			repairs: []models.DSLRepair{
+				{Rule: "code_fence", From: "```dsl ... ```", To: "", Message: "removed a markdown code fence around the DSL"},
+			},
+		},
+		{
+			name: "smart quotes",
+			code: `track(id=1).set_track(name=“Bass”)`,
+			want: `track(id=1).set_track(name="Bass")`,
+			repairs: []models.DSLRepair{
+				{Rule: "smart_quotes", From: "“", To: `"`, Message: `replaced a typographic quote with "`},
+				{Rule: "smart_quotes", From: "”", To: `"`, Message: `replaced a typographic quote with "`},
+			},
+		},
+		{
+			name: "set_selected shorthand",
+			code: `track(id=2).set_selected(true)`,
+			want: `track(id=2).set_track(selected=true)`,
+			repairs: []models.DSLRepair{{
+				Rule: "set_property", From: ".set_selected(true)", To: ".set_track(selected=true)",
+				Message: "rewrote a set_<property>() call as set_track(<property>=...)",
+			}},
+		},
+		{
+			name: "set_mute without arguments",
+			code: `filter(tracks, track.name == "Drums").setMute()`,
+			want: `filter(tracks, track.name == "Drums").set_track(mute=true)`,
+			repairs: []models.DSLRepair{{
+				Rule: "set_property", From: ".setMute()", To: ".set_track(mute=true)",
+				Message: "rewrote a set_<property>() call as set_track(<property>=...)",
+			}},
+		},
+		{
+			name: "method case",
+			code: `Track(id=1).newClip(bar=1, length_bars=2)`,
+			want: `track(id=1).new_clip(bar=1, length_bars=2)`,
+			repairs: []models.DSLRepair{
+				{Rule: "method_case", From: "Track", To: "track", Message: "rewrote a method name in snake_case"},
+				{Rule: "method_case", From: "newClip", To: "new_clip", Message: "rewrote a method name in snake_case"},
+			},
+		},
+		{
+			name: "addAutomation is accepted as is",
+			code: `track(id=1).addAutomation(param="volume", curve="fade_in", start=0, end=4)`,
+			want: `track(id=1).addAutomation(param="volume", curve="fade_in", start=0, end=4)`,
+		},
+		{
+			name: "prefixed iteration variable",
+			code: `filter(clips, _clip.length > 4).delete_clip()`,
+			want: `filter(clips, clip.length > 4).delete_clip()`,
+			repairs: []models.DSLRepair{{
+				Rule: "iteration_variable", From: "_clip", To: "clip",
+				Message: "removed the prefix of an iteration variable (_clip -> clip)",
+			}},
+		},
+		{
+			name: "unquoted string arguments",
+			code: `track(instrument=Serum).set_track(name=Lead Synth, mute=true)`,
+			want: `track(instrument="Serum").set_track(name="Lead Synth", mute=true)`,
+			repairs: []models.DSLRepair{
+				{Rule: "unquoted_string", From: "Serum", To: `"Serum"`, Message: "quoted a bare string argument"},
+				{Rule: "unquoted_string", From: "Lead Synth", To: `"Lead Synth"`, Message: "quoted a bare string argument"},
+			},
+		},
+		{
+			name: "text inside strings is not repaired",
+			code: `track(id=1).set_track(name="_clip.setMute() name=Bass")`,
+			want: `track(id=1).set_track(name="_clip.setMute() name=Bass")`,
+		},
+		{
+			name: "expressions are not quoted",
+			code: `for_each(tracks, track.set_track(name=track.name))`,
+			want: `for_each(tracks, track.set_track(name=track.name))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, repairs := RepairDSL(tt.code)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.repairs, repairs)
+		})
+	}
+}
+
+func TestRepairDSL_StrictContext(t *testing.T) {
+	code := `track(id=2).set_selected(true)`
+
+	got, repairs := repairDSL(context.Background(), code)
+	assert.Equal(t, `track(id=2).set_track(selected=true)`, got)
+	assert.Len(t, repairs, 1)
+
+	got, repairs = repairDSL(WithStrictDSL(context.Background(), true), code)
+	assert.Equal(t, code, got)
+	assert.Empty(t, repairs)
+}
@@ -0,0 +1,226 @@
+package daw
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// dslStatementStarters begin a new statement when they follow a closing parenthesis. ParseDSL
+// executes each statement on its own (see executeStatements).
+var dslStatementStarters = []string{"track(", "master(", "filter(", "map(", "for_each(", "apply_template(", "undo("}
+
+var (
+	dslMethodErrorPattern   = regexp.MustCompile(`method (\w+) error: `)
+	dslUnknownMethodPattern = regexp.MustCompile(`unknown method: (\w+)`)
+	dslErrorPrefixPattern   = regexp.MustCompile(`^(?:failed to execute DSL: )?(?:method \w+ error: )?`)
+	dslCallPattern          = regexp.MustCompile(`\b([A-Za-z_]\w*)\s*\(`)
+)
+
+// dslStatement is one top-level statement, as byte offsets into the DSL code (end exclusive)
+type dslStatement struct {
+	start int
+	end   int
+}
+
+// ValidateDSL translates DSL code against state without calling the LLM.
+// It returns the actions, or the syntax/execution errors with 1-based line and column positions.
+// The error return is reserved for internal failures such as the parser failing to initialize.
+func ValidateDSL(ctx context.Context, dslCode string, state map[string]any) ([]map[string]any, []models.DSLError, error) {
+	if strings.TrimSpace(dslCode) == "" {
+		return nil, []models.DSLError{{Message: "empty DSL code", Line: 1, Column: 1}}, nil
+	}
+
+	// The Grammar School parser accepts unbalanced input, so check structure first
+	statements, syntaxErrors := scanDSLStatements(dslCode)
+	if len(syntaxErrors) > 0 {
+		return nil, syntaxErrors, nil
+	}
+
+	newParser := func() (*FunctionalDSLParser, error) {
+		parser, err := NewFunctionalDSLParser()
+		if err != nil {
+			return nil, err
+		}
+		parser.SetState(state)
+		parser.SetLengthUnit(LengthUnitFromContext(ctx))
+		parser.SetContext(ctx)
+		return parser, nil
+	}
+
+	parser, err := newParser()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create functional DSL parser: %w", err)
+	}
+	actions, err := parser.ParseDSL(dslCode)
+	if err == nil {
+		return actions, nil, nil
+	}
+	if errors.Is(err, errNoActions) {
+		return nil, []models.DSLError{{Message: err.Error(), Line: 1, Column: 1}}, nil
+	}
+
+	// Re-run growing prefixes to find the statement that raised the error
+	failing := statements[0]
+	for _, statement := range statements {
+		prefixParser, parserErr := newParser()
+		if parserErr != nil {
+			break
+		}
+		if _, prefixErr := prefixParser.ParseDSL(dslCode[:statement.end]); prefixErr != nil && prefixErr.Error() == err.Error() {
+			failing = statement
+			break
+		}
+	}
+
+	method := dslErrorMethod(err)
+	offset := failing.start
+	if method != "" {
+		if methodOffset := findDSLMethod(dslCode[failing.start:failing.end], method); methodOffset >= 0 {
+			offset = failing.start + methodOffset
+		}
+	}
+
+	line, column := dslLineColumn(dslCode, offset)
+	return nil, []models.DSLError{{
+		Message: dslErrorPrefixPattern.ReplaceAllString(err.Error(), ""),
+		Line:    line,
+		Column:  column,
+		Method:  method,
+	}}, nil
+}
+
+// scanDSLStatements splits DSL code into top-level statements and reports unterminated
+// strings and unbalanced parentheses
+func scanDSLStatements(code string) ([]dslStatement, []models.DSLError) {
+	var statements []dslStatement
+	var syntaxErrors []models.DSLError
+	var openParens []int
+
+	addError := func(offset int, message string) {
+		line, column := dslLineColumn(code, offset)
+		syntaxErrors = append(syntaxErrors, models.DSLError{Message: message, Line: line, Column: column})
+	}
+	addStatement := func(start, end int) {
+		trimmed := strings.TrimLeftFunc(code[start:end], unicode.IsSpace)
+		if strings.TrimSpace(trimmed) == "" {
+			return
+		}
+		statements = append(statements, dslStatement{start: end - len(trimmed), end: end})
+	}
+
+	statementStart := 0
+	inString := false
+	stringStart := 0
+	escapeNext := false
+
+	for i, r := range code {
+		if escapeNext {
+			escapeNext = false
+			continue
+		}
+
+		switch {
+		case r == '\\':
+			escapeNext = true
+		case r == '"':
+			if !inString {
+				stringStart = i
+			}
+			inString = !inString
+		case inString:
+			continue
+		case r == '(':
+			openParens = append(openParens, i)
+		case r == ')':
+			if len(openParens) == 0 {
+				addError(i, "unexpected ')' without matching '('")
+				continue
+			}
+			openParens = openParens[:len(openParens)-1]
+
+			// A statement starter after a closing parenthesis begins a new statement
+			if len(openParens) == 0 {
+				remaining := strings.TrimSpace(code[i+1:])
+				for _, starter := range dslStatementStarters {
+					if strings.HasPrefix(remaining, starter) {
+						addStatement(statementStart, i+1)
+						statementStart = i + 1
+						break
+					}
+				}
+			}
+		case r == ';' && len(openParens) == 0:
+			addStatement(statementStart, i)
+			statementStart = i + 1
+		}
+	}
+	addStatement(statementStart, len(code))
+
+	if inString {
+		addError(stringStart, "unterminated string")
+	}
+	for _, offset := range openParens {
+		addError(offset, "unclosed '(' without matching ')'")
+	}
+
+	return statements, syntaxErrors
+}
+
+// dslErrorMethod returns the snake_case DSL method named in an engine error, if any
+func dslErrorMethod(err error) string {
+	message := err.Error()
+	if match := dslMethodErrorPattern.FindStringSubmatch(message); match != nil {
+		return toSnakeCase(match[1])
+	}
+	if match := dslUnknownMethodPattern.FindStringSubmatch(message); match != nil {
+		return toSnakeCase(match[1])
+	}
+	return ""
+}
+
+// findDSLMethod returns the byte offset of the first call to method in statement, or -1
+func findDSLMethod(statement, method string) int {
+	for _, match := range dslCallPattern.FindAllStringSubmatchIndex(statement, -1) {
+		if strings.EqualFold(statement[match[2]:match[3]], method) {
+			return match[2]
+		}
+	}
+	return -1
+}
+
+// toSnakeCase converts a Grammar School method name back to DSL form (SetTrack -> set_track)
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// dslLineColumn converts a byte offset into a 1-based line and column (in characters)
+func dslLineColumn(code string, offset int) (int, int) {
+	line, column := 1, 1
+	for _, r := range code[:offset] {
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
@@ -0,0 +1,141 @@
+package daw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestValidateDSL(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "clips": []any{
+				map[string]any{"index": 0, "position": 0.0, "length": 4.0},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		dslCode     string
+		wantActions int
+		wantErrors  []models.DSLError
+	}{
+		{
+			name:        "valid DSL returns actions",
+			dslCode:     "track(name=\"Bass\")\ntrack(id=1).set_track(mute=true)",
+			wantActions: 2,
+		},
+		{
+			name:       "empty DSL",
+			dslCode:    "  \n ",
+			wantErrors: []models.DSLError{{Message: "empty DSL code", Line: 1, Column: 1}},
+		},
+		{
+			name:    "unclosed parenthesis",
+			dslCode: "track(name=\"Bass\")\ntrack(id=1).set_track(mute=true",
+			wantErrors: []models.DSLError{
+				{Message: "unclosed '(' without matching ')'", Line: 2, Column: 22},
+			},
+		},
+		{
+			name:    "unexpected closing parenthesis",
+			dslCode: `track(name="Bass"))`,
+			wantErrors: []models.DSLError{
+				{Message: "unexpected ')' without matching '('", Line: 1, Column: 19},
+			},
+		},
+		{
+			name:    "unterminated string",
+			dslCode: "track(name=\"Bass)",
+			wantErrors: []models.DSLError{
+				{Message: "unterminated string", Line: 1, Column: 12},
+				{Message: "unclosed '(' without matching ')'", Line: 1, Column: 6},
+			},
+		},
+		{
+			name:    "execution error points at the failing method",
+			dslCode: "track(name=\"Bass\")\n  track(id=1).nth_clip(3).set_clip(color=\"red\")",
+			wantErrors: []models.DSLError{
+				{Message: "nth_clip(3) is out of range: track 1 has 1 clip(s)", Line: 2, Column: 15, Method: "nth_clip"},
+			},
+		},
+		{
+			name:    "unknown method",
+			dslCode: "track(name=\"Bass\"); track(id=1).set_colour(color=\"red\")",
+			wantErrors: []models.DSLError{
+				{Message: "unknown method: SetColour", Line: 1, Column: 33, Method: "set_colour"},
+			},
+		},
+		{
+			name:       "no actions",
+			dslCode:    "track(id=1)",
+			wantErrors: []models.DSLError{{Message: "no actions found in DSL code", Line: 1, Column: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, dslErrors, err := ValidateDSL(context.Background(), tt.dslCode, state)
+			if err != nil {
+				t.Fatalf("ValidateDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(dslErrors, tt.wantErrors) {
+				t.Errorf("ValidateDSL() errors = %+v, want %+v", dslErrors, tt.wantErrors)
+			}
+			if len(actions) != tt.wantActions {
+				t.Errorf("ValidateDSL() returned %d actions, want %d", len(actions), tt.wantActions)
+			}
+		})
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"SetTrack":   "set_track",
+		"NthClip":    "nth_clip",
+		"AddFx":      "add_fx",
+		"GetFXChain": "get_fx_chain",
+		"Track":      "track",
+	}
+	for input, want := range tests {
+		if got := toSnakeCase(input); got != want {
+			t.Errorf("toSnakeCase(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestFindDSLMethod(t *testing.T) {
+	tests := []struct {
+		statement, method string
+		want              int
+	}{
+		{`track(id=1).set_clip(clip=0)`, "set_clip", 12},
+		{`track(id=1).SET_CLIP (clip=0)`, "set_clip", 12},
+		{`track(id=1).reset_clip(clip=0).set_clip(clip=0)`, "set_clip", 31},
+		{`track(id=1).set_clip_loop(enabled=true)`, "set_clip", -1},
+		{`track(id=1).new_clip(bar=1)`, "set_clip", -1},
+	}
+	for _, tt := range tests {
+		if got := findDSLMethod(tt.statement, tt.method); got != tt.want {
+			t.Errorf("findDSLMethod(%q, %q) = %d, want %d", tt.statement, tt.method, got, tt.want)
+		}
+	}
+}
+
+// The mock provider's canned DAW responses must stay valid as the DSL evolves
+func TestDefaultMockResponsesAreValidDSL(t *testing.T) {
+	state := map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "Drums"}}}
+	for _, response := range llm.DefaultMockResponses {
+		if response.Tool != "magda_dsl" {
+			continue
+		}
+		actions, dslErrors, err := ValidateDSL(context.Background(), response.Output, state)
+		if err != nil || len(dslErrors) > 0 || len(actions) == 0 {
+			t.Errorf("mock response for %q: %q gave %d actions, errors %v %v", response.Match, response.Output, len(actions), dslErrors, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package daw
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// maxDuplicateCount caps count so a typo can't create hundreds of tracks or clips
+const maxDuplicateCount = 64
+
+// Duplicate handles .duplicate() calls: duplicates the current (or filtered) tracks. REAPER
+// inserts each copy right after its source track, so later track indices shift by count.
+// Example: filter(tracks, track.name == "Bass").duplicate()
+func (r *ReaperDSL) Duplicate(args gs.Args) error {
+	p := r.parser
+
+	count, err := duplicateCount("duplicate", args)
+	if err != nil {
+		return err
+	}
+
+	var trackIndices []int
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			trackIndex, ok := actionInt(trackMap, "index")
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  Duplicate: Could not extract track index from %+v", trackMap)
+				continue
+			}
+			trackIndices = append(trackIndices, trackIndex)
+		}
+		// Duplicate from the last track back so inserted copies don't shift the tracks still to duplicate
+		sort.Sort(sort.Reverse(sort.IntSlice(trackIndices)))
+	} else {
+		if p.currentTrackIndex < 0 {
+			return fmt.Errorf("no track context for duplicate call")
+		}
+		trackIndices = append(trackIndices, p.currentTrackIndex)
+	}
+
+	for _, trackIndex := range trackIndices {
+		action := map[string]any{
+			"action": "duplicate_track",
+			"track":  trackIndex,
+		}
+		if count > 1 {
+			action["count"] = count
+		}
+		p.actions = append(p.actions, action)
+		p.trackCounter += count
+	}
+	logger.Printf(r.parser.ctx, "✅ Duplicate: %d track(s) x%d", len(trackIndices), count)
+	return nil
+}
+
+// DuplicateClip handles .duplicate_clip() calls: duplicates clips count times. offset is the
+// distance from one copy's start to the next (in the request's length unit, or offset_bars in
+// bars) and defaults to the clip length, i.e. copies placed back to back.
+// Example: track(id=1).duplicate_clip(bar=1, count=4, offset_bars=1)
+func (r *ReaperDSL) DuplicateClip(args gs.Args) error {
+	p := r.parser
+
+	count, err := duplicateCount("duplicate_clip", args)
+	if err != nil {
+		return err
+	}
+	props := map[string]any{}
+	if count > 1 {
+		props["count"] = count
+	}
+	if offsetBarsValue, ok := args["offset_bars"]; ok && offsetBarsValue.Kind == gs.ValueNumber {
+		props["offset"] = p.barsToSeconds(offsetBarsValue.Num)
+	} else if offsetValue, ok := args["offset"]; ok && offsetValue.Kind == gs.ValueNumber {
+		props["offset"] = p.lengthToSeconds(offsetValue.Num)
+	}
+	if offset, ok := props["offset"].(float64); ok && offset <= 0 {
+		return fmt.Errorf("duplicate_clip offset must be greater than 0")
+	}
+
+	newAction := func(trackIndex int) map[string]any {
+		action := map[string]any{"action": "duplicate_clip", "track": trackIndex}
+		for k, v := range props {
+			action[k] = v
+		}
+		return action
+	}
+
+	// Check if we have a filtered collection to apply to (clips, or tracks with a clip identifier)
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		applied := 0
+		for _, item := range filtered {
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			_, hasTrack := itemMap["track"]
+			_, hasLength := itemMap["length"]
+			_, hasPosition := itemMap["position"]
+			if hasTrack && (hasLength || hasPosition) {
+				trackIndex, ok := actionInt(itemMap, "track")
+				if !ok {
+					continue
+				}
+				action := newAction(trackIndex)
+				if position, ok := getNumericValue(itemMap["position"]); ok {
+					action["position"] = position
+				} else if clipIndex, ok := actionInt(itemMap, "index"); ok {
+					action["clip"] = clipIndex
+				} else {
+					logger.Printf(r.parser.ctx, "⚠️  DuplicateClip: Could not identify clip (no index or position): %+v", itemMap)
+					continue
+				}
+				p.actions = append(p.actions, action)
+				applied++
+				continue
+			}
+
+			trackIndex, ok := actionInt(itemMap, "index")
+			if !ok {
+				continue
+			}
+			action := newAction(trackIndex)
+			if err := copyDuplicateClipIdentifier(args, action); err != nil {
+				return err
+			}
+			p.actions = append(p.actions, action)
+			applied++
+		}
+		logger.Printf(r.parser.ctx, "✅ DuplicateClip: Applied to %d filtered items", applied)
+		return nil
+	}
+
+	if p.currentTrackIndex < 0 {
+		return fmt.Errorf("no track context for duplicate_clip call")
+	}
+	action := newAction(p.currentTrackIndex)
+	if err := copyDuplicateClipIdentifier(args, action); err != nil {
+		return err
+	}
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+// copyDuplicateClipIdentifier sets the clip to duplicate from clip (index), position, or bar
+func copyDuplicateClipIdentifier(args gs.Args, action map[string]any) error {
+	if clipValue, ok := args["clip"]; ok && clipValue.Kind == gs.ValueNumber {
+		action["clip"] = int(clipValue.Num)
+	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		action["position"] = positionValue.Num
+	} else if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		action["bar"] = int(barValue.Num)
+	} else {
+		return fmt.Errorf("duplicate_clip requires one of: clip (index), position (seconds), or bar (number)")
+	}
+	return nil
+}
+
+// duplicateCount reads count (default 1)
+func duplicateCount(actionType string, args gs.Args) (int, error) {
+	countValue, ok := args["count"]
+	if !ok || countValue.Kind != gs.ValueNumber {
+		return 1, nil
+	}
+	count := int(countValue.Num)
+	if float64(count) != countValue.Num || count < 1 || count > maxDuplicateCount {
+		return 0, fmt.Errorf("%s count must be a whole number from 1 to %d, got %g", actionType, maxDuplicateCount, countValue.Num)
+	}
+	return count, nil
+}
@@ -0,0 +1,109 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_Duplicate(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"project": map[string]any{"bpm": 120.0, "time_signature": "4/4"},
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Bass", "clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 2.0, "selected": true},
+					map[string]any{"index": 1, "position": 8.0, "length": 4.0, "selected": false},
+				}},
+				map[string]any{"index": 1, "name": "Keys"},
+				map[string]any{"index": 2, "name": "Bass Double"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "duplicate a track",
+			dslCode: `track(id=2).duplicate()`,
+			want: []map[string]any{
+				{"action": "duplicate_track", "track": 1},
+			},
+		},
+		{
+			name:    "duplicate filtered tracks from the last one back",
+			dslCode: `filter(tracks, track.index >= 1).duplicate(count=2)`,
+			want: []map[string]any{
+				{"action": "duplicate_track", "track": 2, "count": 2},
+				{"action": "duplicate_track", "track": 1, "count": 2},
+			},
+		},
+		{
+			name:    "tracks created after a duplicate get the next index",
+			dslCode: `track(id=1).duplicate(); track(name="Pad")`,
+			want: []map[string]any{
+				{"action": "duplicate_track", "track": 0},
+				{"action": "create_track", "name": "Pad", "index": 4},
+			},
+		},
+		{
+			name:    "duplicate selected clip four times one bar apart",
+			dslCode: `filter(clips, clip.selected == true).duplicate_clip(count=4, offset_bars=1)`,
+			want: []map[string]any{
+				{"action": "duplicate_clip", "track": 0, "position": 0.0, "count": 4, "offset": 2.0},
+			},
+		},
+		{
+			name:    "duplicate nth clip back to back",
+			dslCode: `track(id=1).nth_clip(2).duplicate_clip()`,
+			want: []map[string]any{
+				{"action": "duplicate_clip", "track": 0, "position": 8.0},
+			},
+		},
+		{
+			name:    "duplicate clip by bar",
+			dslCode: `track(id=2).duplicate_clip(bar=5, count=2, offset=3)`,
+			want: []map[string]any{
+				{"action": "duplicate_clip", "track": 1, "bar": 5, "count": 2, "offset": 3.0},
+			},
+		},
+		{
+			name:    "duplicate clip needs a clip",
+			dslCode: `track(id=1).duplicate_clip(count=2)`,
+			wantErr: "duplicate_clip requires one of",
+		},
+		{
+			name:    "count out of range",
+			dslCode: `track(id=1).duplicate(count=0)`,
+			wantErr: "duplicate count must be a whole number from 1 to 64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
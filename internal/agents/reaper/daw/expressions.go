@@ -0,0 +1,567 @@
+package daw
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+)
+
+// Numeric DSL arguments and filter predicates may be arithmetic expressions over the
+// properties of the item being filtered or changed, e.g. clip.position + clip.length > 16
+// or set_track(volume_db=track.volume_db - 3). Expressions support numbers, property
+// references (name.property), + - * /, unary minus and parentheses.
+
+// exprVars maps an expression variable name (track, clip, fx) to the item it refers to
+type exprVars map[string]map[string]any
+
+// numericExpr is a numeric argument given as an expression. It's resolved per item, and
+// convert (when set) turns the result into the action's unit, like a literal argument.
+type numericExpr struct {
+	src     string
+	convert func(float64) float64
+}
+
+// String renders the expression as written, for filter summaries
+func (e numericExpr) String() string {
+	return e.src
+}
+
+// eval evaluates the expression against vars and converts the result
+func (e numericExpr) eval(vars exprVars) (float64, error) {
+	value, err := evalExpr(e.src, vars)
+	if err != nil {
+		return 0, err
+	}
+	if e.convert != nil {
+		value = e.convert(value)
+	}
+	return value, nil
+}
+
+// numericArg reads a numeric argument given as a number or an expression. Numbers are
+// converted right away; expressions become a numericExpr resolved per item.
+func numericArg(args gs.Args, key string, convert func(float64) float64) (any, bool) {
+	value, ok := args[key]
+	if !ok {
+		return nil, false
+	}
+	switch value.Kind {
+	case gs.ValueNumber:
+		if convert != nil {
+			return convert(value.Num), true
+		}
+		return value.Num, true
+	case gs.ValueString:
+		if strings.TrimSpace(value.Str) == "" {
+			return nil, false
+		}
+		return numericExpr{src: strings.TrimSpace(value.Str), convert: convert}, true
+	default:
+		return nil, false
+	}
+}
+
+// resolveExprProps returns props with every numericExpr evaluated against vars.
+// props is returned as is when it holds no expressions.
+func resolveExprProps(props map[string]any, vars exprVars) (map[string]any, error) {
+	var resolved map[string]any
+	for key, value := range props {
+		expr, ok := value.(numericExpr)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = copyAction(props)
+		}
+		num, err := expr.eval(vars)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key, err)
+		}
+		resolved[key] = num
+	}
+	if resolved == nil {
+		return props, nil
+	}
+	return resolved, nil
+}
+
+// isArithmeticExpr reports whether s is an expression rather than a literal or a lone
+// property reference: it has an arithmetic operator or parentheses outside a leading sign.
+// Quoted strings are never expressions.
+func isArithmeticExpr(s string) bool {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.HasPrefix(s, "\"") {
+		return false
+	}
+	return strings.ContainsAny(s[1:], "+-*/()") || strings.HasPrefix(s, "(")
+}
+
+// isPropertyRef reports whether s is a lone property reference like clip.position
+func isPropertyRef(s string) bool {
+	s = strings.TrimSpace(s)
+	name, prop, ok := strings.Cut(s, ".")
+	if !ok || name == "" || prop == "" || !isIdentByte(name[0]) || !isIdentByte(prop[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isIdentByte(c) && (c < '0' || c > '9') && c != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// predicateVars binds a filtered item to the iteration variable and, like plain
+// predicates, to the track, clip and fx names
+func predicateVars(item map[string]any, iterVar string) exprVars {
+	vars := exprVars{iterVar: item}
+	for _, name := range []string{"track", "clip", "fx"} {
+		vars[name] = item
+	}
+	return vars
+}
+
+// itemProperty returns item's prop. A track's fx_count is derived from its FX chain, so
+// filter(tracks, track.fx_count > 3) works without writing to state.
+func itemProperty(item map[string]any, prop string) (any, bool) {
+	if value, ok := item[prop]; ok {
+		return value, true
+	}
+	if fxChain, ok := item["fx"].([]any); ok && prop == "fx_count" {
+		return len(fxChain), true
+	}
+	return nil, false
+}
+
+// stateTrack returns the track at index from state, or nil when state doesn't have it
+func (p *FunctionalDSLParser) stateTrack(index int) map[string]any {
+	tracks, _ := p.data["tracks"].([]any)
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		trackIndex, ok := actionInt(trackMap, "index")
+		if !ok {
+			trackIndex = i
+		}
+		if trackIndex == index {
+			return trackMap
+		}
+	}
+	return nil
+}
+
+// evalExpr evaluates an arithmetic expression. Property references are looked up in vars
+// and must be numeric.
+func evalExpr(src string, vars exprVars) (float64, error) {
+	node, err := parseExpr(src)
+	if err != nil {
+		return 0, err
+	}
+	return evalNumber(node, vars)
+}
+
+// parseExpr parses an arithmetic expression into its syntax tree
+func parseExpr(src string) (exprNode, error) {
+	e, err := newExprParser(src)
+	if err != nil {
+		return nil, err
+	}
+	node, err := e.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if err := e.expectEnd(); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// exprNode is a node of a parsed expression: a literal, a property reference or arithmetic
+type exprNode interface {
+	eval(vars exprVars) (any, error)
+}
+
+// literalNode is a number, quoted string or boolean
+type literalNode struct {
+	value any
+}
+
+func (n literalNode) eval(exprVars) (any, error) {
+	return n.value, nil
+}
+
+// refNode is a property reference like track.volume_db, or track.fx[0] for a list element
+type refNode struct {
+	ref   string
+	name  string
+	prop  string
+	index int // -1 without [n]
+}
+
+// errMissingProperty is returned for references to properties the item doesn't have.
+// Predicates treat it as no match rather than as an error.
+var errMissingProperty = errors.New("missing property")
+
+func (n refNode) eval(vars exprVars) (any, error) {
+	item, ok := vars[n.name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q in %q", n.name, n.ref)
+	}
+	value, ok := itemProperty(item, n.prop)
+	if !ok {
+		return nil, fmt.Errorf("%s has no property %q: %w", n.name, n.prop, errMissingProperty)
+	}
+	if n.index < 0 {
+		return value, nil
+	}
+	list, _ := value.([]any)
+	if n.index >= len(list) {
+		return nil, fmt.Errorf("%s has no element %d: %w", n.ref, n.index, errMissingProperty)
+	}
+	return list[n.index], nil
+}
+
+// negNode is unary minus
+type negNode struct {
+	operand exprNode
+}
+
+func (n negNode) eval(vars exprVars) (any, error) {
+	value, err := evalNumber(n.operand, vars)
+	return -value, err
+}
+
+// binaryNode is + - * or / over two numbers
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n binaryNode) eval(vars exprVars) (any, error) {
+	left, err := evalNumber(n.left, vars)
+	if err != nil {
+		return nil, err
+	}
+	right, err := evalNumber(n.right, vars)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	default:
+		if right == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return left / right, nil
+	}
+}
+
+// evalNumber evaluates node and requires a numeric result
+func evalNumber(node exprNode, vars exprVars) (float64, error) {
+	value, err := node.eval(vars)
+	if err != nil {
+		if ref, ok := node.(refNode); ok && errors.Is(err, errMissingProperty) {
+			return 0, fmt.Errorf("%s has no numeric property %q", ref.name, ref.prop)
+		}
+		return 0, err
+	}
+	num, ok := getNumericValue(value)
+	if !ok {
+		if ref, ok := node.(refNode); ok {
+			return 0, fmt.Errorf("%s has no numeric property %q", ref.name, ref.prop)
+		}
+		return 0, fmt.Errorf("%v is not a number", formatIterationValue(value))
+	}
+	return num, nil
+}
+
+// exprTokenKind classifies an expression token
+type exprTokenKind int
+
+const (
+	tokenNumber exprTokenKind = iota
+	tokenString
+	tokenIdent // identifiers, keywords and property references
+	tokenOp    // operators and punctuation
+)
+
+// exprToken is one token of an expression or predicate. Strings hold their unescaped text.
+type exprToken struct {
+	kind exprTokenKind
+	text string
+	num  float64
+}
+
+// exprOperators lists operator tokens, two-character ones first
+var exprOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "(", ")", "[", "]", ",", "!", "<", ">"}
+
+// tokenizeExpr splits an expression or predicate into tokens. Numbers may carry a fraction
+// and an exponent (1.5e-3); a leading minus is an operator, parsed as unary minus.
+func tokenizeExpr(src string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '"':
+			text, end, err := scanString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: text})
+			i = end
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			end := scanNumber(src, i)
+			num, err := strconv.ParseFloat(src[i:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q in expression %q", src[i:end], src)
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: src[i:end], num: num})
+			i = end
+		case isIdentByte(c):
+			start := i
+			for i < len(src) && (isIdentByte(src[i]) || src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: src[start:i]})
+		default:
+			op := ""
+			for _, candidate := range exprOperators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q in expression %q", c, src)
+			}
+			tokens = append(tokens, exprToken{kind: tokenOp, text: op})
+			i += len(op)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	return tokens, nil
+}
+
+// scanString reads the quoted string starting at src[start], unescaping \" and \\ (other
+// backslashes are kept, for regular expressions), and returns its text and the index after
+// the closing quote
+func scanString(src string, start int) (string, int, error) {
+	var sb strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case c == '\\' && i+1 < len(src) && (src[i+1] == '"' || src[i+1] == '\\'):
+			i++
+			sb.WriteByte(src[i])
+		case c == '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string in %q", src)
+}
+
+// scanNumber returns the index after the number starting at src[start]
+func scanNumber(src string, start int) int {
+	i := start
+	for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+		i++
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && src[j] >= '0' && src[j] <= '9' {
+			for j < len(src) && src[j] >= '0' && src[j] <= '9' {
+				j++
+			}
+			i = j
+		}
+	}
+	return i
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// exprParser is a recursive descent parser over expression tokens:
+//
+//	sum     := product (("+" | "-") product)*
+//	product := unary (("*" | "/") unary)*
+//	unary   := "-" unary | primary
+//	primary := NUMBER | STRING | BOOLEAN | IDENTIFIER "." IDENTIFIER ("[" NUMBER "]")? | "(" sum ")"
+//
+// Predicates (predicate.go) extend it with comparisons and &&, || and !.
+type exprParser struct {
+	src    string
+	tokens []exprToken
+	pos    int
+}
+
+func newExprParser(src string) (*exprParser, error) {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	return &exprParser{src: src, tokens: tokens}, nil
+}
+
+// peekOp returns the next token if it's an operator, or ""
+func (e *exprParser) peekOp() string {
+	if e.pos < len(e.tokens) && e.tokens[e.pos].kind == tokenOp {
+		return e.tokens[e.pos].text
+	}
+	return ""
+}
+
+// peekIdent returns the next token if it's an identifier or keyword, or ""
+func (e *exprParser) peekIdent() string {
+	if e.pos < len(e.tokens) && e.tokens[e.pos].kind == tokenIdent {
+		return e.tokens[e.pos].text
+	}
+	return ""
+}
+
+// unexpected reports the token at the current position
+func (e *exprParser) unexpected() error {
+	if e.pos >= len(e.tokens) {
+		return fmt.Errorf("unexpected end of expression %q", e.src)
+	}
+	return fmt.Errorf("unexpected %q in expression %q", e.tokens[e.pos].text, e.src)
+}
+
+// expectEnd fails unless every token was consumed
+func (e *exprParser) expectEnd() error {
+	if e.pos < len(e.tokens) {
+		return e.unexpected()
+	}
+	return nil
+}
+
+func (e *exprParser) parseSum() (exprNode, error) {
+	left, err := e.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for op := e.peekOp(); op == "+" || op == "-"; op = e.peekOp() {
+		e.pos++
+		right, err := e.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (e *exprParser) parseProduct() (exprNode, error) {
+	left, err := e.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for op := e.peekOp(); op == "*" || op == "/"; op = e.peekOp() {
+		e.pos++
+		right, err := e.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (e *exprParser) parseUnary() (exprNode, error) {
+	if e.peekOp() == "-" {
+		e.pos++
+		operand, err := e.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		// Fold negative literals so they stay literals: -6 in track.volume_db > -6
+		if lit, ok := operand.(literalNode); ok {
+			if num, ok := lit.value.(float64); ok {
+				return literalNode{value: -num}, nil
+			}
+		}
+		return negNode{operand: operand}, nil
+	}
+	return e.parsePrimary()
+}
+
+func (e *exprParser) parsePrimary() (exprNode, error) {
+	if e.pos >= len(e.tokens) {
+		return nil, e.unexpected()
+	}
+	token := e.tokens[e.pos]
+
+	switch token.kind {
+	case tokenNumber:
+		e.pos++
+		return literalNode{value: token.num}, nil
+	case tokenString:
+		e.pos++
+		return literalNode{value: token.text}, nil
+	case tokenIdent:
+		if token.text == "true" || token.text == "false" {
+			e.pos++
+			return literalNode{value: token.text == "true"}, nil
+		}
+		return e.parseRef()
+	}
+
+	if token.text != "(" {
+		return nil, e.unexpected()
+	}
+	e.pos++
+	node, err := e.parseSum()
+	if err != nil {
+		return nil, err
+	}
+	if e.peekOp() != ")" {
+		return nil, fmt.Errorf("missing ) in expression %q", e.src)
+	}
+	e.pos++
+	return node, nil
+}
+
+// parseRef parses a property reference, with an optional [n] list index
+func (e *exprParser) parseRef() (exprNode, error) {
+	ref := e.tokens[e.pos].text
+	name, prop, ok := strings.Cut(ref, ".")
+	if !ok || name == "" || prop == "" || strings.Contains(prop, ".") {
+		return nil, fmt.Errorf("invalid property reference %q in expression %q (want name.property)", ref, e.src)
+	}
+	e.pos++
+
+	node := refNode{ref: ref, name: name, prop: prop, index: -1}
+	if e.peekOp() != "[" {
+		return node, nil
+	}
+	e.pos++
+	if e.pos >= len(e.tokens) || e.tokens[e.pos].kind != tokenNumber {
+		return nil, e.unexpected()
+	}
+	node.index = int(e.tokens[e.pos].num)
+	e.pos++
+	if e.peekOp() != "]" {
+		return nil, fmt.Errorf("missing ] in expression %q", e.src)
+	}
+	e.pos++
+	return node, nil
+}
@@ -0,0 +1,166 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEvalExpr(t *testing.T) {
+	vars := exprVars{
+		"track": {"volume_db": -6.0, "pan": 0.5, "index": 2},
+		"clip":  {"position": 8.0, "length": 4.0},
+	}
+
+	tests := []struct {
+		expr    string
+		want    float64
+		wantErr string
+	}{
+		{expr: "3", want: 3},
+		{expr: "-2.5", want: -2.5},
+		{expr: "track.volume_db - 3", want: -9},
+		{expr: "track.volume_db-3", want: -9},
+		{expr: "clip.position + clip.length", want: 12},
+		{expr: "1 + 2 * 3", want: 7},
+		{expr: "(1 + 2) * 3", want: 9},
+		{expr: "-(clip.length / 2)", want: -2},
+		{expr: "track.index * 2", want: 4},
+		{expr: "track.pan * -1", want: -0.5},
+		{expr: "1 / 0", wantErr: "division by zero"},
+		{expr: "fx.wet + 1", wantErr: `unknown variable "fx"`},
+		{expr: "track.name", wantErr: `track has no numeric property "name"`},
+		{expr: "(1 + 2", wantErr: "missing )"},
+		{expr: "1 +", wantErr: "unexpected end of expression"},
+		{expr: "2 3", wantErr: `unexpected "3"`},
+		{expr: "track.volume_db % 2", wantErr: "unexpected character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := evalExpr(tt.expr, vars)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("evalExpr() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("evalExpr() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("evalExpr() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_Expressions(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "volume_db": -2.0, "pan": 0.4, "clips": []any{
+				map[string]any{"index": 0, "position": 0.0, "length": 8.0},
+				map[string]any{"index": 1, "position": 12.0, "length": 8.0},
+			}},
+			map[string]any{"index": 1, "name": "Bass", "volume_db": -10.0, "pan": -0.2, "clips": []any{
+				map[string]any{"index": 0, "position": 4.0, "length": 4.0},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "arithmetic filter predicate",
+			dslCode: `filter(clips, clip.position + clip.length > 16).set_clip(name="Late")`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "position": 12.0, "name": "Late"},
+			},
+		},
+		{
+			name:    "arithmetic predicate with >=",
+			dslCode: `filter(clips, clip.position + clip.length >= 8).set_clip(name="Late")`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "position": 0.0, "name": "Late"},
+				{"action": "set_clip", "track": 0, "position": 12.0, "name": "Late"},
+				{"action": "set_clip", "track": 1, "position": 4.0, "name": "Late"},
+			},
+		},
+		{
+			name:    "property on both sides",
+			dslCode: `filter(clips, clip.length > clip.position).set_clip(selected=true)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "position": 0.0, "selected": true},
+			},
+		},
+		{
+			name:    "relative volume on filtered tracks",
+			dslCode: `filter(tracks, track.volume_db > -20).set_track(volume_db=track.volume_db - 3)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "volume_db": -5.0},
+				{"action": "set_track", "track": 1, "volume_db": -13.0},
+			},
+		},
+		{
+			name:    "relative pan on a single track",
+			dslCode: `track(id=1).set_track(pan=track.pan * 0.5, mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "pan": 0.2, "mute": true},
+			},
+		},
+		{
+			name:    "constant expression",
+			dslCode: `track(id=2).set_track(volume_db=(1 - 4) * 2)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 1, "volume_db": -6.0},
+			},
+		},
+		{
+			name:    "relative clip length",
+			dslCode: `filter(clips, clip.length < 5).set_clip(length=clip.length * 2)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 1, "position": 4.0, "length": 8.0},
+			},
+		},
+		{
+			name:    "single clip expression",
+			dslCode: `track(id=1).set_clip(clip=1, length=clip.length / 2)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 1, "length": 4.0},
+			},
+		},
+		{
+			name:    "unknown property",
+			dslCode: `track(id=1).set_track(volume_db=track.gain - 3)`,
+			wantErr: `track has no numeric property "gain"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package daw
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Resolution paths reported in responses: simple commands are compiled locally by the rules
+// below, requests the scope check rejects stop there, and everything else goes to the LLM.
+const (
+	PathRules      = "rules"
+	PathScopeCheck = "scope_check"
+	PathLLM        = "llm"
+)
+
+// fastPathRule compiles a whitelisted command to DSL. Track numbers are 1-based like track(id=N).
+type fastPathRule struct {
+	pattern *regexp.Regexp
+	dsl     func(m []string) (string, bool)
+}
+
+// fastPathRules is the whitelist. Questions are lower-cased, trimmed and stripped of a trailing
+// "please" and punctuation before matching, and a rule must match the whole question.
+var fastPathRules = []fastPathRule{
+	{
+		pattern: regexp.MustCompile(`^(mute|unmute) track ([1-9]\d*)$`),
+		dsl: func(m []string) (string, bool) {
+			return fmt.Sprintf("track(id=%s).set_track(mute=%t)", m[2], m[1] == "mute"), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^(solo|unsolo) track ([1-9]\d*)$`),
+		dsl: func(m []string) (string, bool) {
+			return fmt.Sprintf("track(id=%s).set_track(solo=%t)", m[2], m[1] == "solo"), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^select track ([1-9]\d*)$`),
+		dsl: func(m []string) (string, bool) {
+			return fmt.Sprintf("track(id=%s).set_track(selected=true)", m[1]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^(?:delete|remove) track ([1-9]\d*)$`),
+		dsl: func(m []string) (string, bool) {
+			return fmt.Sprintf("track(id=%s).delete()", m[1]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^set (?:the )?(?:track ([1-9]\d*) volume|volume (?:of|on) track ([1-9]\d*)) to (-?\d+(?:\.\d+)?) ?(?:db)?$`),
+		dsl: func(m []string) (string, bool) {
+			return fmt.Sprintf("track(id=%s).set_track(volume_db=%s)", m[1]+m[2], m[3]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^set (?:the )?(?:track ([1-9]\d*) pan|pan (?:of|on) track ([1-9]\d*)) to (-?\d+(?:\.\d+)?)$`),
+		dsl: func(m []string) (string, bool) {
+			pan, err := strconv.ParseFloat(m[3], 64)
+			if err != nil || pan < -1 || pan > 1 {
+				return "", false
+			}
+			return fmt.Sprintf("track(id=%s).set_track(pan=%s)", m[1]+m[2], m[3]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^(?:color|colour) track ([1-9]\d*) ([a-z]+)$`),
+		dsl: func(m []string) (string, bool) {
+			if colorNameToHex(m[2]) == "" {
+				return "", false
+			}
+			return fmt.Sprintf("track(id=%s).set_track(color=%q)", m[1], m[2]), true
+		},
+	},
+	{
+		pattern: regexp.MustCompile(`^(?:add|create) (?:a )?(?:new )?track$`),
+		dsl: func(m []string) (string, bool) {
+			return "track()", true
+		},
+	},
+}
+
+// fastPathRenamePattern matches renames on the original question, since names keep their case
+var fastPathRenamePattern = regexp.MustCompile(`(?i)^rename track ([1-9]\d*) to ([^"\\]+)$`)
+
+// CompileFastPath compiles a simple command like "mute track 2" to DSL without the LLM.
+// It reports false for anything outside the whitelist.
+func CompileFastPath(question string) (string, bool) {
+	question = normalizeFastPathQuestion(question)
+	if question == "" {
+		return "", false
+	}
+
+	if m := fastPathRenamePattern.FindStringSubmatch(question); m != nil {
+		name := strings.Trim(strings.TrimSpace(m[2]), `'`)
+		if name == "" {
+			return "", false
+		}
+		return fmt.Sprintf(`track(id=%s).set_track(name="%s")`, m[1], name), true
+	}
+
+	lower := strings.ToLower(question)
+	for _, rule := range fastPathRules {
+		if m := rule.pattern.FindStringSubmatch(lower); m != nil {
+			return rule.dsl(m)
+		}
+	}
+	return "", false
+}
+
+// normalizeFastPathQuestion trims whitespace, collapses runs of spaces and drops a trailing
+// "please" and sentence punctuation
+func normalizeFastPathQuestion(question string) string {
+	question = strings.Join(strings.Fields(question), " ")
+	question = strings.TrimRight(question, ".!")
+	if lower := strings.ToLower(question); strings.HasSuffix(lower, " please") {
+		question = strings.TrimRight(question[:len(question)-len(" please")], ",.!")
+	}
+	return strings.TrimSpace(question)
+}
@@ -0,0 +1,90 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompileFastPath(t *testing.T) {
+	tests := []struct {
+		question string
+		want     string
+		wantOK   bool
+	}{
+		{question: "mute track 2", want: "track(id=2).set_track(mute=true)", wantOK: true},
+		{question: "  Unmute  Track 3. ", want: "track(id=3).set_track(mute=false)", wantOK: true},
+		{question: "solo track 1 please", want: "track(id=1).set_track(solo=true)", wantOK: true},
+		{question: "unsolo track 4!", want: "track(id=4).set_track(solo=false)", wantOK: true},
+		{question: "select track 5", want: "track(id=5).set_track(selected=true)", wantOK: true},
+		{question: "delete track 2", want: "track(id=2).delete()", wantOK: true},
+		{question: "remove track 7", want: "track(id=7).delete()", wantOK: true},
+		{question: "set track 3 volume to -6", want: "track(id=3).set_track(volume_db=-6)", wantOK: true},
+		{question: "set track 3 volume to -6 dB", want: "track(id=3).set_track(volume_db=-6)", wantOK: true},
+		{question: "set the volume of track 1 to -3.5db", want: "track(id=1).set_track(volume_db=-3.5)", wantOK: true},
+		{question: "set track 2 pan to -0.5", want: "track(id=2).set_track(pan=-0.5)", wantOK: true},
+		{question: "color track 2 red", want: `track(id=2).set_track(color="red")`, wantOK: true},
+		{question: "add a new track", want: "track()", wantOK: true},
+		{question: "Rename track 1 to Lead Vox", want: `track(id=1).set_track(name="Lead Vox")`, wantOK: true},
+
+		{question: "mute track 0"},
+		{question: "mute track 2 and 3"},
+		{question: "mute the drums"},
+		{question: "set track 2 pan to 50"},
+		{question: "color track 2 sparkly"},
+		{question: `rename track 1 to "Bass"`},
+		{question: "add a track with Serum and a bassline"},
+		{question: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			got, ok := CompileFastPath(tt.question)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("CompileFastPath(%q) = %q, %v, want %q, %v", tt.question, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCompileFastPath_Actions(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "muted": false},
+			map[string]any{"index": 1, "name": "Bass", "muted": false},
+		},
+	}
+
+	tests := []struct {
+		question string
+		want     []map[string]any
+	}{
+		{question: "mute track 2", want: []map[string]any{{"action": "set_track", "track": 1, "mute": true}}},
+		{question: "set track 1 volume to -6", want: []map[string]any{{"action": "set_track", "track": 0, "volume_db": -6.0}}},
+		{question: "rename track 2 to Sub Bass", want: []map[string]any{{"action": "set_track", "track": 1, "name": "Sub Bass"}}},
+		{question: "color track 1 blue", want: []map[string]any{{"action": "set_track", "track": 0, "color": "#0000ff"}}},
+		{question: "delete track 1", want: []map[string]any{{"action": "delete_track", "track": 0}}},
+		{question: "add a track", want: []map[string]any{{"action": "create_track", "index": 2}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.question, func(t *testing.T) {
+			dslCode, ok := CompileFastPath(tt.question)
+			if !ok {
+				t.Fatalf("CompileFastPath(%q) did not match", tt.question)
+			}
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(dslCode)
+			if err != nil {
+				t.Fatalf("ParseDSL(%q) error = %v", dslCode, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL(%q) = %v, want %v", dslCode, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+)
+
+// filterSyntaxParser is the gs.Parser for MAGDA DSL code. The generic Lark parser splits
+// call arguments on "," and "=", which takes predicates apart (track.name == "A, B" turns
+// into several arguments), so filter predicates are parsed here first: each one becomes a
+// predicate in parser.predicates and the call reaches Filter as filter(tracks, predicate=N).
+// The note predicates of remove_notes calls are parsed the same way.
+type filterSyntaxParser struct {
+	lark   *gs.LarkParser
+	parser *FunctionalDSLParser
+}
+
+// filterPredicate is a parsed filter predicate and its source text
+type filterPredicate struct {
+	src  string
+	pred predicate
+}
+
+// Parse implements gs.Parser
+func (f *filterSyntaxParser) Parse(input string) (*gs.CallChain, error) {
+	code, err := f.parser.extractFilterPredicates(input)
+	if err != nil {
+		return nil, err
+	}
+	return f.lark.Parse(code)
+}
+
+// extractFilterPredicates parses the predicate of every filter and remove_notes call in code
+// and replaces it with a predicate=N argument indexing p.predicates
+func (p *FunctionalDSLParser) extractFilterPredicates(code string) (string, error) {
+	p.predicates = nil
+
+	var out strings.Builder
+	inString := false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inString && c == '\\':
+			out.WriteByte(c)
+			if i+1 < len(code) {
+				i++
+				out.WriteByte(code[i])
+			}
+			continue
+		case c == '"':
+			inString = !inString
+		case !inString && strings.HasPrefix(code[i:], "filter(") && (i == 0 || !isIdentByte(code[i-1])):
+			open := i + len("filter")
+			closeIndex, err := matchingParen(code, open)
+			if err != nil {
+				return "", err
+			}
+			args, err := p.rewriteFilterArgs(code[open+1 : closeIndex])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString("filter(" + args + ")")
+			i = closeIndex
+			continue
+		case !inString && strings.HasPrefix(code[i:], ".remove_notes("):
+			open := i + len(".remove_notes")
+			closeIndex, err := matchingParen(code, open)
+			if err != nil {
+				return "", err
+			}
+			arg, err := p.notePredicateArg(code[open+1 : closeIndex])
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(".remove_notes(" + arg + ")")
+			i = closeIndex
+			continue
+		}
+		out.WriteByte(c)
+	}
+	return out.String(), nil
+}
+
+// rewriteFilterArgs parses the predicate in a filter call's arguments
+func (p *FunctionalDSLParser) rewriteFilterArgs(args string) (string, error) {
+	collection, src, found := cutTopLevelComma(args)
+	if !found {
+		// filter(track.muted == true): the collection is inferred from the predicate
+		collection, src = "", args
+		if isIdentifier(strings.TrimSpace(args)) {
+			return args, nil
+		}
+	}
+	src = strings.TrimSpace(src)
+	if strings.HasPrefix(src, "@") {
+		return "", fmt.Errorf("function references (%s) are not yet implemented in filter", src)
+	}
+
+	pred, err := parsePredicate(src)
+	if err != nil {
+		return "", fmt.Errorf("filter predicate %q: %w", src, err)
+	}
+	p.predicates = append(p.predicates, filterPredicate{src: src, pred: pred})
+
+	rewritten := fmt.Sprintf("predicate=%d", len(p.predicates)-1)
+	if collection = strings.TrimSpace(collection); collection != "" {
+		rewritten = collection + ", " + rewritten
+	}
+	return rewritten, nil
+}
+
+// notePredicateArg parses the note predicate of a remove_notes call
+func (p *FunctionalDSLParser) notePredicateArg(src string) (string, error) {
+	src = strings.TrimSpace(src)
+	if variable := predicateVariable(src); variable != "note" {
+		return "", fmt.Errorf("remove_notes predicate %q must test note properties, e.g. remove_notes(note.velocity < 30)", src)
+	}
+	pred, err := parsePredicate(src)
+	if err != nil {
+		return "", fmt.Errorf("remove_notes predicate %q: %w", src, err)
+	}
+	p.predicates = append(p.predicates, filterPredicate{src: src, pred: pred})
+	return fmt.Sprintf("predicate=%d", len(p.predicates)-1), nil
+}
+
+// matchingParen returns the index of the ")" closing the "(" at code[open], skipping quoted
+// strings
+func matchingParen(code string, open int) (int, error) {
+	depth := 0
+	inString := false
+	for i := open; i < len(code); i++ {
+		switch c := code[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed ( in %q", code[max(0, open-len("filter")):])
+}
+
+// cutTopLevelComma splits s around its first comma outside quotes, parentheses and brackets
+func cutTopLevelComma(s string) (string, string, bool) {
+	depth := 0
+	inString := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == ',' && depth == 0:
+			return s[:i], s[i+1:], true
+		}
+	}
+	return s, "", false
+}
+
+// splitTopLevel splits pred on op outside quoted strings, parentheses and brackets
+func splitTopLevel(pred, op string) []string {
+	var parts []string
+	depth := 0
+	inString := false
+	start := 0
+	for i := 0; i < len(pred); i++ {
+		c := pred[i]
+		switch {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(pred[i:], op):
+			parts = append(parts, pred[start:i])
+			i += len(op) - 1
+			start = i + 1
+		}
+	}
+	return append(parts, pred[start:])
+}
+
+// isIdentifier reports whether s is a bare identifier like tracks
+func isIdentifier(s string) bool {
+	if s == "" || !isIdentByte(s[0]) {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !isIdentByte(c) && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
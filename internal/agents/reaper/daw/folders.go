@@ -0,0 +1,434 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// Folders in REAPER are implicit in track order: a track with folder_depth 1 opens a folder,
+// the tracks after it are its children, and a negative folder_depth on the last child closes
+// that many folders. Folder methods rearrange a tree built from the state's track order and
+// emit, in order: create_track for a new folder, move_track to put tracks in their new order,
+// then set_track(folder_depth=...) for every track whose depth changed.
+
+// folderTrack is a track in the folder tree
+type folderTrack struct {
+	key      int // Track index DSL references resolve to (state index, or index at creation); -1 for new folders
+	name     string
+	depth    int // folder_depth as the extension currently has it
+	parent   *folderTrack
+	children []*folderTrack
+}
+
+// trackLayout is the project's track order as a folder tree
+type trackLayout struct {
+	roots []*folderTrack
+}
+
+// MakeFolder handles .make_folder() calls: creates a folder track and moves the current (or
+// filtered) tracks into it. The folder goes where the first of those tracks was.
+// Example: filter(tracks, track.index < 3).make_folder(name="Drums")
+func (r *ReaperDSL) MakeFolder(args gs.Args) error {
+	p := r.parser
+
+	nameValue, ok := args["name"]
+	if !ok || nameValue.Kind != gs.ValueString || strings.TrimSpace(nameValue.Str) == "" {
+		return fmt.Errorf("make_folder requires name (string)")
+	}
+	layout := p.folderLayout()
+	tracks, err := p.folderTargets("make_folder", layout)
+	if err != nil {
+		return err
+	}
+	tracks = outermostTracks(tracks)
+
+	before := layout.order()
+	first := tracks[0]
+	folder := &folderTrack{key: -1, name: nameValue.Str}
+	layout.insertBefore(folder, first)
+	for _, track := range tracks {
+		layout.detach(track)
+		folder.add(track)
+	}
+
+	// The folder track is created where it ends up, then the children are moved after it
+	position := indexOfTrack(layout.order(), folder)
+	p.actions = append(p.actions, map[string]any{
+		"action": "create_track",
+		"index":  position,
+		"name":   nameValue.Str,
+	})
+	p.trackCounter++
+	before = append(before[:position], append([]*folderTrack{folder}, before[position:]...)...)
+
+	p.emitLayoutChanges(layout, before)
+	logger.Printf(r.parser.ctx, "✅ MakeFolder: %q with %d tracks at index %d", nameValue.Str, len(tracks), position)
+	return nil
+}
+
+// AddToFolder handles .add_to_folder() calls: moves the current (or filtered) tracks to the
+// end of an existing folder, picked by name or 1-based track id.
+// Example: track(id=5).add_to_folder(folder="Drums")
+func (r *ReaperDSL) AddToFolder(args gs.Args) error {
+	p := r.parser
+
+	folderValue, ok := args["folder"]
+	if !ok {
+		return fmt.Errorf("add_to_folder requires folder (name or track id)")
+	}
+	layout := p.folderLayout()
+	folder, err := layout.resolve(folderValue)
+	if err != nil {
+		return fmt.Errorf("add_to_folder: %w", err)
+	}
+	tracks, err := p.folderTargets("add_to_folder", layout)
+	if err != nil {
+		return err
+	}
+	return p.moveIntoFolder("add_to_folder", layout, folder, outermostTracks(tracks))
+}
+
+// SetTrackParent handles .set_track_parent() calls: makes the track a child of another track
+// (parent is a 1-based id, parent_name a track name), or moves it out of its folders with parent=0.
+// Example: track(id=4).set_track_parent(parent=1)
+func (r *ReaperDSL) SetTrackParent(args gs.Args) error {
+	p := r.parser
+	layout := p.folderLayout()
+
+	var parentValue gs.Value
+	if value, ok := args["parent"]; ok && value.Kind == gs.ValueNumber {
+		parentValue = value
+	} else if value, ok := args["parent_name"]; ok && value.Kind == gs.ValueString {
+		parentValue = value
+	} else {
+		return fmt.Errorf("set_track_parent requires parent (track id, 0 for none) or parent_name")
+	}
+
+	tracks, err := p.folderTargets("set_track_parent", layout)
+	if err != nil {
+		return err
+	}
+	tracks = outermostTracks(tracks)
+
+	if parentValue.Kind == gs.ValueNumber && parentValue.Num == 0 {
+		before := layout.order()
+		lastAfter := make(map[*folderTrack]*folderTrack) // top-level folder -> last track moved out after it
+		for _, track := range tracks {
+			if track.parent == nil {
+				continue
+			}
+			top := track
+			for top.parent != nil {
+				top = top.parent
+			}
+			after, ok := lastAfter[top]
+			if !ok {
+				after = top
+			}
+			layout.detach(track)
+			layout.insertAfter(track, after)
+			lastAfter[top] = track
+		}
+		p.emitLayoutChanges(layout, before)
+		return nil
+	}
+
+	parent, err := layout.resolve(parentValue)
+	if err != nil {
+		return fmt.Errorf("set_track_parent: %w", err)
+	}
+	return p.moveIntoFolder("set_track_parent", layout, parent, tracks)
+}
+
+// moveIntoFolder makes tracks the last children of folder, in their current order
+func (p *FunctionalDSLParser) moveIntoFolder(actionType string, layout *trackLayout, folder *folderTrack, tracks []*folderTrack) error {
+	for _, track := range tracks {
+		for ancestor := folder; ancestor != nil; ancestor = ancestor.parent {
+			if ancestor == track {
+				return fmt.Errorf("%s: cannot move track %q into itself or its own subfolder", actionType, track.name)
+			}
+		}
+	}
+
+	before := layout.order()
+	for _, track := range tracks {
+		if track.parent == folder {
+			continue
+		}
+		layout.detach(track)
+		folder.add(track)
+	}
+	p.emitLayoutChanges(layout, before)
+	logger.Printf(p.ctx, "✅ %s: Moved %d tracks into %q", actionType, len(tracks), folder.name)
+	return nil
+}
+
+// folderTargets returns the tracks a folder method applies to: the filtered tracks, or the
+// current track
+func (p *FunctionalDSLParser) folderTargets(actionType string, layout *trackLayout) ([]*folderTrack, error) {
+	var keys []int
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if index, ok := actionInt(trackMap, "index"); ok {
+				keys = append(keys, index)
+			}
+		}
+	} else if p.currentTrackIndex >= 0 {
+		keys = append(keys, p.currentTrackIndex)
+	} else {
+		return nil, fmt.Errorf("no track context for %s call", actionType)
+	}
+
+	order := layout.order()
+	tracks := make([]*folderTrack, 0, len(keys))
+	for _, key := range keys {
+		track := layout.find(key)
+		if track == nil {
+			return nil, fmt.Errorf("%s: track %d is not in the project", actionType, key+1)
+		}
+		tracks = append(tracks, track)
+	}
+	if len(tracks) == 0 {
+		return nil, fmt.Errorf("%s: no tracks to move", actionType)
+	}
+
+	// Keep project order regardless of filter order
+	sorted := make([]*folderTrack, 0, len(tracks))
+	for _, track := range order {
+		for _, t := range tracks {
+			if t == track {
+				sorted = append(sorted, track)
+				break
+			}
+		}
+	}
+	return sorted, nil
+}
+
+// emitLayoutChanges emits move_track actions turning the before order into the layout's order,
+// then set_track actions for the folder depths that changed
+func (p *FunctionalDSLParser) emitLayoutChanges(layout *trackLayout, before []*folderTrack) {
+	current := append([]*folderTrack(nil), before...)
+	order := layout.order()
+
+	for i, track := range order {
+		j := indexOfTrack(current, track)
+		if j == i || j < 0 {
+			continue
+		}
+		p.actions = append(p.actions, map[string]any{"action": "move_track", "track": j, "to": i})
+		current = append(current[:j], current[j+1:]...)
+		current = append(current[:i], append([]*folderTrack{track}, current[i:]...)...)
+	}
+
+	for i, track := range order {
+		depth := layout.folderDepth(track)
+		if depth == track.depth {
+			continue
+		}
+		p.actions = append(p.actions, map[string]any{"action": "set_track", "track": i, "folder_depth": depth})
+		track.depth = depth
+	}
+}
+
+// folderLayout returns the track layout, building it from state (plus tracks created earlier
+// in the script) on first use
+func (p *FunctionalDSLParser) folderLayout() *trackLayout {
+	if p.layout != nil {
+		return p.layout
+	}
+
+	layout := &trackLayout{}
+	var open []*folderTrack
+	tracks, _ := p.data["tracks"].([]any)
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		key, ok := actionInt(trackMap, "index")
+		if !ok {
+			key = i
+		}
+		name, _ := trackMap["name"].(string)
+		depth, _ := actionInt(trackMap, "folder_depth")
+		track := &folderTrack{key: key, name: name, depth: depth}
+
+		if len(open) > 0 {
+			open[len(open)-1].add(track)
+		} else {
+			layout.roots = append(layout.roots, track)
+		}
+		if depth > 0 {
+			open = append(open, track)
+		} else if depth < 0 {
+			open = open[:max(0, len(open)+depth)]
+		}
+	}
+
+	for _, action := range p.actions {
+		if action["action"] != "create_track" {
+			continue
+		}
+		index, ok := actionInt(action, "index")
+		if !ok {
+			continue
+		}
+		name, _ := action["name"].(string)
+		track := &folderTrack{key: index, name: name}
+		order := layout.order()
+		if index < len(order) {
+			layout.insertBefore(track, order[index])
+		} else {
+			layout.roots = append(layout.roots, track)
+		}
+	}
+
+	p.layout = layout
+	return layout
+}
+
+// order returns the tracks in project order
+func (l *trackLayout) order() []*folderTrack {
+	var order []*folderTrack
+	var walk func(tracks []*folderTrack)
+	walk = func(tracks []*folderTrack) {
+		for _, track := range tracks {
+			order = append(order, track)
+			walk(track.children)
+		}
+	}
+	walk(l.roots)
+	return order
+}
+
+// folderDepth returns the folder_depth track needs in the layout: 1 for a folder with children,
+// otherwise minus the number of folders it is the last track of
+func (l *trackLayout) folderDepth(track *folderTrack) int {
+	if len(track.children) > 0 {
+		return 1
+	}
+	closes := 0
+	for node := track; node.parent != nil; node = node.parent {
+		siblings := node.parent.children
+		if siblings[len(siblings)-1] != node {
+			break
+		}
+		closes++
+	}
+	return -closes
+}
+
+// find returns the track with the given key
+func (l *trackLayout) find(key int) *folderTrack {
+	for _, track := range l.order() {
+		if track.key == key {
+			return track
+		}
+	}
+	return nil
+}
+
+// resolve finds a track by 1-based id or case-insensitive name
+func (l *trackLayout) resolve(value gs.Value) (*folderTrack, error) {
+	switch value.Kind {
+	case gs.ValueNumber:
+		if track := l.find(int(value.Num) - 1); track != nil {
+			return track, nil
+		}
+		return nil, fmt.Errorf("track %g is not in the project", value.Num)
+	case gs.ValueString:
+		for _, track := range l.order() {
+			if strings.EqualFold(strings.TrimSpace(track.name), strings.TrimSpace(value.Str)) {
+				return track, nil
+			}
+		}
+		return nil, fmt.Errorf("no track named %q in the project", value.Str)
+	}
+	return nil, fmt.Errorf("folder must be a track name or id")
+}
+
+// siblings returns the list track belongs to
+func (l *trackLayout) siblings(track *folderTrack) *[]*folderTrack {
+	if track.parent != nil {
+		return &track.parent.children
+	}
+	return &l.roots
+}
+
+// detach removes track (with its children) from its parent
+func (l *trackLayout) detach(track *folderTrack) {
+	list := l.siblings(track)
+	for i, t := range *list {
+		if t == track {
+			*list = append((*list)[:i], (*list)[i+1:]...)
+			break
+		}
+	}
+	track.parent = nil
+}
+
+// insertBefore puts track next to, and just before, sibling
+func (l *trackLayout) insertBefore(track, sibling *folderTrack) {
+	l.insertAt(track, sibling, 0)
+}
+
+// insertAfter puts track next to, and just after, sibling
+func (l *trackLayout) insertAfter(track, sibling *folderTrack) {
+	l.insertAt(track, sibling, 1)
+}
+
+func (l *trackLayout) insertAt(track, sibling *folderTrack, offset int) {
+	list := l.siblings(sibling)
+	track.parent = sibling.parent
+	for i, t := range *list {
+		if t == sibling {
+			pos := i + offset
+			*list = append((*list)[:pos], append([]*folderTrack{track}, (*list)[pos:]...)...)
+			return
+		}
+	}
+}
+
+// add appends child as the last track of the folder
+func (t *folderTrack) add(child *folderTrack) {
+	child.parent = t
+	t.children = append(t.children, child)
+}
+
+// outermostTracks drops tracks whose folder is also in tracks, since they move with it
+func outermostTracks(tracks []*folderTrack) []*folderTrack {
+	result := make([]*folderTrack, 0, len(tracks))
+	for _, track := range tracks {
+		nested := false
+		for ancestor := track.parent; ancestor != nil && !nested; ancestor = ancestor.parent {
+			for _, other := range tracks {
+				if other == ancestor {
+					nested = true
+					break
+				}
+			}
+		}
+		if !nested {
+			result = append(result, track)
+		}
+	}
+	return result
+}
+
+// indexOfTrack returns the position of track in order, or -1
+func indexOfTrack(order []*folderTrack, track *folderTrack) int {
+	for i, t := range order {
+		if t == track {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,127 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_Folders(t *testing.T) {
+	flatState := func() map[string]any {
+		return map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Kick", "color": "#ff0000"},
+				map[string]any{"index": 1, "name": "Bass", "color": "#0000ff"},
+				map[string]any{"index": 2, "name": "Snare", "color": "#ff0000"},
+				map[string]any{"index": 3, "name": "Hats", "color": "#ff0000"},
+			},
+		}
+	}
+	folderState := func() map[string]any {
+		return map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Drums", "folder_depth": 1},
+				map[string]any{"index": 1, "name": "Kick", "folder_depth": 0},
+				map[string]any{"index": 2, "name": "Snare", "folder_depth": -1},
+				map[string]any{"index": 3, "name": "Perc", "folder_depth": 0},
+				map[string]any{"index": 4, "name": "Bass", "folder_depth": 0},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		state   map[string]any
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "make folder from scattered tracks",
+			state:   flatState(),
+			dslCode: `filter(tracks, track.color == "#ff0000").make_folder(name="Drums")`,
+			want: []map[string]any{
+				{"action": "create_track", "index": 0, "name": "Drums"},
+				{"action": "move_track", "track": 3, "to": 2},
+				{"action": "move_track", "track": 4, "to": 3},
+				{"action": "set_track", "track": 0, "folder_depth": 1},
+				{"action": "set_track", "track": 3, "folder_depth": -1},
+			},
+		},
+		{
+			name:    "add the next track to a folder",
+			state:   folderState(),
+			dslCode: `track(id=4).add_to_folder(folder="Drums")`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 2, "folder_depth": 0},
+				{"action": "set_track", "track": 3, "folder_depth": -1},
+			},
+		},
+		{
+			name:    "add a track created in the script",
+			state:   folderState(),
+			dslCode: `track(name="Toms"); track(id=6).add_to_folder(folder=1)`,
+			want: []map[string]any{
+				{"action": "create_track", "name": "Toms", "index": 5},
+				{"action": "move_track", "track": 5, "to": 3},
+				{"action": "set_track", "track": 2, "folder_depth": 0},
+				{"action": "set_track", "track": 3, "folder_depth": -1},
+			},
+		},
+		{
+			name:    "move a track out of its folder",
+			state:   folderState(),
+			dslCode: `track(id=2).set_track_parent(parent=0)`,
+			want: []map[string]any{
+				{"action": "move_track", "track": 2, "to": 1},
+			},
+		},
+		{
+			name:    "nest a folder in another track by name",
+			state:   folderState(),
+			dslCode: `track(id=1).set_track_parent(parent_name="Bass")`,
+			want: []map[string]any{
+				{"action": "move_track", "track": 3, "to": 0},
+				{"action": "move_track", "track": 4, "to": 1},
+				{"action": "set_track", "track": 1, "folder_depth": 1},
+				{"action": "set_track", "track": 4, "folder_depth": -2},
+			},
+		},
+		{
+			name:    "cannot move a folder into its child",
+			state:   folderState(),
+			dslCode: `track(id=1).set_track_parent(parent=2)`,
+			wantErr: `cannot move track "Drums" into itself`,
+		},
+		{
+			name:    "unknown folder",
+			state:   folderState(),
+			dslCode: `track(id=5).add_to_folder(folder="Synths")`,
+			wantErr: `no track named "Synths"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(tt.state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
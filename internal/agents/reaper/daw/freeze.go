@@ -0,0 +1,45 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// FreezeTrack handles .freeze_track() calls: renders the current (or filtered) tracks' FX to
+// audio and unloads the plugins to save CPU.
+// Example: filter(tracks, track.fx_count > 3).freeze_track()
+func (r *ReaperDSL) FreezeTrack(args gs.Args) error {
+	return r.parser.emitTrackAction("freeze_track", args)
+}
+
+// UnfreezeTrack handles .unfreeze_track() calls: restores frozen tracks' plugins and clips.
+// Example: track(id=2).unfreeze_track()
+func (r *ReaperDSL) UnfreezeTrack(args gs.Args) error {
+	return r.parser.emitTrackAction("unfreeze_track", args)
+}
+
+// BounceInPlace handles .bounce_in_place() calls: renders each track through its FX to a new
+// audio clip that replaces the original clips. Unlike freezing, it can't be undone with
+// unfreeze_track.
+// Example: filter(tracks, track.name contains "Synth").bounce_in_place()
+func (r *ReaperDSL) BounceInPlace(args gs.Args) error {
+	return r.parser.emitTrackAction("bounce_in_place", args)
+}
+
+// emitTrackAction emits an argument-less action for each target track
+func (p *FunctionalDSLParser) emitTrackAction(actionType string, args gs.Args) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%s() takes no arguments", actionType)
+	}
+	tracks, err := p.trackTargets(actionType)
+	if err != nil {
+		return err
+	}
+	for _, track := range tracks {
+		p.actions = append(p.actions, map[string]any{"action": actionType, "track": track})
+	}
+	logger.Printf(p.ctx, "✅ %s: tracks %v", capitalizeMethodName(actionType), tracks)
+	return nil
+}
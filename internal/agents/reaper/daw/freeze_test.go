@@ -0,0 +1,92 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_Freeze(t *testing.T) {
+	fx := func(n int) []any {
+		chain := make([]any, n)
+		for i := range chain {
+			chain[i] = map[string]any{"name": "ReaEQ"}
+		}
+		return chain
+	}
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "fx": fx(2)},
+			map[string]any{"index": 1, "name": "Synth Lead", "fx": fx(4)},
+			map[string]any{"index": 2, "name": "Synth Pad", "fx": fx(5)},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "freeze filtered by fx count",
+			dslCode: `filter(tracks, track.fx_count > 3).freeze_track()`,
+			want: []map[string]any{
+				{"action": "freeze_track", "track": 1},
+				{"action": "freeze_track", "track": 2},
+			},
+		},
+		{
+			name:    "unfreeze one track",
+			dslCode: `track(id=2).unfreeze_track()`,
+			want:    []map[string]any{{"action": "unfreeze_track", "track": 1}},
+		},
+		{
+			name:    "bounce filtered by name",
+			dslCode: `filter(tracks, track.name contains "synth").bounce_in_place()`,
+			want: []map[string]any{
+				{"action": "bounce_in_place", "track": 1},
+				{"action": "bounce_in_place", "track": 2},
+			},
+		},
+		{name: "no track context", dslCode: `freeze_track()`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildUndoActions_Freeze(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "freeze_track", "track": 1},
+		{"action": "unfreeze_track", "track": 2},
+		{"action": "bounce_in_place", "track": 0},
+	}
+	want := []map[string]any{
+		{"action": "freeze_track", "track": 2},
+		{"action": "unfreeze_track", "track": 1},
+	}
+	if got := BuildUndoActions(actions, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildUndoActions() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,329 @@
+package daw
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// FX chain actions identify a plugin by "fx" (0-based position in the track's chain) when the
+// chain is known from state, and by "fxname" otherwise so the extension can resolve it.
+
+// BypassFx handles .bypass_fx() calls: bypasses matching FX on the current track, on filtered
+// tracks, or the filtered FX themselves.
+// Example: filter(fx_chain, fx.name == "ReaVerb").bypass_fx()
+func (r *ReaperDSL) BypassFx(args gs.Args) error {
+	return r.parser.fxChainActions("bypass_fx", args)
+}
+
+// EnableFx handles .enable_fx() calls: re-enables bypassed FX.
+// Example: track(id=1).enable_fx(fx="ReaComp")
+func (r *ReaperDSL) EnableFx(args gs.Args) error {
+	return r.parser.fxChainActions("enable_fx", args)
+}
+
+// RemoveFx handles .remove_fx() calls: removes FX from the chain.
+// Example: track(id=2).remove_fx(fx=1)
+func (r *ReaperDSL) RemoveFx(args gs.Args) error {
+	return r.parser.fxChainActions("remove_fx", args)
+}
+
+// MoveFx handles .move_fx() calls: moves an FX to a 1-based position (to) or before/after
+// another FX in the same chain.
+// Example: track(id=1).move_fx(fx="ReaComp", before="ReaEQ")
+func (r *ReaperDSL) MoveFx(args gs.Args) error {
+	return r.parser.fxChainActions("move_fx", args)
+}
+
+// fxRef is one plugin an FX chain action applies to
+type fxRef struct {
+	track int
+	index int // 0-based position in the chain, -1 when the chain isn't known
+	name  string
+	item  map[string]any // The FX from state, nil when the chain isn't known
+}
+
+// fxChainActions emits one action per plugin matched by the iteration context, the filtered
+// collection (FX or tracks), or the fx argument on the current track
+func (p *FunctionalDSLParser) fxChainActions(actionType string, args gs.Args) error {
+	fxArg, hasFxArg := args["fx"]
+	var refs []fxRef
+
+	if item, ok := p.iterationContext["fx"].(map[string]any); ok {
+		// for_each(fx_chain, fx.bypass_fx())
+		ref, ok := fxRefFromItem(item)
+		if !ok {
+			return fmt.Errorf("%s: could not extract track and position from %+v", actionType, item)
+		}
+		refs = append(refs, ref)
+	} else if chain := p.takeChain(); chain != nil && len(chain.items) > 0 {
+		filtered := chain.items
+		fromFxChain := chain.from == "fx_chain"
+		for _, item := range filtered {
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			if fromFxChain {
+				if ref, ok := fxRefFromItem(itemMap); ok {
+					refs = append(refs, ref)
+				} else {
+					logger.Printf(p.ctx, "⚠️  %s: Could not extract track and position from %+v", actionType, itemMap)
+				}
+				continue
+			}
+			if !hasFxArg {
+				return fmt.Errorf("%s on filtered tracks requires fx (name or 1-based position)", actionType)
+			}
+			trackIndex, ok := actionInt(itemMap, "index")
+			if !ok {
+				logger.Printf(p.ctx, "⚠️  %s: Could not extract track index from %+v", actionType, itemMap)
+				continue
+			}
+			matched, err := p.matchFx(trackIndex, fxArg)
+			if err != nil {
+				logger.Printf(p.ctx, "⚠️  %s: Skipping track %d: %v", actionType, trackIndex, err)
+				continue
+			}
+			refs = append(refs, matched...)
+		}
+		if fromFxChain && actionType != "move_fx" && actionType != "remove_fx" {
+			p.recordFxSummary(actionType, refs)
+		}
+		logger.Printf(p.ctx, "✅ %s: Matched %d FX in %d filtered items", actionType, len(refs), len(filtered))
+	} else {
+		if p.currentTrackIndex < 0 {
+			return fmt.Errorf("no track context for %s call", actionType)
+		}
+		if !hasFxArg {
+			return fmt.Errorf("%s requires fx (name or 1-based position)", actionType)
+		}
+		matched, err := p.matchFx(p.currentTrackIndex, fxArg)
+		if err != nil {
+			return fmt.Errorf("%s: %w", actionType, err)
+		}
+		if actionType == "move_fx" && len(matched) > 1 {
+			return fmt.Errorf("move_fx: %d FX match %s on track %d, use a 1-based position instead", len(matched), fxArg.Str, p.currentTrackIndex+1)
+		}
+		refs = matched
+	}
+
+	if actionType == "remove_fx" {
+		// Remove from the end of each chain so earlier removals don't shift later positions
+		sort.SliceStable(refs, func(i, j int) bool {
+			if refs[i].track != refs[j].track {
+				return refs[i].track < refs[j].track
+			}
+			return refs[i].index > refs[j].index
+		})
+	}
+
+	for _, ref := range refs {
+		action := map[string]any{"action": actionType, "track": ref.track}
+		if ref.index >= 0 {
+			action["fx"] = ref.index
+		}
+		if ref.name != "" {
+			action["fxname"] = ref.name
+		}
+		if actionType == "move_fx" {
+			to, err := p.fxMoveTarget(ref, args)
+			if err != nil {
+				return err
+			}
+			action["to"] = to
+		}
+		p.actions = append(p.actions, action)
+	}
+	return nil
+}
+
+// matchFx finds the FX on a track selected by fxArg: a name (case-insensitive substring, so
+// "Serum" matches "VSTi: Serum (Xfer Records)") or a 1-based position. Without the track's
+// chain in state, the name or position is passed through unresolved.
+func (p *FunctionalDSLParser) matchFx(trackIndex int, fxArg gs.Value) ([]fxRef, error) {
+	chain, known := p.trackFxChain(trackIndex)
+
+	switch fxArg.Kind {
+	case gs.ValueNumber:
+		position := int(fxArg.Num)
+		if float64(position) != fxArg.Num || position < 1 {
+			return nil, fmt.Errorf("fx position must start at 1, got %g", fxArg.Num)
+		}
+		if !known {
+			return []fxRef{{track: trackIndex, index: position - 1}}, nil
+		}
+		for _, ref := range chain {
+			if ref.index == position-1 {
+				return []fxRef{ref}, nil
+			}
+		}
+		return nil, fmt.Errorf("track %d has no FX at position %d", trackIndex+1, position)
+
+	case gs.ValueString:
+		want := strings.ToLower(strings.TrimSpace(fxArg.Str))
+		if want == "" {
+			return nil, fmt.Errorf("fx name is empty")
+		}
+		if !known {
+			return []fxRef{{track: trackIndex, index: -1, name: fxArg.Str}}, nil
+		}
+		var matched []fxRef
+		for _, ref := range chain {
+			if strings.Contains(strings.ToLower(ref.name), want) {
+				matched = append(matched, ref)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no FX matching %q on track %d", fxArg.Str, trackIndex+1)
+		}
+		return matched, nil
+	}
+
+	return nil, fmt.Errorf("fx must be a name or a 1-based position")
+}
+
+// fxMoveTarget returns the 0-based position ref moves to, from to (1-based) or before/after
+// another FX on the same track
+func (p *FunctionalDSLParser) fxMoveTarget(ref fxRef, args gs.Args) (int, error) {
+	if toValue, ok := args["to"]; ok && toValue.Kind == gs.ValueNumber {
+		to := int(toValue.Num)
+		if float64(to) != toValue.Num || to < 1 {
+			return 0, fmt.Errorf("move_fx to must be a position starting at 1, got %g", toValue.Num)
+		}
+		return to - 1, nil
+	}
+
+	for _, key := range []string{"before", "after"} {
+		refValue, ok := args[key]
+		if !ok || refValue.Kind != gs.ValueString {
+			continue
+		}
+		if ref.index < 0 {
+			return 0, fmt.Errorf("move_fx %s needs track %d's FX chain in state", key, ref.track+1)
+		}
+		targets, err := p.matchFx(ref.track, refValue)
+		if err != nil {
+			return 0, fmt.Errorf("move_fx: %w", err)
+		}
+		target := targets[0].index
+		if target == ref.index {
+			return 0, fmt.Errorf("move_fx: cannot move %q %s itself", ref.name, key)
+		}
+		// Positions are after the moved FX is taken out of the chain
+		if key == "before" {
+			if ref.index < target {
+				return target - 1, nil
+			}
+			return target, nil
+		}
+		if ref.index < target {
+			return target, nil
+		}
+		return target + 1, nil
+	}
+
+	return 0, fmt.Errorf("move_fx requires to (1-based position), before, or after")
+}
+
+// trackFxChain returns the FX on a track from state, or false when the track or its chain
+// isn't in state
+func (p *FunctionalDSLParser) trackFxChain(trackIndex int) ([]fxRef, bool) {
+	tracks, _ := p.data["tracks"].([]any)
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index, ok := actionInt(trackMap, "index")
+		if !ok {
+			index = i
+		}
+		if index != trackIndex {
+			continue
+		}
+		rawChain, ok := trackMap["fx"].([]any)
+		if !ok {
+			return nil, false
+		}
+		chain := make([]fxRef, 0, len(rawChain))
+		for j, rawFx := range rawChain {
+			fxMap, ok := rawFx.(map[string]any)
+			if !ok {
+				continue
+			}
+			position, ok := actionInt(fxMap, "index")
+			if !ok {
+				position = j
+			}
+			name, _ := fxMap["name"].(string)
+			chain = append(chain, fxRef{track: trackIndex, index: position, name: name, item: fxMap})
+		}
+		return chain, true
+	}
+	return nil, false
+}
+
+// recordFxSummary records how many filtered FX were already bypassed or enabled
+func (p *FunctionalDSLParser) recordFxSummary(actionType string, refs []fxRef) {
+	props := map[string]any{"enabled": actionType == "enable_fx"}
+	alreadySet := 0
+	for _, ref := range refs {
+		if ref.item != nil && propertiesAlreadySet(ref.item, props) {
+			alreadySet++
+		}
+	}
+	p.recordFilterSummary(actionType, nil, len(refs), alreadySet)
+}
+
+// fxRefFromItem reads the track and position flattenFxChains added to an FX
+func fxRefFromItem(item map[string]any) (fxRef, bool) {
+	trackIndex, ok := actionInt(item, "track")
+	if !ok {
+		return fxRef{}, false
+	}
+	index, ok := actionInt(item, "index")
+	if !ok {
+		return fxRef{}, false
+	}
+	name, _ := item["name"].(string)
+	return fxRef{track: trackIndex, index: index, name: name, item: item}, true
+}
+
+// flattenFxChains collects the FX of all tracks into one collection. Like clips, each FX gets
+// a reference to its track, plus its position in the chain when state doesn't provide one. The
+// FX are copies: state may be shared with the parsers of concurrent requests.
+func flattenFxChains(tracks []any) []any {
+	allFx := make([]any, 0)
+	for i, trackInterface := range tracks {
+		track, ok := trackInterface.(map[string]any)
+		if !ok {
+			continue
+		}
+		fxChain, ok := track["fx"].([]any)
+		if !ok {
+			continue
+		}
+		trackIndex, ok := actionInt(track, "index")
+		if !ok {
+			trackIndex = i
+		}
+		for j, fx := range fxChain {
+			fxMap, ok := fx.(map[string]any)
+			if !ok {
+				continue
+			}
+			fxMap = maps.Clone(fxMap)
+			fxMap["track"] = trackIndex
+			if _, ok := fxMap["index"]; !ok {
+				fxMap["index"] = j
+			}
+			allFx = append(allFx, fxMap)
+		}
+	}
+	return allFx
+}
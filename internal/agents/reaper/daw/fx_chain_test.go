@@ -0,0 +1,168 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_FxChain(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Vocals", "fx": []any{
+					map[string]any{"name": "ReaEQ", "enabled": true},
+					map[string]any{"name": "ReaComp", "enabled": true},
+					map[string]any{"name": "ReaVerbate", "enabled": true},
+				}},
+				map[string]any{"index": 1, "name": "Drums", "fx": []any{
+					map[string]any{"name": "ReaGate", "enabled": true},
+					map[string]any{"name": "ReaVerbate", "enabled": false},
+				}},
+				map[string]any{"index": 2, "name": "Bass"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "bypass all reverbs via fx_chain",
+			dslCode: `filter(fx_chain, fx.name == "ReaVerbate").bypass_fx()`,
+			want: []map[string]any{
+				{"action": "bypass_fx", "track": 0, "fx": 2, "fxname": "ReaVerbate"},
+				{"action": "bypass_fx", "track": 1, "fx": 1, "fxname": "ReaVerbate"},
+			},
+		},
+		{
+			name:    "enable by name on one track",
+			dslCode: `track(id=2).enable_fx(fx="verb")`,
+			want: []map[string]any{
+				{"action": "enable_fx", "track": 1, "fx": 1, "fxname": "ReaVerbate"},
+			},
+		},
+		{
+			name:    "filtered tracks match fx by name",
+			dslCode: `filter(tracks, track.index < 2).bypass_fx(fx="ReaVerbate")`,
+			want: []map[string]any{
+				{"action": "bypass_fx", "track": 0, "fx": 2, "fxname": "ReaVerbate"},
+				{"action": "bypass_fx", "track": 1, "fx": 1, "fxname": "ReaVerbate"},
+			},
+		},
+		{
+			name:    "remove by position",
+			dslCode: `track(id=1).remove_fx(fx=2)`,
+			want: []map[string]any{
+				{"action": "remove_fx", "track": 0, "fx": 1, "fxname": "ReaComp"},
+			},
+		},
+		{
+			name:    "remove filtered fx from the end of each chain",
+			dslCode: `filter(fx_chain, fx.enabled == true).remove_fx()`,
+			want: []map[string]any{
+				{"action": "remove_fx", "track": 0, "fx": 2, "fxname": "ReaVerbate"},
+				{"action": "remove_fx", "track": 0, "fx": 1, "fxname": "ReaComp"},
+				{"action": "remove_fx", "track": 0, "fx": 0, "fxname": "ReaEQ"},
+				{"action": "remove_fx", "track": 1, "fx": 0, "fxname": "ReaGate"},
+			},
+		},
+		{
+			name:    "move compressor before the EQ",
+			dslCode: `track(id=1).move_fx(fx="ReaComp", before="ReaEQ")`,
+			want: []map[string]any{
+				{"action": "move_fx", "track": 0, "fx": 1, "fxname": "ReaComp", "to": 0},
+			},
+		},
+		{
+			name:    "move EQ after the compressor",
+			dslCode: `track(id=1).move_fx(fx="ReaEQ", after="ReaComp")`,
+			want: []map[string]any{
+				{"action": "move_fx", "track": 0, "fx": 0, "fxname": "ReaEQ", "to": 1},
+			},
+		},
+		{
+			name:    "move to a position",
+			dslCode: `track(id=1).move_fx(fx=3, to=1)`,
+			want: []map[string]any{
+				{"action": "move_fx", "track": 0, "fx": 2, "fxname": "ReaVerbate", "to": 0},
+			},
+		},
+		{
+			name:    "unknown chain passes the name through",
+			dslCode: `track(id=3).bypass_fx(fx="ReaComp")`,
+			want: []map[string]any{
+				{"action": "bypass_fx", "track": 2, "fxname": "ReaComp"},
+			},
+		},
+		{
+			name:    "no matching fx on a known chain",
+			dslCode: `track(id=1).bypass_fx(fx="Serum")`,
+			wantErr: `no FX matching "Serum" on track 1`,
+		},
+		{
+			name:    "move needs a target",
+			dslCode: `track(id=1).move_fx(fx="ReaComp")`,
+			wantErr: "move_fx requires to",
+		},
+		{
+			name:    "move before or after needs the chain",
+			dslCode: `track(id=3).move_fx(fx="ReaComp", before="ReaEQ")`,
+			wantErr: "needs track 3's FX chain in state",
+		},
+		{
+			name:    "track context needs fx",
+			dslCode: `track(id=1).bypass_fx()`,
+			wantErr: "bypass_fx requires fx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_FxChainNoOpSummary(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "fx": []any{map[string]any{"name": "ReaVerbate", "enabled": false}}},
+			map[string]any{"index": 1, "fx": []any{map[string]any{"name": "ReaVerbate", "enabled": true}}},
+		},
+	})
+	parser.SetReportNoOps(true)
+
+	if _, err := parser.ParseDSL(`filter(fx_chain, fx.name == "ReaVerbate").bypass_fx()`); err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	summaries := parser.FilterSummaries()
+	if len(summaries) != 1 || summaries[0].Matched != 2 || summaries[0].AlreadySet != 1 {
+		t.Errorf("FilterSummaries() = %+v, want 2 matched, 1 already set", summaries)
+	}
+}
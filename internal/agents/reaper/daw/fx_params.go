@@ -0,0 +1,95 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// SetFxParam handles .set_fx_param() calls: sets a parameter of an FX or instrument on the
+// current (or filtered) tracks. value is normalized (0.0-1.0) like FX automation values.
+// Example: track(id=1).set_fx_param(fx="ReaEQ", param="Freq-Band 1", value=0.35)
+// Note: Method name must be SetFxParam (not SetFXParam) for grammar-school camelCase conversion
+func (r *ReaperDSL) SetFxParam(args gs.Args) error {
+	p := r.parser
+
+	fxValue, ok := args["fx"]
+	if !ok || fxValue.Kind != gs.ValueString || strings.TrimSpace(fxValue.Str) == "" {
+		return fmt.Errorf("set_fx_param requires fx (string)")
+	}
+	paramValue, ok := args["param"]
+	if !ok || paramValue.Kind != gs.ValueString || strings.TrimSpace(paramValue.Str) == "" {
+		return fmt.Errorf("set_fx_param requires param (string)")
+	}
+	valueArg, ok := args["value"]
+	if !ok || valueArg.Kind != gs.ValueNumber {
+		return fmt.Errorf("set_fx_param requires value (number)")
+	}
+	if valueArg.Num < 0 || valueArg.Num > 1 {
+		return fmt.Errorf("set_fx_param value must be between 0.0 and 1.0, got %g", valueArg.Num)
+	}
+
+	newAction := func(trackIndex int) map[string]any {
+		return map[string]any{
+			"action": "set_fx_param",
+			"track":  trackIndex,
+			"fxname": fxValue.Str,
+			"param":  paramValue.Str,
+			"value":  valueArg.Num,
+		}
+	}
+
+	// Check if we have a filtered collection to apply to
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		applied := 0
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			trackIndex, ok := actionInt(trackMap, "index")
+			if !ok {
+				logger.Printf(r.parser.ctx, "⚠️  SetFxParam: Could not extract track index from %+v", trackMap)
+				continue
+			}
+			if !trackHasFx(trackMap, fxValue.Str) {
+				logger.Printf(r.parser.ctx, "⚠️  SetFxParam: Skipping track %d, no FX matching %q", trackIndex, fxValue.Str)
+				continue
+			}
+			p.actions = append(p.actions, newAction(trackIndex))
+			applied++
+		}
+		logger.Printf(r.parser.ctx, "✅ SetFxParam: Applied to %d of %d filtered tracks (fx=%s, param=%s)", applied, len(filtered), fxValue.Str, paramValue.Str)
+		return nil
+	}
+
+	if p.currentTrackIndex < 0 {
+		return fmt.Errorf("no track context for set_fx_param call")
+	}
+	p.actions = append(p.actions, newAction(p.currentTrackIndex))
+	return nil
+}
+
+// trackHasFx reports whether a track's FX chain has a plugin whose name contains fxName
+// (case-insensitive, so "Serum" matches "VSTi: Serum (Xfer Records)"). Tracks without FX
+// information in state are assumed to have it.
+func trackHasFx(trackMap map[string]any, fxName string) bool {
+	fxChain, ok := trackMap["fx"].([]any)
+	if !ok {
+		return true
+	}
+	want := strings.ToLower(strings.TrimSpace(fxName))
+	for _, item := range fxChain {
+		fxMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		name, _ := fxMap["name"].(string)
+		if strings.Contains(strings.ToLower(name), want) {
+			return true
+		}
+	}
+	return false
+}
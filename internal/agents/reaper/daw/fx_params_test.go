@@ -0,0 +1,92 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_SetFxParam(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Lead", "fx": []any{
+					map[string]any{"name": "VSTi: Serum (Xfer Records)"},
+					map[string]any{"name": "ReaEQ"},
+				}},
+				map[string]any{"index": 1, "name": "Pad", "fx": []any{
+					map[string]any{"name": "VSTi: Serum (Xfer Records)"},
+				}},
+				map[string]any{"index": 2, "name": "Drums", "fx": []any{
+					map[string]any{"name": "ReaComp"},
+				}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "set param on track by id",
+			dslCode: `track(id=1).set_fx_param(fx="ReaEQ", param="Freq-Band 1", value=0.35)`,
+			want: []map[string]any{
+				{"action": "set_fx_param", "track": 0, "fxname": "ReaEQ", "param": "Freq-Band 1", "value": 0.35},
+			},
+		},
+		{
+			name:    "filtered tracks skip those without the plugin",
+			dslCode: `filter(tracks, track.index >= 0).set_fx_param(fx="serum", param="Cutoff", value=0.2)`,
+			want: []map[string]any{
+				{"action": "set_fx_param", "track": 0, "fxname": "serum", "param": "Cutoff", "value": 0.2},
+				{"action": "set_fx_param", "track": 1, "fxname": "serum", "param": "Cutoff", "value": 0.2},
+			},
+		},
+		{
+			name:    "for_each applies to every iterated track",
+			dslCode: `for_each(tracks, track.set_fx_param(fx="ReaComp", param="Threshold", value=0.5))`,
+			want: []map[string]any{
+				{"action": "set_fx_param", "track": 0, "fxname": "ReaComp", "param": "Threshold", "value": 0.5},
+				{"action": "set_fx_param", "track": 1, "fxname": "ReaComp", "param": "Threshold", "value": 0.5},
+				{"action": "set_fx_param", "track": 2, "fxname": "ReaComp", "param": "Threshold", "value": 0.5},
+			},
+		},
+		{
+			name:    "value out of range",
+			dslCode: `track(id=1).set_fx_param(fx="ReaEQ", param="Gain-Band 1", value=3)`,
+			wantErr: "value must be between 0.0 and 1.0",
+		},
+		{
+			name:    "missing param",
+			dslCode: `track(id=1).set_fx_param(fx="ReaEQ", value=0.5)`,
+			wantErr: "set_fx_param requires param",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,22 @@
+package daw
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/golden"
+)
+
+// TestFunctionalDSLParser_Golden runs testdata/golden through the parser.
+// go test ./internal/agents/reaper/daw -run Golden -update rewrites the expected actions.
+func TestFunctionalDSLParser_Golden(t *testing.T) {
+	golden.Run(t, "testdata/golden", func(dsl string, state map[string]any) ([]map[string]any, error) {
+		parser, err := NewFunctionalDSLParser()
+		if err != nil {
+			return nil, err
+		}
+		if state != nil {
+			parser.SetState(state)
+		}
+		return parser.ParseDSL(dsl)
+	})
+}
@@ -0,0 +1,131 @@
+package daw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// GrammarVersion identifies a revision of the MAGDA DSL grammar. Clients send the version
+// they understand (GrammarVersionHeader) so the DSL can grow without breaking older REAPER
+// extension builds; the LLM is constrained to that version's grammar.
+type GrammarVersion string
+
+const (
+	// GrammarV1 is track and master statements with their chains and the functional calls
+	// (filter, map, for_each)
+	GrammarV1 GrammarVersion = "v1"
+	// GrammarV2 adds project statements (markers and regions, transport, render), queries and
+	// clarify()
+	GrammarV2 GrammarVersion = "v2"
+
+	// CurrentGrammarVersion is used when the client doesn't ask for a version
+	CurrentGrammarVersion = GrammarV2
+
+	// GrammarVersionHeader is the request header a client names its grammar version in; the
+	// response carries the version used in the same header
+	GrammarVersionHeader = "X-MAGDA-DSL-Version"
+
+	// WarningUnsupportedAction is the warning code for an action left out because the
+	// client's grammar version doesn't have it
+	WarningUnsupportedAction = "unsupported_action"
+)
+
+// SupportedGrammarVersions lists the versions this server can generate, oldest first
+var SupportedGrammarVersions = []GrammarVersion{GrammarV1, GrammarV2}
+
+// grammarV1Start replaces the current start rule: statements only
+const grammarV1Start = `start: statement (";"? statement)*`
+
+// grammarV2Actions are the action types a v1 client can't execute
+var grammarV2Actions = map[string]bool{
+	"add_marker": true, "add_region": true, "delete_marker": true, "rename_region": true,
+	"play": true, "stop": true, "record": true, "set_play_position": true,
+	"render_project": true,
+}
+
+type grammarVersionKey struct{}
+
+// ParseGrammarVersion validates a requested grammar version ("v1", "V1" or "1"). An empty
+// string means CurrentGrammarVersion.
+func ParseGrammarVersion(version string) (GrammarVersion, error) {
+	version = strings.ToLower(strings.TrimSpace(version))
+	if version == "" {
+		return CurrentGrammarVersion, nil
+	}
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	for _, supported := range SupportedGrammarVersions {
+		if GrammarVersion(version) == supported {
+			return supported, nil
+		}
+	}
+	names := make([]string, len(SupportedGrammarVersions))
+	for i, supported := range SupportedGrammarVersions {
+		names[i] = string(supported)
+	}
+	return "", fmt.Errorf("unsupported DSL grammar version %q: must be one of %s", version, strings.Join(names, ", "))
+}
+
+// WithGrammarVersion returns a context carrying the request's grammar version.
+func WithGrammarVersion(ctx context.Context, version GrammarVersion) context.Context {
+	return context.WithValue(ctx, grammarVersionKey{}, version)
+}
+
+// GrammarVersionFromContext returns the request's grammar version, defaulting to the current one.
+func GrammarVersionFromContext(ctx context.Context) GrammarVersion {
+	if version, ok := ctx.Value(grammarVersionKey{}).(GrammarVersion); ok && version != "" {
+		return version
+	}
+	return CurrentGrammarVersion
+}
+
+// GetMagdaDSLGrammarForVersion returns the grammar the LLM is constrained to for version.
+// Rules a version doesn't reach are left in place; the start rule decides what is allowed.
+func GetMagdaDSLGrammarForVersion(version GrammarVersion) string {
+	grammar := GetMagdaDSLGrammarForFunctional()
+	if version == GrammarV1 {
+		lines := strings.Split(grammar, "\n")
+		for i, line := range lines {
+			if strings.HasPrefix(line, "start:") {
+				// Drop the start rule's continuation lines along with it
+				end := i + 1
+				for end < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[end]), "|") {
+					end++
+				}
+				lines = append(lines[:i], append([]string{grammarV1Start}, lines[end:]...)...)
+				break
+			}
+		}
+		grammar = strings.Join(lines, "\n")
+	}
+	return "// DSL grammar version: " + string(version) + "\n" + grammar
+}
+
+// SupportsAction reports whether a client on version can execute action
+func SupportsAction(action map[string]any, version GrammarVersion) bool {
+	name, _ := action["action"].(string)
+	return version != GrammarV1 || !grammarV2Actions[name]
+}
+
+// ActionsForVersion leaves out the actions a client on version can't execute (arranger
+// sections add regions whatever the grammar), with a warning for each
+func ActionsForVersion(actions []map[string]any, version GrammarVersion) ([]map[string]any, []models.ActionWarning) {
+	var warnings []models.ActionWarning
+	kept := make([]map[string]any, 0, len(actions))
+	for i, action := range actions {
+		if SupportsAction(action, version) {
+			kept = append(kept, action)
+			continue
+		}
+		warnings = append(warnings, models.ActionWarning{
+			Code:        WarningUnsupportedAction,
+			Message:     fmt.Sprintf("action %d (%s) was left out: DSL grammar %s doesn't support it", i, action["action"], version),
+			ActionIndex: i,
+		})
+	}
+	return kept, warnings
+}
@@ -0,0 +1,102 @@
+package daw
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+)
+
+func TestParseGrammarVersion(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    GrammarVersion
+		wantErr bool
+	}{
+		{input: "", want: CurrentGrammarVersion},
+		{input: "v1", want: GrammarV1},
+		{input: "V2", want: GrammarV2},
+		{input: " 1 ", want: GrammarV1},
+		{input: "v3", wantErr: true},
+		{input: "latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseGrammarVersion(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGrammarVersion(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGrammarVersion(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGrammarVersionFromContext(t *testing.T) {
+	if got := GrammarVersionFromContext(context.Background()); got != CurrentGrammarVersion {
+		t.Errorf("default grammar version = %q, want %q", got, CurrentGrammarVersion)
+	}
+	ctx := WithGrammarVersion(context.Background(), GrammarV1)
+	if got := GrammarVersionFromContext(ctx); got != GrammarV1 {
+		t.Errorf("grammar version = %q, want %q", got, GrammarV1)
+	}
+}
+
+func TestGetMagdaDSLGrammarForVersion(t *testing.T) {
+	for _, version := range SupportedGrammarVersions {
+		grammar := GetMagdaDSLGrammarForVersion(version)
+		if err := llm.ValidateGrammar("MAGDA DSL "+string(version), grammar); err != nil {
+			t.Errorf("%s grammar is invalid: %v", version, err)
+		}
+		if !strings.HasPrefix(grammar, "// DSL grammar version: "+string(version)+"\n") {
+			t.Errorf("%s grammar doesn't name its version", version)
+		}
+	}
+
+	v1 := GetMagdaDSLGrammarForVersion(GrammarV1)
+	if !strings.Contains(v1, "\n"+grammarV1Start+"\n") {
+		t.Errorf("v1 grammar doesn't use the v1 start rule")
+	}
+	for _, call := range []string{"project_call", "query_call", "clarify_call"} {
+		start := v1[strings.Index(v1, "\nstart:"):]
+		start = start[:strings.Index(start[1:], "\n")+1]
+		if strings.Contains(start, call) {
+			t.Errorf("v1 start rule allows %s: %s", call, start)
+		}
+	}
+
+	v2 := GetMagdaDSLGrammarForVersion(GrammarV2)
+	if !strings.HasSuffix(v2, GetMagdaDSLGrammarForFunctional()) {
+		t.Errorf("v2 grammar differs from the current grammar")
+	}
+}
+
+func TestActionsForVersion(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "add_region", "start_bar": 1, "end_bar": 9},
+		{"action": "set_track", "track": 0, "mute": true},
+		{"action": "play"},
+	}
+
+	kept, warnings := ActionsForVersion(actions, GrammarV2)
+	if len(kept) != len(actions) || len(warnings) != 0 {
+		t.Errorf("v2 kept %d actions with %d warnings, want all and none", len(kept), len(warnings))
+	}
+
+	kept, warnings = ActionsForVersion(actions, GrammarV1)
+	if len(kept) != 2 || kept[0]["action"] != "create_track" || kept[1]["action"] != "set_track" {
+		t.Errorf("v1 kept %v, want create_track and set_track", kept)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("v1 warnings = %v, want 2", warnings)
+	}
+	for i, wantIndex := range []int{1, 3} {
+		if warnings[i].Code != WarningUnsupportedAction || warnings[i].ActionIndex != wantIndex {
+			t.Errorf("warning %d = %+v, want %s at action %d", i, warnings[i], WarningUnsupportedAction, wantIndex)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package daw
+
+import "strings"
+
+// Tracks and clips in state may carry a REAPER GUID ("guid"). Indices shift when the project
+// changes between state capture and execution; GUIDs don't, so planned actions carry the GUID
+// of the track (track_guid), send destination (dest_guid) and clip (clip_guid) they target
+// when state has one. Clients resolve the GUID first and fall back to the index.
+
+// trackIndexByGUID returns the index of the state track with guid (case-insensitive)
+func (p *FunctionalDSLParser) trackIndexByGUID(guid string) (int, bool) {
+	tracks, _ := stateTracks(p.state)
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		trackGUID, _ := trackMap["guid"].(string)
+		if trackGUID == "" || !strings.EqualFold(trackGUID, guid) {
+			continue
+		}
+		if index, ok := actionInt(trackMap, "index"); ok {
+			return index, true
+		}
+		return i, true
+	}
+	return 0, false
+}
+
+// clipGUID returns the GUID of the state clip an action targets, by clip index or position.
+// Clips created by the action itself have no GUID yet.
+func clipGUID(track map[string]any, action map[string]any) string {
+	switch action["action"] {
+	case "create_clip", "create_clip_at_bar":
+		return ""
+	}
+	guid, _ := stateClip(track, action)["guid"].(string)
+	return guid
+}
+
+// stateClip returns the clip on a state track that an action targets by clip index or
+// position (old_position for set_clip_position), or nil when there's no such clip
+func stateClip(track map[string]any, action map[string]any) map[string]any {
+	positionKey := "position"
+	if action["action"] == "set_clip_position" {
+		positionKey = "old_position"
+	}
+	clipIndex, hasIndex := actionInt(action, "clip")
+	position, hasPosition := action[positionKey].(float64)
+	if !hasIndex && !hasPosition {
+		return nil
+	}
+
+	clips, _ := track["clips"].([]any)
+
+	for i, item := range clips {
+		clipMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		if hasIndex {
+			index, ok := actionInt(clipMap, "index")
+			if !ok {
+				index = i
+			}
+			if index == clipIndex {
+				return clipMap
+			}
+			continue
+		}
+		if clipPosition, ok := getNumericValue(clipMap["position"]); ok && clipPosition == position {
+			return clipMap
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func guidState() map[string]any {
+	return map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "guid": "{AAAA-0001}", "clips": []any{
+				map[string]any{"index": 0, "position": 0.0, "guid": "{CLIP-0001}"},
+				map[string]any{"index": 1, "position": 8.0, "guid": "{CLIP-0002}"},
+			}},
+			map[string]any{"index": 1, "name": "Bass", "guid": "{AAAA-0002}"},
+			map[string]any{"index": 2, "name": "Keys"},
+		},
+	}
+}
+
+func TestFunctionalDSLParser_TrackByGUID(t *testing.T) {
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "track by guid",
+			dslCode: `track(guid="{AAAA-0002}").set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 1, "mute": true},
+			},
+		},
+		{
+			name:    "guid match ignores case",
+			dslCode: `track(guid="{aaaa-0001}").delete()`,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0},
+			},
+		},
+		{
+			name:    "unknown guid",
+			dslCode: `track(guid="{FFFF-0000}").delete()`,
+			wantErr: `no track with guid "{FFFF-0000}"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(guidState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanActions_GUIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		actions []map[string]any
+		want    []map[string]any
+	}{
+		{
+			name: "track and send destination guids",
+			actions: []map[string]any{
+				{"action": "add_send", "track": 0, "dest": 1},
+				{"action": "set_track", "track": 2, "name": "Piano"},
+			},
+			want: []map[string]any{
+				{"action": "add_send", "track": 0, "track_guid": "{AAAA-0001}", "dest": 1, "dest_guid": "{AAAA-0002}"},
+				{"action": "set_track", "track": 2, "name": "Piano"},
+			},
+		},
+		{
+			name: "clip guid by index and position",
+			actions: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 1, "color": "red"},
+				{"action": "set_clip_position", "track": 0, "old_position": 0.0, "position": 16.0},
+				{"action": "create_clip", "track": 0, "position": 8.0, "length": 4.0},
+			},
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "track_guid": "{AAAA-0001}", "clip": 1, "clip_guid": "{CLIP-0002}", "color": "red"},
+				{"action": "set_clip_position", "track": 0, "track_guid": "{AAAA-0001}", "old_position": 0.0, "position": 16.0, "clip_guid": "{CLIP-0001}"},
+				{"action": "create_clip", "track": 0, "track_guid": "{AAAA-0001}", "position": 8.0, "length": 4.0},
+			},
+		},
+		{
+			name: "guid follows the track after a delete",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "set_track", "track": 1, "solo": true},
+			},
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0, "track_guid": "{AAAA-0001}"},
+				{"action": "set_track", "track": 0, "track_guid": "{AAAA-0002}", "solo": true},
+			},
+		},
+		{
+			name: "created tracks have no guid",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 3, "name": "Lead"},
+				{"action": "add_track_fx", "track": 3, "fxname": "Serum"},
+			},
+			want: []map[string]any{
+				{"action": "create_track", "index": 3, "name": "Lead"},
+				{"action": "add_track_fx", "track": 3, "fxname": "Serum"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, warnings := PlanActions(tt.actions, guidState())
+			if len(warnings) > 0 {
+				t.Errorf("unexpected warnings: %v", warnings)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("PlanActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
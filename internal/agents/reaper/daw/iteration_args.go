@@ -0,0 +1,117 @@
+package daw
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+)
+
+// Method arguments inside for_each may refer to the item being iterated:
+//
+//	for_each(tracks, track.set_track(name=track.name + " (old)"))
+//	for_each(clips, clip.set_clip(name="Take " + (clip.index + 1)))
+//	for_each(tracks, track.set_track(volume_db=track.volume_db - 3))
+//
+// A lone property keeps its type, arithmetic evaluates to a number, and "+" with a quoted
+// string on either side concatenates. Parenthesise arithmetic inside a concatenation.
+
+// isIterationValue reports whether an unquoted method argument refers to the iteration item
+func isIterationValue(s string) bool {
+	return isPropertyRef(s) || isArithmeticExpr(s) || len(splitTopLevel(s, "+")) > 1
+}
+
+// isStringLiteral reports whether s is exactly one quoted string
+func isStringLiteral(s string) bool {
+	return len(s) >= 2 && strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"") &&
+		!strings.Contains(s[1:len(s)-1], "\"")
+}
+
+// bindIterationValue evaluates a method argument like track.name + " (old)" against the
+// current iteration item
+func bindIterationValue(src string, vars exprVars) (gs.Value, error) {
+	src = strings.TrimSpace(src)
+	parts := splitTopLevel(src, "+")
+
+	concat := false
+	for _, part := range parts {
+		if strings.HasPrefix(strings.TrimSpace(part), "\"") {
+			concat = true
+			break
+		}
+	}
+
+	if !concat {
+		if isPropertyRef(src) {
+			value, err := lookupIterationProperty(src, vars)
+			if err != nil {
+				return gs.Value{}, err
+			}
+			return iterationGSValue(value), nil
+		}
+		num, err := evalExpr(src, vars)
+		if err != nil {
+			return gs.Value{}, err
+		}
+		return gs.Value{Kind: gs.ValueNumber, Num: num}, nil
+	}
+
+	var sb strings.Builder
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		switch {
+		case isStringLiteral(part):
+			sb.WriteString(part[1 : len(part)-1])
+		case isPropertyRef(part):
+			value, err := lookupIterationProperty(part, vars)
+			if err != nil {
+				return gs.Value{}, err
+			}
+			sb.WriteString(formatIterationValue(value))
+		default:
+			num, err := evalExpr(part, vars)
+			if err != nil {
+				return gs.Value{}, fmt.Errorf("in %q: %w", src, err)
+			}
+			sb.WriteString(formatQueryValue(num))
+		}
+	}
+	return gs.Value{Kind: gs.ValueString, Str: sb.String()}, nil
+}
+
+// lookupIterationProperty resolves a property reference like track.name, of any type
+func lookupIterationProperty(ref string, vars exprVars) (any, error) {
+	name, prop, _ := strings.Cut(ref, ".")
+	item, ok := vars[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown variable %q in %q", name, ref)
+	}
+	value, ok := itemProperty(item, prop)
+	if !ok {
+		return nil, fmt.Errorf("%s has no property %q", name, prop)
+	}
+	return value, nil
+}
+
+// iterationGSValue converts a state value to the argument value a method expects
+func iterationGSValue(value any) gs.Value {
+	if num, ok := getNumericValue(value); ok {
+		return gs.Value{Kind: gs.ValueNumber, Num: num}
+	}
+	if b, ok := value.(bool); ok {
+		return gs.Value{Kind: gs.ValueBool, Bool: b}
+	}
+	return gs.Value{Kind: gs.ValueString, Str: formatIterationValue(value)}
+}
+
+// formatIterationValue renders a state value for string concatenation
+func formatIterationValue(value any) string {
+	if num, ok := getNumericValue(value); ok {
+		return formatQueryValue(num)
+	}
+	if b, ok := value.(bool); ok {
+		return strconv.FormatBool(b)
+	}
+	return fmt.Sprintf("%v", value)
+}
@@ -0,0 +1,95 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_IterationArgs(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{
+				"index": 0, "name": "Drums", "volume_db": -2.0,
+				"clips": []any{
+					map[string]any{"index": 0, "name": "Beat", "position": 4.0, "length": 2.0},
+				},
+			},
+			map[string]any{"index": 1, "name": "Bass", "volume_db": 0.0},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+	}{
+		{
+			name:    "string concatenation",
+			dslCode: `for_each(tracks, track.set_track(name=track.name + " (old)"))`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "name": "Drums (old)"},
+				{"action": "set_track", "track": 1, "name": "Bass (old)"},
+			},
+		},
+		{
+			name:    "parenthesised arithmetic in a concatenation",
+			dslCode: `for_each(tracks, track.set_track(name="Track " + (track.index + 1), mute=true))`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "name": "Track 1", "mute": true},
+				{"action": "set_track", "track": 1, "name": "Track 2", "mute": true},
+			},
+		},
+		{
+			name:    "arithmetic",
+			dslCode: `for_each(tracks, track.set_track(volume_db=track.volume_db - 3))`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "volume_db": -5.0},
+				{"action": "set_track", "track": 1, "volume_db": -3.0},
+			},
+		},
+		{
+			name:    "quoted strings stay literal",
+			dslCode: `for_each(tracks, track.set_track(name="track.name, (copy)"))`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "name": "track.name, (copy)"},
+				{"action": "set_track", "track": 1, "name": "track.name, (copy)"},
+			},
+		},
+		{
+			name:    "clip iteration targets each clip",
+			dslCode: `for_each(clips, clip.set_clip(name=clip.name + " @ " + clip.position))`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 0, "name": "Beat @ 4"},
+			},
+		},
+		{
+			name:    "unknown property skips the item",
+			dslCode: `for_each(tracks, track.set_track(name=track.label))`,
+			want:    []map[string]any{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if len(tt.want) == 0 {
+				if err == nil && len(got) > 0 {
+					t.Errorf("ParseDSL() = %v, want no actions", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
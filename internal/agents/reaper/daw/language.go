@@ -0,0 +1,179 @@
+package daw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Language is the language a request is written in, as an ISO 639-1 code. The DSL and the
+// prompts are English; for other languages the DAW prompt gets instructions and a glossary of
+// DAW terms so "erstelle eine Spur mit Serum" reads as "create a track with Serum".
+type Language string
+
+const (
+	LanguageEnglish  Language = "en"
+	LanguageGerman   Language = "de"
+	LanguageSpanish  Language = "es"
+	LanguageFrench   Language = "fr"
+	LanguageJapanese Language = "ja"
+
+	// LanguageAuto detects each request's language
+	LanguageAuto = "auto"
+)
+
+// SupportedLanguages lists the languages requests are detected in and can be pinned to
+var SupportedLanguages = []Language{LanguageEnglish, LanguageGerman, LanguageSpanish, LanguageFrench, LanguageJapanese}
+
+// languageInfo is what the DAW prompt is told about a request in a language
+type languageInfo struct {
+	name     string
+	glossary string // Native DAW terms and their DSL equivalents
+	markers  []string
+}
+
+var languages = map[Language]languageInfo{
+	LanguageEnglish: {
+		name: "English",
+		markers: []string{
+			"the", "to", "and", "with", "on", "of", "add", "create", "make", "mute", "unmute", "set",
+			"new", "called", "named", "delete", "remove", "rename", "track", "tracks", "all", "every",
+		},
+	},
+	LanguageGerman: {
+		name: "German",
+		glossary: "Spur = track, Clip = clip, stummschalten = mute, solo = solo, Lautstärke = volume, " +
+			"Panorama = pan, Hall = reverb, Verzögerung = delay, Takt = bar, erstellen = create, " +
+			"hinzufügen = add, löschen = delete, umbenennen = rename, lauter/leiser = volume up/down",
+		markers: []string{
+			"erstelle", "erstell", "erzeuge", "füge", "fuege", "hinzu", "spur", "spuren", "stumm",
+			"stummschalten", "lösche", "loesche", "lautstärke", "benenne", "setze", "mache", "mach",
+			"schalte", "lauter", "leiser", "takt", "hall", "der", "die", "das", "den", "dem", "mit",
+			"und", "auf", "ein", "eine", "einen", "neue", "neuen", "alle", "von", "zu", "um",
+		},
+	},
+	LanguageSpanish: {
+		name: "Spanish",
+		glossary: "pista = track, clip = clip, silenciar = mute, solo = solo, volumen = volume, " +
+			"paneo = pan, reverberación = reverb, retardo = delay, compás = bar, crear = create, " +
+			"añadir/agregar = add, eliminar/borrar = delete, renombrar = rename, subir/bajar = volume up/down",
+		markers: []string{
+			"crea", "crear", "añade", "añadir", "agrega", "agregar", "pista", "pistas", "silencia",
+			"silenciar", "elimina", "borra", "renombra", "sube", "baja", "pon", "cambia", "volumen",
+			"compás", "llamada", "nueva", "con", "una", "el", "los", "las", "del", "y", "todas", "todos",
+		},
+	},
+	LanguageFrench: {
+		name: "French",
+		glossary: "piste = track, clip = clip, couper le son/muet = mute, solo = solo, volume = volume, " +
+			"panoramique = pan, réverbération = reverb, délai = delay, mesure = bar, créer = create, " +
+			"ajouter = add, supprimer = delete, renommer = rename, monter/baisser = volume up/down",
+		markers: []string{
+			"crée", "créer", "cree", "ajoute", "ajouter", "piste", "pistes", "supprime", "supprimer",
+			"coupe", "renomme", "monte", "baisse", "mets", "appelée", "nouvelle", "mesure", "une",
+			"le", "les", "du", "des", "avec", "et", "sur", "toutes", "tous", "au", "aux",
+		},
+	},
+	LanguageJapanese: {
+		name: "Japanese",
+		glossary: "トラック = track, クリップ = clip, ミュート = mute, ソロ = solo, 音量/ボリューム = volume, " +
+			"パン = pan, リバーブ = reverb, ディレイ = delay, 小節 = bar, 作成/作って = create, " +
+			"追加 = add, 削除 = delete, 名前を変更 = rename",
+	},
+}
+
+type languageKey struct{}
+
+// ParseLanguage validates a pinned language. An empty string or "auto" means detect each
+// request's language, returned as "".
+func ParseLanguage(language string) (Language, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language == "" || language == LanguageAuto {
+		return "", nil
+	}
+	if _, ok := languages[Language(language)]; ok {
+		return Language(language), nil
+	}
+	codes := make([]string, len(SupportedLanguages))
+	for i, supported := range SupportedLanguages {
+		codes[i] = string(supported)
+	}
+	return "", fmt.Errorf("unsupported language %q: must be %s or one of %s", language, LanguageAuto, strings.Join(codes, ", "))
+}
+
+// DetectLanguage guesses the language of a request: Japanese from kana, otherwise by counting
+// common words (articles, DAW verbs and nouns) of each language. English wins ties, so short
+// commands and bare names stay English.
+func DetectLanguage(text string) Language {
+	for _, r := range text {
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			return LanguageJapanese
+		}
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	scores := make(map[Language]int, len(languages))
+	for _, word := range words {
+		for language, info := range languages {
+			for _, marker := range info.markers {
+				if word == marker {
+					scores[language]++
+					break
+				}
+			}
+		}
+	}
+	for _, r := range text {
+		switch {
+		case strings.ContainsRune("äöüß", unicode.ToLower(r)):
+			scores[LanguageGerman]++
+		case strings.ContainsRune("ñ¿¡", unicode.ToLower(r)):
+			scores[LanguageSpanish]++
+		case strings.ContainsRune("çœèêëàâîôû", unicode.ToLower(r)):
+			scores[LanguageFrench]++
+		}
+	}
+
+	best := LanguageEnglish
+	for _, language := range SupportedLanguages {
+		if scores[language] > scores[best] {
+			best = language
+		}
+	}
+	return best
+}
+
+// Name returns the language's English name, or its code when it isn't supported
+func (l Language) Name() string {
+	if info, ok := languages[l]; ok {
+		return info.name
+	}
+	return string(l)
+}
+
+// WithLanguage returns a context carrying the request's language.
+func WithLanguage(ctx context.Context, language Language) context.Context {
+	return context.WithValue(ctx, languageKey{}, language)
+}
+
+// LanguageFromContext returns the request's language, defaulting to English.
+func LanguageFromContext(ctx context.Context) Language {
+	if language, ok := ctx.Value(languageKey{}).(Language); ok && language != "" {
+		return language
+	}
+	return LanguageEnglish
+}
+
+// languageInstruction tells the model how to read a request in language; empty for English
+func languageInstruction(language Language) string {
+	info, ok := languages[language]
+	if !ok || language == LanguageEnglish {
+		return ""
+	}
+	return fmt.Sprintf("The request is in %s. Read it as the equivalent English command and write the DSL as usual. "+
+		"Common terms: %s. Keep names the user gives (tracks, clips, markers, regions) exactly as written, "+
+		"and write clarify() questions and choices in %s.", info.name, info.glossary, info.name)
+}
@@ -0,0 +1,87 @@
+package daw
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want Language
+	}{
+		{text: "create a track with Serum", want: LanguageEnglish},
+		{text: "mute track 2", want: LanguageEnglish},
+		{text: "Serum", want: LanguageEnglish},
+		{text: "", want: LanguageEnglish},
+		{text: "erstelle eine Spur mit Serum", want: LanguageGerman},
+		{text: "Füge Hall auf Spur 2 hinzu", want: LanguageGerman},
+		{text: "schalte alle Drum-Spuren stumm", want: LanguageGerman},
+		{text: "crea una pista con Serum", want: LanguageSpanish},
+		{text: "silencia la pista 2", want: LanguageSpanish},
+		{text: "añade reverb a la batería", want: LanguageSpanish},
+		{text: "crée une piste avec Serum", want: LanguageFrench},
+		{text: "ajoute de la réverbération sur la piste 2", want: LanguageFrench},
+		{text: "Serumでトラックを作成して", want: LanguageJapanese},
+		{text: "トラック2をミュート", want: LanguageJapanese},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := DetectLanguage(tt.text); got != tt.want {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Language
+		wantErr bool
+	}{
+		{input: "", want: ""},
+		{input: "auto", want: ""},
+		{input: " DE ", want: LanguageGerman},
+		{input: "ja", want: LanguageJapanese},
+		{input: "en", want: LanguageEnglish},
+		{input: "german", wantErr: true},
+		{input: "zh", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLanguage(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLanguage(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLanguage(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLanguageFromContext(t *testing.T) {
+	if got := LanguageFromContext(context.Background()); got != LanguageEnglish {
+		t.Errorf("default language = %q, want %q", got, LanguageEnglish)
+	}
+	ctx := WithLanguage(context.Background(), LanguageJapanese)
+	if got := LanguageFromContext(ctx); got != LanguageJapanese {
+		t.Errorf("language = %q, want %q", got, LanguageJapanese)
+	}
+}
+
+func TestLanguageInstruction(t *testing.T) {
+	if got := languageInstruction(LanguageEnglish); got != "" {
+		t.Errorf("English instruction = %q, want none", got)
+	}
+	for _, language := range SupportedLanguages[1:] {
+		instruction := languageInstruction(language)
+		if !strings.Contains(instruction, "The request is in "+language.Name()) || !strings.Contains(instruction, "= track") {
+			t.Errorf("%s instruction = %q", language, instruction)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package daw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+)
+
+// LengthUnit selects how bare length values in the DSL (new_clip length, set_clip length)
+// are interpreted. Actions sent to REAPER always carry lengths in seconds.
+type LengthUnit string
+
+const (
+	// LengthUnitSeconds treats lengths as seconds (the default)
+	LengthUnitSeconds LengthUnit = "seconds"
+	// LengthUnitBars treats lengths as bars, converted to seconds using the project tempo
+	LengthUnitBars LengthUnit = "bars"
+)
+
+type lengthUnitKey struct{}
+
+// ParseLengthUnit validates a request-level length unit. An empty string means seconds.
+func ParseLengthUnit(unit string) (LengthUnit, error) {
+	switch LengthUnit(strings.ToLower(strings.TrimSpace(unit))) {
+	case "", LengthUnitSeconds:
+		return LengthUnitSeconds, nil
+	case LengthUnitBars:
+		return LengthUnitBars, nil
+	default:
+		return "", fmt.Errorf("invalid length_unit %q: must be \"seconds\" or \"bars\"", unit)
+	}
+}
+
+// WithLengthUnit returns a context carrying the request's length unit.
+func WithLengthUnit(ctx context.Context, unit LengthUnit) context.Context {
+	return context.WithValue(ctx, lengthUnitKey{}, unit)
+}
+
+// LengthUnitFromContext returns the request's length unit, defaulting to seconds.
+func LengthUnitFromContext(ctx context.Context) LengthUnit {
+	if unit, ok := ctx.Value(lengthUnitKey{}).(LengthUnit); ok && unit != "" {
+		return unit
+	}
+	return LengthUnitSeconds
+}
+
+// tempo returns the state's tempo map
+func (p *FunctionalDSLParser) tempo() *timeutil.TempoMap {
+	return timeutil.FromState(p.state)
+}
+
+// barsToSeconds converts a length in bars to seconds, measured from the start of the project
+// since lengths carry no position of their own.
+func (p *FunctionalDSLParser) barsToSeconds(bars float64) float64 {
+	return p.tempo().BarsToSeconds(bars)
+}
+
+// barStart returns where a 1-based bar starts in seconds, following tempo changes.
+func (p *FunctionalDSLParser) barStart(bar float64) float64 {
+	return p.tempo().BarToSeconds(bar)
+}
+
+// lengthToSeconds converts a bare DSL length to seconds according to the request's length unit.
+func (p *FunctionalDSLParser) lengthToSeconds(length float64) float64 {
+	if p.lengthUnit == LengthUnitBars {
+		return p.barsToSeconds(length)
+	}
+	return length
+}
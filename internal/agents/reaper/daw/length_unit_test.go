@@ -0,0 +1,152 @@
+package daw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestParseLengthUnit(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    LengthUnit
+		wantErr bool
+	}{
+		{input: "", want: LengthUnitSeconds},
+		{input: "seconds", want: LengthUnitSeconds},
+		{input: "Bars", want: LengthUnitBars},
+		{input: " bars ", want: LengthUnitBars},
+		{input: "beats", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseLengthUnit(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLengthUnit(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseLengthUnit(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLengthUnitFromContext(t *testing.T) {
+	if got := LengthUnitFromContext(context.Background()); got != LengthUnitSeconds {
+		t.Errorf("default length unit = %q, want %q", got, LengthUnitSeconds)
+	}
+	ctx := WithLengthUnit(context.Background(), LengthUnitBars)
+	if got := LengthUnitFromContext(ctx); got != LengthUnitBars {
+		t.Errorf("length unit = %q, want %q", got, LengthUnitBars)
+	}
+}
+
+func TestFunctionalDSLParser_LengthUnit(t *testing.T) {
+	// 90 BPM in 3/4: one bar = 3 beats = 2 seconds
+	state := map[string]any{
+		"project": map[string]any{"bpm": 90.0, "time_signature": "3/4"},
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Keys"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		unit    LengthUnit
+		dslCode string
+		want    []map[string]any
+	}{
+		{
+			name:    "seconds unit leaves new_clip length unchanged",
+			unit:    LengthUnitSeconds,
+			dslCode: `track(id=1).new_clip(position=0, length=3)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 0.0, "length": 3.0},
+			},
+		},
+		{
+			name:    "bars unit converts new_clip length using project tempo",
+			unit:    LengthUnitBars,
+			dslCode: `track(id=1).new_clip(position=0, length=3)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 0.0, "length": 6.0},
+			},
+		},
+		{
+			name:    "length_bars overrides seconds unit on new_clip",
+			unit:    LengthUnitSeconds,
+			dslCode: `track(id=1).new_clip(position=0, length=3, length_bars=2)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 0.0, "length": 4.0},
+			},
+		},
+		{
+			name:    "bars unit maps new_clip length at a bar to length_bars",
+			unit:    LengthUnitBars,
+			dslCode: `track(id=1).new_clip(bar=5, length=2)`,
+			want: []map[string]any{
+				{"action": "create_clip_at_bar", "track": 0, "bar": 5, "length_bars": 2},
+			},
+		},
+		{
+			name:    "fractional length_bars at a bar keeps its length",
+			unit:    LengthUnitSeconds,
+			dslCode: `track(id=1).new_clip(bar=5, length_bars=1.5)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 8.0, "length": 3.0},
+			},
+		},
+		{
+			name:    "bars unit keeps a fractional new_clip length at a bar",
+			unit:    LengthUnitBars,
+			dslCode: `track(id=1).new_clip(bar=1, length=0.5)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 0.0, "length": 1.0},
+			},
+		},
+		{
+			name:    "bars unit converts set_clip length and source_length",
+			unit:    LengthUnitBars,
+			dslCode: `track(id=1).set_clip(clip=0, length=4, source_length=1, loop=true)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 0, "length": 8.0, "source_length": 2.0, "loop": true},
+			},
+		},
+		{
+			name:    "length_bars reads set_clip source_length in bars too",
+			unit:    LengthUnitSeconds,
+			dslCode: `track(id=1).set_clip(clip=0, length_bars=4, source_length=1, loop=true)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 0, "length": 8.0, "source_length": 2.0, "loop": true},
+			},
+		},
+		{
+			name:    "length_bars overrides set_clip length",
+			unit:    LengthUnitSeconds,
+			dslCode: `track(id=1).set_clip(clip=0, length=1.5, length_bars=2)`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "clip": 0, "length": 4.0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+			parser.SetLengthUnit(tt.unit)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package daw
+
+import (
+	"context"
+
+	"github.com/Conceptual-Machines/magda-api/internal/experiments"
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+)
+
+type llmParamsKey struct{}
+
+// LLMParams override the DAW agent's LLM parameters for one request. Empty fields keep the
+// defaults: the routed model, the lowest-latency reasoning effort and the provider's
+// temperature and output limit.
+type LLMParams struct {
+	Model           string   `json:"model,omitempty"`
+	ReasoningEffort string   `json:"reasoning_effort,omitempty"` // none, minimal, low, medium, high or xhigh
+	Temperature     *float64 `json:"temperature,omitempty"`
+	MaxOutputTokens int      `json:"max_output_tokens,omitempty"`
+}
+
+// WithLLMParams returns a context carrying the request's LLM parameter overrides.
+func WithLLMParams(ctx context.Context, params LLMParams) context.Context {
+	return context.WithValue(ctx, llmParamsKey{}, params)
+}
+
+// LLMParamsFromContext returns the request's LLM parameter overrides, if any.
+func LLMParamsFromContext(ctx context.Context) LLMParams {
+	params, _ := ctx.Value(llmParamsKey{}).(LLMParams)
+	return params
+}
+
+// Effective returns the parameters a call on model runs with: the overrides, with the model and
+// the default reasoning effort filled in
+func (p LLMParams) Effective(model string) LLMParams {
+	if p.Model != "" {
+		model = p.Model
+	}
+	p.Model = model
+	if p.ReasoningEffort == "" {
+		p.ReasoningEffort = reasoningModeFor(model)
+	}
+	return p
+}
+
+// generationRequest builds the LLM request for model with the context's overrides and prompt
+// variant
+func generationRequest(ctx context.Context, model string, inputArray []map[string]any, systemPrompt string) *llm.GenerationRequest {
+	params := LLMParamsFromContext(ctx).Effective(model)
+	if variant := experiments.FromContext(ctx); variant != nil {
+		systemPrompt = variant.SystemPrompt(systemPrompt)
+	}
+	return &llm.GenerationRequest{
+		Model:           params.Model,
+		InputArray:      inputArray,
+		ReasoningMode:   params.ReasoningEffort, // Lowest latency the model allows, unless overridden
+		SystemPrompt:    systemPrompt,
+		Temperature:     params.Temperature,
+		MaxOutputTokens: params.MaxOutputTokens,
+	}
+}
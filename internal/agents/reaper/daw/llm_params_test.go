@@ -0,0 +1,31 @@
+package daw
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerationRequest_LLMParams(t *testing.T) {
+	request := generationRequest(context.Background(), "gpt-5-nano", nil, "system")
+	assert.Equal(t, "gpt-5-nano", request.Model)
+	assert.Equal(t, "minimal", request.ReasoningMode)
+	assert.Nil(t, request.Temperature)
+	assert.Zero(t, request.MaxOutputTokens)
+
+	temperature := 0.3
+	ctx := WithLLMParams(context.Background(), LLMParams{
+		Model:           "gpt-5.2",
+		ReasoningEffort: "xhigh",
+		Temperature:     &temperature,
+		MaxOutputTokens: 8000,
+	})
+	request = generationRequest(ctx, "gpt-5-nano", nil, "system")
+	assert.Equal(t, "gpt-5.2", request.Model)
+	assert.Equal(t, "xhigh", request.ReasoningMode)
+	assert.Equal(t, &temperature, request.Temperature)
+	assert.Equal(t, 8000, request.MaxOutputTokens)
+
+	assert.Equal(t, LLMParams{Model: "gpt-5.1", ReasoningEffort: "none"}, LLMParams{}.Effective("gpt-5.1"))
+}
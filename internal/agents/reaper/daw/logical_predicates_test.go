@@ -0,0 +1,124 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_LogicalPredicates(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "muted": true, "clips": []any{
+				map[string]any{"index": 0, "position": 0.0, "length": 1.0, "selected": false},
+				map[string]any{"index": 1, "position": 4.0, "length": 8.0, "selected": true},
+			}},
+			map[string]any{"index": 1, "name": "Master", "muted": true},
+			map[string]any{"index": 2, "name": "Bass", "muted": false, "clips": []any{
+				map[string]any{"index": 0, "position": 2.0, "length": 4.0, "selected": false},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+	}{
+		{
+			name:    "and",
+			dslCode: `filter(tracks, track.muted == true && track.name != "Master").set_track(mute=false)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": false},
+			},
+		},
+		{
+			name:    "or",
+			dslCode: `filter(clips, clip.length < 2 || clip.selected == true).delete_clip()`,
+			want: []map[string]any{
+				{"action": "delete_clip", "track": 0, "position": 0.0},
+				{"action": "delete_clip", "track": 0, "position": 4.0},
+			},
+		},
+		{
+			name:    "and binds tighter than or",
+			dslCode: `filter(tracks, track.name == "Bass" || track.muted == true && track.name != "Drums").set_track(solo=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 1, "solo": true},
+				{"action": "set_track", "track": 2, "solo": true},
+			},
+		},
+		{
+			name:    "not a bare property",
+			dslCode: `filter(tracks, !track.muted).set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 2, "mute": true},
+			},
+		},
+		{
+			name:    "not a group",
+			dslCode: `filter(tracks, !(track.name == "Drums" || track.name == "Master")).set_track(name="Bass 2")`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 2, "name": "Bass 2"},
+			},
+		},
+		{
+			name:    "parentheses override precedence",
+			dslCode: `filter(clips, (clip.length < 2 || clip.length > 6) && clip.selected == false).set_clip(name="Odd")`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 0, "position": 0.0, "name": "Odd"},
+			},
+		},
+		{
+			name:    "operators inside strings are literal",
+			dslCode: `filter(tracks, track.name == "A && B" || track.name == "Bass").set_track(solo=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 2, "solo": true},
+			},
+		},
+		{
+			name:    "split >= in a compound predicate",
+			dslCode: `filter(clips, clip.selected == false && clip.length >= 4).set_clip(name="Long")`,
+			want: []map[string]any{
+				{"action": "set_clip", "track": 2, "position": 2.0, "name": "Long"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	tests := []struct {
+		pred string
+		op   string
+		want []string
+	}{
+		{pred: "a && b", op: "&&", want: []string{"a ", " b"}},
+		{pred: `a == "x && y"`, op: "&&", want: []string{`a == "x && y"`}},
+		{pred: "(a || b) && c", op: "||", want: []string{"(a || b) && c"}},
+		{pred: "a || b || c", op: "||", want: []string{"a ", " b ", " c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pred, func(t *testing.T) {
+			if got := splitTopLevel(tt.pred, tt.op); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTopLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
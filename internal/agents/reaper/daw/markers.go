@@ -0,0 +1,176 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// Markers and regions are project-level: they ignore the current track context and emit actions
+// without a "track" key. They are top-level statements, so separate them with ";" from the
+// statement before, e.g. track(name="Drums"); add_region(start_bar=1, end_bar=9, name="Intro").
+
+// AddMarker handles add_marker() calls: adds a project marker at a bar or position (seconds).
+// Example: add_marker(bar=17, name="Chorus", color="red")
+func (r *ReaperDSL) AddMarker(args gs.Args) error {
+	p := r.parser
+
+	action := map[string]any{"action": "add_marker"}
+	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		if barValue.Num < 1 {
+			return fmt.Errorf("add_marker bar must be 1 or greater, got %g", barValue.Num)
+		}
+		action["bar"] = int(barValue.Num)
+	} else if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		if positionValue.Num < 0 {
+			return fmt.Errorf("add_marker position must not be negative, got %g", positionValue.Num)
+		}
+		action["position"] = positionValue.Num
+	} else {
+		return fmt.Errorf("add_marker requires bar or position")
+	}
+
+	if err := addMarkerAppearance(action, args); err != nil {
+		return fmt.Errorf("add_marker: %w", err)
+	}
+
+	p.actions = append(p.actions, action)
+	logger.Printf(r.parser.ctx, "✅ AddMarker: %+v", action)
+	return nil
+}
+
+// AddRegion handles add_region() calls: adds a project region between two bars or two positions
+// (seconds). end_bar is exclusive, so start_bar=1, end_bar=9 covers bars 1-8.
+// Example: add_region(start_bar=1, end_bar=9, name="Intro", color="blue")
+func (r *ReaperDSL) AddRegion(args gs.Args) error {
+	p := r.parser
+
+	action := map[string]any{"action": "add_region"}
+	startBarValue, hasStartBar := args["start_bar"]
+	endBarValue, hasEndBar := args["end_bar"]
+	startValue, hasStart := args["start"]
+	endValue, hasEnd := args["end"]
+
+	switch {
+	case hasStartBar && hasEndBar && startBarValue.Kind == gs.ValueNumber && endBarValue.Kind == gs.ValueNumber:
+		startBar, endBar := int(startBarValue.Num), int(endBarValue.Num)
+		if startBar < 1 {
+			return fmt.Errorf("add_region start_bar must be 1 or greater, got %d", startBar)
+		}
+		if endBar <= startBar {
+			return fmt.Errorf("add_region end_bar (%d) must be after start_bar (%d)", endBar, startBar)
+		}
+		action["start_bar"] = startBar
+		action["end_bar"] = endBar
+	case hasStart && hasEnd && startValue.Kind == gs.ValueNumber && endValue.Kind == gs.ValueNumber:
+		if startValue.Num < 0 {
+			return fmt.Errorf("add_region start must not be negative, got %g", startValue.Num)
+		}
+		if endValue.Num <= startValue.Num {
+			return fmt.Errorf("add_region end (%g) must be after start (%g)", endValue.Num, startValue.Num)
+		}
+		action["start"] = startValue.Num
+		action["end"] = endValue.Num
+	default:
+		return fmt.Errorf("add_region requires start_bar and end_bar, or start and end")
+	}
+
+	if err := addMarkerAppearance(action, args); err != nil {
+		return fmt.Errorf("add_region: %w", err)
+	}
+
+	p.actions = append(p.actions, action)
+	logger.Printf(r.parser.ctx, "✅ AddRegion: %+v", action)
+	return nil
+}
+
+// DeleteMarker handles delete_marker() calls: removes a marker by its REAPER marker id,
+// its name, or the bar/position it sits at.
+// Example: delete_marker(name="Chorus")
+func (r *ReaperDSL) DeleteMarker(args gs.Args) error {
+	p := r.parser
+
+	action := map[string]any{"action": "delete_marker"}
+	if err := addMarkerIdentifier(action, args, "bar", "position"); err != nil {
+		return fmt.Errorf("delete_marker %w", err)
+	}
+
+	p.actions = append(p.actions, action)
+	logger.Printf(r.parser.ctx, "✅ DeleteMarker: %+v", action)
+	return nil
+}
+
+// RenameRegion handles rename_region() calls: renames a region picked by its REAPER region id
+// or its current name.
+// Example: rename_region(name="Intro", new_name="Verse 1")
+func (r *ReaperDSL) RenameRegion(args gs.Args) error {
+	p := r.parser
+
+	newNameValue, ok := args["new_name"]
+	if !ok || newNameValue.Kind != gs.ValueString || strings.TrimSpace(newNameValue.Str) == "" {
+		return fmt.Errorf("rename_region requires new_name (string)")
+	}
+
+	action := map[string]any{"action": "rename_region"}
+	if err := addMarkerIdentifier(action, args); err != nil {
+		return fmt.Errorf("rename_region %w", err)
+	}
+	action["new_name"] = newNameValue.Str
+
+	p.actions = append(p.actions, action)
+	logger.Printf(r.parser.ctx, "✅ RenameRegion: %+v", action)
+	return nil
+}
+
+// addMarkerIdentifier copies the first identifier found (id, name, then any of positionKeys)
+// onto action. id is the marker/region number shown in REAPER, so it is passed through as-is.
+func addMarkerIdentifier(action map[string]any, args gs.Args, positionKeys ...string) error {
+	if idValue, ok := args["id"]; ok && idValue.Kind == gs.ValueNumber {
+		if idValue.Num < 1 || float64(int(idValue.Num)) != idValue.Num {
+			return fmt.Errorf("id must be a whole number starting at 1, got %g", idValue.Num)
+		}
+		action["id"] = int(idValue.Num)
+		return nil
+	}
+	if nameValue, ok := args["name"]; ok && nameValue.Kind == gs.ValueString && strings.TrimSpace(nameValue.Str) != "" {
+		action["name"] = nameValue.Str
+		return nil
+	}
+	for _, key := range positionKeys {
+		value, ok := args[key]
+		if !ok || value.Kind != gs.ValueNumber {
+			continue
+		}
+		if key == "bar" {
+			action[key] = int(value.Num)
+		} else {
+			action[key] = value.Num
+		}
+		return nil
+	}
+
+	if len(positionKeys) > 0 {
+		return fmt.Errorf("requires id, name, %s", strings.Join(positionKeys, ", or "))
+	}
+	return fmt.Errorf("requires id or name")
+}
+
+// addMarkerAppearance copies the optional name and color of a new marker or region onto action
+func addMarkerAppearance(action map[string]any, args gs.Args) error {
+	if nameValue, ok := args["name"]; ok && nameValue.Kind == gs.ValueString {
+		action["name"] = nameValue.Str
+	}
+
+	colorValue, ok := args["color"]
+	if !ok {
+		return nil
+	}
+	color, err := colorArg(colorValue)
+	if err != nil {
+		return err
+	}
+	action["color"] = color
+	return nil
+}
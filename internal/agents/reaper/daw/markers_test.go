@@ -0,0 +1,114 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_MarkersAndRegions(t *testing.T) {
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "add region by bars with color name",
+			dslCode: `add_region(start_bar=1, end_bar=9, name="Intro", color="blue")`,
+			want: []map[string]any{
+				{"action": "add_region", "start_bar": 1, "end_bar": 9, "name": "Intro", "color": "#0000ff"},
+			},
+		},
+		{
+			name:    "song layout separated by semicolons",
+			dslCode: `add_region(start_bar=1, end_bar=9, name="Intro"); add_region(start_bar=9, end_bar=25, name="Verse"); add_marker(bar=25, name="Chorus")`,
+			want: []map[string]any{
+				{"action": "add_region", "start_bar": 1, "end_bar": 9, "name": "Intro"},
+				{"action": "add_region", "start_bar": 9, "end_bar": 25, "name": "Verse"},
+				{"action": "add_marker", "bar": 25, "name": "Chorus"},
+			},
+		},
+		{
+			name:    "markers mixed with track statements",
+			dslCode: `track(name="Drums"); add_marker(position=12.5); track(id=1).set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "create_track", "name": "Drums", "index": 0},
+				{"action": "add_marker", "position": 12.5},
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+		},
+		{
+			name:    "region in seconds",
+			dslCode: `add_region(start=0, end=30.5)`,
+			want: []map[string]any{
+				{"action": "add_region", "start": 0.0, "end": 30.5},
+			},
+		},
+		{
+			name:    "delete marker by name, id and bar",
+			dslCode: `delete_marker(name="Drop"); delete_marker(id=3); delete_marker(bar=17)`,
+			want: []map[string]any{
+				{"action": "delete_marker", "name": "Drop"},
+				{"action": "delete_marker", "id": 3},
+				{"action": "delete_marker", "bar": 17},
+			},
+		},
+		{
+			name:    "rename region by name and id",
+			dslCode: `rename_region(name="Intro", new_name="Verse 1"); rename_region(id=2, new_name="Chorus")`,
+			want: []map[string]any{
+				{"action": "rename_region", "name": "Intro", "new_name": "Verse 1"},
+				{"action": "rename_region", "id": 2, "new_name": "Chorus"},
+			},
+		},
+		{
+			name:    "marker needs a position",
+			dslCode: `add_marker(name="Drop")`,
+			wantErr: "add_marker requires bar or position",
+		},
+		{
+			name:    "region end before start",
+			dslCode: `add_region(start_bar=9, end_bar=1)`,
+			wantErr: "end_bar (1) must be after start_bar (9)",
+		},
+		{
+			name:    "region needs both bounds",
+			dslCode: `add_region(start_bar=1, name="Intro")`,
+			wantErr: "add_region requires start_bar and end_bar",
+		},
+		{
+			name:    "delete marker needs an identifier",
+			dslCode: `delete_marker(color="red")`,
+			wantErr: "delete_marker requires id, name, bar, or position",
+		},
+		{
+			name:    "rename region needs a new name",
+			dslCode: `rename_region(name="Intro")`,
+			wantErr: "rename_region requires new_name",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
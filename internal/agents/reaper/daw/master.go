@@ -0,0 +1,67 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// MasterTrack is the track of actions on the master track, in place of a track index:
+//
+//	{"action": "set_track", "track": "master", "volume_db": -1}
+const MasterTrack = "master"
+
+// masterTrackProperties are the set_track properties the master track supports
+var masterTrackProperties = map[string]bool{"volume_db": true, "pan": true}
+
+// Master handles master() calls: the following set_track, add_fx and add_automation
+// calls apply to the master track.
+// Example: master().add_fx(fxname="ReaLimit").set_track(volume_db=master.volume_db - 1)
+func (r *ReaperDSL) Master(args gs.Args) error {
+	p := r.parser
+	if len(args) > 0 {
+		return fmt.Errorf("master() takes no arguments")
+	}
+	p.currentTrackIndex = -1
+	p.onMaster = true
+	return nil
+}
+
+// isMasterAction reports whether action targets the master track
+func isMasterAction(action map[string]any) bool {
+	track, _ := action["track"].(string)
+	return track == MasterTrack
+}
+
+// stateMaster returns the state's master track ({"volume_db": 0, "pan": 0, "fx": [...]}), or nil
+func stateMaster(state map[string]any) map[string]any {
+	if inner, ok := state["state"].(map[string]any); ok {
+		state = inner
+	}
+	master, _ := state["master"].(map[string]any)
+	return master
+}
+
+// masterSetTrack emits set_track on the master track; expressions read the master's state
+// as master.volume_db (or track.volume_db)
+func (p *FunctionalDSLParser) masterSetTrack(props map[string]any) error {
+	for key := range props {
+		if !masterTrackProperties[key] {
+			return fmt.Errorf("set_track on master() supports volume_db and pan, not %s", key)
+		}
+	}
+	master := stateMaster(p.state)
+	resolved, err := resolveExprProps(props, exprVars{"master": master, "track": master})
+	if err != nil {
+		return fmt.Errorf("set_track: %w", err)
+	}
+
+	action := map[string]any{"action": "set_track", "track": MasterTrack}
+	for k, v := range resolved {
+		action[k] = v
+	}
+	p.actions = append(p.actions, action)
+	logger.Printf(p.ctx, "✅ SetTrack: master, props=%+v", resolved)
+	return nil
+}
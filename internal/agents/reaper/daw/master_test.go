@@ -0,0 +1,106 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestFunctionalDSLParser_Master(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{map[string]any{"index": 0, "name": "Drums"}},
+		"master": map[string]any{"volume_db": -2.0, "pan": 0.0},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "limiter and relative volume",
+			dslCode: `master().add_fx(fxname="ReaLimit").set_track(volume_db=master.volume_db - 1)`,
+			want: []map[string]any{
+				{"action": "add_track_fx", "track": "master", "fxname": "ReaLimit"},
+				{"action": "set_track", "track": "master", "volume_db": -3.0},
+			},
+		},
+		{
+			name:    "volume and pan",
+			dslCode: `master().set_track(volume_db=-6, pan=0.1)`,
+			want:    []map[string]any{{"action": "set_track", "track": "master", "volume_db": -6.0, "pan": 0.1}},
+		},
+		{
+			name:    "automation",
+			dslCode: `master().add_automation(param="volume", curve="fade_out", start_bar=33, end_bar=37)`,
+			want: []map[string]any{
+				{"action": "add_automation", "track": "master", "param": "volume", "curve": "fade_out", "start_bar": 33.0, "end_bar": 37.0},
+			},
+		},
+		{
+			name:    "track statement after master",
+			dslCode: `master().set_track(volume_db=-1); track(id=1).set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": "master", "volume_db": -1.0},
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+		},
+		{name: "unsupported property", dslCode: `master().set_track(mute=true)`, wantErr: true},
+		{name: "unsupported method", dslCode: `master().new_clip(bar=1, length_bars=4)`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMasterActions_UndoAndPreview(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{map[string]any{"index": 0, "name": "Drums"}},
+		"master": map[string]any{"volume_db": -2.0, "pan": 0.0},
+	}
+	actions := []map[string]any{
+		{"action": "add_track_fx", "track": "master", "fxname": "ReaLimit"},
+		{"action": "set_track", "track": "master", "volume_db": -3.0},
+	}
+
+	wantUndo := []map[string]any{{"action": "set_track", "track": "master", "volume_db": -2.0}}
+	if got := BuildUndoActions(actions, state); !reflect.DeepEqual(got, wantUndo) {
+		t.Errorf("BuildUndoActions() = %v, want %v", got, wantUndo)
+	}
+
+	planned, warnings := PlanActions(actions, state)
+	if !reflect.DeepEqual(planned, actions) || len(warnings) != 0 {
+		t.Errorf("PlanActions() = %v, %v, want actions unchanged", planned, warnings)
+	}
+
+	wantPreview := []models.ActionPreview{
+		{ActionIndex: 0, Action: "add_track_fx", Summary: `Add track fx on master track: fxname="ReaLimit"`},
+		{ActionIndex: 1, Action: "set_track", Summary: `Set master track: volume_db=-3`},
+	}
+	if got := PreviewActions(actions, state); !reflect.DeepEqual(got, wantPreview) {
+		t.Errorf("PreviewActions() = %+v, want %+v", got, wantPreview)
+	}
+}
@@ -0,0 +1,33 @@
+package daw
+
+import (
+	"context"
+	"strings"
+)
+
+type modelKey struct{}
+
+// DefaultModel is the model the DAW agent requests unless the request was routed to another
+const DefaultModel = "gpt-5.1"
+
+// WithModel returns a context carrying the model the DAW agent requests for this request.
+func WithModel(ctx context.Context, model string) context.Context {
+	return context.WithValue(ctx, modelKey{}, model)
+}
+
+// ModelFromContext returns the request's model, defaulting to DefaultModel.
+func ModelFromContext(ctx context.Context) string {
+	if model, ok := ctx.Value(modelKey{}).(string); ok && model != "" {
+		return model
+	}
+	return DefaultModel
+}
+
+// reasoningModeFor returns the lowest-latency reasoning mode model accepts: "none" from
+// GPT-5.1 on, "minimal" for the original GPT-5 models
+func reasoningModeFor(model string) string {
+	if strings.HasPrefix(model, "gpt-5-") || model == "gpt-5" {
+		return "minimal"
+	}
+	return "none"
+}
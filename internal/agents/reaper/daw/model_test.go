@@ -0,0 +1,30 @@
+package daw
+
+import (
+	"context"
+	"testing"
+)
+
+func TestModelFromContext(t *testing.T) {
+	if got := ModelFromContext(context.Background()); got != DefaultModel {
+		t.Errorf("ModelFromContext() = %q, want %q", got, DefaultModel)
+	}
+	if got := ModelFromContext(WithModel(context.Background(), "gpt-5-nano")); got != "gpt-5-nano" {
+		t.Errorf("ModelFromContext() = %q, want gpt-5-nano", got)
+	}
+}
+
+func TestReasoningModeFor(t *testing.T) {
+	tests := map[string]string{
+		"gpt-5.1":    "none",
+		"gpt-5.2":    "none",
+		"gpt-5-nano": "minimal",
+		"gpt-5":      "minimal",
+		"gpt-4.1":    "none",
+	}
+	for model, want := range tests {
+		if got := reasoningModeFor(model); got != want {
+			t.Errorf("reasoningModeFor(%q) = %q, want %q", model, got, want)
+		}
+	}
+}
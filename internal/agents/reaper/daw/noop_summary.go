@@ -0,0 +1,113 @@
+package daw
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+type noOpSummaryKey struct{}
+
+// WithNoOpSummary returns a context that asks the DAW agent to report, per filtered
+// statement, how many matched items were already in the target state.
+func WithNoOpSummary(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, noOpSummaryKey{}, enabled)
+}
+
+// NoOpSummaryFromContext reports whether the request opted in to filter no-op summaries.
+func NoOpSummaryFromContext(ctx context.Context) bool {
+	enabled, _ := ctx.Value(noOpSummaryKey{}).(bool)
+	return enabled
+}
+
+// SetReportNoOps enables collecting a FilterSummary for each filtered statement.
+func (p *FunctionalDSLParser) SetReportNoOps(enabled bool) {
+	p.reportNoOps = enabled
+}
+
+// FilterSummaries returns the summaries collected by the last ParseDSL call.
+// Empty unless SetReportNoOps(true) was called.
+func (p *FunctionalDSLParser) FilterSummaries() []models.FilterSummary {
+	return p.filterSummaries
+}
+
+// recordFilterSummary records how many filtered items a statement matched and how many already
+// had the requested properties.
+func (p *FunctionalDSLParser) recordFilterSummary(method string, props map[string]any, matched, alreadySet int) {
+	if !p.reportNoOps {
+		return
+	}
+	p.filterSummaries = append(p.filterSummaries, models.FilterSummary{
+		Statement:  formatStatement(method, props),
+		Matched:    matched,
+		AlreadySet: alreadySet,
+		Message:    fmt.Sprintf("%d matched, %d already set", matched, alreadySet),
+	})
+}
+
+// formatStatement renders a method call with its properties in a stable order,
+// e.g. set_track(mute=true, name="Bass").
+func formatStatement(method string, props map[string]any) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if s, ok := props[k].(string); ok {
+			parts = append(parts, fmt.Sprintf("%s=%q", k, s))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s=%v", k, props[k]))
+		}
+	}
+	return fmt.Sprintf("%s(%s)", method, strings.Join(parts, ", "))
+}
+
+// propertiesAlreadySet reports whether item (a track or clip from state) already has every
+// property in props. Properties are read under their state keys (mute is muted); missing
+// properties count as different.
+func propertiesAlreadySet(item, props map[string]any) bool {
+	for key, want := range props {
+		stateKey := key
+		if k, ok := trackStateKeys[key]; ok {
+			stateKey = k
+		}
+		current, ok := item[stateKey]
+		if !ok {
+			return false
+		}
+		switch w := want.(type) {
+		case float64:
+			v, ok := getNumericValue(current)
+			if !ok || math.Abs(v-w) > 1e-6 {
+				return false
+			}
+		case bool:
+			if v, ok := current.(bool); !ok || v != w {
+				return false
+			}
+		case string:
+			v, ok := current.(string)
+			if !ok {
+				return false
+			}
+			// Hex colors may differ only in case (#FF0000 vs #ff0000)
+			if key == "color" {
+				if !strings.EqualFold(v, w) {
+					return false
+				}
+			} else if v != w {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
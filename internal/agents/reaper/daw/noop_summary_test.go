@@ -0,0 +1,108 @@
+package daw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestFunctionalDSLParser_FilterSummaries(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "muted": true, "color": "#FF0000", "clips": []any{
+				map[string]any{"index": 0, "position": 0.0, "length": 2.0, "name": "Intro"},
+				map[string]any{"index": 1, "position": 4.0, "length": 2.0},
+			}},
+			map[string]any{"index": 1, "name": "Drums Room", "muted": false, "color": "#00ff00"},
+			map[string]any{"index": 2, "name": "Drums Overhead", "muted": false},
+			map[string]any{"index": 3, "name": "Bass", "muted": false, "soloed": true},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []models.FilterSummary
+	}{
+		{
+			name:    "set_track counts tracks already muted",
+			dslCode: `filter(tracks, track.index < 3).set_track(mute=true)`,
+			want: []models.FilterSummary{
+				{Statement: "set_track(mute=true)", Matched: 3, AlreadySet: 1, Message: "3 matched, 1 already set"},
+			},
+		},
+		{
+			name:    "set_track counts tracks already soloed",
+			dslCode: `filter(tracks, track.index > 1).set_track(solo=true)`,
+			want: []models.FilterSummary{
+				{Statement: "set_track(solo=true)", Matched: 2, AlreadySet: 1, Message: "2 matched, 1 already set"},
+			},
+		},
+		{
+			name:    "color comparison ignores hex case",
+			dslCode: `filter(tracks, track.index < 3).set_track(color="#ff0000")`,
+			want: []models.FilterSummary{
+				{Statement: `set_track(color="#ff0000")`, Matched: 3, AlreadySet: 1, Message: "3 matched, 1 already set"},
+			},
+		},
+		{
+			name:    "set_clip requires every property to match",
+			dslCode: `filter(clips, clip.length < 3.0).set_clip(name="Intro", length=2.0)`,
+			want: []models.FilterSummary{
+				{Statement: `set_clip(length=2, name="Intro")`, Matched: 2, AlreadySet: 1, Message: "2 matched, 1 already set"},
+			},
+		},
+		{
+			name:    "one summary per filtered statement",
+			dslCode: `filter(tracks, track.name == "Bass").set_track(mute=false); filter(tracks, track.name == "Bass").set_track(mute=true)`,
+			want: []models.FilterSummary{
+				{Statement: "set_track(mute=false)", Matched: 1, AlreadySet: 1, Message: "1 matched, 1 already set"},
+				{Statement: "set_track(mute=true)", Matched: 1, AlreadySet: 0, Message: "1 matched, 0 already set"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+			parser.SetReportNoOps(true)
+
+			if _, err := parser.ParseDSL(tt.dslCode); err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if got := parser.FilterSummaries(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterSummaries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_FilterSummariesOptIn(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(map[string]any{
+		"tracks": []any{map[string]any{"index": 0, "name": "Bass", "muted": true}},
+	})
+
+	if _, err := parser.ParseDSL(`filter(tracks, track.name == "Bass").set_track(mute=true)`); err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	if got := parser.FilterSummaries(); len(got) != 0 {
+		t.Errorf("FilterSummaries() without opt-in = %+v, want none", got)
+	}
+
+	if NoOpSummaryFromContext(context.Background()) {
+		t.Error("NoOpSummaryFromContext() should default to false")
+	}
+	if !NoOpSummaryFromContext(WithNoOpSummary(context.Background(), true)) {
+		t.Error("NoOpSummaryFromContext() should be true after WithNoOpSummary(ctx, true)")
+	}
+}
@@ -0,0 +1,33 @@
+package daw
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+)
+
+// dslParseError is LLM output the DSL parser rejected. The DAW agent re-prompts with it.
+type dslParseError struct {
+	dsl string
+	err error
+}
+
+func (e *dslParseError) Error() string { return e.err.Error() }
+
+func (e *dslParseError) Unwrap() error { return e.err }
+
+// retryRequest returns request with the rejected DSL and its parse error appended, asking the
+// model to fix it. Earlier retries stay in the input, so the model sees each failed attempt.
+func retryRequest(request *llm.GenerationRequest, parseErr *dslParseError) *llm.GenerationRequest {
+	retry := *request
+	retry.InputArray = append(slices.Clip(request.InputArray), map[string]any{
+		"role": "user",
+		"content": fmt.Sprintf(
+			"Your DSL did not parse.\nDSL:\n%s\nError: %v\n"+
+				"Fix this DSL: output the whole corrected script, following the grammar exactly.",
+			parseErr.dsl, parseErr.err,
+		),
+	})
+	return &retry
+}
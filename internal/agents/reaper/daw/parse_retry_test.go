@@ -0,0 +1,114 @@
+package daw
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/metrics"
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceProvider returns its outputs in order, one per request
+type sequenceProvider struct {
+	outputs  []string
+	requests []*llm.GenerationRequest
+}
+
+func (p *sequenceProvider) Name() string { return "sequence" }
+
+func (p *sequenceProvider) Generate(ctx context.Context, request *llm.GenerationRequest) (*llm.GenerationResponse, error) {
+	p.requests = append(p.requests, request)
+	if len(p.requests) > len(p.outputs) {
+		return nil, errors.New("no more outputs")
+	}
+	return &llm.GenerationResponse{
+		RawOutput: p.outputs[len(p.requests)-1],
+		Usage:     responses.ResponseUsage{InputTokens: 100, OutputTokens: 10, TotalTokens: 110},
+	}, nil
+}
+
+func (p *sequenceProvider) GenerateStream(
+	ctx context.Context, request *llm.GenerationRequest, callback llm.StreamCallback,
+) (*llm.GenerationResponse, error) {
+	return p.Generate(ctx, request)
+}
+
+func TestDawAgent_RetriesParseErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputs      []string
+		parseRetries int
+		wantActions  int
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "fixed on retry",
+			outputs:      []string{"Sure! Here is a bass track.", `track(name="Bass")`},
+			parseRetries: 1,
+			wantActions:  1,
+			wantRequests: 2,
+		},
+		{
+			name:         "retries disabled",
+			outputs:      []string{"Sure! Here is a bass track.", `track(name="Bass")`},
+			parseRetries: 0,
+			wantRequests: 1,
+			wantErr:      true,
+		},
+		{
+			name:         "still broken after the last retry",
+			outputs:      []string{"Sure!", "Here you go."},
+			parseRetries: 1,
+			wantRequests: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "valid output is not retried",
+			outputs:      []string{`track(name="Bass")`},
+			parseRetries: 1,
+			wantActions:  1,
+			wantRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &sequenceProvider{outputs: tt.outputs}
+			agent := &DawAgent{provider: provider, metrics: metrics.NewSentryMetrics(), useDSL: true, parseRetries: tt.parseRetries}
+
+			result, err := agent.GenerateActions(context.Background(), "add a bass track", nil)
+			assert.Len(t, provider.requests, tt.wantRequests)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, result.Actions, tt.wantActions)
+			assert.Equal(t, tt.outputs[len(tt.outputs)-1], result.DSL)
+		})
+	}
+}
+
+func TestDawAgent_RetryPromptIncludesParseError(t *testing.T) {
+	provider := &sequenceProvider{outputs: []string{"Sure!", `track(name="Bass")`}}
+	agent := &DawAgent{provider: provider, metrics: metrics.NewSentryMetrics(), useDSL: true, parseRetries: 1}
+
+	result, err := agent.GenerateActionsStream(context.Background(), "add a bass track", nil, func(map[string]any) error { return nil })
+	require.NoError(t, err)
+	require.Len(t, provider.requests, 2)
+
+	first, retry := provider.requests[0].InputArray, provider.requests[1].InputArray
+	require.Len(t, retry, len(first)+1)
+	content, _ := retry[len(retry)-1]["content"].(string)
+	assert.Contains(t, content, "Sure!")
+	assert.Contains(t, content, errNotDSL.Error())
+
+	usage, ok := result.Usage.(responses.ResponseUsage)
+	require.True(t, ok)
+	assert.Equal(t, int64(220), usage.TotalTokens)
+}
@@ -0,0 +1,91 @@
+package daw
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+)
+
+// Parsers of concurrent requests (or of one request's parallel DAW tasks) share a normalized
+// state; run with -race
+func TestFunctionalDSLParser_ConcurrentParsersShareState(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{
+				"index": 0.0,
+				"name":  "Drums",
+				"fx":    []any{map[string]any{"name": "ReaEQ"}, map[string]any{"name": "ReaComp"}},
+				"clips": []any{map[string]any{"position": 0.0, "length": 4.0}, map[string]any{"position": 8.0, "length": 4.0}},
+			},
+			map[string]any{"index": 1.0, "name": "Bass", "fx": []any{map[string]any{"name": "ReaComp"}}},
+		},
+	}
+	if _, err := projectstate.Normalize(state); err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	dslCode := `filter(tracks, track.fx_count > 1).set_track(mute=true);` +
+		`filter(fx_chain, fx.name == "ReaComp").bypass_fx();` +
+		`track(id=1).nth_clip(2).set_clip(color="red")`
+
+	parse := func() ([]map[string]any, error) {
+		parser, err := NewFunctionalDSLParser()
+		if err != nil {
+			return nil, err
+		}
+		parser.SetState(state)
+		return parser.ParseDSL(dslCode)
+	}
+	want, err := parse()
+	if err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+
+	const requests = 16
+	results := make([][]map[string]any, requests)
+	errs := make([]error, requests)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = parse()
+		}()
+	}
+	wg.Wait()
+
+	for i := range requests {
+		if errs[i] != nil {
+			t.Fatalf("request %d: ParseDSL() error = %v", i, errs[i])
+		}
+		if !reflect.DeepEqual(results[i], want) {
+			t.Errorf("request %d: ParseDSL() = %v, want %v", i, results[i], want)
+		}
+	}
+
+	drums := state["tracks"].([]any)[0].(map[string]any)
+	if _, ok := drums["fx_count"]; ok {
+		t.Error("parsing wrote fx_count to the shared state")
+	}
+	if _, ok := drums["fx"].([]any)[0].(map[string]any)["track"]; ok {
+		t.Error("parsing wrote track to the shared state's FX")
+	}
+}
+
+func TestFunctionalDSLParser_RefusesConcurrentParse(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.parsing.Store(true)
+	if _, err := parser.ParseDSL(`track(name="Bass")`); !errors.Is(err, errConcurrentParse) {
+		t.Fatalf("ParseDSL() during another parse error = %v, want errConcurrentParse", err)
+	}
+
+	parser.parsing.Store(false)
+	if _, err := parser.ParseDSL(`track(name="Bass")`); err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+}
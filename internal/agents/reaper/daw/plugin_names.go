@@ -0,0 +1,76 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/plugins"
+)
+
+// SetPluginResolver sets how plugin names in the DSL are matched to installed plugins.
+// Without one, names are resolved against the state's installed_fx list.
+func (p *FunctionalDSLParser) SetPluginResolver(resolver plugins.Resolver) {
+	p.pluginResolver = resolver
+}
+
+// pluginNameKey returns the key holding the plugin name of an action that adds a plugin
+func pluginNameKey(action map[string]any) string {
+	switch action["action"] {
+	case "create_track":
+		return "instrument"
+	case "add_track_fx", "add_instrument":
+		return "fxname"
+	}
+	return ""
+}
+
+// resolvePluginNames replaces plugin names with the installed plugin they refer to, e.g.
+// "serum" with "VSTi: Serum (Xfer Records)". A name that could mean several plugins drops
+// the actions and asks the user which one they meant. Unknown names are kept as written.
+func (p *FunctionalDSLParser) resolvePluginNames() {
+	resolver := p.pluginResolver
+	if resolver == nil {
+		registry := plugins.FromState(p.state)
+		if registry == nil {
+			return
+		}
+		resolver = registry
+	}
+
+	resolved := make(map[string]string)
+	for _, action := range p.actions {
+		key := pluginNameKey(action)
+		name, ok := action[key].(string)
+		if key == "" || !ok || name == "" {
+			continue
+		}
+		if fullName, ok := resolved[name]; ok {
+			action[key] = fullName
+			continue
+		}
+
+		resolution := resolver.Resolve(name)
+		switch resolution.Status {
+		case plugins.StatusResolved:
+			logger.Printf(p.ctx, "🔌 Resolved plugin %q to %q (confidence %.2f)", name, resolution.FullName, resolution.Confidence)
+			resolved[name] = resolution.FullName
+			action[key] = resolution.FullName
+		case plugins.StatusAmbiguous:
+			options := make([]string, 0, len(resolution.Candidates))
+			for _, candidate := range resolution.Candidates {
+				options = append(options, candidate.FullName)
+			}
+			logger.Printf(p.ctx, "❓ Plugin %q is ambiguous: %v", name, options)
+			p.clarification = &models.Clarification{
+				Question: fmt.Sprintf("Which plugin did you mean by %q?", name),
+				Options:  options,
+			}
+			p.actions = make([]map[string]any, 0)
+			return
+		default:
+			logger.Printf(p.ctx, "⚠️ Plugin %q is not installed, passing it through", name)
+			resolved[name] = name
+		}
+	}
+}
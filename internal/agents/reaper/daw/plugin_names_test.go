@@ -0,0 +1,91 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/plugins"
+)
+
+func TestFunctionalDSLParser_ResolvePluginNames(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{map[string]any{"index": 0, "name": "Drums"}},
+		"installed_fx": []any{
+			"VSTi: Serum (Xfer Records)",
+			"VST: ReaEQ (Cockos)",
+			"VST: ReaComp (Cockos)",
+		},
+	}
+
+	tests := []struct {
+		name              string
+		dslCode           string
+		want              []map[string]any
+		wantClarification *models.Clarification
+	}{
+		{
+			name:    "instrument on a new track",
+			dslCode: `track(instrument="serum")`,
+			want:    []map[string]any{{"action": "create_track", "index": 1, "instrument": "VSTi: Serum (Xfer Records)"}},
+		},
+		{
+			name:    "fx on an existing track",
+			dslCode: `track(id=1).add_fx(fxname="reaeq")`,
+			want:    []map[string]any{{"action": "add_track_fx", "track": 0, "fxname": "VST: ReaEQ (Cockos)"}},
+		},
+		{
+			name:    "not installed",
+			dslCode: `track(id=1).add_fx(fxname="Kontakt")`,
+			want:    []map[string]any{{"action": "add_track_fx", "track": 0, "fxname": "Kontakt"}},
+		},
+		{
+			name:    "ambiguous",
+			dslCode: `track(instrument="serum"); track(id=1).add_fx(fxname="rea")`,
+			want:    []map[string]any{},
+			wantClarification: &models.Clarification{
+				Question: `Which plugin did you mean by "rea"?`,
+				Options:  []string{"VST: ReaEQ (Cockos)", "VST: ReaComp (Cockos)"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(parser.Clarification(), tt.wantClarification) {
+				t.Errorf("Clarification() = %+v, want %+v", parser.Clarification(), tt.wantClarification)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_SetPluginResolver(t *testing.T) {
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(map[string]any{"installed_fx": []any{"VSTi: Serum (Xfer Records)"}})
+	parser.SetPluginResolver(plugins.NewRegistry([]string{"VST3i: Vital (Vital Audio)"}))
+
+	got, err := parser.ParseDSL(`track(instrument="vital")`)
+	if err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+	want := []map[string]any{{"action": "create_track", "index": 0, "instrument": "VST3i: Vital (Vital Audio)"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDSL() = %v, want %v", got, want)
+	}
+}
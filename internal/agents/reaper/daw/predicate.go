@@ -0,0 +1,362 @@
+package daw
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Filter predicates are parsed into a syntax tree before Filter runs (see filter_syntax.go):
+//
+//	predicate  := and ("||" and)*
+//	and        := not ("&&" not)*
+//	not        := "!" not | "(" predicate ")" | comparison
+//	comparison := sum (("==" | "!=" | "<" | ">" | "<=" | ">=") sum
+//	                  | ("contains" | "starts_with" | "ends_with" | "matches") STRING
+//	                  | "in" "[" value ("," value)* "]")?
+//
+// A comparison without an operator must be a lone property and is true when the property
+// is true: filter(tracks, !track.muted). Properties the item doesn't have never match.
+
+// predicate is a parsed filter predicate
+type predicate interface {
+	match(vars exprVars) (bool, error)
+}
+
+// parsePredicate parses a filter predicate like track.muted == true && track.name != "Master"
+func parsePredicate(src string) (predicate, error) {
+	e, err := newExprParser(src)
+	if err != nil {
+		return nil, err
+	}
+	pred, err := e.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if err := e.expectEnd(); err != nil {
+		return nil, err
+	}
+	return pred, nil
+}
+
+type orPredicate struct {
+	left, right predicate
+}
+
+func (p orPredicate) match(vars exprVars) (bool, error) {
+	if matched, err := p.left.match(vars); matched || err != nil {
+		return matched, err
+	}
+	return p.right.match(vars)
+}
+
+type andPredicate struct {
+	left, right predicate
+}
+
+func (p andPredicate) match(vars exprVars) (bool, error) {
+	if matched, err := p.left.match(vars); !matched || err != nil {
+		return false, err
+	}
+	return p.right.match(vars)
+}
+
+type notPredicate struct {
+	operand predicate
+}
+
+func (p notPredicate) match(vars exprVars) (bool, error) {
+	matched, err := p.operand.match(vars)
+	return !matched && err == nil, err
+}
+
+// comparePredicate compares two expressions. Numbers compare numerically; otherwise only
+// == and != apply, comparing booleans as booleans and anything else as text.
+type comparePredicate struct {
+	left  exprNode
+	op    string
+	right exprNode
+}
+
+func (p comparePredicate) match(vars exprVars) (bool, error) {
+	left, err := p.left.eval(vars)
+	if err != nil {
+		return false, ignoreMissing(err)
+	}
+	right, err := p.right.eval(vars)
+	if err != nil {
+		return false, ignoreMissing(err)
+	}
+
+	leftNum, leftOK := getNumericValue(left)
+	rightNum, rightOK := getNumericValue(right)
+	if leftOK && rightOK {
+		switch p.op {
+		case "<":
+			return leftNum < rightNum, nil
+		case ">":
+			return leftNum > rightNum, nil
+		case "<=":
+			return leftNum <= rightNum, nil
+		case ">=":
+			return leftNum >= rightNum, nil
+		case "==":
+			return leftNum == rightNum, nil
+		default:
+			return leftNum != rightNum, nil
+		}
+	}
+
+	var equal bool
+	leftBool, leftIsBool := left.(bool)
+	rightBool, rightIsBool := right.(bool)
+	if leftIsBool && rightIsBool {
+		equal = leftBool == rightBool
+	} else {
+		equal = formatIterationValue(left) == formatIterationValue(right)
+	}
+	switch p.op {
+	case "==":
+		return equal, nil
+	case "!=":
+		return !equal, nil
+	default:
+		// Ordering needs numbers on both sides
+		return false, nil
+	}
+}
+
+// stringMatchPredicate applies contains, starts_with, ends_with or matches
+type stringMatchPredicate struct {
+	operand exprNode
+	op      string
+	pattern string
+	re      *regexp.Regexp // matches only, compiled when parsed
+}
+
+func (p stringMatchPredicate) match(vars exprVars) (bool, error) {
+	value, err := p.operand.eval(vars)
+	if err != nil {
+		return false, ignoreMissing(err)
+	}
+	if p.re != nil {
+		return p.re.MatchString(formatIterationValue(value)), nil
+	}
+	return matchString(formatIterationValue(value), p.op, p.pattern)
+}
+
+// inPredicate is true when the operand equals one of values
+type inPredicate struct {
+	operand exprNode
+	values  []any
+}
+
+func (p inPredicate) match(vars exprVars) (bool, error) {
+	value, err := p.operand.eval(vars)
+	if err != nil {
+		return false, ignoreMissing(err)
+	}
+	for _, candidate := range p.values {
+		if compareValuesForIn(value, candidate) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// truthyPredicate is a lone property, true when the property is true
+type truthyPredicate struct {
+	ref refNode
+}
+
+func (p truthyPredicate) match(vars exprVars) (bool, error) {
+	value, err := p.ref.eval(vars)
+	if err != nil {
+		return false, ignoreMissing(err)
+	}
+	b, _ := value.(bool)
+	return b, nil
+}
+
+// ignoreMissing turns a missing property into a plain non-match
+func ignoreMissing(err error) error {
+	if errors.Is(err, errMissingProperty) {
+		return nil
+	}
+	return err
+}
+
+func (e *exprParser) parseOr() (predicate, error) {
+	left, err := e.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for e.peekOp() == "||" {
+		e.pos++
+		right, err := e.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orPredicate{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (e *exprParser) parseAnd() (predicate, error) {
+	left, err := e.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for e.peekOp() == "&&" {
+		e.pos++
+		right, err := e.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andPredicate{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (e *exprParser) parseNot() (predicate, error) {
+	switch e.peekOp() {
+	case "!":
+		e.pos++
+		operand, err := e.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notPredicate{operand: operand}, nil
+	case "(":
+		// Either a grouped predicate, (a || b) && c, or arithmetic starting a comparison,
+		// (clip.position + clip.length) > 16. Try the group first.
+		start := e.pos
+		e.pos++
+		if inner, err := e.parseOr(); err == nil && e.peekOp() == ")" {
+			e.pos++
+			if !e.continuesComparison() {
+				return inner, nil
+			}
+		}
+		e.pos = start
+	}
+	return e.parseComparison()
+}
+
+// continuesComparison reports whether the next token continues an expression or comparison
+func (e *exprParser) continuesComparison() bool {
+	switch e.peekOp() {
+	case "==", "!=", "<", ">", "<=", ">=", "+", "-", "*", "/":
+		return true
+	}
+	switch e.peekIdent() {
+	case "contains", "starts_with", "ends_with", "matches", "in":
+		return true
+	}
+	return false
+}
+
+func (e *exprParser) parseComparison() (predicate, error) {
+	left, err := e.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if op := e.peekOp(); op == "==" || op == "!=" || op == "<" || op == ">" || op == "<=" || op == ">=" {
+		e.pos++
+		right, err := e.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		return comparePredicate{left: left, op: op, right: right}, nil
+	}
+
+	switch op := e.peekIdent(); op {
+	case "contains", "starts_with", "ends_with", "matches":
+		e.pos++
+		if e.pos >= len(e.tokens) || e.tokens[e.pos].kind != tokenString {
+			return nil, fmt.Errorf("%s needs a quoted string in %q", op, e.src)
+		}
+		pred := stringMatchPredicate{operand: left, op: op, pattern: e.tokens[e.pos].text}
+		e.pos++
+		if op == "matches" {
+			re, err := regexp.Compile(pred.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regular expression %q: %w", pred.pattern, err)
+			}
+			pred.re = re
+		}
+		return pred, nil
+	case "in":
+		e.pos++
+		values, err := e.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return inPredicate{operand: left, values: values}, nil
+	}
+
+	ref, ok := left.(refNode)
+	if !ok {
+		return nil, fmt.Errorf("expected a comparison in %q", e.src)
+	}
+	return truthyPredicate{ref: ref}, nil
+}
+
+// parseList parses the [value, ...] list of an in predicate
+func (e *exprParser) parseList() ([]any, error) {
+	if e.peekOp() != "[" {
+		return nil, fmt.Errorf("in needs a list like [1, 2] in %q", e.src)
+	}
+	e.pos++
+	var values []any
+	for {
+		node, err := e.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		lit, ok := node.(literalNode)
+		if !ok {
+			return nil, fmt.Errorf("in lists hold literal values in %q", e.src)
+		}
+		values = append(values, lit.value)
+
+		switch e.peekOp() {
+		case ",":
+			e.pos++
+		case "]":
+			e.pos++
+			return values, nil
+		default:
+			return nil, fmt.Errorf("missing ] in %q", e.src)
+		}
+	}
+}
+
+// predicateVariable returns the variable of the first property reference in src (track in
+// track.name == "Bass"), used to infer the collection of filter calls that omit it
+func predicateVariable(src string) string {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return ""
+	}
+	for _, token := range tokens {
+		if name, _, ok := strings.Cut(token.text, "."); ok && token.kind == tokenIdent {
+			return name
+		}
+	}
+	return ""
+}
+
+// collectionForVariable names the collection an item variable iterates: track -> tracks
+func collectionForVariable(variable string) string {
+	switch variable {
+	case "":
+		return ""
+	case "fx":
+		return "fx_chain"
+	default:
+		return variable + "s"
+	}
+}
@@ -0,0 +1,157 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePredicate(t *testing.T) {
+	track := map[string]any{
+		"index": 2, "name": `Say "Hi", (again)`, "muted": true, "volume_db": -6.0, "gain": 0.001,
+		"fx": []any{"ReaEQ", "ReaComp"},
+	}
+	vars := predicateVars(track, "track")
+
+	tests := []struct {
+		pred    string
+		want    bool
+		wantErr string
+	}{
+		{pred: `track.name == "Say \"Hi\", (again)"`, want: true},
+		{pred: `track.name contains ", (again)"`, want: true},
+		{pred: `track.volume_db == -6`, want: true},
+		{pred: `track.volume_db > -6.5`, want: true},
+		{pred: `track.volume_db >= -6 && track.volume_db <= -6`, want: true},
+		{pred: `track.gain == 1e-3`, want: true},
+		{pred: `track.gain < 1.5E-3`, want: true},
+		{pred: `track.index == 2.0`, want: true},
+		{pred: `track.index in [1, 2, 3]`, want: true},
+		{pred: `track.name in ["Bass", "Drums"]`, want: false},
+		{pred: `track.fx[1] == "ReaComp"`, want: true},
+		{pred: `track.fx[5] == "ReaComp"`, want: false},
+		{pred: `(track.index + 1) * 2 == 6`, want: true},
+		{pred: `(track.index == 1 || track.muted) && !(track.volume_db > 0)`, want: true},
+		{pred: `!track.muted`, want: false},
+		{pred: `track.solo == true`, want: false},
+		{pred: `track.solo != true`, want: false},
+		{pred: `track.name > 3`, want: false},
+		{pred: `track.name == "unterminated`, wantErr: "unterminated string"},
+		{pred: `track.name ==`, wantErr: "unexpected end of expression"},
+		{pred: `track.name matches "("`, wantErr: "invalid regular expression"},
+		{pred: `track.name contains Bass`, wantErr: "needs a quoted string"},
+		{pred: `track.index + 1`, wantErr: "expected a comparison"},
+		{pred: `track.index in 1`, wantErr: "needs a list"},
+		{pred: `track.volume_db / 0 > 1`, wantErr: "division by zero"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pred, func(t *testing.T) {
+			pred, err := parsePredicate(tt.pred)
+			var got bool
+			if err == nil {
+				got, err = pred.match(vars)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parsePredicate() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePredicate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_FilterSyntax(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums, Perc", "volume_db": -12.0},
+			map[string]any{"index": 1, "name": "Bass (DI)", "volume_db": -3.0},
+			map[string]any{"index": 2, "name": `Lead "Hero"`, "volume_db": 0.0},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "comma inside a string",
+			dslCode: `filter(tracks, track.name == "Drums, Perc").set_track(mute=true)`,
+			want:    []map[string]any{{"action": "set_track", "track": 0, "mute": true}},
+		},
+		{
+			name:    "parentheses inside a string",
+			dslCode: `filter(tracks, track.name == "Bass (DI)").set_track(solo=true)`,
+			want:    []map[string]any{{"action": "set_track", "track": 1, "solo": true}},
+		},
+		{
+			name:    "escaped quotes",
+			dslCode: `filter(tracks, track.name == "Lead \"Hero\"").delete()`,
+			want:    []map[string]any{{"action": "delete_track", "track": 2}},
+		},
+		{
+			name:    "negative number",
+			dslCode: `filter(tracks, track.volume_db < -6).set_track(volume_db=0)`,
+			want:    []map[string]any{{"action": "set_track", "track": 0, "volume_db": 0.0}},
+		},
+		{
+			name:    "exponent",
+			dslCode: `filter(tracks, track.volume_db > -5e0).set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 1, "mute": true},
+				{"action": "set_track", "track": 2, "mute": true},
+			},
+		},
+		{
+			name:    "two filters in one script",
+			dslCode: `filter(tracks, track.index == 0).set_track(mute=true); filter(tracks, track.name == "Bass (DI)").set_track(mute=false)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": true},
+				{"action": "set_track", "track": 1, "mute": false},
+			},
+		},
+		{
+			name:    "collection inferred from the predicate",
+			dslCode: `filter(track.index >= 2).delete()`,
+			want:    []map[string]any{{"action": "delete_track", "track": 2}},
+		},
+		{
+			name:    "malformed predicate is an error",
+			dslCode: `filter(tracks, track.name == ).delete()`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
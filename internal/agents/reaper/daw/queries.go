@@ -0,0 +1,236 @@
+package daw
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// Aggregation queries answer questions about the project ("how many muted tracks do I
+// have?") instead of changing it. Each one adds a models.QueryAnswer; a script may contain
+// only queries, in which case it produces no actions.
+//
+//	count(tracks)
+//	filter(tracks, track.muted == true).count()
+//	max(clips, clip.length)
+//	filter(clips, clip.selected == true).sum(clip.length)
+//
+// sum, min and max take a property or an arithmetic expression over the item.
+
+// Count handles count() calls: the number of items in a collection or filter result.
+func (r *ReaperDSL) Count(args gs.Args) error {
+	return r.parser.aggregate("count", args)
+}
+
+// Sum handles sum() calls: the total of a property over a collection or filter result.
+func (r *ReaperDSL) Sum(args gs.Args) error {
+	return r.parser.aggregate("sum", args)
+}
+
+// Min handles min() calls: the smallest value of a property and the item holding it.
+func (r *ReaperDSL) Min(args gs.Args) error {
+	return r.parser.aggregate("min", args)
+}
+
+// Max handles max() calls: the largest value of a property and the item holding it.
+func (r *ReaperDSL) Max(args gs.Args) error {
+	return r.parser.aggregate("max", args)
+}
+
+// Answers returns the query answers collected by the last ParseDSL call.
+func (p *FunctionalDSLParser) Answers() []models.QueryAnswer {
+	return p.answers
+}
+
+// aggregate runs one aggregation function and records its answer
+func (p *FunctionalDSLParser) aggregate(fn string, args gs.Args) error {
+	expr := queryExpression(args)
+	if fn != "count" && expr == "" {
+		return fmt.Errorf("%s requires a property, e.g. %s(clips, clip.length)", fn, fn)
+	}
+
+	items, collectionName, filtered, err := p.queryItems(fn, args, expr)
+	if err != nil {
+		return err
+	}
+	iterVar := p.getIterVarFromCollection(collectionName)
+
+	query := fmt.Sprintf("%s(%s)", fn, collectionName)
+	switch {
+	case filtered:
+		query = fmt.Sprintf("filter(%s, ...).%s(%s)", collectionName, fn, expr)
+	case expr != "":
+		query = fmt.Sprintf("%s(%s, %s)", fn, collectionName, expr)
+	}
+
+	answer := models.QueryAnswer{Query: query}
+	if fn == "count" {
+		count := float64(len(items))
+		answer.Value = &count
+		answer.Message = fmt.Sprintf("%d %s", len(items), collectionNoun(collectionName, len(items), filtered))
+		p.answers = append(p.answers, answer)
+		logger.Printf(p.ctx, "🔢 Query: %s = %s", query, answer.Message)
+		return nil
+	}
+
+	var best map[string]any
+	var result float64
+	seen := 0
+	for _, raw := range items {
+		item, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+		value, err := evalExpr(expr, predicateVars(item, iterVar))
+		if err != nil {
+			// Items without the property (e.g. an empty FX slot) don't count
+			continue
+		}
+		switch {
+		case seen == 0:
+			result, best = value, item
+		case fn == "sum":
+			result += value
+		case fn == "min" && value < result, fn == "max" && value > result:
+			result, best = value, item
+		}
+		seen++
+	}
+
+	if seen == 0 && len(items) > 0 {
+		return fmt.Errorf("%s: no %s have a numeric value for %s", fn, collectionName, expr)
+	}
+
+	switch {
+	case fn == "sum":
+		answer.Value = &result
+		answer.Message = fmt.Sprintf("Total %s: %s", expr, formatQueryValue(result))
+	case seen == 0:
+		answer.Message = fmt.Sprintf("No %s to compare", collectionNoun(collectionName, 0, filtered))
+	default:
+		answer.Value = &result
+		answer.Item = best
+		answer.Message = fmt.Sprintf("%s %s: %s (%s)", fn, expr, formatQueryValue(result), describeQueryItem(best, iterVar))
+	}
+	p.answers = append(p.answers, answer)
+	logger.Printf(p.ctx, "🔢 Query: %s = %s", query, answer.Message)
+	return nil
+}
+
+// queryExpression returns the property or expression argument of a query, if any.
+// Positional arguments share one key, so in max(clips, clip.length) only the expression
+// remains; the collection is inferred from it.
+func queryExpression(args gs.Args) string {
+	for _, key := range []string{"property", ""} {
+		value, ok := args[key]
+		if !ok || value.Kind != gs.ValueString {
+			continue
+		}
+		if s := strings.TrimSpace(value.Str); isPropertyRef(s) || isArithmeticExpr(s) {
+			return s
+		}
+	}
+	return ""
+}
+
+// queryItems resolves the items a query runs over: a named collection, the preceding
+// filter's result, or the collection the expression's variable belongs to
+func (p *FunctionalDSLParser) queryItems(fn string, args gs.Args, expr string) ([]any, string, bool, error) {
+	if value, ok := args[""]; ok && value.Kind == gs.ValueString {
+		name := strings.TrimSpace(value.Str)
+		if collection, err := p.resolveCollection(name); err == nil {
+			return collection, name, false, nil
+		}
+	}
+
+	if chain := p.takeChain(); chain != nil {
+		return chain.items, chain.from, true, nil
+	}
+
+	if expr != "" {
+		variable, _, _ := strings.Cut(strings.TrimLeft(expr, "(- "), ".")
+		name := collectionForVariable(variable)
+		if collection, err := p.resolveCollection(name); err == nil {
+			return collection, name, false, nil
+		}
+		if name != "" {
+			// Nothing of that kind in state
+			return nil, name, false, nil
+		}
+	}
+
+	return nil, "", false, fmt.Errorf("%s requires a collection (tracks, clips or fx_chain), e.g. %s(tracks) or filter(tracks, ...).%s()", fn, fn, fn)
+}
+
+// collectionNoun names count items of a collection: "3 tracks", "1 matching clip"
+func collectionNoun(collectionName string, count int, filtered bool) string {
+	noun := collectionName
+	switch {
+	case collectionName == "fx_chain":
+		noun = "FX"
+	case count == 1:
+		noun = strings.TrimSuffix(collectionName, "s")
+	}
+	if filtered {
+		return "matching " + noun
+	}
+	return noun
+}
+
+// describeQueryItem names the item a min/max answer points at
+func describeQueryItem(item map[string]any, iterVar string) string {
+	name, _ := item["name"].(string)
+	var desc string
+	switch iterVar {
+	case "track":
+		if index, ok := actionInt(item, "index"); ok {
+			desc = fmt.Sprintf("track %d", index+1)
+		}
+	case "clip":
+		if track, ok := actionInt(item, "track"); ok {
+			desc = fmt.Sprintf("clip on track %d", track+1)
+		}
+		if position, ok := getNumericValue(item["position"]); ok {
+			desc = strings.TrimSpace(fmt.Sprintf("%s at %ss", desc, formatQueryValue(position)))
+		}
+	}
+	switch {
+	case desc == "" && name == "":
+		return iterVar
+	case desc == "":
+		return fmt.Sprintf("%q", name)
+	case name == "":
+		return desc
+	default:
+		return fmt.Sprintf("%s %q", desc, name)
+	}
+}
+
+// formatQueryValue renders an answer value without float noise
+func formatQueryValue(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1e6)/1e6, 'f', -1, 64)
+}
+
+// isQueryDSL reports whether code contains an aggregation query, top-level or chained
+func isQueryDSL(code string) bool {
+	for _, fn := range []string{"count(", "sum(", "min(", "max("} {
+		if strings.HasPrefix(code, fn) || strings.Contains(code, "."+fn) || strings.Contains(code, "; "+fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// answerText joins the answers' messages into the response's answer field
+func answerText(answers []models.QueryAnswer) string {
+	messages := make([]string, 0, len(answers))
+	for _, answer := range answers {
+		messages = append(messages, answer.Message)
+	}
+	return strings.Join(messages, "\n")
+}
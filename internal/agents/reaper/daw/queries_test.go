@@ -0,0 +1,171 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_Queries(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{
+				"index": 0, "name": "Drums", "muted": true, "volume_db": -6.0,
+				"clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 4.0, "name": "Beat"},
+					map[string]any{"index": 1, "position": 8.0, "length": 2.0, "name": "Fill"},
+				},
+			},
+			map[string]any{
+				"index": 1, "name": "Pad", "muted": false, "volume_db": -12.0,
+				"clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 16.0, "name": "Chords"},
+				},
+			},
+			map[string]any{"index": 2, "name": "Vox", "muted": true, "volume_db": 0.0},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		dslCode     string
+		wantQuery   []string
+		wantValue   []any // float64, or nil for no value
+		wantMessage []string
+		wantItem    string // name of the min/max item, if any
+		wantActions int
+	}{
+		{
+			name:        "count a collection",
+			dslCode:     "count(tracks)",
+			wantQuery:   []string{"count(tracks)"},
+			wantValue:   []any{3.0},
+			wantMessage: []string{"3 tracks"},
+		},
+		{
+			name:        "count a filter result",
+			dslCode:     "filter(tracks, track.muted == true).count()",
+			wantQuery:   []string{"filter(tracks, ...).count()"},
+			wantValue:   []any{2.0},
+			wantMessage: []string{"2 matching tracks"},
+		},
+		{
+			name:        "count one item",
+			dslCode:     `filter(tracks, track.name == "Pad").count()`,
+			wantQuery:   []string{"filter(tracks, ...).count()"},
+			wantValue:   []any{1.0},
+			wantMessage: []string{"1 matching track"},
+		},
+		{
+			name:        "max infers the collection",
+			dslCode:     "max(clips, clip.length)",
+			wantQuery:   []string{"max(clips, clip.length)"},
+			wantValue:   []any{16.0},
+			wantMessage: []string{`max clip.length: 16 (clip on track 2 at 0s "Chords")`},
+			wantItem:    "Chords",
+		},
+		{
+			name:        "min over an expression",
+			dslCode:     "min(tracks, track.volume_db)",
+			wantQuery:   []string{"min(tracks, track.volume_db)"},
+			wantValue:   []any{-12.0},
+			wantMessage: []string{`min track.volume_db: -12 (track 2 "Pad")`},
+			wantItem:    "Pad",
+		},
+		{
+			name:        "sum a filter result",
+			dslCode:     "filter(clips, clip.track == 0).sum(clip.length)",
+			wantQuery:   []string{"filter(clips, ...).sum(clip.length)"},
+			wantValue:   []any{6.0},
+			wantMessage: []string{"Total clip.length: 6"},
+		},
+		{
+			name:        "max over no items has no value",
+			dslCode:     `filter(clips, clip.name == "Missing").max(clip.length)`,
+			wantQuery:   []string{"filter(clips, ...).max(clip.length)"},
+			wantValue:   []any{nil},
+			wantMessage: []string{"No matching clips to compare"},
+		},
+		{
+			name:        "several queries",
+			dslCode:     "count(tracks); count(clips)",
+			wantQuery:   []string{"count(tracks)", "count(clips)"},
+			wantValue:   []any{3.0, 3.0},
+			wantMessage: []string{"3 tracks", "3 clips"},
+		},
+		{
+			name:        "query alongside an action",
+			dslCode:     "filter(tracks, track.muted == true).count(); track(id=1).set_track(mute=false)",
+			wantQuery:   []string{"filter(tracks, ...).count()"},
+			wantValue:   []any{2.0},
+			wantMessage: []string{"2 matching tracks"},
+			wantActions: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			actions, err := parser.ParseDSL(tt.dslCode)
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if len(actions) != tt.wantActions {
+				t.Errorf("ParseDSL() = %d actions, want %d: %v", len(actions), tt.wantActions, actions)
+			}
+
+			answers := parser.Answers()
+			var gotQuery, gotMessage []string
+			var gotValue []any
+			for _, answer := range answers {
+				gotQuery = append(gotQuery, answer.Query)
+				gotMessage = append(gotMessage, answer.Message)
+				if answer.Value == nil {
+					gotValue = append(gotValue, nil)
+				} else {
+					gotValue = append(gotValue, *answer.Value)
+				}
+			}
+			if !reflect.DeepEqual(gotQuery, tt.wantQuery) {
+				t.Errorf("queries = %v, want %v", gotQuery, tt.wantQuery)
+			}
+			if !reflect.DeepEqual(gotValue, tt.wantValue) {
+				t.Errorf("values = %v, want %v", gotValue, tt.wantValue)
+			}
+			if !reflect.DeepEqual(gotMessage, tt.wantMessage) {
+				t.Errorf("messages = %v, want %v", gotMessage, tt.wantMessage)
+			}
+			if tt.wantItem != "" && (len(answers) == 0 || answers[0].Item["name"] != tt.wantItem) {
+				t.Errorf("item = %v, want %q", answers[0].Item, tt.wantItem)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_QueryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		dslCode string
+	}{
+		{name: "sum without a property", dslCode: "filter(tracks, track.muted == true).sum()"},
+		{name: "count without a collection", dslCode: "count()"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "A"}}})
+
+			if _, err := parser.ParseDSL(tt.dslCode); err == nil {
+				t.Errorf("ParseDSL(%q) expected an error", tt.dslCode)
+			}
+		})
+	}
+}
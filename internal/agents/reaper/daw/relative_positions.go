@@ -0,0 +1,225 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+)
+
+// Anchors for new_clip(after=...) and move_clip(after=...)
+const (
+	anchorLastClip   = "last_clip"   // End of the last clip on the track
+	anchorProjectEnd = "project_end" // End of the last clip on any track
+)
+
+// clipDestination is where new_clip or move_clip places a clip, resolved against the state's
+// clip positions and tempo: an absolute position, a point after an anchor, or (move_clip only)
+// a shift from the clip's current position. Users phrase positions relatively ("2 bars after the
+// last clip", "4 bars earlier"), which the model turns into wrong absolute numbers; resolving
+// them here keeps the arithmetic out of the model.
+type clipDestination struct {
+	position *float64    // Absolute position in seconds
+	anchor   string      // anchorLastClip or anchorProjectEnd
+	offset   clipOffset  // From the anchor
+	shift    *clipOffset // From the clip's current position
+}
+
+// clipOffset is a distance in bars or seconds, negative for earlier
+type clipOffset struct {
+	bars, seconds float64
+}
+
+// from returns the position offset from position. Bars follow tempo changes in between.
+func (o clipOffset) from(p *FunctionalDSLParser, position float64) float64 {
+	if o.bars != 0 {
+		position = p.tempo().AddBars(position, o.bars)
+	}
+	return position + o.seconds
+}
+
+// relativeLength reads a bars argument or its counterpart in the request's length unit. Both
+// may be negative.
+func (p *FunctionalDSLParser) relativeLength(args gs.Args, barsKey, lengthKey string) (clipOffset, bool) {
+	if value, ok := args[barsKey]; ok && value.Kind == gs.ValueNumber {
+		return clipOffset{bars: value.Num}, true
+	}
+	if value, ok := args[lengthKey]; ok && value.Kind == gs.ValueNumber {
+		if p.lengthUnit == LengthUnitBars {
+			return clipOffset{bars: value.Num}, true
+		}
+		return clipOffset{seconds: value.Num}, true
+	}
+	return clipOffset{}, false
+}
+
+// anchorDestination reads after= with offset_bars/offset. ok is false without after=.
+func (p *FunctionalDSLParser) anchorDestination(method string, args gs.Args) (dest clipDestination, ok bool, err error) {
+	afterValue, ok := args["after"]
+	if !ok {
+		if _, hasOffset := p.relativeLength(args, "offset_bars", "offset"); hasOffset {
+			return dest, false, fmt.Errorf("%s offset_bars/offset needs an anchor: after=%q or after=%q", method, anchorLastClip, anchorProjectEnd)
+		}
+		return dest, false, nil
+	}
+	if afterValue.Kind != gs.ValueString || (afterValue.Str != anchorLastClip && afterValue.Str != anchorProjectEnd) {
+		return dest, false, fmt.Errorf("%s after must be %q or %q", method, anchorLastClip, anchorProjectEnd)
+	}
+	dest.anchor = afterValue.Str
+	dest.offset, _ = p.relativeLength(args, "offset_bars", "offset")
+	return dest, true, nil
+}
+
+// resolveDestination returns the destination in seconds for a clip on trackIndex. current is
+// the clip's position, needed for a shift; nil when unknown.
+func (p *FunctionalDSLParser) resolveDestination(method string, dest clipDestination, trackIndex int, current *float64) (float64, error) {
+	var position float64
+	switch {
+	case dest.position != nil:
+		position = *dest.position
+	case dest.shift != nil:
+		if current == nil {
+			return 0, fmt.Errorf("%s relative_bars/relative needs the clip's current position: identify it by clip, old_position or bar", method)
+		}
+		position = dest.shift.from(p, *current)
+	default:
+		anchor, err := p.anchorPosition(dest.anchor, trackIndex)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", method, err)
+		}
+		position = dest.offset.from(p, anchor)
+	}
+	if position < 0 {
+		return 0, fmt.Errorf("%s resolves to %.3gs, before the start of the project", method, position)
+	}
+	return position, nil
+}
+
+// anchorPosition returns where an anchor is in seconds: the end of the last clip on trackIndex
+// (anchorLastClip) or on any track (anchorProjectEnd). Clips created earlier in the script count.
+// An empty project ends at 0; an empty track has no last clip.
+func (p *FunctionalDSLParser) anchorPosition(anchor string, trackIndex int) (float64, error) {
+	if anchor == anchorProjectEnd {
+		trackIndex = -1
+	}
+	end, found := p.stateClipsEnd(trackIndex)
+	for _, action := range p.actions {
+		if track, ok := actionInt(action, "track"); !ok || (trackIndex >= 0 && track != trackIndex) {
+			continue
+		}
+		var clipEnd float64
+		switch action["action"] {
+		case "create_clip":
+			position, _ := getNumericValue(action["position"])
+			length, _ := getNumericValue(action["length"])
+			clipEnd = position + length
+		case "create_clip_at_bar":
+			bar, _ := getNumericValue(action["bar"])
+			lengthBars, _ := getNumericValue(action["length_bars"])
+			clipEnd = p.barStart(bar + lengthBars)
+		default:
+			continue
+		}
+		end, found = max(end, clipEnd), true
+	}
+	if !found && anchor == anchorLastClip {
+		return 0, fmt.Errorf("track %d has no clips to place after", trackIndex+1)
+	}
+	return end, nil
+}
+
+// stateClipsEnd returns the end of the last clip in the state on trackIndex, or on any track
+// for -1
+func (p *FunctionalDSLParser) stateClipsEnd(trackIndex int) (end float64, found bool) {
+	tracks, _ := p.data["tracks"].([]any)
+	for i, item := range tracks {
+		track, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index := i
+		if v, ok := getNumericValue(track["index"]); ok {
+			index = int(v)
+		}
+		if trackIndex >= 0 && index != trackIndex {
+			continue
+		}
+		clips, _ := track["clips"].([]any)
+		for _, clipItem := range clips {
+			clip, ok := clipItem.(map[string]any)
+			if !ok {
+				continue
+			}
+			position, ok := getNumericValue(clip["position"])
+			if !ok {
+				continue
+			}
+			length, _ := getNumericValue(clip["length"])
+			end, found = max(end, position+length), true
+		}
+	}
+	return end, found
+}
+
+// moveClipDestination reads where move_clip puts a clip: relative_bars/relative shift it, after=
+// places it after an anchor, and position (or bar) is absolute
+func (p *FunctionalDSLParser) moveClipDestination(args gs.Args) (clipDestination, error) {
+	if shift, ok := p.relativeLength(args, "relative_bars", "relative"); ok {
+		return clipDestination{shift: &shift}, nil
+	}
+	dest, ok, err := p.anchorDestination("move_clip", args)
+	if err != nil || ok {
+		return dest, err
+	}
+	if positionValue, ok := args["position"]; ok && positionValue.Kind == gs.ValueNumber {
+		dest.position = &positionValue.Num
+		return dest, nil
+	}
+	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		position := p.barStart(barValue.Num)
+		dest.position = &position
+		return dest, nil
+	}
+	return dest, fmt.Errorf("move_clip requires position (seconds), bar (number), relative_bars or after")
+}
+
+// destinationProps returns the destination arguments given, for filter summaries
+func destinationProps(args gs.Args) map[string]any {
+	props := map[string]any{}
+	for _, key := range []string{"position", "relative_bars", "relative", "after", "offset_bars", "offset"} {
+		if value, ok := args[key]; ok {
+			switch value.Kind {
+			case gs.ValueNumber:
+				props[key] = value.Num
+			case gs.ValueString:
+				props[key] = value.Str
+			}
+		}
+	}
+	return props
+}
+
+// clipPosition returns the position in seconds of the clip a ClipRef names on trackIndex, or
+// nil if the state doesn't have it
+func (p *FunctionalDSLParser) clipPosition(trackIndex int, ref ClipRef) *float64 {
+	switch {
+	case ref.Position != nil:
+		return ref.Position
+	case ref.Bar != nil:
+		position := p.barStart(float64(*ref.Bar))
+		return &position
+	case ref.Clip != nil:
+		clips, err := p.trackClipsByPosition(trackIndex)
+		if err != nil {
+			return nil
+		}
+		for _, clip := range clips {
+			if index, ok := actionInt(clip, "index"); !ok || index != *ref.Clip {
+				continue
+			}
+			if position, ok := getNumericValue(clip["position"]); ok {
+				return &position
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_RelativePositions(t *testing.T) {
+	// 120 BPM in 4/4: a bar is 2 seconds. The last clip on Bass ends at 12s, the project at 24s.
+	newState := func() map[string]any {
+		return map[string]any{
+			"project": map[string]any{"bpm": 120.0, "time_signature": "4/4"},
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Bass", "clips": []any{
+					map[string]any{"index": 0, "position": 0.0, "length": 2.0},
+					map[string]any{"index": 1, "position": 8.0, "length": 4.0},
+				}},
+				map[string]any{"index": 1, "name": "Keys", "clips": []any{
+					map[string]any{"index": 0, "position": 20.0, "length": 4.0},
+				}},
+				map[string]any{"index": 2, "name": "Pad"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "new clip two bars after the last clip",
+			dslCode: `track(id=1).new_clip(after="last_clip", offset_bars=2, length_bars=4)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 16.0, "length": 8.0},
+			},
+		},
+		{
+			name:    "new clip at the end of the project",
+			dslCode: `track(id=1).new_clip(after="project_end")`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 0, "position": 24.0, "length": 4.0},
+			},
+		},
+		{
+			name:    "negative offset goes back from the anchor",
+			dslCode: `track(id=3).new_clip(after="project_end", offset_bars=-1, length_bars=1)`,
+			want: []map[string]any{
+				{"action": "create_clip", "track": 2, "position": 22.0, "length": 2.0},
+			},
+		},
+		{
+			name:    "clips created earlier in the script count",
+			dslCode: `track(id=3).new_clip(bar=1, length_bars=2).new_clip(after="last_clip")`,
+			want: []map[string]any{
+				{"action": "create_clip_at_bar", "track": 2, "bar": 1, "length_bars": 2},
+				{"action": "create_clip", "track": 2, "position": 4.0, "length": 4.0},
+			},
+		},
+		{
+			name:    "empty track has no last clip",
+			dslCode: `track(id=3).new_clip(after="last_clip")`,
+			wantErr: "track 3 has no clips to place after",
+		},
+		{
+			name:    "offset needs an anchor",
+			dslCode: `track(id=1).new_clip(bar=1, offset_bars=2)`,
+			wantErr: "offset_bars/offset needs an anchor",
+		},
+		{
+			name:    "unknown anchor",
+			dslCode: `track(id=1).new_clip(after="first_clip")`,
+			wantErr: `new_clip after must be "last_clip" or "project_end"`,
+		},
+		{
+			name:    "move a clip two bars earlier",
+			dslCode: `track(id=1).move_clip(clip=1, relative_bars=-2)`,
+			want: []map[string]any{
+				{"action": "set_clip_position", "track": 0, "position": 4.0, "clip": 1},
+			},
+		},
+		{
+			name:    "move the nth clip a bar later",
+			dslCode: `track(id=1).nth_clip(2).move_clip(relative_bars=1)`,
+			want: []map[string]any{
+				{"action": "set_clip_position", "track": 0, "position": 10.0, "old_position": 8.0},
+			},
+		},
+		{
+			name:    "move a clip to the end of the project",
+			dslCode: `track(id=1).move_clip(clip=0, after="project_end")`,
+			want: []map[string]any{
+				{"action": "set_clip_position", "track": 0, "position": 24.0, "clip": 0},
+			},
+		},
+		{
+			name:    "move before the start of the project",
+			dslCode: `track(id=1).move_clip(clip=1, relative_bars=-8)`,
+			wantErr: "before the start of the project",
+		},
+		{
+			name:    "relative move of a clip missing from the state",
+			dslCode: `track(id=3).move_clip(clip=0, relative_bars=1)`,
+			wantErr: "needs the clip's current position",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// DefaultRenderFormat is the render_project format when none is given
+const DefaultRenderFormat = "wav"
+
+// renderFormats are the file formats render_project accepts
+var renderFormats = map[string]bool{"wav": true, "aiff": true, "flac": true, "mp3": true, "ogg": true}
+
+// RenderProject handles render_project() calls: renders the project, or a bar range of it, to
+// a file the extension writes through REAPER's render API. end_bar is exclusive like
+// add_region, and stems=true renders each track to its own file.
+// Example: render_project(format="wav", start_bar=1, end_bar=33)
+func (r *ReaperDSL) RenderProject(args gs.Args) error {
+	p := r.parser
+
+	format := DefaultRenderFormat
+	if formatValue, ok := args["format"]; ok {
+		if formatValue.Kind != gs.ValueString {
+			return fmt.Errorf("render_project format must be a string")
+		}
+		format = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(formatValue.Str), "."))
+		if !renderFormats[format] {
+			return fmt.Errorf("render_project format must be wav, aiff, flac, mp3 or ogg, got %q", formatValue.Str)
+		}
+	}
+	action := map[string]any{"action": "render_project", "format": format}
+
+	startBarValue, hasStartBar := args["start_bar"]
+	endBarValue, hasEndBar := args["end_bar"]
+	switch {
+	case hasStartBar != hasEndBar:
+		return fmt.Errorf("render_project requires both start_bar and end_bar, or neither for the whole project")
+	case hasStartBar && (startBarValue.Kind != gs.ValueNumber || endBarValue.Kind != gs.ValueNumber):
+		return fmt.Errorf("render_project start_bar and end_bar must be numbers")
+	case hasStartBar:
+		startBar, endBar := int(startBarValue.Num), int(endBarValue.Num)
+		if startBar < 1 {
+			return fmt.Errorf("render_project start_bar must be 1 or greater, got %d", startBar)
+		}
+		if endBar <= startBar {
+			return fmt.Errorf("render_project end_bar (%d) must be after start_bar (%d)", endBar, startBar)
+		}
+		action["start_bar"] = startBar
+		action["end_bar"] = endBar
+	}
+
+	stems := false
+	if stemsValue, ok := args["stems"]; ok {
+		if stemsValue.Kind != gs.ValueBool {
+			return fmt.Errorf("render_project stems must be true or false")
+		}
+		stems = stemsValue.Bool
+	}
+	action["stems"] = stems
+
+	p.actions = append(p.actions, action)
+	logger.Printf(p.ctx, "✅ RenderProject: %+v", action)
+	return nil
+}
+
+// isRenderDSL reports whether code contains a top-level render_project statement
+func isRenderDSL(code string) bool {
+	code = strings.TrimSpace(code)
+	return strings.HasPrefix(code, "render_project(") || strings.Contains(code, ";render_project(") ||
+		strings.Contains(code, "; render_project(")
+}
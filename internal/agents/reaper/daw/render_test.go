@@ -0,0 +1,67 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_RenderProject(t *testing.T) {
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "bar range to wav",
+			dslCode: `render_project(format="wav", start_bar=1, end_bar=33)`,
+			want:    []map[string]any{{"action": "render_project", "format": "wav", "start_bar": 1, "end_bar": 33, "stems": false}},
+		},
+		{
+			name:    "stems of the whole project",
+			dslCode: `render_project(stems=true)`,
+			want:    []map[string]any{{"action": "render_project", "format": "wav", "stems": true}},
+		},
+		{
+			name:    "format is normalized",
+			dslCode: `render_project(format=".MP3")`,
+			want:    []map[string]any{{"action": "render_project", "format": "mp3", "stems": false}},
+		},
+		{
+			name:    "after a track statement",
+			dslCode: `track(id=1).set_track(solo=true); render_project(format="flac")`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "solo": true},
+				{"action": "render_project", "format": "flac", "stems": false},
+			},
+		},
+		{name: "unknown format", dslCode: `render_project(format="wma")`, wantErr: true},
+		{name: "start_bar without end_bar", dslCode: `render_project(start_bar=5)`, wantErr: true},
+		{name: "empty range", dslCode: `render_project(start_bar=9, end_bar=9)`, wantErr: true},
+		{name: "bar below 1", dslCode: `render_project(start_bar=0, end_bar=9)`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "Drums"}}})
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package daw
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// Safety modes: what happens to a request that deletes more than the policy allows
+const (
+	SafetyConfirm = "confirm" // Ask the client to send the request again with confirm=true
+	SafetyRefuse  = "refuse"  // Never apply it
+	SafetyOff     = "off"     // Apply it
+)
+
+// maxSafetySummaryLines caps the deletions listed in a confirmation
+const maxSafetySummaryLines = 20
+
+// SafetyPolicy limits the deletions one request may make without the user's confirmation, so
+// an over-broad filter can't wipe the project
+type SafetyPolicy struct {
+	Mode             string // SafetyConfirm (also used for an unknown mode), SafetyRefuse or SafetyOff
+	MaxDeletedTracks int    // 0 disables the limit
+	MaxDeletedClips  int    // 0 disables the limit
+	Confirmed        bool   // The request was sent with confirm=true
+}
+
+type safetyPolicyKey struct{}
+
+// WithSafetyPolicy returns a context carrying the request's safety policy.
+func WithSafetyPolicy(ctx context.Context, policy SafetyPolicy) context.Context {
+	return context.WithValue(ctx, safetyPolicyKey{}, policy)
+}
+
+// SafetyPolicyFromContext returns the request's safety policy; without one, deletions aren't
+// limited.
+func SafetyPolicyFromContext(ctx context.Context) SafetyPolicy {
+	if policy, ok := ctx.Value(safetyPolicyKey{}).(SafetyPolicy); ok {
+		return policy
+	}
+	return SafetyPolicy{Mode: SafetyOff}
+}
+
+// Active reports whether the policy can hold back actions: it isn't off, and a confirmed
+// request isn't asked again
+func (p SafetyPolicy) Active() bool {
+	switch p.Mode {
+	case SafetyOff:
+		return false
+	case SafetyRefuse:
+		return true
+	}
+	return !p.Confirmed
+}
+
+// IsGuardedDeletion reports whether the safety policy counts action: a deleted track or clip
+func IsGuardedDeletion(action map[string]any) bool {
+	switch action["action"] {
+	case "delete_track", "delete_clip":
+		return true
+	}
+	return false
+}
+
+// CheckSafety returns why actions need confirmation, or nil when the policy lets them through.
+// Deleting every track of a non-empty project always needs it, whatever the limits.
+func CheckSafety(actions []map[string]any, state map[string]any, policy SafetyPolicy) *models.ConfirmationRequired {
+	if !policy.Active() {
+		return nil
+	}
+
+	// Actions are in execution order, so the indices of deleted tracks shift: count actions
+	deletedTracks, deletedClips := 0, 0
+	var deletions []map[string]any
+	for _, action := range actions {
+		if !IsGuardedDeletion(action) {
+			continue
+		}
+		deletions = append(deletions, action)
+		if action["action"] == "delete_clip" {
+			deletedClips++
+		} else {
+			deletedTracks++
+		}
+	}
+	trackCount := 0
+	if tracks, ok := stateTracks(state); ok {
+		trackCount = len(tracks)
+	}
+	clearsProject := trackCount > 0 && deletedTracks >= trackCount
+
+	var reasons []string
+	switch {
+	case clearsProject:
+		reasons = append(reasons, fmt.Sprintf("delete all %d tracks in the project", trackCount))
+	case policy.MaxDeletedTracks > 0 && deletedTracks > policy.MaxDeletedTracks:
+		reasons = append(reasons, fmt.Sprintf("delete %d tracks (more than %d)", deletedTracks, policy.MaxDeletedTracks))
+	}
+	if policy.MaxDeletedClips > 0 && deletedClips > policy.MaxDeletedClips {
+		reasons = append(reasons, fmt.Sprintf("delete %d clips (more than %d)", deletedClips, policy.MaxDeletedClips))
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	reason := "This would " + strings.Join(reasons, " and ") + "."
+	if policy.Mode == SafetyRefuse {
+		reason += " The server's safety policy doesn't allow that in one request."
+	} else {
+		reason += " Send the request again with confirm=true to go ahead."
+	}
+
+	previews := PreviewActions(deletions, state)
+	summary := make([]string, 0, min(len(previews), maxSafetySummaryLines+1))
+	for i, preview := range previews {
+		if i == maxSafetySummaryLines {
+			summary = append(summary, fmt.Sprintf("... and %d more", len(previews)-i))
+			break
+		}
+		summary = append(summary, preview.Summary)
+	}
+
+	return &models.ConfirmationRequired{
+		Reason:        reason,
+		DeletedTracks: deletedTracks,
+		DeletedClips:  deletedClips,
+		ClearsProject: clearsProject,
+		Summary:       summary,
+		Refused:       policy.Mode == SafetyRefuse,
+	}
+}
@@ -0,0 +1,134 @@
+package daw
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCheckSafety(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums"},
+			map[string]any{"index": 1, "name": "Bass"},
+			map[string]any{"index": 2, "name": "Keys"},
+			map[string]any{"index": 3, "name": "Vocals"},
+			map[string]any{"index": 4, "name": "FX"},
+		},
+	}
+	deleteTracks := func(n int) []map[string]any {
+		actions := make([]map[string]any, n)
+		for i := range actions {
+			// Execution order: each deletion shifts the next track down to index 0
+			actions[i] = map[string]any{"action": "delete_track", "track": 0}
+		}
+		return actions
+	}
+	deleteClips := func(n int) []map[string]any {
+		actions := make([]map[string]any, n)
+		for i := range actions {
+			actions[i] = map[string]any{"action": "delete_clip", "track": 1, "clip": 0}
+		}
+		return actions
+	}
+	confirm := SafetyPolicy{Mode: SafetyConfirm, MaxDeletedTracks: 2, MaxDeletedClips: 3}
+
+	tests := []struct {
+		name          string
+		actions       []map[string]any
+		policy        SafetyPolicy
+		wantReason    string // Empty: no confirmation needed
+		wantTracks    int
+		wantClears    bool
+		wantRefused   bool
+		wantFirstLine string
+	}{
+		{
+			name:    "within the limits",
+			actions: append(deleteTracks(2), deleteClips(3)...),
+			policy:  confirm,
+		},
+		{
+			name:          "too many tracks",
+			actions:       deleteTracks(3),
+			policy:        confirm,
+			wantReason:    "This would delete 3 tracks (more than 2). Send the request again with confirm=true",
+			wantTracks:    3,
+			wantFirstLine: `Delete track 1 "Drums"`,
+		},
+		{
+			name:       "too many clips",
+			actions:    deleteClips(4),
+			policy:     confirm,
+			wantReason: "delete 4 clips (more than 3)",
+		},
+		{
+			name:       "clearing the project needs confirmation without limits",
+			actions:    deleteTracks(5),
+			policy:     SafetyPolicy{Mode: SafetyConfirm},
+			wantReason: "delete all 5 tracks in the project",
+			wantTracks: 5,
+			wantClears: true,
+		},
+		{
+			name:    "confirmed",
+			actions: deleteTracks(5),
+			policy:  SafetyPolicy{Mode: SafetyConfirm, MaxDeletedTracks: 2, Confirmed: true},
+		},
+		{
+			name:        "refused even when confirmed",
+			actions:     deleteTracks(3),
+			policy:      SafetyPolicy{Mode: SafetyRefuse, MaxDeletedTracks: 2, Confirmed: true},
+			wantReason:  "doesn't allow that in one request",
+			wantTracks:  3,
+			wantRefused: true,
+		},
+		{
+			name:    "off",
+			actions: deleteTracks(5),
+			policy:  SafetyPolicy{Mode: SafetyOff, MaxDeletedTracks: 2},
+		},
+		{
+			name:    "other actions don't count",
+			actions: []map[string]any{{"action": "set_track", "track": 0, "mute": true}, {"action": "remove_fx", "track": 0, "fx": 0}},
+			policy:  SafetyPolicy{Mode: SafetyConfirm, MaxDeletedTracks: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckSafety(tt.actions, state, tt.policy)
+			if tt.wantReason == "" {
+				if got != nil {
+					t.Fatalf("CheckSafety() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatalf("CheckSafety() = nil, want reason containing %q", tt.wantReason)
+			}
+			if !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q, want it to contain %q", got.Reason, tt.wantReason)
+			}
+			if got.DeletedTracks != tt.wantTracks || got.ClearsProject != tt.wantClears || got.Refused != tt.wantRefused {
+				t.Errorf("CheckSafety() = %+v, want %d deleted tracks, clears project %v, refused %v", got, tt.wantTracks, tt.wantClears, tt.wantRefused)
+			}
+			if len(got.Summary) != len(tt.actions) {
+				t.Errorf("Summary has %d lines, want %d", len(got.Summary), len(tt.actions))
+			}
+			if tt.wantFirstLine != "" && got.Summary[0] != tt.wantFirstLine {
+				t.Errorf("Summary[0] = %q, want %q", got.Summary[0], tt.wantFirstLine)
+			}
+		})
+	}
+}
+
+func TestSafetyPolicyFromContext(t *testing.T) {
+	if SafetyPolicyFromContext(context.Background()).Active() {
+		t.Error("a context without a policy should not limit deletions")
+	}
+	ctx := WithSafetyPolicy(context.Background(), SafetyPolicy{Mode: "unknown"})
+	if !SafetyPolicyFromContext(ctx).Active() {
+		t.Error("an unknown mode should ask for confirmation")
+	}
+}
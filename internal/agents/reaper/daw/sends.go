@@ -0,0 +1,136 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// AddSend handles .add_send() calls: creates a send from the current (or filtered) tracks
+// to a destination track. dest is 1-based like track(id=...); dest_name matches a track name.
+// Example: track(id=1).add_send(dest=2, level_db=-6, pre_fader=false)
+func (r *ReaperDSL) AddSend(args gs.Args) error {
+	return r.parser.addSendActions("add_send", args)
+}
+
+// SetSend handles .set_send() calls: changes level, pre/post fader or mute of an existing send.
+// Example: track(id=1).set_send(dest=2, level_db=-3)
+func (r *ReaperDSL) SetSend(args gs.Args) error {
+	return r.parser.addSendActions("set_send", args)
+}
+
+// RemoveSend handles .remove_send() calls: removes the send to the destination track.
+// Example: track(id=1).remove_send(dest=2)
+func (r *ReaperDSL) RemoveSend(args gs.Args) error {
+	return r.parser.addSendActions("remove_send", args)
+}
+
+// addSendActions emits one send action per source track
+func (p *FunctionalDSLParser) addSendActions(actionType string, args gs.Args) error {
+	dest, err := p.sendDestination(actionType, args)
+	if err != nil {
+		return err
+	}
+
+	props := make(map[string]any)
+	if actionType != "remove_send" {
+		if levelValue, ok := args["level_db"]; ok && levelValue.Kind == gs.ValueNumber {
+			props["level_db"] = levelValue.Num
+		}
+		if preFaderValue, ok := args["pre_fader"]; ok && preFaderValue.Kind == gs.ValueBool {
+			props["pre_fader"] = preFaderValue.Bool
+		}
+		if muteValue, ok := args["mute"]; ok && muteValue.Kind == gs.ValueBool {
+			props["mute"] = muteValue.Bool
+		}
+	}
+	if actionType == "set_send" && len(props) == 0 {
+		return fmt.Errorf("set_send requires at least one property: level_db, pre_fader, or mute")
+	}
+
+	newAction := func(source int) map[string]any {
+		action := map[string]any{
+			"action": actionType,
+			"track":  source,
+			"dest":   dest,
+		}
+		for k, v := range props {
+			action[k] = v
+		}
+		return action
+	}
+
+	// Check if we have a filtered collection to apply to
+	if filtered := p.takeFiltered(); len(filtered) > 0 {
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			source, ok := actionInt(trackMap, "index")
+			if !ok {
+				logger.Printf(p.ctx, "⚠️  %s: Could not extract track index from %+v", actionType, trackMap)
+				continue
+			}
+			if source == dest {
+				logger.Printf(p.ctx, "⚠️  %s: Skipping track %d, it is the send destination", actionType, source)
+				continue
+			}
+			p.actions = append(p.actions, newAction(source))
+		}
+		logger.Printf(p.ctx, "✅ %s: Applied to %d filtered tracks (dest=%d)", actionType, len(filtered), dest)
+		return nil
+	}
+
+	if p.currentTrackIndex < 0 {
+		return fmt.Errorf("no track context for %s call", actionType)
+	}
+	if p.currentTrackIndex == dest {
+		return fmt.Errorf("%s: track %d cannot send to itself", actionType, dest+1)
+	}
+	p.actions = append(p.actions, newAction(p.currentTrackIndex))
+	return nil
+}
+
+// sendDestination resolves dest (1-based track id) or dest_name to a 0-based track index
+func (p *FunctionalDSLParser) sendDestination(actionType string, args gs.Args) (int, error) {
+	if destValue, ok := args["dest"]; ok && destValue.Kind == gs.ValueNumber {
+		dest := int(destValue.Num)
+		if float64(dest) != destValue.Num || dest < 1 {
+			return 0, fmt.Errorf("%s dest must be a track id starting at 1, got %g", actionType, destValue.Num)
+		}
+		return dest - 1, nil
+	}
+
+	if nameValue, ok := args["dest_name"]; ok && nameValue.Kind == gs.ValueString {
+		tracks, _ := p.data["tracks"].([]any)
+		for i, item := range tracks {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := trackMap["name"].(string)
+			if !strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(nameValue.Str)) {
+				continue
+			}
+			if index, ok := actionInt(trackMap, "index"); ok {
+				return index, nil
+			}
+			return i, nil
+		}
+		// Tracks created earlier in the script, e.g. track(name="Reverb Bus"); track(id=1).add_send(dest_name="Reverb Bus")
+		for _, action := range p.actions {
+			name, _ := action["name"].(string)
+			if action["action"] == "create_track" && strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(nameValue.Str)) {
+				if index, ok := actionInt(action, "index"); ok {
+					return index, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("%s: no track named %q in state", actionType, nameValue.Str)
+	}
+
+	return 0, fmt.Errorf("%s requires dest (track id) or dest_name", actionType)
+}
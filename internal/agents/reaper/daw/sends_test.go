@@ -0,0 +1,115 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFunctionalDSLParser_Sends(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0, "name": "Vocals"},
+				map[string]any{"index": 1, "name": "Backing Vocals"},
+				map[string]any{"index": 2, "name": "Reverb Bus"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "add send by track id",
+			dslCode: `track(id=1).add_send(dest=3, level_db=-6, pre_fader=false)`,
+			want: []map[string]any{
+				{"action": "add_send", "track": 0, "dest": 2, "level_db": -6.0, "pre_fader": false},
+			},
+		},
+		{
+			name:    "add send by destination name",
+			dslCode: `track(id=1).add_send(dest_name="reverb bus")`,
+			want: []map[string]any{
+				{"action": "add_send", "track": 0, "dest": 2},
+			},
+		},
+		{
+			name:    "filtered tracks send to a bus",
+			dslCode: `filter(tracks, track.index < 2).add_send(dest_name="Reverb Bus", level_db=-12)`,
+			want: []map[string]any{
+				{"action": "add_send", "track": 0, "dest": 2, "level_db": -12.0},
+				{"action": "add_send", "track": 1, "dest": 2, "level_db": -12.0},
+			},
+		},
+		{
+			name:    "send to a track created in the script",
+			dslCode: `track(name="Delay Bus"); track(id=2).add_send(dest_name="Delay Bus", pre_fader=true)`,
+			want: []map[string]any{
+				{"action": "create_track", "name": "Delay Bus", "index": 3},
+				{"action": "add_send", "track": 1, "dest": 3, "pre_fader": true},
+			},
+		},
+		{
+			name:    "set send",
+			dslCode: `track(id=1).set_send(dest=3, level_db=-3, mute=true)`,
+			want: []map[string]any{
+				{"action": "set_send", "track": 0, "dest": 2, "level_db": -3.0, "mute": true},
+			},
+		},
+		{
+			name:    "remove send ignores properties",
+			dslCode: `track(id=1).remove_send(dest=3, level_db=-3)`,
+			want: []map[string]any{
+				{"action": "remove_send", "track": 0, "dest": 2},
+			},
+		},
+		{
+			name:    "set send needs a property",
+			dslCode: `track(id=1).set_send(dest=3)`,
+			wantErr: "set_send requires at least one property",
+		},
+		{
+			name:    "missing destination",
+			dslCode: `track(id=1).add_send(level_db=-6)`,
+			wantErr: "add_send requires dest",
+		},
+		{
+			name:    "unknown destination name",
+			dslCode: `track(id=1).add_send(dest_name="Plate")`,
+			wantErr: `no track named "Plate"`,
+		},
+		{
+			name:    "send to itself",
+			dslCode: `track(id=3).add_send(dest=3)`,
+			wantErr: "cannot send to itself",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(newState())
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
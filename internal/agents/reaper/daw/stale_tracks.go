@@ -0,0 +1,63 @@
+package daw
+
+import (
+	"fmt"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// WarningStaleTrackIndex is the warning code for an action that targets a track index
+// the client's state doesn't have (e.g. the state was captured before tracks were removed).
+const WarningStaleTrackIndex = "stale_track_index"
+
+// DetectStaleTrackIndices flags actions that target a track index beyond the track count
+// in state. Tracks created earlier in the same script (create_track) are valid targets.
+// Returns nil when the state has no track list, since there is nothing to check against.
+func DetectStaleTrackIndices(actions []map[string]any, state map[string]any) []models.ActionWarning {
+	tracks, ok := stateTracks(state)
+	if !ok {
+		return nil
+	}
+	trackCount := len(tracks)
+
+	var warnings []models.ActionWarning
+	createdTracks := make(map[int]bool)
+
+	for i, action := range actions {
+		actionType, _ := action["action"].(string)
+
+		if actionType == "create_track" {
+			if trackIndex, ok := actionInt(action, "index"); ok {
+				createdTracks[trackIndex] = true
+			}
+			continue
+		}
+
+		trackIndex, ok := actionInt(action, "track")
+		if !ok || trackIndex < trackCount || createdTracks[trackIndex] {
+			continue
+		}
+
+		warnings = append(warnings, models.ActionWarning{
+			Code: WarningStaleTrackIndex,
+			Message: fmt.Sprintf("action %d (%s) targets track index %d, but the project state only has %d track(s)",
+				i, actionType, trackIndex, trackCount),
+			ActionIndex: i,
+		})
+	}
+
+	return warnings
+}
+
+// stateTracks returns the track list from state, accepting both {"state": {...}} and {...} formats.
+func stateTracks(state map[string]any) ([]any, bool) {
+	if state == nil {
+		return nil, false
+	}
+	stateMap, ok := state["state"].(map[string]any)
+	if !ok {
+		stateMap = state
+	}
+	tracks, ok := stateMap["tracks"].([]any)
+	return tracks, ok
+}
@@ -0,0 +1,114 @@
+package daw
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectStaleTrackIndices(t *testing.T) {
+	twoTracks := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums"},
+			map[string]any{"index": 1, "name": "Bass"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		state       map[string]any
+		actions     []map[string]any
+		wantIndices []int
+	}{
+		{
+			name:  "existing tracks are valid",
+			state: twoTracks,
+			actions: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": true},
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaEQ"},
+			},
+			wantIndices: nil,
+		},
+		{
+			name:  "index beyond state track count",
+			state: twoTracks,
+			actions: []map[string]any{
+				{"action": "set_track", "track": 1, "mute": true},
+				{"action": "add_track_fx", "track": 4, "fxname": "ReaComp"},
+				{"action": "delete_clip", "track": 2.0, "clip": 0},
+			},
+			wantIndices: []int{1, 2},
+		},
+		{
+			name:  "tracks created in the script are valid",
+			state: twoTracks,
+			actions: []map[string]any{
+				{"action": "create_track", "index": 2, "name": "Keys"},
+				{"action": "create_clip_at_bar", "track": 2, "bar": 1, "length_bars": 4},
+				{"action": "set_track", "track": 3, "name": "Pad"},
+			},
+			wantIndices: []int{2},
+		},
+		{
+			name:  "nested state format",
+			state: map[string]any{"state": map[string]any{"tracks": []any{}}},
+			actions: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+			wantIndices: []int{0},
+		},
+		{
+			name:  "no track list in state skips the check",
+			state: map[string]any{"project": map[string]any{"bpm": 120.0}},
+			actions: []map[string]any{
+				{"action": "set_track", "track": 7, "mute": true},
+			},
+			wantIndices: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			warnings := DetectStaleTrackIndices(tt.actions, tt.state)
+			if len(warnings) != len(tt.wantIndices) {
+				t.Fatalf("DetectStaleTrackIndices() returned %d warnings, want %d: %+v", len(warnings), len(tt.wantIndices), warnings)
+			}
+			for i, w := range warnings {
+				if w.Code != WarningStaleTrackIndex {
+					t.Errorf("warning %d code = %q, want %q", i, w.Code, WarningStaleTrackIndex)
+				}
+				if w.ActionIndex != tt.wantIndices[i] {
+					t.Errorf("warning %d action index = %d, want %d", i, w.ActionIndex, tt.wantIndices[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectStaleTrackIndices_FromDSL(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums"},
+		},
+	}
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	parser.SetState(state)
+
+	actions, err := parser.ParseDSL(`track(id=3).set_track(mute=true); track(name="Keys").add_fx(fxname="ReaEQ")`)
+	if err != nil {
+		t.Fatalf("ParseDSL() error = %v", err)
+	}
+
+	warnings := DetectStaleTrackIndices(actions, state)
+	if len(warnings) != 1 {
+		t.Fatalf("DetectStaleTrackIndices() returned %d warnings, want 1: %+v", len(warnings), warnings)
+	}
+	if warnings[0].ActionIndex != 0 {
+		t.Errorf("warning action index = %d, want 0", warnings[0].ActionIndex)
+	}
+	if !strings.Contains(warnings[0].Message, "track index 2") {
+		t.Errorf("warning message %q should name the offending index", warnings[0].Message)
+	}
+}
@@ -0,0 +1,29 @@
+package daw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// matchString applies a string operator of a filter predicate to value: track.name contains
+// "Synth". contains, starts_with and ends_with ignore case; matches takes a Go regular
+// expression (use (?i) to ignore case).
+func matchString(value, op, pattern string) (bool, error) {
+	switch op {
+	case "contains":
+		return strings.Contains(strings.ToLower(value), strings.ToLower(pattern)), nil
+	case "starts_with":
+		return strings.HasPrefix(strings.ToLower(value), strings.ToLower(pattern)), nil
+	case "ends_with":
+		return strings.HasSuffix(strings.ToLower(value), strings.ToLower(pattern)), nil
+	case "matches":
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+		return re.MatchString(value), nil
+	default:
+		return false, fmt.Errorf("unknown string operator %q", op)
+	}
+}
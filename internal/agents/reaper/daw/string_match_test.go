@@ -0,0 +1,118 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFunctionalDSLParser_StringMatch(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Synth Lead", "muted": false},
+			map[string]any{"index": 1, "name": "Bass Synth", "muted": true},
+			map[string]any{"index": 2, "name": "Drums", "muted": false},
+			map[string]any{"index": 3, "name": "Vox 2", "muted": false},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+	}{
+		{
+			name:    "contains ignores case",
+			dslCode: `filter(tracks, track.name contains "synth").delete()`,
+			want: []map[string]any{
+				{"action": "delete_track", "track": 0},
+				{"action": "delete_track", "track": 1},
+			},
+		},
+		{
+			name:    "starts_with",
+			dslCode: `filter(tracks, track.name starts_with "Synth").set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": true},
+			},
+		},
+		{
+			name:    "ends_with",
+			dslCode: `filter(tracks, track.name ends_with "SYNTH").set_track(solo=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 1, "solo": true},
+			},
+		},
+		{
+			name:    "matches a regular expression",
+			dslCode: `filter(tracks, track.name matches "^(Drums|Vox \d+)$").set_track(mute=true)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 2, "mute": true},
+				{"action": "set_track", "track": 3, "mute": true},
+			},
+		},
+		{
+			name:    "operator words inside the string are literal",
+			dslCode: `filter(tracks, track.name contains " contains ").delete()`,
+			want:    []map[string]any{},
+		},
+		{
+			name:    "combined with a logical operator",
+			dslCode: `filter(tracks, track.name contains "Synth" && track.muted == false).set_track(volume_db=-6)`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "volume_db": -6.0},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if len(tt.want) == 0 {
+				if err == nil && len(got) > 0 {
+					t.Errorf("ParseDSL() = %v, want no actions", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchString(t *testing.T) {
+	tests := []struct {
+		value   string
+		op      string
+		pattern string
+		want    bool
+		wantErr bool
+	}{
+		{value: "Synth Lead", op: "contains", pattern: "LEAD", want: true},
+		{value: "Synth Lead", op: "starts_with", pattern: "lead", want: false},
+		{value: "Synth Lead", op: "ends_with", pattern: "lead", want: true},
+		{value: "Synth Lead", op: "matches", pattern: "^synth", want: false},
+		{value: "Synth Lead", op: "matches", pattern: "(?i)^synth", want: true},
+		{value: "Synth Lead", op: "matches", pattern: "(", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.op+" "+tt.pattern, func(t *testing.T) {
+			got, err := matchString(tt.value, tt.op, tt.pattern)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("matchString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("matchString() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package daw
+
+import (
+	"context"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// TextDeltaCallback receives DSL text as the LLM streams it
+type TextDeltaCallback func(delta string) error
+
+type textDeltaKey struct{}
+
+// WithTextDeltaCallback returns a context that asks the DAW agent to stream the LLM output,
+// forwarding text deltas to callback and emitting actions as each statement completes.
+func WithTextDeltaCallback(ctx context.Context, callback TextDeltaCallback) context.Context {
+	return context.WithValue(ctx, textDeltaKey{}, callback)
+}
+
+// TextDeltaCallbackFromContext returns the request's text delta callback, or nil.
+func TextDeltaCallbackFromContext(ctx context.Context) TextDeltaCallback {
+	callback, _ := ctx.Value(textDeltaKey{}).(TextDeltaCallback)
+	return callback
+}
+
+// partialActionEmitter parses the streamed DSL each time a statement completes and emits
+// actions that haven't been sent yet
+type partialActionEmitter struct {
+	ctx      context.Context
+	state    map[string]any
+	callback func(action map[string]any) error
+
+	text       string
+	statements int // Completed statements already parsed
+	emitted    int // Actions already passed to callback
+}
+
+// add appends a text delta and emits actions for any newly completed statements.
+// The last statement is held back until a following statement (or the final parse) closes it.
+func (e *partialActionEmitter) add(delta string) {
+	e.text += delta
+
+	// Unclosed strings and parentheses are expected while the last statement is streaming;
+	// completed statements are split at depth 0, so they are balanced
+	statements, _ := scanDSLStatements(e.text)
+	completed := len(statements) - 1
+	if completed <= e.statements {
+		return
+	}
+
+	parser, err := NewFunctionalDSLParser()
+	if err != nil {
+		return
+	}
+	parser.SetState(e.state)
+	parser.SetLengthUnit(LengthUnitFromContext(e.ctx))
+	parser.SetContext(e.ctx)
+
+	// The final parse reports the repairs; partial parses only apply them
+	code := e.text[:statements[completed-1].end]
+	if !StrictDSLFromContext(e.ctx) {
+		code, _ = RepairDSL(code)
+	}
+	actions, err := parser.ParseDSL(code)
+	if err != nil {
+		// The final parse reports errors; partial output just waits for more text
+		logger.Printf(e.ctx, "⚠️  Partial DSL parse failed after %d statements: %v", completed, err)
+		return
+	}
+	e.statements = completed
+
+	// Emit execution-ordered actions, so indices after a delete_track are already shifted
+	planned, _ := PlanActions(actions, e.state)
+	for _, action := range planned[min(e.emitted, len(planned)):] {
+		if e.callback != nil {
+			_ = e.callback(action)
+		}
+		e.emitted++
+	}
+}
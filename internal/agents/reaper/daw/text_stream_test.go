@@ -0,0 +1,49 @@
+package daw
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestPartialActionEmitter(t *testing.T) {
+	var got [][]string
+	var batch []string
+	emitter := &partialActionEmitter{
+		ctx:   context.Background(),
+		state: map[string]any{"tracks": []any{}},
+		callback: func(action map[string]any) error {
+			actionType, _ := action["action"].(string)
+			batch = append(batch, actionType)
+			return nil
+		},
+	}
+
+	deltas := []string{
+		`track(instrument="Ser`,
+		`um").new_clip(bar=1, length_bars=4)`,
+		` track(name="Bass")`,
+		`.set_track(mute=true)`,
+		`; track(name="Pad")`,
+	}
+	for _, delta := range deltas {
+		batch = nil
+		emitter.add(delta)
+		got = append(got, batch)
+	}
+
+	// Each statement is emitted once the next one starts; the last waits for the final parse
+	want := [][]string{
+		nil,
+		nil,
+		{"create_track", "create_clip_at_bar"},
+		nil,
+		{"create_track", "set_track"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("emitted batches = %v, want %v", got, want)
+	}
+	if emitter.emitted != 4 {
+		t.Errorf("emitted = %d, want 4", emitter.emitted)
+	}
+}
@@ -0,0 +1,337 @@
+package daw
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/pkg/embedded"
+)
+
+// Track templates are named multi-action snippets from the embedded library ("vocal chain",
+// "parallel compression"), applied with apply_template(). Action values like "{ratio}" are
+// slots filled from the template's params, which the call may override. Two slots are built
+// in: {track} is the target track and {new_track} the track a template's create_track adds.
+
+const (
+	templateTrackSlot    = "track"
+	templateNewTrackSlot = "new_track"
+)
+
+// TrackTemplate is one template of the library
+type TrackTemplate struct {
+	Name        string           `json:"name"`
+	Aliases     []string         `json:"aliases,omitempty"`
+	Description string           `json:"description"`
+	Params      map[string]any   `json:"params,omitempty"` // Parameter defaults: numbers, strings or booleans
+	Actions     []map[string]any `json:"actions"`
+}
+
+var templateSlotPattern = regexp.MustCompile(`\{([a-z_][a-z0-9_]*)\}`)
+
+var loadTrackTemplates = sync.OnceValues(func() ([]TrackTemplate, error) {
+	return parseTrackTemplates(embedded.TrackTemplatesJSON)
+})
+
+// TrackTemplates returns the template library
+func TrackTemplates() ([]TrackTemplate, error) {
+	return loadTrackTemplates()
+}
+
+// parseTrackTemplates reads and validates a template library
+func parseTrackTemplates(data []byte) ([]TrackTemplate, error) {
+	var library struct {
+		Templates []TrackTemplate `json:"templates"`
+	}
+	if err := json.Unmarshal(data, &library); err != nil {
+		return nil, fmt.Errorf("invalid track template library: %w", err)
+	}
+
+	names := make(map[string]string)
+	for _, template := range library.Templates {
+		if err := template.validate(); err != nil {
+			return nil, fmt.Errorf("track template %q: %w", template.Name, err)
+		}
+		for _, name := range append([]string{template.Name}, template.Aliases...) {
+			key := normalizeTemplateName(name)
+			if other, ok := names[key]; ok {
+				return nil, fmt.Errorf("track template %q: name %q is taken by %q", template.Name, name, other)
+			}
+			names[key] = template.Name
+		}
+	}
+	return library.Templates, nil
+}
+
+// validate checks that every slot is a parameter or built in, and that the actions match the
+// action schema with the parameters' defaults
+func (t TrackTemplate) validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("name is empty")
+	}
+	if len(t.Actions) == 0 {
+		return fmt.Errorf("no actions")
+	}
+	for name, value := range t.Params {
+		if name == templateTrackSlot || name == templateNewTrackSlot {
+			return fmt.Errorf("parameter %s is built in", name)
+		}
+		if templateValueKind(value) == "" {
+			return fmt.Errorf("parameter %s must be a number, string or boolean, got %T", name, value)
+		}
+	}
+
+	newTracks, usesNewTrack := 0, false
+	for i, action := range t.Actions {
+		if action["action"] == "create_track" {
+			newTracks++
+		}
+		for _, value := range action {
+			text, _ := value.(string)
+			for _, match := range templateSlotPattern.FindAllStringSubmatch(text, -1) {
+				slot := match[1]
+				if slot == templateNewTrackSlot {
+					usesNewTrack = true
+					continue
+				}
+				if _, ok := t.Params[slot]; !ok && slot != templateTrackSlot {
+					return fmt.Errorf("action %d uses {%s}, which is not a parameter", i, slot)
+				}
+			}
+		}
+	}
+	if newTracks > 1 {
+		return fmt.Errorf("at most one create_track is supported, got %d", newTracks)
+	}
+	if usesNewTrack && newTracks == 0 {
+		return fmt.Errorf("{%s} is used without a create_track", templateNewTrackSlot)
+	}
+
+	actions, err := t.expand(0, 1, nil)
+	if err != nil {
+		return err
+	}
+	for i, action := range actions {
+		if problems := ValidateAction(action); len(problems) > 0 {
+			return fmt.Errorf("action %d (%v): %s", i, action["action"], strings.Join(problems, "; "))
+		}
+	}
+	return nil
+}
+
+// createsTrack reports whether the template adds a track
+func (t TrackTemplate) createsTrack() bool {
+	for _, action := range t.Actions {
+		if action["action"] == "create_track" {
+			return true
+		}
+	}
+	return false
+}
+
+// paramNames returns the template's parameters, sorted
+func (t TrackTemplate) paramNames() []string {
+	names := make([]string, 0, len(t.Params))
+	for name := range t.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// expand returns the template's actions for track (0-based), with newTrack the index its
+// create_track adds and overrides replacing the parameters' defaults
+func (t TrackTemplate) expand(track, newTrack int, overrides map[string]any) ([]map[string]any, error) {
+	values := make(map[string]any, len(t.Params)+2)
+	maps.Copy(values, t.Params)
+	for name, value := range overrides {
+		defaultValue, ok := t.Params[name]
+		if !ok {
+			if len(t.Params) == 0 {
+				return nil, fmt.Errorf("template %q has no parameters, got %s", t.Name, name)
+			}
+			return nil, fmt.Errorf("template %q has no parameter %s (has %s)", t.Name, name, strings.Join(t.paramNames(), ", "))
+		}
+		if want := templateValueKind(defaultValue); templateValueKind(value) != want {
+			return nil, fmt.Errorf("template %q parameter %s must be a %s, got %v", t.Name, name, want, value)
+		}
+		values[name] = value
+	}
+	values[templateTrackSlot] = track
+	values[templateNewTrackSlot] = newTrack
+
+	actions := make([]map[string]any, 0, len(t.Actions))
+	for _, templateAction := range t.Actions {
+		action := make(map[string]any, len(templateAction))
+		for key, value := range templateAction {
+			action[key] = fillTemplateSlots(value, values)
+		}
+		if action["action"] == "set_fx_param" {
+			if value, ok := action["value"].(float64); ok && (value < 0 || value > 1) {
+				return nil, fmt.Errorf("template %q sets %v %v to %g, but FX parameter values are from 0.0 to 1.0", t.Name, action["fxname"], action["param"], value)
+			}
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// fillTemplateSlots replaces the slots in a template action value. A value that is a single
+// slot takes the slot's value and type; slots within a longer string are formatted into it.
+func fillTemplateSlots(value any, values map[string]any) any {
+	text, ok := value.(string)
+	if !ok {
+		return value
+	}
+	if match := templateSlotPattern.FindStringSubmatch(text); match != nil && match[0] == text {
+		return values[match[1]]
+	}
+	return templateSlotPattern.ReplaceAllStringFunc(text, func(slot string) string {
+		return fmt.Sprint(values[slot[1:len(slot)-1]])
+	})
+}
+
+// templateValueKind names the kind of a parameter value, or "" for an unsupported one
+func templateValueKind(value any) string {
+	switch value.(type) {
+	case float64, int:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return ""
+}
+
+// normalizeTemplateName folds case, separators and spacing: "Vocal_Chain" matches "vocal chain"
+func normalizeTemplateName(name string) string {
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(strings.ToLower(name))
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// findTrackTemplate returns the template named name or one of its aliases
+func findTrackTemplate(name string) (TrackTemplate, error) {
+	templates, err := TrackTemplates()
+	if err != nil {
+		return TrackTemplate{}, err
+	}
+	want := normalizeTemplateName(name)
+	for _, template := range templates {
+		for _, candidate := range append([]string{template.Name}, template.Aliases...) {
+			if normalizeTemplateName(candidate) == want {
+				return template, nil
+			}
+		}
+	}
+	available := make([]string, len(templates))
+	for i, template := range templates {
+		available[i] = template.Name
+	}
+	return TrackTemplate{}, fmt.Errorf("unknown track template %q (available: %s)", name, strings.Join(available, ", "))
+}
+
+// ApplyTemplate handles apply_template() calls: adds a template's actions to a track (track
+// is 1-based like track(id=...)), the current track, or each filtered track. The other
+// arguments override the template's parameters.
+// Example: apply_template(name="vocal chain", track=2)
+// Example: filter(tracks, track.name contains "drum").apply_template(name="parallel compression", send_db=-9)
+func (r *ReaperDSL) ApplyTemplate(args gs.Args) error {
+	p := r.parser
+
+	nameValue, ok := args["name"]
+	if !ok || nameValue.Kind != gs.ValueString {
+		return fmt.Errorf("apply_template requires name (string)")
+	}
+	template, err := findTrackTemplate(nameValue.Str)
+	if err != nil {
+		return fmt.Errorf("apply_template: %w", err)
+	}
+
+	overrides := make(map[string]any)
+	for key, value := range args {
+		if key == "name" || key == "track" {
+			continue
+		}
+		switch value.Kind {
+		case gs.ValueNumber:
+			overrides[key] = value.Num
+		case gs.ValueString:
+			overrides[key] = value.Str
+		case gs.ValueBool:
+			overrides[key] = value.Bool
+		default:
+			return fmt.Errorf("apply_template %s must be a number, string or boolean", key)
+		}
+	}
+
+	var targets []int
+	if trackValue, ok := args["track"]; ok {
+		id := int(trackValue.Num)
+		if trackValue.Kind != gs.ValueNumber || float64(id) != trackValue.Num || id < 1 {
+			return fmt.Errorf("apply_template track must be a track id starting at 1")
+		}
+		targets = []int{id - 1}
+	} else if filtered := p.takeFiltered(); len(filtered) > 0 {
+		for _, item := range filtered {
+			trackMap, ok := item.(map[string]any)
+			if !ok {
+				continue
+			}
+			trackIndex, ok := actionInt(trackMap, "index")
+			if !ok {
+				logger.Printf(p.ctx, "⚠️  apply_template: Could not extract track index from %+v", trackMap)
+				continue
+			}
+			targets = append(targets, trackIndex)
+		}
+	} else if p.onMaster {
+		return fmt.Errorf("apply_template does not apply to master()")
+	} else if p.currentTrackIndex >= 0 {
+		targets = []int{p.currentTrackIndex}
+	} else {
+		return fmt.Errorf("apply_template requires track (1-based id) or a track context")
+	}
+
+	for _, track := range targets {
+		actions, err := template.expand(track, p.trackCounter, overrides)
+		if err != nil {
+			return fmt.Errorf("apply_template: %w", err)
+		}
+		if template.createsTrack() {
+			p.trackCounter++
+		}
+		p.actions = append(p.actions, actions...)
+	}
+	logger.Printf(p.ctx, "🧩 ApplyTemplate: %q on %d track(s)", template.Name, len(targets))
+	return nil
+}
+
+// trackTemplatesDescription lists the templates for the LLM, with their parameters' defaults
+func trackTemplatesDescription() string {
+	templates, err := TrackTemplates()
+	if err != nil {
+		log.Printf("⚠️  Track templates unavailable: %v", err)
+		return ""
+	}
+	lines := make([]string, 0, len(templates))
+	for _, template := range templates {
+		line := fmt.Sprintf("%q (%s", template.Name, template.Description)
+		if len(template.Params) > 0 {
+			params := make([]string, 0, len(template.Params))
+			for _, name := range template.paramNames() {
+				params = append(params, fmt.Sprintf("%s=%v", name, template.Params[name]))
+			}
+			line += "; params " + strings.Join(params, ", ")
+		}
+		lines = append(lines, line+")")
+	}
+	return strings.Join(lines, "; ")
+}
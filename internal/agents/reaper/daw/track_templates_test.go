@@ -0,0 +1,186 @@
+package daw
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTrackTemplates_EmbeddedLibrary(t *testing.T) {
+	templates, err := TrackTemplates()
+	if err != nil {
+		t.Fatalf("TrackTemplates() error = %v", err)
+	}
+	for _, name := range []string{"vocal chain", "drum bus", "parallel compression"} {
+		if _, err := findTrackTemplate(name); err != nil {
+			t.Errorf("findTrackTemplate(%q) error = %v", name, err)
+		}
+	}
+	if len(templates) == 0 {
+		t.Fatal("TrackTemplates() returned no templates")
+	}
+
+	description := trackTemplatesDescription()
+	for _, template := range templates {
+		if !strings.Contains(description, `"`+template.Name+`"`) {
+			t.Errorf("trackTemplatesDescription() = %q, missing %q", description, template.Name)
+		}
+	}
+}
+
+func TestParseTrackTemplates_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		library string
+		wantErr string
+	}{
+		{
+			name:    "undefined slot",
+			library: `{"templates": [{"name": "a", "actions": [{"action": "add_track_fx", "track": "{track}", "fxname": "{plugin}"}]}]}`,
+			wantErr: "uses {plugin}, which is not a parameter",
+		},
+		{
+			name:    "new track without create_track",
+			library: `{"templates": [{"name": "a", "actions": [{"action": "add_track_fx", "track": "{new_track}", "fxname": "ReaEQ"}]}]}`,
+			wantErr: "{new_track} is used without a create_track",
+		},
+		{
+			name:    "action outside the schema",
+			library: `{"templates": [{"name": "a", "actions": [{"action": "add_track_fx", "track": "{track}"}]}]}`,
+			wantErr: "fxname is required",
+		},
+		{
+			name: "alias taken by another template",
+			library: `{"templates": [
+				{"name": "vocal chain", "actions": [{"action": "add_track_fx", "track": "{track}", "fxname": "ReaEQ"}]},
+				{"name": "vocals", "aliases": ["Vocal_Chain"], "actions": [{"action": "add_track_fx", "track": "{track}", "fxname": "ReaEQ"}]}
+			]}`,
+			wantErr: `is taken by "vocal chain"`,
+		},
+		{
+			name:    "built-in parameter",
+			library: `{"templates": [{"name": "a", "params": {"track": 1}, "actions": [{"action": "add_track_fx", "track": "{track}", "fxname": "ReaEQ"}]}]}`,
+			wantErr: "parameter track is built in",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTrackTemplates([]byte(tt.library))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("parseTrackTemplates() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFunctionalDSLParser_ApplyTemplate(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Lead Vocal"},
+			map[string]any{"index": 1, "name": "Drums"},
+			map[string]any{"index": 2, "name": "Drum Room"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr string
+	}{
+		{
+			name:    "top level with a track id",
+			dslCode: `apply_template(name="drum bus", track=2)`,
+			want: []map[string]any{
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaEQ"},
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaComp"},
+				{"action": "set_fx_param", "track": 1, "fxname": "ReaComp", "param": "Thresh", "value": 0.5},
+				{"action": "set_fx_param", "track": 1, "fxname": "ReaComp", "param": "Ratio", "value": 0.2},
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaLimit"},
+				{"action": "set_fx_param", "track": 1, "fxname": "ReaLimit", "param": "Ceiling", "value": 0.95},
+			},
+		},
+		{
+			name:    "chained with parameter overrides and a new bus track",
+			dslCode: `track(id=1).apply_template(name="Parallel-Compression", send_db=-9, bus_name="Vox Crush")`,
+			want: []map[string]any{
+				{"action": "create_track", "index": 3, "name": "Vox Crush"},
+				{"action": "add_track_fx", "track": 3, "fxname": "ReaComp"},
+				{"action": "set_fx_param", "track": 3, "fxname": "ReaComp", "param": "Thresh", "value": 0.2},
+				{"action": "set_fx_param", "track": 3, "fxname": "ReaComp", "param": "Ratio", "value": 0.8},
+				{"action": "add_send", "track": 0, "dest": 3, "level_db": -9.0},
+			},
+		},
+		{
+			name:    "filtered tracks each get their own bus",
+			dslCode: `filter(tracks, track.name contains "drum").apply_template(name="reverb send")`,
+			want: []map[string]any{
+				{"action": "create_track", "index": 3, "name": "Reverb"},
+				{"action": "add_track_fx", "track": 3, "fxname": "ReaVerbate"},
+				{"action": "set_fx_param", "track": 3, "fxname": "ReaVerbate", "param": "Wet", "value": 1.0},
+				{"action": "set_fx_param", "track": 3, "fxname": "ReaVerbate", "param": "Dry", "value": 0.0},
+				{"action": "add_send", "track": 1, "dest": 3, "level_db": -12.0},
+				{"action": "create_track", "index": 4, "name": "Reverb"},
+				{"action": "add_track_fx", "track": 4, "fxname": "ReaVerbate"},
+				{"action": "set_fx_param", "track": 4, "fxname": "ReaVerbate", "param": "Wet", "value": 1.0},
+				{"action": "set_fx_param", "track": 4, "fxname": "ReaVerbate", "param": "Dry", "value": 0.0},
+				{"action": "add_send", "track": 2, "dest": 4, "level_db": -12.0},
+			},
+		},
+		{
+			name:    "unknown template",
+			dslCode: `apply_template(name="guitar wall", track=1)`,
+			wantErr: `unknown track template "guitar wall"`,
+		},
+		{
+			name:    "unknown parameter",
+			dslCode: `apply_template(name="vocal chain", track=1, attack=0.2)`,
+			wantErr: "has no parameter attack (has ratio, reverb, threshold)",
+		},
+		{
+			name:    "parameter of the wrong kind",
+			dslCode: `apply_template(name="parallel compression", track=1, send_db="loud")`,
+			wantErr: "parameter send_db must be a number",
+		},
+		{
+			name:    "FX parameter out of range",
+			dslCode: `apply_template(name="vocal chain", track=1, reverb=2)`,
+			wantErr: "FX parameter values are from 0.0 to 1.0",
+		},
+		{
+			name:    "no track",
+			dslCode: `apply_template(name="vocal chain")`,
+			wantErr: "apply_template requires track",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(state)
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseDSL() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+			for i, action := range got {
+				if problems := ValidateAction(action); len(problems) > 0 {
+					t.Errorf("action %d doesn't match the schema: %v", i, problems)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package daw
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+// Transport calls are project-level like markers: they emit actions without a "track" key and
+// are top-level statements, separated by ";" from the statement before, e.g.
+// set_play_position(bar=33); play().
+
+// transportCalls are the top-level transport statements, used to recognise DSL output
+var transportCalls = []string{"play(", "stop(", "record(", "set_play_position("}
+
+// Play handles play() calls: starts playback from the play cursor.
+func (r *ReaperDSL) Play(args gs.Args) error {
+	return r.transportAction("play", args)
+}
+
+// Stop handles stop() calls: stops playback or recording.
+func (r *ReaperDSL) Stop(args gs.Args) error {
+	return r.transportAction("stop", args)
+}
+
+// Record handles record() calls: starts recording on the armed tracks.
+func (r *ReaperDSL) Record(args gs.Args) error {
+	return r.transportAction("record", args)
+}
+
+// SetPlayPosition handles set_play_position() calls: moves the play cursor to a bar or a
+// time (seconds).
+// Example: set_play_position(bar=33); play()
+func (r *ReaperDSL) SetPlayPosition(args gs.Args) error {
+	p := r.parser
+
+	action := map[string]any{"action": "set_play_position"}
+	barValue, hasBar := args["bar"]
+	timeValue, hasTime := args["time"]
+	switch {
+	case hasBar && hasTime:
+		return fmt.Errorf("set_play_position takes bar or time, not both")
+	case hasBar && barValue.Kind == gs.ValueNumber:
+		if barValue.Num < 1 {
+			return fmt.Errorf("set_play_position bar must be 1 or greater, got %g", barValue.Num)
+		}
+		action["bar"] = int(barValue.Num)
+	case hasTime && timeValue.Kind == gs.ValueNumber:
+		if timeValue.Num < 0 {
+			return fmt.Errorf("set_play_position time must not be negative, got %g", timeValue.Num)
+		}
+		action["time"] = timeValue.Num
+	default:
+		return fmt.Errorf("set_play_position requires bar or time")
+	}
+
+	p.actions = append(p.actions, action)
+	logger.Printf(p.ctx, "✅ SetPlayPosition: %+v", action)
+	return nil
+}
+
+// transportAction emits an argument-less transport action
+func (r *ReaperDSL) transportAction(name string, args gs.Args) error {
+	if len(args) > 0 {
+		return fmt.Errorf("%s() takes no arguments", name)
+	}
+	r.parser.actions = append(r.parser.actions, map[string]any{"action": name})
+	logger.Printf(r.parser.ctx, "✅ Transport: %s", name)
+	return nil
+}
+
+// isTransportDSL reports whether code contains a top-level transport statement
+func isTransportDSL(code string) bool {
+	code = strings.TrimSpace(code)
+	for _, call := range transportCalls {
+		if strings.HasPrefix(code, call) || strings.Contains(code, ";"+call) || strings.Contains(code, "; "+call) {
+			return true
+		}
+	}
+	return false
+}
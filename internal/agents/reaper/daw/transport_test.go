@@ -0,0 +1,99 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestFunctionalDSLParser_Transport(t *testing.T) {
+	tests := []struct {
+		name    string
+		dslCode string
+		want    []map[string]any
+		wantErr bool
+	}{
+		{
+			name:    "play from bar",
+			dslCode: `set_play_position(bar=33); play()`,
+			want: []map[string]any{
+				{"action": "set_play_position", "bar": 33},
+				{"action": "play"},
+			},
+		},
+		{
+			name:    "time position",
+			dslCode: `set_play_position(time=12.5)`,
+			want:    []map[string]any{{"action": "set_play_position", "time": 12.5}},
+		},
+		{
+			name:    "after a track statement",
+			dslCode: `track(id=1).set_track(selected=true); record()`,
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "selected": true},
+				{"action": "record"},
+			},
+		},
+		{name: "stop", dslCode: `stop()`, want: []map[string]any{{"action": "stop"}}},
+		{name: "bar below 1", dslCode: `set_play_position(bar=0)`, wantErr: true},
+		{name: "negative time", dslCode: `set_play_position(time=-1)`, wantErr: true},
+		{name: "bar and time", dslCode: `set_play_position(bar=2, time=3)`, wantErr: true},
+		{name: "no position", dslCode: `set_play_position()`, wantErr: true},
+		{name: "arguments to play", dslCode: `play(bar=3)`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewFunctionalDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+			parser.SetState(map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "Vocals"}}})
+
+			got, err := parser.ParseDSL(tt.dslCode)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseDSL(%q) expected an error, got %v", tt.dslCode, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTransportDSL(t *testing.T) {
+	tests := []struct {
+		code string
+		want bool
+	}{
+		{"play()", true},
+		{"set_play_position(bar=33); play()", true},
+		{"track(id=1).set_track(mute=true);stop()", true},
+		{"track(name=\"Record Bus\")", false},
+		{"filter(tracks, track.name contains \"play\")", false},
+	}
+
+	for _, tt := range tests {
+		if got := isTransportDSL(tt.code); got != tt.want {
+			t.Errorf("isTransportDSL(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestPreviewActions_Transport(t *testing.T) {
+	actions := []map[string]any{{"action": "set_play_position", "bar": 33}, {"action": "play"}}
+	want := []models.ActionPreview{
+		{ActionIndex: 0, Action: "set_play_position", Summary: "Set play position: bar=33"},
+		{ActionIndex: 1, Action: "play", Summary: "Play"},
+	}
+	if got := PreviewActions(actions, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("PreviewActions() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,397 @@
+package daw
+
+import (
+	"log"
+	"math"
+)
+
+// undoTrackProperties are the set_track properties restored when undoing set_track or delete_track
+var undoTrackProperties = []string{"name", "volume_db", "pan", "mute", "solo", "selected", "color"}
+
+// trackStateKeys maps the set_track properties whose key in track state differs
+var trackStateKeys = map[string]string{"mute": "muted", "solo": "soloed"}
+
+// undoClipProperties are the set_clip properties restored when undoing set_clip or delete_clip
+var undoClipProperties = []string{"name", "color", "selected", "length", "source_length", "loop"}
+
+// undoState tracks the project as actions are applied, so each undo action restores the values
+// from just before its action ran (e.g. two set_track calls on one track undo to the original).
+type undoState struct {
+	tracks map[int]map[string]any
+	clips  map[int][]map[string]any
+	master map[string]any
+}
+
+// BuildUndoActions returns actions that revert actions, computed from the state they were
+// generated against. Undo actions are in reverse order so they can be applied as-is.
+// Actions that can't be inverted (add_track_fx, add_automation, remove_send, add_region, ...) or whose
+// previous values aren't in state are skipped. Deleted tracks and clips are recreated with their
+// captured properties only; FX and MIDI content are not restored.
+func BuildUndoActions(actions []map[string]any, state map[string]any) []map[string]any {
+	current := newUndoState(state)
+	var batches [][]map[string]any
+
+	for _, action := range actions {
+		if inverse := current.apply(action); len(inverse) > 0 {
+			batches = append(batches, inverse)
+		}
+	}
+
+	undo := make([]map[string]any, 0, len(batches))
+	for i := len(batches) - 1; i >= 0; i-- {
+		undo = append(undo, batches[i]...)
+	}
+	return undo
+}
+
+// newUndoState copies track and clip properties from state
+func newUndoState(state map[string]any) *undoState {
+	s := &undoState{
+		tracks: make(map[int]map[string]any),
+		clips:  make(map[int][]map[string]any),
+		master: stateTrackProperties(stateMaster(state), undoTrackProperties),
+	}
+
+	tracks, _ := stateTracks(state)
+	for i, item := range tracks {
+		trackMap, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index := i
+		if v, ok := getNumericValue(trackMap["index"]); ok {
+			index = int(v)
+		}
+
+		s.tracks[index] = stateTrackProperties(trackMap, append([]string{"instrument"}, undoTrackProperties...))
+
+		rawClips, _ := trackMap["clips"].([]any)
+		for j, rawClip := range rawClips {
+			clipMap, ok := rawClip.(map[string]any)
+			if !ok {
+				continue
+			}
+			clip := copyProperties(clipMap, append([]string{"index", "position"}, undoClipProperties...))
+			if _, ok := clip["index"]; !ok {
+				clip["index"] = j
+			}
+			s.clips[index] = append(s.clips[index], clip)
+		}
+	}
+	return s
+}
+
+// apply records action against the tracked state and returns the actions that revert it
+func (s *undoState) apply(action map[string]any) []map[string]any {
+	actionType, _ := action["action"].(string)
+
+	switch actionType {
+	case "create_track":
+		index, ok := actionInt(action, "index")
+		if !ok {
+			return nil
+		}
+		s.tracks[index] = copyProperties(action, append([]string{"instrument"}, undoTrackProperties...))
+		return []map[string]any{{"action": "delete_track", "track": index}}
+
+	case "delete_track":
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		track, known := s.tracks[index]
+		delete(s.tracks, index)
+		delete(s.clips, index)
+		if !known {
+			log.Printf("⚠️  Undo: no state for deleted track %d, skipping", index)
+			return nil
+		}
+
+		create := map[string]any{"action": "create_track", "index": index}
+		for _, key := range []string{"name", "instrument"} {
+			if v, ok := track[key]; ok {
+				create[key] = v
+			}
+		}
+		inverse := []map[string]any{create}
+		if props := copyProperties(track, []string{"volume_db", "pan", "mute", "solo", "color"}); len(props) > 0 {
+			props["action"] = "set_track"
+			props["track"] = index
+			inverse = append(inverse, props)
+		}
+		return inverse
+
+	case "set_track":
+		if isMasterAction(action) {
+			return s.revertProperties(action, s.master, undoTrackProperties, map[string]any{"action": "set_track", "track": MasterTrack})
+		}
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		track := s.tracks[index]
+		if track == nil {
+			track = make(map[string]any)
+			s.tracks[index] = track
+		}
+		return s.revertProperties(action, track, undoTrackProperties, map[string]any{"action": "set_track", "track": index})
+
+	case "create_clip":
+		index, ok := actionInt(action, "track")
+		position, hasPosition := getNumericValue(action["position"])
+		if !ok || !hasPosition {
+			return nil
+		}
+		clip := map[string]any{"position": position, "index": len(s.clips[index])}
+		if length, ok := getNumericValue(action["length"]); ok {
+			clip["length"] = length
+		}
+		s.clips[index] = append(s.clips[index], clip)
+		return []map[string]any{{"action": "delete_clip", "track": index, "position": position}}
+
+	case "create_clip_at_bar":
+		index, ok := actionInt(action, "track")
+		bar, hasBar := actionInt(action, "bar")
+		if !ok || !hasBar {
+			return nil
+		}
+		return []map[string]any{{"action": "delete_clip", "track": index, "bar": bar}}
+
+	case "delete_clip":
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		clip, clipPos := s.findClip(index, action)
+		if clip == nil {
+			log.Printf("⚠️  Undo: no state for deleted clip on track %d, skipping", index)
+			return nil
+		}
+		s.clips[index] = append(s.clips[index][:clipPos], s.clips[index][clipPos+1:]...)
+
+		position, hasPosition := getNumericValue(clip["position"])
+		length, hasLength := getNumericValue(clip["length"])
+		if !hasPosition || !hasLength {
+			return nil
+		}
+		inverse := []map[string]any{{"action": "create_clip", "track": index, "position": position, "length": length}}
+		if props := copyProperties(clip, []string{"name", "color"}); len(props) > 0 {
+			props["action"] = "set_clip"
+			props["track"] = index
+			props["position"] = position
+			inverse = append(inverse, props)
+		}
+		return inverse
+
+	case "set_clip":
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		clip, _ := s.findClip(index, action)
+		if clip == nil {
+			return nil
+		}
+		target := map[string]any{"action": "set_clip", "track": index}
+		copyClipIdentifier(action, target)
+		return s.revertProperties(action, clip, undoClipProperties, target)
+
+	case "duplicate_track":
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		count, ok := actionInt(action, "count")
+		if !ok {
+			count = 1
+		}
+		inverse := make([]map[string]any, 0, count)
+		for i := 0; i < count; i++ {
+			inverse = append(inverse, map[string]any{"action": "delete_track", "track": index + 1})
+		}
+		return inverse
+
+	case "duplicate_clip":
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		clip, _ := s.findClip(index, action)
+		if clip == nil {
+			return nil
+		}
+		position, hasPosition := getNumericValue(clip["position"])
+		offset, hasOffset := getNumericValue(action["offset"])
+		if !hasOffset {
+			offset, hasOffset = getNumericValue(clip["length"])
+		}
+		if !hasPosition || !hasOffset {
+			return nil
+		}
+		count, ok := actionInt(action, "count")
+		if !ok {
+			count = 1
+		}
+		inverse := make([]map[string]any, 0, count)
+		for i := count; i >= 1; i-- {
+			copyPosition := position + float64(i)*offset
+			copied := copyProperties(clip, undoClipProperties)
+			copied["position"] = copyPosition
+			copied["index"] = len(s.clips[index])
+			s.clips[index] = append(s.clips[index], copied)
+			inverse = append(inverse, map[string]any{"action": "delete_clip", "track": index, "position": copyPosition})
+		}
+		return inverse
+
+	case "add_send":
+		index, ok := actionInt(action, "track")
+		dest, hasDest := actionInt(action, "dest")
+		if !ok || !hasDest {
+			return nil
+		}
+		return []map[string]any{{"action": "remove_send", "track": index, "dest": dest}}
+
+	case "freeze_track", "unfreeze_track":
+		index, ok := actionInt(action, "track")
+		if !ok {
+			return nil
+		}
+		inverse := "unfreeze_track"
+		if actionType == "unfreeze_track" {
+			inverse = "freeze_track"
+		}
+		return []map[string]any{{"action": inverse, "track": index}}
+
+	case "add_marker":
+		target := map[string]any{"action": "delete_marker"}
+		if !copyMarkerIdentifier(action, target) {
+			return nil
+		}
+		return []map[string]any{target}
+
+	case "rename_region":
+		oldName, ok := action["name"].(string)
+		newName, hasNew := action["new_name"].(string)
+		if !ok || !hasNew {
+			// Regions renamed by id don't record the old name
+			return nil
+		}
+		return []map[string]any{{"action": "rename_region", "name": newName, "new_name": oldName}}
+
+	case "set_clip_position":
+		index, ok := actionInt(action, "track")
+		newPosition, hasNew := getNumericValue(action["position"])
+		if !ok || !hasNew {
+			return nil
+		}
+		oldPosition, hasOld := getNumericValue(action["old_position"])
+		clip, _ := s.findClip(index, map[string]any{"position": action["old_position"], "clip": action["clip"]})
+		if !hasOld && clip != nil {
+			oldPosition, hasOld = getNumericValue(clip["position"])
+		}
+		if !hasOld {
+			return nil
+		}
+		if clip != nil {
+			clip["position"] = newPosition
+		}
+		return []map[string]any{{
+			"action":       "set_clip_position",
+			"track":        index,
+			"old_position": newPosition,
+			"position":     oldPosition,
+		}}
+	}
+
+	return nil
+}
+
+// revertProperties updates current with the properties set by action and returns target filled
+// with the previous values, or nil when none of the previous values are known
+func (s *undoState) revertProperties(action, current map[string]any, keys []string, target map[string]any) []map[string]any {
+	restored := 0
+	for _, key := range keys {
+		newValue, changed := action[key]
+		if !changed {
+			continue
+		}
+		if previous, known := current[key]; known {
+			target[key] = previous
+			restored++
+		}
+		current[key] = newValue
+	}
+	if restored == 0 {
+		return nil
+	}
+	return []map[string]any{target}
+}
+
+// findClip locates the clip an action refers to by position or clip index
+func (s *undoState) findClip(trackIndex int, action map[string]any) (map[string]any, int) {
+	clips := s.clips[trackIndex]
+	if position, ok := getNumericValue(action["position"]); ok {
+		for i, clip := range clips {
+			if clipPosition, ok := getNumericValue(clip["position"]); ok && math.Abs(clipPosition-position) < 1e-6 {
+				return clip, i
+			}
+		}
+		return nil, -1
+	}
+	if clipIndex, ok := actionInt(action, "clip"); ok {
+		for i, clip := range clips {
+			if index, ok := actionInt(clip, "index"); ok && index == clipIndex {
+				return clip, i
+			}
+		}
+	}
+	return nil, -1
+}
+
+// copyClipIdentifier copies the clip identifier (position, clip index or bar) from one action to another
+func copyClipIdentifier(from, to map[string]any) {
+	for _, key := range []string{"position", "clip", "bar"} {
+		if v, ok := from[key]; ok {
+			to[key] = v
+			return
+		}
+	}
+}
+
+// copyMarkerIdentifier copies the name, or failing that the bar or position, of a new marker
+func copyMarkerIdentifier(from, to map[string]any) bool {
+	for _, key := range []string{"name", "bar", "position"} {
+		if v, ok := from[key]; ok {
+			to[key] = v
+			return true
+		}
+	}
+	return false
+}
+
+// stateTrackProperties copies the set_track properties keys from a state track, reading each
+// from its state key (muted for mute)
+func stateTrackProperties(track map[string]any, keys []string) map[string]any {
+	props := make(map[string]any)
+	for _, key := range keys {
+		stateKey := key
+		if k, ok := trackStateKeys[key]; ok {
+			stateKey = k
+		}
+		if v, ok := track[stateKey]; ok {
+			props[key] = v
+		}
+	}
+	return props
+}
+
+// copyProperties returns the keys present in source
+func copyProperties(source map[string]any, keys []string) map[string]any {
+	props := make(map[string]any)
+	for _, key := range keys {
+		if v, ok := source[key]; ok {
+			props[key] = v
+		}
+	}
+	return props
+}
@@ -0,0 +1,159 @@
+package daw
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildUndoActions(t *testing.T) {
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0.0, "name": "Drums", "volume_db": -3.0, "muted": false},
+			map[string]any{
+				"index": 1.0, "name": "Bass", "pan": 0.2, "soloed": true,
+				"clips": []any{
+					map[string]any{"index": 0.0, "position": 0.0, "length": 4.0, "name": "Intro"},
+					map[string]any{"index": 1.0, "position": 8.0, "length": 2.0, "color": "#ff0000"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		actions []map[string]any
+		want    []map[string]any
+	}{
+		{
+			name: "create track and clip undo in reverse order",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 2, "name": "Keys"},
+				{"action": "create_clip_at_bar", "track": 2, "bar": 1, "length_bars": 4},
+			},
+			want: []map[string]any{
+				{"action": "delete_clip", "track": 2, "bar": 1},
+				{"action": "delete_track", "track": 2},
+			},
+		},
+		{
+			name: "delete track restores captured properties",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 0},
+			},
+			want: []map[string]any{
+				{"action": "create_track", "index": 0, "name": "Drums"},
+				{"action": "set_track", "track": 0, "volume_db": -3.0, "mute": false},
+			},
+		},
+		{
+			name: "set track restores original values across chained edits",
+			actions: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": true, "volume_db": -6.0},
+				{"action": "set_track", "track": 0, "mute": false},
+			},
+			want: []map[string]any{
+				{"action": "set_track", "track": 0, "mute": true},
+				{"action": "set_track", "track": 0, "mute": false, "volume_db": -3.0},
+			},
+		},
+		{
+			name: "delete track restores solo",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 1},
+			},
+			want: []map[string]any{
+				{"action": "create_track", "index": 1, "name": "Bass"},
+				{"action": "set_track", "track": 1, "pan": 0.2, "solo": true},
+			},
+		},
+		{
+			name: "set track without known previous values is skipped",
+			actions: []map[string]any{
+				{"action": "set_track", "track": 0, "color": "#00ff00"},
+			},
+			want: []map[string]any{},
+		},
+		{
+			name: "clip edits by position and index",
+			actions: []map[string]any{
+				{"action": "set_clip", "track": 1, "position": 0.0, "name": "Verse"},
+				{"action": "set_clip", "track": 1, "clip": 1, "color": "#0000ff"},
+				{"action": "set_clip_position", "track": 1, "clip": 1, "position": 16.0},
+			},
+			want: []map[string]any{
+				{"action": "set_clip_position", "track": 1, "old_position": 16.0, "position": 8.0},
+				{"action": "set_clip", "track": 1, "clip": 1, "color": "#ff0000"},
+				{"action": "set_clip", "track": 1, "position": 0.0, "name": "Intro"},
+			},
+		},
+		{
+			name: "delete clip recreates it",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 1, "position": 8.0},
+			},
+			want: []map[string]any{
+				{"action": "create_clip", "track": 1, "position": 8.0, "length": 2.0},
+				{"action": "set_clip", "track": 1, "position": 8.0, "color": "#ff0000"},
+			},
+		},
+		{
+			name: "actions without an inverse are skipped",
+			actions: []map[string]any{
+				{"action": "add_track_fx", "track": 0, "fxname": "ReaEQ"},
+				{"action": "create_clip", "track": 0, "position": 4.0, "length": 2.0},
+				{"action": "remove_send", "track": 0, "dest": 1},
+			},
+			want: []map[string]any{
+				{"action": "delete_clip", "track": 0, "position": 4.0},
+			},
+		},
+		{
+			name: "add send is removed",
+			actions: []map[string]any{
+				{"action": "add_send", "track": 0, "dest": 1, "level_db": -6.0},
+			},
+			want: []map[string]any{
+				{"action": "remove_send", "track": 0, "dest": 1},
+			},
+		},
+		{
+			name: "duplicates are deleted",
+			actions: []map[string]any{
+				{"action": "duplicate_track", "track": 0, "count": 2},
+				{"action": "duplicate_clip", "track": 1, "position": 0.0, "count": 2},
+				{"action": "duplicate_clip", "track": 1, "clip": 1, "offset": 4.0},
+			},
+			want: []map[string]any{
+				{"action": "delete_clip", "track": 1, "position": 12.0},
+				{"action": "delete_clip", "track": 1, "position": 8.0},
+				{"action": "delete_clip", "track": 1, "position": 4.0},
+				{"action": "delete_track", "track": 1},
+				{"action": "delete_track", "track": 1},
+			},
+		},
+		{
+			name: "markers are deleted and renamed regions renamed back",
+			actions: []map[string]any{
+				{"action": "add_marker", "bar": 17, "name": "Drop"},
+				{"action": "add_marker", "position": 4.5},
+				{"action": "add_region", "start_bar": 1, "end_bar": 9, "name": "Intro"},
+				{"action": "rename_region", "name": "Intro", "new_name": "Verse"},
+				{"action": "rename_region", "id": 2, "new_name": "Chorus"},
+			},
+			want: []map[string]any{
+				{"action": "rename_region", "name": "Verse", "new_name": "Intro"},
+				{"action": "delete_marker", "position": 4.5},
+				{"action": "delete_marker", "name": "Drop"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildUndoActions(tt.actions, state)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildUndoActions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
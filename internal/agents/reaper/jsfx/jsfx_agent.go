@@ -9,6 +9,7 @@ import (
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/metrics"
 	"github.com/getsentry/sentry-go"
 )
@@ -91,9 +92,9 @@ func NewJSFXAgent(cfg *config.Config) *JSFXAgent {
 
 // NewJSFXAgentWithProvider creates a JSFX agent with a specific LLM provider
 func NewJSFXAgentWithProvider(cfg *config.Config, provider llm.Provider) *JSFXAgent {
-	// Use provided provider or create OpenAI provider (default)
+	// Use provided provider or create the configured one, which is required (see CheckProvider)
 	if provider == nil {
-		provider = llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+		provider = cfg.NewProvider()
 	}
 
 	systemPrompt := llm.GetJSFXDirectSystemPrompt()
@@ -117,7 +118,7 @@ func (a *JSFXAgent) Generate(
 	inputArray []map[string]any,
 ) (*JSFXResult, error) {
 	startTime := time.Now()
-	log.Printf("🔧 JSFX REQUEST STARTED (Model: %s)", model)
+	logger.Printf(ctx, "🔧 JSFX REQUEST STARTED (Model: %s)", model)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "jsfx.generate")
@@ -139,7 +140,7 @@ func (a *JSFXAgent) Generate(
 	}
 
 	// Call provider
-	log.Printf("🚀 JSFX REQUEST: %s model=%s, input_messages=%d",
+	logger.Printf(ctx, "🚀 JSFX REQUEST: %s model=%s, input_messages=%d",
 		a.provider.Name(), model, len(inputArray))
 
 	resp, err := a.provider.Generate(ctx, request)
@@ -159,12 +160,12 @@ func (a *JSFXAgent) Generate(
 	// Clean up the output (remove any markdown code fences if present)
 	jsfxCode = cleanJSFXOutput(jsfxCode)
 
-	log.Printf("🔧 JSFX Output (%d bytes):\n%s", len(jsfxCode), truncateForLog(jsfxCode, 500))
+	logger.Printf(ctx, "🔧 JSFX Output (%d bytes):\n%s", len(jsfxCode), truncateForLog(jsfxCode, 500))
 
 	// Extract description from code comments
 	description, cleanCode := parseDescriptionFromCode(jsfxCode)
 	if description != "" {
-		log.Printf("📝 Extracted description: %s", truncateForLog(description, 200))
+		logger.Printf(ctx, "📝 Extracted description: %s", truncateForLog(description, 200))
 	}
 
 	// TODO: Add EEL2 compilation validation here
@@ -182,7 +183,7 @@ func (a *JSFXAgent) Generate(
 	duration := time.Since(startTime)
 	a.metrics.RecordGenerationDuration(ctx, duration, true)
 
-	log.Printf("✅ JSFX COMPLETE: %d bytes of JSFX code", len(jsfxCode))
+	logger.Printf(ctx, "✅ JSFX COMPLETE: %d bytes of JSFX code", len(jsfxCode))
 
 	return result, nil
 }
@@ -343,7 +344,7 @@ func (a *JSFXAgent) GenerateStream(
 	callback JSFXStreamCallback,
 ) (*JSFXResult, error) {
 	startTime := time.Now()
-	log.Printf("🔧 JSFX STREAMING REQUEST STARTED (Model: %s)", model)
+	logger.Printf(ctx, "🔧 JSFX STREAMING REQUEST STARTED (Model: %s)", model)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "jsfx.generate_stream")
@@ -356,7 +357,7 @@ func (a *JSFXAgent) GenerateStream(
 	streamingProvider, ok := a.provider.(llm.StreamingProvider)
 	if !ok {
 		// Fall back to non-streaming with simulated streaming output
-		log.Printf("⚠️ Provider %s does not support streaming, falling back to non-streaming", a.provider.Name())
+		logger.Printf(ctx, "⚠️ Provider %s does not support streaming, falling back to non-streaming", a.provider.Name())
 		return a.generateStreamFallback(ctx, model, inputArray, callback)
 	}
 
@@ -384,13 +385,13 @@ func (a *JSFXAgent) GenerateStream(
 			if chunk != "" && callback != nil {
 				accumulatedCode += chunk
 				if err := callback(chunk); err != nil {
-					log.Printf("⚠️ JSFX Stream callback error: %v", err)
+					logger.Printf(ctx, "⚠️ JSFX Stream callback error: %v", err)
 				}
 			}
 		case "started":
-			log.Printf("🚀 JSFX streaming started")
+			logger.Printf(ctx, "🚀 JSFX streaming started")
 		case "completed":
-			log.Printf("✅ JSFX streaming completed: %d chars", len(accumulatedCode))
+			logger.Printf(ctx, "✅ JSFX streaming completed: %d chars", len(accumulatedCode))
 		case "heartbeat":
 			// Could forward heartbeat to client if needed
 		}
@@ -398,7 +399,7 @@ func (a *JSFXAgent) GenerateStream(
 	}
 
 	// Call streaming provider
-	log.Printf("🚀 JSFX STREAMING REQUEST: %s model=%s, input_messages=%d",
+	logger.Printf(ctx, "🚀 JSFX STREAMING REQUEST: %s model=%s, input_messages=%d",
 		a.provider.Name(), model, len(inputArray))
 
 	resp, err := streamingProvider.GenerateStream(ctx, request, streamCallback)
@@ -422,12 +423,12 @@ func (a *JSFXAgent) GenerateStream(
 	// Clean up the output (remove any markdown code fences if present)
 	jsfxCode = cleanJSFXOutput(jsfxCode)
 
-	log.Printf("🔧 JSFX Streaming Output (%d bytes):\n%s", len(jsfxCode), truncateForLog(jsfxCode, 500))
+	logger.Printf(ctx, "🔧 JSFX Streaming Output (%d bytes):\n%s", len(jsfxCode), truncateForLog(jsfxCode, 500))
 
 	// Extract description from code comments
 	description, cleanCode := parseDescriptionFromCode(jsfxCode)
 	if description != "" {
-		log.Printf("📝 Extracted description: %s", truncateForLog(description, 200))
+		logger.Printf(ctx, "📝 Extracted description: %s", truncateForLog(description, 200))
 	}
 
 	result := &JSFXResult{
@@ -442,7 +443,7 @@ func (a *JSFXAgent) GenerateStream(
 	duration := time.Since(startTime)
 	a.metrics.RecordGenerationDuration(ctx, duration, true)
 
-	log.Printf("✅ JSFX STREAMING COMPLETE: %d bytes of JSFX code in %v", len(jsfxCode), duration)
+	logger.Printf(ctx, "✅ JSFX STREAMING COMPLETE: %d bytes of JSFX code in %v", len(jsfxCode), duration)
 
 	return result, nil
 }
@@ -466,7 +467,7 @@ func (a *JSFXAgent) generateStreamFallback(
 		lines := strings.Split(result.JSFXCode, "\n")
 		for _, line := range lines {
 			if err := callback(line + "\n"); err != nil {
-				log.Printf("⚠️ JSFX Stream callback error: %v", err)
+				logger.Printf(ctx, "⚠️ JSFX Stream callback error: %v", err)
 			}
 		}
 	}
@@ -482,7 +483,7 @@ func (a *JSFXAgent) DescribeJSFX(
 	model string,
 	jsfxCode string,
 ) (string, error) {
-	log.Printf("📝 JSFX DESCRIBE REQUEST (Model: %s)", model)
+	logger.Printf(ctx, "📝 JSFX DESCRIBE REQUEST (Model: %s)", model)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "jsfx.describe")
@@ -507,12 +508,12 @@ JSFX Code:
 
 	resp, err := a.provider.Generate(ctx, request)
 	if err != nil {
-		log.Printf("❌ JSFX Describe error: %v", err)
+		logger.Printf(ctx, "❌ JSFX Describe error: %v", err)
 		return "", fmt.Errorf("failed to generate description: %w", err)
 	}
 
 	description := strings.TrimSpace(resp.RawOutput)
-	log.Printf("✅ JSFX Description: %s", truncateForLog(description, 200))
+	logger.Printf(ctx, "✅ JSFX Description: %s", truncateForLog(description, 200))
 
 	return description, nil
 }
@@ -534,7 +535,7 @@ func (a *JSFXAgent) GenerateWithDescription(
 	if result.JSFXCode != "" {
 		description, descErr := a.DescribeJSFX(ctx, model, result.JSFXCode)
 		if descErr != nil {
-			log.Printf("⚠️ Failed to generate description: %v", descErr)
+			logger.Printf(ctx, "⚠️ Failed to generate description: %v", descErr)
 			// Don't fail the whole request if description fails
 		} else {
 			result.Description = description
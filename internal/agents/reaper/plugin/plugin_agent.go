@@ -2,13 +2,13 @@ package plugin
 
 import (
 	"context"
-	"log"
 	"regexp"
 	"sort"
 	"strings"
 	"unicode"
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 )
 
 // PluginInfo represents a REAPER plugin
@@ -72,7 +72,7 @@ func (a *PluginAgent) GenerateAliases(ctx context.Context, plugins []PluginInfo)
 		return make(map[string]string), nil
 	}
 
-	log.Printf("🔧 Generating aliases programmatically for %d plugins", len(plugins))
+	logger.Printf(ctx, "🔧 Generating aliases programmatically for %d plugins", len(plugins))
 
 	aliases := make(map[string]string)
 
@@ -85,7 +85,7 @@ func (a *PluginAgent) GenerateAliases(ctx context.Context, plugins []PluginInfo)
 			if normalized != "" {
 				// Handle conflicts: keep first mapping
 				if existing, exists := aliases[normalized]; exists && existing != plugin.FullName {
-					log.Printf("⚠️  Alias conflict: '%s' maps to both '%s' and '%s' (keeping first)",
+					logger.Printf(ctx, "⚠️  Alias conflict: '%s' maps to both '%s' and '%s' (keeping first)",
 						normalized, existing, plugin.FullName)
 				} else if !exists {
 					aliases[normalized] = plugin.FullName
@@ -94,7 +94,7 @@ func (a *PluginAgent) GenerateAliases(ctx context.Context, plugins []PluginInfo)
 		}
 	}
 
-	log.Printf("✅ Generated %d aliases for %d plugins", len(aliases), len(plugins))
+	logger.Printf(ctx, "✅ Generated %d aliases for %d plugins", len(aliases), len(plugins))
 	return aliases, nil
 }
 
@@ -0,0 +1,184 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/metrics"
+	"github.com/Conceptual-Machines/magda-api/internal/prompt"
+	"github.com/getsentry/sentry-go"
+)
+
+// TemplateAgent generates project templates - named, colored tracks in folders, buses with
+// sends, and song sections - using LLM + CFG grammar
+type TemplateAgent struct {
+	provider     llm.Provider
+	systemPrompt string
+	metrics      *metrics.SentryMetrics
+}
+
+// TemplateResult contains the DSL output and the actions that build the template
+type TemplateResult struct {
+	DSL     string           `json:"dsl"`     // Raw DSL code from LLM
+	Actions []map[string]any `json:"actions"` // Parsed actions from Grammar School
+	Usage   any              `json:"usage"`
+}
+
+// NewTemplateAgent creates a new template agent
+func NewTemplateAgent(cfg *config.Config) *TemplateAgent {
+	return NewTemplateAgentWithProvider(cfg, nil)
+}
+
+// NewTemplateAgentWithProvider creates a template agent with a specific LLM provider
+func NewTemplateAgentWithProvider(cfg *config.Config, provider llm.Provider) *TemplateAgent {
+	// Use provided provider or create the configured one, which is required (see CheckProvider)
+	if provider == nil {
+		provider = cfg.NewProvider()
+	}
+
+	agent := &TemplateAgent{
+		provider:     provider,
+		systemPrompt: buildTemplateSystemPrompt(),
+		metrics:      metrics.NewSentryMetrics(),
+	}
+
+	log.Printf("🗂️ TEMPLATE AGENT INITIALIZED:")
+	log.Printf("   Provider: %s", provider.Name())
+
+	return agent
+}
+
+// Generate creates a project template from a description. state is the current REAPER state,
+// if any: template tracks are added after its tracks, and its installed plugins are offered
+// to the model and used to resolve plugin names.
+func (a *TemplateAgent) Generate(
+	ctx context.Context,
+	model string,
+	question string,
+	state map[string]any,
+) (*TemplateResult, error) {
+	startTime := time.Now()
+	logger.Printf(ctx, "🗂️ TEMPLATE REQUEST STARTED (Model: %s)", model)
+
+	if strings.TrimSpace(question) == "" {
+		return nil, fmt.Errorf("question is required")
+	}
+
+	// Start Sentry transaction
+	transaction := sentry.StartTransaction(ctx, "template.generate")
+	defer transaction.Finish()
+
+	transaction.SetTag("model", model)
+
+	inputArray := []map[string]any{{"role": "user", "content": question}}
+	if plugins := prompt.InstalledPluginsMessage(state); plugins != "" {
+		inputArray = append(inputArray, map[string]any{"role": "user", "content": plugins})
+	}
+
+	// Build provider request with CFG grammar
+	request := &llm.GenerationRequest{
+		Model:        model,
+		InputArray:   inputArray,
+		SystemPrompt: a.systemPrompt,
+		CFGGrammar: &llm.CFGConfig{
+			ToolName:    "template_dsl",
+			Description: buildTemplateToolDescription(),
+			Grammar:     llm.GetTemplateDSLGrammar(),
+			Syntax:      "lark",
+		},
+	}
+
+	logger.Printf(ctx, "🚀 TEMPLATE REQUEST: %s model=%s, input_messages=%d",
+		a.provider.Name(), model, len(inputArray))
+
+	resp, err := a.provider.Generate(ctx, request)
+	if err != nil {
+		transaction.SetTag("success", "false")
+		sentry.CaptureException(err)
+		return nil, fmt.Errorf("provider request failed: %w", err)
+	}
+
+	dslCode := resp.RawOutput
+	if dslCode == "" {
+		transaction.SetTag("success", "false")
+		return nil, fmt.Errorf("no DSL output in response")
+	}
+
+	logger.Printf(ctx, "🗂️ DSL Output: %s", dslCode)
+
+	parser, err := NewTemplateDSLParser()
+	if err != nil {
+		transaction.SetTag("success", "false")
+		return nil, fmt.Errorf("failed to create DSL parser: %w", err)
+	}
+	parser.SetContext(ctx)
+	parser.SetState(state)
+
+	actions, err := parser.ParseDSL(dslCode)
+	if err != nil {
+		transaction.SetTag("success", "false")
+		return nil, fmt.Errorf("failed to parse DSL: %w", err)
+	}
+
+	result := &TemplateResult{
+		DSL:     dslCode,
+		Actions: actions,
+		Usage:   resp.Usage,
+	}
+
+	// Record metrics
+	transaction.SetTag("success", "true")
+	transaction.SetTag("action_count", fmt.Sprintf("%d", len(actions)))
+
+	duration := time.Since(startTime)
+	a.metrics.RecordGenerationDuration(ctx, duration, true)
+
+	logger.Printf(ctx, "✅ TEMPLATE COMPLETE: %d actions", len(actions))
+
+	return result, nil
+}
+
+// buildTemplateSystemPrompt creates the system prompt for the template agent
+func buildTemplateSystemPrompt() string {
+	return `You are a music producer setting up a new REAPER project. Lay out the tracks, folders,
+buses, sends and song sections for the requested genre or setup.
+
+SCOPE: You ONLY handle requests for project templates and session layouts.
+If a request is not about setting up a project, return NOTHING - do not generate any output.
+
+SYNTAX:
+- folder(name="Drums", color="red") - a folder; tracks join it with folder="Drums"
+- track(name="Kick", folder="Drums", instrument="ReaSamplOmatic5000", color="orange") - folder, instrument and color are optional
+- bus(name="Reverb Bus", color="purple") - a return or group bus
+- fx(track="Reverb Bus", fxname="ReaVerbate") - an effect on a track, bus or folder
+- send(from="Snare", to="Reverb Bus", level_db=-12, pre_fader=false) - level_db and pre_fader are optional
+- section(name="Intro", start_bar=1, end_bar=17, color="blue") - end_bar is exclusive, so this is 16 bars
+
+RULES:
+- Declare folders, tracks and buses before referring to them; names must be unique.
+- Folders can't be nested. Tracks in a folder are placed right after it, in declaration order.
+- Colors are names (red, orange, yellow, green, blue, purple, pink, ...) or hex like "#ff8800".
+- Prefer instruments and effects from the installed plugin list when one is given.
+- Sections follow each other without gaps.
+
+EXAMPLE - techno:
+folder(name="Drums", color="red"); track(name="Kick", folder="Drums", color="red"); track(name="Hats", folder="Drums", color="orange"); bus(name="Reverb", color="purple"); fx(track="Reverb", fxname="ReaVerbate"); track(name="Bass", instrument="ReaSynth", color="blue"); send(from="Hats", to="Reverb", level_db=-12); section(name="Intro", start_bar=1, end_bar=17); section(name="Drop", start_bar=17, end_bar=49, color="red")
+
+Use semicolons to separate calls. Always output valid DSL.
+`
+}
+
+// buildTemplateToolDescription creates the tool description for CFG
+func buildTemplateToolDescription() string {
+	return `Generate a project template. Declare folders, tracks and buses by name, then FX, sends and song sections, separated by semicolons:
+
+folder(name="Drums", color="red"); track(name="Kick", folder="Drums"); bus(name="Reverb"); send(from="Kick", to="Reverb", level_db=-12); section(name="Intro", start_bar=1, end_bar=17)
+
+end_bar is exclusive. Tracks join a folder with folder="NAME"; folders can't be nested.`
+}
@@ -0,0 +1,363 @@
+package template
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/plugins"
+)
+
+// TemplateDSLParser parses Template DSL code using Grammar School and compiles the declared
+// layout to DAW actions
+type TemplateDSLParser struct {
+	engine      *gs.Engine
+	templateDSL *TemplateDSL
+	state       map[string]any
+	ctx         context.Context // Request context, carries correlation IDs into log lines
+
+	// The template as declared, filled in by the DSL methods
+	tracks   []*templateTrack
+	byName   map[string]*templateTrack
+	fx       []templateFx
+	sends    []templateSend
+	sections []map[string]any
+}
+
+// TemplateDSL implements the DSL side-effect methods
+type TemplateDSL struct {
+	parser *TemplateDSLParser
+}
+
+// templateTrack is a declared track, bus or folder
+type templateTrack struct {
+	name       string
+	folder     string // Name of the folder it belongs to, "" at the top level
+	instrument string
+	color      string
+	isFolder   bool
+	children   []*templateTrack
+}
+
+type templateFx struct {
+	track  string
+	fxname string
+}
+
+type templateSend struct {
+	from, to string
+	props    map[string]any
+}
+
+// NewTemplateDSLParser creates a new template DSL parser
+func NewTemplateDSLParser() (*TemplateDSLParser, error) {
+	parser := &TemplateDSLParser{templateDSL: &TemplateDSL{}}
+	parser.templateDSL.parser = parser
+
+	grammar := llm.GetTemplateDSLGrammar()
+	if err := llm.ValidateGrammar("Template DSL", grammar); err != nil {
+		return nil, err
+	}
+
+	engine, err := gs.NewEngine(grammar, parser.templateDSL, gs.NewLarkParser())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create engine: %w", err)
+	}
+	parser.engine = engine
+	return parser, nil
+}
+
+// SetContext sets the request context used for DSL execution and logging
+func (p *TemplateDSLParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// SetState sets the project state. New tracks go after the state's tracks, and plugin names
+// are resolved against the installed plugins it lists.
+func (p *TemplateDSLParser) SetState(state map[string]any) {
+	p.state = state
+}
+
+// ParseDSL parses a template and returns the actions that build it, in order: tracks with
+// their color, folder depth and FX, then sends, then regions for the song sections
+func (p *TemplateDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
+	if strings.TrimSpace(dslCode) == "" {
+		return nil, fmt.Errorf("empty DSL code")
+	}
+
+	p.tracks = nil
+	p.byName = make(map[string]*templateTrack)
+	p.fx = nil
+	p.sends = nil
+	p.sections = nil
+
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to execute DSL: %w", err)
+	}
+	if err := p.engine.Execute(ctx, dslCode); err != nil {
+		return nil, fmt.Errorf("failed to execute DSL: %w", err)
+	}
+
+	actions, err := p.build()
+	if err != nil {
+		return nil, err
+	}
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("no actions found in DSL code")
+	}
+
+	logger.Printf(p.ctx, "✅ Template DSL Parser: %d tracks, %d sends, %d sections → %d actions",
+		len(p.tracks), len(p.sends), len(p.sections), len(actions))
+	return actions, nil
+}
+
+// Folder handles folder() calls: a folder track that the tracks naming it are put in
+func (t *TemplateDSL) Folder(args gs.Args) error {
+	return t.parser.declareTrack("folder", args, true)
+}
+
+// Track handles track() calls: an instrument or audio track
+func (t *TemplateDSL) Track(args gs.Args) error {
+	return t.parser.declareTrack("track", args, false)
+}
+
+// Bus handles bus() calls: a track that other tracks send to
+func (t *TemplateDSL) Bus(args gs.Args) error {
+	if _, ok := args["instrument"]; ok {
+		return fmt.Errorf("bus takes no instrument")
+	}
+	return t.parser.declareTrack("bus", args, false)
+}
+
+// Fx handles fx() calls: adds an effect to a declared track, bus or folder
+func (t *TemplateDSL) Fx(args gs.Args) error {
+	track, err := stringArg("fx", args, "track")
+	if err != nil {
+		return err
+	}
+	fxname, err := stringArg("fx", args, "fxname")
+	if err != nil {
+		return err
+	}
+	t.parser.fx = append(t.parser.fx, templateFx{track: track, fxname: fxname})
+	return nil
+}
+
+// Send handles send() calls: routes one declared track to another
+func (t *TemplateDSL) Send(args gs.Args) error {
+	from, err := stringArg("send", args, "from")
+	if err != nil {
+		return err
+	}
+	to, err := stringArg("send", args, "to")
+	if err != nil {
+		return err
+	}
+	props := make(map[string]any)
+	if levelValue, ok := args["level_db"]; ok && levelValue.Kind == gs.ValueNumber {
+		props["level_db"] = levelValue.Num
+	}
+	if preFaderValue, ok := args["pre_fader"]; ok && preFaderValue.Kind == gs.ValueBool {
+		props["pre_fader"] = preFaderValue.Bool
+	}
+	t.parser.sends = append(t.parser.sends, templateSend{from: from, to: to, props: props})
+	return nil
+}
+
+// Section handles section() calls: a song section, created as a region. end_bar is exclusive.
+func (t *TemplateDSL) Section(args gs.Args) error {
+	name, err := stringArg("section", args, "name")
+	if err != nil {
+		return err
+	}
+	startValue, hasStart := args["start_bar"]
+	endValue, hasEnd := args["end_bar"]
+	if !hasStart || !hasEnd || startValue.Kind != gs.ValueNumber || endValue.Kind != gs.ValueNumber {
+		return fmt.Errorf("section %q requires start_bar and end_bar", name)
+	}
+	startBar, endBar := int(startValue.Num), int(endValue.Num)
+	if startBar < 1 {
+		return fmt.Errorf("section %q start_bar must be 1 or greater, got %d", name, startBar)
+	}
+	if endBar <= startBar {
+		return fmt.Errorf("section %q end_bar (%d) must be after start_bar (%d)", name, endBar, startBar)
+	}
+
+	region := map[string]any{"action": "add_region", "start_bar": startBar, "end_bar": endBar, "name": name}
+	if colorValue, ok := args["color"]; ok && colorValue.Kind == gs.ValueString {
+		region["color"] = magdadaw.ColorHex(colorValue.Str)
+	}
+	t.parser.sections = append(t.parser.sections, region)
+	return nil
+}
+
+// declareTrack records a folder, track or bus under its (unique) name
+func (p *TemplateDSLParser) declareTrack(kind string, args gs.Args, isFolder bool) error {
+	name, err := stringArg(kind, args, "name")
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(strings.TrimSpace(name))
+	if _, exists := p.byName[key]; exists {
+		return fmt.Errorf("%s %q: a track with that name is already declared", kind, name)
+	}
+
+	track := &templateTrack{name: name, isFolder: isFolder}
+	if folderValue, ok := args["folder"]; ok && folderValue.Kind == gs.ValueString {
+		if isFolder {
+			return fmt.Errorf("folder %q: folders can't be nested", name)
+		}
+		track.folder = folderValue.Str
+	}
+	if instrumentValue, ok := args["instrument"]; ok && instrumentValue.Kind == gs.ValueString {
+		track.instrument = instrumentValue.Str
+	}
+	if colorValue, ok := args["color"]; ok && colorValue.Kind == gs.ValueString {
+		track.color = magdadaw.ColorHex(colorValue.Str)
+	}
+
+	p.tracks = append(p.tracks, track)
+	p.byName[key] = track
+	return nil
+}
+
+// lookup returns the declared track called name
+func (p *TemplateDSLParser) lookup(name string) (*templateTrack, bool) {
+	track, ok := p.byName[strings.ToLower(strings.TrimSpace(name))]
+	return track, ok
+}
+
+// build lays the declared tracks out - each folder followed by its children, everything else
+// in declaration order - and emits the actions, with indices after the state's tracks
+func (p *TemplateDSLParser) build() ([]map[string]any, error) {
+	var layout []*templateTrack
+	for _, track := range p.tracks {
+		if track.folder == "" {
+			layout = append(layout, track)
+			continue
+		}
+		folder, ok := p.lookup(track.folder)
+		if !ok || !folder.isFolder {
+			return nil, fmt.Errorf("%q is in folder %q, which isn't declared with folder()", track.name, track.folder)
+		}
+		folder.children = append(folder.children, track)
+	}
+
+	var ordered []*templateTrack
+	for _, track := range layout {
+		ordered = append(ordered, track)
+		ordered = append(ordered, track.children...)
+	}
+
+	base := len(stateTracks(p.state))
+	indices := make(map[*templateTrack]int, len(ordered))
+	depths := make(map[*templateTrack]int)
+	for i, track := range ordered {
+		indices[track] = base + i
+		if track.isFolder && len(track.children) > 0 {
+			depths[track] = 1
+			depths[track.children[len(track.children)-1]] = -1
+		}
+	}
+
+	fxByTrack := make(map[*templateTrack][]string)
+	for _, fx := range p.fx {
+		track, ok := p.lookup(fx.track)
+		if !ok {
+			return nil, fmt.Errorf("fx %q is for %q, which isn't declared", fx.fxname, fx.track)
+		}
+		fxByTrack[track] = append(fxByTrack[track], fx.fxname)
+	}
+
+	resolvePlugin := p.pluginResolver()
+	actions := make([]map[string]any, 0, len(ordered)*2)
+	for _, track := range ordered {
+		index := indices[track]
+		create := map[string]any{"action": "create_track", "index": index, "name": track.name}
+		if track.instrument != "" {
+			create["instrument"] = resolvePlugin(track.instrument)
+		}
+		actions = append(actions, create)
+
+		props := map[string]any{}
+		if track.color != "" {
+			props["color"] = track.color
+		}
+		if depth, ok := depths[track]; ok {
+			props["folder_depth"] = depth
+		}
+		if len(props) > 0 {
+			props["action"] = "set_track"
+			props["track"] = index
+			actions = append(actions, props)
+		}
+
+		for _, fxname := range fxByTrack[track] {
+			actions = append(actions, map[string]any{"action": "add_track_fx", "track": index, "fxname": resolvePlugin(fxname)})
+		}
+	}
+
+	for _, send := range p.sends {
+		from, ok := p.lookup(send.from)
+		if !ok {
+			return nil, fmt.Errorf("send from %q: no track with that name is declared", send.from)
+		}
+		to, ok := p.lookup(send.to)
+		if !ok {
+			return nil, fmt.Errorf("send to %q: no track with that name is declared", send.to)
+		}
+		if from == to {
+			return nil, fmt.Errorf("send: %q cannot send to itself", send.from)
+		}
+		action := map[string]any{"action": "add_send", "track": indices[from], "dest": indices[to]}
+		for k, v := range send.props {
+			action[k] = v
+		}
+		actions = append(actions, action)
+	}
+
+	return append(actions, p.sections...), nil
+}
+
+// pluginResolver returns a function mapping plugin names to the installed plugin they refer
+// to. Ambiguous and unknown names are kept as written.
+func (p *TemplateDSLParser) pluginResolver() func(string) string {
+	registry := plugins.FromState(p.state)
+	if registry == nil {
+		return func(name string) string { return name }
+	}
+	return func(name string) string {
+		resolution := registry.Resolve(name)
+		if resolution.Status != plugins.StatusResolved {
+			logger.Printf(p.ctx, "⚠️ Template plugin %q is %s, keeping the name", name, resolution.Status)
+			return name
+		}
+		return resolution.FullName
+	}
+}
+
+// stringArg returns a required, non-empty string argument
+func stringArg(call string, args gs.Args, key string) (string, error) {
+	value, ok := args[key]
+	if !ok || value.Kind != gs.ValueString || strings.TrimSpace(value.Str) == "" {
+		return "", fmt.Errorf("%s requires %s (string)", call, key)
+	}
+	return value.Str, nil
+}
+
+// stateTracks returns the tracks in state, which may be nested under "state"
+func stateTracks(state map[string]any) []any {
+	if inner, ok := state["state"].(map[string]any); ok {
+		state = inner
+	}
+	tracks, _ := state["tracks"].([]any)
+	return tracks
+}
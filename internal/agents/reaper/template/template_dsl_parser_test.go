@@ -0,0 +1,144 @@
+package template
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateDSLParser_ParseDSL(t *testing.T) {
+	tests := []struct {
+		name     string
+		dsl      string
+		state    map[string]any
+		expected []map[string]any
+	}{
+		{
+			name: "folder children follow the folder",
+			dsl:  `track(name="Bass", instrument="ReaSynth"); folder(name="Drums", color="#ff0000"); track(name="Kick", folder="Drums"); track(name="Snare", folder="Drums")`,
+			expected: []map[string]any{
+				{"action": "create_track", "index": 0, "name": "Bass", "instrument": "ReaSynth"},
+				{"action": "create_track", "index": 1, "name": "Drums"},
+				{"action": "set_track", "track": 1, "color": "#ff0000", "folder_depth": 1},
+				{"action": "create_track", "index": 2, "name": "Kick"},
+				{"action": "create_track", "index": 3, "name": "Snare"},
+				{"action": "set_track", "track": 3, "folder_depth": -1},
+			},
+		},
+		{
+			name: "children declared before their folder",
+			dsl:  `track(name="Kick", folder="Drums"); folder(name="Drums")`,
+			expected: []map[string]any{
+				{"action": "create_track", "index": 0, "name": "Drums"},
+				{"action": "set_track", "track": 0, "folder_depth": 1},
+				{"action": "create_track", "index": 1, "name": "Kick"},
+				{"action": "set_track", "track": 1, "folder_depth": -1},
+			},
+		},
+		{
+			name: "bus with fx and sends",
+			dsl:  `track(name="Snare"); bus(name="Reverb"); fx(track="Reverb", fxname="ReaVerbate"); send(from="snare", to="Reverb", level_db=-12, pre_fader=true)`,
+			expected: []map[string]any{
+				{"action": "create_track", "index": 0, "name": "Snare"},
+				{"action": "create_track", "index": 1, "name": "Reverb"},
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaVerbate"},
+				{"action": "add_send", "track": 0, "dest": 1, "level_db": -12.0, "pre_fader": true},
+			},
+		},
+		{
+			name:  "indices start after existing tracks",
+			dsl:   `track(name="Lead"); section(name="Intro", start_bar=1, end_bar=9)`,
+			state: map[string]any{"tracks": []any{map[string]any{"index": 0}, map[string]any{"index": 1}}},
+			expected: []map[string]any{
+				{"action": "create_track", "index": 2, "name": "Lead"},
+				{"action": "add_region", "start_bar": 1, "end_bar": 9, "name": "Intro"},
+			},
+		},
+		{
+			name: "sections only",
+			dsl:  `section(name="Intro", start_bar=1, end_bar=17); section(name="Drop", start_bar=17, end_bar=33)`,
+			expected: []map[string]any{
+				{"action": "add_region", "start_bar": 1, "end_bar": 17, "name": "Intro"},
+				{"action": "add_region", "start_bar": 17, "end_bar": 33, "name": "Drop"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewTemplateDSLParser()
+			require.NoError(t, err)
+			parser.SetState(tt.state)
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actions)
+		})
+	}
+}
+
+func TestTemplateDSLParser_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsl         string
+		errContains string
+	}{
+		{
+			name:        "duplicate name",
+			dsl:         `track(name="Kick"); bus(name="kick")`,
+			errContains: "already declared",
+		},
+		{
+			name:        "unknown folder",
+			dsl:         `track(name="Kick", folder="Drums")`,
+			errContains: "isn't declared with folder()",
+		},
+		{
+			name:        "folder that is a track",
+			dsl:         `track(name="Drums"); track(name="Kick", folder="Drums")`,
+			errContains: "isn't declared with folder()",
+		},
+		{
+			name:        "nested folder",
+			dsl:         `folder(name="Drums"); folder(name="Toms", folder="Drums")`,
+			errContains: "can't be nested",
+		},
+		{
+			name:        "fx on unknown track",
+			dsl:         `fx(track="Reverb", fxname="ReaVerbate")`,
+			errContains: "isn't declared",
+		},
+		{
+			name:        "send to unknown track",
+			dsl:         `track(name="Kick"); send(from="Kick", to="Reverb")`,
+			errContains: "no track with that name",
+		},
+		{
+			name:        "send to itself",
+			dsl:         `track(name="Kick"); send(from="Kick", to="Kick")`,
+			errContains: "cannot send to itself",
+		},
+		{
+			name:        "section ends before it starts",
+			dsl:         `section(name="Intro", start_bar=9, end_bar=9)`,
+			errContains: "must be after start_bar",
+		},
+		{
+			name:        "empty DSL",
+			dsl:         "",
+			errContains: "empty DSL code",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewTemplateDSLParser()
+			require.NoError(t, err)
+
+			_, err = parser.ParseDSL(tt.dsl)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const maxArrangementBars = 512
+
+// ArrangementSection is one section of a song structure, e.g. an 8-bar chorus from bar 17
+type ArrangementSection struct {
+	Name string `json:"name"`
+	Bar  int    `json:"bar"` // 1-based bar the section starts at
+	Bars int    `json:"bars"`
+}
+
+// presetSection is a section of an arrangement preset with its typical length
+type presetSection struct {
+	name string
+	bars int
+}
+
+// arrangementPresets maps genres to their typical song structure, in order
+var arrangementPresets = map[string][]presetSection{
+	"pop": {
+		{"Intro", 4}, {"Verse", 8}, {"Pre-Chorus", 4}, {"Chorus", 8}, {"Verse", 8},
+		{"Pre-Chorus", 4}, {"Chorus", 8}, {"Bridge", 8}, {"Chorus", 8}, {"Outro", 4},
+	},
+	"rock": {
+		{"Intro", 4}, {"Verse", 8}, {"Chorus", 8}, {"Verse", 8}, {"Chorus", 8},
+		{"Solo", 8}, {"Chorus", 8}, {"Outro", 4},
+	},
+	"edm": {
+		{"Intro", 16}, {"Build", 8}, {"Drop", 16}, {"Breakdown", 16}, {"Build", 8},
+		{"Drop", 16}, {"Outro", 16},
+	},
+	"house": {
+		{"Intro", 16}, {"Groove", 32}, {"Breakdown", 16}, {"Drop", 32}, {"Outro", 16},
+	},
+	"hiphop": {
+		{"Intro", 4}, {"Verse", 16}, {"Hook", 8}, {"Verse", 16}, {"Hook", 8}, {"Outro", 4},
+	},
+	"jazz": { // 32-bar AABA head
+		{"A", 8}, {"A", 8}, {"B", 8}, {"A", 8},
+	},
+}
+
+// arrangementGenreAliases maps other names for a genre to its preset
+var arrangementGenreAliases = map[string]string{
+	"hip-hop": "hiphop", "hip_hop": "hiphop", "hip hop": "hiphop", "rap": "hiphop",
+	"dance": "edm", "electronic": "edm", "trance": "edm", "dubstep": "edm",
+	"techno": "house", "deep house": "house",
+	"aaba": "jazz", "standard": "jazz",
+}
+
+// genreKey normalizes a genre name to its preset key ("Hip Hop" → "hiphop")
+func genreKey(genre string) string {
+	key := strings.ToLower(strings.TrimSpace(genre))
+	if alias, ok := arrangementGenreAliases[key]; ok {
+		return alias
+	}
+	return key
+}
+
+// ArrangementPresetGenres returns the genres with an arrangement preset, sorted
+func ArrangementPresetGenres() []string {
+	genres := make([]string, 0, len(arrangementPresets))
+	for genre := range arrangementPresets {
+		genres = append(genres, genre)
+	}
+	sort.Strings(genres)
+	return genres
+}
+
+// ArrangementPreset lays a genre's song structure out from startBar. sectionBars, when
+// positive, gives every section that length instead of its typical one ("an 8-bar pop
+// structure").
+func ArrangementPreset(genre string, startBar, sectionBars int) ([]ArrangementSection, error) {
+	preset, ok := arrangementPresets[genreKey(genre)]
+	if !ok {
+		return nil, fmt.Errorf("unknown arrangement genre %q (available: %s)", genre, strings.Join(ArrangementPresetGenres(), ", "))
+	}
+	if startBar < 1 {
+		return nil, fmt.Errorf("arrangement bar must be 1 or later, got %d", startBar)
+	}
+	if sectionBars < 0 || sectionBars > maxSectionBars {
+		return nil, fmt.Errorf("arrangement section_bars must be from 1 to %d, got %d", maxSectionBars, sectionBars)
+	}
+
+	sections := make([]ArrangementSection, 0, len(preset))
+	bar := startBar
+	for _, section := range preset {
+		bars := section.bars
+		if sectionBars > 0 {
+			bars = sectionBars
+		}
+		sections = append(sections, ArrangementSection{Name: section.name, Bar: bar, Bars: bars})
+		bar += bars
+	}
+	if total := bar - startBar; total > maxArrangementBars {
+		return nil, fmt.Errorf("arrangement is %d bars, more than the maximum of %d", total, maxArrangementBars)
+	}
+	return sections, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArrangementPreset(t *testing.T) {
+	tests := []struct {
+		name        string
+		genre       string
+		startBar    int
+		sectionBars int
+		want        []ArrangementSection
+	}{
+		{
+			name:     "jazz AABA from bar 1",
+			genre:    "jazz",
+			startBar: 1,
+			want: []ArrangementSection{
+				{Name: "A", Bar: 1, Bars: 8}, {Name: "A", Bar: 9, Bars: 8},
+				{Name: "B", Bar: 17, Bars: 8}, {Name: "A", Bar: 25, Bars: 8},
+			},
+		},
+		{
+			name:     "alias and later start",
+			genre:    "Hip-Hop",
+			startBar: 5,
+			want: []ArrangementSection{
+				{Name: "Intro", Bar: 5, Bars: 4}, {Name: "Verse", Bar: 9, Bars: 16}, {Name: "Hook", Bar: 25, Bars: 8},
+				{Name: "Verse", Bar: 33, Bars: 16}, {Name: "Hook", Bar: 49, Bars: 8}, {Name: "Outro", Bar: 57, Bars: 4},
+			},
+		},
+		{
+			name:        "section_bars overrides every section",
+			genre:       "house",
+			startBar:    1,
+			sectionBars: 8,
+			want: []ArrangementSection{
+				{Name: "Intro", Bar: 1, Bars: 8}, {Name: "Groove", Bar: 9, Bars: 8}, {Name: "Breakdown", Bar: 17, Bars: 8},
+				{Name: "Drop", Bar: 25, Bars: 8}, {Name: "Outro", Bar: 33, Bars: 8},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ArrangementPreset(tt.genre, tt.startBar, tt.sectionBars)
+			if err != nil {
+				t.Fatalf("ArrangementPreset failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ArrangementPreset(%q, %d, %d) = %+v, want %+v", tt.genre, tt.startBar, tt.sectionBars, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrangementPreset_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		genre       string
+		startBar    int
+		sectionBars int
+	}{
+		{name: "unknown genre", genre: "polka", startBar: 1},
+		{name: "bar before 1", genre: "pop", startBar: 0},
+		{name: "section_bars too long", genre: "pop", startBar: 1, sectionBars: maxSectionBars + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ArrangementPreset(tt.genre, tt.startBar, tt.sectionBars); err == nil {
+				t.Errorf("ArrangementPreset(%q, %d, %d) succeeded, want error", tt.genre, tt.startBar, tt.sectionBars)
+			}
+		})
+	}
+}
+
+func TestArrangerDSLParser_Arrangement(t *testing.T) {
+	parser, err := NewArrangerDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	actions, err := parser.ParseDSL(`arrangement(genre="edm", bar=9, tracks=[0, 2])`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	if len(actions) != 1 || actions[0]["type"] != "arrangement" {
+		t.Fatalf("got %+v, want one arrangement action", actions)
+	}
+
+	sections, _ := actions[0]["sections"].([]ArrangementSection)
+	wantNames := []string{"Intro", "Build", "Drop", "Breakdown", "Build", "Drop", "Outro"}
+	if len(sections) != len(wantNames) {
+		t.Fatalf("got %d sections, want %d", len(sections), len(wantNames))
+	}
+	for i, name := range wantNames {
+		if sections[i].Name != name {
+			t.Errorf("section %d = %q, want %q", i, sections[i].Name, name)
+		}
+	}
+	if sections[0].Bar != 9 || sections[1].Bar != 25 {
+		t.Errorf("sections start at bars %d and %d, want 9 and 25", sections[0].Bar, sections[1].Bar)
+	}
+	if tracks, _ := actions[0]["tracks"].([]int); !reflect.DeepEqual(tracks, []int{0, 2}) {
+		t.Errorf("tracks = %v, want [0 2]", tracks)
+	}
+
+	// Arrangements produce no notes of their own
+	notes, err := ConvertArrangerActionToNoteEvents(actions[0], 0)
+	if err != nil || len(notes) != 0 {
+		t.Errorf("ConvertArrangerActionToNoteEvents = %d notes, %v; want none", len(notes), err)
+	}
+}
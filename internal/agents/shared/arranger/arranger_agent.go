@@ -11,6 +11,7 @@ import (
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/metrics"
 	"github.com/Conceptual-Machines/magda-api/internal/models"
 	"github.com/Conceptual-Machines/magda-api/internal/prompt"
@@ -53,9 +54,9 @@ func NewGenerationServiceWithProvider(cfg *config.Config, provider llm.Provider)
 		log.Fatal("Failed to load system prompt:", err)
 	}
 
-	// Use provided provider or create OpenAI provider (default)
+	// Use provided provider or create the configured one, which is required (see CheckProvider)
 	if provider == nil {
-		provider = llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+		provider = cfg.NewProvider()
 	}
 
 	var mcpLabel string
@@ -117,7 +118,7 @@ func (s *GenerationService) Generate(
 	ctx context.Context, model string, inputArray []map[string]any, reasoningMode string,
 ) (*GenerationResult, error) {
 	startTime := time.Now()
-	log.Printf("🎵 GENERATION REQUEST STARTED (Model: %s)", model)
+	logger.Printf(ctx, "🎵 GENERATION REQUEST STARTED (Model: %s)", model)
 
 	// Start Sentry transaction for performance monitoring
 	transaction := sentry.StartTransaction(ctx, "generation.generate")
@@ -158,7 +159,7 @@ func (s *GenerationService) Generate(
 	}
 
 	// Call provider
-	log.Printf("🚀 PROVIDER REQUEST: %s model=%s, mcp_enabled=%t, input_messages=%d",
+	logger.Printf(ctx, "🚀 PROVIDER REQUEST: %s model=%s, mcp_enabled=%t, input_messages=%d",
 		s.provider.Name(), model, s.mcpURL != "", len(inputArray))
 
 	resp, err := s.provider.Generate(ctx, request)
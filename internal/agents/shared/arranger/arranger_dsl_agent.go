@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/metrics"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
 	"github.com/Conceptual-Machines/magda-api/internal/prompt"
 	"github.com/getsentry/sentry-go"
 	"github.com/openai/openai-go/responses"
@@ -43,8 +46,8 @@ func newArrangerAgent(cfg *config.Config, useMCP bool, mcpURL, mcpLabel string)
 		log.Fatal("Failed to load MAGDA system prompt:", err)
 	}
 
-	// Use OpenAI provider (default for now)
-	provider := llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+	// Use the configured provider, which is required (see CheckProvider)
+	provider := cfg.NewProvider()
 
 	agent := &ArrangerAgent{
 		provider:      provider,
@@ -90,7 +93,7 @@ func (a *ArrangerAgent) GenerateActions(
 	ctx context.Context, question string,
 ) (*ArrangerResult, error) {
 	startTime := time.Now()
-	log.Printf("🎵 ARRANGER REQUEST STARTED: question=%s", question)
+	logger.Printf(ctx, "🎵 ARRANGER REQUEST STARTED: question=%s", question)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "arranger.generate_actions")
@@ -109,7 +112,12 @@ func (a *ArrangerAgent) GenerateActions(
 	})
 
 	// Build input messages
-	inputArray := a.buildInputMessages(question)
+	projectKey, hasKey := ProjectKeyFromContext(ctx)
+	var keyHint *KeyEstimate
+	if hasKey {
+		keyHint = &projectKey
+	}
+	inputArray := a.buildInputMessages(question, keyHint, MusicalDefaultsFromContext(ctx))
 
 	// Build provider request
 	request := &llm.GenerationRequest{
@@ -122,24 +130,65 @@ func (a *ArrangerAgent) GenerateActions(
 	// Use CFG grammar for DSL output
 	request.CFGGrammar = &llm.CFGConfig{
 		ToolName: "arranger_dsl",
-		Description: "Generate ONE musical call. Choose exactly ONE:\n" +
+		Description: "Generate ONE musical call (optionally followed by quantize/humanize/groove). Choose exactly ONE:\n" +
 			"1. NOTE (single sustained note): note(pitch=\"E1\", duration=4)\n" +
 			"   - pitch: Note name like E1, C4, F#3, Bb2 (octave 4 = middle C)\n" +
 			"   - duration: Length in beats (1=quarter, 4=whole note/1 bar)\n" +
 			"   - Use for 'sustained E1', 'add note C4', 'bass note', etc.\n" +
 			"2. ARPEGGIO (sequential notes): arpeggio(symbol=Em, note_duration=0.25, length=8)\n" +
-			"   - symbol: Chord symbol (Em, C, Am7, etc.)\n" +
+			"   - symbol: Chord symbol (Em, C, Am7, Cmaj9, C13b9, G7#5, Fsus2, Dm7b5, Bb/D slash chords, etc.)\n" +
 			"   - note_duration: 0.25=16th, 0.5=8th, 1=quarter note\n" +
 			"   - length: total beats (1 bar=4 beats, 2 bars=8 beats)\n" +
+			"   - probability: optional 0-1 chance each note plays (for sparse/generative variation), seed: optional integer for repeatable results\n" +
 			"3. CHORD (simultaneous notes): chord(symbol=C, length=4)\n" +
+			"   - strum_ms: optional milliseconds between notes (15-40 for a guitar/keys strum), direction: down (low to high) or up\n" +
+			"   - inversion: number of inversions, voicing: close, spread (open), or drop2\n" +
 			"4. PROGRESSION (chord sequence): progression(chords=[C, Am, F, G], length=16)\n" +
+			"   - per-chord octave: chords=[C:3, Am:4] (lower octave for bass register), octave: default for chords without one\n" +
+			"   - voicing=smooth: voice leading for pads/keys (common tones held, inversions chosen), voices: notes per chord (2-6), low/high: register limits as note names (low=C3, high=G4)\n" +
+			"5. WALKING BASS (jazz quarter-note bassline): walking_bass(progression=[Dm7, G7, Cmaj7], length=12)\n" +
+			"   - length: total beats, default 1 bar per chord\n" +
+			"6. DRUM PATTERN (kick/snare/hat groove): drum_pattern(style=\"house\", length=8, swing=0.1)\n" +
+			"   - style: house, techno, trap, rock, or bossa; swing: 0 straight to 0.33 triplet feel\n" +
+			"7. SECTION (several tracks at once): section(name=\"Chorus\", bars=8, tracks=[{track=0, progression=[C, Am, F, G]}, {track=1, drum_pattern=\"house\"}])\n" +
+			"   - one clip per tracks entry, all starting at bar (default 1) and lasting bars; track is the 0-based track index\n" +
+			"   - each entry has ONE of progression, walking_bass, arpeggio, chord, or drum_pattern, plus octave, velocity, note_duration, rhythm, swing, velocity_curve\n" +
+			"8. ARRANGEMENT (song structure from a genre preset): arrangement(genre=\"pop\", tracks=[0, 1])\n" +
+			"   - genre: pop, rock, edm, house, hiphop, or jazz (AABA); adds a region per section (intro, verse, chorus, build, drop...) from bar (default 1)\n" +
+			"   - section_bars: give every section that length instead of its typical one; tracks: 0-based tracks to get an empty clip per section\n" +
+			"9. QUANTIZE / HUMANIZE (edit notes): quantize(grid=0.25, strength=0.8), humanize(timing_ms=10, velocity=8)\n" +
+			"   - after a call with '; ' they edit the generated notes: arpeggio(symbol=Em, note_duration=0.25); humanize(timing_ms=10, velocity=8)\n" +
+			"   - on their own they edit the selected clips' existing notes\n" +
+			"   - grid: beats (0.25=16th, 0.5=8th, 1=quarter), strength: 0-1 (1 = snap exactly), timing_ms/velocity: max random shift either way\n" +
+			"10. GROOVE (swing/shuffle feel, a transform like quantize): groove(template=\"swing\", amount=0.6)\n" +
+			"   - template: swing (8ths), shuffle (8ths, heavier off-beats), or swing16 (16ths); amount: 0-1, a percentage of swing maps directly (55% → 0.55)\n" +
+			"   - from_track: copy the feel of a 0-based track's existing notes instead of a template, measured on grid (0.5 for swung 8ths, default 0.25)\n" +
+			"**DYNAMICS**: arpeggio, progression, and drum_pattern take velocity_curve=crescendo|decrescendo|accent_on_beat|random_range (random_range: velocity_range=15, seed=N)\n" +
+			"**SEEDS**: arpeggio, progression, and drum_pattern take probability=0-1 (chance each note plays); random choices (probability, humanize, random_range) follow seed=N, and the same seed repeats them\n" +
+			"   - 'regenerate with seed 42 but faster notes' → the previous call with seed=42 and a shorter note_duration\n" +
+			"**DEFAULTS**: defaults(octave=3, velocity=90, bars=2, time_signature=\"3/4\") changes what later calls leave out, for this and the session's later requests; alone or first, followed by '; '\n" +
+			"   - use it for lasting preferences ('from now on', 'always', 'everything'); octave is the chord/arpeggio octave (default 4) and moves bass parts with it, bars the length of parts without one\n" +
 			"**LENGTH CONVERSION**: 1 bar = 4 beats. So 'sustained' = duration=4, '2 bar' = length=8\n" +
 			"Examples:\n" +
 			"- 'sustained E1' → note(pitch=\"E1\", duration=4)\n" +
 			"- 'add note C4 for 2 bars' → note(pitch=\"C4\", duration=8)\n" +
 			"- 'E minor arpeggio' → arpeggio(symbol=Em, note_duration=0.25, length=4)\n" +
 			"- 'C major chord' → chord(symbol=C, length=4)\n" +
-			"- 'I-vi-IV-V in C' → progression(chords=[C, Am, F, G], length=16)",
+			"- 'strummed G chord' → chord(symbol=G, length=4, strum_ms=25, direction=down)\n" +
+			"- 'I-vi-IV-V in C' → progression(chords=[C, Am, F, G], length=16)\n" +
+			"- 'smooth 4-voice pad chords for ii-V-I in C' → progression(chords=[Dm7, G7, Cmaj7], length=12, voicing=smooth, voices=4)\n" +
+			"- 'walking bass over ii-V-I in C' → walking_bass(progression=[Dm7, G7, Cmaj7], length=12)\n" +
+			"- '2 bar house beat with a little swing' → drum_pattern(style=\"house\", length=8, swing=0.1)\n" +
+			"- 'Am arpeggio that builds up over 4 bars' → arpeggio(symbol=Am, note_duration=0.25, length=16, velocity_curve=crescendo)\n" +
+			"- '8 bar chorus: chords on track 1, house beat on track 2' → section(name=\"Chorus\", bars=8, tracks=[{track=0, progression=[C, Am, F, G]}, {track=1, drum_pattern=\"house\"}])\n" +
+			"- 'arrange an 8-bar pop structure' → arrangement(genre=\"pop\", section_bars=8)\n" +
+			"- 'standard EDM build-drop layout on tracks 1 and 2' → arrangement(genre=\"edm\", tracks=[0, 1])\n" +
+			"- 'quantize the drums to 16ths' → quantize(grid=0.25)\n" +
+			"- 'humanize the hi-hats' → humanize(timing_ms=10, velocity=8)\n" +
+			"- 'add 55% swing to the hats' → groove(template=\"swing\", amount=0.55)\n" +
+			"- 'from now on write everything an octave lower' → defaults(octave=3)\n" +
+			"- 'softer from here on, and play me an Am arpeggio' → defaults(velocity=80); arpeggio(symbol=Am, note_duration=0.25)\n" +
+			"- 'house beat with the groove of track 3' → drum_pattern(style=\"house\", length=4); groove(from_track=2, grid=0.5)",
 		Grammar: llm.GetArrangerDSLGrammar(),
 		Syntax:  "lark",
 	}
@@ -152,10 +201,10 @@ func (a *ArrangerAgent) GenerateActions(
 		}
 	}
 
-	log.Printf("🔧 Using DSL mode (CFG grammar) - Arranger DSL")
+	logger.Printf(ctx, "🔧 Using DSL mode (CFG grammar) - Arranger DSL")
 
 	// Call provider
-	log.Printf("🚀 ARRANGER PROVIDER REQUEST: %s", a.provider.Name())
+	logger.Printf(ctx, "🚀 ARRANGER PROVIDER REQUEST: %s", a.provider.Name())
 
 	resp, err := a.provider.Generate(ctx, request)
 	if err != nil {
@@ -166,7 +215,7 @@ func (a *ArrangerAgent) GenerateActions(
 	}
 
 	// Parse actions from DSL response
-	actions, err := a.parseActionsFromResponse(resp)
+	actions, err := a.parseActionsFromResponse(ctx, resp)
 	if err != nil {
 		transaction.SetTag("success", "false")
 		transaction.SetTag("error_type", "parse_error")
@@ -201,13 +250,13 @@ func (a *ArrangerAgent) GenerateActions(
 		}
 	}
 
-	log.Printf("✅ ARRANGER REQUEST COMPLETE: actions=%d, duration=%v", len(actions), duration)
+	logger.Printf(ctx, "✅ ARRANGER REQUEST COMPLETE: actions=%d, duration=%v", len(actions), duration)
 
 	return result, nil
 }
 
 // buildInputMessages constructs the input array for the LLM
-func (a *ArrangerAgent) buildInputMessages(question string) []map[string]any {
+func (a *ArrangerAgent) buildInputMessages(question string, projectKey *KeyEstimate, defaults models.MusicalDefaults) []map[string]any {
 	messages := []map[string]any{}
 
 	// Add user question
@@ -217,12 +266,31 @@ func (a *ArrangerAgent) buildInputMessages(question string) []map[string]any {
 	}
 	messages = append(messages, userMessage)
 
+	// Keep new parts in the key of what's already in the project
+	if projectKey != nil {
+		messages = append(messages, map[string]any{
+			"role": "user",
+			"content": fmt.Sprintf("Project key, detected from the existing clips: %s (%s; confidence %.2f). "+
+				"Use chords diatonic to this key unless the request names another key or asks for chromatic chords.",
+				projectKey.Name, strings.Join(projectKey.Scale, " "), projectKey.Confidence),
+		})
+	}
+
+	// Relative requests ("an octave lower than before") start from the session's defaults
+	if summary := describeMusicalDefaults(defaults); summary != "" {
+		messages = append(messages, map[string]any{
+			"role": "user",
+			"content": fmt.Sprintf("Session defaults, already applied to parameters the call leaves out: %s. "+
+				"Only write those parameters to override them for this part.", summary),
+		})
+	}
+
 	return messages
 }
 
 // parseActionsFromResponse extracts actions from the LLM response
 // For CFG/DSL mode: RawOutput contains DSL code (e.g., arpeggio("Em", length=2))
-func (a *ArrangerAgent) parseActionsFromResponse(resp *llm.GenerationResponse) ([]map[string]any, error) {
+func (a *ArrangerAgent) parseActionsFromResponse(ctx context.Context, resp *llm.GenerationResponse) ([]map[string]any, error) {
 	// The provider should have stored the raw output (DSL) in RawOutput
 	if resp.RawOutput == "" {
 		return nil, fmt.Errorf("no raw output available in response")
@@ -233,6 +301,7 @@ func (a *ArrangerAgent) parseActionsFromResponse(resp *llm.GenerationResponse) (
 	if err != nil {
 		return nil, fmt.Errorf("failed to create DSL parser: %w", err)
 	}
+	parser.SetContext(ctx)
 
 	actions, err := parser.ParseDSL(resp.RawOutput)
 	if err != nil {
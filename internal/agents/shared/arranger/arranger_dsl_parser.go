@@ -4,10 +4,14 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
 
 	"github.com/Conceptual-Machines/grammar-school-go/gs"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
 )
 
 // ArrangerDSLParser parses Arranger DSL code with chord symbols.
@@ -16,7 +20,9 @@ type ArrangerDSLParser struct {
 	engine      *gs.Engine
 	arrangerDSL *ArrangerDSL
 	actions     []map[string]any
-	rawDSL      string // Store raw DSL for manual parsing (Grammar School has array issues)
+	rawDSL      string          // Store raw DSL for manual parsing (Grammar School has array issues)
+	ctx         context.Context // Request context, carries correlation IDs into log lines
+	defaults    models.MusicalDefaults
 }
 
 // ArrangerDSL implements the DSL methods for musical composition.
@@ -35,6 +41,9 @@ func NewArrangerDSLParser() (*ArrangerDSLParser, error) {
 
 	// Get Arranger DSL grammar
 	grammar := llm.GetArrangerDSLGrammar()
+	if err := llm.ValidateGrammar("Arranger DSL", grammar); err != nil {
+		return nil, err
+	}
 
 	// Use generic Lark parser from grammar-school
 	larkParser := gs.NewLarkParser()
@@ -50,6 +59,11 @@ func NewArrangerDSLParser() (*ArrangerDSLParser, error) {
 	return parser, nil
 }
 
+// SetContext sets the request context used for DSL execution and logging.
+func (p *ArrangerDSLParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
 // ParseDSL parses DSL code and returns arranger actions.
 func (p *ArrangerDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
 	if dslCode == "" {
@@ -63,7 +77,15 @@ func (p *ArrangerDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
 	p.actions = make([]map[string]any, 0)
 
 	// Execute DSL code using Grammar School Engine
-	ctx := context.Background()
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// The session's defaults fill in what the calls leave out; a defaults() call changes them
+	p.defaults = MusicalDefaultsFromContext(ctx)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to execute DSL: %w", err)
+	}
 	if err := p.engine.Execute(ctx, dslCode); err != nil {
 		return nil, fmt.Errorf("failed to execute DSL: %w", err)
 	}
@@ -77,7 +99,12 @@ func (p *ArrangerDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
 	// In that case, keep only the arpeggio (which is sequential notes)
 	p.actions = p.filterRedundantChords(p.actions)
 
-	log.Printf("✅ Arranger DSL Parser: Translated %d actions from DSL", len(p.actions))
+	// Random choices share one seed, which the response reports so they can be repeated
+	if seed, ok := assignSeed(p.actions); ok {
+		logger.Printf(p.ctx, "🎲 Arranger DSL Parser: seed %d", seed)
+	}
+
+	logger.Printf(p.ctx, "✅ Arranger DSL Parser: Translated %d actions from DSL", len(p.actions))
 	return p.actions, nil
 }
 
@@ -107,7 +134,7 @@ func (p *ArrangerDSLParser) filterRedundantChords(actions []map[string]any) []ma
 		if action["type"] == "chord" {
 			if chord, ok := action["chord"].(string); ok {
 				if arpeggioChords[chord] {
-					log.Printf("🔄 Filtering redundant chord action for %s (arpeggio exists)", chord)
+					logger.Printf(p.ctx, "🔄 Filtering redundant chord action for %s (arpeggio exists)", chord)
 					continue // Skip this chord - arpeggio takes precedence
 				}
 			}
@@ -118,6 +145,11 @@ func (p *ArrangerDSLParser) filterRedundantChords(actions []map[string]any) []ma
 	return filtered
 }
 
+// resolvedDefaults returns the values calls fall back on for what they leave out
+func (p *ArrangerDSLParser) resolvedDefaults() partDefaults {
+	return resolveMusicalDefaults(p.defaults)
+}
+
 // ========== Side-effect methods (ArrangerDSL) ==========
 
 // Arpeggio handles arpeggio() calls.
@@ -171,10 +203,11 @@ func (a *ArrangerDSL) Arpeggio(args gs.Args) error {
 		startBeat = startValue.Num
 	}
 
-	// Extract length (default: 4 beats = 1 bar)
+	// Extract length (default: 1 bar, or the session's clip length)
 	// Note: length should be explicit via "length" or "duration" param
 	// Don't treat note_duration as a length fallback
-	length := 4.0
+	defaults := p.resolvedDefaults()
+	length := defaults.clipBeats()
 	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
 		length = lengthValue.Num
 	} else if durationValue, ok := args["duration"]; ok && durationValue.Kind == gs.ValueNumber {
@@ -195,12 +228,12 @@ func (a *ArrangerDSL) Arpeggio(args gs.Args) error {
 	}
 
 	// Extract optional parameters
-	velocity := 100
+	velocity := defaults.velocity
 	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
 		velocity = int(velocityValue.Num)
 	}
 
-	octave := 4
+	octave := defaults.octave
 	if octaveValue, ok := args["octave"]; ok && octaveValue.Kind == gs.ValueNumber {
 		octave = int(octaveValue.Num)
 	}
@@ -245,6 +278,13 @@ func (a *ArrangerDSL) Arpeggio(args gs.Args) error {
 	if bassNote != "" {
 		action["bass"] = bassNote
 	}
+	if err := addVelocityCurve("arpeggio", args, action); err != nil {
+		return err
+	}
+	if err := addProbability("arpeggio", args, action); err != nil {
+		return err
+	}
+	addSeed(args, action)
 
 	p.actions = append(p.actions, action)
 	return nil
@@ -284,8 +324,9 @@ func (a *ArrangerDSL) Chord(args gs.Args) error {
 		startBeat = startValue.Num
 	}
 
-	// Extract length (default: 4 beats = 1 bar)
-	length := 4.0
+	// Extract length (default: 1 bar, or the session's clip length)
+	defaults := p.resolvedDefaults()
+	length := defaults.clipBeats()
 	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
 		length = lengthValue.Num
 	} else if durationValue, ok := args["duration"]; ok && durationValue.Kind == gs.ValueNumber {
@@ -313,7 +354,7 @@ func (a *ArrangerDSL) Chord(args gs.Args) error {
 	}
 
 	// Extract optional parameters
-	velocity := 100
+	velocity := defaults.velocity
 	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
 		velocity = int(velocityValue.Num)
 	}
@@ -328,6 +369,30 @@ func (a *ArrangerDSL) Chord(args gs.Args) error {
 		rhythm = rhythmValue.Str
 	}
 
+	voicing := ""
+	if voicingValue, ok := args["voicing"]; ok && voicingValue.Kind == gs.ValueString {
+		voicing = voicingValue.Str
+		if voicing != "close" && voicing != "spread" && voicing != "drop2" {
+			return fmt.Errorf("chord: voicing must be close, spread, or drop2, got %q", voicing)
+		}
+	}
+
+	// Extract strum (milliseconds between chord notes) and its direction
+	strumMs := 0.0
+	if strumValue, ok := args["strum_ms"]; ok && strumValue.Kind == gs.ValueNumber {
+		strumMs = strumValue.Num
+		if strumMs < 0 || strumMs > maxStrumMs {
+			return fmt.Errorf("chord: strum_ms must be from 0 to %g, got %g", maxStrumMs, strumMs)
+		}
+	}
+	direction := ""
+	if directionValue, ok := args["direction"]; ok && directionValue.Kind == gs.ValueString {
+		direction = directionValue.Str
+		if direction != "down" && direction != "up" {
+			return fmt.Errorf("chord: direction must be down or up, got %q", direction)
+		}
+	}
+
 	// Parse bass note from chord symbol (e.g., "Emin/G" -> bass note is "G")
 	bassNote := ""
 	if strings.Contains(chordSymbol, "/") {
@@ -347,6 +412,9 @@ func (a *ArrangerDSL) Chord(args gs.Args) error {
 		"repeat":   repeat,
 		"velocity": velocity,
 	}
+	if p.defaults.Octave != 0 {
+		action["octave"] = defaults.octave
+	}
 	if startBeat != 0.0 {
 		action["start"] = startBeat
 	}
@@ -356,6 +424,15 @@ func (a *ArrangerDSL) Chord(args gs.Args) error {
 	if inversion != 0 {
 		action["inversion"] = inversion
 	}
+	if voicing != "" && voicing != "close" {
+		action["voicing"] = voicing
+	}
+	if strumMs > 0 {
+		action["strum_ms"] = strumMs
+		if direction != "" {
+			action["direction"] = direction
+		}
+	}
 	if bassNote != "" {
 		action["bass"] = bassNote
 	}
@@ -366,66 +443,27 @@ func (a *ArrangerDSL) Chord(args gs.Args) error {
 
 // Progression handles progression() calls.
 // Example: progression(chords=["C", "Am", "F", "G"], length=4, repeat=2)
+// Example: progression(chords=[C:2, Am:3, F, G], octave=3) - per-chord octaves
+// Example: progression(chords=[C, Am, F, G], voicing=smooth, voices=4, low=C3, high=G4) - voice-led
 func (a *ArrangerDSL) Progression(args gs.Args) error {
 	p := a.parser
 
 	// DEBUG: Log all args to see what Grammar School passes
-	log.Printf("🎵 Progression called with args: %+v", args)
+	logger.Printf(a.parser.ctx, "🎵 Progression called with args: %+v", args)
 
 	// Grammar School has issues parsing arrays - extract chords from raw DSL instead
-	chords := []string{}
-
-	// Use regex to extract chords array from raw DSL
-	// Pattern: chords=[...] or chords = [...]
-	rawDSL := p.rawDSL
-	log.Printf("🎵 Raw DSL: %s", rawDSL)
-
-	// Find chords=[...] pattern
-	chordsStart := strings.Index(rawDSL, "chords=[")
-	if chordsStart == -1 {
-		chordsStart = strings.Index(rawDSL, "chords =[")
-	}
-	if chordsStart == -1 {
-		chordsStart = strings.Index(rawDSL, "chords= [")
-	}
-	if chordsStart == -1 {
-		chordsStart = strings.Index(rawDSL, "chords = [")
-	}
-
-	if chordsStart != -1 {
-		// Find the opening bracket
-		bracketStart := strings.Index(rawDSL[chordsStart:], "[")
-		if bracketStart != -1 {
-			bracketStart += chordsStart
-			// Find the closing bracket
-			bracketEnd := strings.Index(rawDSL[bracketStart:], "]")
-			if bracketEnd != -1 {
-				bracketEnd += bracketStart
-				// Extract the array content
-				arrayContent := rawDSL[bracketStart+1 : bracketEnd]
-				log.Printf("🎵 Extracted array content: %q", arrayContent)
-
-				// Split by comma and clean up
-				parts := strings.Split(arrayContent, ",")
-				for _, part := range parts {
-					part = strings.TrimSpace(part)
-					part = strings.Trim(part, "\"'")
-					if part != "" {
-						chords = append(chords, part)
-					}
-				}
-			}
-		}
-	}
+	logger.Printf(a.parser.ctx, "🎵 Raw DSL: %s", p.rawDSL)
+	chords := extractArrayParam(p.rawDSL, "chords")
 
-	log.Printf("🎵 Extracted chords: %v (len=%d)", chords, len(chords))
+	logger.Printf(a.parser.ctx, "🎵 Extracted chords: %v (len=%d)", chords, len(chords))
 
 	if len(chords) == 0 {
 		return fmt.Errorf("progression: missing chords array")
 	}
 
-	// Extract length (default: number of chords * 4 beats = 1 bar per chord)
-	length := float64(len(chords)) * 4.0
+	// Extract length (default: 1 bar per chord)
+	defaults := p.resolvedDefaults()
+	length := float64(len(chords)) * defaults.beatsPerBar
 	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
 		length = lengthValue.Num
 	} else if durationValue, ok := args["duration"]; ok && durationValue.Kind == gs.ValueNumber {
@@ -440,6 +478,29 @@ func (a *ArrangerDSL) Progression(args gs.Args) error {
 		repeat = int(repetitionsValue.Num)
 	}
 
+	octave := defaults.octave
+	hasOctave := p.defaults.Octave != 0
+	if octaveValue, ok := args["octave"]; ok && octaveValue.Kind == gs.ValueNumber {
+		octave = int(octaveValue.Num)
+		hasOctave = true
+	}
+
+	// Per-chord octaves: chords=[C:3, Am:4] - chords without one use the progression's octave
+	octaves := make([]int, len(chords))
+	hasChordOctaves := false
+	for i, entry := range chords {
+		chordSymbol, chordOctave, ok, err := splitChordOctave(entry)
+		if err != nil {
+			return fmt.Errorf("progression: %w", err)
+		}
+		chords[i] = chordSymbol
+		octaves[i] = octave
+		if ok {
+			octaves[i] = chordOctave
+			hasChordOctaves = true
+		}
+	}
+
 	// Create action
 	action := map[string]any{
 		"type":   "progression",
@@ -447,11 +508,542 @@ func (a *ArrangerDSL) Progression(args gs.Args) error {
 		"length": length,
 		"repeat": repeat,
 	}
+	if hasOctave {
+		action["octave"] = octave
+	}
+	if p.defaults.Velocity != 0 {
+		action["velocity"] = defaults.velocity
+	}
+	if hasChordOctaves {
+		action["octaves"] = octaves
+	}
+	if err := addVoiceLeading(args, action); err != nil {
+		return err
+	}
+	if err := addVelocityCurve("progression", args, action); err != nil {
+		return err
+	}
+	if err := addProbability("progression", args, action); err != nil {
+		return err
+	}
+	addSeed(args, action)
+
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+// addVoiceLeading adds a progression's voicing to action: smooth voice leading with voices and
+// the register limits low and high (note names, stored as MIDI notes). Giving voices, low, or
+// high implies voicing=smooth; per-chord octaves don't combine with it.
+func addVoiceLeading(args gs.Args, action map[string]any) error {
+	smooth := false
+	if voicingValue, ok := args["voicing"]; ok && voicingValue.Kind == gs.ValueString {
+		switch voicingValue.Str {
+		case "smooth":
+			smooth = true
+		case "close":
+		default:
+			return fmt.Errorf("progression: voicing must be close or smooth, got %q", voicingValue.Str)
+		}
+	}
+
+	if voicesValue, ok := args["voices"]; ok && voicesValue.Kind == gs.ValueNumber {
+		voices := int(voicesValue.Num)
+		if voices < minLeadVoices || voices > maxLeadVoices {
+			return fmt.Errorf("progression: voices must be from %d to %d, got %d", minLeadVoices, maxLeadVoices, voices)
+		}
+		action["voices"] = voices
+		smooth = true
+	}
+	for _, key := range []string{"low", "high"} {
+		if noteValue, ok := args[key]; ok && noteValue.Kind == gs.ValueString {
+			note, err := music.NoteNameToMIDI(strings.Trim(noteValue.Str, "\""))
+			if err != nil {
+				return fmt.Errorf("progression: invalid %s note: %w", key, err)
+			}
+			action[key] = note
+			smooth = true
+		}
+	}
+	if !smooth {
+		return nil
+	}
+
+	if _, ok := action["octaves"]; ok {
+		return fmt.Errorf("progression: per-chord octaves don't combine with voicing=smooth; set the register with low and high")
+	}
+	low, hasLow := action["low"].(int)
+	high, hasHigh := action["high"].(int)
+	if hasLow && hasHigh && high-low < 12 {
+		return fmt.Errorf("progression: high must be at least an octave above low for voice leading")
+	}
+	action["voicing"] = "smooth"
+	return nil
+}
+
+// WalkingBass handles walking_bass() calls.
+// Example: walking_bass(progression=[Dm7, G7, Cmaj7], length=12)
+// Generates a quarter-note walking line (one bar per chord by default) at conversion time.
+func (a *ArrangerDSL) WalkingBass(args gs.Args) error {
+	p := a.parser
+
+	// Grammar School has issues parsing arrays - extract chords from raw DSL instead
+	chords := extractArrayParam(p.rawDSL, "progression")
+	if len(chords) == 0 {
+		chords = extractArrayParam(p.rawDSL, "chords")
+	}
+	if len(chords) == 0 {
+		return fmt.Errorf("walking_bass: missing progression array")
+	}
+
+	// Extract length (default: 1 bar per chord)
+	defaults := p.resolvedDefaults()
+	length := float64(len(chords)) * defaults.beatsPerBar
+	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
+		length = lengthValue.Num
+	}
+	if length <= 0 {
+		return fmt.Errorf("walking_bass: length must be positive, got %g", length)
+	}
+
+	velocity := defaults.velocity
+	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
+		velocity = int(velocityValue.Num)
+	}
+
+	octave := defaults.bassOctave()
+	if octaveValue, ok := args["octave"]; ok && octaveValue.Kind == gs.ValueNumber {
+		octave = int(octaveValue.Num)
+	}
+
+	action := map[string]any{
+		"type":     "walking_bass",
+		"chords":   chords,
+		"length":   length,
+		"velocity": velocity,
+		"octave":   octave,
+	}
+	if startValue, ok := args["start"]; ok && startValue.Kind == gs.ValueNumber && startValue.Num != 0 {
+		action["start"] = startValue.Num
+	}
+
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+// Quantize handles quantize() calls: snaps the notes of the other calls (or, on its own, the
+// selected clips' notes) toward a grid in beats.
+// Example: quantize(grid=0.25, strength=0.8)
+func (a *ArrangerDSL) Quantize(args gs.Args) error {
+	p := a.parser
+
+	grid := defaultQuantizeGrid
+	if gridValue, ok := args["grid"]; ok && gridValue.Kind == gs.ValueNumber {
+		grid = gridValue.Num
+	}
+	if grid <= 0 {
+		return fmt.Errorf("quantize: grid must be greater than 0, got %g", grid)
+	}
+
+	strength := 1.0
+	if strengthValue, ok := args["strength"]; ok && strengthValue.Kind == gs.ValueNumber {
+		strength = strengthValue.Num
+	}
+	if strength <= 0 || strength > 1 {
+		return fmt.Errorf("quantize: strength must be greater than 0 and at most 1, got %g", strength)
+	}
+
+	p.actions = append(p.actions, map[string]any{
+		"type":     "quantize",
+		"grid":     grid,
+		"strength": strength,
+	})
+	return nil
+}
+
+// Humanize handles humanize() calls: randomly shifts note timing (milliseconds) and velocity.
+// Example: humanize(timing_ms=10, velocity=8)
+func (a *ArrangerDSL) Humanize(args gs.Args) error {
+	p := a.parser
+
+	timingMs := defaultHumanizeTimingMs
+	if timingValue, ok := args["timing_ms"]; ok && timingValue.Kind == gs.ValueNumber {
+		timingMs = timingValue.Num
+	}
+	if timingMs < 0 {
+		return fmt.Errorf("humanize: timing_ms must not be negative, got %g", timingMs)
+	}
+
+	velocity := defaultHumanizeVelocity
+	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
+		velocity = int(velocityValue.Num)
+	}
+	if velocity < 0 {
+		return fmt.Errorf("humanize: velocity must not be negative, got %d", velocity)
+	}
+
+	action := map[string]any{
+		"type":      "humanize",
+		"timing_ms": timingMs,
+		"velocity":  velocity,
+	}
+	addSeed(args, action)
+
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+// Groove handles groove() calls: moves and accents notes by a named groove template, or by the
+// groove of an existing track's notes (0-based from_track, measured on grid).
+// Example: groove(template="swing", amount=0.6), groove(from_track=2, grid=0.5)
+func (a *ArrangerDSL) Groove(args gs.Args) error {
+	p := a.parser
+
+	amount := defaultGrooveAmount
+	if amountValue, ok := args["amount"]; ok && amountValue.Kind == gs.ValueNumber {
+		amount = amountValue.Num
+	}
+	if amount < 0 || amount > 1 {
+		return fmt.Errorf("groove: amount must be between 0 and 1, got %g", amount)
+	}
+	action := map[string]any{
+		"type":   "groove",
+		"amount": amount,
+	}
+
+	templateValue, hasTemplate := args["template"]
+	trackValue, hasTrack := args["from_track"]
+	switch {
+	case hasTemplate && hasTrack:
+		return fmt.Errorf("groove: use template or from_track, not both")
+	case hasTemplate && templateValue.Kind == gs.ValueString:
+		template := strings.ToLower(strings.Trim(templateValue.Str, "\""))
+		if _, ok := grooveTemplates[template]; !ok {
+			return fmt.Errorf("groove: unknown template %q (available: %s)", template, strings.Join(GrooveTemplateNames(), ", "))
+		}
+		action["template"] = template
+	case hasTrack && trackValue.Kind == gs.ValueNumber:
+		if trackValue.Num < 0 {
+			return fmt.Errorf("groove: from_track must not be negative, got %g", trackValue.Num)
+		}
+		action["from_track"] = int(trackValue.Num)
+		grid := defaultGrooveExtractGrid
+		if gridValue, ok := args["grid"]; ok && gridValue.Kind == gs.ValueNumber {
+			grid = gridValue.Num
+		}
+		if grid <= 0 {
+			return fmt.Errorf("groove: grid must be greater than 0, got %g", grid)
+		}
+		action["grid"] = grid
+	default:
+		return fmt.Errorf("groove: requires template or from_track")
+	}
+
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+// DrumPattern handles drum_pattern() calls: a kick/snare/hat groove in a named style.
+// Example: drum_pattern(style="house", length=8, swing=0.1)
+func (a *ArrangerDSL) DrumPattern(args gs.Args) error {
+	p := a.parser
+
+	style := ""
+	if styleValue, ok := args["style"]; ok && styleValue.Kind == gs.ValueString {
+		style = strings.ToLower(strings.Trim(styleValue.Str, "\""))
+	}
+	if _, ok := drumPatterns[style]; !ok {
+		return fmt.Errorf("drum_pattern: unknown style %q (available: %s)", style, strings.Join(DrumPatternStyles(), ", "))
+	}
+
+	// Extract length (default: 1 bar, or the session's clip length)
+	defaults := p.resolvedDefaults()
+	length := defaults.clipBeats()
+	if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
+		length = lengthValue.Num
+	}
+	if length <= 0 {
+		return fmt.Errorf("drum_pattern: length must be positive, got %g", length)
+	}
+
+	swing := 0.0
+	if swingValue, ok := args["swing"]; ok && swingValue.Kind == gs.ValueNumber {
+		swing = swingValue.Num
+	}
+	if swing < 0 || swing >= 1 {
+		return fmt.Errorf("drum_pattern: swing must be at least 0 and less than 1, got %g", swing)
+	}
+
+	velocity := defaults.velocity
+	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
+		velocity = int(velocityValue.Num)
+	}
+
+	action := map[string]any{
+		"type":     "drum_pattern",
+		"style":    style,
+		"length":   length,
+		"velocity": velocity,
+	}
+	if swing > 0 {
+		action["swing"] = swing
+	}
+	if startValue, ok := args["start"]; ok && startValue.Kind == gs.ValueNumber && startValue.Num != 0 {
+		action["start"] = startValue.Num
+	}
+	if err := addVelocityCurve("drum_pattern", args, action); err != nil {
+		return err
+	}
+	if err := addProbability("drum_pattern", args, action); err != nil {
+		return err
+	}
+	addSeed(args, action)
+
+	p.actions = append(p.actions, action)
+	return nil
+}
+
+// Section handles section() calls: one clip per track from a single request, all starting at
+// the same bar and spanning the section. Each tracks entry is a progression, walking_bass,
+// arpeggio, chord, or drum_pattern with its options.
+// Example: section(name="Chorus", bars=8, tracks=[{track=0, progression=[C, Am, F, G]}, {track=1, drum_pattern="house"}])
+func (a *ArrangerDSL) Section(args gs.Args) error {
+	p := a.parser
+
+	name := ""
+	if nameValue, ok := args["name"]; ok && nameValue.Kind == gs.ValueString {
+		name = strings.Trim(nameValue.Str, "\"")
+	}
+
+	bars := defaultSectionBars
+	if barsValue, ok := args["bars"]; ok && barsValue.Kind == gs.ValueNumber {
+		bars = int(barsValue.Num)
+	}
+	if bars <= 0 || bars > maxSectionBars {
+		return fmt.Errorf("section: bars must be from 1 to %d, got %d", maxSectionBars, bars)
+	}
+
+	bar := 1
+	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		bar = int(barValue.Num)
+	}
+	if bar < 1 {
+		return fmt.Errorf("section: bar must be 1 or later, got %d", bar)
+	}
+
+	// Grammar School splits the nested tracks list on every comma - read it from raw DSL instead
+	bodies, err := extractSectionParts(p.rawDSL)
+	if err != nil {
+		return fmt.Errorf("section: %w", err)
+	}
+	if len(bodies) == 0 {
+		return fmt.Errorf("section: tracks must have at least one entry")
+	}
+
+	defaults := p.resolvedDefaults()
+	lengthBeats := float64(bars) * defaults.beatsPerBar
+	parts := make([]map[string]any, 0, len(bodies))
+	for _, body := range bodies {
+		part, err := parseSectionPart(body, lengthBeats, defaults)
+		if err != nil {
+			return fmt.Errorf("section: %w", err)
+		}
+		parts = append(parts, part)
+	}
+
+	p.actions = append(p.actions, map[string]any{
+		"type":   "section",
+		"name":   name,
+		"bars":   bars,
+		"bar":    bar,
+		"length": lengthBeats,
+		"parts":  parts,
+	})
+	return nil
+}
+
+// Arrangement handles arrangement() calls: lays out a genre's song structure as sections from
+// a preset, for region markers plus an empty clip per section on each of tracks.
+// Example: arrangement(genre="edm", bar=1, tracks=[0, 1])
+func (a *ArrangerDSL) Arrangement(args gs.Args) error {
+	p := a.parser
+
+	genreValue, ok := args["genre"]
+	if !ok || genreValue.Kind != gs.ValueString {
+		return fmt.Errorf("arrangement: genre is required (available: %s)", strings.Join(ArrangementPresetGenres(), ", "))
+	}
+	genre := strings.Trim(genreValue.Str, "\"")
+
+	bar := 1
+	if barValue, ok := args["bar"]; ok && barValue.Kind == gs.ValueNumber {
+		bar = int(barValue.Num)
+	}
+	sectionBars := 0
+	if sectionBarsValue, ok := args["section_bars"]; ok && sectionBarsValue.Kind == gs.ValueNumber {
+		sectionBars = int(sectionBarsValue.Num)
+		if sectionBars <= 0 {
+			return fmt.Errorf("arrangement: section_bars must be from 1 to %d, got %d", maxSectionBars, sectionBars)
+		}
+	}
+
+	sections, err := ArrangementPreset(genre, bar, sectionBars)
+	if err != nil {
+		return fmt.Errorf("arrangement: %w", err)
+	}
+
+	// Grammar School does not pass arrays through Args - read tracks from raw DSL
+	tracks := []int{}
+	for _, entry := range extractArrayParam(p.rawDSL, "tracks") {
+		track, err := strconv.Atoi(entry)
+		if err != nil || track < 0 {
+			return fmt.Errorf("arrangement: tracks must be track indices, got %q", entry)
+		}
+		tracks = append(tracks, track)
+	}
+
+	p.actions = append(p.actions, map[string]any{
+		"type":     "arrangement",
+		"genre":    genre,
+		"bar":      bar,
+		"sections": sections,
+		"tracks":   tracks,
+	})
+	logger.Printf(p.ctx, "🎼 Arrangement %q: %d sections from bar %d, clips on %d tracks", genre, len(sections), bar, len(tracks))
+	return nil
+}
+
+// Defaults handles defaults() calls: changes the musical defaults for the calls after it and,
+// once the response reports them, for the session's later requests.
+// Example: defaults(octave=3, velocity=90, bars=2, time_signature="3/4")
+func (a *ArrangerDSL) Defaults(args gs.Args) error {
+	p := a.parser
+
+	var defaults models.MusicalDefaults
+	if octaveValue, ok := args["octave"]; ok && octaveValue.Kind == gs.ValueNumber {
+		defaults.Octave = int(octaveValue.Num)
+	}
+	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
+		defaults.Velocity = int(velocityValue.Num)
+	}
+	if barsValue, ok := args["bars"]; ok && barsValue.Kind == gs.ValueNumber {
+		defaults.ClipBars = int(barsValue.Num)
+	}
+	if signatureValue, ok := args["time_signature"]; ok && signatureValue.Kind == gs.ValueString {
+		defaults.TimeSignature = strings.Trim(signatureValue.Str, "\"")
+	}
+	if defaults == (models.MusicalDefaults{}) {
+		return fmt.Errorf("defaults: set at least one of octave, velocity, bars, or time_signature")
+	}
+	if err := ValidateMusicalDefaults(defaults); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+	p.defaults = MergeMusicalDefaults(p.defaults, defaults)
+
+	action := map[string]any{"type": "set_defaults"}
+	if defaults.Octave != 0 {
+		action["octave"] = defaults.Octave
+	}
+	if defaults.Velocity != 0 {
+		action["velocity"] = defaults.Velocity
+	}
+	if defaults.ClipBars != 0 {
+		action["clip_bars"] = defaults.ClipBars
+	}
+	if defaults.TimeSignature != "" {
+		action["time_signature"] = defaults.TimeSignature
+	}
 
 	p.actions = append(p.actions, action)
+	logger.Printf(p.ctx, "🎚️ Defaults: %s", describeMusicalDefaults(defaults))
+	return nil
+}
+
+// addVelocityCurve copies velocity_curve (and velocity_range for random_range) from args to action
+func addVelocityCurve(call string, args gs.Args, action map[string]any) error {
+	curveValue, ok := args["velocity_curve"]
+	if !ok || curveValue.Kind != gs.ValueString {
+		return nil
+	}
+	curve := strings.ToLower(curveValue.Str)
+	if !isVelocityCurve(curve) {
+		return fmt.Errorf("%s: unknown velocity_curve %q (available: %s)", call, curveValue.Str, strings.Join(velocityCurves, ", "))
+	}
+	action["velocity_curve"] = curve
+
+	if rangeValue, ok := args["velocity_range"]; ok && rangeValue.Kind == gs.ValueNumber && curve == "random_range" {
+		if rangeValue.Num < 0 {
+			return fmt.Errorf("%s: velocity_range must not be negative, got %g", call, rangeValue.Num)
+		}
+		action["velocity_range"] = int(rangeValue.Num)
+	}
+	return nil
+}
+
+// addProbability adds a call's probability, the chance (0-1] that each note plays, to action
+func addProbability(call string, args gs.Args, action map[string]any) error {
+	probabilityValue, ok := args["probability"]
+	if !ok || probabilityValue.Kind != gs.ValueNumber {
+		return nil
+	}
+	probability := probabilityValue.Num
+	if probability <= 0 || probability > 1 {
+		return fmt.Errorf("%s: probability must be greater than 0 and at most 1, got %g", call, probability)
+	}
+	if probability < 1 {
+		action["probability"] = probability
+	}
 	return nil
 }
 
+// addSeed adds a call's seed to action when its result is random (see usesRandomness). Random
+// calls without one get the request's seed when the DSL is parsed; see assignSeed.
+func addSeed(args gs.Args, action map[string]any) {
+	if seedValue, ok := args["seed"]; ok && seedValue.Kind == gs.ValueNumber && usesRandomness(action) {
+		action["seed"] = int(seedValue.Num)
+	}
+}
+
+// extractArrayParam extracts a bracketed list parameter (e.g. chords=[C, Am, F]) from raw DSL.
+// Grammar School does not pass array values through Args, so they are read from the source text.
+func extractArrayParam(rawDSL, name string) []string {
+	values := []string{}
+
+	// Find name=[...] allowing spaces around the equals sign
+	paramStart := -1
+	for _, pattern := range []string{name + "=[", name + " =[", name + "= [", name + " = ["} {
+		if paramStart = strings.Index(rawDSL, pattern); paramStart != -1 {
+			break
+		}
+	}
+	if paramStart == -1 {
+		return values
+	}
+
+	bracketStart := strings.Index(rawDSL[paramStart:], "[")
+	if bracketStart == -1 {
+		return values
+	}
+	bracketStart += paramStart
+	bracketEnd := strings.Index(rawDSL[bracketStart:], "]")
+	if bracketEnd == -1 {
+		return values
+	}
+	bracketEnd += bracketStart
+
+	// Extract the array content, split by comma and clean up
+	arrayContent := rawDSL[bracketStart+1 : bracketEnd]
+	log.Printf("🎵 Extracted %s array content: %q", name, arrayContent)
+	for _, part := range strings.Split(arrayContent, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.Trim(part, "\"'")
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+
+	return values
+}
+
 // Composition handles composition() calls with chaining.
 // Example: composition().add_arpeggio("Em", length=2).add_chord("C", length=1)
 func (a *ArrangerDSL) Composition(args gs.Args) error {
@@ -500,8 +1092,9 @@ func (a *ArrangerDSL) Note(args gs.Args) error {
 		return fmt.Errorf("note: missing pitch")
 	}
 
-	// Extract duration (default: 4 beats = 1 bar)
-	duration := 4.0
+	// Extract duration (default: 1 bar, or the session's clip length)
+	defaults := p.resolvedDefaults()
+	duration := defaults.clipBeats()
 	if durationValue, ok := args["duration"]; ok && durationValue.Kind == gs.ValueNumber {
 		duration = durationValue.Num
 	} else if lengthValue, ok := args["length"]; ok && lengthValue.Kind == gs.ValueNumber {
@@ -514,8 +1107,8 @@ func (a *ArrangerDSL) Note(args gs.Args) error {
 		startBeat = startValue.Num
 	}
 
-	// Extract velocity (default: 100)
-	velocity := 100
+	// Extract velocity (default: 100, or the session's velocity)
+	velocity := defaults.velocity
 	if velocityValue, ok := args["velocity"]; ok && velocityValue.Kind == gs.ValueNumber {
 		velocity = int(velocityValue.Num)
 	}
@@ -532,7 +1125,7 @@ func (a *ArrangerDSL) Note(args gs.Args) error {
 	}
 
 	p.actions = append(p.actions, action)
-	log.Printf("🎵 Note: pitch=%s, duration=%.1f, velocity=%d", pitch, duration, velocity)
+	logger.Printf(a.parser.ctx, "🎵 Note: pitch=%s, duration=%.1f, velocity=%d", pitch, duration, velocity)
 	return nil
 }
 
@@ -557,7 +1150,7 @@ func (a *ArrangerDSL) Choice(args gs.Args) error {
 	// The content will be parsed as separate statements, so we just mark this as a choice
 	if description != "" {
 		// Store description for later use (could be used in choice metadata)
-		log.Printf("📝 Choice description: %s", description)
+		logger.Printf(a.parser.ctx, "📝 Choice description: %s", description)
 	}
 
 	// Content items will be parsed as separate statements
@@ -1,7 +1,11 @@
 package services
 
 import (
+	"context"
+	"reflect"
 	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
 )
 
 func TestArrangerDSLParser_Arpeggio(t *testing.T) {
@@ -82,6 +86,109 @@ func TestArrangerDSLParser_Arpeggio(t *testing.T) {
 	}
 }
 
+func TestArrangerDSLParser_ArpeggioProbability(t *testing.T) {
+	parser, err := NewArrangerDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	actions, err := parser.ParseDSL(`arpeggio(symbol=Em, note_duration=0.25, probability=0.7, seed=42)`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("Expected 1 action, got %d", len(actions))
+	}
+
+	if probability, ok := actions[0]["probability"].(float64); !ok || probability != 0.7 {
+		t.Errorf("Expected probability 0.7, got %v", actions[0]["probability"])
+	}
+	if seed, ok := actions[0]["seed"].(int); !ok || seed != 42 {
+		t.Errorf("Expected seed 42, got %v", actions[0]["seed"])
+	}
+
+	parser, _ = NewArrangerDSLParser()
+	if _, err := parser.ParseDSL(`arpeggio(symbol=Em, probability=1.5)`); err == nil {
+		t.Error("Expected error for probability above 1")
+	}
+}
+
+func TestArrangerDSLParser_Seed(t *testing.T) {
+	parser, err := NewArrangerDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	// The humanize seed is the request's seed, so the drum pattern's probability follows it too
+	actions, err := parser.ParseDSL(`arpeggio(symbol=Em); drum_pattern(style="house", probability=0.8); humanize(timing_ms=10, seed=42)`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	if _, ok := actions[0]["seed"]; ok {
+		t.Errorf("Arpeggio without random choices got a seed: %v", actions[0]["seed"])
+	}
+	for _, action := range actions[1:] {
+		if seed, ok := action["seed"].(int); !ok || seed != 42 {
+			t.Errorf("Expected %s seed 42, got %v", action["type"], action["seed"])
+		}
+	}
+
+	// Without one, a seed is assigned and reported
+	parser, _ = NewArrangerDSLParser()
+	actions, err = parser.ParseDSL(`progression(chords=[C, F], probability=0.5); humanize(velocity=8)`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	seed, ok := ActionsSeed(actions)
+	if !ok {
+		t.Fatal("Expected an assigned seed")
+	}
+	if actions[1]["seed"] != seed {
+		t.Errorf("Expected humanize seed %d, got %v", seed, actions[1]["seed"])
+	}
+}
+
+func TestArrangerDSLParser_Defaults(t *testing.T) {
+	parser, err := NewArrangerDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	// The session's defaults fill in what calls leave out; a defaults() call changes them for the
+	// calls after it
+	parser.SetContext(WithMusicalDefaults(context.Background(), models.MusicalDefaults{Velocity: 90, TimeSignature: "3/4"}))
+	actions, err := parser.ParseDSL(`defaults(octave=3, bars=2); arpeggio(symbol=Em, note_duration=0.25)`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	if len(actions) != 2 {
+		t.Fatalf("Expected 2 actions, got %d", len(actions))
+	}
+	if got, ok := ActionsDefaults(actions); !ok || got != (models.MusicalDefaults{Octave: 3, ClipBars: 2}) {
+		t.Errorf("ActionsDefaults() = %+v, %v, want octave 3 and 2 bars", got, ok)
+	}
+	arpeggio := actions[1]
+	if arpeggio["octave"] != 3 || arpeggio["velocity"] != 90 || arpeggio["length"] != 6.0 {
+		t.Errorf("Expected octave 3, velocity 90 and 2 bars of 3/4, got %v", arpeggio)
+	}
+
+	// The bass moves with the octave; explicit parameters still win
+	parser, _ = NewArrangerDSLParser()
+	parser.SetContext(WithMusicalDefaults(context.Background(), models.MusicalDefaults{Octave: 3}))
+	actions, err = parser.ParseDSL(`walking_bass(progression=[Dm7, G7], velocity=70)`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	if actions[0]["octave"] != defaultWalkingBassOctave-1 || actions[0]["velocity"] != 70 {
+		t.Errorf("Expected octave %d and velocity 70, got %v", defaultWalkingBassOctave-1, actions[0])
+	}
+
+	parser, _ = NewArrangerDSLParser()
+	if _, err := parser.ParseDSL(`defaults(velocity=200)`); err == nil {
+		t.Error("Expected an error for an out-of-range velocity")
+	}
+}
+
 func TestArrangerDSLParser_Chord(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -219,6 +326,190 @@ func TestArrangerDSLParser_Progression(t *testing.T) {
 	}
 }
 
+func TestArrangerDSLParser_ProgressionOctaves(t *testing.T) {
+	tests := []struct {
+		name           string
+		dsl            string
+		expectedChords []string
+		expectedOctave any
+		expectedOcts   any
+		expectError    bool
+	}{
+		{
+			name:           "per-chord octaves with default",
+			dsl:            `progression(chords=[C:3, Am:4, F, G], octave=5)`,
+			expectedChords: []string{"C", "Am", "F", "G"},
+			expectedOctave: 5,
+			expectedOcts:   []int{3, 4, 5, 5},
+		},
+		{
+			name:           "unspecified octaves default to 4",
+			dsl:            `progression(chords=[C:2, G], length=8)`,
+			expectedChords: []string{"C", "G"},
+			expectedOctave: nil,
+			expectedOcts:   []int{2, 4},
+		},
+		{
+			name:           "no per-chord octaves",
+			dsl:            `progression(chords=[C, G], length=8)`,
+			expectedChords: []string{"C", "G"},
+			expectedOctave: nil,
+			expectedOcts:   nil,
+		},
+		{
+			name:        "invalid octave",
+			dsl:         `progression(chords=[C:low, G], length=8)`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+
+			action := actions[0]
+			if !reflect.DeepEqual(action["chords"], tt.expectedChords) {
+				t.Errorf("Expected chords %v, got %v", tt.expectedChords, action["chords"])
+			}
+			if !reflect.DeepEqual(action["octave"], tt.expectedOctave) {
+				t.Errorf("Expected octave %v, got %v", tt.expectedOctave, action["octave"])
+			}
+			if !reflect.DeepEqual(action["octaves"], tt.expectedOcts) {
+				t.Errorf("Expected octaves %v, got %v", tt.expectedOcts, action["octaves"])
+			}
+		})
+	}
+}
+
+func TestArrangerDSLParser_ProgressionVoiceLeading(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsl         string
+		want        map[string]any // Voice leading fields of the action
+		expectError bool
+	}{
+		{
+			name: "smooth with voices and register",
+			dsl:  `progression(chords=[C, Am, F, G], voicing=smooth, voices=4, low=C3, high=G4)`,
+			want: map[string]any{"voicing": "smooth", "voices": 4, "low": 48, "high": 67},
+		},
+		{
+			name: "voices imply smooth",
+			dsl:  `progression(chords=[Dm7, G7, Cmaj7], voices=3)`,
+			want: map[string]any{"voicing": "smooth", "voices": 3, "low": nil, "high": nil},
+		},
+		{
+			name: "close is the default",
+			dsl:  `progression(chords=[C, G], voicing=close)`,
+			want: map[string]any{"voicing": nil, "voices": nil, "low": nil, "high": nil},
+		},
+		{
+			name:        "per-chord octaves",
+			dsl:         `progression(chords=[C:3, G], voicing=smooth)`,
+			expectError: true,
+		},
+		{
+			name:        "too many voices",
+			dsl:         `progression(chords=[C, G], voices=8)`,
+			expectError: true,
+		},
+		{
+			name:        "register under an octave",
+			dsl:         `progression(chords=[C, G], low=C3, high=G3)`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+			for key, want := range tt.want {
+				if got := actions[0][key]; !reflect.DeepEqual(got, want) {
+					t.Errorf("%s = %v, want %v", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestArrangerDSLParser_WalkingBass(t *testing.T) {
+	tests := []struct {
+		name           string
+		dsl            string
+		expectedChords []string
+		expectedLength float64
+	}{
+		{
+			name:           "explicit length",
+			dsl:            `walking_bass(progression=[Dm7, G7, Cmaj7], length=12)`,
+			expectedChords: []string{"Dm7", "G7", "Cmaj7"},
+			expectedLength: 12.0,
+		},
+		{
+			name:           "defaults to one bar per chord",
+			dsl:            `walking_bass(progression=[Am7, D7])`,
+			expectedChords: []string{"Am7", "D7"},
+			expectedLength: 8.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+			if len(actions) != 1 {
+				t.Fatalf("Expected 1 action, got %d", len(actions))
+			}
+
+			action := actions[0]
+			if action["type"] != "walking_bass" {
+				t.Errorf("Expected type 'walking_bass', got %v", action["type"])
+			}
+			if !reflect.DeepEqual(action["chords"], tt.expectedChords) {
+				t.Errorf("Expected chords %v, got %v", tt.expectedChords, action["chords"])
+			}
+			if length, ok := action["length"].(float64); !ok || length != tt.expectedLength {
+				t.Errorf("Expected length %f, got %v", tt.expectedLength, action["length"])
+			}
+		})
+	}
+}
+
 func TestArrangerDSLParser_NoteDuration(t *testing.T) {
 	parser, err := NewArrangerDSLParser()
 	if err != nil {
@@ -335,3 +626,317 @@ func TestArrangerDSLParser_Note(t *testing.T) {
 		})
 	}
 }
+
+func TestArrangerDSLParser_NoteTransforms(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsl         string
+		want        []map[string]any
+		expectError bool
+	}{
+		{
+			name: "quantize on its own",
+			dsl:  `quantize(grid=0.25, strength=0.8)`,
+			want: []map[string]any{
+				{"type": "quantize", "grid": 0.25, "strength": 0.8},
+			},
+		},
+		{
+			name: "quantize defaults to 16ths at full strength",
+			dsl:  `quantize()`,
+			want: []map[string]any{
+				{"type": "quantize", "grid": 0.25, "strength": 1.0},
+			},
+		},
+		{
+			name: "humanize after an arpeggio",
+			dsl:  `arpeggio(symbol=Em, note_duration=0.25); humanize(timing_ms=15, velocity=6, seed=3)`,
+			want: []map[string]any{
+				{"type": "arpeggio", "chord": "Em", "length": 4.0, "repeat": 0, "velocity": 100, "octave": 4, "direction": "up", "note_duration": 0.25},
+				{"type": "humanize", "timing_ms": 15.0, "velocity": 6, "seed": 3},
+			},
+		},
+		{
+			name: "groove template on its own",
+			dsl:  `groove(template="swing", amount=0.55)`,
+			want: []map[string]any{
+				{"type": "groove", "amount": 0.55, "template": "swing"},
+			},
+		},
+		{
+			name: "groove from a track after a drum pattern",
+			dsl:  `drum_pattern(style="house", length=4); groove(from_track=2, grid=0.5)`,
+			want: []map[string]any{
+				{"type": "drum_pattern", "style": "house", "length": 4.0, "velocity": 100},
+				{"type": "groove", "amount": 1.0, "from_track": 2, "grid": 0.5},
+			},
+		},
+		{
+			name:        "groove template and track",
+			dsl:         `groove(template="swing", from_track=1)`,
+			expectError: true,
+		},
+		{
+			name:        "groove amount above 1",
+			dsl:         `groove(template="shuffle", amount=55)`,
+			expectError: true,
+		},
+		{
+			name:        "strength above 1",
+			dsl:         `quantize(grid=0.25, strength=1.5)`,
+			expectError: true,
+		},
+		{
+			name:        "negative humanize timing",
+			dsl:         `humanize(timing_ms=-5)`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+			if !reflect.DeepEqual(actions, tt.want) {
+				t.Errorf("ParseDSL() = %v, want %v", actions, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrangerDSLParser_DrumPattern(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsl         string
+		want        map[string]any
+		expectError bool
+	}{
+		{
+			name: "house with swing",
+			dsl:  `drum_pattern(style="house", length=8, swing=0.1)`,
+			want: map[string]any{"type": "drum_pattern", "style": "house", "length": 8.0, "velocity": 100, "swing": 0.1},
+		},
+		{
+			name: "defaults to one straight bar",
+			dsl:  `drum_pattern(style="rock")`,
+			want: map[string]any{"type": "drum_pattern", "style": "rock", "length": 4.0, "velocity": 100},
+		},
+		{
+			name:        "unknown style",
+			dsl:         `drum_pattern(style="polka")`,
+			expectError: true,
+		},
+		{
+			name:        "swing of 1",
+			dsl:         `drum_pattern(style="trap", swing=1)`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+			if len(actions) != 1 || !reflect.DeepEqual(actions[0], tt.want) {
+				t.Errorf("ParseDSL() = %v, want [%v]", actions, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrangerDSLParser_ChordStrumAndVoicing(t *testing.T) {
+	parser, err := NewArrangerDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+
+	actions, err := parser.ParseDSL(`chord(symbol=G, length=4, strum_ms=25, direction=up, voicing=drop2, inversion=1)`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+	want := map[string]any{
+		"type": "chord", "chord": "G", "length": 4.0, "repeat": 1, "velocity": 100,
+		"inversion": 1, "voicing": "drop2", "strum_ms": 25.0, "direction": "up",
+	}
+	if len(actions) != 1 || !reflect.DeepEqual(actions[0], want) {
+		t.Errorf("ParseDSL() = %v, want [%v]", actions, want)
+	}
+
+	for _, dsl := range []string{
+		`chord(symbol=G, strum_ms=500)`,
+		`chord(symbol=G, voicing=quartal)`,
+		`chord(symbol=G, strum_ms=20, direction=sideways)`,
+	} {
+		parser, _ = NewArrangerDSLParser()
+		if _, err := parser.ParseDSL(dsl); err == nil {
+			t.Errorf("Expected error for %s", dsl)
+		}
+	}
+}
+
+func TestArrangerDSLParser_VelocityCurve(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsl         string
+		want        map[string]any // Expected velocity_curve, velocity_range, and seed
+		expectError bool
+	}{
+		{
+			name: "arpeggio crescendo",
+			dsl:  `arpeggio(symbol=Am, note_duration=0.25, velocity_curve=crescendo)`,
+			want: map[string]any{"velocity_curve": "crescendo"},
+		},
+		{
+			name: "progression random range with seed",
+			dsl:  `progression(chords=[C, Am, F, G], velocity_curve=random_range, velocity_range=10, seed=4)`,
+			want: map[string]any{"velocity_curve": "random_range", "velocity_range": 10, "seed": 4},
+		},
+		{
+			name: "drum pattern accents",
+			dsl:  `drum_pattern(style="techno", velocity_curve=accent_on_beat)`,
+			want: map[string]any{"velocity_curve": "accent_on_beat"},
+		},
+		{
+			name:        "unknown curve",
+			dsl:         `arpeggio(symbol=Am, velocity_curve=swell)`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+			got := map[string]any{}
+			for _, key := range []string{"velocity_curve", "velocity_range", "seed"} {
+				if value, ok := actions[0][key]; ok {
+					got[key] = value
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dynamics = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArrangerDSLParser_Section(t *testing.T) {
+	tests := []struct {
+		name        string
+		dsl         string
+		want        map[string]any
+		expectError bool
+	}{
+		{
+			name: "progression and drums",
+			dsl:  `section(name="Chorus", bars=8, tracks=[{track=0, progression=[C, Am:3, F, G]}, {track=1, drum_pattern="house", swing=0.1}])`,
+			want: map[string]any{
+				"type": "section", "name": "Chorus", "bars": 8, "bar": 1, "length": 32.0,
+				"parts": []map[string]any{
+					{"track": 0, "action": map[string]any{
+						"type": "progression", "chords": []string{"C", "Am", "F", "G"}, "octaves": []int{4, 3, 4, 4},
+						"length": 32.0, "repeat": 1,
+					}},
+					{"track": 1, "action": map[string]any{
+						"type": "drum_pattern", "style": "house", "length": 32.0, "velocity": 100, "swing": 0.1,
+					}},
+				},
+			},
+		},
+		{
+			name: "arpeggio from bar 9",
+			dsl:  `section(name="Verse", bars=4, bar=9, tracks=[{track=2, arpeggio=Em, note_duration=0.5, octave=5}])`,
+			want: map[string]any{
+				"type": "section", "name": "Verse", "bars": 4, "bar": 9, "length": 16.0,
+				"parts": []map[string]any{
+					{"track": 2, "action": map[string]any{
+						"type": "arpeggio", "chord": "Em", "length": 16.0, "repeat": 0, "velocity": 100,
+						"octave": 5, "direction": "up", "note_duration": 0.5,
+					}},
+				},
+			},
+		},
+		{
+			name:        "part without content",
+			dsl:         `section(name="Intro", bars=4, tracks=[{track=0, octave=3}])`,
+			expectError: true,
+		},
+		{
+			name:        "part with two contents",
+			dsl:         `section(name="Intro", bars=4, tracks=[{track=0, chord=C, arpeggio=C}])`,
+			expectError: true,
+		},
+		{
+			name:        "part without track",
+			dsl:         `section(name="Intro", bars=4, tracks=[{drum_pattern="rock"}])`,
+			expectError: true,
+		},
+		{
+			name:        "zero bars",
+			dsl:         `section(name="Intro", bars=0, tracks=[{track=0, drum_pattern="rock"}])`,
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser, err := NewArrangerDSLParser()
+			if err != nil {
+				t.Fatalf("Failed to create parser: %v", err)
+			}
+
+			actions, err := parser.ParseDSL(tt.dsl)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDSL failed: %v", err)
+			}
+			if len(actions) != 1 || !reflect.DeepEqual(actions[0], tt.want) {
+				t.Errorf("ParseDSL() = %v, want [%v]", actions, tt.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,10 @@ package services
 import (
 	"context"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/getsentry/sentry-go"
 )
 
@@ -89,7 +89,7 @@ func (s *GenerationService) GenerateStream(
 	}
 
 	// Use provider non-streaming
-	log.Printf("🚀 PROVIDER REQUEST: %s model=%s, mcp_enabled=%t",
+	logger.Printf(ctx, "🚀 PROVIDER REQUEST: %s model=%s, mcp_enabled=%t",
 		s.provider.Name(), model, s.mcpURL != "")
 
 	resp, err := s.provider.Generate(ctx, request)
@@ -117,7 +117,7 @@ func (s *GenerationService) GenerateStream(
 	s.metrics.RecordGenerationDuration(ctx, duration, true)
 	s.metrics.RecordMCPUsage(result.MCPUsed, result.MCPCalls)
 
-	log.Printf("⏱️  STREAMING GENERATION (fallback) COMPLETED in %v", duration)
+	logger.Printf(ctx, "⏱️  STREAMING GENERATION (fallback) COMPLETED in %v", duration)
 
 	return result, nil
 }
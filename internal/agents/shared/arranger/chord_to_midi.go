@@ -3,9 +3,14 @@ package services
 import (
 	"fmt"
 	"log"
+	"math/rand"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
 )
 
 // RhythmTemplate defines timing and accent patterns for musical elements
@@ -28,6 +33,12 @@ const (
 	articulationOverlap = 1.1
 )
 
+// Octave range for chord voicing (noteToMIDI: octave * 12 + pitch class)
+const (
+	minChordOctave = 0
+	maxChordOctave = 9
+)
+
 // Predefined rhythm templates (matching aideas-api)
 var rhythmTemplates = map[string]RhythmTemplate{
 	// Basic subdivisions
@@ -113,6 +124,12 @@ var rhythmTemplates = map[string]RhythmTemplate{
 		Accents:      []float64{0.9, 0.85, 0.9, 0.85},
 		Articulation: articulationMidHigh,
 	},
+	"backbeat": {
+		Name:         "backbeat",
+		Offsets:      []float64{1, 3}, // Beats 2 and 4
+		Accents:      []float64{1.0, 1.0},
+		Articulation: articulationMidHigh,
+	},
 	"syncopated": {
 		Name:         "syncopated",
 		Offsets:      []float64{0, 0.5, 1.5, 2, 3, 3.5},
@@ -166,39 +183,21 @@ func GetRhythmTemplate(name string) (RhythmTemplate, bool) {
 }
 
 // ChordToMIDI converts chord symbols to MIDI note numbers
-// Supports: C, Em, Am7, Cmaj7, Emin/G (inversions), etc.
+// Supports triads, 6ths, 7ths, extended and altered jazz chords (C13b9, G7#5, Fsus2, Dm7b5),
+// and slash chords (Bb/D, Emin/G); see music.ParseChord
 // Returns slice of MIDI note numbers (0-127) for the chord
 func ChordToMIDI(chordSymbol string, octave int) ([]int, error) {
-	// Parse bass note if present (e.g., "Emin/G" -> chord="Emin", bass="G")
-	baseChord := chordSymbol
-	bassNote := ""
-	if strings.Contains(chordSymbol, "/") {
-		parts := strings.Split(chordSymbol, "/")
-		if len(parts) == 2 {
-			baseChord = strings.TrimSpace(parts[0])
-			bassNote = strings.TrimSpace(parts[1])
-		}
-	}
-
-	// Parse root note
-	root, err := parseRootNote(baseChord)
+	spec, err := music.ParseChord(chordSymbol)
 	if err != nil {
-		return nil, fmt.Errorf("invalid chord root: %w", err)
+		return nil, fmt.Errorf("invalid chord: %w", err)
 	}
 
-	// Calculate root MIDI note (C4 = 60)
-	rootMIDI := noteToMIDI(root, octave)
-
-	// Determine chord quality and extensions
-	quality := parseChordQuality(baseChord)
-	extensions := parseExtensions(baseChord)
-
-	// Build chord intervals (semitones from root)
-	intervals := buildChordIntervals(quality, extensions)
+	// Calculate root MIDI note (C4 = 48)
+	rootMIDI := noteToMIDI(spec.Root, octave)
 
 	// Convert intervals to MIDI notes
-	notes := make([]int, 0, len(intervals)+1)
-	for _, interval := range intervals {
+	notes := make([]int, 0, len(spec.Intervals)+1)
+	for _, interval := range spec.Intervals {
 		midiNote := rootMIDI + interval
 		if midiNote < 0 || midiNote > 127 {
 			continue // Skip out-of-range notes
@@ -206,16 +205,13 @@ func ChordToMIDI(chordSymbol string, octave int) ([]int, error) {
 		notes = append(notes, midiNote)
 	}
 
-	// Add bass note if specified (inversion)
-	if bassNote != "" {
-		bassRoot, err := parseRootNote(bassNote)
-		if err == nil {
-			// Bass note typically one octave lower
-			bassMIDI := noteToMIDI(bassRoot, octave-1)
-			if bassMIDI >= 0 && bassMIDI <= 127 {
-				// Prepend bass note
-				notes = append([]int{bassMIDI}, notes...)
-			}
+	// Add bass note if specified (slash chord)
+	if spec.Bass != "" {
+		// Bass note typically one octave lower
+		bassMIDI := noteToMIDI(spec.Bass, octave-1)
+		if bassMIDI >= 0 && bassMIDI <= 127 {
+			// Prepend bass note
+			notes = append([]int{bassMIDI}, notes...)
 		}
 	}
 
@@ -227,25 +223,83 @@ func ChordToMIDI(chordSymbol string, octave int) ([]int, error) {
 }
 
 // ConvertArrangerActionToNoteEvents converts an arranger action to NoteEvent array
-// Handles: arpeggios, chords, progressions, single notes
+// Handles: arpeggios, chords, progressions, walking basslines, drum patterns, single notes
+// An optional velocity_curve shapes the dynamics; see applyVelocityCurve
+// An optional probability (0-1] randomly drops notes; seed makes the result reproducible
+// Transform actions (quantize, humanize, groove) produce no notes; see ApplyNoteTransforms
+// Sections produce one clip per track instead; see ConvertSectionToClips
+// set_defaults actions only change the defaults of the calls after them
 func ConvertArrangerActionToNoteEvents(action map[string]any, startBeat float64) ([]models.NoteEvent, error) {
 	actionType, ok := action["type"].(string)
 	if !ok {
 		return nil, fmt.Errorf("action missing type field")
 	}
 
+	var noteEvents []models.NoteEvent
+	var err error
 	switch actionType {
 	case "arpeggio":
-		return convertArpeggioToNoteEvents(action, startBeat)
+		noteEvents, err = convertArpeggioToNoteEvents(action, startBeat)
 	case "chord":
-		return convertChordToNoteEvents(action, startBeat)
+		noteEvents, err = convertChordToNoteEvents(action, startBeat)
 	case "progression":
-		return convertProgressionToNoteEvents(action, startBeat)
+		noteEvents, err = convertProgressionToNoteEvents(action, startBeat)
+	case "walking_bass":
+		noteEvents, err = convertWalkingBassToNoteEvents(action, startBeat)
+	case "drum_pattern":
+		noteEvents, err = convertDrumPatternToNoteEvents(action, startBeat)
 	case "note":
-		return convertSingleNoteToNoteEvents(action, startBeat)
+		noteEvents, err = convertSingleNoteToNoteEvents(action, startBeat)
+	case "quantize", "humanize", "groove", "section", "arrangement", "set_defaults":
+		return nil, nil
 	default:
 		return nil, fmt.Errorf("unknown action type: %s", actionType)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	if noteEvents, err = applyVelocityCurve(noteEvents, action); err != nil {
+		return nil, err
+	}
+	return applyNoteProbability(noteEvents, action)
+}
+
+// applyNoteProbability keeps each note with the action's probability (default 1 = keep all).
+// With a seed the same notes are dropped on every call; without one the choice is random.
+// At least one note is always kept so a non-empty request never produces an empty clip.
+func applyNoteProbability(noteEvents []models.NoteEvent, action map[string]any) ([]models.NoteEvent, error) {
+	probability, hasProbability := getFloat(action, "probability", 1.0)
+	if !hasProbability {
+		return noteEvents, nil
+	}
+	if probability <= 0 || probability > 1 {
+		return nil, fmt.Errorf("probability must be greater than 0 and at most 1, got %g", probability)
+	}
+	if probability == 1 || len(noteEvents) == 0 {
+		return noteEvents, nil
+	}
+
+	seed, hasSeed := getInt(action, "seed", 0)
+	if !hasSeed {
+		seed = int(time.Now().UnixNano())
+	}
+	rng := rand.New(rand.NewSource(int64(seed)))
+
+	kept := make([]models.NoteEvent, 0, len(noteEvents))
+	for _, note := range noteEvents {
+		if rng.Float64() < probability {
+			kept = append(kept, note)
+		}
+	}
+
+	// Never drop everything - keep the first note so the pattern still has its downbeat
+	if len(kept) == 0 {
+		kept = append(kept, noteEvents[0])
+	}
+
+	log.Printf("🎲 Probability %.2f (seed=%d): kept %d of %d notes", probability, seed, len(kept), len(noteEvents))
+	return kept, nil
 }
 
 // convertSingleNoteToNoteEvents converts a single note action to a NoteEvent
@@ -265,7 +319,7 @@ func convertSingleNoteToNoteEvents(action map[string]any, startBeat float64) ([]
 	}
 
 	// Convert note name (e.g., "E1", "C4", "F#3") to MIDI note number
-	midiNote, err := NoteNameToMIDI(pitch)
+	midiNote, err := music.NoteNameToMIDI(pitch)
 	if err != nil {
 		return nil, fmt.Errorf("invalid pitch %q: %w", pitch, err)
 	}
@@ -283,67 +337,6 @@ func convertSingleNoteToNoteEvents(action map[string]any, startBeat float64) ([]
 	}, nil
 }
 
-// NoteNameToMIDI converts a note name like "E1", "C4", "F#3", "Bb2" to MIDI note number
-// Format: <note><accidental?><octave> where:
-//   - note: A-G (case insensitive)
-//   - accidental: # (sharp) or b (flat), optional
-//   - octave: -1 to 9 (C4 = 60 = middle C)
-func NoteNameToMIDI(noteName string) (int, error) {
-	if len(noteName) < 2 {
-		return 0, fmt.Errorf("note name too short: %s", noteName)
-	}
-
-	// Parse note letter (A-G)
-	noteChar := strings.ToUpper(string(noteName[0]))
-	if noteChar < "A" || noteChar > "G" {
-		return 0, fmt.Errorf("invalid note letter: %s", noteChar)
-	}
-
-	// Note semitone offsets from C
-	noteOffsets := map[string]int{
-		"C": 0, "D": 2, "E": 4, "F": 5, "G": 7, "A": 9, "B": 11,
-	}
-	semitone := noteOffsets[noteChar]
-
-	// Check for accidental (# or b)
-	idx := 1
-	if idx < len(noteName) {
-		if noteName[idx] == '#' {
-			semitone++
-			idx++
-		} else if noteName[idx] == 'b' {
-			semitone--
-			idx++
-		}
-	}
-
-	// Parse octave (can be negative like -1)
-	if idx >= len(noteName) {
-		return 0, fmt.Errorf("missing octave in note name: %s", noteName)
-	}
-
-	octaveStr := noteName[idx:]
-	var octave int
-	_, err := fmt.Sscanf(octaveStr, "%d", &octave)
-	if err != nil {
-		return 0, fmt.Errorf("invalid octave in note name %s: %w", noteName, err)
-	}
-
-	// MIDI calculation: (octave + 1) * 12 + semitone
-	// This gives C-1 = 0, C0 = 12, C4 = 60
-	midiNote := (octave+1)*12 + semitone
-
-	// Clamp to valid MIDI range
-	if midiNote < 0 {
-		midiNote = 0
-	}
-	if midiNote > 127 {
-		midiNote = 127
-	}
-
-	return midiNote, nil
-}
-
 // convertArpeggioToNoteEvents converts an arpeggio action to sequential NoteEvents
 func convertArpeggioToNoteEvents(action map[string]any, startBeat float64) ([]models.NoteEvent, error) {
 	chordSymbol, ok := action["chord"].(string)
@@ -465,6 +458,8 @@ func convertArpeggioToNoteEvents(action map[string]any, startBeat float64) ([]mo
 }
 
 // convertChordToNoteEvents converts a chord action to simultaneous NoteEvents
+// inversion/voicing reshape the chord; strum_ms (at the action's bpm, default 120) with
+// direction offsets the notes of each hit like a guitar or keys strum
 func convertChordToNoteEvents(action map[string]any, startBeat float64) ([]models.NoteEvent, error) {
 	chordSymbol, ok := action["chord"].(string)
 	if !ok {
@@ -476,17 +471,38 @@ func convertChordToNoteEvents(action map[string]any, startBeat float64) ([]model
 	velocity, _ := getInt(action, "velocity", 100)
 	octave, _ := getInt(action, "octave", 4)
 	rhythmTemplate, _ := getString(action, "rhythm", "")
+	inversion, _ := getInt(action, "inversion", 0)
+	voicing, _ := getString(action, "voicing", "")
 
 	// Get chord notes
 	chordNotes, err := ChordToMIDI(chordSymbol, octave)
 	if err != nil {
 		return nil, err
 	}
+	if chordNotes, err = voiceChord(chordNotes, inversion, voicing); err != nil {
+		return nil, err
+	}
+
+	noteEvents := convertVoicedChord(chordNotes, velocity, startBeat, length, repeat, rhythmTemplate)
 
+	strumMs, _ := getFloat(action, "strum_ms", 0)
+	if strumMs < 0 || strumMs > maxStrumMs {
+		return nil, fmt.Errorf("chord strum_ms must be from 0 to %g, got %g", maxStrumMs, strumMs)
+	}
+	direction, _ := getString(action, "direction", "down")
+	bpm, _ := getFloat(action, "bpm", timeutil.DefaultBPM)
+	if bpm <= 0 {
+		bpm = timeutil.DefaultBPM
+	}
+	return applyStrum(noteEvents, timeutil.SecondsToBeats(strumMs/1000.0, bpm), direction)
+}
+
+// convertVoicedChord plays chord notes on a rhythm template, or as one hit per repeat
+func convertVoicedChord(chordNotes []int, velocity int, startBeat, length float64, repeat int, rhythmTemplate string) []models.NoteEvent {
 	// Check for rhythm template
 	if rhythmTemplate != "" {
 		if tmpl, ok := GetRhythmTemplate(rhythmTemplate); ok {
-			return applyRhythmTemplateToChord(chordNotes, velocity, startBeat, length, repeat, tmpl), nil
+			return applyRhythmTemplateToChord(chordNotes, velocity, startBeat, length, repeat, tmpl)
 		} else {
 			log.Printf("⚠️ Unknown rhythm template: %s, using default chord behavior", rhythmTemplate)
 		}
@@ -508,10 +524,12 @@ func convertChordToNoteEvents(action map[string]any, startBeat float64) ([]model
 		currentBeat += length
 	}
 
-	return noteEvents, nil
+	return noteEvents
 }
 
-// convertProgressionToNoteEvents converts a progression action to NoteEvents
+// convertProgressionToNoteEvents converts a progression action to NoteEvents. With
+// voicing=smooth the chords are voice-led (see voiceLeadProgression) with voices notes between
+// the MIDI notes low and high, by default from the octave's C up an octave and a fifth.
 func convertProgressionToNoteEvents(action map[string]any, startBeat float64) ([]models.NoteEvent, error) {
 	log.Printf("🎵 convertProgressionToNoteEvents: action=%+v", action)
 
@@ -543,13 +561,28 @@ func convertProgressionToNoteEvents(action map[string]any, startBeat float64) ([
 	velocity, _ := getInt(action, "velocity", 100)
 	octave, _ := getInt(action, "octave", 4)
 
-	log.Printf("🎵 Progression params: length=%.2f, repeat=%d, velocity=%d, octave=%d", length, repeat, velocity, octave)
+	octaves, _ := getIntSlice(action, "octaves") // Optional per-chord octaves
+
+	log.Printf("🎵 Progression params: length=%.2f, repeat=%d, velocity=%d, octave=%d, octaves=%v", length, repeat, velocity, octave, octaves)
 
 	// Calculate chord duration
 	chordDuration := length / float64(len(chords))
 
 	log.Printf("🎵 chordDuration=%.2f (length %.2f / %d chords)", chordDuration, length, len(chords))
 
+	// Smooth voicing: voice-led chords between low and high instead of stacked root positions
+	var voicings [][]int
+	var err error
+	if voicing, _ := getString(action, "voicing", ""); voicing == "smooth" {
+		voices, _ := getInt(action, "voices", 0)
+		low, _ := getInt(action, "low", noteToMIDI("C", clampChordOctave(octave)))
+		high, _ := getInt(action, "high", low+defaultLeadRange)
+		if voicings, err = voiceLeadProgression(chords, voices, low, high); err != nil {
+			return nil, fmt.Errorf("progression: %w", err)
+		}
+		log.Printf("🎵 Voice-led progression: %v", voicings)
+	}
+
 	var noteEvents []models.NoteEvent
 	currentBeat := startBeat
 
@@ -557,8 +590,14 @@ func convertProgressionToNoteEvents(action map[string]any, startBeat float64) ([
 		log.Printf("🎵 Repeat %d/%d", r+1, repeat)
 		for chordIdx, chordSymbol := range chords {
 			log.Printf("🎵 Processing chord %d/%d: %s", chordIdx+1, len(chords), chordSymbol)
-			chordNotes, err := ChordToMIDI(chordSymbol, octave)
-			if err != nil {
+			chordOctave := octave
+			if chordIdx < len(octaves) {
+				chordOctave = octaves[chordIdx]
+			}
+			var chordNotes []int
+			if voicings != nil {
+				chordNotes = voicings[chordIdx]
+			} else if chordNotes, err = ChordToMIDI(chordSymbol, clampChordOctave(chordOctave)); err != nil {
 				log.Printf("🎵 ERROR: ChordToMIDI failed for %s: %v", chordSymbol, err)
 				return nil, fmt.Errorf("invalid chord in progression: %s: %w", chordSymbol, err)
 			}
@@ -701,181 +740,43 @@ func applyRhythmTemplateToArpeggio(arpeggioNotes []int, velocity int, startBeat,
 
 // Helper functions
 
-func parseRootNote(chordSymbol string) (string, error) {
-	if len(chordSymbol) == 0 {
-		return "", fmt.Errorf("empty chord symbol")
-	}
-
-	// Extract root (first 1-2 chars: C, C#, Db, etc.)
-	root := ""
-	if len(chordSymbol) > 1 && (chordSymbol[1] == '#' || chordSymbol[1] == 'b') {
-		root = chordSymbol[:2]
-	} else {
-		root = chordSymbol[:1]
-	}
-
-	// Validate root note
-	validRoots := map[string]bool{
-		"C": true, "C#": true, "Db": true, "D": true, "D#": true, "Eb": true,
-		"E": true, "F": true, "F#": true, "Gb": true, "G": true, "G#": true,
-		"Ab": true, "A": true, "A#": true, "Bb": true, "B": true,
-	}
-
-	if !validRoots[root] {
-		return "", fmt.Errorf("invalid root note: %s", root)
-	}
-
-	return root, nil
-}
-
-func parseChordQuality(chordSymbol string) string {
-	// Remove root note
-	if len(chordSymbol) > 1 && (chordSymbol[1] == '#' || chordSymbol[1] == 'b') {
-		chordSymbol = chordSymbol[2:]
-	} else if len(chordSymbol) > 0 {
-		chordSymbol = chordSymbol[1:]
-	}
-
-	// Check for quality markers
-	if strings.HasPrefix(chordSymbol, "m") && !strings.HasPrefix(chordSymbol, "maj") && !strings.HasPrefix(chordSymbol, "min") {
-		return "minor"
-	}
-	if strings.HasPrefix(chordSymbol, "dim") {
-		return "diminished"
-	}
-	if strings.HasPrefix(chordSymbol, "aug") {
-		return "augmented"
-	}
-	if strings.HasPrefix(chordSymbol, "sus2") {
-		return "sus2"
-	}
-	if strings.HasPrefix(chordSymbol, "sus4") {
-		return "sus4"
+// noteToMIDI places a note name in an arranger chord octave, where C4 is 48. Unknown names
+// give 60.
+func noteToMIDI(note string, octave int) int {
+	offset, ok := music.NoteOffset(note)
+	if !ok {
+		return 60
 	}
-
-	// Default to major
-	return "major"
+	return (octave * 12) + offset
 }
 
-func parseExtensions(chordSymbol string) []string {
-	extensions := []string{}
-
-	// Remove root note first
-	if len(chordSymbol) > 1 && (chordSymbol[1] == '#' || chordSymbol[1] == 'b') {
-		chordSymbol = chordSymbol[2:]
-	} else if len(chordSymbol) > 0 {
-		chordSymbol = chordSymbol[1:]
+// splitChordOctave splits a progression entry like "C:3" into its chord symbol and octave.
+// ok is false when the entry has no octave suffix.
+func splitChordOctave(entry string) (chordSymbol string, octave int, ok bool, err error) {
+	idx := strings.LastIndex(entry, ":")
+	if idx == -1 {
+		return entry, 0, false, nil
 	}
-
-	// Extract extensions BEFORE removing quality markers
-	// This prevents "maj7" from being corrupted to "aj7" by TrimPrefix("m")
-	if strings.Contains(chordSymbol, "maj7") {
-		extensions = append(extensions, "maj7")
-		chordSymbol = strings.ReplaceAll(chordSymbol, "maj7", "")
-	}
-	if strings.Contains(chordSymbol, "min7") {
-		extensions = append(extensions, "min7")
-		chordSymbol = strings.ReplaceAll(chordSymbol, "min7", "")
-	}
-
-	// Now remove quality markers (after extracting maj7/min7)
-	chordSymbol = strings.TrimPrefix(chordSymbol, "m")
-	chordSymbol = strings.TrimPrefix(chordSymbol, "dim")
-	chordSymbol = strings.TrimPrefix(chordSymbol, "aug")
-	chordSymbol = strings.TrimPrefix(chordSymbol, "sus2")
-	chordSymbol = strings.TrimPrefix(chordSymbol, "sus4")
-
-	// Extract remaining extensions
-	if strings.Contains(chordSymbol, "7") {
-		extensions = append(extensions, "7")
-		chordSymbol = strings.ReplaceAll(chordSymbol, "7", "")
-	}
-	if strings.Contains(chordSymbol, "9") {
-		extensions = append(extensions, "9")
-	}
-	if strings.Contains(chordSymbol, "11") {
-		extensions = append(extensions, "11")
-	}
-	if strings.Contains(chordSymbol, "13") {
-		extensions = append(extensions, "13")
-	}
-	if strings.Contains(chordSymbol, "add9") {
-		extensions = append(extensions, "add9")
-	}
-	if strings.Contains(chordSymbol, "add11") {
-		extensions = append(extensions, "add11")
-	}
-	if strings.Contains(chordSymbol, "add13") {
-		extensions = append(extensions, "add13")
+	chordSymbol = strings.TrimSpace(entry[:idx])
+	octave, err = strconv.Atoi(strings.TrimSpace(entry[idx+1:]))
+	if err != nil {
+		return "", 0, false, fmt.Errorf("invalid octave in chord %q: %w", entry, err)
 	}
-
-	return extensions
+	return chordSymbol, octave, true, nil
 }
 
-func buildChordIntervals(quality string, extensions []string) []int {
-	var intervals []int
-
-	// Base triad
-	switch quality {
-	case "major":
-		intervals = []int{0, 4, 7} // Root, Major 3rd, Perfect 5th
-	case "minor":
-		intervals = []int{0, 3, 7} // Root, Minor 3rd, Perfect 5th
-	case "diminished":
-		intervals = []int{0, 3, 6} // Root, Minor 3rd, Diminished 5th
-	case "augmented":
-		intervals = []int{0, 4, 8} // Root, Major 3rd, Augmented 5th
-	case "sus2":
-		intervals = []int{0, 2, 7} // Root, Major 2nd, Perfect 5th
-	case "sus4":
-		intervals = []int{0, 5, 7} // Root, Perfect 4th, Perfect 5th
-	default:
-		intervals = []int{0, 4, 7} // Default to major
-	}
-
-	// Add extensions
-	for _, ext := range extensions {
-		switch ext {
-		case "7", "min7":
-			intervals = append(intervals, 10) // Minor 7th
-		case "maj7":
-			intervals = append(intervals, 11) // Major 7th
-		case "9", "add9":
-			intervals = append(intervals, 14) // Major 9th
-		case "11", "add11":
-			intervals = append(intervals, 17) // Perfect 11th
-		case "13", "add13":
-			intervals = append(intervals, 21) // Major 13th
-		}
+// clampChordOctave keeps a chord's octave inside the MIDI range (octave 0 = MIDI 0-11,
+// octave 9 = MIDI 108-119). Notes that still fall above 127 are dropped by ChordToMIDI.
+func clampChordOctave(octave int) int {
+	if octave < minChordOctave {
+		log.Printf("⚠️ Chord octave %d below MIDI range, clamping to %d", octave, minChordOctave)
+		return minChordOctave
 	}
-
-	return intervals
-}
-
-func noteToMIDI(note string, octave int) int {
-	// Note to semitone offset from C
-	noteMap := map[string]int{
-		"C":  0,
-		"C#": 1, "Db": 1,
-		"D":  2,
-		"D#": 3, "Eb": 3,
-		"E":  4,
-		"F":  5,
-		"F#": 6, "Gb": 6,
-		"G":  7,
-		"G#": 8, "Ab": 8,
-		"A":  9,
-		"A#": 10, "Bb": 10,
-		"B": 11,
-	}
-
-	offset, ok := noteMap[note]
-	if !ok {
-		return 60 // Default to C4
+	if octave > maxChordOctave {
+		log.Printf("⚠️ Chord octave %d above MIDI range, clamping to %d", octave, maxChordOctave)
+		return maxChordOctave
 	}
-
-	// C4 = 60, so: (octave * 12) + offset
-	return (octave * 12) + offset
+	return octave
 }
 
 func getFloat(m map[string]any, key string, defaultValue float64) (float64, bool) {
@@ -915,6 +816,27 @@ func getString(m map[string]any, key string, defaultValue string) (string, bool)
 	return defaultValue, false
 }
 
+func getIntSlice(m map[string]any, key string) ([]int, bool) {
+	switch val := m[key].(type) {
+	case []int:
+		return val, true
+	case []interface{}:
+		result := make([]int, 0, len(val))
+		for _, item := range val {
+			switch n := item.(type) {
+			case int:
+				result = append(result, n)
+			case float64:
+				result = append(result, int(n))
+			default:
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
+
 func reverseSlice(s []int) []int {
 	result := make([]int, len(s))
 	for i, v := range s {
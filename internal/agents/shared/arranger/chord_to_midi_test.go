@@ -1,6 +1,7 @@
 package services
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -249,6 +250,76 @@ func TestConvertArrangerActionToNoteEvents_Progression(t *testing.T) {
 	}
 }
 
+func TestConvertArrangerActionToNoteEvents_ProgressionOctaves(t *testing.T) {
+	tests := []struct {
+		name      string
+		action    map[string]any
+		wantRoots []int // lowest note of each chord
+	}{
+		{
+			name: "per-chord octaves",
+			action: map[string]any{
+				"type":    "progression",
+				"chords":  []string{"C", "Am"},
+				"octaves": []int{3, 4},
+			},
+			wantRoots: []int{36, 57},
+		},
+		{
+			name: "octaves from JSON",
+			action: map[string]any{
+				"type":    "progression",
+				"chords":  []interface{}{"C", "Am"},
+				"octaves": []interface{}{2.0, 3.0},
+			},
+			wantRoots: []int{24, 45},
+		},
+		{
+			name: "chords without octaves use the action octave",
+			action: map[string]any{
+				"type":    "progression",
+				"chords":  []string{"C", "F"},
+				"octave":  3,
+				"octaves": []int{2},
+			},
+			wantRoots: []int{24, 41},
+		},
+		{
+			name: "out-of-range octaves are clamped",
+			action: map[string]any{
+				"type":    "progression",
+				"chords":  []string{"C", "C"},
+				"octaves": []int{-2, 12},
+			},
+			wantRoots: []int{0, 108},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ConvertArrangerActionToNoteEvents(tt.action, 0.0)
+			if err != nil {
+				t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+			}
+
+			var roots []int
+			lastStart := -1.0
+			for _, e := range events {
+				if e.MidiNoteNumber < 0 || e.MidiNoteNumber > 127 {
+					t.Errorf("note %d outside MIDI range", e.MidiNoteNumber)
+				}
+				if e.StartBeats != lastStart {
+					roots = append(roots, e.MidiNoteNumber)
+					lastStart = e.StartBeats
+				}
+			}
+			if !reflect.DeepEqual(roots, tt.wantRoots) {
+				t.Errorf("chord roots = %v, want %v", roots, tt.wantRoots)
+			}
+		})
+	}
+}
+
 func TestChordQualities(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -283,62 +354,6 @@ func TestChordQualities(t *testing.T) {
 	}
 }
 
-// TestNoteNameToMIDI tests the note name to MIDI conversion
-func TestNoteNameToMIDI(t *testing.T) {
-	tests := []struct {
-		name         string
-		noteName     string
-		expectedMIDI int
-		expectError  bool
-	}{
-		// Standard notes (C4 = middle C = MIDI 60)
-		// Formula: (octave + 1) * 12 + semitone
-		{"C4 (middle C)", "C4", 60, false},
-		{"C0", "C0", 12, false},
-		{"C-1", "C-1", 0, false},
-		{"C5", "C5", 72, false},
-		// E1 (the user's request): (1+1)*12 + 4 = 28
-		{"E1", "E1", 28, false},
-		// Other common notes
-		{"A4 (440Hz)", "A4", 69, false}, // (4+1)*12 + 9 = 69
-		{"G3", "G3", 55, false},         // (3+1)*12 + 7 = 55
-		{"D2", "D2", 38, false},         // (2+1)*12 + 2 = 38
-		// Sharp notes
-		{"C#4", "C#4", 61, false}, // 60 + 1 = 61
-		{"F#3", "F#3", 54, false}, // (3+1)*12 + 6 = 54
-		{"G#2", "G#2", 44, false}, // (2+1)*12 + 8 = 44
-		// Flat notes (Bb = A# = 10 semitones)
-		{"Bb2", "Bb2", 46, false}, // (2+1)*12 + 10 = 46
-		{"Eb4", "Eb4", 63, false}, // (4+1)*12 + 3 = 63
-		{"Ab3", "Ab3", 56, false}, // (3+1)*12 + 8 = 56
-		// Edge cases
-		{"B0", "B0", 23, false}, // (0+1)*12 + 11 = 23
-		{"A0", "A0", 21, false}, // (0+1)*12 + 9 = 21
-		// Lowercase should work too (case insensitive)
-		{"lowercase e1", "e1", 28, false},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			midiNote, err := NoteNameToMIDI(tt.noteName)
-			if tt.expectError {
-				if err == nil {
-					t.Error("Expected error but got none")
-				}
-				return
-			}
-
-			if err != nil {
-				t.Fatalf("NoteNameToMIDI failed: %v", err)
-			}
-
-			if midiNote != tt.expectedMIDI {
-				t.Errorf("NoteNameToMIDI(%s) = %d, want %d", tt.noteName, midiNote, tt.expectedMIDI)
-			}
-		})
-	}
-}
-
 // TestConvertSingleNoteToNoteEvents tests conversion of single note actions
 func TestConvertSingleNoteToNoteEvents(t *testing.T) {
 	tests := []struct {
@@ -433,3 +448,211 @@ func TestConvertSingleNoteToNoteEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertArrangerActionToNoteEvents_Probability(t *testing.T) {
+	baseAction := func() map[string]any {
+		return map[string]any{
+			"type":     "arpeggio",
+			"chord":    "Em",
+			"length":   4.0,
+			"velocity": 100,
+			"octave":   4,
+		}
+	}
+
+	full, err := ConvertArrangerActionToNoteEvents(baseAction(), 0.0)
+	if err != nil {
+		t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+	}
+
+	t.Run("probability 1 is unchanged", func(t *testing.T) {
+		action := baseAction()
+		action["probability"] = 1.0
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		if !reflect.DeepEqual(events, full) {
+			t.Errorf("probability=1 changed the notes: got %d events, want %d", len(events), len(full))
+		}
+	})
+
+	t.Run("same seed is reproducible", func(t *testing.T) {
+		action := baseAction()
+		action["probability"] = 0.5
+		action["seed"] = 42
+		first, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		second, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("same seed produced different notes: %v vs %v", first, second)
+		}
+		if len(first) == 0 || len(first) >= len(full) {
+			t.Errorf("expected some but not all notes kept, got %d of %d", len(first), len(full))
+		}
+	})
+
+	t.Run("keeps at least one note", func(t *testing.T) {
+		action := map[string]any{
+			"type":        "note",
+			"pitch":       "E1",
+			"duration":    4.0,
+			"probability": 0.0001,
+			"seed":        1,
+		}
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		if len(events) != 1 {
+			t.Errorf("expected the single note to be kept, got %d events", len(events))
+		}
+	})
+
+	t.Run("invalid probability", func(t *testing.T) {
+		for _, probability := range []float64{0, -0.5, 1.5} {
+			action := baseAction()
+			action["probability"] = probability
+			if _, err := ConvertArrangerActionToNoteEvents(action, 0.0); err == nil {
+				t.Errorf("expected error for probability=%g", probability)
+			}
+		}
+	})
+}
+
+func TestConvertArrangerActionToNoteEvents_WalkingBass(t *testing.T) {
+	tests := []struct {
+		name          string
+		action        map[string]any
+		startBeat     float64
+		expectedNotes []int
+		expectedStart float64
+		expectedDur   float64
+	}{
+		{
+			name: "ii-V-I one bar per chord",
+			action: map[string]any{
+				"type":   "walking_bass",
+				"chords": []string{"Dm7", "G7", "Cmaj7"},
+				"length": 12.0,
+			},
+			// D F A Ab | G B D Db | C B G Eb (approaches back into D)
+			expectedNotes: []int{38, 41, 45, 44, 43, 47, 50, 49, 48, 47, 43, 39},
+			expectedStart: 0.0,
+			expectedDur:   1.0,
+		},
+		{
+			name: "two beats per chord uses root and approach note",
+			action: map[string]any{
+				"type":   "walking_bass",
+				"chords": []interface{}{"C", "F"},
+				"length": 4.0,
+			},
+			expectedNotes: []int{36, 40, 41, 37},
+			expectedStart: 0.0,
+			expectedDur:   1.0,
+		},
+		{
+			name: "explicit start overrides startBeat",
+			action: map[string]any{
+				"type":   "walking_bass",
+				"chords": []string{"E"},
+				"start":  8.0,
+			},
+			startBeat:     0.0,
+			expectedNotes: []int{40, 44, 47, 41},
+			expectedStart: 8.0,
+			expectedDur:   1.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := ConvertArrangerActionToNoteEvents(tt.action, tt.startBeat)
+			if err != nil {
+				t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+			}
+
+			notes := make([]int, len(events))
+			for i, e := range events {
+				notes[i] = e.MidiNoteNumber
+				if e.MidiNoteNumber < walkingBassLowest || e.MidiNoteNumber > walkingBassHighest {
+					t.Errorf("note %d (%d) outside bass register", i, e.MidiNoteNumber)
+				}
+				if want := tt.expectedStart + float64(i)*tt.expectedDur; e.StartBeats != want {
+					t.Errorf("note %d starts at %.2f, want %.2f", i, e.StartBeats, want)
+				}
+				if e.DurationBeats != tt.expectedDur {
+					t.Errorf("note %d duration %.2f, want %.2f", i, e.DurationBeats, tt.expectedDur)
+				}
+			}
+			if !reflect.DeepEqual(notes, tt.expectedNotes) {
+				t.Errorf("walking line = %v, want %v", notes, tt.expectedNotes)
+			}
+		})
+	}
+}
+
+func TestConvertArrangerActionToNoteEvents_WalkingBassRegister(t *testing.T) {
+	// A high octave and wide leaps must still fold back into E1-G3
+	action := map[string]any{
+		"type":   "walking_bass",
+		"chords": []string{"Bb7", "Eb7", "Ab7", "Db7", "Gb7", "B7"},
+		"octave": 5,
+	}
+	events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+	if err != nil {
+		t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+	}
+	if len(events) != 24 {
+		t.Fatalf("expected 24 quarter notes (1 bar per chord), got %d", len(events))
+	}
+	for i, e := range events {
+		if e.MidiNoteNumber < walkingBassLowest || e.MidiNoteNumber > walkingBassHighest {
+			t.Errorf("note %d (%d) outside bass register", i, e.MidiNoteNumber)
+		}
+	}
+
+	if _, err := ConvertArrangerActionToNoteEvents(map[string]any{"type": "walking_bass", "chords": []string{"H7"}}, 0.0); err == nil {
+		t.Error("expected error for invalid chord")
+	}
+}
+
+func TestChordToMIDI_ExtendedChords(t *testing.T) {
+	tests := []struct {
+		symbol string
+		octave int
+		want   []int
+	}{
+		{"C13b9", 4, []int{48, 52, 55, 58, 61, 69}}, // C E G Bb Db A
+		{"G7#5", 4, []int{55, 59, 63, 65}},          // G B D# F
+		{"Fsus2", 4, []int{53, 55, 60}},             // F G C
+		{"Bb/D", 4, []int{38, 58, 62, 65}},          // D3 under Bb D F
+		{"Cb", 4, []int{47, 51, 54}},                // B major, below C4
+		{"B#", 3, []int{48, 52, 55}},                // C major, above B3
+		{"Dm7b5", 3, []int{38, 41, 44, 48}},         // D F Ab C
+		{"A7alt", 3, []int{45, 49, 55, 58, 60, 65}}, // A C# G Bb C F
+		{"Ebmaj7#11", 4, []int{51, 55, 58, 62, 69}}, // Eb G Bb D A
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			notes, err := ChordToMIDI(tt.symbol, tt.octave)
+			if err != nil {
+				t.Fatalf("ChordToMIDI failed: %v", err)
+			}
+			if !reflect.DeepEqual(notes, tt.want) {
+				t.Errorf("ChordToMIDI(%q, %d) = %v, want %v", tt.symbol, tt.octave, notes, tt.want)
+			}
+		})
+	}
+
+	if _, err := ChordToMIDI("C7b10", 4); err == nil {
+		t.Error("ChordToMIDI(C7b10) succeeded, want error for the unknown alteration")
+	}
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// maxStrumMs is the longest strum; beyond this a strum is an arpeggio
+const maxStrumMs = 200.0
+
+// voiceChord applies inversion and voicing to chord notes (sorted low to high). Each inversion
+// moves the lowest note up an octave. Voicings: "close" (as built), "spread" (every second
+// note from the bottom up an octave, e.g. root-5th-10th), and "drop2" (the second-highest
+// note down an octave). Notes that would leave the MIDI range stay where they are.
+func voiceChord(notes []int, inversion int, voicing string) ([]int, error) {
+	voiced := append([]int(nil), notes...)
+	sort.Ints(voiced)
+	if len(voiced) < 2 {
+		return voiced, nil
+	}
+
+	if inversion < 0 || inversion >= len(voiced) {
+		return nil, fmt.Errorf("chord inversion must be from 0 to %d for %d notes, got %d", len(voiced)-1, len(voiced), inversion)
+	}
+	for i := 0; i < inversion; i++ {
+		voiced[0] = shiftOctave(voiced[0], 1)
+		sort.Ints(voiced)
+	}
+
+	switch voicing {
+	case "", "close":
+	case "spread":
+		for i := 1; i < len(voiced); i += 2 {
+			voiced[i] = shiftOctave(voiced[i], 1)
+		}
+	case "drop2":
+		if len(voiced) >= 3 {
+			voiced[len(voiced)-2] = shiftOctave(voiced[len(voiced)-2], -1)
+		}
+	default:
+		return nil, fmt.Errorf("unknown chord voicing %q (use close, spread, or drop2)", voicing)
+	}
+
+	sort.Ints(voiced)
+	return voiced, nil
+}
+
+// shiftOctave moves a MIDI note by octaves, leaving it unchanged if that leaves the MIDI range
+func shiftOctave(note, octaves int) int {
+	shifted := note + 12*octaves
+	if shifted < 0 || shifted > 127 {
+		return note
+	}
+	return shifted
+}
+
+// applyStrum offsets the notes of each chord hit (notes sharing a start) strumBeats apart:
+// "down" strums low to high like a guitar downstroke, "up" high to low. Each note is
+// shortened by its offset so the chord still releases together.
+func applyStrum(noteEvents []models.NoteEvent, strumBeats float64, direction string) ([]models.NoteEvent, error) {
+	if direction != "" && direction != "down" && direction != "up" {
+		return nil, fmt.Errorf("unknown strum direction %q (use down or up)", direction)
+	}
+	if strumBeats <= 0 {
+		return noteEvents, nil
+	}
+
+	hits := make(map[float64][]int) // start -> indices into noteEvents
+	for i, note := range noteEvents {
+		hits[note.StartBeats] = append(hits[note.StartBeats], i)
+	}
+
+	strummed := append([]models.NoteEvent(nil), noteEvents...)
+	for _, indices := range hits {
+		sort.SliceStable(indices, func(a, b int) bool {
+			if direction == "up" {
+				return noteEvents[indices[a]].MidiNoteNumber > noteEvents[indices[b]].MidiNoteNumber
+			}
+			return noteEvents[indices[a]].MidiNoteNumber < noteEvents[indices[b]].MidiNoteNumber
+		})
+		for rank, index := range indices {
+			offset := float64(rank) * strumBeats
+			// Keep at least half the note so a long strum on a short hit stays audible
+			offset = min(offset, strummed[index].DurationBeats/2)
+			strummed[index].StartBeats += offset
+			strummed[index].DurationBeats -= offset
+		}
+	}
+	return strummed, nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVoiceChord(t *testing.T) {
+	// C major triad and Cmaj7, root C4 = 60
+	triad := []int{60, 64, 67}
+	seventh := []int{60, 64, 67, 71}
+
+	tests := []struct {
+		name      string
+		notes     []int
+		inversion int
+		voicing   string
+		want      []int
+		wantErr   bool
+	}{
+		{name: "root position close", notes: triad, want: []int{60, 64, 67}},
+		{name: "first inversion", notes: triad, inversion: 1, want: []int{64, 67, 72}},
+		{name: "second inversion", notes: triad, inversion: 2, want: []int{67, 72, 76}},
+		{name: "spread", notes: triad, voicing: "spread", want: []int{60, 67, 76}},
+		{name: "drop2", notes: seventh, voicing: "drop2", want: []int{55, 60, 64, 71}},
+		{name: "first inversion drop2", notes: seventh, inversion: 1, voicing: "drop2", want: []int{59, 64, 67, 72}},
+		{name: "inversion out of range", notes: triad, inversion: 3, wantErr: true},
+		{name: "unknown voicing", notes: triad, voicing: "quartal", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := voiceChord(tt.notes, tt.inversion, tt.voicing)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("voiceChord() = %v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("voiceChord() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("voiceChord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConvertArrangerActionToNoteEvents_ChordStrum(t *testing.T) {
+	baseAction := func() map[string]any {
+		return map[string]any{
+			"type":     "chord",
+			"chord":    "C",
+			"length":   4.0,
+			"repeat":   2,
+			"velocity": 100,
+		}
+	}
+
+	plain, err := ConvertArrangerActionToNoteEvents(baseAction(), 0.0)
+	if err != nil {
+		t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+	}
+
+	t.Run("downstroke at 120 BPM", func(t *testing.T) {
+		action := baseAction()
+		action["strum_ms"] = 25.0 // 0.05 beats at 120 BPM
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		if len(events) != len(plain) {
+			t.Fatalf("got %d events, want %d", len(events), len(plain))
+		}
+		for i, event := range events {
+			rank := i % 3 // Notes are built low to high within each hit
+			wantStart := plain[i].StartBeats + float64(rank)*0.05
+			if math.Abs(event.StartBeats-wantStart) > 1e-9 {
+				t.Errorf("note %d (pitch %d) start = %g, want %g", i, event.MidiNoteNumber, event.StartBeats, wantStart)
+			}
+			// All notes of a hit still release together
+			if end := event.StartBeats + event.DurationBeats; math.Abs(end-(plain[i].StartBeats+4)) > 1e-9 {
+				t.Errorf("note %d ends at %g, want %g", i, end, plain[i].StartBeats+4)
+			}
+		}
+	})
+
+	t.Run("upstroke starts from the top at the project tempo", func(t *testing.T) {
+		action := baseAction()
+		action["strum_ms"] = 50.0
+		action["direction"] = "up"
+		action["bpm"] = 60.0 // 50ms = 0.05 beats
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		starts := map[int]float64{}
+		for _, event := range events[:3] {
+			starts[event.MidiNoteNumber] = event.StartBeats
+		}
+		want := map[int]float64{55: 0, 52: 0.05, 48: 0.1} // C4 = 48 in noteToMIDI
+		for pitch, start := range want {
+			if math.Abs(starts[pitch]-start) > 1e-9 {
+				t.Errorf("pitch %d start = %g, want %g", pitch, starts[pitch], start)
+			}
+		}
+	})
+
+	t.Run("voicing and inversion reach the notes", func(t *testing.T) {
+		action := baseAction()
+		action["repeat"] = 1
+		action["inversion"] = 1
+		action["voicing"] = "spread"
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		pitches := []int{}
+		for _, event := range events {
+			pitches = append(pitches, event.MidiNoteNumber)
+		}
+		if want := []int{52, 60, 67}; !reflect.DeepEqual(pitches, want) {
+			t.Errorf("pitches = %v, want %v", pitches, want)
+		}
+	})
+
+	t.Run("invalid strum", func(t *testing.T) {
+		for _, change := range []map[string]any{
+			{"strum_ms": -5.0},
+			{"strum_ms": 500.0},
+			{"strum_ms": 20.0, "direction": "sideways"},
+		} {
+			action := baseAction()
+			for k, v := range change {
+				action[k] = v
+			}
+			if _, err := ConvertArrangerActionToNoteEvents(action, 0.0); err == nil {
+				t.Errorf("expected error for %v", change)
+			}
+		}
+	})
+}
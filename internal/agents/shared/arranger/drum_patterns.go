@@ -0,0 +1,195 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// General MIDI drum notes (channel 10)
+const (
+	gmKick      = 36
+	gmSideStick = 37
+	gmSnare     = 38
+	gmClap      = 39
+	gmClosedHat = 42
+	gmOpenHat   = 46
+)
+
+const (
+	defaultDrumPatternLength = 4.0  // 1 bar
+	drumHitDuration          = 0.25 // Drum hits are one-shots; a 16th keeps them readable in the editor
+)
+
+// drumVoice plays one drum note on a rhythm template
+type drumVoice struct {
+	note    int
+	pattern RhythmTemplate
+	gain    float64 // Velocity multiplier for the whole voice (e.g. quieter hats)
+}
+
+// drumPattern is a drum style: its voices and the grid unit swing applies to
+type drumPattern struct {
+	voices    []drumVoice
+	swingUnit float64 // Beats; notes on odd multiples of this are delayed by swing
+}
+
+// Kick patterns that aren't general rhythm templates
+var (
+	rockKick = RhythmTemplate{
+		Name:    "rock_kick",
+		Offsets: []float64{0, 2, 2.5},
+		Accents: []float64{1.0, 0.95, 0.8},
+	}
+	trapKick = RhythmTemplate{
+		Name:    "trap_kick",
+		Offsets: []float64{0, 0.75, 2.5, 3.25},
+		Accents: []float64{1.0, 0.85, 0.95, 0.8},
+	}
+	trapSnare = RhythmTemplate{
+		Name:    "trap_snare",
+		Offsets: []float64{2}, // Half-time backbeat
+		Accents: []float64{1.0},
+	}
+	bossaKick = RhythmTemplate{
+		Name:    "bossa_kick",
+		Offsets: []float64{0, 1.5, 2, 3.5},
+		Accents: []float64{1.0, 0.75, 0.95, 0.75},
+	}
+)
+
+// drumPatterns maps drum_pattern styles to their voices, built on the rhythm templates
+var drumPatterns = map[string]drumPattern{
+	"house": {
+		voices: []drumVoice{
+			{note: gmKick, pattern: rhythmTemplates["quarters"], gain: 1.0},
+			{note: gmClap, pattern: rhythmTemplates["backbeat"], gain: 0.95},
+			{note: gmOpenHat, pattern: rhythmTemplates["offbeat"], gain: 0.8},
+		},
+		swingUnit: 0.25,
+	},
+	"techno": {
+		voices: []drumVoice{
+			{note: gmKick, pattern: rhythmTemplates["quarters"], gain: 1.0},
+			{note: gmClosedHat, pattern: rhythmTemplates["16ths"], gain: 0.7},
+			{note: gmOpenHat, pattern: rhythmTemplates["offbeat"], gain: 0.85},
+		},
+		swingUnit: 0.25,
+	},
+	"trap": {
+		voices: []drumVoice{
+			{note: gmKick, pattern: trapKick, gain: 1.0},
+			{note: gmClap, pattern: trapSnare, gain: 1.0},
+			{note: gmClosedHat, pattern: rhythmTemplates["16ths"], gain: 0.75},
+		},
+		swingUnit: 0.25,
+	},
+	"rock": {
+		voices: []drumVoice{
+			{note: gmKick, pattern: rockKick, gain: 1.0},
+			{note: gmSnare, pattern: rhythmTemplates["backbeat"], gain: 1.0},
+			{note: gmClosedHat, pattern: rhythmTemplates["8ths"], gain: 0.8},
+		},
+		swingUnit: 0.5,
+	},
+	"bossa": {
+		voices: []drumVoice{
+			{note: gmKick, pattern: bossaKick, gain: 0.9},
+			{note: gmSideStick, pattern: rhythmTemplates["bossa"], gain: 0.85}, // 2-bar clave
+			{note: gmClosedHat, pattern: rhythmTemplates["8ths"], gain: 0.6},
+		},
+		swingUnit: 0.5,
+	},
+}
+
+// DrumPatternStyles returns the drum_pattern style names, sorted
+func DrumPatternStyles() []string {
+	styles := make([]string, 0, len(drumPatterns))
+	for style := range drumPatterns {
+		styles = append(styles, style)
+	}
+	sort.Strings(styles)
+	return styles
+}
+
+// convertDrumPatternToNoteEvents converts a drum_pattern action to kick/snare/hat hits on
+// General MIDI drum notes. Each voice's template loops (one bar, or two for 2-bar templates
+// like the bossa clave) until length. swing (0-1) delays every off-beat of the style's swing
+// unit by that fraction of the unit; 1/3 is a triplet feel.
+// Example: drum_pattern(style="house", length=8, swing=0.1)
+func convertDrumPatternToNoteEvents(action map[string]any, startBeat float64) ([]models.NoteEvent, error) {
+	style, _ := getString(action, "style", "")
+	pattern, ok := drumPatterns[strings.ToLower(style)]
+	if !ok {
+		return nil, fmt.Errorf("unknown drum_pattern style %q (available: %s)", style, strings.Join(DrumPatternStyles(), ", "))
+	}
+
+	length, _ := getFloat(action, "length", defaultDrumPatternLength)
+	swing, _ := getFloat(action, "swing", 0)
+	velocity, _ := getInt(action, "velocity", 100)
+	if length <= 0 {
+		return nil, fmt.Errorf("drum_pattern length must be positive, got %g", length)
+	}
+	if swing < 0 || swing >= 1 {
+		return nil, fmt.Errorf("drum_pattern swing must be at least 0 and less than 1, got %g", swing)
+	}
+
+	// Check for explicit start time (overrides startBeat)
+	if explicitStart, ok := getFloat(action, "start", 0); ok && explicitStart != 0 {
+		startBeat = explicitStart
+	}
+
+	var noteEvents []models.NoteEvent
+	for _, voice := range pattern.voices {
+		period := templatePeriod(voice.pattern)
+		for cycleStart := 0.0; cycleStart < length; cycleStart += period {
+			for i, offset := range voice.pattern.Offsets {
+				beat := cycleStart + offset
+				if beat >= length {
+					break
+				}
+				if isOffbeat(offset, pattern.swingUnit) {
+					beat += swing * pattern.swingUnit
+				}
+
+				accent := 1.0
+				if i < len(voice.pattern.Accents) {
+					accent = voice.pattern.Accents[i]
+				}
+				hitVelocity := int(math.Round(float64(velocity) * accent * voice.gain))
+
+				noteEvents = append(noteEvents, models.NoteEvent{
+					MidiNoteNumber: voice.note,
+					Velocity:       max(minNoteVelocity, min(maxNoteVelocity, hitVelocity)),
+					StartBeats:     startBeat + beat,
+					DurationBeats:  drumHitDuration,
+				})
+			}
+		}
+	}
+
+	// Order hits by time so the clip reads naturally
+	sort.SliceStable(noteEvents, func(i, j int) bool {
+		return noteEvents[i].StartBeats < noteEvents[j].StartBeats
+	})
+	return noteEvents, nil
+}
+
+// templatePeriod returns a template's loop length in beats: whole bars covering its offsets
+func templatePeriod(tmpl RhythmTemplate) float64 {
+	last := 0.0
+	for _, offset := range tmpl.Offsets {
+		last = math.Max(last, offset)
+	}
+	return 4.0 * math.Floor(last/4.0+1)
+}
+
+// isOffbeat reports whether offset falls on an odd multiple of unit
+func isOffbeat(offset, unit float64) bool {
+	steps := offset / unit
+	rounded := math.Round(steps)
+	return math.Abs(steps-rounded) < 1e-9 && int(rounded)%2 == 1
+}
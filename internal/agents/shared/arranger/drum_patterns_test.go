@@ -0,0 +1,79 @@
+package services
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConvertArrangerActionToNoteEvents_DrumPattern(t *testing.T) {
+	// hits groups note starts by drum note
+	hits := func(t *testing.T, action map[string]any) map[int][]float64 {
+		t.Helper()
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		byNote := make(map[int][]float64)
+		for i, event := range events {
+			if i > 0 && event.StartBeats < events[i-1].StartBeats {
+				t.Errorf("events not sorted by start: %g after %g", event.StartBeats, events[i-1].StartBeats)
+			}
+			if event.Velocity < 1 || event.Velocity > 127 {
+				t.Errorf("velocity out of MIDI range: %+v", event)
+			}
+			byNote[event.MidiNoteNumber] = append(byNote[event.MidiNoteNumber], event.StartBeats)
+		}
+		return byNote
+	}
+	assertStarts := func(t *testing.T, name string, got, want []float64) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s starts = %v, want %v", name, got, want)
+		}
+		for i := range want {
+			if math.Abs(got[i]-want[i]) > 1e-9 {
+				t.Errorf("%s starts = %v, want %v", name, got, want)
+				return
+			}
+		}
+	}
+
+	t.Run("house is four on the floor with offbeat hats", func(t *testing.T) {
+		byNote := hits(t, map[string]any{"type": "drum_pattern", "style": "house", "length": 8.0})
+		assertStarts(t, "kick", byNote[gmKick], []float64{0, 1, 2, 3, 4, 5, 6, 7})
+		assertStarts(t, "clap", byNote[gmClap], []float64{1, 3, 5, 7})
+		assertStarts(t, "open hat", byNote[gmOpenHat], []float64{0.5, 1.5, 2.5, 3.5, 4.5, 5.5, 6.5, 7.5})
+	})
+
+	t.Run("rock swing delays off-beat 8ths", func(t *testing.T) {
+		byNote := hits(t, map[string]any{"type": "drum_pattern", "style": "rock", "length": 4.0, "swing": 0.2})
+		assertStarts(t, "hat", byNote[gmClosedHat], []float64{0, 0.6, 1, 1.6, 2, 2.6, 3, 3.6})
+		assertStarts(t, "kick", byNote[gmKick], []float64{0, 2, 2.6})
+		assertStarts(t, "snare", byNote[gmSnare], []float64{1, 3})
+	})
+
+	t.Run("two-bar bossa clave stops at length", func(t *testing.T) {
+		byNote := hits(t, map[string]any{"type": "drum_pattern", "style": "bossa", "length": 4.0})
+		assertStarts(t, "side stick", byNote[gmSideStick], []float64{0, 1.5, 3})
+	})
+
+	t.Run("trap snare is half-time", func(t *testing.T) {
+		byNote := hits(t, map[string]any{"type": "drum_pattern", "style": "trap", "length": 8.0, "start": 4.0})
+		assertStarts(t, "clap", byNote[gmClap], []float64{6, 10})
+		if len(byNote[gmClosedHat]) != 32 {
+			t.Errorf("expected 32 hats over 2 bars, got %d", len(byNote[gmClosedHat]))
+		}
+	})
+
+	t.Run("invalid patterns", func(t *testing.T) {
+		for _, action := range []map[string]any{
+			{"type": "drum_pattern", "style": "polka"},
+			{"type": "drum_pattern", "style": "house", "length": 0.0},
+			{"type": "drum_pattern", "style": "house", "swing": 1.0},
+		} {
+			if _, err := ConvertArrangerActionToNoteEvents(action, 0.0); err == nil {
+				t.Errorf("expected error for %v", action)
+			}
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/golden"
+)
+
+// FuzzArrangerParseDSL checks that malformed arranger DSL is rejected with an error rather
+// than a panic. Run with go test ./internal/agents/shared/arranger -fuzz FuzzArrangerParseDSL.
+func FuzzArrangerParseDSL(f *testing.F) {
+	cases, err := golden.Load("testdata/golden")
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, c := range cases {
+		f.Add(c.DSL)
+	}
+	for _, seed := range []string{
+		`progression(chords=[C:3, Am:4, F, G], octave=5)`,
+		`arpeggio(symbol=Em, note_duration=0.25); humanize(timing_ms=15, velocity=6, seed=3)`,
+		`section(name="Intro", bars=4, tracks=[{track=0, chord=C, arpeggio=C}])`,
+		`progression(chords=[C, Am, F, G`,
+		`note(pitch="H#9", duration=-1)`,
+		`chord(symbol=, length=2)`,
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, dsl string) {
+		parser, err := NewArrangerDSLParser()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = parser.ParseDSL(dsl)
+	})
+}
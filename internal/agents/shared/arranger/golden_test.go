@@ -0,0 +1,19 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/golden"
+)
+
+// TestArrangerDSLParser_Golden runs testdata/golden through the parser.
+// go test ./internal/agents/shared/arranger -run Golden -update rewrites the expected actions.
+func TestArrangerDSLParser_Golden(t *testing.T) {
+	golden.Run(t, "testdata/golden", func(dsl string, _ map[string]any) ([]map[string]any, error) {
+		parser, err := NewArrangerDSLParser()
+		if err != nil {
+			return nil, err
+		}
+		return parser.ParseDSL(dsl)
+	})
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+)
+
+const (
+	defaultGrooveAmount      = 1.0
+	defaultGrooveExtractGrid = 0.25 // 16th notes, like quantize
+)
+
+// GrooveTemplate is a timing and accent feel over a cycle of grid steps, starting on the beat
+type GrooveTemplate struct {
+	Grid    float64   // Beats per step
+	Timing  []float64 // Per step, how far notes move in fractions of Grid (0.33 = triplet swing)
+	Accents []float64 // Per step, velocity multipliers
+}
+
+// grooveTemplates are the named grooves. swing and shuffle take their feel from the rhythm
+// templates of the same name, measured against straight 8ths.
+var grooveTemplates = map[string]GrooveTemplate{
+	"swing":   grooveFromRhythm(rhythmTemplates["swing"], 0.5),
+	"shuffle": grooveFromRhythm(rhythmTemplates["shuffle"], 0.5),
+	"swing16": {
+		Grid:    0.25,
+		Timing:  []float64{0, 1.0 / 3},
+		Accents: []float64{1.0, 0.8},
+	},
+}
+
+// GrooveTemplateNames returns the named grooves, sorted
+func GrooveTemplateNames() []string {
+	names := make([]string, 0, len(grooveTemplates))
+	for name := range grooveTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// grooveFromRhythm turns a rhythm template into a groove: each offset's distance from the
+// straight grid step it replaces, and its accent
+func grooveFromRhythm(tmpl RhythmTemplate, grid float64) GrooveTemplate {
+	groove := GrooveTemplate{
+		Grid:    grid,
+		Timing:  make([]float64, len(tmpl.Offsets)),
+		Accents: append([]float64(nil), tmpl.Accents...),
+	}
+	for i, offset := range tmpl.Offsets {
+		groove.Timing[i] = (offset - float64(i)*grid) / grid
+	}
+	return groove
+}
+
+// grooveNotes moves each note by the groove step nearest its start and scales its velocity by
+// the step's accent, both by amount (0-1, 1 = the template's full feel). Durations are kept.
+// The template is named, or given in the action as grid, timing and accents (see ResolveGrooves).
+// Example: groove(template="swing", amount=0.6)
+func grooveNotes(noteEvents []models.NoteEvent, action map[string]any) ([]models.NoteEvent, error) {
+	amount, _ := getFloat(action, "amount", defaultGrooveAmount)
+	if amount < 0 || amount > 1 {
+		return nil, fmt.Errorf("groove amount must be between 0 and 1, got %g", amount)
+	}
+	groove, name, err := actionGroove(action)
+	if err != nil {
+		return nil, err
+	}
+
+	grooved := make([]models.NoteEvent, len(noteEvents))
+	for i, note := range noteEvents {
+		step := math.Round(note.StartBeats / groove.Grid)
+		index := int(step) % len(groove.Timing)
+		if index < 0 {
+			index += len(groove.Timing)
+		}
+		note.StartBeats = math.Max(0, note.StartBeats+amount*groove.Timing[index]*groove.Grid)
+		if index < len(groove.Accents) {
+			accent := 1 + amount*(groove.Accents[index]-1)
+			note.Velocity = max(minNoteVelocity, min(maxNoteVelocity, int(math.Round(float64(note.Velocity)*accent))))
+		}
+		grooved[i] = note
+	}
+
+	log.Printf("🥁 Groove: %d notes with %s at %.0f%%", len(grooved), name, amount*100)
+	return grooved, nil
+}
+
+// actionGroove returns the groove a groove action names or carries, and a name for logs
+func actionGroove(action map[string]any) (GrooveTemplate, string, error) {
+	if timing, ok := getFloatSlice(action, "timing"); ok {
+		grid, _ := getFloat(action, "grid", defaultGrooveExtractGrid)
+		accents, _ := getFloatSlice(action, "accents")
+		if grid <= 0 || len(timing) == 0 {
+			return GrooveTemplate{}, "", fmt.Errorf("groove needs a positive grid and at least one timing step")
+		}
+		name := "an extracted groove"
+		if track, ok := getInt(action, "from_track", 0); ok {
+			name = fmt.Sprintf("the groove of track %d", track+1)
+		}
+		return GrooveTemplate{Grid: grid, Timing: timing, Accents: accents}, name, nil
+	}
+	if _, ok := action["from_track"]; ok {
+		return GrooveTemplate{}, "", fmt.Errorf("groove from_track needs the project state; see ResolveGrooves")
+	}
+	name, _ := getString(action, "template", "")
+	groove, ok := grooveTemplates[name]
+	if !ok {
+		return GrooveTemplate{}, "", fmt.Errorf("unknown groove template %q (available: %s)", name, strings.Join(GrooveTemplateNames(), ", "))
+	}
+	return groove, name, nil
+}
+
+// ExtractGroove measures the feel of notes on a grid, over a cycle of one bar: each step's
+// average distance from the grid (in fractions of grid) and average velocity relative to all
+// the notes. Steps without notes keep straight timing and a neutral accent. ok is false
+// without notes.
+func ExtractGroove(noteEvents []models.NoteEvent, grid, beatsPerBar float64) (GrooveTemplate, bool) {
+	if len(noteEvents) == 0 || grid <= 0 {
+		return GrooveTemplate{}, false
+	}
+	steps := max(1, int(math.Round(beatsPerBar/grid)))
+
+	deviations := make([]float64, steps)
+	velocities := make([]float64, steps)
+	counts := make([]int, steps)
+	totalVelocity := 0.0
+	for _, note := range noteEvents {
+		step := math.Round(note.StartBeats / grid)
+		index := int(step) % steps
+		if index < 0 {
+			index += steps
+		}
+		deviations[index] += (note.StartBeats - step*grid) / grid
+		velocities[index] += float64(note.Velocity)
+		counts[index]++
+		totalVelocity += float64(note.Velocity)
+	}
+	meanVelocity := totalVelocity / float64(len(noteEvents))
+
+	groove := GrooveTemplate{Grid: grid, Timing: make([]float64, steps), Accents: make([]float64, steps)}
+	for i := range steps {
+		groove.Accents[i] = 1
+		if counts[i] == 0 {
+			continue
+		}
+		groove.Timing[i] = roundTo(deviations[i]/float64(counts[i]), 3)
+		if meanVelocity > 0 {
+			groove.Accents[i] = roundTo(velocities[i]/float64(counts[i])/meanVelocity, 3)
+		}
+	}
+	return groove, true
+}
+
+// ResolveGrooves returns actions with the groove of each groove(from_track=N) action extracted
+// from the notes of the clips on that 0-based track in state and added as grid, timing and
+// accents, ready for ApplyNoteTransforms. The bar follows the project's time signature.
+// Example: groove(from_track=2, grid=0.5) takes the feel of track 3's drums
+func ResolveGrooves(actions []map[string]any, state map[string]any) ([]map[string]any, error) {
+	resolved := make([]map[string]any, len(actions))
+	for i, action := range actions {
+		resolved[i] = action
+		trackIndex, ok := getInt(action, "from_track", 0)
+		if action["type"] != "groove" || !ok {
+			continue
+		}
+		if _, hasTiming := action["timing"]; hasTiming {
+			continue
+		}
+
+		grid, _ := getFloat(action, "grid", defaultGrooveExtractGrid)
+		groove, found := ExtractGroove(trackNoteEvents(state, trackIndex), grid, timeutil.FromState(state).BeatsPerBar())
+		if !found {
+			return nil, fmt.Errorf("groove from_track: track %d has no notes to take a groove from", trackIndex+1)
+		}
+
+		withGroove := make(map[string]any, len(action)+3)
+		for k, v := range action {
+			withGroove[k] = v
+		}
+		withGroove["grid"] = groove.Grid
+		withGroove["timing"] = groove.Timing
+		withGroove["accents"] = groove.Accents
+		resolved[i] = withGroove
+	}
+	return resolved, nil
+}
+
+// trackNoteEvents returns the notes of every clip on a 0-based track in state, each relative
+// to its clip's start
+func trackNoteEvents(state map[string]any, trackIndex int) []models.NoteEvent {
+	var noteEvents []models.NoteEvent
+	for i, track := range anyMaps(projectstate.Unwrap(state)["tracks"]) {
+		index, ok := getInt(track, "index", i)
+		if !ok {
+			index = i
+		}
+		if index != trackIndex {
+			continue
+		}
+		for _, clip := range anyMaps(track["clips"]) {
+			noteEvents = append(noteEvents, ClipNoteEvents(clip)...)
+		}
+	}
+	return noteEvents
+}
+
+func getFloatSlice(m map[string]any, key string) ([]float64, bool) {
+	switch val := m[key].(type) {
+	case []float64:
+		return val, true
+	case []any:
+		result := make([]float64, 0, len(val))
+		for _, item := range val {
+			switch n := item.(type) {
+			case float64:
+				result = append(result, n)
+			case int:
+				result = append(result, float64(n))
+			default:
+				return nil, false
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func roundTo(value float64, decimals int) float64 {
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(value*scale) / scale
+}
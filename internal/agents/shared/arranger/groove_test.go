@@ -0,0 +1,179 @@
+package services
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// straightEighths is a bar of 8th notes at velocity 100
+func straightEighths() []models.NoteEvent {
+	notes := make([]models.NoteEvent, 8)
+	for i := range notes {
+		notes[i] = models.NoteEvent{MidiNoteNumber: 42, Velocity: 100, StartBeats: float64(i) * 0.5, DurationBeats: 0.25}
+	}
+	return notes
+}
+
+func TestApplyNoteTransforms_Groove(t *testing.T) {
+	tests := []struct {
+		name           string
+		action         map[string]any
+		wantStarts     []float64 // First four notes
+		wantVelocities []int
+	}{
+		{
+			name:           "full swing delays off-beat 8ths by a triplet",
+			action:         map[string]any{"type": "groove", "template": "swing", "amount": 1.0},
+			wantStarts:     []float64{0, 0.67, 1, 1.67},
+			wantVelocities: []int{100, 70, 90, 70},
+		},
+		{
+			name:           "half swing goes halfway",
+			action:         map[string]any{"type": "groove", "template": "swing", "amount": 0.5},
+			wantStarts:     []float64{0, 0.585, 1, 1.585},
+			wantVelocities: []int{100, 85, 95, 85},
+		},
+		{
+			name:           "no amount leaves the notes",
+			action:         map[string]any{"type": "groove", "template": "shuffle", "amount": 0.0},
+			wantStarts:     []float64{0, 0.5, 1, 1.5},
+			wantVelocities: []int{100, 100, 100, 100},
+		},
+		{
+			name: "carried template",
+			action: map[string]any{
+				"type": "groove", "amount": 1.0, "grid": 0.5,
+				"timing": []any{0.0, 0.2}, "accents": []any{1.1, 0.5},
+			},
+			wantStarts:     []float64{0, 0.6, 1, 1.6},
+			wantVelocities: []int{110, 50, 110, 50},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notes := straightEighths()
+			got, err := ApplyNoteTransforms(notes, []map[string]any{tt.action}, 120)
+			if err != nil {
+				t.Fatalf("ApplyNoteTransforms failed: %v", err)
+			}
+			for i := range tt.wantStarts {
+				if math.Abs(got[i].StartBeats-tt.wantStarts[i]) > 1e-9 {
+					t.Errorf("note %d start = %g, want %g", i, got[i].StartBeats, tt.wantStarts[i])
+				}
+				if got[i].Velocity != tt.wantVelocities[i] {
+					t.Errorf("note %d velocity = %d, want %d", i, got[i].Velocity, tt.wantVelocities[i])
+				}
+				if got[i].DurationBeats != 0.25 {
+					t.Errorf("note %d duration changed: %g", i, got[i].DurationBeats)
+				}
+			}
+			if notes[1].StartBeats != 0.5 {
+				t.Errorf("ApplyNoteTransforms modified its input: %+v", notes[1])
+			}
+		})
+	}
+}
+
+func TestApplyNoteTransforms_GrooveErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  map[string]any
+		wantErr string
+	}{
+		{name: "unknown template", action: map[string]any{"type": "groove", "template": "polka"}, wantErr: "unknown groove template"},
+		{name: "amount above 1", action: map[string]any{"type": "groove", "template": "swing", "amount": 1.5}, wantErr: "between 0 and 1"},
+		{name: "unresolved track", action: map[string]any{"type": "groove", "from_track": 1}, wantErr: "ResolveGrooves"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyNoteTransforms(straightEighths(), []map[string]any{tt.action}, 120)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ApplyNoteTransforms() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractGroove(t *testing.T) {
+	// Swung 8ths with accented downbeats, over two bars
+	var notes []models.NoteEvent
+	for bar := 0; bar < 2; bar++ {
+		for beat := 0; beat < 4; beat++ {
+			start := float64(bar*4 + beat)
+			notes = append(notes,
+				models.NoteEvent{Velocity: 120, StartBeats: start},
+				models.NoteEvent{Velocity: 60, StartBeats: start + 0.65},
+			)
+		}
+	}
+
+	groove, ok := ExtractGroove(notes, 0.5, 4)
+	if !ok {
+		t.Fatal("ExtractGroove found no groove")
+	}
+	if groove.Grid != 0.5 || len(groove.Timing) != 8 || len(groove.Accents) != 8 {
+		t.Fatalf("ExtractGroove() = %+v, want 8 steps of 0.5 beats", groove)
+	}
+	for i := range groove.Timing {
+		wantTiming, wantAccent := 0.0, 4.0/3
+		if i%2 == 1 {
+			wantTiming, wantAccent = 0.3, 2.0/3
+		}
+		if math.Abs(groove.Timing[i]-wantTiming) > 1e-3 || math.Abs(groove.Accents[i]-wantAccent) > 1e-3 {
+			t.Errorf("step %d = %g/%g, want %g/%g", i, groove.Timing[i], groove.Accents[i], wantTiming, wantAccent)
+		}
+	}
+
+	if _, ok := ExtractGroove(nil, 0.5, 4); ok {
+		t.Error("ExtractGroove of no notes should not find a groove")
+	}
+}
+
+func TestResolveGrooves(t *testing.T) {
+	state := map[string]any{
+		"project": map[string]any{"bpm": 120.0, "time_signature": "3/4"},
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Keys"},
+			map[string]any{"index": 1, "name": "Drums", "clips": []any{
+				map[string]any{"notes": []any{
+					map[string]any{"pitch": 42, "velocity": 100, "start": 0.0, "length": 0.25},
+					map[string]any{"pitch": 42, "velocity": 100, "start": 0.6, "length": 0.25},
+				}},
+			}},
+		},
+	}
+	actions := []map[string]any{
+		{"type": "drum_pattern", "style": "house"},
+		{"type": "groove", "from_track": 1, "grid": 0.5, "amount": 1.0},
+	}
+
+	resolved, err := ResolveGrooves(actions, state)
+	if err != nil {
+		t.Fatalf("ResolveGrooves failed: %v", err)
+	}
+	if _, ok := actions[1]["timing"]; ok {
+		t.Error("ResolveGrooves modified its input")
+	}
+	timing, _ := resolved[1]["timing"].([]float64)
+	if len(timing) != 6 || math.Abs(timing[1]-0.2) > 1e-9 {
+		t.Errorf("timing = %v, want 6 steps (a bar of 3/4) with the off-beat 0.2 late", timing)
+	}
+
+	got, err := ApplyNoteTransforms(straightEighths(), resolved, 120)
+	if err != nil {
+		t.Fatalf("ApplyNoteTransforms failed: %v", err)
+	}
+	if math.Abs(got[1].StartBeats-0.6) > 1e-9 {
+		t.Errorf("off-beat start = %g, want 0.6", got[1].StartBeats)
+	}
+
+	_, err = ResolveGrooves([]map[string]any{{"type": "groove", "from_track": 0}}, state)
+	if err == nil || !strings.Contains(err.Error(), "track 1 has no notes") {
+		t.Errorf("ResolveGrooves() error = %v, want an empty-track error", err)
+	}
+}
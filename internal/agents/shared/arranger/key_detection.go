@@ -0,0 +1,434 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
+)
+
+// WarningNonDiatonicChord is the warning code for an arranger chord with notes outside the
+// project key. ActionIndex is the index of the arranger action holding the chord.
+const WarningNonDiatonicChord = "non_diatonic_chord"
+
+// DetectKey needs at least this many notes, over this many pitch classes, to guess a key
+const (
+	minKeyDetectionNotes        = 4
+	minKeyDetectionPitchClasses = 3
+)
+
+// Krumhansl-Kessler key profiles: how strongly each scale degree (from the tonic) implies a key
+var (
+	majorKeyProfile = [12]float64{6.35, 2.23, 3.48, 2.33, 4.38, 4.09, 2.52, 5.19, 2.39, 3.66, 2.29, 2.88}
+	minorKeyProfile = [12]float64{6.33, 2.68, 3.52, 5.38, 2.60, 3.53, 2.54, 4.75, 3.98, 2.69, 3.34, 3.17}
+)
+
+var (
+	majorScale        = []int{0, 2, 4, 5, 7, 9, 11}
+	naturalMinorScale = []int{0, 2, 3, 5, 7, 8, 10}
+)
+
+// KeyEstimate is the likely key of a set of notes
+type KeyEstimate struct {
+	Name       string   `json:"name"`  // e.g. "A minor"
+	Tonic      string   `json:"tonic"` // e.g. "A"
+	Mode       string   `json:"mode"`  // "major" or "minor"
+	Confidence float64  `json:"confidence"`
+	NoteCount  int      `json:"noteCount"`
+	Scale      []string `json:"scale"`
+	tonicPC    int
+}
+
+// NonDiatonicChord is a chord with notes outside a key, and a diatonic chord to use instead
+type NonDiatonicChord struct {
+	Index      int      `json:"index"`
+	Chord      string   `json:"chord"`
+	OutOfKey   []string `json:"outOfKey"`
+	Suggestion string   `json:"suggestion"`
+}
+
+// DetectKey estimates the key of notes by correlating their duration-weighted pitch classes
+// with the Krumhansl-Kessler major and minor profiles for all 24 keys. Confidence is the best
+// correlation (0-1). ok is false when there are too few notes or pitch classes to tell.
+// Example: the notes of an Am - F - C - G loop → A minor
+func DetectKey(noteEvents []models.NoteEvent) (KeyEstimate, bool) {
+	if len(noteEvents) < minKeyDetectionNotes {
+		return KeyEstimate{}, false
+	}
+
+	var weights [12]float64
+	pitchClasses := 0
+	for _, note := range noteEvents {
+		duration := note.DurationBeats
+		if duration <= 0 {
+			duration = 0.25
+		}
+		pc := ((note.MidiNoteNumber % 12) + 12) % 12
+		if weights[pc] == 0 {
+			pitchClasses++
+		}
+		weights[pc] += duration
+	}
+	if pitchClasses < minKeyDetectionPitchClasses {
+		return KeyEstimate{}, false
+	}
+
+	bestTonic, bestMode, bestScore := 0, "", math.Inf(-1)
+	for _, mode := range []string{"major", "minor"} {
+		profile := majorKeyProfile
+		if mode == "minor" {
+			profile = minorKeyProfile
+		}
+		for tonic := 0; tonic < 12; tonic++ {
+			var rotated [12]float64
+			for degree := range rotated {
+				rotated[(tonic+degree)%12] = profile[degree]
+			}
+			if score := correlation(weights, rotated); score > bestScore {
+				bestTonic, bestMode, bestScore = tonic, mode, score
+			}
+		}
+	}
+	key := newKeyEstimate(bestTonic, bestMode)
+	key.Confidence = math.Round(math.Max(0, bestScore)*100) / 100
+	key.NoteCount = len(noteEvents)
+	return key, true
+}
+
+// DetectKeyFromState estimates the project key from the notes of every clip in state
+// (tracks[].clips[].notes, in the add_midi note format)
+func DetectKeyFromState(state map[string]any) (KeyEstimate, bool) {
+	stateMap, ok := state["state"].(map[string]any)
+	if !ok {
+		stateMap = state
+	}
+
+	var noteEvents []models.NoteEvent
+	for _, track := range anyMaps(stateMap["tracks"]) {
+		for _, clip := range anyMaps(track["clips"]) {
+			noteEvents = append(noteEvents, ClipNoteEvents(clip)...)
+		}
+	}
+	return DetectKey(noteEvents)
+}
+
+// ParseKey parses a key name like "A minor", "F# major", "Bbm", or "C"
+func ParseKey(name string) (KeyEstimate, error) {
+	name = strings.TrimSpace(name)
+	tonic, err := music.ParseRootNote(name)
+	if err != nil {
+		return KeyEstimate{}, fmt.Errorf("invalid key %q: %w", name, err)
+	}
+
+	mode := ""
+	switch strings.ToLower(strings.TrimSpace(name[len(tonic):])) {
+	case "", "major", "maj":
+		mode = "major"
+	case "minor", "min", "m":
+		mode = "minor"
+	default:
+		return KeyEstimate{}, fmt.Errorf("invalid key %q: mode must be major or minor", name)
+	}
+	return newKeyEstimate((noteToMIDI(tonic, 0)+12)%12, mode), nil
+}
+
+// CheckProgression returns the chords that have notes outside key. In minor keys the raised
+// 7th (harmonic minor) counts as diatonic, so E7 in A minor passes. Unparseable chords are skipped.
+func CheckProgression(chords []string, key KeyEstimate) []NonDiatonicChord {
+	allowed := key.allowedPitchClasses()
+
+	var nonDiatonic []NonDiatonicChord
+	for i, chord := range chords {
+		spec, err := music.ParseChord(chord)
+		if err != nil {
+			continue
+		}
+
+		var outOfKey []string
+		for _, pc := range spec.PitchClasses() {
+			if !allowed[pc] {
+				outOfKey = append(outOfKey, key.chordNoteName(pc, spec.Root))
+			}
+		}
+		if len(outOfKey) == 0 {
+			continue
+		}
+		nonDiatonic = append(nonDiatonic, NonDiatonicChord{
+			Index:      i,
+			Chord:      chord,
+			OutOfKey:   outOfKey,
+			Suggestion: key.diatonicSubstitute(spec),
+		})
+	}
+	return nonDiatonic
+}
+
+// CorrectProgression returns chords with each non-diatonic chord replaced by its suggestion
+func CorrectProgression(chords []string, key KeyEstimate) []string {
+	corrected := append([]string(nil), chords...)
+	for _, chord := range CheckProgression(chords, key) {
+		corrected[chord.Index] = chord.Suggestion
+	}
+	return corrected
+}
+
+// KeyChordWarnings flags the chords of arranger actions (chord, arpeggio, progression,
+// walking_bass, and section parts) that fall outside key
+func KeyChordWarnings(actions []map[string]any, key KeyEstimate) []models.ActionWarning {
+	var warnings []models.ActionWarning
+	for i, action := range actions {
+		for _, chord := range CheckProgression(actionChords(action), key) {
+			warnings = append(warnings, models.ActionWarning{
+				Code: WarningNonDiatonicChord,
+				Message: fmt.Sprintf("%s chord %s has %s, outside %s; %s is diatonic",
+					action["type"], chord.Chord, strings.Join(chord.OutOfKey, ", "), key.Name, chord.Suggestion),
+				ActionIndex: i,
+			})
+		}
+	}
+	return warnings
+}
+
+type projectKeyKey struct{}
+
+// WithProjectKey returns a context carrying the project's detected key for the arranger prompt
+func WithProjectKey(ctx context.Context, key KeyEstimate) context.Context {
+	return context.WithValue(ctx, projectKeyKey{}, key)
+}
+
+// ProjectKeyFromContext returns the project key set by WithProjectKey, if any
+func ProjectKeyFromContext(ctx context.Context) (KeyEstimate, bool) {
+	key, ok := ctx.Value(projectKeyKey{}).(KeyEstimate)
+	return key, ok
+}
+
+// newKeyEstimate names a key and its scale
+func newKeyEstimate(tonic int, mode string) KeyEstimate {
+	key := KeyEstimate{Mode: mode, tonicPC: tonic}
+	key.Tonic = key.noteName(tonic)
+	key.Name = key.Tonic + " " + mode
+	for _, degree := range key.scale() {
+		key.Scale = append(key.Scale, key.noteName((tonic+degree)%12))
+	}
+	return key
+}
+
+func (k KeyEstimate) scale() []int {
+	if k.Mode == "minor" {
+		return naturalMinorScale
+	}
+	return majorScale
+}
+
+// allowedPitchClasses returns the key's scale, plus the raised 7th in minor
+func (k KeyEstimate) allowedPitchClasses() map[int]bool {
+	allowed := make(map[int]bool, 8)
+	for _, degree := range k.scale() {
+		allowed[(k.tonicPC+degree)%12] = true
+	}
+	if k.Mode == "minor" {
+		allowed[(k.tonicPC+11)%12] = true
+	}
+	return allowed
+}
+
+// noteName spells a pitch class with flats in flat keys (F, Bb, Eb, Ab, Db major and their
+// relative minors) and sharps otherwise
+func (k KeyEstimate) noteName(pc int) string {
+	relativeMajor := k.tonicPC
+	if k.Mode == "minor" {
+		relativeMajor = (k.tonicPC + 3) % 12
+	}
+	switch relativeMajor {
+	case 5, 10, 3, 8, 1: // F, Bb, Eb, Ab, Db
+		return music.PitchClassName(pc, true)
+	}
+	return music.PitchClassName(pc, false)
+}
+
+// chordNoteName spells a note of a chord the way its root is spelled (Bb chord: flats,
+// F# chord: sharps), falling back to the key's spelling for natural roots
+func (k KeyEstimate) chordNoteName(pc int, root string) string {
+	switch {
+	case strings.HasSuffix(root, "b"):
+		return music.PitchClassName(pc, true)
+	case strings.HasSuffix(root, "#"):
+		return music.PitchClassName(pc, false)
+	}
+	return k.noteName(pc)
+}
+
+// diatonicSubstitute returns the diatonic chord on the degree nearest the chord's root: the
+// root's own degree, or else the neighbouring degree sharing more notes with the chord.
+// A seventh chord gets a diatonic seventh chord; a slash bass is kept when it's in the key.
+func (k KeyEstimate) diatonicSubstitute(spec music.Chord) string {
+	rootPC := (noteToMIDI(spec.Root, 0) + 12) % 12
+	withSeventh := false
+	for _, interval := range spec.Intervals {
+		if interval == music.Minor7th || interval == music.Major7th {
+			withSeventh = true
+		}
+	}
+
+	candidates := []int{rootPC}
+	if k.degreeOf(rootPC) == -1 {
+		candidates = []int{(rootPC + 11) % 12, (rootPC + 1) % 12}
+	}
+	original := make(map[int]bool)
+	for _, pc := range spec.PitchClasses() {
+		original[pc] = true
+	}
+
+	best, bestShared := "", -1
+	for _, candidate := range candidates {
+		degree := k.degreeOf(candidate)
+		if degree == -1 {
+			continue
+		}
+		pcs := k.diatonicChordPitchClasses(degree, withSeventh)
+		shared := 0
+		for _, pc := range pcs {
+			if original[pc] {
+				shared++
+			}
+		}
+		if shared > bestShared {
+			best, bestShared = k.noteName(candidate)+chordQualityName(pcs), shared
+		}
+	}
+
+	if spec.Bass != "" {
+		bassPC := (noteToMIDI(spec.Bass, 0) + 12) % 12
+		if k.allowedPitchClasses()[bassPC] {
+			best += "/" + k.noteName(bassPC)
+		}
+	}
+	return best
+}
+
+// degreeOf returns the scale degree (0-6) of a pitch class, or -1 if it isn't in the scale
+func (k KeyEstimate) degreeOf(pc int) int {
+	for degree, interval := range k.scale() {
+		if (k.tonicPC+interval)%12 == pc {
+			return degree
+		}
+	}
+	return -1
+}
+
+// diatonicChordPitchClasses stacks thirds from a scale degree. In minor, V uses the raised 7th
+// (harmonic minor) like the chords CheckProgression accepts.
+func (k KeyEstimate) diatonicChordPitchClasses(degree int, withSeventh bool) []int {
+	scale := append([]int(nil), k.scale()...)
+	if k.Mode == "minor" && degree == 4 {
+		scale[6] = 11
+	}
+	count := 3
+	if withSeventh {
+		count = 4
+	}
+	pcs := make([]int, count)
+	for i := range pcs {
+		pcs[i] = (k.tonicPC + scale[(degree+2*i)%7]) % 12
+	}
+	return pcs
+}
+
+// chordQualityName names a stack of thirds (root first) as a chord suffix: "", m, dim, aug,
+// maj7, 7, m7, m7b5, dim7, mMaj7
+func chordQualityName(pcs []int) string {
+	interval := func(i int) int { return (pcs[i] - pcs[0] + 12) % 12 }
+	third, fifth := interval(1), interval(2)
+
+	triad := ""
+	switch {
+	case third == music.Minor3rd && fifth == music.Flat5th:
+		triad = "dim"
+	case third == music.Minor3rd:
+		triad = "m"
+	case fifth == music.Sharp5th:
+		triad = "aug"
+	}
+	if len(pcs) < 4 {
+		return triad
+	}
+
+	switch seventh := interval(3); {
+	case triad == "dim" && seventh == music.Minor7th:
+		return "m7b5"
+	case triad == "dim":
+		return "dim7"
+	case triad == "m" && seventh == music.Major7th:
+		return "mMaj7"
+	case triad == "m":
+		return "m7"
+	case seventh == music.Major7th:
+		return "maj7"
+	default:
+		return "7"
+	}
+}
+
+// actionChords returns the chord symbols an arranger action plays
+func actionChords(action map[string]any) []string {
+	switch action["type"] {
+	case "chord", "arpeggio":
+		if chord, ok := getString(action, "chord", ""); ok {
+			return []string{chord}
+		}
+	case "progression", "walking_bass":
+		if chords, ok := action["chords"].([]string); ok {
+			return chords
+		}
+	case "section":
+		var chords []string
+		if parts, ok := action["parts"].([]map[string]any); ok {
+			for _, part := range parts {
+				if partAction, ok := part["action"].(map[string]any); ok {
+					chords = append(chords, actionChords(partAction)...)
+				}
+			}
+		}
+		return chords
+	}
+	return nil
+}
+
+// correlation returns the Pearson correlation of two 12-bin profiles
+func correlation(a, b [12]float64) float64 {
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i] / 12
+		meanB += b[i] / 12
+	}
+	var cov, varA, varB float64
+	for i := range a {
+		cov += (a[i] - meanA) * (b[i] - meanB)
+		varA += (a[i] - meanA) * (a[i] - meanA)
+		varB += (b[i] - meanB) * (b[i] - meanB)
+	}
+	if varA == 0 || varB == 0 {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// anyMaps returns the maps in a state list, which may be []any or []map[string]any
+func anyMaps(value any) []map[string]any {
+	switch list := value.(type) {
+	case []map[string]any:
+		return list
+	case []any:
+		maps := make([]map[string]any, 0, len(list))
+		for _, item := range list {
+			if m, ok := item.(map[string]any); ok {
+				maps = append(maps, m)
+			}
+		}
+		return maps
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// melody builds quarter notes from MIDI pitches, with the given durations (default 1 beat)
+func melody(pitches []int, durations map[int]float64) []models.NoteEvent {
+	notes := make([]models.NoteEvent, len(pitches))
+	for i, pitch := range pitches {
+		duration := 1.0
+		if d, ok := durations[i]; ok {
+			duration = d
+		}
+		notes[i] = models.NoteEvent{MidiNoteNumber: pitch, Velocity: 100, StartBeats: float64(i), DurationBeats: duration}
+	}
+	return notes
+}
+
+func TestDetectKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		notes []models.NoteEvent
+		want  string
+	}{
+		{
+			name:  "C major scale landing on C",
+			notes: melody([]int{48, 50, 52, 53, 55, 57, 59, 60}, map[int]float64{0: 4, 7: 4}),
+			want:  "C major",
+		},
+		{
+			name: "A harmonic minor line",
+			// A B C D E F G# A, with long A and E
+			notes: melody([]int{57, 59, 60, 62, 64, 65, 68, 69}, map[int]float64{0: 4, 4: 2, 7: 4}),
+			want:  "A minor",
+		},
+		{
+			name: "G major triads",
+			// G B D, C E G, D F# A, G B D
+			notes: melody([]int{43, 47, 50, 48, 52, 55, 50, 54, 57, 43, 47, 50}, map[int]float64{0: 4, 9: 4}),
+			want:  "G major",
+		},
+		{
+			name: "D minor arpeggio",
+			// Dm, Gm, A7, Dm
+			notes: melody([]int{50, 53, 57, 55, 58, 62, 57, 61, 64, 67, 50, 53, 57}, map[int]float64{0: 4, 10: 4}),
+			want:  "D minor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := DetectKey(tt.notes)
+			if !ok {
+				t.Fatal("DetectKey found no key")
+			}
+			if key.Name != tt.want {
+				t.Errorf("DetectKey() = %s, want %s", key.Name, tt.want)
+			}
+			if key.Confidence <= 0 || key.Confidence > 1 {
+				t.Errorf("confidence = %g, want in (0, 1]", key.Confidence)
+			}
+			if key.NoteCount != len(tt.notes) {
+				t.Errorf("NoteCount = %d, want %d", key.NoteCount, len(tt.notes))
+			}
+		})
+	}
+
+	t.Run("too few notes", func(t *testing.T) {
+		if _, ok := DetectKey(melody([]int{48, 52, 55}, nil)); ok {
+			t.Error("DetectKey found a key from 3 notes")
+		}
+	})
+	t.Run("one pitch class", func(t *testing.T) {
+		if _, ok := DetectKey(melody([]int{48, 60, 48, 36}, nil)); ok {
+			t.Error("DetectKey found a key from a single pitch class")
+		}
+	})
+}
+
+func TestDetectKeyFromState(t *testing.T) {
+	notes := []any{}
+	for i, pitch := range []int{57, 60, 64, 57, 62, 65, 64, 68, 71, 57} {
+		notes = append(notes, map[string]any{"pitch": float64(pitch), "velocity": 100.0, "start": float64(i), "length": 1.0})
+	}
+	state := map[string]any{
+		"state": map[string]any{
+			"tracks": []any{
+				map[string]any{"index": 0.0, "clips": []any{}},
+				map[string]any{"index": 1.0, "clips": []any{map[string]any{"notes": notes}}},
+			},
+		},
+	}
+
+	key, ok := DetectKeyFromState(state)
+	if !ok {
+		t.Fatal("DetectKeyFromState found no key")
+	}
+	if key.Name != "A minor" {
+		t.Errorf("DetectKeyFromState() = %s, want A minor", key.Name)
+	}
+
+	if _, ok := DetectKeyFromState(map[string]any{"tracks": []any{}}); ok {
+		t.Error("DetectKeyFromState found a key in a project without notes")
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		wantName  string
+		wantScale []string
+	}{
+		{"C", "C major", []string{"C", "D", "E", "F", "G", "A", "B"}},
+		{"A minor", "A minor", []string{"A", "B", "C", "D", "E", "F", "G"}},
+		{"Bbm", "Bb minor", []string{"Bb", "C", "Db", "Eb", "F", "Gb", "Ab"}},
+		{"F# major", "F# major", []string{"F#", "G#", "A#", "B", "C#", "D#", "F"}},
+		{"Eb maj", "Eb major", []string{"Eb", "F", "G", "Ab", "Bb", "C", "D"}},
+		{"E min", "E minor", []string{"E", "F#", "G", "A", "B", "C", "D"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseKey(tt.name)
+			if err != nil {
+				t.Fatalf("ParseKey failed: %v", err)
+			}
+			if key.Name != tt.wantName || !reflect.DeepEqual(key.Scale, tt.wantScale) {
+				t.Errorf("ParseKey(%q) = %s %v, want %s %v", tt.name, key.Name, key.Scale, tt.wantName, tt.wantScale)
+			}
+		})
+	}
+
+	for _, name := range []string{"", "H major", "C dorian"} {
+		if _, err := ParseKey(name); err == nil {
+			t.Errorf("ParseKey(%q) succeeded, want error", name)
+		}
+	}
+}
+
+func TestCheckProgression(t *testing.T) {
+	cMajor, _ := ParseKey("C major")
+	aMinor, _ := ParseKey("A minor")
+
+	tests := []struct {
+		name   string
+		chords []string
+		key    KeyEstimate
+		want   []NonDiatonicChord
+	}{
+		{
+			name:   "diatonic pop progression",
+			chords: []string{"C", "Am", "F", "G7", "Em7", "Bm7b5"},
+			key:    cMajor,
+		},
+		{
+			name:   "secondary dominant and borrowed chord",
+			chords: []string{"C", "D", "F", "Bb"},
+			key:    cMajor,
+			want: []NonDiatonicChord{
+				{Index: 1, Chord: "D", OutOfKey: []string{"F#"}, Suggestion: "Dm"},
+				{Index: 3, Chord: "Bb", OutOfKey: []string{"Bb"}, Suggestion: "Bdim"},
+			},
+		},
+		{
+			name:   "sevenths get diatonic sevenths",
+			chords: []string{"D7", "Ebmaj7"},
+			key:    cMajor,
+			want: []NonDiatonicChord{
+				{Index: 0, Chord: "D7", OutOfKey: []string{"F#"}, Suggestion: "Dm7"},
+				{Index: 1, Chord: "Ebmaj7", OutOfKey: []string{"Eb", "Bb"}, Suggestion: "Em7"},
+			},
+		},
+		{
+			name:   "slash bass outside the key is dropped",
+			chords: []string{"C/G", "D/F#", "F/A"},
+			key:    cMajor,
+			want: []NonDiatonicChord{
+				{Index: 1, Chord: "D/F#", OutOfKey: []string{"F#"}, Suggestion: "Dm"},
+			},
+		},
+		{
+			name:   "harmonic minor dominant is diatonic in minor",
+			chords: []string{"Am", "Dm", "E7", "G#dim", "F", "G"},
+			key:    aMinor,
+		},
+		{
+			name:   "major IV in minor",
+			chords: []string{"Am", "D", "E"},
+			key:    aMinor,
+			want: []NonDiatonicChord{
+				{Index: 1, Chord: "D", OutOfKey: []string{"F#"}, Suggestion: "Dm"},
+			},
+		},
+		{
+			name:   "unparseable chords are skipped",
+			chords: []string{"C", "Hm"},
+			key:    cMajor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckProgression(tt.chords, tt.key)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckProgression() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrectProgression(t *testing.T) {
+	key, _ := ParseKey("G major")
+	chords := []string{"G", "E", "C", "D7", "F"}
+
+	got := CorrectProgression(chords, key)
+	want := []string{"G", "Em", "C", "D7", "F#dim"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CorrectProgression() = %v, want %v", got, want)
+	}
+	if chords[1] != "E" {
+		t.Error("CorrectProgression modified its input")
+	}
+}
+
+func TestKeyChordWarnings(t *testing.T) {
+	key, _ := ParseKey("C major")
+	actions := []map[string]any{
+		{"type": "progression", "chords": []string{"C", "D", "G"}},
+		{"type": "drum_pattern", "style": "house"},
+		{"type": "section", "parts": []map[string]any{
+			{"track": 0, "action": map[string]any{"type": "arpeggio", "chord": "Am"}},
+			{"track": 1, "action": map[string]any{"type": "walking_bass", "chords": []string{"Dm7", "Db7"}}},
+		}},
+	}
+
+	warnings := KeyChordWarnings(actions, key)
+	want := []models.ActionWarning{
+		{Code: WarningNonDiatonicChord, Message: "progression chord D has F#, outside C major; Dm is diatonic", ActionIndex: 0},
+		{Code: WarningNonDiatonicChord, Message: "section chord Db7 has Db, Ab, outside C major; Cmaj7 is diatonic", ActionIndex: 2},
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("KeyChordWarnings() = %+v, want %+v", warnings, want)
+	}
+}
+
+func TestProjectKeyContext(t *testing.T) {
+	if _, ok := ProjectKeyFromContext(context.Background()); ok {
+		t.Error("ProjectKeyFromContext found a key in an empty context")
+	}
+
+	key, _ := ParseKey("E minor")
+	got, ok := ProjectKeyFromContext(WithProjectKey(context.Background(), key))
+	if !ok || got.Name != "E minor" {
+		t.Errorf("ProjectKeyFromContext() = %v, %v, want E minor", got.Name, ok)
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// Built-in values for parts that don't give their own, unless the session's defaults change them
+const (
+	defaultOctave      = 4
+	defaultVelocity    = 100
+	defaultClipBars    = 1
+	defaultBeatsPerBar = 4.0
+	minDefaultOctave   = 1
+	maxDefaultOctave   = 8
+	maxTimeSignature   = 32 // Largest numerator and beat unit of a default time signature
+)
+
+// partDefaults are the values a parser call falls back on, resolved from MusicalDefaults
+type partDefaults struct {
+	octave      int
+	velocity    int
+	clipBars    int
+	beatsPerBar float64
+}
+
+// resolveMusicalDefaults fills the unset fields of defaults with the built-in values.
+// defaults must be valid.
+func resolveMusicalDefaults(defaults models.MusicalDefaults) partDefaults {
+	resolved := partDefaults{
+		octave:      defaultOctave,
+		velocity:    defaultVelocity,
+		clipBars:    defaultClipBars,
+		beatsPerBar: defaultBeatsPerBar,
+	}
+	if defaults.Octave != 0 {
+		resolved.octave = defaults.Octave
+	}
+	if defaults.Velocity != 0 {
+		resolved.velocity = defaults.Velocity
+	}
+	if defaults.ClipBars != 0 {
+		resolved.clipBars = defaults.ClipBars
+	}
+	if beatsPerBar, _, err := parseTimeSignature(defaults.TimeSignature); err == nil {
+		resolved.beatsPerBar = beatsPerBar
+	}
+	return resolved
+}
+
+// clipBeats is the length in beats of a part that doesn't give one
+func (d partDefaults) clipBeats() float64 {
+	return float64(d.clipBars) * d.beatsPerBar
+}
+
+// bassOctave moves the walking bass register by as much as the default octave moved, so
+// "everything an octave lower" takes the bass down too
+func (d partDefaults) bassOctave() int {
+	return defaultWalkingBassOctave + d.octave - defaultOctave
+}
+
+// ValidateMusicalDefaults checks the set fields of defaults
+func ValidateMusicalDefaults(defaults models.MusicalDefaults) error {
+	if defaults.Octave != 0 && (defaults.Octave < minDefaultOctave || defaults.Octave > maxDefaultOctave) {
+		return fmt.Errorf("default octave must be from %d to %d, got %d", minDefaultOctave, maxDefaultOctave, defaults.Octave)
+	}
+	if defaults.Velocity != 0 && (defaults.Velocity < minNoteVelocity || defaults.Velocity > maxNoteVelocity) {
+		return fmt.Errorf("default velocity must be from %d to %d, got %d", minNoteVelocity, maxNoteVelocity, defaults.Velocity)
+	}
+	if defaults.ClipBars != 0 && (defaults.ClipBars < 1 || defaults.ClipBars > maxSectionBars) {
+		return fmt.Errorf("default clip_bars must be from 1 to %d, got %d", maxSectionBars, defaults.ClipBars)
+	}
+	if defaults.TimeSignature != "" {
+		if _, _, err := parseTimeSignature(defaults.TimeSignature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeMusicalDefaults returns base with the set fields of override replacing its own
+func MergeMusicalDefaults(base, override models.MusicalDefaults) models.MusicalDefaults {
+	if override.Octave != 0 {
+		base.Octave = override.Octave
+	}
+	if override.Velocity != 0 {
+		base.Velocity = override.Velocity
+	}
+	if override.ClipBars != 0 {
+		base.ClipBars = override.ClipBars
+	}
+	if override.TimeSignature != "" {
+		base.TimeSignature = override.TimeSignature
+	}
+	return base
+}
+
+// parseTimeSignature reads a time signature like "6/8" into beats per bar and the beat unit
+func parseTimeSignature(signature string) (beatsPerBar float64, beatUnit int, err error) {
+	numerator, denominator, found := strings.Cut(strings.TrimSpace(signature), "/")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid time signature %q: want beats/unit like 3/4", signature)
+	}
+	beatsPerBar, err = strconv.ParseFloat(strings.TrimSpace(numerator), 64)
+	if err != nil || beatsPerBar <= 0 || beatsPerBar > maxTimeSignature {
+		return 0, 0, fmt.Errorf("invalid time signature %q: beats per bar must be above 0 and at most %d", signature, maxTimeSignature)
+	}
+	beatUnit, err = strconv.Atoi(strings.TrimSpace(denominator))
+	if err != nil || beatUnit <= 0 || beatUnit > maxTimeSignature || beatUnit&(beatUnit-1) != 0 {
+		return 0, 0, fmt.Errorf("invalid time signature %q: the beat unit must be 1, 2, 4, 8, 16 or 32", signature)
+	}
+	return beatsPerBar, beatUnit, nil
+}
+
+// defaultsFromAction reads the fields a set_defaults action sets
+func defaultsFromAction(action map[string]any) models.MusicalDefaults {
+	var defaults models.MusicalDefaults
+	defaults.Octave, _ = getInt(action, "octave", 0)
+	defaults.Velocity, _ = getInt(action, "velocity", 0)
+	defaults.ClipBars, _ = getInt(action, "clip_bars", 0)
+	defaults.TimeSignature, _ = getString(action, "time_signature", "")
+	return defaults
+}
+
+// ActionsDefaults returns the defaults the actions' defaults() calls set, merged in order. ok
+// is false when there are none.
+func ActionsDefaults(actions []map[string]any) (defaults models.MusicalDefaults, ok bool) {
+	for _, action := range actions {
+		if action["type"] == "set_defaults" {
+			defaults = MergeMusicalDefaults(defaults, defaultsFromAction(action))
+			ok = true
+		}
+	}
+	return defaults, ok
+}
+
+type musicalDefaultsKey struct{}
+
+// WithMusicalDefaults returns a context carrying the session's musical defaults for the arranger
+func WithMusicalDefaults(ctx context.Context, defaults models.MusicalDefaults) context.Context {
+	return context.WithValue(ctx, musicalDefaultsKey{}, defaults)
+}
+
+// MusicalDefaultsFromContext returns the defaults set by WithMusicalDefaults, or none
+func MusicalDefaultsFromContext(ctx context.Context) models.MusicalDefaults {
+	defaults, _ := ctx.Value(musicalDefaultsKey{}).(models.MusicalDefaults)
+	return defaults
+}
+
+// describeMusicalDefaults summarizes the set fields of defaults for the arranger prompt, e.g.
+// "octave 3, velocity 90"
+func describeMusicalDefaults(defaults models.MusicalDefaults) string {
+	var parts []string
+	if defaults.Octave != 0 {
+		parts = append(parts, fmt.Sprintf("octave %d", defaults.Octave))
+	}
+	if defaults.Velocity != 0 {
+		parts = append(parts, fmt.Sprintf("velocity %d", defaults.Velocity))
+	}
+	if defaults.ClipBars != 0 {
+		parts = append(parts, fmt.Sprintf("%d-bar parts", defaults.ClipBars))
+	}
+	if defaults.TimeSignature != "" {
+		parts = append(parts, defaults.TimeSignature+" time")
+	}
+	return strings.Join(parts, ", ")
+}
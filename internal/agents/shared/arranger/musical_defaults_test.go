@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestValidateMusicalDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		defaults models.MusicalDefaults
+		wantErr  string
+	}{
+		{name: "unset"},
+		{name: "all set", defaults: models.MusicalDefaults{Octave: 3, Velocity: 90, ClipBars: 2, TimeSignature: "6/8"}},
+		{name: "octave too low", defaults: models.MusicalDefaults{Octave: -1}, wantErr: "octave"},
+		{name: "octave too high", defaults: models.MusicalDefaults{Octave: 9}, wantErr: "octave"},
+		{name: "velocity", defaults: models.MusicalDefaults{Velocity: 128}, wantErr: "velocity"},
+		{name: "clip bars", defaults: models.MusicalDefaults{ClipBars: maxSectionBars + 1}, wantErr: "clip_bars"},
+		{name: "time signature without unit", defaults: models.MusicalDefaults{TimeSignature: "3"}, wantErr: "time signature"},
+		{name: "beat unit not a power of two", defaults: models.MusicalDefaults{TimeSignature: "4/6"}, wantErr: "beat unit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateMusicalDefaults(tt.defaults)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateMusicalDefaults() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateMusicalDefaults() = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMergeMusicalDefaults(t *testing.T) {
+	base := models.MusicalDefaults{Octave: 3, Velocity: 90, TimeSignature: "3/4"}
+	got := MergeMusicalDefaults(base, models.MusicalDefaults{Octave: 2, ClipBars: 4})
+	want := models.MusicalDefaults{Octave: 2, Velocity: 90, ClipBars: 4, TimeSignature: "3/4"}
+	if got != want {
+		t.Errorf("MergeMusicalDefaults() = %+v, want %+v", got, want)
+	}
+}
+
+func TestResolveMusicalDefaults(t *testing.T) {
+	builtIn := resolveMusicalDefaults(models.MusicalDefaults{})
+	if builtIn.octave != 4 || builtIn.velocity != 100 || builtIn.clipBeats() != 4 || builtIn.bassOctave() != defaultWalkingBassOctave {
+		t.Errorf("built-in defaults = %+v", builtIn)
+	}
+
+	resolved := resolveMusicalDefaults(models.MusicalDefaults{Octave: 5, ClipBars: 2, TimeSignature: "6/8"})
+	if resolved.clipBeats() != 12 {
+		t.Errorf("clipBeats() = %g, want 2 bars of 6", resolved.clipBeats())
+	}
+	if resolved.bassOctave() != defaultWalkingBassOctave+1 {
+		t.Errorf("bassOctave() = %d, want %d", resolved.bassOctave(), defaultWalkingBassOctave+1)
+	}
+}
+
+func TestActionsDefaults(t *testing.T) {
+	if _, ok := ActionsDefaults([]map[string]any{{"type": "chord", "chord": "C", "velocity": 80}}); ok {
+		t.Error("ActionsDefaults found defaults in actions without a defaults() call")
+	}
+
+	got, ok := ActionsDefaults([]map[string]any{
+		{"type": "set_defaults", "octave": 3, "velocity": 90},
+		{"type": "set_defaults", "octave": 2, "time_signature": "3/4"},
+	})
+	want := models.MusicalDefaults{Octave: 2, Velocity: 90, TimeSignature: "3/4"}
+	if !ok || got != want {
+		t.Errorf("ActionsDefaults() = %+v, %v, want %+v", got, ok, want)
+	}
+}
+
+func TestMusicalDefaultsContext(t *testing.T) {
+	if got := MusicalDefaultsFromContext(context.Background()); got != (models.MusicalDefaults{}) {
+		t.Errorf("MusicalDefaultsFromContext found %+v in an empty context", got)
+	}
+	defaults := models.MusicalDefaults{Octave: 3}
+	if got := MusicalDefaultsFromContext(WithMusicalDefaults(context.Background(), defaults)); got != defaults {
+		t.Errorf("MusicalDefaultsFromContext() = %+v, want %+v", got, defaults)
+	}
+	if got := describeMusicalDefaults(models.MusicalDefaults{Octave: 3, ClipBars: 2, TimeSignature: "3/4"}); got != "octave 3, 2-bar parts, 3/4 time" {
+		t.Errorf("describeMusicalDefaults() = %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+)
+
+const (
+	defaultQuantizeGrid     = 0.25 // 16th notes
+	defaultHumanizeTimingMs = 10.0 // Loose but still tight enough for drums
+	defaultHumanizeVelocity = 8    // +/- velocity range
+	minNoteVelocity         = 1
+	maxNoteVelocity         = 127
+)
+
+// IsNoteTransformAction reports whether an arranger action edits notes (quantize, humanize,
+// groove) instead of generating them
+func IsNoteTransformAction(action map[string]any) bool {
+	actionType, _ := action["type"].(string)
+	return actionType == "quantize" || actionType == "humanize" || actionType == "groove"
+}
+
+// ApplyNoteTransforms runs the quantize/humanize/groove actions, in order, on notes generated
+// by the other arranger actions. bpm converts humanize timing from milliseconds to beats
+// (0 = 120). Actions that aren't transforms are ignored; grooves taken from a track must go
+// through ResolveGrooves first.
+func ApplyNoteTransforms(noteEvents []models.NoteEvent, actions []map[string]any, bpm float64) ([]models.NoteEvent, error) {
+	for _, action := range actions {
+		var err error
+		switch action["type"] {
+		case "quantize":
+			noteEvents, err = quantizeNotes(noteEvents, action)
+		case "humanize":
+			noteEvents, err = humanizeNotes(noteEvents, action, bpm)
+		case "groove":
+			noteEvents, err = grooveNotes(noteEvents, action)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return noteEvents, nil
+}
+
+// quantizeNotes moves each note start toward the nearest grid line (in beats) by strength
+// (0-1], 1 = snap exactly. Durations are kept.
+// Example: quantize(grid=0.25, strength=0.8)
+func quantizeNotes(noteEvents []models.NoteEvent, action map[string]any) ([]models.NoteEvent, error) {
+	grid, _ := getFloat(action, "grid", defaultQuantizeGrid)
+	strength, _ := getFloat(action, "strength", 1.0)
+	if grid <= 0 {
+		return nil, fmt.Errorf("quantize grid must be greater than 0, got %g", grid)
+	}
+	if strength <= 0 || strength > 1 {
+		return nil, fmt.Errorf("quantize strength must be greater than 0 and at most 1, got %g", strength)
+	}
+
+	quantized := make([]models.NoteEvent, len(noteEvents))
+	for i, note := range noteEvents {
+		target := math.Round(note.StartBeats/grid) * grid
+		note.StartBeats += (target - note.StartBeats) * strength
+		quantized[i] = note
+	}
+
+	log.Printf("📐 Quantize: %d notes to grid %.3f beats (strength %.2f)", len(quantized), grid, strength)
+	return quantized, nil
+}
+
+// humanizeNotes shifts each note start by up to timing_ms and its velocity by up to velocity,
+// in either direction. With a seed the same offsets are used on every call.
+// Example: humanize(timing_ms=10, velocity=8)
+func humanizeNotes(noteEvents []models.NoteEvent, action map[string]any, bpm float64) ([]models.NoteEvent, error) {
+	timingMs, _ := getFloat(action, "timing_ms", defaultHumanizeTimingMs)
+	velocityRange, _ := getInt(action, "velocity", defaultHumanizeVelocity)
+	if timingMs < 0 {
+		return nil, fmt.Errorf("humanize timing_ms must not be negative, got %g", timingMs)
+	}
+	if velocityRange < 0 {
+		return nil, fmt.Errorf("humanize velocity must not be negative, got %d", velocityRange)
+	}
+	if bpm <= 0 {
+		bpm = timeutil.DefaultBPM
+	}
+
+	seed, hasSeed := getInt(action, "seed", 0)
+	if !hasSeed {
+		seed = int(time.Now().UnixNano())
+	}
+	rng := rand.New(rand.NewSource(int64(seed)))
+
+	timingBeats := timeutil.SecondsToBeats(timingMs/1000.0, bpm)
+	humanized := make([]models.NoteEvent, len(noteEvents))
+	for i, note := range noteEvents {
+		note.StartBeats = math.Max(0, note.StartBeats+(rng.Float64()*2-1)*timingBeats)
+		if velocityRange > 0 {
+			note.Velocity += rng.Intn(2*velocityRange+1) - velocityRange
+			note.Velocity = max(minNoteVelocity, min(maxNoteVelocity, note.Velocity))
+		}
+		humanized[i] = note
+	}
+
+	log.Printf("🎲 Humanize: %d notes by up to %.1fms (%.3f beats) and %d velocity (seed=%d)",
+		len(humanized), timingMs, timingBeats, velocityRange, seed)
+	return humanized, nil
+}
+
+// ClipNoteEvents converts clip note data from state (the add_midi note format: pitch, velocity,
+// start and length in beats) to NoteEvents. Notes without a pitch are skipped.
+func ClipNoteEvents(clip map[string]any) []models.NoteEvent {
+	var notes []map[string]any
+	switch val := clip["notes"].(type) {
+	case []map[string]any:
+		notes = val
+	case []any:
+		for _, item := range val {
+			if note, ok := item.(map[string]any); ok {
+				notes = append(notes, note)
+			}
+		}
+	}
+
+	noteEvents := make([]models.NoteEvent, 0, len(notes))
+	for _, note := range notes {
+		pitch, ok := getInt(note, "pitch", 0)
+		if !ok {
+			continue
+		}
+		velocity, _ := getInt(note, "velocity", 100)
+		start, _ := getFloat(note, "start", 0)
+		length, _ := getFloat(note, "length", 1)
+		noteEvents = append(noteEvents, models.NoteEvent{
+			MidiNoteNumber: pitch,
+			Velocity:       velocity,
+			StartBeats:     start,
+			DurationBeats:  length,
+		})
+	}
+	return noteEvents
+}
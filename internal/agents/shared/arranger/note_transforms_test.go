@@ -0,0 +1,150 @@
+package services
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+func TestApplyNoteTransforms_Quantize(t *testing.T) {
+	notes := []models.NoteEvent{
+		{MidiNoteNumber: 36, Velocity: 100, StartBeats: 0.1, DurationBeats: 0.25},
+		{MidiNoteNumber: 38, Velocity: 90, StartBeats: 1.05, DurationBeats: 0.25},
+		{MidiNoteNumber: 42, Velocity: 80, StartBeats: 1.6, DurationBeats: 0.2},
+	}
+
+	tests := []struct {
+		name       string
+		action     map[string]any
+		wantStarts []float64
+	}{
+		{
+			name:       "snap to 16ths",
+			action:     map[string]any{"type": "quantize", "grid": 0.25, "strength": 1.0},
+			wantStarts: []float64{0.0, 1.0, 1.5},
+		},
+		{
+			name:       "half strength moves halfway",
+			action:     map[string]any{"type": "quantize", "grid": 0.25, "strength": 0.5},
+			wantStarts: []float64{0.05, 1.025, 1.55},
+		},
+		{
+			name:       "quarter grid",
+			action:     map[string]any{"type": "quantize", "grid": 1.0},
+			wantStarts: []float64{0.0, 1.0, 2.0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ApplyNoteTransforms(notes, []map[string]any{tt.action}, 120)
+			if err != nil {
+				t.Fatalf("ApplyNoteTransforms failed: %v", err)
+			}
+			for i, note := range got {
+				if math.Abs(note.StartBeats-tt.wantStarts[i]) > 1e-9 {
+					t.Errorf("note %d start = %g, want %g", i, note.StartBeats, tt.wantStarts[i])
+				}
+				if note.DurationBeats != notes[i].DurationBeats || note.Velocity != notes[i].Velocity {
+					t.Errorf("note %d duration/velocity changed: %+v", i, note)
+				}
+			}
+		})
+	}
+
+	if notes[0].StartBeats != 0.1 {
+		t.Errorf("ApplyNoteTransforms modified its input: %+v", notes[0])
+	}
+
+	if _, err := ApplyNoteTransforms(notes, []map[string]any{{"type": "quantize", "grid": 0.0}}, 120); err == nil {
+		t.Error("expected error for grid=0")
+	}
+}
+
+func TestApplyNoteTransforms_Humanize(t *testing.T) {
+	notes := []models.NoteEvent{
+		{MidiNoteNumber: 42, Velocity: 100, StartBeats: 0.0, DurationBeats: 0.25},
+		{MidiNoteNumber: 42, Velocity: 124, StartBeats: 0.5, DurationBeats: 0.25},
+		{MidiNoteNumber: 42, Velocity: 3, StartBeats: 1.0, DurationBeats: 0.25},
+	}
+	action := map[string]any{"type": "humanize", "timing_ms": 20.0, "velocity": 8, "seed": 42}
+
+	first, err := ApplyNoteTransforms(notes, []map[string]any{action}, 120)
+	if err != nil {
+		t.Fatalf("ApplyNoteTransforms failed: %v", err)
+	}
+	second, err := ApplyNoteTransforms(notes, []map[string]any{action}, 120)
+	if err != nil {
+		t.Fatalf("ApplyNoteTransforms failed: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed produced different notes: %v vs %v", first, second)
+	}
+
+	// 20ms at 120 BPM is 0.04 beats
+	maxShift := 0.04
+	for i, note := range first {
+		if note.StartBeats < 0 {
+			t.Errorf("note %d start moved before 0: %g", i, note.StartBeats)
+		}
+		if math.Abs(note.StartBeats-notes[i].StartBeats) > maxShift+1e-9 {
+			t.Errorf("note %d start moved %g beats, want at most %g", i, note.StartBeats-notes[i].StartBeats, maxShift)
+		}
+		if note.Velocity < 1 || note.Velocity > 127 {
+			t.Errorf("note %d velocity out of MIDI range: %d", i, note.Velocity)
+		}
+		if diff := note.Velocity - notes[i].Velocity; diff > 8 || diff < -8 {
+			t.Errorf("note %d velocity changed by %d, want at most 8", i, diff)
+		}
+		if note.MidiNoteNumber != notes[i].MidiNoteNumber || note.DurationBeats != notes[i].DurationBeats {
+			t.Errorf("note %d pitch/duration changed: %+v", i, note)
+		}
+	}
+
+	if _, err := ApplyNoteTransforms(notes, []map[string]any{{"type": "humanize", "velocity": -1}}, 120); err == nil {
+		t.Error("expected error for negative velocity")
+	}
+}
+
+func TestApplyNoteTransforms_InOrder(t *testing.T) {
+	notes := []models.NoteEvent{{MidiNoteNumber: 60, Velocity: 100, StartBeats: 0.9, DurationBeats: 1}}
+	actions := []map[string]any{
+		{"type": "arpeggio", "chord": "C"},
+		{"type": "humanize", "timing_ms": 0.0, "velocity": 0},
+		{"type": "quantize", "grid": 1.0},
+	}
+
+	got, err := ApplyNoteTransforms(notes, actions, 0)
+	if err != nil {
+		t.Fatalf("ApplyNoteTransforms failed: %v", err)
+	}
+	want := []models.NoteEvent{{MidiNoteNumber: 60, Velocity: 100, StartBeats: 1.0, DurationBeats: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ApplyNoteTransforms() = %v, want %v", got, want)
+	}
+
+	events, err := ConvertArrangerActionToNoteEvents(actions[2], 0)
+	if err != nil || len(events) != 0 {
+		t.Errorf("ConvertArrangerActionToNoteEvents(quantize) = %v, %v; want no notes", events, err)
+	}
+}
+
+func TestClipNoteEvents(t *testing.T) {
+	clip := map[string]any{
+		"notes": []any{
+			map[string]any{"pitch": 36.0, "velocity": 110.0, "start": 0.0, "length": 0.5},
+			map[string]any{"velocity": 90.0, "start": 1.0}, // No pitch - skipped
+			map[string]any{"pitch": 38, "start": 1.0},
+		},
+	}
+
+	want := []models.NoteEvent{
+		{MidiNoteNumber: 36, Velocity: 110, StartBeats: 0.0, DurationBeats: 0.5},
+		{MidiNoteNumber: 38, Velocity: 100, StartBeats: 1.0, DurationBeats: 1.0},
+	}
+	if got := ClipNoteEvents(clip); !reflect.DeepEqual(got, want) {
+		t.Errorf("ClipNoteEvents() = %v, want %v", got, want)
+	}
+}
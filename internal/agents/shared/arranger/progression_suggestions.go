@@ -0,0 +1,399 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
+)
+
+const (
+	defaultProgressionSuggestions = 4
+	maxProgressionSuggestions     = 8
+	maxSeedChords                 = 8
+)
+
+// romanNumerals are the scale degrees I-VII
+var romanNumerals = [7]string{"I", "II", "III", "IV", "V", "VI", "VII"}
+
+const noteLetters = "CDEFGAB"
+
+// ProgressionSuggestion is a candidate chord progression with its Roman numeral analysis
+type ProgressionSuggestion struct {
+	Name     string   `json:"name"` // The progression it continues, e.g. "axis"
+	Chords   []string `json:"chords"`
+	Numerals []string `json:"numerals"`
+	Diatonic bool     `json:"diatonic"` // All chords in the key (the raised 7th allowed in minor)
+}
+
+// progressionTemplate is a common progression as Roman numerals on the key's scale (natural
+// minor in minor keys): upper case is major, lower case minor, ° diminished, ø half-diminished
+type progressionTemplate struct {
+	name     string
+	numerals []string
+}
+
+// progressionTemplates maps the arrangement preset genres to common progressions in each mode,
+// most typical first
+var progressionTemplates = map[string]map[string][]progressionTemplate{
+	"pop": {
+		"major": {
+			{"axis", []string{"I", "V", "vi", "IV"}},
+			{"sensitive female", []string{"vi", "IV", "I", "V"}},
+			{"doo-wop", []string{"I", "vi", "IV", "V"}},
+			{"pop-punk", []string{"I", "IV", "vi", "V"}},
+			{"plagal loop", []string{"I", "IV", "I", "V"}},
+		},
+		"minor": {
+			{"aeolian loop", []string{"i", "VI", "III", "VII"}},
+			{"minor axis", []string{"i", "VI", "VII", "i"}},
+			{"andalusian cadence", []string{"i", "VII", "VI", "V"}},
+			{"minor plagal", []string{"i", "iv", "VI", "V"}},
+		},
+	},
+	"rock": {
+		"major": {
+			{"three-chord", []string{"I", "IV", "V", "IV"}},
+			{"mixolydian", []string{"I", "bVII", "IV", "I"}},
+			{"anthem", []string{"I", "V", "IV", "I"}},
+			{"axis", []string{"I", "V", "vi", "IV"}},
+		},
+		"minor": {
+			{"aeolian vamp", []string{"i", "VII", "VI", "VII"}},
+			{"minor rock", []string{"i", "iv", "VII", "i"}},
+			{"andalusian cadence", []string{"i", "VII", "VI", "V"}},
+			{"riff", []string{"i", "III", "VII", "iv"}},
+		},
+	},
+	"edm": {
+		"major": {
+			{"sensitive female", []string{"vi", "IV", "I", "V"}},
+			{"axis", []string{"I", "V", "vi", "IV"}},
+			{"lift", []string{"IV", "V", "vi", "vi"}},
+			{"anthem", []string{"IV", "I", "V", "vi"}},
+		},
+		"minor": {
+			{"aeolian loop", []string{"i", "VI", "III", "VII"}},
+			{"epic", []string{"i", "VII", "VI", "VII"}},
+			{"drop", []string{"VI", "VII", "i", "i"}},
+			{"minor lift", []string{"i", "iv", "VI", "VII"}},
+		},
+	},
+	"house": {
+		"major": {
+			{"deep house", []string{"ii7", "V7", "Imaj7", "vi7"}},
+			{"soulful", []string{"Imaj7", "iii7", "IVmaj7", "iii7"}},
+			{"garage", []string{"IVmaj7", "iii7", "ii7", "Imaj7"}},
+			{"classic", []string{"vi7", "IVmaj7", "Imaj7", "V"}},
+		},
+		"minor": {
+			{"deep house", []string{"i7", "iv7", "i7", "iv7"}},
+			{"minor groove", []string{"i7", "VImaj7", "III7", "v7"}},
+			{"dark", []string{"i7", "VII", "VImaj7", "v7"}},
+			{"late night", []string{"iv7", "v7", "i7", "i7"}},
+		},
+	},
+	"hiphop": {
+		"major": {
+			{"soul sample", []string{"Imaj7", "vi7", "ii7", "V7"}},
+			{"lo-fi", []string{"IVmaj7", "iii7", "vi7", "ii7"}},
+			{"boom bap", []string{"ii7", "V7", "Imaj7", "Imaj7"}},
+			{"mellow", []string{"I", "iii", "IV", "iv"}},
+		},
+		"minor": {
+			{"trap", []string{"i", "VI", "III", "VII"}},
+			{"dark loop", []string{"i", "iv", "VI", "v"}},
+			{"two-chord", []string{"i7", "iv7", "i7", "iv7"}},
+			{"cinematic", []string{"i", "VI", "iv", "V"}},
+		},
+	},
+	"jazz": {
+		"major": {
+			{"ii-V-I", []string{"ii7", "V7", "Imaj7", "Imaj7"}},
+			{"turnaround", []string{"Imaj7", "vi7", "ii7", "V7"}},
+			{"iii-vi-ii-V", []string{"iii7", "vi7", "ii7", "V7"}},
+			{"backdoor", []string{"ii7", "bVII7", "Imaj7", "Imaj7"}},
+		},
+		"minor": {
+			{"minor ii-V-i", []string{"iiø7", "V7", "i7", "i7"}},
+			{"minor turnaround", []string{"i7", "VImaj7", "iiø7", "V7"}},
+			{"minor blues", []string{"i7", "iv7", "i7", "V7"}},
+			{"line cliché", []string{"i", "imaj7", "i7", "VImaj7"}},
+		},
+	},
+}
+
+// ProgressionGenres returns the genres with suggested progressions, sorted
+func ProgressionGenres() []string {
+	genres := make([]string, 0, len(progressionTemplates))
+	for genre := range progressionTemplates {
+		genres = append(genres, genre)
+	}
+	sort.Strings(genres)
+	return genres
+}
+
+// SuggestProgressions returns up to count (default 4) common progressions of genre in key.
+// Seed chords start every suggestion, which continues a progression round its loop from the
+// seed's last chord to a whole number of loops; progressions sharing more of the seed's roots
+// come first.
+// Example: A minor, pop, seed [Am, F] → Am F C G (aeolian loop), Am F G Am (minor axis), ...
+func SuggestProgressions(key KeyEstimate, genre string, seedChords []string, count int) ([]ProgressionSuggestion, error) {
+	templates, ok := progressionTemplates[genreKey(genre)]
+	if !ok {
+		return nil, fmt.Errorf("unknown genre %q (available: %s)", genre, strings.Join(ProgressionGenres(), ", "))
+	}
+	if count == 0 {
+		count = defaultProgressionSuggestions
+	}
+	if count < 1 || count > maxProgressionSuggestions {
+		return nil, fmt.Errorf("count must be from 1 to %d, got %d", maxProgressionSuggestions, count)
+	}
+	if len(seedChords) > maxSeedChords {
+		return nil, fmt.Errorf("at most %d seed chords, got %d", maxSeedChords, len(seedChords))
+	}
+
+	seedRoots := make([]string, len(seedChords))
+	for i, chord := range seedChords {
+		numeral, err := RomanNumeral(chord, key)
+		if err != nil {
+			return nil, err
+		}
+		seedRoots[i] = numeralRoot(numeral)
+	}
+
+	type candidate struct {
+		suggestion ProgressionSuggestion
+		shared     int
+	}
+	var candidates []candidate
+	seen := map[string]bool{}
+	for _, template := range templates[key.Mode] {
+		chords := make([]string, len(template.numerals))
+		roots := make([]string, len(template.numerals))
+		for i, numeral := range template.numerals {
+			chord, err := key.numeralChord(numeral)
+			if err != nil {
+				return nil, fmt.Errorf("progression %q: %w", template.name, err)
+			}
+			chords[i], roots[i] = chord, numeralRoot(numeral)
+		}
+
+		shared := 0
+		for _, root := range seedRoots {
+			if containsString(roots, root) {
+				shared++
+			}
+		}
+		if len(seedChords) > 0 {
+			// Carry on round the loop from the seed's last chord (or from the loop's start) up to
+			// a whole number of loops
+			next := 0
+			for i, root := range roots {
+				if root == seedRoots[len(seedRoots)-1] {
+					next = i + 1
+					break
+				}
+			}
+			length := len(chords) * (len(seedChords)/len(chords) + 1)
+			continued := append([]string(nil), seedChords...)
+			for i := next; len(continued) < length; i++ {
+				continued = append(continued, chords[i%len(chords)])
+			}
+			chords = continued
+		}
+
+		if id := strings.Join(chords, " "); !seen[id] {
+			seen[id] = true
+			numerals, _ := AnalyzeProgression(chords, key) // Seed chords parsed above, template chords are valid
+			candidates = append(candidates, candidate{
+				suggestion: ProgressionSuggestion{
+					Name:     template.name,
+					Chords:   chords,
+					Numerals: numerals,
+					Diatonic: len(CheckProgression(chords, key)) == 0,
+				},
+				shared: shared,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].shared > candidates[j].shared })
+	suggestions := make([]ProgressionSuggestion, 0, count)
+	for _, c := range candidates {
+		if len(suggestions) == count {
+			break
+		}
+		suggestions = append(suggestions, c.suggestion)
+	}
+	return suggestions, nil
+}
+
+// AnalyzeProgression returns the Roman numeral of each chord in key
+func AnalyzeProgression(chords []string, key KeyEstimate) ([]string, error) {
+	numerals := make([]string, len(chords))
+	for i, chord := range chords {
+		numeral, err := RomanNumeral(chord, key)
+		if err != nil {
+			return nil, err
+		}
+		numerals[i] = numeral
+	}
+	return numerals, nil
+}
+
+// RomanNumeral analyzes a chord in key: the root's scale degree (natural minor in minor keys,
+// where the raised 7th also counts, with b or # for roots outside the scale), upper case for major and lower case for minor
+// chords, °, ø, + and 7/maj7 for the quality and seventh, and inversion figures (6, 64, 65,
+// 43, 42) for a chord tone in the bass. Extensions above the seventh aren't shown, and a bass
+// outside the chord is written after a slash.
+// Example: in C major, Am7 → vi7, G7/B → V65, Bb → bVII; in A minor, E7 → V7
+func RomanNumeral(chord string, key KeyEstimate) (string, error) {
+	spec, err := music.ParseChord(chord)
+	if err != nil {
+		return "", fmt.Errorf("invalid chord %q: %w", chord, err)
+	}
+
+	rootPC := music.PitchClass(noteToMIDI(spec.Root, 0))
+	accidental, degree := "", key.degreeOf(rootPC)
+	switch {
+	case degree != -1:
+	case key.Mode == "minor" && rootPC == music.PitchClass(key.tonicPC+11):
+		degree = 6 // The raised 7th of harmonic minor
+	default:
+		// Outside the scale: an altered degree spelled like the root (F# is #IV, Gb is bV in C),
+		// or else the flattened degree above
+		degree = (strings.Index(noteLetters, spec.Root[:1]) - strings.Index(noteLetters, key.Tonic[:1]) + 7) % 7
+		switch music.PitchClass(rootPC - key.tonicPC - key.scale()[degree]) {
+		case 1:
+			accidental = "#"
+		case 11:
+			accidental = "b"
+		default:
+			degree, accidental = key.degreeOf(music.PitchClass(rootPC+1)), "b"
+		}
+	}
+
+	has := func(interval int) bool { return containsInt(spec.Intervals, interval) }
+	minor := has(music.Minor3rd) && !has(music.Major3rd)
+	diminished := minor && has(music.Flat5th) && !has(music.Perfect5th)
+	augmented := has(music.Major3rd) && has(music.Sharp5th) && !has(music.Perfect5th)
+
+	numeral := romanNumerals[degree]
+	if minor {
+		numeral = strings.ToLower(numeral)
+	}
+	quality, seventh := "", -1
+	switch {
+	case diminished && has(music.Minor7th):
+		quality, seventh = "ø7", music.Minor7th
+	case diminished && has(music.Dim7th):
+		quality, seventh = "°7", music.Dim7th
+	case diminished:
+		quality = "°"
+	case augmented:
+		quality = "+"
+	}
+	if seventh == -1 {
+		switch {
+		case has(music.Major7th):
+			quality, seventh = quality+"maj7", music.Major7th
+		case has(music.Minor7th):
+			quality, seventh = quality+"7", music.Minor7th
+		case !has(music.Minor3rd) && !has(music.Major3rd) && has(music.Perfect4th):
+			quality = "sus4"
+		case !has(music.Minor3rd) && !has(music.Major3rd) && has(music.Major2nd):
+			quality = "sus2"
+		}
+	}
+	numeral = accidental + numeral + quality
+
+	if spec.Bass == "" {
+		return numeral, nil
+	}
+	bass := music.PitchClass(noteToMIDI(spec.Bass, 0) - noteToMIDI(spec.Root, 0))
+	figures := map[int][2]string{} // Bass interval → triad and seventh figures
+	for _, third := range []int{music.Minor3rd, music.Major3rd} {
+		if has(third) {
+			figures[third] = [2]string{"6", "65"}
+		}
+	}
+	for _, fifth := range []int{music.Flat5th, music.Perfect5th, music.Sharp5th} {
+		if has(fifth) {
+			figures[fifth] = [2]string{"64", "43"}
+		}
+	}
+	if seventh != -1 {
+		figures[seventh] = [2]string{"", "42"}
+	}
+	figure, ok := figures[bass]
+	if !ok {
+		return numeral + "/" + spec.Bass, nil
+	}
+	if seventh == -1 {
+		return numeral + figure[0], nil
+	}
+	return strings.TrimSuffix(numeral, "7") + figure[1], nil
+}
+
+// numeralChord spells a Roman numeral from progressionTemplates as a chord symbol in the key.
+// Example: in C major, ii7 → Dm7, bVII → Bb; in A minor, iiø7 → Bm7b5, V7 → E7
+func (k KeyEstimate) numeralChord(numeral string) (string, error) {
+	rest, shift := numeral, 0
+	switch {
+	case strings.HasPrefix(rest, "b"):
+		rest, shift = rest[1:], -1
+	case strings.HasPrefix(rest, "#"):
+		rest, shift = rest[1:], 1
+	}
+	letters := rest[:len(rest)-len(strings.TrimLeft(rest, "IViv"))]
+	degree := -1
+	for i, roman := range romanNumerals {
+		if strings.EqualFold(letters, roman) {
+			degree = i
+		}
+	}
+	if degree == -1 {
+		return "", fmt.Errorf("invalid Roman numeral %q", numeral)
+	}
+	minor := letters == strings.ToLower(letters)
+
+	qualities := map[string]string{"": "", "7": "7", "maj7": "maj7", "+": "aug"}
+	if minor {
+		qualities = map[string]string{"": "m", "7": "m7", "maj7": "mMaj7", "°": "dim", "°7": "dim7", "ø7": "m7b5"}
+	}
+	quality, ok := qualities[rest[len(letters):]]
+	if !ok {
+		return "", fmt.Errorf("invalid Roman numeral %q", numeral)
+	}
+
+	pc := music.PitchClass(k.tonicPC + k.scale()[degree] + shift)
+	root := k.noteName(pc)
+	switch shift {
+	case -1:
+		root = music.PitchClassName(pc, true)
+	case 1:
+		root = music.PitchClassName(pc, false)
+	}
+	return root + quality, nil
+}
+
+// numeralRoot returns a Roman numeral's root degree, ignoring quality: "bvii°7" → "bVII"
+func numeralRoot(numeral string) string {
+	accidental := ""
+	if strings.HasPrefix(numeral, "b") || strings.HasPrefix(numeral, "#") {
+		accidental, numeral = numeral[:1], numeral[1:]
+	}
+	letters := numeral[:len(numeral)-len(strings.TrimLeft(numeral, "IViv"))]
+	return accidental + strings.ToUpper(letters)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRomanNumeral(t *testing.T) {
+	tests := []struct {
+		key   string
+		chord string
+		want  string
+	}{
+		{key: "C major", chord: "C", want: "I"},
+		{key: "C major", chord: "Am7", want: "vi7"},
+		{key: "C major", chord: "Fmaj7", want: "IVmaj7"},
+		{key: "C major", chord: "Bm7b5", want: "viiø7"},
+		{key: "C major", chord: "Bdim", want: "vii°"},
+		{key: "C major", chord: "Bb", want: "bVII"},
+		{key: "C major", chord: "Caug", want: "I+"},
+		{key: "C major", chord: "Gsus4", want: "Vsus4"},
+		{key: "C major", chord: "C/E", want: "I6"},
+		{key: "C major", chord: "C/G", want: "I64"},
+		{key: "C major", chord: "G7/B", want: "V65"},
+		{key: "C major", chord: "G7/F", want: "V42"},
+		{key: "C major", chord: "C/D", want: "I/D"},
+		{key: "A minor", chord: "E7", want: "V7"},
+		{key: "A minor", chord: "G", want: "VII"},
+		{key: "A minor", chord: "G#dim7", want: "vii°7"},
+		{key: "A minor", chord: "Bb", want: "bII"},
+		{key: "F# major", chord: "Fm7b5", want: "viiø7"},
+		{key: "C major", chord: "F#m7b5", want: "#ivø7"},
+		{key: "C major", chord: "Gb7", want: "bV7"},
+		{key: "A minor", chord: "AmMaj7", want: "imaj7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key+" "+tt.chord, func(t *testing.T) {
+			key, err := ParseKey(tt.key)
+			if err != nil {
+				t.Fatalf("ParseKey failed: %v", err)
+			}
+			got, err := RomanNumeral(tt.chord, key)
+			if err != nil {
+				t.Fatalf("RomanNumeral failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RomanNumeral(%s) = %s, want %s", tt.chord, got, tt.want)
+			}
+		})
+	}
+
+	key, _ := ParseKey("C major")
+	if _, err := RomanNumeral("H7", key); err == nil {
+		t.Error("Expected an error for an invalid chord")
+	}
+}
+
+func TestProgressionTemplates(t *testing.T) {
+	for _, genre := range ArrangementPresetGenres() {
+		if _, ok := progressionTemplates[genre]; !ok {
+			t.Errorf("Arrangement genre %s has no progressions", genre)
+		}
+	}
+
+	// Every template spells in every key and reads back as its own numerals
+	for genre, modes := range progressionTemplates {
+		for mode, templates := range modes {
+			if len(templates) < defaultProgressionSuggestions {
+				t.Errorf("%s %s has %d progressions, fewer than the default count", genre, mode, len(templates))
+			}
+			for _, tonic := range []string{"C", "Eb", "F#", "A"} {
+				key, _ := ParseKey(tonic + " " + mode)
+				for _, template := range templates {
+					for _, numeral := range template.numerals {
+						chord, err := key.numeralChord(numeral)
+						if err != nil {
+							t.Fatalf("%s %s %q: %v", genre, mode, template.name, err)
+						}
+						if got, err := RomanNumeral(chord, key); err != nil || got != numeral {
+							t.Errorf("%s: %s → %s → %s (%v)", key.Name, numeral, chord, got, err)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSuggestProgressions(t *testing.T) {
+	cMajor, _ := ParseKey("C major")
+	aMinor, _ := ParseKey("A minor")
+
+	t.Run("genre templates", func(t *testing.T) {
+		got, err := SuggestProgressions(cMajor, "Pop", nil, 2)
+		if err != nil {
+			t.Fatalf("SuggestProgressions failed: %v", err)
+		}
+		want := []ProgressionSuggestion{
+			{Name: "axis", Chords: []string{"C", "G", "Am", "F"}, Numerals: []string{"I", "V", "vi", "IV"}, Diatonic: true},
+			{Name: "sensitive female", Chords: []string{"Am", "F", "C", "G"}, Numerals: []string{"vi", "IV", "I", "V"}, Diatonic: true},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("SuggestProgressions() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("seed chords are continued", func(t *testing.T) {
+		got, err := SuggestProgressions(aMinor, "pop", []string{"Am", "F"}, 0)
+		if err != nil {
+			t.Fatalf("SuggestProgressions failed: %v", err)
+		}
+		var chords []string
+		for _, suggestion := range got {
+			chords = append(chords, strings.Join(suggestion.Chords, " "))
+		}
+		// The andalusian cadence and minor plagal both continue Am F with E Am
+		want := []string{"Am F C G", "Am F G Am", "Am F E Am"}
+		if !reflect.DeepEqual(chords, want) {
+			t.Errorf("chords = %v, want %v", chords, want)
+		}
+		if got[2].Numerals[2] != "V" {
+			t.Errorf("numerals = %v, want the major V", got[2].Numerals)
+		}
+	})
+
+	t.Run("seed longer than a loop", func(t *testing.T) {
+		got, err := SuggestProgressions(cMajor, "jazz", []string{"Dm7", "G7", "Cmaj7", "A7", "Dm7"}, 1)
+		if err != nil {
+			t.Fatalf("SuggestProgressions failed: %v", err)
+		}
+		// The turnaround has every root of the seed, and carries on from ii7
+		want := []string{"Dm7", "G7", "Cmaj7", "A7", "Dm7", "G7", "Cmaj7", "Am7"}
+		if !reflect.DeepEqual(got[0].Chords, want) || got[0].Diatonic {
+			t.Errorf("SuggestProgressions() = %+v, want %v, not diatonic", got[0], want)
+		}
+	})
+
+	errorTests := []struct {
+		name    string
+		genre   string
+		seed    []string
+		count   int
+		wantErr string
+	}{
+		{name: "unknown genre", genre: "polka", wantErr: "unknown genre"},
+		{name: "too many suggestions", genre: "pop", count: 9, wantErr: "count must be"},
+		{name: "invalid seed chord", genre: "pop", seed: []string{"C", "H"}, wantErr: "invalid chord"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SuggestProgressions(cMajor, tt.genre, tt.seed, tt.count)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SuggestProgressions() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
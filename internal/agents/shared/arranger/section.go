@@ -0,0 +1,309 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+const (
+	defaultSectionBars = 4
+	maxSectionBars     = 64
+)
+
+// sectionPartKinds are the content keys a section part may use, one per part
+var sectionPartKinds = []string{"progression", "walking_bass", "arpeggio", "chord", "drum_pattern"}
+
+// SectionClip is one clip of a section: the notes for one track, relative to the clip start
+type SectionClip struct {
+	Track      int
+	Bar        int // 1-based bar the clip starts at
+	LengthBars int
+	Name       string
+	Notes      []models.NoteEvent
+}
+
+// ConvertSectionToClips converts a section action to one clip per part. Each part is an
+// ordinary arranger action (progression, drum_pattern, ...) spanning the whole section.
+// A bpm on the section is passed on to its parts.
+func ConvertSectionToClips(action map[string]any) ([]SectionClip, error) {
+	if action["type"] != "section" {
+		return nil, fmt.Errorf("not a section action: %v", action["type"])
+	}
+	name, _ := getString(action, "name", "")
+	bars, _ := getInt(action, "bars", defaultSectionBars)
+	bar, _ := getInt(action, "bar", 1)
+	parts, ok := action["parts"].([]map[string]any)
+	if !ok || len(parts) == 0 {
+		return nil, fmt.Errorf("section %q has no parts", name)
+	}
+
+	clips := make([]SectionClip, 0, len(parts))
+	for i, part := range parts {
+		track, ok := getInt(part, "track", 0)
+		if !ok {
+			return nil, fmt.Errorf("section %q part %d is missing track", name, i)
+		}
+		partAction, ok := part["action"].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("section %q part %d is missing its content", name, i)
+		}
+		if bpm, ok := action["bpm"]; ok {
+			if _, hasBPM := partAction["bpm"]; !hasBPM {
+				partAction = copyAction(partAction)
+				partAction["bpm"] = bpm
+			}
+		}
+
+		notes, err := ConvertArrangerActionToNoteEvents(partAction, 0)
+		if err != nil {
+			return nil, fmt.Errorf("section %q track %d: %w", name, track, err)
+		}
+		clips = append(clips, SectionClip{
+			Track:      track,
+			Bar:        bar,
+			LengthBars: bars,
+			Name:       name,
+			Notes:      notes,
+		})
+	}
+
+	log.Printf("🎼 Section %q: %d clips over %d bars from bar %d", name, len(clips), bars, bar)
+	return clips, nil
+}
+
+// extractSectionParts returns the bodies of the {...} entries in a section's tracks=[...]
+// parameter. Grammar School splits arguments on every top-level comma, so nested lists and
+// objects are read from the raw DSL like the other array parameters.
+func extractSectionParts(rawDSL string) ([]string, error) {
+	start := -1
+	for _, pattern := range []string{"tracks=[", "tracks =[", "tracks= [", "tracks = ["} {
+		if start = strings.Index(rawDSL, pattern); start != -1 {
+			start += strings.Index(rawDSL[start:], "[")
+			break
+		}
+	}
+	if start == -1 {
+		return nil, fmt.Errorf("missing tracks list")
+	}
+
+	var parts []string
+	depth := 0
+	partStart := -1
+	inString := false
+	for i := start; i < len(rawDSL); i++ {
+		switch c := rawDSL[i]; {
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '[' || c == '{':
+			depth++
+			if c == '{' && depth == 2 {
+				partStart = i + 1
+			}
+		case c == ']' || c == '}':
+			depth--
+			if c == '}' && depth == 1 && partStart != -1 {
+				parts = append(parts, rawDSL[partStart:i])
+				partStart = -1
+			}
+			if depth == 0 {
+				return parts, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unterminated tracks list")
+}
+
+// parseSectionPart builds a part (track plus an arranger action spanning lengthBeats) from the
+// body of a tracks entry, e.g. `track=0, progression=[C, Am, F, G], octave=3`. Options the
+// entry leaves out come from defaults.
+func parseSectionPart(body string, lengthBeats float64, defaults partDefaults) (map[string]any, error) {
+	params := splitTopLevel(body)
+
+	track := -1
+	kind := ""
+	var content string
+	options := map[string]string{}
+	for _, param := range params {
+		key, value, ok := strings.Cut(param, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected key=value, got %q", param)
+		}
+		key = strings.TrimSpace(key)
+		value = strings.TrimSpace(value)
+		switch key {
+		case "track":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("track must be a track index, got %q", value)
+			}
+			track = n
+		case "progression", "walking_bass", "arpeggio", "chord", "drum_pattern":
+			if kind != "" {
+				return nil, fmt.Errorf("part has both %s and %s; use one per track", kind, key)
+			}
+			kind = key
+			content = value
+		default:
+			options[key] = strings.Trim(value, "\"")
+		}
+	}
+	if track < 0 {
+		return nil, fmt.Errorf("part is missing track")
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("track %d needs one of: %s", track, strings.Join(sectionPartKinds, ", "))
+	}
+
+	action := map[string]any{"length": lengthBeats}
+	switch kind {
+	case "progression", "walking_bass":
+		chords := splitList(content)
+		if len(chords) == 0 {
+			return nil, fmt.Errorf("track %d: %s needs a chord list", track, kind)
+		}
+		if kind == "walking_bass" {
+			action["type"] = "walking_bass"
+			action["chords"] = chords
+			action["velocity"] = defaults.velocity
+			action["octave"] = defaults.bassOctave()
+			break
+		}
+		action["type"] = "progression"
+		action["repeat"] = 1
+		if defaults.velocity != defaultVelocity {
+			action["velocity"] = defaults.velocity
+		}
+		if defaults.octave != defaultOctave {
+			action["octave"] = defaults.octave
+		}
+		octaves := make([]int, len(chords))
+		hasChordOctaves := false
+		defaultOctave := defaults.octave
+		if octave, err := strconv.Atoi(options["octave"]); err == nil {
+			defaultOctave = octave
+		}
+		for i, entry := range chords {
+			chordSymbol, chordOctave, ok, err := splitChordOctave(entry)
+			if err != nil {
+				return nil, fmt.Errorf("track %d: %w", track, err)
+			}
+			chords[i] = chordSymbol
+			octaves[i] = defaultOctave
+			if ok {
+				octaves[i] = chordOctave
+				hasChordOctaves = true
+			}
+		}
+		action["chords"] = chords
+		if hasChordOctaves {
+			action["octaves"] = octaves
+		}
+	case "arpeggio":
+		action["type"] = "arpeggio"
+		action["chord"] = strings.Trim(content, "\"")
+		action["repeat"] = 0
+		action["velocity"] = defaults.velocity
+		action["octave"] = defaults.octave
+		action["direction"] = "up"
+	case "chord":
+		action["type"] = "chord"
+		action["chord"] = strings.Trim(content, "\"")
+		action["repeat"] = 1
+		action["velocity"] = defaults.velocity
+		if defaults.octave != defaultOctave {
+			action["octave"] = defaults.octave
+		}
+	case "drum_pattern":
+		style := strings.ToLower(strings.Trim(content, "\""))
+		if _, ok := drumPatterns[style]; !ok {
+			return nil, fmt.Errorf("track %d: unknown drum_pattern style %q (available: %s)", track, style, strings.Join(DrumPatternStyles(), ", "))
+		}
+		action["type"] = "drum_pattern"
+		action["style"] = style
+		action["velocity"] = defaults.velocity
+	}
+
+	for key, value := range options {
+		switch key {
+		case "octave", "velocity":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("track %d: %s must be a whole number, got %q", track, key, value)
+			}
+			action[key] = n
+		case "note_duration", "swing":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("track %d: %s must be a number, got %q", track, key, value)
+			}
+			action[key] = f
+		case "rhythm":
+			action[key] = value
+		case "velocity_curve":
+			if !isVelocityCurve(value) {
+				return nil, fmt.Errorf("track %d: unknown velocity_curve %q (available: %s)", track, value, strings.Join(velocityCurves, ", "))
+			}
+			action[key] = value
+		default:
+			return nil, fmt.Errorf("track %d: unknown section part option %q", track, key)
+		}
+	}
+
+	return map[string]any{"track": track, "action": action}, nil
+}
+
+// splitTopLevel splits s on commas outside brackets, braces, and strings
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth := 0
+	inString := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '[' || c == '{' || c == '(':
+			depth++
+		case c == ']' || c == '}' || c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			if part := strings.TrimSpace(s[start:i]); part != "" {
+				parts = append(parts, part)
+			}
+			start = i + 1
+		}
+	}
+	if part := strings.TrimSpace(s[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// splitList returns the items of a bracketed list like [C, Am, F]
+func splitList(s string) []string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.Trim(strings.TrimSpace(item), "\"'")
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// copyAction returns a shallow copy of an action map
+func copyAction(action map[string]any) map[string]any {
+	copied := make(map[string]any, len(action)+1)
+	for k, v := range action {
+		copied[k] = v
+	}
+	return copied
+}
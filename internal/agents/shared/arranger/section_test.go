@@ -0,0 +1,99 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertSectionToClips(t *testing.T) {
+	parser, err := NewArrangerDSLParser()
+	if err != nil {
+		t.Fatalf("Failed to create parser: %v", err)
+	}
+	actions, err := parser.ParseDSL(`section(name="Chorus", bars=2, bar=5, tracks=[{track=0, progression=[C, G]}, {track=1, drum_pattern="rock"}])`)
+	if err != nil {
+		t.Fatalf("ParseDSL failed: %v", err)
+	}
+
+	clips, err := ConvertSectionToClips(actions[0])
+	if err != nil {
+		t.Fatalf("ConvertSectionToClips failed: %v", err)
+	}
+	if len(clips) != 2 {
+		t.Fatalf("got %d clips, want 2", len(clips))
+	}
+
+	for i, wantTrack := range []int{0, 1} {
+		clip := clips[i]
+		if clip.Track != wantTrack || clip.Bar != 5 || clip.LengthBars != 2 || clip.Name != "Chorus" {
+			t.Errorf("clip %d = track %d bar %d length %d name %q, want track %d bar 5 length 2 name Chorus",
+				i, clip.Track, clip.Bar, clip.LengthBars, clip.Name, wantTrack)
+		}
+		for _, note := range clip.Notes {
+			if note.StartBeats < 0 || note.StartBeats >= 8 {
+				t.Errorf("clip %d note starts at beat %g, want within the 8-beat clip", i, note.StartBeats)
+			}
+		}
+	}
+
+	// Each part matches the standalone call over the section's length
+	progression, _ := ConvertArrangerActionToNoteEvents(map[string]any{
+		"type": "progression", "chords": []string{"C", "G"}, "length": 8.0, "repeat": 1,
+	}, 0)
+	if !reflect.DeepEqual(clips[0].Notes, progression) {
+		t.Errorf("progression clip = %v, want %v", clips[0].Notes, progression)
+	}
+	drums, _ := ConvertArrangerActionToNoteEvents(map[string]any{
+		"type": "drum_pattern", "style": "rock", "length": 8.0, "velocity": 100,
+	}, 0)
+	if !reflect.DeepEqual(clips[1].Notes, drums) {
+		t.Errorf("drum clip = %v, want %v", clips[1].Notes, drums)
+	}
+}
+
+func TestConvertSectionToClips_PassesBPM(t *testing.T) {
+	strummed := map[string]any{"type": "chord", "chord": "C", "length": 4.0, "repeat": 1, "velocity": 100, "strum_ms": 50.0}
+	section := map[string]any{
+		"type": "section", "name": "Intro", "bars": 1, "bar": 1, "bpm": 60.0,
+		"parts": []map[string]any{{"track": 0, "action": strummed}},
+	}
+
+	clips, err := ConvertSectionToClips(section)
+	if err != nil {
+		t.Fatalf("ConvertSectionToClips failed: %v", err)
+	}
+	// 50ms at 60 bpm is 0.05 beats between notes
+	want, _ := ConvertArrangerActionToNoteEvents(map[string]any{
+		"type": "chord", "chord": "C", "length": 4.0, "repeat": 1, "velocity": 100, "strum_ms": 50.0, "bpm": 60.0,
+	}, 0)
+	if !reflect.DeepEqual(clips[0].Notes, want) {
+		t.Errorf("notes = %v, want %v", clips[0].Notes, want)
+	}
+	if _, ok := strummed["bpm"]; ok {
+		t.Error("ConvertSectionToClips modified the part action")
+	}
+}
+
+func TestConvertSectionToClips_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		action map[string]any
+	}{
+		{name: "not a section", action: map[string]any{"type": "chord", "chord": "C"}},
+		{name: "no parts", action: map[string]any{"type": "section", "name": "Empty", "bars": 4}},
+		{
+			name: "bad part",
+			action: map[string]any{"type": "section", "bars": 1, "parts": []map[string]any{
+				{"track": 0, "action": map[string]any{"type": "drum_pattern", "style": "polka"}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ConvertSectionToClips(tt.action); err == nil {
+				t.Error("Expected error but got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package services
+
+import "time"
+
+// maxGeneratedSeed keeps generated seeds short enough to read back ("regenerate with seed 4217")
+const maxGeneratedSeed = 1_000_000
+
+// NewSeed returns a seed for a request whose random choices weren't given one
+func NewSeed() int {
+	return int(time.Now().UnixNano() % maxGeneratedSeed)
+}
+
+// usesRandomness reports whether an action's result depends on its seed: notes dropped by a
+// probability below 1, humanize, or random_range velocities
+func usesRandomness(action map[string]any) bool {
+	if probability, ok := getFloat(action, "probability", 1); ok && probability < 1 {
+		return true
+	}
+	return action["type"] == "humanize" || action["velocity_curve"] == "random_range"
+}
+
+// sectionParts returns the part actions of a section action
+func sectionParts(action map[string]any) []map[string]any {
+	if action["type"] != "section" {
+		return nil
+	}
+	parts, _ := action["parts"].([]map[string]any)
+	var actions []map[string]any
+	for _, part := range parts {
+		if partAction, ok := part["action"].(map[string]any); ok {
+			actions = append(actions, partAction)
+		}
+	}
+	return actions
+}
+
+// assignSeed gives every action that uses randomness without a seed of its own the request's
+// seed: the first seed in the DSL, or NewSeed. With one seed per request, "regenerate with seed
+// 42" repeats every random choice. ok is false when no action uses randomness.
+func assignSeed(actions []map[string]any) (seed int, ok bool) {
+	random := randomActions(actions)
+	if len(random) == 0 {
+		return 0, false
+	}
+	seed, found := 0, false
+	for _, action := range random {
+		if seed, found = getInt(action, "seed", 0); found {
+			break
+		}
+	}
+	if !found {
+		seed = NewSeed()
+	}
+	for _, action := range random {
+		if _, hasSeed := action["seed"]; !hasSeed {
+			action["seed"] = seed
+		}
+	}
+	return seed, true
+}
+
+// ActionsSeed returns the seed of the first action that uses randomness, section parts
+// included, so responses can report it
+func ActionsSeed(actions []map[string]any) (int, bool) {
+	for _, action := range randomActions(actions) {
+		if seed, ok := getInt(action, "seed", 0); ok {
+			return seed, true
+		}
+	}
+	return 0, false
+}
+
+// randomActions returns the actions, and section parts, that use randomness
+func randomActions(actions []map[string]any) []map[string]any {
+	var random []map[string]any
+	for _, action := range actions {
+		if parts := sectionParts(action); parts != nil {
+			random = append(random, randomActions(parts)...)
+		} else if usesRandomness(action) {
+			random = append(random, action)
+		}
+	}
+	return random
+}
@@ -0,0 +1,54 @@
+package services
+
+import "testing"
+
+func TestAssignSeed(t *testing.T) {
+	t.Run("first seed is shared", func(t *testing.T) {
+		actions := []map[string]any{
+			{"type": "arpeggio", "symbol": "Em"},
+			{"type": "drum_pattern", "probability": 0.8},
+			{"type": "humanize", "timing_ms": 10.0, "seed": 7},
+			{"type": "progression", "velocity_curve": "random_range", "seed": 3},
+		}
+		seed, ok := assignSeed(actions)
+		if !ok || seed != 7 {
+			t.Fatalf("assignSeed() = %d, %v, want 7, true", seed, ok)
+		}
+		if _, has := actions[0]["seed"]; has {
+			t.Errorf("arpeggio without random choices got seed %v", actions[0]["seed"])
+		}
+		if actions[1]["seed"] != 7 {
+			t.Errorf("drum_pattern seed = %v, want 7", actions[1]["seed"])
+		}
+		if actions[3]["seed"] != 3 {
+			t.Errorf("progression's own seed changed to %v", actions[3]["seed"])
+		}
+	})
+
+	t.Run("section parts", func(t *testing.T) {
+		part := map[string]any{"type": "humanize", "velocity": 6}
+		actions := []map[string]any{
+			{"type": "section", "parts": []map[string]any{{"track": 0, "action": part}}},
+		}
+		seed, ok := assignSeed(actions)
+		if !ok || seed < 0 || seed >= maxGeneratedSeed {
+			t.Fatalf("assignSeed() = %d, %v, want a generated seed", seed, ok)
+		}
+		if part["seed"] != seed {
+			t.Errorf("part seed = %v, want %d", part["seed"], seed)
+		}
+		if got, ok := ActionsSeed(actions); !ok || got != seed {
+			t.Errorf("ActionsSeed() = %d, %v, want %d, true", got, ok, seed)
+		}
+	})
+
+	t.Run("no random choices", func(t *testing.T) {
+		actions := []map[string]any{{"type": "arpeggio", "probability": 1.0, "seed": 5}}
+		if _, ok := assignSeed(actions); ok {
+			t.Error("assignSeed() reported a seed for actions without random choices")
+		}
+		if _, ok := ActionsSeed(actions); ok {
+			t.Error("ActionsSeed() reported a seed for actions without random choices")
+		}
+	})
+}
@@ -3,11 +3,11 @@ package services
 import (
 	"context"
 	"fmt"
-	"log"
 	"strings"
 	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/pkg/embedded"
 )
 
@@ -52,7 +52,7 @@ func (s *GenerationService) GenerateTwoStageTimingStream(
 		rhythmicReasoning = "medium"
 	}
 
-	log.Printf("🎵 TWO-STAGE TIMING GENERATION STARTED (Model: %s, Stage1 Reasoning: %s, Stage2 Reasoning: %s)",
+	logger.Printf(ctx, "🎵 TWO-STAGE TIMING GENERATION STARTED (Model: %s, Stage1 Reasoning: %s, Stage2 Reasoning: %s)",
 		model, harmonicReasoning, rhythmicReasoning)
 
 	// Stage 1: Fill in harmony (higher reasoning, with MCP)
@@ -68,7 +68,7 @@ func (s *GenerationService) GenerateTwoStageTimingStream(
 		return nil, fmt.Errorf("stage 1 (harmonic enrichment) failed: %w", err)
 	}
 
-	log.Printf("✅ Stage 1 complete: Musical placement (took %v)", stage1Duration)
+	logger.Printf(ctx, "✅ Stage 1 complete: Musical placement (took %v)", stage1Duration)
 	if callback != nil {
 		stage1Rounded := stage1Duration.Round(time.Second)
 		_ = callback(StreamEvent{Type: "progress", Message: fmt.Sprintf("✅ Stage 1 complete: Musical placement (took %v)", stage1Rounded)})
@@ -86,7 +86,7 @@ func (s *GenerationService) GenerateTwoStageTimingStream(
 		return nil, fmt.Errorf("stage 2 (timing skeleton) failed: %w", err)
 	}
 
-	log.Printf("✅ Stage 2 complete: Rhythmical placement - generated %d variations (took %v)",
+	logger.Printf(ctx, "✅ Stage 2 complete: Rhythmical placement - generated %d variations (took %v)",
 		len(timingResult.OutputParsed.Choices), stage2Duration)
 	if callback != nil {
 		stage2Rounded := stage2Duration.Round(time.Second)
@@ -99,7 +99,7 @@ func (s *GenerationService) GenerateTwoStageTimingStream(
 
 		// Result event was already sent in createTimingSkeleton after stream completes
 		// No need to send it again here - it's already in the stream
-		log.Printf("ℹ️  Result event was sent during Stage 2 stream completion")
+		logger.Printf(ctx, "ℹ️  Result event was sent during Stage 2 stream completion")
 	}
 
 	return timingResult, nil
@@ -138,32 +138,32 @@ func (s *GenerationService) createTimingSkeleton(
 		},
 	}
 
-	log.Printf("🎯 Stage 2 (Timing): Calling provider with %s reasoning", reasoningMode)
+	logger.Printf(ctx, "🎯 Stage 2 (Timing): Calling provider with %s reasoning", reasoningMode)
 
 	// Use non-streaming for Stage 2
 	resp, err := s.provider.Generate(ctx, request)
 	if err != nil {
 		// Check if context was cancelled (client disconnected)
 		if ctx.Err() != nil {
-			log.Printf("⚠️  Stage 2 context cancelled (client may have disconnected): %v", ctx.Err())
+			logger.Printf(ctx, "⚠️  Stage 2 context cancelled (client may have disconnected): %v", ctx.Err())
 		}
 		return nil, fmt.Errorf("timing skeleton generation failed: %w", err)
 	}
 
 	// Log detailed response info for debugging
-	log.Printf("🔍 Stage 2 response details: resp=%v, choices=%d, usage=%v", resp != nil, len(resp.OutputParsed.Choices), resp.Usage != nil)
+	logger.Printf(ctx, "🔍 Stage 2 response details: resp=%v, choices=%d, usage=%v", resp != nil, len(resp.OutputParsed.Choices), resp.Usage != nil)
 	if resp.Usage != nil {
-		log.Printf("📊 Stage 2 usage: %+v", resp.Usage)
+		logger.Printf(ctx, "📊 Stage 2 usage: %+v", resp.Usage)
 	}
 
 	// Parse the timing skeleton - the AI should encode it in the first choice's description
 	if len(resp.OutputParsed.Choices) == 0 {
-		log.Printf("❌ Stage 2 failed: no choices in response (resp.OutputParsed.Choices is empty)")
-		log.Printf("🔍 Response structure: resp=%+v", resp)
+		logger.Printf(ctx, "❌ Stage 2 failed: no choices in response (resp.OutputParsed.Choices is empty)")
+		logger.Printf(ctx, "🔍 Response structure: resp=%+v", resp)
 		if resp.OutputParsed.Choices == nil {
-			log.Printf("⚠️  resp.OutputParsed.Choices is nil")
+			logger.Printf(ctx, "⚠️  resp.OutputParsed.Choices is nil")
 		} else {
-			log.Printf("⚠️  resp.OutputParsed.Choices is empty slice (length=0)")
+			logger.Printf(ctx, "⚠️  resp.OutputParsed.Choices is empty slice (length=0)")
 		}
 		return nil, fmt.Errorf("no output from timing skeleton generation")
 	}
@@ -178,12 +178,12 @@ func (s *GenerationService) createTimingSkeleton(
 	}
 	result.OutputParsed.Choices = resp.OutputParsed.Choices
 
-	log.Printf("✅ Stage 2 generated %d structured choices for timing", len(result.OutputParsed.Choices))
+	logger.Printf(ctx, "✅ Stage 2 generated %d structured choices for timing", len(result.OutputParsed.Choices))
 
 	// ALWAYS send result event after Stage 2 completes - even if choices are empty
 	// This ensures the client knows the generation is complete
 	if callback != nil {
-		log.Printf("📤 Sending result event after Stage 2 completion with %d choices", len(result.OutputParsed.Choices))
+		logger.Printf(ctx, "📤 Sending result event after Stage 2 completion with %d choices", len(result.OutputParsed.Choices))
 		resultErr := callback(StreamEvent{
 			Type:    "result",
 			Message: "Generation complete",
@@ -195,12 +195,12 @@ func (s *GenerationService) createTimingSkeleton(
 			},
 		})
 		if resultErr != nil {
-			log.Printf("⚠️  Error sending result event: %v", resultErr)
+			logger.Printf(ctx, "⚠️  Error sending result event: %v", resultErr)
 		} else {
-			log.Printf("✅ Result event sent successfully")
+			logger.Printf(ctx, "✅ Result event sent successfully")
 		}
 	} else {
-		log.Printf("⚠️  Cannot send result event: callback is nil")
+		logger.Printf(ctx, "⚠️  Cannot send result event: callback is nil")
 	}
 
 	return result, nil
@@ -250,7 +250,7 @@ func (s *GenerationService) enrichWithHarmonyStage1(
 		}
 	}
 
-	log.Printf("🎯 Stage 1 (Harmony): Calling provider with %s reasoning and MCP enabled", reasoningMode)
+	logger.Printf(ctx, "🎯 Stage 1 (Harmony): Calling provider with %s reasoning and MCP enabled", reasoningMode)
 
 	// Use non-streaming for Stage 1
 	// Stage 1 doesn't need structured data back, just processes harmonically
@@ -265,7 +265,7 @@ func (s *GenerationService) enrichWithHarmonyStage1(
 				strings.Contains(errStr, "Parse error") ||
 				strings.Contains(errStr, "invalid character"))
 		if isParseError {
-			log.Printf("⚠️  Stage 1 parse error (non-fatal): %v - continuing anyway since Stage 1 doesn't need structured output", err)
+			logger.Printf(ctx, "⚠️  Stage 1 parse error (non-fatal): %v - continuing anyway since Stage 1 doesn't need structured output", err)
 			// Stage 1 is just for harmonic processing - parse errors are OK
 			return nil
 		}
@@ -273,6 +273,6 @@ func (s *GenerationService) enrichWithHarmonyStage1(
 	}
 
 	// Stage 1 just processes harmonically - doesn't need to return structured data
-	log.Printf("✅ Stage 1 harmonic processing complete")
+	logger.Printf(ctx, "✅ Stage 1 harmonic processing complete")
 	return nil
 }
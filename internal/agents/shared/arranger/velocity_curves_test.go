@@ -0,0 +1,124 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertArrangerActionToNoteEvents_VelocityCurve(t *testing.T) {
+	// 8 eighth-note hats over one bar, all at velocity 100
+	hats := func(curve string) map[string]any {
+		action := map[string]any{"type": "drum_pattern", "style": "rock", "length": 4.0, "velocity": 100}
+		if curve != "" {
+			action["velocity_curve"] = curve
+		}
+		return action
+	}
+	hatVelocities := func(t *testing.T, action map[string]any) []int {
+		t.Helper()
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		velocities := []int{}
+		for _, event := range events {
+			if event.MidiNoteNumber == gmClosedHat {
+				velocities = append(velocities, event.Velocity)
+			}
+		}
+		return velocities
+	}
+
+	// rock hats: 8ths template accents x 0.8 voice gain
+	straight := hatVelocities(t, hats(""))
+
+	t.Run("crescendo ramps from half to full", func(t *testing.T) {
+		got := hatVelocities(t, hats("crescendo"))
+		if got[0] != scaleVelocity(straight[0], 0.5) {
+			t.Errorf("first hat = %d, want %d", got[0], scaleVelocity(straight[0], 0.5))
+		}
+		if last := len(got) - 1; got[last] != straight[last] {
+			t.Errorf("last hat = %d, want %d", got[last], straight[last])
+		}
+		for i := range got {
+			if got[i] > straight[i] {
+				t.Errorf("hat %d louder than without a curve: %d > %d", i, got[i], straight[i])
+			}
+		}
+	})
+
+	t.Run("decrescendo ramps from full to half", func(t *testing.T) {
+		got := hatVelocities(t, hats("decrescendo"))
+		if got[0] != straight[0] {
+			t.Errorf("first hat = %d, want %d", got[0], straight[0])
+		}
+		if last := len(got) - 1; got[last] != scaleVelocity(straight[last], 0.5) {
+			t.Errorf("last hat = %d, want %d", got[last], scaleVelocity(straight[last], 0.5))
+		}
+	})
+
+	t.Run("accent on beat", func(t *testing.T) {
+		got := hatVelocities(t, hats("accent_on_beat"))
+		want := make([]int, len(straight))
+		for i, velocity := range straight {
+			switch {
+			case i == 0:
+				want[i] = scaleVelocity(velocity, 1.1)
+			case i%2 == 1:
+				want[i] = scaleVelocity(velocity, 0.75)
+			default:
+				want[i] = velocity
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("velocities = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("random range is deterministic and bounded", func(t *testing.T) {
+		action := hats("random_range")
+		action["velocity_range"] = 10
+		first := hatVelocities(t, action)
+		second := hatVelocities(t, action)
+		if !reflect.DeepEqual(first, second) {
+			t.Errorf("random_range without a seed changed between calls: %v vs %v", first, second)
+		}
+		changed := false
+		for i := range first {
+			if diff := first[i] - straight[i]; diff > 10 || diff < -10 {
+				t.Errorf("hat %d changed by %d, want at most 10", i, diff)
+			}
+			changed = changed || first[i] != straight[i]
+		}
+		if !changed {
+			t.Error("random_range left every velocity unchanged")
+		}
+
+		action["seed"] = 7
+		if seeded := hatVelocities(t, action); reflect.DeepEqual(seeded, first) {
+			t.Errorf("different seed gave the same velocities: %v", seeded)
+		}
+	})
+
+	t.Run("arpeggio crescendo", func(t *testing.T) {
+		action := map[string]any{
+			"type": "arpeggio", "chord": "Am", "length": 4.0, "velocity": 100, "octave": 4,
+			"note_duration": 0.5, "velocity_curve": "crescendo",
+		}
+		events, err := ConvertArrangerActionToNoteEvents(action, 0.0)
+		if err != nil {
+			t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+		}
+		for i := 1; i < len(events); i++ {
+			if events[i].StartBeats > events[i-1].StartBeats && events[i].Velocity < events[i-1].Velocity {
+				t.Errorf("velocity dropped during crescendo at note %d: %d -> %d", i, events[i-1].Velocity, events[i].Velocity)
+			}
+		}
+	})
+
+	t.Run("unknown curve", func(t *testing.T) {
+		if _, err := ConvertArrangerActionToNoteEvents(hats("swell"), 0.0); err == nil {
+			t.Error("expected error for unknown velocity_curve")
+		}
+	})
+}
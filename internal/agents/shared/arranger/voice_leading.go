@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
+)
+
+const (
+	minLeadVoices = 2
+	maxLeadVoices = 6
+	// defaultLeadRange is the default register above the progression octave's C, up to the G
+	// an octave and a fifth higher
+	defaultLeadRange = 19
+	maxLeadRange     = 36 // Keeps the search over voicings small
+)
+
+// Costs, in semitones of movement, of voicings that move the same distance but sound worse
+const (
+	leadDoublingCost     = 1 // Each doubled tone other than the root
+	leadFirstNonRootCost = 4 // An inversion on the first chord, which nothing leads into
+	leadWideSpacingCost  = 2 // Each gap wider than an octave between adjacent upper voices
+)
+
+// leadChord is a chord to voice: its pitch classes, root first, and its slash bass (-1 if none)
+type leadChord struct {
+	root         int
+	pitchClasses []int
+	bass         int
+}
+
+// voiceLeadProgression voices each chord with voices notes between low and high (MIDI notes,
+// both included) so that the voices move as little as possible from one chord to the next:
+// common tones are held and inversions chosen freely, with the first chord in root position
+// near the middle of the range. voices 0 uses each chord's own note count. With fewer voices
+// than tones the fifth goes first, then the upper extensions; with more, tones are doubled,
+// the root first. A slash chord keeps its bass lowest.
+func voiceLeadProgression(chordSymbols []string, voices, low, high int) ([][]int, error) {
+	if voices != 0 && (voices < minLeadVoices || voices > maxLeadVoices) {
+		return nil, fmt.Errorf("voices must be from %d to %d, got %d", minLeadVoices, maxLeadVoices, voices)
+	}
+	if low < 0 || high > 127 || high-low < 12 || high-low > maxLeadRange {
+		return nil, fmt.Errorf("voice leading range must be inside MIDI notes 0-127 and span 12 to %d semitones, got %d-%d", maxLeadRange, low, high)
+	}
+
+	voicings := make([][]int, len(chordSymbols))
+	var previous []int
+	for i, symbol := range chordSymbols {
+		chord, err := newLeadChord(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chord in progression: %s: %w", symbol, err)
+		}
+		count := voices
+		if count == 0 {
+			count = min(max(len(chord.pitchClasses), minLeadVoices), maxLeadVoices)
+		}
+
+		best, bestCost := []int(nil), math.Inf(1)
+		for _, candidate := range chord.voicings(count, low, high) {
+			if cost := chord.leadCost(previous, candidate, float64(low+high)/2); cost < bestCost {
+				best, bestCost = candidate, cost
+			}
+		}
+		if best == nil {
+			return nil, fmt.Errorf("no voicing of %s with %d voices between MIDI notes %d and %d", symbol, count, low, high)
+		}
+		voicings[i], previous = best, best
+	}
+	return voicings, nil
+}
+
+func newLeadChord(symbol string) (leadChord, error) {
+	spec, err := music.ParseChord(symbol)
+	if err != nil {
+		return leadChord{}, err
+	}
+	chord := leadChord{root: music.PitchClass(noteToMIDI(spec.Root, 0)), bass: -1}
+	if spec.Bass != "" {
+		chord.bass = music.PitchClass(noteToMIDI(spec.Bass, 0))
+	}
+	seen := map[int]bool{}
+	for _, interval := range spec.Intervals {
+		pc := music.PitchClass(chord.root + interval)
+		if !seen[pc] {
+			seen[pc] = true
+			chord.pitchClasses = append(chord.pitchClasses, pc)
+		}
+	}
+	return chord, nil
+}
+
+// required returns the tones a voicing of count notes must have: all of them, or, for fewer
+// voices, the root, third and seventh before the extensions and the fifth. A slash bass is
+// always kept.
+func (c leadChord) required(count int) []int {
+	tones := append([]int(nil), c.pitchClasses...)
+	sort.SliceStable(tones, func(i, j int) bool { return c.tonePriority(tones[i]) < c.tonePriority(tones[j]) })
+	if c.bass >= 0 && !containsInt(tones, c.bass) {
+		tones = append([]int{c.bass}, tones...)
+	}
+	if len(tones) > count {
+		tones = tones[:count]
+	}
+	return tones
+}
+
+// tonePriority orders chord tones by how much they define the chord, lowest first
+func (c leadChord) tonePriority(pc int) int {
+	if pc == c.bass {
+		return -1
+	}
+	switch music.PitchClass(pc - c.root) {
+	case 0:
+		return 0
+	case 3, 4: // Third
+		return 1
+	case 10, 11: // Seventh
+		return 2
+	case 7: // Fifth
+		return 4
+	default:
+		return 3
+	}
+}
+
+// voicings returns every set of count distinct notes between low and high, ascending, that
+// has the required tones, only chord tones, and the slash bass (if any) lowest
+func (c leadChord) voicings(count, low, high int) [][]int {
+	var notes []int
+	for note := low; note <= high; note++ {
+		if containsInt(c.pitchClasses, music.PitchClass(note)) || music.PitchClass(note) == c.bass {
+			notes = append(notes, note)
+		}
+	}
+	required := c.required(count)
+
+	var voicings [][]int
+	current := make([]int, 0, count)
+	var choose func(start int)
+	choose = func(start int) {
+		if len(current) == count {
+			if c.covers(current, required) {
+				voicings = append(voicings, append([]int(nil), current...))
+			}
+			return
+		}
+		for i := start; i <= len(notes)-(count-len(current)); i++ {
+			if len(current) > 0 && music.PitchClass(notes[i]) == c.bass && !containsInt(c.pitchClasses, c.bass) {
+				continue // An added slash bass is only played in the bass
+			}
+			current = append(current, notes[i])
+			choose(i + 1)
+			current = current[:len(current)-1]
+		}
+	}
+	choose(0)
+	return voicings
+}
+
+// covers reports whether a voicing has every required tone and, for a slash chord, the bass lowest
+func (c leadChord) covers(voicing, required []int) bool {
+	if c.bass >= 0 && music.PitchClass(voicing[0]) != c.bass {
+		return false
+	}
+	for _, pc := range required {
+		found := false
+		for _, note := range voicing {
+			if music.PitchClass(note) == pc {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// leadCost is how far the voices move from previous to voicing (both ascending, in semitones),
+// plus the costs of doublings and wide spacing. Voices that appear or disappear move from or to
+// the nearest note. Without a previous chord, it is the distance of the voicing's average note
+// from center instead, and inversions cost extra.
+func (c leadChord) leadCost(previous, voicing []int, center float64) float64 {
+	cost := 0.0
+	if previous == nil {
+		mean := 0.0
+		for _, note := range voicing {
+			mean += float64(note)
+		}
+		cost = math.Abs(mean/float64(len(voicing)) - center)
+		if c.bass < 0 && music.PitchClass(voicing[0]) != c.root {
+			cost += leadFirstNonRootCost
+		}
+	} else if len(previous) == len(voicing) {
+		for i, note := range voicing {
+			cost += math.Abs(float64(note - previous[i]))
+		}
+	} else {
+		for _, note := range voicing {
+			cost += float64(nearestDistance(previous, note))
+		}
+		for _, note := range previous {
+			cost += float64(nearestDistance(voicing, note))
+		}
+	}
+
+	seen := map[int]bool{}
+	for i, note := range voicing {
+		pc := music.PitchClass(note)
+		if seen[pc] && pc != c.root {
+			cost += leadDoublingCost
+		}
+		seen[pc] = true
+		if i > 1 && note-voicing[i-1] > 12 {
+			cost += leadWideSpacingCost
+		}
+	}
+	return cost
+}
+
+func nearestDistance(notes []int, note int) int {
+	best := math.MaxInt
+	for _, other := range notes {
+		best = min(best, max(other-note, note-other))
+	}
+	return best
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
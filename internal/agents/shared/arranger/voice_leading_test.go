@@ -0,0 +1,115 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
+)
+
+func TestVoiceLeadProgression(t *testing.T) {
+	t.Run("holds common tones and moves by steps", func(t *testing.T) {
+		got, err := voiceLeadProgression([]string{"C", "Am", "F", "G"}, 3, 48, 67)
+		if err != nil {
+			t.Fatalf("voiceLeadProgression failed: %v", err)
+		}
+		// C-G-E, then G up to A, E up to F, and each voice to the nearest note of G
+		want := [][]int{{48, 55, 64}, {48, 57, 64}, {48, 57, 65}, {50, 59, 67}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("voiceLeadProgression() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("voices, range, and slash bass", func(t *testing.T) {
+		chords := []string{"Cmaj7", "C/E", "G7", "Dm9", "C"}
+		for _, voices := range []int{0, 3, 4, 5} {
+			got, err := voiceLeadProgression(chords, voices, 45, 72)
+			if err != nil {
+				t.Fatalf("voiceLeadProgression(voices=%d) failed: %v", voices, err)
+			}
+			for i, voicing := range got {
+				if voices != 0 && len(voicing) != voices {
+					t.Errorf("voices=%d: %s has %d notes: %v", voices, chords[i], len(voicing), voicing)
+				}
+				for _, note := range voicing {
+					if note < 45 || note > 72 {
+						t.Errorf("voices=%d: %s note %d outside the range", voices, chords[i], note)
+					}
+				}
+			}
+			if music.PitchClass(got[1][0]) != 4 {
+				t.Errorf("voices=%d: C/E bass = %d, want an E", voices, got[1][0])
+			}
+		}
+	})
+
+	t.Run("fewer voices drop the fifth", func(t *testing.T) {
+		got, err := voiceLeadProgression([]string{"G7"}, 3, 48, 67)
+		if err != nil {
+			t.Fatalf("voiceLeadProgression failed: %v", err)
+		}
+		var classes []int
+		for _, note := range got[0] {
+			classes = append(classes, music.PitchClass(note))
+		}
+		for _, pc := range []int{7, 11, 5} {
+			if !containsInt(classes, pc) {
+				t.Errorf("G7 with 3 voices = %v, missing pitch class %d", got[0], pc)
+			}
+		}
+	})
+
+	errorTests := []struct {
+		name      string
+		chords    []string
+		voices    int
+		low, high int
+		wantErr   string
+	}{
+		{name: "too many voices", chords: []string{"C"}, voices: 7, low: 48, high: 67, wantErr: "voices must be from 2 to 6"},
+		{name: "range under an octave", chords: []string{"C"}, low: 48, high: 55, wantErr: "span 12"},
+		{name: "range over three octaves", chords: []string{"C"}, low: 24, high: 72, wantErr: "span 12"},
+		{name: "invalid chord", chords: []string{"C", "H7"}, low: 48, high: 67, wantErr: "invalid chord in progression: H7"},
+		{name: "no room for the voices", chords: []string{"C"}, voices: 6, low: 48, high: 60, wantErr: "no voicing of C"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := voiceLeadProgression(tt.chords, tt.voices, tt.low, tt.high)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("voiceLeadProgression() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConvertArrangerActionToNoteEvents_SmoothProgression(t *testing.T) {
+	events, err := ConvertArrangerActionToNoteEvents(map[string]any{
+		"type":    "progression",
+		"chords":  []any{"C", "Am"},
+		"length":  8.0,
+		"repeat":  2,
+		"voicing": "smooth",
+		"voices":  3,
+	}, 0.0)
+	if err != nil {
+		t.Fatalf("ConvertArrangerActionToNoteEvents failed: %v", err)
+	}
+
+	// Default range from octave 4: C at 48 up to G at 67
+	chords := map[float64][]int{}
+	for _, event := range events {
+		chords[event.StartBeats] = append(chords[event.StartBeats], event.MidiNoteNumber)
+	}
+	want := map[float64][]int{0: {48, 55, 64}, 4: {48, 57, 64}, 8: {48, 55, 64}, 12: {48, 57, 64}}
+	if !reflect.DeepEqual(chords, want) {
+		t.Errorf("chords = %v, want %v", chords, want)
+	}
+
+	_, err = ConvertArrangerActionToNoteEvents(map[string]any{
+		"type": "progression", "chords": []string{"C"}, "voicing": "smooth", "low": 60, "high": 64,
+	}, 0.0)
+	if err == nil {
+		t.Error("Expected an error for a range under an octave")
+	}
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/pkg/music"
+)
+
+const (
+	defaultWalkingBassOctave = 2  // Roots start around D2 (MIDI 38)
+	walkingBassLowest        = 28 // E1 - lowest note of a 4-string bass
+	walkingBassHighest       = 55 // G3 - keep lines below the comping register
+)
+
+// convertWalkingBassToNoteEvents converts a walking_bass action to a quarter-note bass line.
+// Each chord gets an equal share of the length (default: one bar per chord). The line plays
+// the root on the chord's first beat, walks through chord tones, and uses a chromatic approach
+// note a semitone away from the next chord's root on the last beat. The final chord approaches
+// the first root so the line loops cleanly. All notes stay between E1 and G3.
+func convertWalkingBassToNoteEvents(action map[string]any, startBeat float64) ([]models.NoteEvent, error) {
+	chords, err := getStringSlice(action, "chords")
+	if err != nil || len(chords) == 0 {
+		return nil, fmt.Errorf("walking_bass missing chords field")
+	}
+
+	length, _ := getFloat(action, "length", float64(len(chords))*4.0) // Default: 1 bar per chord
+	velocity, _ := getInt(action, "velocity", 100)
+	octave, _ := getInt(action, "octave", defaultWalkingBassOctave)
+	if length <= 0 {
+		return nil, fmt.Errorf("walking_bass length must be positive, got %g", length)
+	}
+
+	// Check for explicit start time (overrides startBeat)
+	if explicitStart, ok := getFloat(action, "start", 0); ok && explicitStart != 0 {
+		startBeat = explicitStart
+	}
+
+	// Resolve each chord's bass pitch, voice-leading every root to the nearest octave of the last
+	roots := make([]int, len(chords))
+	tones := make([][]int, len(chords))
+	for i, chordSymbol := range chords {
+		bassPitchClass, pitchClasses, err := walkingBassChordTones(chordSymbol)
+		if err != nil {
+			return nil, fmt.Errorf("invalid chord in walking bass: %s: %w", chordSymbol, err)
+		}
+		tones[i] = pitchClasses
+		if i == 0 {
+			roots[i] = clampToBassRegister((octave+1)*12 + bassPitchClass)
+		} else {
+			roots[i] = nearestBassPitch(bassPitchClass, roots[i-1])
+		}
+	}
+
+	chordDuration := length / float64(len(chords))
+	beatsPerChord := int(math.Round(chordDuration))
+	if beatsPerChord < 1 {
+		beatsPerChord = 1
+	}
+	noteDuration := chordDuration / float64(beatsPerChord)
+
+	var noteEvents []models.NoteEvent
+	for i := range chords {
+		chordStart := startBeat + float64(i)*chordDuration
+		target := roots[(i+1)%len(roots)]
+		for j, midiNote := range walkingLine(roots[i], tones[i], target, beatsPerChord) {
+			noteEvents = append(noteEvents, models.NoteEvent{
+				MidiNoteNumber: midiNote,
+				Velocity:       velocity,
+				StartBeats:     chordStart + float64(j)*noteDuration,
+				DurationBeats:  noteDuration,
+			})
+		}
+	}
+
+	log.Printf("🎸 Walking bass over %v: %d notes, %.2f beats per chord", chords, len(noteEvents), chordDuration)
+	return noteEvents, nil
+}
+
+// walkingLine builds one chord's worth of walking notes: the root, chord tones moving toward
+// the target, then a chromatic approach note into the target on the last beat.
+func walkingLine(root int, tones []int, target, beats int) []int {
+	line := []int{root}
+	if beats == 1 {
+		return line
+	}
+
+	direction := 1
+	if target < root {
+		direction = -1
+	}
+	current := root
+	for len(line) < beats-1 {
+		next := nextChordTone(current, tones, direction)
+		if next < walkingBassLowest || next > walkingBassHighest {
+			// Ran out of register - turn around
+			direction = -direction
+			next = nextChordTone(current, tones, direction)
+		}
+		line = append(line, next)
+		current = next
+	}
+
+	// Approach the target from the side the line is already on
+	approach := target - 1
+	if current > target {
+		approach = target + 1
+	}
+	if approach < walkingBassLowest || approach > walkingBassHighest {
+		approach = 2*target - approach
+	}
+	return append(line, approach)
+}
+
+// nextChordTone returns the first note after from, moving up (direction 1) or down (-1),
+// whose pitch class is in tones.
+func nextChordTone(from int, tones []int, direction int) int {
+	for step := 1; step <= 12; step++ {
+		candidate := from + step*direction
+		pitchClass := ((candidate % 12) + 12) % 12
+		for _, tone := range tones {
+			if tone == pitchClass {
+				return candidate
+			}
+		}
+	}
+	return from
+}
+
+// walkingBassChordTones returns the pitch class the bass lands on (the slash bass if present,
+// otherwise the root) and the chord's pitch classes within one octave.
+func walkingBassChordTones(chordSymbol string) (int, []int, error) {
+	spec, err := music.ParseChord(chordSymbol)
+	if err != nil {
+		return 0, nil, err
+	}
+	rootPitchClass := (noteToMIDI(spec.Root, 0) + 12) % 12
+
+	pitchClasses := make([]int, 0, len(spec.Intervals)+1)
+	for _, interval := range spec.Intervals {
+		if interval < 12 {
+			pitchClasses = append(pitchClasses, (rootPitchClass+interval)%12)
+		}
+	}
+
+	bassPitchClass := rootPitchClass
+	if spec.Bass != "" {
+		bassPitchClass = (noteToMIDI(spec.Bass, 0) + 12) % 12
+		pitchClasses = append(pitchClasses, bassPitchClass)
+	}
+
+	return bassPitchClass, pitchClasses, nil
+}
+
+// nearestBassPitch returns the note with the given pitch class closest to previous,
+// kept inside the bass register.
+func nearestBassPitch(pitchClass, previous int) int {
+	candidate := previous + ((pitchClass-previous%12)+12)%12
+	if candidate-previous > 6 {
+		candidate -= 12
+	}
+	return clampToBassRegister(candidate)
+}
+
+// clampToBassRegister shifts a note by octaves until it lies between E1 and G3.
+func clampToBassRegister(note int) int {
+	for note < walkingBassLowest {
+		note += 12
+	}
+	for note > walkingBassHighest {
+		note -= 12
+	}
+	return note
+}
+
+// getStringSlice reads a list of strings that may arrive as []string or []interface{} (from JSON).
+func getStringSlice(m map[string]any, key string) ([]string, error) {
+	switch val := m[key].(type) {
+	case []string:
+		return val, nil
+	case []interface{}:
+		result := make([]string, 0, len(val))
+		for i, item := range val {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s[%d] is not a string: %T", key, i, item)
+			}
+			result = append(result, str)
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("%s is not a list of strings: %T", key, m[key])
+	}
+}
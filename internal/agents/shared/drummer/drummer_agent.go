@@ -8,6 +8,7 @@ import (
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/metrics"
 	"github.com/getsentry/sentry-go"
 )
@@ -34,9 +35,9 @@ func NewDrummerAgent(cfg *config.Config) *DrummerAgent {
 
 // NewDrummerAgentWithProvider creates a drummer agent with a specific LLM provider
 func NewDrummerAgentWithProvider(cfg *config.Config, provider llm.Provider) *DrummerAgent {
-	// Use provided provider or create OpenAI provider (default)
+	// Use provided provider or create the configured one, which is required (see CheckProvider)
 	if provider == nil {
-		provider = llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+		provider = cfg.NewProvider()
 	}
 
 	systemPrompt := buildDrummerSystemPrompt()
@@ -60,7 +61,7 @@ func (a *DrummerAgent) Generate(
 	inputArray []map[string]any,
 ) (*DrummerResult, error) {
 	startTime := time.Now()
-	log.Printf("🥁 DRUMMER REQUEST STARTED (Model: %s)", model)
+	logger.Printf(ctx, "🥁 DRUMMER REQUEST STARTED (Model: %s)", model)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "drummer.generate")
@@ -82,7 +83,7 @@ func (a *DrummerAgent) Generate(
 	}
 
 	// Call provider
-	log.Printf("🚀 DRUMMER REQUEST: %s model=%s, input_messages=%d",
+	logger.Printf(ctx, "🚀 DRUMMER REQUEST: %s model=%s, input_messages=%d",
 		a.provider.Name(), model, len(inputArray))
 
 	resp, err := a.provider.Generate(ctx, request)
@@ -99,7 +100,7 @@ func (a *DrummerAgent) Generate(
 		return nil, fmt.Errorf("no DSL output in response")
 	}
 
-	log.Printf("🥁 DSL Output: %s", dslCode)
+	logger.Printf(ctx, "🥁 DSL Output: %s", dslCode)
 
 	// Parse DSL using Grammar School to get actions
 	parser, err := NewDrummerDSLParser()
@@ -107,6 +108,7 @@ func (a *DrummerAgent) Generate(
 		transaction.SetTag("success", "false")
 		return nil, fmt.Errorf("failed to create DSL parser: %w", err)
 	}
+	parser.SetContext(ctx)
 
 	actions, err := parser.ParseDSL(dslCode)
 	if err != nil {
@@ -127,7 +129,7 @@ func (a *DrummerAgent) Generate(
 	duration := time.Since(startTime)
 	a.metrics.RecordGenerationDuration(ctx, duration, true)
 
-	log.Printf("✅ DRUMMER COMPLETE: %d actions", len(actions))
+	logger.Printf(ctx, "✅ DRUMMER COMPLETE: %d actions", len(actions))
 
 	return result, nil
 }
@@ -3,11 +3,13 @@ package drummer
 import (
 	"context"
 	"fmt"
-	"log"
+	"math/rand"
 	"strings"
+	"time"
 
 	"github.com/Conceptual-Machines/grammar-school-go/gs"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 )
 
 // DrummerDSLParser parses Drummer DSL code using Grammar School
@@ -15,6 +17,7 @@ type DrummerDSLParser struct {
 	engine     *gs.Engine
 	drummerDSL *DrummerDSL
 	actions    []map[string]any
+	ctx        context.Context // Request context, carries correlation IDs into log lines
 }
 
 // DrummerDSL implements the DSL side-effect methods
@@ -32,6 +35,9 @@ func NewDrummerDSLParser() (*DrummerDSLParser, error) {
 	parser.drummerDSL.parser = parser
 
 	grammar := llm.GetDrummerDSLGrammar()
+	if err := llm.ValidateGrammar("Drummer DSL", grammar); err != nil {
+		return nil, err
+	}
 	larkParser := gs.NewLarkParser()
 
 	engine, err := gs.NewEngine(grammar, parser.drummerDSL, larkParser)
@@ -43,6 +49,11 @@ func NewDrummerDSLParser() (*DrummerDSLParser, error) {
 	return parser, nil
 }
 
+// SetContext sets the request context used for DSL execution and logging
+func (p *DrummerDSLParser) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
 // ParseDSL parses DSL code and returns actions
 func (p *DrummerDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
 	if dslCode == "" {
@@ -51,7 +62,13 @@ func (p *DrummerDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
 
 	p.actions = make([]map[string]any, 0)
 
-	ctx := context.Background()
+	ctx := p.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to execute DSL: %w", err)
+	}
 	if err := p.engine.Execute(ctx, dslCode); err != nil {
 		return nil, fmt.Errorf("failed to execute DSL: %w", err)
 	}
@@ -60,7 +77,7 @@ func (p *DrummerDSLParser) ParseDSL(dslCode string) ([]map[string]any, error) {
 		return nil, fmt.Errorf("no actions found in DSL code")
 	}
 
-	log.Printf("✅ Drummer DSL Parser: Translated %d actions from DSL", len(p.actions))
+	logger.Printf(p.ctx, "✅ Drummer DSL Parser: Translated %d actions from DSL", len(p.actions))
 	return p.actions, nil
 }
 
@@ -89,6 +106,19 @@ func (d *DrummerDSL) Pattern(args gs.Args) error {
 		velocity = int(velValue.Num)
 	}
 
+	// Optional probability thins the grid for generative variation; seed makes it repeatable
+	if probValue, ok := args["probability"]; ok && probValue.Kind == gs.ValueNumber {
+		probability := probValue.Num
+		if probability <= 0 || probability > 1 {
+			return fmt.Errorf("pattern: probability must be greater than 0 and at most 1, got %g", probability)
+		}
+		seed := time.Now().UnixNano()
+		if seedValue, ok := args["seed"]; ok && seedValue.Kind == gs.ValueNumber {
+			seed = int64(seedValue.Num)
+		}
+		grid = thinGrid(grid, probability, seed)
+	}
+
 	action := map[string]any{
 		"action":   "drum_pattern",
 		"drum":     drumName,
@@ -97,7 +127,7 @@ func (d *DrummerDSL) Pattern(args gs.Args) error {
 	}
 
 	p.actions = append(p.actions, action)
-	log.Printf("🥁 Pattern: drum=%s, grid=%s (%d hits)", drumName, grid, countHits(grid))
+	logger.Printf(d.parser.ctx, "🥁 Pattern: drum=%s, grid=%s (%d hits)", drumName, grid, countHits(grid))
 
 	return nil
 }
@@ -112,3 +142,36 @@ func countHits(grid string) int {
 	}
 	return count
 }
+
+// thinGrid randomly replaces hits with rests, keeping each hit with the given probability.
+// The same seed always drops the same hits. If every hit would be dropped, the first hit
+// is kept so a pattern that had hits never comes back silent.
+func thinGrid(grid string, probability float64, seed int64) string {
+	if probability >= 1 {
+		return grid
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	cells := []rune(grid)
+	firstHit := -1
+	kept := 0
+	for i, c := range cells {
+		if c != 'x' && c != 'X' && c != 'o' {
+			continue
+		}
+		if firstHit < 0 {
+			firstHit = i
+		}
+		if rng.Float64() < probability {
+			kept++
+		} else {
+			cells[i] = '-'
+		}
+	}
+
+	if kept == 0 && firstHit >= 0 {
+		cells[firstHit] = []rune(grid)[firstHit]
+	}
+
+	return string(cells)
+}
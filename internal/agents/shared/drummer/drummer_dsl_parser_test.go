@@ -161,3 +161,43 @@ func TestCountHits(t *testing.T) {
 		})
 	}
 }
+
+func TestDrummerDSLParser_Probability(t *testing.T) {
+	dsl := `pattern(drum=hat, grid="xxxxxxxxxxxxxxxx", probability=0.5, seed=7)`
+
+	parser, err := NewDrummerDSLParser()
+	require.NoError(t, err)
+	actions, err := parser.ParseDSL(dsl)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+
+	grid := actions[0]["grid"].(string)
+	assert.Len(t, grid, 16, "thinning must keep the grid length")
+	hits := countHits(grid)
+	assert.Greater(t, hits, 0)
+	assert.Less(t, hits, 16)
+
+	// Same seed drops the same hits
+	parser2, err := NewDrummerDSLParser()
+	require.NoError(t, err)
+	actions2, err := parser2.ParseDSL(dsl)
+	require.NoError(t, err)
+	assert.Equal(t, grid, actions2[0]["grid"])
+
+	// Probability out of range is rejected
+	parser3, err := NewDrummerDSLParser()
+	require.NoError(t, err)
+	_, err = parser3.ParseDSL(`pattern(drum=kick, grid="x---x---", probability=2)`)
+	assert.Error(t, err)
+}
+
+func TestThinGrid(t *testing.T) {
+	// probability=1 leaves the grid untouched
+	assert.Equal(t, "x-X-o-x-", thinGrid("x-X-o-x-", 1, 1))
+
+	// A near-zero probability still keeps the first hit
+	assert.Equal(t, "--X-----", thinGrid("--X---o-", 0.000001, 1))
+
+	// A grid with no hits stays empty
+	assert.Equal(t, "--------", thinGrid("--------", 0.5, 1))
+}
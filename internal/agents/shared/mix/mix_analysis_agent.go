@@ -4,11 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/getsentry/sentry-go"
 )
 
@@ -348,7 +348,7 @@ type MixAnalysisAgent struct {
 
 // NewMixAnalysisAgent creates a new mix analysis agent
 func NewMixAnalysisAgent(cfg *config.Config) *MixAnalysisAgent {
-	provider := llm.NewOpenAIProvider(cfg.OpenAIAPIKey)
+	provider := cfg.NewProvider()
 
 	return &MixAnalysisAgent{
 		provider:     provider,
@@ -507,7 +507,7 @@ Be specific and practical. Avoid generic advice - base recommendations on the ac
 // Analyze performs mix analysis and returns recommendations
 func (a *MixAnalysisAgent) Analyze(ctx context.Context, request *AnalysisRequest) (*AnalysisResult, error) {
 	startTime := time.Now()
-	log.Printf("🎛️ MIX ANALYSIS STARTED: mode=%s", request.Mode)
+	logger.Printf(ctx, "🎛️ MIX ANALYSIS STARTED: mode=%s", request.Mode)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "mix.analyze")
@@ -527,7 +527,7 @@ func (a *MixAnalysisAgent) Analyze(ctx context.Context, request *AnalysisRequest
 
 	// Map accuracy level to reasoning mode
 	reasoningMode := a.mapAccuracyToReasoning(request.Accuracy, request.Mode)
-	log.Printf("🎯 Accuracy: %s → Reasoning: %s", request.Accuracy, reasoningMode)
+	logger.Printf(ctx, "🎯 Accuracy: %s → Reasoning: %s", request.Accuracy, reasoningMode)
 
 	// Create LLM request with structured output
 	llmRequest := &llm.GenerationRequest{
@@ -548,7 +548,7 @@ func (a *MixAnalysisAgent) Analyze(ctx context.Context, request *AnalysisRequest
 	}
 
 	// Call provider
-	log.Printf("🚀 Calling LLM for mix analysis...")
+	logger.Printf(ctx, "🚀 Calling LLM for mix analysis...")
 	resp, err := a.provider.Generate(ctx, llmRequest)
 	if err != nil {
 		transaction.SetTag("success", "false")
@@ -565,7 +565,7 @@ func (a *MixAnalysisAgent) Analyze(ctx context.Context, request *AnalysisRequest
 	}
 
 	duration := time.Since(startTime)
-	log.Printf("✅ MIX ANALYSIS COMPLETE: %d recommendations in %v", len(result.Recommendations), duration)
+	logger.Printf(ctx, "✅ MIX ANALYSIS COMPLETE: %d recommendations in %v", len(result.Recommendations), duration)
 
 	transaction.SetTag("success", "true")
 	transaction.SetTag("recommendation_count", fmt.Sprintf("%d", len(result.Recommendations)))
@@ -585,7 +585,7 @@ func (a *MixAnalysisAgent) AnalyzeStream(
 	callback MixStreamCallback,
 ) (*AnalysisResult, error) {
 	startTime := time.Now()
-	log.Printf("🎛️ MIX ANALYSIS STREAM STARTED: mode=%s", request.Mode)
+	logger.Printf(ctx, "🎛️ MIX ANALYSIS STREAM STARTED: mode=%s", request.Mode)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "mix.analyze_stream")
@@ -606,7 +606,7 @@ func (a *MixAnalysisAgent) AnalyzeStream(
 
 	// Map accuracy level to reasoning mode
 	reasoningMode := a.mapAccuracyToReasoning(request.Accuracy, request.Mode)
-	log.Printf("🎯 Accuracy: %s → Reasoning: %s", request.Accuracy, reasoningMode)
+	logger.Printf(ctx, "🎯 Accuracy: %s → Reasoning: %s", request.Accuracy, reasoningMode)
 
 	// For streaming, use text prompt that generates readable analysis
 	streamingSystemPrompt := a.systemPrompt + `
@@ -638,7 +638,7 @@ Keep your response concise but informative. Focus on the most impactful observat
 	// Check if provider supports streaming
 	streamingProvider, ok := a.provider.(llm.StreamingProvider)
 	if !ok {
-		log.Printf("⚠️ Provider %s does not support streaming", a.provider.Name())
+		logger.Printf(ctx, "⚠️ Provider %s does not support streaming", a.provider.Name())
 		return nil, fmt.Errorf("streaming not supported by provider")
 	}
 
@@ -651,20 +651,20 @@ Keep your response concise but informative. Focus on the most impactful observat
 			if chunk != "" && callback != nil {
 				accumulatedText += chunk
 				if cbErr := callback(chunk); cbErr != nil {
-					log.Printf("⚠️ Mix Stream callback error: %v", cbErr)
+					logger.Printf(ctx, "⚠️ Mix Stream callback error: %v", cbErr)
 					return cbErr
 				}
 			}
 		case "started":
-			log.Printf("🚀 Mix analysis streaming started")
+			logger.Printf(ctx, "🚀 Mix analysis streaming started")
 		case "completed":
-			log.Printf("✅ Mix analysis streaming completed: %d chars", len(accumulatedText))
+			logger.Printf(ctx, "✅ Mix analysis streaming completed: %d chars", len(accumulatedText))
 		}
 		return nil
 	}
 
 	// Call streaming provider
-	log.Printf("🚀 MIX ANALYSIS STREAMING: model=%s", llmRequest.Model)
+	logger.Printf(ctx, "🚀 MIX ANALYSIS STREAMING: model=%s", llmRequest.Model)
 	resp, err := streamingProvider.GenerateStream(ctx, llmRequest, streamCallback)
 	if err != nil {
 		transaction.SetTag("success", "false")
@@ -679,7 +679,7 @@ Keep your response concise but informative. Focus on the most impactful observat
 	}
 
 	duration := time.Since(startTime)
-	log.Printf("✅ MIX ANALYSIS STREAM COMPLETE in %v", duration)
+	logger.Printf(ctx, "✅ MIX ANALYSIS STREAM COMPLETE in %v", duration)
 
 	transaction.SetTag("success", "true")
 
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"sort"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+)
+
+// TrackActionGroup holds the actions that target one track, in their original order
+type TrackActionGroup struct {
+	Track   int              `json:"track"`
+	Actions []map[string]any `json:"actions"`
+}
+
+// GroupedActions buckets actions by the track they target.
+// Actions that don't target a track (tempo, markers, project settings) go in Project,
+// actions on the master track in Master.
+type GroupedActions struct {
+	Project []map[string]any   `json:"project"`
+	Master  []map[string]any   `json:"master,omitempty"`
+	Tracks  []TrackActionGroup `json:"tracks"`
+}
+
+// groupActionsByTrack buckets actions by target track index, preserving order within each group.
+// create_track is grouped under the index the new track will get, so follow-up actions on
+// the new track land in the same group. Track groups are sorted by index.
+func groupActionsByTrack(actions []map[string]any) GroupedActions {
+	grouped := GroupedActions{
+		Project: []map[string]any{},
+		Tracks:  []TrackActionGroup{},
+	}
+	groupIndex := make(map[int]int) // track index -> position in grouped.Tracks
+
+	for _, action := range actions {
+		if action["track"] == magdadaw.MasterTrack {
+			grouped.Master = append(grouped.Master, action)
+			continue
+		}
+		track, ok := actionTrackIndex(action)
+		if !ok {
+			grouped.Project = append(grouped.Project, action)
+			continue
+		}
+		pos, exists := groupIndex[track]
+		if !exists {
+			pos = len(grouped.Tracks)
+			groupIndex[track] = pos
+			grouped.Tracks = append(grouped.Tracks, TrackActionGroup{Track: track})
+		}
+		grouped.Tracks[pos].Actions = append(grouped.Tracks[pos].Actions, action)
+	}
+
+	sort.SliceStable(grouped.Tracks, func(i, j int) bool {
+		return grouped.Tracks[i].Track < grouped.Tracks[j].Track
+	})
+	return grouped
+}
+
+// actionTrackIndex returns the track an action targets: "index" for create_track, "track" otherwise
+func actionTrackIndex(action map[string]any) (int, bool) {
+	key := "track"
+	if action["action"] == "create_track" {
+		key = "index"
+	}
+	switch v := action[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
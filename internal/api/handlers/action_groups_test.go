@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupActionsByTrack(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "set_track", "track": 2, "mute": true},
+		{"action": "set_tempo", "bpm": 120.0},
+		{"action": "create_track", "index": 3, "name": "Bass"},
+		{"action": "create_clip_at_bar", "track": 3, "bar": 1, "length_bars": 4},
+		{"action": "set_track", "track": 0, "name": "Drums"},
+		{"action": "add_marker", "position": 8.0, "name": "Chorus"},
+		{"action": "set_track", "track": 2.0, "volume_db": -6.0},
+	}
+
+	grouped := groupActionsByTrack(actions)
+
+	assert.Equal(t, []map[string]any{actions[1], actions[5]}, grouped.Project)
+	require.Len(t, grouped.Tracks, 3)
+
+	assert.Equal(t, 0, grouped.Tracks[0].Track)
+	assert.Equal(t, []map[string]any{actions[4]}, grouped.Tracks[0].Actions)
+
+	assert.Equal(t, 2, grouped.Tracks[1].Track)
+	assert.Equal(t, []map[string]any{actions[0], actions[6]}, grouped.Tracks[1].Actions)
+
+	// The new track and its clip are grouped under the new index
+	assert.Equal(t, 3, grouped.Tracks[2].Track)
+	assert.Equal(t, []map[string]any{actions[2], actions[3]}, grouped.Tracks[2].Actions)
+}
+
+func TestGroupActionsByTrack_Master(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "add_track_fx", "track": "master", "fxname": "ReaLimit"},
+		{"action": "set_track", "track": 0, "mute": true},
+		{"action": "set_track", "track": "master", "volume_db": -1.0},
+	}
+
+	grouped := groupActionsByTrack(actions)
+
+	assert.Empty(t, grouped.Project)
+	assert.Equal(t, []map[string]any{actions[0], actions[2]}, grouped.Master)
+	require.Len(t, grouped.Tracks, 1)
+	assert.Equal(t, []map[string]any{actions[1]}, grouped.Tracks[0].Actions)
+}
+
+func TestGroupActionsByTrack_Empty(t *testing.T) {
+	grouped := groupActionsByTrack(nil)
+
+	data, err := json.Marshal(grouped)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"project": [], "tracks": []}`, string(data))
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+
+	magdaarranger "github.com/Conceptual-Machines/magda-api/internal/agents/shared/arranger"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyzeKey detects the project key from the clips in state and checks a progression against it
+// POST /api/v1/analysis/key
+// An explicit key skips detection; auto_correct returns the progression with non-diatonic
+// chords replaced by diatonic ones
+func AnalyzeKey(c *gin.Context) {
+	var req struct {
+		State       map[string]interface{} `json:"state"`
+		Key         string                 `json:"key,omitempty"`         // e.g. "A minor"; detected from state when empty
+		Progression []string               `json:"progression,omitempty"` // Chord symbols to check
+		AutoCorrect bool                   `json:"auto_correct,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var key magdaarranger.KeyEstimate
+	if req.Key != "" {
+		parsed, err := magdaarranger.ParseKey(req.Key)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		key = parsed
+	} else {
+		detected, ok := magdaarranger.DetectKeyFromState(req.State)
+		if !ok {
+			logger.Printf(c.Request.Context(), "🎼 AnalyzeKey: not enough notes in state to detect a key")
+			c.JSON(http.StatusOK, gin.H{
+				"detected": false,
+				"message":  "not enough notes in the project's clips to detect a key",
+			})
+			return
+		}
+		key = detected
+	}
+
+	response := gin.H{
+		"detected": req.Key == "",
+		"key":      key,
+	}
+	if len(req.Progression) > 0 {
+		nonDiatonic := magdaarranger.CheckProgression(req.Progression, key)
+		if nonDiatonic == nil {
+			nonDiatonic = []magdaarranger.NonDiatonicChord{}
+		}
+		response["diatonic"] = len(nonDiatonic) == 0
+		response["non_diatonic"] = nonDiatonic
+		if req.AutoCorrect {
+			response["corrected_progression"] = magdaarranger.CorrectProgression(req.Progression, key)
+		}
+	}
+
+	logger.Printf(c.Request.Context(), "✅ AnalyzeKey: %s (confidence %.2f), %d chords checked", key.Name, key.Confidence, len(req.Progression))
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/audit"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler lists the requests in the audit log and the actions generated for them
+type AuditHandler struct {
+	store audit.Store
+}
+
+func NewAuditHandler(store audit.Store) *AuditHandler {
+	return &AuditHandler{store: store}
+}
+
+// Entries handles GET /api/v1/admin/audit?key=&from=&to=&limit=. from and to are RFC 3339
+// times or YYYY-MM-DD days; they default to the last 24 hours. Without key every caller is
+// listed. At most limit entries (default 100, at most 1000) are returned, the most recent.
+func (h *AuditHandler) Entries(c *gin.Context) {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Audit log is off (AUDIT_LOG=off)"})
+		return
+	}
+
+	now := time.Now().UTC()
+	from, to := now.Add(-24*time.Hour), now
+	var err error
+	if value := c.Query("from"); value != "" {
+		if from, err = audit.ParseTime(value, false); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = audit.ParseTime(value, true); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "audit range ends before it starts"})
+		return
+	}
+	limit := audit.DefaultQueryLimit
+	if value := c.Query("limit"); value != "" {
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 || limit > audit.MaxQueryLimit {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be a number from 1 to " + strconv.Itoa(audit.MaxQueryLimit),
+			})
+			return
+		}
+	}
+
+	key := c.Query("key")
+	entries, err := h.store.Query(c.Request.Context(), key, from, to, limit)
+	if err != nil {
+		logger.Printf(c.Request.Context(), "❌ Audit query failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if entries == nil {
+		entries = []audit.Entry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":     key,
+		"from":    from.Format(time.RFC3339),
+		"to":      to.Format(time.RFC3339),
+		"entries": entries,
+	})
+}
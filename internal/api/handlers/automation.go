@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// PreviewAutomation samples an add_automation curve without applying it
+// POST /api/v1/automation/preview
+// Takes the curve parameters the DSL emits (curve, from, to, freq, amplitude, phase, start/end or
+// start_bar/end_bar) and returns the points, so clients can draw the curve first
+func PreviewAutomation(c *gin.Context) {
+	var spec magdadaw.CurveSpec
+	if err := c.ShouldBindJSON(&spec); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	points, err := magdadaw.SampleCurve(spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Printf(c.Request.Context(), "📈 PreviewAutomation: %s, %d points", spec.Curve, len(points))
+	c.JSON(http.StatusOK, gin.H{
+		"curve":  spec.Curve,
+		"points": points,
+		"count":  len(points),
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewAutomation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/automation/preview", PreviewAutomation)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "fade in",
+			body:     `{"curve": "fade_in", "start": 0, "end": 4, "samples": 5}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"curve": "fade_in", "count": 5, "points": [
+				{"time": 0, "value": 0}, {"time": 1, "value": 0.25}, {"time": 2, "value": 0.5},
+				{"time": 3, "value": 0.75}, {"time": 4, "value": 1}]}`,
+		},
+		{
+			name:     "unknown curve",
+			body:     `{"curve": "wobble", "start": 0, "end": 4}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "missing curve",
+			body:     `{"start": 0, "end": 4}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/automation/preview", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantBody != "" {
+				assert.JSONEq(t, tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+// controlChannelIdleTimeout closes a control channel that sent nothing for this long; clients
+// keep an idle channel open with ping messages
+const controlChannelIdleTimeout = 2 * time.Minute
+
+// ControlMessage is a message from the extension on the control channel
+type ControlMessage struct {
+	Type string `json:"type"`         // "state", "state_delta", "chat", "cancel" or "ping"
+	ID   string `json:"id,omitempty"` // Chat request ID, echoed on the request's events
+	// State is the full project snapshot for "state" (and optionally "chat"), or the changes
+	// since the last one for "state_delta"
+	State map[string]any `json:"state,omitempty"`
+	// Chat options: question, length_unit, group_by_track, noop_summary and session_id
+	MagdaChatRequest
+}
+
+// controlChannel is one open control channel: the project state the extension last sent and
+// the chat request it is running, if any
+type controlChannel struct {
+	h         *MagdaHandler
+	c         *gin.Context
+	ws        *websocket.Conn
+	sessionID string
+
+	sendMu sync.Mutex // Serializes writes; agents stream events concurrently
+
+	mu           sync.Mutex
+	state        map[string]any
+	stateVersion int
+	chatID       string
+	cancelChat   context.CancelFunc
+	chats        sync.WaitGroup
+}
+
+// controlChannels are the open control channels by session_id, for server-initiated messages
+type controlChannels struct {
+	mu        sync.Mutex
+	bySession map[string]map[*controlChannel]struct{}
+}
+
+func newControlChannels() *controlChannels {
+	return &controlChannels{bySession: map[string]map[*controlChannel]struct{}{}}
+}
+
+func (cc *controlChannels) add(channel *controlChannel) {
+	if channel.sessionID == "" {
+		return
+	}
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.bySession[channel.sessionID] == nil {
+		cc.bySession[channel.sessionID] = map[*controlChannel]struct{}{}
+	}
+	cc.bySession[channel.sessionID][channel] = struct{}{}
+}
+
+func (cc *controlChannels) remove(channel *controlChannel) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	delete(cc.bySession[channel.sessionID], channel)
+	if len(cc.bySession[channel.sessionID]) == 0 {
+		delete(cc.bySession, channel.sessionID)
+	}
+}
+
+// Push sends event to every control channel open for sessionID and returns how many got it
+func (cc *controlChannels) Push(sessionID string, event gin.H) int {
+	cc.mu.Lock()
+	channels := make([]*controlChannel, 0, len(cc.bySession[sessionID]))
+	for channel := range cc.bySession[sessionID] {
+		channels = append(channels, channel)
+	}
+	cc.mu.Unlock()
+
+	sent := 0
+	for _, channel := range channels {
+		if channel.send(event) == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+// PushToSession sends a server-initiated event (a follow-up suggestion, a finished background
+// job) to the session's open control channels, returning how many received it
+func (h *MagdaHandler) PushToSession(sessionID string, event gin.H) int {
+	return h.channels.Push(sessionID, event)
+}
+
+// ControlChannel is a WebSocket the REAPER extension keeps open, so it sends the project state
+// once and then only what changed, and receives streamed actions without a request per command.
+// GET /api/v1/ws?session_id=...
+// Client messages: state (full snapshot), state_delta (changes, merged into the snapshot), chat
+// (question and the /chat options, run against the stored state), cancel and ping. Server
+// messages: connected, state_ack, the chat stream's started, text_delta, actions_partial and
+// completed events tagged with the chat's id, cancelled, pong and error.
+func (h *MagdaHandler) ControlChannel(c *gin.Context) {
+	server := websocket.Server{
+		// The extension isn't a browser and sends no Origin; auth ran before the upgrade
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			h.serveControlChannel(c, ws)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *MagdaHandler) serveControlChannel(c *gin.Context, ws *websocket.Conn) {
+	ctx := c.Request.Context()
+	channel := &controlChannel{h: h, c: c, ws: ws, sessionID: c.Query("session_id")}
+	h.channels.add(channel)
+	defer func() {
+		h.channels.remove(channel)
+		channel.cancel("")
+		channel.chats.Wait()
+		_ = ws.Close()
+	}()
+
+	logger.Printf(ctx, "🔌 Control channel opened (session %q)", channel.sessionID)
+	_ = channel.send(gin.H{
+		"type":       "connected",
+		"request_id": c.GetString("request_id"),
+		"session_id": channel.sessionID,
+	})
+
+	for {
+		_ = ws.SetReadDeadline(time.Now().Add(controlChannelIdleTimeout))
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			if !errors.Is(err, io.EOF) {
+				logger.Printf(ctx, "🔌 Control channel closed: %v", err)
+			} else {
+				logger.Printf(ctx, "🔌 Control channel closed by the client")
+			}
+			return
+		}
+		var msg ControlMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			_ = channel.sendError("", fmt.Sprintf("invalid message: %v", err))
+			continue
+		}
+		channel.handle(msg)
+	}
+}
+
+// handle acts on one client message
+func (ch *controlChannel) handle(msg ControlMessage) {
+	switch msg.Type {
+	case "ping":
+		_ = ch.send(gin.H{"type": "pong"})
+	case "state":
+		if msg.State == nil {
+			_ = ch.sendError(msg.ID, "state message without state")
+			return
+		}
+		ch.ackState(ch.setState(msg.State))
+	case "state_delta":
+		version, err := ch.applyDelta(msg.State)
+		if err != nil {
+			_ = ch.sendError(msg.ID, err.Error())
+			return
+		}
+		ch.ackState(version)
+	case "chat":
+		ch.startChat(msg)
+	case "cancel":
+		if !ch.cancel(msg.ID) {
+			_ = ch.sendError(msg.ID, "no running request to cancel")
+		}
+	default:
+		_ = ch.sendError(msg.ID, fmt.Sprintf("unknown message type %q", msg.Type))
+	}
+}
+
+func (ch *controlChannel) setState(state map[string]any) int {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	ch.state = state
+	ch.stateVersion++
+	return ch.stateVersion
+}
+
+func (ch *controlChannel) applyDelta(delta map[string]any) (int, error) {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if delta == nil {
+		return 0, fmt.Errorf("state_delta message without state")
+	}
+	if ch.state == nil {
+		return 0, fmt.Errorf("state_delta needs a state to apply to: send a full state first")
+	}
+	state, err := session.ApplyStateDelta(ch.state, delta)
+	if err != nil {
+		return 0, err
+	}
+	ch.state = state
+	ch.stateVersion++
+	return ch.stateVersion, nil
+}
+
+func (ch *controlChannel) ackState(version int) {
+	_ = ch.send(gin.H{"type": "state_ack", "version": version})
+}
+
+// startChat runs a chat request against the stored state, streaming its events. One request
+// runs at a time per channel.
+func (ch *controlChannel) startChat(msg ControlMessage) {
+	if msg.Question == "" {
+		_ = ch.sendError(msg.ID, "question is required")
+		return
+	}
+	req := msg.MagdaChatRequest
+	if req.SessionID == "" {
+		req.SessionID = ch.sessionID
+	}
+	ctx, err := ch.h.requestContext(ch.c, &req)
+	if err != nil {
+		_ = ch.sendError(msg.ID, err.Error())
+		return
+	}
+
+	ch.mu.Lock()
+	if ch.cancelChat != nil {
+		running := ch.chatID
+		ch.mu.Unlock()
+		_ = ch.sendError(msg.ID, fmt.Sprintf("request %q is still running", running))
+		return
+	}
+	if msg.State != nil {
+		ch.state = msg.State
+		ch.stateVersion++
+	}
+	// The agents annotate the state they're given, so each request gets its own copy
+	req.State, err = session.CopyState(ch.state)
+	if err != nil {
+		ch.mu.Unlock()
+		_ = ch.sendError(msg.ID, err.Error())
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	ch.chatID, ch.cancelChat = msg.ID, cancel
+	ch.chats.Add(1)
+	ch.mu.Unlock()
+
+	go func() {
+		defer ch.chats.Done()
+		defer func() {
+			ch.mu.Lock()
+			ch.chatID, ch.cancelChat = "", nil
+			ch.mu.Unlock()
+			cancel()
+		}()
+		ch.chat(ctx, msg.ID, &req)
+	}()
+}
+
+// chat streams one request: the same events as /api/v1/magda/chat/stream, tagged with id
+func (ch *controlChannel) chat(ctx context.Context, id string, req *MagdaChatRequest) {
+	ctx = ch.h.withSessionHistory(ctx, req.SessionID)
+	sendEvent := func(event gin.H) error {
+		event["id"] = id
+		return ch.send(event)
+	}
+
+	logger.Printf(ctx, "📨 Control channel chat %q: question length=%d, state keys=%d", id, len(req.Question), len(req.State))
+	_ = sendEvent(gin.H{"type": "started", "request_id": ch.c.GetString("request_id")})
+
+	ctx = magdadaw.WithTextDeltaCallback(ctx, func(delta string) error {
+		return sendEvent(gin.H{"type": "text_delta", "delta": delta})
+	})
+	var partialCount atomic.Int64
+	actionCallback := func(action map[string]any) error {
+		return sendEvent(gin.H{
+			"type":    "actions_partial",
+			"actions": []map[string]any{action},
+			"count":   partialCount.Add(1),
+		})
+	}
+
+	result, err := ch.h.orchestrator.GenerateActionsStream(ctx, req.Question, req.State, actionCallback)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			logger.Printf(ctx, "🛑 Control channel chat %q cancelled", id)
+			_ = sendEvent(gin.H{"type": "cancelled"})
+			return
+		}
+		logger.Printf(ctx, "❌ Control channel chat %q: %v", id, err)
+		_ = sendEvent(gin.H{"type": "error", "message": err.Error()})
+		return
+	}
+
+	logger.Printf(ctx, "✅ Control channel chat %q: %d actions", id, len(result.Actions))
+	ch.h.recordTurn(ctx, req.SessionID, req.Question, result)
+	recordAudit(ch.c, req, result)
+	ch.h.beginTransaction(ctx, req, result)
+	_ = sendEvent(completedEvent(ch.c, req, result))
+}
+
+// cancel stops the running chat request; an empty id cancels whichever is running
+func (ch *controlChannel) cancel(id string) bool {
+	ch.mu.Lock()
+	defer ch.mu.Unlock()
+	if ch.cancelChat == nil || id != "" && id != ch.chatID {
+		return false
+	}
+	ch.cancelChat()
+	return true
+}
+
+func (ch *controlChannel) send(event gin.H) error {
+	ch.sendMu.Lock()
+	defer ch.sendMu.Unlock()
+	return websocket.JSON.Send(ch.ws, event)
+}
+
+func (ch *controlChannel) sendError(id, message string) error {
+	event := gin.H{"type": "error", "message": message}
+	if id != "" {
+		event["id"] = id
+	}
+	return ch.send(event)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+// dialControlChannel serves the control channel with the mock LLM provider and connects to it
+func dialControlChannel(t *testing.T) (*MagdaHandler, *websocket.Conn) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	handler := NewMagdaHandler(&config.Config{LLMProvider: "mock", SessionStore: "memory", Environment: "test"})
+	router := gin.New()
+	router.GET("/api/v1/ws", handler.ControlChannel)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws?session_id=s1"
+	ws, err := websocket.Dial(url, "", server.URL)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ws.Close() })
+	require.NoError(t, ws.SetDeadline(time.Now().Add(10*time.Second)))
+
+	connected := receiveEvent(t, ws)
+	require.Equal(t, "connected", connected["type"])
+	assert.Equal(t, "s1", connected["session_id"])
+	return handler, ws
+}
+
+func receiveEvent(t *testing.T, ws *websocket.Conn) map[string]any {
+	t.Helper()
+	var event map[string]any
+	require.NoError(t, websocket.JSON.Receive(ws, &event))
+	return event
+}
+
+func TestControlChannel(t *testing.T) {
+	handler, ws := dialControlChannel(t)
+	send := func(msg map[string]any) {
+		require.NoError(t, websocket.JSON.Send(ws, msg))
+	}
+
+	send(map[string]any{"type": "ping"})
+	assert.Equal(t, "pong", receiveEvent(t, ws)["type"])
+
+	send(map[string]any{"type": "state_delta", "state": map[string]any{"tracks": map[string]any{"0": nil}}})
+	event := receiveEvent(t, ws)
+	assert.Equal(t, "error", event["type"])
+	assert.Contains(t, event["message"], "send a full state first")
+
+	send(map[string]any{"type": "state", "state": map[string]any{"tracks": []any{}}})
+	assert.Equal(t, map[string]any{"type": "state_ack", "version": 1.0}, receiveEvent(t, ws))
+	send(map[string]any{"type": "state_delta", "state": map[string]any{"project": map[string]any{"bpm": 100}}})
+	assert.Equal(t, map[string]any{"type": "state_ack", "version": 2.0}, receiveEvent(t, ws))
+
+	send(map[string]any{"type": "chat", "id": "r1", "question": "Create a track called 'Drums'"})
+	var types []string
+	var completed map[string]any
+	for completed == nil {
+		event := receiveEvent(t, ws)
+		require.NotEqual(t, "error", event["type"], event["message"])
+		assert.Equal(t, "r1", event["id"])
+		types = append(types, event["type"].(string))
+		if event["type"] == "completed" {
+			completed = event
+		}
+	}
+	assert.Equal(t, "started", types[0])
+	assert.Contains(t, types, "actions_partial")
+	actions := completed["actions"].([]any)
+	require.Len(t, actions, 1)
+	assert.Equal(t, "create_track", actions[0].(map[string]any)["action"])
+	assert.Equal(t, "Drums", actions[0].(map[string]any)["name"])
+
+	// The server can push to the session's channels unprompted
+	assert.Equal(t, 1, handler.PushToSession("s1", gin.H{"type": "suggestion", "text": "Add a bass track?"}))
+	assert.Equal(t, "suggestion", receiveEvent(t, ws)["type"])
+	assert.Equal(t, 0, handler.PushToSession("other", gin.H{"type": "suggestion"}))
+
+	send(map[string]any{"type": "cancel"})
+	assert.Equal(t, "error", receiveEvent(t, ws)["type"])
+	send(map[string]any{"type": "rewind"})
+	event = receiveEvent(t, ws)
+	assert.Equal(t, "error", event["type"])
+	assert.Contains(t, event["message"], `unknown message type "rewind"`)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Conceptual-Machines/magda-api/internal/debugcapture"
+	"github.com/gin-gonic/gin"
+)
+
+// DebugHandler lists the LLM provider calls kept by debug capture
+type DebugHandler struct {
+	buffer *debugcapture.Buffer
+}
+
+func NewDebugHandler(buffer *debugcapture.Buffer) *DebugHandler {
+	return &DebugHandler{buffer: buffer}
+}
+
+// Captures handles GET /api/v1/debug/captures?limit=&request_id=: the kept provider calls,
+// newest first, with redacted payloads. request_id keeps one request's calls.
+func (h *DebugHandler) Captures(c *gin.Context) {
+	if h.buffer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Debug capture is off (DEBUG_CAPTURE=off)"})
+		return
+	}
+
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil || limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+			return
+		}
+	}
+
+	captures := h.buffer.List(0)
+	if requestID := c.Query("request_id"); requestID != "" {
+		matching := captures[:0]
+		for _, capture := range captures {
+			if capture.RequestID == requestID {
+				matching = append(matching, capture)
+			}
+		}
+		captures = matching
+	}
+	if limit > 0 && len(captures) > limit {
+		captures = captures[:limit]
+	}
+
+	c.JSON(http.StatusOK, gin.H{"captures": captures})
+}
+
+// ClearCaptures handles DELETE /api/v1/debug/captures
+func (h *DebugHandler) ClearCaptures(c *gin.Context) {
+	if h.buffer == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Debug capture is off (DEBUG_CAPTURE=off)"})
+		return
+	}
+	h.buffer.Clear()
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/debugcapture"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupDebugRouter(buffer *debugcapture.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewDebugHandler(buffer)
+	router.GET("/api/v1/debug/captures", handler.Captures)
+	router.DELETE("/api/v1/debug/captures", handler.ClearCaptures)
+	return router
+}
+
+func TestDebugCaptures(t *testing.T) {
+	t.Run("off", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		setupDebugRouter(nil).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/debug/captures", nil))
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	buffer := debugcapture.Enable(10)
+	t.Cleanup(debugcapture.Disable)
+	for _, requestID := range []string{"req-1", "req-2", "req-1"} {
+		debugcapture.Record(logger.WithRequestID(context.Background(), requestID), debugcapture.Capture{
+			Provider: "openai",
+			Request:  json.RawMessage(`{"input":"from ana@example.com"}`),
+		})
+	}
+	router := setupDebugRouter(buffer)
+
+	list := func(query string) []debugcapture.Capture {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/debug/captures"+query, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.NotContains(t, w.Body.String(), "ana@example.com")
+		var response struct {
+			Captures []debugcapture.Capture `json:"captures"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response.Captures
+	}
+
+	captures := list("")
+	require.Len(t, captures, 3)
+	assert.Equal(t, int64(3), captures[0].ID)
+
+	captures = list("?request_id=req-1&limit=1")
+	require.Len(t, captures, 1)
+	assert.Equal(t, int64(3), captures[0].ID)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/debug/captures?limit=0", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/debug/captures", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, list(""))
+}
@@ -1,21 +1,18 @@
 package handlers
 
 import (
-	"context"
-	"log"
 	"net/http"
-	"time"
 
 	magdaconfig "github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	"github.com/Conceptual-Machines/magda-api/internal/agents/shared/drummer"
 	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/gin-gonic/gin"
 )
 
 const (
 	defaultDrummerModel = "gpt-5.1"
-	drummerTimeoutSecs  = 120
 )
 
 type DrummerHandler struct {
@@ -26,7 +23,16 @@ type DrummerHandler struct {
 func NewDrummerHandler(cfg *config.Config) *DrummerHandler {
 	// Convert config to magda-agents config
 	magdaCfg := &magdaconfig.Config{
-		OpenAIAPIKey: cfg.OpenAIAPIKey,
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+		LLMCache:         cfg.LLMCache,
+		LLMCacheTTL:      cfg.LLMCacheTTL,
+		LLMCacheSize:     cfg.LLMCacheSize,
+		RedisURL:         cfg.RedisURL,
 	}
 	agent := drummer.NewDrummerAgent(magdaCfg)
 
@@ -56,7 +62,7 @@ func (h *DrummerHandler) Generate(c *gin.Context) {
 
 	// Get user from gateway headers (for logging - auth handled by gateway)
 	userID, _ := middleware.GetUserIDFromGateway(c)
-	log.Printf("🥁 Drummer request from user %s", userID)
+	logger.Printf(c.Request.Context(), "🥁 Drummer request from user %s", userID)
 
 	// Use requested model or default
 	model := req.Model
@@ -64,14 +70,17 @@ func (h *DrummerHandler) Generate(c *gin.Context) {
 		model = defaultDrummerModel
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(c.Request.Context(), drummerTimeoutSecs*time.Second)
-	defer cancel()
+	// The route's GENERATE_TIMEOUT deadline is on the request context
+	ctx := c.Request.Context()
 
 	// Call the drummer agent
 	result, err := h.agent.Generate(ctx, model, req.InputArray)
 	if err != nil {
-		log.Printf("❌ Drummer generation failed: %v", err)
+		logger.Printf(c.Request.Context(), "❌ Drummer generation failed: %v", err)
+		if middleware.TimedOut(c, err) {
+			c.JSON(http.StatusGatewayTimeout, middleware.TimeoutError(c))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,7 +89,7 @@ func (h *DrummerHandler) Generate(c *gin.Context) {
 	response := DrummerResponse{
 		DSL:     result.DSL,
 		Actions: result.Actions,
-		Usage:   result.Usage,
+		Usage:   middleware.RecordUsage(c, model, result.Usage),
 	}
 
 	c.JSON(http.StatusOK, response)
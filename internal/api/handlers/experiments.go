@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Conceptual-Machines/magda-api/internal/experiments"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// promptVariantInfo is a prompt variant as the admin endpoints report it, without its prompt
+type promptVariantInfo struct {
+	ID     string  `json:"id"`
+	Weight int     `json:"weight"`
+	Share  float64 `json:"share"` // Fraction of LLM requests the variant gets
+}
+
+// PromptExperiments handles GET /api/v1/admin/experiments: the prompt variants under test and
+// their traffic weights
+func (h *MagdaHandler) PromptExperiments(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"variants": promptVariants(h.experiments)})
+}
+
+// SetPromptExperimentWeights handles PUT /api/v1/admin/experiments with
+// {"weights": {"control": 90, "terse-v2": 10}}. Variants not named keep their weight.
+func (h *MagdaHandler) SetPromptExperimentWeights(c *gin.Context) {
+	var body struct {
+		Weights map[string]int `json:"weights" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if h.experiments == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "No prompt experiments are registered (PROMPT_EXPERIMENTS is not set)"})
+		return
+	}
+	if err := h.experiments.SetWeights(body.Weights); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	logger.Printf(c.Request.Context(), "🧪 Prompt experiment weights changed: %v", body.Weights)
+	c.JSON(http.StatusOK, gin.H{"variants": promptVariants(h.experiments)})
+}
+
+func promptVariants(registry *experiments.Registry) []promptVariantInfo {
+	variants := registry.Variants()
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	infos := make([]promptVariantInfo, len(variants))
+	for i, variant := range variants {
+		infos[i] = promptVariantInfo{ID: variant.ID, Weight: variant.Weight}
+		if total > 0 {
+			infos[i].Share = float64(variant.Weight) / float64(total)
+		}
+	}
+	return infos
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/experiments"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromptExperimentEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry, err := experiments.NewRegistry(
+		experiments.Variant{ID: experiments.ControlID, Weight: 3},
+		experiments.Variant{ID: "terse", Weight: 1, Append: "Be terse."},
+	)
+	require.NoError(t, err)
+	h := &MagdaHandler{experiments: registry}
+	router := gin.New()
+	router.GET("/api/v1/admin/experiments", h.PromptExperiments)
+	router.PUT("/api/v1/admin/experiments", h.SetPromptExperimentWeights)
+
+	request := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/admin/experiments", strings.NewReader(body)))
+		return w
+	}
+
+	w := request(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"variants": [
+		{"id": "control", "weight": 3, "share": 0.75},
+		{"id": "terse", "weight": 1, "share": 0.25}
+	]}`, w.Body.String())
+
+	w = request(http.MethodPut, `{"weights": {"terse": 3}}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `{"id":"terse","weight":3,"share":0.5}`)
+
+	w = request(http.MethodPut, `{"weights": {"verbose": 1}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown prompt variant`)
+
+	h.experiments = nil
+	w = request(http.MethodPut, `{"weights": {"terse": 1}}`)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	w = request(http.MethodGet, "")
+	assert.JSONEq(t, `{"variants": []}`, w.Body.String())
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	"github.com/gin-gonic/gin"
+)
+
+// feedbackRequest is the extension's report of applying the actions of a session's last
+// request
+type feedbackRequest struct {
+	SessionID string                 `json:"session_id" binding:"required"`
+	Results   []session.ActionResult `json:"results"` // One per action reported, by index
+}
+
+// validate checks the results against the actions they report on
+func (r *feedbackRequest) validate(actionCount int) error {
+	if len(r.Results) == 0 {
+		return fmt.Errorf("results is required")
+	}
+	seen := make(map[int]bool, len(r.Results))
+	for i, result := range r.Results {
+		if result.ActionIndex < 0 || result.ActionIndex >= actionCount {
+			return fmt.Errorf("results[%d]: action %d doesn't exist (the last request had %d actions)", i, result.ActionIndex, actionCount)
+		}
+		if seen[result.ActionIndex] {
+			return fmt.Errorf("results[%d]: action %d is reported twice", i, result.ActionIndex)
+		}
+		seen[result.ActionIndex] = true
+	}
+	return nil
+}
+
+// Feedback stores how applying the session's last actions went, per action, with the GUIDs of
+// the objects they created. Later requests in the session see the outcome in their history, so
+// the model doesn't assume a failed action took effect.
+// POST /api/v1/magda/feedback
+func (h *MagdaHandler) Feedback(c *gin.Context) {
+	var req feedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	turns, err := h.sessions.History(ctx, req.SessionID)
+	if err != nil {
+		logger.Printf(ctx, "❌ MAGDA feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	index := session.LastActionTurn(turns)
+	if index < 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": session.ErrNoActionTurn.Error()})
+		return
+	}
+	if err := req.validate(len(turns[index].Actions)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.sessions.RecordResults(ctx, req.SessionID, req.Results); err != nil {
+		if errors.Is(err, session.ErrNoActionTurn) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		logger.Printf(ctx, "❌ MAGDA feedback: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	failed := 0
+	for _, result := range req.Results {
+		if !result.Success {
+			failed++
+		}
+	}
+	logger.Printf(ctx, "📬 Session %s: %d action results reported (%d failed)", req.SessionID, len(req.Results), failed)
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": req.SessionID,
+		"question":   turns[index].Question,
+		"recorded":   len(req.Results),
+		"failed":     failed,
+	})
+}
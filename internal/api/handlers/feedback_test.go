@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeedback(t *testing.T) {
+	ctx := context.Background()
+	h := &MagdaHandler{sessions: session.NewMemoryStore(0, 0)}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/magda/feedback", h.Feedback)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/magda/feedback", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"session_id": "s1", "results": [{"action_index": 0, "success": true}]}`)
+	assert.Equal(t, http.StatusNotFound, w.Code, "no turn with actions yet")
+
+	actions := []map[string]any{
+		{"action": "create_track", "index": 1, "name": "Bass"},
+		{"action": "add_fx", "track": 1, "fxname": "ValhallaRoom"},
+	}
+	require.NoError(t, h.sessions.Append(ctx, "s1", session.Turn{Question: "bass with reverb", Actions: actions}))
+
+	for name, body := range map[string]string{
+		"no session":        `{"results": [{"action_index": 0, "success": true}]}`,
+		"no results":        `{"session_id": "s1", "results": []}`,
+		"index past end":    `{"session_id": "s1", "results": [{"action_index": 2, "success": true}]}`,
+		"reported twice":    `{"session_id": "s1", "results": [{"action_index": 0, "success": true}, {"action_index": 0}]}`,
+		"negative index":    `{"session_id": "s1", "results": [{"action_index": -1}]}`,
+		"not a result list": `{"session_id": "s1", "results": {"action_index": 0}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := post(body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	w = post(`{"session_id": "s1", "results": [` +
+		`{"action_index": 0, "success": true, "guid": "{AB12}"}, ` +
+		`{"action_index": 1, "success": false, "error": "plugin wasn't found"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `2`, jsonField(t, w, "recorded"))
+	assert.JSONEq(t, `1`, jsonField(t, w, "failed"))
+
+	// The next request's history carries the outcome
+	turns, err := h.sessions.History(ctx, "s1")
+	require.NoError(t, err)
+	assert.Contains(t, session.SummarizeHistory(turns, 0), `#2 add_fx FAILED: "plugin wasn't found"`)
+}
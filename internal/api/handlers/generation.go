@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"time"
 
@@ -11,6 +10,7 @@ import (
 	magdaarranger "github.com/Conceptual-Machines/magda-api/internal/agents/shared/arranger"
 	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/openai/openai-go/responses"
 )
@@ -29,8 +29,17 @@ type GenerationHandler struct {
 func NewGenerationHandler(cfg *config.Config) *GenerationHandler {
 	// Convert config to magda-agents config
 	magdaCfg := &magdaconfig.Config{
-		OpenAIAPIKey: cfg.OpenAIAPIKey,
-		MCPServerURL: cfg.MCPServerURL,
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+		MCPServerURL:     cfg.MCPServerURL,
+		LLMCache:         cfg.LLMCache,
+		LLMCacheTTL:      cfg.LLMCacheTTL,
+		LLMCacheSize:     cfg.LLMCacheSize,
+		RedisURL:         cfg.RedisURL,
 	}
 	baseService := magdaarranger.NewGenerationService(magdaCfg)
 
@@ -139,8 +148,17 @@ func (h *GenerationHandler) generateOneShot(c *gin.Context, req GenerateRequest,
 
 	// Create a service with the selected provider
 	magdaCfg := &magdaconfig.Config{
-		OpenAIAPIKey: h.cfg.OpenAIAPIKey,
-		MCPServerURL: h.cfg.MCPServerURL,
+		OpenAIAPIKey:     h.cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  h.cfg.AnthropicAPIKey,
+		AnthropicModel:   h.cfg.AnthropicModel,
+		LLMProvider:      h.cfg.LLMProvider,
+		LLMFallback:      h.cfg.LLMFallback,
+		LLMMockResponses: h.cfg.LLMMockResponses,
+		MCPServerURL:     h.cfg.MCPServerURL,
+		LLMCache:         h.cfg.LLMCache,
+		LLMCacheTTL:      h.cfg.LLMCacheTTL,
+		LLMCacheSize:     h.cfg.LLMCacheSize,
+		RedisURL:         h.cfg.RedisURL,
 	}
 	genService := magdaarranger.NewGenerationService(magdaCfg)
 
@@ -160,7 +178,7 @@ func (h *GenerationHandler) generateOneShot(c *gin.Context, req GenerateRequest,
 	reasoningTokens := h.extractReasoningTokens(result.Usage)
 
 	// TODO: Log usage/metrics here (Sentry, database, etc.)
-	log.Printf("📊 Token usage - Total: %d, Input: %d, Output: %d, Reasoning: %d, Duration: %v",
+	logger.Printf(c.Request.Context(), "📊 Token usage - Total: %d, Input: %d, Output: %d, Reasoning: %d, Duration: %v",
 		totalTokens, inputTokens, outputTokens, reasoningTokens, duration)
 	// Deduct credits (may go negative up to -50)
 	// deductErr := h.creditsService.DeductCredits(userID, creditsCharged)
@@ -189,7 +207,7 @@ func (h *GenerationHandler) generateOneShot(c *gin.Context, req GenerateRequest,
 	response := gin.H{
 		"request_id":    c.GetString("request_id"),
 		"output_parsed": result.OutputParsed,
-		"usage":         result.Usage,
+		"usage":         middleware.RecordUsage(c, model, result.Usage),
 		// "credits_charged":   creditsCharged,
 		// "credits_remaining": creditsRemaining,
 	}
@@ -280,8 +298,17 @@ func (h *GenerationHandler) generateStream(c *gin.Context, req GenerateRequest,
 
 	// Create a service (uses default OpenAI provider from config)
 	magdaCfg := &magdaconfig.Config{
-		OpenAIAPIKey: h.cfg.OpenAIAPIKey,
-		MCPServerURL: h.cfg.MCPServerURL,
+		OpenAIAPIKey:     h.cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  h.cfg.AnthropicAPIKey,
+		AnthropicModel:   h.cfg.AnthropicModel,
+		LLMProvider:      h.cfg.LLMProvider,
+		LLMFallback:      h.cfg.LLMFallback,
+		LLMMockResponses: h.cfg.LLMMockResponses,
+		MCPServerURL:     h.cfg.MCPServerURL,
+		LLMCache:         h.cfg.LLMCache,
+		LLMCacheTTL:      h.cfg.LLMCacheTTL,
+		LLMCacheSize:     h.cfg.LLMCacheSize,
+		RedisURL:         h.cfg.RedisURL,
 	}
 	genService := magdaarranger.NewGenerationService(magdaCfg)
 
@@ -328,8 +355,9 @@ func (h *GenerationHandler) generateStream(c *gin.Context, req GenerateRequest,
 	reasoningTokens := h.extractReasoningTokens(result.Usage)
 
 	// TODO: Log usage/metrics here (Sentry, database, etc.)
-	log.Printf("📊 Token usage (streaming) - Total: %d, Input: %d, Output: %d, Reasoning: %d, Duration: %v",
+	logger.Printf(c.Request.Context(), "📊 Token usage (streaming) - Total: %d, Input: %d, Output: %d, Reasoning: %d, Duration: %v",
 		totalTokens, inputTokens, outputTokens, reasoningTokens, duration)
+	middleware.RecordUsage(c, model, result.Usage)
 
 	// Send final result event with complete output_parsed data
 	finalEvent := magdaarranger.StreamEvent{
@@ -4,10 +4,17 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/health"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// readinessHTTPTimeout bounds each dependency call made by /readyz
+const readinessHTTPTimeout = 5 * time.Second
+
 // HealthCheck returns the health status of the API
 func HealthCheck(c *gin.Context) {
 	mcpURL := os.Getenv("MCP_SERVER_URL")
@@ -25,3 +32,43 @@ func HealthCheck(c *gin.Context) {
 		},
 	})
 }
+
+// Liveness reports that the process is up and serving requests
+// GET /healthz
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// ReadinessHandler reports whether the API's dependencies are usable
+type ReadinessHandler struct {
+	checker *health.Checker
+}
+
+// NewReadinessHandler creates readiness checks for the configured providers, Langfuse and config
+func NewReadinessHandler(cfg *config.Config) *ReadinessHandler {
+	client := &http.Client{Timeout: readinessHTTPTimeout}
+	return &ReadinessHandler{checker: health.NewChecker(health.ChecksFromConfig(cfg, client)...)}
+}
+
+// Readiness returns per-dependency status; 503 when a required dependency failed
+// GET /readyz
+func (h *ReadinessHandler) Readiness(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+
+	status := http.StatusOK
+	state := "ready"
+	if !report.Ready {
+		status = http.StatusServiceUnavailable
+		state = "not_ready"
+		for _, dependency := range report.Dependencies {
+			if dependency.Required && dependency.Status != health.StatusOK {
+				logger.Printf(c.Request.Context(), "❌ Readiness: %s failed: %s", dependency.Name, dependency.Message)
+			}
+		}
+	}
+
+	c.JSON(status, gin.H{
+		"status":       state,
+		"dependencies": report.Dependencies,
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/jobs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// SubmitJob queues a chat request and returns at once with its job ID; the result is fetched
+// from GET /api/v1/jobs/:id
+// POST /api/v1/jobs (the /chat request body)
+func (h *MagdaHandler) SubmitJob(c *gin.Context) {
+	var req MagdaChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Printf(c.Request.Context(), "❌ MAGDA SubmitJob: JSON binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, err := h.requestContext(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx = h.withSessionHistory(ctx, req.SessionID)
+	if !h.withSessionState(ctx, c, &req) {
+		return
+	}
+
+	// The job runs after this request returns, so it gets its own copy of the gin context, and
+	// as long to finish as a streamed request, counted from now
+	jobContext := c.Copy()
+	jobContext.Request = c.Request.WithContext(context.WithoutCancel(c.Request.Context()))
+	job, err := h.jobQueue.Submit(ctx, h.cfg.StreamTimeout, func(ctx context.Context, report *jobs.Reporter) (map[string]any, error) {
+		return h.runJob(ctx, jobContext, &req, report)
+	})
+	if errors.Is(err, jobs.ErrQueueFull) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		logger.Printf(c.Request.Context(), "❌ MAGDA SubmitJob: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job_id":     job.ID,
+		"status":     job.Status,
+		"status_url": "/api/v1/jobs/" + job.ID,
+		"request_id": c.GetString("request_id"),
+	})
+}
+
+// runJob streams the chat request so the job reports progress, and returns the same fields as
+// /chat
+func (h *MagdaHandler) runJob(ctx context.Context, c *gin.Context, req *MagdaChatRequest, report *jobs.Reporter) (map[string]any, error) {
+	ctx = magdadaw.WithTextDeltaCallback(ctx, func(string) error {
+		report.Token()
+		return nil
+	})
+	result, err := h.orchestrator.GenerateActionsStream(ctx, req.Question, req.State, func(map[string]any) error {
+		report.Action()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !req.Preview {
+		h.recordTurn(ctx, req.SessionID, req.Question, result)
+	}
+	recordAudit(c, req, result)
+	h.beginTransaction(ctx, req, result)
+	response := completedEvent(c, req, result)
+	delete(response, "type")
+	return response, nil
+}
+
+// GetJob reports a job's status and progress, and its result once done
+// GET /api/v1/jobs/:id
+func (h *MagdaHandler) GetJob(c *gin.Context) {
+	job, err := h.jobQueue.Get(c.Request.Context(), c.Param("id"))
+	h.writeJob(c, job, err)
+}
+
+// CancelJob cancels a queued or running job. Finished jobs are returned as they are.
+// DELETE /api/v1/jobs/:id
+func (h *MagdaHandler) CancelJob(c *gin.Context) {
+	job, err := h.jobQueue.Cancel(c.Request.Context(), c.Param("id"))
+	h.writeJob(c, job, err)
+}
+
+func (h *MagdaHandler) writeJob(c *gin.Context, job *jobs.Job, err error) {
+	switch {
+	case err != nil:
+		logger.Printf(c.Request.Context(), "❌ MAGDA jobs: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case job == nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "job not found"})
+	default:
+		c.JSON(http.StatusOK, job)
+	}
+}
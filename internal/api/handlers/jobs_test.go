@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/jobs"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &MagdaHandler{jobQueue: jobs.NewQueue(jobs.NewMemoryStore(time.Hour), 1, 1)}
+	router := gin.New()
+	router.GET("/api/v1/jobs/:id", h.GetJob)
+	router.DELETE("/api/v1/jobs/:id", h.CancelJob)
+
+	job, err := h.jobQueue.Submit(context.Background(), 0, func(ctx context.Context, report *jobs.Reporter) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+
+	request := func(method, id string) (int, jobs.Job) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, "/api/v1/jobs/"+id, nil))
+		var body jobs.Job
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	code, body := request(http.MethodGet, job.ID)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, job.ID, body.ID)
+
+	code, body = request(http.MethodDelete, job.ID)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, jobs.StatusCancelled, body.Status)
+
+	code, _ = request(http.MethodGet, "unknown")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = request(http.MethodDelete, "unknown")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"runtime/debug"
 	"strings"
@@ -12,9 +11,13 @@ import (
 	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/jsfx"
 	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/gin-gonic/gin"
 )
 
+// jsfxModel generates and describes JSFX effects
+const jsfxModel = "gpt-5.2"
+
 // Log truncation limits
 const (
 	logMessageMaxLen  = 200
@@ -36,7 +39,16 @@ type JSFXHandler struct {
 func NewJSFXHandler(cfg *config.Config) *JSFXHandler {
 	// Create agent config from API config
 	agentCfg := &agentconfig.Config{
-		OpenAIAPIKey: cfg.OpenAIAPIKey,
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+		LLMCache:         cfg.LLMCache,
+		LLMCacheTTL:      cfg.LLMCacheTTL,
+		LLMCacheSize:     cfg.LLMCacheSize,
+		RedisURL:         cfg.RedisURL,
 	}
 
 	return &JSFXHandler{
@@ -64,8 +76,8 @@ func (h *JSFXHandler) Generate(c *gin.Context) {
 	// Panic recovery
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("❌ JSFX Generate: PANIC recovered: %v", r)
-			log.Printf("   Stack trace:\n%s", string(debug.Stack()))
+			logger.Printf(c.Request.Context(), "❌ JSFX Generate: PANIC recovered: %v", r)
+			logger.Printf(c.Request.Context(), "   Stack trace:\n%s", string(debug.Stack()))
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":      fmt.Sprintf("Internal server error: %v", r),
 				"request_id": c.GetString("request_id"),
@@ -75,20 +87,20 @@ func (h *JSFXHandler) Generate(c *gin.Context) {
 
 	var req JSFXGenerateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ JSFX Generate: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ JSFX Generate: JSON binding error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Log request
-	log.Printf("📨 JSFX Generate: Received request")
-	log.Printf("   Message: %s", truncateStr(req.Message, logMessageMaxLen))
-	log.Printf("   Code length: %d bytes", len(req.Code))
-	log.Printf("   Filename: %s", req.Filename)
+	logger.Printf(c.Request.Context(), "📨 JSFX Generate: Received request")
+	logger.Printf(c.Request.Context(), "   Message: %s", truncateStr(req.Message, logMessageMaxLen))
+	logger.Printf(c.Request.Context(), "   Code length: %d bytes", len(req.Code))
+	logger.Printf(c.Request.Context(), "   Filename: %s", req.Filename)
 
 	// Get user from gateway headers (if authenticated)
 	if userID, ok := middleware.GetUserIDFromGateway(c); ok {
-		log.Printf("   User ID: %s", userID)
+		logger.Printf(c.Request.Context(), "   User ID: %s", userID)
 	}
 
 	// Build input messages for the agent
@@ -122,9 +134,9 @@ func (h *JSFXHandler) Generate(c *gin.Context) {
 
 	// Call JSFX agent
 	ctx := c.Request.Context()
-	result, err := h.agent.Generate(ctx, "gpt-5.2", inputArray)
+	result, err := h.agent.Generate(ctx, jsfxModel, inputArray)
 	if err != nil {
-		log.Printf("❌ JSFX Generate: Agent error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ JSFX Generate: Agent error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":      fmt.Sprintf("JSFX generation failed: %v", err),
 			"request_id": c.GetString("request_id"),
@@ -132,6 +144,8 @@ func (h *JSFXHandler) Generate(c *gin.Context) {
 		return
 	}
 
+	middleware.RecordUsage(c, jsfxModel, result.Usage)
+
 	// Build response
 	response := JSFXGenerateResponse{
 		JSFXCode:     result.JSFXCode,
@@ -141,19 +155,19 @@ func (h *JSFXHandler) Generate(c *gin.Context) {
 	// Set appropriate message based on result
 	if result.CompileError != "" {
 		response.Message = msgJSFXCompileError
-		log.Printf("⚠️ JSFX Generate: Compile error: %s", result.CompileError)
+		logger.Printf(c.Request.Context(), "⚠️ JSFX Generate: Compile error: %s", result.CompileError)
 	} else {
 		response.Message = msgJSFXSuccess
-		log.Printf("✅ JSFX Generate: Success")
+		logger.Printf(c.Request.Context(), "✅ JSFX Generate: Success")
 	}
 
 	// Log response
 	responseJSON, _ := json.Marshal(response)
-	log.Printf("   JSFX length: %d bytes", len(result.JSFXCode))
+	logger.Printf(c.Request.Context(), "   JSFX length: %d bytes", len(result.JSFXCode))
 	if result.CompileError != "" {
-		log.Printf("   Compile error: %s", result.CompileError)
+		logger.Printf(c.Request.Context(), "   Compile error: %s", result.CompileError)
 	}
-	log.Printf("   Response preview: %s", truncateStr(string(responseJSON), logResponseMaxLen))
+	logger.Printf(c.Request.Context(), "   Response preview: %s", truncateStr(string(responseJSON), logResponseMaxLen))
 
 	// Use PureJSON to avoid HTML-escaping < and > in JSFX code
 	c.PureJSON(http.StatusOK, response)
@@ -173,8 +187,8 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 	// Panic recovery
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("❌ JSFX Stream: PANIC recovered: %v", r)
-			log.Printf("   Stack trace:\n%s", string(debug.Stack()))
+			logger.Printf(c.Request.Context(), "❌ JSFX Stream: PANIC recovered: %v", r)
+			logger.Printf(c.Request.Context(), "   Stack trace:\n%s", string(debug.Stack()))
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":      fmt.Sprintf("Internal server error: %v", r),
 				"request_id": c.GetString("request_id"),
@@ -184,16 +198,16 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 
 	var req JSFXGenerateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ JSFX Stream: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ JSFX Stream: JSON binding error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Log request
-	log.Printf("📨 JSFX Stream: Received request (TRUE STREAMING)")
-	log.Printf("   Message: %s", truncateStr(req.Message, logMessageMaxLen))
-	log.Printf("   Code length: %d bytes", len(req.Code))
-	log.Printf("   Filename: %s", req.Filename)
+	logger.Printf(c.Request.Context(), "📨 JSFX Stream: Received request (TRUE STREAMING)")
+	logger.Printf(c.Request.Context(), "   Message: %s", truncateStr(req.Message, logMessageMaxLen))
+	logger.Printf(c.Request.Context(), "   Code length: %d bytes", len(req.Code))
+	logger.Printf(c.Request.Context(), "   Filename: %s", req.Filename)
 
 	// Set up SSE headers
 	c.Header("Content-Type", "text/event-stream")
@@ -205,11 +219,11 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 	sendEvent := func(event map[string]any) error {
 		eventJSON, err := json.Marshal(event)
 		if err != nil {
-			log.Printf("❌ JSFX Stream: Failed to marshal event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ JSFX Stream: Failed to marshal event: %v", err)
 			return err
 		}
 		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON); err != nil {
-			log.Printf("❌ JSFX Stream: Failed to write SSE event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ JSFX Stream: Failed to write SSE event: %v", err)
 			return err
 		}
 		c.Writer.Flush()
@@ -224,7 +238,7 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 
 	// Get user from gateway headers (if authenticated)
 	if userID, ok := middleware.GetUserIDFromGateway(c); ok {
-		log.Printf("   User ID: %s", userID)
+		logger.Printf(c.Request.Context(), "   User ID: %s", userID)
 	}
 
 	// Build input messages for the agent
@@ -274,10 +288,10 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 	}
 
 	// Call JSFX agent with TRUE streaming - chunks arrive in real-time from OpenAI
-	log.Printf("🚀 JSFX Stream: Starting true streaming generation...")
-	result, err := h.agent.GenerateStream(ctx, "gpt-5.2", inputArray, streamCallback)
+	logger.Printf(c.Request.Context(), "🚀 JSFX Stream: Starting true streaming generation...")
+	result, err := h.agent.GenerateStream(ctx, jsfxModel, inputArray, streamCallback)
 	if err != nil {
-		log.Printf("❌ JSFX Stream: Agent error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ JSFX Stream: Agent error: %v", err)
 		_ = sendEvent(map[string]any{
 			"type":    "error",
 			"message": fmt.Sprintf("JSFX generation failed: %v", err),
@@ -285,7 +299,7 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ JSFX Stream: Completed with %d chunks streamed", chunkCount)
+	logger.Printf(c.Request.Context(), "✅ JSFX Stream: Completed with %d chunks streamed", chunkCount)
 
 	// Build final code (use result or streamed output)
 	finalCode := result.JSFXCode
@@ -296,13 +310,13 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 	// Generate description for the code (separate fast call)
 	var description string
 	if finalCode != "" {
-		log.Printf("📝 JSFX Stream: Generating description...")
-		desc, descErr := h.agent.DescribeJSFX(ctx, "gpt-5.2", finalCode)
+		logger.Printf(c.Request.Context(), "📝 JSFX Stream: Generating description...")
+		desc, descErr := h.agent.DescribeJSFX(ctx, jsfxModel, finalCode)
 		if descErr != nil {
-			log.Printf("⚠️ JSFX Stream: Description generation failed: %v", descErr)
+			logger.Printf(c.Request.Context(), "⚠️ JSFX Stream: Description generation failed: %v", descErr)
 		} else {
 			description = desc
-			log.Printf("✅ JSFX Stream: Description generated (%d chars)", len(description))
+			logger.Printf(c.Request.Context(), "✅ JSFX Stream: Description generated (%d chars)", len(description))
 		}
 	}
 
@@ -319,6 +333,6 @@ func (h *JSFXHandler) GenerateStream(c *gin.Context) {
 		"description":   description,
 		"compile_error": result.CompileError,
 		"message":       message,
-		"usage":         result.Usage,
+		"usage":         middleware.RecordUsage(c, jsfxModel, result.Usage),
 	})
 }
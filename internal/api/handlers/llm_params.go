@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+)
+
+// maxTemperature is the highest sampling temperature the providers accept
+const maxTemperature = 2.0
+
+// validateLLMParams checks a request's LLM overrides against the server's allowlists
+func validateLLMParams(cfg *config.Config, params magdadaw.LLMParams) error {
+	var models, efforts []string
+	var maxTokens int
+	if cfg != nil {
+		models, efforts = splitList(cfg.LLMOverrideModels), splitList(cfg.LLMOverrideReasoning)
+		maxTokens = cfg.LLMMaxOutputTokens
+	}
+
+	if params.Model != "" && !slices.Contains(models, params.Model) {
+		if len(models) == 0 {
+			return fmt.Errorf("llm.model: model overrides are disabled on this server")
+		}
+		return fmt.Errorf("llm.model: %q is not allowed (allowed: %s)", params.Model, strings.Join(models, ", "))
+	}
+	if params.ReasoningEffort != "" && !slices.Contains(efforts, params.ReasoningEffort) {
+		return fmt.Errorf("llm.reasoning_effort: %q is not allowed (allowed: %s)", params.ReasoningEffort, strings.Join(efforts, ", "))
+	}
+	if t := params.Temperature; t != nil && (*t < 0 || *t > maxTemperature) {
+		return fmt.Errorf("llm.temperature: %v is outside 0-%v", *t, maxTemperature)
+	}
+	if params.MaxOutputTokens < 0 || params.MaxOutputTokens > maxTokens {
+		return fmt.Errorf("llm.max_output_tokens: %d is outside 1-%d", params.MaxOutputTokens, maxTokens)
+	}
+	return nil
+}
+
+// splitList splits a comma-separated config value, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
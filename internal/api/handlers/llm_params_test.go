@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"testing"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLLMParams(t *testing.T) {
+	cfg := &config.Config{
+		LLMOverrideModels:    "gpt-5.1, gpt-5.2",
+		LLMOverrideReasoning: "none,high,xhigh",
+		LLMMaxOutputTokens:   16000,
+	}
+	temperature := func(t float64) *float64 { return &t }
+
+	tests := []struct {
+		name    string
+		cfg     *config.Config
+		params  magdadaw.LLMParams
+		wantErr string
+	}{
+		{
+			name:   "allowed overrides",
+			cfg:    cfg,
+			params: magdadaw.LLMParams{Model: "gpt-5.2", ReasoningEffort: "xhigh", Temperature: temperature(0.2), MaxOutputTokens: 8000},
+		},
+		{
+			name:    "model not allowed",
+			cfg:     cfg,
+			params:  magdadaw.LLMParams{Model: "gpt-5-nano"},
+			wantErr: `llm.model: "gpt-5-nano" is not allowed (allowed: gpt-5.1, gpt-5.2)`,
+		},
+		{
+			name:    "model overrides disabled",
+			cfg:     &config.Config{},
+			params:  magdadaw.LLMParams{Model: "gpt-5.2"},
+			wantErr: "model overrides are disabled",
+		},
+		{
+			name:    "reasoning effort not allowed",
+			cfg:     cfg,
+			params:  magdadaw.LLMParams{ReasoningEffort: "medium"},
+			wantErr: `llm.reasoning_effort: "medium" is not allowed`,
+		},
+		{
+			name:    "temperature out of range",
+			cfg:     cfg,
+			params:  magdadaw.LLMParams{Temperature: temperature(2.5)},
+			wantErr: "llm.temperature: 2.5 is outside 0-2",
+		},
+		{
+			name:    "too many output tokens",
+			cfg:     cfg,
+			params:  magdadaw.LLMParams{MaxOutputTokens: 64000},
+			wantErr: "llm.max_output_tokens: 64000 is outside 1-16000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLLMParams(tt.cfg, tt.params)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"runtime/debug"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	magdaconfig "github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	magdaorchestrator "github.com/Conceptual-Machines/magda-api/internal/agents/core/coordination"
@@ -15,8 +20,16 @@ import (
 	magdaplugin "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/plugin"
 	magdamix "github.com/Conceptual-Machines/magda-api/internal/agents/shared/mix"
 	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
+	"github.com/Conceptual-Machines/magda-api/internal/audit"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/experiments"
+	"github.com/Conceptual-Machines/magda-api/internal/jobs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
 	"github.com/Conceptual-Machines/magda-api/internal/observability"
+	"github.com/Conceptual-Machines/magda-api/internal/plugins"
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	"github.com/Conceptual-Machines/magda-api/internal/transactions"
 	"github.com/gin-gonic/gin"
 )
 
@@ -29,7 +42,12 @@ type MagdaHandler struct {
 	orchestrator  *magdaorchestrator.Orchestrator
 	pluginService *magdaplugin.PluginAgent
 	mixAgent      *magdamix.MixAnalysisAgent
+	sessions      session.Store
+	jobQueue      *jobs.Queue           // Async chat requests (/api/v1/jobs)
+	transactions  transactions.Store    // Returned action batches awaiting the extension's result
+	experiments   *experiments.Registry // Prompt variants under A/B test, nil without any
 	cfg           *config.Config
+	channels      *controlChannels // Open /ws control channels, by session
 }
 
 // Plugin types from magda-agents
@@ -40,30 +58,170 @@ type Preferences = magdaplugin.Preferences
 func NewMagdaHandler(cfg *config.Config) *MagdaHandler {
 	// Convert magda-api config to magda-agents config
 	magdaCfg := &magdaconfig.Config{
-		OpenAIAPIKey: cfg.OpenAIAPIKey,
-		MCPServerURL: cfg.MCPServerURL,
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+		MCPServerURL:     cfg.MCPServerURL,
+		LLMCache:         cfg.LLMCache,
+		LLMCacheTTL:      cfg.LLMCacheTTL,
+		LLMCacheSize:     cfg.LLMCacheSize,
+		RedisURL:         cfg.RedisURL,
+
+		ModelRouting:        cfg.ModelRouting,
+		ModelRoutingSimple:  cfg.ModelRoutingSimple,
+		ModelRoutingComplex: cfg.ModelRoutingComplex,
+		PromptLanguage:      cfg.PromptLanguage,
+		DSLParseRetries:     cfg.DSLParseRetries,
+		ScopeCheck:          cfg.ScopeCheck,
+	}
+
+	sessions, err := session.NewStore(cfg.SessionStore, cfg.RedisURL, cfg.SessionTTL)
+	if err != nil {
+		log.Printf("⚠️  Session store %q unavailable, using in-memory sessions: %v", cfg.SessionStore, err)
+		sessions = session.NewMemoryStore(cfg.SessionTTL, session.DefaultMaxTurns)
+	}
+	jobStore, err := jobs.NewStore(cfg.JobStore, cfg.RedisURL, cfg.JobTTL)
+	if err != nil {
+		log.Printf("⚠️  Job store %q unavailable, using in-memory jobs: %v", cfg.JobStore, err)
+		jobStore = jobs.NewMemoryStore(cfg.JobTTL)
+	}
+	// Transactions are reported back within a session's lifetime, so they share its store
+	transactionStore, err := transactions.NewStore(cfg.SessionStore, cfg.RedisURL, cfg.SessionTTL)
+	if err != nil {
+		log.Printf("⚠️  Transaction store %q unavailable, using in-memory transactions: %v", cfg.SessionStore, err)
+		transactionStore = transactions.NewMemoryStore(cfg.SessionTTL)
+	}
+	promptExperiments, err := experiments.Load(cfg.PromptExperiments)
+	if err != nil {
+		log.Printf("⚠️  Prompt experiments disabled: %v", err)
 	}
 
 	return &MagdaHandler{
 		orchestrator:  magdaorchestrator.NewOrchestrator(magdaCfg),
 		pluginService: magdaplugin.NewPluginAgent(magdaCfg),
 		mixAgent:      magdamix.NewMixAnalysisAgent(magdaCfg),
+		sessions:      sessions,
+		jobQueue:      jobs.NewQueue(jobStore, cfg.JobWorkers, 0),
+		transactions:  transactionStore,
+		experiments:   promptExperiments,
 		cfg:           cfg,
+		channels:      newControlChannels(),
 	}
 }
 
 type MagdaChatRequest struct {
-	Question string                 `json:"question" binding:"required"`
-	State    map[string]interface{} `json:"state"` // REAPER state snapshot
+	Question     string                  `json:"question" binding:"required"`
+	State        map[string]interface{}  `json:"state"`                    // REAPER state snapshot; plugins lists installed plugins
+	LengthUnit   string                  `json:"length_unit,omitempty"`    // "seconds" (default) or "bars" for bare clip lengths
+	GroupByTrack bool                    `json:"group_by_track,omitempty"` // Also return actions bucketed by target track
+	NoOpSummary  bool                    `json:"noop_summary,omitempty"`   // Report filtered items already in the target state
+	SessionID    string                  `json:"session_id,omitempty"`     // Conversation to resolve follow-ups against
+	Preview      bool                    `json:"preview,omitempty"`        // Describe actions for confirmation; not recorded as executed
+	StateDelta   map[string]interface{}  `json:"state_delta,omitempty"`    // Changes to the session's stored state, instead of state
+	StateVersion int                     `json:"state_version,omitempty"`  // Stored state version state_delta is based on
+	LLM          *magdadaw.LLMParams     `json:"llm,omitempty"`            // Model, reasoning effort, temperature and output limit overrides
+	StrictDSL    bool                    `json:"strict_dsl,omitempty"`     // Parse the generated DSL as written, without repairs
+	Defaults     *models.MusicalDefaults `json:"defaults,omitempty"`       // Octave, velocity, clip length and time signature for parts; kept by the session
+	Confirm      bool                    `json:"confirm,omitempty"`        // Apply deletions beyond the safety policy's limits
+
+	stateVersion int                    // Version of the session state the request runs against, 0 without one
+	transaction  *transactions.Envelope // The actions' transaction, set once they're generated
+}
+
+// requestContext validates request-level options and attaches them to the request context
+func (h *MagdaHandler) requestContext(c *gin.Context, req *MagdaChatRequest) (context.Context, error) {
+	lengthUnit, err := magdadaw.ParseLengthUnit(req.LengthUnit)
+	if err != nil {
+		return nil, err
+	}
+	grammarVersion, err := magdadaw.ParseGrammarVersion(c.GetHeader(magdadaw.GrammarVersionHeader))
+	if err != nil {
+		return nil, err
+	}
+	c.Header(magdadaw.GrammarVersionHeader, string(grammarVersion))
+	ctx := magdadaw.WithLengthUnit(c.Request.Context(), lengthUnit)
+	ctx = magdadaw.WithGrammarVersion(ctx, grammarVersion)
+	if req.LLM != nil {
+		if err := validateLLMParams(h.cfg, *req.LLM); err != nil {
+			return nil, err
+		}
+		ctx = magdadaw.WithLLMParams(ctx, *req.LLM)
+	}
+	if variant := h.experiments.Pick(); variant != nil {
+		ctx = experiments.WithVariant(ctx, variant)
+	}
+	ctx = magdadaw.WithStrictDSL(ctx, req.StrictDSL || h.cfg.DSLRepair == "off")
+	ctx = magdadaw.WithNoOpSummary(ctx, req.NoOpSummary)
+	ctx = magdadaw.WithSafetyPolicy(ctx, magdadaw.SafetyPolicy{
+		Mode:             h.cfg.SafetyGuard,
+		MaxDeletedTracks: h.cfg.SafetyMaxDeletedTracks,
+		MaxDeletedClips:  h.cfg.SafetyMaxDeletedClips,
+		Confirmed:        req.Confirm,
+	})
+	return h.withMusicalDefaults(ctx, req)
+}
+
+// withSessionHistory attaches a summary of the session's recent turns for the DAW prompt.
+// History is best effort: a store failure is logged and the request runs without it.
+func (h *MagdaHandler) withSessionHistory(ctx context.Context, sessionID string) context.Context {
+	if sessionID == "" {
+		return ctx
+	}
+	turns, err := h.sessions.History(ctx, sessionID)
+	if err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to load history: %v", sessionID, err)
+		return ctx
+	}
+	if len(turns) == 0 {
+		return ctx
+	}
+	logger.Printf(ctx, "💬 Session %s: including %d earlier turns", sessionID, min(len(turns), session.DefaultHistoryWindow))
+	return magdadaw.WithConversationHistory(ctx, session.SummarizeHistory(turns, session.DefaultHistoryWindow))
+}
+
+// recordTurn stores a completed request in the session history, along with any musical
+// defaults it set. A clarifying question is stored with it, so the user's reply in the next
+// request is read as the answer.
+func (h *MagdaHandler) recordTurn(ctx context.Context, sessionID, question string, result *magdaorchestrator.OrchestratorResult) {
+	if sessionID == "" {
+		return
+	}
+	// The turn completed even if a streaming client has since disconnected
+	turn := session.Turn{
+		Question:      question,
+		Actions:       result.Actions,
+		Clarification: result.Clarification,
+		Timestamp:     time.Now(),
+	}
+	if err := h.sessions.Append(context.WithoutCancel(ctx), sessionID, turn); err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to store turn: %v", sessionID, err)
+	}
+	h.recordDefaults(ctx, sessionID, result)
+}
+
+// recordAudit appends the request and the actions generated for it to the audit log. Previews
+// are recorded too, flagged as such.
+func recordAudit(c *gin.Context, req *MagdaChatRequest, result *magdaorchestrator.OrchestratorResult) {
+	middleware.RecordAudit(c, audit.Entry{
+		Question: req.Question,
+		DSL:      result.DSL,
+		Actions:  result.Actions,
+		Model:    result.Model,
+		Path:     result.Path,
+		Preview:  req.Preview,
+	})
 }
 
 func (h *MagdaHandler) Chat(c *gin.Context) {
 	// Add panic recovery with detailed logging
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("❌ MAGDA Chat: PANIC recovered: %v", r)
-			log.Printf("   Stack trace:\n%s", string(debug.Stack()))
-			log.Printf("   Request ID: %s", c.GetString("request_id"))
+			logger.Printf(c.Request.Context(), "❌ MAGDA Chat: PANIC recovered: %v", r)
+			logger.Printf(c.Request.Context(), "   Stack trace:\n%s", string(debug.Stack()))
+			logger.Printf(c.Request.Context(), "   Request ID: %s", c.GetString("request_id"))
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":      fmt.Sprintf("Internal server error: %v", r),
 				"request_id": c.GetString("request_id"),
@@ -73,115 +231,200 @@ func (h *MagdaHandler) Chat(c *gin.Context) {
 
 	var req MagdaChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ MAGDA Chat: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MAGDA Chat: JSON binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, err := h.requestContext(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	ctx = h.withSessionHistory(ctx, req.SessionID)
+	if !h.withSessionState(ctx, c, &req) {
+		return
+	}
 
 	// Log incoming request
-	log.Printf("📨 MAGDA Chat: Received request")
-	log.Printf("   Question length: %d", len(req.Question))
+	logger.Printf(c.Request.Context(), "📨 MAGDA Chat: Received request")
+	logger.Printf(c.Request.Context(), "   Question length: %d", len(req.Question))
 	if len(req.Question) > 0 {
 		previewLen := 200
 		if len(req.Question) < previewLen {
 			previewLen = len(req.Question)
 		}
-		log.Printf("   Question preview: %s", req.Question[:previewLen])
+		logger.Printf(c.Request.Context(), "   Question preview: %s", req.Question[:previewLen])
 	}
 	if req.State != nil {
-		log.Printf("   State keys: %d", len(req.State))
+		logger.Printf(c.Request.Context(), "   State keys: %d", len(req.State))
 		// Log state size estimate
 		stateJSON, _ := json.Marshal(req.State)
-		log.Printf("   State JSON size: %d bytes", len(stateJSON))
+		logger.Printf(c.Request.Context(), "   State JSON size: %d bytes", len(stateJSON))
 	} else {
-		log.Printf("   State: nil")
+		logger.Printf(c.Request.Context(), "   State: nil")
 	}
 
 	// Get user from gateway headers (if authenticated)
 	var userID string
 	if id, ok := middleware.GetUserIDFromGateway(c); ok {
 		userID = id
-		log.Printf("   User ID: %s", userID)
+		logger.Printf(c.Request.Context(), "   User ID: %s", userID)
 	}
 
 	// Start Langfuse trace for observability
 	lfClient := observability.GetClient()
-	log.Printf("🔍 Langfuse: Client enabled: %v", lfClient.IsEnabled())
+	logger.Printf(c.Request.Context(), "🔍 Langfuse: Client enabled: %v", lfClient.IsEnabled())
+	var traceTags []string
+	if variant := experiments.VariantID(ctx); variant != "" {
+		traceTags = append(traceTags, "prompt_variant:"+variant)
+	}
 	trace := lfClient.StartTrace(c.Request.Context(), "magda-chat", map[string]interface{}{
-		"question": req.Question,
-		"user_id":  userID,
-	})
-	log.Printf("🔍 Langfuse: Trace created, will finish on defer")
+		"question":       req.Question,
+		"user_id":        userID,
+		"prompt_variant": experiments.VariantID(ctx),
+	}, traceTags...)
+	logger.Printf(c.Request.Context(), "🔍 Langfuse: Trace created, will finish on defer")
 	defer func() {
-		log.Printf("🔍 Langfuse: Finishing trace...")
+		logger.Printf(c.Request.Context(), "🔍 Langfuse: Finishing trace...")
 		trace.Finish()
-		log.Printf("🔍 Langfuse: Trace finished")
+		logger.Printf(c.Request.Context(), "🔍 Langfuse: Trace finished")
 	}()
 
 	// Generate actions from question and state using orchestrator
-	log.Printf("🚀 MAGDA Chat: Calling Orchestrator.GenerateActions")
+	logger.Printf(c.Request.Context(), "🚀 MAGDA Chat: Calling Orchestrator.GenerateActions")
 	gen := trace.Generation("orchestrator", map[string]interface{}{
 		"question": req.Question,
 	})
-	log.Printf("🔍 Langfuse: Generation span created")
+	logger.Printf(c.Request.Context(), "🔍 Langfuse: Generation span created")
 	gen.Input(req.Question)
 
-	result, err := h.orchestrator.GenerateActions(c.Request.Context(), req.Question, req.State)
+	result, err := h.orchestrator.GenerateActions(ctx, req.Question, req.State)
 	if err != nil {
-		log.Printf("❌ MAGDA Chat: GenerateActions error: %v", err)
-		log.Printf("   Error type: %T", err)
-		log.Printf("   Stack trace:\n%s", string(debug.Stack()))
+		logger.Printf(c.Request.Context(), "❌ MAGDA Chat: GenerateActions error: %v", err)
+		logger.Printf(c.Request.Context(), "   Error type: %T", err)
+		logger.Printf(c.Request.Context(), "   Stack trace:\n%s", string(debug.Stack()))
 		gen.SetLevel("ERROR")
 		gen.Output(err.Error())
 		gen.Finish()
+		if middleware.TimedOut(c, err) {
+			c.JSON(http.StatusGatewayTimeout, middleware.TimeoutError(c))
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	// Log result to Langfuse
-	log.Printf("🔍 Langfuse: Setting generation output (%d actions)", len(result.Actions))
+	logger.Printf(c.Request.Context(), "🔍 Langfuse: Setting generation output (%d actions)", len(result.Actions))
 	gen.Output(result.Actions)
 	gen.Metadata(map[string]interface{}{
 		"actions_count": len(result.Actions),
 	})
-	log.Printf("🔍 Langfuse: Finishing generation span...")
+	logger.Printf(c.Request.Context(), "🔍 Langfuse: Finishing generation span...")
 	gen.Finish()
-	log.Printf("🔍 Langfuse: Generation span finished")
+	logger.Printf(c.Request.Context(), "🔍 Langfuse: Generation span finished")
 
 	// Log result
-	log.Printf("✅ MAGDA Chat: GenerateActions succeeded")
-	log.Printf("   Actions count: %d", len(result.Actions))
+	logger.Printf(c.Request.Context(), "✅ MAGDA Chat: GenerateActions succeeded")
+	// A preview isn't executed yet, so it stays out of the session history
+	if !req.Preview {
+		h.recordTurn(ctx, req.SessionID, req.Question, result)
+	}
+	recordAudit(c, &req, result)
+	h.beginTransaction(ctx, &req, result)
+	logger.Printf(c.Request.Context(), "   Actions count: %d", len(result.Actions))
 	if len(result.Actions) > 0 {
 		actionsJSON, _ := json.Marshal(result.Actions)
 		previewLen := 500
 		if len(actionsJSON) < previewLen {
 			previewLen = len(actionsJSON)
 		}
-		log.Printf("   Actions preview: %s", string(actionsJSON[:previewLen]))
+		logger.Printf(c.Request.Context(), "   Actions preview: %s", string(actionsJSON[:previewLen]))
 	}
 	if result.Usage != nil {
-		log.Printf("   Usage: %+v", result.Usage)
+		logger.Printf(c.Request.Context(), "   Usage: %+v", result.Usage)
 	}
 
-	// Build human-readable response text from actions
+	// Build human-readable response text from actions; a query-only request answers instead
 	responseText := buildResponseText(result.Actions)
+	if len(result.Actions) == 0 && result.Answer != "" {
+		responseText = result.Answer
+	}
+	if result.Clarification != nil {
+		responseText = result.Clarification.Question
+	}
+	if result.OutOfScope != nil {
+		responseText = result.OutOfScope.Reason
+	}
+	if result.ConfirmationRequired != nil {
+		responseText = result.ConfirmationRequired.Reason
+	}
 
 	// Build response
 	response := gin.H{
-		"request_id": c.GetString("request_id"),
-		"response":   responseText,
-		"actions":    result.Actions,
-		"usage":      result.Usage,
+		"request_id":   c.GetString("request_id"),
+		"response":     responseText,
+		"actions":      result.Actions,
+		"usage":        middleware.RecordUsage(c, result.Model, result.Usage),
+		"undo_actions": undoActionsOrEmpty(result.UndoActions),
+		"path":         result.Path,
+	}
+	addModelRouting(response, result)
+	addSeed(response, result)
+	addDefaults(response, result)
+	addGrammarVersion(response, result)
+	addTransaction(response, &req)
+	if req.stateVersion > 0 {
+		response["state_version"] = req.stateVersion
+	}
+	if len(result.Warnings) > 0 {
+		response["warnings"] = result.Warnings
+	}
+	if req.GroupByTrack {
+		response["action_groups"] = groupActionsByTrack(result.Actions)
+	}
+	if req.NoOpSummary {
+		response["filter_summary"] = result.FilterSummaries
+	}
+	if len(result.Answers) > 0 {
+		response["answer"] = result.Answer
+		response["answers"] = result.Answers
+	}
+	if result.Clarification != nil {
+		response["clarification"] = result.Clarification
+	}
+	if result.OutOfScope != nil {
+		response["out_of_scope"] = result.OutOfScope
+	}
+	if result.ConfirmationRequired != nil {
+		response["confirmation_required"] = result.ConfirmationRequired
+	}
+	if req.Preview {
+		previews := magdadaw.PreviewActions(result.Actions, req.State)
+		destructive := false
+		for _, preview := range previews {
+			destructive = destructive || preview.Destructive
+		}
+		response["preview"] = true
+		response["action_previews"] = previews
+		response["destructive"] = destructive
+		// What the project looks like after the actions, as the simulator predicts it
+		changes, err := predictChanges(req.State, result.Actions)
+		if err != nil {
+			logger.Printf(c.Request.Context(), "⚠️ Predicting changes failed: %v", err)
+		} else if changes != nil {
+			response["predicted_changes"] = changes
+		}
 	}
 
 	// Log response before sending
 	responseJSON, _ := json.Marshal(response)
-	log.Printf("📤 MAGDA Chat: Sending response (%d bytes)", len(responseJSON))
+	logger.Printf(c.Request.Context(), "📤 MAGDA Chat: Sending response (%d bytes)", len(responseJSON))
 	previewLen := 500
 	if len(responseJSON) < previewLen {
 		previewLen = len(responseJSON)
 	}
-	log.Printf("   Response preview: %s", string(responseJSON[:previewLen]))
+	logger.Printf(c.Request.Context(), "   Response preview: %s", string(responseJSON[:previewLen]))
 
 	// Return actions in the format MAGDA expects
 	c.JSON(http.StatusOK, response)
@@ -191,23 +434,32 @@ func (h *MagdaHandler) Chat(c *gin.Context) {
 func (h *MagdaHandler) ChatStream(c *gin.Context) {
 	var req MagdaChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ MAGDA ChatStream: JSON binding error: %v", err)
-		log.Printf("   Request method: %s, Path: %s", c.Request.Method, c.Request.URL.Path)
+		logger.Printf(c.Request.Context(), "❌ MAGDA ChatStream: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "   Request method: %s, Path: %s", c.Request.Method, c.Request.URL.Path)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, err := h.requestContext(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	ctx = h.withSessionHistory(ctx, req.SessionID)
+	if !h.withSessionState(ctx, c, &req) {
+		return
+	}
 
 	// Log request details
-	log.Printf("📨 MAGDA ChatStream: Question length=%d, State keys=%d", len(req.Question), len(req.State))
+	logger.Printf(c.Request.Context(), "📨 MAGDA ChatStream: Question length=%d, State keys=%d", len(req.Question), len(req.State))
 	if len(req.Question) > 0 {
 		previewLen := 200
 		if len(req.Question) < previewLen {
 			previewLen = len(req.Question)
 		}
-		log.Printf("   Question: %s", req.Question[:previewLen])
+		logger.Printf(c.Request.Context(), "   Question: %s", req.Question[:previewLen])
 	}
 	if len(req.State) > 0 {
-		log.Printf("   State has %d keys", len(req.State))
+		logger.Printf(c.Request.Context(), "   State has %d keys", len(req.State))
 	}
 
 	// User info available from gateway headers if needed
@@ -234,16 +486,16 @@ func (h *MagdaHandler) ChatStream(c *gin.Context) {
 		}
 		eventJSON, err := json.Marshal(event)
 		if err != nil {
-			log.Printf("❌ MAGDA ChatStream: Failed to marshal action event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ MAGDA ChatStream: Failed to marshal action event: %v", err)
 			return err
 		}
 
-		log.Printf("📤 MAGDA ChatStream: Sending action event: %s", string(eventJSON))
+		logger.Printf(c.Request.Context(), "📤 MAGDA ChatStream: Sending action event: %s", string(eventJSON))
 
 		// Write SSE event
 		_, err = fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON)
 		if err != nil {
-			log.Printf("❌ MAGDA ChatStream: Failed to write SSE event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ MAGDA ChatStream: Failed to write SSE event: %v", err)
 			return err
 		}
 
@@ -255,29 +507,57 @@ func (h *MagdaHandler) ChatStream(c *gin.Context) {
 	// Call streaming orchestrator - coordinates DAW + Arranger agents
 	// Emits actions progressively: create_track, create_clip immediately,
 	// then add_midi once arranger notes are ready
-	log.Printf("🚀 MAGDA ChatStream: Calling Orchestrator.GenerateActionsStream")
-	result, err := h.orchestrator.GenerateActionsStream(c.Request.Context(), req.Question, req.State, actionCallback)
+	logger.Printf(c.Request.Context(), "🚀 MAGDA ChatStream: Calling Orchestrator.GenerateActionsStream")
+	result, err := h.orchestrator.GenerateActionsStream(ctx, req.Question, req.State, actionCallback)
 	if err != nil {
-		log.Printf("❌ MAGDA ChatStream: GenerateActionsStream error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MAGDA ChatStream: GenerateActionsStream error: %v", err)
 		// Send error event
-		errorEvent := gin.H{
-			"type":    "error",
-			"message": err.Error(),
-		}
-		eventJSON, _ := json.Marshal(errorEvent)
+		eventJSON, _ := json.Marshal(streamErrorEvent(c, err))
 		_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON)
 		c.Writer.Flush()
 		return
 	}
 
-	log.Printf("✅ MAGDA ChatStream: Completed successfully, %d actions generated", len(result.Actions))
+	logger.Printf(c.Request.Context(), "✅ MAGDA ChatStream: Completed successfully, %d actions generated", len(result.Actions))
+	h.recordTurn(ctx, req.SessionID, req.Question, result)
+	recordAudit(c, &req, result)
+	h.beginTransaction(ctx, &req, result)
 
 	// Send final completion event
 	finalEvent := gin.H{
-		"type":       "done",
-		"request_id": c.GetString("request_id"),
-		"actions":    result.Actions,
-		"usage":      result.Usage,
+		"type":         "done",
+		"request_id":   c.GetString("request_id"),
+		"actions":      result.Actions,
+		"usage":        middleware.RecordUsage(c, result.Model, result.Usage),
+		"undo_actions": undoActionsOrEmpty(result.UndoActions),
+		"path":         result.Path,
+	}
+	addModelRouting(finalEvent, result)
+	addSeed(finalEvent, result)
+	addDefaults(finalEvent, result)
+	addGrammarVersion(finalEvent, result)
+	addTransaction(finalEvent, &req)
+	if len(result.Warnings) > 0 {
+		finalEvent["warnings"] = result.Warnings
+	}
+	if req.GroupByTrack {
+		finalEvent["action_groups"] = groupActionsByTrack(result.Actions)
+	}
+	if req.NoOpSummary {
+		finalEvent["filter_summary"] = result.FilterSummaries
+	}
+	if len(result.Answers) > 0 {
+		finalEvent["answer"] = result.Answer
+		finalEvent["answers"] = result.Answers
+	}
+	if result.Clarification != nil {
+		finalEvent["clarification"] = result.Clarification
+	}
+	if result.OutOfScope != nil {
+		finalEvent["out_of_scope"] = result.OutOfScope
+	}
+	if result.ConfirmationRequired != nil {
+		finalEvent["confirmation_required"] = result.ConfirmationRequired
 	}
 	eventJSON, _ := json.Marshal(finalEvent)
 	_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON)
@@ -298,16 +578,25 @@ func truncateString(s string, maxLen int) string {
 func (h *MagdaHandler) DSLStream(c *gin.Context) {
 	var req MagdaChatRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ MAGDA DSLStream: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MAGDA DSLStream: JSON binding error: %v", err)
 		// Read the request body to log it, then replace it for subsequent handlers
 		bodyBytes, _ := io.ReadAll(c.Request.Body)
 		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // Restore body for potential re-reading
-		log.Printf("   Request body preview: %s", truncateString(string(bodyBytes), maxRequestPreviewLength))
+		logger.Printf(c.Request.Context(), "   Request body preview: %s", truncateString(string(bodyBytes), maxRequestPreviewLength))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, err := h.requestContext(c, &req)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	ctx = h.withSessionHistory(ctx, req.SessionID)
+	if !h.withSessionState(ctx, c, &req) {
+		return
+	}
 
-	log.Printf("📨 MAGDA DSLStream: Question length=%d, State keys=%d", len(req.Question), len(req.State))
+	logger.Printf(c.Request.Context(), "📨 MAGDA DSLStream: Question length=%d, State keys=%d", len(req.Question), len(req.State))
 
 	// Set up SSE headers
 	c.Header("Content-Type", "text/event-stream")
@@ -326,13 +615,13 @@ func (h *MagdaHandler) DSLStream(c *gin.Context) {
 		}
 		eventJSON, err := json.Marshal(event)
 		if err != nil {
-			log.Printf("❌ MAGDA DSLStream: Failed to marshal action event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ MAGDA DSLStream: Failed to marshal action event: %v", err)
 			return err
 		}
-		log.Printf("📤 MAGDA DSLStream: Sending action event: %s", string(eventJSON))
+		logger.Printf(c.Request.Context(), "📤 MAGDA DSLStream: Sending action event: %s", string(eventJSON))
 		_, err = fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON)
 		if err != nil {
-			log.Printf("❌ MAGDA DSLStream: Failed to write SSE event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ MAGDA DSLStream: Failed to write SSE event: %v", err)
 			return err
 		}
 		c.Writer.Flush()
@@ -340,40 +629,254 @@ func (h *MagdaHandler) DSLStream(c *gin.Context) {
 	}
 
 	// Call streaming orchestrator - coordinates DAW + Arranger agents
-	log.Printf("🚀 MAGDA DSLStream: Calling Orchestrator.GenerateActionsStream")
-	result, err := h.orchestrator.GenerateActionsStream(c.Request.Context(), req.Question, req.State, streamCallback)
+	logger.Printf(c.Request.Context(), "🚀 MAGDA DSLStream: Calling Orchestrator.GenerateActionsStream")
+	result, err := h.orchestrator.GenerateActionsStream(ctx, req.Question, req.State, streamCallback)
 	if err != nil {
 		// If we already sent actions via the callback, don't send an error
 		// (DSL mode may report "no output" error even when actions were successfully parsed)
 		if actionCount > 0 {
-			log.Printf("⚠️  MAGDA DSLStream: GenerateActionsStream reported error but %d actions were already sent: %v", actionCount, err)
+			logger.Printf(c.Request.Context(), "⚠️  MAGDA DSLStream: GenerateActionsStream reported error but %d actions were already sent: %v", actionCount, err)
 			// Continue to send final "done" event
 		} else {
-			log.Printf("❌ MAGDA DSLStream: GenerateActionsStream error: %v", err)
-			errorEvent := map[string]interface{}{
-				"type":    "error",
-				"message": err.Error(),
-			}
-			eventJSON, _ := json.Marshal(errorEvent)
+			logger.Printf(c.Request.Context(), "❌ MAGDA DSLStream: GenerateActionsStream error: %v", err)
+			eventJSON, _ := json.Marshal(streamErrorEvent(c, err))
 			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON)
 			c.Writer.Flush()
 			return
 		}
 	}
 
-	log.Printf("✅ MAGDA DSLStream: Completed successfully, %d actions generated", len(result.Actions))
+	logger.Printf(c.Request.Context(), "✅ MAGDA DSLStream: Completed successfully, %d actions generated", len(result.Actions))
+	h.recordTurn(ctx, req.SessionID, req.Question, result)
+	recordAudit(c, &req, result)
+	h.beginTransaction(ctx, &req, result)
 
 	// Send final "done" event with all actions
 	finalEvent := map[string]interface{}{
-		"type":    "done",
-		"actions": result.Actions,
-		"usage":   result.Usage,
+		"type":         "done",
+		"actions":      result.Actions,
+		"usage":        middleware.RecordUsage(c, result.Model, result.Usage),
+		"undo_actions": undoActionsOrEmpty(result.UndoActions),
+		"path":         result.Path,
+	}
+	addModelRouting(finalEvent, result)
+	addSeed(finalEvent, result)
+	addDefaults(finalEvent, result)
+	addGrammarVersion(finalEvent, result)
+	addTransaction(finalEvent, &req)
+	if len(result.Warnings) > 0 {
+		finalEvent["warnings"] = result.Warnings
+	}
+	if req.GroupByTrack {
+		finalEvent["action_groups"] = groupActionsByTrack(result.Actions)
+	}
+	if req.NoOpSummary {
+		finalEvent["filter_summary"] = result.FilterSummaries
+	}
+	if len(result.Answers) > 0 {
+		finalEvent["answer"] = result.Answer
+		finalEvent["answers"] = result.Answers
+	}
+	if result.Clarification != nil {
+		finalEvent["clarification"] = result.Clarification
+	}
+	if result.OutOfScope != nil {
+		finalEvent["out_of_scope"] = result.OutOfScope
+	}
+	if result.ConfirmationRequired != nil {
+		finalEvent["confirmation_required"] = result.ConfirmationRequired
 	}
 	eventJSON, _ := json.Marshal(finalEvent)
 	_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON)
 	c.Writer.Flush()
 }
 
+// MagdaChatStream streams a MAGDA chat request as Server-Sent Events
+// GET/POST /api/v1/magda/chat/stream
+// Events: started, text_delta (DSL as the LLM writes it), actions_partial (actions parsed
+// from each completed statement), completed (the same fields as /chat) or error.
+// GET takes question, state (JSON), state_delta (JSON), state_version, length_unit,
+// group_by_track, noop_summary, strict_dsl and session_id as query params.
+func (h *MagdaHandler) MagdaChatStream(c *gin.Context) {
+	var req MagdaChatRequest
+	if err := bindMagdaChatRequest(c, &req); err != nil {
+		logger.Printf(c.Request.Context(), "❌ MAGDA MagdaChatStream: request binding error: %v", err)
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx, err := h.requestContext(c, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ctx = h.withSessionHistory(ctx, req.SessionID)
+	if !h.withSessionState(ctx, c, &req) {
+		return
+	}
+
+	logger.Printf(c.Request.Context(), "📨 MAGDA MagdaChatStream: Question length=%d, State keys=%d", len(req.Question), len(req.State))
+
+	// Set headers for SSE
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable nginx buffering
+	c.Header("X-Request-ID", c.GetString("request_id"))
+	c.Writer.Flush()
+
+	// Agents run concurrently, so serialize writes to the stream
+	var mu sync.Mutex
+	sendEvent := func(event gin.H) error {
+		eventJSON, err := json.Marshal(event)
+		if err != nil {
+			logger.Printf(c.Request.Context(), "❌ MAGDA MagdaChatStream: Failed to marshal %v event: %v", event["type"], err)
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON); err != nil {
+			logger.Printf(c.Request.Context(), "❌ MAGDA MagdaChatStream: Failed to write SSE event: %v", err)
+			return err
+		}
+		c.Writer.Flush()
+		return nil
+	}
+
+	_ = sendEvent(gin.H{
+		"type":       "started",
+		"request_id": c.GetString("request_id"),
+	})
+
+	ctx = magdadaw.WithTextDeltaCallback(ctx, func(delta string) error {
+		return sendEvent(gin.H{
+			"type":  "text_delta",
+			"delta": delta,
+		})
+	})
+
+	var partialCount atomic.Int64
+	actionCallback := func(action map[string]interface{}) error {
+		return sendEvent(gin.H{
+			"type":    "actions_partial",
+			"actions": []map[string]interface{}{action},
+			"count":   partialCount.Add(1),
+		})
+	}
+
+	logger.Printf(c.Request.Context(), "🚀 MAGDA MagdaChatStream: Calling Orchestrator.GenerateActionsStream")
+	result, err := h.orchestrator.GenerateActionsStream(ctx, req.Question, req.State, actionCallback)
+	if err != nil {
+		logger.Printf(c.Request.Context(), "❌ MAGDA MagdaChatStream: GenerateActionsStream error: %v", err)
+		_ = sendEvent(streamErrorEvent(c, err))
+		return
+	}
+
+	logger.Printf(c.Request.Context(), "✅ MAGDA MagdaChatStream: Completed successfully, %d actions generated", len(result.Actions))
+	h.recordTurn(ctx, req.SessionID, req.Question, result)
+	recordAudit(c, &req, result)
+	h.beginTransaction(ctx, &req, result)
+
+	_ = sendEvent(completedEvent(c, &req, result))
+}
+
+// streamErrorEvent is the error event ending a stream. A request that ran past its timeout
+// gets the fields of middleware.TimeoutError, since its 200 status has already been sent.
+func streamErrorEvent(c *gin.Context, err error) gin.H {
+	if !middleware.TimedOut(c, err) {
+		return gin.H{"type": "error", "message": err.Error()}
+	}
+	event := middleware.TimeoutError(c)
+	event["type"] = "error"
+	event["message"] = event["error"]
+	delete(event, "error")
+	return event
+}
+
+// completedEvent is the final event of a streamed chat request: the same fields as /chat
+func completedEvent(c *gin.Context, req *MagdaChatRequest, result *magdaorchestrator.OrchestratorResult) gin.H {
+	event := gin.H{
+		"type":         "completed",
+		"request_id":   c.GetString("request_id"),
+		"response":     buildResponseText(result.Actions),
+		"actions":      result.Actions,
+		"usage":        middleware.RecordUsage(c, result.Model, result.Usage),
+		"undo_actions": undoActionsOrEmpty(result.UndoActions),
+		"path":         result.Path,
+	}
+	addModelRouting(event, result)
+	addSeed(event, result)
+	addDefaults(event, result)
+	addGrammarVersion(event, result)
+	addTransaction(event, req)
+	if req.stateVersion > 0 {
+		event["state_version"] = req.stateVersion
+	}
+	if len(result.Warnings) > 0 {
+		event["warnings"] = result.Warnings
+	}
+	if req.GroupByTrack {
+		event["action_groups"] = groupActionsByTrack(result.Actions)
+	}
+	if req.NoOpSummary {
+		event["filter_summary"] = result.FilterSummaries
+	}
+	if len(result.Answers) > 0 {
+		event["answer"] = result.Answer
+		event["answers"] = result.Answers
+		if len(result.Actions) == 0 {
+			event["response"] = result.Answer
+		}
+	}
+	if result.Clarification != nil {
+		event["clarification"] = result.Clarification
+		event["response"] = result.Clarification.Question
+	}
+	if result.OutOfScope != nil {
+		event["out_of_scope"] = result.OutOfScope
+		event["response"] = result.OutOfScope.Reason
+	}
+	if result.ConfirmationRequired != nil {
+		event["confirmation_required"] = result.ConfirmationRequired
+		event["response"] = result.ConfirmationRequired.Reason
+	}
+	return event
+}
+
+// bindMagdaChatRequest reads a chat request from the JSON body, or from query params for GET
+// (EventSource clients can't send a body)
+func bindMagdaChatRequest(c *gin.Context, req *MagdaChatRequest) error {
+	if c.Request.Method != http.MethodGet {
+		return c.ShouldBindJSON(req)
+	}
+
+	req.Question = c.Query("question")
+	if req.Question == "" {
+		return fmt.Errorf("question is required")
+	}
+	if stateJSON := c.Query("state"); stateJSON != "" {
+		if err := json.Unmarshal([]byte(stateJSON), &req.State); err != nil {
+			return fmt.Errorf("invalid state: %w", err)
+		}
+	}
+	if deltaJSON := c.Query("state_delta"); deltaJSON != "" {
+		if err := json.Unmarshal([]byte(deltaJSON), &req.StateDelta); err != nil {
+			return fmt.Errorf("invalid state_delta: %w", err)
+		}
+	}
+	if version := c.Query("state_version"); version != "" {
+		var err error
+		if req.StateVersion, err = strconv.Atoi(version); err != nil {
+			return fmt.Errorf("invalid state_version: %w", err)
+		}
+	}
+	req.LengthUnit = c.Query("length_unit")
+	req.GroupByTrack = c.Query("group_by_track") == "true"
+	req.NoOpSummary = c.Query("noop_summary") == "true"
+	req.StrictDSL = c.Query("strict_dsl") == "true"
+	req.SessionID = c.Query("session_id")
+	return nil
+}
+
 // TestDSL is a test endpoint for parsing DSL code directly
 // POST /api/v1/magda/dsl
 // Body: {"dsl": "track(instrument=\"Serum\").newClip(bar=3, length_bars=4)"}
@@ -387,7 +890,7 @@ func (h *MagdaHandler) TestDSL(c *gin.Context) {
 		return
 	}
 
-	log.Printf("🧪 Testing DSL parser with: %s", req.DSL)
+	logger.Printf(c.Request.Context(), "🧪 Testing DSL parser with: %s", req.DSL)
 
 	// Parse DSL directly
 	parser := magdadaw.NewDSLParser()
@@ -409,6 +912,71 @@ func (h *MagdaHandler) TestDSL(c *gin.Context) {
 	})
 }
 
+// ValidateDSL parses raw MAGDA DSL without calling the LLM (dry run)
+// POST /api/v1/magda/validate
+// Returns the translated actions, or structured errors with line/column positions
+func (h *MagdaHandler) ValidateDSL(c *gin.Context) {
+	var req struct {
+		DSL        string                 `json:"dsl" binding:"required"`
+		State      map[string]interface{} `json:"state"`                 // Optional REAPER state snapshot
+		LengthUnit string                 `json:"length_unit,omitempty"` // "seconds" (default) or "bars"
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	lengthUnit, err := magdadaw.ParseLengthUnit(req.LengthUnit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	grammarVersion, err := magdadaw.ParseGrammarVersion(c.GetHeader(magdadaw.GrammarVersionHeader))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header(magdadaw.GrammarVersionHeader, string(grammarVersion))
+	ctx := magdadaw.WithLengthUnit(c.Request.Context(), lengthUnit)
+
+	actions, dslErrors, err := magdadaw.ValidateDSL(ctx, req.DSL, req.State)
+	if err != nil {
+		logger.Printf(c.Request.Context(), "❌ ValidateDSL: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(dslErrors) > 0 {
+		logger.Printf(c.Request.Context(), "🧪 ValidateDSL: %d error(s), first at %d:%d: %s",
+			len(dslErrors), dslErrors[0].Line, dslErrors[0].Column, dslErrors[0].Message)
+		c.JSON(http.StatusBadRequest, gin.H{
+			"valid":  false,
+			"dsl":    req.DSL,
+			"errors": dslErrors,
+		})
+		return
+	}
+
+	warnings := append(magdadaw.DetectActionConflicts(actions), magdadaw.DetectStaleTrackIndices(actions, req.State)...)
+	actions, planWarnings := magdadaw.PlanActions(actions, req.State)
+	warnings = append(warnings, planWarnings...)
+	actions, schemaWarnings := magdadaw.ValidateActions(actions)
+	warnings = append(warnings, schemaWarnings...)
+	actions, versionWarnings := magdadaw.ActionsForVersion(actions, grammarVersion)
+	warnings = append(warnings, versionWarnings...)
+	logger.Printf(c.Request.Context(), "✅ ValidateDSL: %d actions, %d warnings", len(actions), len(warnings))
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":       true,
+		"dsl":         req.DSL,
+		"actions":     actions,
+		"count":       len(actions),
+		"warnings":    warnings,
+		"dsl_version": grammarVersion,
+	})
+}
+
 // ProcessPlugins generates aliases for plugins
 // POST /api/v1/magda/plugins/process
 // Note: Plugins are already deduplicated by the REAPER extension before sending
@@ -422,18 +990,18 @@ func (h *MagdaHandler) ProcessPlugins(c *gin.Context) {
 		return
 	}
 
-	log.Printf("📦 ProcessPlugins: Received %d plugins (already deduplicated by extension)", len(req.Plugins))
+	logger.Printf(c.Request.Context(), "📦 ProcessPlugins: Received %d plugins (already deduplicated by extension)", len(req.Plugins))
 
 	// Plugins are already deduplicated by the REAPER extension
 	// Just generate aliases for the provided plugins
 	aliases, err := h.pluginService.GenerateAliases(c.Request.Context(), req.Plugins)
 	if err != nil {
-		log.Printf("❌ ProcessPlugins: Alias generation error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ ProcessPlugins: Alias generation error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("✅ ProcessPlugins: Generated %d aliases", len(aliases))
+	logger.Printf(c.Request.Context(), "✅ ProcessPlugins: Generated %d aliases", len(aliases))
 
 	c.JSON(http.StatusOK, gin.H{
 		"plugins":       req.Plugins,
@@ -443,6 +1011,62 @@ func (h *MagdaHandler) ProcessPlugins(c *gin.Context) {
 	})
 }
 
+// PluginNormalizeRequest asks for plugin names to be normalized and, given the installed
+// plugins, matched to one of them
+type PluginNormalizeRequest struct {
+	Names   []string     `json:"names" binding:"required"`
+	Plugins []PluginInfo `json:"plugins,omitempty"` // Installed plugins to resolve names against
+}
+
+// PluginNormalizeResult is a name split into format, base name and vendor, and the
+// installed plugin it resolves to
+type PluginNormalizeResult struct {
+	plugins.Plugin
+	Resolution *plugins.Resolution `json:"resolution,omitempty"` // Set when installed plugins were sent
+}
+
+// NormalizePlugins normalizes plugin names for the extension before it sends them on
+// POST /api/v1/plugins/normalize
+func (h *MagdaHandler) NormalizePlugins(c *gin.Context) {
+	var req PluginNormalizeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var registry *plugins.Registry
+	if len(req.Plugins) > 0 {
+		installed := make([]plugins.Plugin, 0, len(req.Plugins))
+		for _, info := range req.Plugins {
+			fullName := info.FullName
+			if fullName == "" {
+				fullName = info.Name
+			}
+			plugin := plugins.ParsePlugin(fullName)
+			plugin.IsInstrument = plugin.IsInstrument || info.IsInstrument
+			installed = append(installed, plugin)
+		}
+		registry = plugins.NewRegistryFromPlugins(installed)
+	}
+
+	results := make([]PluginNormalizeResult, 0, len(req.Names))
+	for _, name := range req.Names {
+		result := PluginNormalizeResult{Plugin: plugins.ParsePlugin(name)}
+		if registry != nil {
+			resolution := registry.Resolve(name)
+			result.Resolution = &resolution
+		}
+		results = append(results, result)
+	}
+
+	logger.Printf(c.Request.Context(), "🔌 NormalizePlugins: Normalized %d names against %d installed plugins", len(results), len(req.Plugins))
+
+	c.JSON(http.StatusOK, gin.H{
+		"results": results,
+		"count":   len(results),
+	})
+}
+
 // buildResponseText creates a human-readable summary from actions
 func buildResponseText(actions []map[string]any) string {
 	if len(actions) == 0 {
@@ -487,23 +1111,23 @@ func buildResponseText(actions []map[string]any) string {
 func (h *MagdaHandler) MixAnalyze(c *gin.Context) {
 	var req magdamix.AnalysisRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ MixAnalyze: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MixAnalyze: JSON binding error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("📨 MixAnalyze: Received request")
-	log.Printf("   Mode: %s", req.Mode)
-	log.Printf("   User request: %s", req.UserRequest)
+	logger.Printf(c.Request.Context(), "📨 MixAnalyze: Received request")
+	logger.Printf(c.Request.Context(), "   Mode: %s", req.Mode)
+	logger.Printf(c.Request.Context(), "   User request: %s", req.UserRequest)
 	if req.Context != nil {
-		log.Printf("   Track: %s (%s)", req.Context.TrackName, req.Context.TrackType)
+		logger.Printf(c.Request.Context(), "   Track: %s (%s)", req.Context.TrackName, req.Context.TrackType)
 	}
 
 	// Get user from gateway headers (if authenticated)
 	var userID string
 	if id, ok := middleware.GetUserIDFromGateway(c); ok {
 		userID = id
-		log.Printf("   User ID: %s", userID)
+		logger.Printf(c.Request.Context(), "   User ID: %s", userID)
 	}
 
 	// Start Langfuse trace
@@ -516,10 +1140,10 @@ func (h *MagdaHandler) MixAnalyze(c *gin.Context) {
 	defer trace.Finish()
 
 	// Call mix analysis agent
-	log.Printf("🚀 MixAnalyze: Calling MixAnalysisAgent.Analyze")
+	logger.Printf(c.Request.Context(), "🚀 MixAnalyze: Calling MixAnalysisAgent.Analyze")
 	result, err := h.mixAgent.Analyze(c.Request.Context(), &req)
 	if err != nil {
-		log.Printf("❌ MixAnalyze: Analysis error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MixAnalyze: Analysis error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -553,9 +1177,9 @@ func (h *MagdaHandler) MixAnalyze(c *gin.Context) {
 		}
 	}
 
-	log.Printf("✅ MixAnalyze: Analysis complete")
-	log.Printf("   Summary length: %d chars", len(responseText))
-	log.Printf("   Recommendations: %d", len(result.Recommendations))
+	logger.Printf(c.Request.Context(), "✅ MixAnalyze: Analysis complete")
+	logger.Printf(c.Request.Context(), "   Summary length: %d chars", len(responseText))
+	logger.Printf(c.Request.Context(), "   Recommendations: %d", len(result.Recommendations))
 
 	// Build response
 	response := gin.H{
@@ -567,3 +1191,44 @@ func (h *MagdaHandler) MixAnalyze(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// undoActionsOrEmpty keeps undo_actions an array in responses when no DAW actions were generated
+func undoActionsOrEmpty(undoActions []map[string]any) []map[string]any {
+	if undoActions == nil {
+		return []map[string]any{}
+	}
+	return undoActions
+}
+
+// addGrammarVersion reports the DSL grammar version the actions were generated for, and the
+// repairs applied to the generated DSL
+func addGrammarVersion(response map[string]any, result *magdaorchestrator.OrchestratorResult) {
+	response["dsl_version"] = result.GrammarVersion
+	if len(result.Repairs) > 0 {
+		response["dsl_repairs"] = result.Repairs
+	}
+}
+
+// addSeed reports the seed of the arranger's random choices, which a later request can pass
+// back ("regenerate with seed 42") to repeat them
+func addSeed(response map[string]any, result *magdaorchestrator.OrchestratorResult) {
+	if result.Seed != nil {
+		response["seed"] = *result.Seed
+	}
+}
+
+// addModelRouting reports the model the DAW calls used, why it was chosen, the parameters they
+// ran with and the prompt variant under test (LLM path only)
+func addModelRouting(response map[string]any, result *magdaorchestrator.OrchestratorResult) {
+	if result.Model == "" {
+		return
+	}
+	response["model"] = result.Model
+	response["routing_reason"] = result.RoutingReason
+	if result.LLMParams != nil {
+		response["llm_params"] = result.LLMParams
+	}
+	if result.PromptVariant != "" {
+		response["prompt_variant"] = result.PromptVariant
+	}
+}
@@ -1200,6 +1200,109 @@ func TestMagdaClipFilteringOperations(t *testing.T) {
 				assert.True(t, trackIndices[0] || trackIndices[1], "Should target clips from at least one track")
 			},
 		},
+		{
+			name:     "split clips at bar 17 across multiple tracks",
+			question: "split all clips at bar 17",
+			state: map[string]interface{}{
+				"project": map[string]interface{}{"bpm": 120.0, "time_signature": "4/4"},
+				"tracks": []map[string]interface{}{
+					{
+						"index": 0,
+						"name":  "Drums",
+						"clips": []map[string]interface{}{
+							{"index": 0, "position": 0.0, "length": 64.0, "selected": false}, // Spans bar 17 (32s)
+						},
+					},
+					{
+						"index": 1,
+						"name":  "Bass",
+						"clips": []map[string]interface{}{
+							{"index": 0, "position": 16.0, "length": 32.0, "selected": false}, // Spans bar 17
+						},
+					},
+					{
+						"index": 2,
+						"name":  "Pad",
+						"clips": []map[string]interface{}{
+							{"index": 0, "position": 40.0, "length": 8.0, "selected": false}, // Starts after bar 17
+						},
+					},
+				},
+			},
+			validate: func(t *testing.T, actions []interface{}) {
+				t.Helper()
+				trackIndices := make(map[int]bool)
+				for _, actionInterface := range actions {
+					action, ok := actionInterface.(map[string]interface{})
+					require.True(t, ok)
+					actionType, ok := action["action"].(string)
+					require.True(t, ok)
+					if actionType != "split_clip" {
+						continue
+					}
+					_, hasBar := action["bar"]
+					_, hasPosition := action["position"]
+					assert.True(t, hasBar || hasPosition, "split_clip should have a split point (bar or position)")
+					track, ok := action["track"].(float64)
+					require.True(t, ok, "split_clip should have a track field")
+					trackIndices[int(track)] = true
+				}
+				// Tracks 0 and 1 have clips spanning bar 17
+				assert.True(t, trackIndices[0], "Should split the clip on track 0")
+				assert.True(t, trackIndices[1], "Should split the clip on track 1")
+				assert.False(t, trackIndices[2], "Should not split track 2 (its clip starts after bar 17)")
+			},
+		},
+		{
+			name:     "transpose an existing clip from its notes in state",
+			question: "transpose the bass clip up an octave",
+			state: map[string]interface{}{
+				"project": map[string]interface{}{"bpm": 120.0, "time_signature": "4/4"},
+				"tracks": []map[string]interface{}{
+					{
+						"index": 0,
+						"name":  "Drums",
+						"clips": []map[string]interface{}{
+							{"index": 0, "position": 0.0, "length": 8.0},
+						},
+					},
+					{
+						"index": 1,
+						"name":  "Bass",
+						"clips": []map[string]interface{}{
+							{"index": 0, "position": 0.0, "length": 8.0, "notes": []map[string]interface{}{
+								{"pitch": 36, "velocity": 100, "start": 0.0, "length": 1.0},
+								{"pitch": 43, "velocity": 100, "start": 2.0, "length": 1.0},
+							}},
+						},
+					},
+				},
+			},
+			validate: func(t *testing.T, actions []interface{}) {
+				t.Helper()
+				found := false
+				for _, actionInterface := range actions {
+					action, ok := actionInterface.(map[string]interface{})
+					require.True(t, ok)
+					if action["action"] != "set_clip_notes" {
+						continue
+					}
+					found = true
+					assert.Equal(t, 1.0, action["track"], "Should edit the clip on the bass track")
+					notes, ok := action["notes"].([]interface{})
+					require.True(t, ok, "set_clip_notes should have notes")
+					require.Len(t, notes, 2)
+					pitches := make([]float64, 0, len(notes))
+					for _, noteInterface := range notes {
+						note, ok := noteInterface.(map[string]interface{})
+						require.True(t, ok)
+						pitches = append(pitches, note["pitch"].(float64))
+					}
+					assert.ElementsMatch(t, []float64{48, 55}, pitches, "Notes should move up 12 semitones")
+				}
+				assert.True(t, found, "Should emit set_clip_notes for the bass clip")
+			},
+		},
 	}
 
 	for _, tc := range testCases {
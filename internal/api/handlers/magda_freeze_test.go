@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateDSL_FreezeTracks translates freeze and bounce DSL without calling the LLM
+// This tests: "freeze all tracks with more than 3 FX"
+func TestValidateDSL_FreezeTracks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/magda/validate", (&MagdaHandler{}).ValidateDSL)
+
+	fxChain := func(n int) []any {
+		chain := make([]any, n)
+		for i := range chain {
+			chain[i] = map[string]any{"name": "ReaComp"}
+		}
+		return chain
+	}
+	state := map[string]any{
+		"tracks": []any{
+			map[string]any{"index": 0, "name": "Drums", "fx": fxChain(1)},
+			map[string]any{"index": 1, "name": "Pad", "fx": fxChain(4)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		dsl      string
+		wantCode int
+		want     []any
+	}{
+		{
+			name:     "freeze by fx count",
+			dsl:      `filter(tracks, track.fx_count > 3).freeze_track()`,
+			wantCode: http.StatusOK,
+			want:     []any{map[string]any{"action": "freeze_track", "track": float64(1)}},
+		},
+		{
+			name:     "unfreeze and bounce",
+			dsl:      `track(id=2).unfreeze_track().bounce_in_place()`,
+			wantCode: http.StatusOK,
+			want: []any{
+				map[string]any{"action": "unfreeze_track", "track": float64(1)},
+				map[string]any{"action": "bounce_in_place", "track": float64(1)},
+			},
+		},
+		{
+			name:     "arguments are rejected",
+			dsl:      `track(id=1).freeze_track(mode="mono")`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsonBody, err := json.Marshal(map[string]any{"dsl": tt.dsl, "state": state})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("POST", "/api/v1/magda/validate", bytes.NewBuffer(jsonBody))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			if tt.wantCode != http.StatusOK {
+				assert.Equal(t, false, response["valid"])
+				return
+			}
+			assert.Equal(t, tt.want, response["actions"])
+		})
+	}
+}
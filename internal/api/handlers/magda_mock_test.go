@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupMockRouter serves the MAGDA chat endpoint with the mock LLM provider, so the
+// HTTP → parser → actions pipeline runs without network access or an API key
+func setupMockRouter() *gin.Engine {
+	cfg := &config.Config{
+		LLMProvider:  "mock",
+		SessionStore: "memory",
+		Environment:  "test",
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.POST("/api/v1/magda/chat", NewMagdaHandler(cfg).Chat)
+	return router
+}
+
+func TestMagdaChatWithMockProvider(t *testing.T) {
+	router := setupMockRouter()
+
+	tests := []struct {
+		name     string
+		question string
+		action   string
+		fields   map[string]any
+	}{
+		{
+			name:     "track with instrument",
+			question: "Create a new track called Bass with Serum",
+			action:   "create_track",
+			fields:   map[string]any{"name": "Bass", "instrument": "Serum"},
+		},
+		{
+			name:     "named track",
+			question: "Create a track called 'Drums'",
+			action:   "create_track",
+			fields:   map[string]any{"name": "Drums"},
+		},
+		{
+			name:     "plain track",
+			question: "Create a track",
+			action:   "create_track",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(MagdaChatRequest{
+				Question: tt.question,
+				State:    map[string]any{"tracks": []any{}},
+			})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/magda/chat", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var response map[string]any
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			actions, ok := response["actions"].([]any)
+			require.True(t, ok, "Response should have 'actions' array")
+			require.NotEmpty(t, actions)
+
+			action, ok := actions[0].(map[string]any)
+			require.True(t, ok)
+			assert.Equal(t, tt.action, action["action"])
+			for field, want := range tt.fields {
+				assert.Equal(t, want, action[field], field)
+			}
+		})
+	}
+}
+
+// Parallel requests each get their own parser and must not see each other's state; run with
+// -race
+func TestMagdaChatConcurrentRequests(t *testing.T) {
+	router := setupMockRouter()
+
+	const requests = 16
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			question, name := "Create a track called 'Drums'", "Drums"
+			if i%2 == 1 {
+				question, name = "Create a new track called Bass with Serum", "Bass"
+			}
+			// Each request's new track goes after its own tracks
+			trackCount := i % 4
+			tracks := make([]any, trackCount)
+			for j := range tracks {
+				tracks[j] = map[string]any{"index": j, "name": fmt.Sprintf("Track %d", j+1)}
+			}
+			body, err := json.Marshal(MagdaChatRequest{Question: question, State: map[string]any{"tracks": tracks}})
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/magda/chat", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if !assert.Equal(t, http.StatusOK, w.Code, w.Body.String()) {
+				return
+			}
+
+			var response struct {
+				Actions []map[string]any `json:"actions"`
+			}
+			if !assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response)) || !assert.Len(t, response.Actions, 1) {
+				return
+			}
+			assert.Equal(t, "create_track", response.Actions[0]["action"])
+			assert.Equal(t, name, response.Actions[0]["name"])
+			assert.EqualValues(t, trackCount, response.Actions[0]["index"])
+		}()
+	}
+	wg.Wait()
+}
+
+// A request past its route's timeout answers 504, or ends its stream with an error event,
+// with the request_timeout code
+func TestMagdaChatTimeout(t *testing.T) {
+	handler := NewMagdaHandler(&config.Config{
+		LLMProvider:  "mock",
+		SessionStore: "memory",
+		Environment:  "test",
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/magda/chat", middleware.Timeout(time.Nanosecond), handler.Chat)
+	router.POST("/api/v1/magda/chat/stream", middleware.Timeout(time.Nanosecond), handler.MagdaChatStream)
+
+	body, err := json.Marshal(MagdaChatRequest{
+		Question: "Create a new track called Bass with Serum",
+		State:    map[string]any{"tracks": []any{}},
+	})
+	require.NoError(t, err)
+
+	t.Run("chat", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/magda/chat", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusGatewayTimeout, w.Code, w.Body.String())
+		var response map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "request_timeout", response["code"])
+		assert.Equal(t, 1e-9, response["timeout_seconds"])
+		assert.Contains(t, response["error"], "timed out")
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/magda/chat/stream", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var last map[string]any
+		for _, line := range strings.Split(w.Body.String(), "\n") {
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				require.NoError(t, json.Unmarshal([]byte(data), &last))
+			}
+		}
+		require.NotNil(t, last, w.Body.String())
+		assert.Equal(t, "error", last["type"])
+		assert.Equal(t, "request_timeout", last["code"])
+		assert.Contains(t, last["message"], "timed out")
+	})
+}
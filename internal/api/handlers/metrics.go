@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"time"
 
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
 	"github.com/gin-gonic/gin"
 )
 
@@ -88,6 +89,8 @@ func (h *MetricsHandler) GetMetrics(c *gin.Context) {
 				"enabled": true,
 				"url":     "https://mcp.musicalaideas.com/mcp",
 			},
+			"llm_retries": llm.RetryMetrics(),
+			"llm_cache":   llm.ResponseCacheMetrics(),
 		},
 	}
 
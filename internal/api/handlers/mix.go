@@ -3,13 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"runtime/debug"
 
 	magdaconfig "github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
 	mixagent "github.com/Conceptual-Machines/magda-api/internal/agents/shared/mix"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/gin-gonic/gin"
 )
 
@@ -21,8 +21,17 @@ type MixHandler struct {
 func NewMixHandler(cfg *config.Config) *MixHandler {
 	// Convert magda-api config to magda-agents config
 	magdaCfg := &magdaconfig.Config{
-		OpenAIAPIKey: cfg.OpenAIAPIKey,
-		MCPServerURL: cfg.MCPServerURL,
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+		MCPServerURL:     cfg.MCPServerURL,
+		LLMCache:         cfg.LLMCache,
+		LLMCacheTTL:      cfg.LLMCacheTTL,
+		LLMCacheSize:     cfg.LLMCacheSize,
+		RedisURL:         cfg.RedisURL,
 	}
 
 	return &MixHandler{
@@ -36,29 +45,29 @@ func NewMixHandler(cfg *config.Config) *MixHandler {
 func (h *MixHandler) MixAnalyze(c *gin.Context) {
 	var req mixagent.AnalysisRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ MixAnalyze: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MixAnalyze: JSON binding error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("🎛️ MixAnalyze: Received request, mode=%s", req.Mode)
+	logger.Printf(c.Request.Context(), "🎛️ MixAnalyze: Received request, mode=%s", req.Mode)
 	if req.Context != nil {
-		log.Printf("   Track type: %s", req.Context.TrackType)
-		log.Printf("   Track name: %s", req.Context.TrackName)
+		logger.Printf(c.Request.Context(), "   Track type: %s", req.Context.TrackType)
+		logger.Printf(c.Request.Context(), "   Track name: %s", req.Context.TrackName)
 	}
 	if req.UserRequest != "" {
-		log.Printf("   User request: %s", req.UserRequest)
+		logger.Printf(c.Request.Context(), "   User request: %s", req.UserRequest)
 	}
 
 	// Call the mix analysis agent
 	result, err := h.agent.Analyze(c.Request.Context(), &req)
 	if err != nil {
-		log.Printf("❌ MixAnalyze: Analysis error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ MixAnalyze: Analysis error: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("✅ MixAnalyze: Analysis complete, %d recommendations", len(result.Recommendations))
+	logger.Printf(c.Request.Context(), "✅ MixAnalyze: Analysis complete, %d recommendations", len(result.Recommendations))
 
 	// Return the analysis result
 	c.JSON(http.StatusOK, result)
@@ -70,8 +79,8 @@ func (h *MixHandler) MixAnalyzeStream(c *gin.Context) {
 	// Panic recovery
 	defer func() {
 		if r := recover(); r != nil {
-			log.Printf("❌ Mix Stream: PANIC recovered: %v", r)
-			log.Printf("   Stack trace:\n%s", string(debug.Stack()))
+			logger.Printf(c.Request.Context(), "❌ Mix Stream: PANIC recovered: %v", r)
+			logger.Printf(c.Request.Context(), "   Stack trace:\n%s", string(debug.Stack()))
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error":      fmt.Sprintf("Internal server error: %v", r),
 				"request_id": c.GetString("request_id"),
@@ -81,15 +90,15 @@ func (h *MixHandler) MixAnalyzeStream(c *gin.Context) {
 
 	var req mixagent.AnalysisRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("❌ Mix Stream: JSON binding error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ Mix Stream: JSON binding error: %v", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	log.Printf("🎛️ MixAnalyzeStream: Received request (TRUE STREAMING), mode=%s", req.Mode)
+	logger.Printf(c.Request.Context(), "🎛️ MixAnalyzeStream: Received request (TRUE STREAMING), mode=%s", req.Mode)
 	if req.Context != nil {
-		log.Printf("   Track type: %s", req.Context.TrackType)
-		log.Printf("   Track name: %s", req.Context.TrackName)
+		logger.Printf(c.Request.Context(), "   Track type: %s", req.Context.TrackType)
+		logger.Printf(c.Request.Context(), "   Track name: %s", req.Context.TrackName)
 	}
 
 	// Set up SSE headers
@@ -102,11 +111,11 @@ func (h *MixHandler) MixAnalyzeStream(c *gin.Context) {
 	sendEvent := func(event map[string]any) error {
 		eventJSON, err := json.Marshal(event)
 		if err != nil {
-			log.Printf("❌ Mix Stream: Failed to marshal event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ Mix Stream: Failed to marshal event: %v", err)
 			return err
 		}
 		if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", eventJSON); err != nil {
-			log.Printf("❌ Mix Stream: Failed to write SSE event: %v", err)
+			logger.Printf(c.Request.Context(), "❌ Mix Stream: Failed to write SSE event: %v", err)
 			return err
 		}
 		c.Writer.Flush()
@@ -133,10 +142,10 @@ func (h *MixHandler) MixAnalyzeStream(c *gin.Context) {
 	}
 
 	// Call mix analysis agent with TRUE streaming
-	log.Printf("🚀 Mix Stream: Starting true streaming analysis...")
+	logger.Printf(c.Request.Context(), "🚀 Mix Stream: Starting true streaming analysis...")
 	result, err := h.agent.AnalyzeStream(ctx, &req, streamCallback)
 	if err != nil {
-		log.Printf("❌ Mix Stream: Analysis error: %v", err)
+		logger.Printf(c.Request.Context(), "❌ Mix Stream: Analysis error: %v", err)
 		_ = sendEvent(map[string]any{
 			"type":    "error",
 			"message": fmt.Sprintf("Mix analysis failed: %v", err),
@@ -144,7 +153,7 @@ func (h *MixHandler) MixAnalyzeStream(c *gin.Context) {
 		return
 	}
 
-	log.Printf("✅ Mix Stream: Completed with %d chunks streamed", chunkCount)
+	logger.Printf(c.Request.Context(), "✅ Mix Stream: Completed with %d chunks streamed", chunkCount)
 
 	// Send complete event with final result
 	_ = sendEvent(map[string]any{
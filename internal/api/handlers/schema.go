@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"net/http"
+
+	magdadaw "github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	"github.com/gin-gonic/gin"
+)
+
+// ActionSchema returns the JSON Schema every returned action is validated against
+// GET /api/v1/schema/actions
+func ActionSchema(c *gin.Context) {
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, magdadaw.ActionSchema())
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+
+	magdaorchestrator "github.com/Conceptual-Machines/magda-api/internal/agents/core/coordination"
+	magdaarranger "github.com/Conceptual-Machines/magda-api/internal/agents/shared/arranger"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+// withMusicalDefaults attaches the musical defaults the arranger fills parts in with: the
+// session's, with the request's defaults over them. A request's defaults are saved to its
+// session, so later requests keep them. The store is best effort: a failure is logged and the
+// request runs with its own defaults.
+func (h *MagdaHandler) withMusicalDefaults(ctx context.Context, req *MagdaChatRequest) (context.Context, error) {
+	if req.Defaults != nil {
+		if err := magdaarranger.ValidateMusicalDefaults(*req.Defaults); err != nil {
+			return nil, err
+		}
+	}
+
+	var defaults models.MusicalDefaults
+	if req.SessionID != "" {
+		stored, err := h.sessions.Defaults(ctx, req.SessionID)
+		if err != nil {
+			logger.Printf(ctx, "⚠️  Session %s: failed to load defaults: %v", req.SessionID, err)
+		} else {
+			defaults = stored
+		}
+	}
+	if req.Defaults != nil {
+		defaults = magdaarranger.MergeMusicalDefaults(defaults, *req.Defaults)
+		// A preview isn't executed, so its defaults don't outlive it
+		if req.SessionID != "" && !req.Preview {
+			h.saveDefaults(ctx, req.SessionID, defaults)
+		}
+	}
+	return magdaarranger.WithMusicalDefaults(ctx, defaults), nil
+}
+
+// recordDefaults merges the defaults the arranger set ("from now on write everything an octave
+// lower") into the session's, for its later requests
+func (h *MagdaHandler) recordDefaults(ctx context.Context, sessionID string, result *magdaorchestrator.OrchestratorResult) {
+	if sessionID == "" || result.Defaults == nil {
+		return
+	}
+	// Read back, since another request may have changed them while this one ran
+	stored, err := h.sessions.Defaults(context.WithoutCancel(ctx), sessionID)
+	if err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to load defaults: %v", sessionID, err)
+		return
+	}
+	h.saveDefaults(ctx, sessionID, magdaarranger.MergeMusicalDefaults(stored, *result.Defaults))
+}
+
+// saveDefaults stores the session's defaults, logging a failure
+func (h *MagdaHandler) saveDefaults(ctx context.Context, sessionID string, defaults models.MusicalDefaults) {
+	// The request may have completed even if a streaming client has since disconnected
+	if err := h.sessions.SaveDefaults(context.WithoutCancel(ctx), sessionID, defaults); err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to store defaults: %v", sessionID, err)
+		return
+	}
+	logger.Printf(ctx, "🎚️ Session %s: stored defaults %+v", sessionID, defaults)
+}
+
+// addDefaults reports the musical defaults the arranger set, which the session keeps
+func addDefaults(response map[string]any, result *magdaorchestrator.OrchestratorResult) {
+	if result.Defaults != nil {
+		response["defaults"] = result.Defaults
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+	"github.com/gin-gonic/gin"
+)
+
+// stateVersionHeader reports the version of the session state a request ran against, which
+// the client's next state_delta is based on
+const stateVersionHeader = "X-State-Version"
+
+// stateConflictError means a state_delta doesn't apply to the session's stored state; the
+// client resends the full state
+type stateConflictError struct {
+	message string
+	current int // Version of the stored state, 0 if there is none
+}
+
+func (e *stateConflictError) Error() string { return e.message }
+
+// stateStoreError is a session store failure while resolving a state_delta
+type stateStoreError struct{ err error }
+
+func (e *stateStoreError) Error() string { return e.err.Error() }
+func (e *stateStoreError) Unwrap() error { return e.err }
+
+// resolveSessionState caches the request's project state per session, so clients send the full
+// state once and then only the changes: a full state replaces the session's state, a
+// state_delta is merged into it (see session.ApplyStateDelta) and a request with neither runs
+// against it. req.State is set to the state the request runs against. Without a session_id
+// the request's state is used as is.
+func (h *MagdaHandler) resolveSessionState(ctx context.Context, req *MagdaChatRequest) error {
+	if req.SessionID == "" {
+		if req.StateDelta != nil {
+			return fmt.Errorf("state_delta requires a session_id")
+		}
+		return nil
+	}
+
+	snapshot, err := h.sessions.State(ctx, req.SessionID)
+	if err != nil {
+		if req.StateDelta != nil {
+			return &stateStoreError{err}
+		}
+		// A full state doesn't need the stored one; the cache is best effort
+		logger.Printf(ctx, "⚠️  Session %s: failed to load state: %v", req.SessionID, err)
+		snapshot = nil
+	}
+
+	switch {
+	case req.State != nil:
+		version := 1
+		if snapshot != nil {
+			version = snapshot.Version + 1
+		}
+		snapshot = &session.StateSnapshot{State: req.State, Version: version}
+	case req.StateDelta != nil:
+		if snapshot == nil {
+			return &stateConflictError{message: "state_delta needs a state to apply to: send the full state"}
+		}
+		if req.StateVersion != snapshot.Version {
+			return &stateConflictError{
+				message: fmt.Sprintf("state_delta is based on state version %d but the session is at version %d: send the full state", req.StateVersion, snapshot.Version),
+				current: snapshot.Version,
+			}
+		}
+		state, err := session.ApplyStateDelta(snapshot.State, req.StateDelta)
+		if err != nil {
+			return err
+		}
+		snapshot = &session.StateSnapshot{State: state, Version: snapshot.Version + 1}
+	case snapshot == nil:
+		return nil
+	default:
+		logger.Printf(ctx, "💾 Session %s: using stored state version %d", req.SessionID, snapshot.Version)
+		// The agents annotate the state they're given, so the stored state isn't handed out
+		if req.State, err = session.CopyState(snapshot.State); err != nil {
+			return err
+		}
+		req.stateVersion = snapshot.Version
+		return nil
+	}
+
+	snapshot.Updated = time.Now()
+	if err := h.sessions.SaveState(context.WithoutCancel(ctx), req.SessionID, *snapshot); err != nil {
+		if req.StateDelta != nil {
+			return &stateStoreError{err}
+		}
+		logger.Printf(ctx, "⚠️  Session %s: failed to store state: %v", req.SessionID, err)
+		return nil
+	}
+	if req.State, err = session.CopyState(snapshot.State); err != nil {
+		return err
+	}
+	req.stateVersion = snapshot.Version
+	logger.Printf(ctx, "💾 Session %s: stored state version %d", req.SessionID, snapshot.Version)
+	return nil
+}
+
+// withSessionState runs resolveSessionState, validates the resulting state and reports its
+// version in the X-State-Version header. On failure it writes the error response and returns
+// false.
+func (h *MagdaHandler) withSessionState(ctx context.Context, c *gin.Context, req *MagdaChatRequest) bool {
+	if err := h.resolveSessionState(ctx, req); err != nil {
+		var conflict *stateConflictError
+		var storeErr *stateStoreError
+		switch {
+		case errors.As(err, &conflict):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "state_version": conflict.current})
+		case errors.As(err, &storeErr):
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return false
+	}
+	// The agents read indices as ints, so the state is checked and normalized once here
+	if _, err := projectstate.Normalize(req.State); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return false
+	}
+	if req.stateVersion > 0 {
+		c.Header(stateVersionHeader, strconv.Itoa(req.stateVersion))
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSessionState(t *testing.T) {
+	ctx := context.Background()
+	h := &MagdaHandler{sessions: session.NewMemoryStore(0, 0)}
+	tracks := func(names ...string) []any {
+		items := make([]any, len(names))
+		for i, name := range names {
+			items[i] = map[string]any{"name": name}
+		}
+		return items
+	}
+
+	// A full state is stored as version 1
+	req := &MagdaChatRequest{SessionID: "s1", State: map[string]any{"tracks": tracks("Drums")}}
+	require.NoError(t, h.resolveSessionState(ctx, req))
+	assert.Equal(t, 1, req.stateVersion)
+
+	// A delta against it is merged and the request runs against the result
+	req = &MagdaChatRequest{
+		SessionID:    "s1",
+		StateDelta:   map[string]any{"tracks": map[string]any{"1": map[string]any{"name": "Bass"}}},
+		StateVersion: 1,
+	}
+	require.NoError(t, h.resolveSessionState(ctx, req))
+	assert.Equal(t, 2, req.stateVersion)
+	assert.Equal(t, map[string]any{"tracks": tracks("Drums", "Bass")}, req.State)
+
+	// The request's copy is its own
+	req.State["tracks"] = nil
+	req = &MagdaChatRequest{SessionID: "s1"}
+	require.NoError(t, h.resolveSessionState(ctx, req))
+	assert.Equal(t, 2, req.stateVersion)
+	assert.Equal(t, map[string]any{"tracks": tracks("Drums", "Bass")}, req.State)
+
+	// A delta against an older version conflicts
+	req = &MagdaChatRequest{SessionID: "s1", StateDelta: map[string]any{"tracks": nil}, StateVersion: 1}
+	err := h.resolveSessionState(ctx, req)
+	var conflict *stateConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Equal(t, 2, conflict.current)
+}
+
+func TestResolveSessionState_Errors(t *testing.T) {
+	ctx := context.Background()
+	h := &MagdaHandler{sessions: session.NewMemoryStore(0, 0)}
+
+	// Without a session the state is used as is
+	state := map[string]any{"tracks": []any{}}
+	req := &MagdaChatRequest{State: state}
+	require.NoError(t, h.resolveSessionState(ctx, req))
+	assert.Equal(t, state, req.State)
+	assert.Zero(t, req.stateVersion)
+
+	err := h.resolveSessionState(ctx, &MagdaChatRequest{StateDelta: map[string]any{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a session_id")
+
+	err = h.resolveSessionState(ctx, &MagdaChatRequest{SessionID: "new", StateDelta: map[string]any{}})
+	var conflict *stateConflictError
+	require.ErrorAs(t, err, &conflict)
+	assert.Zero(t, conflict.current)
+
+	require.NoError(t, h.resolveSessionState(ctx, &MagdaChatRequest{SessionID: "s1", State: state}))
+	err = h.resolveSessionState(ctx, &MagdaChatRequest{
+		SessionID:    "s1",
+		StateDelta:   map[string]any{"tracks": map[string]any{"3": map[string]any{"name": "Pad"}}},
+		StateVersion: 1,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "out of range")
+}
+
+func TestWithSessionState_InvalidState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	h := &MagdaHandler{sessions: session.NewMemoryStore(0, 0)}
+
+	req := &MagdaChatRequest{State: map[string]any{"tracks": []any{map[string]any{"index": 0.5}}}}
+	assert.False(t, h.withSessionState(context.Background(), c, req))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "tracks[0].index: 0.5 is not a whole number")
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/simulator"
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+	"github.com/gin-gonic/gin"
+)
+
+// DiffState compares two project state snapshots, e.g. before and after applying a response's
+// actions, or predicts the changes actions make to a snapshot
+// POST /api/v1/state/diff
+// Takes before and either after or actions. Returns the changes (tracks added, removed and
+// moved, property, FX, clip and automation changes) with one description each, and the
+// descriptions alone as summary; for actions, also the predicted state and the actions that
+// don't fit the project as warnings.
+func DiffState(c *gin.Context) {
+	var req struct {
+		Before  map[string]any   `json:"before" binding:"required"`
+		After   map[string]any   `json:"after"`
+		Actions []map[string]any `json:"actions"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.After == nil) == (req.Actions == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of after and actions is required"})
+		return
+	}
+
+	before, err := projectstate.Parse(req.Before)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before: " + err.Error()})
+		return
+	}
+
+	response := gin.H{}
+	afterState := req.After
+	if req.Actions != nil {
+		simulated, err := simulator.Apply(req.Before, req.Actions)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		afterState = simulated.State
+		response["after"] = simulated.State
+		response["warnings"] = simulated.Warnings
+	}
+	after, err := projectstate.Parse(afterState)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "after: " + err.Error()})
+		return
+	}
+
+	changes := projectstate.Diff(before, after)
+	logger.Printf(c.Request.Context(), "🔀 DiffState: %d changes", len(changes))
+	response["changed"] = len(changes) > 0
+	response["summary"] = projectstate.Summary(changes)
+	response["changes"] = changes
+	c.JSON(http.StatusOK, response)
+}
+
+// predictChanges lists the changes actions are predicted to make to state. Returns nil without
+// a state, since every track would look added.
+func predictChanges(state map[string]any, actions []map[string]any) ([]projectstate.Change, error) {
+	if state == nil {
+		return nil, nil
+	}
+	before, err := projectstate.Parse(state)
+	if err != nil {
+		return nil, err
+	}
+	simulated, err := simulator.Apply(state, actions)
+	if err != nil {
+		return nil, err
+	}
+	after, err := projectstate.Parse(simulated.State)
+	if err != nil {
+		return nil, err
+	}
+	return projectstate.Diff(before, after), nil
+}
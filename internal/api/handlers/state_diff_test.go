@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffState(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/state/diff", DiffState)
+
+	tests := []struct {
+		name        string
+		body        string
+		wantCode    int
+		wantSummary []string
+	}{
+		{
+			name: "track added and muted",
+			body: `{"before": {"tracks": [{"index": 0, "name": "Drums"}]},
+				"after": {"state": {"tracks": [{"index": 0, "name": "Drums", "muted": true}, {"index": 1, "name": "Bass"}]}}}`,
+			wantCode:    http.StatusOK,
+			wantSummary: []string{`Added track 2 "Bass"`, `Changed track 1 "Drums": muted false -> true`},
+		},
+		{
+			name:        "unchanged",
+			body:        `{"before": {"tracks": []}, "after": {"tracks": []}}`,
+			wantCode:    http.StatusOK,
+			wantSummary: []string{},
+		},
+		{
+			name: "predicted from actions",
+			body: `{"before": {"tracks": [{"index": 0, "name": "Drums"}]},
+				"actions": [{"action": "create_track", "index": 0, "name": "Bass"}, {"action": "set_track", "track": 1, "solo": true}]}`,
+			wantCode:    http.StatusOK,
+			wantSummary: []string{`Added track 1 "Bass"`, `Changed track 2 "Drums": soloed false -> true`},
+		},
+		{
+			name:     "missing after and actions",
+			body:     `{"before": {"tracks": []}}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "both after and actions",
+			body:     `{"before": {"tracks": []}, "after": {"tracks": []}, "actions": []}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "invalid state",
+			body:     `{"before": {"tracks": "none"}, "after": {"tracks": []}}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/state/diff", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			require.Equal(t, tt.wantCode, w.Code, w.Body.String())
+			if tt.wantSummary == nil {
+				return
+			}
+			var body struct {
+				Changed bool             `json:"changed"`
+				Summary []string         `json:"summary"`
+				Changes []map[string]any `json:"changes"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantSummary, body.Summary)
+			assert.Equal(t, len(tt.wantSummary) > 0, body.Changed)
+			assert.Len(t, body.Changes, len(tt.wantSummary))
+		})
+	}
+}
+
+func TestDiffState_ActionWarnings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/state/diff", DiffState)
+
+	body := `{"before": {"tracks": [{"index": 0, "name": "Drums"}]},
+		"actions": [{"action": "delete_track", "track": 0}, {"action": "set_track", "track": 0, "mute": true}]}`
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/state/diff", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var response struct {
+		Summary  []string         `json:"summary"`
+		After    map[string]any   `json:"after"`
+		Warnings []map[string]any `json:"warnings"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, []string{`Removed track 1 "Drums"`}, response.Summary)
+	assert.Equal(t, []any{}, response.After["tracks"])
+	require.Len(t, response.Warnings, 1)
+	assert.Equal(t, "inconsistent_action", response.Warnings[0]["code"])
+	assert.EqualValues(t, 1, response.Warnings[0]["actionIndex"])
+}
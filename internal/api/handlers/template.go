@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	magdaconfig "github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/template"
+	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTemplateModel = "gpt-5.1"
+)
+
+type TemplateHandler struct {
+	agent *template.TemplateAgent
+	cfg   *config.Config
+}
+
+func NewTemplateHandler(cfg *config.Config) *TemplateHandler {
+	// Convert config to magda-agents config
+	magdaCfg := &magdaconfig.Config{
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+		LLMCache:         cfg.LLMCache,
+		LLMCacheTTL:      cfg.LLMCacheTTL,
+		LLMCacheSize:     cfg.LLMCacheSize,
+		RedisURL:         cfg.RedisURL,
+	}
+	agent := template.NewTemplateAgent(magdaCfg)
+
+	return &TemplateHandler{
+		agent: agent,
+		cfg:   cfg,
+	}
+}
+
+type TemplateRequest struct {
+	Model    string         `json:"model"`
+	Question string         `json:"question" binding:"required"`
+	State    map[string]any `json:"state"` // Optional: existing tracks and installed plugins
+}
+
+type TemplateResponse struct {
+	DSL     string           `json:"dsl"`
+	Actions []map[string]any `json:"actions"`
+	Usage   any              `json:"usage,omitempty"`
+}
+
+func (h *TemplateHandler) Generate(c *gin.Context) {
+	var req TemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Get user from gateway headers (for logging - auth handled by gateway)
+	userID, _ := middleware.GetUserIDFromGateway(c)
+	logger.Printf(c.Request.Context(), "🗂️ Template request from user %s", userID)
+
+	model := req.Model
+	if model == "" {
+		model = defaultTemplateModel
+	}
+
+	// The route's GENERATE_TIMEOUT deadline is on the request context
+	ctx := c.Request.Context()
+
+	result, err := h.agent.Generate(ctx, model, req.Question, req.State)
+	if err != nil {
+		logger.Printf(c.Request.Context(), "❌ Template generation failed: %v", err)
+		if middleware.TimedOut(c, err) {
+			c.JSON(http.StatusGatewayTimeout, middleware.TimeoutError(c))
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, TemplateResponse{
+		DSL:     result.DSL,
+		Actions: result.Actions,
+		Usage:   middleware.RecordUsage(c, model, result.Usage),
+	})
+}
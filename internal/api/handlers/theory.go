@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+
+	magdaarranger "github.com/Conceptual-Machines/magda-api/internal/agents/shared/arranger"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultSuggestionGenre = "pop"
+
+// SuggestProgressions returns candidate chord progressions for a key and genre, with their
+// Roman numeral analysis, without generating MIDI, so the chat can offer choices first
+// POST /api/v1/theory/suggest
+// Seed chords start every suggestion; count (default 4, up to 8) caps the suggestions
+func SuggestProgressions(c *gin.Context) {
+	var req struct {
+		Key        string   `json:"key" binding:"required"` // e.g. "A minor"
+		Genre      string   `json:"genre,omitempty"`        // Default pop
+		SeedChords []string `json:"seed_chords,omitempty"`  // Chord symbols to continue
+		Count      int      `json:"count,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Genre == "" {
+		req.Genre = defaultSuggestionGenre
+	}
+
+	key, err := magdaarranger.ParseKey(req.Key)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	suggestions, err := magdaarranger.SuggestProgressions(key, req.Genre, req.SeedChords, req.Count)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := gin.H{
+		"key":          key,
+		"genre":        req.Genre,
+		"progressions": suggestions,
+	}
+	if len(req.SeedChords) > 0 {
+		// Seed chords parsed in SuggestProgressions
+		response["seed_numerals"], _ = magdaarranger.AnalyzeProgression(req.SeedChords, key)
+	}
+
+	logger.Printf(c.Request.Context(), "✅ SuggestProgressions: %d %s progressions in %s from %d seed chords", len(suggestions), req.Genre, key.Name, len(req.SeedChords))
+	c.JSON(http.StatusOK, response)
+}
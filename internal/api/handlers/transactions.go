@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	magdaorchestrator "github.com/Conceptual-Machines/magda-api/internal/agents/core/coordination"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	"github.com/Conceptual-Machines/magda-api/internal/simulator"
+	"github.com/Conceptual-Machines/magda-api/internal/transactions"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// beginTransaction stores result's actions as a transaction awaiting the extension's result,
+// and sets req.transaction for the response. Previews, which aren't applied as returned, and
+// results without actions have none. A store failure only leaves the transaction out.
+func (h *MagdaHandler) beginTransaction(ctx context.Context, req *MagdaChatRequest, result *magdaorchestrator.OrchestratorResult) {
+	if h.transactions == nil || req.Preview || len(result.Actions) == 0 {
+		return
+	}
+	now := time.Now()
+	transaction := transactions.Transaction{
+		ID:           uuid.New().String(),
+		SessionID:    req.SessionID,
+		StateVersion: req.stateVersion,
+		Actions:      result.Actions,
+		Rollback:     undoActionsOrEmpty(result.UndoActions),
+		Status:       transactions.StatusPending,
+		Created:      now,
+		Updated:      now,
+	}
+	if err := h.transactions.Create(context.WithoutCancel(ctx), transaction); err != nil {
+		logger.Printf(ctx, "⚠️  Failed to store transaction: %v", err)
+		return
+	}
+	req.transaction = transaction.Envelope()
+}
+
+// addTransaction adds the request's transaction envelope to a response
+func addTransaction(response map[string]any, req *MagdaChatRequest) {
+	if req.transaction != nil {
+		response["transaction"] = req.transaction
+	}
+}
+
+// transactionResult is the extension's report of applying a transaction
+type transactionResult struct {
+	Status   transactions.Status    `json:"status" binding:"required"` // committed, rolled_back or partial
+	Applied  []int                  `json:"applied"`                   // Indices of the actions kept, for partial
+	Failures []transactions.Failure `json:"failures"`
+}
+
+// validate checks the report against the transaction's actions
+func (r *transactionResult) validate(actionCount int) error {
+	if !r.Status.Reported() {
+		return fmt.Errorf("invalid status %q: must be \"committed\", \"rolled_back\" or \"partial\"", r.Status)
+	}
+	if r.Status == transactions.StatusCommitted && len(r.Failures) > 0 {
+		return fmt.Errorf("a committed transaction has no failures")
+	}
+	if r.Status != transactions.StatusPartial && len(r.Applied) > 0 {
+		return fmt.Errorf("applied is only for a partial result")
+	}
+	if r.Status == transactions.StatusPartial && len(r.Applied) == 0 {
+		return fmt.Errorf("a partial result lists the applied actions")
+	}
+	for i, index := range r.Applied {
+		if index < 0 || index >= actionCount {
+			return fmt.Errorf("applied[%d]: action %d doesn't exist (the transaction has %d actions)", i, index, actionCount)
+		}
+		if i > 0 && index <= r.Applied[i-1] {
+			return fmt.Errorf("applied[%d]: indices must be in increasing order", i)
+		}
+	}
+	for i, failure := range r.Failures {
+		if failure.ActionIndex < 0 || failure.ActionIndex >= actionCount {
+			return fmt.Errorf("failures[%d]: action %d doesn't exist (the transaction has %d actions)", i, failure.ActionIndex, actionCount)
+		}
+	}
+	return nil
+}
+
+// GetTransaction returns a transaction and its result, if reported
+// GET /api/v1/transactions/:id
+func (h *MagdaHandler) GetTransaction(c *gin.Context) {
+	transaction, err := h.transactions.Get(c.Request.Context(), c.Param("id"))
+	switch {
+	case err != nil:
+		logger.Printf(c.Request.Context(), "❌ MAGDA transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case transaction == nil:
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+	default:
+		c.JSON(http.StatusOK, transaction)
+	}
+}
+
+// ReportTransaction records how applying a transaction went. For a session, the actions it
+// left applied are applied to the session's stored state, so the next state_delta is based on
+// the project as it now is. A result is taken once.
+// POST /api/v1/transactions/:id/result
+func (h *MagdaHandler) ReportTransaction(c *gin.Context) {
+	var req transactionResult
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	id := c.Param("id")
+	transaction, err := h.transactions.Get(ctx, id)
+	if err != nil {
+		logger.Printf(ctx, "❌ MAGDA transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if transaction == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+	if err := req.validate(len(transaction.Actions)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err = h.transactions.Update(ctx, id, func(tx *transactions.Transaction) {
+		tx.Status, tx.Applied, tx.Failures = req.Status, req.Applied, req.Failures
+	})
+	if errors.Is(err, transactions.ErrReported) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "transaction": transaction})
+		return
+	}
+	if err != nil {
+		logger.Printf(ctx, "❌ MAGDA transactions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if transaction == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return
+	}
+	logger.Printf(ctx, "🧾 Transaction %s: %s (%d failures)", id, transaction.Status, len(transaction.Failures))
+
+	response := gin.H{"transaction": transaction, "state_updated": false}
+	h.applyTransactionToSession(ctx, transaction, response)
+	c.JSON(http.StatusOK, response)
+}
+
+// applyTransactionToSession applies the actions a reported transaction left applied to its
+// session's stored state, when the state is still the version they were generated against,
+// and reports the session's state version in response. A state that has moved on since, or a
+// failure, leaves the state as it is: the client's next request sends the full state.
+func (h *MagdaHandler) applyTransactionToSession(ctx context.Context, transaction *transactions.Transaction, response gin.H) {
+	if transaction.SessionID == "" || transaction.StateVersion == 0 {
+		return
+	}
+	snapshot, err := h.sessions.State(ctx, transaction.SessionID)
+	if err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to load state: %v", transaction.SessionID, err)
+		return
+	}
+	if snapshot == nil {
+		return
+	}
+	response["state_version"] = snapshot.Version
+	if snapshot.Version != transaction.StateVersion {
+		logger.Printf(ctx, "💾 Session %s: state is at version %d, not %d; transaction not applied to it",
+			transaction.SessionID, snapshot.Version, transaction.StateVersion)
+		return
+	}
+	applied := transaction.AppliedActions()
+	if len(applied) == 0 {
+		return
+	}
+
+	simulated, err := simulator.Apply(snapshot.State, applied)
+	if err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to apply transaction to state: %v", transaction.SessionID, err)
+		return
+	}
+	updated := session.StateSnapshot{State: simulated.State, Version: snapshot.Version + 1, Updated: time.Now()}
+	if err := h.sessions.SaveState(context.WithoutCancel(ctx), transaction.SessionID, updated); err != nil {
+		logger.Printf(ctx, "⚠️  Session %s: failed to store state: %v", transaction.SessionID, err)
+		return
+	}
+	logger.Printf(ctx, "💾 Session %s: applied transaction %s, stored state version %d",
+		transaction.SessionID, transaction.ID, updated.Version)
+	response["state_version"] = updated.Version
+	response["state_updated"] = true
+}
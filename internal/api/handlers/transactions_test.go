@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	magdaorchestrator "github.com/Conceptual-Machines/magda-api/internal/agents/core/coordination"
+	"github.com/Conceptual-Machines/magda-api/internal/session"
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+	"github.com/Conceptual-Machines/magda-api/internal/transactions"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTransactionRouter(h *MagdaHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/transactions/:id", h.GetTransaction)
+	router.POST("/api/v1/transactions/:id/result", h.ReportTransaction)
+	return router
+}
+
+func reportTransaction(router *gin.Engine, id, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/"+id+"/result", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// twoActionResult is the result of two actions against a one-track project
+func twoActionResult() *magdaorchestrator.OrchestratorResult {
+	return &magdaorchestrator.OrchestratorResult{
+		Actions: []map[string]any{
+			{"action": "create_track", "index": 1, "name": "Bass"},
+			{"action": "set_track", "track": 0, "mute": true},
+		},
+		UndoActions: []map[string]any{
+			{"action": "set_track", "track": 0, "mute": false},
+			{"action": "delete_track", "track": 1},
+		},
+	}
+}
+
+func TestBeginTransaction(t *testing.T) {
+	ctx := context.Background()
+	h := &MagdaHandler{transactions: transactions.NewMemoryStore(0)}
+
+	req := &MagdaChatRequest{SessionID: "s1", stateVersion: 2}
+	h.beginTransaction(ctx, req, twoActionResult())
+	require.NotNil(t, req.transaction)
+	assert.Len(t, req.transaction.Actions, 2)
+	assert.Equal(t, "delete_track", req.transaction.Rollback[1]["action"])
+
+	stored, err := h.transactions.Get(ctx, req.transaction.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, transactions.StatusPending, stored.Status)
+	assert.Equal(t, "s1", stored.SessionID)
+	assert.Equal(t, 2, stored.StateVersion)
+
+	response := gin.H{}
+	addTransaction(response, req)
+	assert.Equal(t, req.transaction, response["transaction"])
+
+	// Previews and results without actions have none
+	req = &MagdaChatRequest{Preview: true}
+	h.beginTransaction(ctx, req, twoActionResult())
+	assert.Nil(t, req.transaction)
+	req = &MagdaChatRequest{}
+	h.beginTransaction(ctx, req, &magdaorchestrator.OrchestratorResult{})
+	assert.Nil(t, req.transaction)
+}
+
+func TestReportTransaction(t *testing.T) {
+	ctx := context.Background()
+	h := &MagdaHandler{sessions: session.NewMemoryStore(0, 0), transactions: transactions.NewMemoryStore(0)}
+	router := newTransactionRouter(h)
+
+	state := map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "Drums"}}}
+	require.NoError(t, h.sessions.SaveState(ctx, "s1", session.StateSnapshot{State: state, Version: 3}))
+	req := &MagdaChatRequest{SessionID: "s1", stateVersion: 3}
+	h.beginTransaction(ctx, req, twoActionResult())
+	require.NotNil(t, req.transaction)
+	id := req.transaction.ID
+
+	for name, body := range map[string]string{
+		"unknown status":           `{"status": "done"}`,
+		"partial without applied":  `{"status": "partial"}`,
+		"applied out of range":     `{"status": "partial", "applied": [0, 5]}`,
+		"applied out of order":     `{"status": "partial", "applied": [1, 0]}`,
+		"committed with a failure": `{"status": "committed", "failures": [{"actionIndex": 1, "error": "locked"}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := reportTransaction(router, id, body)
+			assert.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+		})
+	}
+
+	// The kept action is applied to the session's state
+	w := reportTransaction(router, id, `{"status": "partial", "applied": [0], "failures": [{"actionIndex": 1, "error": "track is locked"}]}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var response struct {
+		Transaction  transactions.Transaction `json:"transaction"`
+		StateVersion int                      `json:"state_version"`
+		StateUpdated bool                     `json:"state_updated"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, transactions.StatusPartial, response.Transaction.Status)
+	assert.Equal(t, []transactions.Failure{{ActionIndex: 1, Error: "track is locked"}}, response.Transaction.Failures)
+	assert.Equal(t, 4, response.StateVersion)
+	assert.True(t, response.StateUpdated)
+
+	snapshot, err := h.sessions.State(ctx, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, 4, snapshot.Version)
+	project, err := projectstate.Parse(snapshot.State)
+	require.NoError(t, err)
+	require.Len(t, project.Tracks, 2)
+	assert.Equal(t, "Bass", project.Tracks[1].Name)
+	assert.False(t, project.Tracks[0].Mute)
+
+	// A result is taken once
+	w = reportTransaction(router, id, `{"status": "committed"}`)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/transactions/"+id, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"partial"`)
+
+	w = reportTransaction(router, "unknown", `{"status": "committed"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestReportTransaction_StateMovedOn(t *testing.T) {
+	ctx := context.Background()
+	h := &MagdaHandler{sessions: session.NewMemoryStore(0, 0), transactions: transactions.NewMemoryStore(0)}
+	router := newTransactionRouter(h)
+
+	state := map[string]any{"tracks": []any{map[string]any{"index": 0, "name": "Drums"}}}
+	require.NoError(t, h.sessions.SaveState(ctx, "s1", session.StateSnapshot{State: state, Version: 3}))
+	req := &MagdaChatRequest{SessionID: "s1", stateVersion: 3}
+	h.beginTransaction(ctx, req, twoActionResult())
+	require.NotNil(t, req.transaction)
+
+	// The client sent a newer state before reporting: it already has the changes
+	require.NoError(t, h.sessions.SaveState(ctx, "s1", session.StateSnapshot{State: state, Version: 4}))
+	w := reportTransaction(router, req.transaction.ID, `{"status": "committed"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.JSONEq(t, `4`, jsonField(t, w, "state_version"))
+	assert.JSONEq(t, `false`, jsonField(t, w, "state_updated"))
+
+	snapshot, err := h.sessions.State(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, 4, snapshot.Version)
+	assert.Equal(t, state, snapshot.State)
+}
+
+func jsonField(t *testing.T, w *httptest.ResponseRecorder, key string) string {
+	t.Helper()
+	var body map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return string(body[key])
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler reports token usage and cost per caller and day
+type UsageHandler struct {
+	store usage.Store
+}
+
+func NewUsageHandler(store usage.Store) *UsageHandler {
+	return &UsageHandler{store: store}
+}
+
+// Totals handles GET /api/v1/admin/usage?key=&from=YYYY-MM-DD&to=YYYY-MM-DD.
+// Without key every caller is listed; from and to default to today (UTC).
+func (h *UsageHandler) Totals(c *gin.Context) {
+	if h.store == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Usage accounting is off (USAGE_STORE=off)"})
+		return
+	}
+
+	today := usage.Day(time.Now())
+	fromDay := c.DefaultQuery("from", today)
+	toDay := c.DefaultQuery("to", fromDay)
+	from, err := usage.ParseDay(fromDay)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to, err := usage.ParseDay(toDay)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := usage.CheckRange(from, to); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key := c.Query("key")
+	days, err := h.store.Totals(c.Request.Context(), key, from, to)
+	if err != nil {
+		logger.Printf(c.Request.Context(), "❌ Usage query failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var total usage.Totals
+	for _, day := range days {
+		total.Add(day.Totals)
+	}
+	if days == nil {
+		days = []usage.DailyTotals{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key":   key,
+		"from":  fromDay,
+		"to":    toDay,
+		"days":  days,
+		"total": total,
+	})
+}
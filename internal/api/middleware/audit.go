@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/audit"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/gin-gonic/gin"
+)
+
+const auditStoreKey = "audit_store"
+
+// AuditLog makes store available to RecordAudit. A nil store records nothing.
+func AuditLog(store audit.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store != nil {
+			c.Set(auditStoreKey, store)
+		}
+		c.Next()
+	}
+}
+
+// RecordAudit appends entry to the audit log, stamped with the time, caller, request ID and
+// endpoint. A failed write is logged; the request still succeeds.
+func RecordAudit(c *gin.Context, entry audit.Entry) {
+	store, ok := c.Get(auditStoreKey)
+	if !ok {
+		return
+	}
+	entry.Time = time.Now().UTC()
+	entry.Key = UsageKey(c)
+	entry.RequestID = c.GetString("request_id")
+	entry.Endpoint = c.Request.Method + " " + c.FullPath()
+	if err := store.(audit.Store).Append(c.Request.Context(), entry); err != nil {
+		logger.Printf(c.Request.Context(), "⚠️  Audit log unavailable, request not recorded: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit limits requests per API key (or gateway user) and per client IP with token buckets.
+// It must run after the auth middleware, which sets api_key_id / user_id_str.
+// X-RateLimit-Limit/Remaining/Reset report the tightest bucket; rejected requests get a 429
+// with Retry-After. If the limiter backend fails, requests are allowed.
+func RateLimit(limiter ratelimit.Limiter, perKey, perIP ratelimit.Limit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		type check struct {
+			key   string
+			limit ratelimit.Limit
+		}
+		var checks []check
+		if key := rateLimitKey(c); key != "" && perKey.Enabled() {
+			checks = append(checks, check{key: key, limit: perKey})
+		}
+		if perIP.Enabled() {
+			checks = append(checks, check{key: "ip:" + c.ClientIP(), limit: perIP})
+		}
+
+		var tightest *ratelimit.Result
+		for _, check := range checks {
+			result, err := limiter.Allow(c.Request.Context(), check.key, check.limit)
+			if err != nil {
+				logger.Printf(c.Request.Context(), "⚠️  Rate limiter unavailable, allowing request: %v", err)
+				continue
+			}
+			if tightest == nil || !result.Allowed || result.Remaining < tightest.Remaining {
+				tightest = &result
+			}
+			if !result.Allowed {
+				break
+			}
+		}
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(tightest.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(tightest.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(tightest.Reset.Unix(), 10))
+
+		if !tightest.Allowed {
+			retryAfter := int(math.Ceil(tightest.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			logger.Printf(c.Request.Context(), "🚦 Rate limit exceeded for %s (%s)", c.ClientIP(), c.FullPath())
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":       "Rate limit exceeded",
+				"message":     "Too many requests, retry after " + strconv.Itoa(retryAfter) + "s",
+				"retry_after": retryAfter,
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey identifies the caller: the gateway API key, else the authenticated user.
+// Anonymous requests have no key and are only limited per IP.
+func rateLimitKey(c *gin.Context) string {
+	if apiKeyID, ok := c.Get("api_key_id"); ok {
+		if id, ok := apiKeyID.(string); ok && id != "" {
+			return "key:" + id
+		}
+	}
+	if userID, ok := GetUserIDFromGateway(c); ok && userID != "" && userID != "anonymous" {
+		return "user:" + userID
+	}
+	return ""
+}
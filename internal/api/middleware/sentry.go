@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/logger"
@@ -16,20 +17,34 @@ const (
 	httpStatusBadRequest          = http.StatusBadRequest
 	httpStatusInternalServerError = http.StatusInternalServerError
 	sentryFlushTimeout            = 2 * time.Second
+
+	// RequestIDHeader carries the correlation ID in requests and responses
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
 )
 
 // Global metrics instance
 var sentryMetrics = metrics.NewSentryMetrics()
 
-// RequestTracking adds request ID and logging to all requests
+// RequestTracking adds request ID and logging to all requests.
+// An incoming X-Request-ID is reused so IDs can be followed across services; the ID and
+// the trace ID are attached to the request context for logger.Printf.
 func RequestTracking() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Generate request ID
-		requestID := uuid.New().String()
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
 		c.Set("request_id", requestID)
 
+		ctx := logger.WithRequestID(c.Request.Context(), requestID)
+		if traceID := traceIDFromRequest(c.Request); traceID != "" {
+			ctx = logger.WithTraceID(ctx, traceID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
 		// Add to response header
-		c.Header("X-Request-ID", requestID)
+		c.Header(RequestIDHeader, requestID)
 
 		// Start timer
 		start := time.Now()
@@ -64,6 +79,33 @@ func RequestTracking() gin.HandlerFunc {
 	}
 }
 
+// validRequestID accepts client-supplied IDs that are safe to echo and log
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		isAlphanumeric := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+		if !isAlphanumeric && !strings.ContainsRune("-_.:", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// traceIDFromRequest returns the Sentry transaction's trace ID, or the one in a W3C
+// traceparent header ("00-<trace-id>-<parent-id>-<flags>")
+func traceIDFromRequest(r *http.Request) string {
+	if transaction := sentry.TransactionFromContext(r.Context()); transaction != nil {
+		return transaction.TraceID.String()
+	}
+	parts := strings.Split(r.Header.Get("traceparent"), "-")
+	if len(parts) == 4 && len(parts[1]) == 32 {
+		return parts[1]
+	}
+	return ""
+}
+
 // SentryMiddleware returns the Sentry middleware with custom configuration
 func SentryMiddleware() gin.HandlerFunc {
 	return sentrygin.New(sentrygin.Options{
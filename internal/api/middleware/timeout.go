@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const timeoutKey = "request_timeout"
+
+// Timeout gives the request's context a deadline d from now. Handlers pass that context on
+// to LLM providers and DSL parsers, which stop when it passes, and answer with TimeoutError.
+// A d of 0 or less leaves the request without a deadline.
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Set(timeoutKey, d)
+		c.Next()
+	}
+}
+
+// TimedOut reports whether err ended the request because its Timeout passed
+func TimedOut(c *gin.Context, err error) bool {
+	return err != nil && (errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(c.Request.Context().Err(), context.DeadlineExceeded))
+}
+
+// TimeoutError is the error body for a request that ran past its Timeout, sent with
+// 504 Gateway Timeout (or as a stream's error event)
+func TimeoutError(c *gin.Context) gin.H {
+	message := "Request timed out"
+	body := gin.H{
+		"code":       "request_timeout",
+		"request_id": c.GetString("request_id"),
+	}
+	if d, ok := c.Get(timeoutKey); ok {
+		message = fmt.Sprintf("Request timed out after %v", d)
+		body["timeout_seconds"] = d.(time.Duration).Seconds()
+	}
+	body["error"] = message
+	return body
+}
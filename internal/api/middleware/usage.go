@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/observability"
+	"github.com/Conceptual-Machines/magda-api/internal/usage"
+	"github.com/gin-gonic/gin"
+)
+
+const usageStoreKey = "usage_store"
+
+// UsageTracking makes store available to RecordUsage. A nil store still prices usage
+// but keeps no totals.
+func UsageTracking(store usage.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if store != nil {
+			c.Set(usageStoreKey, store)
+		}
+		c.Next()
+	}
+}
+
+// RecordUsage prices a result's token usage for model, adds it to the caller's daily totals
+// and returns the usage block for the response, with cost_usd added.
+// Usage in an unknown shape is returned as is.
+func RecordUsage(c *gin.Context, model string, u any) any {
+	responseUsage, ok := observability.ToResponseUsage(u)
+	if !ok {
+		return u
+	}
+	cost := observability.CalculateOpenAICost(model, responseUsage)
+
+	if store, ok := c.Get(usageStoreKey); ok {
+		totals := usage.Totals{
+			Requests:        1,
+			InputTokens:     responseUsage.InputTokens,
+			OutputTokens:    responseUsage.OutputTokens,
+			ReasoningTokens: responseUsage.OutputTokensDetails.ReasoningTokens,
+			TotalTokens:     responseUsage.TotalTokens,
+			CostUSD:         cost,
+		}
+		if err := store.(usage.Store).Record(c.Request.Context(), UsageKey(c), time.Now(), totals); err != nil {
+			logger.Printf(c.Request.Context(), "⚠️  Usage store unavailable, request not counted: %v", err)
+		}
+	}
+
+	block := map[string]any{}
+	if data, err := json.Marshal(u); err == nil {
+		_ = json.Unmarshal(data, &block)
+	}
+	block["cost_usd"] = cost
+	return block
+}
+
+// UsageKey identifies the caller usage is counted under: the gateway API key, else the
+// authenticated user, else "anonymous"
+func UsageKey(c *gin.Context) string {
+	if key := rateLimitKey(c); key != "" {
+		return key
+	}
+	return "anonymous"
+}
+
+// RequireRole rejects requests whose gateway user doesn't have role
+func RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userRole, ok := GetUserRoleFromGateway(c); !ok || userRole != role {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":   "Forbidden",
+				"message": "This endpoint requires the " + role + " role",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
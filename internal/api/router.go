@@ -1,9 +1,17 @@
 package api
 
 import (
+	"log"
+	"strings"
+
 	"github.com/Conceptual-Machines/magda-api/internal/api/handlers"
 	"github.com/Conceptual-Machines/magda-api/internal/api/middleware"
+	"github.com/Conceptual-Machines/magda-api/internal/audit"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/debugcapture"
+	"github.com/Conceptual-Machines/magda-api/internal/observability"
+	"github.com/Conceptual-Machines/magda-api/internal/ratelimit"
+	"github.com/Conceptual-Machines/magda-api/internal/usage"
 	"github.com/gin-gonic/gin"
 )
 
@@ -28,6 +36,11 @@ func SetupRouter(cfg *config.Config, version string) *gin.Engine {
 	// Health check (no auth required)
 	router.GET("/health", handlers.HealthCheck)
 
+	// Kubernetes probes: liveness and readiness with dependency checks (no auth required)
+	readinessHandler := handlers.NewReadinessHandler(cfg)
+	router.GET("/healthz", handlers.Liveness)
+	router.GET("/readyz", readinessHandler.Readiness)
+
 	// MCP status endpoint (no auth required)
 	router.GET("/mcp/status", handlers.MCPStatus)
 
@@ -39,35 +52,81 @@ func SetupRouter(cfg *config.Config, version string) *gin.Engine {
 	magdaHandler := handlers.NewMagdaHandler(cfg)
 	jsfxHandler := handlers.NewJSFXHandler(cfg)
 	drummerHandler := handlers.NewDrummerHandler(cfg)
+	templateHandler := handlers.NewTemplateHandler(cfg)
 	mixHandler := handlers.NewMixHandler(cfg)
 	generationHandler := handlers.NewGenerationHandler(cfg)
+	usageStore := getUsageStore(cfg)
+	usageHandler := handlers.NewUsageHandler(usageStore)
+	auditStore := getAuditStore(cfg)
+	auditHandler := handlers.NewAuditHandler(auditStore)
+	debugHandler := handlers.NewDebugHandler(getDebugCapture(cfg))
 
 	// API routes v1 with conditional auth based on AUTH_MODE
 	v1 := router.Group("/api/v1")
 	v1.Use(getAuthMiddleware(cfg))
+	v1.Use(getRateLimitMiddleware(cfg))
+	v1.Use(middleware.UsageTracking(usageStore))
+	v1.Use(middleware.AuditLog(auditStore))
+
+	// Requests that wait on an LLM get a deadline (MAGDA_CHAT_TIMEOUT and friends)
+	chat := v1.Group("", middleware.Timeout(cfg.ChatTimeout))
+	stream := v1.Group("", middleware.Timeout(cfg.StreamTimeout))
+	generate := v1.Group("", middleware.Timeout(cfg.GenerateTimeout))
 	{
 		// AIDEAS endpoints - Music generation using arranger agent
 		v1.POST("/aideas/generations", generationHandler.Generate)
 
 		// MAGDA endpoints - DAW control using magda-agents
-		v1.POST("/chat", magdaHandler.Chat)
-		v1.POST("/chat/stream", magdaHandler.ChatStream) // Streaming endpoint
-		v1.POST("/dsl/stream", magdaHandler.DSLStream)   // DSL streaming endpoint
-		v1.POST("/dsl", magdaHandler.TestDSL)            // DSL parser endpoint
+		chat.POST("/chat", magdaHandler.Chat)
+		chat.POST("/magda/chat", magdaHandler.Chat)                         // Same as /chat, alongside the /magda/chat/stream endpoints
+		stream.POST("/chat/stream", magdaHandler.ChatStream)                // Streaming endpoint
+		stream.POST("/dsl/stream", magdaHandler.DSLStream)                  // DSL streaming endpoint
+		v1.POST("/dsl", magdaHandler.TestDSL)                               // DSL parser endpoint
+		v1.POST("/magda/validate", magdaHandler.ValidateDSL)                // DSL dry run (no LLM)
+		stream.GET("/magda/chat/stream", magdaHandler.MagdaChatStream)      // SSE for EventSource clients (query params)
+		stream.POST("/magda/chat/stream", magdaHandler.MagdaChatStream)     // SSE with text deltas
+		v1.POST("/magda/feedback", magdaHandler.Feedback)                   // Per-action results of the session's last actions
+		v1.GET("/ws", magdaHandler.ControlChannel)                          // WebSocket control channel for the extension
+		v1.POST("/jobs", magdaHandler.SubmitJob)                            // Async chat request, returns a job ID
+		v1.GET("/jobs/:id", magdaHandler.GetJob)                            // Job status, progress and result
+		v1.DELETE("/jobs/:id", magdaHandler.CancelJob)                      // Cancel a queued or running job
+		v1.GET("/transactions/:id", magdaHandler.GetTransaction)            // A response's actions and their reported result
+		v1.POST("/transactions/:id/result", magdaHandler.ReportTransaction) // Report applying a response's actions
 
 		// MAGDA Plugin endpoints
 		v1.POST("/plugins/process", magdaHandler.ProcessPlugins)
+		v1.POST("/plugins/normalize", magdaHandler.NormalizePlugins)
 
 		// MAGDA Mix Analysis endpoint
 		v1.POST("/mix/analyze", mixHandler.MixAnalyze)
 		v1.POST("/mix/analyze/stream", mixHandler.MixAnalyzeStream)
 
+		// Music analysis endpoints (no LLM)
+		v1.POST("/analysis/key", handlers.AnalyzeKey)
+		v1.POST("/theory/suggest", handlers.SuggestProgressions) // Candidate progressions, no MIDI
+		v1.POST("/automation/preview", handlers.PreviewAutomation)
+		v1.GET("/schema/actions", handlers.ActionSchema) // JSON Schema of returned actions
+		v1.POST("/state/diff", handlers.DiffState)       // Changes between two project snapshots
+
 		// JSFX agent endpoint - AI-assisted JSFX effect generation
 		v1.POST("/jsfx/generate", jsfxHandler.Generate)
 		v1.POST("/jsfx/generate/stream", jsfxHandler.GenerateStream)
 
 		// Drummer agent endpoint
-		v1.POST("/drummer/generate", drummerHandler.Generate)
+		generate.POST("/drummer/generate", drummerHandler.Generate)
+
+		// Template agent endpoint - project layouts (tracks, folders, buses, sections)
+		generate.POST("/templates/generate", templateHandler.Generate)
+
+		// Admin endpoints
+		v1.GET("/admin/usage", getAdminMiddleware(cfg), usageHandler.Totals)
+		v1.GET("/admin/audit", getAdminMiddleware(cfg), auditHandler.Entries)
+		v1.GET("/admin/experiments", getAdminMiddleware(cfg), magdaHandler.PromptExperiments)
+		v1.PUT("/admin/experiments", getAdminMiddleware(cfg), magdaHandler.SetPromptExperimentWeights)
+
+		// Debug capture of provider payloads (DEBUG_CAPTURE=on)
+		v1.GET("/debug/captures", getAdminMiddleware(cfg), debugHandler.Captures)
+		v1.DELETE("/debug/captures", getAdminMiddleware(cfg), debugHandler.ClearCaptures)
 	}
 
 	return router
@@ -87,3 +146,74 @@ func getAuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 		return middleware.NoAuth()
 	}
 }
+
+// getRateLimitMiddleware returns the rate limiter for RATE_LIMIT_STORE (a pass-through when off)
+func getRateLimitMiddleware(cfg *config.Config) gin.HandlerFunc {
+	limiter, err := ratelimit.NewLimiter(cfg.RateLimitStore, cfg.RedisURL)
+	if err != nil {
+		log.Printf("⚠️  Rate limit store %q unavailable, using in-memory limits: %v", cfg.RateLimitStore, err)
+		limiter = ratelimit.NewMemoryLimiter()
+	}
+	if limiter != nil {
+		log.Printf("🚦 Rate limiting enabled (%s): %d/min per key, %d/min per IP",
+			cfg.RateLimitStore, cfg.RateLimitPerKey, cfg.RateLimitPerIP)
+	}
+	return middleware.RateLimit(limiter,
+		ratelimit.Limit{PerMinute: cfg.RateLimitPerKey, Burst: cfg.RateLimitBurst},
+		ratelimit.Limit{PerMinute: cfg.RateLimitPerIP, Burst: cfg.RateLimitBurst},
+	)
+}
+
+// getAdminMiddleware restricts admin endpoints to gateway users with the admin role.
+// Without the gateway (self-hosted) there are no roles and every caller is trusted.
+func getAdminMiddleware(cfg *config.Config) gin.HandlerFunc {
+	if cfg.IsGatewayMode() {
+		return middleware.RequireRole("admin")
+	}
+	return func(c *gin.Context) { c.Next() }
+}
+
+// getUsageStore applies MODEL_PRICING and returns the store for USAGE_STORE (nil when off)
+func getUsageStore(cfg *config.Config) usage.Store {
+	pricing, err := observability.ParsePricing(cfg.ModelPricing)
+	if err != nil {
+		log.Printf("⚠️  Ignoring MODEL_PRICING: %v", err)
+	} else if len(pricing) > 0 {
+		observability.SetPricing(pricing)
+		log.Printf("💲 Model pricing overridden for %d models", len(pricing))
+	}
+
+	store, err := usage.NewStore(cfg.UsageStore, cfg.RedisURL)
+	if err != nil {
+		log.Printf("⚠️  Usage store %q unavailable, using in-memory totals: %v", cfg.UsageStore, err)
+		store = usage.NewMemoryStore()
+	}
+	return store
+}
+
+// getAuditStore returns the audit log for AUDIT_LOG (nil when off)
+func getAuditStore(cfg *config.Config) audit.Store {
+	store, err := audit.NewStore(cfg.AuditLog, cfg.AuditLogPath)
+	if err != nil {
+		log.Printf("⚠️  Audit log %q unavailable, keeping entries in memory: %v", cfg.AuditLog, err)
+		store = audit.NewMemoryStore()
+	}
+	if store != nil {
+		log.Printf("📜 Audit log enabled (%s)", cfg.AuditLog)
+	}
+	return store
+}
+
+// getDebugCapture turns on the debug capture buffer for DEBUG_CAPTURE=on (nil when off)
+func getDebugCapture(cfg *config.Config) *debugcapture.Buffer {
+	switch strings.ToLower(strings.TrimSpace(cfg.DebugCapture)) {
+	case "", "off":
+		return nil
+	case "on":
+		log.Printf("🐞 Debug capture enabled: keeping the last %d provider calls (redacted) in memory", cfg.DebugCaptureSize)
+		return debugcapture.Enable(cfg.DebugCaptureSize)
+	default:
+		log.Printf("⚠️  Unknown DEBUG_CAPTURE %q, leaving debug capture off", cfg.DebugCapture)
+		return nil
+	}
+}
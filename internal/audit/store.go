@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultQueryLimit is how many entries Query returns when no limit is given
+	DefaultQueryLimit = 100
+	// MaxQueryLimit is the most entries Query returns
+	MaxQueryLimit = 1000
+)
+
+// Entry records one request and the actions generated for it
+type Entry struct {
+	Time      time.Time        `json:"time"`
+	Key       string           `json:"key"` // Caller, as usage is counted (API key, user or "anonymous")
+	RequestID string           `json:"request_id,omitempty"`
+	Endpoint  string           `json:"endpoint"`
+	Question  string           `json:"question"`
+	DSL       string           `json:"dsl,omitempty"` // Empty for the fast path
+	Actions   []map[string]any `json:"actions"`
+	Model     string           `json:"model,omitempty"`
+	Path      string           `json:"path,omitempty"`
+	Preview   bool             `json:"preview,omitempty"` // Returned for confirmation, not applied yet
+}
+
+// Store is an append-only log of entries
+type Store interface {
+	// Append adds entry to the end of the log
+	Append(ctx context.Context, entry Entry) error
+	// Query returns the entries from one time to another (inclusive) in time order, keeping the
+	// most recent limit of them. An empty key returns every caller.
+	Query(ctx context.Context, key string, from, to time.Time, limit int) ([]Entry, error)
+}
+
+// NewStore creates the audit log for backend: "memory" or "file" (JSON lines at path).
+// "off" (or empty) disables the audit log and returns a nil store.
+func NewStore(backend, path string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", "off":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(), nil
+	case "file":
+		if path == "" {
+			return nil, fmt.Errorf("AUDIT_LOG_PATH is required for the file audit log")
+		}
+		return NewFileStore(path)
+	default:
+		return nil, fmt.Errorf("unknown audit log %q: must be \"off\", \"memory\" or \"file\"", backend)
+	}
+}
+
+// ParseTime parses a query bound: an RFC 3339 time, or a YYYY-MM-DD day (UTC), which as the end
+// of a range includes the whole day
+func ParseTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: must be RFC 3339 or YYYY-MM-DD", value)
+	}
+	if end {
+		return day.Add(24*time.Hour - time.Nanosecond), nil
+	}
+	return day, nil
+}
+
+// matches reports whether entry is in the query's range and, with a key, is the key's
+func (e *Entry) matches(key string, from, to time.Time) bool {
+	return (key == "" || e.Key == key) && !e.Time.Before(from) && !e.Time.After(to)
+}
+
+// keepLast appends entry to entries, dropping the oldest beyond limit
+func keepLast(entries []Entry, entry Entry, limit int) []Entry {
+	entries = append(entries, entry)
+	if len(entries) > limit {
+		entries = entries[1:]
+	}
+	return entries
+}
+
+// queryLimit clamps a requested limit to 1..MaxQueryLimit, DefaultQueryLimit when unset
+func queryLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultQueryLimit
+	}
+	return min(limit, MaxQueryLimit)
+}
+
+// MemoryStore keeps entries in process memory; they are per instance and lost on restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryStore creates an in-memory audit log
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append adds entry to the log
+func (m *MemoryStore) Append(ctx context.Context, entry Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+// Query returns the matching entries
+func (m *MemoryStore) Query(ctx context.Context, key string, from, to time.Time, limit int) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	limit = queryLimit(limit)
+	var result []Entry
+	for _, entry := range m.entries {
+		if entry.matches(key, from, to) {
+			result = keepLast(result, entry, limit)
+		}
+	}
+	return result, nil
+}
+
+// FileStore appends entries to a file as JSON lines, so the log survives restarts and can be
+// shipped or inspected with standard tools. Queries read the whole file.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a log at path, creating the file if it doesn't exist
+func NewFileStore(path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileStore{path: path}, nil
+}
+
+// Append writes entry as one line at the end of the file
+func (f *FileStore) Append(ctx context.Context, entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("audit append failed: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("audit append failed: %w", err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("audit append failed: %w", err)
+	}
+	return nil
+}
+
+// Query scans the file for matching entries. Lines that don't decode, such as one cut short
+// by a crash, are skipped.
+func (f *FileStore) Query(ctx context.Context, key string, from, to time.Time, limit int) ([]Entry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("audit query failed: %w", err)
+	}
+	defer file.Close()
+
+	limit = queryLimit(limit)
+	var result []Entry
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			var entry Entry
+			if json.Unmarshal(line, &entry) == nil && entry.matches(key, from, to) {
+				result = keepLast(result, entry, limit)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("audit query failed: %w", err)
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package audit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	fileStore, err := NewFileStore(filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	return map[string]Store{"memory": NewMemoryStore(), "file": fileStore}
+}
+
+func TestStore_Query(t *testing.T) {
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tuesday := monday.Add(24 * time.Hour)
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			entries := []Entry{
+				{Time: monday, Key: "key:a", Question: "add a bass track", DSL: `track(name="Bass")`,
+					Actions: []map[string]any{{"action": "create_track", "name": "Bass"}}, Model: "gpt-5.1"},
+				{Time: monday.Add(time.Hour), Key: "user:7", Question: "mute the drums"},
+				{Time: tuesday, Key: "key:a", Question: "delete track 1"},
+			}
+			for _, entry := range entries {
+				require.NoError(t, store.Append(ctx, entry))
+			}
+
+			result, err := store.Query(ctx, "key:a", monday, tuesday, 0)
+			require.NoError(t, err)
+			require.Len(t, result, 2)
+			assert.Equal(t, "add a bass track", result[0].Question)
+			assert.Equal(t, `track(name="Bass")`, result[0].DSL)
+			assert.Equal(t, "create_track", result[0].Actions[0]["action"])
+			assert.Equal(t, "gpt-5.1", result[0].Model)
+			assert.True(t, result[0].Time.Equal(monday))
+			assert.Equal(t, "delete track 1", result[1].Question)
+
+			// Without a key every caller is listed
+			result, err = store.Query(ctx, "", monday, monday.Add(time.Hour), 0)
+			require.NoError(t, err)
+			require.Len(t, result, 2)
+			assert.Equal(t, "user:7", result[1].Key)
+
+			// A limit keeps the most recent entries
+			result, err = store.Query(ctx, "", monday, tuesday, 1)
+			require.NoError(t, err)
+			require.Len(t, result, 1)
+			assert.Equal(t, "delete track 1", result[0].Question)
+
+			result, err = store.Query(ctx, "key:b", monday, tuesday, 0)
+			require.NoError(t, err)
+			assert.Empty(t, result)
+		})
+	}
+}
+
+func TestFileStore_SkipsBrokenLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	ctx := context.Background()
+	at := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Append(ctx, Entry{Time: at, Key: "key:a", Question: "first"}))
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"time": "2026-03-02T10:00:00Z", "key": "key:a", "quest` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	require.NoError(t, store.Append(ctx, Entry{Time: at, Key: "key:a", Question: "second"}))
+
+	result, err := store.Query(ctx, "key:a", at, at, 0)
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, "second", result[1].Question)
+
+	// A reopened store reads what the previous one wrote
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+	result, err = reopened.Query(ctx, "", at, at, 0)
+	require.NoError(t, err)
+	assert.Len(t, result, 2)
+}
+
+func TestParseTime(t *testing.T) {
+	start, err := ParseTime("2026-03-02", false)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), start)
+
+	end, err := ParseTime("2026-03-02", true)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 2, 23, 59, 59, 999999999, time.UTC), end)
+
+	exact, err := ParseTime("2026-03-02T10:30:00+01:00", true)
+	require.NoError(t, err)
+	assert.True(t, exact.Equal(time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)))
+
+	_, err = ParseTime("yesterday", false)
+	assert.Error(t, err)
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore("off", "")
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = NewStore("memory", "")
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	store, err = NewStore("file", filepath.Join(t.TempDir(), "audit.jsonl"))
+	require.NoError(t, err)
+	assert.IsType(t, &FileStore{}, store)
+
+	_, err = NewStore("file", "")
+	assert.Error(t, err)
+
+	_, err = NewStore("sqlite", "")
+	assert.Error(t, err)
+}
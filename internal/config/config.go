@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
 
 // Config holds the application configuration
 // Note: This is a stateless configuration - no database or auth secrets needed
@@ -11,12 +16,87 @@ type Config struct {
 	Port        string
 
 	// LLM API Keys
-	OpenAIAPIKey string // OpenAI API key for GPT models
+	OpenAIAPIKey     string // OpenAI API key for GPT models
+	AnthropicAPIKey  string // Anthropic API key for Claude models
+	AnthropicModel   string // Claude model used when LLMProvider is "anthropic" (optional)
+	LLMProvider      string // "openai" (default), "anthropic" or "mock" (canned responses, for tests)
+	LLMFallback      string // Comma-separated fallback providers tried after LLMProvider (optional)
+	LLMMockResponses string // JSON file of canned responses for the mock provider (optional)
 
 	// MCP Server (optional)
 	MCPServerURL string
 
+	// Model routing for DAW requests: a cheaper model for simple commands
+	ModelRouting        string // "off" (default) or "auto"
+	ModelRoutingSimple  string // Model for simple commands
+	ModelRoutingComplex string // Model for complex, multi-statement and musical requests
+
+	// Per-request LLM parameter overrides (the chat request's "llm" field)
+	LLMOverrideModels    string // Comma-separated models a request may pick; empty disallows model overrides
+	LLMOverrideReasoning string // Comma-separated reasoning efforts a request may pick
+	LLMMaxOutputTokens   int    // Highest max_output_tokens a request may ask for
+
+	// A/B tests of DAW system prompt variants
+	PromptExperiments string // JSON file of prompt variants and traffic weights (optional)
+
+	// Repairs of near-valid generated DSL before parsing; requests can opt out with strict_dsl
+	DSLRepair       string // "on" (default) or "off"
+	DSLParseRetries int    // Re-prompts with the parse error when generated DSL still doesn't parse; 0 disables
+
+	// Up-front rejection of requests unrelated to music production
+	ScopeCheck string // "local" (default, keyword lists), "llm" (plus a classification call) or "off"
+
+	// Limits on deletions in one request (filter(tracks, track.index >= 0).delete()); deleting
+	// every track always needs confirmation unless the guard is off
+	SafetyGuard            string // "confirm" (default: ask for confirm=true), "refuse" or "off"
+	SafetyMaxDeletedTracks int    // Tracks one request may delete unconfirmed; 0 disables the limit
+	SafetyMaxDeletedClips  int    // Clips one request may delete unconfirmed; 0 disables the limit
+
+	// Per-route request timeouts: past its deadline a request's LLM calls and DSL parsing stop
+	// and it answers 504 (an error event once a stream has started); 0 disables a timeout
+	ChatTimeout     time.Duration // /chat and /magda/chat
+	StreamTimeout   time.Duration // Streaming chat endpoints (/chat/stream, /dsl/stream, /magda/chat/stream)
+	GenerateTimeout time.Duration // Drummer and template generation
+
+	// Language of user requests: "auto" (default) detects it per request, or a code pins it
+	PromptLanguage string // "auto", "en", "de", "es", "fr" or "ja"
+
+	// Conversation history for follow-up requests (keyed by session_id)
+	SessionStore string        // "memory" (default) or "redis"
+	RedisURL     string        // redis://[user:password@]host:port[/db], required for the redis store
+	SessionTTL   time.Duration // How long a session is kept after its last turn
+
+	// Async chat requests (/api/v1/jobs)
+	JobStore   string        // "memory" (default) or "redis" (uses RedisURL, shared between instances)
+	JobTTL     time.Duration // How long a job's status and result are kept after its last update
+	JobWorkers int           // Jobs run at once per instance
+
+	// LLM response cache for repeated identical requests (demos, tests)
+	LLMCache     string        // "off" (default), "memory" or "redis" (uses RedisURL)
+	LLMCacheTTL  time.Duration // How long a cached response is served
+	LLMCacheSize int           // Entries kept by the memory cache
+
+	// Rate limiting on /api/v1 (token buckets, requests per minute)
+	RateLimitStore  string // "off" (default), "memory" or "redis" (uses RedisURL, shared between instances)
+	RateLimitPerKey int    // Per API key / gateway user; 0 disables
+	RateLimitPerIP  int    // Per client IP; 0 disables
+	RateLimitBurst  int    // Bucket size; 0 uses the per-minute rate
+
+	// Cost accounting per API key and day (queried at /api/v1/admin/usage)
+	UsageStore   string // "memory" (default), "redis" (uses RedisURL) or "off"
+	ModelPricing string // JSON overrides of the pricing table: {"model": {"input_per_1k": USD, "output_per_1k": USD}}
+
+	// Append-only log of generated actions per request (queried at /api/v1/admin/audit)
+	AuditLog     string // "off" (default), "memory" or "file" (JSON lines at AuditLogPath)
+	AuditLogPath string
+
+	// In-memory capture of LLM provider payloads, redacted (queried at /api/v1/debug/captures)
+	DebugCapture     string // "off" (default) or "on"
+	DebugCaptureSize int    // Most recent provider calls kept
+
 	// Observability
+	LogFormat         string // "json" or "text" (default: json in production, text otherwise)
+	LogLevel          string // "debug", "info" (default), "warn" or "error"
 	SentryDSN         string // Sentry DSN for error tracking
 	LangfusePublicKey string // Langfuse public key
 	LangfuseSecretKey string // Langfuse secret key
@@ -30,17 +110,68 @@ type Config struct {
 }
 
 func Load() *Config {
+	environment := getEnv("ENVIRONMENT", "development")
+	defaultLogFormat := "text"
+	if environment == "production" {
+		defaultLogFormat = "json"
+	}
+
 	return &Config{
-		Environment:       getEnv("ENVIRONMENT", "development"),
-		Port:              getEnv("PORT", "8080"),
-		OpenAIAPIKey:      getEnv("OPENAI_API_KEY", ""),
-		MCPServerURL:      getEnv("MCP_SERVER_URL", ""),
-		SentryDSN:         getEnv("SENTRY_DSN", ""),
-		LangfusePublicKey: getEnv("LANGFUSE_PUBLIC_KEY", ""),
-		LangfuseSecretKey: getEnv("LANGFUSE_SECRET_KEY", ""),
-		LangfuseHost:      getEnv("LANGFUSE_HOST", "https://cloud.langfuse.com"),
-		LangfuseEnabled:   getEnv("LANGFUSE_ENABLED", "false") == "true",
-		AuthMode:          getEnv("AUTH_MODE", "none"), // Default to no auth for self-hosted
+		Environment:          environment,
+		Port:                 getEnv("PORT", "8080"),
+		OpenAIAPIKey:         getEnv("OPENAI_API_KEY", ""),
+		AnthropicAPIKey:      getEnv("ANTHROPIC_API_KEY", ""),
+		AnthropicModel:       getEnv("ANTHROPIC_MODEL", ""),
+		LLMProvider:          getEnv("LLM_PROVIDER", "openai"),
+		LLMFallback:          getEnv("LLM_FALLBACK_PROVIDERS", ""),
+		LLMMockResponses:     getEnv("LLM_MOCK_RESPONSES", ""),
+		MCPServerURL:         getEnv("MCP_SERVER_URL", ""),
+		ModelRouting:         getEnv("MODEL_ROUTING", "off"),
+		ModelRoutingSimple:   getEnv("MODEL_ROUTING_SIMPLE", "gpt-5-nano"),
+		ModelRoutingComplex:  getEnv("MODEL_ROUTING_COMPLEX", "gpt-5.1"),
+		LLMOverrideModels:    getEnv("LLM_OVERRIDE_MODELS", "gpt-5-mini,gpt-5.1,gpt-5.2"),
+		LLMOverrideReasoning: getEnv("LLM_OVERRIDE_REASONING", "none,low,medium,high,xhigh"),
+		LLMMaxOutputTokens:   getIntEnv("LLM_MAX_OUTPUT_TOKENS", 32000),
+		PromptLanguage:       getEnv("PROMPT_LANGUAGE", "auto"),
+		PromptExperiments:    getEnv("PROMPT_EXPERIMENTS", ""),
+		DSLRepair:            getEnv("DSL_REPAIR", "on"),
+		DSLParseRetries:      getIntEnv("DSL_PARSE_RETRIES", 1),
+		ScopeCheck:           getEnv("SCOPE_CHECK", "local"),
+		SessionStore:         getEnv("SESSION_STORE", "memory"),
+		RedisURL:             getEnv("REDIS_URL", ""),
+		SessionTTL:           getDurationEnv("SESSION_TTL", 24*time.Hour),
+		JobStore:             getEnv("JOB_STORE", "memory"),
+		JobTTL:               getDurationEnv("JOB_TTL", time.Hour),
+		JobWorkers:           getIntEnv("JOB_WORKERS", 4),
+		LLMCache:             getEnv("LLM_CACHE", "off"),
+		LLMCacheTTL:          getDurationEnv("LLM_CACHE_TTL", time.Hour),
+		LLMCacheSize:         getIntEnv("LLM_CACHE_SIZE", 256),
+		RateLimitStore:       getEnv("RATE_LIMIT_STORE", "off"),
+		RateLimitPerKey:      getIntEnv("RATE_LIMIT_PER_KEY", 60),
+		RateLimitPerIP:       getIntEnv("RATE_LIMIT_PER_IP", 120),
+		RateLimitBurst:       getIntEnv("RATE_LIMIT_BURST", 0),
+		UsageStore:           getEnv("USAGE_STORE", "memory"),
+		ModelPricing:         getEnv("MODEL_PRICING", ""),
+		AuditLog:             getEnv("AUDIT_LOG", "off"),
+		AuditLogPath:         getEnv("AUDIT_LOG_PATH", "audit.jsonl"),
+		DebugCapture:         getEnv("DEBUG_CAPTURE", "off"),
+		DebugCaptureSize:     getIntEnv("DEBUG_CAPTURE_SIZE", 50),
+		LogFormat:            getEnv("LOG_FORMAT", defaultLogFormat),
+		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		SentryDSN:            getEnv("SENTRY_DSN", ""),
+		LangfusePublicKey:    getEnv("LANGFUSE_PUBLIC_KEY", ""),
+		LangfuseSecretKey:    getEnv("LANGFUSE_SECRET_KEY", ""),
+		LangfuseHost:         getEnv("LANGFUSE_HOST", "https://cloud.langfuse.com"),
+		LangfuseEnabled:      getEnv("LANGFUSE_ENABLED", "false") == "true",
+		AuthMode:             getEnv("AUTH_MODE", "none"), // Default to no auth for self-hosted
+
+		SafetyGuard:            getEnv("SAFETY_GUARD", "confirm"),
+		SafetyMaxDeletedTracks: getIntEnv("SAFETY_MAX_DELETED_TRACKS", 4),
+		SafetyMaxDeletedClips:  getIntEnv("SAFETY_MAX_DELETED_CLIPS", 16),
+
+		ChatTimeout:     getDurationEnv("MAGDA_CHAT_TIMEOUT", 90*time.Second),
+		StreamTimeout:   getDurationEnv("MAGDA_STREAM_TIMEOUT", 180*time.Second),
+		GenerateTimeout: getDurationEnv("GENERATE_TIMEOUT", 120*time.Second),
 	}
 }
 
@@ -52,6 +183,32 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid %s %q, using %v: %v", key, value, defaultValue, err)
+		return defaultValue
+	}
+	return duration
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠️  Invalid %s %q, using %d: %v", key, value, defaultValue, err)
+		return defaultValue
+	}
+	return n
+}
+
 // IsGatewayMode returns true if running behind the Express gateway
 func (c *Config) IsGatewayMode() bool {
 	return c.AuthMode == "gateway"
@@ -0,0 +1,205 @@
+// Package debugcapture keeps the most recent LLM provider request and response payloads in
+// memory, with credentials and email addresses redacted, for debugging generation
+// (DEBUG_CAPTURE, listed at /api/v1/debug/captures). It is off unless enabled.
+package debugcapture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+)
+
+const (
+	// DefaultSize is how many captures the buffer keeps when no size is given
+	DefaultSize = 50
+	// MaxPayloadBytes is the most of a payload a capture keeps; longer payloads are kept as a
+	// truncated string
+	MaxPayloadBytes = 512 << 10
+
+	redacted      = "[REDACTED]"
+	redactedEmail = "[REDACTED_EMAIL]"
+)
+
+// Capture is one provider call
+type Capture struct {
+	ID         int64           `json:"id"`
+	Time       time.Time       `json:"time"`
+	RequestID  string          `json:"request_id,omitempty"`
+	Provider   string          `json:"provider"`
+	URL        string          `json:"url"`
+	StatusCode int             `json:"status_code,omitempty"` // 0 when no response arrived
+	DurationMS int64           `json:"duration_ms"`
+	Request    json.RawMessage `json:"request"`
+	Response   json.RawMessage `json:"response,omitempty"`
+	Error      string          `json:"error,omitempty"`
+}
+
+// Buffer is a ring buffer of the most recent captures
+type Buffer struct {
+	mu       sync.Mutex
+	captures []Capture // Ring of cap(captures) entries; next is the oldest once it is full
+	next     int
+	lastID   int64
+}
+
+// NewBuffer creates a buffer keeping the last size captures (DefaultSize if size < 1)
+func NewBuffer(size int) *Buffer {
+	if size < 1 {
+		size = DefaultSize
+	}
+	return &Buffer{captures: make([]Capture, 0, size)}
+}
+
+// Add redacts capture's payloads and keeps it, dropping the oldest capture when full
+func (b *Buffer) Add(capture Capture) {
+	capture.Request = Redact(capture.Request)
+	if capture.Response != nil {
+		capture.Response = Redact(capture.Response)
+	}
+	capture.Error = redactString(capture.Error)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastID++
+	capture.ID = b.lastID
+	if len(b.captures) < cap(b.captures) {
+		b.captures = append(b.captures, capture)
+		return
+	}
+	b.captures[b.next] = capture
+	b.next = (b.next + 1) % len(b.captures)
+}
+
+// List returns up to limit captures, newest first (all of them if limit < 1)
+func (b *Buffer) List(limit int) []Capture {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if limit < 1 || limit > len(b.captures) {
+		limit = len(b.captures)
+	}
+	result := make([]Capture, 0, limit)
+	for i := range limit {
+		// Newest is just before next in the ring
+		result = append(result, b.captures[(b.next-1-i+2*len(b.captures))%len(b.captures)])
+	}
+	return result
+}
+
+// Clear drops every capture
+func (b *Buffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.captures = b.captures[:0]
+	b.next = 0
+}
+
+var active atomic.Pointer[Buffer]
+
+// Enable starts keeping the last size captures and returns the buffer; Record is a no-op
+// until then
+func Enable(size int) *Buffer {
+	buffer := NewBuffer(size)
+	active.Store(buffer)
+	return buffer
+}
+
+// Disable stops keeping captures and drops the ones kept
+func Disable() {
+	active.Store(nil)
+}
+
+// Active returns the buffer captures are kept in, or nil when capture is off
+func Active() *Buffer {
+	return active.Load()
+}
+
+// Enabled reports whether Record keeps captures, so callers can skip preparing payloads
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+// Record keeps capture when capture is on, stamped with the time and ctx's request ID
+func Record(ctx context.Context, capture Capture) {
+	buffer := active.Load()
+	if buffer == nil {
+		return
+	}
+	if capture.Time.IsZero() {
+		capture.Time = time.Now().UTC()
+	}
+	if capture.RequestID == "" {
+		capture.RequestID = logger.RequestIDFromContext(ctx)
+	}
+	buffer.Add(capture)
+}
+
+var (
+	// secretPattern matches API keys and bearer tokens in string values
+	secretPattern = regexp.MustCompile(`\bsk-[A-Za-z0-9_\-]{16,}|\bBearer\s+[A-Za-z0-9._~+/\-]+=*`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+	// sensitiveKeys are object keys whose values are always redacted (compared lowercased)
+	sensitiveKeys = map[string]bool{
+		"authorization": true, "x-api-key": true, "api_key": true, "apikey": true,
+		"access_token": true, "refresh_token": true, "token": true,
+		"secret": true, "client_secret": true, "password": true,
+	}
+)
+
+// Redact returns payload with secrets and email addresses replaced: the values of sensitive
+// keys, API keys and bearer tokens anywhere in strings, and email addresses. A payload that
+// isn't JSON, or is longer than MaxPayloadBytes, is kept as a (redacted, truncated) string.
+func Redact(payload []byte) json.RawMessage {
+	if len(payload) <= MaxPayloadBytes {
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err == nil && !decoder.More() {
+			if redactedJSON, err := json.Marshal(redactValue(value)); err == nil {
+				return redactedJSON
+			}
+		}
+	}
+
+	text := string(payload)
+	if len(text) > MaxPayloadBytes {
+		text = strings.ToValidUTF8(text[:MaxPayloadBytes], "") + "...(truncated)"
+	}
+	quoted, _ := json.Marshal(redactString(text))
+	return quoted
+}
+
+func redactValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, item := range v {
+			if sensitiveKeys[strings.ToLower(key)] {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+		return v
+	case string:
+		return redactString(v)
+	default:
+		return v
+	}
+}
+
+func redactString(s string) string {
+	s = secretPattern.ReplaceAllString(s, redacted)
+	return emailPattern.ReplaceAllString(s, redactedEmail)
+}
@@ -0,0 +1,91 @@
+package debugcapture
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuffer_KeepsMostRecent(t *testing.T) {
+	buffer := NewBuffer(3)
+	for _, provider := range []string{"a", "b", "c", "d", "e"} {
+		buffer.Add(Capture{Provider: provider, Request: json.RawMessage(`{}`)})
+	}
+
+	captures := buffer.List(0)
+	require.Len(t, captures, 3)
+	assert.Equal(t, "e", captures[0].Provider)
+	assert.Equal(t, "d", captures[1].Provider)
+	assert.Equal(t, "c", captures[2].Provider)
+	assert.Equal(t, int64(5), captures[0].ID)
+
+	assert.Len(t, buffer.List(2), 2)
+
+	buffer.Clear()
+	assert.Empty(t, buffer.List(0))
+	buffer.Add(Capture{Provider: "f", Request: json.RawMessage(`{}`)})
+	assert.Equal(t, "f", buffer.List(0)[0].Provider)
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{
+			name:    "sensitive keys",
+			payload: `{"model":"gpt-5.1","api_key":"abc","headers":{"Authorization":"xyz"},"max_output_tokens":1000}`,
+			want:    `{"api_key":"[REDACTED]","headers":{"Authorization":"[REDACTED]"},"max_output_tokens":1000,"model":"gpt-5.1"}`,
+		},
+		{
+			name:    "keys and emails in strings",
+			payload: `{"input":[{"role":"user","content":"key sk-proj-abcdefghijklmnop1234 from ana@example.com"}]}`,
+			want:    `{"input":[{"content":"key [REDACTED] from [REDACTED_EMAIL]","role":"user"}]}`,
+		},
+		{
+			name:    "not JSON",
+			payload: `upstream error: Bearer abc.def-123 rejected`,
+			want:    `"upstream error: [REDACTED] rejected"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.JSONEq(t, tt.want, string(Redact([]byte(tt.payload))))
+		})
+	}
+}
+
+func TestRedact_TruncatesLargePayloads(t *testing.T) {
+	payload := `{"input":"` + strings.Repeat("x", 2*MaxPayloadBytes) + `"}`
+
+	var text string
+	require.NoError(t, json.Unmarshal(Redact([]byte(payload)), &text))
+	assert.True(t, strings.HasSuffix(text, "...(truncated)"))
+	assert.Less(t, len(text), len(payload))
+}
+
+func TestRecord(t *testing.T) {
+	t.Cleanup(Disable)
+	ctx := logger.WithRequestID(context.Background(), "req-1")
+
+	Disable()
+	Record(ctx, Capture{Provider: "openai", Request: json.RawMessage(`{}`)})
+	assert.Nil(t, Active())
+
+	buffer := Enable(10)
+	Record(ctx, Capture{Provider: "openai", Request: json.RawMessage(`{"api_key":"abc"}`), Error: "to bob@example.com"})
+
+	captures := buffer.List(0)
+	require.Len(t, captures, 1)
+	assert.Equal(t, "req-1", captures[0].RequestID)
+	assert.False(t, captures[0].Time.IsZero())
+	assert.JSONEq(t, `{"api_key":"[REDACTED]"}`, string(captures[0].Request))
+	assert.Equal(t, "to [REDACTED_EMAIL]", captures[0].Error)
+}
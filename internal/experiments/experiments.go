@@ -0,0 +1,190 @@
+// Package experiments runs A/B tests of DAW system prompt variants: each LLM request is
+// assigned a variant by traffic weight, and responses and traces record which one it got.
+package experiments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ControlID is the variant that keeps the default system prompt
+const ControlID = "control"
+
+// Variant is an alternate system prompt. Prompt replaces the default prompt, Append is added to
+// its end; with neither the variant uses the default prompt, as the control does.
+type Variant struct {
+	ID         string `json:"id"`
+	Weight     int    `json:"weight"`                // Relative share of traffic
+	Prompt     string `json:"prompt,omitempty"`      // Replaces the system prompt
+	PromptFile string `json:"prompt_file,omitempty"` // Prompt read from a file, relative to the experiments file
+	Append     string `json:"append,omitempty"`      // Added to the end of the system prompt
+}
+
+// SystemPrompt returns the variant's system prompt given the default one
+func (v *Variant) SystemPrompt(base string) string {
+	prompt := base
+	if v.Prompt != "" {
+		prompt = v.Prompt
+	}
+	if v.Append != "" {
+		prompt += "\n\n" + v.Append
+	}
+	return prompt
+}
+
+// Registry holds the registered variants and their weights, which can change at runtime. A nil
+// Registry runs no experiment.
+type Registry struct {
+	mu       sync.RWMutex
+	variants []Variant
+	intN     func(n int) int
+}
+
+// NewRegistry registers variants. A control variant with weight 0 is added unless listed.
+func NewRegistry(variants ...Variant) (*Registry, error) {
+	r := &Registry{intN: rand.IntN}
+	seen := map[string]bool{}
+	for _, variant := range variants {
+		switch {
+		case variant.ID == "":
+			return nil, fmt.Errorf("prompt variant without an id")
+		case seen[variant.ID]:
+			return nil, fmt.Errorf("prompt variant %q is registered twice", variant.ID)
+		case variant.Weight < 0:
+			return nil, fmt.Errorf("prompt variant %q: weight %d is negative", variant.ID, variant.Weight)
+		}
+		seen[variant.ID] = true
+		r.variants = append(r.variants, variant)
+	}
+	if !seen[ControlID] {
+		r.variants = append([]Variant{{ID: ControlID}}, r.variants...)
+	}
+	return r, nil
+}
+
+// Load reads variants from a JSON array file; prompt_file paths are relative to it. An empty
+// path registers nothing.
+func Load(path string) (*Registry, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt experiments: %w", err)
+	}
+	var variants []Variant
+	if err := json.Unmarshal(data, &variants); err != nil {
+		return nil, fmt.Errorf("failed to parse prompt experiments %s: %w", path, err)
+	}
+	for i, variant := range variants {
+		if variant.PromptFile == "" {
+			continue
+		}
+		promptPath := variant.PromptFile
+		if !filepath.IsAbs(promptPath) {
+			promptPath = filepath.Join(filepath.Dir(path), promptPath)
+		}
+		prompt, err := os.ReadFile(promptPath)
+		if err != nil {
+			return nil, fmt.Errorf("prompt variant %q: %w", variant.ID, err)
+		}
+		variants[i].Prompt = string(prompt)
+	}
+	return NewRegistry(variants...)
+}
+
+// Pick assigns a variant by weight, or returns nil when no experiment is running: no variant
+// besides the control has traffic.
+func (r *Registry) Pick() *Variant {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total, running := 0, false
+	for _, variant := range r.variants {
+		total += variant.Weight
+		running = running || variant.ID != ControlID && variant.Weight > 0
+	}
+	if !running {
+		return nil
+	}
+	n := r.intN(total)
+	for _, variant := range r.variants {
+		if n < variant.Weight {
+			return &variant
+		}
+		n -= variant.Weight
+	}
+	return nil
+}
+
+// Variants returns the registered variants, control first
+func (r *Registry) Variants() []Variant {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Variant(nil), r.variants...)
+}
+
+// SetWeights changes the weights of the named variants. Nothing changes if any is unknown or
+// negative.
+func (r *Registry) SetWeights(weights map[string]int) error {
+	if r == nil {
+		return fmt.Errorf("no prompt experiments are registered")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := make(map[string]int, len(r.variants))
+	for i, variant := range r.variants {
+		index[variant.ID] = i
+	}
+	ids := make([]string, 0, len(weights))
+	for id := range weights {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids) // Report the same error for the same request
+	for _, id := range ids {
+		if _, ok := index[id]; !ok {
+			return fmt.Errorf("unknown prompt variant %q", id)
+		}
+		if weights[id] < 0 {
+			return fmt.Errorf("prompt variant %q: weight %d is negative", id, weights[id])
+		}
+	}
+	for id, weight := range weights {
+		r.variants[index[id]].Weight = weight
+	}
+	return nil
+}
+
+type variantKey struct{}
+
+// WithVariant returns a context carrying the request's prompt variant.
+func WithVariant(ctx context.Context, variant *Variant) context.Context {
+	return context.WithValue(ctx, variantKey{}, variant)
+}
+
+// FromContext returns the request's prompt variant, or nil outside an experiment.
+func FromContext(ctx context.Context) *Variant {
+	variant, _ := ctx.Value(variantKey{}).(*Variant)
+	return variant
+}
+
+// VariantID returns the ID of the request's prompt variant, or "" outside an experiment.
+func VariantID(ctx context.Context) string {
+	if variant := FromContext(ctx); variant != nil {
+		return variant.ID
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package experiments
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Pick(t *testing.T) {
+	registry, err := NewRegistry(
+		Variant{ID: ControlID, Weight: 3},
+		Variant{ID: "terse", Weight: 1, Append: "Be terse."},
+	)
+	require.NoError(t, err)
+
+	counts := map[string]int{}
+	for n := 0; n < 4; n++ {
+		registry.intN = func(int) int { return n }
+		counts[registry.Pick().ID]++
+	}
+	assert.Equal(t, map[string]int{ControlID: 3, "terse": 1}, counts)
+
+	// Without traffic on a variant there is no experiment
+	require.NoError(t, registry.SetWeights(map[string]int{"terse": 0}))
+	assert.Nil(t, registry.Pick())
+
+	var none *Registry
+	assert.Nil(t, none.Pick())
+}
+
+func TestRegistry_SetWeights(t *testing.T) {
+	registry, err := NewRegistry(Variant{ID: "terse", Weight: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []Variant{{ID: ControlID}, {ID: "terse", Weight: 1}}, registry.Variants())
+
+	require.NoError(t, registry.SetWeights(map[string]int{ControlID: 9, "terse": 1}))
+	assert.Equal(t, []Variant{{ID: ControlID, Weight: 9}, {ID: "terse", Weight: 1}}, registry.Variants())
+
+	err = registry.SetWeights(map[string]int{"terse": 5, "verbose": 1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown prompt variant "verbose"`)
+	err = registry.SetWeights(map[string]int{"terse": -1})
+	require.Error(t, err)
+	assert.Equal(t, 1, registry.Variants()[1].Weight, "a rejected update changes nothing")
+}
+
+func TestNewRegistry_Invalid(t *testing.T) {
+	_, err := NewRegistry(Variant{ID: "a"}, Variant{ID: "a"})
+	assert.ErrorContains(t, err, "registered twice")
+	_, err = NewRegistry(Variant{Weight: 1})
+	assert.ErrorContains(t, err, "without an id")
+	_, err = NewRegistry(Variant{ID: "a", Weight: -1})
+	assert.ErrorContains(t, err, "negative")
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "v2.txt"), []byte("You are MAGDA v2."), 0o600))
+	path := filepath.Join(dir, "experiments.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[
+		{"id": "control", "weight": 90},
+		{"id": "v2", "weight": 10, "prompt_file": "v2.txt"}
+	]`), 0o600))
+
+	registry, err := Load(path)
+	require.NoError(t, err)
+	variants := registry.Variants()
+	require.Len(t, variants, 2)
+	assert.Equal(t, "You are MAGDA v2.", variants[1].SystemPrompt("You are MAGDA."))
+	assert.Equal(t, "You are MAGDA.", variants[0].SystemPrompt("You are MAGDA."))
+
+	registry, err = Load("")
+	require.NoError(t, err)
+	assert.Nil(t, registry)
+}
+
+func TestVariantContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Nil(t, FromContext(ctx))
+	assert.Empty(t, VariantID(ctx))
+
+	variant := &Variant{ID: "terse", Append: "Be terse."}
+	ctx = WithVariant(ctx, variant)
+	assert.Equal(t, "terse", VariantID(ctx))
+	assert.Equal(t, "base\n\nBe terse.", FromContext(ctx).SystemPrompt("base"))
+}
@@ -0,0 +1,274 @@
+// Package golden runs DSL snippets through a parser and compares the resulting actions with
+// expected JSON kept in testdata, so parser changes show up as reviewable diffs.
+//
+// A golden file holds sections introduced by "-- name --" lines. Text before the first
+// section is a comment.
+//
+//	Creates a track with an instrument
+//	-- dsl --
+//	track(instrument="Serum")
+//	-- state --
+//	{"tracks": []}
+//	-- actions --
+//	[{"action": "create_track", "index": 0, "instrument": "Serum"}]
+//
+// dsl is required; state (a REAPER state snapshot) is optional. actions holds the expected
+// actions, or error holds text the parse error must contain. Run the tests with -update to
+// rewrite each file's actions or error section from the parser's current output.
+package golden
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+const (
+	// Extension is the file extension of golden files
+	Extension = ".golden"
+
+	sectionDSL     = "dsl"
+	sectionState   = "state"
+	sectionActions = "actions"
+	sectionError   = "error"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the parser's output")
+
+// ParseFunc parses dsl against an optional state snapshot
+type ParseFunc func(dsl string, state map[string]any) ([]map[string]any, error)
+
+// Case is one golden file
+type Case struct {
+	Name    string
+	Path    string
+	Comment string
+	DSL     string
+	State   map[string]any
+	// Actions is the expected actions JSON; empty when Error is set
+	Actions string
+	// Error is text the parse error must contain
+	Error string
+}
+
+// Load reads every golden file in dir, ordered by name
+func Load(dir string) ([]Case, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+Extension))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	cases := make([]Case, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read golden file: %w", err)
+		}
+		c, err := Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		c.Name = strings.TrimSuffix(filepath.Base(path), Extension)
+		c.Path = path
+		cases = append(cases, c)
+	}
+	return cases, nil
+}
+
+// Parse reads a golden file's sections
+func Parse(text string) (Case, error) {
+	var c Case
+	sections := map[string]*strings.Builder{}
+	current := &strings.Builder{}
+	comment := current
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if name, ok := sectionName(line); ok {
+			if _, seen := sections[name]; seen {
+				return c, fmt.Errorf("duplicate section %q", name)
+			}
+			switch name {
+			case sectionDSL, sectionState, sectionActions, sectionError:
+			default:
+				return c, fmt.Errorf("unknown section %q", name)
+			}
+			current = &strings.Builder{}
+			sections[name] = current
+			continue
+		}
+		current.WriteString(line)
+	}
+
+	c.Comment = strings.TrimSpace(comment.String())
+	if dsl, ok := sections[sectionDSL]; ok {
+		c.DSL = strings.TrimSpace(dsl.String())
+	}
+	if c.DSL == "" {
+		return c, fmt.Errorf("missing dsl section")
+	}
+	if state, ok := sections[sectionState]; ok && strings.TrimSpace(state.String()) != "" {
+		if err := json.Unmarshal([]byte(state.String()), &c.State); err != nil {
+			return c, fmt.Errorf("invalid state: %w", err)
+		}
+	}
+	if actions, ok := sections[sectionActions]; ok {
+		c.Actions = strings.TrimSpace(actions.String())
+	}
+	if errText, ok := sections[sectionError]; ok {
+		c.Error = strings.TrimSpace(errText.String())
+	}
+	if c.Actions != "" && c.Error != "" {
+		return c, fmt.Errorf("actions and error sections are exclusive")
+	}
+	return c, nil
+}
+
+// sectionName reports whether line is a "-- name --" section header
+func sectionName(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "-- ") || !strings.HasSuffix(line, " --") || len(line) < 7 {
+		return "", false
+	}
+	return strings.TrimSpace(line[3 : len(line)-3]), true
+}
+
+// Format writes c back as a golden file
+func Format(c Case) string {
+	var b strings.Builder
+	if c.Comment != "" {
+		b.WriteString(c.Comment + "\n")
+	}
+	b.WriteString("-- dsl --\n" + c.DSL + "\n")
+	if c.State != nil {
+		state, _ := json.MarshalIndent(c.State, "", "  ")
+		b.WriteString("-- state --\n" + string(state) + "\n")
+	}
+	if c.Error != "" {
+		b.WriteString("-- error --\n" + c.Error + "\n")
+	} else {
+		b.WriteString("-- actions --\n" + c.Actions + "\n")
+	}
+	return b.String()
+}
+
+// Canonical formats actions as indented JSON with sorted keys, so numbers and key order
+// compare equal however the expectation was written
+func Canonical(actions any) (string, error) {
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return "", err
+	}
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	formatted, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(formatted), nil
+}
+
+// Run checks every golden file in dir against parse, one subtest per file
+func Run(t *testing.T, dir string, parse ParseFunc) {
+	t.Helper()
+	cases, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatalf("no golden files in %s", dir)
+	}
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			Check(t, c, parse)
+		})
+	}
+}
+
+// Check parses c's DSL and compares the result with the expected actions or error.
+// With -update the file is rewritten instead.
+func Check(t *testing.T, c Case, parse ParseFunc) {
+	t.Helper()
+	actions, parseErr := parse(c.DSL, c.State)
+
+	if *update {
+		c.Actions, c.Error = "", ""
+		if parseErr != nil {
+			c.Error = parseErr.Error()
+		} else {
+			formatted, err := Canonical(actions)
+			if err != nil {
+				t.Fatalf("failed to format actions: %v", err)
+			}
+			c.Actions = formatted
+		}
+		if err := os.WriteFile(c.Path, []byte(Format(c)), 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	if c.Error != "" {
+		if parseErr == nil {
+			t.Fatalf("expected error containing %q, got actions", c.Error)
+		}
+		if !strings.Contains(parseErr.Error(), c.Error) {
+			t.Fatalf("expected error containing %q, got %q", c.Error, parseErr.Error())
+		}
+		return
+	}
+	if parseErr != nil {
+		t.Fatalf("parse failed: %v", parseErr)
+	}
+
+	var expected any
+	if err := json.Unmarshal([]byte(c.Actions), &expected); err != nil {
+		t.Fatalf("invalid expected actions in %s: %v", c.Path, err)
+	}
+	want, err := Canonical(expected)
+	if err != nil {
+		t.Fatalf("failed to format expected actions: %v", err)
+	}
+	got, err := Canonical(actions)
+	if err != nil {
+		t.Fatalf("failed to format actions: %v", err)
+	}
+	if got != want {
+		t.Errorf("actions differ from %s (run with -update to accept)\n%s", c.Path, Diff(want, got))
+	}
+}
+
+// Diff lists the lines of want and got that differ, prefixed "-" and "+"
+func Diff(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w == g {
+			continue
+		}
+		fmt.Fprintf(&b, "line %d:\n", i+1)
+		if i < len(wantLines) {
+			fmt.Fprintf(&b, "  - %s\n", w)
+		}
+		if i < len(gotLines) {
+			fmt.Fprintf(&b, "  + %s\n", g)
+		}
+	}
+	return b.String()
+}
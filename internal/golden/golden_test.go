@@ -0,0 +1,120 @@
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    Case
+		wantErr string
+	}{
+		{
+			name: "all sections",
+			text: "Creates a track\n-- dsl --\ntrack()\n-- state --\n{\"tracks\": []}\n-- actions --\n[{\"action\": \"create_track\"}]\n",
+			want: Case{
+				Comment: "Creates a track",
+				DSL:     "track()",
+				State:   map[string]any{"tracks": []any{}},
+				Actions: `[{"action": "create_track"}]`,
+			},
+		},
+		{
+			name: "expected error",
+			text: "-- dsl --\ntrack(\n-- error --\nparse error\n",
+			want: Case{DSL: "track(", Error: "parse error"},
+		},
+		{
+			name: "multi-line dsl",
+			text: "-- dsl --\ntrack(name=\"A\");\ntrack(name=\"B\")\n-- actions --\n[]\n",
+			want: Case{DSL: "track(name=\"A\");\ntrack(name=\"B\")", Actions: "[]"},
+		},
+		{name: "missing dsl", text: "-- actions --\n[]\n", wantErr: "missing dsl"},
+		{name: "unknown section", text: "-- dsl --\ntrack()\n-- expected --\n[]\n", wantErr: "unknown section"},
+		{name: "duplicate section", text: "-- dsl --\ntrack()\n-- dsl --\ntrack()\n", wantErr: "duplicate section"},
+		{name: "invalid state", text: "-- dsl --\ntrack()\n-- state --\n{tracks}\n", wantErr: "invalid state"},
+		{
+			name:    "actions and error",
+			text:    "-- dsl --\ntrack()\n-- actions --\n[]\n-- error --\nboom\n",
+			wantErr: "exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Comment != tt.want.Comment || got.DSL != tt.want.DSL || got.Actions != tt.want.Actions || got.Error != tt.want.Error {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+			if (got.State == nil) != (tt.want.State == nil) {
+				t.Errorf("Parse() state = %v, want %v", got.State, tt.want.State)
+			}
+		})
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	c := Case{
+		Comment: "Mutes the bass",
+		DSL:     `filter(tracks, track.name == "Bass").set_track(mute=true)`,
+		State:   map[string]any{"tracks": []any{}},
+		Actions: "[]",
+	}
+	got, err := Parse(Format(c))
+	if err != nil {
+		t.Fatalf("Parse(Format()) error = %v", err)
+	}
+	if got.Comment != c.Comment || got.DSL != c.DSL || got.Actions != c.Actions || got.State == nil {
+		t.Errorf("Parse(Format()) = %+v, want %+v", got, c)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	written, err := Canonical([]any{map[string]any{"track": 1.0, "action": "set_track", "volume_db": -6.5}})
+	if err != nil {
+		t.Fatalf("Canonical() error = %v", err)
+	}
+	parsed, err := Canonical([]map[string]any{{"action": "set_track", "track": 1, "volume_db": -6.5}})
+	if err != nil {
+		t.Fatalf("Canonical() error = %v", err)
+	}
+	if written != parsed {
+		t.Errorf("Canonical() differs:\n%s", Diff(written, parsed))
+	}
+}
+
+func TestDiff(t *testing.T) {
+	got := Diff("[\n  1\n]", "[\n  2\n]")
+	if !strings.Contains(got, "line 2:") || !strings.Contains(got, "- "+"  1") || !strings.Contains(got, "+ "+"  2") {
+		t.Errorf("Diff() = %q", got)
+	}
+	if Diff("same", "same") != "" {
+		t.Error("Diff() of equal text should be empty")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	text := "-- dsl --\nmute(1)\n-- actions --\n[{\"action\": \"set_track\", \"mute\": true, \"track\": 1}]\n"
+	if err := os.WriteFile(filepath.Join(dir, "mute"+Extension), []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	Run(t, dir, func(dsl string, _ map[string]any) ([]map[string]any, error) {
+		return []map[string]any{{"track": 1, "mute": true, "action": "set_track"}}, nil
+	})
+}
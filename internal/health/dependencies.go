@@ -0,0 +1,136 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+)
+
+const anthropicAPIVersion = "2023-06-01"
+
+// Models list endpoints: cheap, authenticated calls that verify an API key without generating tokens
+var (
+	openAIModelsURL    = "https://api.openai.com/v1/models"
+	anthropicModelsURL = "https://api.anthropic.com/v1/models"
+)
+
+// ChecksFromConfig builds the readiness checks for cfg: configuration sanity and the
+// primary provider's API key (required), fallback providers' keys and Langfuse (optional)
+func ChecksFromConfig(cfg *config.Config, client *http.Client) []Check {
+	checks := []Check{
+		{Name: "config", Required: true, Run: StaticCheck(ValidateConfig(cfg))},
+	}
+
+	primary := providerName(cfg.LLMProvider)
+	seen := map[string]bool{}
+	for i, name := range append([]string{primary}, fallbackProviderNames(cfg.LLMFallback)...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if run := providerKeyCheck(cfg, client, name); run != nil {
+			checks = append(checks, Check{Name: "llm_provider:" + name, Required: i == 0, Run: run})
+		}
+	}
+
+	if cfg.LangfuseEnabled {
+		host := strings.TrimSuffix(cfg.LangfuseHost, "/")
+		checks = append(checks, Check{
+			Name: "langfuse",
+			Run:  HTTPCheck(client, host+"/api/public/health", nil),
+		})
+	}
+	return checks
+}
+
+// providerKeyCheck lists the provider's models with its API key; nil for unknown providers
+// (ValidateConfig reports those)
+func providerKeyCheck(cfg *config.Config, client *http.Client, name string) func(ctx context.Context) error {
+	switch name {
+	case "openai":
+		if cfg.OpenAIAPIKey == "" {
+			return StaticCheck([]string{"OPENAI_API_KEY is not set"})
+		}
+		return HTTPCheck(client, openAIModelsURL, map[string]string{
+			"Authorization": "Bearer " + cfg.OpenAIAPIKey,
+		})
+	case "anthropic":
+		if cfg.AnthropicAPIKey == "" {
+			return StaticCheck([]string{"ANTHROPIC_API_KEY is not set"})
+		}
+		return HTTPCheck(client, anthropicModelsURL, map[string]string{
+			"x-api-key":         cfg.AnthropicAPIKey,
+			"anthropic-version": anthropicAPIVersion,
+		})
+	default:
+		return nil
+	}
+}
+
+// ValidateConfig returns configuration problems that would break requests at runtime
+func ValidateConfig(cfg *config.Config) []string {
+	var problems []string
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port <= 0 || port > 65535 {
+		problems = append(problems, fmt.Sprintf("PORT %q is not a valid port", cfg.Port))
+	}
+
+	switch providerName(cfg.LLMProvider) {
+	case "openai", "anthropic", "mock":
+	default:
+		problems = append(problems, fmt.Sprintf("LLM_PROVIDER %q is unknown (supported: openai, anthropic, mock)", cfg.LLMProvider))
+	}
+	for _, name := range fallbackProviderNames(cfg.LLMFallback) {
+		if name != "openai" && name != "anthropic" {
+			problems = append(problems, fmt.Sprintf("LLM_FALLBACK_PROVIDERS entry %q is unknown", name))
+		}
+	}
+
+	switch cfg.AuthMode {
+	case "none", "gateway":
+	default:
+		problems = append(problems, fmt.Sprintf("AUTH_MODE %q is unknown (supported: none, gateway)", cfg.AuthMode))
+	}
+
+	stores := []struct{ env, value string }{
+		{"SESSION_STORE", cfg.SessionStore},
+		{"LLM_CACHE", cfg.LLMCache},
+		{"RATE_LIMIT_STORE", cfg.RateLimitStore},
+	}
+	for _, store := range stores {
+		if strings.EqualFold(strings.TrimSpace(store.value), "redis") && cfg.RedisURL == "" {
+			problems = append(problems, fmt.Sprintf("%s=redis requires REDIS_URL", store.env))
+		}
+	}
+
+	if cfg.LangfuseEnabled && (cfg.LangfusePublicKey == "" || cfg.LangfuseSecretKey == "") {
+		problems = append(problems, "LANGFUSE_ENABLED requires LANGFUSE_PUBLIC_KEY and LANGFUSE_SECRET_KEY")
+	}
+	return problems
+}
+
+// providerName normalizes a provider name; empty selects OpenAI, like the provider factory
+func providerName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "openai"
+	}
+	return name
+}
+
+// fallbackProviderNames returns the provider names of "name" / "name:model" fallback specs
+func fallbackProviderNames(specs string) []string {
+	var names []string
+	for _, spec := range strings.Split(specs, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(spec, ":")
+		names = append(names, providerName(name))
+	}
+	return names
+}
@@ -0,0 +1,189 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusOK means the dependency answered as expected
+	StatusOK = "ok"
+	// StatusFailed means the dependency is unreachable or rejected us
+	StatusFailed = "failed"
+
+	defaultCheckTimeout = 5 * time.Second
+	defaultSuccessTTL   = 5 * time.Minute
+	defaultFailureTTL   = 30 * time.Second
+)
+
+// Check is one readiness dependency. Required checks make the service unready when they
+// fail; optional ones only show up in the report.
+type Check struct {
+	Name     string
+	Required bool
+	Run      func(ctx context.Context) error
+}
+
+// DependencyStatus is the latest result of one check
+type DependencyStatus struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Required  bool      `json:"required"`
+	Message   string    `json:"message,omitempty"`
+	LatencyMs int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	Cached    bool      `json:"cached"`
+}
+
+// Report is the readiness verdict with every dependency's status
+type Report struct {
+	Ready        bool               `json:"ready"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// Checker runs readiness checks concurrently and caches their results, so frequent
+// probes don't turn into a stream of provider API calls. Successes are cached longer
+// than failures, so a recovered dependency is noticed quickly.
+type Checker struct {
+	checks     []Check
+	timeout    time.Duration
+	successTTL time.Duration
+	failureTTL time.Duration
+
+	mu      sync.Mutex
+	results map[string]DependencyStatus
+	now     func() time.Time
+}
+
+// NewChecker creates a checker with the default timeout and cache durations
+func NewChecker(checks ...Check) *Checker {
+	return &Checker{
+		checks:     checks,
+		timeout:    defaultCheckTimeout,
+		successTTL: defaultSuccessTTL,
+		failureTTL: defaultFailureTTL,
+		results:    make(map[string]DependencyStatus),
+		now:        time.Now,
+	}
+}
+
+// Ready runs (or reuses cached results of) every check.
+// The service is ready when every required check passed.
+func (c *Checker) Ready(ctx context.Context) Report {
+	statuses := make([]DependencyStatus, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		if cached, ok := c.cached(check.Name); ok {
+			statuses[i] = cached
+			continue
+		}
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			statuses[i] = c.run(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	report := Report{Ready: true, Dependencies: statuses}
+	for _, status := range statuses {
+		if status.Required && status.Status != StatusOK {
+			report.Ready = false
+		}
+	}
+	sort.SliceStable(report.Dependencies, func(i, j int) bool {
+		return report.Dependencies[i].Required && !report.Dependencies[j].Required
+	})
+	return report
+}
+
+// cached returns the check's last result while it is still fresh
+func (c *Checker) cached(name string) (DependencyStatus, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status, ok := c.results[name]
+	if !ok {
+		return DependencyStatus{}, false
+	}
+	ttl := c.successTTL
+	if status.Status != StatusOK {
+		ttl = c.failureTTL
+	}
+	if c.now().Sub(status.CheckedAt) > ttl {
+		return DependencyStatus{}, false
+	}
+	status.Cached = true
+	return status, true
+}
+
+// run executes one check with the checker's timeout and stores the result
+func (c *Checker) run(ctx context.Context, check Check) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	started := c.now()
+	err := check.Run(ctx)
+	status := DependencyStatus{
+		Name:      check.Name,
+		Status:    StatusOK,
+		Required:  check.Required,
+		LatencyMs: c.now().Sub(started).Milliseconds(),
+		CheckedAt: started,
+	}
+	if err != nil {
+		status.Status = StatusFailed
+		status.Message = err.Error()
+	}
+
+	c.mu.Lock()
+	c.results[check.Name] = status
+	c.mu.Unlock()
+	return status
+}
+
+// HTTPCheck returns a check that GETs url with headers and expects a 2xx response.
+// 401/403 are reported as rejected credentials.
+func HTTPCheck(client *http.Client, url string, headers map[string]string) func(ctx context.Context) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return fmt.Errorf("credentials rejected (HTTP %d)", resp.StatusCode)
+		case resp.StatusCode < 200 || resp.StatusCode >= 300:
+			return fmt.Errorf("unexpected HTTP %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// StaticCheck returns a check that fails with the given problems, if any
+func StaticCheck(problems []string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if len(problems) == 0 {
+			return nil
+		}
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+}
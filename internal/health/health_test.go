@@ -0,0 +1,190 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_ReadyAndCaching(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	var mu sync.Mutex
+	calls := map[string]int{}
+	count := func(name string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		calls[name]++
+		return calls[name]
+	}
+	failing := true
+
+	checker := NewChecker(
+		Check{Name: "optional", Run: func(ctx context.Context) error {
+			count("optional")
+			return errors.New("langfuse down")
+		}},
+		Check{Name: "provider", Required: true, Run: func(ctx context.Context) error {
+			count("provider")
+			if failing {
+				return errors.New("credentials rejected (HTTP 401)")
+			}
+			return nil
+		}},
+	)
+	checker.now = func() time.Time { return now }
+
+	report := checker.Ready(context.Background())
+	assert.False(t, report.Ready)
+	require.Len(t, report.Dependencies, 2)
+	assert.Equal(t, "provider", report.Dependencies[0].Name, "required checks are listed first")
+	assert.Equal(t, StatusFailed, report.Dependencies[0].Status)
+	assert.Equal(t, "credentials rejected (HTTP 401)", report.Dependencies[0].Message)
+
+	// Failures are cached briefly
+	failing = false
+	report = checker.Ready(context.Background())
+	assert.False(t, report.Ready)
+	assert.True(t, report.Dependencies[0].Cached)
+	assert.Equal(t, 1, calls["provider"])
+
+	now = now.Add(defaultFailureTTL + time.Second)
+	report = checker.Ready(context.Background())
+	assert.True(t, report.Ready, "optional failures don't make the service unready")
+	assert.Equal(t, StatusOK, report.Dependencies[0].Status)
+	assert.Equal(t, 2, calls["provider"])
+
+	// Successes are cached longer
+	now = now.Add(defaultFailureTTL + time.Second)
+	report = checker.Ready(context.Background())
+	assert.True(t, report.Dependencies[0].Cached)
+	assert.Equal(t, 2, calls["provider"])
+	assert.Equal(t, 3, calls["optional"])
+}
+
+func TestHTTPCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Authorization") {
+		case "Bearer good":
+			w.WriteHeader(http.StatusOK)
+		case "Bearer broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		key     string
+		wantErr string
+	}{
+		{key: "good"},
+		{key: "bad", wantErr: "credentials rejected (HTTP 401)"},
+		{key: "broken", wantErr: "unexpected HTTP 502"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			err := HTTPCheck(server.Client(), server.URL, map[string]string{"Authorization": "Bearer " + tt.key})(context.Background())
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Equal(t, tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestChecksFromConfig(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if r.Header.Get("x-api-key") != "" && r.Header.Get("anthropic-version") == "" {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+	defer func(openAI, anthropic string) { openAIModelsURL, anthropicModelsURL = openAI, anthropic }(openAIModelsURL, anthropicModelsURL)
+	openAIModelsURL = server.URL + "/openai/models"
+	anthropicModelsURL = server.URL + "/anthropic/models"
+
+	cfg := &config.Config{
+		Port:              "8080",
+		OpenAIAPIKey:      "sk-test",
+		AnthropicAPIKey:   "sk-ant-test",
+		LLMProvider:       "anthropic",
+		LLMFallback:       "openai:gpt-5-mini,anthropic",
+		AuthMode:          "none",
+		LangfuseEnabled:   true,
+		LangfuseHost:      server.URL + "/",
+		LangfuseSecretKey: "secret",
+		LangfusePublicKey: "public",
+	}
+	checks := ChecksFromConfig(cfg, server.Client())
+
+	names := map[string]bool{}
+	for _, check := range checks {
+		names[check.Name] = check.Required
+	}
+	assert.Equal(t, map[string]bool{
+		"config":                 true,
+		"llm_provider:anthropic": true,
+		"llm_provider:openai":    false,
+		"langfuse":               false,
+	}, names)
+
+	report := NewChecker(checks...).Ready(context.Background())
+	assert.True(t, report.Ready)
+	sort.Strings(paths)
+	assert.Equal(t, []string{"/anthropic/models", "/api/public/health", "/openai/models"}, paths)
+}
+
+func TestChecksFromConfig_MissingKey(t *testing.T) {
+	cfg := &config.Config{Port: "8080", LLMProvider: "openai", AuthMode: "none"}
+	report := NewChecker(ChecksFromConfig(cfg, nil)...).Ready(context.Background())
+
+	assert.False(t, report.Ready)
+	for _, dependency := range report.Dependencies {
+		if dependency.Name == "llm_provider:openai" {
+			assert.Equal(t, "OPENAI_API_KEY is not set", dependency.Message)
+		}
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := config.Config{Port: "8080", LLMProvider: "openai", AuthMode: "gateway", SessionStore: "memory"}
+	assert.Empty(t, ValidateConfig(&valid))
+	mock := config.Config{Port: "8080", LLMProvider: "mock", AuthMode: "none"}
+	assert.Empty(t, ValidateConfig(&mock))
+
+	broken := config.Config{
+		Port:            "http",
+		LLMProvider:     "gemini",
+		LLMFallback:     "anthropic,mistral:large",
+		AuthMode:        "jwt",
+		SessionStore:    "redis",
+		RateLimitStore:  "redis",
+		LangfuseEnabled: true,
+	}
+	assert.Equal(t, []string{
+		`PORT "http" is not a valid port`,
+		`LLM_PROVIDER "gemini" is unknown (supported: openai, anthropic, mock)`,
+		`LLM_FALLBACK_PROVIDERS entry "mistral" is unknown`,
+		`AUTH_MODE "jwt" is unknown (supported: none, gateway)`,
+		"SESSION_STORE=redis requires REDIS_URL",
+		"RATE_LIMIT_STORE=redis requires REDIS_URL",
+		"LANGFUSE_ENABLED requires LANGFUSE_PUBLIC_KEY and LANGFUSE_SECRET_KEY",
+	}, ValidateConfig(&broken))
+}
@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultWorkers is how many jobs a queue runs at once
+	DefaultWorkers = 4
+	// DefaultQueueSize is how many jobs can wait for a worker
+	DefaultQueueSize = 100
+
+	// progressInterval is how often a running job's progress is written to the store
+	progressInterval = 500 * time.Millisecond
+)
+
+// ErrQueueFull is returned by Submit when every worker is busy and the queue is full
+var ErrQueueFull = errors.New("job queue is full")
+
+// ErrTimedOut is a job's error when its deadline passed before it finished
+var ErrTimedOut = errors.New("job timed out")
+
+// RunFunc does a job's work, reporting progress as it goes, and returns the job's result
+type RunFunc func(ctx context.Context, report *Reporter) (map[string]any, error)
+
+// Queue runs submitted jobs on a fixed pool of workers in this process and keeps their status
+// in a Store. With a shared store, any instance can report a job, and a job cancelled through
+// another instance stops at its next progress update.
+type Queue struct {
+	store   Store
+	pending chan pendingJob
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc // Jobs queued or running here
+}
+
+type pendingJob struct {
+	id  string
+	ctx context.Context
+	run RunFunc
+}
+
+// NewQueue starts workers that run jobs from a queue of size. Non-positive values use the
+// defaults.
+func NewQueue(store Store, workers, size int) *Queue {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	q := &Queue{
+		store:   store,
+		pending: make(chan pendingJob, size),
+		cancels: make(map[string]context.CancelFunc),
+	}
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues run and returns the new job. The job outlives ctx but keeps its values, such
+// as the request ID used in logs. A positive timeout is the job's deadline from now, time
+// spent waiting in the queue included; a job that misses it fails with ErrTimedOut.
+func (q *Queue) Submit(ctx context.Context, timeout time.Duration, run RunFunc) (*Job, error) {
+	now := time.Now()
+	job := Job{ID: uuid.New().String(), Status: StatusQueued, Created: now, Updated: now}
+	if err := q.store.Create(ctx, job); err != nil {
+		return nil, err
+	}
+
+	var jobCtx context.Context
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		jobCtx, cancel = context.WithDeadline(context.WithoutCancel(ctx), now.Add(timeout))
+	} else {
+		jobCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+	}
+	q.mu.Lock()
+	q.cancels[job.ID] = cancel
+	q.mu.Unlock()
+
+	select {
+	case q.pending <- pendingJob{id: job.ID, ctx: jobCtx, run: run}:
+		logger.Printf(ctx, "📥 Job %s queued", job.ID)
+		return &job, nil
+	default:
+		q.release(job.ID)
+		_, _ = q.store.Update(ctx, job.ID, func(j *Job) {
+			j.Status = StatusFailed
+			j.Error = ErrQueueFull.Error()
+		})
+		return nil, ErrQueueFull
+	}
+}
+
+// Get returns the job's current status, or nil if it is unknown or expired
+func (q *Queue) Get(ctx context.Context, id string) (*Job, error) {
+	return q.store.Get(ctx, id)
+}
+
+// Cancel marks an unfinished job cancelled and stops it if it runs here. It returns the job,
+// unchanged if it had already finished, or nil if it is unknown.
+func (q *Queue) Cancel(ctx context.Context, id string) (*Job, error) {
+	job, err := q.store.Update(ctx, id, func(j *Job) { j.Status = StatusCancelled })
+	if err != nil || job == nil {
+		return job, err
+	}
+	if job.Status == StatusCancelled {
+		q.cancel(id)
+	}
+	return job, nil
+}
+
+func (q *Queue) work() {
+	for job := range q.pending {
+		q.run(job)
+	}
+}
+
+// run runs one job, writing its progress to the store until it finishes
+func (q *Queue) run(job pendingJob) {
+	ctx := job.ctx
+	defer q.release(job.id)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		logger.Printf(ctx, "⏱️ Job %s timed out in the queue", job.id)
+		_, _ = q.store.Update(context.WithoutCancel(ctx), job.id, func(j *Job) {
+			j.Status, j.Error = StatusFailed, ErrTimedOut.Error()
+		})
+		return
+	}
+	if ctx.Err() != nil {
+		return // Cancelled while queued
+	}
+
+	report := &Reporter{status: StatusGenerating}
+	update := func(apply func(*Job)) {
+		stored, err := q.store.Update(ctx, job.id, apply)
+		if err != nil {
+			logger.Printf(ctx, "⚠️  Job %s: failed to store status: %v", job.id, err)
+			return
+		}
+		if stored != nil && stored.Status == StatusCancelled {
+			q.cancel(job.id)
+		}
+	}
+	updateProgress := func(j *Job) { j.Status, j.Progress = report.snapshot() }
+	update(updateProgress)
+
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				update(updateProgress)
+			}
+		}
+	}()
+
+	result, err := job.run(ctx, report)
+	close(done)
+	<-stopped
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("%w: %v", ErrTimedOut, err)
+	}
+
+	// The final status is written even if the job was cancelled: the store keeps it cancelled
+	ctx = context.WithoutCancel(ctx)
+	_, progress := report.snapshot()
+	if err != nil {
+		logger.Printf(ctx, "❌ Job %s failed: %v", job.id, err)
+		update(func(j *Job) {
+			j.Status, j.Progress, j.Error = StatusFailed, progress, err.Error()
+		})
+		return
+	}
+	logger.Printf(ctx, "✅ Job %s done: %d actions", job.id, progress.Actions)
+	update(func(j *Job) {
+		j.Status, j.Progress, j.Result = StatusDone, progress, result
+	})
+}
+
+// cancel stops a job if it is queued or running here
+func (q *Queue) cancel(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if cancel, ok := q.cancels[id]; ok {
+		cancel()
+	}
+}
+
+// release forgets a job that is no longer queued or running here
+func (q *Queue) release(id string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if cancel, ok := q.cancels[id]; ok {
+		cancel()
+		delete(q.cancels, id)
+	}
+}
+
+// Reporter collects a running job's progress; it is safe for concurrent use
+type Reporter struct {
+	mu       sync.Mutex
+	status   Status
+	progress Progress
+}
+
+// Token counts a DSL text delta streamed by the LLM
+func (r *Reporter) Token() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Tokens++
+}
+
+// Action counts a parsed action; the job is parsing from the first one on
+func (r *Reporter) Action() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.progress.Actions++
+	r.status = StatusParsing
+}
+
+func (r *Reporter) snapshot() (Status, Progress) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status, r.progress
+}
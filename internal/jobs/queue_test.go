@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waitFor polls the queue until the job reaches status
+func waitFor(t *testing.T, q *Queue, id string, status Status) *Job {
+	t.Helper()
+	var job *Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = q.Get(context.Background(), id)
+		return err == nil && job != nil && job.Status == status
+	}, 2*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestQueue_Done(t *testing.T) {
+	q := NewQueue(NewMemoryStore(time.Hour), 1, 1)
+	job, err := q.Submit(context.Background(), 0, func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		report.Token()
+		report.Token()
+		report.Action()
+		return map[string]any{"actions": []any{"a"}}, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusQueued, job.Status)
+
+	job = waitFor(t, q, job.ID, StatusDone)
+	assert.Equal(t, Progress{Tokens: 2, Actions: 1}, job.Progress)
+	assert.Equal(t, map[string]any{"actions": []any{"a"}}, job.Result)
+}
+
+func TestQueue_Failed(t *testing.T) {
+	q := NewQueue(NewMemoryStore(time.Hour), 1, 1)
+	job, err := q.Submit(context.Background(), 0, func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		return nil, errors.New("LLM unavailable")
+	})
+	require.NoError(t, err)
+
+	job = waitFor(t, q, job.ID, StatusFailed)
+	assert.Equal(t, "LLM unavailable", job.Error)
+}
+
+func TestQueue_Cancel(t *testing.T) {
+	q := NewQueue(NewMemoryStore(time.Hour), 1, 1)
+	started := make(chan struct{})
+	stopped := make(chan error, 1)
+	job, err := q.Submit(context.Background(), 0, func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		close(started)
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+	<-started
+	waitFor(t, q, job.ID, StatusGenerating)
+
+	cancelled, err := q.Cancel(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusCancelled, cancelled.Status)
+	assert.ErrorIs(t, <-stopped, context.Canceled)
+
+	// The job stays cancelled once its worker returns
+	time.Sleep(50 * time.Millisecond)
+	waitFor(t, q, job.ID, StatusCancelled)
+
+	unknown, err := q.Cancel(context.Background(), "unknown")
+	require.NoError(t, err)
+	assert.Nil(t, unknown)
+}
+
+func TestQueue_Full(t *testing.T) {
+	q := NewQueue(NewMemoryStore(time.Hour), 1, 1)
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{}, 1)
+	block := func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		started <- struct{}{}
+		<-release
+		return nil, nil
+	}
+
+	_, err := q.Submit(context.Background(), 0, block)
+	require.NoError(t, err)
+	<-started // The worker is busy
+	_, err = q.Submit(context.Background(), 0, block)
+	require.NoError(t, err) // Waits in the queue
+
+	_, err = q.Submit(context.Background(), 0, block)
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestQueue_Deadline(t *testing.T) {
+	q := NewQueue(NewMemoryStore(time.Hour), 1, 1)
+	job, err := q.Submit(context.Background(), 20*time.Millisecond, func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	require.NoError(t, err)
+
+	job = waitFor(t, q, job.ID, StatusFailed)
+	assert.Equal(t, "job timed out: context deadline exceeded", job.Error)
+}
+
+func TestQueue_DeadlineWhileQueued(t *testing.T) {
+	q := NewQueue(NewMemoryStore(time.Hour), 1, 1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_, err := q.Submit(context.Background(), 0, func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		close(started)
+		<-release
+		return nil, nil
+	})
+	require.NoError(t, err)
+	<-started
+
+	// The deadline runs from submission, so waiting for the busy worker uses it up
+	var ran atomic.Bool
+	job, err := q.Submit(context.Background(), 10*time.Millisecond, func(ctx context.Context, report *Reporter) (map[string]any, error) {
+		ran.Store(true)
+		return nil, nil
+	})
+	require.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	close(release)
+
+	job = waitFor(t, q, job.ID, StatusFailed)
+	assert.Equal(t, ErrTimedOut.Error(), job.Error)
+	assert.False(t, ran.Load())
+}
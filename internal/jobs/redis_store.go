@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+)
+
+const redisKeyPrefix = "magda:job:"
+
+// RedisStore keeps each job as one JSON value so any instance can report or cancel it.
+// Updates only land on the job as they loaded it, so a cancel and a worker's progress
+// update never overwrite each other.
+type RedisStore struct {
+	jobs *redis.JSONStore[Job]
+}
+
+// NewRedisStore creates a store for a redis://[user:password@]host:port[/db] URL.
+// A non-positive ttl uses DefaultTTL. The connection is opened on first use.
+func NewRedisStore(redisURL string, ttl time.Duration) (*RedisStore, error) {
+	client, err := redis.NewClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &RedisStore{jobs: redis.NewJSONStore[Job](client, redisKeyPrefix, "job", ttl)}, nil
+}
+
+// Create stores the job with the store's TTL
+func (s *RedisStore) Create(ctx context.Context, job Job) error {
+	return s.jobs.Set(ctx, job.ID, &job)
+}
+
+// Get loads the job
+func (s *RedisStore) Get(ctx context.Context, id string) (*Job, error) {
+	return s.jobs.Get(ctx, id)
+}
+
+// Update loads the job, applies update and stores it, refreshing the TTL. update runs again
+// if the job changed in the meantime.
+func (s *RedisStore) Update(ctx context.Context, id string, update func(*Job)) (*Job, error) {
+	return s.jobs.Update(ctx, id, func(job *Job) bool {
+		if job.Status.Finished() {
+			return false
+		}
+		update(job)
+		job.Updated = time.Now()
+		return true
+	})
+}
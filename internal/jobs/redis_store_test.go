@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	job, err := store.Get(ctx, "j1")
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Create(ctx, Job{ID: "j1", Status: StatusQueued, Created: created, Updated: created}))
+	assert.Equal(t, 3600, server.Expiry("magda:job:j1"))
+
+	job, err = store.Update(ctx, "j1", func(j *Job) {
+		j.Status = StatusDone
+		j.Result = map[string]any{"actions": []any{}}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, job.Status)
+
+	job, err = store.Update(ctx, "j1", func(j *Job) { j.Status = StatusCancelled })
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, job.Status, "finished jobs don't change")
+
+	job, err = store.Get(ctx, "j1")
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, map[string]any{"actions": []any{}}, job.Result)
+	assert.True(t, created.Equal(job.Created))
+}
@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a job's status is kept after its last update
+const DefaultTTL = time.Hour
+
+// Status is a job's place in its lifecycle
+type Status string
+
+const (
+	StatusQueued     Status = "queued"     // Waiting for a worker
+	StatusGenerating Status = "generating" // The LLM is writing DSL
+	StatusParsing    Status = "parsing"    // Actions are being parsed from the DSL
+	StatusDone       Status = "done"       // Result is set
+	StatusFailed     Status = "failed"     // Error is set
+	StatusCancelled  Status = "cancelled"  // Cancelled with DELETE before it finished
+)
+
+// Finished reports whether a job in this status will not change again
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusFailed || s == StatusCancelled
+}
+
+// Progress is how far a running job has got
+type Progress struct {
+	Tokens  int `json:"tokens"`  // DSL text deltas streamed by the LLM
+	Actions int `json:"actions"` // Actions parsed so far
+}
+
+// Job is an asynchronous request and, once finished, its result
+type Job struct {
+	ID       string         `json:"id"`
+	Status   Status         `json:"status"`
+	Progress Progress       `json:"progress"`
+	Result   map[string]any `json:"result,omitempty"` // Same fields as the synchronous response
+	Error    string         `json:"error,omitempty"`
+	Created  time.Time      `json:"created"`
+	Updated  time.Time      `json:"updated"`
+}
+
+// Store keeps job status keyed by job ID
+type Store interface {
+	// Create stores a new job
+	Create(ctx context.Context, job Job) error
+	// Get returns a job, or nil if it is unknown or expired
+	Get(ctx context.Context, id string) (*Job, error)
+	// Update applies update to a stored job and returns the result, or nil if the job is
+	// unknown. Finished jobs are returned unchanged. update may run more than once, so it
+	// should only change the job.
+	Update(ctx context.Context, id string, update func(*Job)) (*Job, error)
+}
+
+// NewStore creates the store for backend: "memory" (default) or "redis"
+func NewStore(backend, redisURL string, ttl time.Duration) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemoryStore(ttl), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis job store")
+		}
+		return NewRedisStore(redisURL, ttl)
+	default:
+		return nil, fmt.Errorf("unknown job store %q: must be \"memory\" or \"redis\"", backend)
+	}
+}
+
+// MemoryStore keeps jobs in process memory. Jobs are lost on restart and are only visible to
+// the instance that runs them; use RedisStore behind a load balancer.
+type MemoryStore struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+	now  func() time.Time
+}
+
+// NewMemoryStore creates an in-memory store. A non-positive ttl uses DefaultTTL.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &MemoryStore{
+		ttl:  ttl,
+		jobs: make(map[string]*Job),
+		now:  time.Now,
+	}
+}
+
+// Create stores a job and evicts expired ones
+func (s *MemoryStore) Create(ctx context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, stored := range s.jobs {
+		if now.Sub(stored.Updated) > s.ttl {
+			delete(s.jobs, id)
+		}
+	}
+	s.jobs[job.ID] = &job
+	return nil
+}
+
+// Get returns a copy of the job
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.job(id)
+	if job == nil {
+		return nil, nil
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// Update applies update under the store's lock
+func (s *MemoryStore) Update(ctx context.Context, id string, update func(*Job)) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job := s.job(id)
+	if job == nil {
+		return nil, nil
+	}
+	if !job.Status.Finished() {
+		update(job)
+		job.Updated = s.now()
+	}
+	copied := *job
+	return &copied, nil
+}
+
+// job returns the stored job, dropping it if expired. s.mu must be held.
+func (s *MemoryStore) job(id string) *Job {
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	if s.now().Sub(job.Updated) > s.ttl {
+		delete(s.jobs, id)
+		return nil
+	}
+	return job
+}
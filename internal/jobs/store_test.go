@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Update(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(time.Hour)
+	require.NoError(t, store.Create(ctx, Job{ID: "j1", Status: StatusQueued, Updated: time.Now()}))
+
+	job, err := store.Update(ctx, "j1", func(j *Job) {
+		j.Status = StatusParsing
+		j.Progress.Actions = 2
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusParsing, job.Status)
+
+	job, err = store.Update(ctx, "j1", func(j *Job) { j.Status = StatusDone })
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, job.Status)
+
+	// Finished jobs don't change
+	job, err = store.Update(ctx, "j1", func(j *Job) { j.Status = StatusCancelled })
+	require.NoError(t, err)
+	assert.Equal(t, StatusDone, job.Status)
+	assert.Equal(t, 2, job.Progress.Actions)
+
+	job, err = store.Update(ctx, "unknown", func(j *Job) {})
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
+
+func TestMemoryStore_ExpiresJobs(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(time.Hour)
+	store.now = func() time.Time { return now }
+	require.NoError(t, store.Create(ctx, Job{ID: "j1", Status: StatusDone, Updated: now}))
+
+	now = now.Add(30 * time.Minute)
+	job, err := store.Get(ctx, "j1")
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	now = now.Add(time.Hour)
+	job, err = store.Get(ctx, "j1")
+	require.NoError(t, err)
+	assert.Nil(t, job)
+}
@@ -0,0 +1,579 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/getsentry/sentry-go"
+	"github.com/openai/openai-go/responses"
+)
+
+const (
+	// Provider name
+	providerNameAnthropic = "anthropic"
+
+	anthropicAPIURL       = "https://api.anthropic.com/v1/messages"
+	anthropicAPIVersion   = "2023-06-01"
+	defaultAnthropicModel = "claude-sonnet-4-5"
+	anthropicMaxTokens    = 8192
+
+	// CFG grammars are exposed to Claude as a tool with a single string input holding the DSL
+	anthropicDSLInputField = "dsl"
+
+	assistantRole = "assistant"
+	systemRole    = "system"
+
+	// SSE lines can carry large tool inputs; allow up to 1MB per line
+	maxAnthropicSSELineBytes = 1024 * 1024
+)
+
+// AnthropicProvider implements the Provider interface using Anthropic's Messages API.
+// CFG grammars and output schemas are mapped onto forced tool use.
+type AnthropicProvider struct {
+	apiKey       string
+	defaultModel string // Used when a request names a non-Claude model (agents default to GPT models)
+	baseURL      string
+	httpClient   *http.Client
+}
+
+// NewAnthropicProvider creates a new Anthropic provider.
+// defaultModel replaces non-Claude model names; empty uses claude-sonnet-4-5.
+func NewAnthropicProvider(apiKey, defaultModel string) *AnthropicProvider {
+	if defaultModel == "" {
+		defaultModel = defaultAnthropicModel
+	}
+	return &AnthropicProvider{
+		apiKey:       apiKey,
+		defaultModel: defaultModel,
+		baseURL:      anthropicAPIURL,
+		httpClient:   http.DefaultClient,
+	}
+}
+
+// Name returns the provider name
+func (p *AnthropicProvider) Name() string {
+	return providerNameAnthropic
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  map[string]any     `json:"tool_choice,omitempty"`
+	Stream      bool               `json:"stream,omitempty"`
+}
+
+type anthropicContentBlock struct {
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int64 `json:"input_tokens"`
+	OutputTokens int64 `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
+	Usage      anthropicUsage          `json:"usage"`
+}
+
+type anthropicStreamEvent struct {
+	Type    string `json:"type"`
+	Message *struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message,omitempty"`
+	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+	} `json:"delta,omitempty"`
+	Usage *anthropicUsage `json:"usage,omitempty"`
+	Error *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Generate implements non-streaming generation using Anthropic's Messages API
+func (p *AnthropicProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	startTime := time.Now()
+	params := p.buildRequestParams(ctx, request)
+	logger.Printf(ctx, "🎵 ANTHROPIC GENERATION REQUEST STARTED (Model: %s)", params.Model)
+
+	// Start Sentry transaction
+	transaction := sentry.StartTransaction(ctx, "anthropic.generate")
+	defer transaction.Finish()
+
+	transaction.SetTag("model", params.Model)
+	transaction.SetTag("provider", providerNameAnthropic)
+
+	span := transaction.StartChild("anthropic.api_call")
+	apiStartTime := time.Now()
+	body, err := p.makeRequest(ctx, params)
+	span.Finish()
+	if err != nil {
+		logger.Printf(ctx, "❌ ANTHROPIC REQUEST FAILED after %v: %v", time.Since(apiStartTime), err)
+		transaction.SetTag("success", "false")
+		sentry.CaptureException(err)
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	logger.Printf(ctx, "⏱️  ANTHROPIC API CALL COMPLETED in %v", time.Since(apiStartTime))
+
+	var resp anthropicResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		transaction.SetTag("success", "false")
+		return nil, fmt.Errorf("failed to parse anthropic response: %w", err)
+	}
+
+	rawOutput, err := p.extractOutput(request, resp.Content)
+	if err != nil {
+		transaction.SetTag("success", "false")
+		return nil, err
+	}
+
+	usage := anthropicUsageToResponseUsage(resp.Usage)
+	p.logUsageStats(ctx, usage)
+	logger.Printf(ctx, "✅ ANTHROPIC GENERATION COMPLETED in %v (stop_reason=%s)", time.Since(startTime), resp.StopReason)
+
+	transaction.SetTag("success", "true")
+	return &GenerationResponse{
+		RawOutput: rawOutput,
+		Usage:     usage,
+	}, nil
+}
+
+// buildRequestParams converts GenerationRequest to an Anthropic Messages API request
+func (p *AnthropicProvider) buildRequestParams(ctx context.Context, request *GenerationRequest) anthropicRequest {
+	params := anthropicRequest{
+		Model:       p.resolveModel(request.Model),
+		MaxTokens:   anthropicMaxTokens,
+		Temperature: request.Temperature,
+	}
+	if request.MaxOutputTokens > 0 {
+		params.MaxTokens = request.MaxOutputTokens
+	}
+
+	// Claude takes the system prompt separately; developer/system messages are folded into it
+	systemParts := []string{}
+	if request.SystemPrompt != "" {
+		systemParts = append(systemParts, request.SystemPrompt)
+	}
+
+	for _, item := range request.InputArray {
+		role, hasRole := item["role"].(string)
+		content, hasContent := item["content"].(string)
+		if !hasRole || !hasContent {
+			logger.Printf(ctx, "⚠️  Skipping invalid input item (missing role or content): %v", item)
+			continue
+		}
+
+		switch role {
+		case developerRole, systemRole:
+			systemParts = append(systemParts, content)
+			continue
+		case assistantRole:
+		default:
+			role = userRole
+		}
+
+		// Messages must alternate roles, so merge consecutive messages from the same role
+		if n := len(params.Messages); n > 0 && params.Messages[n-1].Role == role {
+			params.Messages[n-1].Content += "\n\n" + content
+			continue
+		}
+		params.Messages = append(params.Messages, anthropicMessage{Role: role, Content: content})
+	}
+	params.System = strings.Join(systemParts, "\n\n")
+
+	if request.MCPConfig != nil {
+		logger.Printf(ctx, "⚠️  MCP is not supported by the Anthropic provider, ignoring MCP server %s", request.MCPConfig.Label)
+	}
+
+	// Force the tool so Claude returns structured output instead of prose
+	switch {
+	case request.CFGGrammar != nil:
+		params.Tools = []anthropicTool{cfgGrammarTool(request.CFGGrammar)}
+		params.ToolChoice = map[string]any{"type": "tool", "name": request.CFGGrammar.ToolName}
+	case request.OutputSchema != nil:
+		params.Tools = []anthropicTool{{
+			Name:        request.OutputSchema.Name,
+			Description: request.OutputSchema.Description,
+			InputSchema: request.OutputSchema.Schema,
+		}}
+		params.ToolChoice = map[string]any{"type": "tool", "name": request.OutputSchema.Name}
+	}
+
+	return params
+}
+
+// resolveModel keeps Claude model names and replaces anything else with the default model
+func (p *AnthropicProvider) resolveModel(model string) string {
+	if strings.HasPrefix(strings.ToLower(model), "claude-") {
+		return model
+	}
+	return p.defaultModel
+}
+
+// cfgGrammarTool exposes a CFG grammar as a tool whose single string input must follow the grammar.
+// Claude has no grammar-constrained decoding, so the grammar is given in the tool description
+// and the DSL is still validated by the caller's parser.
+func cfgGrammarTool(cfg *CFGConfig) anthropicTool {
+	syntax := cfg.Syntax
+	if syntax == "" {
+		syntax = "lark"
+	}
+	description := fmt.Sprintf("%s\n\nThe %q input must be valid according to this %s grammar:\n\n%s",
+		cfg.Description, anthropicDSLInputField, syntax, cfg.Grammar)
+
+	return anthropicTool{
+		Name:        cfg.ToolName,
+		Description: description,
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				anthropicDSLInputField: map[string]any{
+					"type":        "string",
+					"description": "DSL code conforming to the grammar",
+				},
+			},
+			"required": []string{anthropicDSLInputField},
+		},
+	}
+}
+
+// extractOutput returns the DSL (CFG), JSON tool input (output schema), or text from the response content
+func (p *AnthropicProvider) extractOutput(request *GenerationRequest, content []anthropicContentBlock) (string, error) {
+	switch {
+	case request.CFGGrammar != nil:
+		for _, block := range content {
+			if block.Type == "tool_use" && block.Name == request.CFGGrammar.ToolName {
+				return extractDSLFromToolInput(block.Input)
+			}
+		}
+		return "", fmt.Errorf("CFG grammar was configured but Claude did not use the %s tool to generate DSL code",
+			request.CFGGrammar.ToolName)
+
+	case request.OutputSchema != nil:
+		for _, block := range content {
+			if block.Type == "tool_use" && block.Name == request.OutputSchema.Name {
+				return string(block.Input), nil
+			}
+		}
+		return "", fmt.Errorf("output schema was configured but Claude did not use the %s tool",
+			request.OutputSchema.Name)
+	}
+
+	var text strings.Builder
+	for _, block := range content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("anthropic response did not include any output text")
+	}
+	return strings.TrimSpace(text.String()), nil
+}
+
+// extractDSLFromToolInput reads the DSL string from a CFG tool input object
+func extractDSLFromToolInput(input []byte) (string, error) {
+	var toolInput map[string]any
+	if err := json.Unmarshal(input, &toolInput); err != nil {
+		return "", fmt.Errorf("failed to parse CFG tool input: %w", err)
+	}
+	dsl, _ := toolInput[anthropicDSLInputField].(string)
+	if strings.TrimSpace(dsl) == "" {
+		return "", fmt.Errorf("CFG tool input did not include %q DSL code", anthropicDSLInputField)
+	}
+	log.Printf("✅ Found DSL code: %s", truncateString(dsl, maxPreviewChars))
+	return strings.TrimSpace(dsl), nil
+}
+
+// anthropicUsageToResponseUsage reports Claude token usage with the same type as the OpenAI
+// path, so agents record token metrics regardless of provider
+func anthropicUsageToResponseUsage(usage anthropicUsage) responses.ResponseUsage {
+	return responses.ResponseUsage{
+		InputTokens:  usage.InputTokens,
+		OutputTokens: usage.OutputTokens,
+		TotalTokens:  usage.InputTokens + usage.OutputTokens,
+	}
+}
+
+// logUsageStats logs token usage statistics
+func (p *AnthropicProvider) logUsageStats(ctx context.Context, usage responses.ResponseUsage) {
+	logger.Printf(ctx, "📊 USAGE: input=%d, output=%d, total=%d",
+		usage.InputTokens, usage.OutputTokens, usage.TotalTokens)
+}
+
+// newHTTPRequest builds an authenticated Messages API request
+func (p *AnthropicProvider) newHTTPRequest(ctx context.Context, params anthropicRequest) (*http.Request, error) {
+	payload, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode anthropic request: %w", err)
+	}
+
+	logger.Printf(ctx, "📤 Making Anthropic request (JSON size: %d bytes, stream: %t)", len(payload), params.Stream)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", p.apiKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// makeRequest sends a non-streaming request and returns the response body. With DEBUG_CAPTURE
+// on, the request and response payloads are kept (redacted) in the debug capture buffer.
+func (p *AnthropicProvider) makeRequest(ctx context.Context, params anthropicRequest) ([]byte, error) {
+	req, err := p.newHTTPRequest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now()
+
+	httpResp, err := p.httpClient.Do(req)
+	if err != nil {
+		captureCall(ctx, providerNameAnthropic, req, start, 0, nil, err)
+		return nil, err
+	}
+	defer func() {
+		if closeErr := httpResp.Body.Close(); closeErr != nil {
+			logger.Printf(ctx, "⚠️  Failed to close response body: %v", closeErr)
+		}
+	}()
+
+	body, _ := io.ReadAll(httpResp.Body)
+	captureCall(ctx, providerNameAnthropic, req, start, httpResp.StatusCode, body, nil)
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
+	}
+	return body, nil
+}
+
+// GenerateStream implements streaming generation using Anthropic's Messages API.
+// Tool input deltas (DSL or schema JSON) are streamed as text_delta events with is_tool_call set.
+//
+//nolint:gocyclo // Event handling mirrors the Messages API stream event types
+func (p *AnthropicProvider) GenerateStream(
+	ctx context.Context,
+	request *GenerationRequest,
+	callback StreamCallback,
+) (*GenerationResponse, error) {
+	startTime := time.Now()
+	params := p.buildRequestParams(ctx, request)
+	params.Stream = true
+	logger.Printf(ctx, "🎵 ANTHROPIC STREAMING GENERATION REQUEST STARTED (Model: %s)", params.Model)
+
+	// Start Sentry transaction
+	transaction := sentry.StartTransaction(ctx, "anthropic.generate_stream")
+	defer transaction.Finish()
+
+	transaction.SetTag("model", params.Model)
+	transaction.SetTag("provider", providerNameAnthropic)
+	transaction.SetTag("streaming", "true")
+
+	// Send initial event
+	if callback != nil {
+		_ = callback(StreamEvent{Type: "started", Message: "Starting generation..."})
+	}
+
+	span := transaction.StartChild("anthropic.api_stream")
+	defer span.Finish()
+
+	req, err := p.newHTTPRequest(ctx, params)
+	if err != nil {
+		transaction.SetTag("success", "false")
+		return nil, err
+	}
+	httpResp, err := p.httpClient.Do(req)
+	if err != nil {
+		transaction.SetTag("success", "false")
+		sentry.CaptureException(err)
+		return nil, fmt.Errorf("anthropic request failed: %w", err)
+	}
+	defer func() {
+		if closeErr := httpResp.Body.Close(); closeErr != nil {
+			logger.Printf(ctx, "⚠️  Failed to close response body: %v", closeErr)
+		}
+	}()
+
+	if httpResp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(httpResp.Body)
+		transaction.SetTag("success", "false")
+		return nil, fmt.Errorf("anthropic request failed: %w", &APIError{StatusCode: httpResp.StatusCode, Body: string(body)})
+	}
+
+	var accumulatedText strings.Builder
+	var accumulatedToolInput strings.Builder
+	var usage anthropicUsage
+	eventCount := 0
+
+	scanner := bufio.NewScanner(httpResp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAnthropicSSELineBytes)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			logger.Printf(ctx, "⚠️  Skipping unparseable stream event: %v", err)
+			continue
+		}
+		eventCount++
+
+		// Log event type for debugging (first few events only)
+		if eventCount <= maxLogEventCountOpenAI {
+			logger.Printf(ctx, "📥 Stream event #%d: type=%s", eventCount, event.Type)
+		}
+
+		switch event.Type {
+		case "message_start":
+			if event.Message != nil {
+				usage.InputTokens = event.Message.Usage.InputTokens
+			}
+
+		case "content_block_delta":
+			if event.Delta == nil {
+				continue
+			}
+			switch event.Delta.Type {
+			case "text_delta":
+				accumulatedText.WriteString(event.Delta.Text)
+				if callback != nil && event.Delta.Text != "" {
+					_ = callback(StreamEvent{
+						Type:    "text_delta",
+						Message: event.Delta.Text,
+						Data: map[string]interface{}{
+							"accumulated_length": accumulatedText.Len(),
+						},
+					})
+				}
+			case "input_json_delta":
+				accumulatedToolInput.WriteString(event.Delta.PartialJSON)
+				if callback != nil && event.Delta.PartialJSON != "" {
+					_ = callback(StreamEvent{
+						Type:    "text_delta",
+						Message: event.Delta.PartialJSON,
+						Data: map[string]interface{}{
+							"accumulated_length": accumulatedToolInput.Len(),
+							"is_tool_call":       true,
+						},
+					})
+				}
+			}
+
+		case "message_delta":
+			if event.Usage != nil {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+
+		case "error":
+			message := "unknown error"
+			if event.Error != nil {
+				message = event.Error.Message
+			}
+			logger.Printf(ctx, "❌ Stream error: %s", message)
+			transaction.SetTag("success", "false")
+			return nil, fmt.Errorf("stream error: %s", message)
+		}
+
+		// Send periodic heartbeat
+		if eventCount%50 == 0 && callback != nil {
+			_ = callback(StreamEvent{
+				Type:    "heartbeat",
+				Message: "Processing...",
+				Data: map[string]interface{}{
+					"events_received": eventCount,
+					"elapsed_seconds": int(time.Since(startTime).Seconds()),
+				},
+			})
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		logger.Printf(ctx, "❌ Stream error: %v", err)
+		transaction.SetTag("success", "false")
+		sentry.CaptureException(err)
+		return nil, fmt.Errorf("stream error: %w", err)
+	}
+
+	// Assemble the final output the same way as the non-streaming path
+	var content []anthropicContentBlock
+	if accumulatedToolInput.Len() > 0 {
+		toolName := ""
+		if request.CFGGrammar != nil {
+			toolName = request.CFGGrammar.ToolName
+		} else if request.OutputSchema != nil {
+			toolName = request.OutputSchema.Name
+		}
+		content = append(content, anthropicContentBlock{
+			Type:  "tool_use",
+			Name:  toolName,
+			Input: json.RawMessage(accumulatedToolInput.String()),
+		})
+	}
+	if accumulatedText.Len() > 0 {
+		content = append(content, anthropicContentBlock{Type: "text", Text: accumulatedText.String()})
+	}
+
+	rawOutput, err := p.extractOutput(request, content)
+	if err != nil {
+		transaction.SetTag("success", "false")
+		return nil, err
+	}
+
+	logger.Printf(ctx, "✅ ANTHROPIC STREAMING COMPLETE: %d events, %d chars, %v duration",
+		eventCount, len(rawOutput), time.Since(startTime))
+
+	// Send completion event
+	if callback != nil {
+		_ = callback(StreamEvent{
+			Type:    "completed",
+			Message: "Generation complete",
+			Data: map[string]interface{}{
+				"total_length": len(rawOutput),
+				"event_count":  eventCount,
+			},
+		})
+	}
+
+	responseUsage := anthropicUsageToResponseUsage(usage)
+	p.logUsageStats(ctx, responseUsage)
+
+	transaction.SetTag("success", "true")
+	return &GenerationResponse{
+		RawOutput: rawOutput,
+		Usage:     responseUsage,
+	}, nil
+}
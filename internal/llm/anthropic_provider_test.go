@@ -0,0 +1,235 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/debugcapture"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAnthropicProvider(t *testing.T, handler http.HandlerFunc) *AnthropicProvider {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	provider := NewAnthropicProvider("test-key", "")
+	provider.baseURL = server.URL
+	return provider
+}
+
+func TestNewAnthropicProvider(t *testing.T) {
+	provider := NewAnthropicProvider("test-api-key", "")
+	require.NotNil(t, provider)
+	assert.Equal(t, "anthropic", provider.Name())
+	assert.Equal(t, defaultAnthropicModel, provider.defaultModel)
+
+	assert.Equal(t, "claude-opus-4-1", NewAnthropicProvider("key", "claude-opus-4-1").resolveModel("gpt-5.1"))
+	assert.Equal(t, "claude-haiku-4-5", provider.resolveModel("claude-haiku-4-5"))
+}
+
+func TestAnthropicProvider_BuildRequestParams(t *testing.T) {
+	provider := NewAnthropicProvider("test-key", "")
+
+	request := &GenerationRequest{
+		Model:        "gpt-5.1",
+		SystemPrompt: "system prompt",
+		InputArray: []map[string]any{
+			{"role": "developer", "content": "developer context"},
+			{"role": "user", "content": "first"},
+			{"role": "user", "content": "second"},
+			{"role": "assistant", "content": "reply"},
+			{"role": "user"},
+		},
+		CFGGrammar: &CFGConfig{
+			ToolName:    "magda_dsl",
+			Description: "Generate DSL",
+			Grammar:     "start: call",
+		},
+	}
+
+	params := provider.buildRequestParams(context.Background(), request)
+	assert.Equal(t, defaultAnthropicModel, params.Model)
+	assert.Equal(t, "system prompt\n\ndeveloper context", params.System)
+	assert.Equal(t, []anthropicMessage{
+		{Role: "user", Content: "first\n\nsecond"},
+		{Role: "assistant", Content: "reply"},
+	}, params.Messages)
+
+	require.Len(t, params.Tools, 1)
+	assert.Equal(t, "magda_dsl", params.Tools[0].Name)
+	assert.Contains(t, params.Tools[0].Description, "start: call")
+	assert.Equal(t, []string{"dsl"}, params.Tools[0].InputSchema["required"])
+	assert.Equal(t, map[string]any{"type": "tool", "name": "magda_dsl"}, params.ToolChoice)
+}
+
+func TestAnthropicProvider_GenerateCFG(t *testing.T) {
+	var received anthropicRequest
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-key", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicAPIVersion, r.Header.Get("anthropic-version"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+
+		_, _ = fmt.Fprint(w, `{
+			"content": [{"type": "tool_use", "name": "magda_dsl", "input": {"dsl": "track(name=\"Bass\")"}}],
+			"stop_reason": "tool_use",
+			"usage": {"input_tokens": 120, "output_tokens": 30}
+		}`)
+	})
+
+	resp, err := provider.Generate(context.Background(), &GenerationRequest{
+		Model:      "gpt-5.1",
+		InputArray: []map[string]any{{"role": "user", "content": "add a bass track"}},
+		CFGGrammar: &CFGConfig{ToolName: "magda_dsl", Grammar: "start: call"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `track(name="Bass")`, resp.RawOutput)
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 120, OutputTokens: 30, TotalTokens: 150}, resp.Usage)
+	assert.False(t, received.Stream)
+	assert.Equal(t, anthropicMaxTokens, received.MaxTokens)
+}
+
+func TestAnthropicProvider_GenerateOutputSchema(t *testing.T) {
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{
+			"content": [{"type": "tool_use", "name": "MusicalOutput", "input": {"choices": []}}],
+			"usage": {"input_tokens": 10, "output_tokens": 5}
+		}`)
+	})
+
+	resp, err := provider.Generate(context.Background(), &GenerationRequest{
+		InputArray:   []map[string]any{{"role": "user", "content": "compose"}},
+		OutputSchema: &OutputSchema{Name: "MusicalOutput", Schema: map[string]any{"type": "object"}},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"choices": []}`, resp.RawOutput)
+}
+
+func TestAnthropicProvider_GenerateErrors(t *testing.T) {
+	t.Run("API error", func(t *testing.T) {
+		provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = fmt.Fprint(w, `{"type": "error", "error": {"type": "rate_limit_error"}}`)
+		})
+
+		_, err := provider.Generate(context.Background(), &GenerationRequest{
+			InputArray: []map[string]any{{"role": "user", "content": "hi"}},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "API error 429")
+	})
+
+	t.Run("CFG tool not used", func(t *testing.T) {
+		provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = fmt.Fprint(w, `{"content": [{"type": "text", "text": "track(name=\"Bass\")"}]}`)
+		})
+
+		_, err := provider.Generate(context.Background(), &GenerationRequest{
+			InputArray: []map[string]any{{"role": "user", "content": "hi"}},
+			CFGGrammar: &CFGConfig{ToolName: "magda_dsl"},
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "did not use the magda_dsl tool")
+	})
+}
+
+func TestAnthropicProvider_DebugCapture(t *testing.T) {
+	buffer := debugcapture.Enable(10)
+	t.Cleanup(debugcapture.Disable)
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"content": [{"type": "text", "text": "ok"}], "usage": {"input_tokens": 1, "output_tokens": 1}}`)
+	})
+
+	ctx := logger.WithRequestID(context.Background(), "req-7")
+	_, err := provider.Generate(ctx, &GenerationRequest{
+		InputArray: []map[string]any{{"role": "user", "content": "mail me at ana@example.com"}},
+	})
+	require.NoError(t, err)
+
+	captures := buffer.List(0)
+	require.Len(t, captures, 1)
+	assert.Equal(t, "anthropic", captures[0].Provider)
+	assert.Equal(t, "req-7", captures[0].RequestID)
+	assert.Equal(t, http.StatusOK, captures[0].StatusCode)
+	assert.Contains(t, string(captures[0].Request), "mail me at [REDACTED_EMAIL]")
+	assert.NotContains(t, string(captures[0].Request), "ana@example.com")
+	assert.Contains(t, string(captures[0].Response), `"text":"ok"`)
+}
+
+func TestAnthropicProvider_GenerateStream(t *testing.T) {
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		var received anthropicRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		assert.True(t, received.Stream)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type": "message_start", "message": {"usage": {"input_tokens": 50, "output_tokens": 1}}}`,
+			`{"type": "content_block_start", "index": 0, "content_block": {"type": "tool_use", "name": "magda_dsl", "input": {}}}`,
+			`{"type": "content_block_delta", "index": 0, "delta": {"type": "input_json_delta", "partial_json": "{\"dsl\": \"track("}}`,
+			`{"type": "content_block_delta", "index": 0, "delta": {"type": "input_json_delta", "partial_json": "name=\\\"Keys\\\")\"}"}}`,
+			`{"type": "content_block_stop", "index": 0}`,
+			`{"type": "message_delta", "delta": {"stop_reason": "tool_use"}, "usage": {"output_tokens": 12}}`,
+			`{"type": "message_stop"}`,
+		}
+		for _, event := range events {
+			_, _ = fmt.Fprintf(w, "event: message\ndata: %s\n\n", event)
+		}
+	})
+
+	var eventTypes []string
+	resp, err := provider.GenerateStream(context.Background(), &GenerationRequest{
+		InputArray: []map[string]any{{"role": "user", "content": "add keys"}},
+		CFGGrammar: &CFGConfig{ToolName: "magda_dsl"},
+	}, func(event StreamEvent) error {
+		eventTypes = append(eventTypes, event.Type)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, `track(name="Keys")`, resp.RawOutput)
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 50, OutputTokens: 12, TotalTokens: 62}, resp.Usage)
+	assert.Equal(t, []string{"started", "text_delta", "text_delta", "completed"}, eventTypes)
+}
+
+func TestAnthropicProvider_GenerateStreamError(t *testing.T) {
+	provider := newTestAnthropicProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, "event: error\ndata: {\"type\": \"error\", \"error\": {\"type\": \"overloaded_error\", \"message\": \"Overloaded\"}}\n\n")
+	})
+
+	_, err := provider.GenerateStream(context.Background(), &GenerationRequest{
+		InputArray: []map[string]any{{"role": "user", "content": "hi"}},
+	}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Overloaded")
+}
+
+func TestProviderFactory_GetProviderByName(t *testing.T) {
+	factory := NewProviderFactory("openai-key").WithAnthropic("anthropic-key", "claude-opus-4-1")
+
+	provider, err := factory.GetProviderByName("")
+	require.NoError(t, err)
+	assert.Equal(t, "openai", provider.Name())
+
+	provider, err = factory.GetProviderByName("Anthropic")
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", provider.Name())
+
+	provider, err = factory.GetProvider(context.Background(), "claude-haiku-4-5")
+	require.NoError(t, err)
+	assert.Equal(t, "anthropic", provider.Name())
+
+	_, err = factory.GetProviderByName("gemini")
+	assert.Error(t, err)
+
+	_, err = NewProviderFactory("openai-key").GetProviderByName("anthropic")
+	assert.Error(t, err)
+}
@@ -10,18 +10,39 @@ func GetArrangerDSLGrammar() string {
 // SIMPLE SYNTAX ONLY - one call per statement:
 //   arpeggio(symbol=Em, note_duration=0.25) - for arpeggios with specific note duration
 //   arpeggio(symbol=Em, start=0.0, note_duration=0.25) - with explicit start time
+//   arpeggio(symbol=Em, note_duration=0.25, probability=0.7, seed=42) - randomly drop notes
 //   chord(symbol=C, length=4) - for chords (simultaneous notes) with relative timing
 //   chord(symbol=C, start=0, duration=4) - for chords with explicit rhythm timing
+//   chord(symbol=G, length=4, strum_ms=25, direction=down, voicing=spread) - strummed open voicing
 //   progression(chords=[C, Am, F, G], length=16) - for chord progressions
+//   progression(chords=[C:3, Am:4, F, G], octave=4) - per-chord octaves (F and G use octave 4)
+//   progression(chords=[C, Am, F, G], voicing=smooth, voices=4, low=C3, high=G4) - voice-led pads/keys
+//   walking_bass(progression=[Dm7, G7, Cmaj7], length=12) - quarter-note walking bassline
+//   drum_pattern(style="house", length=8, swing=0.1) - kick/snare/hat groove on General MIDI drum notes
+//   section(name="Chorus", bars=8, tracks=[{track=0, progression=[C, Am, F, G]}, {track=1, drum_pattern="house"}]) - one clip per track
+//   arrangement(genre="edm", tracks=[0, 1]) - a genre's song structure as regions, with an empty clip per section on each track
+//   arpeggio(symbol=Am, note_duration=0.25, length=16, velocity_curve=crescendo) - dynamics over the notes
+//   arpeggio(symbol=Em, note_duration=0.25); humanize(timing_ms=10, velocity=8) - generate, then transform
+//   quantize(grid=0.25, strength=0.8) - on its own, edits the selected clips' existing notes
+//   groove(template="swing", amount=0.6) - swing/shuffle feel; groove(from_track=2) copies a track's feel
+//   defaults(octave=3, velocity=90) - session defaults for what later calls leave out, alone or first
 // Note: Supports both relative timing (length) and explicit rhythm timing (start, duration)
 
 // ---------- Start rule ----------
-start: statement
+// One call, optionally followed by note transforms (applied in order), or transforms alone.
+// A defaults() call may come first, or on its own.
+start: (defaults_call ";" SP)? statement (";" SP transform_call)*
+     | (defaults_call ";" SP)? transform_call (";" SP transform_call)*
+     | defaults_call
 
 // ---------- Statements - ONE call only, no chaining ----------
 statement: arpeggio_call
          | chord_call
          | progression_call
+         | walking_bass_call
+         | drum_pattern_call
+         | section_call
+         | arrangement_call
          | note_call
 
 // ---------- Single Note: one note with pitch and duration ----------
@@ -54,6 +75,10 @@ arpeggio_named_param: "symbol" "=" chord_symbol
                     | "velocity" "=" NUMBER
                     | "octave" "=" NUMBER
                     | "direction" "=" ("up" | "down" | "updown")
+                    | "probability" "=" NUMBER  // Chance (0-1] that each note plays; 1 = every note
+                    | "seed" "=" NUMBER  // Random seed so the same notes are dropped (and velocities varied) every time
+                    | "velocity_curve" "=" VELOCITY_CURVE
+                    | "velocity_range" "=" NUMBER  // +/- velocity for velocity_curve=random_range (default 15)
 
 // ---------- Chord: SIMULTANEOUS notes ----------
 chord_call: "chord" "(" chord_params ")"
@@ -69,7 +94,10 @@ chord_named_param: "symbol" "=" chord_symbol
                  | "rhythm" "=" STRING  // Rhythm template name (swing, bossa, syncopated, etc.)
                  | "repeat" "=" NUMBER
                  | "velocity" "=" NUMBER
-                 | "inversion" "=" NUMBER
+                 | "inversion" "=" NUMBER  // Move the lowest note up an octave N times (1 = first inversion)
+                 | "voicing" "=" ("close" | "spread" | "drop2")  // spread: open voicing, drop2: second-highest note down an octave
+                 | "strum_ms" "=" NUMBER  // Milliseconds between chord notes (e.g. 15-40 for a guitar strum)
+                 | "direction" "=" ("down" | "up")  // Strum direction: down = low to high, up = high to low
 
 // ---------- Progression: sequence of chords ----------
 progression_call: "progression" "(" progression_params ")"
@@ -77,20 +105,143 @@ progression_call: "progression" "(" progression_params ")"
 progression_params: progression_named_params
 
 progression_named_params: progression_named_param ("," SP progression_named_param)*
-progression_named_param: "chords" "=" chords_array
+progression_named_param: "chords" "=" progression_chords_array
                        | "length" "=" NUMBER
                        | "start" "=" NUMBER  // Explicit start time in beats (for rhythm timing)
                        | "repeat" "=" NUMBER
+                       | "octave" "=" NUMBER  // Default octave for chords without their own
+                       | "voicing" "=" ("close" | "smooth")  // smooth: voice leading, minimal movement between chords
+                       | "voices" "=" NUMBER  // Notes per chord for smooth voicing, 2-6 (default: the chord's own)
+                       | "low" "=" NOTE_NAME  // Lowest note for smooth voicing (default: the octave's C)
+                       | "high" "=" NOTE_NAME  // Highest note for smooth voicing (default: an octave and a fifth above low)
+                       | "velocity_curve" "=" VELOCITY_CURVE
+                       | "velocity_range" "=" NUMBER
+                       | "probability" "=" NUMBER  // Chance (0-1] that each note plays
+                       | "seed" "=" NUMBER  // Random seed: the same seed repeats the same random choices
+
+// Each chord may carry its own octave: C:3 (bass register), Am:4
+progression_chords_array: "[" (progression_chord ("," SP progression_chord)*)? "]"
+progression_chord: chord_symbol (":" NUMBER)?
+
+// ---------- Walking bass: quarter-note line over a progression ----------
+walking_bass_call: "walking_bass" "(" walking_bass_params ")"
+
+walking_bass_params: walking_bass_named_params
+
+walking_bass_named_params: walking_bass_named_param ("," SP walking_bass_named_param)*
+walking_bass_named_param: "progression" "=" chords_array
+                        | "length" "=" NUMBER  // Total beats; default 4 beats (1 bar) per chord
+                        | "start" "=" NUMBER  // Explicit start time in beats
+                        | "velocity" "=" NUMBER
+                        | "octave" "=" NUMBER  // Octave of the first root, default 2 (D2 for Dm7)
 
 chords_array: "[" (chord_symbol ("," SP chord_symbol)*)? "]"
 
-// ---------- Chord symbol (supports Em, C, Am7, Cmaj7, etc.) ----------
-chord_symbol: CHORD_ROOT CHORD_QUALITY? CHORD_EXTENSION? CHORD_BASS?
+// ---------- Drum pattern: kick/snare/hat groove in a style ----------
+drum_pattern_call: "drum_pattern" "(" drum_pattern_params ")"
+
+drum_pattern_params: drum_pattern_named_params
+
+drum_pattern_named_params: drum_pattern_named_param ("," SP drum_pattern_named_param)*
+drum_pattern_named_param: "style" "=" DRUM_STYLE
+                        | "length" "=" NUMBER  // Total beats, default 4 (1 bar)
+                        | "swing" "=" NUMBER  // 0 = straight, 0.33 = triplet feel (must be below 1)
+                        | "velocity" "=" NUMBER
+                        | "start" "=" NUMBER  // Explicit start time in beats
+                        | "velocity_curve" "=" VELOCITY_CURVE
+                        | "velocity_range" "=" NUMBER
+                        | "probability" "=" NUMBER  // Chance (0-1] that each hit plays
+                        | "seed" "=" NUMBER  // Random seed: the same seed repeats the same random choices
+
+DRUM_STYLE: "\"house\"" | "\"techno\"" | "\"trap\"" | "\"rock\"" | "\"bossa\""
+
+// ---------- Section: one clip per track, all over the same bars ----------
+section_call: "section" "(" section_params ")"
+
+section_params: section_named_params
+
+section_named_params: section_named_param ("," SP section_named_param)*
+section_named_param: "name" "=" STRING  // Clip name, e.g. "Chorus"
+                   | "bars" "=" NUMBER  // Section length in bars, default 4
+                   | "bar" "=" NUMBER  // Bar the section starts at, default 1
+                   | "tracks" "=" section_tracks
+
+section_tracks: "[" section_part ("," SP section_part)* "]"
+section_part: "{" section_part_param ("," SP section_part_param)* "}"
+section_part_param: "track" "=" NUMBER  // 0-based track index (required)
+                  | "progression" "=" progression_chords_array
+                  | "walking_bass" "=" chords_array
+                  | "arpeggio" "=" chord_symbol
+                  | "chord" "=" chord_symbol
+                  | "drum_pattern" "=" DRUM_STYLE
+                  | "octave" "=" NUMBER
+                  | "velocity" "=" NUMBER
+                  | "note_duration" "=" NUMBER  // arpeggio parts
+                  | "rhythm" "=" STRING  // arpeggio and chord parts
+                  | "swing" "=" NUMBER  // drum_pattern parts
+                  | "velocity_curve" "=" VELOCITY_CURVE
+
+// ---------- Arrangement: a genre preset's song structure ----------
+arrangement_call: "arrangement" "(" arrangement_params ")"
+
+arrangement_params: arrangement_named_params
+
+arrangement_named_params: arrangement_named_param ("," SP arrangement_named_param)*
+arrangement_named_param: "genre" "=" ARRANGEMENT_GENRE
+                       | "bar" "=" NUMBER  // Bar the first section starts at, default 1
+                       | "section_bars" "=" NUMBER  // Give every section this length instead of the preset's
+                       | "tracks" "=" track_indices  // 0-based tracks to put an empty clip per section on
+
+track_indices: "[" NUMBER ("," SP NUMBER)* "]"
+
+ARRANGEMENT_GENRE: "\"pop\"" | "\"rock\"" | "\"edm\"" | "\"house\"" | "\"hiphop\"" | "\"jazz\""
+
+// ---------- Defaults: what later calls (and requests in the session) leave out ----------
+defaults_call: "defaults" "(" defaults_params ")"
+defaults_params: defaults_param ("," SP defaults_param)*
+defaults_param: "octave" "=" NUMBER  // Chord/arpeggio octave, 1-8 (default 4); bass parts move with it
+              | "velocity" "=" NUMBER  // 1-127 (default 100)
+              | "bars" "=" NUMBER  // Length of parts that don't give one (default 1)
+              | "time_signature" "=" STRING  // e.g. "3/4"; sets the beats per bar when the project has none
+
+// ---------- Note transforms: edit generated (or existing) notes ----------
+transform_call: quantize_call
+              | humanize_call
+              | groove_call
+
+quantize_call: "quantize" "(" quantize_params? ")"
+quantize_params: quantize_param ("," SP quantize_param)*
+quantize_param: "grid" "=" NUMBER  // Grid in beats: 0.25=16th, 0.5=8th, 1=quarter (default 0.25)
+              | "strength" "=" NUMBER  // 0-1, how far notes move toward the grid (default 1)
+
+humanize_call: "humanize" "(" humanize_params? ")"
+humanize_params: humanize_param ("," SP humanize_param)*
+humanize_param: "timing_ms" "=" NUMBER  // Max timing shift either way in milliseconds (default 10)
+              | "velocity" "=" NUMBER  // Max velocity change either way (default 8)
+              | "seed" "=" NUMBER  // Random seed so the same offsets are used every time
+
+groove_call: "groove" "(" groove_params ")"
+groove_params: groove_param ("," SP groove_param)*
+groove_param: "template" "=" GROOVE_TEMPLATE
+            | "from_track" "=" NUMBER  // 0-based track whose notes the groove is taken from
+            | "grid" "=" NUMBER  // Step in beats to measure from_track on: 0.5 for swung 8ths (default 0.25)
+            | "amount" "=" NUMBER  // 0-1, how much of the groove to apply (default 1)
+
+GROOVE_TEMPLATE: "\"swing\"" | "\"shuffle\"" | "\"swing16\""
+
+// ---------- Chord symbol (Em, C, Am7, Cmaj7, C13b9, G7#5, Fsus2, Dm7b5, Bb/D, etc.) ----------
+chord_symbol: CHORD_ROOT CHORD_QUALITY? CHORD_EXTENSION? CHORD_ALTERATION* CHORD_BASS?
 CHORD_ROOT: /[A-G][#b]?/
-CHORD_QUALITY: "m" | "dim" | "aug" | "sus2" | "sus4"
-CHORD_EXTENSION: /[0-9]+/ | "maj7" | "min7" | "dim7" | "aug7" | "add9" | "add11" | "add13"
+CHORD_QUALITY: "mMaj" | "maj" | "min" | "m" | "dim" | "aug" | "+"
+CHORD_EXTENSION: "13" | "11" | "69" | "9" | "7" | "6" | "5"
+CHORD_ALTERATION: "sus2" | "sus4" | "sus" | "add2" | "add4" | "add9" | "add11" | "add13"
+                | "b5" | "#5" | "b9" | "#9" | "#11" | "b13" | "alt" | "no3" | "no5"
 CHORD_BASS: "/" CHORD_ROOT
 
+// ---------- Dynamics ----------
+// crescendo/decrescendo: ramp over the notes, accent_on_beat: softer off-beats, random_range: seeded variation
+VELOCITY_CURVE: "crescendo" | "decrescendo" | "accent_on_beat" | "random_range"
+
 // ---------- Terminals ----------
 SP: " "+
 STRING: /"[^"]*"/
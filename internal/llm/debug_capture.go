@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/debugcapture"
+)
+
+// captureCall keeps a raw provider call, started at start, in the debug capture buffer when
+// DEBUG_CAPTURE is on. statusCode is 0 and response nil when the call failed without one.
+func captureCall(
+	ctx context.Context, provider string, req *http.Request, start time.Time,
+	statusCode int, response []byte, err error,
+) {
+	if !debugcapture.Enabled() {
+		return
+	}
+	var payload []byte
+	if req.GetBody != nil {
+		if body, bodyErr := req.GetBody(); bodyErr == nil {
+			payload, _ = io.ReadAll(body)
+		}
+	}
+	capture := debugcapture.Capture{
+		Provider:   provider,
+		URL:        req.URL.String(),
+		StatusCode: statusCode,
+		DurationMS: time.Since(start).Milliseconds(),
+		Request:    payload,
+		Response:   response,
+	}
+	if err != nil {
+		capture.Error = err.Error()
+	}
+	debugcapture.Record(ctx, capture)
+}
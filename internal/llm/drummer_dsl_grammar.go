@@ -13,6 +13,7 @@ func GetDrummerDSLGrammar() string {
 // SYNTAX:
 //   pattern(drum=kick, grid="x---x---x---x---")
 //   pattern(drum=snare, grid="----x-------x---", velocity=100)
+//   pattern(drum=hat, grid="xxxxxxxxxxxxxxxx", probability=0.8, seed=7)
 //
 // GRID NOTATION (each char = 1 16th note):
 //   "x" = hit (velocity 100)
@@ -34,6 +35,8 @@ pattern_named_params: pattern_named_param ("," SP pattern_named_param)*
 pattern_named_param: "drum" "=" DRUM_NAME
                    | "grid" "=" STRING
                    | "velocity" "=" NUMBER
+                   | "probability" "=" NUMBER  // Chance (0-1] that each hit plays; 1 = every hit
+                   | "seed" "=" NUMBER  // Random seed so the same hits are dropped every time
 
 // ---------- Drum names ----------
 DRUM_NAME: "kick" | "snare" | "snare_rim" | "snare_xstick"
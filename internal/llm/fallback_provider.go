@@ -0,0 +1,307 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/getsentry/sentry-go"
+	"github.com/openai/openai-go"
+)
+
+const (
+	defaultMaxRetries     = 2
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 8 * time.Second
+)
+
+// RetryPolicy controls how often each provider in a FallbackProvider is retried
+type RetryPolicy struct {
+	MaxRetries     int           // Retries per provider after the first attempt
+	InitialBackoff time.Duration // Wait before the first retry; doubles on each retry
+	MaxBackoff     time.Duration // Upper bound for a single wait
+}
+
+// DefaultRetryPolicy retries each provider twice, backing off 500ms then 1s
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     defaultMaxRetries,
+		InitialBackoff: defaultInitialBackoff,
+		MaxBackoff:     defaultMaxBackoff,
+	}
+}
+
+// backoff returns the wait before the given retry (1-based)
+func (r RetryPolicy) backoff(retry int) time.Duration {
+	wait := r.InitialBackoff
+	for i := 1; i < retry; i++ {
+		wait *= 2
+		if r.MaxBackoff > 0 && wait >= r.MaxBackoff {
+			return r.MaxBackoff
+		}
+	}
+	return wait
+}
+
+// ProviderRetryStats counts retries and failovers for one provider in a fallback chain
+type ProviderRetryStats struct {
+	Provider  string `json:"provider"`
+	Position  int    `json:"position"`  // 0 is the primary provider
+	Requests  int64  `json:"requests"`  // Requests routed to this provider
+	Retries   int64  `json:"retries"`   // Retries after 429/5xx/timeout errors
+	Successes int64  `json:"successes"` // Requests this provider completed
+	Failovers int64  `json:"failovers"` // Requests handed to the next provider after retries ran out
+	Failures  int64  `json:"failures"`  // Requests failed with a non-retryable error
+}
+
+// FallbackProvider wraps an ordered list of providers. Each provider is retried with
+// exponential backoff on rate limits, server errors and timeouts before the request
+// moves on to the next provider. Other errors fail the request immediately.
+type FallbackProvider struct {
+	providers []Provider
+	policy    RetryPolicy
+
+	mu    sync.Mutex
+	stats []ProviderRetryStats
+}
+
+// NewFallbackProvider creates a provider that tries providers in order
+func NewFallbackProvider(policy RetryPolicy, providers ...Provider) *FallbackProvider {
+	stats := make([]ProviderRetryStats, len(providers))
+	for i, provider := range providers {
+		stats[i] = ProviderRetryStats{Provider: provider.Name(), Position: i}
+	}
+	return &FallbackProvider{
+		providers: providers,
+		policy:    policy,
+		stats:     stats,
+	}
+}
+
+// retryTotals keeps process-wide counters per provider name and chain position. Providers
+// are built per request, so the totals outlive the chains that recorded them.
+var retryTotals struct {
+	sync.Mutex
+	stats map[string]*ProviderRetryStats
+}
+
+// RetryMetrics returns retry and failover counters summed across all fallback chains,
+// one entry per provider name and chain position
+func RetryMetrics() []ProviderRetryStats {
+	retryTotals.Lock()
+	totals := make([]ProviderRetryStats, 0, len(retryTotals.stats))
+	for _, stats := range retryTotals.stats {
+		totals = append(totals, *stats)
+	}
+	retryTotals.Unlock()
+
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Position != totals[j].Position {
+			return totals[i].Position < totals[j].Position
+		}
+		return totals[i].Provider < totals[j].Provider
+	})
+	return totals
+}
+
+// Name returns the primary provider's name
+func (f *FallbackProvider) Name() string {
+	if len(f.providers) == 0 {
+		return "fallback"
+	}
+	return f.providers[0].Name()
+}
+
+// RetryStats returns a snapshot of the retry and failover counters for each provider
+func (f *FallbackProvider) RetryStats() []ProviderRetryStats {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]ProviderRetryStats(nil), f.stats...)
+}
+
+// Generate tries each provider in order until one succeeds
+func (f *FallbackProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	return f.run(ctx, func(provider Provider) (*GenerationResponse, bool, error) {
+		resp, err := provider.Generate(ctx, request)
+		return resp, true, err
+	})
+}
+
+// GenerateStream tries each provider in order until one succeeds.
+// Once a provider has streamed output to the callback the request is not retried,
+// since the client would otherwise receive duplicated text.
+func (f *FallbackProvider) GenerateStream(
+	ctx context.Context,
+	request *GenerationRequest,
+	callback StreamCallback,
+) (*GenerationResponse, error) {
+	return f.run(ctx, func(provider Provider) (*GenerationResponse, bool, error) {
+		streamed := false
+		resp, err := provider.GenerateStream(ctx, request, func(event StreamEvent) error {
+			if event.Type == "text_delta" {
+				streamed = true
+			}
+			if callback == nil {
+				return nil
+			}
+			return callback(event)
+		})
+		return resp, !streamed, err
+	})
+}
+
+// run drives the retry/fallback loop. call reports whether a failed attempt may be retried.
+func (f *FallbackProvider) run(
+	ctx context.Context,
+	call func(provider Provider) (*GenerationResponse, bool, error),
+) (*GenerationResponse, error) {
+	if len(f.providers) == 0 {
+		return nil, fmt.Errorf("no LLM providers configured")
+	}
+
+	var lastErr error
+	for i, provider := range f.providers {
+		f.record(i, func(s *ProviderRetryStats) { s.Requests++ })
+
+		for attempt := 0; ; attempt++ {
+			resp, canRetry, err := call(provider)
+			if err == nil {
+				f.record(i, func(s *ProviderRetryStats) { s.Successes++ })
+				if i > 0 {
+					logger.Printf(ctx, "✅ LLM FALLBACK: %s (position %d) served the request", provider.Name(), i)
+				}
+				return resp, nil
+			}
+			lastErr = err
+
+			if !canRetry || !IsRetryableError(err) || ctx.Err() != nil {
+				f.record(i, func(s *ProviderRetryStats) { s.Failures++ })
+				return nil, err
+			}
+			if attempt >= f.policy.MaxRetries {
+				break
+			}
+
+			wait := f.policy.backoff(attempt + 1)
+			f.record(i, func(s *ProviderRetryStats) { s.Retries++ })
+			logger.Printf(ctx, "🔁 LLM RETRY: %s attempt %d/%d failed (%v), retrying in %v",
+				provider.Name(), attempt+1, f.policy.MaxRetries+1, err, wait)
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		f.record(i, func(s *ProviderRetryStats) { s.Failovers++ })
+		if i+1 < len(f.providers) {
+			next := f.providers[i+1].Name()
+			logger.Printf(ctx, "⚠️  LLM FAILOVER: %s exhausted retries (%v), falling back to %s", provider.Name(), lastErr, next)
+			sentry.AddBreadcrumb(&sentry.Breadcrumb{
+				Category: "llm",
+				Message:  fmt.Sprintf("LLM failover: %s -> %s", provider.Name(), next),
+				Level:    sentry.LevelWarning,
+				Data: map[string]interface{}{
+					"from_provider": provider.Name(),
+					"from_position": i,
+					"to_provider":   next,
+					"error":         lastErr.Error(),
+				},
+			})
+		}
+	}
+
+	return nil, fmt.Errorf("all %d LLM providers failed: %w", len(f.providers), lastErr)
+}
+
+// record updates the stats for the provider at position i, and the process-wide totals
+func (f *FallbackProvider) record(i int, update func(s *ProviderRetryStats)) {
+	f.mu.Lock()
+	update(&f.stats[i])
+	name := f.stats[i].Provider
+	f.mu.Unlock()
+
+	key := fmt.Sprintf("%d:%s", i, name)
+	retryTotals.Lock()
+	defer retryTotals.Unlock()
+	total, ok := retryTotals.stats[key]
+	if !ok {
+		if retryTotals.stats == nil {
+			retryTotals.stats = map[string]*ProviderRetryStats{}
+		}
+		total = &ProviderRetryStats{Provider: name, Position: i}
+		retryTotals.stats[key] = total
+	}
+	update(total)
+}
+
+// IsRetryableError reports whether err is a rate limit (429), server error (5xx) or timeout
+func IsRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return isRetryableStatus(apiErr.StatusCode)
+	}
+
+	var openaiErr *openai.Error
+	if errors.As(err, &openaiErr) {
+		return isRetryableStatus(openaiErr.StatusCode)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	// Streaming errors only carry the provider's message
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "rate limit") || strings.Contains(message, "overloaded")
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError
+}
+
+// modelOverrideProvider sends every request with a fixed model, e.g. a cheaper model as a fallback
+type modelOverrideProvider struct {
+	Provider
+	model string
+}
+
+// WithModel returns a provider that replaces the request model with model
+func WithModel(provider Provider, model string) Provider {
+	return &modelOverrideProvider{Provider: provider, model: model}
+}
+
+func (m *modelOverrideProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	return m.Provider.Generate(ctx, m.withModel(request))
+}
+
+func (m *modelOverrideProvider) GenerateStream(
+	ctx context.Context,
+	request *GenerationRequest,
+	callback StreamCallback,
+) (*GenerationResponse, error) {
+	return m.Provider.GenerateStream(ctx, m.withModel(request), callback)
+}
+
+func (m *modelOverrideProvider) withModel(request *GenerationRequest) *GenerationRequest {
+	overridden := *request
+	overridden.Model = m.model
+	return &overridden
+}
@@ -0,0 +1,230 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRetryPolicy() RetryPolicy {
+	return RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+}
+
+// failingProvider returns errs in order, then succeeds with output
+func failingProvider(name, output string, calls *int, errs ...error) *funcProvider {
+	return &funcProvider{
+		name: name,
+		generateFunc: func(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+			*calls++
+			if *calls <= len(errs) {
+				return nil, errs[*calls-1]
+			}
+			return &GenerationResponse{RawOutput: output}, nil
+		},
+	}
+}
+
+func TestFallbackProvider_RetriesThenSucceeds(t *testing.T) {
+	calls := 0
+	primary := failingProvider("openai", "ok", &calls,
+		&APIError{StatusCode: 429, Body: "rate limited"},
+		fmt.Errorf("openai request failed: %w", &APIError{StatusCode: 503}),
+	)
+
+	fallback := NewFallbackProvider(testRetryPolicy(), primary)
+	resp, err := fallback.Generate(context.Background(), &GenerationRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "ok", resp.RawOutput)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, "openai", fallback.Name())
+	assert.Equal(t, []ProviderRetryStats{
+		{Provider: "openai", Position: 0, Requests: 1, Retries: 2, Successes: 1},
+	}, fallback.RetryStats())
+}
+
+func TestFallbackProvider_FailsOverAfterRetries(t *testing.T) {
+	primaryCalls, secondaryCalls := 0, 0
+	rateLimited := &APIError{StatusCode: 429}
+	primary := failingProvider("openai", "primary", &primaryCalls, rateLimited, rateLimited, rateLimited)
+	secondary := failingProvider("anthropic", "secondary", &secondaryCalls)
+
+	fallback := NewFallbackProvider(testRetryPolicy(), primary, secondary)
+	resp, err := fallback.Generate(context.Background(), &GenerationRequest{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "secondary", resp.RawOutput)
+	assert.Equal(t, 3, primaryCalls)
+	assert.Equal(t, 1, secondaryCalls)
+	assert.Equal(t, []ProviderRetryStats{
+		{Provider: "openai", Position: 0, Requests: 1, Retries: 2, Failovers: 1},
+		{Provider: "anthropic", Position: 1, Requests: 1, Successes: 1},
+	}, fallback.RetryStats())
+
+	// Process-wide totals include this chain
+	var primaryFailovers int64
+	for _, stats := range RetryMetrics() {
+		if stats.Provider == "openai" && stats.Position == 0 {
+			primaryFailovers = stats.Failovers
+		}
+	}
+	assert.GreaterOrEqual(t, primaryFailovers, int64(1))
+}
+
+func TestRetryMetrics_SumsChainsByPosition(t *testing.T) {
+	// Providers are built per request, so each run uses a fresh chain
+	for range 3 {
+		calls := 0
+		primary := failingProvider("metrics-test", "ok", &calls, &APIError{StatusCode: 503})
+		_, err := NewFallbackProvider(testRetryPolicy(), primary).Generate(context.Background(), &GenerationRequest{})
+		require.NoError(t, err)
+	}
+
+	var got []ProviderRetryStats
+	for _, stats := range RetryMetrics() {
+		if stats.Provider == "metrics-test" {
+			got = append(got, stats)
+		}
+	}
+	assert.Equal(t, []ProviderRetryStats{
+		{Provider: "metrics-test", Position: 0, Requests: 3, Retries: 3, Successes: 3},
+	}, got)
+}
+
+func TestFallbackProvider_NonRetryableErrorFailsImmediately(t *testing.T) {
+	primaryCalls, secondaryCalls := 0, 0
+	badRequest := &APIError{StatusCode: 400, Body: "invalid grammar"}
+	primary := failingProvider("openai", "primary", &primaryCalls, badRequest)
+	secondary := failingProvider("anthropic", "secondary", &secondaryCalls)
+
+	fallback := NewFallbackProvider(testRetryPolicy(), primary, secondary)
+	_, err := fallback.Generate(context.Background(), &GenerationRequest{})
+	require.Error(t, err)
+
+	assert.ErrorIs(t, err, badRequest)
+	assert.Equal(t, 1, primaryCalls)
+	assert.Equal(t, 0, secondaryCalls)
+	assert.Equal(t, int64(1), fallback.RetryStats()[0].Failures)
+}
+
+func TestFallbackProvider_AllProvidersFail(t *testing.T) {
+	primaryCalls, secondaryCalls := 0, 0
+	serverError := &APIError{StatusCode: 500}
+	primary := failingProvider("openai", "", &primaryCalls, serverError, serverError, serverError)
+	secondary := failingProvider("openai", "", &secondaryCalls, serverError, serverError, serverError)
+
+	fallback := NewFallbackProvider(testRetryPolicy(), primary, secondary)
+	_, err := fallback.Generate(context.Background(), &GenerationRequest{})
+	require.Error(t, err)
+
+	assert.Contains(t, err.Error(), "all 2 LLM providers failed")
+	assert.Equal(t, 3, primaryCalls)
+	assert.Equal(t, 3, secondaryCalls)
+}
+
+func TestFallbackProvider_StreamNotRetriedAfterOutput(t *testing.T) {
+	calls := 0
+	primary := &funcProvider{
+		name: "openai",
+		generateStreamFunc: func(ctx context.Context, request *GenerationRequest, callback StreamCallback) (*GenerationResponse, error) {
+			calls++
+			_ = callback(StreamEvent{Type: "text_delta", Message: "track("})
+			return nil, &APIError{StatusCode: 500}
+		},
+	}
+
+	var deltas []string
+	fallback := NewFallbackProvider(testRetryPolicy(), primary)
+	_, err := fallback.GenerateStream(context.Background(), &GenerationRequest{}, func(event StreamEvent) error {
+		deltas = append(deltas, event.Message)
+		return nil
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, []string{"track("}, deltas)
+}
+
+func TestFallbackProvider_StreamRetriedBeforeOutput(t *testing.T) {
+	calls := 0
+	primary := &funcProvider{
+		name: "openai",
+		generateStreamFunc: func(ctx context.Context, request *GenerationRequest, callback StreamCallback) (*GenerationResponse, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("stream error: Overloaded")
+			}
+			_ = callback(StreamEvent{Type: "text_delta", Message: "track()"})
+			return &GenerationResponse{RawOutput: "track()"}, nil
+		},
+	}
+
+	fallback := NewFallbackProvider(testRetryPolicy(), primary)
+	resp, err := fallback.GenerateStream(context.Background(), &GenerationRequest{}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "track()", resp.RawOutput)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "rate limit", err: &APIError{StatusCode: 429}, want: true},
+		{name: "server error", err: &APIError{StatusCode: 502}, want: true},
+		{name: "wrapped server error", err: fmt.Errorf("request failed: %w", &APIError{StatusCode: 500}), want: true},
+		{name: "bad request", err: &APIError{StatusCode: 400}, want: false},
+		{name: "unauthorized", err: &APIError{StatusCode: 401}, want: false},
+		{name: "deadline exceeded", err: fmt.Errorf("call: %w", context.DeadlineExceeded), want: true},
+		{name: "overloaded stream error", err: errors.New("stream error: Overloaded"), want: true},
+		{name: "parse error", err: errors.New("CFG grammar was configured but LLM did not use CFG tool"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsRetryableError(tt.err))
+		})
+	}
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, policy.backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.backoff(2))
+	assert.Equal(t, 300*time.Millisecond, policy.backoff(3))
+	assert.Equal(t, 300*time.Millisecond, policy.backoff(6))
+}
+
+func TestWithModel(t *testing.T) {
+	var gotModel string
+	inner := &funcProvider{
+		name: "openai",
+		generateFunc: func(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+			gotModel = request.Model
+			return &GenerationResponse{}, nil
+		},
+	}
+
+	request := &GenerationRequest{Model: "gpt-5.1"}
+	provider := WithModel(inner, "gpt-5-mini")
+	_, err := provider.Generate(context.Background(), request)
+	require.NoError(t, err)
+
+	assert.Equal(t, "gpt-5-mini", gotModel)
+	assert.Equal(t, "gpt-5.1", request.Model)
+	assert.Equal(t, "openai", provider.Name())
+
+	specProvider, err := NewProviderFactory("key").GetProviderBySpec("openai:gpt-5-mini")
+	require.NoError(t, err)
+	assert.IsType(t, &modelOverrideProvider{}, specProvider)
+}
@@ -0,0 +1,163 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// grammarDefinitionPattern matches a rule or terminal definition at the start of a line,
+// e.g. "start:", "?expr:", "NUMBER:" or "rule.2:"
+var grammarDefinitionPattern = regexp.MustCompile(`^[?!]?([a-zA-Z_][a-zA-Z0-9_]*)(\.-?\d+)?\s*:`)
+
+// ValidateGrammar performs a structural check of a Lark grammar.
+// The local Lark parser does not compile grammars - only the LLM provider does, at request
+// time - so this catches the mistakes that would otherwise surface there: a missing start rule,
+// duplicate definitions, unbalanced brackets or quotes, and references to undefined rules.
+// name identifies the grammar in the returned error.
+func ValidateGrammar(name, grammar string) error {
+	if strings.TrimSpace(grammar) == "" {
+		return fmt.Errorf("%s grammar is empty", name)
+	}
+
+	defined := make(map[string]int) // name -> line number
+	references := make(map[string]int)
+	current := ""
+	depth := 0
+
+	for i, rawLine := range strings.Split(grammar, "\n") {
+		lineNum := i + 1
+		line := rawLine
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "%") {
+			continue
+		}
+
+		// A definition starts at column 0; indented lines continue the previous definition
+		if m := grammarDefinitionPattern.FindStringSubmatchIndex(line); m != nil {
+			if current != "" && depth != 0 {
+				return fmt.Errorf("%s grammar: unbalanced brackets in definition of %q", name, current)
+			}
+			current = line[m[2]:m[3]]
+			if prev, ok := defined[current]; ok {
+				return fmt.Errorf("%s grammar: %q defined twice (lines %d and %d)", name, current, prev, lineNum)
+			}
+			defined[current] = lineNum
+			depth = 0
+			line = line[m[1]:]
+		} else if current == "" {
+			return fmt.Errorf("%s grammar: line %d is not part of any definition: %s", name, lineNum, trimmed)
+		}
+
+		var err error
+		depth, err = scanGrammarExpression(line, depth, lineNum, references)
+		if err != nil {
+			return fmt.Errorf("%s grammar: %w", name, err)
+		}
+	}
+
+	if current != "" && depth != 0 {
+		return fmt.Errorf("%s grammar: unbalanced brackets in definition of %q", name, current)
+	}
+	if _, ok := defined["start"]; !ok {
+		return fmt.Errorf("%s grammar has no start rule", name)
+	}
+
+	var undefined []string
+	for ref, lineNum := range references {
+		if _, ok := defined[ref]; !ok {
+			undefined = append(undefined, fmt.Sprintf("%s (line %d)", ref, lineNum))
+		}
+	}
+	if len(undefined) > 0 {
+		sort.Strings(undefined)
+		return fmt.Errorf("%s grammar references undefined rules: %s", name, strings.Join(undefined, ", "))
+	}
+
+	return nil
+}
+
+// scanGrammarExpression walks one line of a rule body, recording identifier references
+// and tracking bracket depth. String literals, regex literals and comments are skipped.
+func scanGrammarExpression(line string, depth, lineNum int, references map[string]int) (int, error) {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == '"':
+			end := closingDelimiter(line, i+1, '"')
+			if end < 0 {
+				return depth, fmt.Errorf("unterminated string literal on line %d", lineNum)
+			}
+			i = end
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return depth, nil // Trailing comment
+		case c == '/':
+			end := closingDelimiter(line, i+1, '/')
+			if end < 0 {
+				return depth, fmt.Errorf("unterminated regex literal on line %d", lineNum)
+			}
+			i = end
+			for i+1 < len(line) && isGrammarFlag(line[i+1]) {
+				i++
+			}
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+			if depth < 0 {
+				return depth, fmt.Errorf("unexpected %q on line %d", c, lineNum)
+			}
+		case c == '-' && i+1 < len(line) && line[i+1] == '>':
+			// Alias: the name after -> is not a reference
+			i += 2
+			for i < len(line) && line[i] == ' ' {
+				i++
+			}
+			for i < len(line) && isGrammarIdentChar(line[i]) {
+				i++
+			}
+			i--
+		case isGrammarIdentStart(c):
+			start := i
+			for i+1 < len(line) && isGrammarIdentChar(line[i+1]) {
+				i++
+			}
+			ident := line[start : i+1]
+			if _, seen := references[ident]; !seen {
+				references[ident] = lineNum
+			}
+		case c >= '0' && c <= '9':
+			for i+1 < len(line) && line[i+1] >= '0' && line[i+1] <= '9' {
+				i++
+			}
+		}
+	}
+	return depth, nil
+}
+
+// closingDelimiter returns the index of the next unescaped delimiter at or after start, or -1.
+func closingDelimiter(line string, start int, delim byte) int {
+	for i := start; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] == delim {
+			return i
+		}
+	}
+	return -1
+}
+
+func isGrammarIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isGrammarIdentChar(c byte) bool {
+	return isGrammarIdentStart(c) || (c >= '0' && c <= '9')
+}
+
+func isGrammarFlag(c byte) bool {
+	return c == 'i' || c == 'm' || c == 's' || c == 'x' || c == 'u' || c == 'l'
+}
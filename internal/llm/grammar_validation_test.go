@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateGrammar_BuiltInGrammars(t *testing.T) {
+	grammars := map[string]string{
+		"Arranger DSL": GetArrangerDSLGrammar(),
+		"Drummer DSL":  GetDrummerDSLGrammar(),
+		"JSFX":         GetJSFXGrammar(),
+		"MAGDA DSL":    GetMagdaDSLGrammar(),
+		"Musical DSL":  GetMusicalDSLGrammar(),
+		"Template DSL": GetTemplateDSLGrammar(),
+	}
+
+	for name, grammar := range grammars {
+		t.Run(name, func(t *testing.T) {
+			assert.NoError(t, ValidateGrammar(name, grammar))
+		})
+	}
+}
+
+func TestValidateGrammar_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		grammar     string
+		errContains string
+	}{
+		{
+			name:        "empty grammar",
+			grammar:     "   ",
+			errContains: "Test grammar is empty",
+		},
+		{
+			name:        "missing start rule",
+			grammar:     `rule: "a"`,
+			errContains: "no start rule",
+		},
+		{
+			name:        "undefined rule",
+			grammar:     "start: call\ncall: \"call\" \"(\" params \")\"",
+			errContains: "undefined rules: params (line 2)",
+		},
+		{
+			name:        "unbalanced brackets",
+			grammar:     "start: (\"a\" | \"b\"\nNUMBER: /\\d+/",
+			errContains: "unbalanced brackets in definition of \"start\"",
+		},
+		{
+			name:        "duplicate definition",
+			grammar:     "start: \"a\"\nstart: \"b\"",
+			errContains: "defined twice (lines 1 and 2)",
+		},
+		{
+			name:        "unterminated string",
+			grammar:     `start: "a`,
+			errContains: "unterminated string literal on line 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateGrammar("Test", tt.grammar)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.errContains)
+			}
+		})
+	}
+}
+
+func TestValidateGrammar_IgnoresCommentsRegexAndAliases(t *testing.T) {
+	grammar := `
+// Comment with an undefined_rule reference
+start: item+ -> items  // trailing comment mentioning other_rule
+     | "(" item ")"
+item: WORD
+WORD: /[a-z_]+(undefined)?/i
+%ignore " "
+`
+	assert.NoError(t, ValidateGrammar("Test", grammar))
+}
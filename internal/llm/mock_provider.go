@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	providerNameMock = "mock"
+	// mockCharsPerToken approximates token counts for the usage the mock reports
+	mockCharsPerToken = 4
+	// planRequestMarker introduces the question in the orchestrator's planning prompt
+	planRequestMarker = `REQUEST: "`
+	historyPrefix     = "Earlier requests in this conversation"
+)
+
+// MockResponse is a canned reply. Output is returned for requests to Tool (a CFG tool or
+// output schema name; empty matches any) whose question contains Match (case-insensitive;
+// empty matches any).
+type MockResponse struct {
+	Tool   string `json:"tool,omitempty"`
+	Match  string `json:"match,omitempty"`
+	Output string `json:"output"`
+}
+
+// DefaultMockResponses answer the orchestrator's planning call (no musical agents) and a few
+// DAW requests used in examples and tests
+var DefaultMockResponses = []MockResponse{
+	{
+		Tool:   "MusicalAgentPlan",
+		Output: `{"needsArranger": false, "needsDrummer": false, "arrangerTask": "", "drummerTask": "", "dawTasks": []}`,
+	},
+	{Tool: "ScopeCheck", Output: `{"inScope": true, "topic": ""}`},
+	{Tool: "magda_dsl", Match: "called bass with serum", Output: `track(instrument="Serum", name="Bass")`},
+	{Tool: "magda_dsl", Match: "called 'drums'", Output: `track(name="Drums")`},
+	{Tool: "magda_dsl", Match: "add reverb", Output: `track(id=1).add_fx(fxname="ReaVerbate")`},
+	{Tool: "magda_dsl", Match: "create a track", Output: `track()`},
+	{Tool: "drummer_dsl", Output: `pattern(drum=kick, grid="x---x---x---x---")`},
+	{Tool: "arranger_dsl", Output: `arpeggio(symbol=Em, note_duration=0.25)`},
+}
+
+// MockProvider answers from a table of canned responses without any network call, so the
+// HTTP → parser → actions pipeline can run hermetically in tests and CI (LLM_PROVIDER=mock).
+// Responses are tried in order, then DefaultMockResponses; the first match wins.
+type MockProvider struct {
+	responses []MockResponse
+
+	mu       sync.Mutex
+	requests []*GenerationRequest
+}
+
+// NewMockProvider creates a mock provider that tries responses before DefaultMockResponses
+func NewMockProvider(responses ...MockResponse) *MockProvider {
+	all := make([]MockResponse, 0, len(responses)+len(DefaultMockResponses))
+	all = append(all, responses...)
+	all = append(all, DefaultMockResponses...)
+	return &MockProvider{responses: all}
+}
+
+// LoadMockResponses reads a JSON array of MockResponse from path (LLM_MOCK_RESPONSES)
+func LoadMockResponses(path string) ([]MockResponse, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock responses: %w", err)
+	}
+	var responses []MockResponse
+	if err := json.Unmarshal(data, &responses); err != nil {
+		return nil, fmt.Errorf("invalid mock responses in %s: %w", path, err)
+	}
+	return responses, nil
+}
+
+// Name returns the provider name
+func (p *MockProvider) Name() string {
+	return providerNameMock
+}
+
+// Requests returns the requests the provider has received, oldest first
+func (p *MockProvider) Requests() []*GenerationRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*GenerationRequest(nil), p.requests...)
+}
+
+// Generate returns the first matching canned response. Like a real provider, it fails once
+// ctx is done.
+func (p *MockProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, request)
+	p.mu.Unlock()
+
+	tool := mockTool(request)
+	question := mockQuestion(request)
+	for _, response := range p.responses {
+		if response.Tool != "" && response.Tool != tool {
+			continue
+		}
+		if response.Match != "" && !strings.Contains(strings.ToLower(question), strings.ToLower(response.Match)) {
+			continue
+		}
+		return &GenerationResponse{
+			RawOutput: response.Output,
+			Usage:     mockUsage(request, response.Output),
+		}, nil
+	}
+	return nil, fmt.Errorf("mock provider has no response for %s request %q", tool, question)
+}
+
+// GenerateStream emits the matching response line by line as text deltas
+func (p *MockProvider) GenerateStream(
+	ctx context.Context, request *GenerationRequest, callback StreamCallback,
+) (*GenerationResponse, error) {
+	if callback != nil {
+		_ = callback(StreamEvent{Type: "started", Message: "Starting generation..."})
+	}
+	response, err := p.Generate(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if callback != nil {
+		for _, line := range strings.SplitAfter(response.RawOutput, "\n") {
+			if line == "" {
+				continue
+			}
+			if err := callback(StreamEvent{Type: "text_delta", Message: line}); err != nil {
+				return nil, err
+			}
+		}
+		_ = callback(StreamEvent{Type: "completed", Message: "Generation complete"})
+	}
+	return response, nil
+}
+
+// mockTool names what the request asks for: its CFG tool, else its output schema
+func mockTool(request *GenerationRequest) string {
+	switch {
+	case request.CFGGrammar != nil:
+		return request.CFGGrammar.ToolName
+	case request.OutputSchema != nil:
+		return request.OutputSchema.Name
+	}
+	return ""
+}
+
+// mockQuestion finds the user's request in the input: the quoted REQUEST of a planning
+// prompt, else the first user message that isn't conversation history
+func mockQuestion(request *GenerationRequest) string {
+	var first string
+	for _, message := range request.InputArray {
+		if role, _ := message["role"].(string); role != "user" {
+			continue
+		}
+		content, _ := message["content"].(string)
+		if _, after, ok := strings.Cut(content, planRequestMarker); ok {
+			if question, _, ok := strings.Cut(after, `"`); ok {
+				return question
+			}
+		}
+		if first == "" && !strings.HasPrefix(content, historyPrefix) {
+			first = content
+		}
+	}
+	return first
+}
+
+// mockUsage estimates token counts from the prompt and output lengths
+func mockUsage(request *GenerationRequest, output string) map[string]any {
+	inputChars := len(request.SystemPrompt)
+	for _, message := range request.InputArray {
+		content, _ := message["content"].(string)
+		inputChars += len(content)
+	}
+	input := (inputChars + mockCharsPerToken - 1) / mockCharsPerToken
+	outputTokens := (len(output) + mockCharsPerToken - 1) / mockCharsPerToken
+	return map[string]any{
+		"input_tokens":  input,
+		"output_tokens": outputTokens,
+		"total_tokens":  input + outputTokens,
+	}
+}
@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dslRequest(tool string, messages ...string) *GenerationRequest {
+	request := &GenerationRequest{CFGGrammar: &CFGConfig{ToolName: tool}}
+	for _, content := range messages {
+		request.InputArray = append(request.InputArray, map[string]any{"role": "user", "content": content})
+	}
+	return request
+}
+
+func TestMockProvider_Generate(t *testing.T) {
+	provider := NewMockProvider(
+		MockResponse{Tool: "magda_dsl", Match: "mute the drums", Output: `filter(tracks, track.name == "Drums").set_track(mute=true)`},
+		MockResponse{Tool: "MusicalAgentPlan", Match: "breakbeat", Output: `{"needsDrummer": true, "drummerTask": "a breakbeat"}`},
+	)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		request *GenerationRequest
+		want    string
+	}{
+		{
+			name:    "table entry, case-insensitive",
+			request: dslRequest("magda_dsl", "Mute the drums please", "Current REAPER state: map[]"),
+			want:    `filter(tracks, track.name == "Drums").set_track(mute=true)`,
+		},
+		{
+			name:    "history is not the question",
+			request: dslRequest("magda_dsl", historyPrefix+": mute the drums", "create a track"),
+			want:    `track()`,
+		},
+		{
+			name: "planning prompt matches on its REQUEST only",
+			request: &GenerationRequest{
+				OutputSchema: &OutputSchema{Name: "MusicalAgentPlan"},
+				InputArray: []map[string]any{{"role": "user", "content": `EXAMPLES: "add a breakbeat pattern"` +
+					"\nREQUEST: \"add a breakbeat\"\n"}},
+			},
+			want: `{"needsDrummer": true, "drummerTask": "a breakbeat"}`,
+		},
+		{
+			name: "planning prompt falls back to no musical agents",
+			request: &GenerationRequest{
+				OutputSchema: &OutputSchema{Name: "MusicalAgentPlan"},
+				InputArray: []map[string]any{{"role": "user", "content": `EXAMPLES: "add a breakbeat pattern"` +
+					"\nREQUEST: \"mute track 2\"\n"}},
+			},
+			want: DefaultMockResponses[0].Output,
+		},
+		{
+			name:    "default for another agent",
+			request: dslRequest("drummer_dsl", "a rock beat"),
+			want:    `pattern(drum=kick, grid="x---x---x---x---")`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := provider.Generate(ctx, tt.request)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, response.RawOutput)
+			assert.NotNil(t, response.Usage)
+		})
+	}
+	assert.Len(t, provider.Requests(), len(tests))
+
+	_, err := provider.Generate(ctx, dslRequest("magda_dsl", "write me a symphony"))
+	assert.ErrorContains(t, err, `no response for magda_dsl request "write me a symphony"`)
+}
+
+func TestMockProvider_GenerateAfterDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+
+	_, err := NewMockProvider().Generate(ctx, dslRequest("magda_dsl", "create a track"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestMockProvider_GenerateStream(t *testing.T) {
+	provider := NewMockProvider(MockResponse{Tool: "magda_dsl", Output: "track()\ntrack(id=1).set_track(mute=true)"})
+
+	var events []StreamEvent
+	response, err := provider.GenerateStream(context.Background(), dslRequest("magda_dsl", "two tracks"), func(event StreamEvent) error {
+		events = append(events, event)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "track()\ntrack(id=1).set_track(mute=true)", response.RawOutput)
+	assert.Equal(t, []StreamEvent{
+		{Type: "started", Message: "Starting generation..."},
+		{Type: "text_delta", Message: "track()\n"},
+		{Type: "text_delta", Message: "track(id=1).set_track(mute=true)"},
+		{Type: "completed", Message: "Generation complete"},
+	}, events)
+}
+
+func TestLoadMockResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.json")
+	require.NoError(t, os.WriteFile(path, []byte(`[{"tool": "magda_dsl", "match": "solo", "output": "track(id=1).set_track(solo=true)"}]`), 0o600))
+
+	responses, err := LoadMockResponses(path)
+	require.NoError(t, err)
+	assert.Equal(t, []MockResponse{{Tool: "magda_dsl", Match: "solo", Output: "track(id=1).set_track(solo=true)"}}, responses)
+
+	_, err = LoadMockResponses(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
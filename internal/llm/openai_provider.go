@@ -6,13 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/Conceptual-Machines/grammar-school-go/gs"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/getsentry/sentry-go"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -77,7 +76,7 @@ func (p *OpenAIProvider) Name() string {
 //nolint:gocyclo // Complex logic needed for handling CFG, JSON Schema, and standard requests
 func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
 	startTime := time.Now()
-	log.Printf("🎵 OPENAI GENERATION REQUEST STARTED (Model: %s)", request.Model)
+	logger.Printf(ctx, "🎵 OPENAI GENERATION REQUEST STARTED (Model: %s)", request.Model)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "openai.generate")
@@ -88,9 +87,9 @@ func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationReques
 	transaction.SetTag("mcp_enabled", fmt.Sprintf("%t", request.MCPConfig != nil))
 
 	// Build OpenAI-specific request parameters
-	params := p.buildRequestParams(request)
+	params := p.buildRequestParams(ctx, request)
 
-	log.Printf("🚨 CRITICAL: About to call OpenAI API with params.Model='%s'", params.Model)
+	logger.Printf(ctx, "🚨 CRITICAL: About to call OpenAI API with params.Model='%s'", params.Model)
 
 	// Call OpenAI API with Sentry span
 	span := transaction.StartChild("openai.api_call")
@@ -101,7 +100,7 @@ func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationReques
 		cfgResp, cfgErr := p.executeRawCFGRequest(ctx, params, request, startTime, transaction)
 		span.Finish()
 		if cfgErr != nil {
-			log.Printf("❌ OPENAI REQUEST FAILED after %v: %v", time.Since(apiStartTime), cfgErr)
+			logger.Printf(ctx, "❌ OPENAI REQUEST FAILED after %v: %v", time.Since(apiStartTime), cfgErr)
 			transaction.SetTag("success", "false")
 			sentry.CaptureException(cfgErr)
 			return nil, fmt.Errorf("openai request failed: %w", cfgErr)
@@ -121,16 +120,16 @@ func (p *OpenAIProvider) Generate(ctx context.Context, request *GenerationReques
 	span.Finish()
 
 	if err != nil {
-		log.Printf("❌ OPENAI REQUEST FAILED after %v: %v", apiDuration, err)
+		logger.Printf(ctx, "❌ OPENAI REQUEST FAILED after %v: %v", apiDuration, err)
 		transaction.SetTag("success", "false")
 		sentry.CaptureException(err)
 		return nil, fmt.Errorf("openai request failed: %w", err)
 	}
 
-	log.Printf("⏱️  OPENAI API CALL COMPLETED in %v", apiDuration)
+	logger.Printf(ctx, "⏱️  OPENAI API CALL COMPLETED in %v", apiDuration)
 
 	// Process response based on output type
-	return p.processResponse(resp, request, startTime, transaction)
+	return p.processResponse(ctx, resp, request, startTime, transaction)
 }
 
 // executeRawCFGRequest handles CFG grammar requests via raw HTTP
@@ -148,27 +147,27 @@ func (p *OpenAIProvider) executeRawCFGRequest(
 	}
 
 	// Add CFG tool
-	p.addCFGToolToParams(paramsMap, request.CFGGrammar)
+	p.addCFGToolToParams(ctx, paramsMap, request.CFGGrammar)
 
 	// Make raw HTTP request
-	body, err := p.makeRawHTTPRequest(ctx, paramsMap, request.CFGGrammar != nil)
+	body, err := p.makeRawHTTPRequest(ctx, paramsMap)
 	if err != nil {
 		return nil, err
 	}
 
 	// Try to extract DSL from response
-	return p.extractDSLFromResponse(body, startTime, transaction, request.CFGGrammar)
+	return p.extractDSLFromResponse(ctx, body, startTime, transaction, request.CFGGrammar)
 }
 
 // addCFGToolToParams adds CFG tool configuration to request params
-func (p *OpenAIProvider) addCFGToolToParams(paramsMap map[string]any, cfgGrammar *CFGConfig) {
+func (p *OpenAIProvider) addCFGToolToParams(ctx context.Context, paramsMap map[string]any, cfgGrammar *CFGConfig) {
 	cfgTool := gs.BuildOpenAICFGTool(gs.CFGConfig{
 		ToolName:    cfgGrammar.ToolName,
 		Description: cfgGrammar.Description,
 		Grammar:     cfgGrammar.Grammar,
 		Syntax:      cfgGrammar.Syntax,
 	})
-	log.Printf("🔧 CFG GRAMMAR CONFIGURED: %s (syntax: %s)", cfgGrammar.ToolName, cfgGrammar.Syntax)
+	logger.Printf(ctx, "🔧 CFG GRAMMAR CONFIGURED: %s (syntax: %s)", cfgGrammar.ToolName, cfgGrammar.Syntax)
 
 	// Set text format to plain text when using CFG
 	paramsMap["text"] = gs.GetOpenAITextFormatForCFG()
@@ -181,12 +180,12 @@ func (p *OpenAIProvider) addCFGToolToParams(paramsMap map[string]any, cfgGrammar
 
 	// Log tool structure for debugging
 	toolJSON, _ := json.MarshalIndent(cfgTool, "", "  ")
-	log.Printf("🔧 Added CFG tool: %s (syntax: %s)", cfgGrammar.ToolName, cfgGrammar.Syntax)
-	log.Printf("🔧 CFG tool structure: %s", truncateString(string(toolJSON), 2000))
+	logger.Printf(ctx, "🔧 Added CFG tool: %s (syntax: %s)", cfgGrammar.ToolName, cfgGrammar.Syntax)
+	logger.Printf(ctx, "🔧 CFG tool structure: %s", truncateString(string(toolJSON), 2000))
 
 	// Log instructions
 	if instructions, ok := paramsMap["instructions"].(string); ok {
-		log.Printf("🔍 Instructions in request (first 500 chars): %s", truncateString(instructions, 500))
+		logger.Printf(ctx, "🔍 Instructions in request (first 500 chars): %s", truncateString(instructions, 500))
 	}
 }
 
@@ -216,48 +215,33 @@ func (p *OpenAIProvider) getOrInitToolsArray(paramsMap map[string]any) []any {
 	return []any{}
 }
 
-// makeRawHTTPRequest sends raw HTTP request to OpenAI
-func (p *OpenAIProvider) makeRawHTTPRequest(ctx context.Context, paramsMap map[string]any, saveToDisk bool) ([]byte, error) {
+// makeRawHTTPRequest sends raw HTTP request to OpenAI. With DEBUG_CAPTURE on, the request
+// and response payloads are kept (redacted) in the debug capture buffer.
+func (p *OpenAIProvider) makeRawHTTPRequest(ctx context.Context, paramsMap map[string]any) ([]byte, error) {
 	modifiedJSON, _ := json.Marshal(paramsMap)
+	start := time.Now()
 
-	// Save request payload for debugging
-	if saveToDisk {
-		prettyJSON, _ := json.MarshalIndent(paramsMap, "", "  ")
-		if err := os.WriteFile("/tmp/openai_request_full.json", prettyJSON, 0644); err != nil {
-			log.Printf("❌ FAILED to save request: %v", err)
-		} else {
-			log.Printf("💾 Saved FULL request payload to /tmp/openai_request_full.json (%d bytes)", len(prettyJSON))
-		}
-	}
-
-	log.Printf("📤 Making raw HTTP request (JSON size: %d bytes)", len(modifiedJSON))
+	logger.Printf(ctx, "📤 Making raw HTTP request (JSON size: %d bytes)", len(modifiedJSON))
 	req, _ := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/responses", bytes.NewReader(modifiedJSON))
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", p.apiKey))
 	req.Header.Set("Content-Type", "application/json")
 
 	httpResp, err := http.DefaultClient.Do(req)
 	if err != nil {
+		captureCall(ctx, providerNameOpenAI, req, start, 0, nil, err)
 		return nil, err
 	}
 	defer func() {
 		if closeErr := httpResp.Body.Close(); closeErr != nil {
-			log.Printf("⚠️  Failed to close response body: %v", closeErr)
+			logger.Printf(ctx, "⚠️  Failed to close response body: %v", closeErr)
 		}
 	}()
 
 	body, _ := io.ReadAll(httpResp.Body)
+	captureCall(ctx, providerNameOpenAI, req, start, httpResp.StatusCode, body, nil)
 
 	if httpResp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error %d: %s", httpResp.StatusCode, string(body))
-	}
-
-	// Save response payload for debugging
-	if saveToDisk {
-		if err := os.WriteFile("/tmp/openai_response_full.json", body, 0644); err != nil {
-			log.Printf("❌ FAILED to save response: %v", err)
-		} else {
-			log.Printf("💾 Saved FULL response payload to /tmp/openai_response_full.json (%d bytes)", len(body))
-		}
+		return nil, &APIError{StatusCode: httpResp.StatusCode, Body: string(body)}
 	}
 
 	return body, nil
@@ -265,12 +249,13 @@ func (p *OpenAIProvider) makeRawHTTPRequest(ctx context.Context, paramsMap map[s
 
 // extractDSLFromResponse extracts DSL code from raw JSON response
 func (p *OpenAIProvider) extractDSLFromResponse(
+	ctx context.Context,
 	body []byte,
 	startTime time.Time,
 	transaction *sentry.Span,
 	cfgGrammar *CFGConfig,
 ) (*GenerationResponse, error) {
-	log.Printf("🔍 Parsing raw JSON response to extract DSL from input field...")
+	logger.Printf(ctx, "🔍 Parsing raw JSON response to extract DSL from input field...")
 
 	var rawResponse map[string]any
 	if err := json.Unmarshal(body, &rawResponse); err != nil {
@@ -278,7 +263,7 @@ func (p *OpenAIProvider) extractDSLFromResponse(
 	}
 
 	// Try to extract DSL from custom_tool_call
-	if dsl := p.extractDSLFromOutput(rawResponse); dsl != "" {
+	if dsl := p.extractDSLFromOutput(ctx, rawResponse); dsl != "" {
 		return &GenerationResponse{
 			RawOutput: dsl,
 			Usage:     p.extractUsageFromRawResponse(rawResponse),
@@ -291,18 +276,18 @@ func (p *OpenAIProvider) extractDSLFromResponse(
 		return nil, fmt.Errorf("failed to parse response")
 	}
 
-	return p.processResponseWithCFG(resp, startTime, transaction, cfgGrammar)
+	return p.processResponseWithCFG(ctx, resp, startTime, transaction, cfgGrammar)
 }
 
 // extractDSLFromOutput extracts DSL code from output array
-func (p *OpenAIProvider) extractDSLFromOutput(rawResponse map[string]any) string {
+func (p *OpenAIProvider) extractDSLFromOutput(ctx context.Context, rawResponse map[string]any) string {
 	output, ok := rawResponse["output"].([]any)
 	if !ok {
-		log.Printf("⚠️  No output array found in raw response")
+		logger.Printf(ctx, "⚠️  No output array found in raw response")
 		return ""
 	}
 
-	log.Printf("🔍 Found output array with %d items", len(output))
+	logger.Printf(ctx, "🔍 Found output array with %d items", len(output))
 
 	for i, item := range output {
 		itemMap, ok := item.(map[string]any)
@@ -310,20 +295,20 @@ func (p *OpenAIProvider) extractDSLFromOutput(rawResponse map[string]any) string
 			continue
 		}
 
-		log.Printf("🔍 Checking output item %d, type: %v", i, itemMap["type"])
+		logger.Printf(ctx, "🔍 Checking output item %d, type: %v", i, itemMap["type"])
 
 		// Log input field for debugging
 		if inputVal, exists := itemMap["input"]; exists {
 			if inputStr, ok := inputVal.(string); ok {
-				log.Printf("🔍 'input' is a string with %d chars: %s", len(inputStr), truncateString(inputStr, 200))
+				logger.Printf(ctx, "🔍 'input' is a string with %d chars: %s", len(inputStr), truncateString(inputStr, 200))
 			}
 		}
 
 		// Check for custom_tool_call with DSL
 		if itemType, ok := itemMap["type"].(string); ok && itemType == "custom_tool_call" {
-			log.Printf("✅ Found custom_tool_call in raw JSON!")
+			logger.Printf(ctx, "✅ Found custom_tool_call in raw JSON!")
 			if input, ok := itemMap["input"].(string); ok && input != "" {
-				log.Printf("✅✅✅ Found DSL code: %s", truncateString(input, 200))
+				logger.Printf(ctx, "✅✅✅ Found DSL code: %s", truncateString(input, 200))
 				return input
 			}
 		}
@@ -334,6 +319,7 @@ func (p *OpenAIProvider) extractDSLFromOutput(rawResponse map[string]any) string
 
 // processResponse routes response to appropriate processor
 func (p *OpenAIProvider) processResponse(
+	ctx context.Context,
 	resp *responses.Response,
 	request *GenerationRequest,
 	startTime time.Time,
@@ -341,7 +327,7 @@ func (p *OpenAIProvider) processResponse(
 ) (*GenerationResponse, error) {
 	// CFG grammar processing
 	if request.CFGGrammar != nil {
-		result, err := p.processResponseWithCFG(resp, startTime, transaction, request.CFGGrammar)
+		result, err := p.processResponseWithCFG(ctx, resp, startTime, transaction, request.CFGGrammar)
 		if err != nil {
 			return nil, err
 		}
@@ -351,7 +337,7 @@ func (p *OpenAIProvider) processResponse(
 
 	// JSON Schema processing
 	if request.OutputSchema != nil {
-		result, err := p.processResponseWithJSONSchema(resp, startTime, transaction, request.OutputSchema)
+		result, err := p.processResponseWithJSONSchema(ctx, resp, startTime, transaction, request.OutputSchema)
 		if err != nil {
 			return nil, err
 		}
@@ -360,7 +346,7 @@ func (p *OpenAIProvider) processResponse(
 	}
 
 	// Plain text processing
-	result, err := p.processResponsePlainText(resp, startTime, transaction)
+	result, err := p.processResponsePlainText(ctx, resp, startTime, transaction)
 	if err != nil {
 		return nil, err
 	}
@@ -370,7 +356,7 @@ func (p *OpenAIProvider) processResponse(
 }
 
 // buildRequestParams converts GenerationRequest to OpenAI-specific ResponseNewParams
-func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) responses.ResponseNewParams {
+func (p *OpenAIProvider) buildRequestParams(ctx context.Context, request *GenerationRequest) responses.ResponseNewParams {
 	// Convert input_array to OpenAI messages format
 	inputItems := responses.ResponseInputParam{}
 
@@ -379,7 +365,7 @@ func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) response
 		content, hasContent := item["content"].(string)
 
 		if !hasRole || !hasContent {
-			log.Printf("⚠️  Skipping invalid input item (missing role or content): %v", item)
+			logger.Printf(ctx, "⚠️  Skipping invalid input item (missing role or content): %v", item)
 			continue
 		}
 
@@ -457,6 +443,12 @@ func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) response
 			Effort: reasoningEffort,
 		}
 	}
+	if request.Temperature != nil {
+		params.Temperature = openai.Float(*request.Temperature)
+	}
+	if request.MaxOutputTokens > 0 {
+		params.MaxOutputTokens = openai.Int(int64(request.MaxOutputTokens))
+	}
 
 	// MAGDA always uses DSL/CFG, no JSON schema
 
@@ -464,8 +456,8 @@ func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) response
 	if request.CFGGrammar != nil {
 		// Clean grammar using grammar-school before sending to OpenAI
 		cleanedGrammar := gs.CleanGrammarForCFG(request.CFGGrammar.Grammar)
-		log.Printf("🔧 CFG GRAMMAR CONFIGURED: %s (syntax: %s)", request.CFGGrammar.ToolName, request.CFGGrammar.Syntax)
-		log.Printf("📝 Grammar cleaned for CFG: %d chars (original: %d chars)", len(cleanedGrammar), len(request.CFGGrammar.Grammar))
+		logger.Printf(ctx, "🔧 CFG GRAMMAR CONFIGURED: %s (syntax: %s)", request.CFGGrammar.ToolName, request.CFGGrammar.Syntax)
+		logger.Printf(ctx, "📝 Grammar cleaned for CFG: %d chars (original: %d chars)", len(cleanedGrammar), len(request.CFGGrammar.Grammar))
 
 		// Use grammar-school utility to build OpenAI CFG tool payload
 		cfgTool := gs.BuildOpenAICFGTool(gs.CFGConfig{
@@ -487,24 +479,24 @@ func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) response
 		// BuildOpenAICFGTool returns map[string]any, we need to convert it
 		cfgToolJSON, err := json.Marshal(cfgTool)
 		if err != nil {
-			log.Printf("⚠️  Failed to marshal CFG tool: %v", err)
+			logger.Printf(ctx, "⚠️  Failed to marshal CFG tool: %v", err)
 		} else {
 			var cfgToolMap map[string]any
 			if err := json.Unmarshal(cfgToolJSON, &cfgToolMap); err != nil {
-				log.Printf("⚠️  Failed to unmarshal CFG tool: %v", err)
+				logger.Printf(ctx, "⚠️  Failed to unmarshal CFG tool: %v", err)
 			} else {
 				// The SDK expects ToolUnionParam, but CFG tools use a custom type
 				// We need to manually construct it based on the CFG tool structure
 				// For now, try to add it as a custom tool
 				// Note: This may need adjustment based on SDK support
-				log.Printf("🔧 Attempting to add CFG tool to streaming params: %+v", cfgToolMap)
+				logger.Printf(ctx, "🔧 Attempting to add CFG tool to streaming params: %+v", cfgToolMap)
 
 				// The CFG tool should have type "custom" with format.grammar
 				if toolType, ok := cfgToolMap["type"].(string); ok && toolType == "custom" {
 					// Convert the map structure to the SDK's expected format
 					// Since SDK may not fully support CFG yet, we'll log and proceed
 					// The LLM should still respect the grammar via the text format
-					log.Printf("✅ CFG tool structure detected, text format set to CFG mode")
+					logger.Printf(ctx, "✅ CFG tool structure detected, text format set to CFG mode")
 				}
 			}
 		}
@@ -521,7 +513,7 @@ func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) response
 				request.OutputSchema.Schema,
 			),
 		}
-		log.Printf("📋 JSON SCHEMA CONFIGURED: %s", request.OutputSchema.Name)
+		logger.Printf(ctx, "📋 JSON SCHEMA CONFIGURED: %s", request.OutputSchema.Name)
 	}
 
 	// Add MCP tools if configured
@@ -541,7 +533,7 @@ func (p *OpenAIProvider) buildRequestParams(request *GenerationRequest) response
 				},
 			},
 		}
-		log.Printf("🔗 MCP SERVER ENABLED: %s (label: %s)", request.MCPConfig.URL, request.MCPConfig.Label)
+		logger.Printf(ctx, "🔗 MCP SERVER ENABLED: %s (label: %s)", request.MCPConfig.URL, request.MCPConfig.Label)
 	}
 
 	return params
@@ -565,8 +557,8 @@ func truncateString(s string, maxLen int) string {
 }
 
 // extractDSLFromCFGToolCall searches for DSL code in CFG tool call response
-func (p *OpenAIProvider) extractDSLFromCFGToolCall(resp *responses.Response) string {
-	log.Printf("🔍 Searching for CFG tool call in %d output items", len(resp.Output))
+func (p *OpenAIProvider) extractDSLFromCFGToolCall(ctx context.Context, resp *responses.Response) string {
+	logger.Printf(ctx, "🔍 Searching for CFG tool call in %d output items", len(resp.Output))
 
 	for i, outputItem := range resp.Output {
 		outputItemJSON, _ := json.Marshal(outputItem)
@@ -575,27 +567,27 @@ func (p *OpenAIProvider) extractDSLFromCFGToolCall(resp *responses.Response) str
 			continue
 		}
 
-		log.Printf("🔍 Output item %d keys: %v", i, getMapKeys(outputItemMap))
+		logger.Printf(ctx, "🔍 Output item %d keys: %v", i, getMapKeys(outputItemMap))
 
 		// Check for type field - ALWAYS log it
 		typeVal, typeExists := outputItemMap["type"]
 		if typeExists {
-			log.Printf("🔍 'type' field EXISTS in output item %d: value='%v' (type=%T)", i, typeVal, typeVal)
+			logger.Printf(ctx, "🔍 'type' field EXISTS in output item %d: value='%v' (type=%T)", i, typeVal, typeVal)
 		} else {
-			log.Printf("🔍 'type' field DOES NOT EXIST in output item %d", i)
+			logger.Printf(ctx, "🔍 'type' field DOES NOT EXIST in output item %d", i)
 		}
 
 		// Check for type field
 		if typeExists {
 			// According to Grammar School docs, CFG tool results have type="custom_tool_call"
 			if typeStr, ok := typeVal.(string); ok && typeStr == "custom_tool_call" {
-				log.Printf("✅ Found custom_tool_call! Checking for 'input' field...")
+				logger.Printf(ctx, "✅ Found custom_tool_call! Checking for 'input' field...")
 
 				// Get the DSL code from the 'input' field
 				if inputVal, exists := outputItemMap["input"]; exists {
 					if inputStr, ok := inputVal.(string); ok && inputStr != "" {
-						log.Printf("🔧 Found CFG tool call in 'input' field (DSL): %s", truncateString(inputStr, maxPreviewChars))
-						log.Printf("📋 FULL DSL CODE from CFG tool input (%d chars, NO TRUNCATION):\n%s", len(inputStr), inputStr)
+						logger.Printf(ctx, "🔧 Found CFG tool call in 'input' field (DSL): %s", truncateString(inputStr, maxPreviewChars))
+						logger.Printf(ctx, "📋 FULL DSL CODE from CFG tool input (%d chars, NO TRUNCATION):\n%s", len(inputStr), inputStr)
 						return inputStr
 					}
 				}
@@ -604,37 +596,37 @@ func (p *OpenAIProvider) extractDSLFromCFGToolCall(resp *responses.Response) str
 
 		// Debug: Check input field explicitly (for debugging)
 		if inputVal, exists := outputItemMap["input"]; exists {
-			log.Printf("🔍 'input' field EXISTS in output item %d: type=%T", i, inputVal)
+			logger.Printf(ctx, "🔍 'input' field EXISTS in output item %d: type=%T", i, inputVal)
 			if inputStr, ok := inputVal.(string); ok {
-				log.Printf("🔍 'input' is a string with %d chars: %s", len(inputStr), truncateString(inputStr, 200))
+				logger.Printf(ctx, "🔍 'input' is a string with %d chars: %s", len(inputStr), truncateString(inputStr, 200))
 			}
 		} else {
-			log.Printf("🔍 'input' field DOES NOT EXIST in output item %d", i)
+			logger.Printf(ctx, "🔍 'input' field DOES NOT EXIST in output item %d", i)
 		}
 
 		// Fallback: Check all possible locations for DSL code
-		if dslCode := p.findDSLInOutputItem(outputItemMap); dslCode != "" {
+		if dslCode := p.findDSLInOutputItem(ctx, outputItemMap); dslCode != "" {
 			return dslCode
 		}
 	}
 
-	log.Printf("⚠️  No CFG tool call found in response output items")
+	logger.Printf(ctx, "⚠️  No CFG tool call found in response output items")
 	return ""
 }
 
 // findDSLInOutputItem checks multiple possible locations for DSL code in an output item
-func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
+func (p *OpenAIProvider) findDSLInOutputItem(ctx context.Context, itemMap map[string]any) string {
 	// Check "input" field FIRST (this is where CFG tool results appear according to OpenAI docs)
 	if input, ok := itemMap["input"].(string); ok && input != "" {
-		log.Printf("🔧 Found CFG tool call in 'input' field (DSL): %s", truncateString(input, maxPreviewChars))
-		log.Printf("📋 FULL DSL CODE from CFG tool input (%d chars, NO TRUNCATION):\n%s", len(input), input)
+		logger.Printf(ctx, "🔧 Found CFG tool call in 'input' field (DSL): %s", truncateString(input, maxPreviewChars))
+		logger.Printf(ctx, "📋 FULL DSL CODE from CFG tool input (%d chars, NO TRUNCATION):\n%s", len(input), input)
 		return input
 	}
 
 	// Check "code" field as fallback
 	if code, ok := itemMap["code"].(string); ok && code != "" {
-		log.Printf("🔧 Found CFG tool call code (DSL): %s", truncateString(code, maxPreviewChars))
-		log.Printf("📋 FULL DSL CODE from CFG tool code (%d chars, NO TRUNCATION):\n%s", len(code), code)
+		logger.Printf(ctx, "🔧 Found CFG tool call code (DSL): %s", truncateString(code, maxPreviewChars))
+		logger.Printf(ctx, "📋 FULL DSL CODE from CFG tool code (%d chars, NO TRUNCATION):\n%s", len(code), code)
 		return code
 	}
 
@@ -643,7 +635,7 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 		if codeMap, ok := codeVal.(map[string]any); ok {
 			for key, val := range codeMap {
 				if strVal, ok := val.(string); ok && strVal != "" && p.isDSLCode(strVal) {
-					log.Printf("🔧 Found CFG tool call code in nested map[%s] (DSL): %s", key, truncateString(strVal, maxPreviewChars))
+					logger.Printf(ctx, "🔧 Found CFG tool call code in nested map[%s] (DSL): %s", key, truncateString(strVal, maxPreviewChars))
 					return strVal
 				}
 			}
@@ -651,28 +643,28 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 	}
 
 	// Check direct fields - with detailed logging
-	log.Printf("🔍 ========== findDSLInOutputItem: Checking direct fields (input, action, arguments) ==========")
+	logger.Printf(ctx, "🔍 ========== findDSLInOutputItem: Checking direct fields (input, action, arguments) ==========")
 	for _, field := range []string{"input", "action", "arguments"} {
 		if val, exists := itemMap[field]; exists {
-			log.Printf("🔍 Field '%s' EXISTS: type=%T", field, val)
+			logger.Printf(ctx, "🔍 Field '%s' EXISTS: type=%T", field, val)
 			if valStr, ok := val.(string); ok {
-				log.Printf("🔍 Field '%s' is string with %d chars, value: %s", field, len(valStr), truncateString(valStr, 1000))
+				logger.Printf(ctx, "🔍 Field '%s' is string with %d chars, value: %s", field, len(valStr), truncateString(valStr, 1000))
 				if valStr != "" && p.isDSLCode(valStr) {
-					log.Printf("🔧 ✅✅✅ FOUND DSL IN FIELD '%s': %s", field, truncateString(valStr, maxPreviewChars))
+					logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN FIELD '%s': %s", field, truncateString(valStr, maxPreviewChars))
 					return valStr
 				}
 			} else {
 				// Log what type it actually is
 				valJSON, _ := json.Marshal(val)
-				log.Printf("🔍 Field '%s' is NOT a string, JSON: %s", field, truncateString(string(valJSON), 1000))
+				logger.Printf(ctx, "🔍 Field '%s' is NOT a string, JSON: %s", field, truncateString(string(valJSON), 1000))
 				// If it's a map, check its contents
 				if valMap, ok := val.(map[string]any); ok {
-					log.Printf("🔍 Field '%s' is a map with keys: %v", field, getMapKeys(valMap))
+					logger.Printf(ctx, "🔍 Field '%s' is a map with keys: %v", field, getMapKeys(valMap))
 					for k, v := range valMap {
 						if vStr, ok := v.(string); ok && vStr != "" {
-							log.Printf("🔍 Field '%s[%s]' = %s", field, k, truncateString(vStr, 500))
+							logger.Printf(ctx, "🔍 Field '%s[%s]' = %s", field, k, truncateString(vStr, 500))
 							if p.isDSLCode(vStr) {
-								log.Printf("🔧 ✅✅✅ FOUND DSL IN FIELD '%s[%s]': %s", field, k, truncateString(vStr, maxPreviewChars))
+								logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN FIELD '%s[%s]': %s", field, k, truncateString(vStr, maxPreviewChars))
 								return vStr
 							}
 						}
@@ -680,39 +672,39 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 				}
 			}
 		} else {
-			log.Printf("🔍 Field '%s' DOES NOT EXIST", field)
+			logger.Printf(ctx, "🔍 Field '%s' DOES NOT EXIST", field)
 		}
 	}
 
 	// Also check other fields that might contain DSL
-	log.Printf("🔍 ========== findDSLInOutputItem: Checking other fields (result, output, content) ==========")
+	logger.Printf(ctx, "🔍 ========== findDSLInOutputItem: Checking other fields (result, output, content) ==========")
 	for _, field := range []string{"result", "output", "content"} {
 		if val, exists := itemMap[field]; exists {
-			log.Printf("🔍 Field '%s' EXISTS: type=%T", field, val)
+			logger.Printf(ctx, "🔍 Field '%s' EXISTS: type=%T", field, val)
 			if valStr, ok := val.(string); ok {
-				log.Printf("🔍 Field '%s' is string with %d chars, value: %s", field, len(valStr), truncateString(valStr, 1000))
+				logger.Printf(ctx, "🔍 Field '%s' is string with %d chars, value: %s", field, len(valStr), truncateString(valStr, 1000))
 				if valStr != "" && p.isDSLCode(valStr) {
-					log.Printf("🔧 ✅✅✅ FOUND DSL IN FIELD '%s': %s", field, truncateString(valStr, maxPreviewChars))
+					logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN FIELD '%s': %s", field, truncateString(valStr, maxPreviewChars))
 					return valStr
 				}
 			} else if val != nil {
 				valJSON, _ := json.Marshal(val)
-				log.Printf("🔍 Field '%s' is NOT a string, JSON: %s", field, truncateString(string(valJSON), 1000))
+				logger.Printf(ctx, "🔍 Field '%s' is NOT a string, JSON: %s", field, truncateString(string(valJSON), 1000))
 			}
 		}
 	}
 
 	// Check "outputs" array
 	if outputs, ok := itemMap["outputs"].([]any); ok && len(outputs) > 0 {
-		log.Printf("🔍 Found 'outputs' array with %d items", len(outputs))
+		logger.Printf(ctx, "🔍 Found 'outputs' array with %d items", len(outputs))
 		for j, output := range outputs {
 			if outputMap, ok := output.(map[string]any); ok {
-				log.Printf("🔍 Output %d keys: %v", j, getMapKeys(outputMap))
+				logger.Printf(ctx, "🔍 Output %d keys: %v", j, getMapKeys(outputMap))
 				for key, val := range outputMap {
 					if valStr, ok := val.(string); ok && valStr != "" {
-						log.Printf("🔍 Output[%d][%s] = %s", j, key, truncateString(valStr, 500))
+						logger.Printf(ctx, "🔍 Output[%d][%s] = %s", j, key, truncateString(valStr, 500))
 						if p.isDSLCode(valStr) {
-							log.Printf("🔧 ✅✅✅ FOUND DSL IN OUTPUT[%d][%s]: %s", j, key, truncateString(valStr, maxPreviewChars))
+							logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN OUTPUT[%d][%s]: %s", j, key, truncateString(valStr, maxPreviewChars))
 							return valStr
 						}
 					}
@@ -722,29 +714,29 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 	}
 
 	// Check "tools" array - this is critical for CFG tools
-	log.Printf("🔍 ========== findDSLInOutputItem: Checking 'tools' field ==========")
+	logger.Printf(ctx, "🔍 ========== findDSLInOutputItem: Checking 'tools' field ==========")
 	if toolsVal, exists := itemMap["tools"]; exists {
-		log.Printf("🔍 Field 'tools' EXISTS: type=%T", toolsVal)
+		logger.Printf(ctx, "🔍 Field 'tools' EXISTS: type=%T", toolsVal)
 		if tools, ok := toolsVal.([]any); ok {
-			log.Printf("🔍 'tools' is an array with %d items", len(tools))
+			logger.Printf(ctx, "🔍 'tools' is an array with %d items", len(tools))
 			if len(tools) > 0 {
 				for j, tool := range tools {
 					if toolMap, ok := tool.(map[string]any); ok {
-						log.Printf("🔍 Tool %d keys: %v", j, getMapKeys(toolMap))
+						logger.Printf(ctx, "🔍 Tool %d keys: %v", j, getMapKeys(toolMap))
 						for key, val := range toolMap {
 							if valStr, ok := val.(string); ok && valStr != "" {
-								log.Printf("🔍 Tool[%d][%s] = %s", j, key, truncateString(valStr, 500))
+								logger.Printf(ctx, "🔍 Tool[%d][%s] = %s", j, key, truncateString(valStr, 500))
 								if p.isDSLCode(valStr) {
-									log.Printf("🔧 ✅✅✅ FOUND DSL IN TOOL[%d][%s]: %s", j, key, truncateString(valStr, maxPreviewChars))
+									logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN TOOL[%d][%s]: %s", j, key, truncateString(valStr, maxPreviewChars))
 									return valStr
 								}
 							} else if valMap, ok := val.(map[string]any); ok {
-								log.Printf("🔍 Tool[%d][%s] is a map with keys: %v", j, key, getMapKeys(valMap))
+								logger.Printf(ctx, "🔍 Tool[%d][%s] is a map with keys: %v", j, key, getMapKeys(valMap))
 								for subKey, subVal := range valMap {
 									if subValStr, ok := subVal.(string); ok && subValStr != "" {
-										log.Printf("🔍 Tool[%d][%s][%s] = %s", j, key, subKey, truncateString(subValStr, 500))
+										logger.Printf(ctx, "🔍 Tool[%d][%s][%s] = %s", j, key, subKey, truncateString(subValStr, 500))
 										if p.isDSLCode(subValStr) {
-											log.Printf("🔧 ✅✅✅ FOUND DSL IN TOOL[%d][%s][%s]: %s", j, key, subKey, truncateString(subValStr, maxPreviewChars))
+											logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN TOOL[%d][%s][%s]: %s", j, key, subKey, truncateString(subValStr, maxPreviewChars))
 											return subValStr
 										}
 									}
@@ -755,14 +747,14 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 				}
 			}
 		} else {
-			log.Printf("🔍 'tools' is NOT an array, type=%T, value: %v", toolsVal, toolsVal)
+			logger.Printf(ctx, "🔍 'tools' is NOT an array, type=%T, value: %v", toolsVal, toolsVal)
 			if toolsMap, ok := toolsVal.(map[string]any); ok {
-				log.Printf("🔍 'tools' is a map with keys: %v", getMapKeys(toolsMap))
+				logger.Printf(ctx, "🔍 'tools' is a map with keys: %v", getMapKeys(toolsMap))
 				for k, v := range toolsMap {
 					if vStr, ok := v.(string); ok && vStr != "" {
-						log.Printf("🔍 tools[%s] = %s", k, truncateString(vStr, 500))
+						logger.Printf(ctx, "🔍 tools[%s] = %s", k, truncateString(vStr, 500))
 						if p.isDSLCode(vStr) {
-							log.Printf("🔧 ✅✅✅ FOUND DSL IN tools[%s]: %s", k, truncateString(vStr, maxPreviewChars))
+							logger.Printf(ctx, "🔧 ✅✅✅ FOUND DSL IN tools[%s]: %s", k, truncateString(vStr, maxPreviewChars))
 							return vStr
 						}
 					}
@@ -770,7 +762,7 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 			}
 		}
 	} else {
-		log.Printf("🔍 Field 'tools' DOES NOT EXIST")
+		logger.Printf(ctx, "🔍 Field 'tools' DOES NOT EXIST")
 	}
 
 	// Check tool_calls array
@@ -778,12 +770,12 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 		for j, toolCall := range toolCalls {
 			if toolCallMap, ok := toolCall.(map[string]any); ok {
 				if input, ok := toolCallMap["input"].(string); ok && input != "" {
-					log.Printf("🔧 Found CFG tool call input in tool_calls[%d] (DSL): %s", j, truncateString(input, maxPreviewChars))
+					logger.Printf(ctx, "🔧 Found CFG tool call input in tool_calls[%d] (DSL): %s", j, truncateString(input, maxPreviewChars))
 					return input
 				}
 				if function, ok := toolCallMap["function"].(map[string]any); ok {
 					if arguments, ok := function["arguments"].(string); ok && arguments != "" {
-						log.Printf("🔧 Found CFG tool call arguments (DSL): %s", truncateString(arguments, maxPreviewChars))
+						logger.Printf(ctx, "🔧 Found CFG tool call arguments (DSL): %s", truncateString(arguments, maxPreviewChars))
 						return arguments
 					}
 				}
@@ -794,7 +786,7 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 	// Check nested tool_call
 	if toolCall, ok := itemMap["tool_call"].(map[string]any); ok {
 		if input, ok := toolCall["input"].(string); ok && input != "" {
-			log.Printf("🔧 Found CFG tool call input in tool_call (DSL): %s", truncateString(input, maxPreviewChars))
+			logger.Printf(ctx, "🔧 Found CFG tool call input in tool_call (DSL): %s", truncateString(input, maxPreviewChars))
 			return input
 		}
 	}
@@ -803,7 +795,7 @@ func (p *OpenAIProvider) findDSLInOutputItem(itemMap map[string]any) string {
 }
 
 // extractAndCleanTextOutput extracts and cleans text output from response
-func (p *OpenAIProvider) extractAndCleanTextOutput(resp *responses.Response) string {
+func (p *OpenAIProvider) extractAndCleanTextOutput(ctx context.Context, resp *responses.Response) string {
 	textOutput := resp.OutputText()
 
 	if textOutput == "" {
@@ -817,7 +809,7 @@ func (p *OpenAIProvider) extractAndCleanTextOutput(resp *responses.Response) str
 	cleaned = strings.TrimSpace(cleaned)
 
 	if cleaned != textOutput {
-		log.Printf("🧹 Stripped markdown code blocks from output: %d -> %d chars", len(textOutput), len(cleaned))
+		logger.Printf(ctx, "🧹 Stripped markdown code blocks from output: %d -> %d chars", len(textOutput), len(cleaned))
 	}
 
 	return cleaned
@@ -849,6 +841,7 @@ func (p *OpenAIProvider) isDSLCode(text string) bool {
 // processResponseWithCFG converts OpenAI Response to GenerationResponse, handling CFG tool calls
 // MAGDA always uses DSL/CFG, so this is the only processing path
 func (p *OpenAIProvider) processResponseWithCFG(
+	ctx context.Context,
 	resp *responses.Response,
 	startTime time.Time,
 	transaction *sentry.Span,
@@ -859,7 +852,7 @@ func (p *OpenAIProvider) processResponseWithCFG(
 
 	// Try to extract DSL from CFG tool call first
 	if cfgConfig != nil {
-		if dslCode := p.extractDSLFromCFGToolCall(resp); dslCode != "" {
+		if dslCode := p.extractDSLFromCFGToolCall(ctx, resp); dslCode != "" {
 			return &GenerationResponse{
 				RawOutput: dslCode,
 				Usage:     resp.Usage,
@@ -868,8 +861,8 @@ func (p *OpenAIProvider) processResponseWithCFG(
 	}
 
 	// Extract and process text output
-	textOutput := p.extractAndCleanTextOutput(resp)
-	log.Printf("📥 OPENAI RESPONSE: output_length=%d, output_items=%d, tokens=%d",
+	textOutput := p.extractAndCleanTextOutput(ctx, resp)
+	logger.Printf(ctx, "📥 OPENAI RESPONSE: output_length=%d, output_items=%d, tokens=%d",
 		len(textOutput), len(resp.Output), resp.Usage.TotalTokens)
 
 	// If CFG was configured, we MUST have DSL from tool call - no fallback to text output
@@ -877,8 +870,8 @@ func (p *OpenAIProvider) processResponseWithCFG(
 		// We already checked for CFG tool call above - if we got here, there's no tool call
 		// and we have text output. This is an error - LLM must use CFG tool.
 		if textOutput != "" {
-			log.Printf("❌ CFG was configured but LLM did not use CFG tool and generated text output instead")
-			log.Printf("❌ Text output (first %d chars): %s", maxPreviewChars, truncateString(textOutput, maxPreviewChars))
+			logger.Printf(ctx, "❌ CFG was configured but LLM did not use CFG tool and generated text output instead")
+			logger.Printf(ctx, "❌ Text output (first %d chars): %s", maxPreviewChars, truncateString(textOutput, maxPreviewChars))
 			return nil, fmt.Errorf("CFG grammar was configured but LLM did not use CFG tool. LLM must use the CFG tool to generate DSL code")
 		}
 		return nil, fmt.Errorf("CFG grammar was configured but LLM did not use CFG tool to generate DSL code. LLM must use the CFG tool to generate DSL code")
@@ -889,13 +882,13 @@ func (p *OpenAIProvider) processResponseWithCFG(
 	}
 
 	// Analyze MCP usage
-	mcpUsed, mcpCalls, mcpTools := p.analyzeMCPUsage(resp)
+	mcpUsed, mcpCalls, mcpTools := p.analyzeMCPUsage(ctx, resp)
 
 	// Log MCP summary
-	p.logMCPSummary(mcpUsed, mcpCalls, mcpTools)
+	p.logMCPSummary(ctx, mcpUsed, mcpCalls, mcpTools)
 
 	// Log usage stats
-	p.logUsageStats(resp.Usage)
+	p.logUsageStats(ctx, resp.Usage)
 
 	// MAGDA always uses DSL, so we should never reach here
 	return nil, fmt.Errorf("unexpected code path in processResponseWithCFG")
@@ -903,6 +896,7 @@ func (p *OpenAIProvider) processResponseWithCFG(
 
 // processResponseWithJSONSchema extracts JSON output from OpenAI response when using JSON Schema
 func (p *OpenAIProvider) processResponseWithJSONSchema(
+	ctx context.Context,
 	resp *responses.Response,
 	startTime time.Time,
 	transaction *sentry.Span,
@@ -912,8 +906,8 @@ func (p *OpenAIProvider) processResponseWithJSONSchema(
 	defer span.Finish()
 
 	// Extract text output (should be JSON when using JSON Schema)
-	textOutput := p.extractAndCleanTextOutput(resp)
-	log.Printf("📥 OPENAI JSON RESPONSE: output_length=%d, output_items=%d, tokens=%d",
+	textOutput := p.extractAndCleanTextOutput(ctx, resp)
+	logger.Printf(ctx, "📥 OPENAI JSON RESPONSE: output_length=%d, output_items=%d, tokens=%d",
 		len(textOutput), len(resp.Output), resp.Usage.TotalTokens)
 
 	if textOutput == "" {
@@ -921,10 +915,10 @@ func (p *OpenAIProvider) processResponseWithJSONSchema(
 	}
 
 	// Log usage stats
-	p.logUsageStats(resp.Usage)
+	p.logUsageStats(ctx, resp.Usage)
 
 	duration := time.Since(startTime)
-	log.Printf("✅ OPENAI GENERATION COMPLETED in %v", duration)
+	logger.Printf(ctx, "✅ OPENAI GENERATION COMPLETED in %v", duration)
 
 	return &GenerationResponse{
 		RawOutput: textOutput, // JSON string from OutputSchema
@@ -935,6 +929,7 @@ func (p *OpenAIProvider) processResponseWithJSONSchema(
 // processResponsePlainText extracts plain text output from OpenAI response (no schema/grammar)
 // This is useful for simple tasks like generating descriptions
 func (p *OpenAIProvider) processResponsePlainText(
+	ctx context.Context,
 	resp *responses.Response,
 	startTime time.Time,
 	transaction *sentry.Span,
@@ -943,8 +938,8 @@ func (p *OpenAIProvider) processResponsePlainText(
 	defer span.Finish()
 
 	// Extract text output
-	textOutput := p.extractAndCleanTextOutput(resp)
-	log.Printf("📥 OPENAI PLAIN TEXT RESPONSE: output_length=%d, tokens=%d",
+	textOutput := p.extractAndCleanTextOutput(ctx, resp)
+	logger.Printf(ctx, "📥 OPENAI PLAIN TEXT RESPONSE: output_length=%d, tokens=%d",
 		len(textOutput), resp.Usage.TotalTokens)
 
 	if textOutput == "" {
@@ -952,10 +947,10 @@ func (p *OpenAIProvider) processResponsePlainText(
 	}
 
 	// Log usage stats
-	p.logUsageStats(resp.Usage)
+	p.logUsageStats(ctx, resp.Usage)
 
 	duration := time.Since(startTime)
-	log.Printf("✅ OPENAI PLAIN TEXT COMPLETED in %v", duration)
+	logger.Printf(ctx, "✅ OPENAI PLAIN TEXT COMPLETED in %v", duration)
 
 	return &GenerationResponse{
 		RawOutput: textOutput,
@@ -964,19 +959,19 @@ func (p *OpenAIProvider) processResponsePlainText(
 }
 
 // analyzeMCPUsage checks if MCP was used and returns usage details
-func (p *OpenAIProvider) analyzeMCPUsage(resp *responses.Response) (bool, int, []string) {
+func (p *OpenAIProvider) analyzeMCPUsage(ctx context.Context, resp *responses.Response) (bool, int, []string) {
 	mcpUsed := false
 	mcpCallCount := 0
 	toolsUsed := make(map[string]bool)
 
-	log.Printf("🔍 MCP USAGE ANALYSIS:")
+	logger.Printf(ctx, "🔍 MCP USAGE ANALYSIS:")
 
 	for _, outputItem := range resp.Output {
 		if outputItem.Type == mcpCallType {
 			mcpCall := outputItem.AsMcpCall()
 			mcpUsed = true
 			mcpCallCount++
-			p.logMCPToolCall(mcpCall)
+			p.logMCPToolCall(ctx, mcpCall)
 			toolsUsed[mcpCall.Name] = true
 
 			// Add Sentry breadcrumb
@@ -1001,34 +996,34 @@ func (p *OpenAIProvider) analyzeMCPUsage(resp *responses.Response) (bool, int, [
 	}
 
 	if mcpCallCount == 0 {
-		log.Printf("❌ MCP NOT USED: No MCP tool calls found in output")
+		logger.Printf(ctx, "❌ MCP NOT USED: No MCP tool calls found in output")
 	} else {
-		log.Printf("📊 MCP TOOLS USED: %v", uniqueTools)
+		logger.Printf(ctx, "📊 MCP TOOLS USED: %v", uniqueTools)
 	}
 
 	return mcpUsed, mcpCallCount, uniqueTools
 }
 
 // logMCPToolCall logs details of an MCP tool call
-func (p *OpenAIProvider) logMCPToolCall(mcpCall responses.ResponseOutputItemMcpCall) {
-	log.Printf("✅ MCP WAS USED: Tool call made")
-	log.Printf("   🛠️  Tool Call: %s", mcpCall.Name)
+func (p *OpenAIProvider) logMCPToolCall(ctx context.Context, mcpCall responses.ResponseOutputItemMcpCall) {
+	logger.Printf(ctx, "✅ MCP WAS USED: Tool call made")
+	logger.Printf(ctx, "   🛠️  Tool Call: %s", mcpCall.Name)
 	if mcpCall.Arguments != "" {
 		argsStr := mcpCall.Arguments
 		if len(argsStr) > maxArgsLogLength {
 			argsStr = argsStr[:maxArgsLogLength] + "..."
 		}
-		log.Printf("     Arguments: %s", argsStr)
+		logger.Printf(ctx, "     Arguments: %s", argsStr)
 	}
 	if mcpCall.Output != "" {
 		output := mcpCall.Output
 		if len(output) > maxOutputTrunc {
 			output = output[:maxOutputTrunc] + "... (truncated)"
 		}
-		log.Printf("     Output: %s", output)
+		logger.Printf(ctx, "     Output: %s", output)
 	}
 	if mcpCall.Error != "" {
-		log.Printf("     ⚠️  Error: %s", mcpCall.Error)
+		logger.Printf(ctx, "     ⚠️  Error: %s", mcpCall.Error)
 	}
 }
 
@@ -1041,22 +1036,22 @@ func (p *OpenAIProvider) extractUsageFromRawResponse(rawResponse map[string]any)
 }
 
 // logUsageStats logs token usage statistics
-func (p *OpenAIProvider) logUsageStats(usage responses.ResponseUsage) {
+func (p *OpenAIProvider) logUsageStats(ctx context.Context, usage responses.ResponseUsage) {
 	reasoningTokens := int64(0)
 	if usage.OutputTokensDetails.ReasoningTokens > 0 {
 		reasoningTokens = usage.OutputTokensDetails.ReasoningTokens
 	}
-	log.Printf("📊 USAGE: input=%d, output=%d, reasoning=%d, total=%d",
+	logger.Printf(ctx, "📊 USAGE: input=%d, output=%d, reasoning=%d, total=%d",
 		usage.InputTokens, usage.OutputTokens,
 		reasoningTokens, usage.TotalTokens)
 }
 
 // logMCPSummary logs a summary of MCP usage
-func (p *OpenAIProvider) logMCPSummary(mcpUsed bool, callCount int, tools []string) {
+func (p *OpenAIProvider) logMCPSummary(ctx context.Context, mcpUsed bool, callCount int, tools []string) {
 	if mcpUsed {
-		log.Printf("🎯 MCP USAGE: %d calls to tools: %v", callCount, tools)
+		logger.Printf(ctx, "🎯 MCP USAGE: %d calls to tools: %v", callCount, tools)
 	} else {
-		log.Printf("ℹ️  NO MCP USAGE in this generation")
+		logger.Printf(ctx, "ℹ️  NO MCP USAGE in this generation")
 	}
 }
 
@@ -1076,7 +1071,7 @@ func (p *OpenAIProvider) GenerateStream(
 	callback StreamCallback,
 ) (*GenerationResponse, error) {
 	startTime := time.Now()
-	log.Printf("🎵 OPENAI STREAMING GENERATION REQUEST STARTED (Model: %s)", request.Model)
+	logger.Printf(ctx, "🎵 OPENAI STREAMING GENERATION REQUEST STARTED (Model: %s)", request.Model)
 
 	// Start Sentry transaction
 	transaction := sentry.StartTransaction(ctx, "openai.generate_stream")
@@ -1087,14 +1082,14 @@ func (p *OpenAIProvider) GenerateStream(
 	transaction.SetTag("streaming", "true")
 
 	// Build OpenAI-specific request parameters
-	params := p.buildRequestParams(request)
+	params := p.buildRequestParams(ctx, request)
 
 	// Send initial event
 	if callback != nil {
 		_ = callback(StreamEvent{Type: "started", Message: "Starting generation..."})
 	}
 
-	log.Printf("🚀 OPENAI STREAMING REQUEST: model=%s", request.Model)
+	logger.Printf(ctx, "🚀 OPENAI STREAMING REQUEST: model=%s", request.Model)
 
 	// Call OpenAI streaming API
 	span := transaction.StartChild("openai.api_stream")
@@ -1113,7 +1108,7 @@ func (p *OpenAIProvider) GenerateStream(
 
 		// Log event type for debugging (first few events only)
 		if eventCount <= maxLogEventCountOpenAI {
-			log.Printf("📥 Stream event #%d: type=%s", eventCount, event.Type)
+			logger.Printf(ctx, "📥 Stream event #%d: type=%s", eventCount, event.Type)
 		}
 
 		// Handle different event types
@@ -1138,18 +1133,18 @@ func (p *OpenAIProvider) GenerateStream(
 
 		case "response.output_text.done":
 			// Text output complete
-			log.Printf("✅ Text output complete: %d chars accumulated", len(accumulatedText))
+			logger.Printf(ctx, "✅ Text output complete: %d chars accumulated", len(accumulatedText))
 
 		case "response.completed":
 			// Response complete - extract final response
 			completedEvent := event.AsResponseCompleted()
 			finalResponse = &completedEvent.Response
-			log.Printf("✅ Response completed")
+			logger.Printf(ctx, "✅ Response completed")
 
 		case "response.failed":
 			// Handle failure
 			failedEvent := event.AsResponseFailed()
-			log.Printf("❌ Stream failed: %s", failedEvent.Response.Error.Message)
+			logger.Printf(ctx, "❌ Stream failed: %s", failedEvent.Response.Error.Message)
 			span.Finish()
 			transaction.SetTag("success", "false")
 			return nil, fmt.Errorf("streaming failed: %s", failedEvent.Response.Error.Message)
@@ -1157,7 +1152,7 @@ func (p *OpenAIProvider) GenerateStream(
 		case "error":
 			// Handle error event
 			errorEvent := event.AsError()
-			log.Printf("❌ Stream error: %s", errorEvent.Message)
+			logger.Printf(ctx, "❌ Stream error: %s", errorEvent.Message)
 			span.Finish()
 			transaction.SetTag("success", "false")
 			return nil, fmt.Errorf("stream error: %s", errorEvent.Message)
@@ -1181,12 +1176,12 @@ func (p *OpenAIProvider) GenerateStream(
 
 		case "response.function_call_arguments.done":
 			// Tool call arguments complete
-			log.Printf("✅ Tool call arguments complete: %d chars", len(accumulatedText))
+			logger.Printf(ctx, "✅ Tool call arguments complete: %d chars", len(accumulatedText))
 
 		default:
 			// Log other event types for debugging
 			if eventCount <= maxLogEventCountOpenAI {
-				log.Printf("📋 Other event type: %s", event.Type)
+				logger.Printf(ctx, "📋 Other event type: %s", event.Type)
 			}
 		}
 
@@ -1210,7 +1205,7 @@ func (p *OpenAIProvider) GenerateStream(
 
 	// Check for stream error
 	if err := stream.Err(); err != nil {
-		log.Printf("❌ Stream error: %v", err)
+		logger.Printf(ctx, "❌ Stream error: %v", err)
 		transaction.SetTag("success", "false")
 		sentry.CaptureException(err)
 		return nil, fmt.Errorf("stream error: %w", err)
@@ -1218,7 +1213,7 @@ func (p *OpenAIProvider) GenerateStream(
 
 	// Log completion
 	duration := time.Since(startTime)
-	log.Printf("✅ OPENAI STREAMING COMPLETE: %d events, %d chars, %v duration",
+	logger.Printf(ctx, "✅ OPENAI STREAMING COMPLETE: %d events, %d chars, %v duration",
 		eventCount, len(accumulatedText), duration)
 
 	// Send completion event
@@ -1241,7 +1236,7 @@ func (p *OpenAIProvider) GenerateStream(
 	// Extract usage from final response if available
 	if finalResponse != nil {
 		response.Usage = finalResponse.Usage
-		p.logUsageStats(finalResponse.Usage)
+		p.logUsageStats(ctx, finalResponse.Usage)
 	}
 
 	transaction.SetTag("success", "true")
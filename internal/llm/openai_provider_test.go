@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -34,7 +35,7 @@ func TestOpenAIProvider_BuildRequestParams(t *testing.T) {
 			},
 			checks: func(t *testing.T, provider *OpenAIProvider, request *GenerationRequest) {
 				t.Helper()
-				params := provider.buildRequestParams(request)
+				params := provider.buildRequestParams(context.Background(), request)
 				assert.Equal(t, "gpt-5-mini", params.Model)
 				assert.NotNil(t, params.Instructions.Value)
 				assert.Equal(t, "test system prompt", params.Instructions.Value)
@@ -52,7 +53,7 @@ func TestOpenAIProvider_BuildRequestParams(t *testing.T) {
 			},
 			checks: func(t *testing.T, provider *OpenAIProvider, request *GenerationRequest) {
 				t.Helper()
-				params := provider.buildRequestParams(request)
+				params := provider.buildRequestParams(context.Background(), request)
 				assert.Equal(t, "gpt-5-mini", params.Model)
 			},
 		},
@@ -72,7 +73,7 @@ func TestOpenAIProvider_BuildRequestParams(t *testing.T) {
 			},
 			checks: func(t *testing.T, provider *OpenAIProvider, request *GenerationRequest) {
 				t.Helper()
-				params := provider.buildRequestParams(request)
+				params := provider.buildRequestParams(context.Background(), request)
 				assert.NotEmpty(t, params.Tools)
 				assert.Equal(t, 1, len(params.Tools))
 			},
@@ -99,7 +100,7 @@ func TestOpenAIProvider_BuildRequestParams(t *testing.T) {
 			},
 			checks: func(t *testing.T, provider *OpenAIProvider, request *GenerationRequest) {
 				t.Helper()
-				params := provider.buildRequestParams(request)
+				params := provider.buildRequestParams(context.Background(), request)
 				assert.NotNil(t, params.Text.Format)
 			},
 		},
@@ -137,7 +138,7 @@ func TestOpenAIProvider_ReasoningModeMapping(t *testing.T) {
 					{"role": "user", "content": "test"},
 				},
 			}
-			params := provider.buildRequestParams(request)
+			params := provider.buildRequestParams(context.Background(), request)
 			assert.NotNil(t, params.Reasoning.Effort)
 		})
 	}
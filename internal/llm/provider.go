@@ -2,6 +2,7 @@ package llm
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Conceptual-Machines/magda-api/internal/models"
 )
@@ -31,6 +32,9 @@ type GenerationRequest struct {
 	OutputSchema *OutputSchema
 	// CFG Grammar for DSL output (alternative to JSON Schema)
 	CFGGrammar *CFGConfig
+	// Sampling temperature and output token limit; nil and 0 use the provider's defaults
+	Temperature     *float64
+	MaxOutputTokens int
 }
 
 // CFGConfig contains context-free grammar configuration
@@ -64,6 +68,7 @@ type GenerationResponse struct {
 	MCPUsed   bool     `json:"mcpUsed,omitempty"`
 	MCPCalls  int      `json:"mcpCalls,omitempty"`
 	MCPTools  []string `json:"mcpTools,omitempty"`
+	Cached    bool     `json:"cached,omitempty"` // Served from the response cache; Usage is zero since nothing was billed
 }
 
 // StreamingProvider is an alias for Provider for backward compatibility
@@ -79,3 +84,13 @@ type StreamEvent struct {
 	Message string                 `json:"message,omitempty"`
 	Data    map[string]interface{} `json:"data,omitempty"`
 }
+
+// APIError is returned by raw HTTP provider calls when the API responds with a non-200 status
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
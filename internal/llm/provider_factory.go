@@ -8,7 +8,10 @@ import (
 
 // ProviderFactory creates providers based on model name
 type ProviderFactory struct {
-	openaiAPIKey string
+	openaiAPIKey    string
+	anthropicAPIKey string
+	anthropicModel  string
+	mockResponses   string // JSON file of canned responses for the mock provider
 }
 
 // NewProviderFactory creates a new provider factory
@@ -18,11 +21,67 @@ func NewProviderFactory(openaiAPIKey string) *ProviderFactory {
 	}
 }
 
+// WithAnthropic configures the Anthropic provider.
+// defaultModel is used for requests that name a non-Claude model.
+func (f *ProviderFactory) WithAnthropic(apiKey, defaultModel string) *ProviderFactory {
+	f.anthropicAPIKey = apiKey
+	f.anthropicModel = defaultModel
+	return f
+}
+
+// WithMockResponses sets the JSON file the mock provider reads its canned responses from
+func (f *ProviderFactory) WithMockResponses(path string) *ProviderFactory {
+	f.mockResponses = path
+	return f
+}
+
 // GetProvider returns the appropriate provider for the given model
 func (f *ProviderFactory) GetProvider(ctx context.Context, model string) (Provider, error) {
 	return f.getProviderByModel(ctx, model)
 }
 
+// GetProviderByName returns the provider selected by name ("openai", "anthropic" or "mock").
+// An empty name selects OpenAI.
+func (f *ProviderFactory) GetProviderByName(name string) (Provider, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", providerNameOpenAI:
+		if f.openaiAPIKey == "" {
+			return nil, fmt.Errorf("openai API key not configured")
+		}
+		return NewOpenAIProvider(f.openaiAPIKey), nil
+	case providerNameAnthropic:
+		if f.anthropicAPIKey == "" {
+			return nil, fmt.Errorf("anthropic API key not configured")
+		}
+		return NewAnthropicProvider(f.anthropicAPIKey, f.anthropicModel), nil
+	case providerNameMock:
+		if f.mockResponses == "" {
+			return NewMockProvider(), nil
+		}
+		responses, err := LoadMockResponses(f.mockResponses)
+		if err != nil {
+			return nil, err
+		}
+		return NewMockProvider(responses...), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q (supported: openai, anthropic, mock)", name)
+	}
+}
+
+// GetProviderBySpec returns the provider for a "name" or "name:model" spec.
+// The model form pins every request to that model, e.g. "openai:gpt-5-mini".
+func (f *ProviderFactory) GetProviderBySpec(spec string) (Provider, error) {
+	name, model, hasModel := strings.Cut(strings.TrimSpace(spec), ":")
+	provider, err := f.GetProviderByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if hasModel && strings.TrimSpace(model) != "" {
+		return WithModel(provider, strings.TrimSpace(model)), nil
+	}
+	return provider, nil
+}
+
 // getProviderByModel infers provider from model name
 func (f *ProviderFactory) getProviderByModel(_ context.Context, model string) (Provider, error) {
 	modelLower := strings.ToLower(model)
@@ -35,6 +94,14 @@ func (f *ProviderFactory) getProviderByModel(_ context.Context, model string) (P
 		return NewOpenAIProvider(f.openaiAPIKey), nil
 	}
 
+	// Claude models use Anthropic
+	if strings.HasPrefix(modelLower, "claude-") {
+		if f.anthropicAPIKey == "" {
+			return nil, fmt.Errorf("anthropic API key not configured")
+		}
+		return NewAnthropicProvider(f.anthropicAPIKey, f.anthropicModel), nil
+	}
+
 	// Default to OpenAI for unknown models
 	if f.openaiAPIKey == "" {
 		return nil, fmt.Errorf("openai API key not configured (default provider)")
@@ -9,25 +9,25 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// MockProvider is a test implementation of the Provider interface
-type MockProvider struct {
+// funcProvider is a test implementation of the Provider interface
+type funcProvider struct {
 	name               string
 	generateFunc       func(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error)
 	generateStreamFunc func(ctx context.Context, request *GenerationRequest, callback StreamCallback) (*GenerationResponse, error)
 }
 
-func (m *MockProvider) Name() string {
+func (m *funcProvider) Name() string {
 	return m.name
 }
 
-func (m *MockProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+func (m *funcProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
 	if m.generateFunc != nil {
 		return m.generateFunc(ctx, request)
 	}
 	return &GenerationResponse{}, nil
 }
 
-func (m *MockProvider) GenerateStream(
+func (m *funcProvider) GenerateStream(
 	ctx context.Context, request *GenerationRequest, callback StreamCallback,
 ) (*GenerationResponse, error) {
 	if m.generateStreamFunc != nil {
@@ -37,7 +37,7 @@ func (m *MockProvider) GenerateStream(
 }
 
 func TestProviderInterface(t *testing.T) {
-	mock := &MockProvider{
+	mock := &funcProvider{
 		name: "mock",
 	}
 
@@ -78,9 +78,9 @@ func TestGenerationResponse(t *testing.T) {
 	assert.Len(t, resp.MCPTools, 2)
 }
 
-func TestMockProviderGenerate(t *testing.T) {
+func TestFuncProviderGenerate(t *testing.T) {
 	callCount := 0
-	mock := &MockProvider{
+	mock := &funcProvider{
 		name: "test",
 		generateFunc: func(_ context.Context, request *GenerationRequest) (*GenerationResponse, error) {
 			callCount++
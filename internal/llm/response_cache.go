@@ -0,0 +1,404 @@
+package llm
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+	"github.com/openai/openai-go/responses"
+)
+
+const (
+	// DefaultCacheTTL is how long a cached response is served
+	DefaultCacheTTL = time.Hour
+	// DefaultCacheSize is how many responses the in-memory cache keeps
+	DefaultCacheSize = 256
+
+	responseCacheKeyPrefix = "magda:llmcache:"
+)
+
+// ResponseCache stores generation responses by request key
+type ResponseCache interface {
+	// Get returns the cached response for key; ok is false on a miss
+	Get(ctx context.Context, key string) (resp *GenerationResponse, ok bool, err error)
+	// Set stores resp under key until the cache's TTL runs out
+	Set(ctx context.Context, key string, resp *GenerationResponse) error
+}
+
+// NewResponseCache creates the cache for backend: "memory" or "redis".
+// "off" (or empty) disables caching and returns a nil cache.
+func NewResponseCache(backend, redisURL string, ttl time.Duration, size int) (ResponseCache, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", "off":
+		return nil, nil
+	case "memory":
+		return NewMemoryResponseCache(ttl, size), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis LLM cache")
+		}
+		return NewRedisResponseCache(redisURL, ttl)
+	default:
+		return nil, fmt.Errorf("unknown LLM cache %q: must be \"off\", \"memory\" or \"redis\"", backend)
+	}
+}
+
+// ResponseCacheStats counts cache lookups across all caching providers
+type ResponseCacheStats struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Errors int64 `json:"errors"` // Backend failures; the request goes to the provider instead
+}
+
+var responseCacheStats struct {
+	hits, misses, errors atomic.Int64
+}
+
+// ResponseCacheMetrics returns process-wide cache hit/miss counters
+func ResponseCacheMetrics() ResponseCacheStats {
+	return ResponseCacheStats{
+		Hits:   responseCacheStats.hits.Load(),
+		Misses: responseCacheStats.misses.Load(),
+		Errors: responseCacheStats.errors.Load(),
+	}
+}
+
+// CachingProvider serves repeated identical requests from a ResponseCache instead of
+// calling the wrapped provider. Only successful responses are cached.
+type CachingProvider struct {
+	Provider
+	cache ResponseCache
+}
+
+// NewCachingProvider wraps provider with cache
+func NewCachingProvider(provider Provider, cache ResponseCache) *CachingProvider {
+	return &CachingProvider{Provider: provider, cache: cache}
+}
+
+// Generate returns the cached response for request, or calls the provider and caches its response
+func (c *CachingProvider) Generate(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+	key, cached := c.lookup(ctx, request)
+	if cached != nil {
+		return cached, nil
+	}
+
+	resp, err := c.Provider.Generate(ctx, request)
+	if err == nil {
+		c.store(ctx, key, resp)
+	}
+	return resp, err
+}
+
+// GenerateStream replays a cached response as a single text delta, or streams from the
+// provider and caches the final response
+func (c *CachingProvider) GenerateStream(
+	ctx context.Context,
+	request *GenerationRequest,
+	callback StreamCallback,
+) (*GenerationResponse, error) {
+	key, cached := c.lookup(ctx, request)
+	if cached != nil {
+		if callback != nil {
+			events := []StreamEvent{
+				{Type: "started", Message: "Serving cached response..."},
+				{Type: "text_delta", Message: cached.RawOutput, Data: map[string]interface{}{
+					"accumulated_length": len(cached.RawOutput),
+					"cached":             true,
+				}},
+				{Type: "completed", Message: "Generation complete", Data: map[string]interface{}{
+					"total_length": len(cached.RawOutput),
+					"cached":       true,
+				}},
+			}
+			for _, event := range events {
+				if err := callback(event); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return cached, nil
+	}
+
+	resp, err := c.Provider.GenerateStream(ctx, request, callback)
+	if err == nil {
+		c.store(ctx, key, resp)
+	}
+	return resp, err
+}
+
+// lookup returns the request's cache key and the cached response, if any
+func (c *CachingProvider) lookup(ctx context.Context, request *GenerationRequest) (string, *GenerationResponse) {
+	key, err := CacheKey(request)
+	if err != nil {
+		logger.Printf(ctx, "⚠️  LLM CACHE: cannot key request, skipping cache: %v", err)
+		return "", nil
+	}
+
+	resp, ok, err := c.cache.Get(ctx, key)
+	switch {
+	case err != nil:
+		responseCacheStats.errors.Add(1)
+		logger.Printf(ctx, "⚠️  LLM CACHE: lookup failed, calling %s: %v", c.Name(), err)
+	case ok:
+		responseCacheStats.hits.Add(1)
+		logger.Printf(ctx, "⚡ LLM CACHE HIT: %s (%s)", key[:12], request.Model)
+		return key, cacheHit(resp)
+	default:
+		responseCacheStats.misses.Add(1)
+	}
+	return key, nil
+}
+
+// cacheHit returns a copy of a cached response marked as cached, with zero token usage so the
+// original request isn't billed again
+func cacheHit(resp *GenerationResponse) *GenerationResponse {
+	hit := *resp
+	hit.Cached = true
+	hit.Usage = responses.ResponseUsage{}
+	return &hit
+}
+
+func (c *CachingProvider) store(ctx context.Context, key string, resp *GenerationResponse) {
+	if key == "" || resp == nil {
+		return
+	}
+	if err := c.cache.Set(ctx, key, resp); err != nil {
+		responseCacheStats.errors.Add(1)
+		logger.Printf(ctx, "⚠️  LLM CACHE: failed to store response: %v", err)
+	}
+}
+
+// CacheKey hashes everything that determines a response: model, reasoning mode, system
+// prompt, input messages (question, state and history), output schema, grammar, MCP server,
+// temperature and output limit.
+// Message text is normalized so whitespace and JSON key order don't change the key.
+func CacheKey(request *GenerationRequest) (string, error) {
+	if request == nil {
+		return "", fmt.Errorf("nil request")
+	}
+
+	keyed := map[string]any{
+		"model":     request.Model,
+		"reasoning": request.ReasoningMode,
+		"system":    normalizeCacheText(request.SystemPrompt),
+		"input":     normalizeCacheValue(request.InputArray),
+	}
+	if request.OutputSchema != nil {
+		keyed["schema"] = request.OutputSchema
+	}
+	if request.CFGGrammar != nil {
+		keyed["grammar"] = request.CFGGrammar
+	}
+	if request.MCPConfig != nil {
+		keyed["mcp"] = request.MCPConfig.URL
+	}
+	if request.Temperature != nil {
+		keyed["temperature"] = *request.Temperature
+	}
+	if request.MaxOutputTokens > 0 {
+		keyed["max_output_tokens"] = request.MaxOutputTokens
+	}
+
+	// encoding/json sorts map keys, so equal requests encode identically
+	data, err := json.Marshal(keyed)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// normalizeCacheValue normalizes every string in an input array
+func normalizeCacheValue(value any) any {
+	switch v := value.(type) {
+	case string:
+		return normalizeCacheText(v)
+	case []map[string]any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeCacheValue(item)
+		}
+		return normalized
+	case []any:
+		normalized := make([]any, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeCacheValue(item)
+		}
+		return normalized
+	case map[string]any:
+		normalized := make(map[string]any, len(v))
+		for k, item := range v {
+			normalized[k] = normalizeCacheValue(item)
+		}
+		return normalized
+	default:
+		return v
+	}
+}
+
+// normalizeCacheText canonicalizes JSON text (e.g. a serialized project state) and collapses
+// whitespace in everything else
+func normalizeCacheText(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var parsed any
+		if json.Unmarshal([]byte(trimmed), &parsed) == nil {
+			if canonical, err := json.Marshal(parsed); err == nil {
+				return string(canonical)
+			}
+		}
+	}
+	return strings.Join(strings.Fields(trimmed), " ")
+}
+
+// MemoryResponseCache is an in-process LRU cache with a TTL
+type MemoryResponseCache struct {
+	ttl  time.Duration
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // Most recently used first
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type memoryCacheEntry struct {
+	key     string
+	resp    GenerationResponse
+	expires time.Time
+}
+
+// NewMemoryResponseCache creates an LRU cache. Non-positive values use the defaults.
+func NewMemoryResponseCache(ttl time.Duration, size int) *MemoryResponseCache {
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	if size <= 0 {
+		size = DefaultCacheSize
+	}
+	return &MemoryResponseCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get returns a copy of the cached response and marks it recently used
+func (m *MemoryResponseCache) Get(ctx context.Context, key string) (*GenerationResponse, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryCacheEntry)
+	if m.now().After(entry.expires) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(element)
+	resp := entry.resp
+	return &resp, true, nil
+}
+
+// Set stores a copy of resp, evicting the least recently used entry when full
+func (m *MemoryResponseCache) Set(ctx context.Context, key string, resp *GenerationResponse) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &memoryCacheEntry{key: key, resp: *resp, expires: m.now().Add(m.ttl)}
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(entry)
+	for m.order.Len() > m.size {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryCacheEntry).key)
+	}
+	return nil
+}
+
+// Len returns the number of cached responses, including expired ones not yet evicted
+func (m *MemoryResponseCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+// RedisResponseCache keeps responses in Redis so they are shared between instances.
+// Redis expires entries after the TTL; eviction beyond that is left to the server's maxmemory policy.
+type RedisResponseCache struct {
+	responses *redis.JSONStore[cachedResponse]
+}
+
+// cachedResponse is the stored form of a GenerationResponse (RawOutput is not serialized by default)
+type cachedResponse struct {
+	RawOutput string                 `json:"raw_output"`
+	Choices   []models.MusicalChoice `json:"choices,omitempty"`
+	Usage     any                    `json:"usage,omitempty"`
+	MCPUsed   bool                   `json:"mcp_used,omitempty"`
+	MCPCalls  int                    `json:"mcp_calls,omitempty"`
+	MCPTools  []string               `json:"mcp_tools,omitempty"`
+}
+
+// NewRedisResponseCache creates a cache for a redis://[user:password@]host:port[/db] URL.
+// A non-positive ttl uses the default. The connection is opened on first use.
+func NewRedisResponseCache(redisURL string, ttl time.Duration) (*RedisResponseCache, error) {
+	client, err := redis.NewClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &RedisResponseCache{
+		responses: redis.NewJSONStore[cachedResponse](client, responseCacheKeyPrefix, "cached response", ttl),
+	}, nil
+}
+
+// Get loads and decodes the cached response
+func (r *RedisResponseCache) Get(ctx context.Context, key string) (*GenerationResponse, bool, error) {
+	stored, err := r.responses.Get(ctx, key)
+	if err != nil || stored == nil {
+		return nil, false, err
+	}
+	resp := &GenerationResponse{
+		RawOutput: stored.RawOutput,
+		Usage:     stored.Usage,
+		MCPUsed:   stored.MCPUsed,
+		MCPCalls:  stored.MCPCalls,
+		MCPTools:  stored.MCPTools,
+	}
+	resp.OutputParsed.Choices = stored.Choices
+	return resp, true, nil
+}
+
+// Set stores resp with the cache's TTL
+func (r *RedisResponseCache) Set(ctx context.Context, key string, resp *GenerationResponse) error {
+	return r.responses.Set(ctx, key, &cachedResponse{
+		RawOutput: resp.RawOutput,
+		Choices:   resp.OutputParsed.Choices,
+		Usage:     resp.Usage,
+		MCPUsed:   resp.MCPUsed,
+		MCPCalls:  resp.MCPCalls,
+		MCPTools:  resp.MCPTools,
+	})
+}
@@ -0,0 +1,202 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis/redistest"
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cacheTestRequest(question string) *GenerationRequest {
+	return &GenerationRequest{
+		Model:        "gpt-5.1",
+		SystemPrompt: "You are a DAW assistant",
+		InputArray: []map[string]any{
+			{"role": "user", "content": question},
+			{"role": "user", "content": `{"tracks": [{"index": 0, "name": "Drums"}], "bpm": 120}`},
+		},
+		CFGGrammar: &CFGConfig{ToolName: "magda_dsl", Grammar: "start: call"},
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	base, err := CacheKey(cacheTestRequest("add reverb"))
+	require.NoError(t, err)
+
+	// Whitespace and JSON key order don't change the key
+	same := cacheTestRequest("  add   reverb ")
+	same.InputArray[1]["content"] = `{"bpm":120,"tracks":[{"name":"Drums","index":0}]}`
+	sameKey, err := CacheKey(same)
+	require.NoError(t, err)
+	assert.Equal(t, base, sameKey)
+
+	changes := map[string]func(r *GenerationRequest){
+		"question": func(r *GenerationRequest) { r.InputArray[0]["content"] = "add delay" },
+		"state":    func(r *GenerationRequest) { r.InputArray[1]["content"] = `{"tracks": [], "bpm": 120}` },
+		"model":    func(r *GenerationRequest) { r.Model = "gpt-5-mini" },
+		"system":   func(r *GenerationRequest) { r.SystemPrompt = "You are a mixing assistant" },
+		"grammar":  func(r *GenerationRequest) { r.CFGGrammar.Grammar = "start: other" },
+		"temperature": func(r *GenerationRequest) {
+			temperature := 0.5
+			r.Temperature = &temperature
+		},
+		"max_output_tokens": func(r *GenerationRequest) { r.MaxOutputTokens = 4000 },
+	}
+	for name, change := range changes {
+		t.Run(name, func(t *testing.T) {
+			request := cacheTestRequest("add reverb")
+			change(request)
+			key, err := CacheKey(request)
+			require.NoError(t, err)
+			assert.NotEqual(t, base, key)
+		})
+	}
+}
+
+func TestMemoryResponseCache_LRUAndTTL(t *testing.T) {
+	now := time.Now()
+	cache := NewMemoryResponseCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	require.NoError(t, cache.Set(ctx, "a", &GenerationResponse{RawOutput: "A"}))
+	require.NoError(t, cache.Set(ctx, "b", &GenerationResponse{RawOutput: "B"}))
+	_, ok, _ := cache.Get(ctx, "a") // a is now more recent than b
+	assert.True(t, ok)
+	require.NoError(t, cache.Set(ctx, "c", &GenerationResponse{RawOutput: "C"}))
+
+	_, ok, _ = cache.Get(ctx, "b")
+	assert.False(t, ok, "least recently used entry should be evicted")
+	resp, ok, _ := cache.Get(ctx, "a")
+	require.True(t, ok)
+	assert.Equal(t, "A", resp.RawOutput)
+	assert.Equal(t, 2, cache.Len())
+
+	now = now.Add(2 * time.Minute)
+	_, ok, _ = cache.Get(ctx, "c")
+	assert.False(t, ok, "expired entry should miss")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCachingProvider_Generate(t *testing.T) {
+	calls := 0
+	provider := &funcProvider{
+		name: "openai",
+		generateFunc: func(ctx context.Context, request *GenerationRequest) (*GenerationResponse, error) {
+			calls++
+			if request.InputArray[0]["content"] == "fail" {
+				return nil, errors.New("boom")
+			}
+			return &GenerationResponse{
+				RawOutput: fmt.Sprintf("output %d", calls),
+				Usage:     responses.ResponseUsage{InputTokens: 900, OutputTokens: 100, TotalTokens: 1000},
+			}, nil
+		},
+	}
+	caching := NewCachingProvider(provider, NewMemoryResponseCache(time.Hour, 10))
+	ctx := context.Background()
+	before := ResponseCacheMetrics()
+
+	first, err := caching.Generate(ctx, cacheTestRequest("add reverb"))
+	require.NoError(t, err)
+	second, err := caching.Generate(ctx, cacheTestRequest("add reverb"))
+	require.NoError(t, err)
+	assert.Equal(t, "output 1", first.RawOutput)
+	assert.Equal(t, "output 1", second.RawOutput)
+	assert.Equal(t, 1, calls)
+
+	// Hits are marked and carry no usage, so the original call isn't billed twice
+	assert.False(t, first.Cached)
+	assert.Equal(t, int64(1000), first.Usage.(responses.ResponseUsage).TotalTokens)
+	assert.True(t, second.Cached)
+	assert.Equal(t, responses.ResponseUsage{}, second.Usage)
+	assert.Equal(t, "openai", caching.Name())
+
+	// Errors are not cached
+	_, err = caching.Generate(ctx, cacheTestRequest("fail"))
+	require.Error(t, err)
+	_, err = caching.Generate(ctx, cacheTestRequest("fail"))
+	require.Error(t, err)
+	assert.Equal(t, 3, calls)
+
+	after := ResponseCacheMetrics()
+	assert.Equal(t, int64(1), after.Hits-before.Hits)
+	assert.Equal(t, int64(3), after.Misses-before.Misses)
+}
+
+func TestCachingProvider_GenerateStreamReplaysCachedOutput(t *testing.T) {
+	calls := 0
+	provider := &funcProvider{
+		name: "openai",
+		generateStreamFunc: func(ctx context.Context, request *GenerationRequest, callback StreamCallback) (*GenerationResponse, error) {
+			calls++
+			_ = callback(StreamEvent{Type: "text_delta", Message: "track("})
+			_ = callback(StreamEvent{Type: "text_delta", Message: "name=\"Bass\")"})
+			return &GenerationResponse{RawOutput: `track(name="Bass")`}, nil
+		},
+	}
+	caching := NewCachingProvider(provider, NewMemoryResponseCache(time.Hour, 10))
+
+	for i := 0; i < 2; i++ {
+		var streamed strings.Builder
+		resp, err := caching.GenerateStream(context.Background(), cacheTestRequest("create a bass track"),
+			func(event StreamEvent) error {
+				if event.Type == "text_delta" {
+					streamed.WriteString(event.Message)
+				}
+				return nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, `track(name="Bass")`, resp.RawOutput)
+		assert.Equal(t, `track(name="Bass")`, streamed.String())
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestRedisResponseCache(t *testing.T) {
+	server := redistest.Start(t)
+	cache, err := NewRedisResponseCache(server.URL, 10*time.Minute)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, ok, err := cache.Get(ctx, "k1")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	stored := &GenerationResponse{RawOutput: "track()", MCPUsed: true, MCPCalls: 2}
+	require.NoError(t, cache.Set(ctx, "k1", stored))
+	assert.Equal(t, 600, server.Expiry("magda:llmcache:k1"))
+
+	resp, ok, err := cache.Get(ctx, "k1")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "track()", resp.RawOutput)
+	assert.True(t, resp.MCPUsed)
+	assert.Equal(t, 2, resp.MCPCalls)
+}
+
+func TestNewResponseCache(t *testing.T) {
+	cache, err := NewResponseCache("off", "", 0, 0)
+	require.NoError(t, err)
+	assert.Nil(t, cache)
+
+	cache, err = NewResponseCache("memory", "", 0, 0)
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryResponseCache{}, cache)
+
+	cache, err = NewResponseCache("redis", "redis://localhost:6379", time.Minute, 0)
+	require.NoError(t, err)
+	assert.IsType(t, &RedisResponseCache{}, cache)
+
+	_, err = NewResponseCache("redis", "", time.Minute, 0)
+	assert.Error(t, err)
+	_, err = NewResponseCache("memcached", "", time.Minute, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,67 @@
+package llm
+
+// GetTemplateDSLGrammar returns the Lark grammar definition for the Template DSL.
+// A template declares a project layout by name - folders, tracks, buses, FX, sends and song
+// sections - and the template agent compiles it to DAW actions with track indices.
+func GetTemplateDSLGrammar() string {
+	return `
+// Template DSL Grammar - declarative project templates
+// SYNTAX:
+//   folder(name="Drums", color="red")
+//   track(name="Kick", folder="Drums", instrument="ReaSamplOmatic5000", color="orange")
+//   bus(name="Drum Bus", color="red")
+//   fx(track="Drum Bus", fxname="ReaComp")
+//   send(from="Kick", to="Drum Bus", level_db=-6)
+//   section(name="Intro", start_bar=1, end_bar=17, color="blue")
+//
+// Tracks, buses and folders are referenced by name. end_bar is exclusive.
+
+// ---------- Start rule ----------
+start: template_call (";" SP? template_call)*
+
+template_call: folder_call | track_call | bus_call | fx_call | send_call | section_call
+
+// ---------- Layout ----------
+folder_call: "folder" "(" folder_params ")"
+folder_params: folder_param ("," SP folder_param)*
+folder_param: "name" "=" STRING
+            | "color" "=" STRING
+
+track_call: "track" "(" track_params ")"
+track_params: track_param ("," SP track_param)*
+track_param: "name" "=" STRING
+           | "folder" "=" STRING
+           | "instrument" "=" STRING
+           | "color" "=" STRING
+
+bus_call: "bus" "(" bus_params ")"
+bus_params: bus_param ("," SP bus_param)*
+bus_param: "name" "=" STRING
+         | "folder" "=" STRING
+         | "color" "=" STRING
+
+// ---------- Routing and FX ----------
+fx_call: "fx" "(" "track" "=" STRING "," SP "fxname" "=" STRING ")"
+
+send_call: "send" "(" send_params ")"
+send_params: send_param ("," SP send_param)*
+send_param: "from" "=" STRING
+          | "to" "=" STRING
+          | "level_db" "=" NUMBER
+          | "pre_fader" "=" BOOLEAN
+
+// ---------- Song sections ----------
+section_call: "section" "(" section_params ")"
+section_params: section_param ("," SP section_param)*
+section_param: "name" "=" STRING
+             | "start_bar" "=" NUMBER
+             | "end_bar" "=" NUMBER
+             | "color" "=" STRING
+
+// ---------- Terminals ----------
+SP: " "+
+STRING: /"[^"]*"/
+NUMBER: /-?\d+(\.\d+)?/
+BOOLEAN: "true" | "false"
+`
+}
@@ -2,8 +2,8 @@ package logger
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"time"
 
 	"github.com/getsentry/sentry-go"
@@ -30,7 +30,7 @@ func WithContext(c *gin.Context) Fields {
 
 // Info logs an informational message with structured fields
 func Info(msg string, fields Fields) {
-	log.Printf("[INFO] %s %v", msg, formatFields(fields))
+	slog.Info(msg, fieldsToAttrs(fields)...)
 
 	// Send to Sentry as breadcrumb
 	if hub := sentry.CurrentHub(); hub.Client() != nil {
@@ -46,7 +46,11 @@ func Info(msg string, fields Fields) {
 
 // Error logs an error message with structured fields and sends to Sentry
 func Error(msg string, err error, fields Fields) {
-	log.Printf("[ERROR] %s: %v %v", msg, err, formatFields(fields))
+	attrs := fieldsToAttrs(fields)
+	if err != nil {
+		attrs = append(attrs, "error", err.Error())
+	}
+	slog.Error(msg, attrs...)
 
 	// Send to Sentry
 	if hub := sentry.CurrentHub(); hub.Client() != nil {
@@ -73,7 +77,7 @@ func Error(msg string, err error, fields Fields) {
 
 // Warn logs a warning message with structured fields
 func Warn(msg string, fields Fields) {
-	log.Printf("[WARN] %s %v", msg, formatFields(fields))
+	slog.Warn(msg, fieldsToAttrs(fields)...)
 
 	// Send to Sentry as breadcrumb
 	if hub := sentry.CurrentHub(); hub.Client() != nil {
@@ -89,7 +93,7 @@ func Warn(msg string, fields Fields) {
 
 // Debug logs a debug message with structured fields
 func Debug(msg string, fields Fields) {
-	log.Printf("[DEBUG] %s %v", msg, formatFields(fields))
+	slog.Debug(msg, fieldsToAttrs(fields)...)
 
 	// Send to Sentry as breadcrumb (only in development/debug mode)
 	if hub := sentry.CurrentHub(); hub.Client() != nil {
@@ -151,31 +155,19 @@ func LogGenerationRequest(ctx context.Context, model string, duration time.Durat
 	}
 }
 
-// formatFields converts Fields to a readable string
-func formatFields(fields Fields) string {
-	if len(fields) == 0 {
-		return ""
+// fieldsToAttrs converts Fields to slog key/value pairs in a stable (sorted) order
+func fieldsToAttrs(fields Fields) []any {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
 	}
-	// Simple formatting - could use JSON for production
-	result := "{"
-	first := true
-	for k, v := range fields {
-		if !first {
-			result += ", "
-		}
-		result += k + "="
-		switch val := v.(type) {
-		case string:
-			result += val
-		case int, int64, float64:
-			result += formatValue(val)
-		default:
-			result += formatValue(v)
-		}
-		first = false
+	sort.Strings(keys)
+
+	attrs := make([]any, 0, 2*len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, k, fields[k])
 	}
-	result += "}"
-	return result
+	return attrs
 }
 
 // LogToSentry sends a log message directly to Sentry as an event
@@ -206,19 +198,6 @@ func LogToSentry(level sentry.Level, msg string, fields Fields) {
 	}
 }
 
-func formatValue(v interface{}) string {
-	switch val := v.(type) {
-	case int:
-		return fmt.Sprintf("%d", val)
-	case int64:
-		return fmt.Sprintf("%d", val)
-	case float64:
-		return fmt.Sprintf("%.2f", val)
-	default:
-		return fmt.Sprintf("%v", val)
-	}
-}
-
 func convertFieldsToMap(fields Fields) map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, v := range fields {
@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "request_id"
+	traceIDKey   contextKey = "trace_id"
+)
+
+// WithRequestID returns a context whose log lines carry request_id
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID set by WithRequestID, if any
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTraceID returns a context whose log lines carry trace_id
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// TraceIDFromContext returns the trace ID set by WithTraceID, if any
+func TraceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(traceIDKey).(string)
+	return id
+}
+
+// Init installs the structured logger as the slog default. Output from the standard log
+// package goes through the same handler, so every line shares one format.
+// format is "json" or "text"; level is "debug", "info", "warn" or "error".
+func Init(format, level string) {
+	slog.SetDefault(slog.New(NewHandler(os.Stderr, format, level)))
+}
+
+// NewHandler creates a JSON or text handler that adds request_id and trace_id from the
+// record's context to every line
+func NewHandler(w io.Writer, format, level string) slog.Handler {
+	options := &slog.HandlerOptions{Level: parseLevel(level)}
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+	return contextHandler{Handler: handler}
+}
+
+func parseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// contextHandler adds correlation IDs from the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id := RequestIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	if id := TraceIDFromContext(ctx); id != "" {
+		record.AddAttrs(slog.String("trace_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+// Printf logs a formatted message with the context's correlation IDs.
+// The level follows the message's marker: ❌ or [ERROR] is an error, ⚠️ or [WARN] a warning.
+func Printf(ctx context.Context, format string, args ...any) {
+	logMessage(ctx, fmt.Sprintf(format, args...))
+}
+
+// Println logs its operands like log.Println, with the context's correlation IDs
+func Println(ctx context.Context, args ...any) {
+	logMessage(ctx, fmt.Sprintln(args...))
+}
+
+func logMessage(ctx context.Context, msg string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	msg = strings.TrimRight(msg, "\n")
+	slog.Default().Log(ctx, messageLevel(msg), msg)
+}
+
+// messageLevel infers a level from the emoji/prefix conventions of the existing log lines
+func messageLevel(msg string) slog.Level {
+	trimmed := strings.TrimSpace(msg)
+	switch {
+	case strings.HasPrefix(trimmed, "❌"), strings.HasPrefix(trimmed, "[ERROR]"):
+		return slog.LevelError
+	case strings.HasPrefix(trimmed, "⚠"), strings.HasPrefix(trimmed, "[WARN]"):
+		return slog.LevelWarn
+	default:
+		return slog.LevelInfo
+	}
+}
@@ -0,0 +1,86 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureDefault routes slog's default logger into a buffer for the duration of the test
+func captureDefault(t *testing.T, format, level string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(NewHandler(&buf, format, level)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+func TestPrintf_AddsCorrelationIDs(t *testing.T) {
+	buf := captureDefault(t, "json", "info")
+
+	ctx := WithTraceID(WithRequestID(context.Background(), "req-123"), "4bf92f3577b34da6a3ce929d0e0e4736")
+	Printf(ctx, "✅ Parsed %d actions", 3)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "✅ Parsed 3 actions", line["msg"])
+	assert.Equal(t, "INFO", line["level"])
+	assert.Equal(t, "req-123", line["request_id"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["trace_id"])
+}
+
+func TestPrintf_WithoutIDs(t *testing.T) {
+	buf := captureDefault(t, "json", "info")
+
+	Printf(context.Background(), "🚀 Starting")
+	Println(nil, "no context", 1) //nolint:staticcheck // nil contexts are tolerated
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	for _, raw := range lines {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		assert.NotContains(t, line, "request_id")
+		assert.NotContains(t, line, "trace_id")
+	}
+	assert.Contains(t, lines[1], `"msg":"no context 1"`)
+}
+
+func TestMessageLevel(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want slog.Level
+	}{
+		{msg: "❌ Failed to parse DSL", want: slog.LevelError},
+		{msg: "  [ERROR] boom", want: slog.LevelError},
+		{msg: "⚠️  Session store unavailable", want: slog.LevelWarn},
+		{msg: "[WARN] slow response", want: slog.LevelWarn},
+		{msg: "✅ Done", want: slog.LevelInfo},
+		{msg: "plain message", want: slog.LevelInfo},
+	}
+	for _, tt := range tests {
+		t.Run(tt.msg, func(t *testing.T) {
+			assert.Equal(t, tt.want, messageLevel(tt.msg))
+		})
+	}
+}
+
+func TestNewHandler_LevelAndFormat(t *testing.T) {
+	buf := captureDefault(t, "text", "warn")
+	ctx := WithRequestID(context.Background(), "req-456")
+
+	Printf(ctx, "✅ filtered out below warn")
+	Printf(ctx, "⚠️ kept")
+
+	out := buf.String()
+	assert.NotContains(t, out, "filtered out")
+	assert.Contains(t, out, "level=WARN")
+	assert.Contains(t, out, "request_id=req-456")
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/cloudwatch"
@@ -28,7 +29,7 @@ type Client struct {
 func NewClient(ctx context.Context, environment string) (*Client, error) {
 	// Only enable in production
 	if environment != "production" {
-		log.Printf("📊 CloudWatch Metrics: DISABLED (environment: %s)", environment)
+		logger.Printf(ctx, "📊 CloudWatch Metrics: DISABLED (environment: %s)", environment)
 		return &Client{
 			enabled:     false,
 			environment: environment,
@@ -37,12 +38,12 @@ func NewClient(ctx context.Context, environment string) (*Client, error) {
 
 	cfg, err := config.LoadDefaultConfig(ctx)
 	if err != nil {
-		log.Printf("⚠️  Failed to load AWS config for CloudWatch: %v", err)
+		logger.Printf(ctx, "⚠️  Failed to load AWS config for CloudWatch: %v", err)
 		return &Client{enabled: false}, nil
 	}
 
 	client := cloudwatch.NewFromConfig(cfg)
-	log.Printf("📊 CloudWatch Metrics: ✅ ENABLED (namespace: %s)", namespace)
+	logger.Printf(ctx, "📊 CloudWatch Metrics: ✅ ENABLED (namespace: %s)", namespace)
 
 	return &Client{
 		client:      client,
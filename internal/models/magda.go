@@ -31,3 +31,87 @@ type MusicalChoice struct {
 type MusicalOutput struct {
 	Choices []MusicalChoice `json:"choices"`
 }
+
+// ActionWarning describes a non-fatal problem found in a generated action list
+type ActionWarning struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	ActionIndex int    `json:"actionIndex"`
+}
+
+// ActionPreview describes one action for a confirmation dialog before anything is applied
+type ActionPreview struct {
+	ActionIndex int    `json:"actionIndex"`
+	Action      string `json:"action"`
+	Summary     string `json:"summary"`     // e.g. Delete track 2 "Drums"
+	Destructive bool   `json:"destructive"` // Removes or overwrites content
+}
+
+// FilterSummary reports, for one filtered statement, how many items matched the filter
+// and how many of those were already in the requested state (no-op actions)
+type FilterSummary struct {
+	Statement  string `json:"statement"`
+	Matched    int    `json:"matched"`
+	AlreadySet int    `json:"alreadySet"`
+	Message    string `json:"message"`
+}
+
+// DSLRepair is one deterministic rewrite applied to near-valid generated DSL before parsing
+type DSLRepair struct {
+	Rule    string `json:"rule"`    // Catalog entry, e.g. method_case
+	From    string `json:"from"`    // Text as generated
+	To      string `json:"to"`      // Text as parsed
+	Message string `json:"message"` // What the rule fixes
+}
+
+// DSLError describes a DSL syntax or execution error at a 1-based line and column
+type DSLError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Method  string `json:"method,omitempty"` // DSL method the error was raised in, e.g. set_track
+}
+
+// QueryAnswer is the result of one read-only DSL query (count, sum, min or max)
+type QueryAnswer struct {
+	Query   string         `json:"query"`          // The query as written, e.g. max(clips, clip.length)
+	Value   *float64       `json:"value"`          // Null for min/max over no items
+	Item    map[string]any `json:"item,omitempty"` // min/max only: the item holding the value
+	Message string         `json:"message"`        // Human-readable answer, e.g. "3 tracks"
+}
+
+// OutOfScope is returned instead of actions for requests unrelated to music production,
+// rejected by the scope check before any generation
+type OutOfScope struct {
+	Reason string `json:"reason"` // e.g. "This request is out of scope. MAGDA only handles ..."
+	Check  string `json:"check"`  // "local" (keyword lists) or "llm" (classification call)
+}
+
+// Clarification is returned instead of actions when a request is too ambiguous to act on.
+// The client shows Question and Options and sends the user's reply with the same session_id.
+type Clarification struct {
+	Question string   `json:"question"`          // e.g. "Which track should sound punchier?"
+	Options  []string `json:"options,omitempty"` // Suggested replies, e.g. track names
+}
+
+// ConfirmationRequired is returned instead of actions that would delete more than the safety
+// policy allows in one request, e.g. filter(tracks, track.index >= 0).delete(). The client
+// shows Summary and, unless Refused, sends the request again with confirm=true to apply them.
+type ConfirmationRequired struct {
+	Reason        string   `json:"reason"` // e.g. "This would delete 12 tracks (more than 4)."
+	DeletedTracks int      `json:"deletedTracks"`
+	DeletedClips  int      `json:"deletedClips"`
+	ClearsProject bool     `json:"clearsProject,omitempty"` // Every track in the project would be deleted
+	Summary       []string `json:"summary"`                 // The deletions, one line each
+	Refused       bool     `json:"refused,omitempty"`       // The policy refuses the batch even with confirm=true
+}
+
+// MusicalDefaults are what the arranger uses for a part the request doesn't spell out, kept
+// per session so "from now on write everything an octave lower" sticks. Zero fields are unset
+// and use the arranger's own defaults.
+type MusicalDefaults struct {
+	Octave        int    `json:"octave,omitempty"`         // Chord and arpeggio octave, 4 = middle C; bass parts move with it
+	Velocity      int    `json:"velocity,omitempty"`       // 1-127
+	ClipBars      int    `json:"clip_bars,omitempty"`      // Length of a part that doesn't give one
+	TimeSignature string `json:"time_signature,omitempty"` // e.g. "3/4"; the project's own time signature wins
+}
@@ -1,7 +1,10 @@
 package observability
 
 import (
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/openai/openai-go/responses"
 )
@@ -19,6 +22,26 @@ const (
 	gpt51MiniInputPrice  = 0.0005
 	gpt51MiniOutputPrice = 0.0015
 
+	// GPT-5.2 pricing
+	gpt52InputPrice  = 0.00175
+	gpt52OutputPrice = 0.014
+
+	// GPT-5-mini pricing
+	gpt5MiniInputPrice  = 0.00025
+	gpt5MiniOutputPrice = 0.002
+
+	// GPT-5-nano pricing
+	gpt5NanoInputPrice  = 0.00005
+	gpt5NanoOutputPrice = 0.0004
+
+	// GPT-4.1-mini pricing
+	gpt41MiniInputPrice  = 0.0004
+	gpt41MiniOutputPrice = 0.0016
+
+	// Claude Sonnet 4.5 pricing
+	claudeSonnet45InputPrice  = 0.003
+	claudeSonnet45OutputPrice = 0.015
+
 	// GPT-4o pricing
 	gpt4oInputPrice  = 0.005
 	gpt4oOutputPrice = 0.015
@@ -30,8 +53,8 @@ const (
 
 // ModelPricing contains pricing information per 1K tokens
 type ModelPricing struct {
-	InputPricePer1K  float64 // Price per 1K input tokens in USD
-	OutputPricePer1K float64 // Price per 1K output tokens in USD
+	InputPricePer1K  float64 `json:"input_per_1k"`  // Price per 1K input tokens in USD
+	OutputPricePer1K float64 `json:"output_per_1k"` // Price per 1K output tokens in USD
 }
 
 // PricingTable contains pricing for all models
@@ -45,7 +68,23 @@ var PricingTable = map[string]ModelPricing{
 		InputPricePer1K:  gpt51MiniInputPrice,
 		OutputPricePer1K: gpt51MiniOutputPrice,
 	},
+	"gpt-5.2": {
+		InputPricePer1K:  gpt52InputPrice,
+		OutputPricePer1K: gpt52OutputPrice,
+	},
+	"gpt-5-mini": {
+		InputPricePer1K:  gpt5MiniInputPrice,
+		OutputPricePer1K: gpt5MiniOutputPrice,
+	},
+	"gpt-5-nano": {
+		InputPricePer1K:  gpt5NanoInputPrice,
+		OutputPricePer1K: gpt5NanoOutputPrice,
+	},
 	// GPT-4 models
+	"gpt-4.1-mini": {
+		InputPricePer1K:  gpt41MiniInputPrice,
+		OutputPricePer1K: gpt41MiniOutputPrice,
+	},
 	"gpt-4o": {
 		InputPricePer1K:  gpt4oInputPrice,
 		OutputPricePer1K: gpt4oOutputPrice,
@@ -54,15 +93,59 @@ var PricingTable = map[string]ModelPricing{
 		InputPricePer1K:  gpt4oMiniInputPrice,
 		OutputPricePer1K: gpt4oMiniOutputPrice,
 	},
+	// Claude models
+	"claude-sonnet-4-5": {
+		InputPricePer1K:  claudeSonnet45InputPrice,
+		OutputPricePer1K: claudeSonnet45OutputPrice,
+	},
+}
+
+// ParsePricing parses MODEL_PRICING: a JSON object of model to
+// {"input_per_1k": USD, "output_per_1k": USD}
+func ParsePricing(text string) (map[string]ModelPricing, error) {
+	pricing := map[string]ModelPricing{}
+	if strings.TrimSpace(text) == "" {
+		return pricing, nil
+	}
+	if err := json.Unmarshal([]byte(text), &pricing); err != nil {
+		return nil, fmt.Errorf("invalid MODEL_PRICING: %w", err)
+	}
+	for model, p := range pricing {
+		if p.InputPricePer1K < 0 || p.OutputPricePer1K < 0 {
+			return nil, fmt.Errorf("invalid MODEL_PRICING: negative price for %q", model)
+		}
+	}
+	return pricing, nil
+}
+
+// SetPricing adds or replaces models in PricingTable. Call it at startup, before serving.
+func SetPricing(pricing map[string]ModelPricing) {
+	for model, p := range pricing {
+		PricingTable[model] = p
+	}
+}
+
+// pricingFor finds a model's pricing: an exact match, else the longest model name it starts
+// with (dated snapshots like "gpt-5.1-2025-11-13"), else GPT-5.1 pricing
+func pricingFor(model string) ModelPricing {
+	if pricing, exists := PricingTable[model]; exists {
+		return pricing
+	}
+	match := ""
+	for name := range PricingTable {
+		if strings.HasPrefix(model, name) && len(name) > len(match) {
+			match = name
+		}
+	}
+	if match != "" {
+		return PricingTable[match]
+	}
+	return PricingTable["gpt-5.1"]
 }
 
 // CalculateOpenAICost calculates the cost in USD for an OpenAI API call
 func CalculateOpenAICost(model string, usage responses.ResponseUsage) float64 {
-	pricing, exists := PricingTable[model]
-	if !exists {
-		// Default to GPT-5.1 pricing if model not found
-		pricing = PricingTable["gpt-5.1"]
-	}
+	pricing := pricingFor(model)
 
 	inputCost := (float64(usage.InputTokens) / tokensPerKilo) * pricing.InputPricePer1K
 	outputCost := (float64(usage.OutputTokens) / tokensPerKilo) * pricing.OutputPricePer1K
@@ -78,6 +161,66 @@ func CalculateOpenAICost(model string, usage responses.ResponseUsage) float64 {
 	return totalCost
 }
 
+// CalculateCost calculates the cost in USD of an agent result's usage: a ResponseUsage
+// (either provider), a pointer to one, or the usage map of a raw response.
+// Unknown usage costs nothing.
+func CalculateCost(model string, usage any) float64 {
+	responseUsage, ok := ToResponseUsage(usage)
+	if !ok {
+		return 0
+	}
+	return CalculateOpenAICost(model, responseUsage)
+}
+
+// ToResponseUsage converts the usage reported by agents to a ResponseUsage
+func ToResponseUsage(usage any) (responses.ResponseUsage, bool) {
+	switch u := usage.(type) {
+	case responses.ResponseUsage:
+		return u, true
+	case *responses.ResponseUsage:
+		if u == nil {
+			return responses.ResponseUsage{}, false
+		}
+		return *u, true
+	case map[string]any:
+		data, err := json.Marshal(u)
+		if err != nil {
+			return responses.ResponseUsage{}, false
+		}
+		var responseUsage responses.ResponseUsage
+		if err := json.Unmarshal(data, &responseUsage); err != nil {
+			return responses.ResponseUsage{}, false
+		}
+		return responseUsage, true
+	default:
+		return responses.ResponseUsage{}, false
+	}
+}
+
+// SumUsage adds up the token usage of several LLM calls, e.g. every agent a request ran.
+// Nil usage is skipped; usage that isn't token counts replaces the sum so far.
+func SumUsage(usages ...any) any {
+	var total any
+	for _, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		sum, ok := ToResponseUsage(total)
+		next, nextOK := ToResponseUsage(usage)
+		if !ok || !nextOK {
+			total = usage
+			continue
+		}
+		sum.InputTokens += next.InputTokens
+		sum.InputTokensDetails.CachedTokens += next.InputTokensDetails.CachedTokens
+		sum.OutputTokens += next.OutputTokens
+		sum.OutputTokensDetails.ReasoningTokens += next.OutputTokensDetails.ReasoningTokens
+		sum.TotalTokens += next.TotalTokens
+		total = sum
+	}
+	return total
+}
+
 // FormatCost formats a cost value as a USD string
 func FormatCost(cost float64) string {
 	// Format with specified precision for precision
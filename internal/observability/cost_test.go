@@ -0,0 +1,64 @@
+package observability
+
+import (
+	"testing"
+
+	"github.com/openai/openai-go/responses"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalculateCost(t *testing.T) {
+	usage := responses.ResponseUsage{InputTokens: 2000, OutputTokens: 1000, TotalTokens: 3000}
+	// gpt-4.1-mini: 2 * 0.0004 + 1 * 0.0016
+	want := 0.0024
+
+	tests := []struct {
+		name  string
+		model string
+		usage any
+		want  float64
+	}{
+		{name: "response usage", model: "gpt-4.1-mini", usage: usage, want: want},
+		{name: "pointer", model: "gpt-4.1-mini", usage: &usage, want: want},
+		{name: "raw usage map", model: "gpt-4.1-mini", usage: map[string]any{
+			"input_tokens": 2000.0, "output_tokens": 1000.0, "total_tokens": 3000.0,
+		}, want: want},
+		{name: "dated snapshot uses its model's pricing", model: "gpt-4.1-mini-2025-04-14", usage: usage, want: want},
+		{name: "unknown model uses GPT-5.1 pricing", model: "mystery", usage: usage, want: 0.005},
+		{name: "no usage", model: "gpt-4.1-mini", usage: nil, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, CalculateCost(tt.model, tt.usage), 1e-12)
+		})
+	}
+}
+
+func TestSumUsage(t *testing.T) {
+	usage := responses.ResponseUsage{InputTokens: 2000, OutputTokens: 1000, TotalTokens: 3000}
+	rawUsage := map[string]any{"input_tokens": 100.0, "output_tokens": 50.0, "total_tokens": 150.0}
+
+	assert.Nil(t, SumUsage())
+	assert.Nil(t, SumUsage(nil, nil))
+	assert.Equal(t, usage, SumUsage(nil, usage, nil))
+	assert.Equal(t, responses.ResponseUsage{InputTokens: 2100, OutputTokens: 1050, TotalTokens: 3150},
+		SumUsage(usage, nil, rawUsage))
+	assert.Equal(t, "opaque", SumUsage(usage, "opaque"))
+}
+
+func TestParsePricing(t *testing.T) {
+	pricing, err := ParsePricing(`{"my-model": {"input_per_1k": 0.002, "output_per_1k": 0.008}}`)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]ModelPricing{"my-model": {InputPricePer1K: 0.002, OutputPricePer1K: 0.008}}, pricing)
+
+	pricing, err = ParsePricing("")
+	require.NoError(t, err)
+	assert.Empty(t, pricing)
+
+	_, err = ParsePricing(`{"my-model": {"input_per_1k": -1}}`)
+	assert.Error(t, err)
+	_, err = ParsePricing(`not json`)
+	assert.Error(t, err)
+}
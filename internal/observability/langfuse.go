@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	langfuse "github.com/henomis/langfuse-go"
 	"github.com/henomis/langfuse-go/model"
 	"github.com/openai/openai-go/responses"
@@ -24,7 +25,7 @@ var globalClient *LangfuseClient
 // InitializeLangfuse initializes the global Langfuse client
 func InitializeLangfuse(ctx context.Context, cfg *config.Config) *LangfuseClient {
 	if !cfg.LangfuseEnabled || cfg.LangfuseSecretKey == "" {
-		log.Println("⚠️  Langfuse not configured (LANGFUSE_ENABLED=false or LANGFUSE_SECRET_KEY not set)")
+		logger.Println(ctx, "⚠️  Langfuse not configured (LANGFUSE_ENABLED=false or LANGFUSE_SECRET_KEY not set)")
 		globalClient = &LangfuseClient{enabled: false, ctx: ctx}
 		return globalClient
 	}
@@ -42,8 +43,8 @@ func InitializeLangfuse(ctx context.Context, cfg *config.Config) *LangfuseClient
 			ctx:     ctx,
 		}
 
-		log.Printf("✅ Langfuse initialized (host: %s)", cfg.LangfuseHost)
-		log.Printf("🔍 Langfuse: Public key set: %v, Secret key set: %v",
+		logger.Printf(ctx, "✅ Langfuse initialized (host: %s)", cfg.LangfuseHost)
+		logger.Printf(ctx, "🔍 Langfuse: Public key set: %v, Secret key set: %v",
 			os.Getenv("LANGFUSE_PUBLIC_KEY") != "",
 			os.Getenv("LANGFUSE_SECRET_KEY") != "")
 		return globalClient
@@ -66,8 +67,8 @@ func (c *LangfuseClient) IsEnabled() bool {
 	return c.enabled && c.client != nil
 }
 
-// StartTrace starts a new trace in Langfuse
-func (c *LangfuseClient) StartTrace(ctx context.Context, name string, metadata map[string]interface{}) *Trace {
+// StartTrace starts a new trace in Langfuse, with optional tags to filter traces by
+func (c *LangfuseClient) StartTrace(ctx context.Context, name string, metadata map[string]interface{}, tags ...string) *Trace {
 	if !c.IsEnabled() {
 		return &Trace{enabled: false, ctx: ctx}
 	}
@@ -75,13 +76,14 @@ func (c *LangfuseClient) StartTrace(ctx context.Context, name string, metadata m
 	trace, err := c.client.Trace(&model.Trace{
 		Name:     name,
 		Metadata: metadata,
+		Tags:     tags,
 	})
 	if err != nil {
-		log.Printf("⚠️  Failed to create Langfuse trace: %v", err)
+		logger.Printf(ctx, "⚠️  Failed to create Langfuse trace: %v", err)
 		return &Trace{enabled: false, ctx: ctx}
 	}
 
-	log.Printf("🔍 Langfuse: Created trace %s (name: %s)", trace.ID, name)
+	logger.Printf(ctx, "🔍 Langfuse: Created trace %s (name: %s)", trace.ID, name)
 	return &Trace{
 		trace:   trace,
 		enabled: true,
@@ -0,0 +1,331 @@
+package plugins
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultThreshold is the confidence a match needs to be used without asking the user
+	DefaultThreshold = 0.8
+	// minCandidateConfidence drops matches too weak to offer as an option
+	minCandidateConfidence = 0.5
+	// ambiguityMargin is how far the best match must lead the next plugin to win outright
+	ambiguityMargin = 0.1
+	// maxCandidates caps the options offered for an ambiguous name
+	maxCandidates = 4
+)
+
+// formatOrder ranks duplicate installs of the same plugin (VST3 > VST > AU > JS),
+// matching plugin.DefaultPreferences
+var formatOrder = []string{"VST3", "VST3i", "VST", "VSTi", "AU", "AUi", "CLAP", "CLAPi", "LV2", "LV2i", "JS", "ReaPlugs", "DX", "DXi"}
+
+var (
+	formatPrefix = regexp.MustCompile(`^([A-Za-z0-9]+):\s*`)
+	vendorSuffix = regexp.MustCompile(`\s*\(([^)]+)\)\s*$`)
+)
+
+// Plugin is an installed plugin, e.g. FullName "VSTi: Serum (Xfer Records)" with
+// Name "Serum", Format "VSTi" and Vendor "Xfer Records"
+type Plugin struct {
+	FullName     string `json:"full_name"`
+	Name         string `json:"name"`
+	Format       string `json:"format,omitempty"`
+	Vendor       string `json:"vendor,omitempty"`
+	IsInstrument bool   `json:"is_instrument"`
+}
+
+// ParsePlugin splits a REAPER plugin name into its format, base name and vendor
+func ParsePlugin(fullName string) Plugin {
+	fullName = strings.TrimSpace(fullName)
+	plugin := Plugin{FullName: fullName}
+
+	name := fullName
+	if m := formatPrefix.FindStringSubmatch(name); m != nil {
+		plugin.Format = m[1]
+		name = name[len(m[0]):]
+	}
+	if m := vendorSuffix.FindStringSubmatchIndex(name); m != nil && m[0] > 0 {
+		plugin.Vendor = name[m[2]:m[3]]
+		name = name[:m[0]]
+	}
+	plugin.Name = strings.TrimSpace(name)
+	plugin.IsInstrument = strings.HasSuffix(plugin.Format, "i") // VSTi, VST3i, AUi, ...
+	return plugin
+}
+
+// Status is the outcome of resolving a plugin name
+type Status string
+
+const (
+	StatusResolved  Status = "resolved"  // FullName is the plugin meant
+	StatusAmbiguous Status = "ambiguous" // Candidates are the plugins it could mean
+	StatusNotFound  Status = "not_found" // Nothing installed looks like it
+)
+
+// Candidate is an installed plugin a name may refer to
+type Candidate struct {
+	FullName   string  `json:"full_name"`
+	Confidence float64 `json:"confidence"` // 0..1
+}
+
+// Resolution is the result of resolving a plugin name
+type Resolution struct {
+	Query      string      `json:"query"`
+	Status     Status      `json:"status"`
+	FullName   string      `json:"full_name,omitempty"`  // Set when resolved
+	Confidence float64     `json:"confidence"`           // Of the best match
+	Candidates []Candidate `json:"candidates,omitempty"` // Best first, set when ambiguous
+}
+
+// Resolver maps a plugin name as a user wrote it to an installed plugin
+type Resolver interface {
+	Resolve(name string) Resolution
+}
+
+// Registry resolves plugin names against the plugins installed in a REAPER project
+type Registry struct {
+	plugins   []Plugin
+	threshold float64
+}
+
+// StateKeys are the state keys that list installed plugins: plugins is the inventory the
+// REAPER extension scans, installed_fx the older list of names
+var StateKeys = []string{"plugins", "installed_fx"}
+
+// NewRegistry creates a registry of the given plugin names as REAPER lists them
+func NewRegistry(fullNames []string) *Registry {
+	list := make([]Plugin, 0, len(fullNames))
+	for _, fullName := range fullNames {
+		list = append(list, ParsePlugin(fullName))
+	}
+	return NewRegistryFromPlugins(list)
+}
+
+// NewRegistryFromPlugins creates a registry of already parsed plugins
+func NewRegistryFromPlugins(list []Plugin) *Registry {
+	r := &Registry{threshold: DefaultThreshold}
+	seen := make(map[string]bool, len(list))
+	for _, plugin := range list {
+		if plugin.FullName == "" || seen[plugin.FullName] {
+			continue
+		}
+		seen[plugin.FullName] = true
+		r.plugins = append(r.plugins, plugin)
+	}
+	return r
+}
+
+// FromState creates a registry from the state's plugin lists (see StateKeys), whose entries
+// are plugin names or objects with a full_name (or name) and optionally is_instrument.
+// It returns nil when the state lists none.
+func FromState(state map[string]any) *Registry {
+	if inner, ok := state["state"].(map[string]any); ok {
+		state = inner
+	}
+
+	var list []Plugin
+	for _, key := range StateKeys {
+		items, _ := state[key].([]any)
+		for _, item := range items {
+			if plugin, ok := pluginFromState(item); ok {
+				list = append(list, plugin)
+			}
+		}
+	}
+
+	registry := NewRegistryFromPlugins(list)
+	if registry.Len() == 0 {
+		return nil
+	}
+	return registry
+}
+
+// pluginFromState parses a plugin list entry: a name or an object describing the plugin
+func pluginFromState(item any) (Plugin, bool) {
+	switch v := item.(type) {
+	case string:
+		return ParsePlugin(v), v != ""
+	case map[string]any:
+		fullName, _ := v["full_name"].(string)
+		if fullName == "" {
+			fullName, _ = v["name"].(string)
+		}
+		if fullName == "" {
+			return Plugin{}, false
+		}
+		plugin := ParsePlugin(fullName)
+		if isInstrument, ok := v["is_instrument"].(bool); ok {
+			plugin.IsInstrument = isInstrument
+		}
+		return plugin, true
+	}
+	return Plugin{}, false
+}
+
+// SetThreshold sets the confidence a match needs to be used without asking the user
+func (r *Registry) SetThreshold(threshold float64) {
+	r.threshold = threshold
+}
+
+// Len returns the number of plugins in the registry
+func (r *Registry) Len() int {
+	return len(r.plugins)
+}
+
+// Plugins returns the installed plugins, one per base name in the preferred format, in the
+// order they were listed
+func (r *Registry) Plugins() []Plugin {
+	preferred := make(map[string]int) // base name -> position in list
+	var list []Plugin
+	for _, plugin := range r.plugins {
+		key := compact(plugin.Name)
+		i, ok := preferred[key]
+		switch {
+		case !ok:
+			preferred[key] = len(list)
+			list = append(list, plugin)
+		case formatRank(plugin.Format) < formatRank(list[i].Format):
+			list[i] = plugin
+		}
+	}
+	return list
+}
+
+// Resolve finds the installed plugin a name refers to. The best match wins when it reaches
+// the threshold and clearly beats every other plugin; otherwise the close matches are
+// returned as candidates. Duplicate installs of a plugin count once, in the preferred format.
+func (r *Registry) Resolve(name string) Resolution {
+	resolution := Resolution{Query: name, Status: StatusNotFound}
+	query := compact(name)
+	if query == "" {
+		return resolution
+	}
+
+	// Score every plugin, keeping the best install of each base name
+	best := make(map[string]Candidate)
+	bestFormat := make(map[string]int)
+	for _, plugin := range r.plugins {
+		score := matchScore(name, query, plugin)
+		if score < minCandidateConfidence {
+			continue
+		}
+		key := compact(plugin.Name)
+		current, ok := best[key]
+		rank := formatRank(plugin.Format)
+		if !ok || score > current.Confidence || (score == current.Confidence && rank < bestFormat[key]) {
+			best[key] = Candidate{FullName: plugin.FullName, Confidence: score}
+			bestFormat[key] = rank
+		}
+	}
+	if len(best) == 0 {
+		return resolution
+	}
+
+	candidates := make([]Candidate, 0, len(best))
+	for _, candidate := range best {
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Confidence != candidates[j].Confidence {
+			return candidates[i].Confidence > candidates[j].Confidence
+		}
+		return candidates[i].FullName < candidates[j].FullName
+	})
+
+	top := candidates[0]
+	resolution.Confidence = top.Confidence
+	if top.Confidence >= r.threshold && (len(candidates) == 1 || top.Confidence-candidates[1].Confidence >= ambiguityMargin) {
+		resolution.Status = StatusResolved
+		resolution.FullName = top.FullName
+		return resolution
+	}
+
+	if len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+	resolution.Status = StatusAmbiguous
+	resolution.Candidates = candidates
+	return resolution
+}
+
+// matchScore rates how well a name matches a plugin, from 1 (same name) down to 0
+func matchScore(name, query string, plugin Plugin) float64 {
+	base := compact(plugin.Name)
+	switch {
+	case strings.EqualFold(strings.TrimSpace(name), plugin.FullName), query == base:
+		return 1
+	case base == "":
+		return 0
+	case strings.HasPrefix(base, query):
+		// "serum" in "serumfx", "proq" in "proq3": closer lengths score higher
+		return 0.7 + 0.25*coverage(query, base)
+	case strings.Contains(base, query):
+		return 0.6 + 0.25*coverage(query, base)
+	case strings.Contains(compact(plugin.Vendor), query):
+		// A vendor name matches all of its plugins equally
+		return minCandidateConfidence + 0.05
+	default:
+		// Typos: "valhala vintage verb"
+		return 0.95 * similarity(query, base)
+	}
+}
+
+// coverage is the share of target that query spans
+func coverage(query, target string) float64 {
+	return float64(len(query)) / float64(len(target))
+}
+
+// similarity is 1 minus the edit distance between a and b relative to the longer one
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := max(len(ra), len(rb))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+// levenshtein returns the number of single-rune edits that turn a into b
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// compact lowercases s and drops everything but letters and digits: "Pro-Q 3" -> "proq3"
+func compact(s string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// formatRank orders plugin formats by preference, unknown formats last
+func formatRank(format string) int {
+	for i, f := range formatOrder {
+		if strings.EqualFold(f, format) {
+			return i
+		}
+	}
+	return len(formatOrder)
+}
@@ -0,0 +1,151 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var installed = []string{
+	"VSTi: Serum (Xfer Records)",
+	"VST3i: Serum (Xfer Records)",
+	"VST: Serum FX (Xfer Records)",
+	"VST3: Pro-Q 3 (FabFilter)",
+	"VST3: Pro-C 2 (FabFilter)",
+	"VST: ValhallaVintageVerb (Valhalla DSP, LLC)",
+	"VST: ReaEQ (Cockos)",
+	"VST: ReaComp (Cockos)",
+	"JS: 1175 Compressor",
+}
+
+func TestParsePlugin(t *testing.T) {
+	tests := []struct {
+		fullName string
+		want     Plugin
+	}{
+		{"VSTi: Serum (Xfer Records)", Plugin{FullName: "VSTi: Serum (Xfer Records)", Name: "Serum", Format: "VSTi", Vendor: "Xfer Records", IsInstrument: true}},
+		{"JS: 1175 Compressor", Plugin{FullName: "JS: 1175 Compressor", Name: "1175 Compressor", Format: "JS"}},
+		{"Kontakt 7", Plugin{FullName: "Kontakt 7", Name: "Kontakt 7"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fullName, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParsePlugin(tt.fullName))
+		})
+	}
+}
+
+func TestRegistry_Resolve(t *testing.T) {
+	registry := NewRegistry(installed)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantStatus     Status
+		wantFullName   string
+		wantCandidates []string
+	}{
+		{name: "base name prefers VST3", query: "serum", wantStatus: StatusResolved, wantFullName: "VST3i: Serum (Xfer Records)"},
+		{name: "full name", query: "VSTi: Serum (Xfer Records)", wantStatus: StatusResolved, wantFullName: "VSTi: Serum (Xfer Records)"},
+		{name: "longer name", query: "Serum FX", wantStatus: StatusResolved, wantFullName: "VST: Serum FX (Xfer Records)"},
+		{name: "punctuation and spacing", query: "pro q", wantStatus: StatusResolved, wantFullName: "VST3: Pro-Q 3 (FabFilter)"},
+		{name: "typo", query: "valhala vintage verb", wantStatus: StatusResolved, wantFullName: "VST: ValhallaVintageVerb (Valhalla DSP, LLC)"},
+		{name: "weak single match", query: "compressor", wantStatus: StatusAmbiguous, wantCandidates: []string{"JS: 1175 Compressor"}},
+		{
+			name:           "shared prefix",
+			query:          "rea",
+			wantStatus:     StatusAmbiguous,
+			wantCandidates: []string{"VST: ReaEQ (Cockos)", "VST: ReaComp (Cockos)"},
+		},
+		{
+			name:           "vendor",
+			query:          "fabfilter",
+			wantStatus:     StatusAmbiguous,
+			wantCandidates: []string{"VST3: Pro-C 2 (FabFilter)", "VST3: Pro-Q 3 (FabFilter)"},
+		},
+		{name: "not installed", query: "Kontakt", wantStatus: StatusNotFound},
+		{name: "empty", query: "  ", wantStatus: StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := registry.Resolve(tt.query)
+			assert.Equal(t, tt.query, got.Query)
+			require.Equal(t, tt.wantStatus, got.Status, "candidates: %+v", got.Candidates)
+			assert.Equal(t, tt.wantFullName, got.FullName)
+
+			var candidates []string
+			for _, candidate := range got.Candidates {
+				candidates = append(candidates, candidate.FullName)
+			}
+			assert.Equal(t, tt.wantCandidates, candidates)
+		})
+	}
+}
+
+func TestRegistry_SetThreshold(t *testing.T) {
+	registry := NewRegistry(installed)
+	assert.Equal(t, StatusResolved, registry.Resolve("pro q").Status)
+
+	registry.SetThreshold(0.95)
+	got := registry.Resolve("pro q")
+	assert.Equal(t, StatusAmbiguous, got.Status)
+	assert.Equal(t, "VST3: Pro-Q 3 (FabFilter)", got.Candidates[0].FullName)
+}
+
+func TestRegistry_Plugins(t *testing.T) {
+	var names []string
+	for _, plugin := range NewRegistry(installed).Plugins() {
+		names = append(names, plugin.FullName)
+	}
+	assert.Equal(t, []string{
+		"VST3i: Serum (Xfer Records)",
+		"VST: Serum FX (Xfer Records)",
+		"VST3: Pro-Q 3 (FabFilter)",
+		"VST3: Pro-C 2 (FabFilter)",
+		"VST: ValhallaVintageVerb (Valhalla DSP, LLC)",
+		"VST: ReaEQ (Cockos)",
+		"VST: ReaComp (Cockos)",
+		"JS: 1175 Compressor",
+	}, names)
+}
+
+func TestFromState(t *testing.T) {
+	t.Run("names and objects", func(t *testing.T) {
+		registry := FromState(map[string]any{
+			"state": map[string]any{
+				"installed_fx": []any{
+					"VSTi: Serum (Xfer Records)",
+					map[string]any{"full_name": "VST3: Pro-Q 3 (FabFilter)", "name": "Pro-Q 3"},
+					map[string]any{"name": "JS: 1175 Compressor"},
+					"VSTi: Serum (Xfer Records)",
+				},
+			},
+		})
+		require.NotNil(t, registry)
+		assert.Equal(t, 3, registry.Len())
+		assert.Equal(t, "VSTi: Serum (Xfer Records)", registry.Resolve("serum").FullName)
+	})
+
+	t.Run("extension inventory", func(t *testing.T) {
+		registry := FromState(map[string]any{
+			"plugins": []any{
+				map[string]any{"full_name": "JS: ReaSynth", "is_instrument": true},
+				map[string]any{"full_name": "VST3: Pro-Q 3 (FabFilter)", "is_instrument": false},
+			},
+			"installed_fx": []any{"VST: ReaEQ (Cockos)"},
+		})
+		require.NotNil(t, registry)
+		assert.Equal(t, []Plugin{
+			{FullName: "JS: ReaSynth", Name: "ReaSynth", Format: "JS", IsInstrument: true},
+			{FullName: "VST3: Pro-Q 3 (FabFilter)", Name: "Pro-Q 3", Format: "VST3", Vendor: "FabFilter"},
+			{FullName: "VST: ReaEQ (Cockos)", Name: "ReaEQ", Format: "VST", Vendor: "Cockos"},
+		}, registry.Plugins())
+	})
+
+	t.Run("no installed plugins", func(t *testing.T) {
+		assert.Nil(t, FromState(map[string]any{"tracks": []any{}}))
+		assert.Nil(t, FromState(nil))
+	})
+}
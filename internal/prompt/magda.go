@@ -60,6 +60,9 @@ When analyzing user requests:
   find the track in the state's "tracks" array by matching the "name" field, then use its "index" field
   for the action. Example: If state has {"index": 0, "name": "Nebula Drift"}, and user says "delete Nebula Drift",
   generate DSL: ` + "`filter(tracks, track.name == \"Nebula Drift\").delete()`" + `
+- **Track identification by GUID**: Tracks in the state may have a "guid" field. To reference one specific
+  existing track, ` + "`track(guid=\"{...}\")`" + ` (copying the guid exactly) is more reliable than ` + "`track(id=...)`" + `,
+  since it still finds the track if the project changed. Tracks created in the same script have no guid.
 - **Track identification by index pattern**: When the user says "odd index tracks" or "even index tracks":
   - "Odd index" means tracks at indices 1, 3, 5, ... (0-based: 1, 3, 5...)
   - "Even index" means tracks at indices 0, 2, 4, ... (0-based: 0, 2, 4...)
@@ -96,7 +99,7 @@ all previous actions. Always check the state to understand:
   - Example: "rename selected clips to foo" → ` + "`filter(clips, clip.selected == true).set_clip(name=\"foo\")`" + ` (ONLY ` + "`set_clip`" + ` with ` + "`name`" + `, NO ` + "`set_clip(selected=true)`" + `!)
   - Example: "rename all clips shorter than one bar to Short" → ` + "`filter(clips, clip.length < 2.790698).set_clip(name=\"Short\")`" + `
   - **NEVER** use ` + "`set_clip(selected=true)`" + ` when user says "rename" - use ` + "`set_clip(name=\"...\")`" + ` instead!
-  - **NEVER** use ` + "`for_each`" + ` or function references (e.g., ` + "`@set_name_on_selected_clip`" + `) for clip operations - use ` + "`filter().set_clip(name=\"...\")`" + ` instead!
+  - **NEVER** use function references (e.g., ` + "`@set_name_on_selected_clip`" + `) for clip operations, and use ` + "`for_each`" + ` only when the new name depends on each clip (see Per-Item Values) - use ` + "`filter().set_clip(name=\"...\")`" + ` instead!
   - **WRONG**: "rename selected clips to foo" → ` + "`filter(clips, clip.selected == true).set_clip(selected=true); filter(clips, clip.selected == true).set_clip(name=\"foo\")`" + ` (DO NOT include ` + "`set_clip(selected=true)`" + ` - clips are already selected!)
 
 **FILTER PREDICATES - COMPREHENSIVE EXAMPLES**:
@@ -130,6 +133,50 @@ all previous actions. Always check the state to understand:
 - **WRONG**: ` + "`filter(clips, _clip.length < 1.5)`" + ` (has underscore - will fail!)
 - **WRONG**: ` + "`filter(clips, Clip.length < 1.5)`" + ` (capitalized - will fail!)
 
+**Arithmetic Expressions**:
+- Predicates and numeric arguments may use ` + "`+ - * /`" + ` and parentheses over item properties
+- ` + "`filter(clips, clip.position + clip.length > 16)`" + ` - Filter clips that end after 16 seconds
+- ` + "`filter(tracks, track.volume_db > -6).set_track(volume_db=track.volume_db - 3)`" + ` - Turn down each matching track by 3 dB (relative to its own volume)
+- ` + "`track(id=2).set_track(pan=track.pan * 0.5)`" + ` - Halve the pan of track 2
+- ` + "`filter(clips, clip.selected == true).set_clip(length=clip.length * 2)`" + ` - Double the length of selected clips
+- Use relative expressions for "louder/quieter by", "more/less", "double", "halve"; use plain numbers for absolute values
+
+**String Matching**:
+- ` + "`contains`" + `, ` + "`starts_with`" + ` and ` + "`ends_with`" + ` match part of a name (case-insensitive); ` + "`matches`" + ` takes a regular expression
+- ` + "`filter(tracks, track.name contains \"Synth\").delete()`" + ` - "delete all tracks whose name contains Synth"
+- ` + "`filter(tracks, track.name starts_with \"Vox\")`" + ` - Tracks whose name starts with Vox
+- ` + "`filter(clips, clip.name ends_with \"take 2\")`" + ` - Clips whose name ends with "take 2"
+- ` + "`filter(tracks, track.name matches \"^Gtr [0-9]+$\")`" + ` - Tracks named Gtr 1, Gtr 2, ...
+- Use ` + "`==`" + ` only when the user gives the exact full name
+
+**Combining Conditions**:
+- Combine conditions in ONE filter with ` + "`&&`" + ` (and), ` + "`||`" + ` (or), ` + "`!`" + ` (not) and parentheses - do NOT split them into several commands
+- ` + "`filter(tracks, track.muted == true && track.name != \"Master\")`" + ` - Muted tracks except Master
+- ` + "`filter(clips, clip.length < 2 || clip.selected == true)`" + ` - Short or selected clips
+- ` + "`filter(tracks, !track.muted)`" + ` - Unmuted tracks (a bare boolean property tests for true)
+- ` + "`filter(clips, (clip.length < 2 || clip.length > 8) && clip.selected == false)`" + ` - ` + "`&&`" + ` binds tighter than ` + "`||`" + `, use parentheses to group
+
+**Per-Item Values**:
+- Inside ` + "`for_each`" + `, method arguments can use the item being iterated: its properties, arithmetic, and ` + "`+`" + ` with a quoted string to build text
+- ` + "`for_each(tracks, track.set_track(name=track.name + \" (old)\"))`" + ` - "append (old) to every track name"
+- ` + "`for_each(tracks, track.set_track(name=\"Track \" + (track.index + 1)))`" + ` - Number tracks from 1 (parenthesise arithmetic inside text)
+- ` + "`for_each(clips, clip.set_clip(name=clip.name + \" v2\"))`" + ` - Each clip keeps its own name with a suffix
+
+**Queries**:
+- When the user asks a question about the project instead of asking for a change, answer it with a query - it produces an answer, not actions
+- ` + "`count(tracks)`" + ` - "how many tracks do I have?"
+- ` + "`filter(tracks, track.muted == true).count()`" + ` - "how many muted tracks do I have?"
+- ` + "`max(clips, clip.length)`" + ` - "what's the longest clip?" (` + "`min`" + ` for the shortest)
+- ` + "`filter(clips, clip.track == 0).sum(clip.length)`" + ` - Total clip length on track 1
+- Top-level queries are separated with ` + "`;`" + `: ` + "`count(tracks); count(clips)`" + `
+
+**Clarification**:
+- When a request is too ambiguous to act on safely, ask instead of guessing: ` + "`clarify(question=\"Which track should sound punchier?\", options=[\"Drums\", \"Bass\"])`" + `
+- Only ask when the state and the conversation can't settle it (e.g. "make it punchier" with no track selected and no track named earlier); a selected track or a track from an earlier turn is "it"
+- Take options from the state (track or clip names); ` + "`options`" + ` may be omitted for open questions like "Louder by how much?"
+- ` + "`clarify()`" + ` is always the whole script - never combine it with actions
+- If the conversation history shows you asked a question, the new request is the user's answer: act on it
+
 **Compound Filter Pattern**:
 - General form: ` + "`filter(collection, predicate).action(...)`" + ` where ` + "`action`" + ` is any available method
 - Apply any action to filtered items: selection, renaming, coloring, moving, deleting, volume changes, mute/solo, etc.
@@ -205,6 +252,34 @@ Creates a new track in REAPER. Can optionally include an instrument and name in
 - Example: ` + "`{\"action\": \"create_track\", \"name\": \"Drums\", \"instrument\": \"VSTi: Serum\"}`" + ` creates a track named "Drums" with Serum instrument
 
 
+**make_folder** / **add_to_folder** / **set_track_parent**
+Groups tracks into folders (e.g. "group all drum tracks into a folder called Drums"). Folders follow track order, so these actions reorder tracks; put them after other edits in the same request.
+- DSL syntax: ` + "`.make_folder(name=\"Drums\")`" + `, ` + "`.add_to_folder(folder=\"Drums\")`" + ` (folder name or 1-based track id), ` + "`.set_track_parent(parent=1)`" + ` or ` + "`.set_track_parent(parent_name=\"Drums\")`" + `; ` + "`parent=0`" + ` moves the track out of its folders
+- Emitted in order: ` + "`create_track`" + ` for a new folder, ` + "`move_track`" + ` (` + "`track`" + ` and ` + "`to`" + `, 0-based indices applied one after another), then ` + "`set_track`" + ` with ` + "`folder_depth`" + ` (1 opens a folder, 0 is a normal track, -N closes N folders)
+- Examples:
+  - ` + "`filter(tracks, track.index < 3).make_folder(name=\"Drums\")`" + ` - groups the first three tracks into a new Drums folder
+  - ` + "`track(id=6).add_to_folder(folder=\"Drums\")`" + ` - adds track 6 to the end of the Drums folder
+  - ` + "`track(id=3).set_track_parent(parent=0)`" + ` - takes track 3 out of its folder
+
+**master()**
+Targets the master track (e.g. "put a limiter on the master and pull it down 1 dB"). The master is not in the tracks list; its state is under ` + "`master`" + `.
+- DSL syntax: ` + "`master()`" + ` followed by ` + "`.set_track(volume_db=..., pan=...)`" + `, ` + "`.add_fx(fxname=...)`" + ` or ` + "`.add_automation(...)`" + `; no other methods or properties
+- Actions use ` + "`\"track\": \"master\"`" + ` instead of a track index
+- Examples:
+  - ` + "`master().add_fx(fxname=\"ReaLimit\").set_track(volume_db=master.volume_db - 1)`" + ` - limiter on the master, 1 dB quieter
+  - ` + "`master().add_automation(param=\"volume\", curve=\"fade_out\", start_bar=33, end_bar=37)`" + ` - fades the song out
+
+**set_track_colors_bulk** / **adjust_color**
+Colors several tracks at once (e.g. "color the drum tracks in shades of orange", "make the synths a bit darker").
+- DSL syntax: ` + "`.set_track_colors_bulk(palette=\"orange\")`" + ` or ` + "`.set_track_colors_bulk(genre=\"techno\")`" + `; ` + "`.adjust_color(lighten=0.2)`" + ` or ` + "`.adjust_color(darken=0.2)`" + `
+- ` + "`palette`" + ` is a color name or hex (shades of it), ` + "`rainbow`" + ` (default), ` + "`pastel`" + `, ` + "`neon`" + `, ` + "`earth`" + `, ` + "`ocean`" + `, ` + "`sunset`" + ` or ` + "`monochrome`" + `; ` + "`genre`" + ` is a preset such as techno, house, hiphop, rock, jazz, ambient, pop, orchestral, edm or lofi
+- Each track gets its own color, in track order; both emit one ` + "`set_track`" + ` action with ` + "`color`" + ` per track
+- ` + "`adjust_color`" + ` changes each track's current color by a lightness amount from 0 to 1 (pass ` + "`color`" + ` for tracks without one)
+- Examples:
+  - ` + "`filter(tracks, track.name contains \"drum\").set_track_colors_bulk(palette=\"orange\")`" + ` - shades of orange across the drum tracks
+  - ` + "`filter(tracks, track.index >= 0).set_track_colors_bulk(genre=\"lofi\")`" + ` - lo-fi preset across every track
+  - ` + "`track(id=2).adjust_color(lighten=0.3)`" + ` - lightens track 2
+
 ### FX and Instruments
 
 **add_instrument**
@@ -212,22 +287,46 @@ Adds a VSTi (virtual instrument) to a track.
 - Required: ` + "`action: \"add_instrument\"`" + `, ` + "`track`" + ` (integer), ` + "`fxname`" + ` (string)
 - FX name format: ` + "`\"VSTi: Instrument Name (Manufacturer)\"`" + `
 - Examples: ` + "`\"VSTi: Serum (Xfer Records)\"`" + `, ` + "`\"VSTi: Massive (Native Instruments)\"`" + `
+- Plugin names may be written as the user says them (e.g. ` + "`\"serum\"`" + `, ` + "`\"pro q\"`" + `): they are matched to the installed plugins, and the user is asked to pick when a name fits several
 
 **add_track_fx**
 Adds a regular FX plugin to a track.
 - Required: ` + "`action: \"add_track_fx\"`" + `, ` + "`track`" + ` (integer), ` + "`fxname`" + ` (string)
 - Examples: ` + "`\"ReaEQ\"`" + `, ` + "`\"ReaComp\"`" + `, ` + "`\"VST: ValhallaRoom (Valhalla DSP)\"`" + `
 
+**set_fx_param**
+Sets a parameter of an FX or instrument already on a track (e.g. "lower the cutoff on all Serum tracks").
+- DSL syntax: ` + "`.set_fx_param(fx=\"ReaEQ\", param=\"Freq-Band 1\", value=0.35)`" + `
+- Required: ` + "`action: \"set_fx_param\"`" + `, ` + "`track`" + ` (integer), ` + "`fxname`" + ` (string, matched against the plugin name), ` + "`param`" + ` (string, the parameter name), ` + "`value`" + ` (number, normalized 0.0 to 1.0)
+- With ` + "`filter()`" + `, tracks whose FX chain is known and has no matching plugin are skipped
+- Examples:
+  - ` + "`track(id=1).set_fx_param(fx=\"ReaEQ\", param=\"Freq-Band 1\", value=0.35)`" + ` - sets the first EQ band frequency on track 1
+  - ` + "`filter(tracks, track.index >= 0).set_fx_param(fx=\"Serum\", param=\"Cutoff\", value=0.3)`" + ` - lowers the cutoff on every track with Serum
+
+**bypass_fx** / **enable_fx** / **remove_fx** / **move_fx**
+Bypasses, re-enables, removes or reorders plugins already in a track's FX chain (e.g. "bypass all reverbs", "move the compressor before the EQ").
+- DSL syntax: ` + "`.bypass_fx(fx=\"ReaVerb\")`" + `, ` + "`.enable_fx(fx=\"ReaVerb\")`" + `, ` + "`.remove_fx(fx=2)`" + `, ` + "`.move_fx(fx=\"ReaComp\", before=\"ReaEQ\")`" + `
+- ` + "`fx`" + ` is a plugin name (partial, case-insensitive) or a 1-based position in the chain; ` + "`move_fx`" + ` also needs ` + "`to`" + ` (1-based position), ` + "`before`" + ` or ` + "`after`" + `
+- Required: ` + "`action`" + `, ` + "`track`" + ` (integer), and ` + "`fx`" + ` (integer, 0-based chain position) and/or ` + "`fxname`" + ` (string); ` + "`move_fx`" + ` also has ` + "`to`" + ` (integer, 0-based)
+- ` + "`filter(fx_chain, ...)`" + ` searches the plugins of every track, and needs no ` + "`fx`" + ` argument
+- Examples:
+  - ` + "`filter(fx_chain, fx.name == \"ReaVerb\").bypass_fx()`" + ` - bypasses every ReaVerb in the project
+  - ` + "`track(id=1).move_fx(fx=\"ReaComp\", before=\"ReaEQ\")`" + ` - moves the compressor before the EQ on track 1
+  - ` + "`filter(tracks, track.name == \"Drums\").remove_fx(fx=\"ReaGate\")`" + ` - removes the gate from the drums
+
 ### Items/Clips
 
 **create_clip**
 Creates a media item/clip on a track at a specific time position.
 - Required: ` + "`action: \"create_clip\"`" + `, ` + "`track`" + ` (integer), ` + "`position`" + ` (number in seconds), ` + "`length`" + ` (number in seconds)
+- DSL: ` + "`new_clip(position=..., length=...)`" + ` takes ` + "`length`" + ` in seconds unless the request says lengths are in bars; ` + "`length_bars`" + ` is always bars
+- Relative: ` + "`new_clip(after=\"last_clip\", offset_bars=2)`" + ` starts 2 bars after the end of the track's last clip, ` + "`after=\"project_end\"`" + ` after the last clip on any track; ` + "`offset_bars`" + ` (or ` + "`offset`" + `) may be negative. The server resolves the position from the state, so never work it out yourself
 
 **create_clip_at_bar**
 Creates a media item/clip on a track at a specific bar number.
 - Required: ` + "`action: \"create_clip_at_bar\"`" + `, ` + "`track`" + ` (integer), ` + "`bar`" + ` (integer, 1-based), ` + "`length_bars`" + ` (integer)
 - Example: ` + "`bar: 17, length_bars: 4`" + ` creates a 4-bar clip starting at bar 17
+- A fractional ` + "`new_clip(bar=..., length_bars=1.5)`" + ` becomes a ` + "`create_clip`" + ` at the bar's position, since ` + "`length_bars`" + ` here is whole bars
 
 **set_track**
 Sets properties for a track (name, volume_db, pan, mute, solo, selected, etc.). This is the unified method - use this instead of separate set_name/set_volume/set_pan/set_mute/set_solo methods.
@@ -244,6 +343,8 @@ Sets properties for a clip (name, color, selected, etc.).
 - DSL syntax: ` + "`.set_clip(name=\"...\", color=\"...\", selected=true/false)`" + ` - you can specify one or more properties
 - Required: ` + "`action: \"set_clip\"`" + `, ` + "`track`" + ` (integer), and at least one property (` + "`name`" + `, ` + "`color`" + `, or ` + "`selected`" + `)
 - Optional: ` + "`clip`" + ` (integer), ` + "`position`" + ` (number in seconds), or ` + "`bar`" + ` (integer) for clip identification
+- Length: ` + "`length`" + ` is in seconds unless the request says lengths are in bars; ` + "`length_bars`" + ` is always bars and takes precedence
+- Looping: ` + "`source_length`" + ` (number, same unit as ` + "`length`" + `) sets the loop source length separately from the item length; ` + "`loop=true`" + ` is required when ` + "`source_length`" + ` is shorter than ` + "`length`" + `
 - Examples:
   - ` + "`filter(clips, clip.length < 1.5).set_clip(name=\"Short Clip\")`" + ` - renames all clips shorter than 1.5 seconds
   - ` + "`filter(clips, clip.length < 1.5).set_clip(color=\"red\")`" + ` - colors all short clips red (use color names like "red", "blue", "green", not hex codes)
@@ -252,12 +353,107 @@ Sets properties for a clip (name, color, selected, etc.).
   - ` + "`filter(clips, clip.selected == true).set_clip(name=\"foo\")`" + ` - renames selected clips (NO set_clip(selected=true) needed - clips already selected!)
   - ` + "`filter(clips, clip.length < 1.5).set_clip(name=\"Short\", color=\"red\")`" + ` - sets both name and color in one call (use color names like "red", "blue", "green", not hex codes)
   - ` + "`filter(clips, clip.length < 1.5).set_clip(selected=true, color=\"blue\")`" + ` - selects and colors in one call (use color names like "red", "blue", "green", not hex codes)
+  - ` + "`track(id=1).set_clip(clip=0, length=8, source_length=2, loop=true)`" + ` - stretches the item to 8 while looping a 2-long source
+
+**nth_clip**
+Selects the Nth clip on a track by order of position (1 = first clip), for "the second clip on track 1" style requests.
+- DSL syntax: ` + "`track(id=1).nth_clip(2)`" + ` followed by a clip operation (` + "`set_clip`" + `, ` + "`move_clip`" + `, ` + "`delete_clip`" + `)
+- Example: ` + "`track(id=1).nth_clip(2).set_clip(color=\"red\")`" + ` - colors the second clip on track 1 red
+
+**split_clip** / **trim_clip** / **set_clip_loop**
+Splits, trims or loops clips (e.g. "split all clips at bar 17", "loop the selected clip").
+- DSL syntax: ` + "`.split_clip(bar=17)`" + ` or ` + "`.split_clip(position=32.0)`" + `; ` + "`.trim_clip(clip=0, start=1.5, end=6)`" + `; ` + "`.set_clip_loop(enabled=true, loop_length=2)`" + `
+- ` + "`split_clip`" + `: ` + "`track`" + ` (integer) and the split point, ` + "`position`" + ` (seconds) or ` + "`bar`" + ` (integer); the clip under that point is split. With ` + "`filter(clips, ...)`" + `, clips that don't span the point are skipped
+- ` + "`trim_clip`" + `: ` + "`track`" + `, clip identifier (` + "`clip`" + `, ` + "`position`" + ` or ` + "`bar`" + `), and the new edges as project positions: ` + "`start`" + `/` + "`end`" + ` (seconds) or ` + "`start_bar`" + `/` + "`end_bar`" + `
+- ` + "`set_clip_loop`" + ` is shorthand for ` + "`set_clip(loop=..., source_length=...)`" + ` and emits a ` + "`set_clip`" + ` action; ` + "`loop_length`" + ` is in the same unit as clip lengths
+- Examples:
+  - ` + "`filter(clips, clip.length > 0).split_clip(bar=17)`" + ` - splits every clip that crosses bar 17, on all tracks
+  - ` + "`filter(clips, clip.selected == true).set_clip_loop(enabled=true)`" + ` - loops the selected clip
+  - ` + "`track(id=2).trim_clip(bar=1, end_bar=5)`" + ` - trims the clip at bar 1 on track 2 to end at bar 5
+
+**transpose_clip** / **reverse_clip** / **legato_clip** / **remove_notes**
+Edits the MIDI notes of existing clips, read from the clip ` + "`notes`" + ` in the state (e.g. "transpose the bass clip up an octave", "remove the ghost notes").
+- DSL syntax: ` + "`.transpose_clip(semitones=12)`" + ` (negative is down); ` + "`.reverse_clip()`" + `; ` + "`.legato_clip()`" + `; ` + "`.remove_notes(note.velocity < 30)`" + `
+- On a track every clip with notes is edited, unless ` + "`clip`" + `, ` + "`position`" + ` or ` + "`bar`" + ` picks one; ` + "`filter(clips, ...)`" + ` and ` + "`nth_clip()`" + ` work too
+- ` + "`reverse_clip`" + ` mirrors the notes within the clip; ` + "`legato_clip`" + ` stretches each note to the next one; ` + "`remove_notes`" + ` takes a predicate on ` + "`note.pitch`" + `, ` + "`note.velocity`" + `, ` + "`note.start`" + ` and ` + "`note.length`" + ` (beats from the clip start)
+- Each edited clip becomes a ` + "`set_clip_notes`" + ` action with ` + "`track`" + `, the clip identifier and the new ` + "`notes`" + `
+- Examples:
+  - ` + "`filter(tracks, track.name == \"Bass\").transpose_clip(semitones=12)`" + ` - transposes the bass clips up an octave
+  - ` + "`track(id=2).nth_clip(1).reverse_clip()`" + ` - reverses the first clip on track 2
+  - ` + "`filter(clips, clip.selected == true).remove_notes(note.pitch > 72)`" + ` - removes notes above MIDI pitch 72 from the selected clip
+
+**duplicate_track** / **duplicate_clip**
+Duplicates tracks or clips (e.g. "duplicate the bass track", "duplicate this clip 4 times, one bar apart").
+- DSL syntax: ` + "`.duplicate(count=1)`" + ` on tracks, ` + "`.duplicate_clip(bar=1, count=4, offset_bars=1)`" + ` on clips (identify the clip with ` + "`clip`" + `, ` + "`position`" + ` or ` + "`bar`" + `, or use ` + "`filter(clips, ...)`" + ` / ` + "`nth_clip()`" + `)
+- ` + "`duplicate_track`" + `: ` + "`track`" + ` (integer), optional ` + "`count`" + `; each copy is inserted right after its source track
+- ` + "`duplicate_clip`" + `: ` + "`track`" + ` (integer), clip identifier, optional ` + "`count`" + ` and ` + "`offset`" + ` (seconds from one copy's start to the next; omitted means back to back)
+- Examples:
+  - ` + "`filter(tracks, track.name == \"Bass\").duplicate()`" + ` - duplicates the bass track
+  - ` + "`filter(clips, clip.selected == true).duplicate_clip(count=4, offset_bars=1)`" + ` - four copies of the selected clip, one bar apart
+  - ` + "`track(id=1).nth_clip(1).duplicate_clip(count=3)`" + ` - repeats the first clip on track 1 three times back to back
 
 **set_clip_position** / **move_clip**
 Moves a clip to a different time position.
 - Required: ` + "`action: \"set_clip_position\"`" + `, ` + "`track`" + ` (integer), ` + "`position`" + ` (number in seconds)
 - Optional: ` + "`clip`" + ` (integer), ` + "`old_position`" + ` (number in seconds), or ` + "`bar`" + ` (integer)
 - Example: ` + "`filter(clips, clip.length < 1.5).move_clip(position=10.0)`" + ` moves all short clips to position 10.0 seconds
+- Relative: ` + "`move_clip(clip=0, relative_bars=-4)`" + ` moves a clip 4 bars earlier (` + "`relative`" + ` is in the same unit as ` + "`length`" + `), and ` + "`after=\"last_clip\"`" + ` / ` + "`after=\"project_end\"`" + ` with ` + "`offset_bars`" + ` work as for ` + "`new_clip`" + `
+
+### Routing
+
+**add_send** / **set_send** / **remove_send**
+Creates, changes or removes a send from one track to another (e.g. "send the vocal to the reverb bus").
+- DSL syntax: ` + "`.add_send(dest=2, level_db=-6, pre_fader=false)`" + `, ` + "`.set_send(dest=2, level_db=-3)`" + `, ` + "`.remove_send(dest=2)`" + `
+- ` + "`dest`" + ` is the 1-based id of the receiving track (like ` + "`track(id=...)`" + `); use ` + "`dest_name=\"...\"`" + ` to pick it by name instead
+- Required: ` + "`action`" + `, ` + "`track`" + ` (integer, the sending track), ` + "`dest`" + ` (integer, 0-based in actions)
+- Optional: ` + "`level_db`" + ` (number), ` + "`pre_fader`" + ` (boolean, default post-fader), ` + "`mute`" + ` (boolean); ` + "`set_send`" + ` needs at least one
+- Examples:
+  - ` + "`filter(tracks, track.name == \"Vocals\").add_send(dest_name=\"Reverb Bus\", level_db=-6)`" + ` - sends the vocal to the reverb bus
+  - ` + "`track(id=1).add_send(dest=3, pre_fader=true)`" + ` - pre-fader send from track 1 to track 3
+  - ` + "`track(id=1).remove_send(dest=3)`" + ` - removes that send
+
+### Freeze and Bounce
+
+**freeze_track** / **unfreeze_track** / **bounce_in_place**
+Renders tracks through their FX to save CPU (e.g. "freeze all tracks with more than 3 FX"). ` + "`freeze_track`" + ` can be reverted with ` + "`unfreeze_track`" + `; ` + "`bounce_in_place`" + ` replaces the track's clips with the rendered audio.
+- DSL syntax: ` + "`track(id=1).freeze_track()`" + `, ` + "`track(id=1).unfreeze_track()`" + `, ` + "`track(id=1).bounce_in_place()`" + `; no arguments
+- Works on filtered collections; tracks have ` + "`fx_count`" + ` (number of plugins in the chain) for filtering
+- Examples:
+  - ` + "`filter(tracks, track.fx_count > 3).freeze_track()`" + ` - freezes the heavy tracks
+  - ` + "`filter(tracks, track.name contains \"Synth\").bounce_in_place()`" + ` - bounces the synths to audio
+
+### Markers and Regions
+
+**add_marker** / **add_region** / **delete_marker** / **rename_region**
+Lays out song sections and cue points (e.g. "mark out intro, verse and chorus"). These are project-level, top-level statements: they don't chain off ` + "`track()`" + ` and must be separated from other statements with ` + "`;`" + `.
+- DSL syntax: ` + "`add_marker(bar=17, name=\"Drop\")`" + `, ` + "`add_region(start_bar=1, end_bar=9, name=\"Intro\", color=\"blue\")`" + `, ` + "`delete_marker(name=\"Drop\")`" + `, ` + "`rename_region(name=\"Intro\", new_name=\"Verse 1\")`" + `
+- ` + "`add_marker`" + ` requires ` + "`bar`" + ` (integer, 1-based) or ` + "`position`" + ` (seconds); ` + "`add_region`" + ` requires ` + "`start_bar`" + `/` + "`end_bar`" + ` (end_bar is exclusive, so 1 to 9 covers 8 bars) or ` + "`start`" + `/` + "`end`" + ` (seconds)
+- Optional for new markers and regions: ` + "`name`" + ` (string), ` + "`color`" + ` (color name or hex)
+- ` + "`delete_marker`" + ` picks the marker by ` + "`id`" + ` (REAPER marker number), ` + "`name`" + `, ` + "`bar`" + ` or ` + "`position`" + `; ` + "`rename_region`" + ` picks the region by ` + "`id`" + ` or ` + "`name`" + ` and requires ` + "`new_name`" + `
+- Examples:
+  - ` + "`add_region(start_bar=1, end_bar=9, name=\"Intro\"); add_region(start_bar=9, end_bar=25, name=\"Verse\"); add_region(start_bar=25, end_bar=33, name=\"Chorus\")`" + ` - lays out the song
+  - ` + "`add_marker(bar=33, name=\"Drop\", color=\"red\")`" + ` - marks the drop
+  - ` + "`rename_region(id=2, new_name=\"Verse 1\")`" + ` - renames region 2
+
+### Transport
+
+**play** / **stop** / **record** / **set_play_position**
+Controls playback (e.g. "play from bar 33", "stop", "start recording"). Like markers, these are project-level, top-level statements separated from other statements with ` + "`;`" + `.
+- DSL syntax: ` + "`play()`" + `, ` + "`stop()`" + `, ` + "`record()`" + `, ` + "`set_play_position(bar=33)`" + ` or ` + "`set_play_position(time=12.5)`" + ` (seconds)
+- ` + "`bar`" + ` is an integer starting at 1; give ` + "`bar`" + ` or ` + "`time`" + `, not both
+- Examples:
+  - ` + "`set_play_position(bar=33); play()`" + ` - plays from bar 33
+  - ` + "`set_play_position(bar=1); record()`" + ` - records from the start
+
+### Render
+
+**render_project**
+Bounces or exports audio (e.g. "bounce bars 1-32 to a wav", "render stems for all tracks"). A project-level, top-level statement separated from other statements with ` + "`;`" + `.
+- DSL syntax: ` + "`render_project(format=\"wav\", start_bar=1, end_bar=33, stems=false)`" + `
+- Optional: ` + "`format`" + ` (wav, aiff, flac, mp3 or ogg; default wav), ` + "`start_bar`" + ` and ` + "`end_bar`" + ` together (end_bar is exclusive, so bars 1-32 is 1 to 33; omit both for the whole project), ` + "`stems`" + ` (true renders each track to its own file; default false)
+- Examples:
+  - ` + "`render_project(format=\"wav\", start_bar=1, end_bar=33)`" + ` - bounces bars 1-32
+  - ` + "`render_project(stems=true)`" + ` - renders stems for all tracks
 
 ### Automation
 
@@ -291,6 +487,7 @@ Curve parameters:
 - ` + "`end`" + ` / ` + "`end_bar`" + ` - End time in beats or bars
 - ` + "`from`" + ` / ` + "`to`" + ` - Value range for ramp/exp curves
 - ` + "`freq`" + ` - Oscillation frequency (cycles per bar) for sine/saw/square
+- ` + "`rate`" + ` - Tempo-synced alternative to freq for sine/saw/square: a note division per cycle, ` + "`\"1/4\"`" + `, ` + "`\"1/8\"`" + `, ` + "`\"1/8T\"`" + ` (triplet) or ` + "`\"1/16D\"`" + ` (dotted). Use it whenever the user names a note value ("1/8 note tremolo", "quarter-note pump"); it is converted to freq using the project's time signature
 - ` + "`amplitude`" + ` - Oscillation amplitude (0-1) for oscillators
 - ` + "`phase`" + ` - Phase offset (0-1) for oscillators
 
@@ -300,6 +497,7 @@ Curve parameters:
 - Pan LFO: ` + "`track(id=1).addAutomation(param=\"pan\", curve=\"sine\", freq=0.5, amplitude=1.0, start=0, end=16)`" + `
 - Filter sweep: ` + "`track(id=1).addAutomation(param=\"Serum:Cutoff\", curve=\"ramp\", from=0.2, to=1.0, start=0, end=16)`" + `
 - Sidechain-style pump: ` + "`track(id=1).addAutomation(param=\"volume\", curve=\"saw\", freq=1, amplitude=0.5, start=0, end=32)`" + `
+- 1/8 note tremolo: ` + "`track(id=1).addAutomation(param=\"volume\", curve=\"sine\", rate=\"1/8\", amplitude=0.5, start_bar=1, end_bar=5)`" + `
 - Exponential buildup: ` + "`track(id=1).addAutomation(param=\"Serum:Cutoff\", curve=\"exp_in\", from=0.1, to=1.0, start=0, end=16)`" + `
 
 **Point-Based Syntax (Advanced)**:
@@ -307,6 +505,19 @@ For custom shapes, use manual points: ` + "`.addAutomation(param=\"...\", points
 - ` + "`time`" + ` or ` + "`bar`" + ` - Position of the point
 - ` + "`value`" + ` - Parameter value at this point
 - Optional ` + "`shape`" + ` (0=linear, 1=square, 2=slow, 3=fast start, 4=fast end, 5=bezier)
+
+**clear_automation** / **delete_envelope** / **scale_automation**
+Edits automation that already exists (e.g. "flatten the volume automation on track 2", "halve the pan automation").
+- Required: ` + "`param`" + ` (same names as add_automation)
+- ` + "`clear_automation`" + ` removes the envelope's points, within ` + "`start`" + `/` + "`end`" + ` or ` + "`start_bar`" + `/` + "`end_bar`" + ` when given (otherwise all of them); the envelope stays
+- ` + "`delete_envelope`" + ` removes the envelope entirely; it takes no range
+- ` + "`scale_automation`" + ` requires ` + "`factor`" + ` (0 or greater) and multiplies the point values, optionally within a range
+- Work on track(), filter() and master()
+- Examples:
+  - ` + "`track(id=2).clear_automation(param=\"volume\")`" + ` - flattens the volume automation
+  - ` + "`track(id=1).clear_automation(param=\"pan\", start_bar=9, end_bar=17)`" + ` - removes pan moves in bars 9-16
+  - ` + "`filter(tracks, track.name contains \"Drum\").delete_envelope(param=\"mute\")`" + ` - removes the drums' mute envelopes
+  - ` + "`track(id=3).scale_automation(param=\"volume\", factor=0.5)`" + ` - halves the volume automation
 - **CRITICAL - CLIP FILTERING**: When user says "select all clips [condition]", you MUST:
   - Use ` + "`filter(clips, clip.property < value)`" + ` to filter clips by properties like ` + "`length`" + `, ` + "`position`" + `
   - Chain with ` + "`.set_clip(selected=true)`" + ` to select the filtered clips (NOT set_selected - that method doesn't exist!)
@@ -329,9 +540,9 @@ Project (root container, always exists)
        -> Track Properties (set_track with name, volume_db, pan, mute, solo, selected)
        -> FX Chain
             -> Instrument (add_instrument action)
-                 -> FX Parameters (not yet supported in actions)
+                 -> FX Parameters (set_fx_param action)
             -> Track FX (add_track_fx action)
-                 -> FX Parameters (not yet supported in actions)
+                 -> FX Parameters (set_fx_param action)
        -> Media Items/Clips (create_clip, create_clip_at_bar actions)
             -> Take FX (not yet supported in actions)
                  -> FX Parameters (not yet supported in actions)
@@ -358,7 +569,7 @@ Project (root container, always exists)
    - add_instrument - Adds a VSTi (virtual instrument) to the track
    - add_track_fx - Adds a regular FX plugin to the track
    - Instruments and FX are siblings - they can be added in any order
-   - Each FX has parameters (not yet supported via actions)
+   - Each FX has parameters, set with set_fx_param
 
 5. Media Items/Clips (Level 2 - Direct Children of Track)
    - create_clip - Creates a clip at a specific time position
@@ -0,0 +1,80 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/plugins"
+)
+
+// maxPromptPlugins caps how many installed plugins of each kind are listed in the prompt
+const maxPromptPlugins = 150
+
+// InstalledPluginsMessage lists the plugins installed in REAPER, instruments and effects
+// separately, so the model only suggests plugins the user has. It returns "" when the state
+// doesn't list any.
+func InstalledPluginsMessage(state map[string]any) string {
+	registry := plugins.FromState(state)
+	if registry == nil {
+		return ""
+	}
+
+	var instruments, effects []string
+	for _, plugin := range registry.Plugins() {
+		if plugin.IsInstrument {
+			instruments = append(instruments, plugin.Name)
+		} else {
+			effects = append(effects, plugin.Name)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Installed plugins. Only add instruments and effects from these lists; the short name " +
+		"is enough (e.g. instrument=\"Serum\"). If the user asks for a plugin that isn't installed, " +
+		"suggest an installed one instead.")
+	writePluginList(&sb, "Instruments", instruments)
+	writePluginList(&sb, "Effects", effects)
+	return sb.String()
+}
+
+// writePluginList appends a capped, comma-separated line of plugin names
+func writePluginList(sb *strings.Builder, label string, names []string) {
+	if len(names) == 0 {
+		return
+	}
+	more := ""
+	if len(names) > maxPromptPlugins {
+		more = fmt.Sprintf(" (and %d more)", len(names)-maxPromptPlugins)
+		names = names[:maxPromptPlugins]
+	}
+	fmt.Fprintf(sb, "\n%s: %s%s", label, strings.Join(names, ", "), more)
+}
+
+// WithoutInstalledPlugins returns state without its plugin lists, which
+// InstalledPluginsMessage already covers. The input is not modified.
+func WithoutInstalledPlugins(state map[string]any) map[string]any {
+	root := state
+	if inner, ok := state["state"].(map[string]any); ok {
+		root = inner
+	}
+	found := false
+	for _, key := range plugins.StateKeys {
+		if _, ok := root[key]; ok {
+			found = true
+		}
+	}
+	if !found {
+		return state
+	}
+
+	stripped := copyMap(root)
+	for _, key := range plugins.StateKeys {
+		delete(stripped, key)
+	}
+	if _, ok := state["state"].(map[string]any); ok {
+		outer := copyMap(state)
+		outer["state"] = stripped
+		return outer
+	}
+	return stripped
+}
@@ -0,0 +1,73 @@
+package prompt
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInstalledPluginsMessage(t *testing.T) {
+	state := map[string]any{
+		"state": map[string]any{
+			"plugins": []any{
+				map[string]any{"full_name": "VSTi: Serum (Xfer Records)", "is_instrument": true},
+				map[string]any{"full_name": "VST3i: Serum (Xfer Records)", "is_instrument": true},
+				map[string]any{"full_name": "VST3: Pro-Q 3 (FabFilter)", "is_instrument": false},
+				map[string]any{"full_name": "JS: ReaSynth", "is_instrument": true},
+			},
+		},
+	}
+
+	want := "Installed plugins. Only add instruments and effects from these lists; the short name " +
+		"is enough (e.g. instrument=\"Serum\"). If the user asks for a plugin that isn't installed, " +
+		"suggest an installed one instead.\n" +
+		"Instruments: Serum, ReaSynth\n" +
+		"Effects: Pro-Q 3"
+	if got := InstalledPluginsMessage(state); got != want {
+		t.Errorf("InstalledPluginsMessage() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := InstalledPluginsMessage(map[string]any{"tracks": []any{}}); got != "" {
+		t.Errorf("InstalledPluginsMessage() without plugins = %q, want empty", got)
+	}
+}
+
+func TestWithoutInstalledPlugins(t *testing.T) {
+	tracks := []any{map[string]any{"index": 0, "name": "Drums"}}
+
+	tests := []struct {
+		name  string
+		state map[string]any
+		want  map[string]any
+	}{
+		{
+			name:  "top level",
+			state: map[string]any{"tracks": tracks, "plugins": []any{"VST: ReaEQ (Cockos)"}},
+			want:  map[string]any{"tracks": tracks},
+		},
+		{
+			name:  "nested",
+			state: map[string]any{"state": map[string]any{"tracks": tracks, "installed_fx": []any{"VST: ReaEQ (Cockos)"}}},
+			want:  map[string]any{"state": map[string]any{"tracks": tracks}},
+		},
+		{
+			name:  "no plugins",
+			state: map[string]any{"tracks": tracks},
+			want:  map[string]any{"tracks": tracks},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := WithoutInstalledPlugins(tt.state)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithoutInstalledPlugins() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	state := map[string]any{"tracks": tracks, "plugins": []any{"VST: ReaEQ (Cockos)"}}
+	WithoutInstalledPlugins(state)
+	if _, ok := state["plugins"]; !ok {
+		t.Error("WithoutInstalledPlugins() modified its input")
+	}
+}
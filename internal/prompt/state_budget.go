@@ -0,0 +1,346 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// charsPerToken approximates tokenizer output for JSON-heavy English text
+	charsPerToken = 4
+	// defaultContextWindow is used for models missing from modelContextWindows
+	defaultContextWindow = 128000
+	// stateBudgetShare is the share of the context window the project state may use; the rest
+	// is left for the system prompt, grammar, history and the response
+	stateBudgetShare = 0.25
+
+	// OmittedKey is added to a summarized state to tell the model what was left out
+	OmittedKey = "_omitted"
+)
+
+// modelContextWindows maps model name prefixes to context window sizes in tokens.
+// Longer prefixes win, so "gpt-4.1" is not matched by "gpt-4".
+var modelContextWindows = map[string]int{
+	"gpt-5":    400000,
+	"gpt-4.1":  1047576,
+	"gpt-4o":   128000,
+	"gpt-4":    8192,
+	"o3":       200000,
+	"o4":       200000,
+	"claude-":  200000,
+	"gemini-":  1048576,
+	"llama-3":  128000,
+	"mistral-": 128000,
+}
+
+// ContextWindow returns the context window size in tokens for model
+func ContextWindow(model string) int {
+	model = strings.ToLower(strings.TrimSpace(model))
+	best, window := 0, defaultContextWindow
+	for prefix, size := range modelContextWindows {
+		if strings.HasPrefix(model, prefix) && len(prefix) > best {
+			best, window = len(prefix), size
+		}
+	}
+	return window
+}
+
+// StateTokenBudget returns how many tokens of project state fit in a request to model
+func StateTokenBudget(model string) int {
+	return int(float64(ContextWindow(model)) * stateBudgetShare)
+}
+
+// EstimateTokens approximates the token count of text without a model-specific tokenizer
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// EstimateStateTokens approximates the token count of a state as JSON
+func EstimateStateTokens(state map[string]any) int {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return EstimateTokens(fmt.Sprintf("%+v", state))
+	}
+	return EstimateTokens(string(data))
+}
+
+// StateSummary reports what SummarizeState did to fit a state in its budget
+type StateSummary struct {
+	OriginalTokens int      `json:"originalTokens"`
+	Tokens         int      `json:"tokens"`
+	Budget         int      `json:"budget"`
+	Omitted        []string `json:"omitted,omitempty"` // Also written to the state under OmittedKey
+	OverBudget     bool     `json:"overBudget"`        // Still over budget after every trimming step
+}
+
+// Trimmed reports whether anything was left out of the state
+func (s StateSummary) Trimmed() bool {
+	return len(s.Omitted) > 0
+}
+
+// summarizeStep trims one kind of detail from the tracks and describes what it removed.
+// An empty description means the step found nothing to trim.
+type summarizeStep func(tracks []map[string]any, relevant map[int]bool) string
+
+// SummarizeState shrinks a REAPER state until it fits maxTokens, trimming the least useful
+// detail first:
+//  1. MIDI notes on tracks the question doesn't mention (note_count is kept)
+//  2. FX parameters (fx becomes a list of plugin names)
+//  3. Clips on tracks the question doesn't mention (clip_count is kept)
+//  4. MIDI notes on the remaining tracks
+//  5. Everything but index and name on tracks the question doesn't mention
+//
+// Tracks are relevant when selected, named in the question, or referenced as "track N".
+// Track indices and names are always kept so the model can still address every track.
+// The input is not modified; states that already fit are returned as-is.
+func SummarizeState(state map[string]any, question string, maxTokens int) (map[string]any, StateSummary) {
+	summary := StateSummary{Budget: maxTokens}
+	summary.OriginalTokens = EstimateStateTokens(state)
+	summary.Tokens = summary.OriginalTokens
+	if maxTokens <= 0 || summary.Tokens <= maxTokens {
+		return state, summary
+	}
+
+	// Work on copies of the containers that get modified
+	summarized := copyMap(state)
+	root := summarized
+	if inner, ok := summarized["state"].(map[string]any); ok {
+		root = copyMap(inner)
+		summarized["state"] = root
+	}
+	tracks := copyTracks(root["tracks"])
+	if tracks == nil {
+		summary.OverBudget = true
+		return state, summary
+	}
+	trackList := make([]any, len(tracks))
+	for i, track := range tracks {
+		trackList[i] = track
+	}
+	root["tracks"] = trackList
+	relevant := relevantTracks(tracks, question)
+
+	steps := []summarizeStep{
+		func(tracks []map[string]any, relevant map[int]bool) string {
+			return dropNotes(tracks, relevant, false)
+		},
+		collapseFX,
+		dropClips,
+		func(tracks []map[string]any, relevant map[int]bool) string {
+			return dropNotes(tracks, relevant, true)
+		},
+		stripTracks,
+	}
+	for _, step := range steps {
+		if omitted := step(tracks, relevant); omitted != "" {
+			summary.Omitted = append(summary.Omitted, omitted)
+			root[OmittedKey] = summary.Omitted
+			summary.Tokens = EstimateStateTokens(summarized)
+			if summary.Tokens <= maxTokens {
+				return summarized, summary
+			}
+		}
+	}
+
+	summary.OverBudget = summary.Tokens > maxTokens
+	return summarized, summary
+}
+
+// trackReference matches "track 3" / "track #3" in a question
+var trackReference = regexp.MustCompile(`(?i)\btrack\s*#?(\d+)\b`)
+
+// relevantTracks returns the positions of tracks that are selected, named in the question
+// or referenced by number. "track N" marks both index N and N-1, since users count from 1.
+func relevantTracks(tracks []map[string]any, question string) map[int]bool {
+	question = strings.ToLower(question)
+	referenced := map[int]bool{}
+	for _, match := range trackReference.FindAllStringSubmatch(question, -1) {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			referenced[n] = true
+			referenced[n-1] = true
+		}
+	}
+
+	relevant := map[int]bool{}
+	for i, track := range tracks {
+		index := i
+		if value, ok := track["index"]; ok {
+			if n, ok := toInt(value); ok {
+				index = n
+			}
+		}
+		name, _ := track["name"].(string)
+		name = strings.ToLower(strings.TrimSpace(name))
+		selected, _ := track["selected"].(bool)
+
+		if selected || referenced[index] || (len(name) >= 2 && strings.Contains(question, name)) {
+			relevant[i] = true
+		}
+	}
+	return relevant
+}
+
+// dropNotes replaces clip note lists with note_count, on irrelevant tracks or (all) on every track
+func dropNotes(tracks []map[string]any, relevant map[int]bool, all bool) string {
+	clipCount := 0
+	for i, track := range tracks {
+		if relevant[i] && !all {
+			continue
+		}
+		clips, ok := track["clips"].([]any)
+		if !ok {
+			continue
+		}
+		trimmed := make([]any, len(clips))
+		for j, clip := range clips {
+			trimmed[j] = clip
+			clipMap, ok := clip.(map[string]any)
+			if !ok {
+				continue
+			}
+			notes, ok := clipMap["notes"].([]any)
+			if !ok {
+				continue
+			}
+			clipMap = copyMap(clipMap)
+			delete(clipMap, "notes")
+			clipMap["note_count"] = len(notes)
+			trimmed[j] = clipMap
+			clipCount++
+		}
+		track["clips"] = trimmed
+	}
+
+	switch {
+	case clipCount == 0:
+		return ""
+	case all:
+		return fmt.Sprintf("MIDI notes of %d clips (note_count kept)", clipCount)
+	default:
+		return fmt.Sprintf("MIDI notes of %d clips on tracks not mentioned in the request (note_count kept)", clipCount)
+	}
+}
+
+// collapseFX reduces every FX chain to plugin names, marking bypassed plugins
+func collapseFX(tracks []map[string]any, _ map[int]bool) string {
+	trackCount := 0
+	for _, track := range tracks {
+		chain, ok := track["fx"].([]any)
+		if !ok || len(chain) == 0 {
+			continue
+		}
+		names := make([]any, len(chain))
+		changed := false
+		for i, fx := range chain {
+			names[i] = fx
+			fxMap, ok := fx.(map[string]any)
+			if !ok {
+				continue
+			}
+			name, _ := fxMap["name"].(string)
+			if enabled, ok := fxMap["enabled"].(bool); ok && !enabled {
+				name += " (bypassed)"
+			}
+			names[i] = name
+			changed = true
+		}
+		if changed {
+			track["fx"] = names
+			trackCount++
+		}
+	}
+	if trackCount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("FX parameters on %d tracks (fx lists plugin names only)", trackCount)
+}
+
+// dropClips replaces the clip lists of irrelevant tracks with clip_count
+func dropClips(tracks []map[string]any, relevant map[int]bool) string {
+	trackCount := 0
+	for i, track := range tracks {
+		if relevant[i] {
+			continue
+		}
+		clips, ok := track["clips"].([]any)
+		if !ok || len(clips) == 0 {
+			continue
+		}
+		delete(track, "clips")
+		track["clip_count"] = len(clips)
+		trackCount++
+	}
+	if trackCount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("clips on %d tracks not mentioned in the request (clip_count kept)", trackCount)
+}
+
+// trackIdentityKeys are the track properties kept on stripped tracks, so the model can still
+// reference them by id, name or GUID
+var trackIdentityKeys = map[string]bool{"index": true, "name": true, "guid": true}
+
+// stripTracks keeps only index, name and guid on irrelevant tracks
+func stripTracks(tracks []map[string]any, relevant map[int]bool) string {
+	trackCount := 0
+	for i, track := range tracks {
+		if relevant[i] {
+			continue
+		}
+		stripped := false
+		for key := range track {
+			if !trackIdentityKeys[key] {
+				delete(track, key)
+				stripped = true
+			}
+		}
+		if stripped {
+			trackCount++
+		}
+	}
+	if trackCount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("all properties but index, name and guid on %d tracks not mentioned in the request", trackCount)
+}
+
+// copyTracks shallow-copies each track map; nil if value is not a list of tracks
+func copyTracks(value any) []map[string]any {
+	list, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	tracks := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		track, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		tracks = append(tracks, copyMap(track))
+	}
+	return tracks
+}
+
+func copyMap(m map[string]any) map[string]any {
+	copied := make(map[string]any, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+func toInt(value any) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
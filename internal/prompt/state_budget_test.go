@@ -0,0 +1,180 @@
+package prompt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-5.1", 400000},
+		{"gpt-5-mini", 400000},
+		{"gpt-4.1-mini", 1047576},
+		{"gpt-4o", 128000},
+		{"claude-sonnet-4-5", 200000},
+		{"unknown-model", defaultContextWindow},
+	}
+
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+
+	if got := StateTokenBudget("gpt-5.1"); got != 100000 {
+		t.Errorf("StateTokenBudget(gpt-5.1) = %d, want 100000", got)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("EstimateTokens(\"\") = %d, want 0", got)
+	}
+	if got := EstimateTokens("abcde"); got != 2 {
+		t.Errorf("EstimateTokens(\"abcde\") = %d, want 2", got)
+	}
+}
+
+// largeState builds a project with note-heavy clips and parameterized FX on every track
+func largeState() map[string]any {
+	names := []string{"Drums", "Bass", "Keys", "Lead", "Pad", "Vocals"}
+	tracks := []any{}
+	for i, name := range names {
+		notes := []any{}
+		for n := 0; n < 64; n++ {
+			notes = append(notes, map[string]any{"pitch": 60.0, "velocity": 100.0, "start": float64(n), "length": 0.5})
+		}
+		tracks = append(tracks, map[string]any{
+			"index":     float64(i),
+			"name":      name,
+			"selected":  name == "Pad",
+			"volume_db": -6.0,
+			"fx": []any{
+				map[string]any{"name": "ReaEQ", "enabled": true, "params": map[string]any{"band1_gain": 3.0, "band2_gain": -2.0}},
+				map[string]any{"name": "ReaComp", "enabled": false, "params": map[string]any{"ratio": 4.0}},
+			},
+			"clips": []any{
+				map[string]any{"start": 0.0, "length": 16.0, "name": name + " A", "notes": notes},
+				map[string]any{"start": 16.0, "length": 16.0, "name": name + " B", "notes": notes},
+			},
+		})
+	}
+	return map[string]any{"state": map[string]any{"tracks": tracks, "bpm": 120.0}}
+}
+
+func stateTracks(state map[string]any) []map[string]any {
+	inner := state["state"].(map[string]any)
+	tracks := []map[string]any{}
+	for _, track := range inner["tracks"].([]any) {
+		tracks = append(tracks, track.(map[string]any))
+	}
+	return tracks
+}
+
+func TestSummarizeState_FitsUnchanged(t *testing.T) {
+	state := largeState()
+	got, summary := SummarizeState(state, "add reverb to the bass", 1000000)
+
+	if !reflect.DeepEqual(got, state) {
+		t.Error("SummarizeState changed a state that fits")
+	}
+	if summary.Trimmed() || summary.OverBudget {
+		t.Errorf("summary = %+v, want untrimmed", summary)
+	}
+	if summary.Tokens != summary.OriginalTokens || summary.Tokens == 0 {
+		t.Errorf("tokens = %d, original = %d", summary.Tokens, summary.OriginalTokens)
+	}
+}
+
+func TestSummarizeState_TrimsIrrelevantNotesFirst(t *testing.T) {
+	state := largeState()
+	original := EstimateStateTokens(state)
+
+	// Dropping notes on the 4 tracks that are neither named nor selected is enough
+	got, summary := SummarizeState(state, "make the bass louder", original*2/5)
+	if !summary.Trimmed() || summary.OverBudget {
+		t.Fatalf("summary = %+v, want trimmed within budget", summary)
+	}
+	if len(summary.Omitted) != 1 || !strings.Contains(summary.Omitted[0], "MIDI notes of 8 clips") {
+		t.Errorf("omitted = %v, want notes of 8 clips", summary.Omitted)
+	}
+	if summary.Tokens > summary.Budget {
+		t.Errorf("tokens %d over budget %d", summary.Tokens, summary.Budget)
+	}
+
+	tracks := stateTracks(got)
+	for _, track := range tracks {
+		clip := track["clips"].([]any)[0].(map[string]any)
+		_, hasNotes := clip["notes"]
+		keep := track["name"] == "Bass" || track["name"] == "Pad" // Named in the question, selected
+		if hasNotes != keep {
+			t.Errorf("track %v: has notes = %v, want %v", track["name"], hasNotes, keep)
+		}
+		if !hasNotes && clip["note_count"] != 64 {
+			t.Errorf("track %v: note_count = %v, want 64", track["name"], clip["note_count"])
+		}
+	}
+
+	omitted := got["state"].(map[string]any)[OmittedKey]
+	if !reflect.DeepEqual(omitted, summary.Omitted) {
+		t.Errorf("state %s = %v, want %v", OmittedKey, omitted, summary.Omitted)
+	}
+
+	// The input is untouched
+	if _, ok := stateTracks(state)[0]["clips"].([]any)[0].(map[string]any)["notes"]; !ok {
+		t.Error("SummarizeState modified its input")
+	}
+}
+
+func TestSummarizeState_AllSteps(t *testing.T) {
+	state := largeState()
+	got, summary := SummarizeState(state, "mute track 3", 150)
+
+	if len(summary.Omitted) != 5 {
+		t.Fatalf("omitted = %v, want all 5 steps", summary.Omitted)
+	}
+	if !summary.OverBudget {
+		t.Error("a 150 token budget should be unreachable for 6 tracks")
+	}
+
+	tracks := stateTracks(got)
+	if len(tracks) != 6 {
+		t.Fatalf("got %d tracks, want all 6 kept", len(tracks))
+	}
+	// "track 3" marks index 3 (Lead) and index 2 (Keys); Pad is selected
+	for _, track := range tracks {
+		switch track["name"] {
+		case "Keys", "Lead", "Pad":
+			fx := track["fx"].([]any)
+			if !reflect.DeepEqual(fx, []any{"ReaEQ", "ReaComp (bypassed)"}) {
+				t.Errorf("track %v: fx = %v, want collapsed names", track["name"], fx)
+			}
+			if _, ok := track["clips"]; !ok {
+				t.Errorf("track %v: relevant clips dropped", track["name"])
+			}
+		default:
+			if len(track) != 2 || track["index"] == nil || track["name"] == nil {
+				t.Errorf("track %v = %v, want only index and name", track["name"], track)
+			}
+		}
+	}
+	if got["state"].(map[string]any)["bpm"] != 120.0 {
+		t.Error("project-level fields should be kept")
+	}
+}
+
+func TestSummarizeState_TopLevelTracks(t *testing.T) {
+	state := largeState()["state"].(map[string]any)
+	got, summary := SummarizeState(state, "", EstimateStateTokens(state)/2)
+
+	if !summary.Trimmed() {
+		t.Fatal("SummarizeState did not trim a state without the \"state\" wrapper")
+	}
+	if _, ok := got[OmittedKey]; !ok {
+		t.Errorf("state missing %s", OmittedKey)
+	}
+}
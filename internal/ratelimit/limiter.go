@@ -0,0 +1,212 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+)
+
+const (
+	redisKeyPrefix = "magda:ratelimit:"
+	// sweepInterval is how often MemoryLimiter drops buckets that have refilled completely
+	sweepInterval = time.Minute
+)
+
+// Limit is a token bucket: Burst requests at once, refilled at PerMinute requests per minute
+type Limit struct {
+	PerMinute int
+	Burst     int // Bucket size; non-positive uses PerMinute
+}
+
+// Enabled reports whether the limit allows a finite rate
+func (l Limit) Enabled() bool {
+	return l.PerMinute > 0
+}
+
+func (l Limit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.PerMinute)
+}
+
+func (l Limit) perSecond() float64 {
+	return float64(l.PerMinute) / 60
+}
+
+// Result is the outcome of one Allow call
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket size
+	Remaining  int           // Whole tokens left after this request
+	RetryAfter time.Duration // Wait until the next token when not allowed
+	Reset      time.Time     // When the bucket is full again
+}
+
+// Limiter takes one token from the bucket for key
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// NewLimiter creates the limiter for backend: "memory" or "redis".
+// "off" (or empty) disables rate limiting and returns a nil limiter.
+func NewLimiter(backend, redisURL string) (Limiter, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", "off":
+		return nil, nil
+	case "memory":
+		return NewMemoryLimiter(), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis rate limiter")
+		}
+		return NewRedisLimiter(redisURL)
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q: must be \"off\", \"memory\" or \"redis\"", backend)
+	}
+}
+
+// result builds a Result from the tokens left in a bucket
+func result(allowed bool, tokens float64, limit Limit, now time.Time) Result {
+	rate := limit.perSecond()
+	res := Result{
+		Allowed:   allowed,
+		Limit:     int(limit.burst()),
+		Remaining: int(math.Floor(tokens)),
+		Reset:     now.Add(secondsToDuration((limit.burst() - tokens) / rate)),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return res
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
+
+// MemoryLimiter keeps buckets in process memory; limits are per instance
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time // When the bucket refills completely if untouched
+}
+
+// NewMemoryLimiter creates an in-memory limiter
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Allow refills key's bucket for the time since its last request and takes a token
+func (m *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if now.Sub(m.lastSweep) > sweepInterval {
+		for k, b := range m.buckets {
+			if now.After(b.full) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: limit.burst(), updated: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(limit.burst(), b.tokens+now.Sub(b.updated).Seconds()*limit.perSecond())
+	b.updated = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	res := result(allowed, b.tokens, limit, now)
+	b.full = res.Reset
+	return res, nil
+}
+
+// RedisLimiter keeps buckets in Redis hashes so limits are shared between instances.
+// The refill and take run in one Lua script, so concurrent requests can't overdraw a bucket.
+type RedisLimiter struct {
+	client *redis.Client
+	now    func() time.Time
+}
+
+// tokenBucketScript refills and takes from the bucket at KEYS[1].
+// ARGV: tokens per millisecond, bucket size, now in milliseconds.
+// Returns {allowed (0/1), tokens left as a string}; Lua numbers would be truncated to integers.
+const tokenBucketScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or burst
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate)
+local allowed = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// NewRedisLimiter creates a limiter for a redis://[user:password@]host:port[/db] URL.
+// The connection is opened on first use.
+func NewRedisLimiter(redisURL string) (*RedisLimiter, error) {
+	client, err := redis.NewClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisLimiter{client: client, now: time.Now}, nil
+}
+
+// Allow runs the token bucket script for key
+func (r *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	now := r.now()
+	reply, err := r.client.Do(ctx, "EVAL", tokenBucketScript, "1", redisKeyPrefix+key,
+		strconv.FormatFloat(limit.perSecond()/1000, 'g', -1, 64),
+		strconv.FormatFloat(limit.burst(), 'g', -1, 64),
+		strconv.FormatInt(now.UnixMilli(), 10),
+	)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit check failed: %w", err)
+	}
+
+	items, ok := reply.([]any)
+	if !ok || len(items) != 2 {
+		return Result{}, fmt.Errorf("rate limit check failed: unexpected reply %v", reply)
+	}
+	allowed, _ := items[0].(int64)
+	tokensText, _ := items[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit check failed: invalid token count %q", tokensText)
+	}
+	return result(allowed == 1, tokens, limit, now), nil
+}
@@ -0,0 +1,145 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLimiter_TokenBucket(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+	limit := Limit{PerMinute: 60, Burst: 3} // One token per second, three at once
+
+	for i := 2; i >= 0; i-- {
+		result, err := limiter.Allow(ctx, "key:a", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+		assert.Equal(t, 3, result.Limit)
+	}
+
+	result, err := limiter.Allow(ctx, "key:a", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, now.Add(3*time.Second), result.Reset)
+
+	// Other keys have their own bucket
+	result, _ = limiter.Allow(ctx, "key:b", limit)
+	assert.True(t, result.Allowed)
+
+	// Half a second refills half a token: still limited
+	now = now.Add(500 * time.Millisecond)
+	result, _ = limiter.Allow(ctx, "key:a", limit)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	result, _ = limiter.Allow(ctx, "key:a", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// A long pause refills up to the burst, not beyond
+	now = now.Add(time.Hour)
+	result, _ = limiter.Allow(ctx, "key:a", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+}
+
+func TestMemoryLimiter_DefaultBurstAndSweep(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	result, err := limiter.Allow(ctx, "ip:1.2.3.4", Limit{PerMinute: 120})
+	require.NoError(t, err)
+	assert.Equal(t, 120, result.Limit)
+	assert.Equal(t, 119, result.Remaining)
+
+	// Full buckets are dropped on the next sweep
+	now = now.Add(2 * sweepInterval)
+	_, _ = limiter.Allow(ctx, "ip:5.6.7.8", Limit{PerMinute: 120})
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestRedisLimiter_Allow(t *testing.T) {
+	server := redistest.Start(t)
+	server.SetEvalReply("*2\r\n:1\r\n$3\r\n4.5\r\n")
+	limiter, err := NewRedisLimiter(server.URL)
+	require.NoError(t, err)
+	now := time.UnixMilli(1700000000000)
+	limiter.now = func() time.Time { return now }
+
+	result, err := limiter.Allow(context.Background(), "key:a", Limit{PerMinute: 60, Burst: 10})
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 4, result.Remaining)
+	assert.Equal(t, 10, result.Limit)
+	assert.Equal(t, now.Add(5500*time.Millisecond), result.Reset)
+
+	args := server.LastCommand()
+	require.Len(t, args, 7)
+	assert.Equal(t, []string{"EVAL", "1", "magda:ratelimit:key:a", "0.001", "10", "1700000000000"},
+		append([]string{args[0]}, args[2:]...))
+}
+
+func TestRedisLimiter_Denied(t *testing.T) {
+	server := redistest.Start(t)
+	server.SetEvalReply("*2\r\n:0\r\n$4\r\n0.25\r\n")
+	limiter, err := NewRedisLimiter(server.URL)
+	require.NoError(t, err)
+
+	result, err := limiter.Allow(context.Background(), "ip:1.2.3.4", Limit{PerMinute: 60})
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 750*time.Millisecond, result.RetryAfter)
+}
+
+func TestRedisLimiter_ErrorReply(t *testing.T) {
+	server := redistest.Start(t)
+	server.SetEvalReply("-NOSCRIPT scripting disabled\r\n")
+	limiter, err := NewRedisLimiter(server.URL)
+	require.NoError(t, err)
+
+	_, err = limiter.Allow(context.Background(), "ip:1.2.3.4", Limit{PerMinute: 60})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limit check failed")
+}
+
+func TestNewLimiter(t *testing.T) {
+	tests := []struct {
+		backend  string
+		redisURL string
+		want     string
+		wantErr  bool
+	}{
+		{backend: "off", want: "<nil>"},
+		{backend: "", want: "<nil>"},
+		{backend: "memory", want: "*ratelimit.MemoryLimiter"},
+		{backend: "redis", redisURL: "redis://localhost:6379", want: "*ratelimit.RedisLimiter"},
+		{backend: "redis", wantErr: true},
+		{backend: "etcd", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.backend, func(t *testing.T) {
+			limiter, err := NewLimiter(tt.backend, tt.redisURL)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, fmt.Sprintf("%T", limiter))
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dialTimeout = 5 * time.Second
+	ioTimeout   = 5 * time.Second
+)
+
+// Client speaks the Redis protocol directly over a single connection; commands are serialized.
+// The connection is opened on first use and redialed after connection-level failures.
+type Client struct {
+	addr     string
+	username string
+	password string
+	db       int
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewClient creates a client for a redis://[user:password@]host:port[/db] URL
+func NewClient(redisURL string) (*Client, error) {
+	u, err := url.Parse(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("invalid REDIS_URL: unsupported scheme %q", u.Scheme)
+	}
+
+	client := &Client{addr: u.Host}
+	if u.Port() == "" {
+		client.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		client.username = u.User.Username()
+		client.password, _ = u.User.Password()
+	}
+	if path := strings.TrimPrefix(u.Path, "/"); path != "" {
+		if client.db, err = strconv.Atoi(path); err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: database %q is not a number", path)
+		}
+	}
+	return client, nil
+}
+
+// Do sends one command and reads its reply, reconnecting if the connection was dropped.
+// Replies are strings, int64s, []any arrays or nil; error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		if err := c.connect(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	reply, err := c.roundTrip(args)
+	var redisErr Error
+	if err != nil && !errors.As(err, &redisErr) {
+		// Connection-level failure: drop the connection so the next command redials
+		_ = c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+	return reply, err
+}
+
+// connect dials Redis and authenticates / selects the database from the URL
+func (c *Client) connect(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", c.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis at %s: %w", c.addr, err)
+	}
+	c.conn, c.reader = conn, bufio.NewReader(conn)
+
+	var setup [][]string
+	if c.password != "" {
+		if c.username != "" {
+			setup = append(setup, []string{"AUTH", c.username, c.password})
+		} else {
+			setup = append(setup, []string{"AUTH", c.password})
+		}
+	}
+	if c.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.db)})
+	}
+	for _, command := range setup {
+		if _, err := c.roundTrip(command); err != nil {
+			_ = conn.Close()
+			c.conn, c.reader = nil, nil
+			return fmt.Errorf("redis %s failed: %w", command[0], err)
+		}
+	}
+	return nil
+}
+
+// roundTrip writes a RESP command and reads one reply
+func (c *Client) roundTrip(args []string) (any, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(ioTimeout)); err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(c.conn, sb.String()); err != nil {
+		return nil, err
+	}
+	return ReadReply(c.reader)
+}
+
+// Error is an error reply from the server (the connection is still usable)
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// ReadReply reads one RESP2 reply: simple strings, errors, integers, bulk strings and arrays.
+// Null bulk strings and arrays are returned as nil.
+func ReadReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("invalid redis reply: empty line")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return nil, err
+		}
+		data := make([]byte, size+2) // Payload plus trailing CRLF
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = ReadReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("invalid redis reply: %q", line)
+	}
+}
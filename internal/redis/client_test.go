@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewClient(t *testing.T) {
+	client, err := NewClient("redis://:secret@localhost/2")
+	require.NoError(t, err)
+	assert.Equal(t, "localhost:6379", client.addr)
+	assert.Equal(t, "secret", client.password)
+	assert.Equal(t, 2, client.db)
+
+	client, err = NewClient("redis://user:pw@cache:6380")
+	require.NoError(t, err)
+	assert.Equal(t, "cache:6380", client.addr)
+	assert.Equal(t, "user", client.username)
+	assert.Equal(t, 0, client.db)
+
+	for _, redisURL := range []string{"http://localhost", "redis://localhost/db", "://"} {
+		_, err := NewClient(redisURL)
+		assert.Error(t, err, redisURL)
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	input := "+OK\r\n:42\r\n$5\r\nhello\r\n$-1\r\n*2\r\n$1\r\na\r\n:1\r\n-ERR nope\r\n"
+	reader := bufio.NewReader(strings.NewReader(input))
+
+	for _, want := range []any{"OK", int64(42), "hello", nil, []any{"a", int64(1)}} {
+		reply, err := ReadReply(reader)
+		require.NoError(t, err)
+		assert.Equal(t, want, reply)
+	}
+
+	_, err := ReadReply(reader)
+	assert.Equal(t, Error("ERR nope"), err)
+}
+
+func TestClient_Do(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			reply, err := ReadReply(reader)
+			if err != nil {
+				return
+			}
+			if args, _ := reply.([]any); len(args) > 0 && args[0] == "PING" {
+				_, _ = conn.Write([]byte("+PONG\r\n"))
+			} else {
+				_, _ = conn.Write([]byte("-ERR unknown command\r\n"))
+			}
+		}
+	}()
+
+	client, err := NewClient("redis://" + listener.Addr().String())
+	require.NoError(t, err)
+
+	reply, err := client.Do(context.Background(), "PING")
+	require.NoError(t, err)
+	assert.Equal(t, "PONG", reply)
+
+	// Error replies keep the connection open
+	_, err = client.Do(context.Background(), "FLUSHALL")
+	var redisErr Error
+	require.True(t, errors.As(err, &redisErr))
+	reply, err = client.Do(context.Background(), "PING")
+	require.NoError(t, err)
+	assert.Equal(t, "PONG", reply)
+}
@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// maxUpdateAttempts bounds how often Update retries when the value changes under it
+const maxUpdateAttempts = 5
+
+// CompareAndSetScript replaces KEYS[1] with ARGV[2] (expiring after ARGV[3] seconds) only while
+// it still holds ARGV[1]. It returns 1 when the value was replaced, else 0.
+const CompareAndSetScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then
+  redis.call('SET', KEYS[1], ARGV[2], 'EX', ARGV[3])
+  return 1
+end
+return 0`
+
+// JSONStore keeps values of type T as JSON strings under keyPrefix+id, each expiring ttl
+// after it was last written. name describes the values in errors, e.g. "job".
+type JSONStore[T any] struct {
+	client    *Client
+	keyPrefix string
+	name      string
+	ttl       time.Duration
+}
+
+// NewJSONStore creates a store on client. ttl must be positive.
+func NewJSONStore[T any](client *Client, keyPrefix, name string, ttl time.Duration) *JSONStore[T] {
+	return &JSONStore[T]{client: client, keyPrefix: keyPrefix, name: name, ttl: ttl}
+}
+
+// Get loads and decodes the value, or returns nil when there is none
+func (s *JSONStore[T]) Get(ctx context.Context, id string) (*T, error) {
+	value, _, err := s.load(ctx, id)
+	return value, err
+}
+
+// Set stores value, refreshing the TTL
+func (s *JSONStore[T]) Set(ctx context.Context, id string, value *T) error {
+	data, err := s.encode(value)
+	if err != nil {
+		return err
+	}
+	if _, err := s.client.Do(ctx, "SET", s.keyPrefix+id, data, "EX", s.ttlSeconds()); err != nil {
+		return fmt.Errorf("failed to store %s: %w", s.name, err)
+	}
+	return nil
+}
+
+// Update loads the value, applies update and stores the result, refreshing the TTL. update
+// returns false to leave the value as it is. The write only lands if the value is unchanged
+// since it was loaded; otherwise update runs again on the newly stored value, so it must not
+// do more than change the value. Returns the value as stored, or nil when there is none.
+func (s *JSONStore[T]) Update(ctx context.Context, id string, update func(*T) bool) (*T, error) {
+	for range maxUpdateAttempts {
+		value, data, err := s.load(ctx, id)
+		if err != nil || value == nil || !update(value) {
+			return value, err
+		}
+		updated, err := s.encode(value)
+		if err != nil {
+			return nil, err
+		}
+
+		reply, err := s.client.Do(ctx, "EVAL", CompareAndSetScript, "1", s.keyPrefix+id, data, updated, s.ttlSeconds())
+		if err != nil {
+			return nil, fmt.Errorf("failed to store %s: %w", s.name, err)
+		}
+		if replaced, _ := reply.(int64); replaced == 1 {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to update %s %s: it changed concurrently %d times", s.name, id, maxUpdateAttempts)
+}
+
+// load returns the decoded value and the JSON it was stored as
+func (s *JSONStore[T]) load(ctx context.Context, id string) (*T, string, error) {
+	reply, err := s.client.Do(ctx, "GET", s.keyPrefix+id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load %s: %w", s.name, err)
+	}
+	data, ok := reply.(string)
+	if !ok {
+		return nil, "", nil
+	}
+
+	var value T
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return nil, "", fmt.Errorf("failed to decode %s: %w", s.name, err)
+	}
+	return &value, data, nil
+}
+
+func (s *JSONStore[T]) encode(value *T) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode %s: %w", s.name, err)
+	}
+	return string(data), nil
+}
+
+func (s *JSONStore[T]) ttlSeconds() string {
+	return strconv.Itoa(max(1, int(s.ttl.Seconds())))
+}
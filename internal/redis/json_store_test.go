@@ -0,0 +1,87 @@
+package redis_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+	"github.com/Conceptual-Machines/magda-api/internal/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type counter struct {
+	Count int `json:"count"`
+}
+
+func TestJSONStore(t *testing.T) {
+	server := redistest.Start(t)
+	client, err := redis.NewClient(server.URL)
+	require.NoError(t, err)
+	store := redis.NewJSONStore[counter](client, "test:counter:", "counter", time.Hour)
+
+	ctx := context.Background()
+	value, err := store.Get(ctx, "c1")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, store.Set(ctx, "c1", &counter{Count: 1}))
+	assert.Equal(t, 3600, server.Expiry("test:counter:c1"))
+
+	value, err = store.Update(ctx, "c1", func(c *counter) bool {
+		c.Count++
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, value.Count)
+
+	// Declining leaves the value as it is
+	value, err = store.Update(ctx, "c1", func(c *counter) bool { return false })
+	require.NoError(t, err)
+	assert.Equal(t, 2, value.Count)
+
+	value, err = store.Update(ctx, "unknown", func(c *counter) bool { return true })
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	server.SetValue("test:counter:bad", "{")
+	_, err = store.Get(ctx, "bad")
+	assert.ErrorContains(t, err, "failed to decode counter")
+}
+
+func TestJSONStore_UpdateRetriesOnConcurrentChange(t *testing.T) {
+	server := redistest.Start(t)
+	client, err := redis.NewClient(server.URL)
+	require.NoError(t, err)
+	store := redis.NewJSONStore[counter](client, "test:counter:", "counter", time.Hour)
+
+	ctx := context.Background()
+	require.NoError(t, store.Set(ctx, "c1", &counter{Count: 1}))
+
+	// Another writer changes the value after the first load: the increment is applied to it
+	calls := 0
+	value, err := store.Update(ctx, "c1", func(c *counter) bool {
+		calls++
+		if calls == 1 {
+			server.SetValue("test:counter:c1", `{"count":10}`)
+		}
+		c.Count++
+		return true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 11, value.Count)
+	stored, _ := server.Value("test:counter:c1")
+	assert.JSONEq(t, `{"count":11}`, stored)
+
+	// A value that keeps changing gives up
+	_, err = store.Update(ctx, "c1", func(c *counter) bool {
+		calls++
+		server.SetValue("test:counter:c1", fmt.Sprintf(`{"count":%d}`, -calls))
+		c.Count++
+		return true
+	})
+	assert.ErrorContains(t, err, "changed concurrently")
+}
@@ -0,0 +1,181 @@
+// Package redistest provides an in-memory Redis server for tests of the Redis-backed stores
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+)
+
+// Server speaks the Redis protocol on a local port. It implements the string, list and expiry
+// commands the stores use and EVAL of redis.CompareAndSetScript; other scripts get the reply
+// set with SetEvalReply. Expiry is recorded, not enforced. Any other command, including AUTH
+// and SELECT, gets an error reply.
+type Server struct {
+	URL string // redis:// URL of the server
+
+	mu        sync.Mutex
+	values    map[string]string
+	lists     map[string][]string
+	expiry    map[string]int
+	last      []string
+	evalReply string
+}
+
+// Start starts a server that stops when the test ends
+func Start(t testing.TB) *Server {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start fake redis: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	server := &Server{
+		URL:       "redis://" + listener.Addr().String(),
+		values:    map[string]string{},
+		lists:     map[string][]string{},
+		expiry:    map[string]int{},
+		evalReply: "-ERR unknown script\r\n",
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+// Expiry returns the TTL in seconds last set on key, or 0
+func (s *Server) Expiry(key string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.expiry[key]
+}
+
+// Value returns the string stored at key
+func (s *Server) Value(key string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.values[key]
+	return value, ok
+}
+
+// SetValue stores a string at key, e.g. to change a value under a store
+func (s *Server) SetValue(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// LastCommand returns the arguments of the last command received
+func (s *Server) LastCommand() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.last...)
+}
+
+// SetEvalReply sets the raw RESP reply to EVAL of scripts other than redis.CompareAndSetScript
+func (s *Server) SetEvalReply(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evalReply = reply
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		reply, err := redis.ReadReply(reader)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		if len(args) == 0 {
+			return
+		}
+		_, _ = conn.Write([]byte(s.handle(args)))
+	}
+}
+
+func (s *Server) handle(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = args
+
+	switch args[0] {
+	case "GET":
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return bulk(value)
+	case "SET":
+		s.values[args[1]] = args[2]
+		if len(args) == 5 && args[3] == "EX" {
+			s.expiry[args[1]], _ = strconv.Atoi(args[4])
+		}
+		return "+OK\r\n"
+	case "EXPIRE":
+		s.expiry[args[1]], _ = strconv.Atoi(args[2])
+		return ":1\r\n"
+	case "RPUSH":
+		s.lists[args[1]] = append(s.lists[args[1]], args[2:]...)
+		return fmt.Sprintf(":%d\r\n", len(s.lists[args[1]]))
+	case "LTRIM":
+		start, _ := strconv.Atoi(args[2])
+		list := s.lists[args[1]]
+		if start < 0 && -start < len(list) {
+			s.lists[args[1]] = list[len(list)+start:]
+		}
+		return "+OK\r\n"
+	case "LSET":
+		index, _ := strconv.Atoi(args[2])
+		list := s.lists[args[1]]
+		if index < 0 || index >= len(list) {
+			return "-ERR index out of range\r\n"
+		}
+		list[index] = args[3]
+		return "+OK\r\n"
+	case "LRANGE":
+		list := s.lists[args[1]]
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "*%d\r\n", len(list))
+		for _, item := range list {
+			sb.WriteString(bulk(item))
+		}
+		return sb.String()
+	case "EVAL":
+		if args[1] != redis.CompareAndSetScript {
+			return s.evalReply
+		}
+		// KEYS[1], then ARGV: expected value, new value, TTL
+		key, expected, value := args[3], args[4], args[5]
+		if current, ok := s.values[key]; !ok || current != expected {
+			return ":0\r\n"
+		}
+		s.values[key] = value
+		s.expiry[key], _ = strconv.Atoi(args[6])
+		return ":1\r\n"
+	default:
+		return "-ERR unknown command\r\n"
+	}
+}
+
+func bulk(value string) string {
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+}
@@ -0,0 +1,135 @@
+package session
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultHistoryWindow is how many recent turns are summarized into the prompt
+	DefaultHistoryWindow = 6
+
+	maxSummaryQuestionLength = 200
+	maxSummaryActions        = 8
+)
+
+// SummarizeHistory renders the last window turns as a compact prompt section, one line per
+// turn with the request and the actions it produced, or the clarifying question asked back.
+// Where the extension reported how applying the actions went, failures and the GUIDs of the
+// objects created follow. Returns "" when there are no turns.
+func SummarizeHistory(turns []Turn, window int) string {
+	if len(turns) == 0 {
+		return ""
+	}
+	if window <= 0 {
+		window = DefaultHistoryWindow
+	}
+
+	var sb strings.Builder
+	if omitted := len(turns) - window; omitted > 0 {
+		fmt.Fprintf(&sb, "(%d earlier turns omitted)\n", omitted)
+		turns = turns[omitted:]
+	}
+	for i, turn := range turns {
+		fmt.Fprintf(&sb, "%d. User: %q", i+1, truncate(turn.Question, maxSummaryQuestionLength))
+		if len(turn.Actions) > 0 {
+			fmt.Fprintf(&sb, " -> %s", summarizeActions(turn.Actions))
+		}
+		if len(turn.Results) > 0 {
+			fmt.Fprintf(&sb, " -> Result: %s", summarizeResults(turn.Actions, turn.Results))
+		}
+		if c := turn.Clarification; c != nil {
+			fmt.Fprintf(&sb, " -> You asked: %q", truncate(c.Question, maxSummaryQuestionLength))
+			if len(c.Options) > 0 {
+				fmt.Fprintf(&sb, " (options: %s)", strings.Join(c.Options, ", "))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// summarizeActions renders actions as action(key=value, ...) with keys sorted
+func summarizeActions(actions []map[string]any) string {
+	parts := make([]string, 0, min(len(actions), maxSummaryActions)+1)
+	for i, action := range actions {
+		if i == maxSummaryActions {
+			parts = append(parts, fmt.Sprintf("... +%d more", len(actions)-maxSummaryActions))
+			break
+		}
+
+		keys := make([]string, 0, len(action))
+		for key := range action {
+			if key != "action" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		args := make([]string, 0, len(keys))
+		for _, key := range keys {
+			args = append(args, fmt.Sprintf("%s=%s", key, summarizeValue(action[key])))
+		}
+		parts = append(parts, fmt.Sprintf("%v(%s)", action["action"], strings.Join(args, ", ")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// summarizeResults renders a turn's reported results: each failed action with its error and
+// each applied one with a GUID, or "all applied" when nothing else is worth saying
+func summarizeResults(actions []map[string]any, results []ActionResult) string {
+	failed := 0
+	parts := []string{}
+	for _, result := range results {
+		name := "action"
+		if result.ActionIndex >= 0 && result.ActionIndex < len(actions) {
+			name = fmt.Sprint(actions[result.ActionIndex]["action"])
+		}
+		switch {
+		case !result.Success:
+			failed++
+			part := fmt.Sprintf("#%d %s FAILED", result.ActionIndex+1, name)
+			if result.Error != "" {
+				part += fmt.Sprintf(": %q", truncate(result.Error, maxSummaryQuestionLength))
+			}
+			parts = append(parts, part)
+		case result.GUID != "":
+			parts = append(parts, fmt.Sprintf("#%d %s guid=%s", result.ActionIndex+1, name, result.GUID))
+		}
+	}
+	if len(parts) > maxSummaryActions {
+		parts = append(parts[:maxSummaryActions], fmt.Sprintf("... +%d more", len(parts)-maxSummaryActions))
+	}
+	summary := fmt.Sprintf("%d of %d applied", len(results)-failed, len(results))
+	if failed == 0 && len(results) == len(actions) {
+		summary = "all applied"
+	}
+	if len(parts) == 0 {
+		return summary
+	}
+	return summary + "; " + strings.Join(parts, ", ")
+}
+
+// summarizeValue keeps long values such as MIDI note lists out of the prompt
+func summarizeValue(value any) string {
+	switch v := value.(type) {
+	case []any:
+		return fmt.Sprintf("[%d items]", len(v))
+	case []map[string]any:
+		return fmt.Sprintf("[%d items]", len(v))
+	case map[string]any:
+		return fmt.Sprintf("{%d fields}", len(v))
+	case string:
+		return fmt.Sprintf("%q", truncate(v, maxSummaryQuestionLength))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
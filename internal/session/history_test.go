@@ -0,0 +1,87 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeHistory(t *testing.T) {
+	turns := []Turn{
+		{Question: "create a drums track"},
+		{
+			Question: "create a bass track with Serum",
+			Actions: []map[string]any{
+				{"action": "create_track", "index": 1, "name": "Bass", "instrument": "Serum"},
+				{"action": "add_midi", "track": 1, "notes": []any{map[string]any{}, map[string]any{}}},
+			},
+		},
+		{
+			Question: "mute it",
+			Actions:  []map[string]any{{"action": "set_track", "track": 1, "mute": true}},
+		},
+	}
+
+	assert.Equal(t, "", SummarizeHistory(nil, 3))
+	assert.Equal(t,
+		"(1 earlier turns omitted)\n"+
+			`1. User: "create a bass track with Serum" -> `+
+			`create_track(index=1, instrument="Serum", name="Bass"), add_midi(notes=[2 items], track=1)`+"\n"+
+			`2. User: "mute it" -> set_track(mute=true, track=1)`,
+		SummarizeHistory(turns, 2),
+	)
+}
+
+func TestSummarizeHistory_LimitsActions(t *testing.T) {
+	actions := make([]map[string]any, 10)
+	for i := range actions {
+		actions[i] = map[string]any{"action": "delete_track", "track": i}
+	}
+
+	summary := SummarizeHistory([]Turn{{Question: "delete everything", Actions: actions}}, 0)
+	assert.Contains(t, summary, "delete_track(track=7), ... +2 more")
+	assert.NotContains(t, summary, "track=8")
+}
+
+func TestSummarizeHistory_Clarification(t *testing.T) {
+	turns := []Turn{
+		{
+			Question: "make it punchier",
+			Clarification: &models.Clarification{
+				Question: "Which track should sound punchier?",
+				Options:  []string{"Drums", "Bass"},
+			},
+		},
+		{
+			Question: "the drums",
+			Actions:  []map[string]any{{"action": "set_track", "track": 0, "volume_db": -3}},
+		},
+	}
+
+	assert.Equal(t,
+		`1. User: "make it punchier" -> You asked: "Which track should sound punchier?" (options: Drums, Bass)`+"\n"+
+			`2. User: "the drums" -> set_track(track=0, volume_db=-3)`,
+		SummarizeHistory(turns, 0),
+	)
+}
+
+func TestSummarizeHistory_Results(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "create_track", "index": 1, "name": "Bass"},
+		{"action": "add_fx", "track": 1, "fxname": "ValhallaRoom"},
+	}
+	turns := []Turn{{
+		Question: "create a bass track with reverb",
+		Actions:  actions,
+		Results: []ActionResult{
+			{ActionIndex: 0, Success: true, GUID: "{AB12}"},
+			{ActionIndex: 1, Error: "plugin wasn't found"},
+		},
+	}}
+	assert.Contains(t, SummarizeHistory(turns, 0),
+		` -> Result: 1 of 2 applied; #1 create_track guid={AB12}, #2 add_fx FAILED: "plugin wasn't found"`)
+
+	turns[0].Results = []ActionResult{{ActionIndex: 0, Success: true}, {ActionIndex: 1, Success: true}}
+	assert.Contains(t, SummarizeHistory(turns, 0), " -> Result: all applied")
+}
@@ -0,0 +1,150 @@
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+)
+
+const (
+	redisKeyPrefix         = "magda:session:"
+	redisStateKeySuffix    = ":state"
+	redisDefaultsKeySuffix = ":defaults"
+)
+
+// RedisStore keeps sessions in Redis lists (one JSON turn per entry) so history is shared
+// between instances and survives restarts
+type RedisStore struct {
+	client   *redis.Client
+	ttl      time.Duration
+	maxTurns int
+	states   *redis.JSONStore[StateSnapshot]
+	defaults *redis.JSONStore[models.MusicalDefaults]
+}
+
+// NewRedisStore creates a store for a redis://[user:password@]host:port[/db] URL.
+// Non-positive ttl and maxTurns use the defaults. The connection is opened on first use.
+func NewRedisStore(redisURL string, ttl time.Duration, maxTurns int) (*RedisStore, error) {
+	client, err := redis.NewClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &RedisStore{
+		client:   client,
+		ttl:      ttl,
+		maxTurns: maxTurns,
+	}
+	if store.ttl <= 0 {
+		store.ttl = DefaultTTL
+	}
+	if store.maxTurns <= 0 {
+		store.maxTurns = DefaultMaxTurns
+	}
+	store.states = redis.NewJSONStore[StateSnapshot](client, redisKeyPrefix, "session state", store.ttl)
+	store.defaults = redis.NewJSONStore[models.MusicalDefaults](client, redisKeyPrefix, "session defaults", store.ttl)
+	return store, nil
+}
+
+// History returns the session's turns from its Redis list
+func (s *RedisStore) History(ctx context.Context, sessionID string) ([]Turn, error) {
+	reply, err := s.client.Do(ctx, "LRANGE", redisKeyPrefix+sessionID, "0", "-1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session history: %w", err)
+	}
+
+	items, _ := reply.([]any)
+	turns := make([]Turn, 0, len(items))
+	for _, item := range items {
+		data, ok := item.(string)
+		if !ok {
+			continue
+		}
+		var turn Turn
+		if err := json.Unmarshal([]byte(data), &turn); err != nil {
+			return nil, fmt.Errorf("failed to decode session turn: %w", err)
+		}
+		turns = append(turns, turn)
+	}
+	return turns, nil
+}
+
+// Append pushes a turn, trims the list to the newest turns and refreshes the TTL
+func (s *RedisStore) Append(ctx context.Context, sessionID string, turn Turn) error {
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return fmt.Errorf("failed to encode session turn: %w", err)
+	}
+
+	key := redisKeyPrefix + sessionID
+	commands := [][]string{
+		{"RPUSH", key, string(data)},
+		{"LTRIM", key, strconv.Itoa(-s.maxTurns), "-1"},
+		{"EXPIRE", key, strconv.Itoa(int(s.ttl.Seconds()))},
+	}
+	for _, command := range commands {
+		if _, err := s.client.Do(ctx, command...); err != nil {
+			return fmt.Errorf("failed to store session turn: %w", err)
+		}
+	}
+	return nil
+}
+
+// RecordResults rewrites the newest turn with actions in place and refreshes the TTL
+func (s *RedisStore) RecordResults(ctx context.Context, sessionID string, results []ActionResult) error {
+	turns, err := s.History(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	index := LastActionTurn(turns)
+	if index < 0 {
+		return ErrNoActionTurn
+	}
+	turn := turns[index]
+	turn.Results = results
+	data, err := json.Marshal(turn)
+	if err != nil {
+		return fmt.Errorf("failed to encode session turn: %w", err)
+	}
+
+	key := redisKeyPrefix + sessionID
+	commands := [][]string{
+		{"LSET", key, strconv.Itoa(index), string(data)},
+		{"EXPIRE", key, strconv.Itoa(int(s.ttl.Seconds()))},
+	}
+	for _, command := range commands {
+		if _, err := s.client.Do(ctx, command...); err != nil {
+			return fmt.Errorf("failed to store session turn results: %w", err)
+		}
+	}
+	return nil
+}
+
+// State loads the session's project state, stored as one JSON value next to its turns
+func (s *RedisStore) State(ctx context.Context, sessionID string) (*StateSnapshot, error) {
+	return s.states.Get(ctx, sessionID+redisStateKeySuffix)
+}
+
+// SaveState stores the session's project state with the store's TTL
+func (s *RedisStore) SaveState(ctx context.Context, sessionID string, snapshot StateSnapshot) error {
+	return s.states.Set(ctx, sessionID+redisStateKeySuffix, &snapshot)
+}
+
+// Defaults loads the session's musical defaults, stored as one JSON value next to its turns
+func (s *RedisStore) Defaults(ctx context.Context, sessionID string) (models.MusicalDefaults, error) {
+	defaults, err := s.defaults.Get(ctx, sessionID+redisDefaultsKeySuffix)
+	if err != nil || defaults == nil {
+		return models.MusicalDefaults{}, err
+	}
+	return *defaults, nil
+}
+
+// SaveDefaults stores the session's musical defaults with the store's TTL
+func (s *RedisStore) SaveDefaults(ctx context.Context, sessionID string, defaults models.MusicalDefaults) error {
+	return s.defaults.Set(ctx, sessionID+redisDefaultsKeySuffix, &defaults)
+}
@@ -0,0 +1,111 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/Conceptual-Machines/magda-api/internal/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore_AppendAndHistory(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour, 2)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	actions := []map[string]any{{"action": "create_track", "name": "Bass"}}
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "create a bass track", Actions: actions}))
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "add reverb"}))
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "now make it louder"}))
+
+	turns, err := store.History(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, turns, 2)
+	assert.Equal(t, "add reverb", turns[0].Question)
+	assert.Equal(t, "now make it louder", turns[1].Question)
+	assert.Equal(t, 3600, server.Expiry("magda:session:s1"))
+
+	empty, err := store.History(ctx, "unknown")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestRedisStore_RecordResults(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour, 5)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	assert.ErrorIs(t, store.RecordResults(ctx, "s1", []ActionResult{{ActionIndex: 0}}), ErrNoActionTurn)
+
+	actions := []map[string]any{{"action": "create_track", "name": "Bass"}}
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "create a bass track", Actions: actions}))
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "what's on the bass?"}))
+	results := []ActionResult{{ActionIndex: 0, Success: true, GUID: "{AB12}"}}
+	require.NoError(t, store.RecordResults(ctx, "s1", results))
+
+	turns, err := store.History(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, turns, 2)
+	assert.Equal(t, results, turns[0].Results)
+	assert.Empty(t, turns[1].Results)
+	assert.Equal(t, 3600, server.Expiry("magda:session:s1"))
+}
+
+func TestRedisStore_State(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour, 2)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	snapshot, err := store.State(ctx, "s1")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	saved := StateSnapshot{
+		State:   map[string]any{"tracks": []any{map[string]any{"name": "Bass"}}},
+		Version: 2,
+		Updated: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, store.SaveState(ctx, "s1", saved))
+
+	snapshot, err = store.State(ctx, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, saved, *snapshot)
+	assert.Equal(t, 3600, server.Expiry("magda:session:s1:state"))
+}
+
+func TestRedisStore_Defaults(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour, 2)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	defaults, err := store.Defaults(ctx, "s1")
+	require.NoError(t, err)
+	assert.Zero(t, defaults)
+
+	saved := models.MusicalDefaults{Octave: 3, ClipBars: 2}
+	require.NoError(t, store.SaveDefaults(ctx, "s1", saved))
+
+	defaults, err = store.Defaults(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, saved, defaults)
+	assert.Equal(t, 3600, server.Expiry("magda:session:s1:defaults"))
+}
+
+func TestRedisStore_ErrorReply(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL+"/3", time.Hour, 2)
+	require.NoError(t, err)
+
+	// The fake server rejects SELECT, so connecting fails
+	_, err = store.History(context.Background(), "s1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "redis SELECT failed")
+}
@@ -0,0 +1,122 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// StateSnapshot is the last project state a client sent for a session. Clients send the full
+// state once, then deltas against Version, so large projects aren't re-sent on every request.
+type StateSnapshot struct {
+	State   map[string]any `json:"state"`
+	Version int            `json:"version"` // Incremented by every snapshot or delta
+	Updated time.Time      `json:"updated"`
+}
+
+// CopyState deep-copies a JSON state snapshot
+func CopyState(state map[string]any) (map[string]any, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	var copied map[string]any
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	return copied, nil
+}
+
+// ApplyStateDelta returns state with delta merged in like a JSON Merge Patch (RFC 7386):
+// objects merge, null removes a key and other values replace. An array is patched element by
+// element with an object keyed by index, e.g. {"tracks": {"2": {"muted": true}, "5": null}}: a
+// null element is removed and the index one past the end appends. Indices refer to the array
+// before the delta. state is left unchanged.
+func ApplyStateDelta(state, delta map[string]any) (map[string]any, error) {
+	state, err := CopyState(state)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = map[string]any{}
+	}
+	merged, err := mergeDelta(state, delta, "")
+	if err != nil {
+		return nil, err
+	}
+	return merged.(map[string]any), nil
+}
+
+func mergeDelta(target, patch any, path string) (any, error) {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch, nil
+	}
+	switch target := target.(type) {
+	case map[string]any:
+		for key, value := range patchObject {
+			if value == nil {
+				delete(target, key)
+				continue
+			}
+			merged, err := mergeDelta(target[key], value, path+"."+key)
+			if err != nil {
+				return nil, err
+			}
+			target[key] = merged
+		}
+		return target, nil
+	case []any:
+		return patchArray(target, patchObject, path)
+	default:
+		return mergeDelta(map[string]any{}, patchObject, path)
+	}
+}
+
+func patchArray(items []any, patch map[string]any, path string) ([]any, error) {
+	type element struct {
+		index int
+		value any
+	}
+	elements := make([]element, 0, len(patch))
+	for key, value := range patch {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("state delta %s: %q is not an array index", path, key)
+		}
+		elements = append(elements, element{index: i, value: value})
+	}
+	sort.Slice(elements, func(a, b int) bool { return elements[a].index < elements[b].index })
+
+	var removed []int
+	for _, e := range elements {
+		elementPath := fmt.Sprintf("%s[%d]", path, e.index)
+		switch {
+		case e.index < len(items) && e.value == nil:
+			removed = append(removed, e.index)
+		case e.index < len(items):
+			merged, err := mergeDelta(items[e.index], e.value, elementPath)
+			if err != nil {
+				return nil, err
+			}
+			items[e.index] = merged
+		case e.index == len(items) && e.value != nil:
+			merged, err := mergeDelta(nil, e.value, elementPath)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, merged)
+		default:
+			return nil, fmt.Errorf("state delta %s: index %d is out of range (length %d)", path, e.index, len(items))
+		}
+	}
+	for j := len(removed) - 1; j >= 0; j-- {
+		items = append(items[:removed[j]], items[removed[j]+1:]...)
+	}
+	return items, nil
+}
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyStateDelta(t *testing.T) {
+	newState := func() map[string]any {
+		return map[string]any{
+			"project": map[string]any{"bpm": 120.0, "name": "Song"},
+			"tracks": []any{
+				map[string]any{"index": 0.0, "name": "Drums", "muted": false},
+				map[string]any{"index": 1.0, "name": "Bass", "muted": false},
+			},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		delta   map[string]any
+		want    map[string]any
+		wantErr string
+	}{
+		{
+			name:  "merge object",
+			delta: map[string]any{"project": map[string]any{"bpm": 98.0, "name": nil}},
+			want: map[string]any{
+				"project": map[string]any{"bpm": 98.0},
+				"tracks":  newState()["tracks"],
+			},
+		},
+		{
+			name:  "patch array element",
+			delta: map[string]any{"tracks": map[string]any{"1": map[string]any{"muted": true}}},
+			want: map[string]any{
+				"project": newState()["project"],
+				"tracks": []any{
+					map[string]any{"index": 0.0, "name": "Drums", "muted": false},
+					map[string]any{"index": 1.0, "name": "Bass", "muted": true},
+				},
+			},
+		},
+		{
+			name: "remove and append elements",
+			delta: map[string]any{"tracks": map[string]any{
+				"0": nil,
+				"2": map[string]any{"index": 2.0, "name": "Keys"},
+			}},
+			want: map[string]any{
+				"project": newState()["project"],
+				"tracks": []any{
+					map[string]any{"index": 1.0, "name": "Bass", "muted": false},
+					map[string]any{"index": 2.0, "name": "Keys"},
+				},
+			},
+		},
+		{
+			name:  "replace array",
+			delta: map[string]any{"tracks": []any{}},
+			want:  map[string]any{"project": newState()["project"], "tracks": []any{}},
+		},
+		{
+			name:    "index out of range",
+			delta:   map[string]any{"tracks": map[string]any{"5": map[string]any{"name": "Pad"}}},
+			wantErr: "out of range",
+		},
+		{
+			name:    "not an index",
+			delta:   map[string]any{"tracks": map[string]any{"first": map[string]any{"name": "Pad"}}},
+			wantErr: "not an array index",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newState()
+			got, err := ApplyStateDelta(state, tt.delta)
+			assert.Equal(t, newState(), state, "the original state must not change")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,239 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+)
+
+const (
+	// DefaultTTL is how long a session is kept after its last turn
+	DefaultTTL = 24 * time.Hour
+	// DefaultMaxTurns is how many turns a store keeps per session
+	DefaultMaxTurns = 20
+)
+
+// Turn is one completed request in a conversation
+type Turn struct {
+	Question      string                `json:"question"`
+	Actions       []map[string]any      `json:"actions,omitempty"`
+	Clarification *models.Clarification `json:"clarification,omitempty"` // Question asked back, if any
+	Results       []ActionResult        `json:"results,omitempty"`       // How applying Actions went, once reported
+	Timestamp     time.Time             `json:"timestamp"`
+}
+
+// ActionResult is the extension's report of applying one of a turn's actions
+type ActionResult struct {
+	ActionIndex int    `json:"action_index"`
+	Success     bool   `json:"success"`
+	Error       string `json:"error,omitempty"`
+	GUID        string `json:"guid,omitempty"` // GUID of the object the action created or changed
+}
+
+// ErrNoActionTurn is returned by RecordResults when the session has no turn with actions
+var ErrNoActionTurn = errors.New("session has no turn with actions")
+
+// LastActionTurn returns the index of the newest turn with actions, or -1 if there is none
+func LastActionTurn(turns []Turn) int {
+	for i := len(turns) - 1; i >= 0; i-- {
+		if len(turns[i].Actions) > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// Store keeps conversation history keyed by session ID
+type Store interface {
+	// History returns the session's turns, oldest first. Unknown sessions have no turns.
+	History(ctx context.Context, sessionID string) ([]Turn, error)
+	// Append adds a turn to the session, dropping the oldest turns past the store's limit
+	Append(ctx context.Context, sessionID string, turn Turn) error
+	// RecordResults replaces the results of the session's newest turn with actions, or returns
+	// ErrNoActionTurn
+	RecordResults(ctx context.Context, sessionID string, results []ActionResult) error
+	// State returns the session's last project state, or nil if the client hasn't sent one
+	State(ctx context.Context, sessionID string) (*StateSnapshot, error)
+	// SaveState replaces the session's project state
+	SaveState(ctx context.Context, sessionID string, snapshot StateSnapshot) error
+	// Defaults returns the session's musical defaults, none if they were never set
+	Defaults(ctx context.Context, sessionID string) (models.MusicalDefaults, error)
+	// SaveDefaults replaces the session's musical defaults
+	SaveDefaults(ctx context.Context, sessionID string, defaults models.MusicalDefaults) error
+}
+
+// NewStore creates the store for backend: "memory" (default) or "redis"
+func NewStore(backend, redisURL string, ttl time.Duration) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemoryStore(ttl, DefaultMaxTurns), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis session store")
+		}
+		return NewRedisStore(redisURL, ttl, DefaultMaxTurns)
+	default:
+		return nil, fmt.Errorf("unknown session store %q: must be \"memory\" or \"redis\"", backend)
+	}
+}
+
+// MemoryStore keeps sessions in process memory. Sessions are lost on restart and
+// are not shared between instances; use RedisStore for that.
+type MemoryStore struct {
+	ttl      time.Duration
+	maxTurns int
+
+	mu       sync.Mutex
+	sessions map[string]*memorySession
+	now      func() time.Time
+}
+
+type memorySession struct {
+	turns    []Turn
+	state    *StateSnapshot
+	defaults models.MusicalDefaults
+	updated  time.Time
+}
+
+// NewMemoryStore creates an in-memory store. Non-positive values use the defaults.
+func NewMemoryStore(ttl time.Duration, maxTurns int) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	if maxTurns <= 0 {
+		maxTurns = DefaultMaxTurns
+	}
+	return &MemoryStore{
+		ttl:      ttl,
+		maxTurns: maxTurns,
+		sessions: make(map[string]*memorySession),
+		now:      time.Now,
+	}
+}
+
+// History returns a copy of the session's turns
+func (s *MemoryStore) History(ctx context.Context, sessionID string) ([]Turn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	if s.now().Sub(session.updated) > s.ttl {
+		delete(s.sessions, sessionID)
+		return nil, nil
+	}
+	return append([]Turn(nil), session.turns...), nil
+}
+
+// Append adds a turn and evicts expired sessions
+func (s *MemoryStore) Append(ctx context.Context, sessionID string, turn Turn) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session := s.session(sessionID)
+	session.turns = append(session.turns, turn)
+	if len(session.turns) > s.maxTurns {
+		session.turns = append([]Turn(nil), session.turns[len(session.turns)-s.maxTurns:]...)
+	}
+
+	logger.Printf(ctx, "💬 Session %s: stored turn %d", sessionID, len(session.turns))
+	return nil
+}
+
+// RecordResults stores results on the newest turn with actions
+func (s *MemoryStore) RecordResults(ctx context.Context, sessionID string, results []ActionResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || s.now().Sub(session.updated) > s.ttl {
+		return ErrNoActionTurn
+	}
+	index := LastActionTurn(session.turns)
+	if index < 0 {
+		return ErrNoActionTurn
+	}
+	session.turns[index].Results = results
+	session.updated = s.now()
+
+	logger.Printf(ctx, "💬 Session %s: stored results for turn %d", sessionID, index+1)
+	return nil
+}
+
+// State returns the session's last project state. The state map is shared with the store, so
+// callers copy it before changing it.
+func (s *MemoryStore) State(ctx context.Context, sessionID string) (*StateSnapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok || session.state == nil {
+		return nil, nil
+	}
+	if s.now().Sub(session.updated) > s.ttl {
+		delete(s.sessions, sessionID)
+		return nil, nil
+	}
+	snapshot := *session.state
+	return &snapshot, nil
+}
+
+// SaveState replaces the session's project state and evicts expired sessions
+func (s *MemoryStore) SaveState(ctx context.Context, sessionID string, snapshot StateSnapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.session(sessionID).state = &snapshot
+	return nil
+}
+
+// Defaults returns the session's musical defaults
+func (s *MemoryStore) Defaults(ctx context.Context, sessionID string) (models.MusicalDefaults, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		return models.MusicalDefaults{}, nil
+	}
+	if s.now().Sub(session.updated) > s.ttl {
+		delete(s.sessions, sessionID)
+		return models.MusicalDefaults{}, nil
+	}
+	return session.defaults, nil
+}
+
+// SaveDefaults replaces the session's musical defaults and evicts expired sessions
+func (s *MemoryStore) SaveDefaults(ctx context.Context, sessionID string, defaults models.MusicalDefaults) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.session(sessionID).defaults = defaults
+	return nil
+}
+
+// session returns sessionID's session, creating it, after evicting expired sessions. The
+// session is marked updated now. s.mu must be held.
+func (s *MemoryStore) session(sessionID string) *memorySession {
+	now := s.now()
+	for id, session := range s.sessions {
+		if now.Sub(session.updated) > s.ttl {
+			delete(s.sessions, id)
+		}
+	}
+
+	session, ok := s.sessions[sessionID]
+	if !ok {
+		session = &memorySession{}
+		s.sessions[sessionID] = session
+	}
+	session.updated = now
+	return session
+}
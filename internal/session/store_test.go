@@ -0,0 +1,149 @@
+package session
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_AppendAndHistory(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(time.Hour, 2)
+
+	for _, question := range []string{"create a bass track", "add reverb", "now make it louder"} {
+		require.NoError(t, store.Append(ctx, "s1", Turn{Question: question}))
+	}
+
+	turns, err := store.History(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, turns, 2)
+	assert.Equal(t, "add reverb", turns[0].Question)
+	assert.Equal(t, "now make it louder", turns[1].Question)
+
+	other, err := store.History(ctx, "s2")
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
+
+func TestMemoryStore_ExpiresSessions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(time.Hour, 10)
+	store.now = func() time.Time { return now }
+
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "create a bass track"}))
+
+	now = now.Add(30 * time.Minute)
+	turns, err := store.History(ctx, "s1")
+	require.NoError(t, err)
+	assert.Len(t, turns, 1)
+
+	now = now.Add(2 * time.Hour)
+	turns, err = store.History(ctx, "s1")
+	require.NoError(t, err)
+	assert.Empty(t, turns)
+}
+
+func TestMemoryStore_RecordResults(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(time.Hour, 10)
+
+	results := []ActionResult{{ActionIndex: 0, Error: "plugin wasn't found"}}
+	assert.ErrorIs(t, store.RecordResults(ctx, "s1", results), ErrNoActionTurn)
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "hello"}))
+	assert.ErrorIs(t, store.RecordResults(ctx, "s1", results), ErrNoActionTurn)
+
+	// Results go to the newest turn with actions, past later turns without any
+	actions := []map[string]any{{"action": "add_fx", "track": 0, "fxname": "ValhallaRoom"}}
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "add reverb", Actions: actions}))
+	require.NoError(t, store.Append(ctx, "s1", Turn{Question: "make it louder"}))
+	require.NoError(t, store.RecordResults(ctx, "s1", results))
+
+	turns, err := store.History(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, turns, 3)
+	assert.Equal(t, results, turns[1].Results)
+	assert.Empty(t, turns[2].Results)
+}
+
+func TestMemoryStore_State(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(time.Hour, 10)
+	store.now = func() time.Time { return now }
+
+	snapshot, err := store.State(ctx, "s1")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	state := map[string]any{"tracks": []any{}}
+	require.NoError(t, store.SaveState(ctx, "s1", StateSnapshot{State: state, Version: 1, Updated: now}))
+	// Saving state doesn't add a turn
+	turns, err := store.History(ctx, "s1")
+	require.NoError(t, err)
+	assert.Empty(t, turns)
+
+	snapshot, err = store.State(ctx, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, snapshot)
+	assert.Equal(t, 1, snapshot.Version)
+	assert.Equal(t, state, snapshot.State)
+
+	now = now.Add(2 * time.Hour)
+	snapshot, err = store.State(ctx, "s1")
+	require.NoError(t, err)
+	assert.Nil(t, snapshot)
+}
+
+func TestMemoryStore_Defaults(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(time.Hour, 10)
+	store.now = func() time.Time { return now }
+
+	defaults, err := store.Defaults(ctx, "s1")
+	require.NoError(t, err)
+	assert.Zero(t, defaults)
+
+	saved := models.MusicalDefaults{Octave: 3, Velocity: 90, TimeSignature: "3/4"}
+	require.NoError(t, store.SaveDefaults(ctx, "s1", saved))
+	defaults, err = store.Defaults(ctx, "s1")
+	require.NoError(t, err)
+	assert.Equal(t, saved, defaults)
+
+	// Other sessions keep their own
+	defaults, err = store.Defaults(ctx, "s2")
+	require.NoError(t, err)
+	assert.Zero(t, defaults)
+
+	now = now.Add(2 * time.Hour)
+	defaults, err = store.Defaults(ctx, "s1")
+	require.NoError(t, err)
+	assert.Zero(t, defaults)
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore("", "", 0)
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	store, err = NewStore("redis", "redis://:secret@localhost/2", time.Hour)
+	require.NoError(t, err)
+	redisStore, ok := store.(*RedisStore)
+	require.True(t, ok)
+	assert.Equal(t, time.Hour, redisStore.ttl)
+	assert.Equal(t, DefaultMaxTurns, redisStore.maxTurns)
+
+	_, err = NewStore("redis", "http://localhost", time.Hour)
+	assert.Error(t, err)
+
+	_, err = NewStore("redis", "", time.Hour)
+	assert.Error(t, err)
+
+	_, err = NewStore("postgres", "", time.Hour)
+	assert.Error(t, err)
+}
@@ -0,0 +1,244 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+)
+
+// clipProperties are the clip properties set_clip sets
+var clipProperties = []string{"name", "color", "length", "selected", "loop", "source_length"}
+
+func (s *simulation) applyClipAction(actionType string, action map[string]any) error {
+	t, err := s.target(action)
+	if err != nil {
+		return err
+	}
+	if t == s.master {
+		return fmt.Errorf("the master track has no clips")
+	}
+
+	switch actionType {
+	case "create_clip":
+		position, _ := number(action["position"])
+		length, _ := number(action["length"])
+		return s.createClip(t, position, length, action)
+	case "create_clip_at_bar":
+		bar, ok := number(action["bar"])
+		if !ok {
+			return fmt.Errorf("missing bar")
+		}
+		bars, _ := number(action["length_bars"])
+		start := s.barStart(bar)
+		return s.createClip(t, start, s.barStart(bar+bars)-start, action)
+	case "add_midi":
+		return s.addMIDI(t, action)
+	}
+
+	at, clip, err := s.findClip(t, action, actionType)
+	if err != nil || clip == nil {
+		return err
+	}
+	position, _ := number(clip["position"])
+	length, _ := number(clip["length"])
+
+	switch actionType {
+	case "set_clip":
+		copyFields(clip, action, clipProperties...)
+	case "set_clip_position":
+		copyFields(clip, action, "position")
+	case "delete_clip":
+		t.clips = append(t.clips[:at], t.clips[at+1:]...)
+	case "duplicate_clip":
+		count := 1
+		if n, ok := integer(action, "count"); ok {
+			count = n
+		}
+		if count < 1 {
+			return fmt.Errorf("count %d is less than 1", count)
+		}
+		offset := length
+		if v, ok := number(action["offset"]); ok {
+			offset = v
+		}
+		for i := 1; i <= count; i++ {
+			copied := newClip(clip)
+			copied["position"] = position + float64(i)*offset
+			t.clips = append(t.clips, copied)
+		}
+	case "split_clip":
+		point, ok := s.clipPoint(action)
+		if !ok {
+			return fmt.Errorf("missing split point")
+		}
+		if point <= position+tolerance || point >= position+length-tolerance {
+			return fmt.Errorf("split point %.2fs isn't inside the clip (%.2fs to %.2fs)", point, position, position+length)
+		}
+		right := newClip(clip)
+		right["position"] = point
+		right["length"] = position + length - point
+		clip["length"] = point - position
+		t.clips = append(t.clips, right)
+	case "trim_clip":
+		start, end := position, position+length
+		if v, ok := number(action["start"]); ok {
+			start = v
+		} else if v, ok := number(action["start_bar"]); ok {
+			start = s.barStart(v)
+		}
+		if v, ok := number(action["end"]); ok {
+			end = v
+		} else if v, ok := number(action["end_bar"]); ok {
+			end = s.barStart(v)
+		}
+		if end <= start+tolerance {
+			return fmt.Errorf("trim leaves nothing of the clip (%.2fs to %.2fs)", start, end)
+		}
+		clip["position"] = start
+		clip["length"] = end - start
+	case "set_clip_notes":
+		notes, _ := action["notes"].([]any)
+		clip["notes"] = notes
+		clip["note_count"] = len(notes)
+	}
+	return nil
+}
+
+// createClip adds a clip, which a following add_midi on the track fills
+func (s *simulation) createClip(t *track, position, length float64, action map[string]any) error {
+	if length <= 0 {
+		return fmt.Errorf("length %g isn't positive", length)
+	}
+	clip := map[string]any{"position": position, "length": length}
+	copyFields(clip, action, "name")
+	t.clips = append(t.clips, clip)
+	t.clipsKnown = true
+	s.lastClip[t] = clip
+	return nil
+}
+
+// addMIDI adds notes to the clip created before it on the track, or to a new clip at the start
+// of the project long enough for them (whole bars)
+func (s *simulation) addMIDI(t *track, action map[string]any) error {
+	notes, _ := action["notes"].([]any)
+	clip := s.lastClip[t]
+	if clip == nil {
+		beats := 0.0
+		for _, item := range notes {
+			note, _ := item.(map[string]any)
+			start, _ := number(note["start"])
+			length, _ := number(note["length"])
+			beats = math.Max(beats, start+length)
+		}
+		bars := math.Max(1, math.Ceil(beats/s.tempo.BeatsPerBar()))
+		if err := s.createClip(t, 0, s.tempo.BarsToSeconds(bars), action); err != nil {
+			return err
+		}
+		clip = s.lastClip[t]
+	} else {
+		copyFields(clip, action, "name")
+	}
+	count, _ := integer(clip, "note_count")
+	clip["note_count"] = count + len(notes)
+	return nil
+}
+
+// findClip finds the clip an action targets: by clip_guid, then by its index in state, then by
+// its start (position, or old_position for set_clip_position), then by bar, which matches the
+// clip starting on the bar or else the clip playing over it. split_clip's position and bar are
+// the split point, so there they match the clip playing over it. Returns a nil clip and no
+// error when the clip isn't found and state didn't list the track's clips.
+func (s *simulation) findClip(t *track, action map[string]any, actionType string) (int, map[string]any, error) {
+	if guid, _ := action["clip_guid"].(string); guid != "" {
+		for i, clip := range t.clips {
+			if clip["guid"] == guid {
+				return i, clip, nil
+			}
+		}
+	}
+
+	identified := false
+	if index, ok := integer(action, "clip"); ok {
+		identified = true
+		for i, clip := range t.clips {
+			if clipIndex, ok := integer(clip, "index"); ok && clipIndex == index {
+				return i, clip, nil
+			}
+		}
+	} else if actionType == "split_clip" {
+		if point, ok := s.clipPoint(action); ok {
+			identified = true
+			if i := clipOver(t.clips, point); i >= 0 {
+				return i, t.clips[i], nil
+			}
+		}
+	} else {
+		positionKey := "position"
+		if actionType == "set_clip_position" {
+			positionKey = "old_position"
+		}
+		if position, ok := number(action[positionKey]); ok {
+			identified = true
+			if i := clipAt(t.clips, position); i >= 0 {
+				return i, t.clips[i], nil
+			}
+		} else if bar, ok := number(action["bar"]); ok {
+			identified = true
+			start := s.barStart(bar)
+			if i := clipAt(t.clips, start); i >= 0 {
+				return i, t.clips[i], nil
+			}
+			if i := clipOver(t.clips, start); i >= 0 {
+				return i, t.clips[i], nil
+			}
+		}
+	}
+
+	if _, hasGUID := action["clip_guid"]; !identified && !hasGUID {
+		return -1, nil, fmt.Errorf("no clip identified (clip, position or bar)")
+	}
+	if !t.clipsKnown {
+		return -1, nil, nil
+	}
+	return -1, nil, fmt.Errorf("no matching clip on %s", s.describe(t))
+}
+
+// clipPoint reads split_clip's split point from position or bar
+func (s *simulation) clipPoint(action map[string]any) (float64, bool) {
+	if v, ok := number(action["position"]); ok {
+		return v, true
+	}
+	if v, ok := number(action["bar"]); ok {
+		return s.barStart(v), true
+	}
+	return 0, false
+}
+
+// clipAt returns the index of the clip starting at position, or -1
+func clipAt(clips []map[string]any, position float64) int {
+	for i, clip := range clips {
+		if start, ok := number(clip["position"]); ok && samePosition(start, position) {
+			return i
+		}
+	}
+	return -1
+}
+
+// clipOver returns the index of the clip playing at position, or -1
+func clipOver(clips []map[string]any, position float64) int {
+	for i, clip := range clips {
+		start, _ := number(clip["position"])
+		length, _ := number(clip["length"])
+		if position >= start-tolerance && position < start+length-tolerance {
+			return i
+		}
+	}
+	return -1
+}
+
+// newClip copies clip as a new item: without its GUID or index
+func newClip(clip map[string]any) map[string]any {
+	copied := copyMap(clip)
+	delete(copied, "guid")
+	delete(copied, "index")
+	return copied
+}
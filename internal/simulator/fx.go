@@ -0,0 +1,213 @@
+package simulator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+func (s *simulation) applyFXAction(actionType string, action map[string]any) error {
+	t, err := s.target(action)
+	if err != nil {
+		return err
+	}
+
+	switch actionType {
+	case "add_track_fx":
+		t.fx = append(t.fx, newFX(action))
+		return nil
+	case "add_instrument":
+		if t == s.master {
+			return fmt.Errorf("the master track can't hold an instrument")
+		}
+		// Instruments go first in the chain, ahead of the effects they feed
+		t.fx = append([]map[string]any{newFX(action)}, t.fx...)
+		return nil
+	}
+
+	at, err := s.findFX(t, action)
+	if err != nil || at < 0 {
+		return err
+	}
+	fx := t.fx[at]
+
+	switch actionType {
+	case "set_fx_param":
+		param, _ := action["param"].(string)
+		params, _ := fx["params"].(map[string]any)
+		if params == nil {
+			params = map[string]any{}
+			fx["params"] = params
+		}
+		params[param] = action["value"]
+	case "bypass_fx":
+		fx["enabled"] = false
+	case "enable_fx":
+		fx["enabled"] = true
+	case "remove_fx":
+		t.fx = append(t.fx[:at], t.fx[at+1:]...)
+	case "move_fx":
+		to, ok := integer(action, "to")
+		if !ok {
+			return fmt.Errorf("missing to")
+		}
+		if to < 1 || to > len(t.fx) {
+			return fmt.Errorf("FX position %d doesn't exist (%s has %d FX)", to, s.describe(t), len(t.fx))
+		}
+		t.fx = append(t.fx[:at], t.fx[at+1:]...)
+		t.fx = append(t.fx[:to-1], append([]map[string]any{fx}, t.fx[to-1:]...)...)
+	}
+	return nil
+}
+
+func newFX(action map[string]any) map[string]any {
+	name, _ := action["fxname"].(string)
+	return map[string]any{"name": name, "enabled": true}
+}
+
+// findFX returns the position in t's chain of the FX an action targets, by its 1-based position
+// (fx) or its name (fxname, ignoring case). Returns -1 and no error when the FX isn't found and
+// state didn't list t's FX.
+func (s *simulation) findFX(t *track, action map[string]any) (int, error) {
+	var missing string
+	if position, ok := integer(action, "fx"); ok {
+		if position >= 1 && position <= len(t.fx) {
+			return position - 1, nil
+		}
+		missing = fmt.Sprintf("FX %d", position)
+	} else if name, _ := action["fxname"].(string); name != "" {
+		for i, fx := range t.fx {
+			if fxName, _ := fx["name"].(string); strings.EqualFold(fxName, name) {
+				return i, nil
+			}
+		}
+		missing = fmt.Sprintf("FX %q", name)
+	} else {
+		return -1, fmt.Errorf("no FX identified (fx or fxname)")
+	}
+
+	if !t.fxKnown {
+		return -1, nil
+	}
+	return -1, fmt.Errorf("%s has no %s", s.describe(t), missing)
+}
+
+func (s *simulation) applyAutomationAction(actionType string, action map[string]any) error {
+	t, err := s.target(action)
+	if err != nil {
+		return err
+	}
+	param, _ := action["param"].(string)
+	if param == "" {
+		return fmt.Errorf("missing param")
+	}
+
+	at := -1
+	for i, envelope := range t.envelopes {
+		if envelopeParam, _ := envelope["param"].(string); strings.EqualFold(envelopeParam, param) {
+			at = i
+			break
+		}
+	}
+
+	if actionType == "add_automation" {
+		if at < 0 {
+			t.envelopes = append(t.envelopes, map[string]any{"param": param, "points": []any{}})
+			t.envelopesKnown = true
+			at = len(t.envelopes) - 1
+		}
+		s.writeAutomation(t.envelopes[at], action)
+		return nil
+	}
+
+	if at < 0 {
+		if !t.envelopesKnown {
+			return nil
+		}
+		return fmt.Errorf("%s has no %s automation", s.describe(t), param)
+	}
+	envelope := t.envelopes[at]
+
+	switch actionType {
+	case "clear_automation":
+		start, end := s.timeRange(action)
+		envelope["points"] = keepPoints(envelope, func(time float64) bool { return time < start || time > end })
+	case "delete_envelope":
+		t.envelopes = append(t.envelopes[:at], t.envelopes[at+1:]...)
+	case "scale_automation":
+		factor, _ := number(action["factor"])
+		start, end := s.timeRange(action)
+		for _, point := range points(envelope) {
+			time, _ := number(point["time"])
+			if value, ok := number(point["value"]); ok && time >= start && time <= end {
+				point["value"] = value * factor
+			}
+		}
+	}
+	return nil
+}
+
+// writeAutomation writes an add_automation's points over the range they cover, replacing the
+// points there. A curve is reduced to its end points (from at the start, to at the end): the
+// shape between them is the extension's to draw.
+func (s *simulation) writeAutomation(envelope map[string]any, action map[string]any) {
+	var written []map[string]any
+	if raw, ok := action["points"].([]any); ok {
+		for _, item := range raw {
+			if point, ok := item.(map[string]any); ok {
+				written = append(written, map[string]any{"time": point["time"], "value": point["value"]})
+			}
+		}
+	} else {
+		start, end := s.timeRange(action)
+		if from, ok := number(action["from"]); ok {
+			written = append(written, map[string]any{"time": start, "value": from})
+		}
+		if to, ok := number(action["to"]); ok && !math.IsInf(end, 1) {
+			written = append(written, map[string]any{"time": end, "value": to})
+		}
+	}
+	if len(written) == 0 {
+		return
+	}
+
+	first, last := math.Inf(1), math.Inf(-1)
+	for _, point := range written {
+		time, _ := number(point["time"])
+		first, last = min(first, time), max(last, time)
+	}
+	kept := keepPoints(envelope, func(time float64) bool { return time < first || time > last })
+	for _, point := range written {
+		kept = append(kept, point)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		a, _ := number(kept[i].(map[string]any)["time"])
+		b, _ := number(kept[j].(map[string]any)["time"])
+		return a < b
+	})
+	envelope["points"] = kept
+}
+
+// points returns an envelope's points
+func points(envelope map[string]any) []map[string]any {
+	raw, _ := envelope["points"].([]any)
+	result := make([]map[string]any, 0, len(raw))
+	for _, item := range raw {
+		if point, ok := item.(map[string]any); ok {
+			result = append(result, point)
+		}
+	}
+	return result
+}
+
+// keepPoints returns the envelope's points whose time keep accepts
+func keepPoints(envelope map[string]any, keep func(time float64) bool) []any {
+	kept := []any{}
+	for _, point := range points(envelope) {
+		if time, _ := number(point["time"]); keep(time) {
+			kept = append(kept, point)
+		}
+	}
+	return kept
+}
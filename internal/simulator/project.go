@@ -0,0 +1,169 @@
+package simulator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+)
+
+func (s *simulation) applyProjectAction(actionType string, action map[string]any) error {
+	switch actionType {
+	case "add_marker":
+		var position float64
+		if v, ok := number(action["position"]); ok {
+			position = v
+		} else if v, ok := number(action["bar"]); ok {
+			position = s.barStart(v)
+		} else {
+			return fmt.Errorf("missing bar or position")
+		}
+		marker := map[string]any{"id": nextID(s.markers), "position": position}
+		copyFields(marker, action, "name", "color")
+		s.markers = append(s.markers, marker)
+	case "add_region":
+		start, end := s.timeRange(action)
+		if _, ok := action["start"]; !ok {
+			if _, ok := action["start_bar"]; !ok {
+				return fmt.Errorf("missing start or start_bar")
+			}
+		}
+		if end <= start+tolerance {
+			return fmt.Errorf("region ends before it starts")
+		}
+		region := map[string]any{"id": nextID(s.regions), "start": start, "end": end}
+		copyFields(region, action, "name", "color")
+		s.regions = append(s.regions, region)
+	case "delete_marker":
+		return s.deleteMarkers(action)
+	case "rename_region":
+		region := s.findRegion(action)
+		if region == nil {
+			if !s.markersKnown {
+				return nil
+			}
+			return fmt.Errorf("no matching region")
+		}
+		region["name"] = action["new_name"]
+	case "play", "stop", "record":
+		states := map[string]string{"play": "playing", "stop": "stopped", "record": "recording"}
+		s.transport()["state"] = states[actionType]
+	case "set_play_position":
+		if v, ok := number(action["time"]); ok {
+			s.transport()["position"] = v
+		} else if v, ok := number(action["bar"]); ok {
+			s.transport()["position"] = s.barStart(v)
+		} else {
+			return fmt.Errorf("missing bar or time")
+		}
+	case "set_tempo":
+		bpm, ok := number(action["bpm"])
+		if !ok || bpm <= 0 {
+			return fmt.Errorf("invalid bpm")
+		}
+		project, _ := s.root["project"].(map[string]any)
+		if project == nil {
+			project = map[string]any{}
+			s.root["project"] = project
+		}
+		project["bpm"] = bpm
+		delete(project, "tempo")
+		s.tempo = timeutil.FromState(s.root)
+	case "render_project", "drum_pattern":
+		// Rendering writes files and a drum pattern is a grid for the drummer agent: neither
+		// changes the project
+	}
+	return nil
+}
+
+// deleteMarkers deletes the markers and regions matching the action's id, name, bar (start),
+// position (start) or color. Names and colors ignore case.
+func (s *simulation) deleteMarkers(action map[string]any) error {
+	var match func(item map[string]any, startKey string) bool
+	if id, ok := integer(action, "id"); ok {
+		match = func(item map[string]any, _ string) bool {
+			itemID, ok := integer(item, "id")
+			return ok && itemID == id
+		}
+	} else if name, ok := action["name"].(string); ok {
+		match = func(item map[string]any, _ string) bool {
+			itemName, _ := item["name"].(string)
+			return strings.EqualFold(itemName, name)
+		}
+	} else if color, ok := action["color"].(string); ok {
+		match = func(item map[string]any, _ string) bool {
+			itemColor, _ := item["color"].(string)
+			return strings.EqualFold(itemColor, color)
+		}
+	} else {
+		position, ok := number(action["position"])
+		if !ok {
+			bar, hasBar := number(action["bar"])
+			if !hasBar {
+				return fmt.Errorf("no marker identified (id, name, bar, position or color)")
+			}
+			position = s.barStart(bar)
+		}
+		match = func(item map[string]any, startKey string) bool {
+			start, ok := number(item[startKey])
+			return ok && samePosition(start, position)
+		}
+	}
+
+	deleted := 0
+	s.markers, deleted = removeMatching(s.markers, func(m map[string]any) bool { return match(m, "position") }, deleted)
+	s.regions, deleted = removeMatching(s.regions, func(r map[string]any) bool { return match(r, "start") }, deleted)
+	if deleted == 0 && s.markersKnown {
+		return fmt.Errorf("no matching marker or region")
+	}
+	return nil
+}
+
+// findRegion returns the region rename_region names by id or current name
+func (s *simulation) findRegion(action map[string]any) map[string]any {
+	id, hasID := integer(action, "id")
+	name, _ := action["name"].(string)
+	for _, region := range s.regions {
+		if regionID, ok := integer(region, "id"); hasID && ok && regionID == id {
+			return region
+		}
+		if regionName, _ := region["name"].(string); !hasID && name != "" && strings.EqualFold(regionName, name) {
+			return region
+		}
+	}
+	return nil
+}
+
+// transport returns the project's transport state, creating it
+func (s *simulation) transport() map[string]any {
+	transport, _ := s.root["transport"].(map[string]any)
+	if transport == nil {
+		transport = map[string]any{}
+		s.root["transport"] = transport
+	}
+	return transport
+}
+
+// nextID is the number REAPER gives the next marker or region: one past the highest
+func nextID(items []map[string]any) int {
+	next := 1
+	for _, item := range items {
+		if id, ok := integer(item, "id"); ok && id >= next {
+			next = id + 1
+		}
+	}
+	return next
+}
+
+// removeMatching removes the items match accepts, adding how many to deleted
+func removeMatching(items []map[string]any, match func(map[string]any) bool, deleted int) ([]map[string]any, int) {
+	kept := items[:0]
+	for _, item := range items {
+		if match(item) {
+			deleted++
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	return kept, deleted
+}
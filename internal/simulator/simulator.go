@@ -0,0 +1,465 @@
+// Package simulator predicts the project state a list of actions leaves, by applying them to a
+// state snapshot the way the REAPER extension does. The orchestrator uses it to check that the
+// actions it returns are consistent, each finding the track, clip, FX, send, envelope or marker
+// it targets, and previews and /api/v1/state/diff use the predicted state to list the changes.
+//
+// What actions change beyond the state format is kept under the keys the extension would report
+// it with: "markers", "regions" and "transport" on the project, and "sends" and "frozen" on
+// tracks.
+package simulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/Conceptual-Machines/magda-api/internal/models"
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+	"github.com/Conceptual-Machines/magda-api/internal/timeutil"
+)
+
+// WarningInconsistentAction is the warning code for an action that can't be applied to the
+// project as the actions before it leave it, e.g. an edit of a track deleted earlier or an FX
+// removed twice
+const WarningInconsistentAction = "inconsistent_action"
+
+// tolerance is how far apart two positions in seconds may be and still be the same
+const tolerance = 1e-6
+
+// Result is the predicted project after a list of actions
+type Result struct {
+	State    map[string]any         // The bare project state (not under a "state" key), indices renumbered
+	Warnings []models.ActionWarning // Actions that couldn't be applied, by their index in the list; never nil
+}
+
+// Apply applies actions in order to a copy of state, which is left unchanged, and returns the
+// predicted state. Actions that can't be applied are skipped with a warning. What state leaves
+// out isn't held against the actions: without a track list every track index exists, and an
+// action on a clip, FX, envelope or send that a track doesn't list is skipped silently.
+// Returns an error only for an invalid state or actions that aren't JSON.
+func Apply(state map[string]any, actions []map[string]any) (*Result, error) {
+	sim, err := newSimulation(state)
+	if err != nil {
+		return nil, err
+	}
+	// Actions are read as JSON, whatever Go types their values have
+	data, err := json.Marshal(actions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid actions: %w", err)
+	}
+	actions = nil
+	if err := json.Unmarshal(data, &actions); err != nil {
+		return nil, fmt.Errorf("invalid actions: %w", err)
+	}
+
+	warnings := []models.ActionWarning{}
+	for i, action := range actions {
+		if err := sim.apply(action); err != nil {
+			actionType, _ := action["action"].(string)
+			warnings = append(warnings, models.ActionWarning{
+				Code:        WarningInconsistentAction,
+				Message:     fmt.Sprintf("action %d (%s): %v", i, actionType, err),
+				ActionIndex: i,
+			})
+		}
+	}
+	return &Result{State: sim.state(), Warnings: warnings}, nil
+}
+
+// track is a track being simulated. Sends point at their destination track, so they follow it
+// when tracks are inserted, moved or deleted.
+type track struct {
+	fields    map[string]any // Every other property, as in state
+	clips     []map[string]any
+	fx        []map[string]any
+	envelopes []map[string]any
+	sends     []*send
+
+	// Whether state listed the track's clips, FX, envelopes and sends
+	clipsKnown, fxKnown, envelopesKnown, sendsKnown bool
+}
+
+// send is a send from one track to another
+type send struct {
+	dest   *track         // nil when state names a destination it doesn't have
+	fields map[string]any // level_db, pre_fader, mute, and dest when dest is nil
+}
+
+// simulation is the project as the actions applied so far leave it
+type simulation struct {
+	root        map[string]any // Project-level state other than tracks and master
+	tracks      []*track       // In project order: a track's index is its position
+	master      *track
+	tracksKnown bool
+
+	markers, regions []map[string]any
+	markersKnown     bool
+
+	tempo *timeutil.TempoMap
+
+	// lastClip is the clip each track's most recent create_clip made, which add_midi fills
+	lastClip map[*track]map[string]any
+}
+
+// newSimulation copies and normalizes state
+func newSimulation(state map[string]any) (*simulation, error) {
+	root := map[string]any{}
+	if state != nil {
+		data, err := json.Marshal(projectstate.Unwrap(state))
+		if err != nil {
+			return nil, fmt.Errorf("invalid state: %w", err)
+		}
+		if err := json.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("invalid state: %w", err)
+		}
+		if _, err := projectstate.Normalize(root); err != nil {
+			return nil, err
+		}
+	}
+
+	s := &simulation{root: root, lastClip: make(map[*track]map[string]any)}
+	s.tempo = timeutil.FromState(root)
+
+	rawTracks, known := root["tracks"].([]any)
+	s.tracksKnown = known
+	delete(root, "tracks")
+	byIndex := make(map[int]*track, len(rawTracks))
+	indices := make([]int, 0, len(rawTracks))
+	for _, item := range rawTracks {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		index, _ := integer(fields, "index")
+		byIndex[index] = loadTrack(fields)
+		indices = append(indices, index)
+	}
+	sort.Ints(indices)
+	for _, index := range indices {
+		s.tracks = append(s.tracks, byIndex[index])
+	}
+	if master, ok := root["master"].(map[string]any); ok {
+		s.master = loadTrack(master)
+		delete(root, "master")
+	}
+
+	// Send destinations are resolved once every track is loaded
+	byGUID := make(map[string]*track)
+	for _, t := range s.tracks {
+		if guid, _ := t.fields["guid"].(string); guid != "" {
+			byGUID[guid] = t
+		}
+	}
+	for _, t := range append(append([]*track(nil), s.tracks...), s.master) {
+		if t == nil {
+			continue
+		}
+		for _, sd := range t.sends {
+			if guid, _ := sd.fields["dest_guid"].(string); byGUID[guid] != nil {
+				sd.dest = byGUID[guid]
+			} else if dest, ok := integer(sd.fields, "dest"); ok {
+				sd.dest = byIndex[dest]
+			}
+			if sd.dest != nil {
+				delete(sd.fields, "dest")
+				delete(sd.fields, "dest_guid")
+			}
+		}
+	}
+
+	markers, markersKnown := takeItems(root, "markers")
+	regions, regionsKnown := takeItems(root, "regions")
+	s.markers, s.regions, s.markersKnown = markers, regions, markersKnown || regionsKnown
+	return s, nil
+}
+
+// loadTrack splits a state track into its properties and its collections
+func loadTrack(fields map[string]any) *track {
+	t := &track{fields: fields}
+	t.clips, t.clipsKnown = takeItems(fields, "clips")
+	t.fx, t.fxKnown = takeItems(fields, "fx")
+	t.envelopes, t.envelopesKnown = takeItems(fields, "envelopes")
+	var sends []map[string]any
+	sends, t.sendsKnown = takeItems(fields, "sends")
+	for _, fields := range sends {
+		t.sends = append(t.sends, &send{fields: fields})
+	}
+	return t
+}
+
+// newTrack is a track created by an action: empty, so its collections are known
+func newTrack() *track {
+	return &track{fields: map[string]any{}, clipsKnown: true, fxKnown: true, envelopesKnown: true, sendsKnown: true}
+}
+
+// takeItems removes m[key] and returns its objects, and whether m had the key
+func takeItems(m map[string]any, key string) ([]map[string]any, bool) {
+	raw, ok := m[key].([]any)
+	delete(m, key)
+	items := make([]map[string]any, 0, len(raw))
+	for _, item := range raw {
+		if itemMap, isMap := item.(map[string]any); isMap {
+			items = append(items, itemMap)
+		}
+	}
+	return items, ok
+}
+
+// state renders the simulated project in the state format. Tracks, clips and FX are numbered
+// by position; clips are ordered by position on their track.
+func (s *simulation) state() map[string]any {
+	root := s.root
+	indexOf := make(map[*track]int, len(s.tracks))
+	for i, t := range s.tracks {
+		indexOf[t] = i
+	}
+
+	if s.tracksKnown || len(s.tracks) > 0 {
+		tracks := make([]any, len(s.tracks))
+		for i, t := range s.tracks {
+			tracks[i] = t.state(i, indexOf)
+		}
+		root["tracks"] = tracks
+	}
+	if s.master != nil {
+		root["master"] = s.master.state(-1, indexOf)
+	}
+	if s.markersKnown || len(s.markers) > 0 || len(s.regions) > 0 {
+		root["markers"] = items(s.markers)
+		root["regions"] = items(s.regions)
+	}
+	return root
+}
+
+// state renders the track at index (-1 for the master track)
+func (t *track) state(index int, indexOf map[*track]int) map[string]any {
+	m := t.fields
+	if index >= 0 {
+		m["index"] = index
+	}
+
+	sort.SliceStable(t.clips, func(i, j int) bool {
+		a, _ := number(t.clips[i]["position"])
+		b, _ := number(t.clips[j]["position"])
+		return a < b
+	})
+	for i, clip := range t.clips {
+		clip["index"] = i
+		if index >= 0 {
+			clip["track"] = index
+		}
+	}
+	for i, fx := range t.fx {
+		fx["index"] = i
+	}
+	for i, envelope := range t.envelopes {
+		envelope["index"] = i
+	}
+
+	if t.clipsKnown || len(t.clips) > 0 {
+		m["clips"] = items(t.clips)
+	}
+	if t.fxKnown || len(t.fx) > 0 {
+		m["fx"] = items(t.fx)
+	}
+	if t.envelopesKnown || len(t.envelopes) > 0 {
+		m["envelopes"] = items(t.envelopes)
+	}
+	if t.sendsKnown || len(t.sends) > 0 {
+		sends := make([]any, 0, len(t.sends))
+		for _, sd := range t.sends {
+			fields := sd.fields
+			if sd.dest != nil {
+				fields["dest"] = indexOf[sd.dest]
+			}
+			sends = append(sends, fields)
+		}
+		m["sends"] = sends
+	}
+	return m
+}
+
+func items(maps []map[string]any) []any {
+	result := make([]any, len(maps))
+	for i, m := range maps {
+		result[i] = m
+	}
+	return result
+}
+
+// apply applies one action. It returns why the action doesn't fit the project, or nil; an
+// action that returns an error has changed nothing, unless the error says what was done instead.
+func (s *simulation) apply(action map[string]any) error {
+	actionType, _ := action["action"].(string)
+	switch actionType {
+	case "create_track", "delete_track", "duplicate_track", "move_track", "set_track",
+		"freeze_track", "unfreeze_track", "bounce_in_place":
+		return s.applyTrackAction(actionType, action)
+	case "add_send", "set_send", "remove_send":
+		return s.applySendAction(actionType, action)
+	case "create_clip", "create_clip_at_bar", "set_clip", "set_clip_position", "delete_clip",
+		"duplicate_clip", "split_clip", "trim_clip", "add_midi", "set_clip_notes":
+		return s.applyClipAction(actionType, action)
+	case "add_track_fx", "add_instrument", "set_fx_param", "bypass_fx", "enable_fx", "remove_fx", "move_fx":
+		return s.applyFXAction(actionType, action)
+	case "add_automation", "clear_automation", "delete_envelope", "scale_automation":
+		return s.applyAutomationAction(actionType, action)
+	case "add_marker", "add_region", "delete_marker", "rename_region", "play", "stop", "record",
+		"set_play_position", "set_tempo", "render_project", "drum_pattern":
+		return s.applyProjectAction(actionType, action)
+	case "":
+		return fmt.Errorf("action has no type")
+	default:
+		return fmt.Errorf("unknown action type")
+	}
+}
+
+// target returns the track an action's "track" names: the master track, the track with its
+// track_guid, or the track at its index. Without a track list in state, tracks up to the index
+// are added as needed.
+func (s *simulation) target(action map[string]any) (*track, error) {
+	if name, ok := action["track"].(string); ok {
+		if name != "master" {
+			return nil, fmt.Errorf("invalid track %q", name)
+		}
+		if s.master == nil {
+			s.master = &track{fields: map[string]any{}}
+		}
+		return s.master, nil
+	}
+	return s.trackRef(action, "track", "track_guid")
+}
+
+// trackRef resolves a track reference by GUID, then by index
+func (s *simulation) trackRef(action map[string]any, indexKey, guidKey string) (*track, error) {
+	if guid, _ := action[guidKey].(string); guid != "" {
+		for _, t := range s.tracks {
+			if t.fields["guid"] == guid {
+				return t, nil
+			}
+		}
+	}
+	index, ok := integer(action, indexKey)
+	if !ok {
+		return nil, fmt.Errorf("missing %s", indexKey)
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("%s index %d is negative", indexKey, index)
+	}
+	if index >= len(s.tracks) {
+		if s.tracksKnown {
+			return nil, fmt.Errorf("%s index %d doesn't exist (the project has %d tracks)", indexKey, index, len(s.tracks))
+		}
+		s.grow(index + 1)
+	}
+	return s.tracks[index], nil
+}
+
+// grow adds tracks state doesn't describe until there are n
+func (s *simulation) grow(n int) {
+	for len(s.tracks) < n {
+		s.tracks = append(s.tracks, &track{fields: map[string]any{}})
+	}
+}
+
+// indexOf returns the index of t, or -1 for the master track
+func (s *simulation) indexOf(t *track) int {
+	for i, candidate := range s.tracks {
+		if candidate == t {
+			return i
+		}
+	}
+	return -1
+}
+
+// describe names a track in error messages
+func (s *simulation) describe(t *track) string {
+	if t == s.master {
+		return "the master track"
+	}
+	return "track index " + strconv.Itoa(s.indexOf(t))
+}
+
+// barStart is the position in seconds of a 1-based bar
+func (s *simulation) barStart(bar float64) float64 {
+	return s.tempo.BarToSeconds(bar)
+}
+
+// timeRange reads an optional range from start/end (seconds) or start_bar/end_bar, open ended
+// where a bound is missing
+func (s *simulation) timeRange(action map[string]any) (float64, float64) {
+	start, end := 0.0, math.Inf(1)
+	if v, ok := number(action["start"]); ok {
+		start = v
+	} else if v, ok := number(action["start_bar"]); ok {
+		start = s.barStart(v)
+	}
+	if v, ok := number(action["end"]); ok {
+		end = v
+	} else if v, ok := number(action["end_bar"]); ok {
+		end = s.barStart(v)
+	}
+	return start, end
+}
+
+// copyFields sets m's keys from action
+func copyFields(m, action map[string]any, keys ...string) {
+	for _, key := range keys {
+		if v, ok := action[key]; ok {
+			m[key] = v
+		}
+	}
+}
+
+// deepCopy copies nested maps and slices, so a duplicate doesn't share them with its original
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+func copyMap(m map[string]any) map[string]any {
+	copied, _ := deepCopy(m).(map[string]any)
+	return copied
+}
+
+// number reads a JSON number (float64) or a Go int
+func number(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// integer reads a whole number
+func integer(m map[string]any, key string) (int, bool) {
+	v, ok := number(m[key])
+	if !ok || v != math.Trunc(v) {
+		return 0, false
+	}
+	return int(v), true
+}
+
+func samePosition(a, b float64) bool {
+	return math.Abs(a-b) < tolerance
+}
@@ -0,0 +1,774 @@
+package simulator
+
+import (
+	"encoding/json"
+	"testing"
+
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testState is a two-track project at 120 BPM in 4/4 (a bar is 2 seconds)
+func testState() map[string]any {
+	return map[string]any{
+		"project": map[string]any{"bpm": 120, "time_signature": "4/4"},
+		"tracks": []any{
+			map[string]any{
+				"index": 0, "guid": "t-drums", "name": "Drums",
+				"clips": []any{
+					map[string]any{"index": 0, "guid": "c-verse", "name": "Verse", "position": 0, "length": 8},
+					map[string]any{"index": 1, "name": "Chorus", "position": 8, "length": 8},
+				},
+				"fx": []any{
+					map[string]any{"index": 0, "name": "ReaEQ", "enabled": true},
+					map[string]any{"index": 1, "name": "ReaComp", "enabled": true},
+				},
+				"envelopes": []any{
+					map[string]any{"index": 0, "param": "volume", "points": []any{
+						map[string]any{"time": 0, "value": 0.5},
+						map[string]any{"time": 4, "value": 1},
+						map[string]any{"time": 8, "value": 0.5},
+					}},
+				},
+				"sends": []any{map[string]any{"dest": 1, "level_db": -6}},
+			},
+			map[string]any{"index": 1, "guid": "t-bass", "name": "Bass", "clips": []any{}, "fx": []any{}},
+		},
+		"markers": []any{map[string]any{"id": 1, "name": "Intro", "position": 0}},
+		"regions": []any{map[string]any{"id": 1, "name": "Verse", "start": 0, "end": 16}},
+	}
+}
+
+func apply(t *testing.T, state map[string]any, actions ...map[string]any) *Result {
+	t.Helper()
+	result, err := Apply(state, actions)
+	require.NoError(t, err)
+	return result
+}
+
+// project parses a predicted state
+func project(t *testing.T, result *Result) *projectstate.Project {
+	t.Helper()
+	p, err := projectstate.Parse(result.State)
+	require.NoError(t, err)
+	return p
+}
+
+func trackNames(p *projectstate.Project) []string {
+	names := make([]string, len(p.Tracks))
+	for i, track := range p.Tracks {
+		names[i] = track.Name
+	}
+	return names
+}
+
+// clipSpans lists a track's clips as [position, length]
+func clipSpans(track projectstate.Track) [][2]float64 {
+	spans := make([][2]float64, len(track.Clips))
+	for i, clip := range track.Clips {
+		spans[i] = [2]float64{clip.Position, clip.Length}
+	}
+	return spans
+}
+
+func fxNames(track projectstate.Track) []string {
+	names := make([]string, len(track.FX))
+	for i, fx := range track.FX {
+		names[i] = fx.Name
+	}
+	return names
+}
+
+// trackMap returns a predicted track as a map, for the keys the typed state doesn't have
+func trackMap(result *Result, index int) map[string]any {
+	return result.State["tracks"].([]any)[index].(map[string]any)
+}
+
+func TestApply_LeavesInputUnchanged(t *testing.T) {
+	state := testState()
+	before, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	result := apply(t, state,
+		map[string]any{"action": "delete_track", "track": 0},
+		map[string]any{"action": "set_track", "track": 0, "name": "Low End"},
+	)
+	assert.Equal(t, []string{"Low End"}, trackNames(project(t, result)))
+
+	after, err := json.Marshal(state)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(before), string(after))
+}
+
+func TestApply_TrackActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  []map[string]any
+		want     []string
+		warnings int
+	}{
+		{
+			name:    "create at the top",
+			actions: []map[string]any{{"action": "create_track", "index": 0, "name": "Pad", "instrument": "Serum"}},
+			want:    []string{"Pad", "Drums", "Bass"},
+		},
+		{
+			name:    "create at the end",
+			actions: []map[string]any{{"action": "create_track", "index": 2, "name": "Pad"}},
+			want:    []string{"Drums", "Bass", "Pad"},
+		},
+		{
+			name:     "create past the end lands at the end with a warning",
+			actions:  []map[string]any{{"action": "create_track", "index": 5, "name": "Pad"}},
+			want:     []string{"Drums", "Bass", "Pad"},
+			warnings: 1,
+		},
+		{
+			name:    "delete",
+			actions: []map[string]any{{"action": "delete_track", "track": 0}},
+			want:    []string{"Bass"},
+		},
+		{
+			name: "delete twice by index",
+			actions: []map[string]any{
+				{"action": "delete_track", "track": 1},
+				{"action": "delete_track", "track": 1},
+			},
+			want:     []string{"Drums"},
+			warnings: 1,
+		},
+		{
+			name:    "GUID wins over a stale index",
+			actions: []map[string]any{{"action": "set_track", "track": 0, "track_guid": "t-bass", "name": "Sub"}},
+			want:    []string{"Drums", "Sub"},
+		},
+		{
+			name:    "duplicate",
+			actions: []map[string]any{{"action": "duplicate_track", "track": 0, "count": 2}},
+			want:    []string{"Drums", "Drums", "Drums", "Bass"},
+		},
+		{
+			name:    "move down",
+			actions: []map[string]any{{"action": "move_track", "track": 0, "to": 1}},
+			want:    []string{"Bass", "Drums"},
+		},
+		{
+			name:     "move out of range",
+			actions:  []map[string]any{{"action": "move_track", "track": 0, "to": 2}},
+			want:     []string{"Drums", "Bass"},
+			warnings: 1,
+		},
+		{
+			name: "created track is addressed by its new index",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 2, "name": "Pad"},
+				{"action": "set_track", "track": 2, "name": "Strings"},
+			},
+			want: []string{"Drums", "Bass", "Strings"},
+		},
+		{
+			name:     "edit of a track that doesn't exist",
+			actions:  []map[string]any{{"action": "set_track", "track": 7, "mute": true}},
+			want:     []string{"Drums", "Bass"},
+			warnings: 1,
+		},
+		{
+			name:     "unknown action",
+			actions:  []map[string]any{{"action": "explode_track", "track": 0}},
+			want:     []string{"Drums", "Bass"},
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply(t, testState(), tt.actions...)
+			assert.Equal(t, tt.want, trackNames(project(t, result)))
+			assert.Len(t, result.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestApply_TrackDetails(t *testing.T) {
+	result := apply(t, testState(),
+		map[string]any{"action": "create_track", "index": 0, "name": "Pad", "instrument": "Serum"},
+		map[string]any{"action": "set_track", "track": 1, "volume_db": -3.5, "mute": true, "color": "#ff0000"},
+		map[string]any{"action": "set_track", "track": "master", "volume_db": -1},
+		map[string]any{"action": "freeze_track", "track": 2},
+	)
+	require.Empty(t, result.Warnings)
+	p := project(t, result)
+
+	assert.Equal(t, []string{"Serum"}, fxNames(p.Tracks[0]))
+	assert.Equal(t, 1, p.Tracks[1].Index)
+	assert.Equal(t, -3.5, p.Tracks[1].VolumeDB)
+	assert.True(t, p.Tracks[1].Mute)
+	assert.Equal(t, "#ff0000", p.Tracks[1].Color)
+	for _, clip := range p.Tracks[1].Clips {
+		assert.Equal(t, 1, clip.Track)
+	}
+	require.NotNil(t, p.Master)
+	assert.Equal(t, -1.0, p.Master.VolumeDB)
+	assert.Equal(t, true, trackMap(result, 2)["frozen"])
+
+	// The send from Drums followed Bass from index 1 to 2
+	sends := trackMap(result, 1)["sends"].([]any)
+	require.Len(t, sends, 1)
+	assert.Equal(t, 2, sends[0].(map[string]any)["dest"])
+}
+
+func TestApply_MuteAndSoloUseStateKeys(t *testing.T) {
+	state := testState()
+	state["tracks"].([]any)[0].(map[string]any)["muted"] = true
+	result := apply(t, state,
+		map[string]any{"action": "set_track", "track": 0, "mute": false},
+		map[string]any{"action": "create_track", "index": 2, "name": "Pad", "solo": true},
+	)
+
+	drums := trackMap(result, 0)
+	assert.Equal(t, false, drums["muted"])
+	assert.NotContains(t, drums, "mute")
+	assert.Equal(t, true, trackMap(result, 2)["soloed"])
+	assert.NotContains(t, trackMap(result, 2), "solo")
+}
+
+func TestApply_DuplicateTrackCopiesWithoutGUIDs(t *testing.T) {
+	result := apply(t, testState(),
+		map[string]any{"action": "duplicate_track", "track": 0},
+		map[string]any{"action": "set_track", "track": 1, "name": "Drums 2"},
+		map[string]any{"action": "remove_fx", "track": 1, "fxname": "ReaComp"},
+	)
+	require.Empty(t, result.Warnings)
+	p := project(t, result)
+
+	assert.Equal(t, []string{"Drums", "Drums 2", "Bass"}, trackNames(p))
+	assert.Equal(t, "t-drums", p.Tracks[0].GUID)
+	assert.Empty(t, p.Tracks[1].GUID)
+	assert.Equal(t, "c-verse", p.Tracks[0].Clips[0].GUID)
+	assert.Empty(t, p.Tracks[1].Clips[0].GUID)
+	// The copy's FX chain is its own
+	assert.Equal(t, []string{"ReaEQ", "ReaComp"}, fxNames(p.Tracks[0]))
+	assert.Equal(t, []string{"ReaEQ"}, fxNames(p.Tracks[1]))
+}
+
+func TestApply_DeleteTrackDropsSendsToIt(t *testing.T) {
+	result := apply(t, testState(), map[string]any{"action": "delete_track", "track": 1})
+	require.Empty(t, result.Warnings)
+	assert.Empty(t, trackMap(result, 0)["sends"])
+}
+
+func TestApply_WithoutTrackList(t *testing.T) {
+	// Without a track list any index exists; without clip or FX lists any clip or FX does
+	state := map[string]any{"project": map[string]any{"bpm": 90}}
+	result := apply(t, state,
+		map[string]any{"action": "set_track", "track": 2, "name": "Keys"},
+		map[string]any{"action": "remove_fx", "track": 2, "fxname": "ReaVerb"},
+		map[string]any{"action": "delete_clip", "track": 2, "clip": 0},
+		map[string]any{"action": "clear_automation", "track": 2, "param": "pan"},
+	)
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, []string{"", "", "Keys"}, trackNames(project(t, result)))
+
+	result = apply(t, nil, map[string]any{"action": "create_track", "index": 0, "name": "Bass"})
+	assert.Empty(t, result.Warnings)
+	assert.Equal(t, []string{"Bass"}, trackNames(project(t, result)))
+}
+
+func TestApply_WrappedState(t *testing.T) {
+	result := apply(t, map[string]any{"state": testState()}, map[string]any{"action": "delete_track", "track": 0})
+	assert.Equal(t, []string{"Bass"}, trackNames(project(t, result)))
+}
+
+func TestApply_InvalidState(t *testing.T) {
+	_, err := Apply(map[string]any{"tracks": "none"}, nil)
+	assert.Error(t, err)
+}
+
+func TestApply_ClipActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  []map[string]any
+		want     [][2]float64 // Drums clips as [position, length]
+		warnings int
+	}{
+		{
+			name:    "create in seconds",
+			actions: []map[string]any{{"action": "create_clip", "track": 0, "position": 16, "length": 4}},
+			want:    [][2]float64{{0, 8}, {8, 8}, {16, 4}},
+		},
+		{
+			name:    "create at a bar",
+			actions: []map[string]any{{"action": "create_clip_at_bar", "track": 0, "bar": 9, "length_bars": 2}},
+			want:    [][2]float64{{0, 8}, {8, 8}, {16, 4}},
+		},
+		{
+			name:    "delete by index",
+			actions: []map[string]any{{"action": "delete_clip", "track": 0, "clip": 0}},
+			want:    [][2]float64{{8, 8}},
+		},
+		{
+			name:    "delete by position",
+			actions: []map[string]any{{"action": "delete_clip", "track": 0, "position": 8}},
+			want:    [][2]float64{{0, 8}},
+		},
+		{
+			name:    "delete by a bar inside the clip",
+			actions: []map[string]any{{"action": "delete_clip", "track": 0, "bar": 6}},
+			want:    [][2]float64{{0, 8}},
+		},
+		{
+			name:    "delete by GUID",
+			actions: []map[string]any{{"action": "delete_clip", "track": 0, "clip": 1, "clip_guid": "c-verse"}},
+			want:    [][2]float64{{8, 8}},
+		},
+		{
+			name: "clip indices keep naming the clips state listed",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 0, "clip": 0},
+				{"action": "set_clip", "track": 0, "clip": 1, "length": 4},
+			},
+			want: [][2]float64{{8, 4}},
+		},
+		{
+			name: "deleted twice",
+			actions: []map[string]any{
+				{"action": "delete_clip", "track": 0, "clip": 0},
+				{"action": "delete_clip", "track": 0, "clip": 0},
+			},
+			want:     [][2]float64{{8, 8}},
+			warnings: 1,
+		},
+		{
+			name:     "no clip at the position",
+			actions:  []map[string]any{{"action": "set_clip", "track": 0, "position": 3, "name": "Fill"}},
+			want:     [][2]float64{{0, 8}, {8, 8}},
+			warnings: 1,
+		},
+		{
+			name:    "move",
+			actions: []map[string]any{{"action": "set_clip_position", "track": 0, "old_position": 0, "position": 16}},
+			want:    [][2]float64{{8, 8}, {16, 8}},
+		},
+		{
+			name:    "duplicate with the default offset",
+			actions: []map[string]any{{"action": "duplicate_clip", "track": 0, "clip": 1, "count": 2}},
+			want:    [][2]float64{{0, 8}, {8, 8}, {16, 8}, {24, 8}},
+		},
+		{
+			name:    "duplicate with an offset",
+			actions: []map[string]any{{"action": "duplicate_clip", "track": 0, "position": 8, "offset": 16}},
+			want:    [][2]float64{{0, 8}, {8, 8}, {24, 8}},
+		},
+		{
+			name:    "split at a position",
+			actions: []map[string]any{{"action": "split_clip", "track": 0, "position": 2}},
+			want:    [][2]float64{{0, 2}, {2, 6}, {8, 8}},
+		},
+		{
+			name:    "split a clip at a bar",
+			actions: []map[string]any{{"action": "split_clip", "track": 0, "clip": 1, "bar": 7}},
+			want:    [][2]float64{{0, 8}, {8, 4}, {12, 4}},
+		},
+		{
+			name:     "split at the clip's edge",
+			actions:  []map[string]any{{"action": "split_clip", "track": 0, "clip": 1, "position": 8}},
+			want:     [][2]float64{{0, 8}, {8, 8}},
+			warnings: 1,
+		},
+		{
+			name:    "trim in seconds",
+			actions: []map[string]any{{"action": "trim_clip", "track": 0, "clip": 0, "start": 2, "end": 6}},
+			want:    [][2]float64{{2, 4}, {8, 8}},
+		},
+		{
+			name:    "trim the end in bars",
+			actions: []map[string]any{{"action": "trim_clip", "track": 0, "position": 8, "end_bar": 7}},
+			want:    [][2]float64{{0, 8}, {8, 4}},
+		},
+		{
+			name:     "trim to nothing",
+			actions:  []map[string]any{{"action": "trim_clip", "track": 0, "clip": 0, "start": 6, "end": 6}},
+			want:     [][2]float64{{0, 8}, {8, 8}},
+			warnings: 1,
+		},
+		{
+			name:     "on the master track",
+			actions:  []map[string]any{{"action": "create_clip", "track": "master", "position": 0, "length": 4}},
+			want:     [][2]float64{{0, 8}, {8, 8}},
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply(t, testState(), tt.actions...)
+			assert.Equal(t, tt.want, clipSpans(project(t, result).Tracks[0]))
+			assert.Len(t, result.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestApply_ClipDetails(t *testing.T) {
+	result := apply(t, testState(),
+		map[string]any{"action": "set_clip", "track": 0, "clip": 1, "name": "Hook", "color": "#00ff00"},
+		map[string]any{"action": "set_clip_notes", "track": 0, "clip": 0, "notes": []any{
+			map[string]any{"pitch": 36, "velocity": 100, "start": 0, "length": 1},
+			map[string]any{"pitch": 38, "velocity": 100, "start": 1, "length": 1},
+		}},
+		map[string]any{"action": "split_clip", "track": 0, "clip": 0, "position": 4},
+	)
+	require.Empty(t, result.Warnings)
+	clips := project(t, result).Tracks[0].Clips
+	require.Len(t, clips, 3)
+
+	// Clips are ordered by position and renumbered; the split's right half is a new item
+	assert.Equal(t, []int{0, 1, 2}, []int{clips[0].Index, clips[1].Index, clips[2].Index})
+	assert.Equal(t, "c-verse", clips[0].GUID)
+	assert.Equal(t, 2, clips[0].NoteCount)
+	assert.Equal(t, 38, clips[0].Notes[1].Pitch)
+	assert.Empty(t, clips[1].GUID)
+	assert.Equal(t, "Verse", clips[1].Name)
+	assert.Equal(t, "Hook", clips[2].Name)
+}
+
+func TestApply_AddMIDI(t *testing.T) {
+	notes := []map[string]any{
+		{"pitch": 60, "velocity": 100, "start": 0.0, "length": 1.0},
+		{"pitch": 64, "velocity": 100, "start": 4.0, "length": 2.0},
+	}
+
+	// Into the clip created before it on the track
+	result := apply(t, testState(),
+		map[string]any{"action": "create_clip_at_bar", "track": 1, "bar": 3, "length_bars": 4},
+		map[string]any{"action": "add_midi", "track": 1, "notes": notes, "name": "Bassline"},
+	)
+	require.Empty(t, result.Warnings)
+	bass := project(t, result).Tracks[1]
+	require.Len(t, bass.Clips, 1)
+	assert.Equal(t, [2]float64{4, 8}, [2]float64{bass.Clips[0].Position, bass.Clips[0].Length})
+	assert.Equal(t, 2, bass.Clips[0].NoteCount)
+	assert.Equal(t, "Bassline", bass.Clips[0].Name)
+
+	// Without one, into a new clip long enough for the notes (6 beats: 2 bars)
+	result = apply(t, testState(), map[string]any{"action": "add_midi", "track": 1, "notes": notes})
+	bass = project(t, result).Tracks[1]
+	require.Len(t, bass.Clips, 1)
+	assert.Equal(t, [2]float64{0, 4}, [2]float64{bass.Clips[0].Position, bass.Clips[0].Length})
+	assert.Equal(t, 2, bass.Clips[0].NoteCount)
+}
+
+func TestApply_BounceInPlace(t *testing.T) {
+	state := testState()
+	clips := state["tracks"].([]any)[0].(map[string]any)["clips"].([]any)
+	clips[0].(map[string]any)["note_count"] = 12
+
+	result := apply(t, state, map[string]any{"action": "bounce_in_place", "track": 0})
+	require.Empty(t, result.Warnings)
+	drums := project(t, result).Tracks[0]
+	assert.Equal(t, [][2]float64{{0, 8}, {8, 8}}, clipSpans(drums))
+	assert.Zero(t, drums.Clips[0].NoteCount)
+	assert.Empty(t, drums.Clips[0].GUID)
+}
+
+func TestApply_FXActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  []map[string]any
+		want     []string
+		warnings int
+	}{
+		{
+			name:    "add an effect at the end",
+			actions: []map[string]any{{"action": "add_track_fx", "track": 0, "fxname": "ReaVerb"}},
+			want:    []string{"ReaEQ", "ReaComp", "ReaVerb"},
+		},
+		{
+			name:    "add an instrument at the front",
+			actions: []map[string]any{{"action": "add_instrument", "track": 0, "fxname": "ReaSynth"}},
+			want:    []string{"ReaSynth", "ReaEQ", "ReaComp"},
+		},
+		{
+			name:    "remove by name, ignoring case",
+			actions: []map[string]any{{"action": "remove_fx", "track": 0, "fxname": "reacomp"}},
+			want:    []string{"ReaEQ"},
+		},
+		{
+			name:    "remove by position",
+			actions: []map[string]any{{"action": "remove_fx", "track": 0, "fx": 1}},
+			want:    []string{"ReaComp"},
+		},
+		{
+			name: "removed twice",
+			actions: []map[string]any{
+				{"action": "remove_fx", "track": 0, "fxname": "ReaComp"},
+				{"action": "remove_fx", "track": 0, "fxname": "ReaComp"},
+			},
+			want:     []string{"ReaEQ"},
+			warnings: 1,
+		},
+		{
+			name:    "move",
+			actions: []map[string]any{{"action": "move_fx", "track": 0, "fxname": "ReaComp", "to": 1}},
+			want:    []string{"ReaComp", "ReaEQ"},
+		},
+		{
+			name:     "move out of the chain",
+			actions:  []map[string]any{{"action": "move_fx", "track": 0, "fx": 1, "to": 3}},
+			want:     []string{"ReaEQ", "ReaComp"},
+			warnings: 1,
+		},
+		{
+			name:     "bypass a plugin the track doesn't have",
+			actions:  []map[string]any{{"action": "bypass_fx", "track": 0, "fxname": "Pro-Q 3"}},
+			want:     []string{"ReaEQ", "ReaComp"},
+			warnings: 1,
+		},
+		{
+			name: "added earlier in the list",
+			actions: []map[string]any{
+				{"action": "add_track_fx", "track": 1, "fxname": "ReaComp"},
+				{"action": "set_fx_param", "track": 1, "fxname": "ReaComp", "param": "Threshold", "value": 0.3},
+			},
+			want: []string{"ReaEQ", "ReaComp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply(t, testState(), tt.actions...)
+			assert.Equal(t, tt.want, fxNames(project(t, result).Tracks[0]))
+			assert.Len(t, result.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestApply_FXDetails(t *testing.T) {
+	result := apply(t, testState(),
+		map[string]any{"action": "bypass_fx", "track": 0, "fx": 2},
+		map[string]any{"action": "set_fx_param", "track": 0, "fxname": "ReaEQ", "param": "Gain", "value": 0.75},
+		map[string]any{"action": "add_track_fx", "track": "master", "fxname": "ReaLimit"},
+		map[string]any{"action": "bypass_fx", "track": 0, "fx": 1},
+		map[string]any{"action": "enable_fx", "track": 0, "fx": 1},
+	)
+	require.Empty(t, result.Warnings)
+	p := project(t, result)
+
+	drums := p.Tracks[0]
+	require.NotNil(t, drums.FX[0].Enabled)
+	assert.True(t, *drums.FX[0].Enabled)
+	require.NotNil(t, drums.FX[1].Enabled)
+	assert.False(t, *drums.FX[1].Enabled)
+	fx := trackMap(result, 0)["fx"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{"Gain": 0.75}, fx["params"])
+
+	require.NotNil(t, p.Master)
+	assert.Equal(t, []string{"ReaLimit"}, fxNames(*p.Master))
+}
+
+func TestApply_Sends(t *testing.T) {
+	tests := []struct {
+		name     string
+		actions  []map[string]any
+		want     []any // Drums' sends
+		warnings int
+	}{
+		{
+			name:    "change a send",
+			actions: []map[string]any{{"action": "set_send", "track": 0, "dest": 1, "level_db": -3.0, "mute": true}},
+			want:    []any{map[string]any{"dest": 1, "level_db": -3.0, "mute": true}},
+		},
+		{
+			name:    "remove a send",
+			actions: []map[string]any{{"action": "remove_send", "track": 0, "dest": 1}},
+			want:    []any{},
+		},
+		{
+			name: "add a send to a new track",
+			actions: []map[string]any{
+				{"action": "create_track", "index": 2, "name": "Reverb"},
+				{"action": "add_send", "track": 0, "dest": 2, "level_db": -12.0},
+			},
+			want: []any{
+				map[string]any{"dest": 1, "level_db": -6.0},
+				map[string]any{"dest": 2, "level_db": -12.0},
+			},
+		},
+		{
+			name:     "change a send that isn't there",
+			actions:  []map[string]any{{"action": "set_send", "track": 1, "dest": 0, "level_db": -3.0}},
+			want:     []any{map[string]any{"dest": 1, "level_db": -6.0}},
+			warnings: 0, // Bass's sends aren't listed
+		},
+		{
+			name: "remove a send twice",
+			actions: []map[string]any{
+				{"action": "remove_send", "track": 0, "dest": 1},
+				{"action": "remove_send", "track": 0, "dest": 1},
+			},
+			want:     []any{},
+			warnings: 1,
+		},
+		{
+			name:     "send to itself",
+			actions:  []map[string]any{{"action": "add_send", "track": 0, "dest": 0}},
+			want:     []any{map[string]any{"dest": 1, "level_db": -6.0}},
+			warnings: 1,
+		},
+		{
+			name:     "send to a track that doesn't exist",
+			actions:  []map[string]any{{"action": "add_send", "track": 0, "dest": 4}},
+			want:     []any{map[string]any{"dest": 1, "level_db": -6.0}},
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply(t, testState(), tt.actions...)
+			assert.Equal(t, tt.want, trackMap(result, 0)["sends"])
+			assert.Len(t, result.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestApply_Automation(t *testing.T) {
+	point := func(time, value float64) map[string]any { return map[string]any{"time": time, "value": value} }
+	tests := []struct {
+		name     string
+		actions  []map[string]any
+		want     map[string][]any // Drums' envelope points by param
+		warnings int
+	}{
+		{
+			name: "points replace the ones in their range",
+			actions: []map[string]any{{"action": "add_automation", "track": 0, "param": "volume", "points": []any{
+				point(2, 0.2), point(6, 0.8),
+			}}},
+			want: map[string][]any{"volume": {point(0, 0.5), point(2, 0.2), point(6, 0.8), point(8, 0.5)}},
+		},
+		{
+			name: "a curve records its end points",
+			actions: []map[string]any{
+				{"action": "add_automation", "track": 0, "param": "pan", "curve": "ramp", "start_bar": 1, "end_bar": 3, "from": -1, "to": 1},
+			},
+			want: map[string][]any{"volume": {point(0, 0.5), point(4, 1), point(8, 0.5)}, "pan": {point(0, -1), point(4, 1)}},
+		},
+		{
+			name:    "clear a range",
+			actions: []map[string]any{{"action": "clear_automation", "track": 0, "param": "volume", "start": 2, "end": 8}},
+			want:    map[string][]any{"volume": {point(0, 0.5)}},
+		},
+		{
+			name:    "clear everything",
+			actions: []map[string]any{{"action": "clear_automation", "track": 0, "param": "Volume"}},
+			want:    map[string][]any{"volume": {}},
+		},
+		{
+			name:    "scale a range",
+			actions: []map[string]any{{"action": "scale_automation", "track": 0, "param": "volume", "factor": 0.5, "start_bar": 2}},
+			want:    map[string][]any{"volume": {point(0, 0.5), point(4, 0.5), point(8, 0.25)}},
+		},
+		{
+			name:    "delete the envelope",
+			actions: []map[string]any{{"action": "delete_envelope", "track": 0, "param": "volume"}},
+			want:    map[string][]any{},
+		},
+		{
+			name:     "clear an envelope the track doesn't have",
+			actions:  []map[string]any{{"action": "clear_automation", "track": 0, "param": "pan"}},
+			want:     map[string][]any{"volume": {point(0, 0.5), point(4, 1), point(8, 0.5)}},
+			warnings: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply(t, testState(), tt.actions...)
+			got := map[string][]any{}
+			for _, item := range trackMap(result, 0)["envelopes"].([]any) {
+				envelope := item.(map[string]any)
+				got[envelope["param"].(string)] = envelope["points"].([]any)
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Len(t, result.Warnings, tt.warnings)
+		})
+	}
+}
+
+func TestApply_ProjectActions(t *testing.T) {
+	result := apply(t, testState(),
+		map[string]any{"action": "add_marker", "bar": 5, "name": "Drop"},
+		map[string]any{"action": "add_region", "start_bar": 9, "end_bar": 17, "name": "Chorus"},
+		map[string]any{"action": "rename_region", "name": "verse", "new_name": "Verse 1"},
+		map[string]any{"action": "delete_marker", "name": "Intro"},
+		map[string]any{"action": "set_tempo", "bpm": 60},
+		// Bars after the tempo change are 4 seconds long
+		map[string]any{"action": "set_play_position", "bar": 3},
+		map[string]any{"action": "play"},
+		map[string]any{"action": "render_project", "format": "wav", "stems": false},
+		map[string]any{"action": "drum_pattern", "drum": "kick", "grid": "x---x---x---x---"},
+	)
+	require.Empty(t, result.Warnings)
+
+	assert.Equal(t, []any{map[string]any{"id": 2, "position": 8.0, "name": "Drop"}}, result.State["markers"])
+	assert.Equal(t, []any{
+		map[string]any{"id": 1.0, "start": 0.0, "end": 16.0, "name": "Verse 1"},
+		map[string]any{"id": 2, "start": 16.0, "end": 32.0, "name": "Chorus"},
+	}, result.State["regions"])
+	assert.Equal(t, 60.0, project(t, result).Info.BPM)
+	assert.Equal(t, map[string]any{"state": "playing", "position": 8.0}, result.State["transport"])
+}
+
+func TestApply_ProjectWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		action map[string]any
+	}{
+		{"delete a marker that isn't there", map[string]any{"action": "delete_marker", "name": "Outro"}},
+		{"rename a region that isn't there", map[string]any{"action": "rename_region", "id": 9, "new_name": "Bridge"}},
+		{"region that ends before it starts", map[string]any{"action": "add_region", "start_bar": 5, "end_bar": 5}},
+		{"invalid tempo", map[string]any{"action": "set_tempo", "bpm": 0}},
+		{"marker without a position", map[string]any{"action": "add_marker", "name": "Drop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := apply(t, testState(), tt.action)
+			require.Len(t, result.Warnings, 1)
+			assert.Equal(t, WarningInconsistentAction, result.Warnings[0].Code)
+			assert.Equal(t, 0, result.Warnings[0].ActionIndex)
+		})
+	}
+}
+
+func TestApply_WarningMessages(t *testing.T) {
+	result := apply(t, testState(),
+		map[string]any{"action": "delete_track", "track": 1},
+		map[string]any{"action": "set_track", "track": 1, "mute": true},
+		map[string]any{"action": "remove_fx", "track": 0, "fxname": "Pro-Q 3"},
+	)
+	require.Len(t, result.Warnings, 2)
+	assert.Equal(t, 1, result.Warnings[0].ActionIndex)
+	assert.Equal(t, "action 1 (set_track): track index 1 doesn't exist (the project has 1 tracks)", result.Warnings[0].Message)
+	assert.Equal(t, 2, result.Warnings[1].ActionIndex)
+	assert.Equal(t, `action 2 (remove_fx): track index 0 has no FX "Pro-Q 3"`, result.Warnings[1].Message)
+}
+
+func TestApply_TempoChanges(t *testing.T) {
+	state := testState()
+	// Bars 1-4 are 2 seconds long, bars from 5 on 4 seconds
+	state["project"].(map[string]any)["tempo_changes"] = []any{map[string]any{"bar": 5, "bpm": 60}}
+
+	result := apply(t, state,
+		map[string]any{"action": "create_clip_at_bar", "track": 1, "bar": 4, "length_bars": 2},
+		map[string]any{"action": "add_marker", "bar": 6, "name": "Drop"},
+	)
+	require.Empty(t, result.Warnings)
+	bass := project(t, result).Tracks[1]
+	require.Len(t, bass.Clips, 1)
+	assert.Equal(t, [2]float64{6, 6}, [2]float64{bass.Clips[0].Position, bass.Clips[0].Length})
+	markers, _ := result.State["markers"].([]any)
+	require.Len(t, markers, 2)
+	assert.Equal(t, map[string]any{"id": 2, "name": "Drop", "position": 12.0}, markers[1])
+}
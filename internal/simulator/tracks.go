@@ -0,0 +1,214 @@
+package simulator
+
+import (
+	"fmt"
+)
+
+// trackProperties are the track properties set_track sets and create_track copies
+var trackProperties = []string{"name", "volume_db", "pan", "mute", "solo", "selected", "color", "folder_depth"}
+
+// trackStateKeys maps the track properties whose state key differs from the action's
+var trackStateKeys = map[string]string{"mute": "muted", "solo": "soloed"}
+
+// copyTrackProperties sets the track properties the action gives under their state keys
+func copyTrackProperties(t *track, action map[string]any) {
+	for _, key := range trackProperties {
+		if v, ok := action[key]; ok {
+			if stateKey, ok := trackStateKeys[key]; ok {
+				key = stateKey
+			}
+			t.fields[key] = v
+		}
+	}
+}
+
+func (s *simulation) applyTrackAction(actionType string, action map[string]any) error {
+	if actionType == "create_track" {
+		return s.createTrack(action)
+	}
+
+	t, err := s.target(action)
+	if err != nil {
+		return err
+	}
+	if t == s.master && actionType != "set_track" {
+		return fmt.Errorf("can't be applied to the master track")
+	}
+
+	switch actionType {
+	case "delete_track":
+		s.deleteTrack(t)
+	case "duplicate_track":
+		count := 1
+		if n, ok := integer(action, "count"); ok {
+			count = n
+		}
+		if count < 1 {
+			return fmt.Errorf("count %d is less than 1", count)
+		}
+		at := s.indexOf(t) + 1
+		copies := make([]*track, count)
+		for i := range copies {
+			copies[i] = duplicateTrack(t)
+		}
+		s.tracks = append(s.tracks[:at], append(copies, s.tracks[at:]...)...)
+	case "move_track":
+		to, ok := integer(action, "to")
+		if !ok {
+			return fmt.Errorf("missing to")
+		}
+		if to < 0 || to >= len(s.tracks) {
+			return fmt.Errorf("destination index %d doesn't exist (the project has %d tracks)", to, len(s.tracks))
+		}
+		from := s.indexOf(t)
+		s.tracks = append(s.tracks[:from], s.tracks[from+1:]...)
+		s.tracks = append(s.tracks[:to], append([]*track{t}, s.tracks[to:]...)...)
+	case "set_track":
+		copyTrackProperties(t, action)
+	case "freeze_track":
+		t.fields["frozen"] = true
+	case "unfreeze_track":
+		if frozen, _ := t.fields["frozen"].(bool); !frozen {
+			return fmt.Errorf("%s isn't frozen", s.describe(t))
+		}
+		t.fields["frozen"] = false
+	case "bounce_in_place":
+		// The rendered clips are audio, and new items
+		for _, clip := range t.clips {
+			delete(clip, "note_count")
+			delete(clip, "guid")
+		}
+		delete(s.lastClip, t)
+	}
+	return nil
+}
+
+// createTrack inserts a track at the action's index. An index past the last track creates the
+// track at the end, which is reported.
+func (s *simulation) createTrack(action map[string]any) error {
+	index, ok := integer(action, "index")
+	if !ok {
+		return fmt.Errorf("missing index")
+	}
+	if index < 0 {
+		return fmt.Errorf("index %d is negative", index)
+	}
+	if !s.tracksKnown {
+		s.grow(index)
+	}
+
+	t := newTrack()
+	copyTrackProperties(t, action)
+	if instrument, _ := action["instrument"].(string); instrument != "" {
+		t.fx = append(t.fx, map[string]any{"name": instrument, "enabled": true})
+	}
+
+	var err error
+	if index > len(s.tracks) {
+		err = fmt.Errorf("index %d is past the last track (the project has %d tracks); the track is created at the end",
+			index, len(s.tracks))
+		index = len(s.tracks)
+	}
+	s.tracks = append(s.tracks[:index], append([]*track{t}, s.tracks[index:]...)...)
+	return err
+}
+
+// deleteTrack removes t and the sends to it
+func (s *simulation) deleteTrack(t *track) {
+	at := s.indexOf(t)
+	s.tracks = append(s.tracks[:at], s.tracks[at+1:]...)
+	delete(s.lastClip, t)
+	for _, other := range append(append([]*track(nil), s.tracks...), s.master) {
+		if other == nil {
+			continue
+		}
+		kept := other.sends[:0]
+		for _, sd := range other.sends {
+			if sd.dest != t {
+				kept = append(kept, sd)
+			}
+		}
+		other.sends = kept
+	}
+}
+
+// duplicateTrack copies t with its clips, FX, envelopes and sends. The copy and its clips are
+// new, so they have no GUIDs.
+func duplicateTrack(t *track) *track {
+	copied := &track{
+		fields:         copyMap(t.fields),
+		clipsKnown:     t.clipsKnown,
+		fxKnown:        t.fxKnown,
+		envelopesKnown: t.envelopesKnown,
+		sendsKnown:     t.sendsKnown,
+	}
+	delete(copied.fields, "guid")
+	for _, clip := range t.clips {
+		clip = copyMap(clip)
+		delete(clip, "guid")
+		copied.clips = append(copied.clips, clip)
+	}
+	for _, fx := range t.fx {
+		copied.fx = append(copied.fx, copyMap(fx))
+	}
+	for _, envelope := range t.envelopes {
+		copied.envelopes = append(copied.envelopes, copyMap(envelope))
+	}
+	for _, sd := range t.sends {
+		copied.sends = append(copied.sends, &send{dest: sd.dest, fields: copyMap(sd.fields)})
+	}
+	return copied
+}
+
+func (s *simulation) applySendAction(actionType string, action map[string]any) error {
+	t, err := s.target(action)
+	if err != nil {
+		return err
+	}
+	if t == s.master {
+		return fmt.Errorf("the master track has no sends")
+	}
+	dest, err := s.trackRef(action, "dest", "dest_guid")
+	if err != nil {
+		return err
+	}
+	if dest == t {
+		return fmt.Errorf("%s can't send to itself", s.describe(t))
+	}
+
+	var existing *send
+	at := -1
+	for i, sd := range t.sends {
+		if sd.dest == dest {
+			existing, at = sd, i
+			break
+		}
+	}
+
+	switch actionType {
+	case "add_send":
+		sd := &send{dest: dest, fields: map[string]any{}}
+		copyFields(sd.fields, action, "level_db", "pre_fader", "mute")
+		t.sends = append(t.sends, sd)
+		t.sendsKnown = true
+	case "set_send":
+		if existing == nil {
+			return s.missingSend(t, dest)
+		}
+		copyFields(existing.fields, action, "level_db", "pre_fader", "mute")
+	case "remove_send":
+		if existing == nil {
+			return s.missingSend(t, dest)
+		}
+		t.sends = append(t.sends[:at], t.sends[at+1:]...)
+	}
+	return nil
+}
+
+// missingSend reports a send that isn't there, unless state didn't list t's sends
+func (s *simulation) missingSend(t, dest *track) error {
+	if !t.sendsKnown {
+		return nil
+	}
+	return fmt.Errorf("%s has no send to %s", s.describe(t), s.describe(dest))
+}
@@ -0,0 +1,446 @@
+package state
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// Kinds of Change
+const (
+	ChangeProject         = "project_changed"
+	ChangeTrackAdded      = "track_added"
+	ChangeTrackRemoved    = "track_removed"
+	ChangeTrackMoved      = "track_moved"
+	ChangeTrack           = "track_changed"
+	ChangeFXAdded         = "fx_added"
+	ChangeFXRemoved       = "fx_removed"
+	ChangeFX              = "fx_changed"
+	ChangeClipAdded       = "clip_added"
+	ChangeClipRemoved     = "clip_removed"
+	ChangeClipMoved       = "clip_moved"
+	ChangeClip            = "clip_changed"
+	ChangeEnvelopeAdded   = "envelope_added"
+	ChangeEnvelopeRemoved = "envelope_removed"
+	ChangeEnvelope        = "envelope_changed"
+)
+
+// floatTolerance is how far apart two seconds, dB or pan values may be and still be equal
+const floatTolerance = 1e-6
+
+// Change is one difference between two project snapshots. Track and Clip are 0-based indices in
+// the later snapshot, or in the earlier one for removals; Track is -1 for the master track and
+// unset for project-wide changes.
+type Change struct {
+	Kind        string `json:"kind"`
+	Track       *int   `json:"track,omitempty"`
+	Clip        *int   `json:"clip,omitempty"`
+	Property    string `json:"property,omitempty"`
+	From        any    `json:"from,omitempty"`
+	To          any    `json:"to,omitempty"`
+	Description string `json:"description"` // e.g. Changed track 2 "Bass": muted false -> true
+}
+
+// Diff lists the changes from before to after: tracks added, removed, moved and changed,
+// their FX, clips and envelopes, and project settings. Tracks and clips are matched by GUID,
+// then by name, then by index, so a track inserted at the top doesn't show every track below
+// it as changed.
+func Diff(before, after *Project) []Change {
+	changes := []Change{}
+	changes = append(changes, diffProjectInfo(before.Info, after.Info)...)
+
+	pairs, removed, added := matchTracks(before.Tracks, after.Tracks)
+	for _, track := range removed {
+		changes = append(changes, trackChange(ChangeTrackRemoved, track, "Removed "+describeStateTrack(track)))
+	}
+	for _, track := range added {
+		changes = append(changes, trackChange(ChangeTrackAdded, track, "Added "+describeStateTrack(track)))
+	}
+	changes = append(changes, diffTrackOrder(pairs)...)
+	for _, pair := range pairs {
+		changes = append(changes, diffTrack(pair[0], pair[1])...)
+	}
+	changes = append(changes, diffClips(before, after, pairs)...)
+
+	if before.Master != nil && after.Master != nil {
+		beforeMaster, afterMaster := *before.Master, *after.Master
+		beforeMaster.Index, afterMaster.Index = -1, -1
+		changes = append(changes, diffTrack(&beforeMaster, &afterMaster)...)
+	}
+	return changes
+}
+
+// Summary returns the changes' descriptions, one per line
+func Summary(changes []Change) []string {
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		lines = append(lines, change.Description)
+	}
+	return lines
+}
+
+// diffProjectInfo compares the project-wide settings
+func diffProjectInfo(before, after ProjectInfo) []Change {
+	var changes []Change
+	add := func(property string, from, to any) {
+		changes = append(changes, Change{
+			Kind:        ChangeProject,
+			Property:    property,
+			From:        from,
+			To:          to,
+			Description: fmt.Sprintf("Changed project: %s %s -> %s", property, describeValue(from), describeValue(to)),
+		})
+	}
+	if before.Name != after.Name {
+		add("name", before.Name, after.Name)
+	}
+	if !floatEqual(before.BPM, after.BPM) {
+		add("bpm", before.BPM, after.BPM)
+	}
+	if before.TimeSignature != after.TimeSignature {
+		add("time_signature", before.TimeSignature, after.TimeSignature)
+	}
+	if !floatEqual(before.Length, after.Length) {
+		add("length", before.Length, after.Length)
+	}
+	return changes
+}
+
+// matchTracks pairs the tracks of two snapshots by GUID, then by name, then by index, and
+// returns the pairs in before order with the tracks left over on each side
+func matchTracks(before, after []Track) (pairs [][2]*Track, removed, added []*Track) {
+	matchedAfter := make([]bool, len(after))
+	pairOf := make([]int, len(before))
+	for i := range pairOf {
+		pairOf[i] = -1
+	}
+
+	match := func(same func(b, a *Track) bool) {
+		for i := range before {
+			if pairOf[i] >= 0 {
+				continue
+			}
+			for j := range after {
+				if !matchedAfter[j] && same(&before[i], &after[j]) {
+					pairOf[i], matchedAfter[j] = j, true
+					break
+				}
+			}
+		}
+	}
+	match(func(b, a *Track) bool { return b.GUID != "" && b.GUID == a.GUID })
+	match(func(b, a *Track) bool { return (b.GUID == "" || a.GUID == "") && b.Name != "" && b.Name == a.Name })
+	match(func(b, a *Track) bool { return (b.GUID == "" || a.GUID == "") && b.Index == a.Index })
+
+	for i := range before {
+		if pairOf[i] >= 0 {
+			pairs = append(pairs, [2]*Track{&before[i], &after[pairOf[i]]})
+		} else {
+			removed = append(removed, &before[i])
+		}
+	}
+	for j := range after {
+		if !matchedAfter[j] {
+			added = append(added, &after[j])
+		}
+	}
+	return pairs, removed, added
+}
+
+// diffTrackOrder reports the tracks whose position among the matched tracks changed. Indices
+// shifted by added or removed tracks aren't moves.
+func diffTrackOrder(pairs [][2]*Track) []Change {
+	beforeRank := make(map[*Track]int, len(pairs))
+	byBefore := append([][2]*Track(nil), pairs...)
+	sort.SliceStable(byBefore, func(i, j int) bool { return byBefore[i][0].Index < byBefore[j][0].Index })
+	for rank, pair := range byBefore {
+		beforeRank[pair[1]] = rank
+	}
+	byAfter := append([][2]*Track(nil), pairs...)
+	sort.SliceStable(byAfter, func(i, j int) bool { return byAfter[i][1].Index < byAfter[j][1].Index })
+
+	var changes []Change
+	for rank, pair := range byAfter {
+		if beforeRank[pair[1]] == rank {
+			continue
+		}
+		change := trackChange(ChangeTrackMoved, pair[1], fmt.Sprintf("Moved track %q from position %d to %d",
+			pair[1].Name, pair[0].Index+1, pair[1].Index+1))
+		change.From, change.To = pair[0].Index, pair[1].Index
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// diffTrack compares a track's properties, FX chain and envelopes
+func diffTrack(before, after *Track) []Change {
+	var changes []Change
+	add := func(property string, from, to any) {
+		change := trackChange(ChangeTrack, after, fmt.Sprintf("Changed %s: %s %s -> %s",
+			describeStateTrack(after), property, describeValue(from), describeValue(to)))
+		change.Property, change.From, change.To = property, from, to
+		changes = append(changes, change)
+	}
+	if before.Name != after.Name {
+		add("name", before.Name, after.Name)
+	}
+	if before.Selected != after.Selected {
+		add("selected", before.Selected, after.Selected)
+	}
+	if before.Mute != after.Mute {
+		add("muted", before.Mute, after.Mute)
+	}
+	if before.Solo != after.Solo {
+		add("soloed", before.Solo, after.Solo)
+	}
+	if !floatEqual(before.VolumeDB, after.VolumeDB) {
+		add("volume_db", before.VolumeDB, after.VolumeDB)
+	}
+	if !floatEqual(before.Pan, after.Pan) {
+		add("pan", before.Pan, after.Pan)
+	}
+	if before.Color != after.Color {
+		add("color", before.Color, after.Color)
+	}
+	if before.FolderDepth != after.FolderDepth {
+		add("folder_depth", before.FolderDepth, after.FolderDepth)
+	}
+
+	changes = append(changes, diffFX(before, after)...)
+	return append(changes, diffEnvelopes(before, after)...)
+}
+
+// diffFX compares FX chains, matching plugins by name in chain order
+func diffFX(before, after *Track) []Change {
+	var changes []Change
+	matched := make([]bool, len(after.FX))
+	for _, fx := range before.FX {
+		j := -1
+		for k := range after.FX {
+			if !matched[k] && after.FX[k].Name == fx.Name {
+				j = k
+				break
+			}
+		}
+		if j < 0 {
+			change := trackChange(ChangeFXRemoved, after, fmt.Sprintf("Removed FX %q from %s", fx.Name, describeStateTrack(after)))
+			change.From = fx.Name
+			changes = append(changes, change)
+			continue
+		}
+		matched[j] = true
+		if fx.Enabled != nil && after.FX[j].Enabled != nil && *fx.Enabled != *after.FX[j].Enabled {
+			change := trackChange(ChangeFX, after, fmt.Sprintf("Changed FX %q on %s: enabled %t -> %t",
+				fx.Name, describeStateTrack(after), *fx.Enabled, *after.FX[j].Enabled))
+			change.Property, change.From, change.To = "enabled", *fx.Enabled, *after.FX[j].Enabled
+			changes = append(changes, change)
+		}
+	}
+	for k, fx := range after.FX {
+		if !matched[k] {
+			change := trackChange(ChangeFXAdded, after, fmt.Sprintf("Added FX %q to %s", fx.Name, describeStateTrack(after)))
+			change.To = fx.Name
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// diffEnvelopes compares automation envelopes by parameter
+func diffEnvelopes(before, after *Track) []Change {
+	var changes []Change
+	afterByParam := make(map[string]*Envelope, len(after.Envelopes))
+	for i := range after.Envelopes {
+		afterByParam[after.Envelopes[i].Param] = &after.Envelopes[i]
+	}
+	seen := make(map[string]bool, len(before.Envelopes))
+	for _, envelope := range before.Envelopes {
+		seen[envelope.Param] = true
+		next, ok := afterByParam[envelope.Param]
+		switch {
+		case !ok:
+			change := trackChange(ChangeEnvelopeRemoved, after, fmt.Sprintf("Removed %s automation from %s",
+				envelope.Param, describeStateTrack(after)))
+			change.Property = envelope.Param
+			changes = append(changes, change)
+		case !pointsEqual(envelope.Points, next.Points):
+			change := trackChange(ChangeEnvelope, after, fmt.Sprintf("Changed %s automation on %s: %d -> %d points",
+				envelope.Param, describeStateTrack(after), len(envelope.Points), len(next.Points)))
+			change.Property, change.From, change.To = envelope.Param, len(envelope.Points), len(next.Points)
+			changes = append(changes, change)
+		}
+	}
+	for _, envelope := range after.Envelopes {
+		if !seen[envelope.Param] {
+			change := trackChange(ChangeEnvelopeAdded, after, fmt.Sprintf("Added %s automation to %s",
+				envelope.Param, describeStateTrack(after)))
+			change.Property = envelope.Param
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// stateClip is a clip with the track it is on
+type stateClip struct {
+	clip  *Clip
+	track *Track
+}
+
+// diffClips compares the clips of the matched tracks. Clips are matched by GUID anywhere in
+// the project, so a clip dragged to another track is a move, then by index on the same track.
+// The clips of added and removed tracks come and go with them and aren't listed.
+func diffClips(before, after *Project, pairs [][2]*Track) []Change {
+	afterTrackOf := make(map[*Track]*Track, len(pairs))
+	keptTracks := make(map[*Track]bool, len(pairs))
+	for _, pair := range pairs {
+		afterTrackOf[pair[0]] = pair[1]
+		keptTracks[pair[1]] = true
+	}
+
+	afterClips := collectClips(after.Tracks)
+	matched := make(map[*Clip]bool, len(afterClips))
+	var changes []Change
+	for _, old := range collectClips(before.Tracks) {
+		var next *stateClip
+		for i := range afterClips {
+			candidate := &afterClips[i]
+			if matched[candidate.clip] {
+				continue
+			}
+			if old.clip.GUID != "" && old.clip.GUID == candidate.clip.GUID ||
+				(old.clip.GUID == "" || candidate.clip.GUID == "") &&
+					afterTrackOf[old.track] == candidate.track && old.clip.Index == candidate.clip.Index {
+				next = candidate
+				break
+			}
+		}
+		if next == nil {
+			if afterTrackOf[old.track] != nil {
+				changes = append(changes, clipChange(ChangeClipRemoved, old, "Removed "+describeStateClip(old)))
+			}
+			continue
+		}
+		matched[next.clip] = true
+		changes = append(changes, diffClip(old, *next, afterTrackOf[old.track] != next.track)...)
+	}
+	for _, clip := range afterClips {
+		if !matched[clip.clip] && keptTracks[clip.track] {
+			changes = append(changes, clipChange(ChangeClipAdded, clip, "Added "+describeStateClip(clip)))
+		}
+	}
+	return changes
+}
+
+// diffClip compares a clip's track, position, length, name and notes
+func diffClip(before, after stateClip, movedTrack bool) []Change {
+	var changes []Change
+	add := func(kind, property string, from, to any, description string) {
+		change := clipChange(kind, after, description)
+		change.Property, change.From, change.To = property, from, to
+		changes = append(changes, change)
+	}
+	if movedTrack {
+		add(ChangeClipMoved, "track", before.track.Index, after.track.Index, fmt.Sprintf("Moved %s from %s to %s",
+			describeClipName(before), describeStateTrack(before.track), describeStateTrack(after.track)))
+	}
+	if !floatEqual(before.clip.Position, after.clip.Position) {
+		add(ChangeClipMoved, "position", before.clip.Position, after.clip.Position, fmt.Sprintf("Moved %s: position %s -> %s",
+			describeStateClip(after), describeSeconds(before.clip.Position), describeSeconds(after.clip.Position)))
+	}
+	if !floatEqual(before.clip.Length, after.clip.Length) {
+		add(ChangeClip, "length", before.clip.Length, after.clip.Length, fmt.Sprintf("Changed %s: length %s -> %s",
+			describeStateClip(after), describeSeconds(before.clip.Length), describeSeconds(after.clip.Length)))
+	}
+	if before.clip.Name != after.clip.Name {
+		add(ChangeClip, "name", before.clip.Name, after.clip.Name, fmt.Sprintf("Changed %s: name %q -> %q",
+			describeStateClip(after), before.clip.Name, after.clip.Name))
+	}
+	if before.clip.NoteCount != after.clip.NoteCount {
+		add(ChangeClip, "note_count", before.clip.NoteCount, after.clip.NoteCount, fmt.Sprintf("Changed %s: note_count %d -> %d",
+			describeStateClip(after), before.clip.NoteCount, after.clip.NoteCount))
+	} else if len(before.clip.Notes) > 0 && len(after.clip.Notes) > 0 && !slices.Equal(before.clip.Notes, after.clip.Notes) {
+		// Same number of notes, edited (e.g. transposed)
+		add(ChangeClip, "notes", nil, nil, fmt.Sprintf("Changed %s: notes edited", describeStateClip(after)))
+	}
+	return changes
+}
+
+// collectClips lists the clips of tracks in track then clip order
+func collectClips(tracks []Track) []stateClip {
+	var clips []stateClip
+	for i := range tracks {
+		for j := range tracks[i].Clips {
+			clips = append(clips, stateClip{clip: &tracks[i].Clips[j], track: &tracks[i]})
+		}
+	}
+	return clips
+}
+
+func trackChange(kind string, track *Track, description string) Change {
+	index := track.Index
+	return Change{Kind: kind, Track: &index, Description: description}
+}
+
+func clipChange(kind string, clip stateClip, description string) Change {
+	change := trackChange(kind, clip.track, description)
+	index := clip.clip.Index
+	change.Clip = &index
+	return change
+}
+
+// describeStateTrack renders a track as track 2 "Drums", 1-based like action previews
+func describeStateTrack(track *Track) string {
+	switch {
+	case track.Index < 0:
+		return "master track"
+	case track.Name != "":
+		return fmt.Sprintf("track %d %q", track.Index+1, track.Name)
+	default:
+		return fmt.Sprintf("track %d", track.Index+1)
+	}
+}
+
+// describeStateClip renders a clip as clip 1 "Verse" on track 2 "Drums"
+func describeStateClip(clip stateClip) string {
+	return describeClipName(clip) + " on " + describeStateTrack(clip.track)
+}
+
+func describeClipName(clip stateClip) string {
+	if clip.clip.Name != "" {
+		return fmt.Sprintf("clip %d %q", clip.clip.Index+1, clip.clip.Name)
+	}
+	return fmt.Sprintf("clip %d", clip.clip.Index+1)
+}
+
+func describeSeconds(seconds float64) string {
+	return fmt.Sprintf("%.2fs", seconds)
+}
+
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case float64:
+		return fmt.Sprintf("%g", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func floatEqual(a, b float64) bool {
+	return math.Abs(a-b) < floatTolerance
+}
+
+func pointsEqual(a, b []EnvelopePoint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !floatEqual(a[i].Time, b[i].Time) || !floatEqual(a[i].Value, b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
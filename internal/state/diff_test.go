@@ -0,0 +1,166 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		name    string
+		before  Project
+		after   Project
+		want    []string
+		wantLen int
+	}{
+		{
+			name:   "no changes",
+			before: Project{Tracks: []Track{{Index: 0, Name: "Drums", VolumeDB: -6}}},
+			after:  Project{Tracks: []Track{{Index: 0, Name: "Drums", VolumeDB: -6.0000001}}},
+			want:   []string{},
+		},
+		{
+			name:   "track inserted at the top shifts the others without changing them",
+			before: Project{Tracks: []Track{{Index: 0, Name: "Drums"}, {Index: 1, Name: "Bass"}}},
+			after:  Project{Tracks: []Track{{Index: 0, Name: "Pad"}, {Index: 1, Name: "Drums"}, {Index: 2, Name: "Bass"}}},
+			want:   []string{`Added track 1 "Pad"`},
+		},
+		{
+			name:   "track removed",
+			before: Project{Tracks: []Track{{Index: 0, Name: "Drums", Clips: []Clip{{Index: 0, Position: 0, Length: 4}}}, {Index: 1, Name: "Bass"}}},
+			after:  Project{Tracks: []Track{{Index: 0, Name: "Bass"}}},
+			want:   []string{`Removed track 1 "Drums"`},
+		},
+		{
+			name:   "tracks swapped",
+			before: Project{Tracks: []Track{{Index: 0, GUID: "a", Name: "Drums"}, {Index: 1, GUID: "b", Name: "Bass"}}},
+			after:  Project{Tracks: []Track{{Index: 0, GUID: "b", Name: "Bass"}, {Index: 1, GUID: "a", Name: "Drums"}}},
+			want:   []string{`Moved track "Bass" from position 2 to 1`, `Moved track "Drums" from position 1 to 2`},
+		},
+		{
+			name:   "renamed track without GUID keeps its index",
+			before: Project{Tracks: []Track{{Index: 0, Name: "Track 1"}}},
+			after:  Project{Tracks: []Track{{Index: 0, Name: "Lead"}}},
+			want:   []string{`Changed track 1 "Lead": name "Track 1" -> "Lead"`},
+		},
+		{
+			name: "track properties, FX and envelopes",
+			before: Project{Tracks: []Track{{
+				Index: 0, Name: "Bass", VolumeDB: -6,
+				FX:        []FX{{Index: 0, Name: "ReaEQ", Enabled: &enabled}, {Index: 1, Name: "ReaComp"}},
+				Envelopes: []Envelope{{Param: "volume", Points: []EnvelopePoint{{Time: 0, Value: 0}}}},
+			}}},
+			after: Project{Tracks: []Track{{
+				Index: 0, Name: "Bass", VolumeDB: -3, Mute: true,
+				FX:        []FX{{Index: 0, Name: "ReaEQ", Enabled: &disabled}, {Index: 1, Name: "Serum"}},
+				Envelopes: []Envelope{{Param: "pan", Points: []EnvelopePoint{{Time: 0, Value: 0}}}},
+			}}},
+			want: []string{
+				`Changed track 1 "Bass": muted false -> true`,
+				`Changed track 1 "Bass": volume_db -6 -> -3`,
+				`Changed FX "ReaEQ" on track 1 "Bass": enabled true -> false`,
+				`Removed FX "ReaComp" from track 1 "Bass"`,
+				`Added FX "Serum" to track 1 "Bass"`,
+				`Removed volume automation from track 1 "Bass"`,
+				`Added pan automation to track 1 "Bass"`,
+			},
+		},
+		{
+			name: "clips moved, resized, added and removed",
+			before: Project{Tracks: []Track{{Index: 0, Name: "Drums", Clips: []Clip{
+				{Index: 0, Name: "Verse", Position: 0, Length: 4},
+				{Index: 1, Position: 8, Length: 4},
+			}}}},
+			after: Project{Tracks: []Track{{Index: 0, Name: "Drums", Clips: []Clip{
+				{Index: 0, Name: "Verse", Position: 2, Length: 8},
+			}}}},
+			want: []string{
+				`Moved clip 1 "Verse" on track 1 "Drums": position 0.00s -> 2.00s`,
+				`Changed clip 1 "Verse" on track 1 "Drums": length 4.00s -> 8.00s`,
+				`Removed clip 2 on track 1 "Drums"`,
+			},
+		},
+		{
+			name: "clip notes transposed",
+			before: Project{Tracks: []Track{{Index: 0, Name: "Bass", Clips: []Clip{
+				{Index: 0, Position: 0, Length: 4, NoteCount: 2, Notes: []Note{{Pitch: 36, Velocity: 100, Length: 1}, {Pitch: 43, Velocity: 100, Start: 1, Length: 1}}},
+			}}}},
+			after: Project{Tracks: []Track{{Index: 0, Name: "Bass", Clips: []Clip{
+				{Index: 0, Position: 0, Length: 4, NoteCount: 2, Notes: []Note{{Pitch: 48, Velocity: 100, Length: 1}, {Pitch: 55, Velocity: 100, Start: 1, Length: 1}}},
+			}}}},
+			want: []string{`Changed clip 1 on track 1 "Bass": notes edited`},
+		},
+		{
+			name: "clip dragged to another track",
+			before: Project{Tracks: []Track{
+				{Index: 0, Name: "Drums", Clips: []Clip{{Index: 0, GUID: "c1", Position: 4, Length: 4}}},
+				{Index: 1, Name: "Perc"},
+			}},
+			after: Project{Tracks: []Track{
+				{Index: 0, Name: "Drums"},
+				{Index: 1, Name: "Perc", Clips: []Clip{{Index: 0, GUID: "c1", Track: 1, Position: 4, Length: 4}}},
+			}},
+			want: []string{`Moved clip 1 from track 1 "Drums" to track 2 "Perc"`},
+		},
+		{
+			name:   "project and master",
+			before: Project{Info: ProjectInfo{BPM: 120}, Master: &Track{Name: "MASTER", VolumeDB: 0}},
+			after:  Project{Info: ProjectInfo{BPM: 98}, Master: &Track{Name: "MASTER", VolumeDB: -1.5}},
+			want:   []string{"Changed project: bpm 120 -> 98", "Changed master track: volume_db 0 -> -1.5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := Diff(&tt.before, &tt.after)
+			assert.Equal(t, tt.want, Summary(changes))
+		})
+	}
+}
+
+func TestDiff_MachineReadable(t *testing.T) {
+	before := Project{Tracks: []Track{{Index: 0, Name: "Bass", Clips: []Clip{{Index: 0, Position: 0, Length: 4}}}}}
+	after := Project{Tracks: []Track{{Index: 0, Name: "Bass", Solo: true, Clips: []Clip{{Index: 0, Position: 4, Length: 4}}}}}
+
+	changes := Diff(&before, &after)
+	require.Len(t, changes, 2)
+
+	assert.Equal(t, ChangeTrack, changes[0].Kind)
+	require.NotNil(t, changes[0].Track)
+	assert.Equal(t, 0, *changes[0].Track)
+	assert.Nil(t, changes[0].Clip)
+	assert.Equal(t, "soloed", changes[0].Property)
+	assert.Equal(t, false, changes[0].From)
+	assert.Equal(t, true, changes[0].To)
+
+	assert.Equal(t, ChangeClipMoved, changes[1].Kind)
+	require.NotNil(t, changes[1].Clip)
+	assert.Equal(t, 0, *changes[1].Clip)
+	assert.Equal(t, "position", changes[1].Property)
+	assert.Equal(t, 0.0, changes[1].From)
+	assert.Equal(t, 4.0, changes[1].To)
+}
+
+func TestDiff_ClientState(t *testing.T) {
+	parse := func(data string) *Project {
+		var raw map[string]any
+		require.NoError(t, json.Unmarshal([]byte(data), &raw))
+		project, err := Parse(raw)
+		require.NoError(t, err)
+		return project
+	}
+	before := parse(`{"tracks": [{"index": 0, "name": "Drums"}, {"index": 1, "name": "Bass", "soloed": true}]}`)
+	after := parse(`{"tracks": [{"index": 0, "name": "Drums", "muted": true}, {"index": 1, "name": "Bass", "soloed": false}]}`)
+
+	changes := Diff(before, after)
+	assert.Equal(t, []string{
+		`Changed track 1 "Drums": muted false -> true`,
+		`Changed track 2 "Bass": soloed true -> false`,
+	}, Summary(changes))
+	assert.Equal(t, "muted", changes[0].Property)
+	assert.Equal(t, "soloed", changes[1].Property)
+}
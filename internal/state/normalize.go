@@ -0,0 +1,225 @@
+package state
+
+import (
+	"fmt"
+	"math"
+)
+
+// Unwrap returns the project state inside state: clients send it either bare or under a
+// "state" key
+func Unwrap(state map[string]any) map[string]any {
+	if inner, ok := state["state"].(map[string]any); ok {
+		return inner
+	}
+	return state
+}
+
+// Normalize validates a state and settles its types in place, so agents read every index as
+// an int and every collection as []any: JSON numbers arrive as float64 and Go callers pass ints
+// and typed slices. Track, clip, FX and envelope indices become ints, defaulting to the
+// position in their array; every clip on a track gets the track's index as "track"; MIDI note
+// pitches and velocities become ints; numeric track colors become "#rrggbb". Returns state, or
+// an error naming the first invalid field.
+// A state that is already normalized is only read, so concurrent requests can share one.
+func Normalize(state map[string]any) (map[string]any, error) {
+	if state == nil {
+		return nil, nil
+	}
+	s := Unwrap(state)
+
+	tracks, err := array(s, "tracks", "tracks")
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[int]bool, len(tracks))
+	for i, item := range tracks {
+		path := fmt.Sprintf("tracks[%d]", i)
+		track, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid state: %s is %s, not an object", path, typeName(item))
+		}
+		index, err := normalizeIndex(track, "index", i, path)
+		if err != nil {
+			return nil, err
+		}
+		if seen[index] {
+			return nil, fmt.Errorf("invalid state: %s.index: track %d appears twice", path, index)
+		}
+		seen[index] = true
+		if err := normalizeTrack(track, index, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if master, ok := s["master"].(map[string]any); ok {
+		if err := normalizeTrack(master, -1, "master"); err != nil {
+			return nil, err
+		}
+	}
+
+	// A top-level clips collection keeps the track index each clip names, if any
+	clips, err := array(s, "clips", "clips")
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range clips {
+		path := fmt.Sprintf("clips[%d]", i)
+		clip, ok := item.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid state: %s is %s, not an object", path, typeName(item))
+		}
+		if _, err := normalizeIndex(clip, "index", i, path); err != nil {
+			return nil, err
+		}
+		if _, err := normalizeIndex(clip, "track", -1, path); err != nil {
+			return nil, err
+		}
+		if err := normalizeNotes(clip, path); err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// normalizeTrack normalizes a track's color and the items in its FX chain, clips and
+// envelopes. index is the track's index, or -1 for the master track.
+func normalizeTrack(track map[string]any, index int, path string) error {
+	switch color := track["color"].(type) {
+	case float64:
+		track["color"] = fmt.Sprintf("#%06x", int(color)&0xffffff)
+	case int:
+		track["color"] = fmt.Sprintf("#%06x", color&0xffffff)
+	}
+
+	for _, key := range []string{"fx", "clips", "envelopes"} {
+		items, err := array(track, key, path+"."+key)
+		if err != nil {
+			return err
+		}
+		for j, item := range items {
+			itemPath := fmt.Sprintf("%s.%s[%d]", path, key, j)
+			itemMap, ok := item.(map[string]any)
+			if !ok {
+				return fmt.Errorf("invalid state: %s is %s, not an object", itemPath, typeName(item))
+			}
+			if _, err := normalizeIndex(itemMap, "index", j, itemPath); err != nil {
+				return err
+			}
+			if key != "clips" {
+				continue
+			}
+			if current, ok := itemMap["track"].(int); index >= 0 && (!ok || current != index) {
+				itemMap["track"] = index
+			}
+			if err := normalizeNotes(itemMap, itemPath); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// normalizeNotes normalizes a clip's MIDI notes: each must have a pitch from 0 to 127, and
+// pitch and velocity become ints
+func normalizeNotes(clip map[string]any, path string) error {
+	notes, err := array(clip, "notes", path+".notes")
+	if err != nil {
+		return err
+	}
+	for i, item := range notes {
+		notePath := fmt.Sprintf("%s.notes[%d]", path, i)
+		note, ok := item.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid state: %s is %s, not an object", notePath, typeName(item))
+		}
+		if value, ok := note["pitch"]; !ok || value == nil {
+			return fmt.Errorf("invalid state: %s.pitch is missing", notePath)
+		}
+		pitch, err := normalizeIndex(note, "pitch", -1, notePath)
+		if err != nil {
+			return err
+		}
+		if pitch > 127 {
+			return fmt.Errorf("invalid state: %s.pitch: %d is above 127", notePath, pitch)
+		}
+		if _, err := normalizeIndex(note, "velocity", -1, notePath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizeIndex stores item[key] as an int and returns it. A missing index is set to
+// fallback, or left unset when fallback is negative.
+func normalizeIndex(item map[string]any, key string, fallback int, path string) (int, error) {
+	value, ok := item[key]
+	if !ok || value == nil {
+		if fallback < 0 {
+			return 0, nil
+		}
+		item[key] = fallback
+		return fallback, nil
+	}
+
+	var index int
+	switch v := value.(type) {
+	case int:
+		index = v
+	case int64:
+		index = int(v)
+	case float64:
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			return 0, fmt.Errorf("invalid state: %s.%s: %v is not a whole number", path, key, v)
+		}
+		index = int(v)
+	default:
+		return 0, fmt.Errorf("invalid state: %s.%s is %s, not a number", path, key, typeName(value))
+	}
+	if index < 0 {
+		return 0, fmt.Errorf("invalid state: %s.%s: %d is negative", path, key, index)
+	}
+	if _, ok := value.(int); !ok {
+		item[key] = index
+	}
+	return index, nil
+}
+
+// array returns m[key] as an array, storing a Go []map[string]any as []any. A missing or null
+// key is an empty array.
+func array(m map[string]any, key, path string) ([]any, error) {
+	switch value := m[key].(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		return value, nil
+	case []map[string]any:
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = item
+		}
+		m[key] = items
+		return items, nil
+	default:
+		return nil, fmt.Errorf("invalid state: %s is %s, not an array", path, typeName(value))
+	}
+}
+
+// typeName names a JSON value's type for error messages
+func typeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case string:
+		return "a string"
+	case int, int64, float64:
+		return "a number"
+	case []any:
+		return "an array"
+	case map[string]any:
+		return "an object"
+	default:
+		return fmt.Sprintf("a %T", value)
+	}
+}
@@ -0,0 +1,118 @@
+// Package state describes the REAPER project state the extension sends with each request.
+// Agents read the state as JSON maps; Normalize validates it and settles the types of indices
+// once, and Parse converts it to typed structs.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Project is a project state snapshot
+type Project struct {
+	Info   ProjectInfo `json:"project"`
+	Tracks []Track     `json:"tracks"`
+	Master *Track      `json:"master,omitempty"`
+}
+
+// ProjectInfo is the project-wide part of the state
+type ProjectInfo struct {
+	Name          string  `json:"name,omitempty"`
+	BPM           float64 `json:"bpm,omitempty"`
+	TimeSignature string  `json:"time_signature,omitempty"` // e.g. "4/4"
+	BeatsPerBar   float64 `json:"beats_per_bar,omitempty"`
+	Length        float64 `json:"length,omitempty"` // Seconds
+}
+
+// Track is a project track. Index is its 0-based position in the project.
+type Track struct {
+	Index       int        `json:"index"`
+	GUID        string     `json:"guid,omitempty"`
+	Name        string     `json:"name"`
+	Selected    bool       `json:"selected,omitempty"`
+	Mute        bool       `json:"muted,omitempty"`
+	Solo        bool       `json:"soloed,omitempty"`
+	VolumeDB    float64    `json:"volume_db,omitempty"`
+	Pan         float64    `json:"pan,omitempty"`
+	Color       string     `json:"color,omitempty"` // "#rrggbb" or a color name
+	FolderDepth int        `json:"folder_depth,omitempty"`
+	FX          []FX       `json:"fx,omitempty"`
+	Clips       []Clip     `json:"clips,omitempty"`
+	Envelopes   []Envelope `json:"envelopes,omitempty"`
+}
+
+// Clip is a media item on a track. Position and Length are in seconds.
+type Clip struct {
+	Index     int     `json:"index"`
+	Track     int     `json:"track"` // Index of the track the clip is on
+	GUID      string  `json:"guid,omitempty"`
+	Name      string  `json:"name,omitempty"`
+	Position  float64 `json:"position"`
+	Length    float64 `json:"length"`
+	Selected  bool    `json:"selected,omitempty"`
+	NoteCount int     `json:"note_count,omitempty"`
+	Notes     []Note  `json:"notes,omitempty"` // MIDI notes, when the extension sends them
+}
+
+// Note is a MIDI note in a clip. Start and Length are in beats from the clip's start.
+type Note struct {
+	Pitch    int     `json:"pitch"`
+	Velocity int     `json:"velocity"`
+	Start    float64 `json:"start"`
+	Length   float64 `json:"length"`
+}
+
+// FX is a plugin in a track's FX chain
+type FX struct {
+	Index   int    `json:"index"`
+	Name    string `json:"name"`
+	Enabled *bool  `json:"enabled,omitempty"` // Unset when the extension doesn't report it
+}
+
+// Envelope is an automation envelope on a track
+type Envelope struct {
+	Index  int             `json:"index"`
+	Param  string          `json:"param"` // e.g. "volume", "pan" or "Serum:Cutoff"
+	Points []EnvelopePoint `json:"points,omitempty"`
+}
+
+// EnvelopePoint is an automation point. Time is in seconds.
+type EnvelopePoint struct {
+	Time  float64 `json:"time"`
+	Value float64 `json:"value"`
+}
+
+// Parse normalizes a copy of raw and converts it to a Project. raw is left unchanged.
+func Parse(raw map[string]any) (*Project, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	var copied map[string]any
+	if err := json.Unmarshal(data, &copied); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	normalized, err := Normalize(copied)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err = json.Marshal(Unwrap(normalized)); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	var project Project
+	if err := json.Unmarshal(data, &project); err != nil {
+		return nil, fmt.Errorf("invalid state: %w", err)
+	}
+	return &project, nil
+}
+
+// Track returns the track with index, or nil
+func (p *Project) Track(index int) *Track {
+	for i := range p.Tracks {
+		if p.Tracks[i].Index == index {
+			return &p.Tracks[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+package state
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	state := map[string]any{
+		"state": map[string]any{
+			"tracks": []any{
+				map[string]any{
+					"index": 0.0,
+					"name":  "Drums",
+					"color": float64(0xff0000),
+					"clips": []any{
+						map[string]any{"position": 0.0, "length": 4.0},
+						map[string]any{"index": 1.0, "position": 8.0, "length": 4.0, "track": 7.0},
+					},
+					"fx": []map[string]any{{"name": "ReaComp"}},
+				},
+				map[string]any{"name": "Bass"},
+			},
+		},
+	}
+
+	normalized, err := Normalize(state)
+	require.NoError(t, err)
+	tracks := Unwrap(normalized)["tracks"].([]any)
+
+	drums := tracks[0].(map[string]any)
+	assert.Equal(t, 0, drums["index"])
+	assert.Equal(t, "#ff0000", drums["color"])
+	clips := drums["clips"].([]any)
+	assert.Equal(t, map[string]any{"index": 0, "track": 0, "position": 0.0, "length": 4.0}, clips[0])
+	assert.Equal(t, map[string]any{"index": 1, "track": 0, "position": 8.0, "length": 4.0}, clips[1])
+	assert.Equal(t, []any{map[string]any{"index": 0, "name": "ReaComp"}}, drums["fx"])
+
+	assert.Equal(t, 1, tracks[1].(map[string]any)["index"], "a missing index defaults to the position")
+}
+
+// Parsers of concurrent requests normalize the same state again; run with -race
+func TestNormalize_SharedState(t *testing.T) {
+	state := map[string]any{"tracks": []any{
+		map[string]any{"index": 0.0, "clips": []any{map[string]any{"position": 0.0}}, "fx": []any{map[string]any{"name": "ReaEQ"}}},
+	}}
+	_, err := Normalize(state)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := Normalize(state)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNormalize_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		state   map[string]any
+		wantErr string
+	}{
+		{
+			name:    "tracks not an array",
+			state:   map[string]any{"tracks": map[string]any{}},
+			wantErr: "tracks is an object, not an array",
+		},
+		{
+			name:    "track not an object",
+			state:   map[string]any{"tracks": []any{"Drums"}},
+			wantErr: "tracks[0] is a string, not an object",
+		},
+		{
+			name:    "fractional index",
+			state:   map[string]any{"tracks": []any{map[string]any{"index": 1.5}}},
+			wantErr: "tracks[0].index: 1.5 is not a whole number",
+		},
+		{
+			name:    "negative index",
+			state:   map[string]any{"tracks": []any{map[string]any{"index": -1.0}}},
+			wantErr: "tracks[0].index: -1 is negative",
+		},
+		{
+			name: "duplicate index",
+			state: map[string]any{"tracks": []any{
+				map[string]any{"index": 0.0},
+				map[string]any{"index": 0.0},
+			}},
+			wantErr: "track 0 appears twice",
+		},
+		{
+			name: "clip index not a number",
+			state: map[string]any{"tracks": []any{
+				map[string]any{"clips": []any{map[string]any{"index": "first"}}},
+			}},
+			wantErr: "tracks[0].clips[0].index is a string, not a number",
+		},
+		{
+			name: "note without pitch",
+			state: map[string]any{"tracks": []any{
+				map[string]any{"clips": []any{map[string]any{"notes": []any{map[string]any{"start": 0.0}}}}},
+			}},
+			wantErr: "tracks[0].clips[0].notes[0].pitch is missing",
+		},
+		{
+			name: "note pitch out of range",
+			state: map[string]any{"tracks": []any{
+				map[string]any{"clips": []any{map[string]any{"notes": []any{map[string]any{"pitch": 128.0}}}}},
+			}},
+			wantErr: "tracks[0].clips[0].notes[0].pitch: 128 is above 127",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Normalize(tt.state)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	raw := map[string]any{
+		"project": map[string]any{"name": "Song", "bpm": 98.0, "time_signature": "3/4"},
+		"tracks": []any{
+			map[string]any{
+				"index":    0.0,
+				"name":     "Drums",
+				"selected": true,
+				"fx":       []any{map[string]any{"name": "ReaComp", "enabled": false}},
+				"clips":    []any{map[string]any{"position": 2.0, "length": 4.0}},
+			},
+			map[string]any{"index": 1.0, "name": "Bass", "volume_db": -6.0, "clips": []any{
+				map[string]any{"position": 0.0, "length": 2.0, "notes": []any{
+					map[string]any{"pitch": 36.0, "velocity": 100.0, "start": 0.0, "length": 0.5},
+					map[string]any{"pitch": 43.0, "velocity": 90.0, "start": 1.0, "length": 0.5},
+				}},
+			}},
+		},
+	}
+
+	project, err := Parse(raw)
+	require.NoError(t, err)
+	assert.Equal(t, ProjectInfo{Name: "Song", BPM: 98, TimeSignature: "3/4"}, project.Info)
+	require.Len(t, project.Tracks, 2)
+
+	drums := project.Track(0)
+	require.NotNil(t, drums)
+	assert.True(t, drums.Selected)
+	require.Len(t, drums.FX, 1)
+	assert.Equal(t, "ReaComp", drums.FX[0].Name)
+	require.NotNil(t, drums.FX[0].Enabled)
+	assert.False(t, *drums.FX[0].Enabled)
+	assert.Equal(t, []Clip{{Index: 0, Track: 0, Position: 2, Length: 4}}, drums.Clips)
+
+	bass := project.Track(1)
+	assert.Equal(t, -6.0, bass.VolumeDB)
+	require.Len(t, bass.Clips, 1)
+	assert.Equal(t, []Note{
+		{Pitch: 36, Velocity: 100, Start: 0, Length: 0.5},
+		{Pitch: 43, Velocity: 90, Start: 1, Length: 0.5},
+	}, bass.Clips[0].Notes)
+	assert.Nil(t, project.Track(2))
+
+	// raw is left as it was
+	assert.Equal(t, 0.0, raw["tracks"].([]any)[0].(map[string]any)["index"])
+}
+
+func TestParse_ClientTrack(t *testing.T) {
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal([]byte(`{"tracks": [
+		{"index": 0, "name": "Drums", "muted": true, "soloed": false, "volume_db": -3, "pan": 0.25},
+		{"index": 1, "name": "Bass", "soloed": true}
+	]}`), &raw))
+
+	project, err := Parse(raw)
+	require.NoError(t, err)
+	assert.True(t, project.Track(0).Mute)
+	assert.False(t, project.Track(0).Solo)
+	assert.True(t, project.Track(1).Solo)
+
+	// Encoding the typed track gives back the keys the client sent
+	data, err := json.Marshal(project.Tracks)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[
+		{"index": 0, "name": "Drums", "muted": true, "volume_db": -3, "pan": 0.25},
+		{"index": 1, "name": "Bass", "soloed": true}
+	]`, string(data))
+}
@@ -0,0 +1,252 @@
+// Package timeutil converts between bars, beats and seconds on a project's tempo map. Bars are
+// 1-based like REAPER's ruler, so bar 1 starts at 0 seconds; beats count from 0 at the start of
+// the project. A beat is one count of the time signature, so a bar of 4/4 at 120 BPM is 2
+// seconds and a bar of 6/8 at 120 BPM is 3.
+package timeutil
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	projectstate "github.com/Conceptual-Machines/magda-api/internal/state"
+)
+
+const (
+	// DefaultBPM is the tempo when the state doesn't have one
+	DefaultBPM = 120.0
+	// DefaultBeatsPerBar is the time signature numerator when the state doesn't have one
+	DefaultBeatsPerBar = 4.0
+	// DefaultBeatUnit is the note value of a beat when the state doesn't have one (quarter notes)
+	DefaultBeatUnit = 4
+)
+
+// TempoChange is a tempo or time signature change at the start of a bar. A zero BPM or
+// BeatsPerBar keeps the value in effect before it.
+type TempoChange struct {
+	Bar         float64 // 1-based
+	BPM         float64
+	BeatsPerBar float64
+}
+
+// segment is a stretch of constant tempo and meter, from where it starts to the next one
+type segment struct {
+	bar, beat, seconds float64 // Start: bars (0-based), beats and seconds since the project start
+	bpm, beatsPerBar   float64
+}
+
+// secondsPerBeat is the length of a beat in the segment
+func (s segment) secondsPerBeat() float64 { return 60 / s.bpm }
+
+// TempoMap is a project's tempo and meter over time. The zero value isn't usable; create one
+// with New, Constant or FromState.
+type TempoMap struct {
+	segments []segment // Sorted; the first starts at bar 1
+	beatUnit int
+}
+
+// Constant returns a tempo map without changes. Non-positive values use the defaults.
+func Constant(bpm, beatsPerBar float64) *TempoMap {
+	return New(bpm, beatsPerBar, nil)
+}
+
+// New returns a tempo map starting at bpm and beatsPerBar (non-positive values use the
+// defaults) with changes, in any order. A change at or before bar 1 replaces the starting tempo
+// or meter; of two changes at the same bar, the later one wins.
+func New(bpm, beatsPerBar float64, changes []TempoChange) *TempoMap {
+	if bpm <= 0 {
+		bpm = DefaultBPM
+	}
+	if beatsPerBar <= 0 {
+		beatsPerBar = DefaultBeatsPerBar
+	}
+
+	sorted := append([]TempoChange(nil), changes...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Bar < sorted[j].Bar })
+
+	segments := []segment{{bpm: bpm, beatsPerBar: beatsPerBar}}
+	for _, change := range sorted {
+		last := &segments[len(segments)-1]
+		bar := max(change.Bar-1, 0)
+		if bar > last.bar {
+			bars := bar - last.bar
+			segments = append(segments, segment{
+				bar:         bar,
+				beat:        last.beat + bars*last.beatsPerBar,
+				seconds:     last.seconds + bars*last.beatsPerBar*last.secondsPerBeat(),
+				bpm:         last.bpm,
+				beatsPerBar: last.beatsPerBar,
+			})
+			last = &segments[len(segments)-1]
+		}
+		if change.BPM > 0 {
+			last.bpm = change.BPM
+		}
+		if change.BeatsPerBar > 0 {
+			last.beatsPerBar = change.BeatsPerBar
+		}
+	}
+	return &TempoMap{segments: segments, beatUnit: DefaultBeatUnit}
+}
+
+// FromState reads the tempo map from a project state, bare or under a "state" key:
+// project.bpm (or tempo), project.beats_per_bar or the numerator of project.time_signature
+// ("3/4"), and project.tempo_changes, a list of {bar, bpm, beats_per_bar or time_signature}.
+// Missing or invalid values fall back to 120 BPM in 4/4; invalid changes are skipped.
+func FromState(state map[string]any) *TempoMap {
+	project, _ := projectstate.Unwrap(state)["project"].(map[string]any)
+
+	bpm := 0.0
+	for _, key := range []string{"bpm", "tempo"} {
+		if v, ok := number(project[key]); ok && v > 0 {
+			bpm = v
+			break
+		}
+	}
+	beatsPerBar, beatUnit := meter(project)
+
+	var changes []TempoChange
+	rawChanges, _ := project["tempo_changes"].([]any)
+	for _, item := range rawChanges {
+		fields, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		bar, ok := number(fields["bar"])
+		if !ok {
+			continue
+		}
+		change := TempoChange{Bar: bar}
+		change.BPM, _ = number(fields["bpm"])
+		change.BeatsPerBar, _ = meter(fields)
+		if change.BPM > 0 || change.BeatsPerBar > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	m := New(bpm, beatsPerBar, changes)
+	if beatUnit > 0 {
+		m.beatUnit = beatUnit
+	}
+	return m
+}
+
+// TimeSignature returns the time signature at the start of the project in state, e.g. "6/8", or
+// "" if state doesn't have one
+func TimeSignature(state map[string]any) string {
+	project, _ := projectstate.Unwrap(state)["project"].(map[string]any)
+	beatsPerBar, beatUnit := meter(project)
+	if beatsPerBar <= 0 {
+		return ""
+	}
+	if beatUnit <= 0 {
+		beatUnit = DefaultBeatUnit
+	}
+	return strconv.FormatFloat(beatsPerBar, 'f', -1, 64) + "/" + strconv.Itoa(beatUnit)
+}
+
+// meter reads beats_per_bar, or the time_signature's numerator, and the time_signature's beat
+// unit from fields. Missing values are 0.
+func meter(fields map[string]any) (beatsPerBar float64, beatUnit int) {
+	signature, _ := fields["time_signature"].(string)
+	numerator, denominator, found := strings.Cut(signature, "/")
+	if found {
+		if v, err := strconv.Atoi(strings.TrimSpace(denominator)); err == nil && v > 0 {
+			beatUnit = v
+		}
+	}
+	if v, ok := number(fields["beats_per_bar"]); ok && v > 0 {
+		beatsPerBar = v
+	} else if found {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(numerator), 64); err == nil && v > 0 {
+			beatsPerBar = v
+		}
+	}
+	return beatsPerBar, beatUnit
+}
+
+func number(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+// BPM is the tempo at the start of the project
+func (m *TempoMap) BPM() float64 { return m.segments[0].bpm }
+
+// BeatsPerBar is the time signature numerator at the start of the project
+func (m *TempoMap) BeatsPerBar() float64 { return m.segments[0].beatsPerBar }
+
+// BeatUnit is the note value of a beat: 4 for quarter notes, 8 in 6/8
+func (m *TempoMap) BeatUnit() int { return m.beatUnit }
+
+// HasChanges reports whether the tempo or meter changes during the project
+func (m *TempoMap) HasChanges() bool { return len(m.segments) > 1 }
+
+// At returns the tempo and beats per bar in effect at a position in seconds
+func (m *TempoMap) At(seconds float64) (bpm, beatsPerBar float64) {
+	s := m.find(func(s segment) float64 { return s.seconds }, seconds)
+	return s.bpm, s.beatsPerBar
+}
+
+// BarToSeconds returns where a 1-based bar starts. Fractional bars are positions inside the
+// bar; bars before 1 extend the starting tempo backwards.
+func (m *TempoMap) BarToSeconds(bar float64) float64 {
+	bars := bar - 1
+	s := m.find(func(s segment) float64 { return s.bar }, bars)
+	return s.seconds + (bars-s.bar)*s.beatsPerBar*s.secondsPerBeat()
+}
+
+// SecondsToBar returns the 1-based, fractional bar at a position
+func (m *TempoMap) SecondsToBar(seconds float64) float64 {
+	s := m.find(func(s segment) float64 { return s.seconds }, seconds)
+	return 1 + s.bar + (seconds-s.seconds)/s.secondsPerBeat()/s.beatsPerBar
+}
+
+// BeatToSeconds returns the position of a beat counted from 0 at the project start
+func (m *TempoMap) BeatToSeconds(beat float64) float64 {
+	s := m.find(func(s segment) float64 { return s.beat }, beat)
+	return s.seconds + (beat-s.beat)*s.secondsPerBeat()
+}
+
+// SecondsToBeat returns the beat, counted from 0 at the project start, at a position
+func (m *TempoMap) SecondsToBeat(seconds float64) float64 {
+	s := m.find(func(s segment) float64 { return s.seconds }, seconds)
+	return s.beat + (seconds-s.seconds)/s.secondsPerBeat()
+}
+
+// BarsToSeconds returns the length of bars counted from the start of the project, for lengths
+// that have no position of their own
+func (m *TempoMap) BarsToSeconds(bars float64) float64 {
+	return m.BarToSeconds(1+bars) - m.BarToSeconds(1)
+}
+
+// AddBars returns the position bars after seconds (before, for negative bars), following tempo
+// changes in between
+func (m *TempoMap) AddBars(seconds, bars float64) float64 {
+	return m.BarToSeconds(m.SecondsToBar(seconds) + bars)
+}
+
+// find returns the last segment starting at or before value, by key, or the first segment for
+// values before the project start
+func (m *TempoMap) find(key func(segment) float64, value float64) segment {
+	i := sort.Search(len(m.segments), func(i int) bool { return key(m.segments[i]) > value })
+	return m.segments[max(i-1, 0)]
+}
+
+// SecondsToBeats converts a duration in seconds to beats at a constant tempo
+func SecondsToBeats(seconds, bpm float64) float64 {
+	return seconds * bpm / 60
+}
+
+// BeatsToSeconds converts a duration in beats to seconds at a constant tempo
+func BeatsToSeconds(beats, bpm float64) float64 {
+	return beats * 60 / bpm
+}
@@ -0,0 +1,177 @@
+package timeutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstant(t *testing.T) {
+	tests := []struct {
+		name            string
+		bpm             float64
+		beatsPerBar     float64
+		bar             float64
+		wantBarStart    float64
+		wantBPM         float64
+		wantBeatsPerBar float64
+	}{
+		{name: "120 BPM in 4/4", bpm: 120, beatsPerBar: 4, bar: 3, wantBarStart: 4, wantBPM: 120, wantBeatsPerBar: 4},
+		{name: "90 BPM in 3/4", bpm: 90, beatsPerBar: 3, bar: 2, wantBarStart: 2, wantBPM: 90, wantBeatsPerBar: 3},
+		{name: "fractional bar", bpm: 120, beatsPerBar: 4, bar: 1.5, wantBarStart: 1, wantBPM: 120, wantBeatsPerBar: 4},
+		{name: "before bar 1", bpm: 120, beatsPerBar: 4, bar: 0, wantBarStart: -2, wantBPM: 120, wantBeatsPerBar: 4},
+		{name: "defaults", bar: 2, wantBarStart: 2, wantBPM: 120, wantBeatsPerBar: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Constant(tt.bpm, tt.beatsPerBar)
+			assert.Equal(t, tt.wantBPM, m.BPM())
+			assert.Equal(t, tt.wantBeatsPerBar, m.BeatsPerBar())
+			assert.False(t, m.HasChanges())
+			assert.InDelta(t, tt.wantBarStart, m.BarToSeconds(tt.bar), 1e-9)
+			assert.InDelta(t, tt.bar, m.SecondsToBar(tt.wantBarStart), 1e-9)
+		})
+	}
+}
+
+func TestTempoMap_Changes(t *testing.T) {
+	// Bars 1-4 at 120 BPM in 4/4 (2s each), bars 5-6 at 60 BPM (4s each), then 3/4 at 60 BPM (3s)
+	m := New(120, 4, []TempoChange{
+		{Bar: 7, BeatsPerBar: 3},
+		{Bar: 5, BPM: 60},
+	})
+	assert.True(t, m.HasChanges())
+
+	tests := []struct {
+		bar     float64
+		seconds float64
+		beat    float64
+	}{
+		{bar: 1, seconds: 0, beat: 0},
+		{bar: 3, seconds: 4, beat: 8},
+		{bar: 5, seconds: 8, beat: 16},
+		{bar: 6, seconds: 12, beat: 20},
+		{bar: 6.5, seconds: 14, beat: 22},
+		{bar: 7, seconds: 16, beat: 24},
+		{bar: 9, seconds: 22, beat: 30},
+	}
+	for _, tt := range tests {
+		assert.InDelta(t, tt.seconds, m.BarToSeconds(tt.bar), 1e-9, "BarToSeconds(%g)", tt.bar)
+		assert.InDelta(t, tt.bar, m.SecondsToBar(tt.seconds), 1e-9, "SecondsToBar(%g)", tt.seconds)
+		assert.InDelta(t, tt.seconds, m.BeatToSeconds(tt.beat), 1e-9, "BeatToSeconds(%g)", tt.beat)
+		assert.InDelta(t, tt.beat, m.SecondsToBeat(tt.seconds), 1e-9, "SecondsToBeat(%g)", tt.seconds)
+	}
+
+	bpm, beatsPerBar := m.At(10)
+	assert.Equal(t, 60.0, bpm)
+	assert.Equal(t, 4.0, beatsPerBar)
+	bpm, beatsPerBar = m.At(20)
+	assert.Equal(t, 60.0, bpm)
+	assert.Equal(t, 3.0, beatsPerBar)
+
+	// Lengths without a position are measured from the start; AddBars follows the changes
+	assert.InDelta(t, 8.0, m.BarsToSeconds(4), 1e-9)
+	assert.InDelta(t, 12.0, m.BarsToSeconds(5), 1e-9)
+	assert.InDelta(t, 12.0, m.AddBars(6, 2), 1e-9)
+	assert.InDelta(t, 7.0, m.AddBars(14, -2), 1e-9)
+}
+
+func TestNew_ChangesAtTheStart(t *testing.T) {
+	m := New(120, 4, []TempoChange{{Bar: 1, BPM: 100}, {Bar: 1, BPM: 150, BeatsPerBar: 3}})
+	assert.False(t, m.HasChanges())
+	assert.Equal(t, 150.0, m.BPM())
+	assert.Equal(t, 3.0, m.BeatsPerBar())
+}
+
+func TestFromState(t *testing.T) {
+	tests := []struct {
+		name            string
+		state           map[string]any
+		wantBPM         float64
+		wantBeatsPerBar float64
+		wantBeatUnit    int
+		wantBar5        float64
+		wantSignature   string // TimeSignature, "" without one in the state
+	}{
+		{
+			name:            "missing project uses defaults",
+			state:           map[string]any{},
+			wantBPM:         120,
+			wantBeatsPerBar: 4,
+			wantBeatUnit:    4,
+			wantBar5:        8,
+		},
+		{
+			name:            "nil state",
+			wantBPM:         120,
+			wantBeatsPerBar: 4,
+			wantBeatUnit:    4,
+			wantBar5:        8,
+		},
+		{
+			name:            "bpm and time signature",
+			state:           map[string]any{"project": map[string]any{"bpm": 90.0, "time_signature": "6/8"}},
+			wantBPM:         90,
+			wantBeatsPerBar: 6,
+			wantBeatUnit:    8,
+			wantBar5:        16,
+			wantSignature:   "6/8",
+		},
+		{
+			name: "nested state with tempo and beats_per_bar",
+			state: map[string]any{"state": map[string]any{
+				"project": map[string]any{"tempo": 140, "beats_per_bar": 7},
+			}},
+			wantBPM:         140,
+			wantBeatsPerBar: 7,
+			wantBeatUnit:    4,
+			wantBar5:        4 * 7 * 60 / 140.0,
+			wantSignature:   "7/4",
+		},
+		{
+			name: "invalid values fall back",
+			state: map[string]any{"project": map[string]any{
+				"bpm": -5.0, "time_signature": "x/y", "tempo_changes": "fast",
+			}},
+			wantBPM:         120,
+			wantBeatsPerBar: 4,
+			wantBeatUnit:    4,
+			wantBar5:        8,
+		},
+		{
+			name: "tempo changes",
+			state: map[string]any{"project": map[string]any{
+				"bpm": 120.0,
+				"tempo_changes": []any{
+					map[string]any{"bar": 3, "bpm": 60},
+					map[string]any{"bar": 4, "time_signature": "2/4"},
+					map[string]any{"bpm": 200}, // No bar
+					map[string]any{"bar": 2},   // No change
+					"every other bar",          // Not a change
+				},
+			}},
+			wantBPM:         120,
+			wantBeatsPerBar: 4,
+			wantBeatUnit:    4,
+			// Bars 1-2 at 2s, bar 3 at 4s, bar 4 in 2/4 at 60 BPM is 2s
+			wantBar5: 10,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := FromState(tt.state)
+			assert.Equal(t, tt.wantBPM, m.BPM())
+			assert.Equal(t, tt.wantBeatsPerBar, m.BeatsPerBar())
+			assert.Equal(t, tt.wantBeatUnit, m.BeatUnit())
+			assert.InDelta(t, tt.wantBar5, m.BarToSeconds(5), 1e-9)
+			assert.Equal(t, tt.wantSignature, TimeSignature(tt.state))
+		})
+	}
+}
+
+func TestBeatConversions(t *testing.T) {
+	assert.InDelta(t, 0.04, SecondsToBeats(0.02, 120), 1e-9)
+	assert.InDelta(t, 1.5, BeatsToSeconds(3, 120), 1e-9)
+}
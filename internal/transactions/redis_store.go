@@ -0,0 +1,59 @@
+package transactions
+
+import (
+	"context"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+)
+
+const redisKeyPrefix = "magda:transaction:"
+
+// RedisStore keeps each transaction as one JSON value so any instance can take its result.
+// Updates only land on the transaction as they loaded it, so a result is taken once even
+// when two are reported at the same time.
+type RedisStore struct {
+	transactions *redis.JSONStore[Transaction]
+}
+
+// NewRedisStore creates a store for a redis://[user:password@]host:port[/db] URL.
+// A non-positive ttl uses DefaultTTL. The connection is opened on first use.
+func NewRedisStore(redisURL string, ttl time.Duration) (*RedisStore, error) {
+	client, err := redis.NewClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &RedisStore{transactions: redis.NewJSONStore[Transaction](client, redisKeyPrefix, "transaction", ttl)}, nil
+}
+
+// Create stores the transaction with the store's TTL
+func (s *RedisStore) Create(ctx context.Context, transaction Transaction) error {
+	return s.transactions.Set(ctx, transaction.ID, &transaction)
+}
+
+// Get loads the transaction
+func (s *RedisStore) Get(ctx context.Context, id string) (*Transaction, error) {
+	return s.transactions.Get(ctx, id)
+}
+
+// Update loads the transaction, applies update and stores it, refreshing the TTL. update runs
+// again if the transaction changed in the meantime, and ErrReported is returned if it was
+// reported by then.
+func (s *RedisStore) Update(ctx context.Context, id string, update func(*Transaction)) (*Transaction, error) {
+	reported := false
+	transaction, err := s.transactions.Update(ctx, id, func(transaction *Transaction) bool {
+		if reported = transaction.Status.Reported(); reported {
+			return false
+		}
+		update(transaction)
+		transaction.Updated = time.Now()
+		return true
+	})
+	if err == nil && reported {
+		return transaction, ErrReported
+	}
+	return transaction, err
+}
@@ -0,0 +1,66 @@
+package transactions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedisStore(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	transaction, err := store.Get(ctx, "t1")
+	require.NoError(t, err)
+	assert.Nil(t, transaction)
+
+	created := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Create(ctx, Transaction{
+		ID: "t1", SessionID: "s1", StateVersion: 2, Status: StatusPending, Created: created, Updated: created,
+		Actions:  []map[string]any{{"action": "create_track", "index": 0}},
+		Rollback: []map[string]any{{"action": "delete_track", "track": 0}},
+	}))
+	assert.Equal(t, 3600, server.Expiry("magda:transaction:t1"))
+
+	transaction, err = store.Update(ctx, "t1", func(tx *Transaction) { tx.Status = StatusCommitted })
+	require.NoError(t, err)
+	assert.Equal(t, StatusCommitted, transaction.Status)
+
+	transaction, err = store.Update(ctx, "t1", func(tx *Transaction) { tx.Status = StatusRolledBack })
+	assert.ErrorIs(t, err, ErrReported)
+	assert.Equal(t, StatusCommitted, transaction.Status, "reported transactions don't change")
+
+	transaction, err = store.Get(ctx, "t1")
+	require.NoError(t, err)
+	require.NotNil(t, transaction)
+	assert.Equal(t, StatusCommitted, transaction.Status)
+	assert.Equal(t, 2, transaction.StateVersion)
+	assert.Equal(t, "delete_track", transaction.Rollback[0]["action"])
+	assert.True(t, created.Equal(transaction.Created))
+}
+
+func TestRedisStore_ConcurrentReports(t *testing.T) {
+	server := redistest.Start(t)
+	store, err := NewRedisStore(server.URL, time.Hour)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, store.Create(ctx, Transaction{ID: "t1", Status: StatusPending}))
+	pending, _ := server.Value("magda:transaction:t1")
+
+	// Another instance takes a result between this one's load and write
+	transaction, err := store.Update(ctx, "t1", func(tx *Transaction) {
+		if stored, _ := server.Value("magda:transaction:t1"); stored == pending {
+			server.SetValue("magda:transaction:t1", `{"transaction_id":"t1","status":"rolled_back"}`)
+		}
+		tx.Status = StatusCommitted
+	})
+	assert.ErrorIs(t, err, ErrReported)
+	assert.Equal(t, StatusRolledBack, transaction.Status)
+}
@@ -0,0 +1,191 @@
+// Package transactions tracks the action batches the API returns until the extension reports
+// how applying them went. Each response's actions form a transaction with the undo actions
+// that roll them back, so the extension can apply all or nothing.
+package transactions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long a transaction is kept after its last update
+const DefaultTTL = 24 * time.Hour
+
+// ErrReported is returned by Update, with the transaction, when its result was already reported
+var ErrReported = errors.New("a result was already reported")
+
+// Status is how applying a transaction went
+type Status string
+
+const (
+	StatusPending    Status = "pending"     // Returned to the client, no result reported yet
+	StatusCommitted  Status = "committed"   // Every action was applied
+	StatusRolledBack Status = "rolled_back" // An action failed and the applied ones were undone
+	StatusPartial    Status = "partial"     // Some actions were applied and kept
+)
+
+// Reported reports whether the transaction's result has been reported; it won't change again
+func (s Status) Reported() bool {
+	return s == StatusCommitted || s == StatusRolledBack || s == StatusPartial
+}
+
+// Failure is an action the extension couldn't apply
+type Failure struct {
+	ActionIndex int    `json:"actionIndex"`
+	Error       string `json:"error"`
+}
+
+// Transaction is one response's actions, the actions that roll them back and, once reported,
+// the result of applying them
+type Transaction struct {
+	ID           string           `json:"transaction_id"`
+	SessionID    string           `json:"session_id,omitempty"`
+	StateVersion int              `json:"state_version,omitempty"` // Session state version the actions were generated against
+	Actions      []map[string]any `json:"actions"`
+	Rollback     []map[string]any `json:"rollback"`
+	Status       Status           `json:"status"`
+	Applied      []int            `json:"applied,omitempty"` // Indices of the actions kept, for a partial result
+	Failures     []Failure        `json:"failures,omitempty"`
+	Created      time.Time        `json:"created"`
+	Updated      time.Time        `json:"updated"`
+}
+
+// Envelope is the part of a transaction returned with the actions
+type Envelope struct {
+	ID       string           `json:"transaction_id"`
+	Actions  []map[string]any `json:"actions"`
+	Rollback []map[string]any `json:"rollback"`
+}
+
+// Envelope returns the transaction as returned to the client
+func (t *Transaction) Envelope() *Envelope {
+	return &Envelope{ID: t.ID, Actions: t.Actions, Rollback: t.Rollback}
+}
+
+// AppliedActions returns the actions the reported result left applied, in order
+func (t *Transaction) AppliedActions() []map[string]any {
+	switch t.Status {
+	case StatusCommitted:
+		return t.Actions
+	case StatusPartial:
+		applied := make([]map[string]any, 0, len(t.Applied))
+		for _, index := range t.Applied {
+			applied = append(applied, t.Actions[index])
+		}
+		return applied
+	default:
+		return nil
+	}
+}
+
+// Store keeps transactions keyed by ID
+type Store interface {
+	// Create stores a new transaction
+	Create(ctx context.Context, transaction Transaction) error
+	// Get returns a transaction, or nil if it is unknown or expired
+	Get(ctx context.Context, id string) (*Transaction, error)
+	// Update applies update to a pending transaction and returns the result, or nil if the
+	// transaction is unknown. Reported transactions are returned unchanged with ErrReported.
+	// update may run more than once, so it should only change the transaction.
+	Update(ctx context.Context, id string, update func(*Transaction)) (*Transaction, error)
+}
+
+// NewStore creates the store for backend: "memory" (default) or "redis"
+func NewStore(backend, redisURL string, ttl time.Duration) (Store, error) {
+	switch backend {
+	case "", "memory":
+		return NewMemoryStore(ttl), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis transaction store")
+		}
+		return NewRedisStore(redisURL, ttl)
+	default:
+		return nil, fmt.Errorf("unknown transaction store %q: must be \"memory\" or \"redis\"", backend)
+	}
+}
+
+// MemoryStore keeps transactions in process memory. They are lost on restart and are only
+// visible to the instance that created them; use RedisStore behind a load balancer.
+type MemoryStore struct {
+	ttl time.Duration
+
+	mu           sync.Mutex
+	transactions map[string]*Transaction
+	now          func() time.Time
+}
+
+// NewMemoryStore creates an in-memory store. A non-positive ttl uses DefaultTTL.
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &MemoryStore{
+		ttl:          ttl,
+		transactions: make(map[string]*Transaction),
+		now:          time.Now,
+	}
+}
+
+// Create stores a transaction and evicts expired ones
+func (s *MemoryStore) Create(ctx context.Context, transaction Transaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for id, stored := range s.transactions {
+		if now.Sub(stored.Updated) > s.ttl {
+			delete(s.transactions, id)
+		}
+	}
+	s.transactions[transaction.ID] = &transaction
+	return nil
+}
+
+// Get returns a copy of the transaction
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction := s.transaction(id)
+	if transaction == nil {
+		return nil, nil
+	}
+	copied := *transaction
+	return &copied, nil
+}
+
+// Update applies update under the store's lock
+func (s *MemoryStore) Update(ctx context.Context, id string, update func(*Transaction)) (*Transaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	transaction := s.transaction(id)
+	if transaction == nil {
+		return nil, nil
+	}
+	if transaction.Status.Reported() {
+		copied := *transaction
+		return &copied, ErrReported
+	}
+	update(transaction)
+	transaction.Updated = s.now()
+	copied := *transaction
+	return &copied, nil
+}
+
+// transaction returns the stored transaction, dropping it if expired. s.mu must be held.
+func (s *MemoryStore) transaction(id string) *Transaction {
+	transaction, ok := s.transactions[id]
+	if !ok {
+		return nil
+	}
+	if s.now().Sub(transaction.Updated) > s.ttl {
+		delete(s.transactions, id)
+		return nil
+	}
+	return transaction
+}
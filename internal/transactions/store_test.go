@@ -0,0 +1,81 @@
+package transactions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Update(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(time.Hour)
+	require.NoError(t, store.Create(ctx, Transaction{ID: "t1", Status: StatusPending, Updated: time.Now()}))
+
+	transaction, err := store.Update(ctx, "t1", func(tx *Transaction) {
+		tx.Status = StatusRolledBack
+		tx.Failures = []Failure{{ActionIndex: 1, Error: "track not found"}}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StatusRolledBack, transaction.Status)
+
+	// A result is taken once
+	called := false
+	transaction, err = store.Update(ctx, "t1", func(tx *Transaction) {
+		called = true
+		tx.Status = StatusCommitted
+	})
+	assert.ErrorIs(t, err, ErrReported)
+	assert.False(t, called)
+	assert.Equal(t, StatusRolledBack, transaction.Status)
+	assert.Equal(t, []Failure{{ActionIndex: 1, Error: "track not found"}}, transaction.Failures)
+
+	transaction, err = store.Update(ctx, "unknown", func(tx *Transaction) {})
+	require.NoError(t, err)
+	assert.Nil(t, transaction)
+}
+
+func TestMemoryStore_ExpiresTransactions(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryStore(time.Hour)
+	store.now = func() time.Time { return now }
+	require.NoError(t, store.Create(ctx, Transaction{ID: "t1", Status: StatusPending, Updated: now}))
+
+	now = now.Add(30 * time.Minute)
+	transaction, err := store.Get(ctx, "t1")
+	require.NoError(t, err)
+	require.NotNil(t, transaction)
+
+	now = now.Add(time.Hour)
+	transaction, err = store.Get(ctx, "t1")
+	require.NoError(t, err)
+	assert.Nil(t, transaction)
+}
+
+func TestTransaction_AppliedActions(t *testing.T) {
+	actions := []map[string]any{
+		{"action": "create_track", "index": 0},
+		{"action": "set_track", "track": 0, "name": "Bass"},
+		{"action": "add_track_fx", "track": 0, "fxname": "ReaComp"},
+	}
+	tests := []struct {
+		status  Status
+		applied []int
+		want    []map[string]any
+	}{
+		{StatusPending, nil, nil},
+		{StatusCommitted, nil, actions},
+		{StatusRolledBack, nil, nil},
+		{StatusPartial, []int{0, 2}, []map[string]any{actions[0], actions[2]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.status), func(t *testing.T) {
+			transaction := Transaction{Actions: actions, Status: tt.status, Applied: tt.applied}
+			assert.Equal(t, tt.want, transaction.AppliedActions())
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Conceptual-Machines/magda-api/internal/redis"
+)
+
+const (
+	redisKeyPrefix = "magda:usage:"
+	dayLayout      = "2006-01-02"
+	// redisRetention is how long the redis store keeps a day's totals
+	redisRetention = 400 * 24 * time.Hour
+	// MaxQueryDays is the longest date range Totals accepts
+	MaxQueryDays = 366
+)
+
+// Totals is the token usage and cost of a number of requests
+type Totals struct {
+	Requests        int64   `json:"requests"`
+	InputTokens     int64   `json:"input_tokens"`
+	OutputTokens    int64   `json:"output_tokens"`
+	ReasoningTokens int64   `json:"reasoning_tokens"`
+	TotalTokens     int64   `json:"total_tokens"`
+	CostUSD         float64 `json:"cost_usd"`
+}
+
+// Add adds other's usage to t
+func (t *Totals) Add(other Totals) {
+	t.Requests += other.Requests
+	t.InputTokens += other.InputTokens
+	t.OutputTokens += other.OutputTokens
+	t.ReasoningTokens += other.ReasoningTokens
+	t.TotalTokens += other.TotalTokens
+	t.CostUSD += other.CostUSD
+}
+
+// DailyTotals is one caller's usage on one UTC day
+type DailyTotals struct {
+	Key string `json:"key"`
+	Day string `json:"day"` // YYYY-MM-DD
+	Totals
+}
+
+// Store aggregates usage per caller key and UTC day
+type Store interface {
+	// Record adds one request's usage to key's totals for the day of at
+	Record(ctx context.Context, key string, at time.Time, usage Totals) error
+	// Totals returns the daily totals from one day to another (inclusive), ordered by day
+	// then key. An empty key returns every caller.
+	Totals(ctx context.Context, key string, from, to time.Time) ([]DailyTotals, error)
+}
+
+// NewStore creates the usage store for backend: "memory" or "redis".
+// "off" (or empty) disables usage accounting and returns a nil store.
+func NewStore(backend, redisURL string) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", "off":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(), nil
+	case "redis":
+		if redisURL == "" {
+			return nil, fmt.Errorf("REDIS_URL is required for the redis usage store")
+		}
+		return NewRedisStore(redisURL)
+	default:
+		return nil, fmt.Errorf("unknown usage store %q: must be \"off\", \"memory\" or \"redis\"", backend)
+	}
+}
+
+// Day formats the UTC day of t as stored
+func Day(t time.Time) string {
+	return t.UTC().Format(dayLayout)
+}
+
+// ParseDay parses a YYYY-MM-DD day
+func ParseDay(day string) (time.Time, error) {
+	t, err := time.Parse(dayLayout, day)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid day %q: must be YYYY-MM-DD", day)
+	}
+	return t, nil
+}
+
+// CheckRange reports whether Totals accepts the days from one day to another
+func CheckRange(from, to time.Time) error {
+	from, to = from.UTC().Truncate(24*time.Hour), to.UTC().Truncate(24*time.Hour)
+	if to.Before(from) {
+		return fmt.Errorf("usage range ends (%s) before it starts (%s)", Day(to), Day(from))
+	}
+	if n := int(to.Sub(from)/(24*time.Hour)) + 1; n > MaxQueryDays {
+		return fmt.Errorf("usage range is %d days, more than the maximum of %d", n, MaxQueryDays)
+	}
+	return nil
+}
+
+// days lists the UTC days from one day to another, inclusive
+func days(from, to time.Time) ([]string, error) {
+	if err := CheckRange(from, to); err != nil {
+		return nil, err
+	}
+	from, to = from.UTC().Truncate(24*time.Hour), to.UTC().Truncate(24*time.Hour)
+	var result []string
+	for day := from; !day.After(to); day = day.Add(24 * time.Hour) {
+		result = append(result, Day(day))
+	}
+	return result, nil
+}
+
+// MemoryStore keeps totals in process memory; they are per instance and lost on restart
+type MemoryStore struct {
+	mu     sync.Mutex
+	totals map[string]map[string]*Totals // day -> key -> totals
+}
+
+// NewMemoryStore creates an in-memory usage store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{totals: make(map[string]map[string]*Totals)}
+}
+
+// Record adds usage to key's totals for the day
+func (m *MemoryStore) Record(ctx context.Context, key string, at time.Time, usage Totals) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	day := Day(at)
+	if m.totals[day] == nil {
+		m.totals[day] = make(map[string]*Totals)
+	}
+	if m.totals[day][key] == nil {
+		m.totals[day][key] = &Totals{}
+	}
+	m.totals[day][key].Add(usage)
+	return nil
+}
+
+// Totals returns the daily totals in the range
+func (m *MemoryStore) Totals(ctx context.Context, key string, from, to time.Time) ([]DailyTotals, error) {
+	dayList, err := days(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []DailyTotals
+	for _, day := range dayList {
+		keys := make([]string, 0, len(m.totals[day]))
+		for k := range m.totals[day] {
+			if key == "" || k == key {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result = append(result, DailyTotals{Key: k, Day: day, Totals: *m.totals[day][k]})
+		}
+	}
+	return result, nil
+}
+
+// RedisStore keeps totals in Redis hashes so they are shared between instances.
+// magda:usage:<day>:<key> holds a caller's counters; magda:usage:<day> is the set of callers.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a store for a redis://[user:password@]host:port[/db] URL.
+// The connection is opened on first use.
+func NewRedisStore(redisURL string) (*RedisStore, error) {
+	client, err := redis.NewClient(redisURL)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Record increments key's counters for the day
+func (r *RedisStore) Record(ctx context.Context, key string, at time.Time, usage Totals) error {
+	day := Day(at)
+	hash := redisKeyPrefix + day + ":" + key
+	ttl := strconv.FormatInt(int64(redisRetention/time.Second), 10)
+
+	commands := [][]string{
+		{"HINCRBY", hash, "requests", strconv.FormatInt(usage.Requests, 10)},
+		{"HINCRBY", hash, "input_tokens", strconv.FormatInt(usage.InputTokens, 10)},
+		{"HINCRBY", hash, "output_tokens", strconv.FormatInt(usage.OutputTokens, 10)},
+		{"HINCRBY", hash, "reasoning_tokens", strconv.FormatInt(usage.ReasoningTokens, 10)},
+		{"HINCRBY", hash, "total_tokens", strconv.FormatInt(usage.TotalTokens, 10)},
+		{"HINCRBYFLOAT", hash, "cost_usd", strconv.FormatFloat(usage.CostUSD, 'f', -1, 64)},
+		{"EXPIRE", hash, ttl},
+		{"SADD", redisKeyPrefix + day, key},
+		{"EXPIRE", redisKeyPrefix + day, ttl},
+	}
+	for _, command := range commands {
+		if _, err := r.client.Do(ctx, command...); err != nil {
+			return fmt.Errorf("usage record failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// Totals reads the counters of each day in the range
+func (r *RedisStore) Totals(ctx context.Context, key string, from, to time.Time) ([]DailyTotals, error) {
+	dayList, err := days(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []DailyTotals
+	for _, day := range dayList {
+		keys := []string{key}
+		if key == "" {
+			if keys, err = r.callers(ctx, day); err != nil {
+				return nil, err
+			}
+		}
+		for _, k := range keys {
+			reply, err := r.client.Do(ctx, "HGETALL", redisKeyPrefix+day+":"+k)
+			if err != nil {
+				return nil, fmt.Errorf("usage query failed: %w", err)
+			}
+			fields, _ := reply.([]any)
+			if len(fields) == 0 {
+				continue
+			}
+			totals, err := parseTotals(fields)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, DailyTotals{Key: k, Day: day, Totals: totals})
+		}
+	}
+	return result, nil
+}
+
+// callers lists the keys with usage on day, sorted
+func (r *RedisStore) callers(ctx context.Context, day string) ([]string, error) {
+	reply, err := r.client.Do(ctx, "SMEMBERS", redisKeyPrefix+day)
+	if err != nil {
+		return nil, fmt.Errorf("usage query failed: %w", err)
+	}
+	members, _ := reply.([]any)
+	keys := make([]string, 0, len(members))
+	for _, member := range members {
+		if k, ok := member.(string); ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// parseTotals reads an HGETALL reply of field, value pairs
+func parseTotals(fields []any) (Totals, error) {
+	var totals Totals
+	for i := 0; i+1 < len(fields); i += 2 {
+		name, _ := fields[i].(string)
+		value, _ := fields[i+1].(string)
+		if name == "cost_usd" {
+			cost, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return Totals{}, fmt.Errorf("usage query failed: invalid cost %q", value)
+			}
+			totals.CostUSD = cost
+			continue
+		}
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return Totals{}, fmt.Errorf("usage query failed: invalid %s %q", name, value)
+		}
+		switch name {
+		case "requests":
+			totals.Requests = n
+		case "input_tokens":
+			totals.InputTokens = n
+		case "output_tokens":
+			totals.OutputTokens = n
+		case "reasoning_tokens":
+			totals.ReasoningTokens = n
+		case "total_tokens":
+			totals.TotalTokens = n
+		}
+	}
+	return totals, nil
+}
@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Totals(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	monday := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	tuesday := monday.Add(24 * time.Hour)
+
+	request := Totals{Requests: 1, InputTokens: 1000, OutputTokens: 200, TotalTokens: 1200, CostUSD: 0.0016}
+	require.NoError(t, store.Record(ctx, "key:a", monday, request))
+	require.NoError(t, store.Record(ctx, "key:a", monday.Add(time.Hour), request))
+	require.NoError(t, store.Record(ctx, "user:7", monday, request))
+	require.NoError(t, store.Record(ctx, "key:a", tuesday, request))
+
+	days, err := store.Totals(ctx, "key:a", monday, tuesday)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, "2026-03-02", days[0].Day)
+	assert.Equal(t, int64(2), days[0].Requests)
+	assert.Equal(t, int64(2400), days[0].TotalTokens)
+	assert.InDelta(t, 0.0032, days[0].CostUSD, 1e-12)
+	assert.Equal(t, "2026-03-03", days[1].Day)
+	assert.Equal(t, int64(1), days[1].Requests)
+
+	// Without a key every caller is listed, by day then key
+	days, err = store.Totals(ctx, "", monday, monday)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, "key:a", days[0].Key)
+	assert.Equal(t, "user:7", days[1].Key)
+
+	days, err = store.Totals(ctx, "key:b", monday, tuesday)
+	require.NoError(t, err)
+	assert.Empty(t, days)
+}
+
+func TestCheckRange(t *testing.T) {
+	day := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, CheckRange(day, day))
+	assert.NoError(t, CheckRange(day, day.AddDate(0, 0, MaxQueryDays-1)))
+	assert.Error(t, CheckRange(day, day.AddDate(0, 0, MaxQueryDays)))
+	assert.Error(t, CheckRange(day, day.AddDate(0, 0, -1)))
+}
+
+func TestParseTotals(t *testing.T) {
+	totals, err := parseTotals([]any{
+		"requests", "3", "input_tokens", "1500", "output_tokens", "300",
+		"reasoning_tokens", "100", "total_tokens", "1800", "cost_usd", "0.0042",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Totals{
+		Requests: 3, InputTokens: 1500, OutputTokens: 300, ReasoningTokens: 100, TotalTokens: 1800, CostUSD: 0.0042,
+	}, totals)
+
+	_, err = parseTotals([]any{"requests", "many"})
+	assert.Error(t, err)
+}
+
+func TestNewStore(t *testing.T) {
+	store, err := NewStore("off", "")
+	require.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = NewStore("memory", "")
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	store, err = NewStore("redis", "redis://localhost:6379")
+	require.NoError(t, err)
+	assert.IsType(t, &RedisStore{}, store)
+
+	_, err = NewStore("redis", "")
+	assert.Error(t, err)
+	_, err = NewStore("postgres", "")
+	assert.Error(t, err)
+}
@@ -2,12 +2,19 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"time"
 
+	magdaconfig "github.com/Conceptual-Machines/magda-api/internal/agents/core/config"
+	"github.com/Conceptual-Machines/magda-api/internal/agents/reaper/daw"
+	arranger "github.com/Conceptual-Machines/magda-api/internal/agents/shared/arranger"
+	"github.com/Conceptual-Machines/magda-api/internal/agents/shared/drummer"
 	"github.com/Conceptual-Machines/magda-api/internal/api"
 	"github.com/Conceptual-Machines/magda-api/internal/config"
+	"github.com/Conceptual-Machines/magda-api/internal/llm"
+	"github.com/Conceptual-Machines/magda-api/internal/logger"
 	"github.com/Conceptual-Machines/magda-api/internal/observability"
 	"github.com/getsentry/sentry-go"
 	"github.com/gin-gonic/gin"
@@ -35,6 +42,7 @@ func main() {
 
 	// Load configuration
 	cfg := config.Load()
+	logger.Init(cfg.LogFormat, cfg.LogLevel)
 
 	// Initialize Sentry (optional)
 	if cfg.SentryDSN != "" {
@@ -73,6 +81,27 @@ func main() {
 	}
 	observability.InitializeLangfuse(context.Background(), cfg)
 
+	// Fail fast if a DSL grammar is malformed instead of failing every request that uses it
+	if err := verifyGrammars(); err != nil {
+		sentry.CaptureException(err)
+		log.Fatalf("❌ Grammar check failed: %v", err)
+	}
+	log.Println("✅ DSL grammars verified")
+
+	// Fail fast without a usable LLM provider instead of failing every request
+	llmCfg := &magdaconfig.Config{
+		OpenAIAPIKey:     cfg.OpenAIAPIKey,
+		AnthropicAPIKey:  cfg.AnthropicAPIKey,
+		AnthropicModel:   cfg.AnthropicModel,
+		LLMProvider:      cfg.LLMProvider,
+		LLMFallback:      cfg.LLMFallback,
+		LLMMockResponses: cfg.LLMMockResponses,
+	}
+	if err := llmCfg.CheckProvider(); err != nil {
+		sentry.CaptureException(err)
+		log.Fatalf("❌ LLM provider check failed: %v", err)
+	}
+
 	// Log auth mode
 	log.Printf("🔐 Auth mode: %s", cfg.AuthMode)
 
@@ -97,6 +126,26 @@ func main() {
 	}
 }
 
+// verifyGrammars builds each DSL parser once so a malformed grammar stops startup.
+// The checks are purely local (no provider or network calls), so transient issues
+// elsewhere never block startup here.
+func verifyGrammars() error {
+	if _, err := daw.NewFunctionalDSLParser(); err != nil {
+		return fmt.Errorf("DAW parser: %w", err)
+	}
+	if _, err := arranger.NewArrangerDSLParser(); err != nil {
+		return fmt.Errorf("arranger parser: %w", err)
+	}
+	if _, err := drummer.NewDrummerDSLParser(); err != nil {
+		return fmt.Errorf("drummer parser: %w", err)
+	}
+	// JSFX output is validated by the provider only, so check its grammar directly
+	if err := llm.ValidateGrammar("JSFX", llm.GetJSFXGrammar()); err != nil {
+		return fmt.Errorf("JSFX grammar: %w", err)
+	}
+	return nil
+}
+
 func filterSensitiveHeaders(headers map[string]string) map[string]string {
 	filtered := make(map[string]string)
 	sensitiveKeys := map[string]bool{
@@ -27,6 +27,9 @@ var KeyEmotionalQualitiesCsv []byte
 //go:embed data/core_data/progressions.json
 var ProgressionsJSON []byte
 
+//go:embed data/core_data/track_templates.json
+var TrackTemplatesJSON []byte
+
 //go:embed data/core_data/advanced_harmonic_theory.txt
 var AdvancedHarmonicTheoryTxt []byte
 
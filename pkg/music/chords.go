@@ -0,0 +1,312 @@
+package music
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxChordSymbolLength bounds the symbols ParseChord accepts; real symbols are far shorter
+const maxChordSymbolLength = 32
+
+// Chord is a parsed chord symbol: root, optional slash bass, and the chord tones as
+// semitones above the root (sorted, without the bass)
+type Chord struct {
+	Root      string `json:"root"`
+	Bass      string `json:"bass,omitempty"`
+	Intervals []int  `json:"intervals"`
+}
+
+// chordModifiers are the tokens allowed after the chord's quality and extension, longest first
+// so "#11" is not read as "#1" and "sus4" not as "sus"
+var chordModifiers = []string{
+	"add13", "add11", "add9", "add6", "add4", "add2",
+	"sus2", "sus4", "sus",
+	"#11", "b13", "b5", "#5", "b9", "#9",
+	"alt", "no3", "no5",
+}
+
+// ParseChord parses a jazz chord symbol:
+//
+//	root [quality] [maj] [extension] [modifiers...] [/bass]
+//
+// Qualities: m/min/-, dim/o/°, ø (half-diminished), aug/+, mMaj/mM (minor-major), 5 (power chord).
+// maj/M/Δ makes the seventh major. Extensions: 6, 69 (or 6/9), 7, 9, 11, 13; 9, 11, and 13 include
+// the seventh and the lower tensions (dominant/major 11 drop the 3rd, 13 skips the 11th unless minor).
+// Modifiers may be parenthesised or comma-separated: sus2, sus4, sus, add2/4/6/9/11/13, b5, #5,
+// b9, #9, #11, b13, alt (b9, #9, b13, no 5th), no3, no5.
+// Unknown text is an error rather than dropped, so a mistyped tension is never silently lost.
+// Example: C13b9 → C E G Bb Db A, G7#5 → G B D# F, Bb/D → D under Bb D F
+func ParseChord(symbol string) (Chord, error) {
+	symbol = strings.TrimSpace(symbol)
+	if len(symbol) > maxChordSymbolLength {
+		return Chord{}, fmt.Errorf("chord symbol too long: %q", symbol)
+	}
+
+	root, err := ParseRootNote(symbol)
+	if err != nil {
+		return Chord{}, err
+	}
+	rest := symbol[len(root):]
+
+	// Slash bass: Bb/D. "6/9" is an extension, not a bass note.
+	var spec Chord
+	spec.Root = root
+	if idx := strings.LastIndex(rest, "/"); idx != -1 && !(rest[idx+1:] == "9" && strings.HasSuffix(rest[:idx], "6")) {
+		bass, err := ParseRootNote(rest[idx+1:])
+		if err != nil || len(bass) != len(rest[idx+1:]) {
+			return Chord{}, fmt.Errorf("invalid bass note in chord %q", symbol)
+		}
+		spec.Bass = bass
+		rest = rest[:idx]
+	}
+	rest = strings.Replace(rest, "6/9", "69", 1)
+
+	third, fifth := Major3rd, Perfect5th
+	seventh := 0 // 0 = no seventh
+	majorSeventh := false
+	diminished := false
+	power := false
+
+	// Quality
+	switch {
+	case hasAnyPrefix(&rest, "mMaj", "mmaj", "mM", "minMaj", "m(maj"):
+		third, majorSeventh = Minor3rd, true
+	case strings.HasPrefix(rest, "maj"), strings.HasPrefix(rest, "M"), strings.HasPrefix(rest, "Δ"):
+		// Major seventh marker, read below
+	case hasAnyPrefix(&rest, "min", "m", "-"):
+		third = Minor3rd
+	case hasAnyPrefix(&rest, "dim", "°", "o"):
+		third, fifth, diminished = Minor3rd, Flat5th, true
+	case hasAnyPrefix(&rest, "ø"):
+		third, fifth, seventh = Minor3rd, Flat5th, Minor7th
+		rest = strings.TrimPrefix(rest, "7")
+	case hasAnyPrefix(&rest, "aug", "+"):
+		fifth = Sharp5th
+	case rest == "5":
+		power = true
+		rest = ""
+	}
+
+	// Major seventh marker: Cmaj7, CM9, CΔ7. CΔ alone is a maj7; Cmaj and CM alone are triads.
+	if hasAnyPrefix(&rest, "Δ") {
+		majorSeventh = true
+		if rest == "" {
+			seventh = Major7th
+		}
+	} else if hasAnyPrefix(&rest, "maj", "Maj", "M") {
+		majorSeventh = rest != "" && rest[0] >= '0' && rest[0] <= '9'
+	}
+
+	// Extension
+	tensions := []int{}
+	extension := ""
+	for _, candidate := range []string{"13", "11", "69", "9", "7", "6"} {
+		if hasAnyPrefix(&rest, candidate) {
+			extension = candidate
+			break
+		}
+	}
+	seventhInterval := Minor7th
+	if majorSeventh {
+		seventhInterval = Major7th
+	} else if diminished {
+		seventhInterval = Dim7th
+	}
+	switch extension {
+	case "6":
+		tensions = append(tensions, Major6th)
+	case "69":
+		tensions = append(tensions, Major6th, Ninth)
+	case "7":
+		seventh = seventhInterval
+	case "9":
+		seventh = seventhInterval
+		tensions = append(tensions, Ninth)
+	case "11":
+		seventh = seventhInterval
+		tensions = append(tensions, Ninth, Eleventh)
+		if third == Major3rd {
+			third = 0 // The 11th clashes with a major 3rd
+		}
+	case "13":
+		seventh = seventhInterval
+		tensions = append(tensions, Ninth, Thirteenth)
+		if third == Minor3rd {
+			tensions = append(tensions, Eleventh)
+		}
+	case "":
+		if majorSeventh && seventh == 0 && third == Minor3rd {
+			seventh = Major7th // mMaj alone is a minor-major 7th
+		}
+	}
+
+	// Modifiers
+	for rest != "" {
+		if hasAnyPrefix(&rest, "(", ")", ",", " ") {
+			continue
+		}
+		modifier := ""
+		for _, candidate := range chordModifiers {
+			if hasAnyPrefix(&rest, candidate) {
+				modifier = candidate
+				break
+			}
+		}
+		switch modifier {
+		case "sus2":
+			third = Major2nd
+		case "sus4", "sus":
+			third = Perfect4th
+		case "add2":
+			tensions = append(tensions, Major2nd)
+		case "add4":
+			tensions = append(tensions, Perfect4th)
+		case "add6":
+			tensions = append(tensions, Major6th)
+		case "add9":
+			tensions = append(tensions, Ninth)
+		case "add11":
+			tensions = append(tensions, Eleventh)
+		case "add13":
+			tensions = append(tensions, Thirteenth)
+		case "b5":
+			fifth = Flat5th
+		case "#5":
+			fifth = Sharp5th
+		case "b9":
+			tensions = replaceInterval(tensions, Ninth, Flat9th)
+		case "#9":
+			tensions = replaceInterval(tensions, Ninth, Sharp9th)
+		case "#11":
+			tensions = replaceInterval(tensions, Eleventh, Sharp11th)
+			if third == 0 {
+				third = Major3rd // #11 doesn't clash with the 3rd
+			}
+		case "b13":
+			tensions = replaceInterval(tensions, Thirteenth, Flat13th)
+		case "alt":
+			if seventh == 0 {
+				seventh = Minor7th
+			}
+			fifth = 0
+			tensions = replaceInterval(tensions, Ninth, Flat9th)
+			tensions = append(tensions, Sharp9th, Flat13th)
+		case "no3":
+			third = 0
+		case "no5":
+			fifth = 0
+		default:
+			return Chord{}, fmt.Errorf("unsupported chord symbol %q: cannot read %q", symbol, rest)
+		}
+	}
+
+	intervals := []int{0}
+	if !power && third != 0 {
+		intervals = append(intervals, third)
+	}
+	if fifth != 0 {
+		intervals = append(intervals, fifth)
+	}
+	if seventh != 0 {
+		intervals = append(intervals, seventh)
+	}
+	intervals = append(intervals, tensions...)
+
+	// Sort and drop duplicates (e.g. C7b9 plus an explicit add of the same tone)
+	sort.Ints(intervals)
+	spec.Intervals = intervals[:1]
+	for _, interval := range intervals[1:] {
+		if interval != spec.Intervals[len(spec.Intervals)-1] {
+			spec.Intervals = append(spec.Intervals, interval)
+		}
+	}
+	return spec, nil
+}
+
+// hasAnyPrefix trims the first matching prefix from s and reports whether one matched
+func hasAnyPrefix(s *string, prefixes ...string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(*s, prefix) {
+			*s = (*s)[len(prefix):]
+			return true
+		}
+	}
+	return false
+}
+
+// replaceInterval replaces natural with altered in intervals, adding altered if natural is absent
+func replaceInterval(intervals []int, natural, altered int) []int {
+	replaced := make([]int, 0, len(intervals)+1)
+	for _, interval := range intervals {
+		if interval != natural {
+			replaced = append(replaced, interval)
+		}
+	}
+	return append(replaced, altered)
+}
+
+// PitchClasses returns the chord's distinct pitch classes, including a slash bass, sorted
+func (c Chord) PitchClasses() []int {
+	rootPC := PitchClass(noteOffsets[c.Root])
+	seen := make(map[int]bool)
+	var pcs []int
+	add := func(pc int) {
+		if !seen[pc] {
+			seen[pc] = true
+			pcs = append(pcs, pc)
+		}
+	}
+	for _, interval := range c.Intervals {
+		add(PitchClass(rootPC + interval))
+	}
+	if c.Bass != "" {
+		add(PitchClass(noteOffsets[c.Bass]))
+	}
+	sort.Ints(pcs)
+	return pcs
+}
+
+// Notes spells the chord tones up from the root, each on the letter of its degree, after the
+// slash bass if any: C7 → C E G Bb, Cdim7 → C Eb Gb Bbb, Bb/D → D Bb D F
+func (c Chord) Notes() []string {
+	rootLetter := strings.IndexByte(noteLetters, c.Root[0])
+	rootPC := noteOffsets[c.Root]
+	diminished7th := containsInterval(c.Intervals, Minor3rd) && containsInterval(c.Intervals, Flat5th) &&
+		!containsInterval(c.Intervals, Minor7th) && !containsInterval(c.Intervals, Major7th)
+
+	var notes []string
+	if c.Bass != "" {
+		notes = append(notes, c.Bass)
+	}
+	for _, interval := range c.Intervals {
+		degree := intervalDegrees[interval%len(intervalDegrees)]
+		if interval == Dim7th && diminished7th {
+			degree = 6
+		}
+		notes = append(notes, spellNote(rootLetter+degree, rootPC+interval))
+	}
+	return notes
+}
+
+// intervalDegrees are the scale steps (0 = root, 1 = 2nd/9th, ...) chord tone intervals up to
+// a 13th are spelled on: a b5 and #5 stay fifths, #9 a ninth, #11 an eleventh
+var intervalDegrees = [22]int{0, 1, 1, 2, 2, 3, 4, 4, 4, 5, 6, 6, 0, 1, 1, 1, 2, 3, 3, 4, 5, 5}
+
+// SpellChord spells a chord symbol's notes; see Chord.Notes
+func SpellChord(symbol string) ([]string, error) {
+	chord, err := ParseChord(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return chord.Notes(), nil
+}
+
+func containsInterval(intervals []int, interval int) bool {
+	for _, i := range intervals {
+		if i == interval {
+			return true
+		}
+	}
+	return false
+}
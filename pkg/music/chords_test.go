@@ -0,0 +1,186 @@
+package music
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseChord(t *testing.T) {
+	tests := []struct {
+		symbol    string
+		root      string
+		bass      string
+		intervals []int
+	}{
+		// Triads
+		{"C", "C", "", []int{0, 4, 7}},
+		{"Cm", "C", "", []int{0, 3, 7}},
+		{"Cmin", "C", "", []int{0, 3, 7}},
+		{"C-", "C", "", []int{0, 3, 7}},
+		{"Cmaj", "C", "", []int{0, 4, 7}},
+		{"CM", "C", "", []int{0, 4, 7}},
+		{"Cdim", "C", "", []int{0, 3, 6}},
+		{"C°", "C", "", []int{0, 3, 6}},
+		{"Caug", "C", "", []int{0, 4, 8}},
+		{"C+", "C", "", []int{0, 4, 8}},
+		{"Csus2", "C", "", []int{0, 2, 7}},
+		{"Fsus2", "F", "", []int{0, 2, 7}},
+		{"Csus4", "C", "", []int{0, 5, 7}},
+		{"Csus", "C", "", []int{0, 5, 7}},
+		{"C5", "C", "", []int{0, 7}},
+
+		// Sixths
+		{"C6", "C", "", []int{0, 4, 7, 9}},
+		{"Cm6", "C", "", []int{0, 3, 7, 9}},
+		{"C69", "C", "", []int{0, 4, 7, 9, 14}},
+		{"C6/9", "C", "", []int{0, 4, 7, 9, 14}},
+
+		// Sevenths
+		{"C7", "C", "", []int{0, 4, 7, 10}},
+		{"Cmaj7", "C", "", []int{0, 4, 7, 11}},
+		{"CM7", "C", "", []int{0, 4, 7, 11}},
+		{"CΔ", "C", "", []int{0, 4, 7, 11}},
+		{"CΔ7", "C", "", []int{0, 4, 7, 11}},
+		{"Cm7", "C", "", []int{0, 3, 7, 10}},
+		{"Cmin7", "C", "", []int{0, 3, 7, 10}},
+		{"C-7", "C", "", []int{0, 3, 7, 10}},
+		{"CmMaj7", "C", "", []int{0, 3, 7, 11}},
+		{"Cm(maj7)", "C", "", []int{0, 3, 7, 11}},
+		{"CmMaj", "C", "", []int{0, 3, 7, 11}},
+		{"Cdim7", "C", "", []int{0, 3, 6, 9}},
+		{"Co7", "C", "", []int{0, 3, 6, 9}},
+		{"Cm7b5", "C", "", []int{0, 3, 6, 10}},
+		{"Cø", "C", "", []int{0, 3, 6, 10}},
+		{"Cø7", "C", "", []int{0, 3, 6, 10}},
+		{"Caug7", "C", "", []int{0, 4, 8, 10}},
+		{"C+7", "C", "", []int{0, 4, 8, 10}},
+		{"C7sus4", "C", "", []int{0, 5, 7, 10}},
+		{"C7sus2", "C", "", []int{0, 2, 7, 10}},
+
+		// Extensions
+		{"C9", "C", "", []int{0, 4, 7, 10, 14}},
+		{"Cmaj9", "C", "", []int{0, 4, 7, 11, 14}},
+		{"Cm9", "C", "", []int{0, 3, 7, 10, 14}},
+		{"C9sus4", "C", "", []int{0, 5, 7, 10, 14}},
+		{"C11", "C", "", []int{0, 7, 10, 14, 17}},
+		{"Cm11", "C", "", []int{0, 3, 7, 10, 14, 17}},
+		{"C13", "C", "", []int{0, 4, 7, 10, 14, 21}},
+		{"Cmaj13", "C", "", []int{0, 4, 7, 11, 14, 21}},
+		{"Cm13", "C", "", []int{0, 3, 7, 10, 14, 17, 21}},
+		{"Cø9", "C", "", []int{0, 3, 6, 10, 14}},
+
+		// Added tones
+		{"Cadd9", "C", "", []int{0, 4, 7, 14}},
+		{"Cadd2", "C", "", []int{0, 2, 4, 7}},
+		{"Cadd4", "C", "", []int{0, 4, 5, 7}},
+		{"Cmadd9", "C", "", []int{0, 3, 7, 14}},
+		{"Cm(add9)", "C", "", []int{0, 3, 7, 14}},
+		{"Cadd11", "C", "", []int{0, 4, 7, 17}},
+		{"C7add13", "C", "", []int{0, 4, 7, 10, 21}},
+
+		// Alterations
+		{"C7b5", "C", "", []int{0, 4, 6, 10}},
+		{"G7#5", "G", "", []int{0, 4, 8, 10}},
+		{"C7b9", "C", "", []int{0, 4, 7, 10, 13}},
+		{"C7#9", "C", "", []int{0, 4, 7, 10, 15}},
+		{"C7b9#9", "C", "", []int{0, 4, 7, 10, 13, 15}},
+		{"C9b5", "C", "", []int{0, 4, 6, 10, 14}},
+		{"C13b9", "C", "", []int{0, 4, 7, 10, 13, 21}},
+		{"C13#11", "C", "", []int{0, 4, 7, 10, 14, 18, 21}},
+		{"Cmaj7#11", "C", "", []int{0, 4, 7, 11, 18}},
+		{"C11#11", "C", "", []int{0, 4, 7, 10, 14, 18}},
+		{"C7b13", "C", "", []int{0, 4, 7, 10, 20}},
+		{"C7(b9,#11)", "C", "", []int{0, 4, 7, 10, 13, 18}},
+		{"C7(b9, b13)", "C", "", []int{0, 4, 7, 10, 13, 20}},
+		{"C7alt", "C", "", []int{0, 4, 10, 13, 15, 20}},
+		{"Calt", "C", "", []int{0, 4, 10, 13, 15, 20}},
+		{"C7no3", "C", "", []int{0, 7, 10}},
+		{"Cmaj7no5", "C", "", []int{0, 4, 11}},
+
+		// Roots with accidentals
+		{"Bb7", "Bb", "", []int{0, 4, 7, 10}},
+		{"F#m7b5", "F#", "", []int{0, 3, 6, 10}},
+		{"Ebmaj9", "Eb", "", []int{0, 4, 7, 11, 14}},
+		{"Cb", "Cb", "", []int{0, 4, 7}},
+		{"Db13b9", "Db", "", []int{0, 4, 7, 10, 13, 21}},
+
+		// Slash chords
+		{"Bb/D", "Bb", "D", []int{0, 4, 7}},
+		{"Emin/G", "E", "G", []int{0, 3, 7}},
+		{"C7/E", "C", "E", []int{0, 4, 7, 10}},
+		{"Am7/G", "A", "G", []int{0, 3, 7, 10}},
+		{"C6/E", "C", "E", []int{0, 4, 7, 9}},
+		{"D/F#", "D", "F#", []int{0, 4, 7}},
+		{"G13b9/Ab", "G", "Ab", []int{0, 4, 7, 10, 13, 21}},
+		{"C5/G", "C", "G", []int{0, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			spec, err := ParseChord(tt.symbol)
+			if err != nil {
+				t.Fatalf("ParseChord(%q) failed: %v", tt.symbol, err)
+			}
+			if spec.Root != tt.root || spec.Bass != tt.bass {
+				t.Errorf("root/bass = %q/%q, want %q/%q", spec.Root, spec.Bass, tt.root, tt.bass)
+			}
+			if !reflect.DeepEqual(spec.Intervals, tt.intervals) {
+				t.Errorf("intervals = %v, want %v", spec.Intervals, tt.intervals)
+			}
+		})
+	}
+}
+
+func TestParseChord_Errors(t *testing.T) {
+	for _, symbol := range []string{
+		"",
+		"H7",
+		"c",
+		"C7b10",  // Unknown alteration
+		"Cmaj7x", // Trailing junk
+		"Cfoo",
+		"C/H",  // Invalid bass
+		"C/",   // Missing bass
+		"C/Dm", // Bass must be a note, not a chord
+		"C7(b9,#11)(b13)(b5)(#5)(#9)(add9)",
+	} {
+		t.Run(symbol, func(t *testing.T) {
+			if _, err := ParseChord(symbol); err == nil {
+				t.Errorf("ParseChord(%q) succeeded, want error", symbol)
+			}
+		})
+	}
+}
+
+func TestChordNotes(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   []string
+	}{
+		{"C7", []string{"C", "E", "G", "Bb"}},
+		{"F#m7b5", []string{"F#", "A", "C", "E"}},
+		{"Cdim7", []string{"C", "Eb", "Gb", "Bbb"}},
+		{"G7#5", []string{"G", "B", "D#", "F"}},
+		{"Ebmaj7#11", []string{"Eb", "G", "Bb", "D", "A"}},
+		{"A7alt", []string{"A", "C#", "G", "Bb", "B#", "F"}},
+		{"Bb/D", []string{"D", "Bb", "D", "F"}},
+		{"C6", []string{"C", "E", "G", "A"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			got, err := SpellChord(tt.symbol)
+			if err != nil {
+				t.Fatalf("SpellChord(%q) failed: %v", tt.symbol, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SpellChord(%q) = %v, want %v", tt.symbol, got, tt.want)
+			}
+		})
+	}
+
+	chord, _ := ParseChord("Bb/D")
+	if got := chord.PitchClasses(); !reflect.DeepEqual(got, []int{2, 5, 10}) {
+		t.Errorf("PitchClasses() = %v, want [2 5 10]", got)
+	}
+}
@@ -0,0 +1,46 @@
+package music
+
+import "fmt"
+
+// Chord tone intervals in semitones from the root
+const (
+	Major2nd   = 2
+	Minor3rd   = 3
+	Major3rd   = 4
+	Perfect4th = 5
+	Flat5th    = 6
+	Perfect5th = 7
+	Sharp5th   = 8
+	Major6th   = 9
+	Dim7th     = 9
+	Minor7th   = 10
+	Major7th   = 11
+	Octave     = 12
+	Flat9th    = 13
+	Ninth      = 14
+	Sharp9th   = 15
+	Eleventh   = 17
+	Sharp11th  = 18
+	Flat13th   = 20
+	Thirteenth = 21
+)
+
+// intervalNames names intervals up to a major 13th, by semitones
+var intervalNames = [...]string{
+	"unison", "minor 2nd", "major 2nd", "minor 3rd", "major 3rd", "perfect 4th", "tritone",
+	"perfect 5th", "minor 6th", "major 6th", "minor 7th", "major 7th", "octave",
+	"minor 9th", "major 9th", "minor 10th", "major 10th", "perfect 11th", "augmented 11th",
+	"perfect 12th", "minor 13th", "major 13th",
+}
+
+// IntervalName names an interval in semitones, up or down: 7 → "perfect 5th", -3 → "minor 3rd".
+// Intervals wider than a major 13th are named in semitones.
+func IntervalName(semitones int) string {
+	if semitones < 0 {
+		semitones = -semitones
+	}
+	if semitones < len(intervalNames) {
+		return intervalNames[semitones]
+	}
+	return fmt.Sprintf("%d semitones", semitones)
+}
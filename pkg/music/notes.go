@@ -0,0 +1,154 @@
+// Package music converts between note names, MIDI notes and frequencies, and names and spells
+// intervals, scales and chord symbols
+package music
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ConcertA is the frequency of A4 (MIDI note 69) in Hz
+const (
+	ConcertA     = 440.0
+	concertANote = 69
+)
+
+const noteLetters = "CDEFGAB"
+
+// letterOffsets are the natural notes' semitones above C, in noteLetters order
+var letterOffsets = [7]int{0, 2, 4, 5, 7, 9, 11}
+
+// Pitch class names with sharps and with flats
+var (
+	sharpNoteNames = [12]string{"C", "C#", "D", "D#", "E", "F", "F#", "G", "G#", "A", "A#", "B"}
+	flatNoteNames  = [12]string{"C", "Db", "D", "Eb", "E", "F", "Gb", "G", "Ab", "A", "Bb", "B"}
+)
+
+// noteOffsets are note names' semitones above C. Enharmonic spellings from jazz charts leave
+// the octave: Cb is the B below, B# the C above.
+var noteOffsets = map[string]int{
+	"C": 0, "C#": 1, "Db": 1, "D": 2, "D#": 3, "Eb": 3, "E": 4, "F": 5,
+	"F#": 6, "Gb": 6, "G": 7, "G#": 8, "Ab": 8, "A": 9, "A#": 10, "Bb": 10, "B": 11,
+	"Cb": -1, "Fb": 4, "E#": 5, "B#": 12,
+}
+
+// ParseRootNote returns the note name at the start of a chord symbol or key: "F#m7" → "F#"
+func ParseRootNote(chordSymbol string) (string, error) {
+	if len(chordSymbol) == 0 {
+		return "", fmt.Errorf("empty chord symbol")
+	}
+
+	// Extract root (first 1-2 chars: C, C#, Db, etc.)
+	root := chordSymbol[:1]
+	if len(chordSymbol) > 1 && (chordSymbol[1] == '#' || chordSymbol[1] == 'b') {
+		root = chordSymbol[:2]
+	}
+	if _, ok := noteOffsets[root]; !ok {
+		return "", fmt.Errorf("invalid root note: %s", root)
+	}
+	return root, nil
+}
+
+// NoteOffset returns a note name's semitones above C, from -1 (Cb) to 12 (B#)
+func NoteOffset(name string) (int, bool) {
+	offset, ok := noteOffsets[name]
+	return offset, ok
+}
+
+// PitchClass returns a MIDI note's pitch class, 0 (C) to 11 (B)
+func PitchClass(note int) int {
+	return ((note % 12) + 12) % 12
+}
+
+// PitchClassName names a pitch class with sharps, or with flats: 10 → "A#" or "Bb"
+func PitchClassName(pc int, flats bool) string {
+	if flats {
+		return flatNoteNames[PitchClass(pc)]
+	}
+	return sharpNoteNames[PitchClass(pc)]
+}
+
+// NoteNameToMIDI converts a note name like "E1", "C4", "F#3", "Bb2" to MIDI note number
+// Format: <note><accidental?><octave> where:
+//   - note: A-G (case insensitive)
+//   - accidental: # (sharp) or b (flat), optional
+//   - octave: -1 to 9 (C4 = 60 = middle C)
+func NoteNameToMIDI(noteName string) (int, error) {
+	if len(noteName) < 2 {
+		return 0, fmt.Errorf("note name too short: %s", noteName)
+	}
+
+	// Parse note letter (A-G)
+	letter := strings.IndexByte(noteLetters, strings.ToUpper(noteName[:1])[0])
+	if letter == -1 {
+		return 0, fmt.Errorf("invalid note letter: %s", strings.ToUpper(noteName[:1]))
+	}
+	semitone := letterOffsets[letter]
+
+	// Check for accidental (# or b)
+	idx := 1
+	if noteName[idx] == '#' {
+		semitone++
+		idx++
+	} else if noteName[idx] == 'b' {
+		semitone--
+		idx++
+	}
+
+	// Parse octave (can be negative like -1)
+	if idx >= len(noteName) {
+		return 0, fmt.Errorf("missing octave in note name: %s", noteName)
+	}
+	octave, err := strconv.Atoi(noteName[idx:])
+	if err != nil {
+		return 0, fmt.Errorf("invalid octave in note name %s: %w", noteName, err)
+	}
+
+	// MIDI calculation: (octave + 1) * 12 + semitone
+	// This gives C-1 = 0, C0 = 12, C4 = 60
+	midiNote := (octave+1)*12 + semitone
+
+	// Clamp to valid MIDI range
+	return min(max(midiNote, 0), 127), nil
+}
+
+// MIDIToNoteName names a MIDI note (0-127) with sharps, or with flats, in NoteNameToMIDI's
+// octaves: 60 → "C4", 70 → "A#4" or "Bb4"
+func MIDIToNoteName(note int, flats bool) string {
+	return PitchClassName(note, flats) + strconv.Itoa(note/12-1)
+}
+
+// Frequency returns a MIDI note's frequency in Hz in equal temperament: 69 (A4) → 440
+func Frequency(note int) float64 {
+	return ConcertA * math.Pow(2, float64(note-concertANote)/12)
+}
+
+// FrequencyToMIDI returns the MIDI note of a frequency in Hz, with the fraction in semitones
+// above it: 440 → 69, 450 → 69.39. Round it for the nearest note.
+func FrequencyToMIDI(hz float64) (float64, error) {
+	if hz <= 0 {
+		return 0, fmt.Errorf("frequency must be positive, got %g", hz)
+	}
+	return concertANote + 12*math.Log2(hz/ConcertA), nil
+}
+
+// spellNote names pitch class pc on a letter (index into noteLetters) with up to two
+// accidentals, or as a sharp name when that letter is further away
+func spellNote(letter, pc int) string {
+	name := noteLetters[letter%7 : letter%7+1]
+	switch PitchClass(pc - letterOffsets[letter%7]) {
+	case 0:
+		return name
+	case 1:
+		return name + "#"
+	case 2:
+		return name + "##"
+	case 11:
+		return name + "b"
+	case 10:
+		return name + "bb"
+	}
+	return PitchClassName(pc, false)
+}
@@ -0,0 +1,121 @@
+package music
+
+import (
+	"math"
+	"testing"
+)
+
+// TestNoteNameToMIDI tests the note name to MIDI conversion
+func TestNoteNameToMIDI(t *testing.T) {
+	tests := []struct {
+		name         string
+		noteName     string
+		expectedMIDI int
+		expectError  bool
+	}{
+		// Standard notes (C4 = middle C = MIDI 60)
+		// Formula: (octave + 1) * 12 + semitone
+		{"C4 (middle C)", "C4", 60, false},
+		{"C0", "C0", 12, false},
+		{"C-1", "C-1", 0, false},
+		{"C5", "C5", 72, false},
+		// E1 (the user's request): (1+1)*12 + 4 = 28
+		{"E1", "E1", 28, false},
+		// Other common notes
+		{"A4 (440Hz)", "A4", 69, false}, // (4+1)*12 + 9 = 69
+		{"G3", "G3", 55, false},         // (3+1)*12 + 7 = 55
+		{"D2", "D2", 38, false},         // (2+1)*12 + 2 = 38
+		// Sharp notes
+		{"C#4", "C#4", 61, false}, // 60 + 1 = 61
+		{"F#3", "F#3", 54, false}, // (3+1)*12 + 6 = 54
+		{"G#2", "G#2", 44, false}, // (2+1)*12 + 8 = 44
+		// Flat notes (Bb = A# = 10 semitones)
+		{"Bb2", "Bb2", 46, false}, // (2+1)*12 + 10 = 46
+		{"Eb4", "Eb4", 63, false}, // (4+1)*12 + 3 = 63
+		{"Ab3", "Ab3", 56, false}, // (3+1)*12 + 8 = 56
+		// Edge cases
+		{"B0", "B0", 23, false}, // (0+1)*12 + 11 = 23
+		{"A0", "A0", 21, false}, // (0+1)*12 + 9 = 21
+		// Lowercase should work too (case insensitive)
+		{"lowercase e1", "e1", 28, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			midiNote, err := NoteNameToMIDI(tt.noteName)
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error but got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NoteNameToMIDI failed: %v", err)
+			}
+
+			if midiNote != tt.expectedMIDI {
+				t.Errorf("NoteNameToMIDI(%s) = %d, want %d", tt.noteName, midiNote, tt.expectedMIDI)
+			}
+		})
+	}
+}
+
+func TestMIDIToNoteName(t *testing.T) {
+	tests := []struct {
+		note  int
+		flats bool
+		want  string
+	}{
+		{60, false, "C4"},
+		{28, false, "E1"},
+		{70, false, "A#4"},
+		{70, true, "Bb4"},
+		{0, false, "C-1"},
+		{127, false, "G9"},
+	}
+
+	for _, tt := range tests {
+		got := MIDIToNoteName(tt.note, tt.flats)
+		if got != tt.want {
+			t.Errorf("MIDIToNoteName(%d, %v) = %s, want %s", tt.note, tt.flats, got, tt.want)
+		}
+		if note, err := NoteNameToMIDI(got); err != nil || note != tt.note {
+			t.Errorf("NoteNameToMIDI(%s) = %d, %v, want %d", got, note, err, tt.note)
+		}
+	}
+}
+
+func TestFrequency(t *testing.T) {
+	for _, tt := range []struct {
+		note int
+		hz   float64
+	}{
+		{69, 440},
+		{57, 220},
+		{60, 261.6256},
+		{28, 41.2034},
+	} {
+		if got := Frequency(tt.note); math.Abs(got-tt.hz) > 1e-4 {
+			t.Errorf("Frequency(%d) = %g, want %g", tt.note, got, tt.hz)
+		}
+		if got, err := FrequencyToMIDI(tt.hz); err != nil || math.Abs(got-float64(tt.note)) > 1e-4 {
+			t.Errorf("FrequencyToMIDI(%g) = %g, %v, want %d", tt.hz, got, err, tt.note)
+		}
+	}
+
+	if _, err := FrequencyToMIDI(0); err == nil {
+		t.Error("Expected an error for a zero frequency")
+	}
+}
+
+func TestIntervalName(t *testing.T) {
+	for semitones, want := range map[int]string{
+		0: "unison", Minor3rd: "minor 3rd", -Perfect5th: "perfect 5th", Flat5th: "tritone",
+		Octave: "octave", Sharp11th: "augmented 11th", Thirteenth: "major 13th", 24: "24 semitones",
+	} {
+		if got := IntervalName(semitones); got != want {
+			t.Errorf("IntervalName(%d) = %s, want %s", semitones, got, want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package music
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// scales maps scale names to their intervals above the tonic
+var scales = map[string][]int{
+	"major":            {0, 2, 4, 5, 7, 9, 11},
+	"natural_minor":    {0, 2, 3, 5, 7, 8, 10},
+	"harmonic_minor":   {0, 2, 3, 5, 7, 8, 11},
+	"melodic_minor":    {0, 2, 3, 5, 7, 9, 11},
+	"dorian":           {0, 2, 3, 5, 7, 9, 10},
+	"phrygian":         {0, 1, 3, 5, 7, 8, 10},
+	"lydian":           {0, 2, 4, 6, 7, 9, 11},
+	"mixolydian":       {0, 2, 4, 5, 7, 9, 10},
+	"locrian":          {0, 1, 3, 5, 6, 8, 10},
+	"major_pentatonic": {0, 2, 4, 7, 9},
+	"minor_pentatonic": {0, 3, 5, 7, 10},
+	"blues":            {0, 3, 5, 6, 7, 10},
+	"chromatic":        {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+}
+
+// scaleAliases maps other names for a scale to its entry in scales
+var scaleAliases = map[string]string{
+	"ionian": "major", "minor": "natural_minor", "aeolian": "natural_minor",
+	"pentatonic": "major_pentatonic",
+}
+
+// ScaleNames returns the scales ScaleIntervals knows, sorted
+func ScaleNames() []string {
+	names := make([]string, 0, len(scales))
+	for name := range scales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScaleIntervals returns a scale's intervals above the tonic: "dorian" → 0 2 3 5 7 9 10.
+// Names are case insensitive, with spaces or underscores ("Harmonic Minor").
+func ScaleIntervals(name string) ([]int, error) {
+	key := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+	if alias, ok := scaleAliases[key]; ok {
+		key = alias
+	}
+	intervals, ok := scales[key]
+	if !ok {
+		return nil, fmt.Errorf("unknown scale %q (available: %s)", name, strings.Join(ScaleNames(), ", "))
+	}
+	return append([]int(nil), intervals...), nil
+}
+
+// SpellScale names the notes of a scale from tonic. Seven-note scales use each letter once
+// (F major has Bb, not A#); the others use flats when the tonic is flat or F, and sharps otherwise.
+func SpellScale(tonic, name string) ([]string, error) {
+	root, err := ParseRootNote(tonic)
+	if err != nil || root != tonic {
+		return nil, fmt.Errorf("invalid tonic %q", tonic)
+	}
+	intervals, err := ScaleIntervals(name)
+	if err != nil {
+		return nil, err
+	}
+
+	letter, flats := strings.IndexByte(noteLetters, tonic[0]), strings.HasSuffix(tonic, "b") || tonic == "F"
+	notes := make([]string, len(intervals))
+	for i, interval := range intervals {
+		if len(intervals) == 7 {
+			notes[i] = spellNote(letter+i, noteOffsets[tonic]+interval)
+		} else {
+			notes[i] = PitchClassName(noteOffsets[tonic]+interval, flats)
+		}
+	}
+	return notes, nil
+}
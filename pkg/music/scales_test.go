@@ -0,0 +1,59 @@
+package music
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSpellScale(t *testing.T) {
+	tests := []struct {
+		tonic string
+		scale string
+		want  []string
+	}{
+		{"C", "major", []string{"C", "D", "E", "F", "G", "A", "B"}},
+		{"F", "major", []string{"F", "G", "A", "Bb", "C", "D", "E"}},
+		{"F#", "Major", []string{"F#", "G#", "A#", "B", "C#", "D#", "E#"}},
+		{"A", "harmonic minor", []string{"A", "B", "C", "D", "E", "F", "G#"}},
+		{"D", "dorian", []string{"D", "E", "F", "G", "A", "B", "C"}},
+		{"Eb", "minor", []string{"Eb", "F", "Gb", "Ab", "Bb", "Cb", "Db"}},
+		{"A", "minor_pentatonic", []string{"A", "C", "D", "E", "G"}},
+		{"Bb", "blues", []string{"Bb", "Db", "Eb", "E", "F", "Ab"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tonic+" "+tt.scale, func(t *testing.T) {
+			got, err := SpellScale(tt.tonic, tt.scale)
+			if err != nil {
+				t.Fatalf("SpellScale failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SpellScale(%s, %s) = %v, want %v", tt.tonic, tt.scale, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := SpellScale("C", "bebop"); err == nil || !strings.Contains(err.Error(), "unknown scale") {
+		t.Errorf("SpellScale(C, bebop) error = %v, want an unknown scale", err)
+	}
+	if _, err := SpellScale("Cm", "major"); err == nil {
+		t.Error("Expected an error for a tonic that isn't a note")
+	}
+}
+
+func TestScaleIntervals(t *testing.T) {
+	intervals, err := ScaleIntervals("aeolian")
+	if err != nil {
+		t.Fatalf("ScaleIntervals failed: %v", err)
+	}
+	if want := []int{0, 2, 3, 5, 7, 8, 10}; !reflect.DeepEqual(intervals, want) {
+		t.Errorf("ScaleIntervals(aeolian) = %v, want %v", intervals, want)
+	}
+
+	// The result is a copy
+	intervals[1] = 1
+	if again, _ := ScaleIntervals("natural_minor"); again[1] != 2 {
+		t.Error("ScaleIntervals returned the scale's own slice")
+	}
+}